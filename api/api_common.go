@@ -44,12 +44,24 @@ type Common interface {
 	// Version provides information about API provider
 	Version(context.Context) (APIVersion, error) //perm:read
 
+	// Capabilities reports the API versions served by the node, and the
+	// deprecated methods of the API served on the endpoint. Clients call it on
+	// connect to negotiate the API version, see client.NegotiateCommon
+	Capabilities(context.Context) (APICapabilities, error) //perm:read
+
 	// Discover returns an OpenRPC document describing an RPC API.
 	Discover(ctx context.Context) (apitypes.OpenRPCDocument, error) //perm:read
 
 	// trigger graceful shutdown
 	Shutdown(context.Context) error //perm:admin
 
+	// ShutdownStatus reports the progress of the shutdown of the node
+	// components, and the tasks in flight which would hold it, e.g. running
+	// sealing jobs, open retrievals and message waits. As the API server is
+	// stopped first, it is mostly useful before triggering the shutdown, the
+	// progress of the shutdown itself is logged.
+	ShutdownStatus(context.Context) (ShutdownStatus, error) //perm:read
+
 	// StartTime returns node start time
 	StartTime(context.Context) (time.Time, error) //perm:read
 
@@ -57,6 +69,14 @@ type Common interface {
 	Session(context.Context) (uuid.UUID, error) //perm:read
 
 	Closing(context.Context) (<-chan struct{}, error) //perm:read
+
+	// MethodGroup: Disk
+
+	// DiskForecast returns the growth rates of the directories tracked by the
+	// node, and when their filesystems are forecast to be full: the chain
+	// store on full nodes, the deal staging area, DAGStore transients and
+	// sealing scratch paths on miners.
+	DiskForecast(context.Context) ([]DiskForecast, error) //perm:read
 }
 
 // APIVersion provides various build-time information
@@ -10,6 +10,9 @@ import (
 const (
 	EOutOfGas = iota + jsonrpc.FirstUserCode
 	EActorNotFound
+	EReadOnly
+	EMessageInMpool
+	ESectorExists
 )
 
 type ErrOutOfGas struct{}
@@ -24,6 +27,30 @@ func (e *ErrActorNotFound) Error() string {
 	return "actor not found"
 }
 
+// ErrReadOnly is returned by the nodes serving their API in read-only mode
+// when calling a method which needs more than the read permission.
+type ErrReadOnly struct{}
+
+func (e *ErrReadOnly) Error() string {
+	return "the node API is read-only"
+}
+
+// ErrMessageInMpool is returned when pushing a message which is already in the
+// message pool.
+type ErrMessageInMpool struct{}
+
+func (e *ErrMessageInMpool) Error() string {
+	return "message already in mpool"
+}
+
+// ErrSectorExists is returned when importing a sector with the number of a
+// sector which is already in the sealing pipeline.
+type ErrSectorExists struct{}
+
+func (e *ErrSectorExists) Error() string {
+	return "sector already exists in the sealing pipeline"
+}
+
 var RPCErrors = jsonrpc.NewErrors()
 
 func ErrorIsIn(err error, errorTypes []error) bool {
@@ -39,4 +66,7 @@ func ErrorIsIn(err error, errorTypes []error) bool {
 func init() {
 	RPCErrors.Register(EOutOfGas, new(*ErrOutOfGas))
 	RPCErrors.Register(EActorNotFound, new(*ErrActorNotFound))
+	RPCErrors.Register(EReadOnly, new(*ErrReadOnly))
+	RPCErrors.Register(EMessageInMpool, new(*ErrMessageInMpool))
+	RPCErrors.Register(ESectorExists, new(*ErrSectorExists))
 }
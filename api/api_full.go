@@ -17,6 +17,7 @@ import (
 	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
 	"github.com/filecoin-project/go-fil-markets/storagemarket"
 	"github.com/filecoin-project/go-jsonrpc"
+	"github.com/filecoin-project/go-jsonrpc/auth"
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-state-types/big"
 	"github.com/filecoin-project/go-state-types/builtin/v8/paych"
@@ -25,9 +26,11 @@ import (
 	verifregtypes "github.com/filecoin-project/go-state-types/builtin/v9/verifreg"
 	"github.com/filecoin-project/go-state-types/crypto"
 	"github.com/filecoin-project/go-state-types/dline"
+	"github.com/filecoin-project/go-state-types/exitcode"
 	abinetwork "github.com/filecoin-project/go-state-types/network"
 
 	apitypes "github.com/filecoin-project/lotus/api/types"
+	"github.com/filecoin-project/lotus/blockstore"
 	"github.com/filecoin-project/lotus/chain/actors/builtin"
 	lminer "github.com/filecoin-project/lotus/chain/actors/builtin/miner"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/power"
@@ -102,6 +105,53 @@ type FullNode interface {
 	// messages returned by a call to ChainGetParentMessages with the same blockCid.
 	ChainGetParentReceipts(ctx context.Context, blockCid cid.Cid) ([]*types.MessageReceipt, error) //perm:read
 
+	// ChainGetParentReceiptsBatch returns, for each of the specified tipsets, the
+	// messages of its parent tipset along with the receipts of their execution.
+	// It saves indexers a ChainGetParentMessages and ChainGetParentReceipts round
+	// trip per block. At most 100 tipsets can be requested at once.
+	ChainGetParentReceiptsBatch(ctx context.Context, tsks []types.TipSetKey) ([]ParentReceipts, error) //perm:read
+
+	// ChainGetReceiptProof returns the receipt of an executed message, along with the
+	// blocks of the receipts AMT proving it against the ParentMessageReceipts root of the
+	// tipset containing the receipt. Replaced messages are resolved to the message that
+	// was executed.
+	ChainGetReceiptProof(ctx context.Context, msg cid.Cid) (*ReceiptProof, error) //perm:read
+
+	// ChainGetAttestation returns an attestation of the inclusion and execution of a message,
+	// signed by the attestation key configured on the node, along with a proof of the message
+	// receipt. Attestations of messages which aren't final yet may be invalidated by a reorg.
+	// Requires the attestation service to be enabled in the node config.
+	ChainGetAttestation(ctx context.Context, msg cid.Cid) (*AttestationBundle, error) //perm:read
+
+	// ChainJournalSince returns up to limit entries of the chain journal, a persistent
+	// record of head changes, message executions and actor events, with explicit revert
+	// records on reorgs, starting after the cursor version. Pass 0 to read from the
+	// beginning. Requires Index.EnableChainJournal to be set in the node config.
+	ChainJournalSince(ctx context.Context, cursor uint64, limit int) (*ChainJournalPage, error) //perm:read
+
+	// ChainConsensusFaults returns the consensus faults detected in the incoming
+	// blocks at or after the since epoch. Requires FaultReporter.EnableConsensusFaultReporter
+	// to be set in the node config.
+	ChainConsensusFaults(ctx context.Context, since abi.ChainEpoch) ([]ConsensusFault, error) //perm:read
+
+	// ChainBlockTiming returns, for each peer which relayed blocks to the node over
+	// pubsub, the history of the arrival times of the blocks relative to the start
+	// of their epoch, and whether the block relay policy deprioritized the peer.
+	ChainBlockTiming(context.Context) ([]PeerBlockTiming, error) //perm:read
+
+	// ChainReplicaStatus returns the replication role of the node: the primary it follows and
+	// how far it is, when running as a hot standby, or the standbys following it. Requires
+	// Replication.EnableServer or Replication.Primary to be set in the node config.
+	ChainReplicaStatus(context.Context) (*ReplicaStatus, error) //perm:read
+
+	// ChainReplicaPromote promotes a hot standby to a primary: the node stops following its
+	// primary and starts syncing the chain and the message pool from the network on its own.
+	ChainReplicaPromote(context.Context) error //perm:admin
+
+	// ChainSnapshots returns the snapshots exported by the snapshot service, the most recent
+	// first. Requires Snapshots.EnableSnapshots to be set in the node config.
+	ChainSnapshots(context.Context) ([]ChainSnapshot, error) //perm:read
+
 	// ChainGetParentMessages returns messages stored in parent tipset of the
 	// specified block.
 	ChainGetParentMessages(ctx context.Context, blockCid cid.Cid) ([]Message, error) //perm:read
@@ -166,6 +216,13 @@ type FullNode interface {
 	// Would return `[revert(tBA), apply(tAB), apply(tAA)]`
 	ChainGetPath(ctx context.Context, from types.TipSetKey, to types.TipSetKey) ([]*HeadChange, error) //perm:read
 
+	// ChainGetPathPage returns the operations returned by ChainGetPath a page at a time,
+	// loading only the tipsets of the page, so paths between distant tipsets can be
+	// processed without holding them in memory. The first page is requested with an empty
+	// cursor, the next ones with the Cursor of the previous page, until it's empty. The
+	// limit is the maximum number of operations in the page.
+	ChainGetPathPage(ctx context.Context, from types.TipSetKey, to types.TipSetKey, cursor string, limit int) (*HeadChangePage, error) //perm:read
+
 	// ChainExport returns a stream of bytes with CAR dump of chain data.
 	// The exported chain data includes the header chain from the given tipset
 	// back to genesis, the entire genesis state, and the most recent 'nroots'
@@ -173,6 +230,15 @@ type FullNode interface {
 	// If oldmsgskip is set, messages from before the requested roots are also not included.
 	ChainExport(ctx context.Context, nroots abi.ChainEpoch, oldmsgskip bool, tsk types.TipSetKey) (<-chan []byte, error) //perm:read
 
+	// ChainExportActorStates returns a stream of bytes with a CAR dump of chain
+	// data, like ChainExport, except that the most recent 'nroots' state trees
+	// only include the state of the given actors. The other actors only have
+	// their entry in the state tree, with their code, nonce, balance and the CID
+	// of their state. The genesis state is included entirely.
+	// These snapshots are meant for inspecting the state of a few actors, they
+	// can't be imported into a node.
+	ChainExportActorStates(ctx context.Context, nroots abi.ChainEpoch, oldmsgskip bool, actors []address.Address, tsk types.TipSetKey) (<-chan []byte, error) //perm:read
+
 	// ChainExportRangeInternal triggers the export of a chain
 	// CAR-snapshot directly to disk. It is similar to ChainExport,
 	// except, depending on options, the snapshot can include receipts,
@@ -191,6 +257,27 @@ type FullNode interface {
 	// the splitstore
 	ChainHotGC(ctx context.Context, opts HotGCOpts) error //perm:admin
 
+	// ChainBlockstoreMaintain starts an online maintenance run of the chain blockstore, or of the
+	// hotstore when using the splitstore. The run continues in the background, its progress is
+	// reported by ChainBlockstoreMaintenanceStatus. Only supported with badger blockstores.
+	ChainBlockstoreMaintain(ctx context.Context, opts BlockstoreMaintainOpts) (*BlockstoreMaintenanceRun, error) //perm:admin
+
+	// ChainBlockstoreMaintenanceStatus returns the state of the last blockstore maintenance run,
+	// and when the next scheduled run may start.
+	ChainBlockstoreMaintenanceStatus(context.Context) (*BlockstoreMaintenanceStatus, error) //perm:read
+
+	// ChainBlockstoreScrubStatus returns the progress of the blockstore scrubber and the corrupt
+	// blocks it quarantined.
+	ChainBlockstoreScrubStatus(context.Context) (*BlockstoreScrubStatus, error) //perm:read
+
+	// ChainCacheStats returns the sizes, hits and misses of the in-memory caches of the chain
+	// and state.
+	ChainCacheStats(context.Context) ([]blockstore.CacheStats, error) //perm:read
+
+	// ChainCacheResize sets the size of a chain or state cache, as listed by ChainCacheStats,
+	// until the node restarts. The adaptive mode grows the cache from this size.
+	ChainCacheResize(ctx context.Context, name string, size int) error //perm:admin
+
 	// ChainCheckBlockstore performs an (asynchronous) health check on the chain/state blockstore
 	// if supported by the underlying implementation.
 	ChainCheckBlockstore(context.Context) error //perm:admin
@@ -261,6 +348,10 @@ type FullNode interface {
 
 	// MpoolSelect returns a list of pending messages for inclusion in the next block
 	MpoolSelect(context.Context, types.TipSetKey, float64) ([]*types.SignedMessage, error) //perm:read
+	// MpoolSelectPreview runs the message selection of MpoolSelect and reports
+	// its decisions: the selected messages in block order with the reward they
+	// would pay, and why each of the other pending messages was left out.
+	MpoolSelectPreview(ctx context.Context, tsk types.TipSetKey, ticketQuality float64) (*MpoolSelectPreview, error) //perm:read
 
 	// MpoolPush pushes a signed message to mempool.
 	MpoolPush(context.Context, *types.SignedMessage) (cid.Cid, error) //perm:write
@@ -276,6 +367,16 @@ type FullNode interface {
 	// based on current chain conditions
 	MpoolPushMessage(ctx context.Context, msg *types.Message, spec *MessageSendSpec) (*types.SignedMessage, error) //perm:sign
 
+	// MpoolPredictCid returns the CID the message will have in the mempool once
+	// signed with the given key. The message is used as is, so its nonce and gas
+	// fields must already be set.
+	MpoolPredictCid(ctx context.Context, msg *types.Message, key address.Address) (cid.Cid, error) //perm:sign
+
+	// MpoolReplace replaces a pending message, selected by its CID or by its
+	// sender and nonce, with a copy paying the fees needed for the mempool to
+	// accept it, then signs and pushes the copy.
+	MpoolReplace(ctx context.Context, spec MpoolReplaceSpec) (*MpoolReplaceResult, error) //perm:sign
+
 	// MpoolBatchPush batch pushes a signed message to mempool.
 	MpoolBatchPush(context.Context, []*types.SignedMessage) ([]cid.Cid, error) //perm:write
 
@@ -297,6 +398,14 @@ type FullNode interface {
 	MpoolGetNonce(context.Context, address.Address) (uint64, error) //perm:read
 	MpoolSub(context.Context) (<-chan MpoolUpdate, error)           //perm:read
 
+	// MpoolGasMarket summarizes the effective gas premiums of the pending
+	// messages at the base fee of the next block, and those paid by the
+	// messages of the lookback most recent tipsets (10 when 0).
+	MpoolGasMarket(ctx context.Context, lookback int) (*MpoolGasMarket, error) //perm:read
+	// MpoolGasMarketSub sends the MpoolGasMarket summary each time the
+	// message pool moves to a new head.
+	MpoolGasMarketSub(ctx context.Context, lookback int) (<-chan MpoolGasMarket, error) //perm:read
+
 	// MpoolClear clears pending messages from the mpool.
 	// If clearLocal is true, ALL messages will be cleared.
 	// If clearLocal is false, local messages will be protected, all others will be cleared.
@@ -356,6 +465,21 @@ type FullNode interface {
 	ClientImport(ctx context.Context, ref FileRef) (*ImportRes, error) //perm:admin
 	// ClientRemoveImport removes file import
 	ClientRemoveImport(ctx context.Context, importID imports.ID) error //perm:admin
+	// ClientPrepareData builds UnixFS DAGs out of the file under the specified
+	// path, split into CAR files which each fit into a piece of
+	// targetPieceSize, and imports them. Each CAR can then be used in a
+	// storage deal with its root. The manifest of the prepared data, with the
+	// piece CID of each CAR, is recorded in the client datastore.
+	ClientPrepareData(ctx context.Context, path string, targetPieceSize abi.PaddedPieceSize) (*DataPrepManifest, error) //perm:admin
+	// ClientListDataPreps returns the manifests recorded by ClientPrepareData,
+	// newest first.
+	ClientListDataPreps(ctx context.Context) ([]DataPrepManifest, error) //perm:read
+	// ClientAggregatePieces combines the imported CARs with the specified
+	// roots into a single piece of dealSize (the smallest fitting size when 0),
+	// with a data segment index (FRC-0058) at its end, and writes the
+	// unpadded data of the aggregate to outpath. It returns the piece CID of
+	// the aggregate, and the inclusion proof of each of the pieces.
+	ClientAggregatePieces(ctx context.Context, roots []cid.Cid, dealSize abi.PaddedPieceSize, outpath string) (*PieceAggregate, error) //perm:write
 	// ClientStartDeal proposes a deal with a miner.
 	ClientStartDeal(ctx context.Context, params *StartDealParams) (*cid.Cid, error) //perm:admin
 	// ClientStatelessDeal fire-and-forget-proposes an offline deal to a miner without subsequent tracking.
@@ -378,6 +502,9 @@ type FullNode interface {
 	ClientRetrieve(ctx context.Context, params RetrievalOrder) (*RestrievalRes, error) //perm:admin
 	// ClientRetrieveWait waits for retrieval to be complete
 	ClientRetrieveWait(ctx context.Context, deal retrievalmarket.DealID) error //perm:admin
+	// ClientRetrievalResume resumes a failed retrieval from the last block verified
+	// by it, retrieving only the missing blocks in a new retrieval deal.
+	ClientRetrievalResume(ctx context.Context, dealID retrievalmarket.DealID) (*RestrievalRes, error) //perm:admin
 	// ClientExport exports a file stored in the local filestore to a system file
 	ClientExport(ctx context.Context, exportRef ExportRef, fileRef FileRef) error //perm:admin
 	// ClientListRetrievals returns information about retrievals made by the local client
@@ -416,6 +543,56 @@ type FullNode interface {
 
 	// ClientListAsks() []Ask
 
+	// ClientReplicate makes deals for the data in params with n storage
+	// providers matching the selector. Candidate providers are asked for
+	// their storage ask, and the cheapest ones are chosen.
+	// params.Miner is ignored. Each deal is proposed at the ask price of the
+	// provider; a non-zero params.EpochPrice is the maximum deal price per
+	// epoch accepted. Providers with the same price are ordered by score.
+	// When a deal can't be proposed, the next matching provider is tried.
+	ClientReplicate(ctx context.Context, params *StartDealParams, n int, sel ProviderSelector) (*ReplicationStatus, error) //perm:admin
+	// ClientGetReplication returns the aggregate status of the deals made by
+	// ClientReplicate.
+	ClientGetReplication(ctx context.Context, id uuid.UUID) (*ReplicationStatus, error) //perm:read
+	// ClientListReplications returns the status of all replications.
+	ClientListReplications(ctx context.Context) ([]ReplicationStatus, error) //perm:read
+	// ClientSetProviderTags sets the tags of a storage provider in the local
+	// reputation store, e.g. its region, for use in ProviderSelector.Tags.
+	ClientSetProviderTags(ctx context.Context, miner address.Address, tags []string) error //perm:write
+	// ClientListProviderReputation returns the local reputation records of
+	// storage providers: their tags and the outcome of the deals and
+	// retrievals made with them. The records can be imported on another node
+	// with ClientImportProviderReputation.
+	ClientListProviderReputation(ctx context.Context) ([]ProviderReputation, error) //perm:read
+	// ClientImportProviderReputation imports reputation records, e.g. exported
+	// from another node. Records are added to the existing ones, or replace
+	// them if replace is set.
+	ClientImportProviderReputation(ctx context.Context, recs []ProviderReputation, replace bool) error //perm:admin
+	// ClientProviderScores scores storage providers from their local
+	// reputation records, best first. With no miners, all known providers are
+	// scored.
+	ClientProviderScores(ctx context.Context, miners []address.Address) ([]ProviderScore, error) //perm:read
+	// ClientDealTemplateSet stores a deal template, replacing the template of
+	// the same name. Deals started with StartDealParams.Template set take
+	// their defaults from the template, and are checked against its limits.
+	ClientDealTemplateSet(ctx context.Context, tmpl DealTemplate) error //perm:write
+	// ClientDealTemplateList returns the stored deal templates.
+	ClientDealTemplateList(ctx context.Context) ([]DealTemplate, error) //perm:read
+	// ClientDealTemplateRemove removes a deal template.
+	ClientDealTemplateRemove(ctx context.Context, name string) error //perm:write
+	// ClientCreateAllocations sends a message transferring datacap from the
+	// client to the verified registry, which creates the requested allocations.
+	// Each allocation is claimed by its provider when it proves a sector with
+	// the piece, before the allocation expiration.
+	ClientCreateAllocations(ctx context.Context, client address.Address, reqs []verifregtypes.AllocationRequest) (cid.Cid, error) //perm:sign
+	// ClientListAllocations returns the allocations of a client: the ones not
+	// claimed yet, and the claims made with them. Claims are only found for the
+	// providers the node knows the client allocated datacap to.
+	ClientListAllocations(ctx context.Context, client address.Address) ([]AllocationInfo, error) //perm:read
+	// ClientExtendClaims sends a message from the client extending the terms
+	// of claims made with its allocations.
+	ClientExtendClaims(ctx context.Context, client address.Address, terms []verifregtypes.ClaimTerm) (cid.Cid, error) //perm:sign
+
 	// MethodGroup: State
 	// The State methods are used to query, inspect, and interact with chain state.
 	// Most methods take a TipSetKey as a parameter. The state looked up is the parent state of the tipset.
@@ -427,6 +604,12 @@ type FullNode interface {
 	// message is not applied on-top-of the messages in the passed-in
 	// tipset.
 	StateCall(context.Context, *types.Message, types.TipSetKey) (*InvocResult, error) //perm:read
+	// StateCallWithOverrides runs the given message like StateCall, on a copy
+	// of the state in which the given actors have been overridden. This allows
+	// asking how a call would behave with different balances, nonces, code or
+	// actor state; none of the changes are persisted. Actors which don't exist
+	// are created when their code is overridden.
+	StateCallWithOverrides(ctx context.Context, msg *types.Message, overrides []ActorOverride, tsk types.TipSetKey) (*InvocResult, error) //perm:read
 	// StateReplay replays a given message, assuming it was included in a block in the specified tipset.
 	//
 	// If a tipset key is provided, and a replacing message is not found on chain,
@@ -445,16 +628,55 @@ type FullNode interface {
 	// different signature, but with all other parameters matching (source/destination,
 	// nonce, params, etc.)
 	StateReplay(context.Context, types.TipSetKey, cid.Cid) (*InvocResult, error) //perm:read
+	// StateReplayEvents re-executes the tipsets with heights in the [from, to] range to re-derive
+	// the actor events emitted by their messages, whether or not the node stores events. An
+	// update is sent on the channel for every replayed tipset, in ascending height order, and the
+	// channel is closed when done. At most 2880 epochs can be replayed per call.
+	StateReplayEvents(ctx context.Context, from, to abi.ChainEpoch) (<-chan EventReplayUpdate, error) //perm:read
 	// StateGetActor returns the indicated actor's nonce and balance.
 	StateGetActor(ctx context.Context, actor address.Address, tsk types.TipSetKey) (*types.Actor, error) //perm:read
+	// StateGetProof returns the indicated actor, along with the blocks of the state tree
+	// proving it against the parent state root of the tipset. The proof can be verified
+	// without trusting the node by checking the block CIDs and walking the state tree from
+	// the state root in the tipset's block headers.
+	StateGetProof(ctx context.Context, actor address.Address, tsk types.TipSetKey) (*ActorStateProof, error) //perm:read
 	// StateReadState returns the indicated actor's state.
 	StateReadState(ctx context.Context, actor address.Address, tsk types.TipSetKey) (*ActorState, error) //perm:read
 	// StateListMessages looks back and returns all messages with a matching to or from address, stopping at the given height.
 	StateListMessages(ctx context.Context, match *MessageMatch, tsk types.TipSetKey, toht abi.ChainEpoch) ([]cid.Cid, error) //perm:read
+	// StateListMessagesFast returns a page of the messages sent or received by an address,
+	// newest first unless ascending order is requested, using the address message index
+	// instead of walking the chain. The address is matched in both its ID and robust forms.
+	// Only the epochs indexed by the node are listed. Requires Index.EnableAddressIndex to
+	// be set in the node config.
+	StateListMessagesFast(ctx context.Context, query AddressMessageQuery) (*AddressMessagePage, error) //perm:read
+	// StateResolveAddresses resolves a batch of addresses of any protocol to the ID, delegated
+	// (f4) and Ethereum addresses of their actors, at the chain head. The delegated addresses
+	// are looked up in the address index when Index.EnableAddressIndex is set in the node config,
+	// without loading the state. The addresses which can't be resolved have their Error set.
+	StateResolveAddresses(ctx context.Context, addrs []address.Address) ([]ResolvedAddress, error) //perm:read
 	// StateDecodeParams attempts to decode the provided params, based on the recipient actor address and method number.
 	StateDecodeParams(ctx context.Context, toAddr address.Address, method abi.MethodNum, params []byte, tsk types.TipSetKey) (interface{}, error) //perm:read
 	// StateEncodeParams attempts to encode the provided json params to the binary from
 	StateEncodeParams(ctx context.Context, toActCode cid.Cid, method abi.MethodNum, params json.RawMessage) ([]byte, error) //perm:read
+	// StateActorSchema returns JSON schemas of the state, and of the method params and return
+	// values of the builtin actor with the given code CID. The schemas are generated from the
+	// actor types bundled with the node, so any builtin actor version known to the node can
+	// be decoded without hardcoding its types.
+	StateActorSchema(ctx context.Context, code cid.Cid) (*ActorSchema, error) //perm:read
+	// StateEncodeMethodParams encodes JSON params to CBOR for a method of a builtin actor. The
+	// actor is identified by its code CID, or by its name and actors version, so messages can
+	// be crafted for any actors version bundled with the node, without an actor on chain.
+	StateEncodeMethodParams(ctx context.Context, method ActorMethod, params json.RawMessage) ([]byte, error) //perm:read
+	// StateDecodeMethodParams decodes the CBOR params of a method of a builtin actor.
+	StateDecodeMethodParams(ctx context.Context, method ActorMethod, params []byte) (interface{}, error) //perm:read
+	// StateDecodeMethodReturn decodes the CBOR return value of a method of a builtin actor.
+	StateDecodeMethodReturn(ctx context.Context, method ActorMethod, ret []byte) (interface{}, error) //perm:read
+	// StateMigrationDryRun runs the state migration of the next network upgrade against the
+	// state computed by the given tipset, in memory, and reports the migration time, the
+	// migrated state root and the changes to actors. Nothing is persisted. Migrating the
+	// state of a large network takes a lot of time and memory.
+	StateMigrationDryRun(ctx context.Context, tsk types.TipSetKey) (*MigrationDryRun, error) //perm:admin
 
 	// StateNetworkName returns the name of the network the node is synced to
 	StateNetworkName(context.Context) (dtypes.NetworkName, error) //perm:read
@@ -467,6 +689,12 @@ type FullNode interface {
 	StateMinerProvingDeadline(context.Context, address.Address, types.TipSetKey) (*dline.Info, error) //perm:read
 	// StateMinerPower returns the power of the indicated miner
 	StateMinerPower(context.Context, address.Address, types.TipSetKey) (*MinerPower, error) //perm:read
+	// StateMinerPowerHistory returns the samples of the power, pledge and balance of a miner
+	// taken every Index.PowerHistoryInterval epochs between the given heights, inclusive, with
+	// the blocks it won, oldest first. A to height of 0 means no upper bound. Only the epochs
+	// observed by the node are sampled. Requires Index.EnablePowerHistory to be set in the node
+	// config, and the miner to be listed in Index.PowerHistoryMiners.
+	StateMinerPowerHistory(ctx context.Context, miner address.Address, from, to abi.ChainEpoch) ([]MinerPowerSample, error) //perm:read
 	// StateMinerInfo returns info about the indicated miner
 	StateMinerInfo(context.Context, address.Address, types.TipSetKey) (MinerInfo, error) //perm:read
 	// StateMinerDeadlines returns all the proving deadlines for the given miner
@@ -569,6 +797,9 @@ type FullNode interface {
 	StateAccountKey(context.Context, address.Address, types.TipSetKey) (address.Address, error) //perm:read
 	// StateLookupRobustAddress returns the public key address of the given ID address for non-account addresses (multisig, miners etc)
 	StateLookupRobustAddress(context.Context, address.Address, types.TipSetKey) (address.Address, error) //perm:read
+	// StateLookupDelegatedAddress returns the delegated (f4) address of the actor of the given
+	// address, or an error when the actor has no delegated address.
+	StateLookupDelegatedAddress(context.Context, address.Address, types.TipSetKey) (address.Address, error) //perm:read
 	// StateChangedActors returns all the actors whose states change between the two given state CIDs
 	// TODO: Should this take tipset keys instead?
 	StateChangedActors(context.Context, cid.Cid, cid.Cid) (map[string]types.Actor, error) //perm:read
@@ -636,6 +867,12 @@ type FullNode interface {
 	// StateActorManifestCID returns the CID of the builtin actors manifest for the given network version
 	StateActorManifestCID(context.Context, abinetwork.Version) (cid.Cid, error) //perm:read
 
+	// StateGasStats returns the statistics of the gas used by the messages executed on
+	// chain, per actor code and method, over the tipsets matching the filter. The node
+	// keeps the statistics of the last Index.GasStatsRetention epochs it observed.
+	// Requires Index.EnableGasStats to be set in the node config.
+	StateGasStats(ctx context.Context, filter GasStatsFilter) (*GasStatsResult, error) //perm:read
+
 	// StateGetRandomnessFromTickets is used to sample the chain for randomness.
 	StateGetRandomnessFromTickets(ctx context.Context, personalization crypto.DomainSeparationTag, randEpoch abi.ChainEpoch, entropy []byte, tsk types.TipSetKey) (abi.Randomness, error) //perm:read
 	// StateGetRandomnessFromBeacon is used to sample the beacon for randomness.
@@ -744,6 +981,11 @@ type FullNode interface {
 	MarketReleaseFunds(ctx context.Context, addr address.Address, amt types.BigInt) error //perm:sign
 	// MarketWithdraw withdraws unlocked funds from the market actor
 	MarketWithdraw(ctx context.Context, wallet, addr address.Address, amt types.BigInt) (cid.Cid, error) //perm:sign
+	// MarketFundsStatus returns the market escrow of the address, split between
+	// the funds locked by deals on chain, the funds reserved for the deals
+	// being made and the funds available, along with the latest automatic
+	// withdrawal of the released funds.
+	MarketFundsStatus(ctx context.Context, addr address.Address) (MarketFundsStatus, error) //perm:read
 
 	// MethodGroup: Paych
 	// The Paych methods are for interacting with and managing payment channels
@@ -791,20 +1033,26 @@ type FullNode interface {
 	FilecoinAddressToEthAddress(ctx context.Context, filecoinAddress address.Address) (ethtypes.EthAddress, error) //perm:read
 	// EthBlockNumber returns the height of the latest (heaviest) TipSet
 	EthBlockNumber(ctx context.Context) (ethtypes.EthUint64, error) //perm:read
+	// EthSyncing returns false when the node is in sync, otherwise the progress of the sync,
+	// in the format of the Fevm.CompatibilityProfile set in the node config.
+	EthSyncing(ctx context.Context) (ethtypes.EthSyncingResult, error) //perm:read
 	// EthGetBlockTransactionCountByNumber returns the number of messages in the TipSet
 	EthGetBlockTransactionCountByNumber(ctx context.Context, blkNum ethtypes.EthUint64) (ethtypes.EthUint64, error) //perm:read
 	// EthGetBlockTransactionCountByHash returns the number of messages in the TipSet
 	EthGetBlockTransactionCountByHash(ctx context.Context, blkHash ethtypes.EthHash) (ethtypes.EthUint64, error) //perm:read
 
-	EthGetBlockByHash(ctx context.Context, blkHash ethtypes.EthHash, fullTxInfo bool) (ethtypes.EthBlock, error)                               //perm:read
-	EthGetBlockByNumber(ctx context.Context, blkNum string, fullTxInfo bool) (ethtypes.EthBlock, error)                                        //perm:read
-	EthGetTransactionByHash(ctx context.Context, txHash *ethtypes.EthHash) (*ethtypes.EthTx, error)                                            //perm:read
-	EthGetTransactionByHashLimited(ctx context.Context, txHash *ethtypes.EthHash, limit abi.ChainEpoch) (*ethtypes.EthTx, error)               //perm:read
-	EthGetTransactionHashByCid(ctx context.Context, cid cid.Cid) (*ethtypes.EthHash, error)                                                    //perm:read
-	EthGetMessageCidByTransactionHash(ctx context.Context, txHash *ethtypes.EthHash) (*cid.Cid, error)                                         //perm:read
-	EthGetTransactionCount(ctx context.Context, sender ethtypes.EthAddress, blkOpt string) (ethtypes.EthUint64, error)                         //perm:read
-	EthGetTransactionReceipt(ctx context.Context, txHash ethtypes.EthHash) (*EthTxReceipt, error)                                              //perm:read
-	EthGetTransactionReceiptLimited(ctx context.Context, txHash ethtypes.EthHash, limit abi.ChainEpoch) (*EthTxReceipt, error)                 //perm:read
+	EthGetBlockByHash(ctx context.Context, blkHash ethtypes.EthHash, fullTxInfo bool) (ethtypes.EthBlock, error)                 //perm:read
+	EthGetBlockByNumber(ctx context.Context, blkNum string, fullTxInfo bool) (ethtypes.EthBlock, error)                          //perm:read
+	EthGetTransactionByHash(ctx context.Context, txHash *ethtypes.EthHash) (*ethtypes.EthTx, error)                              //perm:read
+	EthGetTransactionByHashLimited(ctx context.Context, txHash *ethtypes.EthHash, limit abi.ChainEpoch) (*ethtypes.EthTx, error) //perm:read
+	EthGetTransactionHashByCid(ctx context.Context, cid cid.Cid) (*ethtypes.EthHash, error)                                      //perm:read
+	EthGetMessageCidByTransactionHash(ctx context.Context, txHash *ethtypes.EthHash) (*cid.Cid, error)                           //perm:read
+	EthGetTransactionCount(ctx context.Context, sender ethtypes.EthAddress, blkOpt string) (ethtypes.EthUint64, error)           //perm:read
+	EthGetTransactionReceipt(ctx context.Context, txHash ethtypes.EthHash) (*EthTxReceipt, error)                                //perm:read
+	EthGetTransactionReceiptLimited(ctx context.Context, txHash ethtypes.EthHash, limit abi.ChainEpoch) (*EthTxReceipt, error)   //perm:read
+	// EthGetBlockReceipts returns the receipts of all transactions in the block,
+	// specified either by its number, by a tag, or by its hash
+	EthGetBlockReceipts(ctx context.Context, blkParam string) ([]*EthTxReceipt, error)                                                         //perm:read
 	EthGetTransactionByBlockHashAndIndex(ctx context.Context, blkHash ethtypes.EthHash, txIndex ethtypes.EthUint64) (ethtypes.EthTx, error)    //perm:read
 	EthGetTransactionByBlockNumberAndIndex(ctx context.Context, blkNum ethtypes.EthUint64, txIndex ethtypes.EthUint64) (ethtypes.EthTx, error) //perm:read
 
@@ -823,6 +1071,11 @@ type FullNode interface {
 	EthCall(ctx context.Context, tx ethtypes.EthCall, blkParam string) (ethtypes.EthBytes, error) //perm:read
 
 	EthSendRawTransaction(ctx context.Context, rawTx ethtypes.EthBytes) (ethtypes.EthHash, error) //perm:read
+	// EthSimulateRawTransaction executes a signed raw transaction on top of the pending state,
+	// after the sender's pending mpool messages with lower nonces, without submitting it. The
+	// transaction is executed with its own gas limit and fees, so the result reflects how it
+	// would execute if it was included in the next tipset.
+	EthSimulateRawTransaction(ctx context.Context, rawTx ethtypes.EthBytes) (*EthTxSimulation, error) //perm:read
 
 	// Returns event logs matching given filter spec.
 	EthGetLogs(ctx context.Context, filter *ethtypes.EthFilterSpec) (*ethtypes.EthFilterResult, error) //perm:read
@@ -859,9 +1112,56 @@ type FullNode interface {
 	// Unsubscribe from a websocket subscription
 	EthUnsubscribe(ctx context.Context, id ethtypes.EthSubscriptionID) (bool, error) //perm:write
 
+	// EthEventWatchList returns the contract addresses and topics whose events are retained in the
+	// event index indefinitely, regardless of Fevm.Events.RetentionEpochs.
+	EthEventWatchList(ctx context.Context) (*EthEventWatchList, error) //perm:read
+	// EthEventWatchListAdd adds contract addresses and topics to the event watch-list. When event
+	// retention is enabled, the events of the added addresses and topics emitted since backfillFrom
+	// which have already been pruned are indexed again in the background. Until then, queries for
+	// their events before the retention window fail. Pass a negative backfillFrom to skip backfilling.
+	EthEventWatchListAdd(ctx context.Context, watch EthEventWatchList, backfillFrom abi.ChainEpoch) error //perm:admin
+	// EthEventWatchListRemove removes contract addresses and topics from the event watch-list, their
+	// events become eligible for pruning.
+	EthEventWatchListRemove(ctx context.Context, watch EthEventWatchList) error //perm:admin
+
 	// Returns the client version
 	Web3ClientVersion(ctx context.Context) (string, error) //perm:read
 
+	// MethodGroup: Webhook
+	// The Webhook methods manage webhook subscriptions, which get notified
+	// when matching messages are executed, or reverted in a reorg. They are
+	// only available when Webhooks.EnableWebhooks is set in the node config.
+
+	// WebhookSubscribe adds a webhook subscription, notifications of the messages
+	// matching any of the patterns are posted to url. When secret isn't empty,
+	// notifications are signed with HMAC-SHA256 in the X-Lotus-Signature header.
+	WebhookSubscribe(ctx context.Context, url string, secret string, patterns []WebhookPattern) (uuid.UUID, error) //perm:admin
+	// WebhookUnsubscribe removes a webhook subscription.
+	WebhookUnsubscribe(ctx context.Context, id uuid.UUID) error //perm:admin
+	// WebhookList returns the webhook subscriptions.
+	WebhookList(ctx context.Context) ([]WebhookSubscription, error) //perm:admin
+
+	// MethodGroup: Tenant
+	// The Tenant methods manage the namespaces which API tokens can be bound to.
+	// The requests made with a namespaced token only see the wallet keys assigned
+	// to the namespace, and the market client deals started with them.
+
+	// AuthNewNamespaced creates an API token bound to the namespace. Namespaced
+	// tokens can't be granted the admin permission.
+	AuthNewNamespaced(ctx context.Context, perms []auth.Permission, namespace string) ([]byte, error) //perm:admin
+	// AuthNewWithScope creates an API token with the permissions, restricted to the
+	// methods and wallet keys of the scope, e.g. for a bot which only needs a few
+	// methods. The created tokens can be listed with AuthListTokens, and can't create
+	// tokens themselves.
+	AuthNewWithScope(ctx context.Context, perms []auth.Permission, scope AuthScope) ([]byte, error) //perm:admin
+	// AuthListTokens lists the tokens created with AuthNewWithScope.
+	AuthListTokens(ctx context.Context) ([]AuthToken, error) //perm:admin
+	// TenantAssignWallet assigns a wallet key to the namespace, or removes it from
+	// its namespace when namespace is empty.
+	TenantAssignWallet(ctx context.Context, addr address.Address, namespace string) error //perm:admin
+	// TenantList returns the namespaces with their wallet keys.
+	TenantList(ctx context.Context) ([]Tenant, error) //perm:admin
+
 	// CreateBackup creates node backup onder the specified file name. The
 	// method requires that the lotus daemon is running with the
 	// LOTUS_BACKUP_BASE_PATH environment variable set to some path, and that
@@ -980,6 +1280,16 @@ type Message struct {
 	Message *types.Message
 }
 
+type ParentReceipts struct {
+	TipSet types.TipSetKey
+	Height abi.ChainEpoch
+	// Parent is the tipset which included the messages
+	Parent   types.TipSetKey
+	Messages []Message
+	// Receipts are one-to-one with Messages
+	Receipts []*types.MessageReceipt
+}
+
 type ActorState struct {
 	Balance types.BigInt
 	Code    cid.Cid
@@ -1100,6 +1410,28 @@ func (o *QueryOffer) Order(client address.Address) RetrievalOrder {
 	}
 }
 
+// MarketFundsStatus is the state of the market escrow of an address
+type MarketFundsStatus struct {
+	Escrow types.BigInt
+	// Locked is the escrow locked by the deals on chain
+	Locked types.BigInt
+	// Reserved is the escrow reserved for the deals being made
+	Reserved types.BigInt
+	// Available is the escrow neither locked nor reserved
+	Available types.BigInt
+	// PendingMessage is the top up or withdrawal message in flight, if any
+	PendingMessage *cid.Cid
+	// LastWithdrawal is the latest automatic withdrawal, if any
+	LastWithdrawal *MarketWithdrawal
+}
+
+// MarketWithdrawal is an automatic withdrawal of released market funds
+type MarketWithdrawal struct {
+	Time    time.Time
+	Amount  types.BigInt
+	Message cid.Cid
+}
+
 type MarketBalance struct {
 	Escrow big.Int
 	Locked big.Int
@@ -1141,6 +1473,148 @@ type InvocResult struct {
 	Duration       time.Duration
 }
 
+// ActorOverride replaces parts of an actor for StateCallWithOverrides. Fields
+// which aren't set keep their value from the state.
+type ActorOverride struct {
+	Address address.Address
+
+	Balance *types.BigInt
+	Nonce   *uint64
+	// Code replaces the code CID of the actor, e.g. to run its state with
+	// another version of the actor.
+	Code *cid.Cid
+	// Head replaces the state root of the actor with an object the node
+	// already has.
+	Head *cid.Cid
+	// State replaces the state root of the actor with the given DAG-CBOR
+	// encoded object. It can't be set together with Head.
+	State []byte
+}
+
+type ActorSchema struct {
+	Code    cid.Cid
+	Name    string
+	Version int
+	// State is a JSON schema of the actor state, null if the state type is unknown.
+	State   json.RawMessage
+	Methods []ActorMethodSchema
+}
+
+// ActorMethod identifies a method of a builtin actor.
+type ActorMethod struct {
+	// Code is the code CID of the actor. When undefined, the actor is identified
+	// by Name and Version instead.
+	Code cid.Cid
+	// Name is the name of the actor in the builtin actors manifest, e.g. "storageminer".
+	Name string
+	// Version is the actors version, 0 for the actors version of the current network.
+	Version int
+	// Method is the method number, used when MethodName is empty.
+	Method abi.MethodNum
+	// MethodName is the exported name of the method, e.g. "SubmitWindowedPoSt".
+	MethodName string
+}
+
+type ActorMethodSchema struct {
+	Num  abi.MethodNum
+	Name string
+	// Params and Return are JSON schemas of the method params and return value.
+	Params json.RawMessage
+	Return json.RawMessage
+}
+
+// MigrationDryRun is the result of a network upgrade migration run in memory.
+type MigrationDryRun struct {
+	// TipSet is the tipset whose computed state was migrated.
+	TipSet types.TipSetKey
+	// Height is the upgrade epoch and Network the network version after the upgrade.
+	Height  abi.ChainEpoch
+	Network apitypes.NetworkVersion
+
+	OldStateRoot cid.Cid
+	NewStateRoot cid.Cid
+	Duration     time.Duration
+
+	// Actors counts the migrated actors by old and new actor code.
+	Actors []MigrationActorChanges
+	// Added and Removed are the actors created and deleted by the migration.
+	Added   []address.Address
+	Removed []address.Address
+}
+
+type MigrationActorChanges struct {
+	Name       string
+	OldCode    cid.Cid
+	OldVersion int
+	NewCode    cid.Cid
+	NewVersion int
+
+	Actors         int
+	StateChanged   int
+	BalanceChanged int
+}
+
+// ProofBlock is a raw IPLD block which is part of a merkle proof.
+type ProofBlock struct {
+	Cid  cid.Cid
+	Data []byte
+}
+
+// ActorStateProof proves an actor against a state root.
+type ActorStateProof struct {
+	TipSet    types.TipSetKey
+	StateRoot cid.Cid
+	Address   address.Address
+	Actor     *types.Actor
+	// Proof contains all the blocks read when looking the actor up from the
+	// state root, including the resolution of robust addresses.
+	Proof []ProofBlock
+}
+
+// ReceiptProof proves a message receipt against a receipts root.
+type ReceiptProof struct {
+	// TipSet is the tipset whose ParentMessageReceipts is ReceiptsRoot.
+	TipSet       types.TipSetKey
+	ReceiptsRoot cid.Cid
+	// Message is the CID of the executed message, and Index its index in the
+	// messages of the parent tipset.
+	Message cid.Cid
+	Index   uint64
+	Receipt types.MessageReceipt
+	Proof   []ProofBlock
+}
+
+// MessageAttestation is a statement that a message was included in the chain,
+// and executed with the given result. It is the signed part of an attestation
+// bundle, signatures cover its CBOR encoding.
+type MessageAttestation struct {
+	Network string
+	// Message is the CID of the executed message, which may be a replacement
+	// of the message an attestation was requested for.
+	Message         cid.Cid
+	InclusionTipSet []cid.Cid
+	InclusionHeight abi.ChainEpoch
+	// ExecutionTipSet is the tipset whose ParentMessageReceipts contains the
+	// receipt of the message.
+	ExecutionTipSet []cid.Cid
+	ReceiptsRoot    cid.Cid
+	ExitCode        exitcode.ExitCode
+	GasUsed         int64
+	// AttestedHeight is the height of the chain head when the attestation was
+	// made, and Final whether the inclusion tipset was final at that height.
+	AttestedHeight abi.ChainEpoch
+	Final          bool
+}
+
+// AttestationBundle is a signed message attestation, with a proof of the
+// message receipt against the receipts root.
+type AttestationBundle struct {
+	Attestation MessageAttestation
+	Signer      address.Address
+	Signature   crypto.Signature
+	Proof       *ReceiptProof
+}
+
 type MethodCall struct {
 	types.MessageReceipt
 	Error string
@@ -1156,6 +1630,10 @@ type StartDealParams struct {
 	DealStartEpoch     abi.ChainEpoch
 	FastRetrieval      bool
 	VerifiedDeal       bool
+
+	// Template is the name of the deal template the deal is made with, see
+	// ClientDealTemplateSet.
+	Template string `json:",omitempty"`
 }
 
 func (s *StartDealParams) UnmarshalJSON(raw []byte) (err error) {
@@ -1174,6 +1652,199 @@ func (s *StartDealParams) UnmarshalJSON(raw []byte) (err error) {
 	return nil
 }
 
+// ProviderSelector selects the storage providers data is replicated to
+type ProviderSelector struct {
+	// Candidates are the providers to choose from. When empty, the providers
+	// in the local reputation store and the providers of previous deals are
+	// used.
+	Candidates []address.Address
+	// Exclude lists providers which must not be chosen, e.g. the providers
+	// already storing the data
+	Exclude []address.Address
+	// Tags the providers must all have in the local reputation store
+	Tags []string
+	// MinRetrievalSuccessRate is the minimum fraction of successful
+	// retrievals from a provider, between 0 and 1. When set, providers
+	// without any recorded retrieval aren't chosen.
+	MinRetrievalSuccessRate float64
+	// MinScore is the minimum provider score, see ProviderScore
+	MinScore float64
+}
+
+// DealTemplate is a named preset of storage deal parameters. The parameters
+// left unset in a deal are taken from the template, and the deal is refused
+// if it doesn't fit the limits of the template.
+type DealTemplate struct {
+	Name string
+
+	// Duration is the deal duration in epochs, used when the deal doesn't set
+	// MinBlocksDuration.
+	Duration abi.ChainEpoch
+	// Verified makes the deals verified.
+	Verified bool
+	// FastRetrieval requests an unsealed copy of the data from the provider.
+	FastRetrieval bool
+
+	// MaxPricePerGiB is the highest price per GiB per epoch the deals can pay,
+	// unlimited if not set.
+	MaxPricePerGiB *types.BigInt `json:",omitempty"`
+	// MinProviderCollateral and MaxProviderCollateral bound the collateral of
+	// the provider, unbounded if not set. Deals which don't set a collateral
+	// use MinProviderCollateral.
+	MinProviderCollateral *types.BigInt `json:",omitempty"`
+	MaxProviderCollateral *types.BigInt `json:",omitempty"`
+}
+
+type ReplicationStatus struct {
+	ID        uuid.UUID
+	Root      cid.Cid
+	Requested int
+	Created   time.Time
+
+	// Active, InProgress and Failed count the deals in each state
+	Active     int
+	InProgress int
+	Failed     int
+
+	Deals []ReplicationDeal
+}
+
+type ReplicationDeal struct {
+	Miner address.Address
+	// ProposalCid is nil if the deal couldn't be proposed
+	ProposalCid *cid.Cid
+	State       storagemarket.StorageDealStatus
+	Message     string
+}
+
+// DataPrepManifest describes a file prepared for storage deals by
+// ClientPrepareData
+type DataPrepManifest struct {
+	ID              uuid.UUID
+	Source          string
+	SourceSize      uint64
+	TargetPieceSize abi.PaddedPieceSize
+	Created         time.Time
+
+	Pieces []DataPrepPiece
+}
+
+type DataPrepPiece struct {
+	// Offset and Length are the range of the source file in the piece
+	Offset uint64
+	Length uint64
+
+	Root     cid.Cid
+	ImportID imports.ID
+	CarPath  string
+	CarSize  uint64
+
+	PieceCid  cid.Cid
+	PieceSize abi.PaddedPieceSize
+}
+
+// PieceAggregate is a piece made of several pieces, built by
+// ClientAggregatePieces
+type PieceAggregate struct {
+	PieceCid  cid.Cid
+	PieceSize abi.PaddedPieceSize
+	// Path is the path of the unpadded data of the aggregate
+	Path string
+
+	Segments []AggregateSegment
+}
+
+type AggregateSegment struct {
+	Root      cid.Cid
+	PieceCid  cid.Cid
+	PieceSize abi.PaddedPieceSize
+	// Offset is the padded offset of the piece in the aggregate
+	Offset uint64
+	// InclusionProof is the CBOR encoded datasegment.InclusionProof of the
+	// piece in the aggregate
+	InclusionProof []byte
+}
+
+// ProviderReputation is the local record of a storage provider: the outcome
+// of the deals and retrievals made with it
+type ProviderReputation struct {
+	Miner address.Address
+	Tags  []string
+
+	DealsAccepted uint64
+	DealsRejected uint64
+	// AcceptanceLatency is the total time between proposing and the provider
+	// accepting the accepted deals
+	AcceptanceLatency time.Duration
+	// SealingSuccesses and SealingFailures count the accepted deals which
+	// were activated, and the ones which failed
+	SealingSuccesses uint64
+	SealingFailures  uint64
+	LastDeal         time.Time
+
+	RetrievalSuccesses uint64
+	RetrievalFailures  uint64
+	// RetrievalBytes and RetrievalDuration are the total bytes received and
+	// time taken by the successful retrievals
+	RetrievalBytes    uint64
+	RetrievalDuration time.Duration
+	LastRetrieval     time.Time
+}
+
+type AllocationState string
+
+const (
+	// AllocationPending allocations can be claimed by their provider.
+	AllocationPending AllocationState = "pending"
+	// AllocationExpiring allocations can still be claimed, but expire soon.
+	AllocationExpiring AllocationState = "expiring"
+	// AllocationExpired allocations weren't claimed before their expiration,
+	// their datacap can be recovered by removing them.
+	AllocationExpired AllocationState = "expired"
+	// AllocationClaimed allocations were claimed by their provider.
+	AllocationClaimed AllocationState = "claimed"
+	// AllocationClaimExpired allocations were claimed, and the term of the
+	// claim ended.
+	AllocationClaimExpired AllocationState = "claim-expired"
+)
+
+// AllocationInfo is a datacap allocation, or the claim made with it, which
+// keeps the id of the allocation.
+type AllocationInfo struct {
+	ID       verifregtypes.AllocationId
+	State    AllocationState
+	Client   abi.ActorID
+	Provider abi.ActorID
+	Data     cid.Cid
+	Size     abi.PaddedPieceSize
+	TermMin  abi.ChainEpoch
+	TermMax  abi.ChainEpoch
+
+	// Expiration is the epoch by which an unclaimed allocation must be claimed.
+	Expiration abi.ChainEpoch
+	// TermStart and Sector are those of the claim; its term ends at
+	// TermStart+TermMax.
+	TermStart abi.ChainEpoch
+	Sector    abi.SectorNumber
+}
+
+// ProviderScore rates a storage provider from its local record. Rates are
+// smoothed, so that providers without any recorded outcome rate 0.5.
+type ProviderScore struct {
+	Miner address.Address
+	// Score is between 0 and 1, weighting the sealing and retrieval success
+	// rates at 0.4 each, and the deal acceptance rate at 0.2
+	Score float64
+
+	AcceptanceRate       float64
+	SealingSuccessRate   float64
+	RetrievalSuccessRate float64
+
+	AvgAcceptanceLatency time.Duration
+	// AvgRetrievalSpeed is in bytes per second
+	AvgRetrievalSpeed float64
+}
+
 type IpldObject struct {
 	Cid cid.Cid
 	Obj interface{}
@@ -1243,6 +1914,103 @@ type MpoolUpdate struct {
 	Message *types.SignedMessage
 }
 
+type MpoolSelectPreview struct {
+	// TipSet is the tipset the block would be built on
+	TipSet        types.TipSetKey
+	TicketQuality float64
+	// Strategy is the selection algorithm used for the ticket quality,
+	// greedy or optimal
+	Strategy string
+	BaseFee  types.BigInt
+
+	// GasLimit and Reward are the totals of the selected messages
+	GasLimit int64
+	Reward   types.BigInt
+
+	Selected []MpoolSelectedMessage
+	Excluded []MpoolExcludedMessage
+}
+
+type MpoolSelectedMessage struct {
+	Cid     cid.Cid
+	Message *types.SignedMessage
+	// Reward is the premium the message pays to the block miner at the
+	// current base fee
+	Reward  types.BigInt
+	GasPerf float64
+}
+
+type MpoolExcludedMessage struct {
+	Cid     cid.Cid
+	From    address.Address
+	Nonce   uint64
+	Reward  types.BigInt
+	GasPerf float64
+	Reason  string
+}
+
+// MpoolGasMarket is a summary of the gas premiums offered by the pending
+// messages, and paid by the messages of the recent tipsets
+type MpoolGasMarket struct {
+	TipSet types.TipSetKey
+	Height abi.ChainEpoch
+	// BaseFee is the base fee of the next block
+	BaseFee types.BigInt
+
+	Pending GasPremiumDistribution
+	// Underpriced is the number of pending messages the fee cap of which is
+	// below the base fee
+	Underpriced int
+
+	// Included are the premiums paid in the recent tipsets, newest first
+	Included []MpoolGasMarketEpoch
+}
+
+type MpoolGasMarketEpoch struct {
+	Height   abi.ChainEpoch
+	BaseFee  types.BigInt
+	Premiums GasPremiumDistribution
+}
+
+// GasPremiumDistribution is the distribution of the effective gas premiums
+// of a set of messages
+type GasPremiumDistribution struct {
+	Messages int
+	GasLimit int64
+
+	Min    types.BigInt
+	P25    types.BigInt
+	Median types.BigInt
+	P75    types.BigInt
+	P90    types.BigInt
+	Max    types.BigInt
+}
+
+type MpoolReplaceSpec struct {
+	// Message is the CID of the message to replace, when set From and Nonce
+	// are ignored
+	Message *cid.Cid
+	From    address.Address
+	Nonce   uint64
+	// MaxFee caps the fee of the new message, the default max fee of the node
+	// applies when it isn't set
+	MaxFee abi.TokenAmount
+}
+
+type MpoolReplaceResult struct {
+	Old MessageFees
+	New MessageFees
+}
+
+type MessageFees struct {
+	Cid        cid.Cid
+	GasLimit   int64
+	GasFeeCap  abi.TokenAmount
+	GasPremium abi.TokenAmount
+	// MaxFee is the most the message can pay, GasFeeCap * GasLimit
+	MaxFee abi.TokenAmount
+}
+
 type ComputeStateOutput struct {
 	Root  cid.Cid
 	Trace []*InvocResult
@@ -1305,6 +2073,12 @@ type HeadChange struct {
 	Val  *types.TipSet
 }
 
+type HeadChangePage struct {
+	Changes []*HeadChange
+	// Cursor gets the next page, empty on the last page.
+	Cursor string
+}
+
 type MsigProposeResponse int
 
 const (
@@ -1347,6 +2121,59 @@ type MessageMatch struct {
 	From address.Address
 }
 
+const (
+	MessageDirectionFrom = "from"
+	MessageDirectionTo   = "to"
+)
+
+// AddressMessageQuery selects messages in the address message index.
+type AddressMessageQuery struct {
+	Address address.Address
+	// Direction is MessageDirectionFrom for the messages sent by the address,
+	// MessageDirectionTo for the messages it received, or empty for both.
+	Direction string
+	// FromHeight and ToHeight bound the epochs of the messages, ToHeight 0 for no upper bound.
+	FromHeight abi.ChainEpoch
+	ToHeight   abi.ChainEpoch
+	// Ascending lists the oldest messages first.
+	Ascending bool
+	// Limit is the maximum number of messages in the page, 100 when 0.
+	Limit int
+	// Cursor continues a listing from the Cursor of its previous page.
+	Cursor string
+}
+
+type AddressMessage struct {
+	Cid    cid.Cid
+	TipSet types.TipSetKey
+	Height abi.ChainEpoch
+	From   address.Address
+	To     address.Address
+}
+
+type AddressMessagePage struct {
+	Messages []AddressMessage
+	// Cursor continues the listing, empty on the last page.
+	Cursor string
+	// IndexedFrom is the lowest indexed epoch.
+	IndexedFrom abi.ChainEpoch
+}
+
+// MaxResolveAddresses is the maximum number of addresses resolved at once by
+// StateResolveAddresses.
+const MaxResolveAddresses = 1000
+
+type ResolvedAddress struct {
+	Address address.Address
+	ID      address.Address
+	// Delegated is the f4 address of the actor, when it has one.
+	Delegated *address.Address
+	// EthAddress is derived from the delegated address of the actor, or from its
+	// ID when it has no delegated address.
+	EthAddress *ethtypes.EthAddress
+	Error      string `json:",omitempty"`
+}
+
 type MsigTransaction struct {
 	ID     int64
 	To     address.Address
@@ -1368,6 +2195,143 @@ type HotGCOpts struct {
 	Moving    bool
 }
 
+type BlockstoreMaintainOpts struct {
+	// Mode is either "vlog-gc" to garbage collect the value log, or "compaction" to fully compact
+	// the blockstore before garbage collecting the value log.
+	Mode string
+	// Threshold is the fraction of garbage of the value log files to rewrite; 0 for the default.
+	Threshold float64
+}
+
+const (
+	BlockstoreMaintenanceManual    = "manual"
+	BlockstoreMaintenanceScheduled = "scheduled"
+)
+
+const (
+	BlockstoreMaintenanceRunning   = "running"
+	BlockstoreMaintenanceCompleted = "completed"
+	BlockstoreMaintenanceFailed    = "failed"
+)
+
+type BlockstoreMaintenanceRun struct {
+	Mode string
+	// Trigger is "manual" or "scheduled"
+	Trigger string
+	// State is "running", "completed" or "failed"
+	State string
+	// Phase is the current phase of a running maintenance, "compaction" or "vlog-gc"
+	Phase string
+
+	ValueLogsRewritten int
+	SizeBefore         int64
+	SizeAfter          int64
+
+	Started  time.Time
+	Finished time.Time
+	Error    string
+}
+
+type BlockstoreMaintenanceStatus struct {
+	// Last is the running or last finished run, nil before the first run
+	Last *BlockstoreMaintenanceRun
+	// NextScheduled is the earliest time the next scheduled run may start, nil when no
+	// maintenance windows are configured
+	NextScheduled *time.Time
+}
+
+const (
+	// BlockstoreCorruptUnreadable is a block that can't be read from the blockstore
+	BlockstoreCorruptUnreadable = "unreadable"
+	// BlockstoreCorruptMismatch is a block whose data doesn't hash to its cid
+	BlockstoreCorruptMismatch = "mismatch"
+)
+
+type BlockstoreIncident struct {
+	Cid cid.Cid
+	// Kind is "unreadable" or "mismatch"
+	Kind     string
+	Error    string
+	Detected time.Time
+
+	// Repaired is set once the block is fetched again from the network
+	Repaired       bool
+	RepairAttempts int
+	RepairError    string
+}
+
+type BlockstoreScrubStatus struct {
+	Running bool
+	// PassStarted is the start of the current or last pass
+	PassStarted time.Time
+	// PassFinished is the end of the last completed pass
+	PassFinished time.Time
+	// Checked is the number of blocks checked by the current or last pass
+	Checked int64
+
+	Incidents []BlockstoreIncident
+}
+
+type Tenant struct {
+	Namespace string
+	Wallets   []address.Address
+	// Default is the default wallet key of the namespace, if set
+	Default address.Address
+}
+
+// AuthScope restricts what an API token can be used for. The empty scope
+// doesn't restrict the token.
+type AuthScope struct {
+	// Label describes the token, e.g. the name of the bot using it.
+	Label string
+	// Methods are the only methods the token can call, without the Filecoin.
+	// prefix, e.g. ChainHead. The permissions of the token must allow them too.
+	// The REST endpoints need the method they stand for: ClientImport for
+	// /rest/v0/import, ClientExport for /rest/v0/export and ClientRetrieve for
+	// /rest/v0/store.
+	Methods []string
+	// Wallets are the only wallet keys the token can sign with, send messages
+	// from, and manage funds, deals and payment channels of.
+	Wallets []address.Address
+}
+
+// AuthToken describes a token created with AuthNewWithScope.
+type AuthToken struct {
+	ID      string
+	Perms   []auth.Permission
+	Scope   AuthScope
+	Created time.Time
+}
+
+// EthEventWatchList lists the contract addresses and topics whose events are
+// retained in the event index indefinitely.
+type EthEventWatchList struct {
+	Addresses []ethtypes.EthAddress
+	Topics    []ethtypes.EthHash
+}
+
+// EthTxSimulation is the result of simulating the execution of a raw transaction.
+type EthTxSimulation struct {
+	TransactionHash ethtypes.EthHash     `json:"transactionHash"`
+	From            ethtypes.EthAddress  `json:"from"`
+	To              *ethtypes.EthAddress `json:"to"`
+	Nonce           ethtypes.EthUint64   `json:"nonce"`
+	// ExpectedNonce is the next nonce of the sender, accounting for its pending
+	// mpool messages. A transaction with a higher nonce won't be executed until
+	// the gap is filled, one with a lower nonce replaces a pending message.
+	ExpectedNonce ethtypes.EthUint64 `json:"expectedNonce"`
+	// PendingMessages is the number of pending mpool messages from the sender
+	// executed before the transaction.
+	PendingMessages ethtypes.EthUint64   `json:"pendingMessages"`
+	Status          ethtypes.EthUint64   `json:"status"`
+	GasLimit        ethtypes.EthUint64   `json:"gasLimit"`
+	GasUsed         ethtypes.EthUint64   `json:"gasUsed"`
+	ContractAddress *ethtypes.EthAddress `json:"contractAddress"`
+	ReturnData      ethtypes.EthBytes    `json:"returnData"`
+	RevertReason    string               `json:"revertReason,omitempty"`
+	Error           string               `json:"error,omitempty"`
+}
+
 type EthTxReceipt struct {
 	TransactionHash   ethtypes.EthHash     `json:"transactionHash"`
 	TransactionIndex  ethtypes.EthUint64   `json:"transactionIndex"`
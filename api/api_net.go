@@ -32,9 +32,18 @@ type Net interface {
 	NetDisconnect(context.Context, peer.ID) error                             //perm:write
 	NetFindPeer(context.Context, peer.ID) (peer.AddrInfo, error)              //perm:read
 	NetPubsubScores(context.Context) ([]PubsubScore, error)                   //perm:read
-	NetAutoNatStatus(context.Context) (NatInfo, error)                        //perm:read
-	NetAgentVersion(ctx context.Context, p peer.ID) (string, error)           //perm:read
-	NetPeerInfo(context.Context, peer.ID) (*ExtendedPeerInfo, error)          //perm:read
+
+	// NetPubsubRejections returns the number of gossipsub messages rejected
+	// per topic, by rejection reason, with the peers whose messages were
+	// rejected the most.
+	NetPubsubRejections(context.Context) ([]PubsubTopicRejections, error) //perm:read
+	// NetPubsubRejectedMessages returns samples of the most recently rejected
+	// gossipsub messages of a topic, or of all topics if topic is empty.
+	NetPubsubRejectedMessages(ctx context.Context, topic string) ([]PubsubRejectedMessage, error) //perm:read
+
+	NetAutoNatStatus(context.Context) (NatInfo, error)               //perm:read
+	NetAgentVersion(ctx context.Context, p peer.ID) (string, error)  //perm:read
+	NetPeerInfo(context.Context, peer.ID) (*ExtendedPeerInfo, error) //perm:read
 
 	// NetBandwidthStats returns statistics about the nodes total bandwidth
 	// usage and current rate across all peers and protocols.
@@ -57,6 +66,25 @@ type Net interface {
 	NetProtectRemove(ctx context.Context, acl []peer.ID) error //perm:admin
 	NetProtectList(ctx context.Context) ([]peer.ID, error)     //perm:read
 
+	// NetPeerScores returns the connection manager value, tags and gossipsub
+	// score of connected peers, from the most to the least valuable.
+	NetPeerScores(ctx context.Context) ([]PeerScore, error) //perm:read
+	// NetSetPeerTag sets a connection manager tag on a peer, peers with higher
+	// tag values are kept longer when trimming connections. A value of 0
+	// removes the tag.
+	NetSetPeerTag(ctx context.Context, p peer.ID, tag string, value int) error //perm:admin
+
+	// Allow-list API. Allowed peers are stored in the node datastore, protected
+	// from connection trimming, and reconnected to according to the reconnect
+	// policy when disconnected. Peers can be denied with the NetBlock API,
+	// which is also persisted.
+	NetAllowAdd(ctx context.Context, peers []peer.AddrInfo) error //perm:admin
+	NetAllowRemove(ctx context.Context, peers []peer.ID) error    //perm:admin
+	NetAllowList(ctx context.Context) ([]peer.AddrInfo, error)    //perm:read
+
+	NetGetReconnectPolicy(ctx context.Context) (NetReconnectPolicy, error)      //perm:read
+	NetSetReconnectPolicy(ctx context.Context, policy NetReconnectPolicy) error //perm:admin
+
 	// ResourceManager API
 	NetStat(ctx context.Context, scope string) (NetStat, error)          //perm:read
 	NetLimit(ctx context.Context, scope string) (NetLimit, error)        //perm:read
@@ -74,4 +102,28 @@ type CommonNet interface {
 type NatInfo struct {
 	Reachability network.Reachability
 	PublicAddr   string
+
+	// ReachabilityHistory lists the latest reachability changes observed by
+	// AutoNAT, oldest first.
+	ReachabilityHistory []ReachabilityChange
+	// Relays lists the circuit relays the node holds a reservation on, and
+	// RelayAddrs the relayed addresses it announces through them.
+	Relays     []peer.ID
+	RelayAddrs []string
+	HolePunch  HolePunchStats
+}
+
+type ReachabilityChange struct {
+	Reachability network.Reachability
+	Time         time.Time
+}
+
+// HolePunchStats summarizes the DCUtR hole punches run by the node since it
+// started.
+type HolePunchStats struct {
+	Enabled   bool
+	Attempts  int64
+	Successes int64
+	Failures  int64
+	LastError string
 }
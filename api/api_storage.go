@@ -20,12 +20,14 @@ import (
 	"github.com/filecoin-project/go-state-types/big"
 	"github.com/filecoin-project/go-state-types/builtin/v9/market"
 	"github.com/filecoin-project/go-state-types/builtin/v9/miner"
+	"github.com/filecoin-project/go-state-types/exitcode"
 	abinetwork "github.com/filecoin-project/go-state-types/network"
 
 	builtinactors "github.com/filecoin-project/lotus/chain/actors/builtin"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/storage/pipeline/sealiface"
 	"github.com/filecoin-project/lotus/storage/sealer/fsutil"
+	"github.com/filecoin-project/lotus/storage/sealer/sealtasks"
 	"github.com/filecoin-project/lotus/storage/sealer/storiface"
 )
 
@@ -50,6 +52,23 @@ type StorageMiner interface {
 	ActorSectorSize(context.Context, address.Address) (abi.SectorSize, error) //perm:read
 	ActorAddressConfig(ctx context.Context) (AddressConfig, error)            //perm:read
 
+	// ActorAddressRotationPlan plans the change of the owner, worker and
+	// control addresses of the miner as steps to execute one at a time, and
+	// dry-runs them: it checks the new addresses and the balances of the
+	// senders, looks for the messages and multisig proposals already pending,
+	// and estimates the gas of the next step.
+	ActorAddressRotationPlan(ctx context.Context, rot AddressRotation) (*AddressRotationPlan, error) //perm:read
+	// ActorAddressRotationStep sends the message of the next step of the
+	// rotation, which must be ready. A step sent by a multisig is proposed
+	// through proposer, one of its signers, and executes once enough signers
+	// approved it. This method returns the message CID and does not wait for
+	// message execution
+	ActorAddressRotationStep(ctx context.Context, rot AddressRotation, proposer address.Address) (cid.Cid, error) //perm:admin
+	// ActorAddressBalances returns the balances of the worker and control
+	// addresses found by the last check of the balance monitor, and the
+	// refills sent to them from the owner address in the last 24 hours.
+	ActorAddressBalances(ctx context.Context) (AddressBalanceStatus, error) //perm:read
+
 	// WithdrawBalance allows to withdraw balance from miner actor to owner address
 	// Specify amount as "0" to withdraw full balance. This method returns a message CID
 	// and does not wait for message execution
@@ -62,6 +81,17 @@ type StorageMiner interface {
 
 	MiningBase(context.Context) (*types.TipSet, error) //perm:read
 
+	// MinerBlocksReport returns the journal of the elections won by the miner
+	// between the given epochs, with the blocks produced, their fate on chain,
+	// and the rewards they earned. A to epoch of 0 means up to the chain head.
+	MinerBlocksReport(ctx context.Context, from, to abi.ChainEpoch) (*MinerBlocksReport, error) //perm:read
+
+	// MinerSpendReport returns the gas spent by the messages the miner sent
+	// which were executed between the given epochs, per subsystem, in total and
+	// in buckets of the given number of epochs. A to epoch of 0 means up to the
+	// chain head, a bucket of 0 means daily buckets.
+	MinerSpendReport(ctx context.Context, from, to, bucket abi.ChainEpoch) (*SpendReport, error) //perm:read
+
 	ComputeWindowPoSt(ctx context.Context, dlIdx uint64, tsk types.TipSetKey) ([]miner.SubmitWindowedPoStParams, error) //perm:admin
 
 	ComputeDataCid(ctx context.Context, pieceSize abi.UnpaddedPieceSize, pieceData storiface.Data) (abi.PieceInfo, error) //perm:admin
@@ -71,6 +101,15 @@ type StorageMiner interface {
 
 	// Get the status of a given sector by ID
 	SectorsStatus(ctx context.Context, sid abi.SectorNumber, showOnChainInfo bool) (SectorInfo, error) //perm:read
+	// SectorsStatusBatch gets the status of multiple sectors, looked up in
+	// parallel. The results are in the order of the requested sectors, the
+	// sectors which can't be looked up have their Error set instead of failing
+	// the call.
+	SectorsStatusBatch(ctx context.Context, sids []abi.SectorNumber, showOnChainInfo bool) ([]SectorStatusResult, error) //perm:read
+	// SectorsRetryPolicy returns the retry policy applying to the failures of the
+	// sector, with the number of consecutive failures of their class and whether
+	// the last one is retried.
+	SectorsRetryPolicy(ctx context.Context, sid abi.SectorNumber) (SectorRetryPolicy, error) //perm:read
 
 	// Add piece to an open sector. If no sectors with enough space are open,
 	// either a new sector will be created, or this call will block until more
@@ -88,8 +127,24 @@ type StorageMiner interface {
 	// List sectors in particular states
 	SectorsListInStates(context.Context, []SectorState) ([]abi.SectorNumber, error) //perm:read
 
+	// SectorsQuery returns the sectors matching the query from the sector metadata
+	// database, ordered by sector number. Requires Sealing.EnableSectorDB to be set in
+	// the miner config.
+	SectorsQuery(ctx context.Context, q SectorQuery) ([]SectorQueryResult, error) //perm:read
+
 	SectorsRefs(context.Context) (map[string][]SealedRef, error) //perm:read
 
+	// SectorsUnsealRegenList lists the sectors whose lost unsealed copy is being
+	// regenerated, or waits for the approval of the operator to be regenerated.
+	// Requires Sealing.RegenerateUnsealed to be set in the miner config.
+	SectorsUnsealRegenList(ctx context.Context) ([]UnsealRegenJob, error) //perm:read
+	// SectorsUnsealRegenApprove approves the regeneration of the unsealed copy of
+	// the sectors, when Sealing.RegenerateUnsealedApproval is set.
+	SectorsUnsealRegenApprove(ctx context.Context, sectors []abi.SectorNumber) error //perm:admin
+	// SectorsUnsealRegenReject stops proposing the regeneration of the unsealed copy
+	// of the sectors until it is approved.
+	SectorsUnsealRegenReject(ctx context.Context, sectors []abi.SectorNumber) error //perm:admin
+
 	// SectorStartSealing can be called on sectors in Empty or WaitDeals states
 	// to trigger sealing early
 	SectorStartSealing(context.Context, abi.SectorNumber) error //perm:write
@@ -126,7 +181,21 @@ type StorageMiner interface {
 	SectorCommitFlush(ctx context.Context) ([]sealiface.CommitBatchRes, error) //perm:admin
 	// SectorCommitPending returns a list of pending Commit sectors to be sent in the next aggregate message
 	SectorCommitPending(ctx context.Context) ([]abi.SectorID, error) //perm:admin
-	SectorMatchPendingPiecesToOpenSectors(ctx context.Context) error //perm:admin
+	// SectorCommitAggregationSimulate projects the fees of sending the pending
+	// Commit sectors individually and as one aggregate, and what each
+	// aggregation policy would do with them at the current base fee.
+	SectorCommitAggregationSimulate(ctx context.Context) (*sealiface.CommitAggregationSimulation, error) //perm:read
+	// SectorsCostEstimate estimates the collateral, the gas fees of the
+	// PreCommit, Commit and WindowPoSt messages, and the termination penalties
+	// of a sector of the proof type holding the deal mix and committed for
+	// duration epochs, at the current network conditions. A duration of 0 is
+	// the committed capacity sector lifetime of the sealing config.
+	SectorsCostEstimate(ctx context.Context, spt abi.RegisteredSealProof, mix SectorDealMix, duration abi.ChainEpoch) (*SectorCostEstimate, error) //perm:read
+	// SectorsPendingSubmission returns the pending PreCommit, Commit and WindowPoSt
+	// messages, and whether their submission is deferred because the base fee is
+	// above the cap configured for them.
+	SectorsPendingSubmission(ctx context.Context) ([]PendingSubmission, error) //perm:read
+	SectorMatchPendingPiecesToOpenSectors(ctx context.Context) error           //perm:admin
 	// SectorAbortUpgrade can be called on sectors that are in the process of being upgraded to abort it
 	SectorAbortUpgrade(context.Context, abi.SectorNumber) error //perm:admin
 	// SectorUnseal unseals the provided sector
@@ -148,11 +217,22 @@ type StorageMiner interface {
 	SectorNumFree(ctx context.Context, name string) error //perm:admin
 
 	SectorReceive(ctx context.Context, meta RemoteSectorMeta) error //perm:admin
+	// SectorReceiveBatch imports a batch of sectors like SectorReceive. All the
+	// sectors of the batch are checked against the same chain state, and a sector
+	// failing the checks doesn't prevent the other sectors from being imported.
+	SectorReceiveBatch(ctx context.Context, metas []RemoteSectorMeta) ([]SectorReceiveResult, error) //perm:admin
 
 	// WorkerConnect tells the node to connect to workers RPC
 	WorkerConnect(context.Context, string) error                              //perm:admin retry:true
 	WorkerStats(context.Context) (map[uuid.UUID]storiface.WorkerStats, error) //perm:admin
 	WorkerJobs(context.Context) (map[uuid.UUID][]storiface.WorkerJob, error)  //perm:admin
+	// WorkerDrain stops assigning new tasks to the worker. Once its running tasks
+	// are done, the sector files in its sealing-only storage are moved to other
+	// workers, and the worker is detached.
+	WorkerDrain(ctx context.Context, worker uuid.UUID) error //perm:admin
+	// WorkerUndrain cancels a drain started with WorkerDrain, the worker gets
+	// new tasks assigned again
+	WorkerUndrain(ctx context.Context, worker uuid.UUID) error //perm:admin
 
 	// storiface.WorkerReturn
 	ReturnDataCid(ctx context.Context, callID storiface.CallID, pi abi.PieceInfo, err *storiface.CallError) error                                         //perm:admin retry:true
@@ -174,11 +254,23 @@ type StorageMiner interface {
 	ReturnDownloadSector(ctx context.Context, callID storiface.CallID, err *storiface.CallError) error                                                    //perm:admin retry:true
 	ReturnFetch(ctx context.Context, callID storiface.CallID, err *storiface.CallError) error                                                             //perm:admin retry:true
 
+	// storiface.TaskLogReturn
+	ReturnTaskLogs(ctx context.Context, log storiface.TaskLog) error //perm:admin retry:true
+
 	// SealingSchedDiag dumps internal sealing scheduler state
 	SealingSchedDiag(ctx context.Context, doSched bool) (interface{}, error) //perm:admin
 	SealingAbort(ctx context.Context, call storiface.CallID) error           //perm:admin
 	// SealingSchedRemove removes a request from sealing pipeline
 	SealingRemoveRequest(ctx context.Context, schedId uuid.UUID) error //perm:admin
+	// SealingJobsTree returns the state of all sealing jobs: the queued ones in
+	// scheduling order, with the reason they can't be assigned to any worker if
+	// there is one, the jobs assigned to or running on each worker, and the jobs
+	// returned by workers but not yet processed.
+	SealingJobsTree(ctx context.Context) (storiface.JobsTree, error) //perm:admin
+	// SealingTaskLogs returns the logs captured by the workers for the tasks of
+	// the given type run for the sector, or for all its tasks if the type is
+	// empty, oldest first.
+	SealingTaskLogs(ctx context.Context, sector abi.SectorNumber, task sealtasks.TaskType) ([]storiface.TaskLog, error) //perm:admin
 
 	// paths.SectorIndex
 	StorageAttach(context.Context, storiface.StorageInfo, fsutil.FsStat) error                                                         //perm:admin
@@ -214,6 +306,12 @@ type StorageMiner interface {
 	StorageDetachLocal(ctx context.Context, path string) error                           //perm:admin
 	StorageRedeclareLocal(ctx context.Context, id *storiface.ID, dropMissing bool) error //perm:admin
 
+	// StoragePlacementReport checks how the copies of the data of the sectors,
+	// sealed or unsealed, are spread over the failure domains of the long-term
+	// storage paths, given by their "region", "rack" and "host" labels, and
+	// lists the sectors with copies sharing a failure domain.
+	StoragePlacementReport(ctx context.Context) (*storiface.PlacementReport, error) //perm:read
+
 	MarketImportDealData(ctx context.Context, propcid cid.Cid, path string) error //perm:write
 	MarketListDeals(ctx context.Context) ([]*MarketDeal, error)                   //perm:read
 
@@ -225,8 +323,28 @@ type StorageMiner interface {
 	MarketGetAsk(ctx context.Context) (*storagemarket.SignedStorageAsk, error)                                                                                                           //perm:read
 	MarketSetRetrievalAsk(ctx context.Context, rask *retrievalmarket.Ask) error                                                                                                          //perm:admin
 	MarketGetRetrievalAsk(ctx context.Context) (*retrievalmarket.Ask, error)                                                                                                             //perm:read
-	MarketListDataTransfers(ctx context.Context) ([]DataTransferChannel, error)                                                                                                          //perm:write
-	MarketDataTransferUpdates(ctx context.Context) (<-chan DataTransferChannel, error)                                                                                                   //perm:write
+
+	// MarketSetAskSpec sets the storage ask immediately. On top of the flat
+	// prices accepted by MarketSetAsk, the spec can carry a price curve
+	// adjusting the verified and unverified prices by piece size.
+	MarketSetAskSpec(ctx context.Context, ask StorageAskSpec) error //perm:admin
+	// MarketGetAskSpec returns the storage ask currently in effect, including
+	// its price curve.
+	MarketGetAskSpec(ctx context.Context) (*StorageAskSpec, error) //perm:read
+	// MarketScheduleAsk schedules the storage ask to take effect at the given
+	// epoch. Scheduling an ask at an epoch which already has one replaces it.
+	MarketScheduleAsk(ctx context.Context, epoch abi.ChainEpoch, ask StorageAskSpec) error //perm:admin
+	// MarketListScheduledAsks returns the storage asks waiting for their
+	// epoch, in the order they will take effect.
+	MarketListScheduledAsks(ctx context.Context) ([]ScheduledStorageAsk, error) //perm:read
+	// MarketCancelScheduledAsk cancels the storage ask scheduled at the epoch.
+	MarketCancelScheduledAsk(ctx context.Context, epoch abi.ChainEpoch) error //perm:admin
+	// MarketAskHistory returns the audit log of storage ask changes, oldest
+	// first.
+	MarketAskHistory(ctx context.Context) ([]StorageAskChange, error) //perm:read
+
+	MarketListDataTransfers(ctx context.Context) ([]DataTransferChannel, error)        //perm:write
+	MarketDataTransferUpdates(ctx context.Context) (<-chan DataTransferChannel, error) //perm:write
 	// MarketDataTransferDiagnostics generates debugging information about current data transfers over graphsync
 	MarketDataTransferDiagnostics(ctx context.Context, p peer.ID) (*TransferDiagnostics, error) //perm:write
 	// MarketRestartDataTransfer attempts to restart a data transfer with the given transfer ID and other peer
@@ -237,6 +355,68 @@ type StorageMiner interface {
 	MarketPublishPendingDeals(ctx context.Context) error                                                                         //perm:admin
 	MarketRetryPublishDeal(ctx context.Context, propcid cid.Cid) error                                                           //perm:admin
 
+	// MarketRetrievalStats returns retrieval statistics collected by the
+	// retrieval provider since the markets subsystem started: the most
+	// retrieved pieces, the most active clients, and hourly buckets of
+	// retrieval activity. topN limits the number of pieces and clients
+	// returned; zero returns all of them.
+	MarketRetrievalStats(ctx context.Context, topN int) (*RetrievalStats, error) //perm:read
+	// MarketRetrievalTimeline returns the timeline of a retrieval served by the
+	// retrieval provider: when it was accepted, when unsealing started and ended,
+	// when the first byte was sent, samples of the throughput, and how it ended.
+	MarketRetrievalTimeline(ctx context.Context, receiver peer.ID, dealID retrievalmarket.DealID) (*RetrievalTimeline, error) //perm:read
+	// MarketListRetrievalTimelines returns the timelines of the most recent
+	// retrievals, newest first. limit is the number of timelines returned, zero
+	// returns all of them.
+	MarketListRetrievalTimelines(ctx context.Context, limit int) ([]RetrievalTimeline, error) //perm:read
+	// MarketDealSLAReport compares the wall-clock time the storage deals took
+	// from the receipt of their data to the sector holding them being Proving
+	// with the sealing SLA configured with Dealmaking.SealingSLA. The deals
+	// which breached the SLA come first.
+	MarketDealSLAReport(ctx context.Context) (*DealSLAReport, error) //perm:read
+
+	// MarketRetrievabilitySamples returns the last results of the sampling of
+	// the retrievability of the deal data, newest first. limit caps the number
+	// of samples returned; zero returns all the samples kept.
+	// Requires DAGStore.RetrievabilitySampling to be set in the config.
+	MarketRetrievabilitySamples(ctx context.Context, limit int) ([]RetrievabilitySample, error) //perm:read
+	// MarketRetrievabilityCheck samples the given number of pieces now, the
+	// configured number when zero, and returns the results.
+	MarketRetrievabilityCheck(ctx context.Context, pieces int) ([]RetrievabilitySample, error) //perm:admin
+
+	// MarketDataTransferRestarts returns the automatic restarts of stalled
+	// data transfers, for the transfers in progress and the most recently
+	// finished ones. Empty unless stall detection is enabled with
+	// Dealmaking.TransferRestart.StallTimeout.
+	MarketDataTransferRestarts(ctx context.Context) ([]DataTransferRestartHistory, error) //perm:read
+
+	// MarketDealTransferStatus returns the progress of the data transfer of
+	// the storage deal with the given proposal CID.
+	MarketDealTransferStatus(ctx context.Context, propCid cid.Cid) (*DealTransferStatus, error) //perm:read
+	// MarketDealTransferUpdates returns a channel receiving the progress of
+	// the storage deal data transfers, at most once a second for each
+	// transfer, and on each status change.
+	MarketDealTransferUpdates(ctx context.Context) (<-chan DealTransferStatus, error) //perm:read
+
+	// MarketCommPDiagnostics returns the diagnostics captured when the data
+	// of the deal with the given proposal CID didn't match the piece CID of
+	// the proposal.
+	MarketCommPDiagnostics(ctx context.Context, propCid cid.Cid) (*CommPDiagnostics, error) //perm:read
+	// MarketListCommPDiagnostics returns the diagnostics of the deals whose
+	// data didn't match the piece CID of the proposal, oldest first.
+	MarketListCommPDiagnostics(ctx context.Context) ([]CommPDiagnostics, error) //perm:read
+
+	// MarketSetLabels sets user-defined labels on a piece or a deal, given
+	// its piece CID or its deal proposal CID. Labels with an empty value are
+	// removed. The labels of a deal are the labels of its piece, overridden by
+	// the labels set on the deal.
+	MarketSetLabels(ctx context.Context, target cid.Cid, labels map[string]string) error //perm:write
+	// MarketGetLabels returns the labels set on a piece or a deal.
+	MarketGetLabels(ctx context.Context, target cid.Cid) (map[string]string, error) //perm:read
+	// MarketListDealsFiltered returns the deals of the miner on chain
+	// matching the filter, with their labels.
+	MarketListDealsFiltered(ctx context.Context, filter MarketDealFilter) ([]LabeledMarketDeal, error) //perm:read
+
 	// DagstoreListShards returns information about all shards known to the
 	// DAG store. Only available on nodes running the markets subsystem.
 	DagstoreListShards(ctx context.Context) ([]DagstoreShardInfo, error) //perm:read
@@ -289,6 +469,12 @@ type StorageMiner interface {
 	// IndexerAnnounceAllDeals informs the indexer nodes aboutall active deals.
 	IndexerAnnounceAllDeals(ctx context.Context) error //perm:admin
 
+	// DagstoreShardDiagnostics collects the state of the shard of the piece
+	// and of the resources backing it (registration, mount, index, transient
+	// file, recent operations) into a single bundle, to troubleshoot failing
+	// shards.
+	DagstoreShardDiagnostics(ctx context.Context, pieceCID cid.Cid) (*DagstoreShardDiagnostics, error) //perm:admin
+
 	// DagstoreLookupPieces returns information about shards that contain the given CID.
 	DagstoreLookupPieces(ctx context.Context, cid cid.Cid) ([]DagstoreShardInfo, error) //perm:admin
 
@@ -317,6 +503,13 @@ type StorageMiner interface {
 	PiecesListCidInfos(ctx context.Context) ([]cid.Cid, error)                               //perm:read
 	PiecesGetPieceInfo(ctx context.Context, pieceCid cid.Cid) (*piecestore.PieceInfo, error) //perm:read
 	PiecesGetCIDInfo(ctx context.Context, payloadCid cid.Cid) (*piecestore.CIDInfo, error)   //perm:read
+	// PiecesGetBatch gets the info of multiple pieces, in the order of the
+	// requested pieces. The pieces which can't be looked up have their Error
+	// set instead of failing the call.
+	PiecesGetBatch(ctx context.Context, pieceCids []cid.Cid) ([]PieceInfoResult, error) //perm:read
+	// PiecesGetRootCids returns the roots of the payload DAG of the piece, as
+	// found in the CAR header when the piece was indexed by the DAG store.
+	PiecesGetRootCids(ctx context.Context, pieceCid cid.Cid) ([]cid.Cid, error) //perm:read
 
 	// CreateBackup creates node backup onder the specified file name. The
 	// method requires that the lotus-miner is running with the
@@ -328,14 +521,81 @@ type StorageMiner interface {
 
 	ComputeProof(ctx context.Context, ssi []builtinactors.ExtendedSectorInfo, rand abi.PoStRandomness, poStEpoch abi.ChainEpoch, nv abinetwork.Version) ([]builtinactors.PoStProof, error) //perm:read
 
+	// ParamsStatus returns the state of the proof parameter files the miner needs.
+	// Files which weren't verified before are read in full to check their digest,
+	// which can take a while.
+	ParamsStatus(ctx context.Context) ([]ProofParamStatus, error) //perm:read
+	// ParamsRepair verifies all proof parameter files the miner needs, and fetches
+	// the missing and corrupt ones from the mirrors configured in ProofParams.Mirrors.
+	ParamsRepair(ctx context.Context) error //perm:admin
+
+	// MinerSelfTest checks the environment of the node: the clock skew to an NTP
+	// server, the write throughput of the repo and local storage paths, the proof
+	// parameters, the reachability of the libp2p addresses, the connectivity to
+	// peers and to the full node, and the API tokens.
+	MinerSelfTest(ctx context.Context) (*SelfTestReport, error) //perm:admin
+
 	// RecoverFault can be used to declare recoveries manually. It sends messages
 	// to the miner actor with details of recovered sectors and returns the CID of messages. It honors the
 	// maxPartitionsPerRecoveryMessage from the config
 	RecoverFault(ctx context.Context, sectors []abi.SectorNumber) ([]cid.Cid, error) //perm:admin
+
+	// ProvingDetectedFaults lists the live sectors which lost their sealed copy
+	// according to the storage index, for example when the storage path holding
+	// it was declared dead, with the deadline they are proven in. Requires
+	// Proving.EnableFaultDetection to be set in the miner config.
+	ProvingDetectedFaults(ctx context.Context) ([]DetectedFault, error) //perm:read
+	// ProvingDetectedFaultsApprove approves the declaration of the detected faults
+	// of the sectors, when Proving.DeclareDetectedFaultsApproval is set.
+	ProvingDetectedFaultsApprove(ctx context.Context, sectors []abi.SectorNumber) error //perm:admin
+	// ProvingDetectedFaultsReject stops proposing the declaration of the detected
+	// faults of the sectors until it is approved.
+	ProvingDetectedFaultsReject(ctx context.Context, sectors []abi.SectorNumber) error //perm:admin
+
+	// ProvingDisputes lists the DisputeWindowedPoSt messages which targeted the
+	// miner, with the WindowPoSt submissions of the disputed deadline collected
+	// when they were detected. Requires Proving.EnableDisputeWatcher to be set in
+	// the miner config.
+	ProvingDisputes(ctx context.Context) ([]WdPoStDispute, error) //perm:read
+	// ProvingDisputesAck acknowledges the disputes sent in the messages, the
+	// dispute alert is resolved once all the disputes are acknowledged.
+	ProvingDisputesAck(ctx context.Context, msgs []cid.Cid) error //perm:admin
+
+	// ProvingDeadlineLoad reports the sectors and partitions of each deadline, and
+	// how unevenly the WindowPoSt work is spread across the deadlines.
+	ProvingDeadlineLoad(ctx context.Context) (*DeadlineLoadReport, error) //perm:read
+	// ProvingRebalancePlan projects the deadlines the miner actor would assign
+	// newSectors new sectors to if they were committed now, and the partition
+	// compactions which would even out the deadlines.
+	ProvingRebalancePlan(ctx context.Context, newSectors uint64) (*DeadlineRebalancePlan, error) //perm:read
+	// ProvingRebalanceCompact sends the CompactPartitions messages of the
+	// rebalance plan for the deadlines which can be compacted now, and returns
+	// the CIDs of the messages.
+	ProvingRebalanceCompact(ctx context.Context) ([]cid.Cid, error) //perm:admin
 }
 
 var _ storiface.WorkerReturn = *new(StorageMiner)
 
+type ProofParamState string
+
+const (
+	ProofParamPresent ProofParamState = "present"
+	ProofParamMissing ProofParamState = "missing"
+	ProofParamCorrupt ProofParamState = "corrupt"
+)
+
+type ProofParamStatus struct {
+	Name string
+	// SectorSize is the sector size the file is used for, zero for files used for
+	// all sector sizes.
+	SectorSize abi.SectorSize
+	State      ProofParamState
+	// Size is the size of the file on disk.
+	Size int64
+	// Error explains why the file is corrupt.
+	Error string
+}
+
 type SealRes struct {
 	Err   string
 	GoErr error `json:"-"`
@@ -374,6 +634,8 @@ type SectorInfo struct {
 	ReplicaUpdateMessage *cid.Cid
 
 	LastErr string
+	// LastErrExplanation classifies LastErr, nil when LastErr is empty
+	LastErrExplanation *SectorErrorExplanation
 
 	Log []SectorLog
 
@@ -391,6 +653,67 @@ type SectorInfo struct {
 	Early abi.ChainEpoch
 }
 
+// SectorErrorCategory is the kind of a sector error, telling operators where
+// to look for its cause.
+type SectorErrorCategory string
+
+const (
+	SectorErrStorageSpace  SectorErrorCategory = "storage-space"
+	SectorErrStorageAccess SectorErrorCategory = "storage-access"
+	SectorErrWorker        SectorErrorCategory = "worker"
+	SectorErrResources     SectorErrorCategory = "resources"
+	SectorErrProof         SectorErrorCategory = "proof"
+	SectorErrTicket        SectorErrorCategory = "ticket"
+	SectorErrData          SectorErrorCategory = "data"
+	SectorErrDeals         SectorErrorCategory = "deals"
+	SectorErrFunds         SectorErrorCategory = "funds"
+	SectorErrChain         SectorErrorCategory = "chain"
+	SectorErrNode          SectorErrorCategory = "node"
+	SectorErrUnknown       SectorErrorCategory = "unknown"
+)
+
+type SectorErrorExplanation struct {
+	Category SectorErrorCategory
+	// Rule is the name of the rule which matched the error, empty for the
+	// unknown category
+	Rule        string
+	Summary     string
+	Remediation string
+}
+
+// SectorStatusResult is the status of a sector requested in a batch, Info is
+// nil when Error is set.
+type SectorStatusResult struct {
+	SectorID abi.SectorNumber
+	Info     *SectorInfo
+	Error    string
+}
+
+// SectorRetryPolicy is the retry policy of the class of the last failures of
+// a sector. Class is empty when the sector didn't fail since it last started
+// proving.
+type SectorRetryPolicy struct {
+	SectorID abi.SectorNumber
+	State    SectorState
+
+	Class    sealiface.FailureClass
+	Failures uint64
+	Policy   sealiface.RetryPolicy
+
+	// Backoff is the wait before retrying the last failure, unless Exhausted
+	// is set because the failures aren't retried anymore.
+	Backoff   time.Duration
+	Exhausted bool
+}
+
+// PieceInfoResult is the info of a piece requested in a batch, Info is nil
+// when Error is set.
+type PieceInfoResult struct {
+	PieceCID cid.Cid
+	Info     *piecestore.PieceInfo
+	Error    string
+}
+
 type SealedRef struct {
 	SectorID abi.SectorNumber
 	Offset   abi.PaddedPieceSize
@@ -419,6 +742,213 @@ func (st *SealSeed) Equals(ost *SealSeed) bool {
 	return bytes.Equal(st.Value, ost.Value) && st.Epoch == ost.Epoch
 }
 
+// SectorQuery filters the sectors returned by SectorsQuery, zero fields match
+// all sectors.
+type SectorQuery struct {
+	States []SectorState
+	// DealID matches the sectors containing the deal.
+	DealID *abi.DealID
+	// ExpiresAfter and ExpiresBefore match the sectors whose on-chain expiration
+	// is in the range, sectors which aren't on chain never match them.
+	ExpiresAfter  abi.ChainEpoch
+	ExpiresBefore abi.ChainEpoch
+	// Failed matches the sectors in a failure state.
+	Failed bool
+	// After matches the sectors with a number greater than After, for paging.
+	After abi.SectorNumber
+	Limit int
+}
+
+type SectorQueryResult struct {
+	SectorNumber abi.SectorNumber
+	State        SectorState
+	SectorType   abi.RegisteredSealProof
+	Deals        []abi.DealID
+	// Expiration is the on-chain expiration of the sector, refreshed
+	// periodically, or zero when unknown.
+	Expiration abi.ChainEpoch
+	Failed     bool
+	// LastError is the most recent error event of the sector.
+	LastError string
+	UpdatedAt time.Time
+}
+
+type SubmissionClass string
+
+const (
+	SubmissionPreCommit  SubmissionClass = "precommit"
+	SubmissionCommit     SubmissionClass = "commit"
+	SubmissionWindowPoSt SubmissionClass = "wdpost"
+)
+
+// PendingSubmission describes the pending messages of a class.
+type PendingSubmission struct {
+	Class SubmissionClass
+	// Sectors are the sectors of the pending PreCommit and Commit messages.
+	Sectors []abi.SectorNumber
+	// Deadline is the deadline of the pending WindowPoSt messages.
+	Deadline uint64
+
+	// Deferred is set while the messages are deferred because BaseFee is above
+	// FeeCap.
+	Deferred      bool
+	DeferredSince time.Time
+	BaseFee       abi.TokenAmount
+	FeeCap        abi.TokenAmount
+	// ForceAt is when deferred PreCommit and Commit messages are sent regardless
+	// of the base fee, to meet the cutoff of the sectors.
+	ForceAt time.Time
+	// ForceEpoch is the epoch at which deferred WindowPoSt messages are sent
+	// regardless of the base fee, to land before the deadline closes.
+	ForceEpoch abi.ChainEpoch
+}
+
+// SectorDealMix is how the space of a sector is filled with deals. Deals and
+// VerifiedDeals are the fractions, between 0 and 1, of the sector filled with
+// regular and verified deals; the rest of the sector is committed capacity.
+type SectorDealMix struct {
+	Deals         float64
+	VerifiedDeals float64
+}
+
+// SectorCostEstimate is what it's expected to cost to commit a sector, see
+// SectorsCostEstimate.
+type SectorCostEstimate struct {
+	Height    abi.ChainEpoch
+	SealProof abi.RegisteredSealProof
+	Duration  abi.ChainEpoch
+	QAPower   abi.StoragePower
+	BaseFee   abi.TokenAmount
+
+	PreCommitDeposit abi.TokenAmount
+	InitialPledge    abi.TokenAmount
+
+	// PreCommitFee and CommitFee are the fees of the messages committing the
+	// sector, WindowPoStFee is the share of the sector in the fees of the
+	// WindowPoSt messages over its lifetime, all at the current base fee.
+	PreCommitFee  abi.TokenAmount
+	CommitFee     abi.TokenAmount
+	WindowPoStFee abi.TokenAmount
+	// GasFromStats is set when the gas used by the messages is the average
+	// recorded by the gas statistics of the chain node, rather than a default.
+	GasFromStats bool
+
+	// ExpectedReward is the block reward the sector is expected to earn over
+	// its lifetime.
+	ExpectedReward abi.TokenAmount
+	// TerminationPenalty is the penalty for terminating the sector as soon as
+	// it's active, MaxTerminationPenalty the largest penalty for terminating
+	// it before it expires.
+	TerminationPenalty    abi.TokenAmount
+	MaxTerminationPenalty abi.TokenAmount
+}
+
+type UnsealRegenState string
+
+const (
+	UnsealRegenAwaitingApproval UnsealRegenState = "awaiting-approval"
+	UnsealRegenRejected         UnsealRegenState = "rejected"
+	UnsealRegenQueued           UnsealRegenState = "queued"
+	UnsealRegenRunning          UnsealRegenState = "running"
+	UnsealRegenFailed           UnsealRegenState = "failed"
+)
+
+// UnsealRegenJob is the regeneration of the lost unsealed copy of a sector.
+type UnsealRegenJob struct {
+	Sector abi.SectorNumber
+	// Deals are the active deals of the sector which should be kept unsealed.
+	Deals    []abi.DealID
+	State    UnsealRegenState
+	Approved bool
+	// Attempts counts the unseal tasks scheduled for the sector.
+	Attempts  int
+	LastError string
+	// Since is the time of the last state change.
+	Since time.Time
+}
+
+type DetectedFaultState string
+
+const (
+	// DetectedFaultPending faults are within the grace period, the sealed copy
+	// may come back
+	DetectedFaultPending          DetectedFaultState = "pending"
+	DetectedFaultReported         DetectedFaultState = "reported"
+	DetectedFaultAwaitingApproval DetectedFaultState = "awaiting-approval"
+	DetectedFaultRejected         DetectedFaultState = "rejected"
+	DetectedFaultQueued           DetectedFaultState = "queued"
+	DetectedFaultDeclared         DetectedFaultState = "declared"
+	DetectedFaultFailed           DetectedFaultState = "failed"
+	// DetectedFaultTooLate faults missed the fault declaration cutoff of their
+	// deadline, the sector will be skipped by the WindowPoSt
+	DetectedFaultTooLate DetectedFaultState = "too-late"
+)
+
+// DetectedFault is a live sector without a healthy sealed copy in the storage
+// index.
+type DetectedFault struct {
+	Sector    abi.SectorNumber
+	Deadline  uint64
+	Partition uint64
+	// Open is the epoch at which the next proving window of the deadline opens,
+	// the fault must be declared before Cutoff to apply to it.
+	Open   abi.ChainEpoch
+	Cutoff abi.ChainEpoch
+
+	State    DetectedFaultState
+	Approved bool
+	// Message is the DeclareFaults message, once sent.
+	Message   *cid.Cid
+	LastError string
+	// Detected is the time the sealed copy was found missing.
+	Detected time.Time
+	// Since is the time of the last state change.
+	Since time.Time
+}
+
+// WdPoStDispute is a DisputeWindowedPoSt message which targeted the miner.
+type WdPoStDispute struct {
+	Message cid.Cid
+	From    address.Address
+	// Height and TipSet are of the tipset in which the message was executed.
+	Height    abi.ChainEpoch
+	TipSet    types.TipSetKey
+	Deadline  uint64
+	PoStIndex uint64
+	// Successful is set when the disputed proof was found invalid: the sectors
+	// it proved were marked faulty, and the miner was penalized.
+	Successful bool
+	ExitCode   exitcode.ExitCode
+
+	Detected     time.Time
+	Acknowledged bool
+
+	// Evidence is collected from the chain when the dispute is detected.
+	Evidence      *WdPoStDisputeEvidence
+	EvidenceError string
+}
+
+// WdPoStDisputeEvidence are the WindowPoSt submissions in the proving window
+// of the disputed deadline, and its partitions before the dispute.
+type WdPoStDisputeEvidence struct {
+	Open  abi.ChainEpoch
+	Close abi.ChainEpoch
+
+	Submissions []WdPoStSubmission
+	Partitions  []Partition
+}
+
+// WdPoStSubmission is a SubmitWindowedPoSt message, executed at Height.
+type WdPoStSubmission struct {
+	Message  cid.Cid
+	From     address.Address
+	Height   abi.ChainEpoch
+	ExitCode exitcode.ExitCode
+	// Disputed is set on the submission of the disputed proof.
+	Disputed bool
+	Params   miner.SubmitWindowedPoStParams
+}
+
 type SectorState string
 
 func (s *SectorState) String() string {
@@ -454,6 +984,307 @@ type PendingDealInfo struct {
 	PublishPeriod      time.Duration
 }
 
+// StorageAskSpec describes a storage ask. Prices are in attoFIL per GiB per
+// epoch.
+type StorageAskSpec struct {
+	Price         types.BigInt
+	VerifiedPrice types.BigInt
+	Duration      abi.ChainEpoch
+	MinPieceSize  abi.PaddedPieceSize
+	MaxPieceSize  abi.PaddedPieceSize
+
+	// PriceCurve adjusts the prices by piece size. The point with the largest
+	// MinPieceSize not above the size of a piece applies to it; pieces smaller
+	// than all the points pay the flat prices. The published ask carries the
+	// lowest prices of the curve, higher prices are enforced when deals are
+	// accepted.
+	PriceCurve []AskPricePoint `json:",omitempty"`
+}
+
+// AskPricePoint scales the ask prices for pieces of at least MinPieceSize.
+type AskPricePoint struct {
+	MinPieceSize abi.PaddedPieceSize
+	// Multiplier applies to the price of unverified deals
+	Multiplier float64
+	// VerifiedMultiplier applies to the price of verified deals
+	VerifiedMultiplier float64
+}
+
+// ScheduledStorageAsk is a storage ask taking effect at a future epoch.
+type ScheduledStorageAsk struct {
+	Epoch abi.ChainEpoch
+	Ask   StorageAskSpec
+}
+
+// StorageAskChangeAction is the kind of a storage ask change.
+type StorageAskChangeAction string
+
+const (
+	// AskChangeSet is an ask set through the API, taking effect immediately
+	AskChangeSet StorageAskChangeAction = "set"
+	// AskChangeScheduled is an ask scheduled for a future epoch
+	AskChangeScheduled StorageAskChangeAction = "scheduled"
+	// AskChangeCancelled is the cancellation of a scheduled ask
+	AskChangeCancelled StorageAskChangeAction = "cancelled"
+	// AskChangeApplied is a scheduled ask which took effect
+	AskChangeApplied StorageAskChangeAction = "applied"
+)
+
+// StorageAskChange is an entry of the storage ask audit log.
+type StorageAskChange struct {
+	Time   time.Time
+	Action StorageAskChangeAction
+	// Epoch is the chain height when the change happened
+	Epoch abi.ChainEpoch
+	// ScheduledEpoch is the epoch of the scheduled ask the change refers to,
+	// zero for asks set immediately
+	ScheduledEpoch abi.ChainEpoch `json:",omitempty"`
+	Ask            StorageAskSpec
+}
+
+// RetrievabilitySample is the result of reading a piece of a deal sector
+// through the DAG store and verifying the hashes of its blocks.
+type RetrievabilitySample struct {
+	Time     time.Time
+	PieceCID cid.Cid
+	Sector   abi.SectorNumber
+	Deal     abi.DealID
+	// Unsealed is whether the piece had an unsealed copy before it was read
+	Unsealed bool
+	// Blocks is the number of blocks read and verified
+	Blocks   int
+	Bytes    int64
+	Duration time.Duration
+	OK       bool
+	Error    string
+}
+
+// RetrievalStats summarizes retrievals served by the retrieval provider
+type RetrievalStats struct {
+	// Since is the time at which statistics collection started
+	Since time.Time
+
+	TotalRetrievals  uint64
+	TotalBytesServed uint64
+
+	// TopPieces and TopClients are ordered by the number of bytes served
+	TopPieces  []RetrievalPieceStats
+	TopClients []RetrievalClientStats
+
+	// Buckets holds retrieval activity in hourly buckets, oldest first
+	Buckets []RetrievalStatsBucket
+}
+
+type RetrievalPieceStats struct {
+	PieceCID      cid.Cid
+	Retrievals    uint64
+	BytesServed   uint64
+	LastRetrieval time.Time
+}
+
+type RetrievalClientStats struct {
+	Client        peer.ID
+	Retrievals    uint64
+	BytesServed   uint64
+	LastRetrieval time.Time
+}
+
+type RetrievalStatsBucket struct {
+	Start       time.Time
+	Retrievals  uint64
+	BytesServed uint64
+}
+
+// RetrievalTimeline is the timeline of a retrieval served by the retrieval
+// provider
+type RetrievalTimeline struct {
+	Receiver   peer.ID
+	DealID     retrievalmarket.DealID
+	PayloadCID cid.Cid
+	PieceCID   *cid.Cid
+
+	Start time.Time
+	// End is zero while the retrieval is ongoing
+	End time.Time
+
+	Status    retrievalmarket.DealStatus
+	Message   string
+	BytesSent uint64
+
+	Events []RetrievalTimelineEvent
+	// Samples of the throughput of the transfer, their interval grows with
+	// the duration of the transfer
+	Samples []RetrievalThroughputSample
+}
+
+type RetrievalTimelineEvent struct {
+	Time  time.Time
+	Event RetrievalTimelineEventType
+	// Message is the error of the failed retrievals
+	Message string `json:",omitempty"`
+}
+
+type RetrievalTimelineEventType string
+
+const (
+	RetrievalAccepted         RetrievalTimelineEventType = "accepted"
+	RetrievalUnsealingStarted RetrievalTimelineEventType = "unsealing-started"
+	RetrievalUnsealed         RetrievalTimelineEventType = "unsealed"
+	RetrievalFirstByte        RetrievalTimelineEventType = "first-byte"
+	RetrievalCompleted        RetrievalTimelineEventType = "completed"
+	RetrievalFailed           RetrievalTimelineEventType = "failed"
+	RetrievalCancelled        RetrievalTimelineEventType = "cancelled"
+)
+
+type RetrievalThroughputSample struct {
+	Time      time.Time
+	BytesSent uint64
+	// BytesPerSecond is the throughput since the previous sample
+	BytesPerSecond uint64
+}
+
+// DealSLAReport compares the time the storage deals took from the receipt of
+// their data to their sector being Proving with the sealing SLA
+type DealSLAReport struct {
+	SLA time.Duration
+	// Deals are sorted by state, breaches first, then by the time their data
+	// was received
+	Deals []DealSLAStatus
+
+	Breached int
+	AtRisk   int
+	Sealing  int
+	Failed   int
+	Met      int
+}
+
+type DealSLAStatus struct {
+	ProposalCid  cid.Cid
+	DealID       abi.DealID
+	Client       address.Address
+	PieceCid     cid.Cid
+	SectorNumber abi.SectorNumber
+
+	DataReceived time.Time
+	// Proving is when the sector holding the deal reached Proving, zero while
+	// it is sealing. When the sealing pipeline runs in another process, it is
+	// when the deal was activated.
+	Proving time.Time
+	// Elapsed is the time from the receipt of the data to Proving, or to now
+	// for the deals still sealing
+	Elapsed time.Duration
+
+	State DealSLAState
+	// Message is the error of the failed deals
+	Message string `json:",omitempty"`
+}
+
+type DealSLAState string
+
+const (
+	DealSLABreached DealSLAState = "breached"
+	DealSLAAtRisk   DealSLAState = "at-risk"
+	DealSLASealing  DealSLAState = "sealing"
+	DealSLAFailed   DealSLAState = "failed"
+	DealSLAMet      DealSLAState = "met"
+)
+
+// DataTransferRestartHistory lists the automatic restarts of a stalled data
+// transfer
+type DataTransferRestartHistory struct {
+	ChannelID datatransfer.ChannelID
+	Status    datatransfer.Status
+	// LastProgress is the last time data was sent, received or queued on
+	// the transfer
+	LastProgress time.Time
+	// NextRestart is when the transfer will be restarted if it doesn't make
+	// progress, zero if no restart is pending
+	NextRestart time.Time
+	// GaveUp is set once the transfer was restarted the maximum number of
+	// times without progress
+	GaveUp   bool
+	Finished bool
+	Restarts []DataTransferRestart
+}
+
+type DataTransferRestart struct {
+	Time time.Time
+	// StalledFor is how long the transfer made no progress before the restart
+	StalledFor time.Duration
+	// Error is set when the restart failed
+	Error string
+}
+
+// DealTransferStatus is the progress of the data transfer of a storage deal
+type DealTransferStatus struct {
+	ProposalCid cid.Cid
+	ChannelID   datatransfer.ChannelID
+	Status      datatransfer.Status
+	Started     time.Time
+	// LastProgress is the last time data was received
+	LastProgress time.Time
+
+	BytesReceived uint64
+	// BlocksVerified is the number of blocks received and verified against
+	// their CID
+	BlocksVerified int64
+	// Throughput is the average number of bytes received per second over the
+	// last minute
+	Throughput uint64
+	// Stalled is set when no data was received for the stall timeout, and
+	// the transfer isn't paused
+	Stalled  bool
+	Finished bool
+}
+
+// CommPDiagnostics describes the data of a deal which didn't match the piece
+// CID of the proposal
+type CommPDiagnostics struct {
+	ProposalCid cid.Cid
+	Time        time.Time
+	// Offline is set for the data imported for offline deals
+	Offline bool
+
+	ProposalPieceCid  cid.Cid
+	ProposalPieceSize abi.PaddedPieceSize
+	PayloadCid        cid.Cid
+
+	// DataSize is the size of the data the piece CID is computed over: the
+	// CARv1 payload for online deals, the imported file for offline deals
+	DataSize uint64
+	// CalculatedPieceCid is the piece CID of the data padded to the piece
+	// size of the proposal
+	CalculatedPieceCid cid.Cid
+	// NaturalPieceCid is the piece CID of the data padded to the smallest
+	// piece holding it, of NaturalPieceSize
+	NaturalPieceCid  cid.Cid
+	NaturalPieceSize abi.PaddedPieceSize
+	// PayloadPieceCid is the piece CID of the CARv1 payload of an imported
+	// CARv2 file
+	PayloadPieceCid *cid.Cid `json:",omitempty"`
+
+	CarVersion uint64
+	CarRoots   []cid.Cid
+	Blocks     uint64
+	FirstBlock *CommPBlock `json:",omitempty"`
+	LastBlock  *CommPBlock `json:",omitempty"`
+	// TrailingBytes is the size of the data after the last block
+	TrailingBytes uint64
+
+	// Findings are the likely causes of the mismatch
+	Findings []string
+	// Error is set when the data couldn't be fully analysed
+	Error string `json:",omitempty"`
+}
+
+type CommPBlock struct {
+	Cid cid.Cid
+	// Offset is the offset of the block section in the CARv1 payload, Size
+	// is the size of the block data
+	Offset uint64
+	Size   uint64
+}
+
 type SectorOffset struct {
 	Sector abi.SectorNumber
 	Offset abi.PaddedPieceSize
@@ -485,6 +1316,65 @@ type DagstoreShardInfo struct {
 	Error string
 }
 
+// DagstoreShardDiagnostics is the state of a shard and of the resources
+// backing it.
+type DagstoreShardDiagnostics struct {
+	Key   string
+	State string
+	Error string
+
+	Registration DagstoreShardRegistration
+	Mount        DagstoreShardMount
+	Index        DagstoreShardIndex
+	Transient    DagstoreShardTransient
+	// Traces are the last operations on the shard, oldest first
+	Traces []DagstoreShardTrace
+}
+
+// DagstoreShardRegistration is the shard as persisted by the DAG store.
+type DagstoreShardRegistration struct {
+	// Persisted is false when the shard isn't registered
+	Persisted     bool
+	MountURL      string
+	TransientPath string
+	Lazy          bool
+	State         string
+	Error         string
+}
+
+// DagstoreShardMount is the mount of a shard resolved from its URL.
+type DagstoreShardMount struct {
+	Resolved bool
+	Exists   bool
+	Size     int64
+	Ready    bool
+	Error    string
+}
+
+// DagstoreShardIndex is the full index of a shard.
+type DagstoreShardIndex struct {
+	Exists bool
+	Size   uint64
+	Error  string
+}
+
+// DagstoreShardTransient is the local copy of the data of a shard.
+type DagstoreShardTransient struct {
+	Path    string
+	Exists  bool
+	Size    int64
+	ModTime time.Time
+	Error   string
+}
+
+// DagstoreShardTrace is an operation on a shard.
+type DagstoreShardTrace struct {
+	Time  time.Time
+	Op    string
+	State string
+	Error string
+}
+
 // DagstoreShardResult enumerates results per shard.
 type DagstoreShardResult struct {
 	Key     string
@@ -517,6 +1407,13 @@ type NumAssignerMeta struct {
 	Next abi.SectorNumber
 }
 
+// SectorReceiveResult is the outcome of importing one sector with SectorReceiveBatch
+type SectorReceiveResult struct {
+	Sector abi.SectorNumber
+	// Error is empty when the sector was imported
+	Error string
+}
+
 type RemoteSectorMeta struct {
 	////////
 	// BASIC SECTOR INFORMATION
@@ -622,3 +1519,312 @@ type RemoteSealingDoneParams struct {
 	// Optional commit message CID
 	CommitMessage *cid.Cid
 }
+
+// DeadlineLoad is the WindowPoSt load of a deadline.
+type DeadlineLoad struct {
+	Index      uint64
+	Partitions int
+	// TotalSectors includes the terminated sectors which are still in the
+	// partitions until they are compacted.
+	TotalSectors  uint64
+	LiveSectors   uint64
+	ActiveSectors uint64
+	FaultySectors uint64
+	// Mutable is set when new sectors can be assigned to the deadline now.
+	Mutable bool
+	// Compactable is set when the partitions of the deadline can be compacted
+	// now.
+	Compactable bool
+}
+
+type DeadlineLoadReport struct {
+	Height           abi.ChainEpoch
+	CurrentDeadline  uint64
+	PartitionSectors uint64
+	Deadlines        []DeadlineLoad
+
+	MinLiveSectors  uint64
+	MaxLiveSectors  uint64
+	MeanLiveSectors float64
+	MaxPartitions   int
+	// Imbalance is the spread of live sectors across the deadlines relative to
+	// the mean, (max - min) / mean; 0 when the deadlines are even.
+	Imbalance float64
+}
+
+// DeadlineAssignment is the number of new sectors assigned to a deadline.
+type DeadlineAssignment struct {
+	Deadline uint64
+	Sectors  uint64
+}
+
+// DeadlineCompaction is the compaction of the partitions of a deadline
+// holding terminated sectors.
+type DeadlineCompaction struct {
+	Deadline   uint64
+	Partitions []uint64
+	// SavedPartitions is the number of partitions the compaction removes.
+	SavedPartitions int
+	Compactable     bool
+}
+
+type DeadlineRebalancePlan struct {
+	NewSectors uint64
+	// Assignments are the deadlines the miner actor would assign the new
+	// sectors to if they were committed now.
+	Assignments []DeadlineAssignment
+	Compactions []DeadlineCompaction
+
+	ImbalanceBefore     float64
+	ImbalanceAfter      float64
+	MaxPartitionsBefore int
+	MaxPartitionsAfter  int
+}
+
+// Statuses of a won election in the block production journal.
+const (
+	// MinedBlockFailed means that no block was submitted for the election
+	MinedBlockFailed = "failed"
+	// MinedBlockPending means that the chain hasn't reached the block's epoch yet
+	MinedBlockPending  = "pending"
+	MinedBlockIncluded = "included"
+	// MinedBlockOrphaned means that the tipset at the block's epoch doesn't
+	// include the block
+	MinedBlockOrphaned = "orphaned"
+)
+
+type MinedBlockRecord struct {
+	Epoch      abi.ChainEpoch
+	Parents    types.TipSetKey
+	NullRounds abi.ChainEpoch
+	WinCount   int64
+	Timestamp  time.Time
+
+	// Block is the produced block, if any
+	Block    *cid.Cid
+	Messages int
+	Error    string
+
+	// BlockReward is the reward for the election, GasReward the premiums of
+	// the block's messages, and PenaltyRisk the penalty the miner would pay if
+	// the messages with a fee cap below the base fee used all their gas. Gas
+	// rewards of messages also included by other blocks of the tipset go to the
+	// miner of the first block including them.
+	BlockReward abi.TokenAmount
+	GasReward   abi.TokenAmount
+	PenaltyRisk abi.TokenAmount
+
+	Status string
+	// Final is set once the block's status can't change anymore
+	Final bool
+}
+
+type MinerBlocksReport struct {
+	From, To abi.ChainEpoch
+
+	Won      int
+	Produced int
+	Included int
+	Orphaned int
+
+	// Rewards of the included blocks
+	BlockRewards abi.TokenAmount
+	GasRewards   abi.TokenAmount
+	PenaltyRisk  abi.TokenAmount
+
+	Blocks []MinedBlockRecord
+}
+
+// SpendTotal is the gas spent by the messages of a subsystem of the miner.
+type SpendTotal struct {
+	Subsystem string
+	Messages  int
+	GasUsed   int64
+
+	BaseFeeBurn        abi.TokenAmount
+	OverEstimationBurn abi.TokenAmount
+	MinerTip           abi.TokenAmount
+	TotalCost          abi.TokenAmount
+}
+
+type SpendBucket struct {
+	// Start is the first epoch of the bucket
+	Start  abi.ChainEpoch
+	Totals []SpendTotal
+}
+
+type SpendReport struct {
+	From, To abi.ChainEpoch
+	Bucket   abi.ChainEpoch
+
+	Totals  []SpendTotal
+	Buckets []SpendBucket
+
+	// Pending is the number of messages sent which aren't executed yet
+	Pending int
+}
+
+// AddressRotation is a change of the owner, worker and control addresses of a
+// miner. Undef addresses and nil control addresses are left unchanged, an
+// empty control address list removes all control addresses.
+type AddressRotation struct {
+	NewOwner   address.Address
+	NewWorker  address.Address
+	NewControl []address.Address
+}
+
+type AddressRotationStepKind string
+
+const (
+	// RotationChangeWorker sets the control addresses, and proposes the new
+	// worker which becomes effective after the worker change delay.
+	RotationChangeWorker AddressRotationStepKind = "change-worker"
+	// RotationConfirmWorker makes the proposed worker effective.
+	RotationConfirmWorker AddressRotationStepKind = "confirm-worker"
+	// RotationProposeOwner is sent by the owner to nominate the new owner.
+	RotationProposeOwner AddressRotationStepKind = "propose-owner"
+	// RotationConfirmOwner is sent by the new owner to accept the nomination.
+	RotationConfirmOwner AddressRotationStepKind = "confirm-owner"
+)
+
+type AddressRotationStepState string
+
+const (
+	RotationStepDone AddressRotationStepState = "done"
+	// RotationStepReady steps can be sent now.
+	RotationStepReady AddressRotationStepState = "ready"
+	// RotationStepPending steps have a message in the mpool, or a multisig
+	// proposal waiting for approvals.
+	RotationStepPending AddressRotationStepState = "pending"
+	// RotationStepWaiting steps wait for the previous steps, or for the worker
+	// change epoch.
+	RotationStepWaiting AddressRotationStepState = "waiting"
+	// RotationStepBlocked steps have failed checks.
+	RotationStepBlocked AddressRotationStepState = "blocked"
+)
+
+// AddressRotationCheck is a dry-run check of a rotation or one of its steps.
+type AddressRotationCheck struct {
+	Name string
+	OK   bool
+	// Warning is set on the checks which don't block the rotation when failed
+	Warning bool
+	Detail  string
+}
+
+type AddressRotationStep struct {
+	Index int
+	Kind  AddressRotationStepKind
+	State AddressRotationStepState
+
+	// From is the address the miner actor requires to send the message. When
+	// it is a multisig, the message is proposed by one of its signers.
+	From     address.Address
+	Multisig bool
+	Method   abi.MethodNum
+	Params   []byte
+	// NotBefore is the first epoch at which the step can be executed
+	NotBefore abi.ChainEpoch
+
+	// PendingMessages are the messages of the step in the mpool
+	PendingMessages []cid.Cid
+	// MsigTxID is the pending proposal of the step on the From multisig, -1
+	// when there is none
+	MsigTxID      int64
+	MsigApprovals int
+	MsigThreshold uint64
+
+	// EstimatedFee is the maximum fee of the step when it is ready
+	EstimatedFee abi.TokenAmount
+
+	Checks      []AddressRotationCheck
+	Description string
+	// Rollback describes how to revert the step once it is executed
+	Rollback string
+}
+
+type AddressRotationPlan struct {
+	Miner  address.Address
+	Height abi.ChainEpoch
+
+	Owner        address.Address
+	Worker       address.Address
+	Control      []address.Address
+	PendingOwner address.Address
+	// PendingWorker is the worker proposed on chain, effective at
+	// WorkerChangeEpoch
+	PendingWorker     address.Address
+	WorkerChangeEpoch abi.ChainEpoch
+
+	// Target is the rotation with its addresses resolved to ID addresses
+	Target AddressRotation
+	Checks []AddressRotationCheck
+	Steps  []AddressRotationStep
+}
+
+// Next returns the first step of the plan which isn't done, nil when the
+// rotation is complete.
+func (p *AddressRotationPlan) Next() *AddressRotationStep {
+	for i := range p.Steps {
+		if p.Steps[i].State != RotationStepDone {
+			return &p.Steps[i]
+		}
+	}
+	return nil
+}
+
+// AddressBalance is the balance of a worker or control address of the miner.
+type AddressBalance struct {
+	Address address.Address
+	// Roles are the uses of the address: worker, control, or the messages it
+	// is configured to send (precommit, commit, terminate, dealpublish)
+	Roles   []string
+	Balance abi.TokenAmount
+	// Low is set when the balance is under the low-water mark
+	Low bool
+	// RefillPending is the refill message sent to the address which isn't
+	// executed yet
+	RefillPending cid.Cid
+}
+
+// AddressRefill is a transfer from the owner address to a worker or control
+// address sent by the balance monitor.
+type AddressRefill struct {
+	To       address.Address
+	Amount   abi.TokenAmount
+	Message  cid.Cid
+	Time     time.Time
+	Executed bool
+}
+
+type AddressBalanceStatus struct {
+	// Checked is the time of the last check, zero until the first one
+	Checked time.Time
+	// Error is the error of the last check, if it failed
+	Error string
+
+	Owner          address.Address
+	LowWater       abi.TokenAmount
+	RefillTo       abi.TokenAmount
+	DailyRefillCap abi.TokenAmount
+	// Refilled is the amount sent by the refills of the last 24 hours
+	Refilled abi.TokenAmount
+
+	Addresses []AddressBalance
+	Refills   []AddressRefill
+}
+
+// MarketDealFilter selects deals by their labels, and optionally by their
+// piece. A label with an empty value matches the deals with the label,
+// whatever its value.
+type MarketDealFilter struct {
+	Labels   map[string]string
+	PieceCID *cid.Cid
+}
+
+type LabeledMarketDeal struct {
+	DealID      abi.DealID
+	ProposalCID cid.Cid
+	MarketDeal
+	Labels map[string]string
+}
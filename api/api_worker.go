@@ -26,6 +26,9 @@ import (
 
 type Worker interface {
 	Version(context.Context) (Version, error) //perm:admin
+	// Capabilities reports the API versions served by the worker, and the
+	// deprecated methods of its API
+	Capabilities(context.Context) (APICapabilities, error) //perm:admin
 
 	// TaskType -> Weight
 	TaskTypes(context.Context) (map[sealtasks.TaskType]struct{}, error) //perm:admin
@@ -8,13 +8,13 @@ import (
 	"math"
 	"sort"
 
-	cid "github.com/ipfs/go-cid"
-	cbg "github.com/whyrusleeping/cbor-gen"
-	xerrors "golang.org/x/xerrors"
-
 	abi "github.com/filecoin-project/go-state-types/abi"
 	paych "github.com/filecoin-project/go-state-types/builtin/v8/paych"
 	market "github.com/filecoin-project/go-state-types/builtin/v9/market"
+	exitcode "github.com/filecoin-project/go-state-types/exitcode"
+	cid "github.com/ipfs/go-cid"
+	cbg "github.com/whyrusleeping/cbor-gen"
+	xerrors "golang.org/x/xerrors"
 )
 
 var _ = xerrors.Errorf
@@ -1287,3 +1287,489 @@ func (t *DealSchedule) UnmarshalCBOR(r io.Reader) (err error) {
 
 	return nil
 }
+func (t *MessageAttestation) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+
+	cw := cbg.NewCborWriter(w)
+
+	if _, err := cw.Write([]byte{170}); err != nil {
+		return err
+	}
+
+	// t.Final (bool) (bool)
+	if len("Final") > cbg.MaxLength {
+		return xerrors.Errorf("Value in field \"Final\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("Final"))); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, string("Final")); err != nil {
+		return err
+	}
+
+	if err := cbg.WriteBool(w, t.Final); err != nil {
+		return err
+	}
+
+	// t.GasUsed (int64) (int64)
+	if len("GasUsed") > cbg.MaxLength {
+		return xerrors.Errorf("Value in field \"GasUsed\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("GasUsed"))); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, string("GasUsed")); err != nil {
+		return err
+	}
+
+	if t.GasUsed >= 0 {
+		if err := cw.WriteMajorTypeHeader(cbg.MajUnsignedInt, uint64(t.GasUsed)); err != nil {
+			return err
+		}
+	} else {
+		if err := cw.WriteMajorTypeHeader(cbg.MajNegativeInt, uint64(-t.GasUsed-1)); err != nil {
+			return err
+		}
+	}
+
+	// t.Message (cid.Cid) (struct)
+	if len("Message") > cbg.MaxLength {
+		return xerrors.Errorf("Value in field \"Message\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("Message"))); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, string("Message")); err != nil {
+		return err
+	}
+
+	if err := cbg.WriteCid(cw, t.Message); err != nil {
+		return xerrors.Errorf("failed to write cid field t.Message: %w", err)
+	}
+
+	// t.Network (string) (string)
+	if len("Network") > cbg.MaxLength {
+		return xerrors.Errorf("Value in field \"Network\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("Network"))); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, string("Network")); err != nil {
+		return err
+	}
+
+	if len(t.Network) > cbg.MaxLength {
+		return xerrors.Errorf("Value in field t.Network was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Network))); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, string(t.Network)); err != nil {
+		return err
+	}
+
+	// t.ExitCode (exitcode.ExitCode) (int64)
+	if len("ExitCode") > cbg.MaxLength {
+		return xerrors.Errorf("Value in field \"ExitCode\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("ExitCode"))); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, string("ExitCode")); err != nil {
+		return err
+	}
+
+	if t.ExitCode >= 0 {
+		if err := cw.WriteMajorTypeHeader(cbg.MajUnsignedInt, uint64(t.ExitCode)); err != nil {
+			return err
+		}
+	} else {
+		if err := cw.WriteMajorTypeHeader(cbg.MajNegativeInt, uint64(-t.ExitCode-1)); err != nil {
+			return err
+		}
+	}
+
+	// t.ReceiptsRoot (cid.Cid) (struct)
+	if len("ReceiptsRoot") > cbg.MaxLength {
+		return xerrors.Errorf("Value in field \"ReceiptsRoot\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("ReceiptsRoot"))); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, string("ReceiptsRoot")); err != nil {
+		return err
+	}
+
+	if err := cbg.WriteCid(cw, t.ReceiptsRoot); err != nil {
+		return xerrors.Errorf("failed to write cid field t.ReceiptsRoot: %w", err)
+	}
+
+	// t.AttestedHeight (abi.ChainEpoch) (int64)
+	if len("AttestedHeight") > cbg.MaxLength {
+		return xerrors.Errorf("Value in field \"AttestedHeight\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("AttestedHeight"))); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, string("AttestedHeight")); err != nil {
+		return err
+	}
+
+	if t.AttestedHeight >= 0 {
+		if err := cw.WriteMajorTypeHeader(cbg.MajUnsignedInt, uint64(t.AttestedHeight)); err != nil {
+			return err
+		}
+	} else {
+		if err := cw.WriteMajorTypeHeader(cbg.MajNegativeInt, uint64(-t.AttestedHeight-1)); err != nil {
+			return err
+		}
+	}
+
+	// t.ExecutionTipSet ([]cid.Cid) (slice)
+	if len("ExecutionTipSet") > cbg.MaxLength {
+		return xerrors.Errorf("Value in field \"ExecutionTipSet\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("ExecutionTipSet"))); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, string("ExecutionTipSet")); err != nil {
+		return err
+	}
+
+	if len(t.ExecutionTipSet) > cbg.MaxLength {
+		return xerrors.Errorf("Slice value in field t.ExecutionTipSet was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajArray, uint64(len(t.ExecutionTipSet))); err != nil {
+		return err
+	}
+	for _, v := range t.ExecutionTipSet {
+		if err := cbg.WriteCid(w, v); err != nil {
+			return xerrors.Errorf("failed writing cid field t.ExecutionTipSet: %w", err)
+		}
+	}
+
+	// t.InclusionHeight (abi.ChainEpoch) (int64)
+	if len("InclusionHeight") > cbg.MaxLength {
+		return xerrors.Errorf("Value in field \"InclusionHeight\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("InclusionHeight"))); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, string("InclusionHeight")); err != nil {
+		return err
+	}
+
+	if t.InclusionHeight >= 0 {
+		if err := cw.WriteMajorTypeHeader(cbg.MajUnsignedInt, uint64(t.InclusionHeight)); err != nil {
+			return err
+		}
+	} else {
+		if err := cw.WriteMajorTypeHeader(cbg.MajNegativeInt, uint64(-t.InclusionHeight-1)); err != nil {
+			return err
+		}
+	}
+
+	// t.InclusionTipSet ([]cid.Cid) (slice)
+	if len("InclusionTipSet") > cbg.MaxLength {
+		return xerrors.Errorf("Value in field \"InclusionTipSet\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("InclusionTipSet"))); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, string("InclusionTipSet")); err != nil {
+		return err
+	}
+
+	if len(t.InclusionTipSet) > cbg.MaxLength {
+		return xerrors.Errorf("Slice value in field t.InclusionTipSet was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajArray, uint64(len(t.InclusionTipSet))); err != nil {
+		return err
+	}
+	for _, v := range t.InclusionTipSet {
+		if err := cbg.WriteCid(w, v); err != nil {
+			return xerrors.Errorf("failed writing cid field t.InclusionTipSet: %w", err)
+		}
+	}
+	return nil
+}
+
+func (t *MessageAttestation) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = MessageAttestation{}
+
+	cr := cbg.NewCborReader(r)
+
+	maj, extra, err := cr.ReadHeader()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+	}()
+
+	if maj != cbg.MajMap {
+		return fmt.Errorf("cbor input should be of type map")
+	}
+
+	if extra > cbg.MaxLength {
+		return fmt.Errorf("MessageAttestation: map struct too large (%d)", extra)
+	}
+
+	var name string
+	n := extra
+
+	for i := uint64(0); i < n; i++ {
+
+		{
+			sval, err := cbg.ReadString(cr)
+			if err != nil {
+				return err
+			}
+
+			name = string(sval)
+		}
+
+		switch name {
+		// t.Final (bool) (bool)
+		case "Final":
+
+			maj, extra, err = cr.ReadHeader()
+			if err != nil {
+				return err
+			}
+			if maj != cbg.MajOther {
+				return fmt.Errorf("booleans must be major type 7")
+			}
+			switch extra {
+			case 20:
+				t.Final = false
+			case 21:
+				t.Final = true
+			default:
+				return fmt.Errorf("booleans are either major type 7, value 20 or 21 (got %d)", extra)
+			}
+			// t.GasUsed (int64) (int64)
+		case "GasUsed":
+			{
+				maj, extra, err := cr.ReadHeader()
+				var extraI int64
+				if err != nil {
+					return err
+				}
+				switch maj {
+				case cbg.MajUnsignedInt:
+					extraI = int64(extra)
+					if extraI < 0 {
+						return fmt.Errorf("int64 positive overflow")
+					}
+				case cbg.MajNegativeInt:
+					extraI = int64(extra)
+					if extraI < 0 {
+						return fmt.Errorf("int64 negative overflow")
+					}
+					extraI = -1 - extraI
+				default:
+					return fmt.Errorf("wrong type for int64 field: %d", maj)
+				}
+
+				t.GasUsed = int64(extraI)
+			}
+			// t.Message (cid.Cid) (struct)
+		case "Message":
+
+			{
+
+				c, err := cbg.ReadCid(cr)
+				if err != nil {
+					return xerrors.Errorf("failed to read cid field t.Message: %w", err)
+				}
+
+				t.Message = c
+
+			}
+			// t.Network (string) (string)
+		case "Network":
+
+			{
+				sval, err := cbg.ReadString(cr)
+				if err != nil {
+					return err
+				}
+
+				t.Network = string(sval)
+			}
+			// t.ExitCode (exitcode.ExitCode) (int64)
+		case "ExitCode":
+			{
+				maj, extra, err := cr.ReadHeader()
+				var extraI int64
+				if err != nil {
+					return err
+				}
+				switch maj {
+				case cbg.MajUnsignedInt:
+					extraI = int64(extra)
+					if extraI < 0 {
+						return fmt.Errorf("int64 positive overflow")
+					}
+				case cbg.MajNegativeInt:
+					extraI = int64(extra)
+					if extraI < 0 {
+						return fmt.Errorf("int64 negative overflow")
+					}
+					extraI = -1 - extraI
+				default:
+					return fmt.Errorf("wrong type for int64 field: %d", maj)
+				}
+
+				t.ExitCode = exitcode.ExitCode(extraI)
+			}
+			// t.ReceiptsRoot (cid.Cid) (struct)
+		case "ReceiptsRoot":
+
+			{
+
+				c, err := cbg.ReadCid(cr)
+				if err != nil {
+					return xerrors.Errorf("failed to read cid field t.ReceiptsRoot: %w", err)
+				}
+
+				t.ReceiptsRoot = c
+
+			}
+			// t.AttestedHeight (abi.ChainEpoch) (int64)
+		case "AttestedHeight":
+			{
+				maj, extra, err := cr.ReadHeader()
+				var extraI int64
+				if err != nil {
+					return err
+				}
+				switch maj {
+				case cbg.MajUnsignedInt:
+					extraI = int64(extra)
+					if extraI < 0 {
+						return fmt.Errorf("int64 positive overflow")
+					}
+				case cbg.MajNegativeInt:
+					extraI = int64(extra)
+					if extraI < 0 {
+						return fmt.Errorf("int64 negative overflow")
+					}
+					extraI = -1 - extraI
+				default:
+					return fmt.Errorf("wrong type for int64 field: %d", maj)
+				}
+
+				t.AttestedHeight = abi.ChainEpoch(extraI)
+			}
+			// t.ExecutionTipSet ([]cid.Cid) (slice)
+		case "ExecutionTipSet":
+
+			maj, extra, err = cr.ReadHeader()
+			if err != nil {
+				return err
+			}
+
+			if extra > cbg.MaxLength {
+				return fmt.Errorf("t.ExecutionTipSet: array too large (%d)", extra)
+			}
+
+			if maj != cbg.MajArray {
+				return fmt.Errorf("expected cbor array")
+			}
+
+			if extra > 0 {
+				t.ExecutionTipSet = make([]cid.Cid, extra)
+			}
+
+			for i := 0; i < int(extra); i++ {
+
+				c, err := cbg.ReadCid(cr)
+				if err != nil {
+					return xerrors.Errorf("reading cid field t.ExecutionTipSet failed: %w", err)
+				}
+				t.ExecutionTipSet[i] = c
+			}
+
+			// t.InclusionHeight (abi.ChainEpoch) (int64)
+		case "InclusionHeight":
+			{
+				maj, extra, err := cr.ReadHeader()
+				var extraI int64
+				if err != nil {
+					return err
+				}
+				switch maj {
+				case cbg.MajUnsignedInt:
+					extraI = int64(extra)
+					if extraI < 0 {
+						return fmt.Errorf("int64 positive overflow")
+					}
+				case cbg.MajNegativeInt:
+					extraI = int64(extra)
+					if extraI < 0 {
+						return fmt.Errorf("int64 negative overflow")
+					}
+					extraI = -1 - extraI
+				default:
+					return fmt.Errorf("wrong type for int64 field: %d", maj)
+				}
+
+				t.InclusionHeight = abi.ChainEpoch(extraI)
+			}
+			// t.InclusionTipSet ([]cid.Cid) (slice)
+		case "InclusionTipSet":
+
+			maj, extra, err = cr.ReadHeader()
+			if err != nil {
+				return err
+			}
+
+			if extra > cbg.MaxLength {
+				return fmt.Errorf("t.InclusionTipSet: array too large (%d)", extra)
+			}
+
+			if maj != cbg.MajArray {
+				return fmt.Errorf("expected cbor array")
+			}
+
+			if extra > 0 {
+				t.InclusionTipSet = make([]cid.Cid, extra)
+			}
+
+			for i := 0; i < int(extra); i++ {
+
+				c, err := cbg.ReadCid(cr)
+				if err != nil {
+					return xerrors.Errorf("reading cid field t.InclusionTipSet failed: %w", err)
+				}
+				t.InclusionTipSet[i] = c
+			}
+
+		default:
+			// Field doesn't exist on this type, so ignore it
+			cbg.ScanForLinks(r, func(cid.Cid) {})
+		}
+	}
+
+	return nil
+}
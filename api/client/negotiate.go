@@ -0,0 +1,114 @@
+package client
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+var log = logging.Logger("apiclient")
+
+// NegotiateCommon negotiates the API version with a full node or a miner, the
+// client being built for the expected version. See CheckCompatible.
+func NegotiateCommon(ctx context.Context, c api.Common, expected api.Version) (api.APICapabilities, error) {
+	return negotiate(ctx, c.Capabilities, func(ctx context.Context) (api.Version, error) {
+		v, err := c.Version(ctx)
+		return v.APIVersion, err
+	}, expected)
+}
+
+// NegotiateWorker negotiates the API version with a worker, the client being
+// built for the expected version. See CheckCompatible.
+func NegotiateWorker(ctx context.Context, w api.Worker, expected api.Version) (api.APICapabilities, error) {
+	return negotiate(ctx, w.Capabilities, w.Version, expected)
+}
+
+func negotiate(ctx context.Context, capabilities func(context.Context) (api.APICapabilities, error), version func(context.Context) (api.Version, error), expected api.Version) (api.APICapabilities, error) {
+	caps, err := capabilities(ctx)
+	if err != nil {
+		if !isMethodNotFound(err) {
+			return api.APICapabilities{}, xerrors.Errorf("getting remote API capabilities: %w", err)
+		}
+
+		// the remote predates the capabilities handshake, it only reports its
+		// version
+		v, err := version(ctx)
+		if err != nil {
+			return api.APICapabilities{}, xerrors.Errorf("getting remote API version: %w", err)
+		}
+		caps = api.APICapabilities{APIVersion: v}
+	}
+
+	return caps, CheckCompatible(caps, expected)
+}
+
+func isMethodNotFound(err error) bool {
+	// go-jsonrpc doesn't export its error codes
+	return strings.Contains(err.Error(), "RPC error (-32601)")
+}
+
+// CheckCompatible checks that a client built for the expected API version can
+// talk to the remote. The remote has to serve the same major version, and at
+// least the minor version the client was built for. Newer minor versions are
+// accepted with a warning, so that the nodes of a fleet don't all have to be
+// upgraded at once.
+func CheckCompatible(caps api.APICapabilities, expected api.Version) error {
+	remote := caps.APIVersion
+	if remote.EqMajorMinor(expected) {
+		return nil
+	}
+
+	rmj, rmi, _ := remote.Ints()
+	emj, emi, _ := expected.Ints()
+	if rmj == emj && rmi > emi {
+		log.Warnf("remote API version %s is newer than the client's %s, consider upgrading the client", remote, expected)
+		return nil
+	}
+
+	for endpoint, v := range caps.Versions {
+		if v.EqMajorMinor(expected) {
+			return xerrors.Errorf("Remote API version didn't match (expected %s, remote %s), the expected version is served on the %s endpoint", expected, remote, endpoint)
+		}
+	}
+	return xerrors.Errorf("Remote API version didn't match (expected %s, remote %s)", expected, remote)
+}
+
+// WarnDeprecated wraps the methods of the client struct which are deprecated
+// on the remote, logging a warning the first time each of them is called.
+// outstr must be a pointer to an API struct, like api.FullNodeStruct.
+func WarnDeprecated(caps api.APICapabilities, outstr interface{}) {
+	if len(caps.Deprecated) == 0 {
+		return
+	}
+
+	for _, internal := range api.GetInternalStructs(outstr) {
+		rint := reflect.ValueOf(internal).Elem()
+
+		for f := 0; f < rint.NumField(); f++ {
+			field := rint.Type().Field(f)
+			if field.Type.Kind() != reflect.Func || rint.Field(f).IsNil() {
+				continue
+			}
+
+			d, ok := caps.Deprecation(field.Name)
+			if !ok {
+				continue
+			}
+
+			fn := reflect.ValueOf(rint.Field(f).Interface())
+			var once sync.Once
+			rint.Field(f).Set(reflect.MakeFunc(field.Type, func(args []reflect.Value) []reflect.Value {
+				once.Do(func() {
+					log.Warnw("calling deprecated API method", "method", d.Method, "replacement", d.Replacement, "note", d.Note)
+				})
+				return fn.Call(args)
+			}))
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// stm: #unit
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+func TestCheckCompatible(t *testing.T) {
+	require.NoError(t, CheckCompatible(api.APICapabilities{APIVersion: api.FullAPIVersion1}, api.FullAPIVersion1))
+
+	mj, mi, _ := api.FullAPIVersion1.Ints()
+	newer := api.Version(mj<<16 | (mi+1)<<8)
+	older := api.Version(mj<<16 | (mi-1)<<8)
+	nextMajor := api.Version((mj + 1) << 16)
+
+	// newer minor versions only add methods
+	require.NoError(t, CheckCompatible(api.APICapabilities{APIVersion: newer}, api.FullAPIVersion1))
+	require.Error(t, CheckCompatible(api.APICapabilities{APIVersion: older}, api.FullAPIVersion1))
+	require.Error(t, CheckCompatible(api.APICapabilities{APIVersion: nextMajor}, api.FullAPIVersion1))
+
+	// the error points to the endpoint serving the expected version
+	err := CheckCompatible(api.APICapabilities{
+		APIVersion: api.FullAPIVersion0,
+		Versions:   map[string]api.Version{"v0": api.FullAPIVersion0, "v1": api.FullAPIVersion1},
+	}, api.FullAPIVersion1)
+	require.ErrorContains(t, err, "v1 endpoint")
+}
+
+func TestNegotiateFallback(t *testing.T) {
+	ctx := context.Background()
+
+	var remote api.CommonStruct
+	remote.Internal.Capabilities = func(context.Context) (api.APICapabilities, error) {
+		return api.APICapabilities{}, xerrors.Errorf("RPC error (-32601): method 'Filecoin.Capabilities' not found")
+	}
+	remote.Internal.Version = func(context.Context) (api.APIVersion, error) {
+		return api.APIVersion{APIVersion: api.MinerAPIVersion0}, nil
+	}
+
+	// nodes predating the handshake are checked with their version
+	caps, err := NegotiateCommon(ctx, &remote, api.MinerAPIVersion0)
+	require.NoError(t, err)
+	require.Equal(t, api.MinerAPIVersion0, caps.APIVersion)
+
+	_, err = NegotiateCommon(ctx, &remote, api.FullAPIVersion1)
+	require.Error(t, err)
+
+	remote.Internal.Capabilities = func(context.Context) (api.APICapabilities, error) {
+		return api.CapabilitiesForType(api.NodeMiner)
+	}
+	caps, err = NegotiateCommon(ctx, &remote, api.MinerAPIVersion0)
+	require.NoError(t, err)
+	_, deprecated := caps.Deprecation("MarketListRetrievalDeals")
+	require.True(t, deprecated)
+}
+
+func TestWarnDeprecated(t *testing.T) {
+	ctx := context.Background()
+
+	var calls int
+	var remote api.CommonStruct
+	remote.Internal.LogList = func(context.Context) ([]string, error) {
+		calls++
+		return []string{"rpc"}, nil
+	}
+
+	WarnDeprecated(api.APICapabilities{
+		Deprecated: []api.MethodDeprecation{{Method: "LogList"}, {Method: "StartTime"}},
+	}, &remote)
+
+	// the deprecated methods are still called, missing ones are left alone
+	for i := 0; i < 2; i++ {
+		subs, err := remote.LogList(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []string{"rpc"}, subs)
+	}
+	require.Equal(t, 2, calls)
+	require.Nil(t, remote.Internal.StartTime)
+}
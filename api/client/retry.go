@@ -0,0 +1,244 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/go-jsonrpc"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// RetryPolicy configures how the calls of a client wrapped with WithRetry are
+// retried.
+type RetryPolicy struct {
+	// Attempts is the maximum number of attempts of a call.
+	Attempts int
+	// InitialBackoff is the delay before the first retry, doubled before every
+	// following one, up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Retryable tells whether a call which failed with the error is retried.
+	Retryable func(err error) bool
+}
+
+// DefaultRetryPolicy retries calls which failed because the connection to the
+// node dropped, or timed out.
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts:       5,
+	InitialBackoff: time.Second,
+	MaxBackoff:     30 * time.Second,
+	Retryable:      IsConnectionError,
+}
+
+// IsConnectionError tells whether the call failed to reach the node, or to get
+// its response, either because the connection dropped or because it timed
+// out. Errors returned by the node itself are never connection errors.
+func IsConnectionError(err error) bool {
+	var cerr *jsonrpc.RPCConnectionError
+	if errors.As(err, &cerr) {
+		return true
+	}
+
+	var nerr net.Error
+	return errors.As(err, &nerr) && nerr.Timeout()
+}
+
+// RetrySafe makes a mutating call safe to retry.
+type RetrySafe struct {
+	// Key identifies the effect of the call from its params. Concurrent calls
+	// with the same key share a single call. Keys are only used by the client,
+	// they aren't sent to the node.
+	Key func(params []interface{}) string
+	// Applied tells whether a retried attempt failed because an earlier
+	// attempt, whose result was lost, took effect.
+	Applied func(err error) bool
+	// Result returns the results, without the error, of a call which took
+	// effect.
+	Result func(params []interface{}) []interface{}
+}
+
+// DefaultRetrySafeCalls are the mutating calls of the node APIs which can be
+// retried, as the node tells apart, with a typed error, a repeated call which
+// already took effect.
+var DefaultRetrySafeCalls = map[string]RetrySafe{
+	"MpoolPush": {
+		Key: func(params []interface{}) string {
+			return params[0].(*types.SignedMessage).Cid().String()
+		},
+		Applied: func(err error) bool {
+			return api.ErrorIsIn(err, []error{&api.ErrMessageInMpool{}})
+		},
+		Result: func(params []interface{}) []interface{} {
+			return []interface{}{params[0].(*types.SignedMessage).Cid()}
+		},
+	},
+	"SectorReceive": {
+		Key: func(params []interface{}) string {
+			meta := params[0].(api.RemoteSectorMeta)
+			return fmt.Sprintf("%s-%d", meta.Sector.Miner, meta.Sector.Number)
+		},
+		Applied: func(err error) bool {
+			return api.ErrorIsIn(err, []error{&api.ErrSectorExists{}})
+		},
+		Result: func(params []interface{}) []interface{} {
+			return nil
+		},
+	},
+}
+
+// WithRetry makes the calls of the API client retried following the policy.
+// Read calls are always retried, other calls only when they are listed in
+// retrySafe.
+//
+//	full, closer, err := client.NewFullNodeRPCV1(ctx, addr, headers)
+//	...
+//	client.WithRetry(full, client.DefaultRetryPolicy, client.DefaultRetrySafeCalls)
+func WithRetry(apiClient interface{}, policy RetryPolicy, retrySafe map[string]RetrySafe) {
+	r := &retrier{
+		policy:   policy,
+		inflight: map[string]*inflightCall{},
+	}
+
+	for _, str := range api.GetInternalStructs(apiClient) {
+		rint := reflect.ValueOf(str).Elem()
+		for f := 0; f < rint.NumField(); f++ {
+			field := rint.Type().Field(f)
+			fn := rint.Field(f)
+			if fn.Kind() != reflect.Func || fn.IsNil() || field.Type.NumIn() == 0 ||
+				field.Type.In(0) != reflect.TypeOf((*context.Context)(nil)).Elem() {
+				continue
+			}
+
+			safe, ok := retrySafe[field.Name]
+			if !ok && field.Tag.Get("perm") != "read" {
+				continue
+			}
+
+			var sp *RetrySafe
+			if ok {
+				sp = &safe
+			}
+			// copy the function, the field is replaced by the wrapper
+			fn.Set(r.wrap(field.Name, reflect.ValueOf(fn.Interface()), sp))
+		}
+	}
+}
+
+type inflightCall struct {
+	done   chan struct{}
+	result []reflect.Value
+}
+
+type retrier struct {
+	policy RetryPolicy
+
+	lk       sync.Mutex
+	inflight map[string]*inflightCall
+}
+
+func (r *retrier) wrap(method string, fn reflect.Value, safe *RetrySafe) reflect.Value {
+	ft := fn.Type()
+	// channels are only returned by subscriptions, which can't be retried
+	if ft.NumOut() == 0 || ft.Out(ft.NumOut()-1) != reflect.TypeOf((*error)(nil)).Elem() {
+		return fn
+	}
+	for i := 0; i < ft.NumOut(); i++ {
+		if ft.Out(i).Kind() == reflect.Chan {
+			return fn
+		}
+	}
+
+	return reflect.MakeFunc(ft, func(args []reflect.Value) []reflect.Value {
+		if safe == nil {
+			return r.call(method, fn, args, nil)
+		}
+
+		params := make([]interface{}, len(args)-1)
+		for i := range params {
+			params[i] = args[i+1].Interface()
+		}
+		key := method + "/" + safe.Key(params)
+
+		r.lk.Lock()
+		if c, ok := r.inflight[key]; ok {
+			r.lk.Unlock()
+			<-c.done
+			return c.result
+		}
+		c := &inflightCall{done: make(chan struct{})}
+		r.inflight[key] = c
+		r.lk.Unlock()
+
+		defer func() {
+			r.lk.Lock()
+			delete(r.inflight, key)
+			r.lk.Unlock()
+			close(c.done)
+		}()
+
+		c.result = r.call(method, fn, args, func(err error) []reflect.Value {
+			if !safe.Applied(err) {
+				return nil
+			}
+			log.Infow("retried call already took effect", "method", method, "key", key)
+
+			out := make([]reflect.Value, ft.NumOut())
+			res := safe.Result(params)
+			for i := 0; i < ft.NumOut()-1; i++ {
+				out[i] = reflect.ValueOf(res[i])
+			}
+			out[len(out)-1] = reflect.Zero(ft.Out(ft.NumOut() - 1))
+			return out
+		})
+		return c.result
+	})
+}
+
+// call calls the method until it succeeds, fails with an error which isn't
+// retried, or the attempts are exhausted. When set, applied is called with the
+// errors of retried attempts, and returns the results of the call when it took
+// effect.
+func (r *retrier) call(method string, fn reflect.Value, args []reflect.Value, applied func(error) []reflect.Value) []reflect.Value {
+	ctx := args[0].Interface().(context.Context)
+	backoff := r.policy.InitialBackoff
+
+	var out []reflect.Value
+	for attempt := 0; ; attempt++ {
+		out = fn.Call(args)
+		errv := out[len(out)-1]
+		if errv.IsNil() {
+			return out
+		}
+		err := errv.Interface().(error)
+
+		if attempt > 0 && applied != nil {
+			if res := applied(err); res != nil {
+				return res
+			}
+		}
+
+		// the caller giving up on the call isn't a connection error
+		if attempt+1 >= r.policy.Attempts || ctx.Err() != nil || !r.policy.Retryable(err) {
+			return out
+		}
+
+		log.Warnw("retrying call", "method", method, "attempt", attempt+1, "backoff", backoff, "error", err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return out
+		}
+
+		backoff *= 2
+		if r.policy.MaxBackoff > 0 && backoff > r.policy.MaxBackoff {
+			backoff = r.policy.MaxBackoff
+		}
+	}
+}
@@ -0,0 +1,196 @@
+// stm: #unit
+package client
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-jsonrpc"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var testPolicy = RetryPolicy{
+	Attempts:       3,
+	InitialBackoff: time.Millisecond,
+	Retryable:      DefaultRetryPolicy.Retryable,
+}
+
+func TestWithRetryRead(t *testing.T) {
+	ctx := context.Background()
+
+	var calls int
+	var full api.FullNodeStruct
+	full.Internal.ChainHead = func(context.Context) (*types.TipSet, error) {
+		calls++
+		if calls < 3 {
+			return nil, &jsonrpc.RPCConnectionError{}
+		}
+		return nil, nil
+	}
+	full.Internal.MpoolPushUntrusted = func(context.Context, *types.SignedMessage) (cid.Cid, error) {
+		calls++
+		return cid.Undef, &jsonrpc.RPCConnectionError{}
+	}
+
+	WithRetry(&full, testPolicy, DefaultRetrySafeCalls)
+
+	_, err := full.ChainHead(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, calls)
+
+	// network timeouts are retried too
+	calls = 0
+	full.Internal.ChainHead = func(context.Context) (*types.TipSet, error) {
+		calls++
+		if calls < 2 {
+			return nil, xerrors.Errorf("reading response: %w", &net.DNSError{IsTimeout: true})
+		}
+		return nil, nil
+	}
+	WithRetry(&full, testPolicy, DefaultRetrySafeCalls)
+	_, err = full.ChainHead(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, calls)
+
+	// mutating calls which aren't safe to retry aren't retried
+	calls = 0
+	_, err = full.MpoolPushUntrusted(ctx, &types.SignedMessage{})
+	require.Error(t, err)
+	require.Equal(t, 1, calls)
+
+	// nor errors which aren't connection errors
+	for _, cerr := range []error{xerrors.Errorf("nope"), &jsonrpc.ErrClient{}} {
+		cerr := cerr
+		calls = 0
+		full.Internal.ChainHead = func(context.Context) (*types.TipSet, error) {
+			calls++
+			return nil, cerr
+		}
+		WithRetry(&full, testPolicy, DefaultRetrySafeCalls)
+		_, err = full.ChainHead(ctx)
+		require.Error(t, err)
+		require.Equal(t, 1, calls)
+	}
+
+	// nor calls given up by the caller
+	cctx, cancel := context.WithCancel(ctx)
+	calls = 0
+	full.Internal.ChainHead = func(context.Context) (*types.TipSet, error) {
+		calls++
+		cancel()
+		return nil, &jsonrpc.RPCConnectionError{}
+	}
+	WithRetry(&full, testPolicy, DefaultRetrySafeCalls)
+	_, err = full.ChainHead(cctx)
+	require.Error(t, err)
+	require.Equal(t, 1, calls)
+}
+
+func TestWithRetryIdempotent(t *testing.T) {
+	ctx := context.Background()
+
+	from, err := address.NewIDAddress(1000)
+	require.NoError(t, err)
+	sm := &types.SignedMessage{Message: types.Message{From: from, To: from, Nonce: 7}}
+
+	var lk sync.Mutex
+	var calls int
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	var full api.FullNodeStruct
+	full.Internal.MpoolPush = func(ctx context.Context, m *types.SignedMessage) (cid.Cid, error) {
+		lk.Lock()
+		calls++
+		n := calls
+		lk.Unlock()
+
+		switch n {
+		case 1:
+			// the message gets into the pool, but the response is lost
+			close(started)
+			<-release
+			return cid.Undef, &jsonrpc.RPCConnectionError{}
+		default:
+			return cid.Undef, &api.ErrMessageInMpool{}
+		}
+	}
+
+	WithRetry(&full, testPolicy, DefaultRetrySafeCalls)
+
+	// concurrent pushes of the same message share the call
+	var wg sync.WaitGroup
+	res := make([]cid.Cid, 2)
+	for i := range res {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if i == 1 {
+				<-started
+			}
+			c, err := full.MpoolPush(ctx, sm)
+			require.NoError(t, err)
+			res[i] = c
+		}()
+	}
+	<-started
+	// let the second push join the first one
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	require.Equal(t, 2, calls)
+	require.Equal(t, sm.Cid(), res[0])
+	require.Equal(t, sm.Cid(), res[1])
+
+	// the first attempt failing with the error isn't mistaken for a retry
+	calls = 1
+	_, err = full.MpoolPush(ctx, sm)
+	require.True(t, api.ErrorIsIn(err, []error{&api.ErrMessageInMpool{}}))
+	require.Equal(t, 2, calls)
+
+	// another message with the same nonce doesn't mean the push took effect
+	calls = 0
+	full.Internal.MpoolPush = func(ctx context.Context, m *types.SignedMessage) (cid.Cid, error) {
+		calls++
+		if calls == 1 {
+			return cid.Undef, &jsonrpc.RPCConnectionError{}
+		}
+		return cid.Undef, xerrors.Errorf("message from f01000 with nonce 7 already in mpool, increase GasPremium to trigger replace by fee")
+	}
+	WithRetry(&full, testPolicy, DefaultRetrySafeCalls)
+	_, err = full.MpoolPush(ctx, sm)
+	require.ErrorContains(t, err, "replace by fee")
+	require.Equal(t, 2, calls)
+}
+
+func TestWithRetrySectorReceive(t *testing.T) {
+	ctx := context.Background()
+
+	var calls int
+	var miner api.StorageMinerStruct
+	miner.Internal.SectorReceive = func(ctx context.Context, meta api.RemoteSectorMeta) error {
+		calls++
+		if calls == 1 {
+			return &jsonrpc.RPCConnectionError{}
+		}
+		return &api.ErrSectorExists{}
+	}
+
+	WithRetry(&miner, testPolicy, DefaultRetrySafeCalls)
+
+	require.NoError(t, miner.SectorReceive(ctx, api.RemoteSectorMeta{Sector: abi.SectorID{Miner: 1000, Number: 3}}))
+	require.Equal(t, 2, calls)
+}
@@ -45,6 +45,7 @@ import (
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 	"github.com/filecoin-project/lotus/node/repo/imports"
 	sealing "github.com/filecoin-project/lotus/storage/pipeline"
+	"github.com/filecoin-project/lotus/storage/pipeline/sealiface"
 	"github.com/filecoin-project/lotus/storage/sealer/sealtasks"
 	"github.com/filecoin-project/lotus/storage/sealer/storiface"
 )
@@ -113,12 +114,16 @@ func init() {
 	addExample(123)
 	addExample(uintptr(0))
 	addExample(abi.MethodNum(1))
+	methodNum := abi.MethodNum(1)
+	addExample(&methodNum)
 	addExample(exitcode.ExitCode(0))
 	addExample(crypto.DomainSeparationTag_ElectionProofProduction)
 	addExample(true)
 	addExample(abi.UnpaddedPieceSize(1024))
 	addExample(abi.UnpaddedPieceSize(1024).Padded())
 	addExample(abi.DealID(5432))
+	dealID := abi.DealID(5432)
+	addExample(&dealID)
 	addExample(abi.SectorNumber(9))
 	addExample(abi.SectorSize(32 * 1024 * 1024 * 1024))
 	addExample(api.MpoolChange(0))
@@ -149,7 +154,10 @@ func init() {
 	addExample(claimId)
 	addExample(&claimId)
 	addExample(map[verifreg.ClaimId]verifreg.Claim{})
+	addExample(api.AllocationPending)
 	addExample(map[string]int{"name": 42})
+	addExample(map[string]string{"name": "string value"})
+	addExample(map[string]int64{"validation failed": 42})
 	addExample(map[string]time.Time{"name": time.Unix(1615243938, 0).UTC()})
 	addExample(&types.ExecutionTrace{
 		Msg:    ExampleValue("init", reflect.TypeOf(types.MessageTrace{}), nil).(types.MessageTrace),
@@ -208,6 +216,7 @@ func init() {
 	addExample(retrievalmarket.DealID(5))
 	addExample(abi.ActorID(1000))
 	addExample(map[string]cid.Cid{})
+	addExample(map[string]api.Version{"v0": api.FullAPIVersion0, "v1": api.FullAPIVersion1})
 	addExample(map[string][]api.SealedRef{
 		"98000": {
 			api.SealedRef{
@@ -218,8 +227,20 @@ func init() {
 		},
 	})
 	addExample(api.SectorState(sealing.Proving))
+	addExample(api.SectorErrStorageSpace)
+	addExample(api.UnsealRegenAwaitingApproval)
+	addExample(api.DetectedFaultPending)
+	addExample(api.SubmissionPreCommit)
+	addExample(sealiface.CommitAggregateAboveBaseFee)
+	addExample(sealiface.FailSealing)
+	addExample(api.ProofParamPresent)
+	addExample(api.RetrievalFirstByte)
+	addExample(api.SelfTestPass)
+	addExample(api.AskChangeApplied)
+	addExample(api.DealSLABreached)
 	addExample(storiface.ID("76f1988b-ef30-4d7e-b3ec-9a627f4ba5a8"))
 	addExample(storiface.FTUnsealed)
+	addExample(storiface.JobBlockedResources)
 	addExample(storiface.PathSealing)
 	addExample(map[storiface.ID][]storiface.Decl{
 		"76f1988b-ef30-4d7e-b3ec-9a627f4ba5a8": {
@@ -305,6 +326,9 @@ func init() {
 	)
 
 	addExample(api.CheckStatusCode(0))
+	addExample(api.ShutdownStopping)
+	addExample(api.RotationChangeWorker)
+	addExample(api.RotationStepReady)
 	addExample(map[string]interface{}{"abc": 123})
 	addExample(api.MinerSubsystems{
 		api.SubsystemMining,
@@ -1,11 +1,17 @@
 package api
 
-import apitypes "github.com/filecoin-project/lotus/api/types"
+import (
+	"sort"
+	"strings"
+
+	apitypes "github.com/filecoin-project/lotus/api/types"
+)
 
 func CreateEthRPCAliases(as apitypes.Aliaser) {
 	// TODO: maybe use reflect to automatically register all the eth aliases
 	as.AliasMethod("eth_accounts", "Filecoin.EthAccounts")
 	as.AliasMethod("eth_blockNumber", "Filecoin.EthBlockNumber")
+	as.AliasMethod("eth_syncing", "Filecoin.EthSyncing")
 	as.AliasMethod("eth_getBlockTransactionCountByNumber", "Filecoin.EthGetBlockTransactionCountByNumber")
 	as.AliasMethod("eth_getBlockTransactionCountByHash", "Filecoin.EthGetBlockTransactionCountByHash")
 
@@ -14,6 +20,7 @@ func CreateEthRPCAliases(as apitypes.Aliaser) {
 	as.AliasMethod("eth_getTransactionByHash", "Filecoin.EthGetTransactionByHash")
 	as.AliasMethod("eth_getTransactionCount", "Filecoin.EthGetTransactionCount")
 	as.AliasMethod("eth_getTransactionReceipt", "Filecoin.EthGetTransactionReceipt")
+	as.AliasMethod("eth_getBlockReceipts", "Filecoin.EthGetBlockReceipts")
 	as.AliasMethod("eth_getTransactionByBlockHashAndIndex", "Filecoin.EthGetTransactionByBlockHashAndIndex")
 	as.AliasMethod("eth_getTransactionByBlockNumberAndIndex", "Filecoin.EthGetTransactionByBlockNumberAndIndex")
 
@@ -44,3 +51,54 @@ func CreateEthRPCAliases(as apitypes.Aliaser) {
 
 	as.AliasMethod("web3_clientVersion", "Filecoin.Web3ClientVersion")
 }
+
+type namespaceCollector map[string]struct{}
+
+func (nc namespaceCollector) AliasMethod(alias, original string) {
+	nc[ethNamespace(alias)] = struct{}{}
+}
+
+func ethNamespace(method string) string {
+	ns, _, _ := strings.Cut(method, "_")
+	return ns
+}
+
+// EthNamespaces returns the namespaces of the Ethereum JSON-RPC methods, like
+// eth, net and web3.
+func EthNamespaces() []string {
+	nc := namespaceCollector{}
+	CreateEthRPCAliases(nc)
+
+	out := make([]string, 0, len(nc))
+	for ns := range nc {
+		out = append(out, ns)
+	}
+	sort.Strings(out)
+	return out
+}
+
+type namespaceFilter struct {
+	as       apitypes.Aliaser
+	disabled map[string]struct{}
+}
+
+func (nf *namespaceFilter) AliasMethod(alias, original string) {
+	if _, ok := nf.disabled[ethNamespace(alias)]; ok {
+		return
+	}
+	nf.as.AliasMethod(alias, original)
+}
+
+// FilterEthNamespaces wraps an Aliaser to skip the Ethereum JSON-RPC methods of
+// the disabled namespaces.
+func FilterEthNamespaces(as apitypes.Aliaser, disabled []string) apitypes.Aliaser {
+	if len(disabled) == 0 {
+		return as
+	}
+
+	nf := &namespaceFilter{as: as, disabled: map[string]struct{}{}}
+	for _, ns := range disabled {
+		nf.disabled[ns] = struct{}{}
+	}
+	return nf
+}
@@ -0,0 +1,30 @@
+// stm: #unit
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type aliasRecorder map[string]string
+
+func (ar aliasRecorder) AliasMethod(alias, original string) {
+	ar[alias] = original
+}
+
+func TestFilterEthNamespaces(t *testing.T) {
+	require.Equal(t, []string{"eth", "net", "web3"}, EthNamespaces())
+
+	all := aliasRecorder{}
+	CreateEthRPCAliases(FilterEthNamespaces(all, nil))
+	require.Equal(t, "Filecoin.EthSyncing", all["eth_syncing"])
+	require.Equal(t, "Filecoin.NetVersion", all["net_version"])
+
+	filtered := aliasRecorder{}
+	CreateEthRPCAliases(FilterEthNamespaces(filtered, []string{"net", "web3"}))
+	require.Contains(t, filtered, "eth_blockNumber")
+	for alias := range filtered {
+		require.Equal(t, "eth", ethNamespace(alias))
+	}
+}
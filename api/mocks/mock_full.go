@@ -36,6 +36,7 @@ import (
 
 	api "github.com/filecoin-project/lotus/api"
 	apitypes "github.com/filecoin-project/lotus/api/types"
+	blockstore "github.com/filecoin-project/lotus/blockstore"
 	miner0 "github.com/filecoin-project/lotus/chain/actors/builtin/miner"
 	types "github.com/filecoin-project/lotus/chain/types"
 	ethtypes "github.com/filecoin-project/lotus/chain/types/ethtypes"
@@ -67,6 +68,21 @@ func (m *MockFullNode) EXPECT() *MockFullNodeMockRecorder {
 	return m.recorder
 }
 
+// AuthListTokens mocks base method.
+func (m *MockFullNode) AuthListTokens(arg0 context.Context) ([]api.AuthToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthListTokens", arg0)
+	ret0, _ := ret[0].([]api.AuthToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthListTokens indicates an expected call of AuthListTokens.
+func (mr *MockFullNodeMockRecorder) AuthListTokens(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthListTokens", reflect.TypeOf((*MockFullNode)(nil).AuthListTokens), arg0)
+}
+
 // AuthNew mocks base method.
 func (m *MockFullNode) AuthNew(arg0 context.Context, arg1 []auth.Permission) ([]byte, error) {
 	m.ctrl.T.Helper()
@@ -82,6 +98,36 @@ func (mr *MockFullNodeMockRecorder) AuthNew(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthNew", reflect.TypeOf((*MockFullNode)(nil).AuthNew), arg0, arg1)
 }
 
+// AuthNewNamespaced mocks base method.
+func (m *MockFullNode) AuthNewNamespaced(arg0 context.Context, arg1 []auth.Permission, arg2 string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthNewNamespaced", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthNewNamespaced indicates an expected call of AuthNewNamespaced.
+func (mr *MockFullNodeMockRecorder) AuthNewNamespaced(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthNewNamespaced", reflect.TypeOf((*MockFullNode)(nil).AuthNewNamespaced), arg0, arg1, arg2)
+}
+
+// AuthNewWithScope mocks base method.
+func (m *MockFullNode) AuthNewWithScope(arg0 context.Context, arg1 []auth.Permission, arg2 api.AuthScope) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthNewWithScope", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthNewWithScope indicates an expected call of AuthNewWithScope.
+func (mr *MockFullNodeMockRecorder) AuthNewWithScope(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthNewWithScope", reflect.TypeOf((*MockFullNode)(nil).AuthNewWithScope), arg0, arg1, arg2)
+}
+
 // AuthVerify mocks base method.
 func (m *MockFullNode) AuthVerify(arg0 context.Context, arg1 string) ([]auth.Permission, error) {
 	m.ctrl.T.Helper()
@@ -97,6 +143,36 @@ func (mr *MockFullNodeMockRecorder) AuthVerify(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthVerify", reflect.TypeOf((*MockFullNode)(nil).AuthVerify), arg0, arg1)
 }
 
+// Capabilities mocks base method.
+func (m *MockFullNode) Capabilities(arg0 context.Context) (api.APICapabilities, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Capabilities", arg0)
+	ret0, _ := ret[0].(api.APICapabilities)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Capabilities indicates an expected call of Capabilities.
+func (mr *MockFullNodeMockRecorder) Capabilities(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Capabilities", reflect.TypeOf((*MockFullNode)(nil).Capabilities), arg0)
+}
+
+// ChainBlockTiming mocks base method.
+func (m *MockFullNode) ChainBlockTiming(arg0 context.Context) ([]api.PeerBlockTiming, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainBlockTiming", arg0)
+	ret0, _ := ret[0].([]api.PeerBlockTiming)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainBlockTiming indicates an expected call of ChainBlockTiming.
+func (mr *MockFullNodeMockRecorder) ChainBlockTiming(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainBlockTiming", reflect.TypeOf((*MockFullNode)(nil).ChainBlockTiming), arg0)
+}
+
 // ChainBlockstoreInfo mocks base method.
 func (m *MockFullNode) ChainBlockstoreInfo(arg0 context.Context) (map[string]interface{}, error) {
 	m.ctrl.T.Helper()
@@ -112,6 +188,80 @@ func (mr *MockFullNodeMockRecorder) ChainBlockstoreInfo(arg0 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainBlockstoreInfo", reflect.TypeOf((*MockFullNode)(nil).ChainBlockstoreInfo), arg0)
 }
 
+// ChainBlockstoreMaintain mocks base method.
+func (m *MockFullNode) ChainBlockstoreMaintain(arg0 context.Context, arg1 api.BlockstoreMaintainOpts) (*api.BlockstoreMaintenanceRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainBlockstoreMaintain", arg0, arg1)
+	ret0, _ := ret[0].(*api.BlockstoreMaintenanceRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainBlockstoreMaintain indicates an expected call of ChainBlockstoreMaintain.
+func (mr *MockFullNodeMockRecorder) ChainBlockstoreMaintain(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainBlockstoreMaintain", reflect.TypeOf((*MockFullNode)(nil).ChainBlockstoreMaintain), arg0, arg1)
+}
+
+// ChainBlockstoreMaintenanceStatus mocks base method.
+func (m *MockFullNode) ChainBlockstoreMaintenanceStatus(arg0 context.Context) (*api.BlockstoreMaintenanceStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainBlockstoreMaintenanceStatus", arg0)
+	ret0, _ := ret[0].(*api.BlockstoreMaintenanceStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainBlockstoreMaintenanceStatus indicates an expected call of ChainBlockstoreMaintenanceStatus.
+func (mr *MockFullNodeMockRecorder) ChainBlockstoreMaintenanceStatus(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainBlockstoreMaintenanceStatus", reflect.TypeOf((*MockFullNode)(nil).ChainBlockstoreMaintenanceStatus), arg0)
+}
+
+// ChainBlockstoreScrubStatus mocks base method.
+func (m *MockFullNode) ChainBlockstoreScrubStatus(arg0 context.Context) (*api.BlockstoreScrubStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainBlockstoreScrubStatus", arg0)
+	ret0, _ := ret[0].(*api.BlockstoreScrubStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainBlockstoreScrubStatus indicates an expected call of ChainBlockstoreScrubStatus.
+func (mr *MockFullNodeMockRecorder) ChainBlockstoreScrubStatus(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainBlockstoreScrubStatus", reflect.TypeOf((*MockFullNode)(nil).ChainBlockstoreScrubStatus), arg0)
+}
+
+// ChainCacheResize mocks base method.
+func (m *MockFullNode) ChainCacheResize(arg0 context.Context, arg1 string, arg2 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainCacheResize", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChainCacheResize indicates an expected call of ChainCacheResize.
+func (mr *MockFullNodeMockRecorder) ChainCacheResize(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainCacheResize", reflect.TypeOf((*MockFullNode)(nil).ChainCacheResize), arg0, arg1, arg2)
+}
+
+// ChainCacheStats mocks base method.
+func (m *MockFullNode) ChainCacheStats(arg0 context.Context) ([]blockstore.CacheStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainCacheStats", arg0)
+	ret0, _ := ret[0].([]blockstore.CacheStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainCacheStats indicates an expected call of ChainCacheStats.
+func (mr *MockFullNodeMockRecorder) ChainCacheStats(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainCacheStats", reflect.TypeOf((*MockFullNode)(nil).ChainCacheStats), arg0)
+}
+
 // ChainCheckBlockstore mocks base method.
 func (m *MockFullNode) ChainCheckBlockstore(arg0 context.Context) error {
 	m.ctrl.T.Helper()
@@ -126,6 +276,21 @@ func (mr *MockFullNodeMockRecorder) ChainCheckBlockstore(arg0 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainCheckBlockstore", reflect.TypeOf((*MockFullNode)(nil).ChainCheckBlockstore), arg0)
 }
 
+// ChainConsensusFaults mocks base method.
+func (m *MockFullNode) ChainConsensusFaults(arg0 context.Context, arg1 abi.ChainEpoch) ([]api.ConsensusFault, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainConsensusFaults", arg0, arg1)
+	ret0, _ := ret[0].([]api.ConsensusFault)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainConsensusFaults indicates an expected call of ChainConsensusFaults.
+func (mr *MockFullNodeMockRecorder) ChainConsensusFaults(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainConsensusFaults", reflect.TypeOf((*MockFullNode)(nil).ChainConsensusFaults), arg0, arg1)
+}
+
 // ChainDeleteObj mocks base method.
 func (m *MockFullNode) ChainDeleteObj(arg0 context.Context, arg1 cid.Cid) error {
 	m.ctrl.T.Helper()
@@ -155,6 +320,21 @@ func (mr *MockFullNodeMockRecorder) ChainExport(arg0, arg1, arg2, arg3 interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainExport", reflect.TypeOf((*MockFullNode)(nil).ChainExport), arg0, arg1, arg2, arg3)
 }
 
+// ChainExportActorStates mocks base method.
+func (m *MockFullNode) ChainExportActorStates(arg0 context.Context, arg1 abi.ChainEpoch, arg2 bool, arg3 []address.Address, arg4 types.TipSetKey) (<-chan []byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainExportActorStates", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(<-chan []byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainExportActorStates indicates an expected call of ChainExportActorStates.
+func (mr *MockFullNodeMockRecorder) ChainExportActorStates(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainExportActorStates", reflect.TypeOf((*MockFullNode)(nil).ChainExportActorStates), arg0, arg1, arg2, arg3, arg4)
+}
+
 // ChainExportRangeInternal mocks base method.
 func (m *MockFullNode) ChainExportRangeInternal(arg0 context.Context, arg1, arg2 types.TipSetKey, arg3 api.ChainExportConfig) error {
 	m.ctrl.T.Helper()
@@ -169,6 +349,21 @@ func (mr *MockFullNodeMockRecorder) ChainExportRangeInternal(arg0, arg1, arg2, a
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainExportRangeInternal", reflect.TypeOf((*MockFullNode)(nil).ChainExportRangeInternal), arg0, arg1, arg2, arg3)
 }
 
+// ChainGetAttestation mocks base method.
+func (m *MockFullNode) ChainGetAttestation(arg0 context.Context, arg1 cid.Cid) (*api.AttestationBundle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainGetAttestation", arg0, arg1)
+	ret0, _ := ret[0].(*api.AttestationBundle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainGetAttestation indicates an expected call of ChainGetAttestation.
+func (mr *MockFullNodeMockRecorder) ChainGetAttestation(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainGetAttestation", reflect.TypeOf((*MockFullNode)(nil).ChainGetAttestation), arg0, arg1)
+}
+
 // ChainGetBlock mocks base method.
 func (m *MockFullNode) ChainGetBlock(arg0 context.Context, arg1 cid.Cid) (*types.BlockHeader, error) {
 	m.ctrl.T.Helper()
@@ -304,6 +499,21 @@ func (mr *MockFullNodeMockRecorder) ChainGetParentReceipts(arg0, arg1 interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainGetParentReceipts", reflect.TypeOf((*MockFullNode)(nil).ChainGetParentReceipts), arg0, arg1)
 }
 
+// ChainGetParentReceiptsBatch mocks base method.
+func (m *MockFullNode) ChainGetParentReceiptsBatch(arg0 context.Context, arg1 []types.TipSetKey) ([]api.ParentReceipts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainGetParentReceiptsBatch", arg0, arg1)
+	ret0, _ := ret[0].([]api.ParentReceipts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainGetParentReceiptsBatch indicates an expected call of ChainGetParentReceiptsBatch.
+func (mr *MockFullNodeMockRecorder) ChainGetParentReceiptsBatch(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainGetParentReceiptsBatch", reflect.TypeOf((*MockFullNode)(nil).ChainGetParentReceiptsBatch), arg0, arg1)
+}
+
 // ChainGetPath mocks base method.
 func (m *MockFullNode) ChainGetPath(arg0 context.Context, arg1, arg2 types.TipSetKey) ([]*api.HeadChange, error) {
 	m.ctrl.T.Helper()
@@ -319,6 +529,36 @@ func (mr *MockFullNodeMockRecorder) ChainGetPath(arg0, arg1, arg2 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainGetPath", reflect.TypeOf((*MockFullNode)(nil).ChainGetPath), arg0, arg1, arg2)
 }
 
+// ChainGetPathPage mocks base method.
+func (m *MockFullNode) ChainGetPathPage(arg0 context.Context, arg1, arg2 types.TipSetKey, arg3 string, arg4 int) (*api.HeadChangePage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainGetPathPage", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(*api.HeadChangePage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainGetPathPage indicates an expected call of ChainGetPathPage.
+func (mr *MockFullNodeMockRecorder) ChainGetPathPage(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainGetPathPage", reflect.TypeOf((*MockFullNode)(nil).ChainGetPathPage), arg0, arg1, arg2, arg3, arg4)
+}
+
+// ChainGetReceiptProof mocks base method.
+func (m *MockFullNode) ChainGetReceiptProof(arg0 context.Context, arg1 cid.Cid) (*api.ReceiptProof, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainGetReceiptProof", arg0, arg1)
+	ret0, _ := ret[0].(*api.ReceiptProof)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainGetReceiptProof indicates an expected call of ChainGetReceiptProof.
+func (mr *MockFullNodeMockRecorder) ChainGetReceiptProof(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainGetReceiptProof", reflect.TypeOf((*MockFullNode)(nil).ChainGetReceiptProof), arg0, arg1)
+}
+
 // ChainGetTipSet mocks base method.
 func (m *MockFullNode) ChainGetTipSet(arg0 context.Context, arg1 types.TipSetKey) (*types.TipSet, error) {
 	m.ctrl.T.Helper()
@@ -408,6 +648,21 @@ func (mr *MockFullNodeMockRecorder) ChainHotGC(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainHotGC", reflect.TypeOf((*MockFullNode)(nil).ChainHotGC), arg0, arg1)
 }
 
+// ChainJournalSince mocks base method.
+func (m *MockFullNode) ChainJournalSince(arg0 context.Context, arg1 uint64, arg2 int) (*api.ChainJournalPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainJournalSince", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*api.ChainJournalPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainJournalSince indicates an expected call of ChainJournalSince.
+func (mr *MockFullNodeMockRecorder) ChainJournalSince(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainJournalSince", reflect.TypeOf((*MockFullNode)(nil).ChainJournalSince), arg0, arg1, arg2)
+}
+
 // ChainNotify mocks base method.
 func (m *MockFullNode) ChainNotify(arg0 context.Context) (<-chan []*api.HeadChange, error) {
 	m.ctrl.T.Helper()
@@ -466,6 +721,35 @@ func (mr *MockFullNodeMockRecorder) ChainReadObj(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainReadObj", reflect.TypeOf((*MockFullNode)(nil).ChainReadObj), arg0, arg1)
 }
 
+// ChainReplicaPromote mocks base method.
+func (m *MockFullNode) ChainReplicaPromote(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainReplicaPromote", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChainReplicaPromote indicates an expected call of ChainReplicaPromote.
+func (mr *MockFullNodeMockRecorder) ChainReplicaPromote(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainReplicaPromote", reflect.TypeOf((*MockFullNode)(nil).ChainReplicaPromote), arg0)
+}
+
+// ChainReplicaStatus mocks base method.
+func (m *MockFullNode) ChainReplicaStatus(arg0 context.Context) (*api.ReplicaStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainReplicaStatus", arg0)
+	ret0, _ := ret[0].(*api.ReplicaStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainReplicaStatus indicates an expected call of ChainReplicaStatus.
+func (mr *MockFullNodeMockRecorder) ChainReplicaStatus(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainReplicaStatus", reflect.TypeOf((*MockFullNode)(nil).ChainReplicaStatus), arg0)
+}
+
 // ChainSetHead mocks base method.
 func (m *MockFullNode) ChainSetHead(arg0 context.Context, arg1 types.TipSetKey) error {
 	m.ctrl.T.Helper()
@@ -480,6 +764,21 @@ func (mr *MockFullNodeMockRecorder) ChainSetHead(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainSetHead", reflect.TypeOf((*MockFullNode)(nil).ChainSetHead), arg0, arg1)
 }
 
+// ChainSnapshots mocks base method.
+func (m *MockFullNode) ChainSnapshots(arg0 context.Context) ([]api.ChainSnapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainSnapshots", arg0)
+	ret0, _ := ret[0].([]api.ChainSnapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainSnapshots indicates an expected call of ChainSnapshots.
+func (mr *MockFullNodeMockRecorder) ChainSnapshots(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainSnapshots", reflect.TypeOf((*MockFullNode)(nil).ChainSnapshots), arg0)
+}
+
 // ChainStatObj mocks base method.
 func (m *MockFullNode) ChainStatObj(arg0 context.Context, arg1, arg2 cid.Cid) (api.ObjStat, error) {
 	m.ctrl.T.Helper()
@@ -510,6 +809,21 @@ func (mr *MockFullNodeMockRecorder) ChainTipSetWeight(arg0, arg1 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainTipSetWeight", reflect.TypeOf((*MockFullNode)(nil).ChainTipSetWeight), arg0, arg1)
 }
 
+// ClientAggregatePieces mocks base method.
+func (m *MockFullNode) ClientAggregatePieces(arg0 context.Context, arg1 []cid.Cid, arg2 abi.PaddedPieceSize, arg3 string) (*api.PieceAggregate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClientAggregatePieces", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*api.PieceAggregate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClientAggregatePieces indicates an expected call of ClientAggregatePieces.
+func (mr *MockFullNodeMockRecorder) ClientAggregatePieces(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientAggregatePieces", reflect.TypeOf((*MockFullNode)(nil).ClientAggregatePieces), arg0, arg1, arg2, arg3)
+}
+
 // ClientCalcCommP mocks base method.
 func (m *MockFullNode) ClientCalcCommP(arg0 context.Context, arg1 string) (*api.CommPRet, error) {
 	m.ctrl.T.Helper()
@@ -553,6 +867,21 @@ func (mr *MockFullNodeMockRecorder) ClientCancelRetrievalDeal(arg0, arg1 interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientCancelRetrievalDeal", reflect.TypeOf((*MockFullNode)(nil).ClientCancelRetrievalDeal), arg0, arg1)
 }
 
+// ClientCreateAllocations mocks base method.
+func (m *MockFullNode) ClientCreateAllocations(arg0 context.Context, arg1 address.Address, arg2 []verifreg.AllocationRequest) (cid.Cid, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClientCreateAllocations", arg0, arg1, arg2)
+	ret0, _ := ret[0].(cid.Cid)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClientCreateAllocations indicates an expected call of ClientCreateAllocations.
+func (mr *MockFullNodeMockRecorder) ClientCreateAllocations(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientCreateAllocations", reflect.TypeOf((*MockFullNode)(nil).ClientCreateAllocations), arg0, arg1, arg2)
+}
+
 // ClientDataTransferUpdates mocks base method.
 func (m *MockFullNode) ClientDataTransferUpdates(arg0 context.Context) (<-chan api.DataTransferChannel, error) {
 	m.ctrl.T.Helper()
@@ -598,6 +927,49 @@ func (mr *MockFullNodeMockRecorder) ClientDealSize(arg0, arg1 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientDealSize", reflect.TypeOf((*MockFullNode)(nil).ClientDealSize), arg0, arg1)
 }
 
+// ClientDealTemplateList mocks base method.
+func (m *MockFullNode) ClientDealTemplateList(arg0 context.Context) ([]api.DealTemplate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClientDealTemplateList", arg0)
+	ret0, _ := ret[0].([]api.DealTemplate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClientDealTemplateList indicates an expected call of ClientDealTemplateList.
+func (mr *MockFullNodeMockRecorder) ClientDealTemplateList(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientDealTemplateList", reflect.TypeOf((*MockFullNode)(nil).ClientDealTemplateList), arg0)
+}
+
+// ClientDealTemplateRemove mocks base method.
+func (m *MockFullNode) ClientDealTemplateRemove(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClientDealTemplateRemove", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClientDealTemplateRemove indicates an expected call of ClientDealTemplateRemove.
+func (mr *MockFullNodeMockRecorder) ClientDealTemplateRemove(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientDealTemplateRemove", reflect.TypeOf((*MockFullNode)(nil).ClientDealTemplateRemove), arg0, arg1)
+}
+
+// ClientDealTemplateSet mocks base method.
+func (m *MockFullNode) ClientDealTemplateSet(arg0 context.Context, arg1 api.DealTemplate) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClientDealTemplateSet", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClientDealTemplateSet indicates an expected call of ClientDealTemplateSet.
+func (mr *MockFullNodeMockRecorder) ClientDealTemplateSet(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientDealTemplateSet", reflect.TypeOf((*MockFullNode)(nil).ClientDealTemplateSet), arg0, arg1)
+}
+
 // ClientExport mocks base method.
 func (m *MockFullNode) ClientExport(arg0 context.Context, arg1 api.ExportRef, arg2 api.FileRef) error {
 	m.ctrl.T.Helper()
@@ -612,6 +984,21 @@ func (mr *MockFullNodeMockRecorder) ClientExport(arg0, arg1, arg2 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientExport", reflect.TypeOf((*MockFullNode)(nil).ClientExport), arg0, arg1, arg2)
 }
 
+// ClientExtendClaims mocks base method.
+func (m *MockFullNode) ClientExtendClaims(arg0 context.Context, arg1 address.Address, arg2 []verifreg.ClaimTerm) (cid.Cid, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClientExtendClaims", arg0, arg1, arg2)
+	ret0, _ := ret[0].(cid.Cid)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClientExtendClaims indicates an expected call of ClientExtendClaims.
+func (mr *MockFullNodeMockRecorder) ClientExtendClaims(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientExtendClaims", reflect.TypeOf((*MockFullNode)(nil).ClientExtendClaims), arg0, arg1, arg2)
+}
+
 // ClientFindData mocks base method.
 func (m *MockFullNode) ClientFindData(arg0 context.Context, arg1 cid.Cid, arg2 *cid.Cid) ([]api.QueryOffer, error) {
 	m.ctrl.T.Helper()
@@ -686,6 +1073,21 @@ func (mr *MockFullNodeMockRecorder) ClientGetDealUpdates(arg0 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientGetDealUpdates", reflect.TypeOf((*MockFullNode)(nil).ClientGetDealUpdates), arg0)
 }
 
+// ClientGetReplication mocks base method.
+func (m *MockFullNode) ClientGetReplication(arg0 context.Context, arg1 uuid.UUID) (*api.ReplicationStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClientGetReplication", arg0, arg1)
+	ret0, _ := ret[0].(*api.ReplicationStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClientGetReplication indicates an expected call of ClientGetReplication.
+func (mr *MockFullNodeMockRecorder) ClientGetReplication(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientGetReplication", reflect.TypeOf((*MockFullNode)(nil).ClientGetReplication), arg0, arg1)
+}
+
 // ClientGetRetrievalUpdates mocks base method.
 func (m *MockFullNode) ClientGetRetrievalUpdates(arg0 context.Context) (<-chan api.RetrievalInfo, error) {
 	m.ctrl.T.Helper()
@@ -731,6 +1133,50 @@ func (mr *MockFullNodeMockRecorder) ClientImport(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientImport", reflect.TypeOf((*MockFullNode)(nil).ClientImport), arg0, arg1)
 }
 
+// ClientImportProviderReputation mocks base method.
+func (m *MockFullNode) ClientImportProviderReputation(arg0 context.Context, arg1 []api.ProviderReputation, arg2 bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClientImportProviderReputation", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClientImportProviderReputation indicates an expected call of ClientImportProviderReputation.
+func (mr *MockFullNodeMockRecorder) ClientImportProviderReputation(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientImportProviderReputation", reflect.TypeOf((*MockFullNode)(nil).ClientImportProviderReputation), arg0, arg1, arg2)
+}
+
+// ClientListAllocations mocks base method.
+func (m *MockFullNode) ClientListAllocations(arg0 context.Context, arg1 address.Address) ([]api.AllocationInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClientListAllocations", arg0, arg1)
+	ret0, _ := ret[0].([]api.AllocationInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClientListAllocations indicates an expected call of ClientListAllocations.
+func (mr *MockFullNodeMockRecorder) ClientListAllocations(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientListAllocations", reflect.TypeOf((*MockFullNode)(nil).ClientListAllocations), arg0, arg1)
+}
+
+// ClientListDataPreps mocks base method.
+func (m *MockFullNode) ClientListDataPreps(arg0 context.Context) ([]api.DataPrepManifest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClientListDataPreps", arg0)
+	ret0, _ := ret[0].([]api.DataPrepManifest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClientListDataPreps indicates an expected call of ClientListDataPreps.
+func (mr *MockFullNodeMockRecorder) ClientListDataPreps(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientListDataPreps", reflect.TypeOf((*MockFullNode)(nil).ClientListDataPreps), arg0)
+}
+
 // ClientListDataTransfers mocks base method.
 func (m *MockFullNode) ClientListDataTransfers(arg0 context.Context) ([]api.DataTransferChannel, error) {
 	m.ctrl.T.Helper()
@@ -776,6 +1222,36 @@ func (mr *MockFullNodeMockRecorder) ClientListImports(arg0 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientListImports", reflect.TypeOf((*MockFullNode)(nil).ClientListImports), arg0)
 }
 
+// ClientListProviderReputation mocks base method.
+func (m *MockFullNode) ClientListProviderReputation(arg0 context.Context) ([]api.ProviderReputation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClientListProviderReputation", arg0)
+	ret0, _ := ret[0].([]api.ProviderReputation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClientListProviderReputation indicates an expected call of ClientListProviderReputation.
+func (mr *MockFullNodeMockRecorder) ClientListProviderReputation(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientListProviderReputation", reflect.TypeOf((*MockFullNode)(nil).ClientListProviderReputation), arg0)
+}
+
+// ClientListReplications mocks base method.
+func (m *MockFullNode) ClientListReplications(arg0 context.Context) ([]api.ReplicationStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClientListReplications", arg0)
+	ret0, _ := ret[0].([]api.ReplicationStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClientListReplications indicates an expected call of ClientListReplications.
+func (mr *MockFullNodeMockRecorder) ClientListReplications(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientListReplications", reflect.TypeOf((*MockFullNode)(nil).ClientListReplications), arg0)
+}
+
 // ClientListRetrievals mocks base method.
 func (m *MockFullNode) ClientListRetrievals(arg0 context.Context) ([]api.RetrievalInfo, error) {
 	m.ctrl.T.Helper()
@@ -806,6 +1282,36 @@ func (mr *MockFullNodeMockRecorder) ClientMinerQueryOffer(arg0, arg1, arg2, arg3
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientMinerQueryOffer", reflect.TypeOf((*MockFullNode)(nil).ClientMinerQueryOffer), arg0, arg1, arg2, arg3)
 }
 
+// ClientPrepareData mocks base method.
+func (m *MockFullNode) ClientPrepareData(arg0 context.Context, arg1 string, arg2 abi.PaddedPieceSize) (*api.DataPrepManifest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClientPrepareData", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*api.DataPrepManifest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClientPrepareData indicates an expected call of ClientPrepareData.
+func (mr *MockFullNodeMockRecorder) ClientPrepareData(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientPrepareData", reflect.TypeOf((*MockFullNode)(nil).ClientPrepareData), arg0, arg1, arg2)
+}
+
+// ClientProviderScores mocks base method.
+func (m *MockFullNode) ClientProviderScores(arg0 context.Context, arg1 []address.Address) ([]api.ProviderScore, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClientProviderScores", arg0, arg1)
+	ret0, _ := ret[0].([]api.ProviderScore)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClientProviderScores indicates an expected call of ClientProviderScores.
+func (mr *MockFullNodeMockRecorder) ClientProviderScores(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientProviderScores", reflect.TypeOf((*MockFullNode)(nil).ClientProviderScores), arg0, arg1)
+}
+
 // ClientQueryAsk mocks base method.
 func (m *MockFullNode) ClientQueryAsk(arg0 context.Context, arg1 peer.ID, arg2 address.Address) (*api.StorageAsk, error) {
 	m.ctrl.T.Helper()
@@ -835,6 +1341,21 @@ func (mr *MockFullNodeMockRecorder) ClientRemoveImport(arg0, arg1 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientRemoveImport", reflect.TypeOf((*MockFullNode)(nil).ClientRemoveImport), arg0, arg1)
 }
 
+// ClientReplicate mocks base method.
+func (m *MockFullNode) ClientReplicate(arg0 context.Context, arg1 *api.StartDealParams, arg2 int, arg3 api.ProviderSelector) (*api.ReplicationStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClientReplicate", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*api.ReplicationStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClientReplicate indicates an expected call of ClientReplicate.
+func (mr *MockFullNodeMockRecorder) ClientReplicate(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientReplicate", reflect.TypeOf((*MockFullNode)(nil).ClientReplicate), arg0, arg1, arg2, arg3)
+}
+
 // ClientRestartDataTransfer mocks base method.
 func (m *MockFullNode) ClientRestartDataTransfer(arg0 context.Context, arg1 datatransfer.TransferID, arg2 peer.ID, arg3 bool) error {
 	m.ctrl.T.Helper()
@@ -849,6 +1370,21 @@ func (mr *MockFullNodeMockRecorder) ClientRestartDataTransfer(arg0, arg1, arg2,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientRestartDataTransfer", reflect.TypeOf((*MockFullNode)(nil).ClientRestartDataTransfer), arg0, arg1, arg2, arg3)
 }
 
+// ClientRetrievalResume mocks base method.
+func (m *MockFullNode) ClientRetrievalResume(arg0 context.Context, arg1 retrievalmarket.DealID) (*api.RestrievalRes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClientRetrievalResume", arg0, arg1)
+	ret0, _ := ret[0].(*api.RestrievalRes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClientRetrievalResume indicates an expected call of ClientRetrievalResume.
+func (mr *MockFullNodeMockRecorder) ClientRetrievalResume(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientRetrievalResume", reflect.TypeOf((*MockFullNode)(nil).ClientRetrievalResume), arg0, arg1)
+}
+
 // ClientRetrieve mocks base method.
 func (m *MockFullNode) ClientRetrieve(arg0 context.Context, arg1 api.RetrievalOrder) (*api.RestrievalRes, error) {
 	m.ctrl.T.Helper()
@@ -892,6 +1428,20 @@ func (mr *MockFullNodeMockRecorder) ClientRetrieveWait(arg0, arg1 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientRetrieveWait", reflect.TypeOf((*MockFullNode)(nil).ClientRetrieveWait), arg0, arg1)
 }
 
+// ClientSetProviderTags mocks base method.
+func (m *MockFullNode) ClientSetProviderTags(arg0 context.Context, arg1 address.Address, arg2 []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClientSetProviderTags", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClientSetProviderTags indicates an expected call of ClientSetProviderTags.
+func (mr *MockFullNodeMockRecorder) ClientSetProviderTags(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientSetProviderTags", reflect.TypeOf((*MockFullNode)(nil).ClientSetProviderTags), arg0, arg1, arg2)
+}
+
 // ClientStartDeal mocks base method.
 func (m *MockFullNode) ClientStartDeal(arg0 context.Context, arg1 *api.StartDealParams) (*cid.Cid, error) {
 	m.ctrl.T.Helper()
@@ -966,6 +1516,21 @@ func (mr *MockFullNodeMockRecorder) Discover(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Discover", reflect.TypeOf((*MockFullNode)(nil).Discover), arg0)
 }
 
+// DiskForecast mocks base method.
+func (m *MockFullNode) DiskForecast(arg0 context.Context) ([]api.DiskForecast, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DiskForecast", arg0)
+	ret0, _ := ret[0].([]api.DiskForecast)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DiskForecast indicates an expected call of DiskForecast.
+func (mr *MockFullNodeMockRecorder) DiskForecast(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiskForecast", reflect.TypeOf((*MockFullNode)(nil).DiskForecast), arg0)
+}
+
 // EthAccounts mocks base method.
 func (m *MockFullNode) EthAccounts(arg0 context.Context) ([]ethtypes.EthAddress, error) {
 	m.ctrl.T.Helper()
@@ -1056,6 +1621,49 @@ func (mr *MockFullNodeMockRecorder) EthEstimateGas(arg0, arg1 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EthEstimateGas", reflect.TypeOf((*MockFullNode)(nil).EthEstimateGas), arg0, arg1)
 }
 
+// EthEventWatchList mocks base method.
+func (m *MockFullNode) EthEventWatchList(arg0 context.Context) (*api.EthEventWatchList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EthEventWatchList", arg0)
+	ret0, _ := ret[0].(*api.EthEventWatchList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EthEventWatchList indicates an expected call of EthEventWatchList.
+func (mr *MockFullNodeMockRecorder) EthEventWatchList(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EthEventWatchList", reflect.TypeOf((*MockFullNode)(nil).EthEventWatchList), arg0)
+}
+
+// EthEventWatchListAdd mocks base method.
+func (m *MockFullNode) EthEventWatchListAdd(arg0 context.Context, arg1 api.EthEventWatchList, arg2 abi.ChainEpoch) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EthEventWatchListAdd", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EthEventWatchListAdd indicates an expected call of EthEventWatchListAdd.
+func (mr *MockFullNodeMockRecorder) EthEventWatchListAdd(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EthEventWatchListAdd", reflect.TypeOf((*MockFullNode)(nil).EthEventWatchListAdd), arg0, arg1, arg2)
+}
+
+// EthEventWatchListRemove mocks base method.
+func (m *MockFullNode) EthEventWatchListRemove(arg0 context.Context, arg1 api.EthEventWatchList) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EthEventWatchListRemove", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EthEventWatchListRemove indicates an expected call of EthEventWatchListRemove.
+func (mr *MockFullNodeMockRecorder) EthEventWatchListRemove(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EthEventWatchListRemove", reflect.TypeOf((*MockFullNode)(nil).EthEventWatchListRemove), arg0, arg1)
+}
+
 // EthFeeHistory mocks base method.
 func (m *MockFullNode) EthFeeHistory(arg0 context.Context, arg1 jsonrpc.RawParams) (ethtypes.EthFeeHistory, error) {
 	m.ctrl.T.Helper()
@@ -1131,6 +1739,21 @@ func (mr *MockFullNodeMockRecorder) EthGetBlockByNumber(arg0, arg1, arg2 interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EthGetBlockByNumber", reflect.TypeOf((*MockFullNode)(nil).EthGetBlockByNumber), arg0, arg1, arg2)
 }
 
+// EthGetBlockReceipts mocks base method.
+func (m *MockFullNode) EthGetBlockReceipts(arg0 context.Context, arg1 string) ([]*api.EthTxReceipt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EthGetBlockReceipts", arg0, arg1)
+	ret0, _ := ret[0].([]*api.EthTxReceipt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EthGetBlockReceipts indicates an expected call of EthGetBlockReceipts.
+func (mr *MockFullNodeMockRecorder) EthGetBlockReceipts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EthGetBlockReceipts", reflect.TypeOf((*MockFullNode)(nil).EthGetBlockReceipts), arg0, arg1)
+}
+
 // EthGetBlockTransactionCountByHash mocks base method.
 func (m *MockFullNode) EthGetBlockTransactionCountByHash(arg0 context.Context, arg1 ethtypes.EthHash) (ethtypes.EthUint64, error) {
 	m.ctrl.T.Helper()
@@ -1461,6 +2084,21 @@ func (mr *MockFullNodeMockRecorder) EthSendRawTransaction(arg0, arg1 interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EthSendRawTransaction", reflect.TypeOf((*MockFullNode)(nil).EthSendRawTransaction), arg0, arg1)
 }
 
+// EthSimulateRawTransaction mocks base method.
+func (m *MockFullNode) EthSimulateRawTransaction(arg0 context.Context, arg1 ethtypes.EthBytes) (*api.EthTxSimulation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EthSimulateRawTransaction", arg0, arg1)
+	ret0, _ := ret[0].(*api.EthTxSimulation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EthSimulateRawTransaction indicates an expected call of EthSimulateRawTransaction.
+func (mr *MockFullNodeMockRecorder) EthSimulateRawTransaction(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EthSimulateRawTransaction", reflect.TypeOf((*MockFullNode)(nil).EthSimulateRawTransaction), arg0, arg1)
+}
+
 // EthSubscribe mocks base method.
 func (m *MockFullNode) EthSubscribe(arg0 context.Context, arg1 jsonrpc.RawParams) (ethtypes.EthSubscriptionID, error) {
 	m.ctrl.T.Helper()
@@ -1476,6 +2114,21 @@ func (mr *MockFullNodeMockRecorder) EthSubscribe(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EthSubscribe", reflect.TypeOf((*MockFullNode)(nil).EthSubscribe), arg0, arg1)
 }
 
+// EthSyncing mocks base method.
+func (m *MockFullNode) EthSyncing(arg0 context.Context) (ethtypes.EthSyncingResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EthSyncing", arg0)
+	ret0, _ := ret[0].(ethtypes.EthSyncingResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EthSyncing indicates an expected call of EthSyncing.
+func (mr *MockFullNodeMockRecorder) EthSyncing(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EthSyncing", reflect.TypeOf((*MockFullNode)(nil).EthSyncing), arg0)
+}
+
 // EthUninstallFilter mocks base method.
 func (m *MockFullNode) EthUninstallFilter(arg0 context.Context, arg1 ethtypes.EthFilterID) (bool, error) {
 	m.ctrl.T.Helper()
@@ -1655,6 +2308,21 @@ func (mr *MockFullNodeMockRecorder) MarketAddBalance(arg0, arg1, arg2, arg3 inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarketAddBalance", reflect.TypeOf((*MockFullNode)(nil).MarketAddBalance), arg0, arg1, arg2, arg3)
 }
 
+// MarketFundsStatus mocks base method.
+func (m *MockFullNode) MarketFundsStatus(arg0 context.Context, arg1 address.Address) (api.MarketFundsStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarketFundsStatus", arg0, arg1)
+	ret0, _ := ret[0].(api.MarketFundsStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarketFundsStatus indicates an expected call of MarketFundsStatus.
+func (mr *MockFullNodeMockRecorder) MarketFundsStatus(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarketFundsStatus", reflect.TypeOf((*MockFullNode)(nil).MarketFundsStatus), arg0, arg1)
+}
+
 // MarketGetReserved mocks base method.
 func (m *MockFullNode) MarketGetReserved(arg0 context.Context, arg1 address.Address) (big.Int, error) {
 	m.ctrl.T.Helper()
@@ -1848,6 +2516,36 @@ func (mr *MockFullNodeMockRecorder) MpoolClear(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolClear", reflect.TypeOf((*MockFullNode)(nil).MpoolClear), arg0, arg1)
 }
 
+// MpoolGasMarket mocks base method.
+func (m *MockFullNode) MpoolGasMarket(arg0 context.Context, arg1 int) (*api.MpoolGasMarket, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolGasMarket", arg0, arg1)
+	ret0, _ := ret[0].(*api.MpoolGasMarket)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MpoolGasMarket indicates an expected call of MpoolGasMarket.
+func (mr *MockFullNodeMockRecorder) MpoolGasMarket(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolGasMarket", reflect.TypeOf((*MockFullNode)(nil).MpoolGasMarket), arg0, arg1)
+}
+
+// MpoolGasMarketSub mocks base method.
+func (m *MockFullNode) MpoolGasMarketSub(arg0 context.Context, arg1 int) (<-chan api.MpoolGasMarket, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolGasMarketSub", arg0, arg1)
+	ret0, _ := ret[0].(<-chan api.MpoolGasMarket)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MpoolGasMarketSub indicates an expected call of MpoolGasMarketSub.
+func (mr *MockFullNodeMockRecorder) MpoolGasMarketSub(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolGasMarketSub", reflect.TypeOf((*MockFullNode)(nil).MpoolGasMarketSub), arg0, arg1)
+}
+
 // MpoolGetConfig mocks base method.
 func (m *MockFullNode) MpoolGetConfig(arg0 context.Context) (*types.MpoolConfig, error) {
 	m.ctrl.T.Helper()
@@ -1893,6 +2591,21 @@ func (mr *MockFullNodeMockRecorder) MpoolPending(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolPending", reflect.TypeOf((*MockFullNode)(nil).MpoolPending), arg0, arg1)
 }
 
+// MpoolPredictCid mocks base method.
+func (m *MockFullNode) MpoolPredictCid(arg0 context.Context, arg1 *types.Message, arg2 address.Address) (cid.Cid, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolPredictCid", arg0, arg1, arg2)
+	ret0, _ := ret[0].(cid.Cid)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MpoolPredictCid indicates an expected call of MpoolPredictCid.
+func (mr *MockFullNodeMockRecorder) MpoolPredictCid(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolPredictCid", reflect.TypeOf((*MockFullNode)(nil).MpoolPredictCid), arg0, arg1, arg2)
+}
+
 // MpoolPush mocks base method.
 func (m *MockFullNode) MpoolPush(arg0 context.Context, arg1 *types.SignedMessage) (cid.Cid, error) {
 	m.ctrl.T.Helper()
@@ -1938,6 +2651,21 @@ func (mr *MockFullNodeMockRecorder) MpoolPushUntrusted(arg0, arg1 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolPushUntrusted", reflect.TypeOf((*MockFullNode)(nil).MpoolPushUntrusted), arg0, arg1)
 }
 
+// MpoolReplace mocks base method.
+func (m *MockFullNode) MpoolReplace(arg0 context.Context, arg1 api.MpoolReplaceSpec) (*api.MpoolReplaceResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolReplace", arg0, arg1)
+	ret0, _ := ret[0].(*api.MpoolReplaceResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MpoolReplace indicates an expected call of MpoolReplace.
+func (mr *MockFullNodeMockRecorder) MpoolReplace(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolReplace", reflect.TypeOf((*MockFullNode)(nil).MpoolReplace), arg0, arg1)
+}
+
 // MpoolSelect mocks base method.
 func (m *MockFullNode) MpoolSelect(arg0 context.Context, arg1 types.TipSetKey, arg2 float64) ([]*types.SignedMessage, error) {
 	m.ctrl.T.Helper()
@@ -1953,6 +2681,21 @@ func (mr *MockFullNodeMockRecorder) MpoolSelect(arg0, arg1, arg2 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolSelect", reflect.TypeOf((*MockFullNode)(nil).MpoolSelect), arg0, arg1, arg2)
 }
 
+// MpoolSelectPreview mocks base method.
+func (m *MockFullNode) MpoolSelectPreview(arg0 context.Context, arg1 types.TipSetKey, arg2 float64) (*api.MpoolSelectPreview, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MpoolSelectPreview", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*api.MpoolSelectPreview)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MpoolSelectPreview indicates an expected call of MpoolSelectPreview.
+func (mr *MockFullNodeMockRecorder) MpoolSelectPreview(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MpoolSelectPreview", reflect.TypeOf((*MockFullNode)(nil).MpoolSelectPreview), arg0, arg1, arg2)
+}
+
 // MpoolSetConfig mocks base method.
 func (m *MockFullNode) MpoolSetConfig(arg0 context.Context, arg1 *types.MpoolConfig) error {
 	m.ctrl.T.Helper()
@@ -2267,6 +3010,49 @@ func (mr *MockFullNodeMockRecorder) NetAgentVersion(arg0, arg1 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetAgentVersion", reflect.TypeOf((*MockFullNode)(nil).NetAgentVersion), arg0, arg1)
 }
 
+// NetAllowAdd mocks base method.
+func (m *MockFullNode) NetAllowAdd(arg0 context.Context, arg1 []peer.AddrInfo) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetAllowAdd", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// NetAllowAdd indicates an expected call of NetAllowAdd.
+func (mr *MockFullNodeMockRecorder) NetAllowAdd(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetAllowAdd", reflect.TypeOf((*MockFullNode)(nil).NetAllowAdd), arg0, arg1)
+}
+
+// NetAllowList mocks base method.
+func (m *MockFullNode) NetAllowList(arg0 context.Context) ([]peer.AddrInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetAllowList", arg0)
+	ret0, _ := ret[0].([]peer.AddrInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NetAllowList indicates an expected call of NetAllowList.
+func (mr *MockFullNodeMockRecorder) NetAllowList(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetAllowList", reflect.TypeOf((*MockFullNode)(nil).NetAllowList), arg0)
+}
+
+// NetAllowRemove mocks base method.
+func (m *MockFullNode) NetAllowRemove(arg0 context.Context, arg1 []peer.ID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetAllowRemove", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// NetAllowRemove indicates an expected call of NetAllowRemove.
+func (mr *MockFullNodeMockRecorder) NetAllowRemove(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetAllowRemove", reflect.TypeOf((*MockFullNode)(nil).NetAllowRemove), arg0, arg1)
+}
+
 // NetAutoNatStatus mocks base method.
 func (m *MockFullNode) NetAutoNatStatus(arg0 context.Context) (api.NatInfo, error) {
 	m.ctrl.T.Helper()
@@ -2428,6 +3214,21 @@ func (mr *MockFullNodeMockRecorder) NetFindPeer(arg0, arg1 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetFindPeer", reflect.TypeOf((*MockFullNode)(nil).NetFindPeer), arg0, arg1)
 }
 
+// NetGetReconnectPolicy mocks base method.
+func (m *MockFullNode) NetGetReconnectPolicy(arg0 context.Context) (api.NetReconnectPolicy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetGetReconnectPolicy", arg0)
+	ret0, _ := ret[0].(api.NetReconnectPolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NetGetReconnectPolicy indicates an expected call of NetGetReconnectPolicy.
+func (mr *MockFullNodeMockRecorder) NetGetReconnectPolicy(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetGetReconnectPolicy", reflect.TypeOf((*MockFullNode)(nil).NetGetReconnectPolicy), arg0)
+}
+
 // NetLimit mocks base method.
 func (m *MockFullNode) NetLimit(arg0 context.Context, arg1 string) (api.NetLimit, error) {
 	m.ctrl.T.Helper()
@@ -2473,6 +3274,21 @@ func (mr *MockFullNodeMockRecorder) NetPeerInfo(arg0, arg1 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetPeerInfo", reflect.TypeOf((*MockFullNode)(nil).NetPeerInfo), arg0, arg1)
 }
 
+// NetPeerScores mocks base method.
+func (m *MockFullNode) NetPeerScores(arg0 context.Context) ([]api.PeerScore, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetPeerScores", arg0)
+	ret0, _ := ret[0].([]api.PeerScore)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NetPeerScores indicates an expected call of NetPeerScores.
+func (mr *MockFullNodeMockRecorder) NetPeerScores(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetPeerScores", reflect.TypeOf((*MockFullNode)(nil).NetPeerScores), arg0)
+}
+
 // NetPeers mocks base method.
 func (m *MockFullNode) NetPeers(arg0 context.Context) ([]peer.AddrInfo, error) {
 	m.ctrl.T.Helper()
@@ -2546,6 +3362,36 @@ func (mr *MockFullNodeMockRecorder) NetProtectRemove(arg0, arg1 interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetProtectRemove", reflect.TypeOf((*MockFullNode)(nil).NetProtectRemove), arg0, arg1)
 }
 
+// NetPubsubRejectedMessages mocks base method.
+func (m *MockFullNode) NetPubsubRejectedMessages(arg0 context.Context, arg1 string) ([]api.PubsubRejectedMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetPubsubRejectedMessages", arg0, arg1)
+	ret0, _ := ret[0].([]api.PubsubRejectedMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NetPubsubRejectedMessages indicates an expected call of NetPubsubRejectedMessages.
+func (mr *MockFullNodeMockRecorder) NetPubsubRejectedMessages(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetPubsubRejectedMessages", reflect.TypeOf((*MockFullNode)(nil).NetPubsubRejectedMessages), arg0, arg1)
+}
+
+// NetPubsubRejections mocks base method.
+func (m *MockFullNode) NetPubsubRejections(arg0 context.Context) ([]api.PubsubTopicRejections, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetPubsubRejections", arg0)
+	ret0, _ := ret[0].([]api.PubsubTopicRejections)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NetPubsubRejections indicates an expected call of NetPubsubRejections.
+func (mr *MockFullNodeMockRecorder) NetPubsubRejections(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetPubsubRejections", reflect.TypeOf((*MockFullNode)(nil).NetPubsubRejections), arg0)
+}
+
 // NetPubsubScores mocks base method.
 func (m *MockFullNode) NetPubsubScores(arg0 context.Context) ([]api.PubsubScore, error) {
 	m.ctrl.T.Helper()
@@ -2575,6 +3421,34 @@ func (mr *MockFullNodeMockRecorder) NetSetLimit(arg0, arg1, arg2 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetSetLimit", reflect.TypeOf((*MockFullNode)(nil).NetSetLimit), arg0, arg1, arg2)
 }
 
+// NetSetPeerTag mocks base method.
+func (m *MockFullNode) NetSetPeerTag(arg0 context.Context, arg1 peer.ID, arg2 string, arg3 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetSetPeerTag", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// NetSetPeerTag indicates an expected call of NetSetPeerTag.
+func (mr *MockFullNodeMockRecorder) NetSetPeerTag(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetSetPeerTag", reflect.TypeOf((*MockFullNode)(nil).NetSetPeerTag), arg0, arg1, arg2, arg3)
+}
+
+// NetSetReconnectPolicy mocks base method.
+func (m *MockFullNode) NetSetReconnectPolicy(arg0 context.Context, arg1 api.NetReconnectPolicy) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetSetReconnectPolicy", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// NetSetReconnectPolicy indicates an expected call of NetSetReconnectPolicy.
+func (mr *MockFullNodeMockRecorder) NetSetReconnectPolicy(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetSetReconnectPolicy", reflect.TypeOf((*MockFullNode)(nil).NetSetReconnectPolicy), arg0, arg1)
+}
+
 // NetStat mocks base method.
 func (m *MockFullNode) NetStat(arg0 context.Context, arg1 string) (api.NetStat, error) {
 	m.ctrl.T.Helper()
@@ -2933,6 +3807,21 @@ func (mr *MockFullNodeMockRecorder) Shutdown(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Shutdown", reflect.TypeOf((*MockFullNode)(nil).Shutdown), arg0)
 }
 
+// ShutdownStatus mocks base method.
+func (m *MockFullNode) ShutdownStatus(arg0 context.Context) (api.ShutdownStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ShutdownStatus", arg0)
+	ret0, _ := ret[0].(api.ShutdownStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ShutdownStatus indicates an expected call of ShutdownStatus.
+func (mr *MockFullNodeMockRecorder) ShutdownStatus(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShutdownStatus", reflect.TypeOf((*MockFullNode)(nil).ShutdownStatus), arg0)
+}
+
 // StartTime mocks base method.
 func (m *MockFullNode) StartTime(arg0 context.Context) (time.Time, error) {
 	m.ctrl.T.Helper()
@@ -2993,6 +3882,21 @@ func (mr *MockFullNodeMockRecorder) StateActorManifestCID(arg0, arg1 interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateActorManifestCID", reflect.TypeOf((*MockFullNode)(nil).StateActorManifestCID), arg0, arg1)
 }
 
+// StateActorSchema mocks base method.
+func (m *MockFullNode) StateActorSchema(arg0 context.Context, arg1 cid.Cid) (*api.ActorSchema, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateActorSchema", arg0, arg1)
+	ret0, _ := ret[0].(*api.ActorSchema)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateActorSchema indicates an expected call of StateActorSchema.
+func (mr *MockFullNodeMockRecorder) StateActorSchema(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateActorSchema", reflect.TypeOf((*MockFullNode)(nil).StateActorSchema), arg0, arg1)
+}
+
 // StateAllMinerFaults mocks base method.
 func (m *MockFullNode) StateAllMinerFaults(arg0 context.Context, arg1 abi.ChainEpoch, arg2 types.TipSetKey) ([]*api.Fault, error) {
 	m.ctrl.T.Helper()
@@ -3023,6 +3927,21 @@ func (mr *MockFullNodeMockRecorder) StateCall(arg0, arg1, arg2 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateCall", reflect.TypeOf((*MockFullNode)(nil).StateCall), arg0, arg1, arg2)
 }
 
+// StateCallWithOverrides mocks base method.
+func (m *MockFullNode) StateCallWithOverrides(arg0 context.Context, arg1 *types.Message, arg2 []api.ActorOverride, arg3 types.TipSetKey) (*api.InvocResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateCallWithOverrides", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*api.InvocResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateCallWithOverrides indicates an expected call of StateCallWithOverrides.
+func (mr *MockFullNodeMockRecorder) StateCallWithOverrides(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateCallWithOverrides", reflect.TypeOf((*MockFullNode)(nil).StateCallWithOverrides), arg0, arg1, arg2, arg3)
+}
+
 // StateChangedActors mocks base method.
 func (m *MockFullNode) StateChangedActors(arg0 context.Context, arg1, arg2 cid.Cid) (map[string]types.ActorV5, error) {
 	m.ctrl.T.Helper()
@@ -3098,6 +4017,36 @@ func (mr *MockFullNodeMockRecorder) StateDealProviderCollateralBounds(arg0, arg1
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateDealProviderCollateralBounds", reflect.TypeOf((*MockFullNode)(nil).StateDealProviderCollateralBounds), arg0, arg1, arg2, arg3)
 }
 
+// StateDecodeMethodParams mocks base method.
+func (m *MockFullNode) StateDecodeMethodParams(arg0 context.Context, arg1 api.ActorMethod, arg2 []byte) (interface{}, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateDecodeMethodParams", arg0, arg1, arg2)
+	ret0, _ := ret[0].(interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateDecodeMethodParams indicates an expected call of StateDecodeMethodParams.
+func (mr *MockFullNodeMockRecorder) StateDecodeMethodParams(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateDecodeMethodParams", reflect.TypeOf((*MockFullNode)(nil).StateDecodeMethodParams), arg0, arg1, arg2)
+}
+
+// StateDecodeMethodReturn mocks base method.
+func (m *MockFullNode) StateDecodeMethodReturn(arg0 context.Context, arg1 api.ActorMethod, arg2 []byte) (interface{}, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateDecodeMethodReturn", arg0, arg1, arg2)
+	ret0, _ := ret[0].(interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateDecodeMethodReturn indicates an expected call of StateDecodeMethodReturn.
+func (mr *MockFullNodeMockRecorder) StateDecodeMethodReturn(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateDecodeMethodReturn", reflect.TypeOf((*MockFullNode)(nil).StateDecodeMethodReturn), arg0, arg1, arg2)
+}
+
 // StateDecodeParams mocks base method.
 func (m *MockFullNode) StateDecodeParams(arg0 context.Context, arg1 address.Address, arg2 abi.MethodNum, arg3 []byte, arg4 types.TipSetKey) (interface{}, error) {
 	m.ctrl.T.Helper()
@@ -3113,6 +4062,21 @@ func (mr *MockFullNodeMockRecorder) StateDecodeParams(arg0, arg1, arg2, arg3, ar
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateDecodeParams", reflect.TypeOf((*MockFullNode)(nil).StateDecodeParams), arg0, arg1, arg2, arg3, arg4)
 }
 
+// StateEncodeMethodParams mocks base method.
+func (m *MockFullNode) StateEncodeMethodParams(arg0 context.Context, arg1 api.ActorMethod, arg2 json.RawMessage) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateEncodeMethodParams", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateEncodeMethodParams indicates an expected call of StateEncodeMethodParams.
+func (mr *MockFullNodeMockRecorder) StateEncodeMethodParams(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateEncodeMethodParams", reflect.TypeOf((*MockFullNode)(nil).StateEncodeMethodParams), arg0, arg1, arg2)
+}
+
 // StateEncodeParams mocks base method.
 func (m *MockFullNode) StateEncodeParams(arg0 context.Context, arg1 cid.Cid, arg2 abi.MethodNum, arg3 json.RawMessage) ([]byte, error) {
 	m.ctrl.T.Helper()
@@ -3128,6 +4092,21 @@ func (mr *MockFullNodeMockRecorder) StateEncodeParams(arg0, arg1, arg2, arg3 int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateEncodeParams", reflect.TypeOf((*MockFullNode)(nil).StateEncodeParams), arg0, arg1, arg2, arg3)
 }
 
+// StateGasStats mocks base method.
+func (m *MockFullNode) StateGasStats(arg0 context.Context, arg1 api.GasStatsFilter) (*api.GasStatsResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateGasStats", arg0, arg1)
+	ret0, _ := ret[0].(*api.GasStatsResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateGasStats indicates an expected call of StateGasStats.
+func (mr *MockFullNodeMockRecorder) StateGasStats(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateGasStats", reflect.TypeOf((*MockFullNode)(nil).StateGasStats), arg0, arg1)
+}
+
 // StateGetActor mocks base method.
 func (m *MockFullNode) StateGetActor(arg0 context.Context, arg1 address.Address, arg2 types.TipSetKey) (*types.ActorV5, error) {
 	m.ctrl.T.Helper()
@@ -3248,6 +4227,21 @@ func (mr *MockFullNodeMockRecorder) StateGetNetworkParams(arg0 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateGetNetworkParams", reflect.TypeOf((*MockFullNode)(nil).StateGetNetworkParams), arg0)
 }
 
+// StateGetProof mocks base method.
+func (m *MockFullNode) StateGetProof(arg0 context.Context, arg1 address.Address, arg2 types.TipSetKey) (*api.ActorStateProof, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateGetProof", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*api.ActorStateProof)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateGetProof indicates an expected call of StateGetProof.
+func (mr *MockFullNodeMockRecorder) StateGetProof(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateGetProof", reflect.TypeOf((*MockFullNode)(nil).StateGetProof), arg0, arg1, arg2)
+}
+
 // StateGetRandomnessFromBeacon mocks base method.
 func (m *MockFullNode) StateGetRandomnessFromBeacon(arg0 context.Context, arg1 crypto.DomainSeparationTag, arg2 abi.ChainEpoch, arg3 []byte, arg4 types.TipSetKey) (abi.Randomness, error) {
 	m.ctrl.T.Helper()
@@ -3308,6 +4302,21 @@ func (mr *MockFullNodeMockRecorder) StateListMessages(arg0, arg1, arg2, arg3 int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateListMessages", reflect.TypeOf((*MockFullNode)(nil).StateListMessages), arg0, arg1, arg2, arg3)
 }
 
+// StateListMessagesFast mocks base method.
+func (m *MockFullNode) StateListMessagesFast(arg0 context.Context, arg1 api.AddressMessageQuery) (*api.AddressMessagePage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateListMessagesFast", arg0, arg1)
+	ret0, _ := ret[0].(*api.AddressMessagePage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateListMessagesFast indicates an expected call of StateListMessagesFast.
+func (mr *MockFullNodeMockRecorder) StateListMessagesFast(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateListMessagesFast", reflect.TypeOf((*MockFullNode)(nil).StateListMessagesFast), arg0, arg1)
+}
+
 // StateListMiners mocks base method.
 func (m *MockFullNode) StateListMiners(arg0 context.Context, arg1 types.TipSetKey) ([]address.Address, error) {
 	m.ctrl.T.Helper()
@@ -3323,6 +4332,21 @@ func (mr *MockFullNodeMockRecorder) StateListMiners(arg0, arg1 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateListMiners", reflect.TypeOf((*MockFullNode)(nil).StateListMiners), arg0, arg1)
 }
 
+// StateLookupDelegatedAddress mocks base method.
+func (m *MockFullNode) StateLookupDelegatedAddress(arg0 context.Context, arg1 address.Address, arg2 types.TipSetKey) (address.Address, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateLookupDelegatedAddress", arg0, arg1, arg2)
+	ret0, _ := ret[0].(address.Address)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateLookupDelegatedAddress indicates an expected call of StateLookupDelegatedAddress.
+func (mr *MockFullNodeMockRecorder) StateLookupDelegatedAddress(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateLookupDelegatedAddress", reflect.TypeOf((*MockFullNode)(nil).StateLookupDelegatedAddress), arg0, arg1, arg2)
+}
+
 // StateLookupID mocks base method.
 func (m *MockFullNode) StateLookupID(arg0 context.Context, arg1 address.Address, arg2 types.TipSetKey) (address.Address, error) {
 	m.ctrl.T.Helper()
@@ -3413,6 +4437,21 @@ func (mr *MockFullNodeMockRecorder) StateMarketStorageDeal(arg0, arg1, arg2 inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateMarketStorageDeal", reflect.TypeOf((*MockFullNode)(nil).StateMarketStorageDeal), arg0, arg1, arg2)
 }
 
+// StateMigrationDryRun mocks base method.
+func (m *MockFullNode) StateMigrationDryRun(arg0 context.Context, arg1 types.TipSetKey) (*api.MigrationDryRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateMigrationDryRun", arg0, arg1)
+	ret0, _ := ret[0].(*api.MigrationDryRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateMigrationDryRun indicates an expected call of StateMigrationDryRun.
+func (mr *MockFullNodeMockRecorder) StateMigrationDryRun(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateMigrationDryRun", reflect.TypeOf((*MockFullNode)(nil).StateMigrationDryRun), arg0, arg1)
+}
+
 // StateMinerActiveSectors mocks base method.
 func (m *MockFullNode) StateMinerActiveSectors(arg0 context.Context, arg1 address.Address, arg2 types.TipSetKey) ([]*miner.SectorOnChainInfo, error) {
 	m.ctrl.T.Helper()
@@ -3548,6 +4587,21 @@ func (mr *MockFullNodeMockRecorder) StateMinerPower(arg0, arg1, arg2 interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateMinerPower", reflect.TypeOf((*MockFullNode)(nil).StateMinerPower), arg0, arg1, arg2)
 }
 
+// StateMinerPowerHistory mocks base method.
+func (m *MockFullNode) StateMinerPowerHistory(arg0 context.Context, arg1 address.Address, arg2, arg3 abi.ChainEpoch) ([]api.MinerPowerSample, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateMinerPowerHistory", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]api.MinerPowerSample)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateMinerPowerHistory indicates an expected call of StateMinerPowerHistory.
+func (mr *MockFullNodeMockRecorder) StateMinerPowerHistory(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateMinerPowerHistory", reflect.TypeOf((*MockFullNode)(nil).StateMinerPowerHistory), arg0, arg1, arg2, arg3)
+}
+
 // StateMinerPreCommitDepositForPower mocks base method.
 func (m *MockFullNode) StateMinerPreCommitDepositForPower(arg0 context.Context, arg1 address.Address, arg2 miner.SectorPreCommitInfo, arg3 types.TipSetKey) (big.Int, error) {
 	m.ctrl.T.Helper()
@@ -3698,6 +4752,36 @@ func (mr *MockFullNodeMockRecorder) StateReplay(arg0, arg1, arg2 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateReplay", reflect.TypeOf((*MockFullNode)(nil).StateReplay), arg0, arg1, arg2)
 }
 
+// StateReplayEvents mocks base method.
+func (m *MockFullNode) StateReplayEvents(arg0 context.Context, arg1, arg2 abi.ChainEpoch) (<-chan api.EventReplayUpdate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateReplayEvents", arg0, arg1, arg2)
+	ret0, _ := ret[0].(<-chan api.EventReplayUpdate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateReplayEvents indicates an expected call of StateReplayEvents.
+func (mr *MockFullNodeMockRecorder) StateReplayEvents(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateReplayEvents", reflect.TypeOf((*MockFullNode)(nil).StateReplayEvents), arg0, arg1, arg2)
+}
+
+// StateResolveAddresses mocks base method.
+func (m *MockFullNode) StateResolveAddresses(arg0 context.Context, arg1 []address.Address) ([]api.ResolvedAddress, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StateResolveAddresses", arg0, arg1)
+	ret0, _ := ret[0].([]api.ResolvedAddress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateResolveAddresses indicates an expected call of StateResolveAddresses.
+func (mr *MockFullNodeMockRecorder) StateResolveAddresses(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateResolveAddresses", reflect.TypeOf((*MockFullNode)(nil).StateResolveAddresses), arg0, arg1)
+}
+
 // StateSearchMsg mocks base method.
 func (m *MockFullNode) StateSearchMsg(arg0 context.Context, arg1 types.TipSetKey, arg2 cid.Cid, arg3 abi.ChainEpoch, arg4 bool) (*api.MsgLookup, error) {
 	m.ctrl.T.Helper()
@@ -3978,6 +5062,35 @@ func (mr *MockFullNodeMockRecorder) SyncValidateTipset(arg0, arg1 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncValidateTipset", reflect.TypeOf((*MockFullNode)(nil).SyncValidateTipset), arg0, arg1)
 }
 
+// TenantAssignWallet mocks base method.
+func (m *MockFullNode) TenantAssignWallet(arg0 context.Context, arg1 address.Address, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TenantAssignWallet", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TenantAssignWallet indicates an expected call of TenantAssignWallet.
+func (mr *MockFullNodeMockRecorder) TenantAssignWallet(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TenantAssignWallet", reflect.TypeOf((*MockFullNode)(nil).TenantAssignWallet), arg0, arg1, arg2)
+}
+
+// TenantList mocks base method.
+func (m *MockFullNode) TenantList(arg0 context.Context) ([]api.Tenant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TenantList", arg0)
+	ret0, _ := ret[0].([]api.Tenant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TenantList indicates an expected call of TenantList.
+func (mr *MockFullNodeMockRecorder) TenantList(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TenantList", reflect.TypeOf((*MockFullNode)(nil).TenantList), arg0)
+}
+
 // Version mocks base method.
 func (m *MockFullNode) Version(arg0 context.Context) (api.APIVersion, error) {
 	m.ctrl.T.Helper()
@@ -4200,3 +5313,47 @@ func (mr *MockFullNodeMockRecorder) Web3ClientVersion(arg0 interface{}) *gomock.
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Web3ClientVersion", reflect.TypeOf((*MockFullNode)(nil).Web3ClientVersion), arg0)
 }
+
+// WebhookList mocks base method.
+func (m *MockFullNode) WebhookList(arg0 context.Context) ([]api.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WebhookList", arg0)
+	ret0, _ := ret[0].([]api.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WebhookList indicates an expected call of WebhookList.
+func (mr *MockFullNodeMockRecorder) WebhookList(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WebhookList", reflect.TypeOf((*MockFullNode)(nil).WebhookList), arg0)
+}
+
+// WebhookSubscribe mocks base method.
+func (m *MockFullNode) WebhookSubscribe(arg0 context.Context, arg1, arg2 string, arg3 []api.WebhookPattern) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WebhookSubscribe", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WebhookSubscribe indicates an expected call of WebhookSubscribe.
+func (mr *MockFullNodeMockRecorder) WebhookSubscribe(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WebhookSubscribe", reflect.TypeOf((*MockFullNode)(nil).WebhookSubscribe), arg0, arg1, arg2, arg3)
+}
+
+// WebhookUnsubscribe mocks base method.
+func (m *MockFullNode) WebhookUnsubscribe(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WebhookUnsubscribe", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WebhookUnsubscribe indicates an expected call of WebhookUnsubscribe.
+func (mr *MockFullNodeMockRecorder) WebhookUnsubscribe(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WebhookUnsubscribe", reflect.TypeOf((*MockFullNode)(nil).WebhookUnsubscribe), arg0, arg1)
+}
@@ -34,6 +34,7 @@ import (
 	"github.com/filecoin-project/go-state-types/proof"
 
 	apitypes "github.com/filecoin-project/lotus/api/types"
+	"github.com/filecoin-project/lotus/blockstore"
 	builtinactors "github.com/filecoin-project/lotus/chain/actors/builtin"
 	lminer "github.com/filecoin-project/lotus/chain/actors/builtin/miner"
 	"github.com/filecoin-project/lotus/chain/types"
@@ -73,10 +74,14 @@ type CommonMethods struct {
 
 	AuthVerify func(p0 context.Context, p1 string) ([]auth.Permission, error) `perm:"read"`
 
+	Capabilities func(p0 context.Context) (APICapabilities, error) `perm:"read"`
+
 	Closing func(p0 context.Context) (<-chan struct{}, error) `perm:"read"`
 
 	Discover func(p0 context.Context) (apitypes.OpenRPCDocument, error) `perm:"read"`
 
+	DiskForecast func(p0 context.Context) ([]DiskForecast, error) `perm:"read"`
+
 	LogAlerts func(p0 context.Context) ([]alerting.Alert, error) `perm:"admin"`
 
 	LogList func(p0 context.Context) ([]string, error) `perm:"write"`
@@ -87,6 +92,8 @@ type CommonMethods struct {
 
 	Shutdown func(p0 context.Context) error `perm:"admin"`
 
+	ShutdownStatus func(p0 context.Context) (ShutdownStatus, error) `perm:"read"`
+
 	StartTime func(p0 context.Context) (time.Time, error) `perm:"read"`
 
 	Version func(p0 context.Context) (APIVersion, error) `perm:"read"`
@@ -132,16 +139,40 @@ type FullNodeStruct struct {
 }
 
 type FullNodeMethods struct {
+	AuthListTokens func(p0 context.Context) ([]AuthToken, error) `perm:"admin"`
+
+	AuthNewNamespaced func(p0 context.Context, p1 []auth.Permission, p2 string) ([]byte, error) `perm:"admin"`
+
+	AuthNewWithScope func(p0 context.Context, p1 []auth.Permission, p2 AuthScope) ([]byte, error) `perm:"admin"`
+
+	ChainBlockTiming func(p0 context.Context) ([]PeerBlockTiming, error) `perm:"read"`
+
 	ChainBlockstoreInfo func(p0 context.Context) (map[string]interface{}, error) `perm:"read"`
 
+	ChainBlockstoreMaintain func(p0 context.Context, p1 BlockstoreMaintainOpts) (*BlockstoreMaintenanceRun, error) `perm:"admin"`
+
+	ChainBlockstoreMaintenanceStatus func(p0 context.Context) (*BlockstoreMaintenanceStatus, error) `perm:"read"`
+
+	ChainBlockstoreScrubStatus func(p0 context.Context) (*BlockstoreScrubStatus, error) `perm:"read"`
+
+	ChainCacheResize func(p0 context.Context, p1 string, p2 int) error `perm:"admin"`
+
+	ChainCacheStats func(p0 context.Context) ([]blockstore.CacheStats, error) `perm:"read"`
+
 	ChainCheckBlockstore func(p0 context.Context) error `perm:"admin"`
 
+	ChainConsensusFaults func(p0 context.Context, p1 abi.ChainEpoch) ([]ConsensusFault, error) `perm:"read"`
+
 	ChainDeleteObj func(p0 context.Context, p1 cid.Cid) error `perm:"admin"`
 
 	ChainExport func(p0 context.Context, p1 abi.ChainEpoch, p2 bool, p3 types.TipSetKey) (<-chan []byte, error) `perm:"read"`
 
+	ChainExportActorStates func(p0 context.Context, p1 abi.ChainEpoch, p2 bool, p3 []address.Address, p4 types.TipSetKey) (<-chan []byte, error) `perm:"read"`
+
 	ChainExportRangeInternal func(p0 context.Context, p1 types.TipSetKey, p2 types.TipSetKey, p3 ChainExportConfig) error `perm:"admin"`
 
+	ChainGetAttestation func(p0 context.Context, p1 cid.Cid) (*AttestationBundle, error) `perm:"read"`
+
 	ChainGetBlock func(p0 context.Context, p1 cid.Cid) (*types.BlockHeader, error) `perm:"read"`
 
 	ChainGetBlockMessages func(p0 context.Context, p1 cid.Cid) (*BlockMessages, error) `perm:"read"`
@@ -160,8 +191,14 @@ type FullNodeMethods struct {
 
 	ChainGetParentReceipts func(p0 context.Context, p1 cid.Cid) ([]*types.MessageReceipt, error) `perm:"read"`
 
+	ChainGetParentReceiptsBatch func(p0 context.Context, p1 []types.TipSetKey) ([]ParentReceipts, error) `perm:"read"`
+
 	ChainGetPath func(p0 context.Context, p1 types.TipSetKey, p2 types.TipSetKey) ([]*HeadChange, error) `perm:"read"`
 
+	ChainGetPathPage func(p0 context.Context, p1 types.TipSetKey, p2 types.TipSetKey, p3 string, p4 int) (*HeadChangePage, error) `perm:"read"`
+
+	ChainGetReceiptProof func(p0 context.Context, p1 cid.Cid) (*ReceiptProof, error) `perm:"read"`
+
 	ChainGetTipSet func(p0 context.Context, p1 types.TipSetKey) (*types.TipSet, error) `perm:"read"`
 
 	ChainGetTipSetAfterHeight func(p0 context.Context, p1 abi.ChainEpoch, p2 types.TipSetKey) (*types.TipSet, error) `perm:"read"`
@@ -174,6 +211,8 @@ type FullNodeMethods struct {
 
 	ChainHotGC func(p0 context.Context, p1 HotGCOpts) error `perm:"admin"`
 
+	ChainJournalSince func(p0 context.Context, p1 uint64, p2 int) (*ChainJournalPage, error) `perm:"read"`
+
 	ChainNotify func(p0 context.Context) (<-chan []*HeadChange, error) `perm:"read"`
 
 	ChainPrune func(p0 context.Context, p1 PruneOpts) error `perm:"admin"`
@@ -182,26 +221,44 @@ type FullNodeMethods struct {
 
 	ChainReadObj func(p0 context.Context, p1 cid.Cid) ([]byte, error) `perm:"read"`
 
+	ChainReplicaPromote func(p0 context.Context) error `perm:"admin"`
+
+	ChainReplicaStatus func(p0 context.Context) (*ReplicaStatus, error) `perm:"read"`
+
 	ChainSetHead func(p0 context.Context, p1 types.TipSetKey) error `perm:"admin"`
 
+	ChainSnapshots func(p0 context.Context) ([]ChainSnapshot, error) `perm:"read"`
+
 	ChainStatObj func(p0 context.Context, p1 cid.Cid, p2 cid.Cid) (ObjStat, error) `perm:"read"`
 
 	ChainTipSetWeight func(p0 context.Context, p1 types.TipSetKey) (types.BigInt, error) `perm:"read"`
 
+	ClientAggregatePieces func(p0 context.Context, p1 []cid.Cid, p2 abi.PaddedPieceSize, p3 string) (*PieceAggregate, error) `perm:"write"`
+
 	ClientCalcCommP func(p0 context.Context, p1 string) (*CommPRet, error) `perm:"write"`
 
 	ClientCancelDataTransfer func(p0 context.Context, p1 datatransfer.TransferID, p2 peer.ID, p3 bool) error `perm:"write"`
 
 	ClientCancelRetrievalDeal func(p0 context.Context, p1 retrievalmarket.DealID) error `perm:"write"`
 
+	ClientCreateAllocations func(p0 context.Context, p1 address.Address, p2 []verifregtypes.AllocationRequest) (cid.Cid, error) `perm:"sign"`
+
 	ClientDataTransferUpdates func(p0 context.Context) (<-chan DataTransferChannel, error) `perm:"write"`
 
 	ClientDealPieceCID func(p0 context.Context, p1 cid.Cid) (DataCIDSize, error) `perm:"read"`
 
 	ClientDealSize func(p0 context.Context, p1 cid.Cid) (DataSize, error) `perm:"read"`
 
+	ClientDealTemplateList func(p0 context.Context) ([]DealTemplate, error) `perm:"read"`
+
+	ClientDealTemplateRemove func(p0 context.Context, p1 string) error `perm:"write"`
+
+	ClientDealTemplateSet func(p0 context.Context, p1 DealTemplate) error `perm:"write"`
+
 	ClientExport func(p0 context.Context, p1 ExportRef, p2 FileRef) error `perm:"admin"`
 
+	ClientExtendClaims func(p0 context.Context, p1 address.Address, p2 []verifregtypes.ClaimTerm) (cid.Cid, error) `perm:"sign"`
+
 	ClientFindData func(p0 context.Context, p1 cid.Cid, p2 *cid.Cid) ([]QueryOffer, error) `perm:"read"`
 
 	ClientGenCar func(p0 context.Context, p1 FileRef, p2 string) error `perm:"write"`
@@ -212,34 +269,56 @@ type FullNodeMethods struct {
 
 	ClientGetDealUpdates func(p0 context.Context) (<-chan DealInfo, error) `perm:"write"`
 
+	ClientGetReplication func(p0 context.Context, p1 uuid.UUID) (*ReplicationStatus, error) `perm:"read"`
+
 	ClientGetRetrievalUpdates func(p0 context.Context) (<-chan RetrievalInfo, error) `perm:"write"`
 
 	ClientHasLocal func(p0 context.Context, p1 cid.Cid) (bool, error) `perm:"write"`
 
 	ClientImport func(p0 context.Context, p1 FileRef) (*ImportRes, error) `perm:"admin"`
 
+	ClientImportProviderReputation func(p0 context.Context, p1 []ProviderReputation, p2 bool) error `perm:"admin"`
+
+	ClientListAllocations func(p0 context.Context, p1 address.Address) ([]AllocationInfo, error) `perm:"read"`
+
+	ClientListDataPreps func(p0 context.Context) ([]DataPrepManifest, error) `perm:"read"`
+
 	ClientListDataTransfers func(p0 context.Context) ([]DataTransferChannel, error) `perm:"write"`
 
 	ClientListDeals func(p0 context.Context) ([]DealInfo, error) `perm:"write"`
 
 	ClientListImports func(p0 context.Context) ([]Import, error) `perm:"write"`
 
+	ClientListProviderReputation func(p0 context.Context) ([]ProviderReputation, error) `perm:"read"`
+
+	ClientListReplications func(p0 context.Context) ([]ReplicationStatus, error) `perm:"read"`
+
 	ClientListRetrievals func(p0 context.Context) ([]RetrievalInfo, error) `perm:"write"`
 
 	ClientMinerQueryOffer func(p0 context.Context, p1 address.Address, p2 cid.Cid, p3 *cid.Cid) (QueryOffer, error) `perm:"read"`
 
+	ClientPrepareData func(p0 context.Context, p1 string, p2 abi.PaddedPieceSize) (*DataPrepManifest, error) `perm:"admin"`
+
+	ClientProviderScores func(p0 context.Context, p1 []address.Address) ([]ProviderScore, error) `perm:"read"`
+
 	ClientQueryAsk func(p0 context.Context, p1 peer.ID, p2 address.Address) (*StorageAsk, error) `perm:"read"`
 
 	ClientRemoveImport func(p0 context.Context, p1 imports.ID) error `perm:"admin"`
 
+	ClientReplicate func(p0 context.Context, p1 *StartDealParams, p2 int, p3 ProviderSelector) (*ReplicationStatus, error) `perm:"admin"`
+
 	ClientRestartDataTransfer func(p0 context.Context, p1 datatransfer.TransferID, p2 peer.ID, p3 bool) error `perm:"write"`
 
+	ClientRetrievalResume func(p0 context.Context, p1 retrievalmarket.DealID) (*RestrievalRes, error) `perm:"admin"`
+
 	ClientRetrieve func(p0 context.Context, p1 RetrievalOrder) (*RestrievalRes, error) `perm:"admin"`
 
 	ClientRetrieveTryRestartInsufficientFunds func(p0 context.Context, p1 address.Address) error `perm:"write"`
 
 	ClientRetrieveWait func(p0 context.Context, p1 retrievalmarket.DealID) error `perm:"admin"`
 
+	ClientSetProviderTags func(p0 context.Context, p1 address.Address, p2 []string) error `perm:"write"`
+
 	ClientStartDeal func(p0 context.Context, p1 *StartDealParams) (*cid.Cid, error) `perm:"admin"`
 
 	ClientStatelessDeal func(p0 context.Context, p1 *StartDealParams) (*cid.Cid, error) `perm:"write"`
@@ -258,6 +337,12 @@ type FullNodeMethods struct {
 
 	EthEstimateGas func(p0 context.Context, p1 ethtypes.EthCall) (ethtypes.EthUint64, error) `perm:"read"`
 
+	EthEventWatchList func(p0 context.Context) (*EthEventWatchList, error) `perm:"read"`
+
+	EthEventWatchListAdd func(p0 context.Context, p1 EthEventWatchList, p2 abi.ChainEpoch) error `perm:"admin"`
+
+	EthEventWatchListRemove func(p0 context.Context, p1 EthEventWatchList) error `perm:"admin"`
+
 	EthFeeHistory func(p0 context.Context, p1 jsonrpc.RawParams) (ethtypes.EthFeeHistory, error) `perm:"read"`
 
 	EthGasPrice func(p0 context.Context) (ethtypes.EthBigInt, error) `perm:"read"`
@@ -268,6 +353,8 @@ type FullNodeMethods struct {
 
 	EthGetBlockByNumber func(p0 context.Context, p1 string, p2 bool) (ethtypes.EthBlock, error) `perm:"read"`
 
+	EthGetBlockReceipts func(p0 context.Context, p1 string) ([]*EthTxReceipt, error) `perm:"read"`
+
 	EthGetBlockTransactionCountByHash func(p0 context.Context, p1 ethtypes.EthHash) (ethtypes.EthUint64, error) `perm:"read"`
 
 	EthGetBlockTransactionCountByNumber func(p0 context.Context, p1 ethtypes.EthUint64) (ethtypes.EthUint64, error) `perm:"read"`
@@ -312,8 +399,12 @@ type FullNodeMethods struct {
 
 	EthSendRawTransaction func(p0 context.Context, p1 ethtypes.EthBytes) (ethtypes.EthHash, error) `perm:"read"`
 
+	EthSimulateRawTransaction func(p0 context.Context, p1 ethtypes.EthBytes) (*EthTxSimulation, error) `perm:"read"`
+
 	EthSubscribe func(p0 context.Context, p1 jsonrpc.RawParams) (ethtypes.EthSubscriptionID, error) `perm:"write"`
 
+	EthSyncing func(p0 context.Context) (ethtypes.EthSyncingResult, error) `perm:"read"`
+
 	EthUninstallFilter func(p0 context.Context, p1 ethtypes.EthFilterID) (bool, error) `perm:"write"`
 
 	EthUnsubscribe func(p0 context.Context, p1 ethtypes.EthSubscriptionID) (bool, error) `perm:"write"`
@@ -330,6 +421,8 @@ type FullNodeMethods struct {
 
 	MarketAddBalance func(p0 context.Context, p1 address.Address, p2 address.Address, p3 types.BigInt) (cid.Cid, error) `perm:"sign"`
 
+	MarketFundsStatus func(p0 context.Context, p1 address.Address) (MarketFundsStatus, error) `perm:"read"`
+
 	MarketGetReserved func(p0 context.Context, p1 address.Address) (types.BigInt, error) `perm:"sign"`
 
 	MarketReleaseFunds func(p0 context.Context, p1 address.Address, p2 types.BigInt) error `perm:"sign"`
@@ -356,20 +449,30 @@ type FullNodeMethods struct {
 
 	MpoolClear func(p0 context.Context, p1 bool) error `perm:"write"`
 
+	MpoolGasMarket func(p0 context.Context, p1 int) (*MpoolGasMarket, error) `perm:"read"`
+
+	MpoolGasMarketSub func(p0 context.Context, p1 int) (<-chan MpoolGasMarket, error) `perm:"read"`
+
 	MpoolGetConfig func(p0 context.Context) (*types.MpoolConfig, error) `perm:"read"`
 
 	MpoolGetNonce func(p0 context.Context, p1 address.Address) (uint64, error) `perm:"read"`
 
 	MpoolPending func(p0 context.Context, p1 types.TipSetKey) ([]*types.SignedMessage, error) `perm:"read"`
 
+	MpoolPredictCid func(p0 context.Context, p1 *types.Message, p2 address.Address) (cid.Cid, error) `perm:"sign"`
+
 	MpoolPush func(p0 context.Context, p1 *types.SignedMessage) (cid.Cid, error) `perm:"write"`
 
 	MpoolPushMessage func(p0 context.Context, p1 *types.Message, p2 *MessageSendSpec) (*types.SignedMessage, error) `perm:"sign"`
 
 	MpoolPushUntrusted func(p0 context.Context, p1 *types.SignedMessage) (cid.Cid, error) `perm:"write"`
 
+	MpoolReplace func(p0 context.Context, p1 MpoolReplaceSpec) (*MpoolReplaceResult, error) `perm:"sign"`
+
 	MpoolSelect func(p0 context.Context, p1 types.TipSetKey, p2 float64) ([]*types.SignedMessage, error) `perm:"read"`
 
+	MpoolSelectPreview func(p0 context.Context, p1 types.TipSetKey, p2 float64) (*MpoolSelectPreview, error) `perm:"read"`
+
 	MpoolSetConfig func(p0 context.Context, p1 *types.MpoolConfig) error `perm:"admin"`
 
 	MpoolSub func(p0 context.Context) (<-chan MpoolUpdate, error) `perm:"read"`
@@ -458,10 +561,14 @@ type FullNodeMethods struct {
 
 	StateActorManifestCID func(p0 context.Context, p1 abinetwork.Version) (cid.Cid, error) `perm:"read"`
 
+	StateActorSchema func(p0 context.Context, p1 cid.Cid) (*ActorSchema, error) `perm:"read"`
+
 	StateAllMinerFaults func(p0 context.Context, p1 abi.ChainEpoch, p2 types.TipSetKey) ([]*Fault, error) `perm:"read"`
 
 	StateCall func(p0 context.Context, p1 *types.Message, p2 types.TipSetKey) (*InvocResult, error) `perm:"read"`
 
+	StateCallWithOverrides func(p0 context.Context, p1 *types.Message, p2 []ActorOverride, p3 types.TipSetKey) (*InvocResult, error) `perm:"read"`
+
 	StateChangedActors func(p0 context.Context, p1 cid.Cid, p2 cid.Cid) (map[string]types.Actor, error) `perm:"read"`
 
 	StateCirculatingSupply func(p0 context.Context, p1 types.TipSetKey) (abi.TokenAmount, error) `perm:"read"`
@@ -472,10 +579,18 @@ type FullNodeMethods struct {
 
 	StateDealProviderCollateralBounds func(p0 context.Context, p1 abi.PaddedPieceSize, p2 bool, p3 types.TipSetKey) (DealCollateralBounds, error) `perm:"read"`
 
+	StateDecodeMethodParams func(p0 context.Context, p1 ActorMethod, p2 []byte) (interface{}, error) `perm:"read"`
+
+	StateDecodeMethodReturn func(p0 context.Context, p1 ActorMethod, p2 []byte) (interface{}, error) `perm:"read"`
+
 	StateDecodeParams func(p0 context.Context, p1 address.Address, p2 abi.MethodNum, p3 []byte, p4 types.TipSetKey) (interface{}, error) `perm:"read"`
 
+	StateEncodeMethodParams func(p0 context.Context, p1 ActorMethod, p2 json.RawMessage) ([]byte, error) `perm:"read"`
+
 	StateEncodeParams func(p0 context.Context, p1 cid.Cid, p2 abi.MethodNum, p3 json.RawMessage) ([]byte, error) `perm:"read"`
 
+	StateGasStats func(p0 context.Context, p1 GasStatsFilter) (*GasStatsResult, error) `perm:"read"`
+
 	StateGetActor func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*types.Actor, error) `perm:"read"`
 
 	StateGetAllocation func(p0 context.Context, p1 address.Address, p2 verifregtypes.AllocationId, p3 types.TipSetKey) (*verifregtypes.Allocation, error) `perm:"read"`
@@ -492,6 +607,8 @@ type FullNodeMethods struct {
 
 	StateGetNetworkParams func(p0 context.Context) (*NetworkParams, error) `perm:"read"`
 
+	StateGetProof func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*ActorStateProof, error) `perm:"read"`
+
 	StateGetRandomnessFromBeacon func(p0 context.Context, p1 crypto.DomainSeparationTag, p2 abi.ChainEpoch, p3 []byte, p4 types.TipSetKey) (abi.Randomness, error) `perm:"read"`
 
 	StateGetRandomnessFromTickets func(p0 context.Context, p1 crypto.DomainSeparationTag, p2 abi.ChainEpoch, p3 []byte, p4 types.TipSetKey) (abi.Randomness, error) `perm:"read"`
@@ -500,8 +617,12 @@ type FullNodeMethods struct {
 
 	StateListMessages func(p0 context.Context, p1 *MessageMatch, p2 types.TipSetKey, p3 abi.ChainEpoch) ([]cid.Cid, error) `perm:"read"`
 
+	StateListMessagesFast func(p0 context.Context, p1 AddressMessageQuery) (*AddressMessagePage, error) `perm:"read"`
+
 	StateListMiners func(p0 context.Context, p1 types.TipSetKey) ([]address.Address, error) `perm:"read"`
 
+	StateLookupDelegatedAddress func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (address.Address, error) `perm:"read"`
+
 	StateLookupID func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (address.Address, error) `perm:"read"`
 
 	StateLookupRobustAddress func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (address.Address, error) `perm:"read"`
@@ -514,6 +635,8 @@ type FullNodeMethods struct {
 
 	StateMarketStorageDeal func(p0 context.Context, p1 abi.DealID, p2 types.TipSetKey) (*MarketDeal, error) `perm:"read"`
 
+	StateMigrationDryRun func(p0 context.Context, p1 types.TipSetKey) (*MigrationDryRun, error) `perm:"admin"`
+
 	StateMinerActiveSectors func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) ([]*miner.SectorOnChainInfo, error) `perm:"read"`
 
 	StateMinerAllocated func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*bitfield.BitField, error) `perm:"read"`
@@ -532,6 +655,8 @@ type FullNodeMethods struct {
 
 	StateMinerPower func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*MinerPower, error) `perm:"read"`
 
+	StateMinerPowerHistory func(p0 context.Context, p1 address.Address, p2 abi.ChainEpoch, p3 abi.ChainEpoch) ([]MinerPowerSample, error) `perm:"read"`
+
 	StateMinerPreCommitDepositForPower func(p0 context.Context, p1 address.Address, p2 miner.SectorPreCommitInfo, p3 types.TipSetKey) (types.BigInt, error) `perm:"read"`
 
 	StateMinerProvingDeadline func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*dline.Info, error) `perm:"read"`
@@ -552,6 +677,10 @@ type FullNodeMethods struct {
 
 	StateReplay func(p0 context.Context, p1 types.TipSetKey, p2 cid.Cid) (*InvocResult, error) `perm:"read"`
 
+	StateReplayEvents func(p0 context.Context, p1 abi.ChainEpoch, p2 abi.ChainEpoch) (<-chan EventReplayUpdate, error) `perm:"read"`
+
+	StateResolveAddresses func(p0 context.Context, p1 []address.Address) ([]ResolvedAddress, error) `perm:"read"`
+
 	StateSearchMsg func(p0 context.Context, p1 types.TipSetKey, p2 cid.Cid, p3 abi.ChainEpoch, p4 bool) (*MsgLookup, error) `perm:"read"`
 
 	StateSectorExpiration func(p0 context.Context, p1 address.Address, p2 abi.SectorNumber, p3 types.TipSetKey) (*lminer.SectorExpiration, error) `perm:"read"`
@@ -590,6 +719,10 @@ type FullNodeMethods struct {
 
 	SyncValidateTipset func(p0 context.Context, p1 types.TipSetKey) (bool, error) `perm:"read"`
 
+	TenantAssignWallet func(p0 context.Context, p1 address.Address, p2 string) error `perm:"admin"`
+
+	TenantList func(p0 context.Context) ([]Tenant, error) `perm:"admin"`
+
 	WalletBalance func(p0 context.Context, p1 address.Address) (types.BigInt, error) `perm:"read"`
 
 	WalletDefaultAddress func(p0 context.Context) (address.Address, error) `perm:"write"`
@@ -617,6 +750,12 @@ type FullNodeMethods struct {
 	WalletVerify func(p0 context.Context, p1 address.Address, p2 []byte, p3 *crypto.Signature) (bool, error) `perm:"read"`
 
 	Web3ClientVersion func(p0 context.Context) (string, error) `perm:"read"`
+
+	WebhookList func(p0 context.Context) ([]WebhookSubscription, error) `perm:"admin"`
+
+	WebhookSubscribe func(p0 context.Context, p1 string, p2 string, p3 []WebhookPattern) (uuid.UUID, error) `perm:"admin"`
+
+	WebhookUnsubscribe func(p0 context.Context, p1 uuid.UUID) error `perm:"admin"`
 }
 
 type FullNodeStub struct {
@@ -640,6 +779,8 @@ type GatewayMethods struct {
 
 	ChainGetParentReceipts func(p0 context.Context, p1 cid.Cid) ([]*types.MessageReceipt, error) ``
 
+	ChainGetParentReceiptsBatch func(p0 context.Context, p1 []types.TipSetKey) ([]ParentReceipts, error) ``
+
 	ChainGetPath func(p0 context.Context, p1 types.TipSetKey, p2 types.TipSetKey) ([]*HeadChange, error) ``
 
 	ChainGetTipSet func(p0 context.Context, p1 types.TipSetKey) (*types.TipSet, error) ``
@@ -680,6 +821,8 @@ type GatewayMethods struct {
 
 	EthGetBlockByNumber func(p0 context.Context, p1 string, p2 bool) (ethtypes.EthBlock, error) ``
 
+	EthGetBlockReceipts func(p0 context.Context, p1 string) ([]*EthTxReceipt, error) ``
+
 	EthGetBlockTransactionCountByHash func(p0 context.Context, p1 ethtypes.EthHash) (ethtypes.EthUint64, error) ``
 
 	EthGetBlockTransactionCountByNumber func(p0 context.Context, p1 ethtypes.EthUint64) (ethtypes.EthUint64, error) ``
@@ -720,8 +863,12 @@ type GatewayMethods struct {
 
 	EthSendRawTransaction func(p0 context.Context, p1 ethtypes.EthBytes) (ethtypes.EthHash, error) ``
 
+	EthSimulateRawTransaction func(p0 context.Context, p1 ethtypes.EthBytes) (*EthTxSimulation, error) ``
+
 	EthSubscribe func(p0 context.Context, p1 jsonrpc.RawParams) (ethtypes.EthSubscriptionID, error) ``
 
+	EthSyncing func(p0 context.Context) (ethtypes.EthSyncingResult, error) ``
+
 	EthUninstallFilter func(p0 context.Context, p1 ethtypes.EthFilterID) (bool, error) ``
 
 	EthUnsubscribe func(p0 context.Context, p1 ethtypes.EthSubscriptionID) (bool, error) ``
@@ -811,6 +958,12 @@ type NetMethods struct {
 
 	NetAgentVersion func(p0 context.Context, p1 peer.ID) (string, error) `perm:"read"`
 
+	NetAllowAdd func(p0 context.Context, p1 []peer.AddrInfo) error `perm:"admin"`
+
+	NetAllowList func(p0 context.Context) ([]peer.AddrInfo, error) `perm:"read"`
+
+	NetAllowRemove func(p0 context.Context, p1 []peer.ID) error `perm:"admin"`
+
 	NetAutoNatStatus func(p0 context.Context) (NatInfo, error) `perm:"read"`
 
 	NetBandwidthStats func(p0 context.Context) (metrics.Stats, error) `perm:"read"`
@@ -833,10 +986,14 @@ type NetMethods struct {
 
 	NetFindPeer func(p0 context.Context, p1 peer.ID) (peer.AddrInfo, error) `perm:"read"`
 
+	NetGetReconnectPolicy func(p0 context.Context) (NetReconnectPolicy, error) `perm:"read"`
+
 	NetLimit func(p0 context.Context, p1 string) (NetLimit, error) `perm:"read"`
 
 	NetPeerInfo func(p0 context.Context, p1 peer.ID) (*ExtendedPeerInfo, error) `perm:"read"`
 
+	NetPeerScores func(p0 context.Context) ([]PeerScore, error) `perm:"read"`
+
 	NetPeers func(p0 context.Context) ([]peer.AddrInfo, error) `perm:"read"`
 
 	NetPing func(p0 context.Context, p1 peer.ID) (time.Duration, error) `perm:"read"`
@@ -847,10 +1004,18 @@ type NetMethods struct {
 
 	NetProtectRemove func(p0 context.Context, p1 []peer.ID) error `perm:"admin"`
 
+	NetPubsubRejectedMessages func(p0 context.Context, p1 string) ([]PubsubRejectedMessage, error) `perm:"read"`
+
+	NetPubsubRejections func(p0 context.Context) ([]PubsubTopicRejections, error) `perm:"read"`
+
 	NetPubsubScores func(p0 context.Context) ([]PubsubScore, error) `perm:"read"`
 
 	NetSetLimit func(p0 context.Context, p1 string, p2 NetLimit) error `perm:"admin"`
 
+	NetSetPeerTag func(p0 context.Context, p1 peer.ID, p2 string, p3 int) error `perm:"admin"`
+
+	NetSetReconnectPolicy func(p0 context.Context, p1 NetReconnectPolicy) error `perm:"admin"`
+
 	NetStat func(p0 context.Context, p1 string) (NetStat, error) `perm:"read"`
 }
 
@@ -879,8 +1044,14 @@ type StorageMinerStruct struct {
 type StorageMinerMethods struct {
 	ActorAddress func(p0 context.Context) (address.Address, error) `perm:"read"`
 
+	ActorAddressBalances func(p0 context.Context) (AddressBalanceStatus, error) `perm:"read"`
+
 	ActorAddressConfig func(p0 context.Context) (AddressConfig, error) `perm:"read"`
 
+	ActorAddressRotationPlan func(p0 context.Context, p1 AddressRotation) (*AddressRotationPlan, error) `perm:"read"`
+
+	ActorAddressRotationStep func(p0 context.Context, p1 AddressRotation, p2 address.Address) (cid.Cid, error) `perm:"admin"`
+
 	ActorSectorSize func(p0 context.Context, p1 address.Address) (abi.SectorSize, error) `perm:"read"`
 
 	ActorWithdrawBalance func(p0 context.Context, p1 abi.TokenAmount) (cid.Cid, error) `perm:"admin"`
@@ -911,6 +1082,8 @@ type StorageMinerMethods struct {
 
 	DagstoreRegisterShard func(p0 context.Context, p1 string) error `perm:"admin"`
 
+	DagstoreShardDiagnostics func(p0 context.Context, p1 cid.Cid) (*DagstoreShardDiagnostics, error) `perm:"admin"`
+
 	DealsConsiderOfflineRetrievalDeals func(p0 context.Context) (bool, error) `perm:"admin"`
 
 	DealsConsiderOfflineStorageDeals func(p0 context.Context) (bool, error) `perm:"admin"`
@@ -947,52 +1120,122 @@ type StorageMinerMethods struct {
 
 	IndexerAnnounceDeal func(p0 context.Context, p1 cid.Cid) error `perm:"admin"`
 
+	MarketAskHistory func(p0 context.Context) ([]StorageAskChange, error) `perm:"read"`
+
 	MarketCancelDataTransfer func(p0 context.Context, p1 datatransfer.TransferID, p2 peer.ID, p3 bool) error `perm:"write"`
 
+	MarketCancelScheduledAsk func(p0 context.Context, p1 abi.ChainEpoch) error `perm:"admin"`
+
+	MarketCommPDiagnostics func(p0 context.Context, p1 cid.Cid) (*CommPDiagnostics, error) `perm:"read"`
+
 	MarketDataTransferDiagnostics func(p0 context.Context, p1 peer.ID) (*TransferDiagnostics, error) `perm:"write"`
 
+	MarketDataTransferRestarts func(p0 context.Context) ([]DataTransferRestartHistory, error) `perm:"read"`
+
 	MarketDataTransferUpdates func(p0 context.Context) (<-chan DataTransferChannel, error) `perm:"write"`
 
+	MarketDealSLAReport func(p0 context.Context) (*DealSLAReport, error) `perm:"read"`
+
+	MarketDealTransferStatus func(p0 context.Context, p1 cid.Cid) (*DealTransferStatus, error) `perm:"read"`
+
+	MarketDealTransferUpdates func(p0 context.Context) (<-chan DealTransferStatus, error) `perm:"read"`
+
 	MarketGetAsk func(p0 context.Context) (*storagemarket.SignedStorageAsk, error) `perm:"read"`
 
+	MarketGetAskSpec func(p0 context.Context) (*StorageAskSpec, error) `perm:"read"`
+
 	MarketGetDealUpdates func(p0 context.Context) (<-chan storagemarket.MinerDeal, error) `perm:"read"`
 
+	MarketGetLabels func(p0 context.Context, p1 cid.Cid) (map[string]string, error) `perm:"read"`
+
 	MarketGetRetrievalAsk func(p0 context.Context) (*retrievalmarket.Ask, error) `perm:"read"`
 
 	MarketImportDealData func(p0 context.Context, p1 cid.Cid, p2 string) error `perm:"write"`
 
+	MarketListCommPDiagnostics func(p0 context.Context) ([]CommPDiagnostics, error) `perm:"read"`
+
 	MarketListDataTransfers func(p0 context.Context) ([]DataTransferChannel, error) `perm:"write"`
 
 	MarketListDeals func(p0 context.Context) ([]*MarketDeal, error) `perm:"read"`
 
+	MarketListDealsFiltered func(p0 context.Context, p1 MarketDealFilter) ([]LabeledMarketDeal, error) `perm:"read"`
+
 	MarketListIncompleteDeals func(p0 context.Context) ([]storagemarket.MinerDeal, error) `perm:"read"`
 
 	MarketListRetrievalDeals func(p0 context.Context) ([]struct{}, error) `perm:"read"`
 
+	MarketListRetrievalTimelines func(p0 context.Context, p1 int) ([]RetrievalTimeline, error) `perm:"read"`
+
+	MarketListScheduledAsks func(p0 context.Context) ([]ScheduledStorageAsk, error) `perm:"read"`
+
 	MarketPendingDeals func(p0 context.Context) (PendingDealInfo, error) `perm:"write"`
 
 	MarketPublishPendingDeals func(p0 context.Context) error `perm:"admin"`
 
 	MarketRestartDataTransfer func(p0 context.Context, p1 datatransfer.TransferID, p2 peer.ID, p3 bool) error `perm:"write"`
 
+	MarketRetrievabilityCheck func(p0 context.Context, p1 int) ([]RetrievabilitySample, error) `perm:"admin"`
+
+	MarketRetrievabilitySamples func(p0 context.Context, p1 int) ([]RetrievabilitySample, error) `perm:"read"`
+
+	MarketRetrievalStats func(p0 context.Context, p1 int) (*RetrievalStats, error) `perm:"read"`
+
+	MarketRetrievalTimeline func(p0 context.Context, p1 peer.ID, p2 retrievalmarket.DealID) (*RetrievalTimeline, error) `perm:"read"`
+
 	MarketRetryPublishDeal func(p0 context.Context, p1 cid.Cid) error `perm:"admin"`
 
+	MarketScheduleAsk func(p0 context.Context, p1 abi.ChainEpoch, p2 StorageAskSpec) error `perm:"admin"`
+
 	MarketSetAsk func(p0 context.Context, p1 types.BigInt, p2 types.BigInt, p3 abi.ChainEpoch, p4 abi.PaddedPieceSize, p5 abi.PaddedPieceSize) error `perm:"admin"`
 
+	MarketSetAskSpec func(p0 context.Context, p1 StorageAskSpec) error `perm:"admin"`
+
+	MarketSetLabels func(p0 context.Context, p1 cid.Cid, p2 map[string]string) error `perm:"write"`
+
 	MarketSetRetrievalAsk func(p0 context.Context, p1 *retrievalmarket.Ask) error `perm:"admin"`
 
+	MinerBlocksReport func(p0 context.Context, p1 abi.ChainEpoch, p2 abi.ChainEpoch) (*MinerBlocksReport, error) `perm:"read"`
+
+	MinerSelfTest func(p0 context.Context) (*SelfTestReport, error) `perm:"admin"`
+
+	MinerSpendReport func(p0 context.Context, p1 abi.ChainEpoch, p2 abi.ChainEpoch, p3 abi.ChainEpoch) (*SpendReport, error) `perm:"read"`
+
 	MiningBase func(p0 context.Context) (*types.TipSet, error) `perm:"read"`
 
+	ParamsRepair func(p0 context.Context) error `perm:"admin"`
+
+	ParamsStatus func(p0 context.Context) ([]ProofParamStatus, error) `perm:"read"`
+
+	PiecesGetBatch func(p0 context.Context, p1 []cid.Cid) ([]PieceInfoResult, error) `perm:"read"`
+
 	PiecesGetCIDInfo func(p0 context.Context, p1 cid.Cid) (*piecestore.CIDInfo, error) `perm:"read"`
 
 	PiecesGetPieceInfo func(p0 context.Context, p1 cid.Cid) (*piecestore.PieceInfo, error) `perm:"read"`
 
+	PiecesGetRootCids func(p0 context.Context, p1 cid.Cid) ([]cid.Cid, error) `perm:"read"`
+
 	PiecesListCidInfos func(p0 context.Context) ([]cid.Cid, error) `perm:"read"`
 
 	PiecesListPieces func(p0 context.Context) ([]cid.Cid, error) `perm:"read"`
 
 	PledgeSector func(p0 context.Context) (abi.SectorID, error) `perm:"write"`
 
+	ProvingDeadlineLoad func(p0 context.Context) (*DeadlineLoadReport, error) `perm:"read"`
+
+	ProvingDetectedFaults func(p0 context.Context) ([]DetectedFault, error) `perm:"read"`
+
+	ProvingDetectedFaultsApprove func(p0 context.Context, p1 []abi.SectorNumber) error `perm:"admin"`
+
+	ProvingDetectedFaultsReject func(p0 context.Context, p1 []abi.SectorNumber) error `perm:"admin"`
+
+	ProvingDisputes func(p0 context.Context) ([]WdPoStDispute, error) `perm:"read"`
+
+	ProvingDisputesAck func(p0 context.Context, p1 []cid.Cid) error `perm:"admin"`
+
+	ProvingRebalanceCompact func(p0 context.Context) ([]cid.Cid, error) `perm:"admin"`
+
+	ProvingRebalancePlan func(p0 context.Context, p1 uint64) (*DeadlineRebalancePlan, error) `perm:"read"`
+
 	RecoverFault func(p0 context.Context, p1 []abi.SectorNumber) ([]cid.Cid, error) `perm:"admin"`
 
 	ReturnAddPiece func(p0 context.Context, p1 storiface.CallID, p2 abi.PieceInfo, p3 *storiface.CallError) error `perm:"admin"`
@@ -1029,20 +1272,28 @@ type StorageMinerMethods struct {
 
 	ReturnSealPreCommit2 func(p0 context.Context, p1 storiface.CallID, p2 storiface.SectorCids, p3 *storiface.CallError) error `perm:"admin"`
 
+	ReturnTaskLogs func(p0 context.Context, p1 storiface.TaskLog) error `perm:"admin"`
+
 	ReturnUnsealPiece func(p0 context.Context, p1 storiface.CallID, p2 *storiface.CallError) error `perm:"admin"`
 
 	RuntimeSubsystems func(p0 context.Context) (MinerSubsystems, error) `perm:"read"`
 
 	SealingAbort func(p0 context.Context, p1 storiface.CallID) error `perm:"admin"`
 
+	SealingJobsTree func(p0 context.Context) (storiface.JobsTree, error) `perm:"admin"`
+
 	SealingRemoveRequest func(p0 context.Context, p1 uuid.UUID) error `perm:"admin"`
 
 	SealingSchedDiag func(p0 context.Context, p1 bool) (interface{}, error) `perm:"admin"`
 
+	SealingTaskLogs func(p0 context.Context, p1 abi.SectorNumber, p2 sealtasks.TaskType) ([]storiface.TaskLog, error) `perm:"admin"`
+
 	SectorAbortUpgrade func(p0 context.Context, p1 abi.SectorNumber) error `perm:"admin"`
 
 	SectorAddPieceToAny func(p0 context.Context, p1 abi.UnpaddedPieceSize, p2 storiface.Data, p3 PieceDealInfo) (SectorOffset, error) `perm:"admin"`
 
+	SectorCommitAggregationSimulate func(p0 context.Context) (*sealiface.CommitAggregationSimulation, error) `perm:"read"`
+
 	SectorCommitFlush func(p0 context.Context) ([]sealiface.CommitBatchRes, error) `perm:"admin"`
 
 	SectorCommitPending func(p0 context.Context) ([]abi.SectorID, error) `perm:"admin"`
@@ -1071,6 +1322,8 @@ type StorageMinerMethods struct {
 
 	SectorReceive func(p0 context.Context, p1 RemoteSectorMeta) error `perm:"admin"`
 
+	SectorReceiveBatch func(p0 context.Context, p1 []RemoteSectorMeta) ([]SectorReceiveResult, error) `perm:"admin"`
+
 	SectorRemove func(p0 context.Context, p1 abi.SectorNumber) error `perm:"admin"`
 
 	SectorSetExpectedSealDuration func(p0 context.Context, p1 time.Duration) error `perm:"write"`
@@ -1087,18 +1340,34 @@ type StorageMinerMethods struct {
 
 	SectorUnseal func(p0 context.Context, p1 abi.SectorNumber) error `perm:"admin"`
 
+	SectorsCostEstimate func(p0 context.Context, p1 abi.RegisteredSealProof, p2 SectorDealMix, p3 abi.ChainEpoch) (*SectorCostEstimate, error) `perm:"read"`
+
 	SectorsList func(p0 context.Context) ([]abi.SectorNumber, error) `perm:"read"`
 
 	SectorsListInStates func(p0 context.Context, p1 []SectorState) ([]abi.SectorNumber, error) `perm:"read"`
 
+	SectorsPendingSubmission func(p0 context.Context) ([]PendingSubmission, error) `perm:"read"`
+
+	SectorsQuery func(p0 context.Context, p1 SectorQuery) ([]SectorQueryResult, error) `perm:"read"`
+
 	SectorsRefs func(p0 context.Context) (map[string][]SealedRef, error) `perm:"read"`
 
+	SectorsRetryPolicy func(p0 context.Context, p1 abi.SectorNumber) (SectorRetryPolicy, error) `perm:"read"`
+
 	SectorsStatus func(p0 context.Context, p1 abi.SectorNumber, p2 bool) (SectorInfo, error) `perm:"read"`
 
+	SectorsStatusBatch func(p0 context.Context, p1 []abi.SectorNumber, p2 bool) ([]SectorStatusResult, error) `perm:"read"`
+
 	SectorsSummary func(p0 context.Context) (map[SectorState]int, error) `perm:"read"`
 
 	SectorsUnsealPiece func(p0 context.Context, p1 storiface.SectorRef, p2 storiface.UnpaddedByteIndex, p3 abi.UnpaddedPieceSize, p4 abi.SealRandomness, p5 *cid.Cid) error `perm:"admin"`
 
+	SectorsUnsealRegenApprove func(p0 context.Context, p1 []abi.SectorNumber) error `perm:"admin"`
+
+	SectorsUnsealRegenList func(p0 context.Context) ([]UnsealRegenJob, error) `perm:"read"`
+
+	SectorsUnsealRegenReject func(p0 context.Context, p1 []abi.SectorNumber) error `perm:"admin"`
+
 	SectorsUpdate func(p0 context.Context, p1 abi.SectorNumber, p2 SectorState) error `perm:"admin"`
 
 	StorageAddLocal func(p0 context.Context, p1 string) error `perm:"admin"`
@@ -1129,6 +1398,8 @@ type StorageMinerMethods struct {
 
 	StorageLock func(p0 context.Context, p1 abi.SectorID, p2 storiface.SectorFileType, p3 storiface.SectorFileType) error `perm:"admin"`
 
+	StoragePlacementReport func(p0 context.Context) (*storiface.PlacementReport, error) `perm:"read"`
+
 	StorageRedeclareLocal func(p0 context.Context, p1 *storiface.ID, p2 bool) error `perm:"admin"`
 
 	StorageReportHealth func(p0 context.Context, p1 storiface.ID, p2 storiface.HealthReport) error `perm:"admin"`
@@ -1139,9 +1410,13 @@ type StorageMinerMethods struct {
 
 	WorkerConnect func(p0 context.Context, p1 string) error `perm:"admin"`
 
+	WorkerDrain func(p0 context.Context, p1 uuid.UUID) error `perm:"admin"`
+
 	WorkerJobs func(p0 context.Context) (map[uuid.UUID][]storiface.WorkerJob, error) `perm:"admin"`
 
 	WorkerStats func(p0 context.Context) (map[uuid.UUID]storiface.WorkerStats, error) `perm:"admin"`
+
+	WorkerUndrain func(p0 context.Context, p1 uuid.UUID) error `perm:"admin"`
 }
 
 type StorageMinerStub struct {
@@ -1180,6 +1455,8 @@ type WorkerStruct struct {
 type WorkerMethods struct {
 	AddPiece func(p0 context.Context, p1 storiface.SectorRef, p2 []abi.UnpaddedPieceSize, p3 abi.UnpaddedPieceSize, p4 storiface.Data) (storiface.CallID, error) `perm:"admin"`
 
+	Capabilities func(p0 context.Context) (APICapabilities, error) `perm:"admin"`
+
 	DataCid func(p0 context.Context, p1 abi.UnpaddedPieceSize, p2 storiface.Data) (storiface.CallID, error) `perm:"admin"`
 
 	DownloadSectorData func(p0 context.Context, p1 storiface.SectorRef, p2 bool, p3 map[storiface.SectorFileType]storiface.SectorLocation) (storiface.CallID, error) `perm:"admin"`
@@ -1311,6 +1588,17 @@ func (s *CommonStub) AuthVerify(p0 context.Context, p1 string) ([]auth.Permissio
 	return *new([]auth.Permission), ErrNotSupported
 }
 
+func (s *CommonStruct) Capabilities(p0 context.Context) (APICapabilities, error) {
+	if s.Internal.Capabilities == nil {
+		return *new(APICapabilities), ErrNotSupported
+	}
+	return s.Internal.Capabilities(p0)
+}
+
+func (s *CommonStub) Capabilities(p0 context.Context) (APICapabilities, error) {
+	return *new(APICapabilities), ErrNotSupported
+}
+
 func (s *CommonStruct) Closing(p0 context.Context) (<-chan struct{}, error) {
 	if s.Internal.Closing == nil {
 		return nil, ErrNotSupported
@@ -1333,6 +1621,17 @@ func (s *CommonStub) Discover(p0 context.Context) (apitypes.OpenRPCDocument, err
 	return *new(apitypes.OpenRPCDocument), ErrNotSupported
 }
 
+func (s *CommonStruct) DiskForecast(p0 context.Context) ([]DiskForecast, error) {
+	if s.Internal.DiskForecast == nil {
+		return *new([]DiskForecast), ErrNotSupported
+	}
+	return s.Internal.DiskForecast(p0)
+}
+
+func (s *CommonStub) DiskForecast(p0 context.Context) ([]DiskForecast, error) {
+	return *new([]DiskForecast), ErrNotSupported
+}
+
 func (s *CommonStruct) LogAlerts(p0 context.Context) ([]alerting.Alert, error) {
 	if s.Internal.LogAlerts == nil {
 		return *new([]alerting.Alert), ErrNotSupported
@@ -1388,6 +1687,17 @@ func (s *CommonStub) Shutdown(p0 context.Context) error {
 	return ErrNotSupported
 }
 
+func (s *CommonStruct) ShutdownStatus(p0 context.Context) (ShutdownStatus, error) {
+	if s.Internal.ShutdownStatus == nil {
+		return *new(ShutdownStatus), ErrNotSupported
+	}
+	return s.Internal.ShutdownStatus(p0)
+}
+
+func (s *CommonStub) ShutdownStatus(p0 context.Context) (ShutdownStatus, error) {
+	return *new(ShutdownStatus), ErrNotSupported
+}
+
 func (s *CommonStruct) StartTime(p0 context.Context) (time.Time, error) {
 	if s.Internal.StartTime == nil {
 		return *new(time.Time), ErrNotSupported
@@ -1421,6 +1731,50 @@ func (s *EthSubscriberStub) EthSubscription(p0 context.Context, p1 jsonrpc.RawPa
 	return ErrNotSupported
 }
 
+func (s *FullNodeStruct) AuthListTokens(p0 context.Context) ([]AuthToken, error) {
+	if s.Internal.AuthListTokens == nil {
+		return *new([]AuthToken), ErrNotSupported
+	}
+	return s.Internal.AuthListTokens(p0)
+}
+
+func (s *FullNodeStub) AuthListTokens(p0 context.Context) ([]AuthToken, error) {
+	return *new([]AuthToken), ErrNotSupported
+}
+
+func (s *FullNodeStruct) AuthNewNamespaced(p0 context.Context, p1 []auth.Permission, p2 string) ([]byte, error) {
+	if s.Internal.AuthNewNamespaced == nil {
+		return *new([]byte), ErrNotSupported
+	}
+	return s.Internal.AuthNewNamespaced(p0, p1, p2)
+}
+
+func (s *FullNodeStub) AuthNewNamespaced(p0 context.Context, p1 []auth.Permission, p2 string) ([]byte, error) {
+	return *new([]byte), ErrNotSupported
+}
+
+func (s *FullNodeStruct) AuthNewWithScope(p0 context.Context, p1 []auth.Permission, p2 AuthScope) ([]byte, error) {
+	if s.Internal.AuthNewWithScope == nil {
+		return *new([]byte), ErrNotSupported
+	}
+	return s.Internal.AuthNewWithScope(p0, p1, p2)
+}
+
+func (s *FullNodeStub) AuthNewWithScope(p0 context.Context, p1 []auth.Permission, p2 AuthScope) ([]byte, error) {
+	return *new([]byte), ErrNotSupported
+}
+
+func (s *FullNodeStruct) ChainBlockTiming(p0 context.Context) ([]PeerBlockTiming, error) {
+	if s.Internal.ChainBlockTiming == nil {
+		return *new([]PeerBlockTiming), ErrNotSupported
+	}
+	return s.Internal.ChainBlockTiming(p0)
+}
+
+func (s *FullNodeStub) ChainBlockTiming(p0 context.Context) ([]PeerBlockTiming, error) {
+	return *new([]PeerBlockTiming), ErrNotSupported
+}
+
 func (s *FullNodeStruct) ChainBlockstoreInfo(p0 context.Context) (map[string]interface{}, error) {
 	if s.Internal.ChainBlockstoreInfo == nil {
 		return *new(map[string]interface{}), ErrNotSupported
@@ -1432,6 +1786,61 @@ func (s *FullNodeStub) ChainBlockstoreInfo(p0 context.Context) (map[string]inter
 	return *new(map[string]interface{}), ErrNotSupported
 }
 
+func (s *FullNodeStruct) ChainBlockstoreMaintain(p0 context.Context, p1 BlockstoreMaintainOpts) (*BlockstoreMaintenanceRun, error) {
+	if s.Internal.ChainBlockstoreMaintain == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.ChainBlockstoreMaintain(p0, p1)
+}
+
+func (s *FullNodeStub) ChainBlockstoreMaintain(p0 context.Context, p1 BlockstoreMaintainOpts) (*BlockstoreMaintenanceRun, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *FullNodeStruct) ChainBlockstoreMaintenanceStatus(p0 context.Context) (*BlockstoreMaintenanceStatus, error) {
+	if s.Internal.ChainBlockstoreMaintenanceStatus == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.ChainBlockstoreMaintenanceStatus(p0)
+}
+
+func (s *FullNodeStub) ChainBlockstoreMaintenanceStatus(p0 context.Context) (*BlockstoreMaintenanceStatus, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *FullNodeStruct) ChainBlockstoreScrubStatus(p0 context.Context) (*BlockstoreScrubStatus, error) {
+	if s.Internal.ChainBlockstoreScrubStatus == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.ChainBlockstoreScrubStatus(p0)
+}
+
+func (s *FullNodeStub) ChainBlockstoreScrubStatus(p0 context.Context) (*BlockstoreScrubStatus, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *FullNodeStruct) ChainCacheResize(p0 context.Context, p1 string, p2 int) error {
+	if s.Internal.ChainCacheResize == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.ChainCacheResize(p0, p1, p2)
+}
+
+func (s *FullNodeStub) ChainCacheResize(p0 context.Context, p1 string, p2 int) error {
+	return ErrNotSupported
+}
+
+func (s *FullNodeStruct) ChainCacheStats(p0 context.Context) ([]blockstore.CacheStats, error) {
+	if s.Internal.ChainCacheStats == nil {
+		return *new([]blockstore.CacheStats), ErrNotSupported
+	}
+	return s.Internal.ChainCacheStats(p0)
+}
+
+func (s *FullNodeStub) ChainCacheStats(p0 context.Context) ([]blockstore.CacheStats, error) {
+	return *new([]blockstore.CacheStats), ErrNotSupported
+}
+
 func (s *FullNodeStruct) ChainCheckBlockstore(p0 context.Context) error {
 	if s.Internal.ChainCheckBlockstore == nil {
 		return ErrNotSupported
@@ -1443,6 +1852,17 @@ func (s *FullNodeStub) ChainCheckBlockstore(p0 context.Context) error {
 	return ErrNotSupported
 }
 
+func (s *FullNodeStruct) ChainConsensusFaults(p0 context.Context, p1 abi.ChainEpoch) ([]ConsensusFault, error) {
+	if s.Internal.ChainConsensusFaults == nil {
+		return *new([]ConsensusFault), ErrNotSupported
+	}
+	return s.Internal.ChainConsensusFaults(p0, p1)
+}
+
+func (s *FullNodeStub) ChainConsensusFaults(p0 context.Context, p1 abi.ChainEpoch) ([]ConsensusFault, error) {
+	return *new([]ConsensusFault), ErrNotSupported
+}
+
 func (s *FullNodeStruct) ChainDeleteObj(p0 context.Context, p1 cid.Cid) error {
 	if s.Internal.ChainDeleteObj == nil {
 		return ErrNotSupported
@@ -1465,6 +1885,17 @@ func (s *FullNodeStub) ChainExport(p0 context.Context, p1 abi.ChainEpoch, p2 boo
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) ChainExportActorStates(p0 context.Context, p1 abi.ChainEpoch, p2 bool, p3 []address.Address, p4 types.TipSetKey) (<-chan []byte, error) {
+	if s.Internal.ChainExportActorStates == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.ChainExportActorStates(p0, p1, p2, p3, p4)
+}
+
+func (s *FullNodeStub) ChainExportActorStates(p0 context.Context, p1 abi.ChainEpoch, p2 bool, p3 []address.Address, p4 types.TipSetKey) (<-chan []byte, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) ChainExportRangeInternal(p0 context.Context, p1 types.TipSetKey, p2 types.TipSetKey, p3 ChainExportConfig) error {
 	if s.Internal.ChainExportRangeInternal == nil {
 		return ErrNotSupported
@@ -1476,6 +1907,17 @@ func (s *FullNodeStub) ChainExportRangeInternal(p0 context.Context, p1 types.Tip
 	return ErrNotSupported
 }
 
+func (s *FullNodeStruct) ChainGetAttestation(p0 context.Context, p1 cid.Cid) (*AttestationBundle, error) {
+	if s.Internal.ChainGetAttestation == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.ChainGetAttestation(p0, p1)
+}
+
+func (s *FullNodeStub) ChainGetAttestation(p0 context.Context, p1 cid.Cid) (*AttestationBundle, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) ChainGetBlock(p0 context.Context, p1 cid.Cid) (*types.BlockHeader, error) {
 	if s.Internal.ChainGetBlock == nil {
 		return nil, ErrNotSupported
@@ -1575,6 +2017,17 @@ func (s *FullNodeStub) ChainGetParentReceipts(p0 context.Context, p1 cid.Cid) ([
 	return *new([]*types.MessageReceipt), ErrNotSupported
 }
 
+func (s *FullNodeStruct) ChainGetParentReceiptsBatch(p0 context.Context, p1 []types.TipSetKey) ([]ParentReceipts, error) {
+	if s.Internal.ChainGetParentReceiptsBatch == nil {
+		return *new([]ParentReceipts), ErrNotSupported
+	}
+	return s.Internal.ChainGetParentReceiptsBatch(p0, p1)
+}
+
+func (s *FullNodeStub) ChainGetParentReceiptsBatch(p0 context.Context, p1 []types.TipSetKey) ([]ParentReceipts, error) {
+	return *new([]ParentReceipts), ErrNotSupported
+}
+
 func (s *FullNodeStruct) ChainGetPath(p0 context.Context, p1 types.TipSetKey, p2 types.TipSetKey) ([]*HeadChange, error) {
 	if s.Internal.ChainGetPath == nil {
 		return *new([]*HeadChange), ErrNotSupported
@@ -1586,6 +2039,28 @@ func (s *FullNodeStub) ChainGetPath(p0 context.Context, p1 types.TipSetKey, p2 t
 	return *new([]*HeadChange), ErrNotSupported
 }
 
+func (s *FullNodeStruct) ChainGetPathPage(p0 context.Context, p1 types.TipSetKey, p2 types.TipSetKey, p3 string, p4 int) (*HeadChangePage, error) {
+	if s.Internal.ChainGetPathPage == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.ChainGetPathPage(p0, p1, p2, p3, p4)
+}
+
+func (s *FullNodeStub) ChainGetPathPage(p0 context.Context, p1 types.TipSetKey, p2 types.TipSetKey, p3 string, p4 int) (*HeadChangePage, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *FullNodeStruct) ChainGetReceiptProof(p0 context.Context, p1 cid.Cid) (*ReceiptProof, error) {
+	if s.Internal.ChainGetReceiptProof == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.ChainGetReceiptProof(p0, p1)
+}
+
+func (s *FullNodeStub) ChainGetReceiptProof(p0 context.Context, p1 cid.Cid) (*ReceiptProof, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) ChainGetTipSet(p0 context.Context, p1 types.TipSetKey) (*types.TipSet, error) {
 	if s.Internal.ChainGetTipSet == nil {
 		return nil, ErrNotSupported
@@ -1652,6 +2127,17 @@ func (s *FullNodeStub) ChainHotGC(p0 context.Context, p1 HotGCOpts) error {
 	return ErrNotSupported
 }
 
+func (s *FullNodeStruct) ChainJournalSince(p0 context.Context, p1 uint64, p2 int) (*ChainJournalPage, error) {
+	if s.Internal.ChainJournalSince == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.ChainJournalSince(p0, p1, p2)
+}
+
+func (s *FullNodeStub) ChainJournalSince(p0 context.Context, p1 uint64, p2 int) (*ChainJournalPage, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) ChainNotify(p0 context.Context) (<-chan []*HeadChange, error) {
 	if s.Internal.ChainNotify == nil {
 		return nil, ErrNotSupported
@@ -1696,7 +2182,29 @@ func (s *FullNodeStub) ChainReadObj(p0 context.Context, p1 cid.Cid) ([]byte, err
 	return *new([]byte), ErrNotSupported
 }
 
-func (s *FullNodeStruct) ChainSetHead(p0 context.Context, p1 types.TipSetKey) error {
+func (s *FullNodeStruct) ChainReplicaPromote(p0 context.Context) error {
+	if s.Internal.ChainReplicaPromote == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.ChainReplicaPromote(p0)
+}
+
+func (s *FullNodeStub) ChainReplicaPromote(p0 context.Context) error {
+	return ErrNotSupported
+}
+
+func (s *FullNodeStruct) ChainReplicaStatus(p0 context.Context) (*ReplicaStatus, error) {
+	if s.Internal.ChainReplicaStatus == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.ChainReplicaStatus(p0)
+}
+
+func (s *FullNodeStub) ChainReplicaStatus(p0 context.Context) (*ReplicaStatus, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *FullNodeStruct) ChainSetHead(p0 context.Context, p1 types.TipSetKey) error {
 	if s.Internal.ChainSetHead == nil {
 		return ErrNotSupported
 	}
@@ -1707,6 +2215,17 @@ func (s *FullNodeStub) ChainSetHead(p0 context.Context, p1 types.TipSetKey) erro
 	return ErrNotSupported
 }
 
+func (s *FullNodeStruct) ChainSnapshots(p0 context.Context) ([]ChainSnapshot, error) {
+	if s.Internal.ChainSnapshots == nil {
+		return *new([]ChainSnapshot), ErrNotSupported
+	}
+	return s.Internal.ChainSnapshots(p0)
+}
+
+func (s *FullNodeStub) ChainSnapshots(p0 context.Context) ([]ChainSnapshot, error) {
+	return *new([]ChainSnapshot), ErrNotSupported
+}
+
 func (s *FullNodeStruct) ChainStatObj(p0 context.Context, p1 cid.Cid, p2 cid.Cid) (ObjStat, error) {
 	if s.Internal.ChainStatObj == nil {
 		return *new(ObjStat), ErrNotSupported
@@ -1729,6 +2248,17 @@ func (s *FullNodeStub) ChainTipSetWeight(p0 context.Context, p1 types.TipSetKey)
 	return *new(types.BigInt), ErrNotSupported
 }
 
+func (s *FullNodeStruct) ClientAggregatePieces(p0 context.Context, p1 []cid.Cid, p2 abi.PaddedPieceSize, p3 string) (*PieceAggregate, error) {
+	if s.Internal.ClientAggregatePieces == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.ClientAggregatePieces(p0, p1, p2, p3)
+}
+
+func (s *FullNodeStub) ClientAggregatePieces(p0 context.Context, p1 []cid.Cid, p2 abi.PaddedPieceSize, p3 string) (*PieceAggregate, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) ClientCalcCommP(p0 context.Context, p1 string) (*CommPRet, error) {
 	if s.Internal.ClientCalcCommP == nil {
 		return nil, ErrNotSupported
@@ -1762,6 +2292,17 @@ func (s *FullNodeStub) ClientCancelRetrievalDeal(p0 context.Context, p1 retrieva
 	return ErrNotSupported
 }
 
+func (s *FullNodeStruct) ClientCreateAllocations(p0 context.Context, p1 address.Address, p2 []verifregtypes.AllocationRequest) (cid.Cid, error) {
+	if s.Internal.ClientCreateAllocations == nil {
+		return *new(cid.Cid), ErrNotSupported
+	}
+	return s.Internal.ClientCreateAllocations(p0, p1, p2)
+}
+
+func (s *FullNodeStub) ClientCreateAllocations(p0 context.Context, p1 address.Address, p2 []verifregtypes.AllocationRequest) (cid.Cid, error) {
+	return *new(cid.Cid), ErrNotSupported
+}
+
 func (s *FullNodeStruct) ClientDataTransferUpdates(p0 context.Context) (<-chan DataTransferChannel, error) {
 	if s.Internal.ClientDataTransferUpdates == nil {
 		return nil, ErrNotSupported
@@ -1795,6 +2336,39 @@ func (s *FullNodeStub) ClientDealSize(p0 context.Context, p1 cid.Cid) (DataSize,
 	return *new(DataSize), ErrNotSupported
 }
 
+func (s *FullNodeStruct) ClientDealTemplateList(p0 context.Context) ([]DealTemplate, error) {
+	if s.Internal.ClientDealTemplateList == nil {
+		return *new([]DealTemplate), ErrNotSupported
+	}
+	return s.Internal.ClientDealTemplateList(p0)
+}
+
+func (s *FullNodeStub) ClientDealTemplateList(p0 context.Context) ([]DealTemplate, error) {
+	return *new([]DealTemplate), ErrNotSupported
+}
+
+func (s *FullNodeStruct) ClientDealTemplateRemove(p0 context.Context, p1 string) error {
+	if s.Internal.ClientDealTemplateRemove == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.ClientDealTemplateRemove(p0, p1)
+}
+
+func (s *FullNodeStub) ClientDealTemplateRemove(p0 context.Context, p1 string) error {
+	return ErrNotSupported
+}
+
+func (s *FullNodeStruct) ClientDealTemplateSet(p0 context.Context, p1 DealTemplate) error {
+	if s.Internal.ClientDealTemplateSet == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.ClientDealTemplateSet(p0, p1)
+}
+
+func (s *FullNodeStub) ClientDealTemplateSet(p0 context.Context, p1 DealTemplate) error {
+	return ErrNotSupported
+}
+
 func (s *FullNodeStruct) ClientExport(p0 context.Context, p1 ExportRef, p2 FileRef) error {
 	if s.Internal.ClientExport == nil {
 		return ErrNotSupported
@@ -1806,6 +2380,17 @@ func (s *FullNodeStub) ClientExport(p0 context.Context, p1 ExportRef, p2 FileRef
 	return ErrNotSupported
 }
 
+func (s *FullNodeStruct) ClientExtendClaims(p0 context.Context, p1 address.Address, p2 []verifregtypes.ClaimTerm) (cid.Cid, error) {
+	if s.Internal.ClientExtendClaims == nil {
+		return *new(cid.Cid), ErrNotSupported
+	}
+	return s.Internal.ClientExtendClaims(p0, p1, p2)
+}
+
+func (s *FullNodeStub) ClientExtendClaims(p0 context.Context, p1 address.Address, p2 []verifregtypes.ClaimTerm) (cid.Cid, error) {
+	return *new(cid.Cid), ErrNotSupported
+}
+
 func (s *FullNodeStruct) ClientFindData(p0 context.Context, p1 cid.Cid, p2 *cid.Cid) ([]QueryOffer, error) {
 	if s.Internal.ClientFindData == nil {
 		return *new([]QueryOffer), ErrNotSupported
@@ -1861,6 +2446,17 @@ func (s *FullNodeStub) ClientGetDealUpdates(p0 context.Context) (<-chan DealInfo
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) ClientGetReplication(p0 context.Context, p1 uuid.UUID) (*ReplicationStatus, error) {
+	if s.Internal.ClientGetReplication == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.ClientGetReplication(p0, p1)
+}
+
+func (s *FullNodeStub) ClientGetReplication(p0 context.Context, p1 uuid.UUID) (*ReplicationStatus, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) ClientGetRetrievalUpdates(p0 context.Context) (<-chan RetrievalInfo, error) {
 	if s.Internal.ClientGetRetrievalUpdates == nil {
 		return nil, ErrNotSupported
@@ -1894,6 +2490,39 @@ func (s *FullNodeStub) ClientImport(p0 context.Context, p1 FileRef) (*ImportRes,
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) ClientImportProviderReputation(p0 context.Context, p1 []ProviderReputation, p2 bool) error {
+	if s.Internal.ClientImportProviderReputation == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.ClientImportProviderReputation(p0, p1, p2)
+}
+
+func (s *FullNodeStub) ClientImportProviderReputation(p0 context.Context, p1 []ProviderReputation, p2 bool) error {
+	return ErrNotSupported
+}
+
+func (s *FullNodeStruct) ClientListAllocations(p0 context.Context, p1 address.Address) ([]AllocationInfo, error) {
+	if s.Internal.ClientListAllocations == nil {
+		return *new([]AllocationInfo), ErrNotSupported
+	}
+	return s.Internal.ClientListAllocations(p0, p1)
+}
+
+func (s *FullNodeStub) ClientListAllocations(p0 context.Context, p1 address.Address) ([]AllocationInfo, error) {
+	return *new([]AllocationInfo), ErrNotSupported
+}
+
+func (s *FullNodeStruct) ClientListDataPreps(p0 context.Context) ([]DataPrepManifest, error) {
+	if s.Internal.ClientListDataPreps == nil {
+		return *new([]DataPrepManifest), ErrNotSupported
+	}
+	return s.Internal.ClientListDataPreps(p0)
+}
+
+func (s *FullNodeStub) ClientListDataPreps(p0 context.Context) ([]DataPrepManifest, error) {
+	return *new([]DataPrepManifest), ErrNotSupported
+}
+
 func (s *FullNodeStruct) ClientListDataTransfers(p0 context.Context) ([]DataTransferChannel, error) {
 	if s.Internal.ClientListDataTransfers == nil {
 		return *new([]DataTransferChannel), ErrNotSupported
@@ -1927,6 +2556,28 @@ func (s *FullNodeStub) ClientListImports(p0 context.Context) ([]Import, error) {
 	return *new([]Import), ErrNotSupported
 }
 
+func (s *FullNodeStruct) ClientListProviderReputation(p0 context.Context) ([]ProviderReputation, error) {
+	if s.Internal.ClientListProviderReputation == nil {
+		return *new([]ProviderReputation), ErrNotSupported
+	}
+	return s.Internal.ClientListProviderReputation(p0)
+}
+
+func (s *FullNodeStub) ClientListProviderReputation(p0 context.Context) ([]ProviderReputation, error) {
+	return *new([]ProviderReputation), ErrNotSupported
+}
+
+func (s *FullNodeStruct) ClientListReplications(p0 context.Context) ([]ReplicationStatus, error) {
+	if s.Internal.ClientListReplications == nil {
+		return *new([]ReplicationStatus), ErrNotSupported
+	}
+	return s.Internal.ClientListReplications(p0)
+}
+
+func (s *FullNodeStub) ClientListReplications(p0 context.Context) ([]ReplicationStatus, error) {
+	return *new([]ReplicationStatus), ErrNotSupported
+}
+
 func (s *FullNodeStruct) ClientListRetrievals(p0 context.Context) ([]RetrievalInfo, error) {
 	if s.Internal.ClientListRetrievals == nil {
 		return *new([]RetrievalInfo), ErrNotSupported
@@ -1949,6 +2600,28 @@ func (s *FullNodeStub) ClientMinerQueryOffer(p0 context.Context, p1 address.Addr
 	return *new(QueryOffer), ErrNotSupported
 }
 
+func (s *FullNodeStruct) ClientPrepareData(p0 context.Context, p1 string, p2 abi.PaddedPieceSize) (*DataPrepManifest, error) {
+	if s.Internal.ClientPrepareData == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.ClientPrepareData(p0, p1, p2)
+}
+
+func (s *FullNodeStub) ClientPrepareData(p0 context.Context, p1 string, p2 abi.PaddedPieceSize) (*DataPrepManifest, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *FullNodeStruct) ClientProviderScores(p0 context.Context, p1 []address.Address) ([]ProviderScore, error) {
+	if s.Internal.ClientProviderScores == nil {
+		return *new([]ProviderScore), ErrNotSupported
+	}
+	return s.Internal.ClientProviderScores(p0, p1)
+}
+
+func (s *FullNodeStub) ClientProviderScores(p0 context.Context, p1 []address.Address) ([]ProviderScore, error) {
+	return *new([]ProviderScore), ErrNotSupported
+}
+
 func (s *FullNodeStruct) ClientQueryAsk(p0 context.Context, p1 peer.ID, p2 address.Address) (*StorageAsk, error) {
 	if s.Internal.ClientQueryAsk == nil {
 		return nil, ErrNotSupported
@@ -1971,6 +2644,17 @@ func (s *FullNodeStub) ClientRemoveImport(p0 context.Context, p1 imports.ID) err
 	return ErrNotSupported
 }
 
+func (s *FullNodeStruct) ClientReplicate(p0 context.Context, p1 *StartDealParams, p2 int, p3 ProviderSelector) (*ReplicationStatus, error) {
+	if s.Internal.ClientReplicate == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.ClientReplicate(p0, p1, p2, p3)
+}
+
+func (s *FullNodeStub) ClientReplicate(p0 context.Context, p1 *StartDealParams, p2 int, p3 ProviderSelector) (*ReplicationStatus, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) ClientRestartDataTransfer(p0 context.Context, p1 datatransfer.TransferID, p2 peer.ID, p3 bool) error {
 	if s.Internal.ClientRestartDataTransfer == nil {
 		return ErrNotSupported
@@ -1982,6 +2666,17 @@ func (s *FullNodeStub) ClientRestartDataTransfer(p0 context.Context, p1 datatran
 	return ErrNotSupported
 }
 
+func (s *FullNodeStruct) ClientRetrievalResume(p0 context.Context, p1 retrievalmarket.DealID) (*RestrievalRes, error) {
+	if s.Internal.ClientRetrievalResume == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.ClientRetrievalResume(p0, p1)
+}
+
+func (s *FullNodeStub) ClientRetrievalResume(p0 context.Context, p1 retrievalmarket.DealID) (*RestrievalRes, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) ClientRetrieve(p0 context.Context, p1 RetrievalOrder) (*RestrievalRes, error) {
 	if s.Internal.ClientRetrieve == nil {
 		return nil, ErrNotSupported
@@ -2015,6 +2710,17 @@ func (s *FullNodeStub) ClientRetrieveWait(p0 context.Context, p1 retrievalmarket
 	return ErrNotSupported
 }
 
+func (s *FullNodeStruct) ClientSetProviderTags(p0 context.Context, p1 address.Address, p2 []string) error {
+	if s.Internal.ClientSetProviderTags == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.ClientSetProviderTags(p0, p1, p2)
+}
+
+func (s *FullNodeStub) ClientSetProviderTags(p0 context.Context, p1 address.Address, p2 []string) error {
+	return ErrNotSupported
+}
+
 func (s *FullNodeStruct) ClientStartDeal(p0 context.Context, p1 *StartDealParams) (*cid.Cid, error) {
 	if s.Internal.ClientStartDeal == nil {
 		return nil, ErrNotSupported
@@ -2114,6 +2820,39 @@ func (s *FullNodeStub) EthEstimateGas(p0 context.Context, p1 ethtypes.EthCall) (
 	return *new(ethtypes.EthUint64), ErrNotSupported
 }
 
+func (s *FullNodeStruct) EthEventWatchList(p0 context.Context) (*EthEventWatchList, error) {
+	if s.Internal.EthEventWatchList == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.EthEventWatchList(p0)
+}
+
+func (s *FullNodeStub) EthEventWatchList(p0 context.Context) (*EthEventWatchList, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *FullNodeStruct) EthEventWatchListAdd(p0 context.Context, p1 EthEventWatchList, p2 abi.ChainEpoch) error {
+	if s.Internal.EthEventWatchListAdd == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.EthEventWatchListAdd(p0, p1, p2)
+}
+
+func (s *FullNodeStub) EthEventWatchListAdd(p0 context.Context, p1 EthEventWatchList, p2 abi.ChainEpoch) error {
+	return ErrNotSupported
+}
+
+func (s *FullNodeStruct) EthEventWatchListRemove(p0 context.Context, p1 EthEventWatchList) error {
+	if s.Internal.EthEventWatchListRemove == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.EthEventWatchListRemove(p0, p1)
+}
+
+func (s *FullNodeStub) EthEventWatchListRemove(p0 context.Context, p1 EthEventWatchList) error {
+	return ErrNotSupported
+}
+
 func (s *FullNodeStruct) EthFeeHistory(p0 context.Context, p1 jsonrpc.RawParams) (ethtypes.EthFeeHistory, error) {
 	if s.Internal.EthFeeHistory == nil {
 		return *new(ethtypes.EthFeeHistory), ErrNotSupported
@@ -2169,6 +2908,17 @@ func (s *FullNodeStub) EthGetBlockByNumber(p0 context.Context, p1 string, p2 boo
 	return *new(ethtypes.EthBlock), ErrNotSupported
 }
 
+func (s *FullNodeStruct) EthGetBlockReceipts(p0 context.Context, p1 string) ([]*EthTxReceipt, error) {
+	if s.Internal.EthGetBlockReceipts == nil {
+		return *new([]*EthTxReceipt), ErrNotSupported
+	}
+	return s.Internal.EthGetBlockReceipts(p0, p1)
+}
+
+func (s *FullNodeStub) EthGetBlockReceipts(p0 context.Context, p1 string) ([]*EthTxReceipt, error) {
+	return *new([]*EthTxReceipt), ErrNotSupported
+}
+
 func (s *FullNodeStruct) EthGetBlockTransactionCountByHash(p0 context.Context, p1 ethtypes.EthHash) (ethtypes.EthUint64, error) {
 	if s.Internal.EthGetBlockTransactionCountByHash == nil {
 		return *new(ethtypes.EthUint64), ErrNotSupported
@@ -2411,6 +3161,17 @@ func (s *FullNodeStub) EthSendRawTransaction(p0 context.Context, p1 ethtypes.Eth
 	return *new(ethtypes.EthHash), ErrNotSupported
 }
 
+func (s *FullNodeStruct) EthSimulateRawTransaction(p0 context.Context, p1 ethtypes.EthBytes) (*EthTxSimulation, error) {
+	if s.Internal.EthSimulateRawTransaction == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.EthSimulateRawTransaction(p0, p1)
+}
+
+func (s *FullNodeStub) EthSimulateRawTransaction(p0 context.Context, p1 ethtypes.EthBytes) (*EthTxSimulation, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) EthSubscribe(p0 context.Context, p1 jsonrpc.RawParams) (ethtypes.EthSubscriptionID, error) {
 	if s.Internal.EthSubscribe == nil {
 		return *new(ethtypes.EthSubscriptionID), ErrNotSupported
@@ -2422,6 +3183,17 @@ func (s *FullNodeStub) EthSubscribe(p0 context.Context, p1 jsonrpc.RawParams) (e
 	return *new(ethtypes.EthSubscriptionID), ErrNotSupported
 }
 
+func (s *FullNodeStruct) EthSyncing(p0 context.Context) (ethtypes.EthSyncingResult, error) {
+	if s.Internal.EthSyncing == nil {
+		return *new(ethtypes.EthSyncingResult), ErrNotSupported
+	}
+	return s.Internal.EthSyncing(p0)
+}
+
+func (s *FullNodeStub) EthSyncing(p0 context.Context) (ethtypes.EthSyncingResult, error) {
+	return *new(ethtypes.EthSyncingResult), ErrNotSupported
+}
+
 func (s *FullNodeStruct) EthUninstallFilter(p0 context.Context, p1 ethtypes.EthFilterID) (bool, error) {
 	if s.Internal.EthUninstallFilter == nil {
 		return false, ErrNotSupported
@@ -2510,6 +3282,17 @@ func (s *FullNodeStub) MarketAddBalance(p0 context.Context, p1 address.Address,
 	return *new(cid.Cid), ErrNotSupported
 }
 
+func (s *FullNodeStruct) MarketFundsStatus(p0 context.Context, p1 address.Address) (MarketFundsStatus, error) {
+	if s.Internal.MarketFundsStatus == nil {
+		return *new(MarketFundsStatus), ErrNotSupported
+	}
+	return s.Internal.MarketFundsStatus(p0, p1)
+}
+
+func (s *FullNodeStub) MarketFundsStatus(p0 context.Context, p1 address.Address) (MarketFundsStatus, error) {
+	return *new(MarketFundsStatus), ErrNotSupported
+}
+
 func (s *FullNodeStruct) MarketGetReserved(p0 context.Context, p1 address.Address) (types.BigInt, error) {
 	if s.Internal.MarketGetReserved == nil {
 		return *new(types.BigInt), ErrNotSupported
@@ -2653,6 +3436,28 @@ func (s *FullNodeStub) MpoolClear(p0 context.Context, p1 bool) error {
 	return ErrNotSupported
 }
 
+func (s *FullNodeStruct) MpoolGasMarket(p0 context.Context, p1 int) (*MpoolGasMarket, error) {
+	if s.Internal.MpoolGasMarket == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.MpoolGasMarket(p0, p1)
+}
+
+func (s *FullNodeStub) MpoolGasMarket(p0 context.Context, p1 int) (*MpoolGasMarket, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *FullNodeStruct) MpoolGasMarketSub(p0 context.Context, p1 int) (<-chan MpoolGasMarket, error) {
+	if s.Internal.MpoolGasMarketSub == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.MpoolGasMarketSub(p0, p1)
+}
+
+func (s *FullNodeStub) MpoolGasMarketSub(p0 context.Context, p1 int) (<-chan MpoolGasMarket, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) MpoolGetConfig(p0 context.Context) (*types.MpoolConfig, error) {
 	if s.Internal.MpoolGetConfig == nil {
 		return nil, ErrNotSupported
@@ -2686,6 +3491,17 @@ func (s *FullNodeStub) MpoolPending(p0 context.Context, p1 types.TipSetKey) ([]*
 	return *new([]*types.SignedMessage), ErrNotSupported
 }
 
+func (s *FullNodeStruct) MpoolPredictCid(p0 context.Context, p1 *types.Message, p2 address.Address) (cid.Cid, error) {
+	if s.Internal.MpoolPredictCid == nil {
+		return *new(cid.Cid), ErrNotSupported
+	}
+	return s.Internal.MpoolPredictCid(p0, p1, p2)
+}
+
+func (s *FullNodeStub) MpoolPredictCid(p0 context.Context, p1 *types.Message, p2 address.Address) (cid.Cid, error) {
+	return *new(cid.Cid), ErrNotSupported
+}
+
 func (s *FullNodeStruct) MpoolPush(p0 context.Context, p1 *types.SignedMessage) (cid.Cid, error) {
 	if s.Internal.MpoolPush == nil {
 		return *new(cid.Cid), ErrNotSupported
@@ -2719,6 +3535,17 @@ func (s *FullNodeStub) MpoolPushUntrusted(p0 context.Context, p1 *types.SignedMe
 	return *new(cid.Cid), ErrNotSupported
 }
 
+func (s *FullNodeStruct) MpoolReplace(p0 context.Context, p1 MpoolReplaceSpec) (*MpoolReplaceResult, error) {
+	if s.Internal.MpoolReplace == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.MpoolReplace(p0, p1)
+}
+
+func (s *FullNodeStub) MpoolReplace(p0 context.Context, p1 MpoolReplaceSpec) (*MpoolReplaceResult, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) MpoolSelect(p0 context.Context, p1 types.TipSetKey, p2 float64) ([]*types.SignedMessage, error) {
 	if s.Internal.MpoolSelect == nil {
 		return *new([]*types.SignedMessage), ErrNotSupported
@@ -2730,6 +3557,17 @@ func (s *FullNodeStub) MpoolSelect(p0 context.Context, p1 types.TipSetKey, p2 fl
 	return *new([]*types.SignedMessage), ErrNotSupported
 }
 
+func (s *FullNodeStruct) MpoolSelectPreview(p0 context.Context, p1 types.TipSetKey, p2 float64) (*MpoolSelectPreview, error) {
+	if s.Internal.MpoolSelectPreview == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.MpoolSelectPreview(p0, p1, p2)
+}
+
+func (s *FullNodeStub) MpoolSelectPreview(p0 context.Context, p1 types.TipSetKey, p2 float64) (*MpoolSelectPreview, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) MpoolSetConfig(p0 context.Context, p1 *types.MpoolConfig) error {
 	if s.Internal.MpoolSetConfig == nil {
 		return ErrNotSupported
@@ -3214,6 +4052,17 @@ func (s *FullNodeStub) StateActorManifestCID(p0 context.Context, p1 abinetwork.V
 	return *new(cid.Cid), ErrNotSupported
 }
 
+func (s *FullNodeStruct) StateActorSchema(p0 context.Context, p1 cid.Cid) (*ActorSchema, error) {
+	if s.Internal.StateActorSchema == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.StateActorSchema(p0, p1)
+}
+
+func (s *FullNodeStub) StateActorSchema(p0 context.Context, p1 cid.Cid) (*ActorSchema, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) StateAllMinerFaults(p0 context.Context, p1 abi.ChainEpoch, p2 types.TipSetKey) ([]*Fault, error) {
 	if s.Internal.StateAllMinerFaults == nil {
 		return *new([]*Fault), ErrNotSupported
@@ -3236,6 +4085,17 @@ func (s *FullNodeStub) StateCall(p0 context.Context, p1 *types.Message, p2 types
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) StateCallWithOverrides(p0 context.Context, p1 *types.Message, p2 []ActorOverride, p3 types.TipSetKey) (*InvocResult, error) {
+	if s.Internal.StateCallWithOverrides == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.StateCallWithOverrides(p0, p1, p2, p3)
+}
+
+func (s *FullNodeStub) StateCallWithOverrides(p0 context.Context, p1 *types.Message, p2 []ActorOverride, p3 types.TipSetKey) (*InvocResult, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) StateChangedActors(p0 context.Context, p1 cid.Cid, p2 cid.Cid) (map[string]types.Actor, error) {
 	if s.Internal.StateChangedActors == nil {
 		return *new(map[string]types.Actor), ErrNotSupported
@@ -3291,6 +4151,28 @@ func (s *FullNodeStub) StateDealProviderCollateralBounds(p0 context.Context, p1
 	return *new(DealCollateralBounds), ErrNotSupported
 }
 
+func (s *FullNodeStruct) StateDecodeMethodParams(p0 context.Context, p1 ActorMethod, p2 []byte) (interface{}, error) {
+	if s.Internal.StateDecodeMethodParams == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.StateDecodeMethodParams(p0, p1, p2)
+}
+
+func (s *FullNodeStub) StateDecodeMethodParams(p0 context.Context, p1 ActorMethod, p2 []byte) (interface{}, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *FullNodeStruct) StateDecodeMethodReturn(p0 context.Context, p1 ActorMethod, p2 []byte) (interface{}, error) {
+	if s.Internal.StateDecodeMethodReturn == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.StateDecodeMethodReturn(p0, p1, p2)
+}
+
+func (s *FullNodeStub) StateDecodeMethodReturn(p0 context.Context, p1 ActorMethod, p2 []byte) (interface{}, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) StateDecodeParams(p0 context.Context, p1 address.Address, p2 abi.MethodNum, p3 []byte, p4 types.TipSetKey) (interface{}, error) {
 	if s.Internal.StateDecodeParams == nil {
 		return nil, ErrNotSupported
@@ -3302,6 +4184,17 @@ func (s *FullNodeStub) StateDecodeParams(p0 context.Context, p1 address.Address,
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) StateEncodeMethodParams(p0 context.Context, p1 ActorMethod, p2 json.RawMessage) ([]byte, error) {
+	if s.Internal.StateEncodeMethodParams == nil {
+		return *new([]byte), ErrNotSupported
+	}
+	return s.Internal.StateEncodeMethodParams(p0, p1, p2)
+}
+
+func (s *FullNodeStub) StateEncodeMethodParams(p0 context.Context, p1 ActorMethod, p2 json.RawMessage) ([]byte, error) {
+	return *new([]byte), ErrNotSupported
+}
+
 func (s *FullNodeStruct) StateEncodeParams(p0 context.Context, p1 cid.Cid, p2 abi.MethodNum, p3 json.RawMessage) ([]byte, error) {
 	if s.Internal.StateEncodeParams == nil {
 		return *new([]byte), ErrNotSupported
@@ -3313,6 +4206,17 @@ func (s *FullNodeStub) StateEncodeParams(p0 context.Context, p1 cid.Cid, p2 abi.
 	return *new([]byte), ErrNotSupported
 }
 
+func (s *FullNodeStruct) StateGasStats(p0 context.Context, p1 GasStatsFilter) (*GasStatsResult, error) {
+	if s.Internal.StateGasStats == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.StateGasStats(p0, p1)
+}
+
+func (s *FullNodeStub) StateGasStats(p0 context.Context, p1 GasStatsFilter) (*GasStatsResult, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) StateGetActor(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*types.Actor, error) {
 	if s.Internal.StateGetActor == nil {
 		return nil, ErrNotSupported
@@ -3401,6 +4305,17 @@ func (s *FullNodeStub) StateGetNetworkParams(p0 context.Context) (*NetworkParams
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) StateGetProof(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*ActorStateProof, error) {
+	if s.Internal.StateGetProof == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.StateGetProof(p0, p1, p2)
+}
+
+func (s *FullNodeStub) StateGetProof(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*ActorStateProof, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) StateGetRandomnessFromBeacon(p0 context.Context, p1 crypto.DomainSeparationTag, p2 abi.ChainEpoch, p3 []byte, p4 types.TipSetKey) (abi.Randomness, error) {
 	if s.Internal.StateGetRandomnessFromBeacon == nil {
 		return *new(abi.Randomness), ErrNotSupported
@@ -3445,8 +4360,19 @@ func (s *FullNodeStub) StateListMessages(p0 context.Context, p1 *MessageMatch, p
 	return *new([]cid.Cid), ErrNotSupported
 }
 
-func (s *FullNodeStruct) StateListMiners(p0 context.Context, p1 types.TipSetKey) ([]address.Address, error) {
-	if s.Internal.StateListMiners == nil {
+func (s *FullNodeStruct) StateListMessagesFast(p0 context.Context, p1 AddressMessageQuery) (*AddressMessagePage, error) {
+	if s.Internal.StateListMessagesFast == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.StateListMessagesFast(p0, p1)
+}
+
+func (s *FullNodeStub) StateListMessagesFast(p0 context.Context, p1 AddressMessageQuery) (*AddressMessagePage, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *FullNodeStruct) StateListMiners(p0 context.Context, p1 types.TipSetKey) ([]address.Address, error) {
+	if s.Internal.StateListMiners == nil {
 		return *new([]address.Address), ErrNotSupported
 	}
 	return s.Internal.StateListMiners(p0, p1)
@@ -3456,6 +4382,17 @@ func (s *FullNodeStub) StateListMiners(p0 context.Context, p1 types.TipSetKey) (
 	return *new([]address.Address), ErrNotSupported
 }
 
+func (s *FullNodeStruct) StateLookupDelegatedAddress(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (address.Address, error) {
+	if s.Internal.StateLookupDelegatedAddress == nil {
+		return *new(address.Address), ErrNotSupported
+	}
+	return s.Internal.StateLookupDelegatedAddress(p0, p1, p2)
+}
+
+func (s *FullNodeStub) StateLookupDelegatedAddress(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (address.Address, error) {
+	return *new(address.Address), ErrNotSupported
+}
+
 func (s *FullNodeStruct) StateLookupID(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (address.Address, error) {
 	if s.Internal.StateLookupID == nil {
 		return *new(address.Address), ErrNotSupported
@@ -3522,6 +4459,17 @@ func (s *FullNodeStub) StateMarketStorageDeal(p0 context.Context, p1 abi.DealID,
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) StateMigrationDryRun(p0 context.Context, p1 types.TipSetKey) (*MigrationDryRun, error) {
+	if s.Internal.StateMigrationDryRun == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.StateMigrationDryRun(p0, p1)
+}
+
+func (s *FullNodeStub) StateMigrationDryRun(p0 context.Context, p1 types.TipSetKey) (*MigrationDryRun, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) StateMinerActiveSectors(p0 context.Context, p1 address.Address, p2 types.TipSetKey) ([]*miner.SectorOnChainInfo, error) {
 	if s.Internal.StateMinerActiveSectors == nil {
 		return *new([]*miner.SectorOnChainInfo), ErrNotSupported
@@ -3621,6 +4569,17 @@ func (s *FullNodeStub) StateMinerPower(p0 context.Context, p1 address.Address, p
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) StateMinerPowerHistory(p0 context.Context, p1 address.Address, p2 abi.ChainEpoch, p3 abi.ChainEpoch) ([]MinerPowerSample, error) {
+	if s.Internal.StateMinerPowerHistory == nil {
+		return *new([]MinerPowerSample), ErrNotSupported
+	}
+	return s.Internal.StateMinerPowerHistory(p0, p1, p2, p3)
+}
+
+func (s *FullNodeStub) StateMinerPowerHistory(p0 context.Context, p1 address.Address, p2 abi.ChainEpoch, p3 abi.ChainEpoch) ([]MinerPowerSample, error) {
+	return *new([]MinerPowerSample), ErrNotSupported
+}
+
 func (s *FullNodeStruct) StateMinerPreCommitDepositForPower(p0 context.Context, p1 address.Address, p2 miner.SectorPreCommitInfo, p3 types.TipSetKey) (types.BigInt, error) {
 	if s.Internal.StateMinerPreCommitDepositForPower == nil {
 		return *new(types.BigInt), ErrNotSupported
@@ -3731,6 +4690,28 @@ func (s *FullNodeStub) StateReplay(p0 context.Context, p1 types.TipSetKey, p2 ci
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) StateReplayEvents(p0 context.Context, p1 abi.ChainEpoch, p2 abi.ChainEpoch) (<-chan EventReplayUpdate, error) {
+	if s.Internal.StateReplayEvents == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.StateReplayEvents(p0, p1, p2)
+}
+
+func (s *FullNodeStub) StateReplayEvents(p0 context.Context, p1 abi.ChainEpoch, p2 abi.ChainEpoch) (<-chan EventReplayUpdate, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *FullNodeStruct) StateResolveAddresses(p0 context.Context, p1 []address.Address) ([]ResolvedAddress, error) {
+	if s.Internal.StateResolveAddresses == nil {
+		return *new([]ResolvedAddress), ErrNotSupported
+	}
+	return s.Internal.StateResolveAddresses(p0, p1)
+}
+
+func (s *FullNodeStub) StateResolveAddresses(p0 context.Context, p1 []address.Address) ([]ResolvedAddress, error) {
+	return *new([]ResolvedAddress), ErrNotSupported
+}
+
 func (s *FullNodeStruct) StateSearchMsg(p0 context.Context, p1 types.TipSetKey, p2 cid.Cid, p3 abi.ChainEpoch, p4 bool) (*MsgLookup, error) {
 	if s.Internal.StateSearchMsg == nil {
 		return nil, ErrNotSupported
@@ -3940,6 +4921,28 @@ func (s *FullNodeStub) SyncValidateTipset(p0 context.Context, p1 types.TipSetKey
 	return false, ErrNotSupported
 }
 
+func (s *FullNodeStruct) TenantAssignWallet(p0 context.Context, p1 address.Address, p2 string) error {
+	if s.Internal.TenantAssignWallet == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.TenantAssignWallet(p0, p1, p2)
+}
+
+func (s *FullNodeStub) TenantAssignWallet(p0 context.Context, p1 address.Address, p2 string) error {
+	return ErrNotSupported
+}
+
+func (s *FullNodeStruct) TenantList(p0 context.Context) ([]Tenant, error) {
+	if s.Internal.TenantList == nil {
+		return *new([]Tenant), ErrNotSupported
+	}
+	return s.Internal.TenantList(p0)
+}
+
+func (s *FullNodeStub) TenantList(p0 context.Context) ([]Tenant, error) {
+	return *new([]Tenant), ErrNotSupported
+}
+
 func (s *FullNodeStruct) WalletBalance(p0 context.Context, p1 address.Address) (types.BigInt, error) {
 	if s.Internal.WalletBalance == nil {
 		return *new(types.BigInt), ErrNotSupported
@@ -4094,6 +5097,39 @@ func (s *FullNodeStub) Web3ClientVersion(p0 context.Context) (string, error) {
 	return "", ErrNotSupported
 }
 
+func (s *FullNodeStruct) WebhookList(p0 context.Context) ([]WebhookSubscription, error) {
+	if s.Internal.WebhookList == nil {
+		return *new([]WebhookSubscription), ErrNotSupported
+	}
+	return s.Internal.WebhookList(p0)
+}
+
+func (s *FullNodeStub) WebhookList(p0 context.Context) ([]WebhookSubscription, error) {
+	return *new([]WebhookSubscription), ErrNotSupported
+}
+
+func (s *FullNodeStruct) WebhookSubscribe(p0 context.Context, p1 string, p2 string, p3 []WebhookPattern) (uuid.UUID, error) {
+	if s.Internal.WebhookSubscribe == nil {
+		return *new(uuid.UUID), ErrNotSupported
+	}
+	return s.Internal.WebhookSubscribe(p0, p1, p2, p3)
+}
+
+func (s *FullNodeStub) WebhookSubscribe(p0 context.Context, p1 string, p2 string, p3 []WebhookPattern) (uuid.UUID, error) {
+	return *new(uuid.UUID), ErrNotSupported
+}
+
+func (s *FullNodeStruct) WebhookUnsubscribe(p0 context.Context, p1 uuid.UUID) error {
+	if s.Internal.WebhookUnsubscribe == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.WebhookUnsubscribe(p0, p1)
+}
+
+func (s *FullNodeStub) WebhookUnsubscribe(p0 context.Context, p1 uuid.UUID) error {
+	return ErrNotSupported
+}
+
 func (s *GatewayStruct) ChainGetBlockMessages(p0 context.Context, p1 cid.Cid) (*BlockMessages, error) {
 	if s.Internal.ChainGetBlockMessages == nil {
 		return nil, ErrNotSupported
@@ -4149,6 +5185,17 @@ func (s *GatewayStub) ChainGetParentReceipts(p0 context.Context, p1 cid.Cid) ([]
 	return *new([]*types.MessageReceipt), ErrNotSupported
 }
 
+func (s *GatewayStruct) ChainGetParentReceiptsBatch(p0 context.Context, p1 []types.TipSetKey) ([]ParentReceipts, error) {
+	if s.Internal.ChainGetParentReceiptsBatch == nil {
+		return *new([]ParentReceipts), ErrNotSupported
+	}
+	return s.Internal.ChainGetParentReceiptsBatch(p0, p1)
+}
+
+func (s *GatewayStub) ChainGetParentReceiptsBatch(p0 context.Context, p1 []types.TipSetKey) ([]ParentReceipts, error) {
+	return *new([]ParentReceipts), ErrNotSupported
+}
+
 func (s *GatewayStruct) ChainGetPath(p0 context.Context, p1 types.TipSetKey, p2 types.TipSetKey) ([]*HeadChange, error) {
 	if s.Internal.ChainGetPath == nil {
 		return *new([]*HeadChange), ErrNotSupported
@@ -4369,6 +5416,17 @@ func (s *GatewayStub) EthGetBlockByNumber(p0 context.Context, p1 string, p2 bool
 	return *new(ethtypes.EthBlock), ErrNotSupported
 }
 
+func (s *GatewayStruct) EthGetBlockReceipts(p0 context.Context, p1 string) ([]*EthTxReceipt, error) {
+	if s.Internal.EthGetBlockReceipts == nil {
+		return *new([]*EthTxReceipt), ErrNotSupported
+	}
+	return s.Internal.EthGetBlockReceipts(p0, p1)
+}
+
+func (s *GatewayStub) EthGetBlockReceipts(p0 context.Context, p1 string) ([]*EthTxReceipt, error) {
+	return *new([]*EthTxReceipt), ErrNotSupported
+}
+
 func (s *GatewayStruct) EthGetBlockTransactionCountByHash(p0 context.Context, p1 ethtypes.EthHash) (ethtypes.EthUint64, error) {
 	if s.Internal.EthGetBlockTransactionCountByHash == nil {
 		return *new(ethtypes.EthUint64), ErrNotSupported
@@ -4589,6 +5647,17 @@ func (s *GatewayStub) EthSendRawTransaction(p0 context.Context, p1 ethtypes.EthB
 	return *new(ethtypes.EthHash), ErrNotSupported
 }
 
+func (s *GatewayStruct) EthSimulateRawTransaction(p0 context.Context, p1 ethtypes.EthBytes) (*EthTxSimulation, error) {
+	if s.Internal.EthSimulateRawTransaction == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.EthSimulateRawTransaction(p0, p1)
+}
+
+func (s *GatewayStub) EthSimulateRawTransaction(p0 context.Context, p1 ethtypes.EthBytes) (*EthTxSimulation, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *GatewayStruct) EthSubscribe(p0 context.Context, p1 jsonrpc.RawParams) (ethtypes.EthSubscriptionID, error) {
 	if s.Internal.EthSubscribe == nil {
 		return *new(ethtypes.EthSubscriptionID), ErrNotSupported
@@ -4600,6 +5669,17 @@ func (s *GatewayStub) EthSubscribe(p0 context.Context, p1 jsonrpc.RawParams) (et
 	return *new(ethtypes.EthSubscriptionID), ErrNotSupported
 }
 
+func (s *GatewayStruct) EthSyncing(p0 context.Context) (ethtypes.EthSyncingResult, error) {
+	if s.Internal.EthSyncing == nil {
+		return *new(ethtypes.EthSyncingResult), ErrNotSupported
+	}
+	return s.Internal.EthSyncing(p0)
+}
+
+func (s *GatewayStub) EthSyncing(p0 context.Context) (ethtypes.EthSyncingResult, error) {
+	return *new(ethtypes.EthSyncingResult), ErrNotSupported
+}
+
 func (s *GatewayStruct) EthUninstallFilter(p0 context.Context, p1 ethtypes.EthFilterID) (bool, error) {
 	if s.Internal.EthUninstallFilter == nil {
 		return false, ErrNotSupported
@@ -5040,6 +6120,39 @@ func (s *NetStub) NetAgentVersion(p0 context.Context, p1 peer.ID) (string, error
 	return "", ErrNotSupported
 }
 
+func (s *NetStruct) NetAllowAdd(p0 context.Context, p1 []peer.AddrInfo) error {
+	if s.Internal.NetAllowAdd == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.NetAllowAdd(p0, p1)
+}
+
+func (s *NetStub) NetAllowAdd(p0 context.Context, p1 []peer.AddrInfo) error {
+	return ErrNotSupported
+}
+
+func (s *NetStruct) NetAllowList(p0 context.Context) ([]peer.AddrInfo, error) {
+	if s.Internal.NetAllowList == nil {
+		return *new([]peer.AddrInfo), ErrNotSupported
+	}
+	return s.Internal.NetAllowList(p0)
+}
+
+func (s *NetStub) NetAllowList(p0 context.Context) ([]peer.AddrInfo, error) {
+	return *new([]peer.AddrInfo), ErrNotSupported
+}
+
+func (s *NetStruct) NetAllowRemove(p0 context.Context, p1 []peer.ID) error {
+	if s.Internal.NetAllowRemove == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.NetAllowRemove(p0, p1)
+}
+
+func (s *NetStub) NetAllowRemove(p0 context.Context, p1 []peer.ID) error {
+	return ErrNotSupported
+}
+
 func (s *NetStruct) NetAutoNatStatus(p0 context.Context) (NatInfo, error) {
 	if s.Internal.NetAutoNatStatus == nil {
 		return *new(NatInfo), ErrNotSupported
@@ -5161,6 +6274,17 @@ func (s *NetStub) NetFindPeer(p0 context.Context, p1 peer.ID) (peer.AddrInfo, er
 	return *new(peer.AddrInfo), ErrNotSupported
 }
 
+func (s *NetStruct) NetGetReconnectPolicy(p0 context.Context) (NetReconnectPolicy, error) {
+	if s.Internal.NetGetReconnectPolicy == nil {
+		return *new(NetReconnectPolicy), ErrNotSupported
+	}
+	return s.Internal.NetGetReconnectPolicy(p0)
+}
+
+func (s *NetStub) NetGetReconnectPolicy(p0 context.Context) (NetReconnectPolicy, error) {
+	return *new(NetReconnectPolicy), ErrNotSupported
+}
+
 func (s *NetStruct) NetLimit(p0 context.Context, p1 string) (NetLimit, error) {
 	if s.Internal.NetLimit == nil {
 		return *new(NetLimit), ErrNotSupported
@@ -5183,6 +6307,17 @@ func (s *NetStub) NetPeerInfo(p0 context.Context, p1 peer.ID) (*ExtendedPeerInfo
 	return nil, ErrNotSupported
 }
 
+func (s *NetStruct) NetPeerScores(p0 context.Context) ([]PeerScore, error) {
+	if s.Internal.NetPeerScores == nil {
+		return *new([]PeerScore), ErrNotSupported
+	}
+	return s.Internal.NetPeerScores(p0)
+}
+
+func (s *NetStub) NetPeerScores(p0 context.Context) ([]PeerScore, error) {
+	return *new([]PeerScore), ErrNotSupported
+}
+
 func (s *NetStruct) NetPeers(p0 context.Context) ([]peer.AddrInfo, error) {
 	if s.Internal.NetPeers == nil {
 		return *new([]peer.AddrInfo), ErrNotSupported
@@ -5238,6 +6373,28 @@ func (s *NetStub) NetProtectRemove(p0 context.Context, p1 []peer.ID) error {
 	return ErrNotSupported
 }
 
+func (s *NetStruct) NetPubsubRejectedMessages(p0 context.Context, p1 string) ([]PubsubRejectedMessage, error) {
+	if s.Internal.NetPubsubRejectedMessages == nil {
+		return *new([]PubsubRejectedMessage), ErrNotSupported
+	}
+	return s.Internal.NetPubsubRejectedMessages(p0, p1)
+}
+
+func (s *NetStub) NetPubsubRejectedMessages(p0 context.Context, p1 string) ([]PubsubRejectedMessage, error) {
+	return *new([]PubsubRejectedMessage), ErrNotSupported
+}
+
+func (s *NetStruct) NetPubsubRejections(p0 context.Context) ([]PubsubTopicRejections, error) {
+	if s.Internal.NetPubsubRejections == nil {
+		return *new([]PubsubTopicRejections), ErrNotSupported
+	}
+	return s.Internal.NetPubsubRejections(p0)
+}
+
+func (s *NetStub) NetPubsubRejections(p0 context.Context) ([]PubsubTopicRejections, error) {
+	return *new([]PubsubTopicRejections), ErrNotSupported
+}
+
 func (s *NetStruct) NetPubsubScores(p0 context.Context) ([]PubsubScore, error) {
 	if s.Internal.NetPubsubScores == nil {
 		return *new([]PubsubScore), ErrNotSupported
@@ -5260,6 +6417,28 @@ func (s *NetStub) NetSetLimit(p0 context.Context, p1 string, p2 NetLimit) error
 	return ErrNotSupported
 }
 
+func (s *NetStruct) NetSetPeerTag(p0 context.Context, p1 peer.ID, p2 string, p3 int) error {
+	if s.Internal.NetSetPeerTag == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.NetSetPeerTag(p0, p1, p2, p3)
+}
+
+func (s *NetStub) NetSetPeerTag(p0 context.Context, p1 peer.ID, p2 string, p3 int) error {
+	return ErrNotSupported
+}
+
+func (s *NetStruct) NetSetReconnectPolicy(p0 context.Context, p1 NetReconnectPolicy) error {
+	if s.Internal.NetSetReconnectPolicy == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.NetSetReconnectPolicy(p0, p1)
+}
+
+func (s *NetStub) NetSetReconnectPolicy(p0 context.Context, p1 NetReconnectPolicy) error {
+	return ErrNotSupported
+}
+
 func (s *NetStruct) NetStat(p0 context.Context, p1 string) (NetStat, error) {
 	if s.Internal.NetStat == nil {
 		return *new(NetStat), ErrNotSupported
@@ -5293,6 +6472,17 @@ func (s *StorageMinerStub) ActorAddress(p0 context.Context) (address.Address, er
 	return *new(address.Address), ErrNotSupported
 }
 
+func (s *StorageMinerStruct) ActorAddressBalances(p0 context.Context) (AddressBalanceStatus, error) {
+	if s.Internal.ActorAddressBalances == nil {
+		return *new(AddressBalanceStatus), ErrNotSupported
+	}
+	return s.Internal.ActorAddressBalances(p0)
+}
+
+func (s *StorageMinerStub) ActorAddressBalances(p0 context.Context) (AddressBalanceStatus, error) {
+	return *new(AddressBalanceStatus), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) ActorAddressConfig(p0 context.Context) (AddressConfig, error) {
 	if s.Internal.ActorAddressConfig == nil {
 		return *new(AddressConfig), ErrNotSupported
@@ -5304,6 +6494,28 @@ func (s *StorageMinerStub) ActorAddressConfig(p0 context.Context) (AddressConfig
 	return *new(AddressConfig), ErrNotSupported
 }
 
+func (s *StorageMinerStruct) ActorAddressRotationPlan(p0 context.Context, p1 AddressRotation) (*AddressRotationPlan, error) {
+	if s.Internal.ActorAddressRotationPlan == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.ActorAddressRotationPlan(p0, p1)
+}
+
+func (s *StorageMinerStub) ActorAddressRotationPlan(p0 context.Context, p1 AddressRotation) (*AddressRotationPlan, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *StorageMinerStruct) ActorAddressRotationStep(p0 context.Context, p1 AddressRotation, p2 address.Address) (cid.Cid, error) {
+	if s.Internal.ActorAddressRotationStep == nil {
+		return *new(cid.Cid), ErrNotSupported
+	}
+	return s.Internal.ActorAddressRotationStep(p0, p1, p2)
+}
+
+func (s *StorageMinerStub) ActorAddressRotationStep(p0 context.Context, p1 AddressRotation, p2 address.Address) (cid.Cid, error) {
+	return *new(cid.Cid), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) ActorSectorSize(p0 context.Context, p1 address.Address) (abi.SectorSize, error) {
 	if s.Internal.ActorSectorSize == nil {
 		return *new(abi.SectorSize), ErrNotSupported
@@ -5469,6 +6681,17 @@ func (s *StorageMinerStub) DagstoreRegisterShard(p0 context.Context, p1 string)
 	return ErrNotSupported
 }
 
+func (s *StorageMinerStruct) DagstoreShardDiagnostics(p0 context.Context, p1 cid.Cid) (*DagstoreShardDiagnostics, error) {
+	if s.Internal.DagstoreShardDiagnostics == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.DagstoreShardDiagnostics(p0, p1)
+}
+
+func (s *StorageMinerStub) DagstoreShardDiagnostics(p0 context.Context, p1 cid.Cid) (*DagstoreShardDiagnostics, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *StorageMinerStruct) DealsConsiderOfflineRetrievalDeals(p0 context.Context) (bool, error) {
 	if s.Internal.DealsConsiderOfflineRetrievalDeals == nil {
 		return false, ErrNotSupported
@@ -5667,6 +6890,17 @@ func (s *StorageMinerStub) IndexerAnnounceDeal(p0 context.Context, p1 cid.Cid) e
 	return ErrNotSupported
 }
 
+func (s *StorageMinerStruct) MarketAskHistory(p0 context.Context) ([]StorageAskChange, error) {
+	if s.Internal.MarketAskHistory == nil {
+		return *new([]StorageAskChange), ErrNotSupported
+	}
+	return s.Internal.MarketAskHistory(p0)
+}
+
+func (s *StorageMinerStub) MarketAskHistory(p0 context.Context) ([]StorageAskChange, error) {
+	return *new([]StorageAskChange), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) MarketCancelDataTransfer(p0 context.Context, p1 datatransfer.TransferID, p2 peer.ID, p3 bool) error {
 	if s.Internal.MarketCancelDataTransfer == nil {
 		return ErrNotSupported
@@ -5678,6 +6912,28 @@ func (s *StorageMinerStub) MarketCancelDataTransfer(p0 context.Context, p1 datat
 	return ErrNotSupported
 }
 
+func (s *StorageMinerStruct) MarketCancelScheduledAsk(p0 context.Context, p1 abi.ChainEpoch) error {
+	if s.Internal.MarketCancelScheduledAsk == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.MarketCancelScheduledAsk(p0, p1)
+}
+
+func (s *StorageMinerStub) MarketCancelScheduledAsk(p0 context.Context, p1 abi.ChainEpoch) error {
+	return ErrNotSupported
+}
+
+func (s *StorageMinerStruct) MarketCommPDiagnostics(p0 context.Context, p1 cid.Cid) (*CommPDiagnostics, error) {
+	if s.Internal.MarketCommPDiagnostics == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.MarketCommPDiagnostics(p0, p1)
+}
+
+func (s *StorageMinerStub) MarketCommPDiagnostics(p0 context.Context, p1 cid.Cid) (*CommPDiagnostics, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *StorageMinerStruct) MarketDataTransferDiagnostics(p0 context.Context, p1 peer.ID) (*TransferDiagnostics, error) {
 	if s.Internal.MarketDataTransferDiagnostics == nil {
 		return nil, ErrNotSupported
@@ -5689,6 +6945,17 @@ func (s *StorageMinerStub) MarketDataTransferDiagnostics(p0 context.Context, p1
 	return nil, ErrNotSupported
 }
 
+func (s *StorageMinerStruct) MarketDataTransferRestarts(p0 context.Context) ([]DataTransferRestartHistory, error) {
+	if s.Internal.MarketDataTransferRestarts == nil {
+		return *new([]DataTransferRestartHistory), ErrNotSupported
+	}
+	return s.Internal.MarketDataTransferRestarts(p0)
+}
+
+func (s *StorageMinerStub) MarketDataTransferRestarts(p0 context.Context) ([]DataTransferRestartHistory, error) {
+	return *new([]DataTransferRestartHistory), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) MarketDataTransferUpdates(p0 context.Context) (<-chan DataTransferChannel, error) {
 	if s.Internal.MarketDataTransferUpdates == nil {
 		return nil, ErrNotSupported
@@ -5700,6 +6967,39 @@ func (s *StorageMinerStub) MarketDataTransferUpdates(p0 context.Context) (<-chan
 	return nil, ErrNotSupported
 }
 
+func (s *StorageMinerStruct) MarketDealSLAReport(p0 context.Context) (*DealSLAReport, error) {
+	if s.Internal.MarketDealSLAReport == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.MarketDealSLAReport(p0)
+}
+
+func (s *StorageMinerStub) MarketDealSLAReport(p0 context.Context) (*DealSLAReport, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *StorageMinerStruct) MarketDealTransferStatus(p0 context.Context, p1 cid.Cid) (*DealTransferStatus, error) {
+	if s.Internal.MarketDealTransferStatus == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.MarketDealTransferStatus(p0, p1)
+}
+
+func (s *StorageMinerStub) MarketDealTransferStatus(p0 context.Context, p1 cid.Cid) (*DealTransferStatus, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *StorageMinerStruct) MarketDealTransferUpdates(p0 context.Context) (<-chan DealTransferStatus, error) {
+	if s.Internal.MarketDealTransferUpdates == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.MarketDealTransferUpdates(p0)
+}
+
+func (s *StorageMinerStub) MarketDealTransferUpdates(p0 context.Context) (<-chan DealTransferStatus, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *StorageMinerStruct) MarketGetAsk(p0 context.Context) (*storagemarket.SignedStorageAsk, error) {
 	if s.Internal.MarketGetAsk == nil {
 		return nil, ErrNotSupported
@@ -5711,6 +7011,17 @@ func (s *StorageMinerStub) MarketGetAsk(p0 context.Context) (*storagemarket.Sign
 	return nil, ErrNotSupported
 }
 
+func (s *StorageMinerStruct) MarketGetAskSpec(p0 context.Context) (*StorageAskSpec, error) {
+	if s.Internal.MarketGetAskSpec == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.MarketGetAskSpec(p0)
+}
+
+func (s *StorageMinerStub) MarketGetAskSpec(p0 context.Context) (*StorageAskSpec, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *StorageMinerStruct) MarketGetDealUpdates(p0 context.Context) (<-chan storagemarket.MinerDeal, error) {
 	if s.Internal.MarketGetDealUpdates == nil {
 		return nil, ErrNotSupported
@@ -5722,6 +7033,17 @@ func (s *StorageMinerStub) MarketGetDealUpdates(p0 context.Context) (<-chan stor
 	return nil, ErrNotSupported
 }
 
+func (s *StorageMinerStruct) MarketGetLabels(p0 context.Context, p1 cid.Cid) (map[string]string, error) {
+	if s.Internal.MarketGetLabels == nil {
+		return *new(map[string]string), ErrNotSupported
+	}
+	return s.Internal.MarketGetLabels(p0, p1)
+}
+
+func (s *StorageMinerStub) MarketGetLabels(p0 context.Context, p1 cid.Cid) (map[string]string, error) {
+	return *new(map[string]string), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) MarketGetRetrievalAsk(p0 context.Context) (*retrievalmarket.Ask, error) {
 	if s.Internal.MarketGetRetrievalAsk == nil {
 		return nil, ErrNotSupported
@@ -5744,6 +7066,17 @@ func (s *StorageMinerStub) MarketImportDealData(p0 context.Context, p1 cid.Cid,
 	return ErrNotSupported
 }
 
+func (s *StorageMinerStruct) MarketListCommPDiagnostics(p0 context.Context) ([]CommPDiagnostics, error) {
+	if s.Internal.MarketListCommPDiagnostics == nil {
+		return *new([]CommPDiagnostics), ErrNotSupported
+	}
+	return s.Internal.MarketListCommPDiagnostics(p0)
+}
+
+func (s *StorageMinerStub) MarketListCommPDiagnostics(p0 context.Context) ([]CommPDiagnostics, error) {
+	return *new([]CommPDiagnostics), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) MarketListDataTransfers(p0 context.Context) ([]DataTransferChannel, error) {
 	if s.Internal.MarketListDataTransfers == nil {
 		return *new([]DataTransferChannel), ErrNotSupported
@@ -5766,6 +7099,17 @@ func (s *StorageMinerStub) MarketListDeals(p0 context.Context) ([]*MarketDeal, e
 	return *new([]*MarketDeal), ErrNotSupported
 }
 
+func (s *StorageMinerStruct) MarketListDealsFiltered(p0 context.Context, p1 MarketDealFilter) ([]LabeledMarketDeal, error) {
+	if s.Internal.MarketListDealsFiltered == nil {
+		return *new([]LabeledMarketDeal), ErrNotSupported
+	}
+	return s.Internal.MarketListDealsFiltered(p0, p1)
+}
+
+func (s *StorageMinerStub) MarketListDealsFiltered(p0 context.Context, p1 MarketDealFilter) ([]LabeledMarketDeal, error) {
+	return *new([]LabeledMarketDeal), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) MarketListIncompleteDeals(p0 context.Context) ([]storagemarket.MinerDeal, error) {
 	if s.Internal.MarketListIncompleteDeals == nil {
 		return *new([]storagemarket.MinerDeal), ErrNotSupported
@@ -5788,6 +7132,28 @@ func (s *StorageMinerStub) MarketListRetrievalDeals(p0 context.Context) ([]struc
 	return *new([]struct{}), ErrNotSupported
 }
 
+func (s *StorageMinerStruct) MarketListRetrievalTimelines(p0 context.Context, p1 int) ([]RetrievalTimeline, error) {
+	if s.Internal.MarketListRetrievalTimelines == nil {
+		return *new([]RetrievalTimeline), ErrNotSupported
+	}
+	return s.Internal.MarketListRetrievalTimelines(p0, p1)
+}
+
+func (s *StorageMinerStub) MarketListRetrievalTimelines(p0 context.Context, p1 int) ([]RetrievalTimeline, error) {
+	return *new([]RetrievalTimeline), ErrNotSupported
+}
+
+func (s *StorageMinerStruct) MarketListScheduledAsks(p0 context.Context) ([]ScheduledStorageAsk, error) {
+	if s.Internal.MarketListScheduledAsks == nil {
+		return *new([]ScheduledStorageAsk), ErrNotSupported
+	}
+	return s.Internal.MarketListScheduledAsks(p0)
+}
+
+func (s *StorageMinerStub) MarketListScheduledAsks(p0 context.Context) ([]ScheduledStorageAsk, error) {
+	return *new([]ScheduledStorageAsk), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) MarketPendingDeals(p0 context.Context) (PendingDealInfo, error) {
 	if s.Internal.MarketPendingDeals == nil {
 		return *new(PendingDealInfo), ErrNotSupported
@@ -5821,6 +7187,50 @@ func (s *StorageMinerStub) MarketRestartDataTransfer(p0 context.Context, p1 data
 	return ErrNotSupported
 }
 
+func (s *StorageMinerStruct) MarketRetrievabilityCheck(p0 context.Context, p1 int) ([]RetrievabilitySample, error) {
+	if s.Internal.MarketRetrievabilityCheck == nil {
+		return *new([]RetrievabilitySample), ErrNotSupported
+	}
+	return s.Internal.MarketRetrievabilityCheck(p0, p1)
+}
+
+func (s *StorageMinerStub) MarketRetrievabilityCheck(p0 context.Context, p1 int) ([]RetrievabilitySample, error) {
+	return *new([]RetrievabilitySample), ErrNotSupported
+}
+
+func (s *StorageMinerStruct) MarketRetrievabilitySamples(p0 context.Context, p1 int) ([]RetrievabilitySample, error) {
+	if s.Internal.MarketRetrievabilitySamples == nil {
+		return *new([]RetrievabilitySample), ErrNotSupported
+	}
+	return s.Internal.MarketRetrievabilitySamples(p0, p1)
+}
+
+func (s *StorageMinerStub) MarketRetrievabilitySamples(p0 context.Context, p1 int) ([]RetrievabilitySample, error) {
+	return *new([]RetrievabilitySample), ErrNotSupported
+}
+
+func (s *StorageMinerStruct) MarketRetrievalStats(p0 context.Context, p1 int) (*RetrievalStats, error) {
+	if s.Internal.MarketRetrievalStats == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.MarketRetrievalStats(p0, p1)
+}
+
+func (s *StorageMinerStub) MarketRetrievalStats(p0 context.Context, p1 int) (*RetrievalStats, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *StorageMinerStruct) MarketRetrievalTimeline(p0 context.Context, p1 peer.ID, p2 retrievalmarket.DealID) (*RetrievalTimeline, error) {
+	if s.Internal.MarketRetrievalTimeline == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.MarketRetrievalTimeline(p0, p1, p2)
+}
+
+func (s *StorageMinerStub) MarketRetrievalTimeline(p0 context.Context, p1 peer.ID, p2 retrievalmarket.DealID) (*RetrievalTimeline, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *StorageMinerStruct) MarketRetryPublishDeal(p0 context.Context, p1 cid.Cid) error {
 	if s.Internal.MarketRetryPublishDeal == nil {
 		return ErrNotSupported
@@ -5832,6 +7242,17 @@ func (s *StorageMinerStub) MarketRetryPublishDeal(p0 context.Context, p1 cid.Cid
 	return ErrNotSupported
 }
 
+func (s *StorageMinerStruct) MarketScheduleAsk(p0 context.Context, p1 abi.ChainEpoch, p2 StorageAskSpec) error {
+	if s.Internal.MarketScheduleAsk == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.MarketScheduleAsk(p0, p1, p2)
+}
+
+func (s *StorageMinerStub) MarketScheduleAsk(p0 context.Context, p1 abi.ChainEpoch, p2 StorageAskSpec) error {
+	return ErrNotSupported
+}
+
 func (s *StorageMinerStruct) MarketSetAsk(p0 context.Context, p1 types.BigInt, p2 types.BigInt, p3 abi.ChainEpoch, p4 abi.PaddedPieceSize, p5 abi.PaddedPieceSize) error {
 	if s.Internal.MarketSetAsk == nil {
 		return ErrNotSupported
@@ -5843,6 +7264,28 @@ func (s *StorageMinerStub) MarketSetAsk(p0 context.Context, p1 types.BigInt, p2
 	return ErrNotSupported
 }
 
+func (s *StorageMinerStruct) MarketSetAskSpec(p0 context.Context, p1 StorageAskSpec) error {
+	if s.Internal.MarketSetAskSpec == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.MarketSetAskSpec(p0, p1)
+}
+
+func (s *StorageMinerStub) MarketSetAskSpec(p0 context.Context, p1 StorageAskSpec) error {
+	return ErrNotSupported
+}
+
+func (s *StorageMinerStruct) MarketSetLabels(p0 context.Context, p1 cid.Cid, p2 map[string]string) error {
+	if s.Internal.MarketSetLabels == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.MarketSetLabels(p0, p1, p2)
+}
+
+func (s *StorageMinerStub) MarketSetLabels(p0 context.Context, p1 cid.Cid, p2 map[string]string) error {
+	return ErrNotSupported
+}
+
 func (s *StorageMinerStruct) MarketSetRetrievalAsk(p0 context.Context, p1 *retrievalmarket.Ask) error {
 	if s.Internal.MarketSetRetrievalAsk == nil {
 		return ErrNotSupported
@@ -5854,6 +7297,39 @@ func (s *StorageMinerStub) MarketSetRetrievalAsk(p0 context.Context, p1 *retriev
 	return ErrNotSupported
 }
 
+func (s *StorageMinerStruct) MinerBlocksReport(p0 context.Context, p1 abi.ChainEpoch, p2 abi.ChainEpoch) (*MinerBlocksReport, error) {
+	if s.Internal.MinerBlocksReport == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.MinerBlocksReport(p0, p1, p2)
+}
+
+func (s *StorageMinerStub) MinerBlocksReport(p0 context.Context, p1 abi.ChainEpoch, p2 abi.ChainEpoch) (*MinerBlocksReport, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *StorageMinerStruct) MinerSelfTest(p0 context.Context) (*SelfTestReport, error) {
+	if s.Internal.MinerSelfTest == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.MinerSelfTest(p0)
+}
+
+func (s *StorageMinerStub) MinerSelfTest(p0 context.Context) (*SelfTestReport, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *StorageMinerStruct) MinerSpendReport(p0 context.Context, p1 abi.ChainEpoch, p2 abi.ChainEpoch, p3 abi.ChainEpoch) (*SpendReport, error) {
+	if s.Internal.MinerSpendReport == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.MinerSpendReport(p0, p1, p2, p3)
+}
+
+func (s *StorageMinerStub) MinerSpendReport(p0 context.Context, p1 abi.ChainEpoch, p2 abi.ChainEpoch, p3 abi.ChainEpoch) (*SpendReport, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *StorageMinerStruct) MiningBase(p0 context.Context) (*types.TipSet, error) {
 	if s.Internal.MiningBase == nil {
 		return nil, ErrNotSupported
@@ -5865,6 +7341,39 @@ func (s *StorageMinerStub) MiningBase(p0 context.Context) (*types.TipSet, error)
 	return nil, ErrNotSupported
 }
 
+func (s *StorageMinerStruct) ParamsRepair(p0 context.Context) error {
+	if s.Internal.ParamsRepair == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.ParamsRepair(p0)
+}
+
+func (s *StorageMinerStub) ParamsRepair(p0 context.Context) error {
+	return ErrNotSupported
+}
+
+func (s *StorageMinerStruct) ParamsStatus(p0 context.Context) ([]ProofParamStatus, error) {
+	if s.Internal.ParamsStatus == nil {
+		return *new([]ProofParamStatus), ErrNotSupported
+	}
+	return s.Internal.ParamsStatus(p0)
+}
+
+func (s *StorageMinerStub) ParamsStatus(p0 context.Context) ([]ProofParamStatus, error) {
+	return *new([]ProofParamStatus), ErrNotSupported
+}
+
+func (s *StorageMinerStruct) PiecesGetBatch(p0 context.Context, p1 []cid.Cid) ([]PieceInfoResult, error) {
+	if s.Internal.PiecesGetBatch == nil {
+		return *new([]PieceInfoResult), ErrNotSupported
+	}
+	return s.Internal.PiecesGetBatch(p0, p1)
+}
+
+func (s *StorageMinerStub) PiecesGetBatch(p0 context.Context, p1 []cid.Cid) ([]PieceInfoResult, error) {
+	return *new([]PieceInfoResult), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) PiecesGetCIDInfo(p0 context.Context, p1 cid.Cid) (*piecestore.CIDInfo, error) {
 	if s.Internal.PiecesGetCIDInfo == nil {
 		return nil, ErrNotSupported
@@ -5887,6 +7396,17 @@ func (s *StorageMinerStub) PiecesGetPieceInfo(p0 context.Context, p1 cid.Cid) (*
 	return nil, ErrNotSupported
 }
 
+func (s *StorageMinerStruct) PiecesGetRootCids(p0 context.Context, p1 cid.Cid) ([]cid.Cid, error) {
+	if s.Internal.PiecesGetRootCids == nil {
+		return *new([]cid.Cid), ErrNotSupported
+	}
+	return s.Internal.PiecesGetRootCids(p0, p1)
+}
+
+func (s *StorageMinerStub) PiecesGetRootCids(p0 context.Context, p1 cid.Cid) ([]cid.Cid, error) {
+	return *new([]cid.Cid), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) PiecesListCidInfos(p0 context.Context) ([]cid.Cid, error) {
 	if s.Internal.PiecesListCidInfos == nil {
 		return *new([]cid.Cid), ErrNotSupported
@@ -5920,6 +7440,94 @@ func (s *StorageMinerStub) PledgeSector(p0 context.Context) (abi.SectorID, error
 	return *new(abi.SectorID), ErrNotSupported
 }
 
+func (s *StorageMinerStruct) ProvingDeadlineLoad(p0 context.Context) (*DeadlineLoadReport, error) {
+	if s.Internal.ProvingDeadlineLoad == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.ProvingDeadlineLoad(p0)
+}
+
+func (s *StorageMinerStub) ProvingDeadlineLoad(p0 context.Context) (*DeadlineLoadReport, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *StorageMinerStruct) ProvingDetectedFaults(p0 context.Context) ([]DetectedFault, error) {
+	if s.Internal.ProvingDetectedFaults == nil {
+		return *new([]DetectedFault), ErrNotSupported
+	}
+	return s.Internal.ProvingDetectedFaults(p0)
+}
+
+func (s *StorageMinerStub) ProvingDetectedFaults(p0 context.Context) ([]DetectedFault, error) {
+	return *new([]DetectedFault), ErrNotSupported
+}
+
+func (s *StorageMinerStruct) ProvingDetectedFaultsApprove(p0 context.Context, p1 []abi.SectorNumber) error {
+	if s.Internal.ProvingDetectedFaultsApprove == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.ProvingDetectedFaultsApprove(p0, p1)
+}
+
+func (s *StorageMinerStub) ProvingDetectedFaultsApprove(p0 context.Context, p1 []abi.SectorNumber) error {
+	return ErrNotSupported
+}
+
+func (s *StorageMinerStruct) ProvingDetectedFaultsReject(p0 context.Context, p1 []abi.SectorNumber) error {
+	if s.Internal.ProvingDetectedFaultsReject == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.ProvingDetectedFaultsReject(p0, p1)
+}
+
+func (s *StorageMinerStub) ProvingDetectedFaultsReject(p0 context.Context, p1 []abi.SectorNumber) error {
+	return ErrNotSupported
+}
+
+func (s *StorageMinerStruct) ProvingDisputes(p0 context.Context) ([]WdPoStDispute, error) {
+	if s.Internal.ProvingDisputes == nil {
+		return *new([]WdPoStDispute), ErrNotSupported
+	}
+	return s.Internal.ProvingDisputes(p0)
+}
+
+func (s *StorageMinerStub) ProvingDisputes(p0 context.Context) ([]WdPoStDispute, error) {
+	return *new([]WdPoStDispute), ErrNotSupported
+}
+
+func (s *StorageMinerStruct) ProvingDisputesAck(p0 context.Context, p1 []cid.Cid) error {
+	if s.Internal.ProvingDisputesAck == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.ProvingDisputesAck(p0, p1)
+}
+
+func (s *StorageMinerStub) ProvingDisputesAck(p0 context.Context, p1 []cid.Cid) error {
+	return ErrNotSupported
+}
+
+func (s *StorageMinerStruct) ProvingRebalanceCompact(p0 context.Context) ([]cid.Cid, error) {
+	if s.Internal.ProvingRebalanceCompact == nil {
+		return *new([]cid.Cid), ErrNotSupported
+	}
+	return s.Internal.ProvingRebalanceCompact(p0)
+}
+
+func (s *StorageMinerStub) ProvingRebalanceCompact(p0 context.Context) ([]cid.Cid, error) {
+	return *new([]cid.Cid), ErrNotSupported
+}
+
+func (s *StorageMinerStruct) ProvingRebalancePlan(p0 context.Context, p1 uint64) (*DeadlineRebalancePlan, error) {
+	if s.Internal.ProvingRebalancePlan == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.ProvingRebalancePlan(p0, p1)
+}
+
+func (s *StorageMinerStub) ProvingRebalancePlan(p0 context.Context, p1 uint64) (*DeadlineRebalancePlan, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *StorageMinerStruct) RecoverFault(p0 context.Context, p1 []abi.SectorNumber) ([]cid.Cid, error) {
 	if s.Internal.RecoverFault == nil {
 		return *new([]cid.Cid), ErrNotSupported
@@ -6118,6 +7726,17 @@ func (s *StorageMinerStub) ReturnSealPreCommit2(p0 context.Context, p1 storiface
 	return ErrNotSupported
 }
 
+func (s *StorageMinerStruct) ReturnTaskLogs(p0 context.Context, p1 storiface.TaskLog) error {
+	if s.Internal.ReturnTaskLogs == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.ReturnTaskLogs(p0, p1)
+}
+
+func (s *StorageMinerStub) ReturnTaskLogs(p0 context.Context, p1 storiface.TaskLog) error {
+	return ErrNotSupported
+}
+
 func (s *StorageMinerStruct) ReturnUnsealPiece(p0 context.Context, p1 storiface.CallID, p2 *storiface.CallError) error {
 	if s.Internal.ReturnUnsealPiece == nil {
 		return ErrNotSupported
@@ -6151,6 +7770,17 @@ func (s *StorageMinerStub) SealingAbort(p0 context.Context, p1 storiface.CallID)
 	return ErrNotSupported
 }
 
+func (s *StorageMinerStruct) SealingJobsTree(p0 context.Context) (storiface.JobsTree, error) {
+	if s.Internal.SealingJobsTree == nil {
+		return *new(storiface.JobsTree), ErrNotSupported
+	}
+	return s.Internal.SealingJobsTree(p0)
+}
+
+func (s *StorageMinerStub) SealingJobsTree(p0 context.Context) (storiface.JobsTree, error) {
+	return *new(storiface.JobsTree), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) SealingRemoveRequest(p0 context.Context, p1 uuid.UUID) error {
 	if s.Internal.SealingRemoveRequest == nil {
 		return ErrNotSupported
@@ -6173,6 +7803,17 @@ func (s *StorageMinerStub) SealingSchedDiag(p0 context.Context, p1 bool) (interf
 	return nil, ErrNotSupported
 }
 
+func (s *StorageMinerStruct) SealingTaskLogs(p0 context.Context, p1 abi.SectorNumber, p2 sealtasks.TaskType) ([]storiface.TaskLog, error) {
+	if s.Internal.SealingTaskLogs == nil {
+		return *new([]storiface.TaskLog), ErrNotSupported
+	}
+	return s.Internal.SealingTaskLogs(p0, p1, p2)
+}
+
+func (s *StorageMinerStub) SealingTaskLogs(p0 context.Context, p1 abi.SectorNumber, p2 sealtasks.TaskType) ([]storiface.TaskLog, error) {
+	return *new([]storiface.TaskLog), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) SectorAbortUpgrade(p0 context.Context, p1 abi.SectorNumber) error {
 	if s.Internal.SectorAbortUpgrade == nil {
 		return ErrNotSupported
@@ -6195,6 +7836,17 @@ func (s *StorageMinerStub) SectorAddPieceToAny(p0 context.Context, p1 abi.Unpadd
 	return *new(SectorOffset), ErrNotSupported
 }
 
+func (s *StorageMinerStruct) SectorCommitAggregationSimulate(p0 context.Context) (*sealiface.CommitAggregationSimulation, error) {
+	if s.Internal.SectorCommitAggregationSimulate == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.SectorCommitAggregationSimulate(p0)
+}
+
+func (s *StorageMinerStub) SectorCommitAggregationSimulate(p0 context.Context) (*sealiface.CommitAggregationSimulation, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *StorageMinerStruct) SectorCommitFlush(p0 context.Context) ([]sealiface.CommitBatchRes, error) {
 	if s.Internal.SectorCommitFlush == nil {
 		return *new([]sealiface.CommitBatchRes), ErrNotSupported
@@ -6349,6 +8001,17 @@ func (s *StorageMinerStub) SectorReceive(p0 context.Context, p1 RemoteSectorMeta
 	return ErrNotSupported
 }
 
+func (s *StorageMinerStruct) SectorReceiveBatch(p0 context.Context, p1 []RemoteSectorMeta) ([]SectorReceiveResult, error) {
+	if s.Internal.SectorReceiveBatch == nil {
+		return *new([]SectorReceiveResult), ErrNotSupported
+	}
+	return s.Internal.SectorReceiveBatch(p0, p1)
+}
+
+func (s *StorageMinerStub) SectorReceiveBatch(p0 context.Context, p1 []RemoteSectorMeta) ([]SectorReceiveResult, error) {
+	return *new([]SectorReceiveResult), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) SectorRemove(p0 context.Context, p1 abi.SectorNumber) error {
 	if s.Internal.SectorRemove == nil {
 		return ErrNotSupported
@@ -6437,6 +8100,17 @@ func (s *StorageMinerStub) SectorUnseal(p0 context.Context, p1 abi.SectorNumber)
 	return ErrNotSupported
 }
 
+func (s *StorageMinerStruct) SectorsCostEstimate(p0 context.Context, p1 abi.RegisteredSealProof, p2 SectorDealMix, p3 abi.ChainEpoch) (*SectorCostEstimate, error) {
+	if s.Internal.SectorsCostEstimate == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.SectorsCostEstimate(p0, p1, p2, p3)
+}
+
+func (s *StorageMinerStub) SectorsCostEstimate(p0 context.Context, p1 abi.RegisteredSealProof, p2 SectorDealMix, p3 abi.ChainEpoch) (*SectorCostEstimate, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *StorageMinerStruct) SectorsList(p0 context.Context) ([]abi.SectorNumber, error) {
 	if s.Internal.SectorsList == nil {
 		return *new([]abi.SectorNumber), ErrNotSupported
@@ -6459,6 +8133,28 @@ func (s *StorageMinerStub) SectorsListInStates(p0 context.Context, p1 []SectorSt
 	return *new([]abi.SectorNumber), ErrNotSupported
 }
 
+func (s *StorageMinerStruct) SectorsPendingSubmission(p0 context.Context) ([]PendingSubmission, error) {
+	if s.Internal.SectorsPendingSubmission == nil {
+		return *new([]PendingSubmission), ErrNotSupported
+	}
+	return s.Internal.SectorsPendingSubmission(p0)
+}
+
+func (s *StorageMinerStub) SectorsPendingSubmission(p0 context.Context) ([]PendingSubmission, error) {
+	return *new([]PendingSubmission), ErrNotSupported
+}
+
+func (s *StorageMinerStruct) SectorsQuery(p0 context.Context, p1 SectorQuery) ([]SectorQueryResult, error) {
+	if s.Internal.SectorsQuery == nil {
+		return *new([]SectorQueryResult), ErrNotSupported
+	}
+	return s.Internal.SectorsQuery(p0, p1)
+}
+
+func (s *StorageMinerStub) SectorsQuery(p0 context.Context, p1 SectorQuery) ([]SectorQueryResult, error) {
+	return *new([]SectorQueryResult), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) SectorsRefs(p0 context.Context) (map[string][]SealedRef, error) {
 	if s.Internal.SectorsRefs == nil {
 		return *new(map[string][]SealedRef), ErrNotSupported
@@ -6470,6 +8166,17 @@ func (s *StorageMinerStub) SectorsRefs(p0 context.Context) (map[string][]SealedR
 	return *new(map[string][]SealedRef), ErrNotSupported
 }
 
+func (s *StorageMinerStruct) SectorsRetryPolicy(p0 context.Context, p1 abi.SectorNumber) (SectorRetryPolicy, error) {
+	if s.Internal.SectorsRetryPolicy == nil {
+		return *new(SectorRetryPolicy), ErrNotSupported
+	}
+	return s.Internal.SectorsRetryPolicy(p0, p1)
+}
+
+func (s *StorageMinerStub) SectorsRetryPolicy(p0 context.Context, p1 abi.SectorNumber) (SectorRetryPolicy, error) {
+	return *new(SectorRetryPolicy), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) SectorsStatus(p0 context.Context, p1 abi.SectorNumber, p2 bool) (SectorInfo, error) {
 	if s.Internal.SectorsStatus == nil {
 		return *new(SectorInfo), ErrNotSupported
@@ -6481,6 +8188,17 @@ func (s *StorageMinerStub) SectorsStatus(p0 context.Context, p1 abi.SectorNumber
 	return *new(SectorInfo), ErrNotSupported
 }
 
+func (s *StorageMinerStruct) SectorsStatusBatch(p0 context.Context, p1 []abi.SectorNumber, p2 bool) ([]SectorStatusResult, error) {
+	if s.Internal.SectorsStatusBatch == nil {
+		return *new([]SectorStatusResult), ErrNotSupported
+	}
+	return s.Internal.SectorsStatusBatch(p0, p1, p2)
+}
+
+func (s *StorageMinerStub) SectorsStatusBatch(p0 context.Context, p1 []abi.SectorNumber, p2 bool) ([]SectorStatusResult, error) {
+	return *new([]SectorStatusResult), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) SectorsSummary(p0 context.Context) (map[SectorState]int, error) {
 	if s.Internal.SectorsSummary == nil {
 		return *new(map[SectorState]int), ErrNotSupported
@@ -6503,6 +8221,39 @@ func (s *StorageMinerStub) SectorsUnsealPiece(p0 context.Context, p1 storiface.S
 	return ErrNotSupported
 }
 
+func (s *StorageMinerStruct) SectorsUnsealRegenApprove(p0 context.Context, p1 []abi.SectorNumber) error {
+	if s.Internal.SectorsUnsealRegenApprove == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.SectorsUnsealRegenApprove(p0, p1)
+}
+
+func (s *StorageMinerStub) SectorsUnsealRegenApprove(p0 context.Context, p1 []abi.SectorNumber) error {
+	return ErrNotSupported
+}
+
+func (s *StorageMinerStruct) SectorsUnsealRegenList(p0 context.Context) ([]UnsealRegenJob, error) {
+	if s.Internal.SectorsUnsealRegenList == nil {
+		return *new([]UnsealRegenJob), ErrNotSupported
+	}
+	return s.Internal.SectorsUnsealRegenList(p0)
+}
+
+func (s *StorageMinerStub) SectorsUnsealRegenList(p0 context.Context) ([]UnsealRegenJob, error) {
+	return *new([]UnsealRegenJob), ErrNotSupported
+}
+
+func (s *StorageMinerStruct) SectorsUnsealRegenReject(p0 context.Context, p1 []abi.SectorNumber) error {
+	if s.Internal.SectorsUnsealRegenReject == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.SectorsUnsealRegenReject(p0, p1)
+}
+
+func (s *StorageMinerStub) SectorsUnsealRegenReject(p0 context.Context, p1 []abi.SectorNumber) error {
+	return ErrNotSupported
+}
+
 func (s *StorageMinerStruct) SectorsUpdate(p0 context.Context, p1 abi.SectorNumber, p2 SectorState) error {
 	if s.Internal.SectorsUpdate == nil {
 		return ErrNotSupported
@@ -6668,6 +8419,17 @@ func (s *StorageMinerStub) StorageLock(p0 context.Context, p1 abi.SectorID, p2 s
 	return ErrNotSupported
 }
 
+func (s *StorageMinerStruct) StoragePlacementReport(p0 context.Context) (*storiface.PlacementReport, error) {
+	if s.Internal.StoragePlacementReport == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.StoragePlacementReport(p0)
+}
+
+func (s *StorageMinerStub) StoragePlacementReport(p0 context.Context) (*storiface.PlacementReport, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *StorageMinerStruct) StorageRedeclareLocal(p0 context.Context, p1 *storiface.ID, p2 bool) error {
 	if s.Internal.StorageRedeclareLocal == nil {
 		return ErrNotSupported
@@ -6723,6 +8485,17 @@ func (s *StorageMinerStub) WorkerConnect(p0 context.Context, p1 string) error {
 	return ErrNotSupported
 }
 
+func (s *StorageMinerStruct) WorkerDrain(p0 context.Context, p1 uuid.UUID) error {
+	if s.Internal.WorkerDrain == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.WorkerDrain(p0, p1)
+}
+
+func (s *StorageMinerStub) WorkerDrain(p0 context.Context, p1 uuid.UUID) error {
+	return ErrNotSupported
+}
+
 func (s *StorageMinerStruct) WorkerJobs(p0 context.Context) (map[uuid.UUID][]storiface.WorkerJob, error) {
 	if s.Internal.WorkerJobs == nil {
 		return *new(map[uuid.UUID][]storiface.WorkerJob), ErrNotSupported
@@ -6745,6 +8518,17 @@ func (s *StorageMinerStub) WorkerStats(p0 context.Context) (map[uuid.UUID]storif
 	return *new(map[uuid.UUID]storiface.WorkerStats), ErrNotSupported
 }
 
+func (s *StorageMinerStruct) WorkerUndrain(p0 context.Context, p1 uuid.UUID) error {
+	if s.Internal.WorkerUndrain == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.WorkerUndrain(p0, p1)
+}
+
+func (s *StorageMinerStub) WorkerUndrain(p0 context.Context, p1 uuid.UUID) error {
+	return ErrNotSupported
+}
+
 func (s *WalletStruct) WalletDelete(p0 context.Context, p1 address.Address) error {
 	if s.Internal.WalletDelete == nil {
 		return ErrNotSupported
@@ -6833,6 +8617,17 @@ func (s *WorkerStub) AddPiece(p0 context.Context, p1 storiface.SectorRef, p2 []a
 	return *new(storiface.CallID), ErrNotSupported
 }
 
+func (s *WorkerStruct) Capabilities(p0 context.Context) (APICapabilities, error) {
+	if s.Internal.Capabilities == nil {
+		return *new(APICapabilities), ErrNotSupported
+	}
+	return s.Internal.Capabilities(p0)
+}
+
+func (s *WorkerStub) Capabilities(p0 context.Context) (APICapabilities, error) {
+	return *new(APICapabilities), ErrNotSupported
+}
+
 func (s *WorkerStruct) DataCid(p0 context.Context, p1 abi.UnpaddedPieceSize, p2 storiface.Data) (storiface.CallID, error) {
 	if s.Internal.DataCid == nil {
 		return *new(storiface.CallID), ErrNotSupported
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"reflect"
+)
+
+// ReadOnlyFullAPI wraps the FullNode API, rejecting the calls to the methods
+// which need more than the read permission, whatever the permissions of the
+// caller.
+func ReadOnlyFullAPI(a FullNode) FullNode {
+	var out FullNodeStruct
+	readOnlyProxies(a, &out)
+	return &out
+}
+
+// ReadOnlyStorMinerAPI wraps the StorageMiner API like ReadOnlyFullAPI.
+func ReadOnlyStorMinerAPI(a StorageMiner) StorageMiner {
+	var out StorageMinerStruct
+	readOnlyProxies(a, &out)
+	return &out
+}
+
+func readOnlyProxies(in, out interface{}) {
+	ra := reflect.ValueOf(in)
+	for _, o := range GetInternalStructs(out) {
+		rint := reflect.ValueOf(o).Elem()
+
+		for f := 0; f < rint.NumField(); f++ {
+			field := rint.Type().Field(f)
+			fn := ra.MethodByName(field.Name)
+			if field.Tag.Get("perm") == string(PermRead) {
+				rint.Field(f).Set(fn)
+				continue
+			}
+
+			rint.Field(f).Set(reflect.MakeFunc(field.Type, func(args []reflect.Value) (results []reflect.Value) {
+				var err error = &ErrReadOnly{}
+				rerr := reflect.ValueOf(&err).Elem()
+
+				if field.Type.NumOut() == 2 {
+					return []reflect.Value{
+						reflect.Zero(field.Type.Out(0)),
+						rerr,
+					}
+				}
+				return []reflect.Value{rerr}
+			}))
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// stm: #unit
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+func TestReadOnlyFullAPI(t *testing.T) {
+	ctx := context.Background()
+
+	var pushed, read bool
+	var full FullNodeStruct
+	full.Internal.ChainHead = func(context.Context) (*types.TipSet, error) {
+		read = true
+		return nil, nil
+	}
+	full.Internal.MpoolPush = func(context.Context, *types.SignedMessage) (cid.Cid, error) {
+		pushed = true
+		return cid.Undef, nil
+	}
+	full.Internal.WalletDelete = func(context.Context, address.Address) error {
+		pushed = true
+		return nil
+	}
+
+	ro := ReadOnlyFullAPI(&full)
+
+	_, err := ro.ChainHead(ctx)
+	require.NoError(t, err)
+	require.True(t, read)
+
+	_, err = ro.MpoolPush(ctx, &types.SignedMessage{})
+	var roErr *ErrReadOnly
+	require.True(t, errors.As(err, &roErr))
+
+	err = ro.WalletDelete(ctx, address.Undef)
+	require.True(t, errors.As(err, &roErr))
+	require.False(t, pushed)
+}
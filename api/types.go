@@ -153,6 +153,328 @@ type NetBlockList struct {
 	IPSubnets []string
 }
 
+type PeerScore struct {
+	ID        peer.ID
+	Protected bool
+	// ConnMgrValue is the sum of the peer's connection manager tags, peers
+	// with the lowest values are disconnected first when trimming connections.
+	ConnMgrValue int
+	Tags         map[string]int
+	// PubsubScore is the peer's gossipsub score, or 0 if pubsub doesn't know
+	// the peer.
+	PubsubScore float64
+}
+
+type NetReconnectPolicy struct {
+	// Enabled turns reconnecting to disconnected allowed peers on or off.
+	Enabled bool
+	// MinBackoff is the delay before retrying after a first failed attempt,
+	// doubling with each failure up to MaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+const (
+	JournalTipSet  = "tipset"
+	JournalMessage = "message"
+	JournalEvent   = "event"
+)
+
+// ChainJournalEntry is an entry of the chain journal. Tipset entries record
+// head changes, message entries the execution of a message and event entries
+// the actor events it emitted. Messages are executed on top of the parent
+// tipset of the tipset they are recorded in, which contains their receipts.
+//
+// When a tipset is reverted, the entries recorded for it are reverted in the
+// reverse order, with Revert set and Reverts the version of the reverted entry.
+type ChainJournalEntry struct {
+	// Version is the position of the entry in the journal, versions strictly
+	// increase.
+	Version uint64
+	Kind    string
+	Revert  bool
+	Reverts uint64 `json:",omitempty"`
+
+	TipSet types.TipSetKey
+	Height abi.ChainEpoch
+	// Parents is set on tipset entries.
+	Parents *types.TipSetKey `json:",omitempty"`
+
+	// Message and Receipt are set on message and event entries.
+	Message *cid.Cid              `json:",omitempty"`
+	Receipt *types.MessageReceipt `json:",omitempty"`
+
+	// Event and EventIndex, the index of the event in the events emitted by
+	// the message, are set on event entries.
+	Event      *types.Event `json:",omitempty"`
+	EventIndex int          `json:",omitempty"`
+}
+
+type ChainJournalPage struct {
+	Entries []ChainJournalEntry
+	// Cursor is the version of the last returned entry, to be passed to the
+	// next ChainJournalSince call.
+	Cursor uint64
+}
+
+// ConsensusFault is a consensus fault detected in the incoming blocks by the
+// consensus fault reporter.
+type ConsensusFault struct {
+	Miner address.Address
+	Epoch abi.ChainEpoch
+	// Type is one of "double-fork mining", "time-offset mining" and
+	// "parent-grinding".
+	Type string
+
+	// Block1 and Block2 are the conflicting blocks, as passed to the
+	// ReportConsensusFault method of the miner actor. Extra is the witness
+	// of parent-grinding faults, if it was found.
+	Block1 cid.Cid
+	Block2 cid.Cid
+	Extra  *cid.Cid `json:",omitempty"`
+
+	Detected time.Time
+
+	// Report is the ReportConsensusFault message sent for the fault, or
+	// ReportError why it couldn't be sent, when reporting is enabled.
+	Report      *cid.Cid `json:",omitempty"`
+	ReportError string   `json:",omitempty"`
+}
+
+// PeerBlockTiming is the history of the arrival times of the blocks relayed
+// by a peer over pubsub, see ChainBlockTiming.
+type PeerBlockTiming struct {
+	Peer peer.ID
+	// Late and Equivocations count the blocks of the history which arrived
+	// late, and which were equivocations of their miner.
+	Late          int
+	Equivocations int
+	// Deprioritized is set when the relay policy lowered the pubsub score of
+	// the peer.
+	Deprioritized bool
+
+	History []BlockArrival
+}
+
+// BlockArrival is a block received over pubsub.
+type BlockArrival struct {
+	Block  cid.Cid
+	Height abi.ChainEpoch
+	Miner  address.Address
+
+	Time time.Time
+	// Offset is the arrival time relative to the start of the epoch of the
+	// block.
+	Offset       time.Duration
+	Late         bool
+	Equivocation bool
+}
+
+// ReplicaStatus is the replication state of a node, see ChainReplicaStatus.
+type ReplicaStatus struct {
+	// Role is "standby" when the node follows a primary, "primary" otherwise.
+	Role string
+
+	// Primary is the node followed by a standby, Connected whether the
+	// standby is currently following it, and PrimaryHead the last head
+	// received from it at LastUpdate.
+	Primary       peer.ID         `json:",omitempty"`
+	Connected     bool            `json:",omitempty"`
+	PrimaryHead   types.TipSetKey `json:",omitempty"`
+	PrimaryHeight abi.ChainEpoch  `json:",omitempty"`
+	LastUpdate    time.Time       `json:",omitempty"`
+
+	// Standbys are the standby nodes following this node.
+	Standbys []ReplicaStandby `json:",omitempty"`
+}
+
+// ReplicaStandby is a standby node following this node.
+type ReplicaStandby struct {
+	Peer  peer.ID
+	Since time.Time
+	// Head is the last tipset sent to the standby.
+	Head   types.TipSetKey
+	Height abi.ChainEpoch
+}
+
+// ChainSnapshot is a chain snapshot exported by the snapshot service, see
+// ChainSnapshots.
+type ChainSnapshot struct {
+	// Name is the file name of the snapshot, under which it is served
+	Name   string
+	Height abi.ChainEpoch
+	TipSet types.TipSetKey
+	Size   int64
+	// SHA256 is the hex checksum of the snapshot file
+	SHA256  string
+	Created time.Time
+	// Took is how long the export took
+	Took time.Duration
+}
+
+// GasStatsFilter selects the gas statistics returned by StateGasStats.
+type GasStatsFilter struct {
+	// FromHeight and ToHeight bound the heights of the tipsets recording the
+	// messages, inclusive. A ToHeight of 0 means no upper bound.
+	FromHeight abi.ChainEpoch
+	ToHeight   abi.ChainEpoch
+
+	ActorCode *cid.Cid       `json:",omitempty"`
+	Method    *abi.MethodNum `json:",omitempty"`
+}
+
+// GasStats are the statistics of the gas used by the messages sent to actors
+// of a code, calling a method. ActorCode is undefined for messages sent to
+// actors which didn't exist after the message executed.
+type GasStats struct {
+	ActorCode  cid.Cid
+	ActorName  string
+	Method     abi.MethodNum
+	MethodName string
+
+	Messages uint64
+	// Failed is the number of messages which exited with an error.
+	Failed uint64
+
+	GasUsed    int64
+	MinGasUsed int64
+	MaxGasUsed int64
+	AvgGasUsed int64
+	GasLimit   int64
+
+	BaseFeeBurn        abi.TokenAmount
+	OverEstimationBurn abi.TokenAmount
+	MinerTip           abi.TokenAmount
+}
+
+type GasStatsResult struct {
+	// FromHeight and ToHeight are the heights of the first and last tipsets
+	// with statistics in the selected range.
+	FromHeight abi.ChainEpoch
+	ToHeight   abi.ChainEpoch
+	TipSets    int
+
+	Stats []GasStats
+}
+
+// MinerPowerSample is a sample of the power and funds of a miner recorded by
+// the power history, see StateMinerPowerHistory.
+type MinerPowerSample struct {
+	Miner  address.Address
+	Height abi.ChainEpoch
+	TipSet types.TipSetKey
+
+	RawBytePower         abi.StoragePower
+	QualityAdjPower      abi.StoragePower
+	TotalRawBytePower    abi.StoragePower
+	TotalQualityAdjPower abi.StoragePower
+
+	Balance           abi.TokenAmount
+	InitialPledge     abi.TokenAmount
+	VestingFunds      abi.TokenAmount
+	PreCommitDeposits abi.TokenAmount
+
+	// BlocksWon is the sum of the win counts of the blocks mined since the
+	// previous sample, and Rewards their block rewards, before penalties.
+	BlocksWon int64
+	Rewards   abi.TokenAmount
+}
+
+// ReplayedEvent is an actor event re-derived by StateReplayEvents.
+type ReplayedEvent struct {
+	// The message emitting the event, and its index in the receipts of the
+	// tipset.
+	MsgCid cid.Cid
+	MsgIdx int
+	// The index of the event among the events of the message.
+	EventIdx int
+
+	Emitter abi.ActorID
+	Entries []types.EventEntry
+}
+
+// EventReplayUpdate holds the events emitted by the messages of a tipset,
+// replayed by StateReplayEvents.
+type EventReplayUpdate struct {
+	Height abi.ChainEpoch
+	TipSet types.TipSetKey
+	Events []ReplayedEvent
+
+	// Replayed is the number of tipsets replayed so far, out of Total.
+	Replayed int
+	Total    int
+
+	// Error is set in the last update when the replay failed.
+	Error string
+}
+
+// WebhookPattern matches messages sent from or to Address, calling Method.
+// An undefined Address matches any address, and a nil Method any method.
+type WebhookPattern struct {
+	Address address.Address
+	Method  *abi.MethodNum
+}
+
+func (p WebhookPattern) Matches(msg *types.Message) bool {
+	if p.Address != address.Undef && msg.From != p.Address && msg.To != p.Address {
+		return false
+	}
+	return p.Method == nil || *p.Method == msg.Method
+}
+
+type WebhookSubscription struct {
+	ID  uuid.UUID
+	URL string
+	// Secret is the key of the HMAC-SHA256 signatures of the notifications,
+	// it isn't returned by WebhookList.
+	Secret   string `json:",omitempty"`
+	Patterns []WebhookPattern
+}
+
+const (
+	WebhookApplied  = "applied"
+	WebhookReverted = "reverted"
+)
+
+// WebhookNotification is the body of the requests posted to webhooks. Type
+// is WebhookApplied when a message is executed, and WebhookReverted when the
+// tipset containing its receipt is reverted.
+type WebhookNotification struct {
+	Subscription uuid.UUID
+	Type         string
+	Message      cid.Cid
+	From         address.Address
+	To           address.Address
+	Method       abi.MethodNum
+	// TipSet is the tipset containing the message receipt, at Height.
+	TipSet  types.TipSetKey
+	Height  abi.ChainEpoch
+	Receipt types.MessageReceipt
+}
+
+type PubsubTopicRejections struct {
+	Topic string
+	Total int64
+	// Reasons are the rejection counts by pubsub rejection reason, messages
+	// ignored by validators are counted as "validation ignored".
+	Reasons map[string]int64
+	// TopPeers are the peers whose messages were rejected the most.
+	TopPeers []PubsubPeerRejections
+}
+
+type PubsubPeerRejections struct {
+	ID       peer.ID
+	Rejected int64
+}
+
+type PubsubRejectedMessage struct {
+	Topic        string
+	ReceivedFrom peer.ID
+	Reason       string
+	Time         time.Time
+	Data         []byte
+}
+
 type ExtendedPeerInfo struct {
 	ID          peer.ID
 	Agent       string
@@ -189,6 +511,94 @@ type NodeChainStatus struct {
 	BlocksPerTipsetLastFinality float64
 }
 
+// SelfTestResult is the outcome of a check of a node self-test
+type SelfTestResult string
+
+const (
+	SelfTestPass SelfTestResult = "pass"
+	SelfTestWarn SelfTestResult = "warn"
+	SelfTestFail SelfTestResult = "fail"
+	// SelfTestSkip is the result of the checks which don't apply to the node
+	SelfTestSkip SelfTestResult = "skip"
+)
+
+type SelfTestCheck struct {
+	Name     string
+	Result   SelfTestResult
+	Message  string
+	Duration time.Duration
+}
+
+// SelfTestReport is the outcome of the checks of the environment of a node:
+// its clock, disks, proof parameters, network reachability, peers and API
+// token.
+type SelfTestReport struct {
+	Time time.Time
+	// Passed is set when no check failed
+	Passed bool
+	Checks []SelfTestCheck
+}
+
+// DiskForecast is the disk usage forecast of a directory tracked by the node,
+// e.g. the chain store, the DAGStore transients or a sealing scratch path.
+type DiskForecast struct {
+	Name string
+	Path string
+
+	// Used is the space used by the directory, and Available the space left
+	// on its filesystem, in bytes
+	Used      int64
+	Available int64
+
+	// GrowthRate is the growth of the used space in bytes per day, over the
+	// samples of the forecast window
+	GrowthRate int64
+	Samples    int
+	// FullIn is the time left until the filesystem is full at the growth
+	// rate, zero when the used space isn't growing
+	FullIn time.Duration
+}
+
+// ShutdownState is the shutdown state of a node component
+type ShutdownState string
+
+const (
+	ShutdownPending  ShutdownState = "pending"
+	ShutdownStopping ShutdownState = "stopping"
+	ShutdownStopped  ShutdownState = "stopped"
+	ShutdownFailed   ShutdownState = "failed"
+	// ShutdownTimedOut is the state of the components which didn't stop within
+	// their grace period, the shutdown carries on without them
+	ShutdownTimedOut ShutdownState = "timed-out"
+)
+
+type ShutdownComponent struct {
+	Name  string
+	State ShutdownState
+	// Grace is how long the component is given to stop, 0 without limit
+	Grace   time.Duration
+	Started time.Time
+	Took    time.Duration
+	Error   string
+}
+
+// ShutdownTask is a task which holds the shutdown of a node while it runs,
+// e.g. a message wait, a sealing task or a retrieval.
+type ShutdownTask struct {
+	Component   string
+	Kind        string
+	Description string
+	// Started is zero when the start of the task isn't known
+	Started time.Time
+}
+
+type ShutdownStatus struct {
+	ShuttingDown bool
+	Started      time.Time
+	Components   []ShutdownComponent
+	InFlight     []ShutdownTask
+}
+
 type CheckStatusCode int
 
 //go:generate go run golang.org/x/tools/cmd/stringer -type=CheckStatusCode -trimprefix=CheckStatus
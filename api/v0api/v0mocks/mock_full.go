@@ -112,6 +112,21 @@ func (mr *MockFullNodeMockRecorder) BeaconGetEntry(arg0, arg1 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeaconGetEntry", reflect.TypeOf((*MockFullNode)(nil).BeaconGetEntry), arg0, arg1)
 }
 
+// Capabilities mocks base method.
+func (m *MockFullNode) Capabilities(arg0 context.Context) (api.APICapabilities, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Capabilities", arg0)
+	ret0, _ := ret[0].(api.APICapabilities)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Capabilities indicates an expected call of Capabilities.
+func (mr *MockFullNodeMockRecorder) Capabilities(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Capabilities", reflect.TypeOf((*MockFullNode)(nil).Capabilities), arg0)
+}
+
 // ChainDeleteObj mocks base method.
 func (m *MockFullNode) ChainDeleteObj(arg0 context.Context, arg1 cid.Cid) error {
 	m.ctrl.T.Helper()
@@ -896,6 +911,21 @@ func (mr *MockFullNodeMockRecorder) Discover(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Discover", reflect.TypeOf((*MockFullNode)(nil).Discover), arg0)
 }
 
+// DiskForecast mocks base method.
+func (m *MockFullNode) DiskForecast(arg0 context.Context) ([]api.DiskForecast, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DiskForecast", arg0)
+	ret0, _ := ret[0].([]api.DiskForecast)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DiskForecast indicates an expected call of DiskForecast.
+func (mr *MockFullNodeMockRecorder) DiskForecast(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiskForecast", reflect.TypeOf((*MockFullNode)(nil).DiskForecast), arg0)
+}
+
 // GasEstimateFeeCap mocks base method.
 func (m *MockFullNode) GasEstimateFeeCap(arg0 context.Context, arg1 *types.Message, arg2 int64, arg3 types.TipSetKey) (big.Int, error) {
 	m.ctrl.T.Helper()
@@ -1582,6 +1612,49 @@ func (mr *MockFullNodeMockRecorder) NetAgentVersion(arg0, arg1 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetAgentVersion", reflect.TypeOf((*MockFullNode)(nil).NetAgentVersion), arg0, arg1)
 }
 
+// NetAllowAdd mocks base method.
+func (m *MockFullNode) NetAllowAdd(arg0 context.Context, arg1 []peer.AddrInfo) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetAllowAdd", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// NetAllowAdd indicates an expected call of NetAllowAdd.
+func (mr *MockFullNodeMockRecorder) NetAllowAdd(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetAllowAdd", reflect.TypeOf((*MockFullNode)(nil).NetAllowAdd), arg0, arg1)
+}
+
+// NetAllowList mocks base method.
+func (m *MockFullNode) NetAllowList(arg0 context.Context) ([]peer.AddrInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetAllowList", arg0)
+	ret0, _ := ret[0].([]peer.AddrInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NetAllowList indicates an expected call of NetAllowList.
+func (mr *MockFullNodeMockRecorder) NetAllowList(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetAllowList", reflect.TypeOf((*MockFullNode)(nil).NetAllowList), arg0)
+}
+
+// NetAllowRemove mocks base method.
+func (m *MockFullNode) NetAllowRemove(arg0 context.Context, arg1 []peer.ID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetAllowRemove", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// NetAllowRemove indicates an expected call of NetAllowRemove.
+func (mr *MockFullNodeMockRecorder) NetAllowRemove(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetAllowRemove", reflect.TypeOf((*MockFullNode)(nil).NetAllowRemove), arg0, arg1)
+}
+
 // NetAutoNatStatus mocks base method.
 func (m *MockFullNode) NetAutoNatStatus(arg0 context.Context) (api.NatInfo, error) {
 	m.ctrl.T.Helper()
@@ -1743,6 +1816,21 @@ func (mr *MockFullNodeMockRecorder) NetFindPeer(arg0, arg1 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetFindPeer", reflect.TypeOf((*MockFullNode)(nil).NetFindPeer), arg0, arg1)
 }
 
+// NetGetReconnectPolicy mocks base method.
+func (m *MockFullNode) NetGetReconnectPolicy(arg0 context.Context) (api.NetReconnectPolicy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetGetReconnectPolicy", arg0)
+	ret0, _ := ret[0].(api.NetReconnectPolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NetGetReconnectPolicy indicates an expected call of NetGetReconnectPolicy.
+func (mr *MockFullNodeMockRecorder) NetGetReconnectPolicy(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetGetReconnectPolicy", reflect.TypeOf((*MockFullNode)(nil).NetGetReconnectPolicy), arg0)
+}
+
 // NetLimit mocks base method.
 func (m *MockFullNode) NetLimit(arg0 context.Context, arg1 string) (api.NetLimit, error) {
 	m.ctrl.T.Helper()
@@ -1773,6 +1861,21 @@ func (mr *MockFullNodeMockRecorder) NetPeerInfo(arg0, arg1 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetPeerInfo", reflect.TypeOf((*MockFullNode)(nil).NetPeerInfo), arg0, arg1)
 }
 
+// NetPeerScores mocks base method.
+func (m *MockFullNode) NetPeerScores(arg0 context.Context) ([]api.PeerScore, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetPeerScores", arg0)
+	ret0, _ := ret[0].([]api.PeerScore)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NetPeerScores indicates an expected call of NetPeerScores.
+func (mr *MockFullNodeMockRecorder) NetPeerScores(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetPeerScores", reflect.TypeOf((*MockFullNode)(nil).NetPeerScores), arg0)
+}
+
 // NetPeers mocks base method.
 func (m *MockFullNode) NetPeers(arg0 context.Context) ([]peer.AddrInfo, error) {
 	m.ctrl.T.Helper()
@@ -1846,6 +1949,36 @@ func (mr *MockFullNodeMockRecorder) NetProtectRemove(arg0, arg1 interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetProtectRemove", reflect.TypeOf((*MockFullNode)(nil).NetProtectRemove), arg0, arg1)
 }
 
+// NetPubsubRejectedMessages mocks base method.
+func (m *MockFullNode) NetPubsubRejectedMessages(arg0 context.Context, arg1 string) ([]api.PubsubRejectedMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetPubsubRejectedMessages", arg0, arg1)
+	ret0, _ := ret[0].([]api.PubsubRejectedMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NetPubsubRejectedMessages indicates an expected call of NetPubsubRejectedMessages.
+func (mr *MockFullNodeMockRecorder) NetPubsubRejectedMessages(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetPubsubRejectedMessages", reflect.TypeOf((*MockFullNode)(nil).NetPubsubRejectedMessages), arg0, arg1)
+}
+
+// NetPubsubRejections mocks base method.
+func (m *MockFullNode) NetPubsubRejections(arg0 context.Context) ([]api.PubsubTopicRejections, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetPubsubRejections", arg0)
+	ret0, _ := ret[0].([]api.PubsubTopicRejections)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NetPubsubRejections indicates an expected call of NetPubsubRejections.
+func (mr *MockFullNodeMockRecorder) NetPubsubRejections(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetPubsubRejections", reflect.TypeOf((*MockFullNode)(nil).NetPubsubRejections), arg0)
+}
+
 // NetPubsubScores mocks base method.
 func (m *MockFullNode) NetPubsubScores(arg0 context.Context) ([]api.PubsubScore, error) {
 	m.ctrl.T.Helper()
@@ -1875,6 +2008,34 @@ func (mr *MockFullNodeMockRecorder) NetSetLimit(arg0, arg1, arg2 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetSetLimit", reflect.TypeOf((*MockFullNode)(nil).NetSetLimit), arg0, arg1, arg2)
 }
 
+// NetSetPeerTag mocks base method.
+func (m *MockFullNode) NetSetPeerTag(arg0 context.Context, arg1 peer.ID, arg2 string, arg3 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetSetPeerTag", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// NetSetPeerTag indicates an expected call of NetSetPeerTag.
+func (mr *MockFullNodeMockRecorder) NetSetPeerTag(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetSetPeerTag", reflect.TypeOf((*MockFullNode)(nil).NetSetPeerTag), arg0, arg1, arg2, arg3)
+}
+
+// NetSetReconnectPolicy mocks base method.
+func (m *MockFullNode) NetSetReconnectPolicy(arg0 context.Context, arg1 api.NetReconnectPolicy) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetSetReconnectPolicy", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// NetSetReconnectPolicy indicates an expected call of NetSetReconnectPolicy.
+func (mr *MockFullNodeMockRecorder) NetSetReconnectPolicy(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetSetReconnectPolicy", reflect.TypeOf((*MockFullNode)(nil).NetSetReconnectPolicy), arg0, arg1)
+}
+
 // NetStat mocks base method.
 func (m *MockFullNode) NetStat(arg0 context.Context, arg1 string) (api.NetStat, error) {
 	m.ctrl.T.Helper()
@@ -2158,6 +2319,21 @@ func (mr *MockFullNodeMockRecorder) Shutdown(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Shutdown", reflect.TypeOf((*MockFullNode)(nil).Shutdown), arg0)
 }
 
+// ShutdownStatus mocks base method.
+func (m *MockFullNode) ShutdownStatus(arg0 context.Context) (api.ShutdownStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ShutdownStatus", arg0)
+	ret0, _ := ret[0].(api.ShutdownStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ShutdownStatus indicates an expected call of ShutdownStatus.
+func (mr *MockFullNodeMockRecorder) ShutdownStatus(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShutdownStatus", reflect.TypeOf((*MockFullNode)(nil).ShutdownStatus), arg0)
+}
+
 // StartTime mocks base method.
 func (m *MockFullNode) StartTime(arg0 context.Context) (time.Time, error) {
 	m.ctrl.T.Helper()
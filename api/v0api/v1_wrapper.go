@@ -77,6 +77,18 @@ func (w *WrapperV1Full) Version(ctx context.Context) (api.APIVersion, error) {
 	return ver, nil
 }
 
+func (w *WrapperV1Full) Capabilities(ctx context.Context) (api.APICapabilities, error) {
+	caps, err := w.FullNode.Capabilities(ctx)
+	if err != nil {
+		return api.APICapabilities{}, err
+	}
+
+	caps.APIVersion = api.FullAPIVersion0
+	caps.Deprecated = append(append([]api.MethodDeprecation{}, caps.Deprecated...), api.FullAPIDeprecated0...)
+
+	return caps, nil
+}
+
 func (w *WrapperV1Full) executePrototype(ctx context.Context, p *api.MessagePrototype) (cid.Cid, error) {
 	sm, err := w.FullNode.MpoolPushMessage(ctx, &p.Message, nil)
 	if err != nil {
@@ -52,6 +52,77 @@ func VersionForType(nodeType NodeType) (Version, error) {
 	}
 }
 
+// MethodDeprecation describes a deprecated API method.
+type MethodDeprecation struct {
+	Method string
+	// Replacement is the method to call instead, if any
+	Replacement string `json:",omitempty"`
+	Note        string `json:",omitempty"`
+}
+
+// APICapabilities is reported by the nodes on RPC connect, letting the clients
+// check that they can talk to the node, and warn about the deprecated methods
+// they call.
+type APICapabilities struct {
+	// APIVersion is the version of the API served on the endpoint the client is
+	// connected to
+	APIVersion Version
+	// Versions are the API versions served by the node, by endpoint (e.g. "v0")
+	Versions map[string]Version
+	// Deprecated are the deprecated methods of the API served on the endpoint
+	Deprecated []MethodDeprecation
+}
+
+// Deprecation returns the deprecation status of the method.
+func (c APICapabilities) Deprecation(method string) (MethodDeprecation, bool) {
+	for _, d := range c.Deprecated {
+		if d.Method == method {
+			return d, true
+		}
+	}
+	return MethodDeprecation{}, false
+}
+
+// deprecated methods of the rpc api exposed
+var (
+	FullAPIDeprecated0 = []MethodDeprecation{
+		{Method: "StateGetReceipt", Replacement: "StateSearchMsg", Note: "not supported in the v1 API"},
+	}
+	FullAPIDeprecated1 = []MethodDeprecation{}
+
+	MinerAPIDeprecated0 = []MethodDeprecation{
+		{Method: "MarketListRetrievalDeals", Note: "always returns an empty list"},
+	}
+	WorkerAPIDeprecated0 = []MethodDeprecation{}
+)
+
+// CapabilitiesForType returns the capabilities of the main API endpoint of the
+// node type.
+func CapabilitiesForType(nodeType NodeType) (APICapabilities, error) {
+	switch nodeType {
+	case NodeFull:
+		return APICapabilities{
+			APIVersion: FullAPIVersion1,
+			Versions:   map[string]Version{"v0": FullAPIVersion0, "v1": FullAPIVersion1},
+			Deprecated: FullAPIDeprecated1,
+		}, nil
+	case NodeMiner:
+		return APICapabilities{
+			APIVersion: MinerAPIVersion0,
+			Versions:   map[string]Version{"v0": MinerAPIVersion0},
+			Deprecated: MinerAPIDeprecated0,
+		}, nil
+	case NodeWorker:
+		return APICapabilities{
+			APIVersion: WorkerAPIVersion0,
+			Versions:   map[string]Version{"v0": WorkerAPIVersion0},
+			Deprecated: WorkerAPIDeprecated0,
+		}, nil
+	default:
+		return APICapabilities{}, xerrors.Errorf("unknown node type %d", nodeType)
+	}
+}
+
 // semver versions of the rpc api exposed
 var (
 	FullAPIVersion0 = newVer(1, 5, 0)
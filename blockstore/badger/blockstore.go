@@ -128,6 +128,7 @@ var _ blockstore.Viewer = (*Blockstore)(nil)
 var _ blockstore.BlockstoreIterator = (*Blockstore)(nil)
 var _ blockstore.BlockstoreGC = (*Blockstore)(nil)
 var _ blockstore.BlockstoreSize = (*Blockstore)(nil)
+var _ blockstore.BlockstoreMaintain = (*Blockstore)(nil)
 var _ io.Closer = (*Blockstore)(nil)
 
 // Open creates a new badger-backed blockstore, with the supplied options.
@@ -449,15 +450,7 @@ func (b *Blockstore) onlineGC(ctx context.Context, threshold float64, checkFreq
 	defer b.unlockDB()
 
 	// compact first to gather the necessary statistics for GC
-	nworkers := runtime.NumCPU() / 2
-	if nworkers < 2 {
-		nworkers = 2
-	}
-	if nworkers > 7 { // max out at 1 goroutine per badger level
-		nworkers = 7
-	}
-
-	err := b.db.Flatten(nworkers)
+	err := b.db.Flatten(flattenWorkers())
 	if err != nil {
 		return err
 	}
@@ -483,6 +476,17 @@ func (b *Blockstore) onlineGC(ctx context.Context, threshold float64, checkFreq
 	return err
 }
 
+func flattenWorkers() int {
+	nworkers := runtime.NumCPU() / 2
+	if nworkers < 2 {
+		nworkers = 2
+	}
+	if nworkers > 7 { // max out at 1 goroutine per badger level
+		nworkers = 7
+	}
+	return nworkers
+}
+
 // CollectGarbage compacts and runs garbage collection on the value log;
 // implements the BlockstoreGC trait
 func (b *Blockstore) CollectGarbage(ctx context.Context, opts ...blockstore.BlockstoreGCOption) error {
@@ -556,6 +560,58 @@ func (b *Blockstore) GCOnce(ctx context.Context, opts ...blockstore.BlockstoreGC
 	return err
 }
 
+// Maintain runs online maintenance of the blockstore; implements the BlockstoreMaintain trait.
+// The value log files holding more than threshold garbage are rewritten until there are none
+// left or ctx is done. In compaction mode the LSM tree is first flattened, which gathers the
+// statistics of the whole value log for GC; the compaction itself can't be interrupted.
+func (b *Blockstore) Maintain(ctx context.Context, mode blockstore.MaintenanceMode, threshold float64, progress func(blockstore.MaintenanceProgress)) error {
+	if err := b.access(); err != nil {
+		return err
+	}
+	defer b.viewers.Done()
+
+	if mode != blockstore.MaintainValueLogGC && mode != blockstore.MaintainCompaction {
+		return xerrors.Errorf("unknown maintenance mode %q", mode)
+	}
+	if threshold == 0 {
+		threshold = defaultGCThreshold
+	}
+
+	b.lockDB()
+	defer b.unlockDB()
+
+	var p blockstore.MaintenanceProgress
+	if mode == blockstore.MaintainCompaction {
+		p.Phase = blockstore.MaintenancePhaseCompaction
+		progress(p)
+
+		if err := b.db.Flatten(flattenWorkers()); err != nil {
+			return xerrors.Errorf("compacting blockstore: %w", err)
+		}
+	}
+
+	p.Phase = blockstore.MaintenancePhaseValueLogGC
+	progress(p)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		err := b.db.RunValueLogGC(threshold)
+		if err == badger.ErrNoRewrite {
+			// no value log file left with enough garbage
+			return nil
+		}
+		if err != nil {
+			return xerrors.Errorf("value log GC: %w", err)
+		}
+
+		p.ValueLogsRewritten++
+		progress(p)
+	}
+}
+
 // Size returns the aggregate size of the blockstore
 func (b *Blockstore) Size() (int64, error) {
 	if err := b.access(); err != nil {
@@ -92,6 +92,35 @@ func WithCheck(check func() error) BlockstoreGCOption {
 	}
 }
 
+// MaintenanceMode selects the work done by a blockstore maintenance run
+type MaintenanceMode string
+
+const (
+	// MaintainValueLogGC garbage collects the value log of the blockstore
+	MaintainValueLogGC MaintenanceMode = "vlog-gc"
+	// MaintainCompaction fully compacts the blockstore before garbage collecting its value log
+	MaintainCompaction MaintenanceMode = "compaction"
+)
+
+// Phases of a maintenance run, as reported by MaintenanceProgress
+const (
+	MaintenancePhaseCompaction = "compaction"
+	MaintenancePhaseValueLogGC = "vlog-gc"
+)
+
+// MaintenanceProgress reports the progress of a maintenance run
+type MaintenanceProgress struct {
+	Phase string
+	// number of value log files rewritten so far
+	ValueLogsRewritten int
+}
+
+// BlockstoreMaintain is a trait for blockstores that support online maintenance of their
+// on-disk structures; progress is called whenever the run makes progress
+type BlockstoreMaintain interface {
+	Maintain(ctx context.Context, mode MaintenanceMode, threshold float64, progress func(MaintenanceProgress)) error
+}
+
 // BlockstoreSize is a trait for on-disk blockstores that can report their size
 type BlockstoreSize interface {
 	Size() (int64, error)
@@ -0,0 +1,323 @@
+package blockstore
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"golang.org/x/xerrors"
+)
+
+// ErrCacheNotFound is returned when resizing a cache which isn't registered.
+var ErrCacheNotFound = xerrors.New("cache not found")
+
+// CacheStats are the statistics of a cache.
+type CacheStats struct {
+	Name string
+	// Size is the maximum number of entries of the cache, BaseSize the size
+	// it was configured with, which the adaptive mode grows from.
+	Size     int
+	BaseSize int
+	Entries  int
+
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+}
+
+// TunableCache is a cache which can be registered with a CacheTuner.
+type TunableCache interface {
+	Stats() CacheStats
+
+	setBaseSize(size int)
+	resize(size int)
+}
+
+// Cache is a size-bounded LRU cache counting its hits and misses. Caches
+// registered with a CacheTuner can be resized at runtime, and their metrics
+// are reported by the tuner.
+type Cache[K comparable, V any] struct {
+	name string
+	lru  *lru.Cache[K, V]
+
+	lk       sync.Mutex
+	size     int
+	baseSize int
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+}
+
+// NewCache creates a cache of the given size, which must be positive.
+func NewCache[K comparable, V any](name string, size int) (*Cache[K, V], error) {
+	c := &Cache[K, V]{
+		name:     name,
+		size:     size,
+		baseSize: size,
+	}
+
+	var err error
+	c.lru, err = lru.NewWithEvict[K, V](size, func(K, V) {
+		c.evictions.Add(1)
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("creating cache %s: %w", name, err)
+	}
+	return c, nil
+}
+
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	v, ok := c.lru.Get(key)
+	if ok {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+	return v, ok
+}
+
+// Contains tells whether the key is cached, without counting a hit or a miss.
+func (c *Cache[K, V]) Contains(key K) bool {
+	return c.lru.Contains(key)
+}
+
+func (c *Cache[K, V]) Add(key K, value V) {
+	c.lru.Add(key, value)
+}
+
+func (c *Cache[K, V]) Remove(key K) {
+	c.lru.Remove(key)
+}
+
+// Purge removes all the entries of the cache, they are counted as evicted.
+func (c *Cache[K, V]) Purge() {
+	c.lru.Purge()
+}
+
+func (c *Cache[K, V]) Stats() CacheStats {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	return CacheStats{
+		Name:      c.name,
+		Size:      c.size,
+		BaseSize:  c.baseSize,
+		Entries:   c.lru.Len(),
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+	}
+}
+
+func (c *Cache[K, V]) setBaseSize(size int) {
+	c.lk.Lock()
+	c.baseSize = size
+	c.lk.Unlock()
+	c.resize(size)
+}
+
+func (c *Cache[K, V]) resize(size int) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	c.size = size
+	c.lru.Resize(size)
+}
+
+// CacheTunerConfig configures the adaptive mode of a CacheTuner.
+type CacheTunerConfig struct {
+	// Adaptive enables growing the caches under read-heavy load.
+	Adaptive bool
+	// MaxFactor bounds how many times their base size caches grow to.
+	MaxFactor int
+	// MinLookupRate is the rate of lookups per second from which a cache is
+	// under read-heavy load.
+	MinLookupRate int
+	// TargetHitRatio is the hit ratio under which a full cache under
+	// read-heavy load is grown.
+	TargetHitRatio float64
+}
+
+// adaptEvery is the number of metrics emissions between adaptations of the
+// cache sizes.
+const adaptEvery = 12
+
+// CacheTuner keeps the caches of a node by name, so that their sizes can be
+// tuned at runtime with ResizeCache, and reports their metrics every
+// CacheMetricsEmitInterval. In adaptive mode, it doubles the size of full
+// caches which miss too often under read-heavy load, up to MaxFactor times
+// their base size, and halves it back towards their base size once the load
+// drops.
+type CacheTuner struct {
+	cfg CacheTunerConfig
+
+	lk     sync.Mutex
+	caches map[string]TunableCache
+	last   map[string]CacheStats
+
+	closing chan struct{}
+	closed  chan struct{}
+}
+
+func NewCacheTuner(cfg CacheTunerConfig) *CacheTuner {
+	return &CacheTuner{
+		cfg:     cfg,
+		caches:  map[string]TunableCache{},
+		last:    map[string]CacheStats{},
+		closing: make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+}
+
+// Register adds the cache to the caches of the tuner, their names must be
+// unique.
+func (t *CacheTuner) Register(c TunableCache) error {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	name := c.Stats().Name
+	if _, ok := t.caches[name]; ok {
+		return xerrors.Errorf("cache %s already registered", name)
+	}
+	t.caches[name] = c
+	return nil
+}
+
+// Unregister removes the cache with the given name from the caches of the
+// tuner.
+func (t *CacheTuner) Unregister(name string) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	delete(t.caches, name)
+	delete(t.last, name)
+}
+
+// Caches returns the statistics of the registered caches, sorted by name.
+func (t *CacheTuner) Caches() []CacheStats {
+	t.lk.Lock()
+	out := make([]CacheStats, 0, len(t.caches))
+	for _, c := range t.caches {
+		out = append(out, c.Stats())
+	}
+	t.lk.Unlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// ResizeCache sets the size of the registered cache with the given name. The
+// adaptive mode grows the cache from this size.
+func (t *CacheTuner) ResizeCache(name string, size int) error {
+	if size <= 0 {
+		return xerrors.Errorf("cache size must be positive, got %d", size)
+	}
+
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	c, ok := t.caches[name]
+	if !ok {
+		return xerrors.Errorf("resizing cache %s: %w", name, ErrCacheNotFound)
+	}
+	c.setBaseSize(size)
+	return nil
+}
+
+func (t *CacheTuner) Start(context.Context) error {
+	go t.run()
+	return nil
+}
+
+func (t *CacheTuner) Stop(ctx context.Context) error {
+	close(t.closing)
+
+	select {
+	case <-t.closed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (t *CacheTuner) run() {
+	defer close(t.closed)
+
+	ticker := time.NewTicker(CacheMetricsEmitInterval)
+	defer ticker.Stop()
+
+	for i := 1; ; i++ {
+		select {
+		case <-ticker.C:
+		case <-t.closing:
+			return
+		}
+
+		t.emit()
+		if t.cfg.Adaptive && i%adaptEvery == 0 {
+			t.adapt(adaptEvery * CacheMetricsEmitInterval)
+		}
+	}
+}
+
+func (t *CacheTuner) emit() {
+	for _, st := range t.Caches() {
+		ctx, _ := tag.New(context.Background(), tag.Upsert(CacheName, st.Name))
+
+		var ratio float64
+		if lookups := st.Hits + st.Misses; lookups > 0 {
+			ratio = float64(st.Hits) / float64(lookups)
+		}
+		stats.Record(ctx,
+			CacheMeasures.HitRatio.M(ratio),
+			CacheMeasures.Hits.M(int64(st.Hits)),
+			CacheMeasures.Misses.M(int64(st.Misses)),
+			CacheMeasures.Entries.M(int64(st.Entries)),
+			CacheMeasures.Evictions.M(int64(st.Evictions)),
+		)
+	}
+}
+
+func (t *CacheTuner) adapt(window time.Duration) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	for name, c := range t.caches {
+		st := c.Stats()
+		last, ok := t.last[name]
+		t.last[name] = st
+		if !ok {
+			continue
+		}
+
+		hits, misses := st.Hits-last.Hits, st.Misses-last.Misses
+		rate := float64(hits+misses) / window.Seconds()
+		maxSize := st.BaseSize * t.cfg.MaxFactor
+
+		switch {
+		case rate >= float64(t.cfg.MinLookupRate) && st.Entries >= st.Size && st.Size < maxSize &&
+			float64(hits) < t.cfg.TargetHitRatio*float64(hits+misses):
+			size := st.Size * 2
+			if size > maxSize {
+				size = maxSize
+			}
+			log.Infow("growing cache under read-heavy load", "cache", st.Name, "size", size, "lookupRate", rate)
+			c.resize(size)
+		case rate < float64(t.cfg.MinLookupRate)/4 && st.Size > st.BaseSize:
+			size := st.Size / 2
+			if size < st.BaseSize {
+				size = st.BaseSize
+			}
+			log.Infow("shrinking cache after the load dropped", "cache", st.Name, "size", size, "lookupRate", rate)
+			c.resize(size)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+// stm: #unit
+package blockstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachedBlockstore(t *testing.T) {
+	ctx := context.Background()
+
+	mem := NewMemory()
+	require.NoError(t, mem.PutMany(ctx, []blocks.Block{b0, b1, b2}))
+
+	cache, err := NewCache[cid.Cid, blocks.Block]("test-cached", 2)
+	require.NoError(t, err)
+	cbs := NewCachedBlockstore(mem, cache)
+
+	blk, err := cbs.Get(ctx, b0.Cid())
+	require.NoError(t, err)
+	require.Equal(t, b0.RawData(), blk.RawData())
+
+	// served from the cache
+	require.NoError(t, mem.DeleteBlock(ctx, b0.Cid()))
+	blk, err = cbs.Get(ctx, b0.Cid())
+	require.NoError(t, err)
+	require.Equal(t, b0.RawData(), blk.RawData())
+
+	require.NoError(t, cbs.View(ctx, b1.Cid(), func(data []byte) error {
+		require.Equal(t, b1.RawData(), data)
+		return nil
+	}))
+	require.NoError(t, mem.DeleteBlock(ctx, b1.Cid()))
+	has, err := cbs.Has(ctx, b1.Cid())
+	require.NoError(t, err)
+	require.True(t, has)
+
+	// the reads with a hot view aren't served from the cache
+	has, err = cbs.Has(WithHotView(ctx), b1.Cid())
+	require.NoError(t, err)
+	require.False(t, has)
+
+	st := cbs.cache.Stats()
+	require.Equal(t, "test-cached", st.Name)
+	require.Equal(t, 2, st.Entries)
+	require.Equal(t, uint64(1), st.Hits)
+	require.Equal(t, uint64(2), st.Misses)
+
+	// b0 is evicted
+	_, err = cbs.Get(ctx, b2.Cid())
+	require.NoError(t, err)
+	_, err = cbs.Get(ctx, b0.Cid())
+	require.True(t, ipld.IsNotFound(err))
+	require.Equal(t, uint64(1), cbs.cache.Stats().Evictions)
+
+	// deleted blocks are dropped from the cache
+	require.NoError(t, cbs.DeleteBlock(ctx, b2.Cid()))
+	has, err = cbs.Has(ctx, b2.Cid())
+	require.NoError(t, err)
+	require.False(t, has)
+
+	// nor are they once purged
+	cbs.Purge()
+	has, err = cbs.Has(ctx, b1.Cid())
+	require.NoError(t, err)
+	require.False(t, has)
+}
+
+func TestResizeCache(t *testing.T) {
+	tuner := NewCacheTuner(CacheTunerConfig{})
+
+	c, err := NewCache[int, int]("test-resize", 4)
+	require.NoError(t, err)
+	require.NoError(t, tuner.Register(c))
+
+	for i := 0; i < 4; i++ {
+		c.Add(i, i)
+	}
+
+	require.NoError(t, tuner.ResizeCache("test-resize", 2))
+	st := c.Stats()
+	require.Equal(t, 2, st.Size)
+	require.Equal(t, 2, st.BaseSize)
+	require.Equal(t, 2, st.Entries)
+
+	require.Error(t, tuner.ResizeCache("test-resize", 0))
+	require.True(t, errors.Is(tuner.ResizeCache("test-unknown", 2), ErrCacheNotFound))
+
+	// the caches of other tuners with the same name aren't resized
+	other, err := NewCache[int, int]("test-resize", 4)
+	require.NoError(t, err)
+	require.Error(t, tuner.Register(other))
+	require.NoError(t, NewCacheTuner(CacheTunerConfig{}).Register(other))
+	require.NoError(t, tuner.ResizeCache("test-resize", 3))
+	require.Equal(t, 4, other.Stats().Size)
+
+	caches := tuner.Caches()
+	require.Len(t, caches, 1)
+	require.Equal(t, "test-resize", caches[0].Name)
+
+	tuner.Unregister("test-resize")
+	require.True(t, errors.Is(tuner.ResizeCache("test-resize", 2), ErrCacheNotFound))
+}
+
+func TestCacheTunerAdapt(t *testing.T) {
+	c, err := NewCache[int, int]("test-adapt", 10)
+	require.NoError(t, err)
+
+	tuner := NewCacheTuner(CacheTunerConfig{
+		Adaptive:       true,
+		MaxFactor:      3,
+		MinLookupRate:  10,
+		TargetHitRatio: 0.9,
+	})
+	require.NoError(t, tuner.Register(c))
+	window := time.Second
+
+	lookups := func(n int) {
+		for i := 0; i < n; i++ {
+			c.Add(i, i)
+			c.Get(i + 1000)
+		}
+	}
+
+	// the first adaptation only records the counters
+	tuner.adapt(window)
+	require.Equal(t, 10, c.Stats().Size)
+
+	// full, missing and under load, the cache grows up to 3 times its size
+	lookups(100)
+	tuner.adapt(window)
+	require.Equal(t, 20, c.Stats().Size)
+
+	lookups(100)
+	tuner.adapt(window)
+	require.Equal(t, 30, c.Stats().Size)
+
+	lookups(100)
+	tuner.adapt(window)
+	require.Equal(t, 30, c.Stats().Size)
+
+	// it shrinks back once the load drops
+	tuner.adapt(window)
+	require.Equal(t, 15, c.Stats().Size)
+	tuner.adapt(window)
+	require.Equal(t, 10, c.Stats().Size)
+	tuner.adapt(window)
+	require.Equal(t, 10, c.Stats().Size)
+
+	// caches hitting enough aren't grown
+	for i := 0; i < 10; i++ {
+		c.Add(i, i)
+	}
+	for i := 0; i < 100; i++ {
+		c.Get(i % 10)
+	}
+	tuner.adapt(window)
+	require.Equal(t, 10, c.Stats().Size)
+}
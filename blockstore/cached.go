@@ -0,0 +1,122 @@
+package blockstore
+
+import (
+	"context"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+)
+
+var _ Blockstore = (*CachedBlockstore)(nil)
+
+// CachedBlockstore keeps the blocks read from a blockstore in a cache, so that
+// hot blocks, e.g. the state tree and actor HAMT nodes read over and over by
+// RPC calls, are read from memory. Blocks are only cached when read, writes go
+// to the underlying blockstore.
+//
+// The reads served from the cache don't reach the underlying blockstore, so
+// the cache must be purged when the underlying blockstore is garbage
+// collected, and the reads with a hot view are always passed through, for the
+// blocks to be moved to the hot store.
+type CachedBlockstore struct {
+	bs    Blockstore
+	cache *Cache[cid.Cid, blocks.Block]
+}
+
+// NewCachedBlockstore creates a blockstore caching the blocks read from bs in
+// the cache.
+func NewCachedBlockstore(bs Blockstore, cache *Cache[cid.Cid, blocks.Block]) *CachedBlockstore {
+	return &CachedBlockstore{bs: bs, cache: cache}
+}
+
+// Purge empties the cache.
+func (b *CachedBlockstore) Purge() {
+	b.cache.Purge()
+}
+
+func (b *CachedBlockstore) Has(ctx context.Context, c cid.Cid) (bool, error) {
+	if IsHotView(ctx) {
+		return b.bs.Has(ctx, c)
+	}
+	if b.cache.Contains(c) {
+		return true, nil
+	}
+	return b.bs.Has(ctx, c)
+}
+
+func (b *CachedBlockstore) Get(ctx context.Context, c cid.Cid) (blocks.Block, error) {
+	if IsHotView(ctx) {
+		return b.bs.Get(ctx, c)
+	}
+	if blk, ok := b.cache.Get(c); ok {
+		return blk, nil
+	}
+
+	blk, err := b.bs.Get(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	b.cache.Add(c, blk)
+	return blk, nil
+}
+
+func (b *CachedBlockstore) GetSize(ctx context.Context, c cid.Cid) (int, error) {
+	if IsHotView(ctx) {
+		return b.bs.GetSize(ctx, c)
+	}
+	if blk, ok := b.cache.Get(c); ok {
+		return len(blk.RawData()), nil
+	}
+	return b.bs.GetSize(ctx, c)
+}
+
+func (b *CachedBlockstore) View(ctx context.Context, c cid.Cid, callback func([]byte) error) error {
+	if IsHotView(ctx) {
+		return b.bs.View(ctx, c, callback)
+	}
+	if blk, ok := b.cache.Get(c); ok {
+		return callback(blk.RawData())
+	}
+
+	return b.bs.View(ctx, c, func(data []byte) error {
+		// the data is only valid in the callback
+		cpy := make([]byte, len(data))
+		copy(cpy, data)
+		if blk, err := blocks.NewBlockWithCid(cpy, c); err == nil {
+			b.cache.Add(c, blk)
+		}
+		return callback(data)
+	})
+}
+
+func (b *CachedBlockstore) Put(ctx context.Context, blk blocks.Block) error {
+	return b.bs.Put(ctx, blk)
+}
+
+func (b *CachedBlockstore) PutMany(ctx context.Context, blks []blocks.Block) error {
+	return b.bs.PutMany(ctx, blks)
+}
+
+func (b *CachedBlockstore) DeleteBlock(ctx context.Context, c cid.Cid) error {
+	b.cache.Remove(c)
+	return b.bs.DeleteBlock(ctx, c)
+}
+
+func (b *CachedBlockstore) DeleteMany(ctx context.Context, cids []cid.Cid) error {
+	for _, c := range cids {
+		b.cache.Remove(c)
+	}
+	return b.bs.DeleteMany(ctx, cids)
+}
+
+func (b *CachedBlockstore) AllKeysChan(ctx context.Context) (<-chan cid.Cid, error) {
+	return b.bs.AllKeysChan(ctx)
+}
+
+func (b *CachedBlockstore) HashOnRead(enabled bool) {
+	b.bs.HashOnRead(enabled)
+}
+
+func (b *CachedBlockstore) Flush(ctx context.Context) error {
+	return b.bs.Flush(ctx)
+}
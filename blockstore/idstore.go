@@ -183,3 +183,19 @@ func (b *idstore) Close() error {
 func (b *idstore) Flush(ctx context.Context) error {
 	return b.bs.Flush(ctx)
 }
+
+func (b *idstore) Maintain(ctx context.Context, mode MaintenanceMode, threshold float64, progress func(MaintenanceProgress)) error {
+	m, ok := b.bs.(BlockstoreMaintain)
+	if !ok {
+		return xerrors.Errorf("underlying blockstore (type %T) doesn't support maintenance", b.bs)
+	}
+	return m.Maintain(ctx, mode, threshold, progress)
+}
+
+func (b *idstore) Size() (int64, error) {
+	sz, ok := b.bs.(BlockstoreSize)
+	if !ok {
+		return 0, xerrors.Errorf("underlying blockstore (type %T) doesn't report its size", b.bs)
+	}
+	return sz.Size()
+}
@@ -0,0 +1,282 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/build"
+)
+
+var log = logging.Logger("bsmaint")
+
+// checkInterval is how often the scheduler checks whether a maintenance
+// window is open.
+var checkInterval = time.Minute
+
+// Window is a daily time window, as offsets from midnight in the local time
+// of the node. A window ending before it starts wraps around midnight.
+type Window struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// ParseWindow parses a window in the "HH:MM-HH:MM" format.
+func ParseWindow(s string) (Window, error) {
+	var sh, sm, eh, em int
+	if _, err := fmt.Sscanf(s, "%d:%d-%d:%d", &sh, &sm, &eh, &em); err != nil {
+		return Window{}, xerrors.Errorf("parsing maintenance window %q: expected HH:MM-HH:MM", s)
+	}
+	for _, v := range [][2]int{{sh, sm}, {eh, em}} {
+		if v[0] < 0 || v[0] > 23 || v[1] < 0 || v[1] > 59 {
+			return Window{}, xerrors.Errorf("parsing maintenance window %q: invalid time of day", s)
+		}
+	}
+
+	w := Window{
+		Start: time.Duration(sh)*time.Hour + time.Duration(sm)*time.Minute,
+		End:   time.Duration(eh)*time.Hour + time.Duration(em)*time.Minute,
+	}
+	if w.Start == w.End {
+		return Window{}, xerrors.Errorf("parsing maintenance window %q: empty window", s)
+	}
+	return w, nil
+}
+
+// active returns whether the window is open at t, and when it closes.
+func (w Window) active(t time.Time) (bool, time.Time) {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	offset := t.Sub(midnight)
+
+	switch {
+	case w.Start < w.End && offset >= w.Start && offset < w.End:
+		return true, midnight.Add(w.End)
+	case w.Start > w.End && offset >= w.Start:
+		return true, midnight.AddDate(0, 0, 1).Add(w.End)
+	case w.Start > w.End && offset < w.End:
+		return true, midnight.Add(w.End)
+	}
+	return false, time.Time{}
+}
+
+// next returns the first time the window opens after t.
+func (w Window) next(t time.Time) time.Time {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	start := midnight.Add(w.Start)
+	if !start.After(t) {
+		start = midnight.AddDate(0, 0, 1).Add(w.Start)
+	}
+	return start
+}
+
+// Config configures the scheduled maintenance runs.
+type Config struct {
+	// Windows are the windows in which scheduled runs start; scheduled runs
+	// are disabled when empty.
+	Windows []Window
+	Mode    blockstore.MaintenanceMode
+	// Threshold is the value log GC threshold, 0 for the blockstore default.
+	Threshold float64
+	// Interval is the minimum time between the start of two scheduled runs.
+	Interval time.Duration
+}
+
+// Scheduler runs maintenance on a blockstore, on demand and in the
+// configured windows, one run at a time.
+type Scheduler struct {
+	// ctx bounds the runs started on demand
+	ctx context.Context
+	bs  blockstore.BlockstoreMaintain
+	cfg Config
+
+	lk            sync.Mutex
+	last          *api.BlockstoreMaintenanceRun
+	lastScheduled time.Time
+	wg            sync.WaitGroup
+}
+
+// NewScheduler creates a scheduler for bs; the runs are stopped when ctx is
+// done.
+func NewScheduler(ctx context.Context, bs blockstore.BlockstoreMaintain, cfg Config) *Scheduler {
+	return &Scheduler{
+		ctx: ctx,
+		bs:  bs,
+		cfg: cfg,
+	}
+}
+
+// Run starts the scheduled runs in the configured windows until the context of
+// the scheduler is done, then waits for the ongoing run to stop.
+func (s *Scheduler) Run() {
+	ctx := s.ctx
+	defer s.wg.Wait()
+
+	if len(s.cfg.Windows) == 0 {
+		<-ctx.Done()
+		return
+	}
+
+	ticker := build.Clock.Ticker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		s.checkWindows(ctx, build.Clock.Now())
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *Scheduler) checkWindows(ctx context.Context, now time.Time) {
+	s.lk.Lock()
+	due := s.lastScheduled.IsZero() || now.Sub(s.lastScheduled) >= s.cfg.Interval
+	s.lk.Unlock()
+	if !due {
+		return
+	}
+
+	for _, w := range s.cfg.Windows {
+		active, end := w.active(now)
+		if !active {
+			continue
+		}
+
+		// value log GC stops when the window closes
+		wctx, cancel := context.WithDeadline(ctx, end)
+		if _, err := s.start(wctx, cancel, s.cfg.Mode, s.cfg.Threshold, api.BlockstoreMaintenanceScheduled); err != nil {
+			cancel()
+			log.Debugw("not starting scheduled blockstore maintenance", "error", err)
+			return
+		}
+
+		s.lk.Lock()
+		s.lastScheduled = now
+		s.lk.Unlock()
+		return
+	}
+}
+
+// Maintain starts a run in the background, unless another run is ongoing.
+func (s *Scheduler) Maintain(mode blockstore.MaintenanceMode, threshold float64) (*api.BlockstoreMaintenanceRun, error) {
+	ctx, cancel := context.WithCancel(s.ctx)
+	run, err := s.start(ctx, cancel, mode, threshold, api.BlockstoreMaintenanceManual)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return run, nil
+}
+
+func (s *Scheduler) start(ctx context.Context, cancel context.CancelFunc, mode blockstore.MaintenanceMode, threshold float64, trigger string) (*api.BlockstoreMaintenanceRun, error) {
+	if mode != blockstore.MaintainValueLogGC && mode != blockstore.MaintainCompaction {
+		return nil, xerrors.Errorf("unknown maintenance mode %q", mode)
+	}
+
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	if s.last != nil && s.last.State == api.BlockstoreMaintenanceRunning {
+		return nil, xerrors.Errorf("blockstore maintenance already running since %s", s.last.Started)
+	}
+
+	s.last = &api.BlockstoreMaintenanceRun{
+		Mode:       string(mode),
+		Trigger:    trigger,
+		State:      api.BlockstoreMaintenanceRunning,
+		SizeBefore: s.size(),
+		Started:    build.Clock.Now(),
+	}
+	run := *s.last
+
+	log.Infow("starting blockstore maintenance", "mode", mode, "trigger", trigger)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer cancel()
+
+		err := s.bs.Maintain(ctx, mode, threshold, func(p blockstore.MaintenanceProgress) {
+			s.lk.Lock()
+			s.last.Phase = p.Phase
+			s.last.ValueLogsRewritten = p.ValueLogsRewritten
+			s.lk.Unlock()
+		})
+
+		size := s.size()
+
+		s.lk.Lock()
+		defer s.lk.Unlock()
+
+		s.last.Phase = ""
+		s.last.SizeAfter = size
+		s.last.Finished = build.Clock.Now()
+		if err != nil && !(trigger == api.BlockstoreMaintenanceScheduled && xerrors.Is(err, context.DeadlineExceeded)) {
+			s.last.State = api.BlockstoreMaintenanceFailed
+			s.last.Error = err.Error()
+			log.Errorw("blockstore maintenance failed", "mode", mode, "error", err)
+			return
+		}
+
+		s.last.State = api.BlockstoreMaintenanceCompleted
+		log.Infow("blockstore maintenance completed", "mode", mode, "rewritten", s.last.ValueLogsRewritten,
+			"before", s.last.SizeBefore, "after", s.last.SizeAfter, "took", s.last.Finished.Sub(s.last.Started))
+	}()
+
+	return &run, nil
+}
+
+func (s *Scheduler) size() int64 {
+	sz, ok := s.bs.(blockstore.BlockstoreSize)
+	if !ok {
+		return 0
+	}
+	size, err := sz.Size()
+	if err != nil {
+		log.Warnw("getting blockstore size", "error", err)
+		return 0
+	}
+	return size
+}
+
+// Status returns the state of the last run and the earliest start of the next
+// scheduled run.
+func (s *Scheduler) Status() *api.BlockstoreMaintenanceStatus {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	var st api.BlockstoreMaintenanceStatus
+	if s.last != nil {
+		last := *s.last
+		st.Last = &last
+	}
+
+	if len(s.cfg.Windows) > 0 {
+		from := build.Clock.Now()
+		if !s.lastScheduled.IsZero() && s.lastScheduled.Add(s.cfg.Interval).After(from) {
+			from = s.lastScheduled.Add(s.cfg.Interval)
+		}
+
+		var next time.Time
+		for _, w := range s.cfg.Windows {
+			t := from
+			if active, _ := w.active(from); !active {
+				t = w.next(from)
+			}
+			if next.IsZero() || t.Before(next) {
+				next = t
+			}
+		}
+		st.NextScheduled = &next
+	}
+
+	return &st
+}
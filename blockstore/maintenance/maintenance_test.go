@@ -0,0 +1,162 @@
+// stm: #unit
+package maintenance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+)
+
+type fakeBlockstore struct {
+	proceed chan struct{}
+	size    int64
+}
+
+func (f *fakeBlockstore) Maintain(ctx context.Context, mode blockstore.MaintenanceMode, threshold float64, progress func(blockstore.MaintenanceProgress)) error {
+	progress(blockstore.MaintenanceProgress{Phase: blockstore.MaintenancePhaseValueLogGC})
+	for i := 1; ; i++ {
+		select {
+		case _, ok := <-f.proceed:
+			if !ok {
+				return nil
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		f.size -= 10
+		progress(blockstore.MaintenanceProgress{Phase: blockstore.MaintenancePhaseValueLogGC, ValueLogsRewritten: i})
+	}
+}
+
+func (f *fakeBlockstore) Size() (int64, error) {
+	return f.size, nil
+}
+
+func TestParseWindow(t *testing.T) {
+	w, err := ParseWindow("02:30-05:00")
+	require.NoError(t, err)
+	require.Equal(t, Window{Start: 2*time.Hour + 30*time.Minute, End: 5 * time.Hour}, w)
+
+	for _, s := range []string{"", "2-5", "02:00-24:00", "02:60-03:00", "03:00-03:00"} {
+		_, err := ParseWindow(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestWindowActive(t *testing.T) {
+	day := time.Date(2023, 5, 10, 0, 0, 0, 0, time.UTC)
+	at := func(h, m int) time.Time {
+		return day.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute)
+	}
+
+	w, err := ParseWindow("02:00-05:00")
+	require.NoError(t, err)
+
+	active, end := w.active(at(3, 0))
+	require.True(t, active)
+	require.Equal(t, at(5, 0), end)
+	active, _ = w.active(at(5, 0))
+	require.False(t, active)
+	require.Equal(t, at(26, 0), w.next(at(5, 0)))
+	require.Equal(t, at(2, 0), w.next(at(1, 0)))
+
+	// wrapping around midnight
+	w, err = ParseWindow("23:00-01:00")
+	require.NoError(t, err)
+
+	active, end = w.active(at(23, 30))
+	require.True(t, active)
+	require.Equal(t, at(25, 0), end)
+	active, end = w.active(at(0, 30))
+	require.True(t, active)
+	require.Equal(t, at(1, 0), end)
+	active, _ = w.active(at(12, 0))
+	require.False(t, active)
+}
+
+func TestMaintain(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fb := &fakeBlockstore{proceed: make(chan struct{}), size: 100}
+	s := NewScheduler(ctx, fb, Config{})
+
+	st := s.Status()
+	require.Nil(t, st.Last)
+	require.Nil(t, st.NextScheduled)
+
+	_, err := s.Maintain("unknown", 0)
+	require.Error(t, err)
+
+	run, err := s.Maintain(blockstore.MaintainValueLogGC, 0)
+	require.NoError(t, err)
+	require.Equal(t, api.BlockstoreMaintenanceRunning, run.State)
+	require.Equal(t, api.BlockstoreMaintenanceManual, run.Trigger)
+	require.Equal(t, int64(100), run.SizeBefore)
+
+	// a single run at a time
+	_, err = s.Maintain(blockstore.MaintainCompaction, 0)
+	require.Error(t, err)
+
+	fb.proceed <- struct{}{}
+	fb.proceed <- struct{}{}
+	require.Eventually(t, func() bool {
+		return s.Status().Last.ValueLogsRewritten == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, blockstore.MaintenancePhaseValueLogGC, s.Status().Last.Phase)
+
+	close(fb.proceed)
+	require.Eventually(t, func() bool {
+		return s.Status().Last.State == api.BlockstoreMaintenanceCompleted
+	}, 5*time.Second, 10*time.Millisecond)
+	last := s.Status().Last
+	require.Equal(t, int64(80), last.SizeAfter)
+	require.Empty(t, last.Phase)
+	require.False(t, last.Finished.IsZero())
+}
+
+func TestScheduledMaintenance(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w, err := ParseWindow("02:00-05:00")
+	require.NoError(t, err)
+
+	fb := &fakeBlockstore{proceed: make(chan struct{})}
+	s := NewScheduler(ctx, fb, Config{
+		Windows:  []Window{w},
+		Mode:     blockstore.MaintainValueLogGC,
+		Interval: 12 * time.Hour,
+	})
+
+	day := time.Date(2023, 5, 10, 0, 0, 0, 0, time.Local)
+
+	s.checkWindows(ctx, day.Add(time.Hour))
+	require.Nil(t, s.Status().Last)
+
+	s.checkWindows(ctx, day.Add(3*time.Hour))
+	last := s.Status().Last
+	require.NotNil(t, last)
+	require.Equal(t, api.BlockstoreMaintenanceScheduled, last.Trigger)
+
+	// the window closed long ago, which stops the run without failing it
+	require.Eventually(t, func() bool {
+		return s.Status().Last.State == api.BlockstoreMaintenanceCompleted
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// a single scheduled run per interval
+	s.checkWindows(ctx, day.Add(4*time.Hour))
+	require.Equal(t, last.Started, s.Status().Last.Started)
+
+	next := s.Status().NextScheduled
+	require.NotNil(t, next)
+	require.True(t, next.After(time.Now()))
+
+	s.checkWindows(ctx, day.Add(27*time.Hour))
+	require.NotEqual(t, last.Started, s.Status().Last.Started)
+}
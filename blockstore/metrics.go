@@ -9,9 +9,10 @@ import (
 )
 
 //
-// Currently unused, but kept in repo in case we introduce one of the candidate
-// cache implementations (Freecache, Ristretto), both of which report these
-// metrics.
+// These metrics are reported by the CacheTuner for the caches registered with
+// it, tagged with the cache name. Not all of them apply to these caches, the
+// others are kept in case we introduce one of the candidate cache
+// implementations (Freecache, Ristretto), both of which report them.
 //
 
 // CacheMetricsEmitInterval is the interval at which metrics are emitted onto
@@ -0,0 +1,60 @@
+package blockstore
+
+import (
+	"context"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+)
+
+type overlayBlockstore struct {
+	Blockstore
+
+	overlay Blockstore
+}
+
+// NewOverlay returns a blockstore reading the blocks missing from the base
+// blockstore from the read-only overlay.
+//
+//   - Reads return from the base blockstore, falling back to the overlay when
+//     the block isn't found.
+//   - Writes (puts and deletes) and iteration only apply to the base blockstore.
+func NewOverlay(base, overlay Blockstore) Blockstore {
+	return &overlayBlockstore{
+		Blockstore: base,
+		overlay:    overlay,
+	}
+}
+
+func (o *overlayBlockstore) Has(ctx context.Context, c cid.Cid) (bool, error) {
+	has, err := o.Blockstore.Has(ctx, c)
+	if has || err != nil {
+		return has, err
+	}
+	return o.overlay.Has(ctx, c)
+}
+
+func (o *overlayBlockstore) Get(ctx context.Context, c cid.Cid) (blocks.Block, error) {
+	blk, err := o.Blockstore.Get(ctx, c)
+	if ipld.IsNotFound(err) {
+		return o.overlay.Get(ctx, c)
+	}
+	return blk, err
+}
+
+func (o *overlayBlockstore) View(ctx context.Context, c cid.Cid, callback func([]byte) error) error {
+	err := o.Blockstore.View(ctx, c, callback)
+	if ipld.IsNotFound(err) {
+		return o.overlay.View(ctx, c, callback)
+	}
+	return err
+}
+
+func (o *overlayBlockstore) GetSize(ctx context.Context, c cid.Cid) (int, error) {
+	size, err := o.Blockstore.GetSize(ctx, c)
+	if ipld.IsNotFound(err) {
+		return o.overlay.GetSize(ctx, c)
+	}
+	return size, err
+}
@@ -0,0 +1,62 @@
+// stm: #unit
+package blockstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverlayBlockstore(t *testing.T) {
+	ctx := context.Background()
+	base := NewMemory()
+	overlay := NewMemory()
+
+	_ = base.Put(ctx, b0)
+	_ = overlay.Put(ctx, b1)
+
+	o := NewOverlay(base, overlay)
+
+	// reads fall back to the overlay
+	v0, err := o.Get(ctx, b0.Cid())
+	require.NoError(t, err)
+	require.Equal(t, b0.RawData(), v0.RawData())
+
+	v1, err := o.Get(ctx, b1.Cid())
+	require.NoError(t, err)
+	require.Equal(t, b1.RawData(), v1.RawData())
+
+	has, err := o.Has(ctx, b1.Cid())
+	require.NoError(t, err)
+	require.True(t, has)
+
+	size, err := o.GetSize(ctx, b1.Cid())
+	require.NoError(t, err)
+	require.Equal(t, len(b1.RawData()), size)
+
+	require.NoError(t, o.View(ctx, b1.Cid(), func(data []byte) error {
+		require.Equal(t, b1.RawData(), data)
+		return nil
+	}))
+
+	has, err = o.Has(ctx, b2.Cid())
+	require.NoError(t, err)
+	require.False(t, has)
+	_, err = o.Get(ctx, b2.Cid())
+	require.Error(t, err)
+
+	// writes only go to the base blockstore
+	require.NoError(t, o.Put(ctx, b2))
+	has, err = base.Has(ctx, b2.Cid())
+	require.NoError(t, err)
+	require.True(t, has)
+	has, err = overlay.Has(ctx, b2.Cid())
+	require.NoError(t, err)
+	require.False(t, has)
+
+	require.NoError(t, o.DeleteBlock(ctx, b1.Cid()))
+	has, err = o.Has(ctx, b1.Cid())
+	require.NoError(t, err)
+	require.True(t, has)
+}
@@ -0,0 +1,325 @@
+package scrub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	"github.com/ipfs/go-datastore/query"
+	ipld "github.com/ipfs/go-ipld-format"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/multiformats/go-multihash"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"golang.org/x/time/rate"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/metrics"
+)
+
+var log = logging.Logger("bsscrub")
+
+var (
+	// startDelay delays the first pass, leaving the blockstore to the node
+	// startup.
+	startDelay = 30 * time.Minute
+
+	// fetchTimeout bounds the time spent refetching a quarantined block.
+	fetchTimeout = time.Minute
+)
+
+// Fetcher fetches blocks from the network, e.g. the chain bitswap exchange.
+type Fetcher interface {
+	GetBlock(ctx context.Context, c cid.Cid) (blocks.Block, error)
+}
+
+// Blockstore is a blockstore whose keys can be iterated.
+type Blockstore interface {
+	blockstore.Blockstore
+	blockstore.BlockstoreIterator
+}
+
+// Config configures the scrubber.
+type Config struct {
+	// Interval is the time between the end of a pass and the start of the
+	// next one.
+	Interval time.Duration
+	// Rate is the maximum number of blocks checked per second.
+	Rate int
+}
+
+// Scrubber checks in the background that the blocks of a blockstore can be
+// read and match their cid. It quarantines the corrupt blocks by removing
+// them from the blockstore and recording an incident, and refetches them from
+// the network when it has a fetcher.
+type Scrubber struct {
+	bs        Blockstore
+	fetcher   Fetcher
+	incidents ds.Datastore
+	cfg       Config
+	limiter   *rate.Limiter
+
+	lk     sync.Mutex
+	status api.BlockstoreScrubStatus
+}
+
+// NewScrubber creates a scrubber for bs recording its incidents in dstore.
+// Quarantined blocks aren't refetched when fetcher is nil.
+func NewScrubber(bs Blockstore, fetcher Fetcher, dstore ds.Batching, cfg Config) *Scrubber {
+	limit := rate.Inf
+	if cfg.Rate > 0 {
+		limit = rate.Limit(cfg.Rate)
+	}
+
+	return &Scrubber{
+		bs:        bs,
+		fetcher:   fetcher,
+		incidents: namespace.Wrap(dstore, ds.NewKey("/blockstore/scrub/incidents")),
+		cfg:       cfg,
+		limiter:   rate.NewLimiter(limit, 1),
+	}
+}
+
+// Run scrubs the blockstore every interval until ctx is done.
+func (s *Scrubber) Run(ctx context.Context) {
+	wait := startDelay
+	for {
+		select {
+		case <-build.Clock.After(wait):
+		case <-ctx.Done():
+			return
+		}
+		wait = s.cfg.Interval
+
+		if err := s.Pass(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Errorw("blockstore scrub pass failed", "error", err)
+		}
+	}
+}
+
+type corruptBlock struct {
+	c    cid.Cid
+	kind string
+	err  string
+}
+
+// Pass retries the refetch of the quarantined blocks, then checks all the
+// blocks of the blockstore and quarantines the corrupt ones.
+func (s *Scrubber) Pass(ctx context.Context) error {
+	s.lk.Lock()
+	s.status.Running = true
+	s.status.PassStarted = build.Clock.Now()
+	s.status.Checked = 0
+	s.lk.Unlock()
+
+	defer func() {
+		s.lk.Lock()
+		s.status.Running = false
+		s.lk.Unlock()
+	}()
+
+	log.Infow("starting blockstore scrub pass")
+
+	if err := s.retryRepairs(ctx); err != nil {
+		return err
+	}
+
+	// the corrupt blocks are quarantined after the iteration, which doesn't
+	// support deleting keys underneath it
+	var corrupt []corruptBlock
+	err := s.bs.ForEachKey(func(c cid.Cid) error {
+		if err := s.limiter.Wait(ctx); err != nil {
+			return err
+		}
+
+		if cb := s.check(ctx, c); cb != nil {
+			log.Warnw("corrupt block found", "cid", cb.c, "kind", cb.kind, "error", cb.err)
+			corrupt = append(corrupt, *cb)
+		}
+
+		s.lk.Lock()
+		s.status.Checked++
+		s.lk.Unlock()
+		stats.Record(ctx, metrics.BlockstoreScrubChecked.M(1))
+		return nil
+	})
+	if err != nil {
+		return xerrors.Errorf("iterating blockstore: %w", err)
+	}
+
+	for _, cb := range corrupt {
+		if err := s.quarantine(ctx, cb); err != nil {
+			return err
+		}
+	}
+
+	s.lk.Lock()
+	s.status.PassFinished = build.Clock.Now()
+	checked := s.status.Checked
+	s.lk.Unlock()
+
+	log.Infow("blockstore scrub pass done", "checked", checked, "corrupt", len(corrupt))
+	return nil
+}
+
+func (s *Scrubber) check(ctx context.Context, c cid.Cid) *corruptBlock {
+	pref := c.Prefix()
+	if pref.MhType == multihash.IDENTITY {
+		return nil
+	}
+
+	var mismatch bool
+	err := s.bs.View(ctx, c, func(data []byte) error {
+		sum, err := pref.Sum(data)
+		if err != nil {
+			return err
+		}
+		mismatch = !bytes.Equal(sum.Hash(), c.Hash())
+		return nil
+	})
+	switch {
+	case ipld.IsNotFound(err):
+		// deleted since the iteration found it
+		return nil
+	case err != nil:
+		return &corruptBlock{c: c, kind: api.BlockstoreCorruptUnreadable, err: err.Error()}
+	case mismatch:
+		return &corruptBlock{c: c, kind: api.BlockstoreCorruptMismatch, err: "block data doesn't match its hash"}
+	}
+	return nil
+}
+
+// quarantine records the incident and removes the block from the blockstore,
+// so that it isn't served anymore, then attempts to refetch it.
+func (s *Scrubber) quarantine(ctx context.Context, cb corruptBlock) error {
+	inc := api.BlockstoreIncident{
+		Cid:      cb.c,
+		Kind:     cb.kind,
+		Error:    cb.err,
+		Detected: build.Clock.Now(),
+	}
+	if err := s.put(ctx, inc); err != nil {
+		return err
+	}
+
+	_ = stats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(metrics.CorruptionType, cb.kind)}, metrics.BlockstoreScrubCorrupt.M(1))
+
+	if err := s.bs.DeleteBlock(ctx, cb.c); err != nil {
+		return xerrors.Errorf("removing corrupt block %s: %w", cb.c, err)
+	}
+
+	return s.repair(ctx, inc)
+}
+
+func (s *Scrubber) retryRepairs(ctx context.Context) error {
+	incs, err := s.Incidents(ctx)
+	if err != nil {
+		return err
+	}
+	for _, inc := range incs {
+		if inc.Repaired {
+			continue
+		}
+		if err := s.repair(ctx, inc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// repair refetches the block of the incident from the network; failures are
+// recorded in the incident and retried on the next pass.
+func (s *Scrubber) repair(ctx context.Context, inc api.BlockstoreIncident) error {
+	if s.fetcher == nil {
+		return nil
+	}
+
+	inc.RepairAttempts++
+
+	fctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	blk, err := s.fetcher.GetBlock(fctx, inc.Cid)
+	cancel()
+	if err == nil {
+		err = s.bs.Put(ctx, blk)
+	}
+
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Warnw("refetching quarantined block failed", "cid", inc.Cid, "attempts", inc.RepairAttempts, "error", err)
+		inc.RepairError = err.Error()
+	} else {
+		log.Infow("refetched quarantined block", "cid", inc.Cid)
+		inc.Repaired = true
+		inc.RepairError = ""
+		stats.Record(ctx, metrics.BlockstoreScrubRepaired.M(1))
+	}
+
+	return s.put(ctx, inc)
+}
+
+func (s *Scrubber) put(ctx context.Context, inc api.BlockstoreIncident) error {
+	b, err := json.Marshal(inc)
+	if err != nil {
+		return xerrors.Errorf("encoding incident: %w", err)
+	}
+	if err := s.incidents.Put(ctx, ds.NewKey(inc.Cid.String()), b); err != nil {
+		return xerrors.Errorf("storing incident: %w", err)
+	}
+	return nil
+}
+
+// Incidents returns the recorded incidents, ordered by detection time.
+func (s *Scrubber) Incidents(ctx context.Context) ([]api.BlockstoreIncident, error) {
+	res, err := s.incidents.Query(ctx, query.Query{})
+	if err != nil {
+		return nil, xerrors.Errorf("querying incidents: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	out := []api.BlockstoreIncident{}
+	for e := range res.Next() {
+		if e.Error != nil {
+			return nil, xerrors.Errorf("iterating incidents: %w", e.Error)
+		}
+		var inc api.BlockstoreIncident
+		if err := json.Unmarshal(e.Value, &inc); err != nil {
+			return nil, xerrors.Errorf("decoding incident %s: %w", e.Key, err)
+		}
+		out = append(out, inc)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Detected.Before(out[j].Detected)
+	})
+	return out, nil
+}
+
+// Status returns the progress of the scrubber and the recorded incidents.
+func (s *Scrubber) Status(ctx context.Context) (*api.BlockstoreScrubStatus, error) {
+	incs, err := s.Incidents(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.lk.Lock()
+	st := s.status
+	s.lk.Unlock()
+
+	st.Incidents = incs
+	return &st, nil
+}
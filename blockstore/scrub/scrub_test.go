@@ -0,0 +1,119 @@
+// stm: #unit
+package scrub
+
+import (
+	"context"
+	"testing"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+)
+
+type testBlockstore struct {
+	blockstore.MemBlockstore
+	unreadable map[cid.Cid]bool
+}
+
+func (tb *testBlockstore) View(ctx context.Context, c cid.Cid, cb func([]byte) error) error {
+	if tb.unreadable[c] {
+		return xerrors.Errorf("checksum mismatch in table")
+	}
+	return tb.MemBlockstore.View(ctx, c, cb)
+}
+
+func (tb *testBlockstore) ForEachKey(f func(cid.Cid) error) error {
+	for _, b := range tb.MemBlockstore {
+		if err := f(b.Cid()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type testFetcher struct {
+	blocks map[cid.Cid]blocks.Block
+}
+
+func (tf *testFetcher) GetBlock(_ context.Context, c cid.Cid) (blocks.Block, error) {
+	b, ok := tf.blocks[c]
+	if !ok {
+		return nil, xerrors.Errorf("block not found")
+	}
+	return b, nil
+}
+
+func TestScrub(t *testing.T) {
+	ctx := context.Background()
+
+	good := blocks.NewBlock([]byte("good"))
+	mismatched := blocks.NewBlock([]byte("mismatched"))
+	unreadable := blocks.NewBlock([]byte("unreadable"))
+
+	tb := &testBlockstore{
+		MemBlockstore: blockstore.NewMemory(),
+		unreadable:    map[cid.Cid]bool{unreadable.Cid(): true},
+	}
+	require.NoError(t, tb.Put(ctx, good))
+	require.NoError(t, tb.Put(ctx, unreadable))
+	corrupted, err := blocks.NewBlockWithCid([]byte("bitrot"), mismatched.Cid())
+	require.NoError(t, err)
+	require.NoError(t, tb.Put(ctx, corrupted))
+
+	// only the mismatched block can be fetched again
+	tf := &testFetcher{blocks: map[cid.Cid]blocks.Block{mismatched.Cid(): mismatched}}
+	s := NewScrubber(tb, tf, ds.NewMapDatastore(), Config{})
+
+	require.NoError(t, s.Pass(ctx))
+
+	st, err := s.Status(ctx)
+	require.NoError(t, err)
+	require.False(t, st.Running)
+	require.Equal(t, int64(3), st.Checked)
+	require.False(t, st.PassFinished.IsZero())
+	require.Len(t, st.Incidents, 2)
+
+	incs := map[cid.Cid]api.BlockstoreIncident{}
+	for _, inc := range st.Incidents {
+		incs[inc.Cid] = inc
+	}
+
+	inc := incs[mismatched.Cid()]
+	require.Equal(t, api.BlockstoreCorruptMismatch, inc.Kind)
+	require.True(t, inc.Repaired)
+	require.Equal(t, 1, inc.RepairAttempts)
+
+	inc = incs[unreadable.Cid()]
+	require.Equal(t, api.BlockstoreCorruptUnreadable, inc.Kind)
+	require.False(t, inc.Repaired)
+	require.NotEmpty(t, inc.RepairError)
+
+	// the refetched block replaces the corrupt one, the unreadable one is
+	// quarantined
+	data, err := tb.Get(ctx, mismatched.Cid())
+	require.NoError(t, err)
+	require.Equal(t, mismatched.RawData(), data.RawData())
+	has, err := tb.Has(ctx, unreadable.Cid())
+	require.NoError(t, err)
+	require.False(t, has)
+
+	// the refetch of the quarantined block is retried on the next pass
+	tf.blocks[unreadable.Cid()] = unreadable
+	delete(tb.unreadable, unreadable.Cid())
+	require.NoError(t, s.Pass(ctx))
+
+	st, err = s.Status(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), st.Checked)
+	for _, inc := range st.Incidents {
+		require.True(t, inc.Repaired, inc.Cid)
+		incs[inc.Cid] = inc
+	}
+	require.Equal(t, 1, incs[mismatched.Cid()].RepairAttempts)
+	require.Equal(t, 2, incs[unreadable.Cid()].RepairAttempts)
+}
@@ -379,7 +379,8 @@ func (s *state4) Info() (MinerInfo, error) {
 		Worker:           info.Worker,
 		ControlAddresses: info.ControlAddresses,
 
-		PendingWorkerKey: (*WorkerKeyChange)(info.PendingWorkerKey),
+		PendingWorkerKey:    (*WorkerKeyChange)(info.PendingWorkerKey),
+		PendingOwnerAddress: info.PendingOwnerAddress,
 
 		PeerId:                     info.PeerId,
 		Multiaddrs:                 info.Multiaddrs,
@@ -0,0 +1,500 @@
+// Package addrindex maintains an index of the messages included on chain by
+// sender and recipient address, to list the message history of an address
+// without walking the chain, and of the delegated (f4) addresses of the actors
+// seen in the messages, to resolve them without loading the state.
+package addrindex
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log/v2"
+	_ "github.com/mattn/go-sqlite3"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/events"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var log = logging.Logger("addrindex")
+
+const DBName = "addrindex.db"
+
+// DefaultLimit is the number of messages listed when the query has no limit.
+const DefaultLimit = 100
+
+// MaxLimit is the maximum number of messages listed at once.
+const MaxLimit = 10000
+
+const noDelegatedCacheSize = 1 << 16
+
+var dbDefs = []string{
+	`CREATE TABLE IF NOT EXISTS address_messages (
+		address TEXT NOT NULL,
+		height INTEGER NOT NULL,
+		msg_index INTEGER NOT NULL,
+		msg_cid TEXT NOT NULL,
+		from_addr TEXT NOT NULL,
+		to_addr TEXT NOT NULL,
+		tipset_key BLOB NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS address_messages_address_height ON address_messages (address, height, msg_index)`,
+	`CREATE INDEX IF NOT EXISTS address_messages_tipset_key ON address_messages (tipset_key)`,
+	`CREATE TABLE IF NOT EXISTS indexed_tipsets (
+		tipset_key BLOB PRIMARY KEY ON CONFLICT REPLACE,
+		height INTEGER NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS indexed_tipsets_height ON indexed_tipsets (height)`,
+	`CREATE TABLE IF NOT EXISTS delegated_addresses (
+		id_addr TEXT PRIMARY KEY ON CONFLICT REPLACE,
+		delegated_addr TEXT NOT NULL,
+		tipset_key BLOB NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS delegated_addresses_delegated ON delegated_addresses (delegated_addr)`,
+	`CREATE INDEX IF NOT EXISTS delegated_addresses_tipset_key ON delegated_addresses (tipset_key)`,
+	`CREATE TABLE IF NOT EXISTS _meta (
+		version UINT64 NOT NULL UNIQUE
+	)`,
+	`INSERT OR IGNORE INTO _meta (version) VALUES (1)`,
+}
+
+const (
+	dbqInsertMessage  = "INSERT INTO address_messages (address, height, msg_index, msg_cid, from_addr, to_addr, tipset_key) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	dbqInsertTipSet   = "INSERT INTO indexed_tipsets (tipset_key, height) VALUES (?, ?)"
+	dbqDeleteMessages = "DELETE FROM address_messages WHERE tipset_key = ?"
+	dbqDeleteTipSet   = "DELETE FROM indexed_tipsets WHERE tipset_key = ?"
+	dbqHasTipSet      = "SELECT COUNT(*) FROM indexed_tipsets WHERE tipset_key = ?"
+	dbqMinHeight      = "SELECT MIN(height) FROM indexed_tipsets"
+	dbqSelectMessages = "SELECT DISTINCT height, msg_index, msg_cid, from_addr, to_addr, tipset_key FROM address_messages"
+
+	dbqInsertDelegated = "INSERT INTO delegated_addresses (id_addr, delegated_addr, tipset_key) VALUES (?, ?, ?)"
+	dbqDeleteDelegated = "DELETE FROM delegated_addresses WHERE tipset_key = ?"
+	dbqSelectDelegated = "SELECT id_addr, delegated_addr FROM delegated_addresses WHERE id_addr = ? OR delegated_addr = ? LIMIT 1"
+)
+
+// ChainAPI is the subset of the full node API used to index messages.
+type ChainAPI interface {
+	ChainGetTipSet(context.Context, types.TipSetKey) (*types.TipSet, error)
+	ChainGetMessagesInTipset(context.Context, types.TipSetKey) ([]api.Message, error)
+	StateGetActor(context.Context, address.Address, types.TipSetKey) (*types.Actor, error)
+	StateLookupID(context.Context, address.Address, types.TipSetKey) (address.Address, error)
+}
+
+// Index is a tipset observer, see events.Events.Observe, indexing the messages
+// included in the applied tipsets by the addresses of their sender and
+// recipient, as they appear in the messages. Tipsets applied while the node
+// wasn't running can be indexed with Backfill.
+type Index struct {
+	api ChainAPI
+	db  *sql.DB
+
+	// ID addresses of the actors without a delegated address, which are only
+	// assigned at the creation of the actors
+	noDelegated *lru.Cache[address.Address, struct{}]
+
+	lk sync.Mutex
+}
+
+var _ events.TipSetObserver = (*Index)(nil)
+
+func NewIndex(path string, a ChainAPI) (*Index, error) {
+	db, err := sql.Open("sqlite3", path+"?mode=rwc")
+	if err != nil {
+		return nil, xerrors.Errorf("opening address index database: %w", err)
+	}
+
+	for _, stmt := range dbDefs {
+		if _, err := db.Exec(stmt); err != nil {
+			_ = db.Close()
+			return nil, xerrors.Errorf("executing sql statement '%s': %w", stmt, err)
+		}
+	}
+
+	noDelegated, _ := lru.New[address.Address, struct{}](noDelegatedCacheSize)
+
+	return &Index{
+		api:         a,
+		db:          db,
+		noDelegated: noDelegated,
+	}, nil
+}
+
+func (x *Index) Close() error {
+	return x.db.Close()
+}
+
+func (x *Index) Apply(ctx context.Context, from, to *types.TipSet) error {
+	x.lk.Lock()
+	defer x.lk.Unlock()
+
+	if err := x.index(ctx, to); err != nil {
+		return xerrors.Errorf("indexing messages of %s: %w", to.Key(), err)
+	}
+	return nil
+}
+
+func (x *Index) Revert(ctx context.Context, from, to *types.TipSet) error {
+	x.lk.Lock()
+	defer x.lk.Unlock()
+
+	tx, err := x.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := deleteTipSet(ctx, tx, from.Key().Bytes()); err != nil {
+		_ = tx.Rollback()
+		return xerrors.Errorf("reverting messages of %s: %w", from.Key(), err)
+	}
+	return tx.Commit()
+}
+
+func deleteTipSet(ctx context.Context, tx *sql.Tx, tsk []byte) error {
+	if _, err := tx.ExecContext(ctx, dbqDeleteMessages, tsk); err != nil {
+		return err
+	}
+	// the actors created in reverted tipsets may get other IDs
+	if _, err := tx.ExecContext(ctx, dbqDeleteDelegated, tsk); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, dbqDeleteTipSet, tsk)
+	return err
+}
+
+// index records the messages included in ts, replacing any previous record of
+// the tipset.
+func (x *Index) index(ctx context.Context, ts *types.TipSet) error {
+	msgs, err := x.api.ChainGetMessagesInTipset(ctx, ts.Key())
+	if err != nil {
+		return xerrors.Errorf("loading messages: %w", err)
+	}
+
+	tsk := ts.Key().Bytes()
+	height := int64(ts.Height())
+
+	tx, err := x.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := deleteTipSet(ctx, tx, tsk); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	seen := map[address.Address]struct{}{}
+	for i, m := range msgs {
+		from, to := m.Message.From.String(), m.Message.To.String()
+		addrs := []string{from}
+		if to != from {
+			addrs = append(addrs, to)
+		}
+		for _, a := range addrs {
+			if _, err := tx.ExecContext(ctx, dbqInsertMessage, a, height, i, m.Cid.String(), from, to, tsk); err != nil {
+				_ = tx.Rollback()
+				return err
+			}
+		}
+		seen[m.Message.From] = struct{}{}
+		seen[m.Message.To] = struct{}{}
+	}
+	for a := range seen {
+		if err := x.indexDelegated(ctx, tx, a, ts.Key()); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, dbqInsertTipSet, tsk, height); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// indexDelegated records the delegated address of the actor of a, an ID or a
+// delegated address, when it has one and isn't indexed yet. The actors which
+// don't exist yet in the parent state of ts are indexed when they next appear.
+func (x *Index) indexDelegated(ctx context.Context, tx *sql.Tx, a address.Address, tsk types.TipSetKey) error {
+	if a.Protocol() != address.ID && a.Protocol() != address.Delegated {
+		return nil
+	}
+	if x.noDelegated.Contains(a) {
+		return nil
+	}
+
+	var id, delegated string
+	err := tx.QueryRowContext(ctx, dbqSelectDelegated, a.String(), a.String()).Scan(&id, &delegated)
+	if err == nil {
+		return nil
+	}
+	if err != sql.ErrNoRows {
+		return xerrors.Errorf("looking up %s: %w", a, err)
+	}
+
+	idAddr, delegatedAddr, ok := x.lookupState(ctx, a, tsk)
+	if !ok {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, dbqInsertDelegated, idAddr.String(), delegatedAddr.String(), tsk.Bytes()); err != nil {
+		return xerrors.Errorf("recording delegated address of %s: %w", idAddr, err)
+	}
+	return nil
+}
+
+// lookupState returns the ID and the delegated address of the actor of a in
+// the parent state of tsk, ok is false when the actor doesn't exist or has no
+// delegated address.
+func (x *Index) lookupState(ctx context.Context, a address.Address, tsk types.TipSetKey) (id, delegated address.Address, ok bool) {
+	id = a
+	if a.Protocol() != address.ID {
+		var err error
+		if id, err = x.api.StateLookupID(ctx, a, tsk); err != nil {
+			log.Debugw("looking up actor ID", "address", a, "error", err)
+			return address.Undef, address.Undef, false
+		}
+	}
+
+	act, err := x.api.StateGetActor(ctx, id, tsk)
+	if err != nil {
+		log.Debugw("loading actor", "address", id, "error", err)
+		return address.Undef, address.Undef, false
+	}
+	if act.Address == nil || act.Address.Protocol() != address.Delegated {
+		x.noDelegated.Add(id, struct{}{})
+		return address.Undef, address.Undef, false
+	}
+	return id, *act.Address, true
+}
+
+// LookupDelegated returns the ID and the delegated address of the actor of a,
+// an ID or a delegated address. The actors not indexed yet are looked up in the
+// state of ts, and indexed, found is false when the actor doesn't exist or has
+// no delegated address.
+func (x *Index) LookupDelegated(ctx context.Context, a address.Address, ts *types.TipSet) (id, delegated address.Address, found bool, err error) {
+	if a.Protocol() != address.ID && a.Protocol() != address.Delegated {
+		return address.Undef, address.Undef, false, xerrors.Errorf("%s is neither an ID nor a delegated address", a)
+	}
+
+	var ids, delegateds string
+	err = x.db.QueryRowContext(ctx, dbqSelectDelegated, a.String(), a.String()).Scan(&ids, &delegateds)
+	switch err {
+	case nil:
+		if id, err = address.NewFromString(ids); err != nil {
+			return address.Undef, address.Undef, false, xerrors.Errorf("decoding ID address: %w", err)
+		}
+		if delegated, err = address.NewFromString(delegateds); err != nil {
+			return address.Undef, address.Undef, false, xerrors.Errorf("decoding delegated address: %w", err)
+		}
+		return id, delegated, true, nil
+	case sql.ErrNoRows:
+		if x.noDelegated.Contains(a) {
+			return address.Undef, address.Undef, false, nil
+		}
+	default:
+		return address.Undef, address.Undef, false, xerrors.Errorf("querying address index: %w", err)
+	}
+
+	id, delegated, found = x.lookupState(ctx, a, ts.Key())
+	if !found {
+		return address.Undef, address.Undef, false, nil
+	}
+
+	// recorded with the tipset looked up, so that it's removed if the tipset is
+	// reverted
+	x.lk.Lock()
+	defer x.lk.Unlock()
+	if _, err := x.db.ExecContext(ctx, dbqInsertDelegated, id.String(), delegated.String(), ts.Key().Bytes()); err != nil {
+		return address.Undef, address.Undef, false, xerrors.Errorf("recording delegated address of %s: %w", id, err)
+	}
+	return id, delegated, true, nil
+}
+
+// Backfill indexes the tipsets from head down to minHeight which aren't
+// indexed yet, such as the tipsets applied while the node wasn't running.
+func (x *Index) Backfill(ctx context.Context, head *types.TipSet, minHeight abi.ChainEpoch) error {
+	var indexed int
+	for ts := head; ts.Height() >= minHeight; {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var n int
+		if err := x.db.QueryRowContext(ctx, dbqHasTipSet, ts.Key().Bytes()).Scan(&n); err != nil {
+			return xerrors.Errorf("looking up %s: %w", ts.Key(), err)
+		}
+		if n == 0 {
+			x.lk.Lock()
+			err := x.index(ctx, ts)
+			x.lk.Unlock()
+			if err != nil {
+				return xerrors.Errorf("indexing messages of %s: %w", ts.Key(), err)
+			}
+			indexed++
+		}
+
+		if ts.Height() == 0 {
+			break
+		}
+		pts, err := x.api.ChainGetTipSet(ctx, ts.Parents())
+		if err != nil {
+			return xerrors.Errorf("loading parent of %s: %w", ts.Key(), err)
+		}
+		ts = pts
+	}
+
+	if indexed > 0 {
+		log.Infow("backfilled address index", "tipsets", indexed, "from", head.Height(), "to", minHeight)
+	}
+	return nil
+}
+
+type cursor struct {
+	height abi.ChainEpoch
+	index  int64
+}
+
+func (c cursor) String() string {
+	return fmt.Sprintf("%d:%d", c.height, c.index)
+}
+
+func parseCursor(s string) (cursor, error) {
+	h, i, ok := strings.Cut(s, ":")
+	if !ok {
+		return cursor{}, xerrors.Errorf("invalid cursor %q", s)
+	}
+	height, err := strconv.ParseInt(h, 10, 64)
+	if err != nil {
+		return cursor{}, xerrors.Errorf("invalid cursor %q: %w", s, err)
+	}
+	index, err := strconv.ParseInt(i, 10, 64)
+	if err != nil {
+		return cursor{}, xerrors.Errorf("invalid cursor %q: %w", s, err)
+	}
+	return cursor{height: abi.ChainEpoch(height), index: index}, nil
+}
+
+// List returns a page of the messages sent or received by any of the given
+// addresses, which should be the forms of the same actor, e.g. its ID and its
+// robust address, in place of the address of the query.
+func (x *Index) List(ctx context.Context, addrs []address.Address, q api.AddressMessageQuery) (*api.AddressMessagePage, error) {
+	if len(addrs) == 0 {
+		return nil, xerrors.Errorf("no address to list the messages of")
+	}
+
+	limit := q.Limit
+	switch {
+	case limit <= 0:
+		limit = DefaultLimit
+	case limit > MaxLimit:
+		return nil, xerrors.Errorf("limit %d is above the maximum of %d", limit, MaxLimit)
+	}
+
+	var (
+		where []string
+		args  []interface{}
+	)
+
+	in := make([]string, len(addrs))
+	for i, a := range addrs {
+		in[i] = "?"
+		args = append(args, a.String())
+	}
+	where = append(where, "address IN ("+strings.Join(in, ", ")+")")
+
+	switch q.Direction {
+	case "":
+	case api.MessageDirectionFrom:
+		where = append(where, "from_addr = address")
+	case api.MessageDirectionTo:
+		where = append(where, "to_addr = address")
+	default:
+		return nil, xerrors.Errorf("invalid direction %q, expected %q, %q or none", q.Direction, api.MessageDirectionFrom, api.MessageDirectionTo)
+	}
+
+	where = append(where, "height >= ?")
+	args = append(args, int64(q.FromHeight))
+	if q.ToHeight > 0 {
+		where = append(where, "height <= ?")
+		args = append(args, int64(q.ToHeight))
+	}
+
+	cmp, order := "<", "DESC"
+	if q.Ascending {
+		cmp, order = ">", "ASC"
+	}
+	if q.Cursor != "" {
+		c, err := parseCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		where = append(where, fmt.Sprintf("(height %s ? OR (height = ? AND msg_index %s ?))", cmp, cmp))
+		args = append(args, int64(c.height), int64(c.height), c.index)
+	}
+
+	query := fmt.Sprintf("%s WHERE %s ORDER BY height %s, msg_index %s LIMIT ?", dbqSelectMessages, strings.Join(where, " AND "), order, order)
+	// one more row tells whether there is a next page
+	args = append(args, limit+1)
+
+	rows, err := x.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, xerrors.Errorf("querying address index: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	out := &api.AddressMessagePage{
+		Messages: []api.AddressMessage{},
+	}
+	var last cursor
+	for rows.Next() {
+		if len(out.Messages) == limit {
+			out.Cursor = last.String()
+			break
+		}
+
+		var (
+			height, index  int64
+			mcid, from, to string
+			tsk            []byte
+			m              api.AddressMessage
+		)
+		if err := rows.Scan(&height, &index, &mcid, &from, &to, &tsk); err != nil {
+			return nil, err
+		}
+
+		if m.Cid, err = cid.Decode(mcid); err != nil {
+			return nil, xerrors.Errorf("decoding message cid: %w", err)
+		}
+		if m.From, err = address.NewFromString(from); err != nil {
+			return nil, xerrors.Errorf("decoding sender: %w", err)
+		}
+		if m.To, err = address.NewFromString(to); err != nil {
+			return nil, xerrors.Errorf("decoding recipient: %w", err)
+		}
+		if m.TipSet, err = types.TipSetKeyFromBytes(tsk); err != nil {
+			return nil, xerrors.Errorf("decoding tipset key: %w", err)
+		}
+		m.Height = abi.ChainEpoch(height)
+
+		out.Messages = append(out.Messages, m)
+		last = cursor{height: m.Height, index: index}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var minHeight sql.NullInt64
+	if err := x.db.QueryRowContext(ctx, dbqMinHeight).Scan(&minHeight); err != nil {
+		return nil, xerrors.Errorf("querying the indexed range: %w", err)
+	}
+	out.IndexedFrom = abi.ChainEpoch(minHeight.Int64)
+
+	return out, nil
+}
@@ -0,0 +1,219 @@
+// stm: #unit
+package addrindex
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+var (
+	actorA = mock.Address(1001)
+	actorB = mock.Address(1002)
+	actorC = mock.Address(1003)
+)
+
+type fakeChain struct {
+	tipsets map[types.TipSetKey]*types.TipSet
+	msgs    map[types.TipSetKey][]api.Message
+
+	// actors by ID, and IDs by delegated address
+	actors map[address.Address]*types.Actor
+	ids    map[address.Address]address.Address
+
+	lookups int
+}
+
+func (fc *fakeChain) mk(parent *types.TipSet, nonce uint64, msgs ...*types.Message) *types.TipSet {
+	ts := mock.TipSet(mock.MkBlock(parent, 1, nonce))
+	fc.tipsets[ts.Key()] = ts
+	for _, m := range msgs {
+		fc.msgs[ts.Key()] = append(fc.msgs[ts.Key()], api.Message{Cid: m.Cid(), Message: m})
+	}
+	return ts
+}
+
+func (fc *fakeChain) ChainGetTipSet(_ context.Context, tsk types.TipSetKey) (*types.TipSet, error) {
+	return fc.tipsets[tsk], nil
+}
+
+func (fc *fakeChain) ChainGetMessagesInTipset(_ context.Context, tsk types.TipSetKey) ([]api.Message, error) {
+	return fc.msgs[tsk], nil
+}
+
+func (fc *fakeChain) StateGetActor(_ context.Context, a address.Address, _ types.TipSetKey) (*types.Actor, error) {
+	fc.lookups++
+	act, ok := fc.actors[a]
+	if !ok {
+		return nil, types.ErrActorNotFound
+	}
+	return act, nil
+}
+
+func (fc *fakeChain) StateLookupID(_ context.Context, a address.Address, _ types.TipSetKey) (address.Address, error) {
+	if a.Protocol() == address.ID {
+		return a, nil
+	}
+	id, ok := fc.ids[a]
+	if !ok {
+		return address.Undef, types.ErrActorNotFound
+	}
+	return id, nil
+}
+
+func msg(from, to address.Address, nonce uint64) *types.Message {
+	return &types.Message{From: from, To: to, Nonce: nonce, Value: types.NewInt(0)}
+}
+
+func cids(p *api.AddressMessagePage) []cid.Cid {
+	var out []cid.Cid
+	for _, m := range p.Messages {
+		out = append(out, m.Cid)
+	}
+	return out
+}
+
+func TestIndex(t *testing.T) {
+	ctx := context.Background()
+	fc := &fakeChain{
+		tipsets: map[types.TipSetKey]*types.TipSet{},
+		msgs:    map[types.TipSetKey][]api.Message{},
+	}
+
+	x, err := NewIndex(filepath.Join(t.TempDir(), DBName), fc)
+	require.NoError(t, err)
+	defer x.Close() //nolint:errcheck
+
+	m1, m2, m3 := msg(actorA, actorB, 0), msg(actorB, actorA, 0), msg(actorA, actorA, 1)
+	m4 := msg(actorA, actorC, 2)
+
+	gen := fc.mk(nil, 0)
+	ts1 := fc.mk(gen, 1, m1, m2, m3)
+	ts2 := fc.mk(ts1, 2, m4)
+
+	require.NoError(t, x.Apply(ctx, gen, ts1))
+	require.NoError(t, x.Apply(ctx, ts1, ts2))
+
+	// newest first, self-sends listed once
+	res, err := x.List(ctx, []address.Address{actorA}, api.AddressMessageQuery{})
+	require.NoError(t, err)
+	require.Equal(t, []cid.Cid{m4.Cid(), m3.Cid(), m2.Cid(), m1.Cid()}, cids(res))
+	require.Empty(t, res.Cursor)
+	require.Equal(t, ts1.Height(), res.IndexedFrom)
+	require.Equal(t, ts2.Key(), res.Messages[0].TipSet)
+	require.Equal(t, actorC, res.Messages[0].To)
+
+	// directions
+	res, err = x.List(ctx, []address.Address{actorA}, api.AddressMessageQuery{Direction: api.MessageDirectionTo})
+	require.NoError(t, err)
+	require.Equal(t, []cid.Cid{m3.Cid(), m2.Cid()}, cids(res))
+
+	res, err = x.List(ctx, []address.Address{actorB}, api.AddressMessageQuery{Direction: api.MessageDirectionFrom})
+	require.NoError(t, err)
+	require.Equal(t, []cid.Cid{m2.Cid()}, cids(res))
+
+	_, err = x.List(ctx, []address.Address{actorB}, api.AddressMessageQuery{Direction: "sideways"})
+	require.Error(t, err)
+
+	// a message between two forms of the same actor is listed once
+	res, err = x.List(ctx, []address.Address{actorB, actorC}, api.AddressMessageQuery{})
+	require.NoError(t, err)
+	require.Equal(t, []cid.Cid{m4.Cid(), m2.Cid(), m1.Cid()}, cids(res))
+
+	// pages in ascending order
+	q := api.AddressMessageQuery{Ascending: true, Limit: 3}
+	res, err = x.List(ctx, []address.Address{actorA}, q)
+	require.NoError(t, err)
+	require.Equal(t, []cid.Cid{m1.Cid(), m2.Cid(), m3.Cid()}, cids(res))
+	require.NotEmpty(t, res.Cursor)
+
+	q.Cursor = res.Cursor
+	res, err = x.List(ctx, []address.Address{actorA}, q)
+	require.NoError(t, err)
+	require.Equal(t, []cid.Cid{m4.Cid()}, cids(res))
+	require.Empty(t, res.Cursor)
+
+	// height range
+	res, err = x.List(ctx, []address.Address{actorA}, api.AddressMessageQuery{ToHeight: ts1.Height()})
+	require.NoError(t, err)
+	require.Len(t, res.Messages, 3)
+
+	// reverts remove the messages of the tipset
+	require.NoError(t, x.Revert(ctx, ts2, ts1))
+	res, err = x.List(ctx, []address.Address{actorC}, api.AddressMessageQuery{})
+	require.NoError(t, err)
+	require.Empty(t, res.Messages)
+
+	// backfill indexes the missing tipsets only
+	ts3 := fc.mk(ts2, 3, msg(actorC, actorB, 0))
+	require.NoError(t, x.Backfill(ctx, ts3, 0))
+	res, err = x.List(ctx, []address.Address{actorC}, api.AddressMessageQuery{})
+	require.NoError(t, err)
+	require.Len(t, res.Messages, 2)
+	require.Equal(t, gen.Height(), res.IndexedFrom)
+}
+
+func TestDelegated(t *testing.T) {
+	ctx := context.Background()
+
+	contract, err := address.NewDelegatedAddress(10, make([]byte, 20))
+	require.NoError(t, err)
+	evmActor := mock.Address(1010)
+
+	fc := &fakeChain{
+		tipsets: map[types.TipSetKey]*types.TipSet{},
+		msgs:    map[types.TipSetKey][]api.Message{},
+		actors: map[address.Address]*types.Actor{
+			actorA:   {},
+			evmActor: {Address: &contract},
+		},
+		ids: map[address.Address]address.Address{contract: evmActor},
+	}
+
+	x, err := NewIndex(filepath.Join(t.TempDir(), DBName), fc)
+	require.NoError(t, err)
+	defer x.Close() //nolint:errcheck
+
+	gen := fc.mk(nil, 0)
+	ts1 := fc.mk(gen, 1, msg(actorA, contract, 0))
+	require.NoError(t, x.Apply(ctx, gen, ts1))
+
+	// indexed when applied, in both directions
+	lookups := fc.lookups
+	id, delegated, found, err := x.LookupDelegated(ctx, contract, ts1)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, evmActor, id)
+	require.Equal(t, contract, delegated)
+
+	_, delegated, found, err = x.LookupDelegated(ctx, evmActor, ts1)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, contract, delegated)
+
+	// actors without a delegated address are only looked up once
+	_, _, found, err = x.LookupDelegated(ctx, actorA, ts1)
+	require.NoError(t, err)
+	require.False(t, found)
+	require.Equal(t, lookups, fc.lookups)
+
+	_, _, _, err = x.LookupDelegated(ctx, mock.Address(5000), ts1)
+	require.NoError(t, err)
+	require.Equal(t, lookups+1, fc.lookups)
+
+	// reverts remove the addresses first seen in the tipset
+	require.NoError(t, x.Revert(ctx, ts1, gen))
+	_, _, found, err = x.LookupDelegated(ctx, evmActor, gen)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, lookups+2, fc.lookups)
+}
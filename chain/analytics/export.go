@@ -0,0 +1,344 @@
+// Package analytics exports chain history to Parquet files, for loading into
+// data warehouses.
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var log = logging.Logger("analytics")
+
+// CursorFile is the name of the file, in the output directory, recording the
+// first epoch not exported yet.
+const CursorFile = "cursor.json"
+
+// MessageRow is a message included in a tipset.
+type MessageRow struct {
+	Height     int64  `parquet:"name=height, type=INT64"`
+	Cid        string `parquet:"name=cid, type=BYTE_ARRAY, convertedtype=UTF8"`
+	From       string `parquet:"name=from, type=BYTE_ARRAY, convertedtype=UTF8"`
+	To         string `parquet:"name=to, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Nonce      int64  `parquet:"name=nonce, type=INT64"`
+	Value      string `parquet:"name=value, type=BYTE_ARRAY, convertedtype=UTF8"`
+	GasLimit   int64  `parquet:"name=gas_limit, type=INT64"`
+	GasFeeCap  string `parquet:"name=gas_fee_cap, type=BYTE_ARRAY, convertedtype=UTF8"`
+	GasPremium string `parquet:"name=gas_premium, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Method     int64  `parquet:"name=method, type=INT64"`
+	Params     string `parquet:"name=params, type=BYTE_ARRAY"`
+}
+
+// ReceiptRow is the receipt of a message, Height is the height of the tipset
+// the message was included in.
+type ReceiptRow struct {
+	Height     int64  `parquet:"name=height, type=INT64"`
+	MessageCid string `parquet:"name=message_cid, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Index      int64  `parquet:"name=idx, type=INT64"`
+	ExitCode   int64  `parquet:"name=exit_code, type=INT64"`
+	GasUsed    int64  `parquet:"name=gas_used, type=INT64"`
+	Return     string `parquet:"name=return, type=BYTE_ARRAY"`
+}
+
+// BalanceChangeRow is the change of an actor balance from executing the
+// messages of the tipset at Height, including implicit messages.
+type BalanceChangeRow struct {
+	Height     int64  `parquet:"name=height, type=INT64"`
+	Address    string `parquet:"name=address, type=BYTE_ARRAY, convertedtype=UTF8"`
+	OldBalance string `parquet:"name=old_balance, type=BYTE_ARRAY, convertedtype=UTF8"`
+	NewBalance string `parquet:"name=new_balance, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Change     string `parquet:"name=change, type=BYTE_ARRAY, convertedtype=UTF8"`
+}
+
+// Cursor records the export progress.
+type Cursor struct {
+	NextEpoch abi.ChainEpoch
+}
+
+// ExportAPI is the subset of the full node API used for exporting.
+type ExportAPI interface {
+	ChainGetTipSetAfterHeight(context.Context, abi.ChainEpoch, types.TipSetKey) (*types.TipSet, error)
+	ChainGetMessagesInTipset(context.Context, types.TipSetKey) ([]api.Message, error)
+	ChainGetParentReceipts(context.Context, cid.Cid) ([]*types.MessageReceipt, error)
+	StateChangedActors(context.Context, cid.Cid, cid.Cid) (map[string]types.Actor, error)
+	StateGetActor(context.Context, address.Address, types.TipSetKey) (*types.Actor, error)
+}
+
+// Exporter writes the messages, receipts and balance changes of the tipsets
+// in an epoch range to Parquet files, one file per table per chunk of epochs.
+// Chunks are written atomically and the cursor is advanced after each chunk,
+// so an interrupted export can be resumed.
+type Exporter struct {
+	api ExportAPI
+	dir string
+
+	// ChunkSize is the number of epochs per output file.
+	ChunkSize abi.ChainEpoch
+	// Progress, if set, is called after each chunk is written.
+	Progress func(from, to abi.ChainEpoch)
+}
+
+func NewExporter(a ExportAPI, dir string) *Exporter {
+	return &Exporter{
+		api:       a,
+		dir:       dir,
+		ChunkSize: 2880,
+	}
+}
+
+// Cursor returns the export cursor of the output directory, or nil if
+// nothing was exported yet.
+func (e *Exporter) Cursor() (*Cursor, error) {
+	b, err := os.ReadFile(filepath.Join(e.dir, CursorFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("reading cursor: %w", err)
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, xerrors.Errorf("decoding cursor: %w", err)
+	}
+	return &c, nil
+}
+
+func (e *Exporter) setCursor(c Cursor) error {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return writeAtomic(filepath.Join(e.dir, CursorFile), func(path string) error {
+		return os.WriteFile(path, b, 0644)
+	})
+}
+
+// Export exports the tipsets from the from epoch to the to epoch included,
+// as seen from the head tipset, starting after the cursor if there is one.
+func (e *Exporter) Export(ctx context.Context, head types.TipSetKey, from, to abi.ChainEpoch) error {
+	if e.ChunkSize <= 0 {
+		return xerrors.Errorf("chunk size must be positive")
+	}
+	for _, table := range []string{"messages", "receipts", "balance_changes"} {
+		if err := os.MkdirAll(filepath.Join(e.dir, table), 0755); err != nil {
+			return err
+		}
+	}
+
+	cur, err := e.Cursor()
+	if err != nil {
+		return err
+	}
+	if cur != nil && cur.NextEpoch > from {
+		log.Infow("resuming export", "epoch", cur.NextEpoch)
+		from = cur.NextEpoch
+	}
+
+	for start := from; start <= to; start += e.ChunkSize {
+		end := start + e.ChunkSize - 1
+		if end > to {
+			end = to
+		}
+
+		if err := e.exportChunk(ctx, head, start, end); err != nil {
+			return xerrors.Errorf("exporting epochs %d-%d: %w", start, end, err)
+		}
+		if err := e.setCursor(Cursor{NextEpoch: end + 1}); err != nil {
+			return xerrors.Errorf("writing cursor: %w", err)
+		}
+		if e.Progress != nil {
+			e.Progress(start, end)
+		}
+	}
+
+	return nil
+}
+
+func (e *Exporter) exportChunk(ctx context.Context, head types.TipSetKey, start, end abi.ChainEpoch) error {
+	var msgs []*MessageRow
+	var rcts []*ReceiptRow
+	var changes []*BalanceChangeRow
+
+	ts, err := e.api.ChainGetTipSetAfterHeight(ctx, start, head)
+	if err != nil {
+		return xerrors.Errorf("loading tipset at %d: %w", start, err)
+	}
+
+	for ts.Height() <= end {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// the messages of a tipset are executed in the state of its child
+		child, err := e.api.ChainGetTipSetAfterHeight(ctx, ts.Height()+1, head)
+		if err != nil {
+			return xerrors.Errorf("loading child of tipset at %d: %w", ts.Height(), err)
+		}
+		if child.Parents() != ts.Key() || child.Height() <= ts.Height() {
+			return xerrors.Errorf("tipset at %d isn't the parent of the next tipset, reorg?", ts.Height())
+		}
+
+		m, r, c, err := e.exportTipSet(ctx, ts, child)
+		if err != nil {
+			return xerrors.Errorf("exporting tipset at %d: %w", ts.Height(), err)
+		}
+		msgs, rcts, changes = append(msgs, m...), append(rcts, r...), append(changes, c...)
+
+		ts = child
+	}
+
+	name := func(table string) string {
+		return filepath.Join(e.dir, table, chunkName(start, end))
+	}
+	if err := writeParquet(name("messages"), new(MessageRow), msgs); err != nil {
+		return err
+	}
+	if err := writeParquet(name("receipts"), new(ReceiptRow), rcts); err != nil {
+		return err
+	}
+	return writeParquet(name("balance_changes"), new(BalanceChangeRow), changes)
+}
+
+func (e *Exporter) exportTipSet(ctx context.Context, ts, child *types.TipSet) ([]*MessageRow, []*ReceiptRow, []*BalanceChangeRow, error) {
+	h := int64(ts.Height())
+
+	msgs, err := e.api.ChainGetMessagesInTipset(ctx, ts.Key())
+	if err != nil {
+		return nil, nil, nil, xerrors.Errorf("loading messages: %w", err)
+	}
+	rcts, err := e.api.ChainGetParentReceipts(ctx, child.Cids()[0])
+	if err != nil {
+		return nil, nil, nil, xerrors.Errorf("loading receipts: %w", err)
+	}
+	if len(rcts) != len(msgs) {
+		return nil, nil, nil, xerrors.Errorf("got %d receipts for %d messages", len(rcts), len(msgs))
+	}
+
+	msgRows := make([]*MessageRow, len(msgs))
+	rctRows := make([]*ReceiptRow, len(msgs))
+	for i, m := range msgs {
+		msgRows[i] = &MessageRow{
+			Height:     h,
+			Cid:        m.Cid.String(),
+			From:       m.Message.From.String(),
+			To:         m.Message.To.String(),
+			Nonce:      int64(m.Message.Nonce),
+			Value:      m.Message.Value.String(),
+			GasLimit:   m.Message.GasLimit,
+			GasFeeCap:  m.Message.GasFeeCap.String(),
+			GasPremium: m.Message.GasPremium.String(),
+			Method:     int64(m.Message.Method),
+			Params:     string(m.Message.Params),
+		}
+		rctRows[i] = &ReceiptRow{
+			Height:     h,
+			MessageCid: m.Cid.String(),
+			Index:      int64(i),
+			ExitCode:   int64(rcts[i].ExitCode),
+			GasUsed:    rcts[i].GasUsed,
+			Return:     string(rcts[i].Return),
+		}
+	}
+
+	changed, err := e.api.StateChangedActors(ctx, ts.ParentState(), child.ParentState())
+	if err != nil {
+		return nil, nil, nil, xerrors.Errorf("diffing states: %w", err)
+	}
+
+	var changeRows []*BalanceChangeRow
+	for addrStr, act := range changed {
+		addr, err := address.NewFromString(addrStr)
+		if err != nil {
+			return nil, nil, nil, xerrors.Errorf("parsing address %s: %w", addrStr, err)
+		}
+
+		oldBalance := big.Zero()
+		old, err := e.api.StateGetActor(ctx, addr, ts.Key())
+		switch {
+		case err == nil:
+			oldBalance = old.Balance
+		case !strings.Contains(err.Error(), types.ErrActorNotFound.Error()):
+			return nil, nil, nil, xerrors.Errorf("loading actor %s: %w", addr, err)
+		}
+
+		if oldBalance.Equals(act.Balance) {
+			continue
+		}
+		changeRows = append(changeRows, &BalanceChangeRow{
+			Height:     h,
+			Address:    addrStr,
+			OldBalance: oldBalance.String(),
+			NewBalance: act.Balance.String(),
+			Change:     big.Sub(act.Balance, oldBalance).String(),
+		})
+	}
+	sort.Slice(changeRows, func(i, j int) bool {
+		return changeRows[i].Address < changeRows[j].Address
+	})
+
+	return msgRows, rctRows, changeRows, nil
+}
+
+func writeParquet[T any](path string, schema *T, rows []*T) error {
+	return writeAtomic(path, func(tmp string) error {
+		fw, err := local.NewLocalFileWriter(tmp)
+		if err != nil {
+			return xerrors.Errorf("creating %s: %w", tmp, err)
+		}
+
+		pw, err := writer.NewParquetWriter(fw, schema, 4)
+		if err != nil {
+			_ = fw.Close()
+			return xerrors.Errorf("creating parquet writer: %w", err)
+		}
+		pw.CompressionType = parquet.CompressionCodec_SNAPPY
+
+		for _, row := range rows {
+			if err := pw.Write(row); err != nil {
+				_ = fw.Close()
+				return xerrors.Errorf("writing row: %w", err)
+			}
+		}
+
+		if err := pw.WriteStop(); err != nil {
+			_ = fw.Close()
+			return xerrors.Errorf("finishing parquet file: %w", err)
+		}
+		return fw.Close()
+	})
+}
+
+// writeAtomic writes a file through a temporary file, renamed once written.
+func writeAtomic(path string, write func(tmp string) error) error {
+	tmp := path + ".tmp"
+	if err := write(tmp); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// chunkName returns the file name of a chunk, zero-padded so that file names
+// sort by epoch.
+func chunkName(start, end abi.ChainEpoch) string {
+	return fmt.Sprintf("%010d-%010d.parquet", start, end)
+}
@@ -0,0 +1,172 @@
+// stm: #unit
+package analytics
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/reader"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/exitcode"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+// fakeChain is a chain where the tipset at each height includes a single
+// message from the sender, moving one unit to the receiver.
+type fakeChain struct {
+	tipsets  []*types.TipSet
+	minAsked abi.ChainEpoch
+}
+
+var (
+	sender   = mock.Address(100)
+	receiver = mock.Address(101)
+)
+
+func newFakeChain(t *testing.T, length int) *fakeChain {
+	fc := &fakeChain{minAsked: -1}
+
+	var parent *types.TipSet
+	for i := 0; i < length; i++ {
+		blk := mock.MkBlock(parent, 1, uint64(i))
+		if i == 5 {
+			// null round
+			blk.Height++
+		}
+		root, err := abi.CidBuilder.Sum([]byte{byte(i)})
+		require.NoError(t, err)
+		blk.ParentStateRoot = root
+
+		parent = mock.TipSet(blk)
+		fc.tipsets = append(fc.tipsets, parent)
+	}
+	return fc
+}
+
+func (fc *fakeChain) byKey(tsk types.TipSetKey) (int, *types.TipSet) {
+	for i, ts := range fc.tipsets {
+		if ts.Key() == tsk {
+			return i, ts
+		}
+	}
+	return -1, nil
+}
+
+func (fc *fakeChain) ChainGetTipSetAfterHeight(_ context.Context, h abi.ChainEpoch, _ types.TipSetKey) (*types.TipSet, error) {
+	if fc.minAsked == -1 || h < fc.minAsked {
+		fc.minAsked = h
+	}
+	for _, ts := range fc.tipsets {
+		if ts.Height() >= h {
+			return ts, nil
+		}
+	}
+	return nil, xerrors.Errorf("no tipset after %d", h)
+}
+
+func (fc *fakeChain) ChainGetMessagesInTipset(_ context.Context, tsk types.TipSetKey) ([]api.Message, error) {
+	_, ts := fc.byKey(tsk)
+	msg := mock.UnsignedMessage(sender, receiver, uint64(ts.Height()))
+	return []api.Message{{Cid: msg.Cid(), Message: msg}}, nil
+}
+
+func (fc *fakeChain) ChainGetParentReceipts(_ context.Context, blk cid.Cid) ([]*types.MessageReceipt, error) {
+	_, ts := fc.byKey(types.NewTipSetKey(blk))
+	return []*types.MessageReceipt{{ExitCode: exitcode.Ok, GasUsed: int64(ts.Height())}}, nil
+}
+
+func (fc *fakeChain) StateChangedActors(_ context.Context, _, _ cid.Cid) (map[string]types.Actor, error) {
+	return map[string]types.Actor{
+		sender.String():   {Balance: big.NewInt(100)},
+		receiver.String(): {Balance: big.NewInt(1)},
+	}, nil
+}
+
+func (fc *fakeChain) StateGetActor(_ context.Context, addr address.Address, _ types.TipSetKey) (*types.Actor, error) {
+	if addr == receiver {
+		return nil, types.ErrActorNotFound
+	}
+	return &types.Actor{Balance: big.NewInt(100)}, nil
+}
+
+func readRows[T any](t *testing.T, dir, table string) []T {
+	files, err := filepath.Glob(filepath.Join(dir, table, "*.parquet"))
+	require.NoError(t, err)
+
+	var out []T
+	for _, f := range files {
+		fr, err := local.NewLocalFileReader(f)
+		require.NoError(t, err)
+
+		pr, err := reader.NewParquetReader(fr, new(T), 1)
+		require.NoError(t, err)
+
+		rows := make([]T, pr.GetNumRows())
+		require.NoError(t, pr.Read(&rows))
+		out = append(out, rows...)
+
+		pr.ReadStop()
+		require.NoError(t, fr.Close())
+	}
+	return out
+}
+
+func TestExport(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	fc := newFakeChain(t, 12)
+	head := fc.tipsets[len(fc.tipsets)-1].Key()
+
+	e := NewExporter(fc, dir)
+	e.ChunkSize = 3
+	require.NoError(t, e.Export(ctx, head, 1, 4))
+
+	cur, err := e.Cursor()
+	require.NoError(t, err)
+	require.EqualValues(t, 5, cur.NextEpoch)
+
+	// the second export resumes at the cursor
+	fc.minAsked = -1
+	require.NoError(t, e.Export(ctx, head, 1, 10))
+	require.EqualValues(t, 5, fc.minAsked)
+
+	files, err := os.ReadDir(filepath.Join(dir, "messages"))
+	require.NoError(t, err)
+	require.Len(t, files, 4) // 1-3, 4-4, 5-7, 8-10
+
+	// epochs 1 to 10 have 9 tipsets, with a null round at 5
+	msgs := readRows[MessageRow](t, dir, "messages")
+	require.Len(t, msgs, 9)
+	for _, m := range msgs {
+		require.NotEqual(t, int64(5), m.Height)
+		require.Equal(t, sender.String(), m.From)
+		require.EqualValues(t, m.Height, m.Nonce)
+	}
+
+	rcts := readRows[ReceiptRow](t, dir, "receipts")
+	require.Len(t, rcts, 9)
+	for i, r := range rcts {
+		require.Equal(t, msgs[i].Cid, r.MessageCid)
+	}
+
+	// the sender's balance doesn't change in the fake chain
+	changes := readRows[BalanceChangeRow](t, dir, "balance_changes")
+	require.Len(t, changes, 9)
+	for _, c := range changes {
+		require.Equal(t, receiver.String(), c.Address)
+		require.Equal(t, "0", c.OldBalance)
+		require.Equal(t, "1", c.Change)
+	}
+}
@@ -0,0 +1,144 @@
+// Package attestation produces signed attestations of message inclusion and
+// execution, for bridges and other off-chain consumers that trust a node
+// operator's key rather than running a light client.
+package attestation
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/stmgr"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/lib/sigs"
+)
+
+var log = logging.Logger("attestation")
+
+// Attestor signs attestations of message execution with a wallet key.
+type Attestor struct {
+	sm     *stmgr.StateManager
+	cs     *store.ChainStore
+	wallet api.Wallet
+
+	signer   address.Address
+	network  string
+	finality abi.ChainEpoch
+}
+
+// NewAttestor creates an attestor signing with the given wallet address. A
+// message is attested as final once its inclusion tipset is finality epochs
+// deep.
+func NewAttestor(sm *stmgr.StateManager, cs *store.ChainStore, wallet api.Wallet, signer address.Address, network string, finality abi.ChainEpoch) *Attestor {
+	return &Attestor{
+		sm:       sm,
+		cs:       cs,
+		wallet:   wallet,
+		signer:   signer,
+		network:  network,
+		finality: finality,
+	}
+}
+
+// Signer returns the address attestations are signed with.
+func (a *Attestor) Signer() address.Address {
+	return a.signer
+}
+
+// Attest returns a signed attestation of the execution of a message in the
+// current heaviest chain.
+func (a *Attestor) Attest(ctx context.Context, msg cid.Cid) (*api.AttestationBundle, error) {
+	head := a.cs.GetHeaviestTipSet()
+
+	proof, err := a.sm.SearchForReceiptProof(ctx, head, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	execTs, err := a.cs.LoadTipSet(ctx, proof.TipSet)
+	if err != nil {
+		return nil, xerrors.Errorf("loading execution tipset: %w", err)
+	}
+	inclTs, err := a.cs.LoadTipSet(ctx, execTs.Parents())
+	if err != nil {
+		return nil, xerrors.Errorf("loading inclusion tipset: %w", err)
+	}
+
+	att := api.MessageAttestation{
+		Network:         a.network,
+		Message:         proof.Message,
+		InclusionTipSet: inclTs.Cids(),
+		InclusionHeight: inclTs.Height(),
+		ExecutionTipSet: execTs.Cids(),
+		ReceiptsRoot:    proof.ReceiptsRoot,
+		ExitCode:        proof.Receipt.ExitCode,
+		GasUsed:         proof.Receipt.GasUsed,
+		AttestedHeight:  head.Height(),
+		Final:           head.Height()-inclTs.Height() >= a.finality,
+	}
+
+	return a.sign(ctx, att, proof)
+}
+
+func (a *Attestor) sign(ctx context.Context, att api.MessageAttestation, proof *api.ReceiptProof) (*api.AttestationBundle, error) {
+	buf := new(bytes.Buffer)
+	if err := att.MarshalCBOR(buf); err != nil {
+		return nil, xerrors.Errorf("serializing attestation: %w", err)
+	}
+
+	sig, err := a.wallet.WalletSign(ctx, a.signer, buf.Bytes(), api.MsgMeta{Type: api.MTUnknown})
+	if err != nil {
+		return nil, xerrors.Errorf("signing attestation: %w", err)
+	}
+
+	log.Debugw("signed attestation", "message", att.Message, "height", att.InclusionHeight, "final", att.Final)
+
+	return &api.AttestationBundle{
+		Attestation: att,
+		Signer:      a.signer,
+		Signature:   *sig,
+		Proof:       proof,
+	}, nil
+}
+
+// VerifyBundle checks the signature of an attestation bundle, and that its
+// receipt proof proves the attested execution result. It doesn't check that
+// the signer is trusted, or that the attested tipsets are in any chain.
+func VerifyBundle(ctx context.Context, b *api.AttestationBundle) error {
+	buf := new(bytes.Buffer)
+	if err := b.Attestation.MarshalCBOR(buf); err != nil {
+		return xerrors.Errorf("serializing attestation: %w", err)
+	}
+
+	if err := sigs.Verify(&b.Signature, b.Signer, buf.Bytes()); err != nil {
+		return xerrors.Errorf("invalid attestation signature: %w", err)
+	}
+
+	if b.Proof == nil {
+		return xerrors.Errorf("attestation bundle has no receipt proof")
+	}
+
+	att, p := &b.Attestation, b.Proof
+	if p.Message != att.Message {
+		return xerrors.Errorf("receipt proof is for message %s, not the attested %s", p.Message, att.Message)
+	}
+	if p.ReceiptsRoot != att.ReceiptsRoot {
+		return xerrors.Errorf("receipt proof is against %s, not the attested receipts root %s", p.ReceiptsRoot, att.ReceiptsRoot)
+	}
+	if p.TipSet != types.NewTipSetKey(att.ExecutionTipSet...) {
+		return xerrors.Errorf("receipt proof is for tipset %s, not the attested execution tipset", p.TipSet)
+	}
+	if p.Receipt.ExitCode != att.ExitCode || p.Receipt.GasUsed != att.GasUsed {
+		return xerrors.Errorf("proven receipt doesn't match the attested execution result")
+	}
+
+	return stmgr.VerifyReceiptProof(ctx, p)
+}
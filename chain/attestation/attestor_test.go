@@ -0,0 +1,102 @@
+// stm: #unit
+package attestation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/exitcode"
+	blockadt "github.com/filecoin-project/specs-actors/actors/util/adt"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/actors/adt"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/wallet"
+)
+
+func TestVerifyBundle(t *testing.T) {
+	ctx := context.Background()
+
+	w, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	require.NoError(t, err)
+	signer, err := w.WalletNew(ctx, types.KTSecp256k1)
+	require.NoError(t, err)
+
+	// a receipts amt, proven by all of its blocks
+	bs := blockstore.NewMemory()
+	arr := blockadt.MakeEmptyArray(adt.WrapStore(ctx, cbor.NewCborStore(bs)))
+	for i := uint64(0); i < 10; i++ {
+		require.NoError(t, arr.Set(i, &types.MessageReceipt{ExitCode: exitcode.Ok, GasUsed: int64(i)}))
+	}
+	root, err := arr.Root()
+	require.NoError(t, err)
+
+	keys, err := bs.AllKeysChan(ctx)
+	require.NoError(t, err)
+	var blocks []api.ProofBlock
+	for c := range keys {
+		blk, err := bs.Get(ctx, c)
+		require.NoError(t, err)
+		blocks = append(blocks, api.ProofBlock{Cid: c, Data: blk.RawData()})
+	}
+
+	msg, err := abi.CidBuilder.Sum([]byte("message"))
+	require.NoError(t, err)
+	blk, err := abi.CidBuilder.Sum([]byte("block"))
+	require.NoError(t, err)
+	execTs := []cid.Cid{blk}
+	proof := &api.ReceiptProof{
+		TipSet:       types.NewTipSetKey(execTs...),
+		ReceiptsRoot: root,
+		Message:      msg,
+		Index:        7,
+		Receipt:      types.MessageReceipt{ExitCode: exitcode.Ok, GasUsed: 7},
+		Proof:        blocks,
+	}
+
+	a := &Attestor{wallet: w, signer: signer}
+	b, err := a.sign(ctx, api.MessageAttestation{
+		Network:         "testnet",
+		Message:         msg,
+		InclusionTipSet: execTs,
+		InclusionHeight: 10,
+		ExecutionTipSet: execTs,
+		ReceiptsRoot:    root,
+		ExitCode:        exitcode.Ok,
+		GasUsed:         7,
+		AttestedHeight:  1000,
+		Final:           true,
+	}, proof)
+	require.NoError(t, err)
+	require.NoError(t, VerifyBundle(ctx, b))
+
+	// changing the attestation invalidates the signature
+	forged := *b
+	forged.Attestation.GasUsed = 8
+	require.ErrorContains(t, VerifyBundle(ctx, &forged), "signature")
+
+	forged = *b
+	forged.Attestation.Final = false
+	require.ErrorContains(t, VerifyBundle(ctx, &forged), "signature")
+
+	// the proof must prove the attested receipt
+	forgedProof := *proof
+	forgedProof.Index = 8
+	forged = *b
+	forged.Proof = &forgedProof
+	require.Error(t, VerifyBundle(ctx, &forged))
+
+	forgedProof = *proof
+	forgedProof.Receipt.GasUsed = 8
+	forged.Proof = &forgedProof
+	require.ErrorContains(t, VerifyBundle(ctx, &forged), "attested execution result")
+
+	forged.Proof = nil
+	require.Error(t, VerifyBundle(ctx, &forged))
+}
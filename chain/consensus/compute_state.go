@@ -109,7 +109,7 @@ func (t *TipSetExecutor) ApplyBlocks(ctx context.Context,
 			LookbackState:  stmgr.LookbackStateGetterForTipset(sm, ts),
 			TipSetGetter:   stmgr.TipSetGetterForTipset(sm.ChainStore(), ts),
 			Tracing:        vmTracing,
-			ReturnEvents:   sm.ChainStore().IsStoringEvents(),
+			ReturnEvents:   sm.ChainStore().IsStoringEvents() || stmgr.ReturnEvents(ctx),
 			ExecutionLane:  vm.ExecutionLanePriority,
 		}
 
@@ -7,6 +7,7 @@ import (
 	"os"
 	"time"
 
+	lru "github.com/hashicorp/golang-lru/v2"
 	"github.com/ipfs/go-cid"
 	logging "github.com/ipfs/go-log/v2"
 	"go.opencensus.io/trace"
@@ -25,6 +26,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/actors/builtin"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/power"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/reward"
+	"github.com/filecoin-project/lotus/chain/actors/policy"
 	"github.com/filecoin-project/lotus/chain/beacon"
 	"github.com/filecoin-project/lotus/chain/consensus"
 	"github.com/filecoin-project/lotus/chain/rand"
@@ -53,8 +55,16 @@ type FilecoinEC struct {
 	verifier storiface.Verifier
 
 	genesis *types.TipSet
+
+	// verifiedProofs holds the blocks whose proofs were verified by
+	// ValidateBlockProofs
+	verifiedProofs *lru.Cache[cid.Cid, struct{}]
 }
 
+// verifiedProofsCacheSize bounds the number of blocks whose proofs are
+// remembered, it must exceed the blocks the syncer verifies ahead.
+const verifiedProofsCacheSize = 8192
+
 // Blocks that are more than MaxHeightDrift epochs above
 // the theoretical max height based on systime are quickly rejected
 const MaxHeightDrift = 5
@@ -99,12 +109,18 @@ func NewFilecoinExpectedConsensus(sm *stmgr.StateManager, beacon beacon.Schedule
 		log.Warn("*********************************************************************************************")
 	}
 
+	verifiedProofs, err := lru.New[cid.Cid, struct{}](verifiedProofsCacheSize)
+	if err != nil {
+		panic(err)
+	}
+
 	return &FilecoinEC{
-		store:    sm.ChainStore(),
-		beacon:   beacon,
-		sm:       sm,
-		verifier: verifier,
-		genesis:  genesis,
+		store:          sm.ChainStore(),
+		beacon:         beacon,
+		sm:             sm,
+		verifier:       verifier,
+		genesis:        genesis,
+		verifiedProofs: verifiedProofs,
 	}
 }
 
@@ -120,8 +136,6 @@ func (filec *FilecoinEC) ValidateBlock(ctx context.Context, b *types.FullBlock)
 		return xerrors.Errorf("load parent tipset failed (%s): %w", h.Parents, err)
 	}
 
-	winPoStNv := filec.sm.GetNetworkVersion(ctx, baseTs.Height())
-
 	lbts, lbst, err := stmgr.GetLookbackTipSetForRound(ctx, filec.sm, baseTs, h.Height)
 	if err != nil {
 		return xerrors.Errorf("failed to get lookback tipset for block: %w", err)
@@ -187,24 +201,6 @@ func (filec *FilecoinEC) ValidateBlock(ctx context.Context, b *types.FullBlock)
 			return xerrors.New("block's miner is ineligible to mine")
 		}
 
-		rBeacon := *prevBeacon
-		if len(h.BeaconEntries) != 0 {
-			rBeacon = h.BeaconEntries[len(h.BeaconEntries)-1]
-		}
-		buf := new(bytes.Buffer)
-		if err := h.Miner.MarshalCBOR(buf); err != nil {
-			return xerrors.Errorf("failed to marshal miner address to cbor: %w", err)
-		}
-
-		vrfBase, err := rand.DrawRandomness(rBeacon.Data, crypto.DomainSeparationTag_ElectionProofProduction, h.Height, buf.Bytes())
-		if err != nil {
-			return xerrors.Errorf("could not draw randomness: %w", err)
-		}
-
-		if err := VerifyElectionPoStVRF(ctx, waddr, vrfBase, h.ElectionProof.VRFProof); err != nil {
-			return xerrors.Errorf("validating block election proof failed: %w", err)
-		}
-
 		slashed, err := stmgr.GetMinerSlashed(ctx, filec.sm, baseTs, h.Miner)
 		if err != nil {
 			return xerrors.Errorf("failed to check if block miner was slashed: %w", err)
@@ -227,6 +223,91 @@ func (filec *FilecoinEC) ValidateBlock(ctx context.Context, b *types.FullBlock)
 		return nil
 	})
 
+	await := []async.ErrorFuture{
+		minerCheck,
+		winnerCheck,
+	}
+
+	// skip the proofs already verified by ValidateBlockProofs
+	if _, ok := filec.verifiedProofs.Get(h.Cid()); !ok {
+		await = append(await, filec.proofChecks(ctx, h, baseTs, *prevBeacon, lbst, waddr)...)
+	}
+
+	await = append(await, consensus.CommonBlkChecks(ctx, filec.sm, filec.store, b, baseTs)...)
+
+	return consensus.RunAsyncChecks(ctx, await)
+}
+
+// ValidateBlockProofs verifies the signature, election proof, ticket, beacon
+// entries and winning PoSt of a block. They only depend on the headers of its
+// ancestors and on the state at the winning PoSt lookback, so they can be
+// verified while the parents of the block are being validated. Blocks whose
+// proofs are valid are remembered, and ValidateBlock doesn't verify them again.
+func (filec *FilecoinEC) ValidateBlockProofs(ctx context.Context, h *types.BlockHeader) error {
+	if _, ok := filec.verifiedProofs.Get(h.Cid()); ok {
+		return nil
+	}
+
+	if err := blockSanityChecks(h); err != nil {
+		return xerrors.Errorf("incoming header failed basic sanity checks: %w", err)
+	}
+
+	baseTs, err := filec.store.LoadTipSet(ctx, types.NewTipSetKey(h.Parents...))
+	if err != nil {
+		return xerrors.Errorf("load parent tipset failed (%s): %w", h.Parents, err)
+	}
+
+	_, lbst, err := stmgr.GetLookbackTipSetForRound(ctx, filec.sm, baseTs, h.Height)
+	if err != nil {
+		return xerrors.Errorf("failed to get lookback tipset for block: %w", err)
+	}
+
+	prevBeacon, err := filec.store.GetLatestBeaconEntry(ctx, baseTs)
+	if err != nil {
+		return xerrors.Errorf("failed to get latest beacon entry: %w", err)
+	}
+
+	waddr, err := stmgr.GetMinerWorkerRaw(ctx, filec.sm, lbst, h.Miner)
+	if err != nil {
+		return xerrors.Errorf("GetMinerWorkerRaw failed: %w", err)
+	}
+
+	if err := consensus.RunAsyncChecks(ctx, filec.proofChecks(ctx, h, baseTs, *prevBeacon, lbst, waddr)); err != nil {
+		return err
+	}
+
+	filec.verifiedProofs.Add(h.Cid(), struct{}{})
+	return nil
+}
+
+// ProofsLookback returns the winning PoSt lookback at the round, the proofs of
+// its blocks are verified against the state that many epochs before.
+func (filec *FilecoinEC) ProofsLookback(ctx context.Context, round abi.ChainEpoch) abi.ChainEpoch {
+	return policy.GetWinningPoStSectorSetLookback(filec.sm.GetNetworkVersion(ctx, round))
+}
+
+func (filec *FilecoinEC) proofChecks(ctx context.Context, h *types.BlockHeader, baseTs *types.TipSet, prevBeacon types.BeaconEntry, lbst cid.Cid, waddr address.Address) []async.ErrorFuture {
+	electionCheck := async.Err(func() error {
+		rBeacon := prevBeacon
+		if len(h.BeaconEntries) != 0 {
+			rBeacon = h.BeaconEntries[len(h.BeaconEntries)-1]
+		}
+		buf := new(bytes.Buffer)
+		if err := h.Miner.MarshalCBOR(buf); err != nil {
+			return xerrors.Errorf("failed to marshal miner address to cbor: %w", err)
+		}
+
+		vrfBase, err := rand.DrawRandomness(rBeacon.Data, crypto.DomainSeparationTag_ElectionProofProduction, h.Height, buf.Bytes())
+		if err != nil {
+			return xerrors.Errorf("could not draw randomness: %w", err)
+		}
+
+		if err := VerifyElectionPoStVRF(ctx, waddr, vrfBase, h.ElectionProof.VRFProof); err != nil {
+			return xerrors.Errorf("validating block election proof failed: %w", err)
+		}
+		return nil
+	})
+
 	blockSigCheck := async.Err(func() error {
 		if err := verifyBlockSignature(ctx, h, waddr); err != nil {
 			return xerrors.Errorf("check block signature failed: %w", err)
@@ -241,7 +322,7 @@ func (filec *FilecoinEC) ValidateBlock(ctx context.Context, b *types.FullBlock)
 		}
 
 		nv := filec.sm.GetNetworkVersion(ctx, h.Height)
-		if err := beacon.ValidateBlockValues(filec.beacon, nv, h, baseTs.Height(), *prevBeacon); err != nil {
+		if err := beacon.ValidateBlockValues(filec.beacon, nv, h, baseTs.Height(), prevBeacon); err != nil {
 			return xerrors.Errorf("failed to validate blocks random beacon values: %w", err)
 		}
 		return nil
@@ -257,7 +338,7 @@ func (filec *FilecoinEC) ValidateBlock(ctx context.Context, b *types.FullBlock)
 			buf.Write(baseTs.MinTicket().VRFProof)
 		}
 
-		beaconBase := *prevBeacon
+		beaconBase := prevBeacon
 		if len(h.BeaconEntries) != 0 {
 			beaconBase = h.BeaconEntries[len(h.BeaconEntries)-1]
 		}
@@ -275,23 +356,20 @@ func (filec *FilecoinEC) ValidateBlock(ctx context.Context, b *types.FullBlock)
 	})
 
 	wproofCheck := async.Err(func() error {
-		if err := filec.VerifyWinningPoStProof(ctx, winPoStNv, h, *prevBeacon, lbst, waddr); err != nil {
+		winPoStNv := filec.sm.GetNetworkVersion(ctx, baseTs.Height())
+		if err := filec.VerifyWinningPoStProof(ctx, winPoStNv, h, prevBeacon, lbst, waddr); err != nil {
 			return xerrors.Errorf("invalid election post: %w", err)
 		}
 		return nil
 	})
 
-	commonChecks := consensus.CommonBlkChecks(ctx, filec.sm, filec.store, b, baseTs)
-	await := append([]async.ErrorFuture{
-		minerCheck,
+	return []async.ErrorFuture{
+		electionCheck,
 		tktsCheck,
 		blockSigCheck,
 		beaconValuesCheck,
 		wproofCheck,
-		winnerCheck,
-	}, commonChecks...)
-
-	return consensus.RunAsyncChecks(ctx, await)
+	}
 }
 
 func blockSanityChecks(h *types.BlockHeader) error {
@@ -32,6 +32,18 @@ type Consensus interface {
 	// the block (signature verifications, VRF checks, message validity, etc.)
 	ValidateBlock(ctx context.Context, b *types.FullBlock) (err error)
 
+	// ValidateBlockProofs verifies the proofs of a block header (signatures, VRFs, etc.)
+	// ahead of ValidateBlock, which doesn't verify them again when they are valid.
+	//
+	// It is called by the syncer while the parents of the block are being validated, once
+	// the tipsets up to ProofsLookback epochs before the block are validated. An error
+	// doesn't make the block invalid, ValidateBlock has the final word.
+	ValidateBlockProofs(ctx context.Context, h *types.BlockHeader) error
+
+	// ProofsLookback returns how many epochs before a round is the state the proofs of its
+	// blocks are verified against.
+	ProofsLookback(ctx context.Context, round abi.ChainEpoch) abi.ChainEpoch
+
 	// IsEpochInConsensusRange returns true if the epoch is "in range" for consensus. That is:
 	// - It's not before finality.
 	// - It's not too far in the future.
@@ -0,0 +1,356 @@
+package eventjournal
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sync"
+
+	"github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log/v2"
+	_ "github.com/mattn/go-sqlite3"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/events"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var log = logging.Logger("eventjournal")
+
+const DBName = "chainjournal.db"
+
+var dbDefs = []string{
+	`CREATE TABLE IF NOT EXISTS journal (
+		version INTEGER PRIMARY KEY AUTOINCREMENT,
+		kind TEXT NOT NULL,
+		revert INTEGER NOT NULL,
+		tipset_key BLOB NOT NULL,
+		height INTEGER NOT NULL,
+		data BLOB NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS journal_tipset_key ON journal (tipset_key)`,
+	`CREATE TABLE IF NOT EXISTS _meta (
+		version UINT64 NOT NULL UNIQUE
+	)`,
+	`INSERT OR IGNORE INTO _meta (version) VALUES (1)`,
+}
+
+const (
+	dbqInsertEntry    = "INSERT INTO journal (kind, revert, tipset_key, height, data) VALUES (?, ?, ?, ?, ?)"
+	dbqLastTipSet     = "SELECT version, data FROM journal WHERE kind = 'tipset' ORDER BY version DESC LIMIT 1"
+	dbqLastApply      = "SELECT MAX(version) FROM journal WHERE tipset_key = ? AND kind = 'tipset' AND revert = 0"
+	dbqAppliedEntries = "SELECT version, data FROM journal WHERE tipset_key = ? AND revert = 0 AND version >= ? ORDER BY version DESC"
+	dbqEntriesSince   = "SELECT version, data FROM journal WHERE version > ? ORDER BY version LIMIT ?"
+)
+
+// MaxPageSize is the maximum number of entries returned by EventsSince.
+const MaxPageSize = 10000
+
+// ChainAPI is the subset of the full node API used to build journal entries.
+type ChainAPI interface {
+	ChainGetPath(ctx context.Context, from, to types.TipSetKey) ([]*api.HeadChange, error)
+	ChainGetParentMessages(context.Context, cid.Cid) ([]api.Message, error)
+	ChainGetParentReceipts(context.Context, cid.Cid) ([]*types.MessageReceipt, error)
+	ChainGetEvents(context.Context, cid.Cid) ([]types.Event, error)
+}
+
+// Journal is a persistent record of head changes, message executions and
+// actor events. It is a tipset observer, see events.Events.Observe. Head
+// changes missed while the journal wasn't observing, e.g. while the node was
+// down, are recorded on the next head change, so that consumers see every
+// tipset applied after the first one recorded, and every revert of them.
+type Journal struct {
+	api ChainAPI
+	db  *sql.DB
+
+	lk sync.Mutex
+	// head is the last applied tipset, empty until the first head change
+	head types.TipSetKey
+}
+
+var _ events.TipSetObserver = (*Journal)(nil)
+
+func NewJournal(path string, a ChainAPI) (*Journal, error) {
+	db, err := sql.Open("sqlite3", path+"?mode=rwc")
+	if err != nil {
+		return nil, xerrors.Errorf("opening chain journal database: %w", err)
+	}
+
+	for _, stmt := range dbDefs {
+		if _, err := db.Exec(stmt); err != nil {
+			_ = db.Close()
+			return nil, xerrors.Errorf("executing sql statement '%s': %w", stmt, err)
+		}
+	}
+
+	j := &Journal{
+		api:  a,
+		db:   db,
+		head: types.EmptyTSK,
+	}
+
+	var (
+		version uint64
+		data    []byte
+	)
+	err = db.QueryRow(dbqLastTipSet).Scan(&version, &data)
+	switch {
+	case err == sql.ErrNoRows:
+	case err != nil:
+		_ = db.Close()
+		return nil, xerrors.Errorf("loading journal head: %w", err)
+	default:
+		e, err := decodeEntry(version, data)
+		if err != nil {
+			_ = db.Close()
+			return nil, err
+		}
+		j.head = e.TipSet
+		if e.Revert {
+			j.head = *e.Parents
+		}
+	}
+
+	return j, nil
+}
+
+func (j *Journal) Close() error {
+	return j.db.Close()
+}
+
+func (j *Journal) Apply(ctx context.Context, from, to *types.TipSet) error {
+	j.lk.Lock()
+	defer j.lk.Unlock()
+
+	if err := j.catchUp(ctx, from); err != nil {
+		return err
+	}
+	return j.applyTipSet(ctx, to)
+}
+
+func (j *Journal) Revert(ctx context.Context, from, to *types.TipSet) error {
+	j.lk.Lock()
+	defer j.lk.Unlock()
+
+	if err := j.catchUp(ctx, from); err != nil {
+		return err
+	}
+	return j.revertTipSet(ctx, from)
+}
+
+// catchUp records the head changes from the journal head to ts, j.lk must be
+// held.
+func (j *Journal) catchUp(ctx context.Context, ts *types.TipSet) error {
+	if j.head == types.EmptyTSK || j.head == ts.Key() {
+		return nil
+	}
+
+	path, err := j.api.ChainGetPath(ctx, j.head, ts.Key())
+	if err != nil {
+		return xerrors.Errorf("getting path from journal head %s to %s: %w", j.head, ts.Key(), err)
+	}
+
+	for _, hc := range path {
+		switch hc.Type {
+		case "revert":
+			err = j.revertTipSet(ctx, hc.Val)
+		case "apply":
+			err = j.applyTipSet(ctx, hc.Val)
+		default:
+			err = xerrors.Errorf("unexpected head change type %q", hc.Type)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (j *Journal) applyTipSet(ctx context.Context, ts *types.TipSet) error {
+	parents := ts.Parents()
+	entries := []api.ChainJournalEntry{{
+		Kind:    api.JournalTipSet,
+		TipSet:  ts.Key(),
+		Height:  ts.Height(),
+		Parents: &parents,
+	}}
+
+	msgs, err := j.api.ChainGetParentMessages(ctx, ts.Cids()[0])
+	if err != nil {
+		return xerrors.Errorf("loading messages executed in %s: %w", ts.Key(), err)
+	}
+	rcts, err := j.api.ChainGetParentReceipts(ctx, ts.Cids()[0])
+	if err != nil {
+		return xerrors.Errorf("loading receipts of %s: %w", ts.Key(), err)
+	}
+	if len(msgs) != len(rcts) {
+		return xerrors.Errorf("got %d messages but %d receipts in %s", len(msgs), len(rcts), ts.Key())
+	}
+
+	for i, msg := range msgs {
+		msgCid := msg.Cid
+		entries = append(entries, api.ChainJournalEntry{
+			Kind:    api.JournalMessage,
+			TipSet:  ts.Key(),
+			Height:  ts.Height(),
+			Message: &msgCid,
+			Receipt: rcts[i],
+		})
+
+		if rcts[i].EventsRoot == nil {
+			continue
+		}
+		evs, err := j.api.ChainGetEvents(ctx, *rcts[i].EventsRoot)
+		if err != nil {
+			return xerrors.Errorf("loading events of message %s: %w", msgCid, err)
+		}
+		for k := range evs {
+			entries = append(entries, api.ChainJournalEntry{
+				Kind:       api.JournalEvent,
+				TipSet:     ts.Key(),
+				Height:     ts.Height(),
+				Message:    &msgCid,
+				Receipt:    rcts[i],
+				Event:      &evs[k],
+				EventIndex: k,
+			})
+		}
+	}
+
+	if err := j.insert(ctx, entries); err != nil {
+		return xerrors.Errorf("recording tipset %s: %w", ts.Key(), err)
+	}
+	j.head = ts.Key()
+	return nil
+}
+
+func (j *Journal) revertTipSet(ctx context.Context, ts *types.TipSet) error {
+	tsk := ts.Key().Bytes()
+
+	var applied sql.NullInt64
+	if err := j.db.QueryRowContext(ctx, dbqLastApply, tsk).Scan(&applied); err != nil {
+		return xerrors.Errorf("looking up tipset %s: %w", ts.Key(), err)
+	}
+	if !applied.Valid {
+		// the tipset was the head when the journal started
+		log.Debugw("reverted tipset not in the journal", "tipset", ts.Key())
+		j.head = ts.Parents()
+		return nil
+	}
+
+	rows, err := j.db.QueryContext(ctx, dbqAppliedEntries, tsk, applied.Int64)
+	if err != nil {
+		return xerrors.Errorf("loading entries of tipset %s: %w", ts.Key(), err)
+	}
+
+	// entries are reverted in the reverse order
+	var reverts []api.ChainJournalEntry
+	for rows.Next() {
+		var (
+			version uint64
+			data    []byte
+		)
+		if err := rows.Scan(&version, &data); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		e, err := decodeEntry(version, data)
+		if err != nil {
+			_ = rows.Close()
+			return err
+		}
+		e.Revert = true
+		e.Reverts = e.Version
+		reverts = append(reverts, e)
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if err := j.insert(ctx, reverts); err != nil {
+		return xerrors.Errorf("recording revert of tipset %s: %w", ts.Key(), err)
+	}
+	j.head = ts.Parents()
+	return nil
+}
+
+func (j *Journal) insert(ctx context.Context, entries []api.ChainJournalEntry) error {
+	tx, err := j.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, dbqInsertEntry)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	for _, e := range entries {
+		// versions are assigned by the database
+		e.Version = 0
+		data, err := json.Marshal(e)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if _, err := stmt.ExecContext(ctx, e.Kind, e.Revert, e.TipSet.Bytes(), int64(e.Height), data); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// EventsSince returns up to limit entries with a version greater than cursor,
+// in order.
+func (j *Journal) EventsSince(ctx context.Context, cursor uint64, limit int) (*api.ChainJournalPage, error) {
+	if limit <= 0 || limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+
+	rows, err := j.db.QueryContext(ctx, dbqEntriesSince, cursor, limit)
+	if err != nil {
+		return nil, xerrors.Errorf("querying journal: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	page := &api.ChainJournalPage{
+		Entries: []api.ChainJournalEntry{},
+		Cursor:  cursor,
+	}
+	for rows.Next() {
+		var (
+			version uint64
+			data    []byte
+		)
+		if err := rows.Scan(&version, &data); err != nil {
+			return nil, err
+		}
+		e, err := decodeEntry(version, data)
+		if err != nil {
+			return nil, err
+		}
+		page.Entries = append(page.Entries, e)
+		page.Cursor = version
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+func decodeEntry(version uint64, data []byte) (api.ChainJournalEntry, error) {
+	var e api.ChainJournalEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return e, xerrors.Errorf("decoding journal entry %d: %w", version, err)
+	}
+	e.Version = version
+	return e, nil
+}
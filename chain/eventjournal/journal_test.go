@@ -0,0 +1,167 @@
+// stm: #unit
+package eventjournal
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+// fakeChain executes one message per tipset, each emitting two events.
+type fakeChain struct {
+	tipsets map[types.TipSetKey]*types.TipSet
+	blocks  map[cid.Cid]*types.TipSet
+}
+
+var eventsRoot = mustCid([]byte("events"))
+
+func mustCid(b []byte) cid.Cid {
+	c, err := abi.CidBuilder.Sum(b)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func newFakeChain() *fakeChain {
+	return &fakeChain{
+		tipsets: map[types.TipSetKey]*types.TipSet{},
+		blocks:  map[cid.Cid]*types.TipSet{},
+	}
+}
+
+func (fc *fakeChain) mk(parent *types.TipSet, nonce uint64) *types.TipSet {
+	ts := mock.TipSet(mock.MkBlock(parent, 1, nonce))
+	fc.tipsets[ts.Key()] = ts
+	fc.blocks[ts.Cids()[0]] = ts
+	return ts
+}
+
+func (fc *fakeChain) ChainGetPath(_ context.Context, from, to types.TipSetKey) ([]*api.HeadChange, error) {
+	revert, apply, err := store.ReorgOps(context.Background(), func(_ context.Context, tsk types.TipSetKey) (*types.TipSet, error) {
+		return fc.tipsets[tsk], nil
+	}, fc.tipsets[from], fc.tipsets[to])
+	if err != nil {
+		return nil, err
+	}
+
+	var path []*api.HeadChange
+	for _, ts := range revert {
+		path = append(path, &api.HeadChange{Type: store.HCRevert, Val: ts})
+	}
+	for i := len(apply) - 1; i >= 0; i-- {
+		path = append(path, &api.HeadChange{Type: store.HCApply, Val: apply[i]})
+	}
+	return path, nil
+}
+
+func (fc *fakeChain) ChainGetParentMessages(_ context.Context, blk cid.Cid) ([]api.Message, error) {
+	ts := fc.blocks[blk]
+	msg := mock.UnsignedMessage(mock.Address(100), mock.Address(101), uint64(ts.Height()))
+	return []api.Message{{Cid: msg.Cid(), Message: msg}}, nil
+}
+
+func (fc *fakeChain) ChainGetParentReceipts(_ context.Context, blk cid.Cid) ([]*types.MessageReceipt, error) {
+	ts := fc.blocks[blk]
+	return []*types.MessageReceipt{{GasUsed: int64(ts.Height()), EventsRoot: &eventsRoot}}, nil
+}
+
+func (fc *fakeChain) ChainGetEvents(_ context.Context, root cid.Cid) ([]types.Event, error) {
+	return []types.Event{{Emitter: 1000}, {Emitter: 1001}}, nil
+}
+
+func TestJournal(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), DBName)
+
+	fc := newFakeChain()
+	gen := fc.mk(nil, 0)
+	a1 := fc.mk(gen, 1)
+	a2 := fc.mk(a1, 2)
+	b2 := fc.mk(a1, 3)
+	b3 := fc.mk(b2, 4)
+	b4 := fc.mk(b3, 5)
+
+	j, err := NewJournal(path, fc)
+	require.NoError(t, err)
+
+	require.NoError(t, j.Apply(ctx, gen, a1))
+	require.NoError(t, j.Apply(ctx, a1, a2))
+	require.NoError(t, j.Revert(ctx, a2, a1))
+	require.NoError(t, j.Apply(ctx, a1, b2))
+	require.NoError(t, j.Close())
+
+	// b3 is missed while the journal is closed, and recorded on the next change
+	j, err = NewJournal(path, fc)
+	require.NoError(t, err)
+	require.Equal(t, b2.Key(), j.head)
+	require.NoError(t, j.Apply(ctx, b3, b4))
+
+	var entries []api.ChainJournalEntry
+	var cursor uint64
+	for {
+		page, err := j.EventsSince(ctx, cursor, 3)
+		require.NoError(t, err)
+		if len(page.Entries) == 0 {
+			require.Equal(t, cursor, page.Cursor)
+			break
+		}
+		entries = append(entries, page.Entries...)
+		cursor = page.Cursor
+	}
+
+	// each tipset has a tipset entry, a message entry and two event entries
+	require.Len(t, entries, 6*4)
+	for i, e := range entries {
+		require.EqualValues(t, i+1, e.Version)
+	}
+
+	kinds := []string{api.JournalTipSet, api.JournalMessage, api.JournalEvent, api.JournalEvent}
+	expect := []struct {
+		ts     *types.TipSet
+		revert bool
+	}{
+		{a1, false},
+		{a2, false},
+		{a2, true},
+		{b2, false},
+		{b3, false},
+		{b4, false},
+	}
+	for i, ex := range expect {
+		for k := 0; k < 4; k++ {
+			e := entries[i*4+k]
+			require.Equal(t, ex.ts.Key(), e.TipSet)
+			require.Equal(t, ex.revert, e.Revert)
+
+			if !ex.revert {
+				require.Equal(t, kinds[k], e.Kind)
+				continue
+			}
+
+			// reverts are recorded in the reverse order
+			require.EqualValues(t, 4+(3-k)+1, e.Reverts)
+			reverted := entries[e.Reverts-1]
+			require.Equal(t, kinds[3-k], e.Kind)
+			require.Equal(t, reverted.Kind, e.Kind)
+			require.Equal(t, reverted.Event, e.Event)
+			require.Equal(t, reverted.EventIndex, e.EventIndex)
+		}
+	}
+
+	require.Equal(t, gen.Key(), *entries[0].Parents)
+	require.EqualValues(t, 1, entries[3].EventIndex)
+	require.EqualValues(t, 1001, entries[3].Event.Emitter)
+	require.EqualValues(t, a1.Height(), entries[1].Receipt.GasUsed)
+	require.NoError(t, j.Close())
+}
@@ -60,7 +60,36 @@ var ddls = []string{
 	`INSERT OR IGNORE INTO _meta (version) VALUES (1)`,
 }
 
-const schemaVersion = 1
+// ddlsV2 adds the watch-list used for retention, the height below which
+// unwatched events were pruned, and the indexes needed to prune events
+// efficiently.
+var ddlsV2 = []string{
+	// configured and added tell whether the entry is in the config, or was
+	// added with the API, retained_from is the height from which its events
+	// are all in the index
+	`CREATE TABLE IF NOT EXISTS event_watch (
+		kind INTEGER NOT NULL,
+		value BLOB NOT NULL,
+		configured INTEGER NOT NULL DEFAULT 0,
+		added INTEGER NOT NULL DEFAULT 0,
+		retained_from INTEGER NOT NULL DEFAULT 0,
+		UNIQUE(kind, value)
+	)`,
+
+	`CREATE TABLE IF NOT EXISTS event_pruned (
+		id INTEGER PRIMARY KEY CHECK (id = 0),
+		height INTEGER NOT NULL
+	)`,
+
+	`CREATE INDEX IF NOT EXISTS event_height ON event (height)`,
+
+	`CREATE INDEX IF NOT EXISTS event_entry_event_id ON event_entry (event_id)`,
+
+	// version 2.
+	`INSERT OR IGNORE INTO _meta (version) VALUES (2)`,
+}
+
+const schemaVersion = 2
 
 const (
 	insertEvent = `INSERT OR IGNORE INTO event
@@ -70,6 +99,9 @@ const (
 	insertEntry = `INSERT OR IGNORE INTO event_entry
 	(event_id, indexed, flags, key, codec, value)
 	VALUES(?, ?, ?, ?, ?, ?)`
+
+	eventExists = `SELECT EXISTS(SELECT 1 FROM event
+	WHERE height=? AND tipset_key_cid=? AND message_cid=? AND event_index=? AND reverted=?)`
 )
 
 type EventIndex struct {
@@ -92,7 +124,7 @@ func NewEventIndex(path string) (*EventIndex, error) {
 	q, err := db.Query("SELECT name FROM sqlite_master WHERE type='table' AND name='_meta';")
 	if err == sql.ErrNoRows || !q.Next() {
 		// empty database, create the schema
-		for _, ddl := range ddls {
+		for _, ddl := range append(ddls, ddlsV2...) {
 			if _, err := db.Exec(ddl); err != nil {
 				_ = db.Close()
 				return nil, xerrors.Errorf("exec ddl %q: %w", ddl, err)
@@ -111,6 +143,16 @@ func NewEventIndex(path string) (*EventIndex, error) {
 			_ = db.Close()
 			return nil, xerrors.Errorf("invalid database version: no version found")
 		}
+		if version == 1 {
+			// upgrade from version 1
+			for _, ddl := range ddlsV2 {
+				if _, err := db.Exec(ddl); err != nil {
+					_ = db.Close()
+					return nil, xerrors.Errorf("exec ddl %q: %w", ddl, err)
+				}
+			}
+			version = 2
+		}
 		if version != schemaVersion {
 			_ = db.Close()
 			return nil, xerrors.Errorf("invalid database version: got %d, expected %d", version, schemaVersion)
@@ -130,6 +172,13 @@ func (ei *EventIndex) Close() error {
 }
 
 func (ei *EventIndex) CollectEvents(ctx context.Context, te *TipSetEvents, revert bool, resolver func(ctx context.Context, emitter abi.ActorID, ts *types.TipSet) (address.Address, bool)) error {
+	return ei.collectEvents(ctx, te, revert, resolver, nil)
+}
+
+// collectEvents indexes the events of the tipset. If keep is not nil, only the
+// events it returns true for are indexed, and events already present in the
+// index are skipped.
+func (ei *EventIndex) collectEvents(ctx context.Context, te *TipSetEvents, revert bool, resolver func(ctx context.Context, emitter abi.ActorID, ts *types.TipSet) (address.Address, bool), keep func(addr address.Address, ev *types.Event) bool) error {
 	// cache of lookups between actor id and f4 address
 
 	addressLookups := make(map[abi.ActorID]address.Address)
@@ -151,6 +200,10 @@ func (ei *EventIndex) CollectEvents(ctx context.Context, te *TipSetEvents, rever
 	if err != nil {
 		return xerrors.Errorf("prepare insert entry: %w", err)
 	}
+	stmtExists, err := tx.Prepare(eventExists)
+	if err != nil {
+		return xerrors.Errorf("prepare event exists: %w", err)
+	}
 
 	for msgIdx, em := range ems {
 		for evIdx, ev := range em.Events() {
@@ -170,6 +223,20 @@ func (ei *EventIndex) CollectEvents(ctx context.Context, te *TipSetEvents, rever
 				return xerrors.Errorf("tipset key cid: %w", err)
 			}
 
+			if keep != nil {
+				if !keep(addr, ev) {
+					continue
+				}
+
+				var exists bool
+				if err := stmtExists.QueryRow(te.msgTs.Height(), tsKeyCid.Bytes(), em.Message().Cid().Bytes(), evIdx, revert).Scan(&exists); err != nil {
+					return xerrors.Errorf("checking for existing event: %w", err)
+				}
+				if exists {
+					continue
+				}
+			}
+
 			res, err := stmtEvent.Exec(
 				te.msgTs.Height(),          // height
 				te.msgTs.Key().Bytes(),     // tipset_key
@@ -214,6 +281,12 @@ func (ei *EventIndex) CollectEvents(ctx context.Context, te *TipSetEvents, rever
 
 // PrefillFilter fills a filter's collection of events from the historic index
 func (ei *EventIndex) PrefillFilter(ctx context.Context, f *EventFilter) error {
+	if f.tipsetCid == cid.Undef {
+		if err := ei.checkRetained(ctx, f); err != nil {
+			return err
+		}
+	}
+
 	clauses := []string{}
 	values := []any{}
 	joins := []string{}
@@ -0,0 +1,334 @@
+package filter
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// kinds of watch-list entries
+const (
+	watchKindAddress = 1
+	watchKindTopic   = 2
+)
+
+const (
+	// entries which weren't watched yet are only retained from the height
+	// below which events have been pruned
+	upsertWatch = `INSERT INTO event_watch (kind, value, configured, added, retained_from)
+		VALUES(?, ?, ?, ?, (SELECT COALESCE(max(height), 0) FROM event_pruned))
+		ON CONFLICT(kind, value) DO UPDATE SET configured=max(configured, excluded.configured), added=max(added, excluded.added)`
+	deleteWatch     = `DELETE FROM event_watch WHERE kind=? AND value=?`
+	clearConfigured = `UPDATE event_watch SET configured=0`
+	deleteUnused    = `DELETE FROM event_watch WHERE configured=0 AND added=0`
+	selectWatch     = `SELECT kind, value, retained_from FROM event_watch`
+	updateRetained  = `UPDATE event_watch SET retained_from=min(retained_from, ?3) WHERE kind=?1 AND value=?2`
+
+	selectPruned = `SELECT COALESCE(max(height), 0) FROM event_pruned`
+	updatePruned = `INSERT INTO event_pruned (id, height) VALUES(0, ?)
+		ON CONFLICT(id) DO UPDATE SET height=max(height, excluded.height)`
+
+	// events that are neither emitted by a watched address nor carry a
+	// watched topic in one of their indexed entries
+	unwatchedEvents = `SELECT id FROM event WHERE height<?
+		AND emitter_addr NOT IN (SELECT value FROM event_watch WHERE kind=1)
+		AND NOT EXISTS (SELECT 1 FROM event_entry JOIN event_watch ON event_watch.kind=2 AND event_entry.value=event_watch.value
+			WHERE event_entry.event_id=event.id AND event_entry.indexed=1)`
+
+	pruneEntries = `DELETE FROM event_entry WHERE event_id IN (` + unwatchedEvents + `)`
+	pruneEvents  = `DELETE FROM event WHERE id IN (` + unwatchedEvents + `)`
+)
+
+type watchEntry struct {
+	kind  int
+	value []byte
+	// all the events of the entry from this height are in the index
+	retainedFrom abi.ChainEpoch
+}
+
+func (ei *EventIndex) watchEntries(ctx context.Context) ([]watchEntry, error) {
+	rows, err := ei.db.QueryContext(ctx, selectWatch)
+	if err != nil {
+		return nil, xerrors.Errorf("query watch-list: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	var out []watchEntry
+	for rows.Next() {
+		var we watchEntry
+		if err := rows.Scan(&we.kind, &we.value, &we.retainedFrom); err != nil {
+			return nil, xerrors.Errorf("read watch-list row: %w", err)
+		}
+		out = append(out, we)
+	}
+
+	return out, rows.Err()
+}
+
+// WatchList returns the emitter addresses and topics whose events are
+// retained indefinitely.
+func (ei *EventIndex) WatchList(ctx context.Context) ([]address.Address, [][]byte, error) {
+	entries, err := ei.watchEntries(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var addrs []address.Address
+	var topics [][]byte
+	for _, we := range entries {
+		switch we.kind {
+		case watchKindAddress:
+			addr, err := address.NewFromBytes(we.value)
+			if err != nil {
+				return nil, nil, xerrors.Errorf("parse watched address: %w", err)
+			}
+			addrs = append(addrs, addr)
+		case watchKindTopic:
+			topics = append(topics, we.value)
+		}
+	}
+
+	return addrs, topics, nil
+}
+
+// Watch adds emitter addresses and topics to the watch-list.
+func (ei *EventIndex) Watch(ctx context.Context, addrs []address.Address, topics [][]byte) error {
+	return ei.updateWatchList(ctx, func(tx *sql.Tx) error {
+		return execWatchList(tx, upsertWatch, addrs, topics, false, true)
+	})
+}
+
+// WatchConfigured makes the emitter addresses and topics the configured part
+// of the watch-list. Configured entries which aren't configured anymore are
+// removed, unless they were also added with Watch.
+func (ei *EventIndex) WatchConfigured(ctx context.Context, addrs []address.Address, topics [][]byte) error {
+	return ei.updateWatchList(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.Exec(clearConfigured); err != nil {
+			return xerrors.Errorf("clear configured watch-list: %w", err)
+		}
+		if err := execWatchList(tx, upsertWatch, addrs, topics, true, false); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(deleteUnused); err != nil {
+			return xerrors.Errorf("delete unconfigured watch-list entries: %w", err)
+		}
+		return nil
+	})
+}
+
+// Unwatch removes emitter addresses and topics from the watch-list. Their
+// events become eligible for pruning.
+func (ei *EventIndex) Unwatch(ctx context.Context, addrs []address.Address, topics [][]byte) error {
+	return ei.updateWatchList(ctx, func(tx *sql.Tx) error {
+		return execWatchList(tx, deleteWatch, addrs, topics)
+	})
+}
+
+// setRetainedFrom records that all the events of the watched addresses and
+// topics from the height are in the index.
+func (ei *EventIndex) setRetainedFrom(ctx context.Context, addrs []address.Address, topics [][]byte, height abi.ChainEpoch) error {
+	return ei.updateWatchList(ctx, func(tx *sql.Tx) error {
+		return execWatchList(tx, updateRetained, addrs, topics, height)
+	})
+}
+
+func (ei *EventIndex) updateWatchList(ctx context.Context, update func(tx *sql.Tx) error) error {
+	tx, err := ei.db.BeginTx(ctx, nil)
+	if err != nil {
+		return xerrors.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if err := update(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return xerrors.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// execWatchList runs the query with the kind and value of each address and
+// topic, followed by the args.
+func execWatchList(tx *sql.Tx, query string, addrs []address.Address, topics [][]byte, args ...interface{}) error {
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return xerrors.Errorf("prepare watch-list update: %w", err)
+	}
+
+	for _, addr := range addrs {
+		if _, err := stmt.Exec(append([]interface{}{watchKindAddress, addr.Bytes()}, args...)...); err != nil {
+			return xerrors.Errorf("update watched address %s: %w", addr, err)
+		}
+	}
+	for _, topic := range topics {
+		if _, err := stmt.Exec(append([]interface{}{watchKindTopic, topic}, args...)...); err != nil {
+			return xerrors.Errorf("update watched topic %x: %w", topic, err)
+		}
+	}
+	return nil
+}
+
+// checkRetained returns an error when some of the events matching the filter
+// may have been pruned, instead of returning incomplete results. They are all
+// retained when the filter only matches the events of watched addresses, or
+// of watched topics, retained from the minimum height of the filter.
+func (ei *EventIndex) checkRetained(ctx context.Context, f *EventFilter) error {
+	var pruned abi.ChainEpoch
+	if err := ei.db.QueryRowContext(ctx, selectPruned).Scan(&pruned); err != nil {
+		return xerrors.Errorf("query pruned height: %w", err)
+	}
+
+	minHeight := f.minHeight
+	if minHeight < 0 {
+		minHeight = 0
+	}
+	if minHeight >= pruned {
+		return nil
+	}
+
+	entries, err := ei.watchEntries(ctx)
+	if err != nil {
+		return err
+	}
+	retained := func(kind int, value []byte) bool {
+		for _, we := range entries {
+			if we.kind == kind && bytes.Equal(we.value, value) {
+				return we.retainedFrom <= minHeight
+			}
+		}
+		return false
+	}
+
+	if len(f.addresses) > 0 {
+		all := true
+		for _, addr := range f.addresses {
+			all = all && retained(watchKindAddress, addr.Bytes())
+		}
+		if all {
+			return nil
+		}
+	}
+	for _, vals := range f.keys {
+		if len(vals) == 0 {
+			continue
+		}
+		all := true
+		for _, val := range vals {
+			all = all && retained(watchKindTopic, val)
+		}
+		if all {
+			return nil
+		}
+	}
+
+	return xerrors.Errorf("events emitted before height %d were pruned from the event index, except for the events of watched addresses and topics", pruned)
+}
+
+// PruneEvents deletes the events emitted before the given height, except
+// for the events of watched addresses and topics. It returns the number of
+// events deleted.
+func (ei *EventIndex) PruneEvents(ctx context.Context, before abi.ChainEpoch) (int64, error) {
+	tx, err := ei.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, xerrors.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if _, err := tx.ExecContext(ctx, pruneEntries, before); err != nil {
+		return 0, xerrors.Errorf("prune event entries: %w", err)
+	}
+
+	// deleting the entries of unwatched events doesn't change which events
+	// match, events with watched topics still have their entries
+	res, err := tx.ExecContext(ctx, pruneEvents, before)
+	if err != nil {
+		return 0, xerrors.Errorf("prune events: %w", err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, xerrors.Errorf("get pruned event count: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, updatePruned, before); err != nil {
+		return 0, xerrors.Errorf("update pruned height: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, xerrors.Errorf("commit transaction: %w", err)
+	}
+	return deleted, nil
+}
+
+// Backfill indexes the events emitted by the given addresses, or carrying
+// one of the given topics, in the tipsets from minHeight up to and including
+// the tipset executed by ts. Events already in the index are skipped. Once
+// done, the events of the addresses and topics from minHeight are known to be
+// in the index.
+func (m *EventFilterManager) Backfill(ctx context.Context, ts *types.TipSet, minHeight abi.ChainEpoch, addrs []address.Address, topics [][]byte) error {
+	if m.EventIndex == nil {
+		return xerrors.Errorf("historic event index disabled")
+	}
+	if len(addrs) == 0 && len(topics) == 0 {
+		return nil
+	}
+
+	watched := make(map[address.Address]struct{}, len(addrs))
+	for _, addr := range addrs {
+		watched[addr] = struct{}{}
+	}
+
+	keep := func(addr address.Address, ev *types.Event) bool {
+		if _, ok := watched[addr]; ok {
+			return true
+		}
+		for _, entry := range ev.Entries {
+			if !isIndexedValue(entry.Flags) {
+				continue
+			}
+			for _, topic := range topics {
+				if bytes.Equal(entry.Value, topic) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	rctTs := ts
+	for rctTs.Height() > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		msgTs, err := m.ChainStore.GetTipSetFromKey(ctx, rctTs.Parents())
+		if err != nil {
+			return xerrors.Errorf("load parent tipset at height %d: %w", rctTs.Height(), err)
+		}
+		if msgTs.Height() < minHeight {
+			break
+		}
+
+		te := &TipSetEvents{
+			msgTs: msgTs,
+			rctTs: rctTs,
+			load:  m.loadExecutedMessages,
+		}
+		if err := m.EventIndex.collectEvents(ctx, te, false, m.AddressResolver, keep); err != nil {
+			return xerrors.Errorf("backfill events at height %d: %w", msgTs.Height(), err)
+		}
+
+		rctTs = msgTs
+	}
+
+	return m.EventIndex.setRetainedFrom(ctx, addrs, topics, minHeight)
+}
@@ -0,0 +1,141 @@
+package filter
+
+import (
+	"context"
+	pseudo "math/rand"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+func TestEventIndexRetention(t *testing.T) {
+	ctx := context.Background()
+	rng := pseudo.New(pseudo.NewSource(299792458))
+
+	watchedAddr := randomF4Addr(t, rng)
+	otherAddr := randomF4Addr(t, rng)
+	watchedTopic := randomBytes(32, rng)
+
+	addrMap := addressMap{}
+	addrMap.add(abi.ActorID(1), watchedAddr)
+	addrMap.add(abi.ActorID(2), otherAddr)
+
+	st := newStore()
+	tipSetEvents := func(h abi.ChainEpoch, events ...*types.Event) *TipSetEvents {
+		return buildTipSetEvents(t, rng, h, executedMessage{
+			msg: fakeMessage(randomF4Addr(t, rng), randomF4Addr(t, rng)),
+			rct: fakeReceipt(t, rng, st, events),
+			evs: events,
+		})
+	}
+
+	fromWatched := fakeEvent(1, []kv{{k: "t1", v: randomBytes(32, rng)}}, nil)
+	withTopic := fakeEvent(2, []kv{{k: "t1", v: watchedTopic}}, nil)
+	unwatched := fakeEvent(2, []kv{{k: "t1", v: randomBytes(32, rng)}}, []kv{{k: "d", v: watchedTopic}})
+
+	old := tipSetEvents(100, fromWatched, withTopic, unwatched)
+	recent := tipSetEvents(200, unwatched)
+
+	ei, err := NewEventIndex(filepath.Join(t.TempDir(), "actorevents.db"))
+	require.NoError(t, err)
+	defer ei.Close() //nolint:errcheck
+
+	for _, te := range []*TipSetEvents{old, recent} {
+		require.NoError(t, ei.CollectEvents(ctx, te, false, addrMap.ResolveAddress))
+	}
+
+	require.NoError(t, ei.Watch(ctx, []address.Address{watchedAddr}, [][]byte{watchedTopic}))
+	addrs, topics, err := ei.WatchList(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []address.Address{watchedAddr}, addrs)
+	require.Equal(t, [][]byte{watchedTopic}, topics)
+
+	prefill := func(minHeight abi.ChainEpoch, addresses []address.Address, keys map[string][][]byte) ([]*CollectedEvent, error) {
+		f := &EventFilter{minHeight: minHeight, maxHeight: -1, addresses: addresses, keys: keys}
+		if err := ei.PrefillFilter(ctx, f); err != nil {
+			return nil, err
+		}
+		return f.TakeCollectedEvents(ctx), nil
+	}
+	count := func() int {
+		var n int
+		require.NoError(t, ei.db.QueryRow("SELECT count(*) FROM event").Scan(&n))
+		return n
+	}
+
+	ces, err := prefill(0, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, ces, 4)
+
+	// only the old unwatched event is pruned, unindexed entries matching a
+	// watched topic don't count
+	deleted, err := ei.PruneEvents(ctx, 150)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, deleted)
+	require.Equal(t, 3, count())
+
+	// queries for the pruned range fail, unless they only match the events
+	// of watched addresses or topics
+	_, err = prefill(0, nil, nil)
+	require.ErrorContains(t, err, "pruned")
+	_, err = prefill(0, []address.Address{watchedAddr, otherAddr}, nil)
+	require.ErrorContains(t, err, "pruned")
+
+	ces, err = prefill(0, []address.Address{watchedAddr}, nil)
+	require.NoError(t, err)
+	require.Len(t, ces, 1)
+	ces, err = prefill(0, nil, map[string][][]byte{"t1": {watchedTopic}})
+	require.NoError(t, err)
+	require.Len(t, ces, 1)
+	ces, err = prefill(150, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, ces, 1)
+
+	// an address watched after pruning is only retained from then on, until
+	// its events are backfilled
+	require.NoError(t, ei.Watch(ctx, []address.Address{otherAddr}, nil))
+	_, err = prefill(100, []address.Address{otherAddr}, nil)
+	require.ErrorContains(t, err, "pruned")
+
+	fromOther := func(addr address.Address, _ *types.Event) bool { return addr == otherAddr }
+	require.NoError(t, ei.collectEvents(ctx, old, false, addrMap.ResolveAddress, fromOther))
+	require.NoError(t, ei.setRetainedFrom(ctx, []address.Address{otherAddr}, nil, 100))
+	require.Equal(t, 4, count())
+
+	ces, err = prefill(100, []address.Address{otherAddr}, nil)
+	require.NoError(t, err)
+	require.Len(t, ces, 3)
+
+	// entries removed from the config stop being watched, unless they were
+	// added with the API
+	require.NoError(t, ei.WatchConfigured(ctx, []address.Address{watchedAddr}, nil))
+	require.NoError(t, ei.WatchConfigured(ctx, nil, nil))
+	addrs, _, err = ei.WatchList(ctx)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []address.Address{watchedAddr, otherAddr}, addrs)
+
+	require.NoError(t, ei.Unwatch(ctx, []address.Address{watchedAddr}, [][]byte{watchedTopic}))
+	require.NoError(t, ei.WatchConfigured(ctx, []address.Address{watchedAddr}, nil))
+	addrs, _, err = ei.WatchList(ctx)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []address.Address{watchedAddr, otherAddr}, addrs)
+
+	require.NoError(t, ei.WatchConfigured(ctx, nil, nil))
+	addrs, topics, err = ei.WatchList(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []address.Address{otherAddr}, addrs)
+	require.Empty(t, topics)
+
+	// the event of the unwatched address goes, the events of the other address
+	// stay, whatever their topics
+	deleted, err = ei.PruneEvents(ctx, 150)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, deleted)
+	require.Equal(t, 3, count())
+}
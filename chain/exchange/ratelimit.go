@@ -0,0 +1,150 @@
+package exchange
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"golang.org/x/time/rate"
+
+	"github.com/filecoin-project/lotus/metrics"
+)
+
+// maxTrackedPeers bounds the number of per-peer rate limiters kept in memory.
+const maxTrackedPeers = 4096
+
+// throttledPeersWindow is the period over which the throttled peers are
+// counted for the metrics.ChainExchangeThrottledPeers gauge.
+const throttledPeersWindow = time.Minute
+
+// Request outcomes, recorded with the metrics.ChainExchangeOutcome tag.
+const (
+	OutcomeServed      = "served"
+	OutcomeTruncated   = "truncated"
+	OutcomeRateLimited = "rate_limited"
+)
+
+// ServerLimits configures the per-peer rate limiting of the server. The
+// zero value doesn't limit anything.
+type ServerLimits struct {
+	// RequestsPerSecond is the number of requests a peer can make per
+	// second, with bursts of up to RequestBurst requests. 0 disables the
+	// limit.
+	RequestsPerSecond float64
+	RequestBurst      int
+
+	// TipsetsPerSecond is the number of tipsets a peer can request per
+	// second, with bursts of up to TipsetBurst tipsets. Requests exceeding
+	// the quota are served partially. 0 disables the quota.
+	TipsetsPerSecond float64
+	TipsetBurst      int
+
+	// MaxRequestLength caps the length of the requests, the longer requests
+	// are served partially. 0 means MaxRequestLength.
+	MaxRequestLength uint64
+}
+
+type peerLimiter struct {
+	requests *rate.Limiter
+	tipsets  *rate.Limiter
+}
+
+// serverLimiter applies the ServerLimits to the peers making requests.
+type serverLimiter struct {
+	limits ServerLimits
+
+	lk        sync.Mutex
+	peers     *lru.Cache[peer.ID, *peerLimiter]
+	throttled map[peer.ID]time.Time
+}
+
+func newServerLimiter(limits ServerLimits) *serverLimiter {
+	peers, _ := lru.New[peer.ID, *peerLimiter](maxTrackedPeers)
+
+	if limits.RequestBurst < int(limits.RequestsPerSecond) {
+		limits.RequestBurst = int(limits.RequestsPerSecond)
+	}
+	if limits.RequestBurst < 1 {
+		limits.RequestBurst = 1
+	}
+	if limits.TipsetBurst < int(limits.TipsetsPerSecond) {
+		limits.TipsetBurst = int(limits.TipsetsPerSecond)
+	}
+	if limits.TipsetBurst < 1 {
+		limits.TipsetBurst = 1
+	}
+	if limits.MaxRequestLength == 0 || limits.MaxRequestLength > MaxRequestLength {
+		limits.MaxRequestLength = MaxRequestLength
+	}
+
+	return &serverLimiter{
+		limits:    limits,
+		peers:     peers,
+		throttled: map[peer.ID]time.Time{},
+	}
+}
+
+func limitOf(perSecond float64) rate.Limit {
+	if perSecond <= 0 {
+		return rate.Inf
+	}
+	return rate.Limit(perSecond)
+}
+
+// allow takes a request of the given length from the peer out of its quotas,
+// and returns the length the peer is allowed, 0 when the peer is throttled.
+func (l *serverLimiter) allow(pid peer.ID, length uint64) uint64 {
+	now := time.Now()
+
+	l.lk.Lock()
+	defer l.lk.Unlock()
+
+	pl, ok := l.peers.Get(pid)
+	if !ok {
+		pl = &peerLimiter{
+			requests: rate.NewLimiter(limitOf(l.limits.RequestsPerSecond), l.limits.RequestBurst),
+			tipsets:  rate.NewLimiter(limitOf(l.limits.TipsetsPerSecond), l.limits.TipsetBurst),
+		}
+		l.peers.Add(pid, pl)
+	}
+
+	if length > l.limits.MaxRequestLength {
+		length = l.limits.MaxRequestLength
+	}
+
+	allowed := uint64(0)
+	if pl.requests.AllowN(now, 1) {
+		// serve as much of the request as the tipset quota of the peer allows
+		for n := length; n > 0; n /= 2 {
+			if pl.tipsets.AllowN(now, int(n)) {
+				allowed = n
+				break
+			}
+		}
+	}
+
+	if allowed == 0 {
+		if _, ok := l.throttled[pid]; !ok {
+			log.Infow("throttling chain exchange requests", "peer", pid)
+		}
+		l.throttled[pid] = now
+	}
+
+	for p, at := range l.throttled {
+		if now.Sub(at) > throttledPeersWindow {
+			delete(l.throttled, p)
+		}
+	}
+	stats.Record(context.Background(), metrics.ChainExchangeThrottledPeers.M(int64(len(l.throttled))))
+
+	return allowed
+}
+
+func recordOutcome(outcome string) {
+	ctx, _ := tag.New(context.Background(), tag.Upsert(metrics.ChainExchangeOutcome, outcome))
+	stats.Record(ctx, metrics.ChainExchangeRequests.M(1))
+}
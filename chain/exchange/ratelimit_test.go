@@ -0,0 +1,48 @@
+// stm: #unit
+package exchange
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerLimiter(t *testing.T) {
+	a, b := peer.ID("peer-a"), peer.ID("peer-b")
+
+	t.Run("requests", func(t *testing.T) {
+		l := newServerLimiter(ServerLimits{RequestsPerSecond: 0.001, RequestBurst: 2})
+
+		require.Equal(t, uint64(10), l.allow(a, 10))
+		require.Equal(t, uint64(10), l.allow(a, 10))
+		require.Zero(t, l.allow(a, 10))
+		require.Len(t, l.throttled, 1)
+
+		// the peers are limited individually
+		require.Equal(t, uint64(10), l.allow(b, 10))
+	})
+
+	t.Run("tipsets", func(t *testing.T) {
+		l := newServerLimiter(ServerLimits{TipsetsPerSecond: 0.001, TipsetBurst: 100})
+
+		require.Equal(t, uint64(60), l.allow(a, 60))
+		// 40 tipsets are left in the quota
+		require.Equal(t, uint64(30), l.allow(a, 60))
+		require.Equal(t, uint64(7), l.allow(a, 7))
+		require.Equal(t, uint64(3), l.allow(a, 3))
+		require.Zero(t, l.allow(a, 1))
+		require.Len(t, l.throttled, 1)
+	})
+
+	t.Run("max length", func(t *testing.T) {
+		l := newServerLimiter(ServerLimits{MaxRequestLength: 50})
+
+		require.Equal(t, uint64(50), l.allow(a, 900))
+		require.Equal(t, uint64(20), l.allow(a, 20))
+		require.Empty(t, l.throttled)
+
+		l = newServerLimiter(ServerLimits{MaxRequestLength: 10000})
+		require.Equal(t, MaxRequestLength, l.allow(a, 10000))
+	})
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/ipfs/go-cid"
 	inet "github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
 	"go.opencensus.io/trace"
 	"golang.org/x/xerrors"
 
@@ -21,6 +22,9 @@ import (
 // libp2p ChainExchange protocol.
 type server struct {
 	cs *store.ChainStore
+
+	// limiter is nil when the requests aren't rate limited
+	limiter *serverLimiter
 }
 
 var _ Server = (*server)(nil)
@@ -33,6 +37,15 @@ func NewServer(cs *store.ChainStore) Server {
 	}
 }
 
+// NewLimitedServer creates a new libp2p-based exchange.Server, rate limiting
+// the requests of each peer with the given limits.
+func NewLimitedServer(cs *store.ChainStore, limits ServerLimits) Server {
+	return &server{
+		cs:      cs,
+		limiter: newServerLimiter(limits),
+	}
+}
+
 // HandleStream implements Server.HandleStream. Refer to the godocs there.
 func (s *server) HandleStream(stream inet.Stream) {
 	ctx, span := trace.StartSpan(context.Background(), "chainxchg.HandleStream")
@@ -48,7 +61,7 @@ func (s *server) HandleStream(stream inet.Stream) {
 	log.Debugw("block sync request",
 		"start", req.Head, "len", req.Length)
 
-	resp, err := s.processRequest(ctx, &req)
+	resp, err := s.processRequest(ctx, stream.Conn().RemotePeer(), &req)
 	if err != nil {
 		log.Warn("failed to process request: ", err)
 		return
@@ -70,7 +83,7 @@ func (s *server) HandleStream(stream inet.Stream) {
 
 // Validate and service the request. We return either a protocol
 // response or an internal error.
-func (s *server) processRequest(ctx context.Context, req *Request) (*Response, error) {
+func (s *server) processRequest(ctx context.Context, pid peer.ID, req *Request) (*Response, error) {
 	validReq, errResponse := validateRequest(ctx, req)
 	if errResponse != nil {
 		// The request did not pass validation, return the response
@@ -78,7 +91,36 @@ func (s *server) processRequest(ctx context.Context, req *Request) (*Response, e
 		return errResponse, nil
 	}
 
-	return s.serviceRequest(ctx, validReq)
+	var truncated bool
+	if s.limiter != nil {
+		allowed := s.limiter.allow(pid, validReq.length)
+		if allowed == 0 {
+			recordOutcome(OutcomeRateLimited)
+			return &Response{
+				Status:       GoAway,
+				ErrorMessage: "rate limited",
+			}, nil
+		}
+
+		truncated = allowed < validReq.length
+		validReq.length = allowed
+	}
+
+	resp, err := s.serviceRequest(ctx, validReq)
+	if err != nil {
+		return nil, err
+	}
+
+	if !truncated {
+		recordOutcome(OutcomeServed)
+		return resp, nil
+	}
+
+	if resp.Status == Ok {
+		resp.Status = Partial
+	}
+	recordOutcome(OutcomeTruncated)
+	return resp, nil
 }
 
 // Validate request. We either return a `validatedRequest`, or an error
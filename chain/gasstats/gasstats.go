@@ -0,0 +1,373 @@
+// Package gasstats maintains rolling statistics of the gas used by the messages
+// executed on chain, per actor code and method.
+package gasstats
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+
+	"github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log/v2"
+	_ "github.com/mattn/go-sqlite3"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/events"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/vm"
+)
+
+var log = logging.Logger("gasstats")
+
+const DBName = "gasstats.db"
+
+// DefaultRetention is the number of epochs of statistics kept by default,
+// about a week.
+const DefaultRetention = abi.ChainEpoch(20160)
+
+var dbDefs = []string{
+	`CREATE TABLE IF NOT EXISTS gas_stats (
+		tipset_key BLOB NOT NULL,
+		height INTEGER NOT NULL,
+		actor_code TEXT NOT NULL,
+		method INTEGER NOT NULL,
+		messages INTEGER NOT NULL,
+		failed INTEGER NOT NULL,
+		gas_used INTEGER NOT NULL,
+		min_gas_used INTEGER NOT NULL,
+		max_gas_used INTEGER NOT NULL,
+		gas_limit INTEGER NOT NULL,
+		base_fee_burn TEXT NOT NULL,
+		over_estimation_burn TEXT NOT NULL,
+		miner_tip TEXT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS gas_stats_height ON gas_stats (height)`,
+	`CREATE INDEX IF NOT EXISTS gas_stats_tipset_key ON gas_stats (tipset_key)`,
+	`CREATE TABLE IF NOT EXISTS _meta (
+		version UINT64 NOT NULL UNIQUE
+	)`,
+	`INSERT OR IGNORE INTO _meta (version) VALUES (1)`,
+}
+
+const (
+	dbqInsertStats = `INSERT INTO gas_stats (tipset_key, height, actor_code, method, messages, failed, gas_used, min_gas_used, max_gas_used,
+		gas_limit, base_fee_burn, over_estimation_burn, miner_tip) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	dbqDeleteTipSet = "DELETE FROM gas_stats WHERE tipset_key = ?"
+	dbqPrune        = "DELETE FROM gas_stats WHERE height < ?"
+	dbqSelectRange  = `SELECT tipset_key, height, actor_code, method, messages, failed, gas_used, min_gas_used, max_gas_used,
+		gas_limit, base_fee_burn, over_estimation_burn, miner_tip FROM gas_stats WHERE height >= ? AND height <= ?`
+)
+
+// ChainAPI is the subset of the full node API used to compute the statistics.
+type ChainAPI interface {
+	ChainGetTipSet(context.Context, types.TipSetKey) (*types.TipSet, error)
+	ChainGetParentMessages(context.Context, cid.Cid) ([]api.Message, error)
+	ChainGetParentReceipts(context.Context, cid.Cid) ([]*types.MessageReceipt, error)
+	StateGetActor(context.Context, address.Address, types.TipSetKey) (*types.Actor, error)
+}
+
+// MethodNamer returns the name of a method of an actor.
+type MethodNamer func(code cid.Cid, method abi.MethodNum) string
+
+type statsKey struct {
+	code   string
+	method abi.MethodNum
+}
+
+// Stats is a tipset observer, see events.Events.Observe, recording the gas used
+// by the messages executed in the parents of the applied tipsets. Tipsets
+// applied while the node wasn't running are missing from the statistics.
+type Stats struct {
+	api       ChainAPI
+	db        *sql.DB
+	retention abi.ChainEpoch
+	names     MethodNamer
+
+	lk sync.Mutex
+}
+
+var _ events.TipSetObserver = (*Stats)(nil)
+
+func NewStats(path string, a ChainAPI, retention abi.ChainEpoch, names MethodNamer) (*Stats, error) {
+	if retention <= 0 {
+		retention = DefaultRetention
+	}
+
+	db, err := sql.Open("sqlite3", path+"?mode=rwc")
+	if err != nil {
+		return nil, xerrors.Errorf("opening gas stats database: %w", err)
+	}
+
+	for _, stmt := range dbDefs {
+		if _, err := db.Exec(stmt); err != nil {
+			_ = db.Close()
+			return nil, xerrors.Errorf("executing sql statement '%s': %w", stmt, err)
+		}
+	}
+
+	return &Stats{
+		api:       a,
+		db:        db,
+		retention: retention,
+		names:     names,
+	}, nil
+}
+
+func (s *Stats) Close() error {
+	return s.db.Close()
+}
+
+func (s *Stats) Apply(ctx context.Context, from, to *types.TipSet) error {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	stats, err := s.compute(ctx, to)
+	if err != nil {
+		return xerrors.Errorf("computing gas stats of %s: %w", to.Key(), err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	tsk := to.Key().Bytes()
+	if _, err := tx.ExecContext(ctx, dbqDeleteTipSet, tsk); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	for key, st := range stats {
+		if _, err := tx.ExecContext(ctx, dbqInsertStats, tsk, int64(to.Height()), key.code, uint64(key.method),
+			st.Messages, st.Failed, st.GasUsed, st.MinGasUsed, st.MaxGasUsed, st.GasLimit,
+			st.BaseFeeBurn.String(), st.OverEstimationBurn.String(), st.MinerTip.String()); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, dbqPrune, int64(to.Height()-s.retention)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Stats) Revert(ctx context.Context, from, to *types.TipSet) error {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	if _, err := s.db.ExecContext(ctx, dbqDeleteTipSet, from.Key().Bytes()); err != nil {
+		return xerrors.Errorf("reverting gas stats of %s: %w", from.Key(), err)
+	}
+	return nil
+}
+
+// compute aggregates the gas used by the messages executed on top of the
+// parent of ts.
+func (s *Stats) compute(ctx context.Context, ts *types.TipSet) (map[statsKey]*api.GasStats, error) {
+	msgs, err := s.api.ChainGetParentMessages(ctx, ts.Cids()[0])
+	if err != nil {
+		return nil, xerrors.Errorf("loading messages: %w", err)
+	}
+	rcts, err := s.api.ChainGetParentReceipts(ctx, ts.Cids()[0])
+	if err != nil {
+		return nil, xerrors.Errorf("loading receipts: %w", err)
+	}
+	if len(msgs) != len(rcts) {
+		return nil, xerrors.Errorf("got %d messages but %d receipts", len(msgs), len(rcts))
+	}
+
+	out := map[statsKey]*api.GasStats{}
+	if len(msgs) == 0 {
+		return out, nil
+	}
+
+	pts, err := s.api.ChainGetTipSet(ctx, ts.Parents())
+	if err != nil {
+		return nil, xerrors.Errorf("loading parent tipset: %w", err)
+	}
+	baseFee := pts.Blocks()[0].ParentBaseFee
+
+	codes := map[address.Address]string{}
+	for i, m := range msgs {
+		code, ok := codes[m.Message.To]
+		if !ok {
+			// the state of ts is the state after executing the messages
+			act, err := s.api.StateGetActor(ctx, m.Message.To, ts.Key())
+			if err != nil {
+				log.Debugw("looking up message recipient", "message", m.Cid, "to", m.Message.To, "error", err)
+			} else {
+				code = act.Code.String()
+			}
+			codes[m.Message.To] = code
+		}
+
+		key := statsKey{code: code, method: m.Message.Method}
+		st, ok := out[key]
+		if !ok {
+			st = &api.GasStats{
+				MinGasUsed:         rcts[i].GasUsed,
+				BaseFeeBurn:        big.Zero(),
+				OverEstimationBurn: big.Zero(),
+				MinerTip:           big.Zero(),
+			}
+			out[key] = st
+		}
+
+		gasOut := vm.ComputeGasOutputs(rcts[i].GasUsed, m.Message.GasLimit, baseFee, m.Message.GasFeeCap, m.Message.GasPremium, true)
+		addMessage(st, rcts[i], m.Message.GasLimit, gasOut)
+	}
+
+	return out, nil
+}
+
+func addMessage(st *api.GasStats, rct *types.MessageReceipt, gasLimit int64, gasOut vm.GasOutputs) {
+	st.Messages++
+	if rct.ExitCode.IsError() {
+		st.Failed++
+	}
+	st.GasUsed += rct.GasUsed
+	st.GasLimit += gasLimit
+	if rct.GasUsed < st.MinGasUsed {
+		st.MinGasUsed = rct.GasUsed
+	}
+	if rct.GasUsed > st.MaxGasUsed {
+		st.MaxGasUsed = rct.GasUsed
+	}
+	st.BaseFeeBurn = big.Add(st.BaseFeeBurn, gasOut.BaseFeeBurn)
+	st.OverEstimationBurn = big.Add(st.OverEstimationBurn, gasOut.OverEstimationBurn)
+	st.MinerTip = big.Add(st.MinerTip, gasOut.MinerTip)
+}
+
+// merge adds the statistics of other to st.
+func merge(st, other *api.GasStats) {
+	if st.Messages == 0 || other.MinGasUsed < st.MinGasUsed {
+		st.MinGasUsed = other.MinGasUsed
+	}
+	if other.MaxGasUsed > st.MaxGasUsed {
+		st.MaxGasUsed = other.MaxGasUsed
+	}
+	st.Messages += other.Messages
+	st.Failed += other.Failed
+	st.GasUsed += other.GasUsed
+	st.GasLimit += other.GasLimit
+	st.BaseFeeBurn = big.Add(st.BaseFeeBurn, other.BaseFeeBurn)
+	st.OverEstimationBurn = big.Add(st.OverEstimationBurn, other.OverEstimationBurn)
+	st.MinerTip = big.Add(st.MinerTip, other.MinerTip)
+}
+
+// Query returns the statistics of the messages recorded in the tipsets matching
+// the filter, ordered by the total gas used.
+func (s *Stats) Query(ctx context.Context, filter api.GasStatsFilter) (*api.GasStatsResult, error) {
+	to := filter.ToHeight
+	if to <= 0 {
+		to = abi.ChainEpoch(1<<63 - 1)
+	}
+	if filter.FromHeight > to {
+		return nil, xerrors.Errorf("FromHeight %d is above ToHeight %d", filter.FromHeight, to)
+	}
+
+	rows, err := s.db.QueryContext(ctx, dbqSelectRange, int64(filter.FromHeight), int64(to))
+	if err != nil {
+		return nil, xerrors.Errorf("querying gas stats: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	res := &api.GasStatsResult{
+		Stats: []api.GasStats{},
+	}
+	tipsets := map[string]struct{}{}
+	stats := map[statsKey]*api.GasStats{}
+
+	for rows.Next() {
+		var (
+			tsk                          []byte
+			height                       int64
+			code                         string
+			method                       uint64
+			st                           api.GasStats
+			baseFee, overEstimation, tip string
+		)
+		if err := rows.Scan(&tsk, &height, &code, &method, &st.Messages, &st.Failed, &st.GasUsed, &st.MinGasUsed, &st.MaxGasUsed,
+			&st.GasLimit, &baseFee, &overEstimation, &tip); err != nil {
+			return nil, err
+		}
+
+		if filter.ActorCode != nil && code != filter.ActorCode.String() {
+			continue
+		}
+		if filter.Method != nil && abi.MethodNum(method) != *filter.Method {
+			continue
+		}
+
+		for _, f := range []struct {
+			s   string
+			out *abi.TokenAmount
+		}{{baseFee, &st.BaseFeeBurn}, {overEstimation, &st.OverEstimationBurn}, {tip, &st.MinerTip}} {
+			if *f.out, err = big.FromString(f.s); err != nil {
+				return nil, xerrors.Errorf("decoding fee %q: %w", f.s, err)
+			}
+		}
+
+		tipsets[string(tsk)] = struct{}{}
+		if res.FromHeight == 0 || abi.ChainEpoch(height) < res.FromHeight {
+			res.FromHeight = abi.ChainEpoch(height)
+		}
+		if abi.ChainEpoch(height) > res.ToHeight {
+			res.ToHeight = abi.ChainEpoch(height)
+		}
+
+		key := statsKey{code: code, method: abi.MethodNum(method)}
+		agg, ok := stats[key]
+		if !ok {
+			agg = &api.GasStats{
+				Method:             key.method,
+				BaseFeeBurn:        big.Zero(),
+				OverEstimationBurn: big.Zero(),
+				MinerTip:           big.Zero(),
+			}
+			stats[key] = agg
+		}
+		merge(agg, &st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	res.TipSets = len(tipsets)
+	for key, st := range stats {
+		if key.code != "" {
+			c, err := cid.Decode(key.code)
+			if err != nil {
+				return nil, xerrors.Errorf("decoding actor code %q: %w", key.code, err)
+			}
+			st.ActorCode = c
+			st.ActorName = builtin.ActorNameByCode(c)
+			if s.names != nil {
+				st.MethodName = s.names(c, st.Method)
+			}
+		}
+		if st.Messages > 0 {
+			st.AvgGasUsed = st.GasUsed / int64(st.Messages)
+		}
+		res.Stats = append(res.Stats, *st)
+	}
+	sort.Slice(res.Stats, func(i, j int) bool {
+		if res.Stats[i].GasUsed != res.Stats[j].GasUsed {
+			return res.Stats[i].GasUsed > res.Stats[j].GasUsed
+		}
+		if res.Stats[i].ActorName != res.Stats[j].ActorName {
+			return res.Stats[i].ActorName < res.Stats[j].ActorName
+		}
+		return res.Stats[i].Method < res.Stats[j].Method
+	})
+
+	return res, nil
+}
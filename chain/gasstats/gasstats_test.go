@@ -0,0 +1,197 @@
+// stm: #unit
+package gasstats
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/exitcode"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+var (
+	codeA = mustCid("actor-a")
+	codeB = mustCid("actor-b")
+
+	actorA = mock.Address(1001)
+	actorB = mock.Address(1002)
+	// a recipient which doesn't exist
+	actorC = mock.Address(1003)
+)
+
+func mustCid(s string) cid.Cid {
+	c, err := abi.CidBuilder.Sum([]byte(s))
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+type execution struct {
+	msgs []api.Message
+	rcts []*types.MessageReceipt
+}
+
+type fakeChain struct {
+	tipsets map[types.TipSetKey]*types.TipSet
+	// executions are keyed by the first block of the tipset recording them
+	executions map[cid.Cid]execution
+}
+
+func (fc *fakeChain) mk(parent *types.TipSet, nonce uint64, msgs ...*types.Message) *types.TipSet {
+	ts := mock.TipSet(mock.MkBlock(parent, 1, nonce))
+	fc.tipsets[ts.Key()] = ts
+
+	var ex execution
+	for _, m := range msgs {
+		ex.msgs = append(ex.msgs, api.Message{Cid: m.Cid(), Message: m})
+		rct := &types.MessageReceipt{GasUsed: m.GasLimit / 2}
+		// calls to method 3 fail
+		if m.Method == 3 {
+			rct.ExitCode = exitcode.ErrIllegalArgument
+		}
+		ex.rcts = append(ex.rcts, rct)
+	}
+	fc.executions[ts.Cids()[0]] = ex
+	return ts
+}
+
+func (fc *fakeChain) ChainGetTipSet(_ context.Context, tsk types.TipSetKey) (*types.TipSet, error) {
+	return fc.tipsets[tsk], nil
+}
+
+func (fc *fakeChain) ChainGetParentMessages(_ context.Context, blk cid.Cid) ([]api.Message, error) {
+	return fc.executions[blk].msgs, nil
+}
+
+func (fc *fakeChain) ChainGetParentReceipts(_ context.Context, blk cid.Cid) ([]*types.MessageReceipt, error) {
+	return fc.executions[blk].rcts, nil
+}
+
+func (fc *fakeChain) StateGetActor(_ context.Context, a address.Address, _ types.TipSetKey) (*types.Actor, error) {
+	switch a {
+	case actorA:
+		return &types.Actor{Code: codeA}, nil
+	case actorB:
+		return &types.Actor{Code: codeB}, nil
+	}
+	return nil, types.ErrActorNotFound
+}
+
+func msg(to address.Address, method abi.MethodNum, gasLimit int64) *types.Message {
+	return &types.Message{
+		From:       mock.Address(100),
+		To:         to,
+		Method:     method,
+		GasLimit:   gasLimit,
+		GasFeeCap:  big.NewInt(int64(build.MinimumBaseFee) * 10),
+		GasPremium: big.NewInt(1),
+		Value:      big.Zero(),
+	}
+}
+
+func TestStats(t *testing.T) {
+	ctx := context.Background()
+
+	fc := &fakeChain{
+		tipsets:    map[types.TipSetKey]*types.TipSet{},
+		executions: map[cid.Cid]execution{},
+	}
+	genesis := fc.mk(nil, 0)
+	ts1 := fc.mk(genesis, 1, msg(actorA, 2, 1000), msg(actorA, 2, 3000), msg(actorB, 3, 500))
+	ts2 := fc.mk(ts1, 2, msg(actorA, 2, 2000), msg(actorC, 0, 100))
+	// a fork of ts2
+	ts2b := fc.mk(ts1, 3, msg(actorB, 3, 800))
+
+	s, err := NewStats(filepath.Join(t.TempDir(), DBName), fc, 10, func(code cid.Cid, method abi.MethodNum) string {
+		if code == codeA && method == 2 {
+			return "DoThing"
+		}
+		return ""
+	})
+	require.NoError(t, err)
+	defer s.Close() //nolint:errcheck
+
+	require.NoError(t, s.Apply(ctx, genesis, ts1))
+	require.NoError(t, s.Apply(ctx, ts1, ts2))
+
+	res, err := s.Query(ctx, api.GasStatsFilter{})
+	require.NoError(t, err)
+	require.Equal(t, 2, res.TipSets)
+	require.Equal(t, ts1.Height(), res.FromHeight)
+	require.Equal(t, ts2.Height(), res.ToHeight)
+	require.Len(t, res.Stats, 3)
+
+	a := res.Stats[0]
+	require.Equal(t, codeA, a.ActorCode)
+	require.Equal(t, abi.MethodNum(2), a.Method)
+	require.Equal(t, "DoThing", a.MethodName)
+	require.Equal(t, uint64(3), a.Messages)
+	require.Equal(t, int64(3000), a.GasUsed)
+	require.Equal(t, int64(6000), a.GasLimit)
+	require.Equal(t, int64(500), a.MinGasUsed)
+	require.Equal(t, int64(1500), a.MaxGasUsed)
+	require.Equal(t, int64(1000), a.AvgGasUsed)
+	require.Equal(t, big.NewInt(3000*int64(build.MinimumBaseFee)), a.BaseFeeBurn)
+	require.Equal(t, big.NewInt(6000), a.MinerTip)
+
+	b := res.Stats[1]
+	require.Equal(t, codeB, b.ActorCode)
+	require.Equal(t, uint64(1), b.Failed)
+
+	// the recipient of the last message doesn't exist
+	require.Equal(t, cid.Undef, res.Stats[2].ActorCode)
+
+	// filters
+	res, err = s.Query(ctx, api.GasStatsFilter{FromHeight: ts2.Height()})
+	require.NoError(t, err)
+	require.Equal(t, 1, res.TipSets)
+	require.Equal(t, uint64(1), res.Stats[0].Messages)
+
+	method := abi.MethodNum(3)
+	res, err = s.Query(ctx, api.GasStatsFilter{Method: &method})
+	require.NoError(t, err)
+	require.Len(t, res.Stats, 1)
+	require.Equal(t, codeB, res.Stats[0].ActorCode)
+
+	res, err = s.Query(ctx, api.GasStatsFilter{ActorCode: &codeA, ToHeight: ts1.Height()})
+	require.NoError(t, err)
+	require.Len(t, res.Stats, 1)
+	require.Equal(t, uint64(2), res.Stats[0].Messages)
+
+	_, err = s.Query(ctx, api.GasStatsFilter{FromHeight: 10, ToHeight: 5})
+	require.Error(t, err)
+
+	// reorg to ts2b
+	require.NoError(t, s.Revert(ctx, ts2, ts1))
+	require.NoError(t, s.Apply(ctx, ts1, ts2b))
+
+	res, err = s.Query(ctx, api.GasStatsFilter{FromHeight: ts2.Height()})
+	require.NoError(t, err)
+	require.Len(t, res.Stats, 1)
+	require.Equal(t, codeB, res.Stats[0].ActorCode)
+	require.Equal(t, int64(400), res.Stats[0].GasUsed)
+
+	// statistics older than the retention are pruned
+	head := ts2b
+	for i := uint64(0); i <= 10; i++ {
+		next := fc.mk(head, 10+i)
+		require.NoError(t, s.Apply(ctx, head, next))
+		head = next
+	}
+	res, err = s.Query(ctx, api.GasStatsFilter{})
+	require.NoError(t, err)
+	require.Zero(t, res.TipSets)
+	require.Empty(t, res.Stats)
+}
@@ -0,0 +1,285 @@
+package slashsvc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	cborutil "github.com/filecoin-project/go-cbor-util"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin"
+	miner11 "github.com/filecoin-project/go-state-types/builtin/v11/miner"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/gen/slashfilter"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/metrics"
+)
+
+var log = logging.Logger("slashsvc")
+
+// API is the node access needed to build and send consensus fault reports.
+type API interface {
+	ChainGetBlock(context.Context, cid.Cid) (*types.BlockHeader, error)
+	MpoolPushMessage(context.Context, *types.Message, *api.MessageSendSpec) (*types.SignedMessage, error)
+}
+
+// Reporter checks the incoming block headers for consensus faults with a
+// slash filter, records the faults it finds, and reports them to the miner
+// actor when it has a reporter address.
+type Reporter struct {
+	api    API
+	sf     *slashfilter.SlashFilter
+	faults ds.Datastore
+	from   address.Address
+
+	// headers caches the recently checked headers, which may not have been
+	// synced by the node when they are part of a fault
+	headers *lru.Cache[cid.Cid, *types.BlockHeader]
+
+	lk sync.Mutex
+}
+
+const headerCacheSize = 2048
+
+// NewReporter creates a reporter storing its state in dstore. Faults are only
+// detected and recorded when from is undefined.
+func NewReporter(fapi API, dstore ds.Batching, from address.Address) (*Reporter, error) {
+	headers, err := lru.New[cid.Cid, *types.BlockHeader](headerCacheSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Reporter{
+		api:     fapi,
+		sf:      slashfilter.New(namespace.Wrap(dstore, ds.NewKey("/slashsvc"))),
+		faults:  namespace.Wrap(dstore, ds.NewKey("/slashsvc/faults")),
+		from:    from,
+		headers: headers,
+	}, nil
+}
+
+// Run checks the headers received on incoming until it is closed.
+func (r *Reporter) Run(ctx context.Context, incoming <-chan *types.BlockHeader) {
+	for bh := range incoming {
+		if err := r.CheckBlock(ctx, bh); err != nil {
+			log.Errorw("checking block for consensus faults", "block", bh.Cid(), "miner", bh.Miner, "error", err)
+		}
+	}
+}
+
+// CheckBlock checks a block header against the previous blocks of its miner,
+// and records and reports the consensus fault it constitutes, if any.
+func (r *Reporter) CheckBlock(ctx context.Context, bh *types.BlockHeader) error {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	r.headers.Add(bh.Cid(), bh)
+
+	fault, err := r.sf.CheckBlock(ctx, bh, bh.Height-1)
+	if err != nil {
+		return err
+	}
+	if fault == nil {
+		return nil
+	}
+
+	log.Warnw("consensus fault detected", "type", fault.Type, "miner", bh.Miner, "epoch", bh.Height, "block", bh.Cid(), "other", fault.Other)
+	_ = stats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(metrics.ConsensusFaultType, fault.Type)}, metrics.ConsensusFaultDetected.M(1))
+
+	cf := api.ConsensusFault{
+		Miner:    bh.Miner,
+		Epoch:    bh.Height,
+		Type:     fault.Type,
+		Block1:   fault.Other,
+		Block2:   bh.Cid(),
+		Detected: build.Clock.Now(),
+	}
+
+	if r.from != address.Undef {
+		reported, err := r.reported(ctx, bh.Miner, bh.Height)
+		if err != nil {
+			return err
+		}
+		// the miner actor accepts a single report per faulty epoch
+		if !reported {
+			msg, err := r.report(ctx, &cf, bh)
+			if err != nil {
+				cf.ReportError = err.Error()
+				log.Errorw("failed to report consensus fault", "miner", bh.Miner, "epoch", bh.Height, "error", err)
+			} else {
+				cf.Report = &msg
+				_ = stats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(metrics.ConsensusFaultType, fault.Type)}, metrics.ConsensusFaultReported.M(1))
+			}
+		}
+	}
+
+	return r.put(ctx, cf)
+}
+
+// report sends a ReportConsensusFault message for the fault, and returns its
+// cid.
+func (r *Reporter) report(ctx context.Context, cf *api.ConsensusFault, bh *types.BlockHeader) (cid.Cid, error) {
+	other, err := r.getBlock(ctx, cf.Block1)
+	if err != nil {
+		return cid.Undef, err
+	}
+
+	params := miner11.ReportConsensusFaultParams{}
+	if params.BlockHeader1, err = cborutil.Dump(other); err != nil {
+		return cid.Undef, err
+	}
+	if params.BlockHeader2, err = cborutil.Dump(bh); err != nil {
+		return cid.Undef, err
+	}
+
+	if cf.Type == slashfilter.ParentGrinding {
+		// the witness is the parent of the block which is a sibling of the
+		// block the miner left out
+		witness, err := r.findWitness(ctx, other, bh)
+		if err != nil {
+			return cid.Undef, err
+		}
+		if witness == nil {
+			return cid.Undef, xerrors.Errorf("no parent-grinding witness in the parents of the block")
+		}
+		wc := witness.Cid()
+		cf.Extra = &wc
+
+		if params.BlockHeaderExtra, err = cborutil.Dump(witness); err != nil {
+			return cid.Undef, err
+		}
+	}
+
+	enc, err := actors.SerializeParams(&params)
+	if err != nil {
+		return cid.Undef, err
+	}
+
+	smsg, err := r.api.MpoolPushMessage(ctx, &types.Message{
+		To:     bh.Miner,
+		From:   r.from,
+		Value:  big.Zero(),
+		Method: builtin.MethodsMiner.ReportConsensusFault,
+		Params: enc,
+	}, nil)
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("pushing report message: %w", err)
+	}
+
+	log.Infow("reported consensus fault", "miner", bh.Miner, "epoch", bh.Height, "message", smsg.Cid())
+	return smsg.Cid(), nil
+}
+
+func (r *Reporter) findWitness(ctx context.Context, omitted, bh *types.BlockHeader) (*types.BlockHeader, error) {
+	for _, p := range bh.Parents {
+		parent, err := r.getBlock(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		if parent.Height == omitted.Height && types.CidArrsEqual(parent.Parents, omitted.Parents) {
+			return parent, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *Reporter) getBlock(ctx context.Context, c cid.Cid) (*types.BlockHeader, error) {
+	if bh, ok := r.headers.Get(c); ok {
+		return bh, nil
+	}
+	bh, err := r.api.ChainGetBlock(ctx, c)
+	if err != nil {
+		return nil, xerrors.Errorf("getting block %s: %w", c, err)
+	}
+	return bh, nil
+}
+
+func faultPrefix(miner address.Address, epoch abi.ChainEpoch) ds.Key {
+	return ds.NewKey(fmt.Sprintf("/%s/%d", miner, epoch))
+}
+
+func (r *Reporter) reported(ctx context.Context, miner address.Address, epoch abi.ChainEpoch) (bool, error) {
+	res, err := r.faults.Query(ctx, query.Query{Prefix: faultPrefix(miner, epoch).String()})
+	if err != nil {
+		return false, xerrors.Errorf("querying faults: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	for e := range res.Next() {
+		if e.Error != nil {
+			return false, xerrors.Errorf("iterating faults: %w", e.Error)
+		}
+		var cf api.ConsensusFault
+		if err := json.Unmarshal(e.Value, &cf); err != nil {
+			return false, xerrors.Errorf("decoding fault %s: %w", e.Key, err)
+		}
+		if cf.Report != nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *Reporter) put(ctx context.Context, cf api.ConsensusFault) error {
+	b, err := json.Marshal(cf)
+	if err != nil {
+		return xerrors.Errorf("encoding fault: %w", err)
+	}
+	key := faultPrefix(cf.Miner, cf.Epoch).ChildString(cf.Block2.String())
+	if err := r.faults.Put(ctx, key, b); err != nil {
+		return xerrors.Errorf("storing fault: %w", err)
+	}
+	return nil
+}
+
+// Faults returns the faults detected at or after the since epoch, ordered by
+// epoch.
+func (r *Reporter) Faults(ctx context.Context, since abi.ChainEpoch) ([]api.ConsensusFault, error) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	res, err := r.faults.Query(ctx, query.Query{})
+	if err != nil {
+		return nil, xerrors.Errorf("querying faults: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	out := []api.ConsensusFault{}
+	for e := range res.Next() {
+		if e.Error != nil {
+			return nil, xerrors.Errorf("iterating faults: %w", e.Error)
+		}
+		var cf api.ConsensusFault
+		if err := json.Unmarshal(e.Value, &cf); err != nil {
+			return nil, xerrors.Errorf("decoding fault %s: %w", e.Key, err)
+		}
+		if cf.Epoch >= since {
+			out = append(out, cf)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Epoch != out[j].Epoch {
+			return out[i].Epoch < out[j].Epoch
+		}
+		return out[i].Detected.Before(out[j].Detected)
+	})
+	return out, nil
+}
@@ -0,0 +1,135 @@
+// stm: #unit
+package slashsvc
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/builtin"
+	miner11 "github.com/filecoin-project/go-state-types/builtin/v11/miner"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/gen/slashfilter"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+type fakeAPI struct {
+	blocks map[cid.Cid]*types.BlockHeader
+	sent   []*types.SignedMessage
+}
+
+func (fa *fakeAPI) ChainGetBlock(_ context.Context, c cid.Cid) (*types.BlockHeader, error) {
+	return fa.blocks[c], nil
+}
+
+func (fa *fakeAPI) MpoolPushMessage(_ context.Context, msg *types.Message, _ *api.MessageSendSpec) (*types.SignedMessage, error) {
+	smsg := &types.SignedMessage{Message: *msg}
+	fa.sent = append(fa.sent, smsg)
+	return smsg, nil
+}
+
+func TestDoubleForkMining(t *testing.T) {
+	ctx := context.Background()
+
+	fa := &fakeAPI{}
+	r, err := NewReporter(fa, ds.NewMapDatastore(), mock.Address(100))
+	require.NoError(t, err)
+
+	genesis := mock.TipSet(mock.MkBlock(nil, 1, 0))
+	b1 := mock.MkBlock(genesis, 1, 1)
+	b2 := mock.MkBlock(genesis, 1, 2)
+	b3 := mock.MkBlock(genesis, 1, 3)
+
+	require.NoError(t, r.CheckBlock(ctx, b1))
+	// checking a block again isn't a fault
+	require.NoError(t, r.CheckBlock(ctx, b1))
+
+	faults, err := r.Faults(ctx, 0)
+	require.NoError(t, err)
+	require.Empty(t, faults)
+
+	require.NoError(t, r.CheckBlock(ctx, b2))
+	require.NoError(t, r.CheckBlock(ctx, b3))
+
+	faults, err = r.Faults(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, faults, 2)
+	require.Equal(t, slashfilter.DoubleForkMining, faults[0].Type)
+	require.Equal(t, b1.Miner, faults[0].Miner)
+	require.Equal(t, b1.Height, faults[0].Epoch)
+	require.Equal(t, b1.Cid(), faults[0].Block1)
+	require.Equal(t, b2.Cid(), faults[0].Block2)
+
+	// the fault is reported once per epoch
+	require.Len(t, fa.sent, 1)
+	require.Equal(t, b1.Miner, fa.sent[0].Message.To)
+	require.Equal(t, builtin.MethodsMiner.ReportConsensusFault, fa.sent[0].Message.Method)
+	require.NotNil(t, faults[0].Report)
+	require.Equal(t, fa.sent[0].Cid(), *faults[0].Report)
+	require.Nil(t, faults[1].Report)
+
+	faults, err = r.Faults(ctx, b1.Height+1)
+	require.NoError(t, err)
+	require.Empty(t, faults)
+}
+
+func TestParentGrinding(t *testing.T) {
+	ctx := context.Background()
+
+	genesis := mock.TipSet(mock.MkBlock(nil, 1, 0))
+	omitted := mock.MkBlock(genesis, 1, 1)
+	// a block of another miner at the same height, which the next block of
+	// the miner is mined on instead of its own block
+	witness := mock.MkBlock(genesis, 1, 2)
+	witness.Miner = mock.Address(200)
+	grinding := mock.MkBlock(mock.TipSet(witness), 1, 3)
+
+	// the witness is fetched from the chain
+	fa := &fakeAPI{blocks: map[cid.Cid]*types.BlockHeader{witness.Cid(): witness}}
+	r, err := NewReporter(fa, ds.NewMapDatastore(), mock.Address(100))
+	require.NoError(t, err)
+
+	require.NoError(t, r.CheckBlock(ctx, omitted))
+	require.NoError(t, r.CheckBlock(ctx, grinding))
+
+	faults, err := r.Faults(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, faults, 1)
+	require.Equal(t, slashfilter.ParentGrinding, faults[0].Type)
+	require.Equal(t, omitted.Cid(), faults[0].Block1)
+	require.Equal(t, grinding.Cid(), faults[0].Block2)
+	require.NotNil(t, faults[0].Extra)
+	require.Equal(t, witness.Cid(), *faults[0].Extra)
+
+	require.Len(t, fa.sent, 1)
+	var params miner11.ReportConsensusFaultParams
+	require.NoError(t, params.UnmarshalCBOR(bytes.NewReader(fa.sent[0].Message.Params)))
+	extra, err := types.DecodeBlock(params.BlockHeaderExtra)
+	require.NoError(t, err)
+	require.Equal(t, witness.Cid(), extra.Cid())
+}
+
+func TestDetectOnly(t *testing.T) {
+	ctx := context.Background()
+
+	fa := &fakeAPI{}
+	r, err := NewReporter(fa, ds.NewMapDatastore(), address.Undef)
+	require.NoError(t, err)
+
+	genesis := mock.TipSet(mock.MkBlock(nil, 1, 0))
+	require.NoError(t, r.CheckBlock(ctx, mock.MkBlock(genesis, 1, 1)))
+	require.NoError(t, r.CheckBlock(ctx, mock.MkBlock(genesis, 1, 2)))
+
+	faults, err := r.Faults(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, faults, 1)
+	require.Nil(t, faults[0].Report)
+	require.Empty(t, fa.sent)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-datastore"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
 	"github.com/filecoin-project/go-state-types/builtin"
 	"github.com/filecoin-project/go-state-types/builtin/v9/market"
 
@@ -42,19 +44,35 @@ type fundManagerAPI interface {
 	StateWaitMsg(ctx context.Context, cid cid.Cid, confidence uint64, limit abi.ChainEpoch, allowReplaced bool) (*api.MsgLookup, error)
 }
 
+// FundManagerConfig configures the automatic management of market escrow
+type FundManagerConfig struct {
+	// TopUpBuffer is added to the escrow top ups, on top of the funds missing
+	// for the reservation
+	TopUpBuffer abi.TokenAmount
+	// WithdrawInterval is how often the unreserved funds of the addresses are
+	// withdrawn, zero disables automatic withdrawals
+	WithdrawInterval time.Duration
+	// WithdrawKeep is the amount of unreserved funds left in escrow
+	WithdrawKeep abi.TokenAmount
+	// WithdrawMin is the smallest amount automatically withdrawn
+	WithdrawMin abi.TokenAmount
+}
+
 // FundManager keeps track of funds in a set of addresses
 type FundManager struct {
 	ctx      context.Context
 	shutdown context.CancelFunc
 	api      fundManagerAPI
 	str      *Store
+	cfg      FundManagerConfig
 
 	lk          sync.Mutex
 	fundedAddrs map[address.Address]*fundedAddress
 }
 
-func NewFundManager(lc fx.Lifecycle, api FundManagerAPI, ds dtypes.MetadataDS) *FundManager {
+func NewFundManager(lc fx.Lifecycle, api FundManagerAPI, ds dtypes.MetadataDS, cfg FundManagerConfig) *FundManager {
 	fm := newFundManager(&api, ds)
+	fm.cfg = cfg
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
 			return fm.Start()
@@ -76,6 +94,11 @@ func newFundManager(api fundManagerAPI, ds datastore.Batching) *FundManager {
 		api:         api,
 		str:         newStore(ds),
 		fundedAddrs: make(map[address.Address]*fundedAddress),
+		cfg: FundManagerConfig{
+			TopUpBuffer:  abi.NewTokenAmount(0),
+			WithdrawKeep: abi.NewTokenAmount(0),
+			WithdrawMin:  abi.NewTokenAmount(0),
+		},
 	}
 }
 
@@ -87,6 +110,10 @@ func (fm *FundManager) Start() error {
 	fm.lk.Lock()
 	defer fm.lk.Unlock()
 
+	if fm.cfg.WithdrawInterval > 0 {
+		go fm.runAutoWithdraw()
+	}
+
 	// TODO:
 	// To save memory:
 	// - in State() only load addresses with in-progress messages
@@ -138,6 +165,90 @@ func (fm *FundManager) GetReserved(addr address.Address) abi.TokenAmount {
 	return fm.getFundedAddress(addr).getReserved()
 }
 
+// FundsStatus returns the market escrow of the address, split between the
+// funds locked on chain by deals, the funds reserved for deals being made,
+// and the funds available.
+func (fm *FundManager) FundsStatus(ctx context.Context, addr address.Address) (api.MarketFundsStatus, error) {
+	bal, err := fm.api.StateMarketBalance(ctx, addr, types.EmptyTSK)
+	if err != nil {
+		return api.MarketFundsStatus{}, xerrors.Errorf("getting market balance: %w", err)
+	}
+
+	fa := fm.getFundedAddress(addr)
+	fa.lk.RLock()
+	defer fa.lk.RUnlock()
+
+	available := big.Sub(big.Sub(bal.Escrow, bal.Locked), fa.state.AmtReserved)
+	return api.MarketFundsStatus{
+		Escrow:         bal.Escrow,
+		Locked:         bal.Locked,
+		Reserved:       fa.state.AmtReserved,
+		Available:      big.Max(available, big.Zero()),
+		PendingMessage: fa.state.MsgCid,
+		LastWithdrawal: fa.lastWithdrawal,
+	}, nil
+}
+
+func (fm *FundManager) runAutoWithdraw() {
+	ticker := build.Clock.Ticker(fm.cfg.WithdrawInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			fm.autoWithdraw()
+		case <-fm.ctx.Done():
+			return
+		}
+	}
+}
+
+// autoWithdraw withdraws the unreserved funds of the addresses, which are
+// released by the market actor once deals end.
+func (fm *FundManager) autoWithdraw() {
+	fm.lk.Lock()
+	addrs := make([]*fundedAddress, 0, len(fm.fundedAddrs))
+	for _, fa := range fm.fundedAddrs {
+		addrs = append(addrs, fa)
+	}
+	fm.lk.Unlock()
+
+	for _, fa := range addrs {
+		avail, err := fa.env.AvailableFunds(fm.ctx, fa.state.Addr)
+		if err != nil {
+			log.Errorf("getting available funds for %s: %v", fa.state.Addr, err)
+			continue
+		}
+
+		fa.lk.RLock()
+		amt := big.Sub(big.Sub(avail, fa.state.AmtReserved), fm.cfg.WithdrawKeep)
+		wallet := fa.wallet
+		fa.lk.RUnlock()
+
+		if amt.LessThanEqual(big.Zero()) || amt.LessThan(fm.cfg.WithdrawMin) {
+			continue
+		}
+		if wallet == address.Undef {
+			wallet = fa.state.Addr
+		}
+
+		msgCid, err := fa.withdraw(fm.ctx, wallet, amt)
+		if err != nil {
+			log.Errorf("withdrawing released funds of %s: %v", fa.state.Addr, err)
+			continue
+		}
+		log.Infof("withdrew %s of released funds of %s in %s", types.FIL(amt), fa.state.Addr, msgCid)
+
+		fa.lk.Lock()
+		fa.lastWithdrawal = &api.MarketWithdrawal{
+			Time:    build.Clock.Now(),
+			Amount:  amt,
+			Message: msgCid,
+		}
+		fa.lk.Unlock()
+	}
+}
+
 // FundedAddressState keeps track of the state of an address with funds in the
 // datastore
 type FundedAddressState struct {
@@ -152,13 +263,20 @@ type FundedAddressState struct {
 // fundedAddress keeps track of the state and request queues for a
 // particular address
 type fundedAddress struct {
-	ctx context.Context
-	env *fundManagerEnvironment
-	str *Store
+	ctx         context.Context
+	env         *fundManagerEnvironment
+	str         *Store
+	topUpBuffer abi.TokenAmount
 
 	lk    sync.RWMutex
 	state *FundedAddressState
 
+	// wallet is the wallet of the latest reservation, used for the automatic
+	// withdrawals
+	wallet address.Address
+	// lastWithdrawal is the latest automatic withdrawal
+	lastWithdrawal *api.MarketWithdrawal
+
 	// Note: These request queues are ephemeral, they are not saved to store
 	reservations []*fundRequest
 	releases     []*fundRequest
@@ -170,9 +288,10 @@ type fundedAddress struct {
 
 func newFundedAddress(fm *FundManager, addr address.Address) *fundedAddress {
 	return &fundedAddress{
-		ctx: fm.ctx,
-		env: &fundManagerEnvironment{api: fm.api},
-		str: fm.str,
+		ctx:         fm.ctx,
+		env:         &fundManagerEnvironment{api: fm.api},
+		str:         fm.str,
+		topUpBuffer: fm.cfg.TopUpBuffer,
 		state: &FundedAddressState{
 			Addr:        addr,
 			AmtReserved: abi.NewTokenAmount(0),
@@ -200,6 +319,10 @@ func (a *fundedAddress) getReserved() abi.TokenAmount {
 }
 
 func (a *fundedAddress) reserve(ctx context.Context, wallet address.Address, amt abi.TokenAmount) (cid.Cid, error) {
+	a.lk.Lock()
+	a.wallet = wallet
+	a.lk.Unlock()
+
 	return a.requestAndWait(ctx, wallet, amt, &a.reservations)
 }
 
@@ -405,6 +528,11 @@ func (a *fundedAddress) processReservations(reservations []*fundRequest, release
 		// amount to add = new reserved amount - available
 		amtToAdd = types.BigSub(reserved, avail)
 		a.debugf("reserved %d - avail %d = to add %d", reserved, avail, amtToAdd)
+
+		// top up a bit more, so that the next reservations don't need a message
+		if amtToAdd.GreaterThan(abi.NewTokenAmount(0)) && !a.topUpBuffer.Nil() {
+			amtToAdd = types.BigAdd(amtToAdd, a.topUpBuffer)
+		}
 	}
 
 	// If there's nothing to add to the balance, bail out
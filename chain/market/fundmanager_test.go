@@ -610,6 +610,57 @@ func TestFundManagerReleaseAfterPublish(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestFundManagerTopUpAndAutoWithdraw(t *testing.T) {
+	//stm: @MARKET_RESERVE_FUNDS_001, @MARKET_RELEASE_FUNDS_001, @MARKET_WITHDRAW_FUNDS_001
+	s := setup(t)
+	defer s.fm.Stop()
+
+	s.fm.cfg.TopUpBuffer = abi.NewTokenAmount(5)
+	s.fm.cfg.WithdrawKeep = abi.NewTokenAmount(2)
+	s.fm.cfg.WithdrawMin = abi.NewTokenAmount(1)
+
+	// Reserve 10, the top up includes the buffer
+	// balance:  0 -> 15
+	// reserved: 0 -> 10
+	sentinel, err := s.fm.Reserve(s.ctx, s.walletAddr, s.acctAddr, abi.NewTokenAmount(10))
+	require.NoError(t, err)
+	msg := s.mockApi.getSentMessage(sentinel)
+	checkAddMessageFields(t, msg, s.walletAddr, s.acctAddr, abi.NewTokenAmount(15))
+	s.mockApi.completeMsg(sentinel)
+
+	// Reserve 3, covered by the buffer
+	// balance:  15
+	// reserved: 10 -> 13
+	sentinel, err = s.fm.Reserve(s.ctx, s.walletAddr, s.acctAddr, abi.NewTokenAmount(3))
+	require.NoError(t, err)
+	require.Equal(t, cid.Undef, sentinel)
+
+	st, err := s.fm.FundsStatus(s.ctx, s.acctAddr)
+	require.NoError(t, err)
+	require.Equal(t, abi.NewTokenAmount(15), st.Escrow)
+	require.Equal(t, abi.NewTokenAmount(13), st.Reserved)
+	require.Equal(t, abi.NewTokenAmount(2), st.Available)
+
+	// Release 13, the funds beyond what is kept are withdrawn
+	// balance:  15 -> 2
+	// reserved: 13 -> 0
+	require.NoError(t, s.fm.Release(s.acctAddr, abi.NewTokenAmount(13)))
+	s.fm.autoWithdraw()
+
+	st, err = s.fm.FundsStatus(s.ctx, s.acctAddr)
+	require.NoError(t, err)
+	require.NotNil(t, st.LastWithdrawal)
+	require.Equal(t, abi.NewTokenAmount(13), st.LastWithdrawal.Amount)
+	msg = s.mockApi.getSentMessage(st.LastWithdrawal.Message)
+	checkWithdrawMessageFields(t, msg, s.walletAddr, s.acctAddr, abi.NewTokenAmount(13))
+	s.mockApi.completeMsg(st.LastWithdrawal.Message)
+
+	// Nothing left to withdraw
+	count := s.mockApi.messageCount()
+	s.fm.autoWithdraw()
+	require.Equal(t, count, s.mockApi.messageCount())
+}
+
 type scaffold struct {
 	ctx        context.Context
 	ds         *ds_sync.MutexDatastore
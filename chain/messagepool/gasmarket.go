@@ -0,0 +1,178 @@
+package messagepool
+
+import (
+	"context"
+	"sort"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// DefaultGasMarketLookback is the number of recent tipsets the inclusions of
+// which are summarized when no lookback is given
+const DefaultGasMarketLookback = 10
+
+// MaxGasMarketLookback is the largest number of recent tipsets which can be
+// summarized
+const MaxGasMarketLookback = 120
+
+// GasMarket summarizes the effective gas premiums of the pending messages at
+// the base fee of the next block, and those paid by the messages included in
+// the lookback tipsets up to the current head.
+func (mp *MessagePool) GasMarket(ctx context.Context, lookback int) (*api.MpoolGasMarket, error) {
+	if lookback == 0 {
+		lookback = DefaultGasMarketLookback
+	}
+	if lookback < 0 || lookback > MaxGasMarketLookback {
+		return nil, xerrors.Errorf("lookback must be between 0 and %d", MaxGasMarketLookback)
+	}
+
+	pending, ts := mp.Pending(ctx)
+	if ts == nil {
+		return nil, xerrors.Errorf("message pool has no current tipset")
+	}
+
+	baseFee, err := mp.api.ChainComputeBaseFee(ctx, ts)
+	if err != nil {
+		return nil, xerrors.Errorf("computing basefee: %w", err)
+	}
+
+	out := &api.MpoolGasMarket{
+		TipSet:   ts.Key(),
+		Height:   ts.Height(),
+		BaseFee:  baseFee,
+		Included: []api.MpoolGasMarketEpoch{},
+	}
+
+	msgs := make([]*types.Message, 0, len(pending))
+	for _, m := range pending {
+		if m.Message.GasFeeCap.LessThan(baseFee) {
+			out.Underpriced++
+		}
+		msgs = append(msgs, &m.Message)
+	}
+	out.Pending = premiumDistribution(msgs, baseFee)
+
+	for i := 0; i < lookback && ts.Height() > 0; i++ {
+		cmsgs, err := mp.api.MessagesForTipset(ctx, ts)
+		if err != nil {
+			return nil, xerrors.Errorf("loading messages of tipset at %d: %w", ts.Height(), err)
+		}
+
+		msgs := make([]*types.Message, len(cmsgs))
+		for j, m := range cmsgs {
+			msgs[j] = m.VMMessage()
+		}
+
+		tsBaseFee := ts.Blocks()[0].ParentBaseFee
+		out.Included = append(out.Included, api.MpoolGasMarketEpoch{
+			Height:   ts.Height(),
+			BaseFee:  tsBaseFee,
+			Premiums: premiumDistribution(msgs, tsBaseFee),
+		})
+
+		ts, err = mp.api.LoadTipSet(ctx, ts.Parents())
+		if err != nil {
+			return nil, xerrors.Errorf("loading parent tipset: %w", err)
+		}
+	}
+
+	return out, nil
+}
+
+// GasMarketUpdates sends the summary of the gas market each time the message
+// pool moves to a new head.
+func (mp *MessagePool) GasMarketUpdates(ctx context.Context, lookback int) (<-chan api.MpoolGasMarket, error) {
+	first, err := mp.GasMarket(ctx, lookback)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan api.MpoolGasMarket, 1)
+	sub := mp.changes.Sub(headUpdates)
+
+	// coalesce the head changes, so a slow consumer doesn't block the pool
+	changed := make(chan struct{}, 1)
+	go func() {
+		for range sub {
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	go func() {
+		defer close(out)
+		defer mp.changes.Unsub(sub)
+
+		next := first
+		for {
+			select {
+			case out <- *next:
+			case <-ctx.Done():
+				return
+			case <-mp.closer:
+				return
+			}
+
+			select {
+			case <-changed:
+			case <-ctx.Done():
+				return
+			case <-mp.closer:
+				return
+			}
+
+			next, err = mp.GasMarket(ctx, lookback)
+			if err != nil {
+				log.Errorf("computing gas market: %s", err)
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// premiumDistribution returns the distribution of the effective premiums of
+// the messages at the base fee
+func premiumDistribution(msgs []*types.Message, baseFee abi.TokenAmount) api.GasPremiumDistribution {
+	out := api.GasPremiumDistribution{
+		Messages: len(msgs),
+		Min:      big.Zero(),
+		P25:      big.Zero(),
+		Median:   big.Zero(),
+		P75:      big.Zero(),
+		P90:      big.Zero(),
+		Max:      big.Zero(),
+	}
+	if len(msgs) == 0 {
+		return out
+	}
+
+	premiums := make([]abi.TokenAmount, len(msgs))
+	for i, m := range msgs {
+		premiums[i] = m.EffectiveGasPremium(baseFee)
+		out.GasLimit += m.GasLimit
+	}
+	sort.Slice(premiums, func(i, j int) bool {
+		return premiums[i].LessThan(premiums[j])
+	})
+
+	at := func(pct int) abi.TokenAmount {
+		return premiums[(len(premiums)-1)*pct/100]
+	}
+	out.Min = at(0)
+	out.P25 = at(25)
+	out.Median = at(50)
+	out.P75 = at(75)
+	out.P90 = at(90)
+	out.Max = at(100)
+	return out
+}
@@ -0,0 +1,86 @@
+// stm: #unit
+package messagepool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/wallet"
+)
+
+func TestGasMarket(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mp, tma := makeTestMpool()
+
+	w, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	require.NoError(t, err)
+	a1, err := w.WalletNew(ctx, types.KTSecp256k1)
+	require.NoError(t, err)
+	a2, err := w.WalletNew(ctx, types.KTSecp256k1)
+	require.NoError(t, err)
+	tma.setBalance(a1, 1)
+
+	// three messages paying 10, 20 and 30 are included in the first block
+	block := tma.nextBlock()
+	var included []*types.SignedMessage
+	for i := 0; i < 3; i++ {
+		included = append(included, makeTestMessage(w, a1, a2, uint64(i), 1_000_000, uint64(10*(i+1))))
+	}
+	tma.setBlockMessages(block, included...)
+	tma.setStateNonce(a1, 3)
+	tma.applyBlock(t, block)
+
+	// ten messages offering 1 to 10 are pending
+	for i := 0; i < 10; i++ {
+		mustAdd(t, mp, makeTestMessage(w, a1, a2, uint64(3+i), 2_000_000, uint64(i+1)))
+	}
+
+	updates, err := mp.GasMarketUpdates(ctx, 0)
+	require.NoError(t, err)
+	first := <-updates
+
+	// the base fee rises by 4, the fee cap of the messages offering less
+	// than 4 is now below it
+	tma.baseFee = types.NewInt(104)
+	gm, err := mp.GasMarket(ctx, 0)
+	require.NoError(t, err)
+
+	require.Equal(t, abi.ChainEpoch(1), gm.Height)
+	require.Equal(t, 10, gm.Pending.Messages)
+	require.Equal(t, int64(20_000_000), gm.Pending.GasLimit)
+	require.Equal(t, 3, gm.Underpriced)
+	require.Equal(t, types.NewInt(0), gm.Pending.Min)
+	require.Equal(t, types.NewInt(0), gm.Pending.P25)
+	require.Equal(t, types.NewInt(1), gm.Pending.Median)
+	require.Equal(t, types.NewInt(3), gm.Pending.P75)
+	require.Equal(t, types.NewInt(5), gm.Pending.P90)
+	require.Equal(t, types.NewInt(6), gm.Pending.Max)
+
+	// the lookback stops at genesis
+	require.Len(t, gm.Included, 1)
+	require.Equal(t, abi.ChainEpoch(1), gm.Included[0].Height)
+	require.Equal(t, 3, gm.Included[0].Premiums.Messages)
+	require.Equal(t, types.NewInt(10), gm.Included[0].Premiums.Min)
+	require.Equal(t, types.NewInt(20), gm.Included[0].Premiums.Median)
+	require.Equal(t, types.NewInt(30), gm.Included[0].Premiums.Max)
+
+	require.Equal(t, gm.Included, first.Included)
+	require.Equal(t, types.NewInt(1), first.Pending.Min)
+
+	// a new head is sent to the subscribers
+	tma.applyBlock(t, tma.nextBlock())
+	next := <-updates
+	require.Equal(t, abi.ChainEpoch(2), next.Height)
+	require.Len(t, next.Included, 2)
+	require.Zero(t, next.Included[0].Premiums.Messages)
+
+	_, err = mp.GasMarket(ctx, MaxGasMarketLookback+1)
+	require.Error(t, err)
+}
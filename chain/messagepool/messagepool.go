@@ -87,6 +87,7 @@ const (
 	localMsgsDs = "/mpool/local"
 
 	localUpdates = "update"
+	headUpdates  = "head"
 )
 
 // Journal event types.
@@ -423,6 +424,7 @@ func New(ctx context.Context, api Provider, ds dtypes.MetadataDS, us stmgr.Upgra
 		if err != nil {
 			log.Errorf("mpool head notif handler error: %+v", err)
 		}
+		mp.changes.Pub(struct{}{}, headUpdates)
 		return err
 	})
 
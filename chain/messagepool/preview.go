@@ -0,0 +1,158 @@
+package messagepool
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	tbig "github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// SelectMessagesPreview runs the message selection for a block on ts, like
+// SelectMessages, and explains its result: the reward of each selected
+// message, and why each of the other pending messages was left out.
+func (mp *MessagePool) SelectMessagesPreview(ctx context.Context, ts *types.TipSet, tq float64) (*api.MpoolSelectPreview, error) {
+	mp.curTsLk.Lock()
+	defer mp.curTsLk.Unlock()
+
+	mp.lk.Lock()
+	defer mp.lk.Unlock()
+
+	sm, err := mp.selectMessages(ctx, ts, tq)
+	if err != nil {
+		return nil, err
+	}
+
+	baseFee, err := mp.api.ChainComputeBaseFee(ctx, ts)
+	if err != nil {
+		return nil, xerrors.Errorf("computing basefee: %w", err)
+	}
+
+	pending, err := mp.getPendingMessages(ctx, mp.curTs, ts)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &api.MpoolSelectPreview{
+		TipSet:        ts.Key(),
+		TicketQuality: tq,
+		Strategy:      "optimal",
+		BaseFee:       baseFee,
+		Reward:        tbig.Zero(),
+		Selected:      []api.MpoolSelectedMessage{},
+		Excluded:      []api.MpoolExcludedMessage{},
+	}
+	if greedySelection(tq) {
+		out.Strategy = "greedy"
+	}
+
+	selected := map[cid.Cid]struct{}{}
+	if sm != nil {
+		for _, m := range sm.msgs {
+			selected[m.Cid()] = struct{}{}
+
+			reward := mp.getGasReward(m, baseFee)
+			out.Selected = append(out.Selected, api.MpoolSelectedMessage{
+				Cid:     m.Cid(),
+				Message: m,
+				Reward:  tbig.NewFromGo(reward),
+				GasPerf: mp.getGasPerf(reward, m.Message.GasLimit),
+			})
+			out.GasLimit += m.Message.GasLimit
+			out.Reward = tbig.Add(out.Reward, tbig.NewFromGo(reward))
+		}
+	}
+
+	for actor, mset := range pending {
+		out.Excluded = append(out.Excluded, mp.explainExclusions(actor, mset, selected, baseFee, ts)...)
+	}
+	sort.Slice(out.Excluded, func(i, j int) bool {
+		if out.Excluded[i].From != out.Excluded[j].From {
+			return out.Excluded[i].From.String() < out.Excluded[j].From.String()
+		}
+		return out.Excluded[i].Nonce < out.Excluded[j].Nonce
+	})
+
+	return out, nil
+}
+
+// explainExclusions returns the pending messages of an actor which weren't
+// selected, with the reason why.
+func (mp *MessagePool) explainExclusions(actor address.Address, mset map[uint64]*types.SignedMessage, selected map[cid.Cid]struct{}, baseFee types.BigInt, ts *types.TipSet) []api.MpoolExcludedMessage {
+	msgs := make([]*types.SignedMessage, 0, len(mset))
+	for _, m := range mset {
+		msgs = append(msgs, m)
+	}
+	sort.Slice(msgs, func(i, j int) bool {
+		return msgs[i].Message.Nonce < msgs[j].Message.Nonce
+	})
+
+	var out []api.MpoolExcludedMessage
+	exclude := func(m *types.SignedMessage, reason string) {
+		reward := mp.getGasReward(m, baseFee)
+		out = append(out, api.MpoolExcludedMessage{
+			Cid:     m.Cid(),
+			From:    m.Message.From,
+			Nonce:   m.Message.Nonce,
+			Reward:  tbig.NewFromGo(reward),
+			GasPerf: mp.getGasPerf(reward, m.Message.GasLimit),
+			Reason:  reason,
+		})
+	}
+
+	a, err := mp.api.GetActorAfter(actor, ts)
+	if err != nil {
+		for _, m := range msgs {
+			exclude(m, fmt.Sprintf("failed to load the sender: %s", err))
+		}
+		return out
+	}
+
+	skip, end, _, reason := mp.chainableMessages(actor, a, msgs, baseFee, ts)
+
+	for _, m := range msgs[:skip] {
+		exclude(m, fmt.Sprintf("nonce below the sender nonce %d", a.Nonce))
+	}
+
+	// once a message is left out, none of the following ones can be included
+	var blocking *types.SignedMessage
+	for i, m := range msgs[skip:end] {
+		if _, ok := selected[m.Cid()]; ok {
+			continue
+		}
+		if blocking != nil {
+			exclude(m, fmt.Sprintf("depends on excluded message with nonce %d", blocking.Message.Nonce))
+			continue
+		}
+		blocking = m
+
+		switch {
+		case i >= build.BlockMessageLimit:
+			exclude(m, "the sender has more pending messages than fit in a block")
+		case mp.getGasReward(m, baseFee).Cmp(big.NewInt(0)) < 0:
+			exclude(m, "fee cap below the base fee")
+		default:
+			exclude(m, "outbid: the block was filled with messages of higher gas performance")
+		}
+	}
+
+	for _, m := range msgs[end:] {
+		if blocking != nil {
+			exclude(m, fmt.Sprintf("depends on excluded message with nonce %d", blocking.Message.Nonce))
+			continue
+		}
+		blocking = m
+		exclude(m, reason)
+	}
+
+	return out
+}
@@ -2,6 +2,7 @@ package messagepool
 
 import (
 	"context"
+	"fmt"
 	"math/big"
 	"math/rand"
 	"sort"
@@ -47,6 +48,21 @@ func (mp *MessagePool) SelectMessages(ctx context.Context, ts *types.TipSet, tq
 	mp.lk.Lock()
 	defer mp.lk.Unlock()
 
+	sm, err := mp.selectMessages(ctx, ts, tq)
+	if err != nil {
+		return nil, err
+	}
+
+	if sm == nil {
+		return nil, nil
+	}
+
+	return sm.msgs, nil
+}
+
+// selectMessages selects the messages for a block on ts; the caller must hold
+// curTsLk and lk.
+func (mp *MessagePool) selectMessages(ctx context.Context, ts *types.TipSet, tq float64) (*selectedMessages, error) {
 	// See if we need to prune before selection; excessive buildup can lead to slow selection,
 	// so prune if we have too many messages (ignoring the cooldown).
 	mpCfg := mp.getConfig()
@@ -62,7 +78,7 @@ func (mp *MessagePool) SelectMessages(ctx context.Context, ts *types.TipSet, tq
 	// first block will always have higher effective performance
 	var sm *selectedMessages
 	var err error
-	if tq > 0.84 {
+	if greedySelection(tq) {
 		sm, err = mp.selectMessagesGreedy(ctx, mp.curTs, ts)
 	} else {
 		sm, err = mp.selectMessagesOptimal(ctx, mp.curTs, ts, tq)
@@ -82,7 +98,11 @@ func (mp *MessagePool) SelectMessages(ctx context.Context, ts *types.TipSet, tq
 		sm.msgs = sm.msgs[:build.BlockMessageLimit]
 	}
 
-	return sm.msgs, nil
+	return sm, nil
+}
+
+func greedySelection(tq float64) bool {
+	return tq > 0.84
 }
 
 type selectedMessages struct {
@@ -771,39 +791,18 @@ func (*MessagePool) getGasPerf(gasReward *big.Int, gasLimit int64) float64 {
 	return r
 }
 
-func (mp *MessagePool) createMessageChains(actor address.Address, mset map[uint64]*types.SignedMessage, baseFee types.BigInt, ts *types.TipSet) []*msgChain {
-	// collect all messages
-	msgs := make([]*types.SignedMessage, 0, len(mset))
-	for _, m := range mset {
-		msgs = append(msgs, m)
-	}
-
-	// sort by nonce
-	sort.Slice(msgs, func(i, j int) bool {
-		return msgs[i].Message.Nonce < msgs[j].Message.Nonce
-	})
-
-	// sanity checks:
-	// - there can be no gaps in nonces, starting from the current actor nonce
-	//   if there is a gap, drop messages after the gap, we can't include them
-	// - all messages must have minimum gas and the total gas for the candidate messages
-	//   cannot exceed the block limit; drop all messages that exceed the limit
-	// - the total gasReward cannot exceed the actor's balance; drop all messages that exceed
-	//   the balance
-	a, err := mp.api.GetActorAfter(actor, ts)
-	if err != nil {
-		log.Errorf("failed to load actor state, not building chain for %s: %v", actor, err)
-		return nil
-	}
-
+// chainableMessages applies the sanity checks of chain construction to the
+// nonce sorted messages of an actor. The messages in msgs[:skip] have nonces
+// below the actor nonce, and the messages in msgs[end:] can't be included, the
+// first one for the returned reason. The rewards of the messages in
+// msgs[skip:end] are returned.
+func (mp *MessagePool) chainableMessages(actor address.Address, a *types.Actor, msgs []*types.SignedMessage, baseFee types.BigInt, ts *types.TipSet) (skip, end int, rewards []*big.Int, reason string) {
 	curNonce := a.Nonce
 	balance := a.Balance.Int
 	gasLimit := int64(0)
-	skip := 0
-	i := 0
-	rewards := make([]*big.Int, 0, len(msgs))
-	for i = 0; i < len(msgs); i++ {
-		m := msgs[i]
+	rewards = make([]*big.Int, 0, len(msgs))
+	for end = 0; end < len(msgs); end++ {
+		m := msgs[end]
 
 		if m.Message.Nonce < curNonce {
 			log.Warnf("encountered message from actor %s with nonce (%d) less than the current nonce (%d)",
@@ -813,23 +812,23 @@ func (mp *MessagePool) createMessageChains(actor address.Address, mset map[uint6
 		}
 
 		if m.Message.Nonce != curNonce {
-			break
+			return skip, end, rewards, fmt.Sprintf("nonce gap: expected nonce %d", curNonce)
 		}
 		curNonce++
 
 		minGas := vm.PricelistByEpoch(ts.Height()).OnChainMessage(m.ChainLength()).Total()
 		if m.Message.GasLimit < minGas {
-			break
+			return skip, end, rewards, fmt.Sprintf("gas limit below the minimum of %d", minGas)
 		}
 
 		gasLimit += m.Message.GasLimit
 		if gasLimit > build.BlockGasLimit {
-			break
+			return skip, end, rewards, "the gas limits of the pending messages of the sender exceed the block gas limit"
 		}
 
 		required := m.Message.RequiredFunds().Int
 		if balance.Cmp(required) < 0 {
-			break
+			return skip, end, rewards, "insufficient balance to pay for gas"
 		}
 
 		balance = new(big.Int).Sub(balance, required)
@@ -841,6 +840,36 @@ func (mp *MessagePool) createMessageChains(actor address.Address, mset map[uint6
 		rewards = append(rewards, gasReward)
 	}
 
+	return skip, end, rewards, ""
+}
+
+func (mp *MessagePool) createMessageChains(actor address.Address, mset map[uint64]*types.SignedMessage, baseFee types.BigInt, ts *types.TipSet) []*msgChain {
+	// collect all messages
+	msgs := make([]*types.SignedMessage, 0, len(mset))
+	for _, m := range mset {
+		msgs = append(msgs, m)
+	}
+
+	// sort by nonce
+	sort.Slice(msgs, func(i, j int) bool {
+		return msgs[i].Message.Nonce < msgs[j].Message.Nonce
+	})
+
+	// sanity checks:
+	// - there can be no gaps in nonces, starting from the current actor nonce
+	//   if there is a gap, drop messages after the gap, we can't include them
+	// - all messages must have minimum gas and the total gas for the candidate messages
+	//   cannot exceed the block limit; drop all messages that exceed the limit
+	// - the total gasReward cannot exceed the actor's balance; drop all messages that exceed
+	//   the balance
+	a, err := mp.api.GetActorAfter(actor, ts)
+	if err != nil {
+		log.Errorf("failed to load actor state, not building chain for %s: %v", actor, err)
+		return nil
+	}
+
+	skip, i, rewards, _ := mp.chainableMessages(actor, a, msgs, baseFee, ts)
+
 	// check we have a sane set of messages to construct the chains
 	if i > skip {
 		msgs = msgs[skip:i]
@@ -1690,3 +1690,89 @@ readLoop:
 	}
 
 }
+
+func TestSelectMessagesPreview(t *testing.T) {
+	mp, tma := makeTestMpool()
+
+	w, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var actors []address.Address
+	for i := 0; i < 4; i++ {
+		a, err := w.WalletNew(context.Background(), types.KTSecp256k1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tma.setBalance(a, 1) // in FIL
+		actors = append(actors, a)
+	}
+	a1, a2, a3, a4 := actors[0], actors[1], actors[2], actors[3]
+
+	block := tma.nextBlock()
+	ts := mock.TipSet(block)
+	tma.applyBlock(t, block)
+
+	gasLimit := gasguess.Costs[gasguess.CostKey{Code: builtin2.StorageMarketActorCodeID, M: 2}]
+
+	// a1 pays well
+	for i := 0; i < 3; i++ {
+		mustAdd(t, mp, makeTestMessage(w, a1, a2, uint64(i), gasLimit, 100))
+	}
+	// a2 has a nonce gap
+	mustAdd(t, mp, makeTestMessage(w, a2, a1, 0, gasLimit, 100))
+	mustAdd(t, mp, makeTestMessage(w, a2, a1, 2, gasLimit, 100))
+	// a3 can't pay for its messages
+	mustAdd(t, mp, makeTestMessage(w, a3, a1, 0, gasLimit, 100))
+	mustAdd(t, mp, makeTestMessage(w, a3, a1, 1, gasLimit, 100))
+	tma.setBalance(a3, 0)
+	// a4's fee cap is below the base fee
+	mustAdd(t, mp, makeTestMessage(w, a4, a1, 0, gasLimit, 1))
+	tma.baseFee = types.NewInt(150)
+
+	preview, err := mp.SelectMessagesPreview(context.Background(), ts, 1.0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if preview.Strategy != "greedy" {
+		t.Fatalf("expected greedy selection, got %s", preview.Strategy)
+	}
+	if len(preview.Selected) != 4 {
+		t.Fatalf("expected 4 selected messages, got %d", len(preview.Selected))
+	}
+
+	reward := types.NewInt(0)
+	for _, m := range preview.Selected {
+		// premium capped by the fee cap: 200 - 150
+		if !m.Reward.Equals(types.NewInt(uint64(50 * gasLimit))) {
+			t.Fatalf("unexpected reward %s", m.Reward)
+		}
+		reward = types.BigAdd(reward, m.Reward)
+	}
+	if !preview.Reward.Equals(reward) {
+		t.Fatalf("expected total reward %s, got %s", reward, preview.Reward)
+	}
+	if preview.GasLimit != 4*gasLimit {
+		t.Fatalf("expected gas limit %d, got %d", 4*gasLimit, preview.GasLimit)
+	}
+
+	reasons := map[address.Address][]string{}
+	for _, m := range preview.Excluded {
+		reasons[m.From] = append(reasons[m.From], m.Reason)
+	}
+	expected := map[address.Address][]string{
+		a2: {"nonce gap: expected nonce 1"},
+		a3: {"insufficient balance to pay for gas", "depends on excluded message with nonce 0"},
+		a4: {"fee cap below the base fee"},
+	}
+	if len(reasons) != len(expected) {
+		t.Fatalf("expected exclusions for %d senders, got %v", len(expected), reasons)
+	}
+	for a, exp := range expected {
+		if fmt.Sprint(reasons[a]) != fmt.Sprint(exp) {
+			t.Fatalf("expected exclusions %v for %s, got %v", exp, a, reasons[a])
+		}
+	}
+}
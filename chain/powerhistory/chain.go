@@ -0,0 +1,77 @@
+package powerhistory
+
+import (
+	"context"
+
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors/adt"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/reward"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// ChainAPI is the subset of the full node API the miners are sampled with.
+type ChainAPI interface {
+	blockstore.ChainIO
+
+	ChainGetTipSet(context.Context, types.TipSetKey) (*types.TipSet, error)
+	StateGetActor(context.Context, address.Address, types.TipSetKey) (*types.Actor, error)
+	StateLookupID(context.Context, address.Address, types.TipSetKey) (address.Address, error)
+	StateMinerPower(context.Context, address.Address, types.TipSetKey) (*api.MinerPower, error)
+}
+
+type apiChain struct {
+	ChainAPI
+
+	store adt.Store
+}
+
+// NewChain returns the Chain read through the API.
+func NewChain(ctx context.Context, a ChainAPI) Chain {
+	return &apiChain{
+		ChainAPI: a,
+		store:    adt.WrapStore(ctx, cbor.NewCborStore(blockstore.NewAPIBlockstore(a))),
+	}
+}
+
+func (c *apiChain) MinerFunds(ctx context.Context, m address.Address, tsk types.TipSetKey) (abi.TokenAmount, miner.LockedFunds, error) {
+	act, err := c.StateGetActor(ctx, m, tsk)
+	if err != nil {
+		return big.Zero(), miner.LockedFunds{}, xerrors.Errorf("loading miner actor: %w", err)
+	}
+	mas, err := miner.Load(c.store, act)
+	if err != nil {
+		return big.Zero(), miner.LockedFunds{}, xerrors.Errorf("loading miner state: %w", err)
+	}
+	funds, err := mas.LockedFunds()
+	if err != nil {
+		return big.Zero(), miner.LockedFunds{}, err
+	}
+	return act.Balance, funds, nil
+}
+
+func (c *apiChain) BlockReward(ctx context.Context, tsk types.TipSetKey) (abi.TokenAmount, error) {
+	// the blocks of a tipset are rewarded in its parent state
+	act, err := c.StateGetActor(ctx, reward.Address, tsk)
+	if err != nil {
+		return big.Zero(), xerrors.Errorf("loading reward actor: %w", err)
+	}
+	rs, err := reward.Load(c.store, act)
+	if err != nil {
+		return big.Zero(), xerrors.Errorf("loading reward state: %w", err)
+	}
+	r, err := rs.ThisEpochReward()
+	if err != nil {
+		return big.Zero(), err
+	}
+	return big.Div(r, big.NewInt(int64(build.BlocksPerEpoch))), nil
+}
@@ -0,0 +1,436 @@
+// Package powerhistory maintains time series of the power, pledge and balance
+// of a set of miners, sampled at regular epoch intervals, and of the blocks
+// they win, to query their history without loading historical states.
+package powerhistory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+
+	logging "github.com/ipfs/go-log/v2"
+	_ "github.com/mattn/go-sqlite3"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/events"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var log = logging.Logger("powerhistory")
+
+const DBName = "powerhistory.db"
+
+// DefaultInterval is the number of epochs between two samples by default,
+// about an hour.
+const DefaultInterval = abi.ChainEpoch(120)
+
+var dbDefs = []string{
+	`CREATE TABLE IF NOT EXISTS miner_samples (
+		tipset_key BLOB NOT NULL,
+		height INTEGER NOT NULL,
+		miner TEXT NOT NULL,
+		raw_power TEXT NOT NULL,
+		qa_power TEXT NOT NULL,
+		total_raw_power TEXT NOT NULL,
+		total_qa_power TEXT NOT NULL,
+		balance TEXT NOT NULL,
+		initial_pledge TEXT NOT NULL,
+		vesting_funds TEXT NOT NULL,
+		precommit_deposits TEXT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS miner_samples_miner_height ON miner_samples (miner, height)`,
+	`CREATE INDEX IF NOT EXISTS miner_samples_tipset_key ON miner_samples (tipset_key)`,
+	`CREATE TABLE IF NOT EXISTS miner_wins (
+		tipset_key BLOB NOT NULL,
+		height INTEGER NOT NULL,
+		miner TEXT NOT NULL,
+		win_count INTEGER NOT NULL,
+		reward TEXT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS miner_wins_miner_height ON miner_wins (miner, height)`,
+	`CREATE INDEX IF NOT EXISTS miner_wins_tipset_key ON miner_wins (tipset_key)`,
+	`CREATE TABLE IF NOT EXISTS indexed_tipsets (
+		tipset_key BLOB PRIMARY KEY ON CONFLICT REPLACE,
+		height INTEGER NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS _meta (
+		version UINT64 NOT NULL UNIQUE
+	)`,
+	`INSERT OR IGNORE INTO _meta (version) VALUES (1)`,
+}
+
+const (
+	dbqInsertSample = `INSERT INTO miner_samples (tipset_key, height, miner, raw_power, qa_power, total_raw_power, total_qa_power,
+		balance, initial_pledge, vesting_funds, precommit_deposits) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	dbqInsertWin     = "INSERT INTO miner_wins (tipset_key, height, miner, win_count, reward) VALUES (?, ?, ?, ?, ?)"
+	dbqInsertTipSet  = "INSERT INTO indexed_tipsets (tipset_key, height) VALUES (?, ?)"
+	dbqDeleteSamples = "DELETE FROM miner_samples WHERE tipset_key = ?"
+	dbqDeleteWins    = "DELETE FROM miner_wins WHERE tipset_key = ?"
+	dbqDeleteTipSet  = "DELETE FROM indexed_tipsets WHERE tipset_key = ?"
+	dbqHasTipSet     = "SELECT COUNT(*) FROM indexed_tipsets WHERE tipset_key = ?"
+	dbqSelectSamples = `SELECT tipset_key, height, raw_power, qa_power, total_raw_power, total_qa_power,
+		balance, initial_pledge, vesting_funds, precommit_deposits FROM miner_samples WHERE miner = ? AND height >= ? AND height <= ? ORDER BY height`
+	dbqPrevSample = "SELECT MAX(height) FROM miner_samples WHERE miner = ? AND height < ?"
+	dbqSelectWins = "SELECT height, win_count, reward FROM miner_wins WHERE miner = ? AND height > ? AND height <= ?"
+)
+
+// History is a tipset observer, see events.Events.Observe, sampling the
+// watched miners in the applied tipsets crossing a multiple of the interval,
+// and recording the blocks they win. Tipsets applied while the node wasn't
+// running can be indexed with Backfill.
+type History struct {
+	chain    Chain
+	db       *sql.DB
+	interval abi.ChainEpoch
+
+	// addresses of the watched miners as configured, and their ID addresses
+	// once resolved
+	watched  []address.Address
+	resolved map[address.Address]address.Address
+
+	lk sync.Mutex
+}
+
+var _ events.TipSetObserver = (*History)(nil)
+
+func NewHistory(path string, c Chain, miners []address.Address, interval abi.ChainEpoch) (*History, error) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	db, err := sql.Open("sqlite3", path+"?mode=rwc")
+	if err != nil {
+		return nil, xerrors.Errorf("opening power history database: %w", err)
+	}
+
+	for _, stmt := range dbDefs {
+		if _, err := db.Exec(stmt); err != nil {
+			_ = db.Close()
+			return nil, xerrors.Errorf("executing sql statement '%s': %w", stmt, err)
+		}
+	}
+
+	return &History{
+		chain:    c,
+		db:       db,
+		interval: interval,
+		watched:  miners,
+		resolved: map[address.Address]address.Address{},
+	}, nil
+}
+
+func (h *History) Close() error {
+	return h.db.Close()
+}
+
+func (h *History) Apply(ctx context.Context, from, to *types.TipSet) error {
+	h.lk.Lock()
+	defer h.lk.Unlock()
+
+	// applied tipsets follow each other, from is the parent of to
+	if err := h.index(ctx, to, from.Height()); err != nil {
+		return xerrors.Errorf("indexing power history of %s: %w", to.Key(), err)
+	}
+	return nil
+}
+
+func (h *History) Revert(ctx context.Context, from, to *types.TipSet) error {
+	h.lk.Lock()
+	defer h.lk.Unlock()
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := deleteTipSet(ctx, tx, from.Key().Bytes()); err != nil {
+		_ = tx.Rollback()
+		return xerrors.Errorf("reverting power history of %s: %w", from.Key(), err)
+	}
+	return tx.Commit()
+}
+
+func deleteTipSet(ctx context.Context, tx *sql.Tx, tsk []byte) error {
+	for _, q := range []string{dbqDeleteSamples, dbqDeleteWins, dbqDeleteTipSet} {
+		if _, err := tx.ExecContext(ctx, q, tsk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// miners returns the ID addresses of the watched miners which exist in the
+// state of tsk.
+func (h *History) miners(ctx context.Context, tsk types.TipSetKey) map[address.Address]struct{} {
+	out := make(map[address.Address]struct{}, len(h.watched))
+	for _, a := range h.watched {
+		id, ok := h.resolved[a]
+		if !ok {
+			var err error
+			if id, err = h.chain.StateLookupID(ctx, a, tsk); err != nil {
+				log.Debugw("looking up miner ID", "miner", a, "error", err)
+				continue
+			}
+			h.resolved[a] = id
+		}
+		out[id] = struct{}{}
+	}
+	return out
+}
+
+// index records the blocks won by the watched miners in ts, and samples them
+// when ts is the first tipset of an interval, replacing any previous record of
+// the tipset.
+func (h *History) index(ctx context.Context, ts *types.TipSet, parentHeight abi.ChainEpoch) error {
+	miners := h.miners(ctx, ts.Key())
+
+	wins := map[address.Address]int64{}
+	for _, b := range ts.Blocks() {
+		if _, ok := miners[b.Miner]; ok && b.ElectionProof != nil {
+			wins[b.Miner] += b.ElectionProof.WinCount
+		}
+	}
+
+	var reward abi.TokenAmount
+	if len(wins) > 0 {
+		var err error
+		if reward, err = h.chain.BlockReward(ctx, ts.Key()); err != nil {
+			return xerrors.Errorf("loading block reward: %w", err)
+		}
+	}
+
+	var samples []api.MinerPowerSample
+	if ts.Height()/h.interval != parentHeight/h.interval {
+		for m := range miners {
+			s, err := h.sample(ctx, m, ts.Key())
+			if err != nil {
+				log.Debugw("sampling miner", "miner", m, "height", ts.Height(), "error", err)
+				continue
+			}
+			samples = append(samples, s)
+		}
+	}
+
+	tsk := ts.Key().Bytes()
+	height := int64(ts.Height())
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := deleteTipSet(ctx, tx, tsk); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	for m, n := range wins {
+		if _, err := tx.ExecContext(ctx, dbqInsertWin, tsk, height, m.String(), n, big.Mul(reward, big.NewInt(n)).String()); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	for _, s := range samples {
+		if _, err := tx.ExecContext(ctx, dbqInsertSample, tsk, height, s.Miner.String(),
+			s.RawBytePower.String(), s.QualityAdjPower.String(), s.TotalRawBytePower.String(), s.TotalQualityAdjPower.String(),
+			s.Balance.String(), s.InitialPledge.String(), s.VestingFunds.String(), s.PreCommitDeposits.String()); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, dbqInsertTipSet, tsk, height); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (h *History) sample(ctx context.Context, m address.Address, tsk types.TipSetKey) (api.MinerPowerSample, error) {
+	pow, err := h.chain.StateMinerPower(ctx, m, tsk)
+	if err != nil {
+		return api.MinerPowerSample{}, xerrors.Errorf("loading power: %w", err)
+	}
+	balance, funds, err := h.chain.MinerFunds(ctx, m, tsk)
+	if err != nil {
+		return api.MinerPowerSample{}, xerrors.Errorf("loading funds: %w", err)
+	}
+
+	return api.MinerPowerSample{
+		Miner:                m,
+		RawBytePower:         pow.MinerPower.RawBytePower,
+		QualityAdjPower:      pow.MinerPower.QualityAdjPower,
+		TotalRawBytePower:    pow.TotalPower.RawBytePower,
+		TotalQualityAdjPower: pow.TotalPower.QualityAdjPower,
+		Balance:              balance,
+		InitialPledge:        funds.InitialPledgeRequirement,
+		VestingFunds:         funds.VestingFunds,
+		PreCommitDeposits:    funds.PreCommitDeposits,
+	}, nil
+}
+
+// Backfill indexes the tipsets from head down to minHeight which aren't
+// indexed yet, such as the tipsets applied while the node wasn't running.
+func (h *History) Backfill(ctx context.Context, head *types.TipSet, minHeight abi.ChainEpoch) error {
+	var indexed int
+	for ts := head; ts.Height() >= minHeight && ts.Height() > 0; {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		pts, err := h.chain.ChainGetTipSet(ctx, ts.Parents())
+		if err != nil {
+			return xerrors.Errorf("loading parent of %s: %w", ts.Key(), err)
+		}
+
+		var n int
+		if err := h.db.QueryRowContext(ctx, dbqHasTipSet, ts.Key().Bytes()).Scan(&n); err != nil {
+			return xerrors.Errorf("looking up %s: %w", ts.Key(), err)
+		}
+		if n == 0 {
+			h.lk.Lock()
+			err := h.index(ctx, ts, pts.Height())
+			h.lk.Unlock()
+			if err != nil {
+				return xerrors.Errorf("indexing power history of %s: %w", ts.Key(), err)
+			}
+			indexed++
+		}
+
+		ts = pts
+	}
+
+	if indexed > 0 {
+		log.Infow("backfilled power history", "tipsets", indexed, "from", head.Height(), "to", minHeight)
+	}
+	return nil
+}
+
+// Watched returns whether the miner of the given addresses, its ID and the
+// address it was looked up with, is sampled.
+func (h *History) Watched(addrs ...address.Address) bool {
+	h.lk.Lock()
+	defer h.lk.Unlock()
+
+	for _, w := range h.watched {
+		for _, a := range addrs {
+			if a == w || a == h.resolved[w] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Query returns the samples of the miner of ID address m taken between the
+// heights from and to, inclusive, oldest first. A to of 0 means no upper bound.
+// The blocks won are counted since the previous sample.
+func (h *History) Query(ctx context.Context, m address.Address, from, to abi.ChainEpoch) ([]api.MinerPowerSample, error) {
+	if to <= 0 {
+		to = abi.ChainEpoch(1<<63 - 1)
+	}
+	if from > to {
+		return nil, xerrors.Errorf("from height %d is above to height %d", from, to)
+	}
+
+	rows, err := h.db.QueryContext(ctx, dbqSelectSamples, m.String(), int64(from), int64(to))
+	if err != nil {
+		return nil, xerrors.Errorf("querying power history: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	out := []api.MinerPowerSample{}
+	for rows.Next() {
+		var (
+			tsk    []byte
+			height int64
+			vals   [8]string
+			s      = api.MinerPowerSample{Miner: m, Rewards: big.Zero()}
+		)
+		if err := rows.Scan(&tsk, &height, &vals[0], &vals[1], &vals[2], &vals[3], &vals[4], &vals[5], &vals[6], &vals[7]); err != nil {
+			return nil, err
+		}
+
+		s.Height = abi.ChainEpoch(height)
+		if s.TipSet, err = types.TipSetKeyFromBytes(tsk); err != nil {
+			return nil, xerrors.Errorf("decoding tipset key: %w", err)
+		}
+		for i, f := range []*big.Int{&s.RawBytePower, &s.QualityAdjPower, &s.TotalRawBytePower, &s.TotalQualityAdjPower,
+			&s.Balance, &s.InitialPledge, &s.VestingFunds, &s.PreCommitDeposits} {
+			if *f, err = big.FromString(vals[i]); err != nil {
+				return nil, xerrors.Errorf("decoding %q: %w", vals[i], err)
+			}
+		}
+
+		out = append(out, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return out, nil
+	}
+
+	if err := h.addWins(ctx, m, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// addWins counts the blocks won by m in the samples, since the sample before
+// each of them.
+func (h *History) addWins(ctx context.Context, m address.Address, samples []api.MinerPowerSample) error {
+	var prev sql.NullInt64
+	if err := h.db.QueryRowContext(ctx, dbqPrevSample, m.String(), int64(samples[0].Height)).Scan(&prev); err != nil {
+		return xerrors.Errorf("querying previous sample: %w", err)
+	}
+	since := samples[0].Height - h.interval
+	if prev.Valid {
+		since = abi.ChainEpoch(prev.Int64)
+	}
+
+	rows, err := h.db.QueryContext(ctx, dbqSelectWins, m.String(), int64(since), int64(samples[len(samples)-1].Height))
+	if err != nil {
+		return xerrors.Errorf("querying blocks won: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	for rows.Next() {
+		var (
+			height, n int64
+			rs        string
+		)
+		if err := rows.Scan(&height, &n, &rs); err != nil {
+			return err
+		}
+		reward, err := big.FromString(rs)
+		if err != nil {
+			return xerrors.Errorf("decoding reward %q: %w", rs, err)
+		}
+
+		// the first sample at or above the win
+		i := sort.Search(len(samples), func(i int) bool {
+			return samples[i].Height >= abi.ChainEpoch(height)
+		})
+		samples[i].BlocksWon += n
+		samples[i].Rewards = big.Add(samples[i].Rewards, reward)
+	}
+	return rows.Err()
+}
+
+// Chain is the chain state the miners are sampled from.
+type Chain interface {
+	ChainGetTipSet(context.Context, types.TipSetKey) (*types.TipSet, error)
+	StateLookupID(context.Context, address.Address, types.TipSetKey) (address.Address, error)
+	StateMinerPower(context.Context, address.Address, types.TipSetKey) (*api.MinerPower, error)
+	// MinerFunds returns the balance and the locked funds of a miner in the
+	// state of tsk.
+	MinerFunds(ctx context.Context, m address.Address, tsk types.TipSetKey) (abi.TokenAmount, miner.LockedFunds, error)
+	// BlockReward returns the reward of a block with a win count of 1 in the
+	// tipset tsk.
+	BlockReward(ctx context.Context, tsk types.TipSetKey) (abi.TokenAmount, error)
+}
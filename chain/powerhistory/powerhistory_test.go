@@ -0,0 +1,166 @@
+// stm: #unit
+package powerhistory
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/power"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+var (
+	minerA = mock.Address(1001)
+	minerB = mock.Address(1002)
+	// the robust address of minerA
+	robustA, _ = address.NewActorAddress([]byte("miner-a"))
+)
+
+type fakeChain struct {
+	tipsets map[types.TipSetKey]*types.TipSet
+}
+
+func (fc *fakeChain) mk(parent *types.TipSet, nonce uint64, m address.Address, winCount int64) *types.TipSet {
+	blk := mock.MkBlock(parent, 1, nonce)
+	blk.Miner = m
+	blk.ElectionProof.WinCount = winCount
+	ts := mock.TipSet(blk)
+	fc.tipsets[ts.Key()] = ts
+	return ts
+}
+
+func (fc *fakeChain) height(tsk types.TipSetKey) abi.ChainEpoch {
+	return fc.tipsets[tsk].Height()
+}
+
+func (fc *fakeChain) ChainGetTipSet(_ context.Context, tsk types.TipSetKey) (*types.TipSet, error) {
+	return fc.tipsets[tsk], nil
+}
+
+func (fc *fakeChain) StateLookupID(_ context.Context, a address.Address, _ types.TipSetKey) (address.Address, error) {
+	if a == robustA {
+		return minerA, nil
+	}
+	return a, nil
+}
+
+// the power of the miners is 1000 bytes per epoch
+func (fc *fakeChain) StateMinerPower(_ context.Context, m address.Address, tsk types.TipSetKey) (*api.MinerPower, error) {
+	h := int64(fc.height(tsk))
+	return &api.MinerPower{
+		MinerPower: power.Claim{RawBytePower: big.NewInt(h * 1000), QualityAdjPower: big.NewInt(h * 10000)},
+		TotalPower: power.Claim{RawBytePower: big.NewInt(h * 2000), QualityAdjPower: big.NewInt(h * 20000)},
+	}, nil
+}
+
+func (fc *fakeChain) MinerFunds(_ context.Context, m address.Address, tsk types.TipSetKey) (abi.TokenAmount, miner.LockedFunds, error) {
+	h := int64(fc.height(tsk))
+	return big.NewInt(h * 100), miner.LockedFunds{
+		VestingFunds:             big.NewInt(h * 10),
+		InitialPledgeRequirement: big.NewInt(h * 20),
+		PreCommitDeposits:        big.NewInt(h),
+	}, nil
+}
+
+func (fc *fakeChain) BlockReward(context.Context, types.TipSetKey) (abi.TokenAmount, error) {
+	return big.NewInt(100), nil
+}
+
+func TestHistory(t *testing.T) {
+	ctx := context.Background()
+
+	fc := &fakeChain{tipsets: map[types.TipSetKey]*types.TipSet{}}
+	genesis := fc.mk(nil, 0, minerA, 0)
+	ts1 := fc.mk(genesis, 1, minerA, 1)
+	ts2 := fc.mk(ts1, 2, minerB, 1)
+	ts3 := fc.mk(ts2, 3, minerA, 2)
+	ts4 := fc.mk(ts3, 4, minerA, 1)
+	// a fork of ts4
+	ts4b := fc.mk(ts3, 5, minerB, 1)
+
+	h, err := NewHistory(filepath.Join(t.TempDir(), DBName), fc, []address.Address{robustA}, 2)
+	require.NoError(t, err)
+	defer h.Close() //nolint:errcheck
+
+	for _, ts := range []*types.TipSet{ts1, ts2, ts3, ts4} {
+		pts, err := fc.ChainGetTipSet(ctx, ts.Parents())
+		require.NoError(t, err)
+		require.NoError(t, h.Apply(ctx, pts, ts))
+	}
+
+	require.True(t, h.Watched(robustA))
+	require.True(t, h.Watched(minerA))
+	require.False(t, h.Watched(minerB))
+
+	checkSamples := func(h *History, blocksAt4 int64) {
+		samples, err := h.Query(ctx, minerA, 0, 0)
+		require.NoError(t, err)
+		require.Len(t, samples, 2)
+
+		s := samples[0]
+		require.Equal(t, ts2.Height(), s.Height)
+		require.Equal(t, ts2.Key(), s.TipSet)
+		require.Equal(t, minerA, s.Miner)
+		require.Equal(t, big.NewInt(2000), s.RawBytePower)
+		require.Equal(t, big.NewInt(20000), s.QualityAdjPower)
+		require.Equal(t, big.NewInt(40000), s.TotalQualityAdjPower)
+		require.Equal(t, big.NewInt(200), s.Balance)
+		require.Equal(t, big.NewInt(40), s.InitialPledge)
+		require.Equal(t, big.NewInt(20), s.VestingFunds)
+		require.Equal(t, big.NewInt(2), s.PreCommitDeposits)
+		// the blocks of ts1
+		require.Equal(t, int64(1), s.BlocksWon)
+		require.Equal(t, big.NewInt(100), s.Rewards)
+
+		s = samples[1]
+		require.Equal(t, ts4.Height(), s.Height)
+		require.Equal(t, blocksAt4, s.BlocksWon)
+		require.Equal(t, big.NewInt(blocksAt4*100), s.Rewards)
+	}
+	// the blocks of ts3 and ts4
+	checkSamples(h, 3)
+
+	// the blocks won since the previous sample are counted when querying from
+	// a later height
+	samples, err := h.Query(ctx, minerA, 3, 0)
+	require.NoError(t, err)
+	require.Len(t, samples, 1)
+	require.Equal(t, int64(3), samples[0].BlocksWon)
+
+	samples, err = h.Query(ctx, minerA, 0, 3)
+	require.NoError(t, err)
+	require.Len(t, samples, 1)
+	require.Equal(t, ts2.Height(), samples[0].Height)
+
+	// unwatched miners aren't sampled
+	samples, err = h.Query(ctx, minerB, 0, 0)
+	require.NoError(t, err)
+	require.Empty(t, samples)
+
+	_, err = h.Query(ctx, minerA, 10, 5)
+	require.Error(t, err)
+
+	// reorg to ts4b
+	require.NoError(t, h.Revert(ctx, ts4, ts3))
+	require.NoError(t, h.Apply(ctx, ts3, ts4b))
+	checkSamples(h, 2)
+
+	// backfilling a fresh history records the same samples, once
+	h2, err := NewHistory(filepath.Join(t.TempDir(), DBName), fc, []address.Address{minerA}, 2)
+	require.NoError(t, err)
+	defer h2.Close() //nolint:errcheck
+
+	require.NoError(t, h2.Backfill(ctx, ts4b, 0))
+	require.NoError(t, h2.Backfill(ctx, ts4b, 0))
+	checkSamples(h2, 2)
+}
@@ -0,0 +1,273 @@
+package replica
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain"
+	"github.com/filecoin-project/lotus/chain/messagepool"
+	"github.com/filecoin-project/lotus/chain/stmgr"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+const protectTag = "replica-primary"
+
+const (
+	minBackoff = time.Second
+	maxBackoff = time.Minute
+)
+
+// Follower makes a standby node follow the chain and the message pool of its
+// primary, until it's promoted.
+//
+// The standby trusts its primary for the block headers: it only checks that
+// the messages match the headers, and that its own state computation matches
+// the parent state of every tipset before taking it as its head.
+type Follower struct {
+	primary peer.AddrInfo
+	h       host.Host
+	cs      *store.ChainStore
+	sm      *stmgr.StateManager
+	syncer  *chain.Syncer
+	mp      *messagepool.MessagePool
+
+	lk          sync.Mutex
+	promoted    bool
+	onPromote   []func()
+	connected   bool
+	primaryHead *types.TipSet
+	lastUpdate  time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewFollower creates a follower of the primary, given as a multiaddress
+// including its peer ID.
+func NewFollower(primary string, h host.Host, cs *store.ChainStore, sm *stmgr.StateManager, syncer *chain.Syncer, mp *messagepool.MessagePool) (*Follower, error) {
+	ai, err := peer.AddrInfoFromString(primary)
+	if err != nil {
+		return nil, xerrors.Errorf("parsing primary address %q: %w", primary, err)
+	}
+
+	return &Follower{
+		primary: *ai,
+		h:       h,
+		cs:      cs,
+		sm:      sm,
+		syncer:  syncer,
+		mp:      mp,
+		done:    make(chan struct{}),
+	}, nil
+}
+
+// OnPromote registers a function called when the standby is promoted.
+func (f *Follower) OnPromote(fn func()) {
+	f.lk.Lock()
+	defer f.lk.Unlock()
+	f.onPromote = append(f.onPromote, fn)
+}
+
+func (f *Follower) Start(context.Context) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	f.cancel = cancel
+
+	f.h.ConnManager().Protect(f.primary.ID, protectTag)
+	go f.run(ctx)
+	return nil
+}
+
+func (f *Follower) Stop(ctx context.Context) error {
+	f.cancel()
+
+	select {
+	case <-f.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Promote stops following the primary, and runs the functions registered
+// with OnPromote.
+func (f *Follower) Promote(ctx context.Context) error {
+	f.lk.Lock()
+	if f.promoted {
+		f.lk.Unlock()
+		return xerrors.Errorf("node already promoted")
+	}
+	f.promoted = true
+	hooks := f.onPromote
+	f.lk.Unlock()
+
+	if err := f.Stop(ctx); err != nil {
+		return xerrors.Errorf("stopping to follow the primary: %w", err)
+	}
+	f.h.ConnManager().Unprotect(f.primary.ID, protectTag)
+
+	log.Warnw("promoted to primary", "previous primary", f.primary.ID)
+	for _, hook := range hooks {
+		hook()
+	}
+	return nil
+}
+
+// Status returns the replication status of the node.
+func (f *Follower) Status() *api.ReplicaStatus {
+	f.lk.Lock()
+	defer f.lk.Unlock()
+
+	if f.promoted {
+		return &api.ReplicaStatus{Role: RolePrimary}
+	}
+
+	st := &api.ReplicaStatus{
+		Role:       RoleStandby,
+		Primary:    f.primary.ID,
+		Connected:  f.connected,
+		LastUpdate: f.lastUpdate,
+	}
+	if f.primaryHead != nil {
+		st.PrimaryHead = f.primaryHead.Key()
+		st.PrimaryHeight = f.primaryHead.Height()
+	}
+	return st
+}
+
+func (f *Follower) run(ctx context.Context) {
+	defer close(f.done)
+
+	backoff := minBackoff
+	for {
+		started := time.Now()
+		err := f.follow(ctx)
+
+		f.lk.Lock()
+		f.connected = false
+		f.lk.Unlock()
+
+		if ctx.Err() != nil {
+			return
+		}
+		log.Warnw("following the primary", "primary", f.primary.ID, "error", err, "retry", backoff)
+
+		if time.Since(started) > maxBackoff {
+			backoff = minBackoff
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+func (f *Follower) follow(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if err := f.h.Connect(ctx, f.primary); err != nil {
+		return xerrors.Errorf("connecting to the primary: %w", err)
+	}
+	stream, err := f.h.NewStream(ctx, f.primary.ID, ProtocolID)
+	if err != nil {
+		return xerrors.Errorf("opening replication stream: %w", err)
+	}
+	defer stream.Close() //nolint:errcheck
+
+	go func() {
+		<-ctx.Done()
+		_ = stream.Reset()
+	}()
+
+	head := f.cs.GetHeaviestTipSet()
+	_ = stream.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if err := json.NewEncoder(stream).Encode(&Request{Head: head.Key()}); err != nil {
+		return xerrors.Errorf("sending replication request: %w", err)
+	}
+	_ = stream.SetWriteDeadline(time.Time{})
+
+	f.lk.Lock()
+	f.connected = true
+	f.lk.Unlock()
+	log.Infow("following the primary", "primary", f.primary.ID, "head", head.Key(), "height", head.Height())
+
+	dec := json.NewDecoder(stream)
+	for {
+		var u Update
+		_ = stream.SetReadDeadline(time.Now().Add(readTimeout))
+		if err := dec.Decode(&u); err != nil {
+			return xerrors.Errorf("reading update: %w", err)
+		}
+		if u.Error != "" {
+			return xerrors.Errorf("primary error: %s", u.Error)
+		}
+
+		for _, fts := range u.Tipsets {
+			if err := f.apply(ctx, fts); err != nil {
+				return err
+			}
+		}
+		for _, m := range u.Messages {
+			if err := f.mp.Add(ctx, m); err != nil {
+				log.Debugw("adding message from the primary", "message", m.Cid(), "error", err)
+			}
+		}
+
+		f.lk.Lock()
+		f.lastUpdate = time.Now()
+		f.lk.Unlock()
+	}
+}
+
+// apply checks a tipset received from the primary and takes it as the head.
+func (f *Follower) apply(ctx context.Context, fts *store.FullTipSet) error {
+	headers := make([]*types.BlockHeader, len(fts.Blocks))
+	for i, b := range fts.Blocks {
+		// also stores the messages
+		if err := f.syncer.ValidateMsgMeta(b); err != nil {
+			return xerrors.Errorf("validating the messages of block %s: %w", b.Cid(), err)
+		}
+		headers[i] = b.Header
+	}
+	ts, err := types.NewTipSet(headers)
+	if err != nil {
+		return xerrors.Errorf("invalid tipset from the primary: %w", err)
+	}
+
+	parent, err := f.cs.LoadTipSet(ctx, ts.Parents())
+	if err != nil {
+		return xerrors.Errorf("loading the parent of tipset %s: %w", ts.Key(), err)
+	}
+	st, _, err := f.sm.TipSetState(ctx, parent)
+	if err != nil {
+		return xerrors.Errorf("computing the state of tipset %s: %w", parent.Key(), err)
+	}
+	if st != ts.ParentState() {
+		return xerrors.Errorf("state of tipset %s at %d doesn't match the primary (%s != %s)", parent.Key(), parent.Height(), st, ts.ParentState())
+	}
+
+	if err := f.cs.PersistTipset(ctx, ts); err != nil {
+		return xerrors.Errorf("persisting tipset %s: %w", ts.Key(), err)
+	}
+	if err := f.cs.SetHead(ctx, ts); err != nil {
+		return xerrors.Errorf("setting head to tipset %s: %w", ts.Key(), err)
+	}
+
+	f.lk.Lock()
+	f.primaryHead = ts
+	f.lk.Unlock()
+	return nil
+}
@@ -0,0 +1,65 @@
+// Package replica replicates the chain and the message pool of a primary node
+// to hot standby nodes, over a dedicated libp2p protocol.
+//
+// A standby doesn't sync on its own: it streams the tipsets, with their
+// messages, and the message pool additions of its primary, checks the message
+// roots and the state of every tipset and takes the primary's head as its own.
+// When the primary fails, the standby is promoted and starts syncing from the
+// network like any other node, with an up to date chain and message pool.
+package replica
+
+import (
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+
+	"github.com/filecoin-project/lotus/chain/actors/policy"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var log = logging.Logger("replica")
+
+const ProtocolID = "/fil/chain/replica/0.0.1"
+
+// The replication roles of a node.
+const (
+	RolePrimary = "primary"
+	RoleStandby = "standby"
+)
+
+const (
+	// catchUpLimit is how far behind its primary a standby can be when it
+	// starts following it. Standbys further behind must be synced first.
+	catchUpLimit = policy.ChainFinality
+
+	// heartbeatInterval is how often the primary sends an empty update to
+	// idle standbys, and readTimeout how long standbys wait for an update
+	// before considering the primary gone.
+	heartbeatInterval = 15 * time.Second
+	readTimeout       = 4 * heartbeatInterval
+
+	writeTimeout = 30 * time.Second
+
+	// followBurst is how many times in a row a standby can start following
+	// the node before its rate limit applies, e.g. while reconnecting.
+	followBurst = 3
+)
+
+// Request is sent by a standby when it starts following its primary.
+type Request struct {
+	// Head is the head of the standby, the primary sends the tipsets
+	// between it and its own head first.
+	Head types.TipSetKey
+}
+
+// Update is streamed by the primary to its standbys.
+type Update struct {
+	// Tipsets are the tipsets the primary took as its head, oldest first.
+	Tipsets []*store.FullTipSet `json:",omitempty"`
+	// Messages are the messages added to the message pool of the primary.
+	Messages []*types.SignedMessage `json:",omitempty"`
+	// Error is set when the primary can't be followed, before it closes the
+	// stream.
+	Error string `json:",omitempty"`
+}
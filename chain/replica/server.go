@@ -0,0 +1,261 @@
+package replica
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	inet "github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"golang.org/x/time/rate"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/messagepool"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// Server streams the chain and the message pool of a primary node to the
+// standbys following it.
+type Server struct {
+	h       host.Host
+	cs      *store.ChainStore
+	mp      *messagepool.MessagePool
+	allowed map[peer.ID]*rate.Limiter
+
+	lk       sync.Mutex
+	standbys map[peer.ID]*api.ReplicaStandby
+}
+
+// NewServer creates a replication server which only the allowed peers can
+// follow, each of them starting to follow the node at most followsPerHour
+// times per hour. A followsPerHour of 0 disables the limit.
+func NewServer(h host.Host, cs *store.ChainStore, mp *messagepool.MessagePool, allowed []peer.ID, followsPerHour int) (*Server, error) {
+	if len(allowed) == 0 {
+		return nil, xerrors.Errorf("no peers allowed to follow the node")
+	}
+
+	limit := rate.Inf
+	if followsPerHour > 0 {
+		limit = rate.Every(time.Hour / time.Duration(followsPerHour))
+	}
+
+	s := &Server{
+		h:        h,
+		cs:       cs,
+		mp:       mp,
+		allowed:  map[peer.ID]*rate.Limiter{},
+		standbys: map[peer.ID]*api.ReplicaStandby{},
+	}
+	for _, p := range allowed {
+		s.allowed[p] = rate.NewLimiter(limit, followBurst)
+	}
+	return s, nil
+}
+
+func (s *Server) Start(context.Context) error {
+	s.h.SetStreamHandler(ProtocolID, s.handleStream)
+	return nil
+}
+
+func (s *Server) Stop(context.Context) error {
+	s.h.RemoveStreamHandler(ProtocolID)
+	return nil
+}
+
+// Standbys returns the standbys currently following the node.
+func (s *Server) Standbys() []api.ReplicaStandby {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	out := make([]api.ReplicaStandby, 0, len(s.standbys))
+	for _, sb := range s.standbys {
+		out = append(out, *sb)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Since.Before(out[j].Since)
+	})
+	return out
+}
+
+func (s *Server) handleStream(stream inet.Stream) {
+	defer stream.Close() //nolint:errcheck
+
+	p := stream.Conn().RemotePeer()
+	limiter, ok := s.allowed[p]
+	if !ok {
+		log.Warnw("rejecting replication by a peer which isn't allowed", "peer", p)
+		_ = stream.Reset()
+		return
+	}
+	if !limiter.Allow() {
+		// every new stream makes the node send the tipsets the standby is
+		// missing
+		log.Warnw("rejecting replication by a standby following the node too often", "peer", p)
+		_ = stream.Reset()
+		return
+	}
+
+	var req Request
+	_ = stream.SetReadDeadline(time.Now().Add(readTimeout))
+	if err := json.NewDecoder(stream).Decode(&req); err != nil {
+		log.Warnw("reading replication request", "peer", p, "error", err)
+		return
+	}
+	_ = stream.SetReadDeadline(time.Time{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// standbys don't send anything after their request, reading only
+	// returns once they close the stream
+	go func() {
+		_, _ = io.Copy(io.Discard, stream)
+		cancel()
+	}()
+
+	sb := &api.ReplicaStandby{Peer: p, Since: time.Now()}
+	s.lk.Lock()
+	s.standbys[p] = sb
+	s.lk.Unlock()
+	defer func() {
+		s.lk.Lock()
+		if s.standbys[p] == sb {
+			delete(s.standbys, p)
+		}
+		s.lk.Unlock()
+	}()
+
+	log.Infow("standby following", "peer", p, "head", req.Head)
+	if err := s.serve(ctx, stream, sb, req); err != nil && ctx.Err() == nil {
+		log.Warnw("replicating to standby", "peer", p, "error", err)
+	}
+	log.Infow("standby stopped following", "peer", p)
+}
+
+func (s *Server) serve(ctx context.Context, stream inet.Stream, sb *api.ReplicaStandby, req Request) error {
+	enc := json.NewEncoder(stream)
+	send := func(u *Update) error {
+		_ = stream.SetWriteDeadline(time.Now().Add(writeTimeout))
+		defer stream.SetWriteDeadline(time.Time{}) //nolint:errcheck
+		return enc.Encode(u)
+	}
+	fail := func(err error) error {
+		_ = send(&Update{Error: err.Error()})
+		return err
+	}
+
+	// subscribe before catching up, the first notification is the current
+	// head
+	heads := s.cs.SubHeadChanges(ctx)
+	msgs, err := s.mp.Updates(ctx)
+	if err != nil {
+		return fail(xerrors.Errorf("subscribing to the message pool: %w", err))
+	}
+
+	var head *types.TipSet
+	select {
+	case hcs := <-heads:
+		head = hcs[0].Val
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	from, err := s.cs.LoadTipSet(ctx, req.Head)
+	if err != nil {
+		return fail(xerrors.Errorf("standby head %s unknown: %w", req.Head, err))
+	}
+	if gap := head.Height() - from.Height(); gap > catchUpLimit {
+		return fail(xerrors.Errorf("standby %d epochs behind, more than the %d it can catch up, sync it first", gap, catchUpLimit))
+	}
+	_, apply, err := store.ReorgOps(ctx, s.cs.LoadTipSet, from, head)
+	if err != nil {
+		return fail(xerrors.Errorf("computing the tipsets to catch up: %w", err))
+	}
+	for i := len(apply) - 1; i >= 0; i-- {
+		if err := s.sendTipSet(ctx, send, sb, apply[i]); err != nil {
+			return err
+		}
+	}
+
+	pending, _ := s.mp.Pending(ctx)
+	if len(pending) > 0 {
+		if err := send(&Update{Messages: pending}); err != nil {
+			return xerrors.Errorf("sending pending messages: %w", err)
+		}
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case hcs, ok := <-heads:
+			if !ok {
+				return xerrors.Errorf("head change subscription closed")
+			}
+			var applied []*types.TipSet
+			for _, hc := range hcs {
+				if hc.Type == store.HCApply {
+					applied = append(applied, hc.Val)
+				}
+			}
+			sort.Slice(applied, func(i, j int) bool {
+				return applied[i].Height() < applied[j].Height()
+			})
+			for _, ts := range applied {
+				if err := s.sendTipSet(ctx, send, sb, ts); err != nil {
+					return err
+				}
+			}
+		case u, ok := <-msgs:
+			if !ok {
+				return xerrors.Errorf("message pool subscription closed")
+			}
+			if u.Type != api.MpoolAdd {
+				continue
+			}
+			if err := send(&Update{Messages: []*types.SignedMessage{u.Message}}); err != nil {
+				return xerrors.Errorf("sending message: %w", err)
+			}
+		case <-heartbeat.C:
+			if err := send(&Update{}); err != nil {
+				return xerrors.Errorf("sending heartbeat: %w", err)
+			}
+			continue
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		heartbeat.Reset(heartbeatInterval)
+	}
+}
+
+func (s *Server) sendTipSet(ctx context.Context, send func(*Update) error, sb *api.ReplicaStandby, ts *types.TipSet) error {
+	blks := make([]*types.FullBlock, len(ts.Blocks()))
+	for i, b := range ts.Blocks() {
+		bmsgs, smsgs, err := s.cs.MessagesForBlock(ctx, b)
+		if err != nil {
+			return xerrors.Errorf("loading messages of block %s: %w", b.Cid(), err)
+		}
+		blks[i] = &types.FullBlock{
+			Header:        b,
+			BlsMessages:   bmsgs,
+			SecpkMessages: smsgs,
+		}
+	}
+
+	if err := send(&Update{Tipsets: []*store.FullTipSet{store.NewFullTipSet(blks)}}); err != nil {
+		return xerrors.Errorf("sending tipset %s: %w", ts.Key(), err)
+	}
+
+	s.lk.Lock()
+	sb.Head = ts.Key()
+	sb.Height = ts.Height()
+	s.lk.Unlock()
+	return nil
+}
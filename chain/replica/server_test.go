@@ -0,0 +1,59 @@
+// stm: #unit
+package replica
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerPeers(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := NewServer(nil, nil, nil, nil, 0)
+	require.Error(t, err)
+
+	mn := mocknet.New()
+	primary, err := mn.GenPeer()
+	require.NoError(t, err)
+	standby, err := mn.GenPeer()
+	require.NoError(t, err)
+	other, err := mn.GenPeer()
+	require.NoError(t, err)
+	require.NoError(t, mn.LinkAll())
+	require.NoError(t, mn.ConnectAllButSelf())
+
+	s, err := NewServer(primary, nil, nil, []peer.ID{standby.ID()}, 1)
+	require.NoError(t, err)
+	require.NoError(t, s.Start(ctx))
+	defer s.Stop(ctx) //nolint:errcheck
+
+	// follow sends an invalid request, the server closes the stream after
+	// reading it, or resets it when rejecting the peer
+	follow := func(h host.Host) error {
+		st, err := h.NewStream(ctx, primary.ID(), ProtocolID)
+		if err != nil {
+			return err
+		}
+		defer st.Close() //nolint:errcheck
+
+		if _, err := st.Write([]byte("invalid\n")); err != nil {
+			return err
+		}
+		_, err = io.ReadAll(st)
+		return err
+	}
+
+	require.Error(t, follow(other))
+
+	for i := 0; i < followBurst; i++ {
+		require.NoError(t, follow(standby))
+	}
+	require.Error(t, follow(standby))
+	require.Empty(t, s.Standbys())
+}
@@ -0,0 +1,349 @@
+// Package snapshot exports snapshots of the chain on a schedule, keeps the most
+// recent ones with a manifest of their checksums, and serves them over HTTP, so
+// operators can host the snapshots their nodes bootstrap from.
+package snapshot
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var log = logging.Logger("snapshot")
+
+const (
+	ManifestName = "manifest.json"
+
+	// maxHeadDelay is how far behind the wall clock the head can be for the node
+	// to count as synced, the scheduled snapshots are skipped until it is
+	maxHeadDelay = 10 * time.Duration(build.BlockDelaySecs) * time.Second
+
+	retryDelay = 10 * time.Minute
+)
+
+type Chain interface {
+	GetHeaviestTipSet() *types.TipSet
+	Export(ctx context.Context, ts *types.TipSet, inclRecentRoots abi.ChainEpoch, skipOldMsgs bool, w io.Writer) error
+}
+
+type Config struct {
+	// Dir is the directory the snapshots are written to
+	Dir      string
+	Interval time.Duration
+	// Keep is the number of snapshots kept, the older ones are removed
+	Keep int
+	// RecentStateRoots is the number of epochs of state included, the messages
+	// of the older epochs are skipped
+	RecentStateRoots abi.ChainEpoch
+}
+
+type Manifest struct {
+	// Snapshots are the available snapshots, the most recent first
+	Snapshots []api.ChainSnapshot
+}
+
+type Service struct {
+	cfg   Config
+	chain Chain
+
+	exportLk sync.Mutex
+
+	lk       sync.Mutex
+	manifest Manifest
+
+	started bool
+	closing chan struct{}
+	closed  chan struct{}
+}
+
+func New(cfg Config, chain Chain) (*Service, error) {
+	if cfg.Keep < 1 {
+		return nil, xerrors.Errorf("at least one snapshot must be kept, got %d", cfg.Keep)
+	}
+	if err := os.MkdirAll(cfg.Dir, 0755); err != nil {
+		return nil, xerrors.Errorf("creating snapshot directory: %w", err)
+	}
+
+	s := &Service{
+		cfg:     cfg,
+		chain:   chain,
+		closing: make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+
+	b, err := os.ReadFile(filepath.Join(cfg.Dir, ManifestName))
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, xerrors.Errorf("reading manifest: %w", err)
+	default:
+		if err := json.Unmarshal(b, &s.manifest); err != nil {
+			return nil, xerrors.Errorf("parsing manifest: %w", err)
+		}
+	}
+
+	// drop the snapshots removed by hand
+	kept := s.manifest.Snapshots[:0]
+	for _, snap := range s.manifest.Snapshots {
+		if _, err := os.Stat(filepath.Join(cfg.Dir, snap.Name)); err != nil {
+			log.Warnw("snapshot in the manifest not found", "name", snap.Name, "error", err)
+			continue
+		}
+		kept = append(kept, snap)
+	}
+	s.manifest.Snapshots = kept
+
+	return s, nil
+}
+
+// Start schedules the snapshots, the first one is taken right away when the
+// last snapshot is older than the interval.
+func (s *Service) Start(ctx context.Context) {
+	s.started = true
+	go s.run(ctx)
+}
+
+func (s *Service) run(ctx context.Context) {
+	defer close(s.closed)
+
+	for {
+		next := time.Duration(0)
+		if last := s.List(); len(last) > 0 {
+			next = time.Until(last[0].Created.Add(s.cfg.Interval))
+		}
+
+		select {
+		case <-time.After(next):
+		case <-s.closing:
+			return
+		case <-ctx.Done():
+			return
+		}
+
+		head := s.chain.GetHeaviestTipSet()
+		if delay := time.Since(time.Unix(int64(head.MinTimestamp()), 0)); delay > maxHeadDelay {
+			log.Warnw("node not in sync, delaying the snapshot", "head", head.Height(), "delay", delay)
+			if !s.wait(ctx, retryDelay) {
+				return
+			}
+			continue
+		}
+
+		snap, err := s.Snapshot(ctx)
+		if err != nil {
+			log.Errorw("taking snapshot", "error", err)
+			if !s.wait(ctx, retryDelay) {
+				return
+			}
+			continue
+		}
+		log.Infow("took snapshot", "name", snap.Name, "height", snap.Height, "size", snap.Size, "took", snap.Took)
+	}
+}
+
+func (s *Service) wait(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-time.After(d):
+		return true
+	case <-s.closing:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (s *Service) Close() error {
+	close(s.closing)
+	if s.started {
+		<-s.closed
+	}
+	return nil
+}
+
+// Snapshot exports a snapshot of the chain at the head, adds it to the manifest
+// and removes the snapshots past the number kept.
+func (s *Service) Snapshot(ctx context.Context) (api.ChainSnapshot, error) {
+	s.exportLk.Lock()
+	defer s.exportLk.Unlock()
+
+	start := time.Now()
+	ts := s.chain.GetHeaviestTipSet()
+	name := fmt.Sprintf("%d_%s.car", ts.Height(), start.UTC().Format("2006_01_02T15_04_05Z"))
+
+	tmp := filepath.Join(s.cfg.Dir, "."+name+".tmp")
+	f, err := os.Create(tmp)
+	if err != nil {
+		return api.ChainSnapshot{}, xerrors.Errorf("creating snapshot file: %w", err)
+	}
+	defer os.Remove(tmp) //nolint:errcheck
+
+	h := sha256.New()
+	cw := &countWriter{w: io.MultiWriter(f, h)}
+	bw := bufio.NewWriterSize(cw, 1<<20)
+	if err := s.chain.Export(ctx, ts, s.cfg.RecentStateRoots, true, bw); err != nil {
+		_ = f.Close()
+		return api.ChainSnapshot{}, xerrors.Errorf("exporting chain: %w", err)
+	}
+	if err := bw.Flush(); err != nil {
+		_ = f.Close()
+		return api.ChainSnapshot{}, xerrors.Errorf("writing snapshot: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return api.ChainSnapshot{}, xerrors.Errorf("syncing snapshot: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return api.ChainSnapshot{}, xerrors.Errorf("closing snapshot: %w", err)
+	}
+
+	snap := api.ChainSnapshot{
+		Name:    name,
+		Height:  ts.Height(),
+		TipSet:  ts.Key(),
+		Size:    cw.n,
+		SHA256:  hex.EncodeToString(h.Sum(nil)),
+		Created: start,
+		Took:    time.Since(start),
+	}
+
+	// the checksum file can be checked with sha256sum -c
+	if err := os.WriteFile(filepath.Join(s.cfg.Dir, name+".sha256"), []byte(snap.SHA256+"  "+name+"\n"), 0644); err != nil {
+		return api.ChainSnapshot{}, xerrors.Errorf("writing checksum: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(s.cfg.Dir, name)); err != nil {
+		return api.ChainSnapshot{}, xerrors.Errorf("moving snapshot: %w", err)
+	}
+
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	s.manifest.Snapshots = append([]api.ChainSnapshot{snap}, s.manifest.Snapshots...)
+	var removed []api.ChainSnapshot
+	if len(s.manifest.Snapshots) > s.cfg.Keep {
+		removed = s.manifest.Snapshots[s.cfg.Keep:]
+		s.manifest.Snapshots = s.manifest.Snapshots[:s.cfg.Keep:s.cfg.Keep]
+	}
+
+	// the manifest is written before the old snapshots are removed, so that it
+	// never lists missing files
+	if err := s.writeManifest(); err != nil {
+		return api.ChainSnapshot{}, err
+	}
+	for _, old := range removed {
+		for _, p := range []string{old.Name, old.Name + ".sha256"} {
+			if err := os.Remove(filepath.Join(s.cfg.Dir, p)); err != nil && !os.IsNotExist(err) {
+				log.Warnw("removing old snapshot", "name", p, "error", err)
+			}
+		}
+	}
+
+	return snap, nil
+}
+
+// writeManifest writes the manifest atomically, the caller holds the lock
+func (s *Service) writeManifest() error {
+	b, err := json.MarshalIndent(s.manifest, "", "  ")
+	if err != nil {
+		return xerrors.Errorf("marshaling manifest: %w", err)
+	}
+
+	tmp := filepath.Join(s.cfg.Dir, "."+ManifestName+".tmp")
+	if err := os.WriteFile(tmp, b, 0644); err != nil {
+		return xerrors.Errorf("writing manifest: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(s.cfg.Dir, ManifestName)); err != nil {
+		return xerrors.Errorf("moving manifest: %w", err)
+	}
+	return nil
+}
+
+// List returns the available snapshots, the most recent first.
+func (s *Service) List() []api.ChainSnapshot {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	return append([]api.ChainSnapshot{}, s.manifest.Snapshots...)
+}
+
+// ServeHTTP serves the manifest at /manifest.json, the snapshots and their
+// checksum files by name, and redirects /latest to the most recent snapshot.
+func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.lk.Lock()
+	m := Manifest{Snapshots: append([]api.ChainSnapshot{}, s.manifest.Snapshots...)}
+	s.lk.Unlock()
+
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	switch name {
+	case ManifestName:
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(m)
+		return
+	case "latest":
+		if len(m.Snapshots) == 0 {
+			http.Error(w, "no snapshot yet", http.StatusNotFound)
+			return
+		}
+		http.Redirect(w, r, m.Snapshots[0].Name, http.StatusFound)
+		return
+	}
+
+	// only serve the files listed in the manifest
+	for _, snap := range m.Snapshots {
+		if name != snap.Name && name != snap.Name+".sha256" {
+			continue
+		}
+
+		f, err := os.Open(filepath.Join(s.cfg.Dir, name))
+		if err != nil {
+			http.Error(w, "snapshot not found", http.StatusNotFound)
+			return
+		}
+		defer f.Close() //nolint:errcheck
+
+		if name == snap.Name {
+			w.Header().Set("Content-Type", "application/vnd.ipld.car")
+			w.Header().Set("X-Content-SHA256", snap.SHA256)
+		} else {
+			w.Header().Set("Content-Type", "text/plain")
+		}
+		http.ServeContent(w, r, name, snap.Created, f)
+		return
+	}
+
+	http.NotFound(w, r)
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,146 @@
+// stm: #unit
+package snapshot
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var dummyCid, _ = cid.Parse("bafkqaaa")
+
+type fakeChain struct {
+	t      *testing.T
+	height abi.ChainEpoch
+}
+
+func (c *fakeChain) GetHeaviestTipSet() *types.TipSet {
+	maddr, err := address.NewIDAddress(1000)
+	require.NoError(c.t, err)
+
+	ts, err := types.NewTipSet([]*types.BlockHeader{{
+		Miner:                 maddr,
+		Height:                c.height,
+		Timestamp:             uint64(time.Now().Unix()),
+		ParentStateRoot:       dummyCid,
+		ParentMessageReceipts: dummyCid,
+		Messages:              dummyCid,
+	}})
+	require.NoError(c.t, err)
+	return ts
+}
+
+func (c *fakeChain) Export(ctx context.Context, ts *types.TipSet, inclRecentRoots abi.ChainEpoch, skipOldMsgs bool, w io.Writer) error {
+	_, err := fmt.Fprintf(w, "snapshot at %d", ts.Height())
+	return err
+}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	chain := &fakeChain{t: t}
+
+	s, err := New(Config{Dir: dir, Interval: time.Hour, Keep: 2, RecentStateRoots: 2000}, chain)
+	require.NoError(t, err)
+
+	for h := abi.ChainEpoch(1); h <= 3; h++ {
+		chain.height = h
+		snap, err := s.Snapshot(ctx)
+		require.NoError(t, err)
+
+		content := fmt.Sprintf("snapshot at %d", h)
+		sum := sha256.Sum256([]byte(content))
+		require.Equal(t, hex.EncodeToString(sum[:]), snap.SHA256)
+		require.Equal(t, int64(len(content)), snap.Size)
+		require.Equal(t, h, snap.Height)
+
+		b, err := os.ReadFile(filepath.Join(dir, snap.Name))
+		require.NoError(t, err)
+		require.Equal(t, content, string(b))
+
+		b, err = os.ReadFile(filepath.Join(dir, snap.Name+".sha256"))
+		require.NoError(t, err)
+		require.Equal(t, snap.SHA256+"  "+snap.Name+"\n", string(b))
+	}
+
+	// the oldest snapshot was removed
+	snaps := s.List()
+	require.Len(t, snaps, 2)
+	require.Equal(t, abi.ChainEpoch(3), snaps[0].Height)
+	require.Equal(t, abi.ChainEpoch(2), snaps[1].Height)
+	files, err := filepath.Glob(filepath.Join(dir, "*.car"))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	// the manifest is reloaded
+	var m Manifest
+	b, err := os.ReadFile(filepath.Join(dir, ManifestName))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(b, &m))
+	require.Len(t, m.Snapshots, 2)
+
+	s2, err := New(Config{Dir: dir, Interval: time.Hour, Keep: 2}, chain)
+	require.NoError(t, err)
+	require.Equal(t, snaps[0].SHA256, s2.List()[0].SHA256)
+
+	// serving
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/latest")
+	require.NoError(t, err)
+	b, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "snapshot at 3", string(b))
+	require.Equal(t, snaps[0].SHA256, resp.Header.Get("X-Content-SHA256"))
+
+	resp, err = http.Get(srv.URL + "/" + ManifestName)
+	require.NoError(t, err)
+	m = Manifest{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&m))
+	require.NoError(t, resp.Body.Close())
+	require.Len(t, m.Snapshots, 2)
+
+	for path, code := range map[string]int{
+		"/" + snaps[1].Name + ".sha256":     http.StatusOK,
+		"/" + filepath.Base(files[0]) + "x": http.StatusNotFound,
+		"/../" + ManifestName:               http.StatusNotFound,
+	} {
+		resp, err := http.Get(srv.URL + path)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, code, resp.StatusCode, path)
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	chain := &fakeChain{t: t, height: 10}
+	s, err := New(Config{Dir: t.TempDir(), Interval: time.Hour, Keep: 1}, chain)
+	require.NoError(t, err)
+
+	// no snapshot yet, one is taken on start
+	s.Start(context.Background())
+	require.Eventually(t, func() bool {
+		return len(s.List()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, s.Close())
+}
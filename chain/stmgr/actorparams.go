@@ -0,0 +1,123 @@
+package stmgr
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+
+	cbg "github.com/whyrusleeping/cbor-gen"
+	"golang.org/x/xerrors"
+
+	actorstypes "github.com/filecoin-project/go-state-types/actors"
+	"github.com/filecoin-project/go-state-types/network"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/vm"
+)
+
+// ResolveActorMethod looks up a method of a builtin actor in the actor
+// registry. When the actor is identified by name and its version is zero, the
+// actors version of the given network version is used.
+func ResolveActorMethod(ar *vm.ActorRegistry, m api.ActorMethod, nv network.Version) (vm.MethodMeta, error) {
+	code := m.Code
+	if !code.Defined() {
+		if m.Name == "" {
+			return vm.MethodMeta{}, xerrors.Errorf("either the code or the name of the actor must be set")
+		}
+
+		av := actorstypes.Version(m.Version)
+		if m.Version == 0 {
+			var err error
+			if av, err = actorstypes.VersionForNetwork(nv); err != nil {
+				return vm.MethodMeta{}, xerrors.Errorf("getting actors version for network version %d: %w", nv, err)
+			}
+		}
+
+		var ok bool
+		if code, ok = actors.GetActorCodeID(av, m.Name); !ok {
+			return vm.MethodMeta{}, xerrors.Errorf("unknown actor %q in actors version %d: %w", m.Name, av, ErrMetadataNotFound)
+		}
+	}
+
+	methods, ok := ar.Methods[code]
+	if !ok {
+		return vm.MethodMeta{}, xerrors.Errorf("unknown actor code %s: %w", code, ErrMetadataNotFound)
+	}
+
+	if m.MethodName == "" {
+		mm, ok := methods[m.Method]
+		if !ok {
+			return vm.MethodMeta{}, xerrors.Errorf("unknown method %d for actor %s: %w", m.Method, code, ErrMetadataNotFound)
+		}
+		return mm, nil
+	}
+
+	for num, mm := range methods {
+		if mm.Name != m.MethodName {
+			continue
+		}
+		if m.Method != 0 && m.Method != num {
+			return vm.MethodMeta{}, xerrors.Errorf("method %s of actor %s is method %d, not %d", m.MethodName, code, num, m.Method)
+		}
+		return mm, nil
+	}
+	return vm.MethodMeta{}, xerrors.Errorf("unknown method %s for actor %s: %w", m.MethodName, code, ErrMetadataNotFound)
+}
+
+// EncodeMethodParams encodes JSON params of a builtin actor method to CBOR.
+func EncodeMethodParams(ar *vm.ActorRegistry, m api.ActorMethod, nv network.Version, params json.RawMessage) ([]byte, error) {
+	mm, err := ResolveActorMethod(ar, m, nv)
+	if err != nil {
+		return nil, err
+	}
+
+	p := reflect.New(mm.Params.Elem()).Interface()
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, p); err != nil {
+			return nil, xerrors.Errorf("json unmarshal: %w", err)
+		}
+	}
+
+	marshaler, ok := p.(cbg.CBORMarshaler)
+	if !ok {
+		return nil, xerrors.Errorf("params type %T of method %s can't be encoded", p, mm.Name)
+	}
+
+	var buf bytes.Buffer
+	if err := marshaler.MarshalCBOR(&buf); err != nil {
+		return nil, xerrors.Errorf("cbor marshal: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeMethodParams decodes the CBOR params of a builtin actor method.
+func DecodeMethodParams(ar *vm.ActorRegistry, m api.ActorMethod, nv network.Version, params []byte) (interface{}, error) {
+	mm, err := ResolveActorMethod(ar, m, nv)
+	if err != nil {
+		return nil, err
+	}
+	return decodeMethodValue(mm.Params, params)
+}
+
+// DecodeMethodReturn decodes the CBOR return value of a builtin actor method.
+func DecodeMethodReturn(ar *vm.ActorRegistry, m api.ActorMethod, nv network.Version, ret []byte) (interface{}, error) {
+	mm, err := ResolveActorMethod(ar, m, nv)
+	if err != nil {
+		return nil, err
+	}
+	return decodeMethodValue(mm.Ret, ret)
+}
+
+func decodeMethodValue(t reflect.Type, b []byte) (interface{}, error) {
+	v := reflect.New(t.Elem()).Interface()
+
+	unmarshaler, ok := v.(cbg.CBORUnmarshaler)
+	if !ok {
+		return nil, xerrors.Errorf("type %T can't be decoded", v)
+	}
+	if err := unmarshaler.UnmarshalCBOR(bytes.NewReader(b)); err != nil {
+		return nil, xerrors.Errorf("cbor unmarshal: %w", err)
+	}
+	return v, nil
+}
@@ -0,0 +1,80 @@
+// stm: #unit
+package stmgr_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	actorstypes "github.com/filecoin-project/go-state-types/actors"
+	"github.com/filecoin-project/go-state-types/builtin"
+	miner10 "github.com/filecoin-project/go-state-types/builtin/v10/miner"
+	"github.com/filecoin-project/go-state-types/manifest"
+	"github.com/filecoin-project/go-state-types/network"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/consensus"
+	"github.com/filecoin-project/lotus/chain/stmgr"
+)
+
+func TestMethodParams(t *testing.T) {
+	ar := consensus.NewActorRegistry()
+
+	worker, err := address.NewIDAddress(1001)
+	require.NoError(t, err)
+	params, err := json.Marshal(&miner10.ChangeWorkerAddressParams{NewWorker: worker})
+	require.NoError(t, err)
+
+	// by name and version, and by method name
+	m := api.ActorMethod{
+		Name:       manifest.MinerKey,
+		Version:    int(actorstypes.Version10),
+		MethodName: "ChangeWorkerAddress",
+	}
+	enc, err := stmgr.EncodeMethodParams(ar, m, network.Version18, params)
+	require.NoError(t, err)
+
+	expected, err := actors.SerializeParams(&miner10.ChangeWorkerAddressParams{NewWorker: worker})
+	require.NoError(t, err)
+	require.Equal(t, expected, enc)
+
+	// by code and method number
+	code, ok := actors.GetActorCodeID(actorstypes.Version10, manifest.MinerKey)
+	require.True(t, ok)
+	m = api.ActorMethod{
+		Code:   code,
+		Method: builtin.MethodsMiner.ChangeWorkerAddress,
+	}
+	dec, err := stmgr.DecodeMethodParams(ar, m, network.Version18, enc)
+	require.NoError(t, err)
+	require.Equal(t, worker, dec.(*miner10.ChangeWorkerAddressParams).NewWorker)
+
+	// the version of the network is used when none is given
+	m = api.ActorMethod{
+		Name:   manifest.MinerKey,
+		Method: builtin.MethodsMiner.ControlAddresses,
+	}
+	ret, err := actors.SerializeParams(&miner10.GetControlAddressesReturn{Owner: worker, Worker: worker})
+	require.NoError(t, err)
+	dec, err = stmgr.DecodeMethodReturn(ar, m, network.Version18, ret)
+	require.NoError(t, err)
+	require.Equal(t, worker, dec.(*miner10.GetControlAddressesReturn).Owner)
+
+	// mismatching method name and number
+	m = api.ActorMethod{
+		Code:       code,
+		Method:     builtin.MethodsMiner.ControlAddresses,
+		MethodName: "ChangeWorkerAddress",
+	}
+	_, err = stmgr.EncodeMethodParams(ar, m, network.Version18, params)
+	require.Error(t, err)
+
+	// unknown actor and method
+	_, err = stmgr.EncodeMethodParams(ar, api.ActorMethod{Name: "nosuchactor", Version: 10}, network.Version18, params)
+	require.ErrorIs(t, err, stmgr.ErrMetadataNotFound)
+	_, err = stmgr.EncodeMethodParams(ar, api.ActorMethod{Code: code, MethodName: "NoSuchMethod"}, network.Version18, params)
+	require.ErrorIs(t, err, stmgr.ErrMetadataNotFound)
+}
@@ -0,0 +1,117 @@
+package stmgr
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/alecthomas/jsonschema"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/vm"
+)
+
+var (
+	cidType      = reflect.TypeOf(cid.Cid{})
+	addressType  = reflect.TypeOf(address.Address{})
+	bigIntType   = reflect.TypeOf(big.Int{})
+	bitFieldType = reflect.TypeOf(bitfield.BitField{})
+)
+
+// actorSchemaTypeMapper maps types with custom JSON encodings to the schema
+// of their encoded form.
+func actorSchemaTypeMapper(t reflect.Type) *jsonschema.Type {
+	switch t {
+	case cidType:
+		return &jsonschema.Type{
+			Type:  "object",
+			Title: "Content Identifier",
+			PatternProperties: map[string]*jsonschema.Type{
+				"^/$": {Type: "string"},
+			},
+			Required: []string{"/"},
+		}
+	case addressType:
+		return &jsonschema.Type{Type: "string", Title: "Address"}
+	case bigIntType:
+		return &jsonschema.Type{Type: "string", Title: "BigInt", Pattern: "^-?[0-9]+$"}
+	case bitFieldType:
+		return &jsonschema.Type{
+			Type:        "array",
+			Title:       "BitField",
+			Description: "RLE+ encoded bitfield, as a list of alternating run lengths starting with unset bits",
+			Items:       &jsonschema.Type{Type: "integer"},
+		}
+	}
+	return nil
+}
+
+func reflectSchema(t reflect.Type) (json.RawMessage, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	r := &jsonschema.Reflector{
+		AllowAdditionalProperties:  true,
+		RequiredFromJSONSchemaTags: true,
+		FullyQualifyTypeNames:      true,
+		TypeMapper:                 actorSchemaTypeMapper,
+	}
+
+	return json.Marshal(r.ReflectFromType(t))
+}
+
+// ActorSchema returns JSON schemas for the state and the exported methods of
+// the builtin actor with the given code, as known to the actor registry.
+func ActorSchema(ar *vm.ActorRegistry, code cid.Cid) (out *api.ActorSchema, err error) {
+	methods, ok := ar.Methods[code]
+	if !ok {
+		return nil, xerrors.Errorf("unknown actor code %s: %w", code, ErrMetadataNotFound)
+	}
+
+	// the reflector panics on types it can't represent
+	defer func() {
+		if r := recover(); r != nil {
+			out, err = nil, xerrors.Errorf("generating schema for actor %s: %v", code, r)
+		}
+	}()
+
+	out = &api.ActorSchema{Code: code}
+	if name, av, ok := actors.GetActorMetaByCode(code); ok {
+		out.Name = name
+		out.Version = int(av)
+	}
+
+	if st, ok := ar.StateType(code); ok {
+		if out.State, err = reflectSchema(reflect.TypeOf(st)); err != nil {
+			return nil, xerrors.Errorf("generating state schema: %w", err)
+		}
+	}
+
+	for num, m := range methods {
+		ms := api.ActorMethodSchema{
+			Num:  num,
+			Name: m.Name,
+		}
+		if ms.Params, err = reflectSchema(m.Params); err != nil {
+			return nil, xerrors.Errorf("generating params schema for method %d: %w", num, err)
+		}
+		if ms.Return, err = reflectSchema(m.Ret); err != nil {
+			return nil, xerrors.Errorf("generating return schema for method %d: %w", num, err)
+		}
+		out.Methods = append(out.Methods, ms)
+	}
+
+	sort.Slice(out.Methods, func(i, j int) bool {
+		return out.Methods[i].Num < out.Methods[j].Num
+	})
+
+	return out, nil
+}
@@ -0,0 +1,53 @@
+// stm: #unit
+package stmgr_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	actorstypes "github.com/filecoin-project/go-state-types/actors"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/go-state-types/manifest"
+
+	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/consensus"
+	"github.com/filecoin-project/lotus/chain/stmgr"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestActorSchema(t *testing.T) {
+	ar := consensus.NewActorRegistry()
+
+	code, ok := actors.GetActorCodeID(actorstypes.Version10, manifest.MinerKey)
+	require.True(t, ok)
+
+	schema, err := stmgr.ActorSchema(ar, code)
+	require.NoError(t, err)
+	require.Equal(t, manifest.MinerKey, schema.Name)
+	require.Equal(t, int(actorstypes.Version10), schema.Version)
+
+	var st map[string]interface{}
+	require.NoError(t, json.Unmarshal(schema.State, &st))
+	require.Contains(t, st, "definitions")
+
+	require.NotEmpty(t, schema.Methods)
+	var found bool
+	for i, m := range schema.Methods {
+		if i > 0 {
+			require.Less(t, schema.Methods[i-1].Num, m.Num)
+		}
+		require.True(t, json.Valid(m.Params))
+		require.True(t, json.Valid(m.Return))
+		if m.Num == builtin.MethodsMiner.SubmitWindowedPoSt {
+			found = true
+			require.Equal(t, "SubmitWindowedPoSt", m.Name)
+		}
+	}
+	require.True(t, found)
+
+	// unknown code
+	_, err = stmgr.ActorSchema(ar, mock.MkBlock(nil, 0, 0).Cid())
+	require.ErrorIs(t, err, stmgr.ErrMetadataNotFound)
+}
@@ -31,6 +31,12 @@ var ErrExpensiveFork = errors.New("refusing explicit call due to state fork at e
 // tipset's parent. In the presence of null blocks, the height at which the message is invoked may
 // be less than the specified tipset.
 func (sm *StateManager) Call(ctx context.Context, msg *types.Message, ts *types.TipSet) (*api.InvocResult, error) {
+	return sm.CallWithOverrides(ctx, msg, nil, ts)
+}
+
+// CallWithOverrides is like Call, but executes the message on a copy of the
+// state in which the given actors have been overridden.
+func (sm *StateManager) CallWithOverrides(ctx context.Context, msg *types.Message, overrides []api.ActorOverride, ts *types.TipSet) (*api.InvocResult, error) {
 	// Copy the message as we modify it below.
 	msgCopy := *msg
 	msg = &msgCopy
@@ -48,12 +54,12 @@ func (sm *StateManager) Call(ctx context.Context, msg *types.Message, ts *types.
 		msg.Value = types.NewInt(0)
 	}
 
-	return sm.callInternal(ctx, msg, nil, ts, cid.Undef, sm.GetNetworkVersion, false, false)
+	return sm.callInternal(ctx, msg, nil, overrides, ts, cid.Undef, sm.GetNetworkVersion, false, false)
 }
 
 // CallWithGas calculates the state for a given tipset, and then applies the given message on top of that state.
 func (sm *StateManager) CallWithGas(ctx context.Context, msg *types.Message, priorMsgs []types.ChainMsg, ts *types.TipSet, applyTsMessages bool) (*api.InvocResult, error) {
-	return sm.callInternal(ctx, msg, priorMsgs, ts, cid.Undef, sm.GetNetworkVersion, true, applyTsMessages)
+	return sm.callInternal(ctx, msg, priorMsgs, nil, ts, cid.Undef, sm.GetNetworkVersion, true, applyTsMessages)
 }
 
 // CallAtStateAndVersion allows you to specify a message to execute on the given stateCid and network version.
@@ -65,13 +71,13 @@ func (sm *StateManager) CallAtStateAndVersion(ctx context.Context, msg *types.Me
 		return v
 	}
 
-	return sm.callInternal(ctx, msg, nil, nil, stateCid, nvGetter, true, false)
+	return sm.callInternal(ctx, msg, nil, nil, nil, stateCid, nvGetter, true, false)
 }
 
 //   - If no tipset is specified, the first tipset without an expensive migration or one in its parent is used.
 //   - If executing a message at a given tipset or its parent would trigger an expensive migration, the call will
 //     fail with ErrExpensiveFork.
-func (sm *StateManager) callInternal(ctx context.Context, msg *types.Message, priorMsgs []types.ChainMsg, ts *types.TipSet, stateCid cid.Cid, nvGetter rand.NetworkVersionGetter, checkGas, applyTsMessages bool) (*api.InvocResult, error) {
+func (sm *StateManager) callInternal(ctx context.Context, msg *types.Message, priorMsgs []types.ChainMsg, overrides []api.ActorOverride, ts *types.TipSet, stateCid cid.Cid, nvGetter rand.NetworkVersionGetter, checkGas, applyTsMessages bool) (*api.InvocResult, error) {
 	ctx, span := trace.StartSpan(ctx, "statemanager.callInternal")
 	defer span.End()
 
@@ -79,6 +85,19 @@ func (sm *StateManager) callInternal(ctx context.Context, msg *types.Message, pr
 	msgCopy := *msg
 	msg = &msgCopy
 
+	// Only calls against a tipset's own state, without any prior messages or
+	// overrides, are fully determined by the tipset and the message, and can
+	// be cached.
+	cacheable := sm.callCache != nil && stateCid == cid.Undef && len(priorMsgs) == 0 && len(overrides) == 0
+	var cacheKey callCacheKey
+	if cacheable {
+		cacheKey = callCacheKey{
+			msg:             msg.Cid(),
+			checkGas:        checkGas,
+			applyTsMessages: applyTsMessages,
+		}
+	}
+
 	var err error
 	var pts *types.TipSet
 	if ts == nil {
@@ -111,6 +130,13 @@ func (sm *StateManager) callInternal(ctx context.Context, msg *types.Message, pr
 		}
 	}
 
+	if cacheable {
+		cacheKey.tsk = ts.Key()
+		if res, ok := sm.callCache.get(ctx, cacheKey); ok {
+			return res, nil
+		}
+	}
+
 	// Unless executing on a specific state cid, apply all the messages from the current tipset
 	// first. Unfortunately, we can't just execute the tipset, because that will run cron. We
 	// don't want to apply miner messages after cron runs in a given epoch.
@@ -183,6 +209,21 @@ func (sm *StateManager) callInternal(ctx context.Context, msg *types.Message, pr
 		return nil, xerrors.Errorf("flushing vm: %w", err)
 	}
 
+	// The overrides apply on top of the prior messages, so the message sees
+	// exactly the overridden actors.
+	if len(overrides) > 0 {
+		stateCid, err = applyStateOverrides(ctx, buffStore, stateCid, overrides)
+		if err != nil {
+			return nil, xerrors.Errorf("applying state overrides: %w", err)
+		}
+
+		vmopt.StateBase = stateCid
+		vmi, err = sm.newVM(ctx, vmopt)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to set up vm: %w", err)
+		}
+	}
+
 	stTree, err := state.LoadStateTree(cbor.NewCborStore(buffStore), stateCid)
 	if err != nil {
 		return nil, xerrors.Errorf("loading state tree: %w", err)
@@ -255,7 +296,7 @@ func (sm *StateManager) callInternal(ctx context.Context, msg *types.Message, pr
 		errs = ret.ActorErr.Error()
 	}
 
-	return &api.InvocResult{
+	res := &api.InvocResult{
 		MsgCid:         msg.Cid(),
 		Msg:            msg,
 		MsgRct:         &ret.MessageReceipt,
@@ -263,7 +304,12 @@ func (sm *StateManager) callInternal(ctx context.Context, msg *types.Message, pr
 		ExecutionTrace: ret.ExecutionTrace,
 		Error:          errs,
 		Duration:       ret.Duration,
-	}, err
+	}
+	if cacheable && err == nil {
+		sm.callCache.add(cacheKey, res)
+	}
+
+	return res, err
 }
 
 var errHaltExecution = fmt.Errorf("halt")
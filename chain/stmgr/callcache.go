@@ -0,0 +1,193 @@
+package stmgr
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hashicorp/golang-lru/v2/simplelru"
+	"github.com/ipfs/go-cid"
+	"go.opencensus.io/stats"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/metrics"
+)
+
+// callCacheMaxEntries bounds the number of entries in the call cache,
+// independently of their size.
+const callCacheMaxEntries = 1 << 16
+
+// callCacheEntryOverhead approximates the fixed memory cost of a cached call
+// result, on top of the variable size byte slices it references.
+const callCacheEntryOverhead = 1024
+
+// callCacheKey identifies a call by the tipset it executes against, the
+// message, and the execution flags that affect its result.
+type callCacheKey struct {
+	tsk             types.TipSetKey
+	msg             cid.Cid
+	checkGas        bool
+	applyTsMessages bool
+}
+
+type callCacheEntry struct {
+	res  *api.InvocResult
+	size int64
+}
+
+// callCache memoizes the results of read-only calls. Its memory use is
+// bounded by maxBytes, and it is cleared on every head change.
+type callCache struct {
+	lk       sync.Mutex
+	lru      *simplelru.LRU[callCacheKey, callCacheEntry]
+	size     int64
+	maxBytes int64
+}
+
+func newCallCache(maxBytes int64) (*callCache, error) {
+	c := &callCache{maxBytes: maxBytes}
+
+	lru, err := simplelru.NewLRU[callCacheKey, callCacheEntry](callCacheMaxEntries, func(_ callCacheKey, e callCacheEntry) {
+		c.size -= e.size
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.lru = lru
+
+	return c, nil
+}
+
+func (c *callCache) get(ctx context.Context, key callCacheKey) (*api.InvocResult, bool) {
+	c.lk.Lock()
+	e, ok := c.lru.Get(key)
+	c.lk.Unlock()
+
+	if !ok {
+		stats.Record(ctx, metrics.CallCacheMiss.M(1))
+		return nil, false
+	}
+	stats.Record(ctx, metrics.CallCacheHit.M(1))
+
+	// hand out a copy so callers can't modify the cached result
+	return copyInvocResult(e.res), true
+}
+
+func (c *callCache) add(key callCacheKey, res *api.InvocResult) {
+	size := invocResultSize(res)
+	if size > c.maxBytes {
+		return
+	}
+	res = copyInvocResult(res)
+
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	// replacing an entry doesn't call the eviction callback, so remove it first
+	// to keep the size accounting right
+	c.lru.Remove(key)
+	c.lru.Add(key, callCacheEntry{res: res, size: size})
+	c.size += size
+	for c.size > c.maxBytes {
+		c.lru.RemoveOldest()
+	}
+}
+
+func (c *callCache) purge() {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	c.lru.Purge()
+}
+
+// copyInvocResult deep copies a call result, except for the big integers,
+// which are never modified in place.
+func copyInvocResult(res *api.InvocResult) *api.InvocResult {
+	out := *res
+	if res.Msg != nil {
+		msg := *res.Msg
+		msg.Params = copyBytes(msg.Params)
+		out.Msg = &msg
+	}
+	if res.MsgRct != nil {
+		rct := *res.MsgRct
+		rct.Return = copyBytes(rct.Return)
+		out.MsgRct = &rct
+	}
+	out.ExecutionTrace = copyExecutionTrace(res.ExecutionTrace)
+	return &out
+}
+
+func copyExecutionTrace(et types.ExecutionTrace) types.ExecutionTrace {
+	et.Msg.Params = copyBytes(et.Msg.Params)
+	et.MsgRct.Return = copyBytes(et.MsgRct.Return)
+
+	if et.GasCharges != nil {
+		charges := make([]*types.GasTrace, len(et.GasCharges))
+		for i, gc := range et.GasCharges {
+			if gc != nil {
+				c := *gc
+				charges[i] = &c
+			}
+		}
+		et.GasCharges = charges
+	}
+
+	if et.Subcalls != nil {
+		subcalls := make([]types.ExecutionTrace, len(et.Subcalls))
+		for i := range et.Subcalls {
+			subcalls[i] = copyExecutionTrace(et.Subcalls[i])
+		}
+		et.Subcalls = subcalls
+	}
+	return et
+}
+
+func copyBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
+
+// invocResultSize estimates the memory retained by a call result.
+func invocResultSize(res *api.InvocResult) int64 {
+	size := int64(callCacheEntryOverhead + len(res.Error))
+	if res.Msg != nil {
+		size += int64(len(res.Msg.Params))
+	}
+	if res.MsgRct != nil {
+		size += int64(len(res.MsgRct.Return))
+	}
+	return size + executionTraceSize(&res.ExecutionTrace)
+}
+
+func executionTraceSize(et *types.ExecutionTrace) int64 {
+	size := int64(callCacheEntryOverhead + len(et.Msg.Params) + len(et.MsgRct.Return))
+	for _, gc := range et.GasCharges {
+		size += int64(64 + len(gc.Name))
+	}
+	for i := range et.Subcalls {
+		size += executionTraceSize(&et.Subcalls[i])
+	}
+	return size
+}
+
+// EnableCallCache enables memoization of Call and CallWithGas results for
+// identical messages executed against the same tipset, using at most
+// maxBytes of memory. Calls with prior messages are never cached. The cache
+// is cleared whenever the chain head changes.
+func (sm *StateManager) EnableCallCache(maxBytes int64) error {
+	cc, err := newCallCache(maxBytes)
+	if err != nil {
+		return err
+	}
+	sm.callCache = cc
+
+	sm.cs.SubscribeHeadChanges(func(rev, app []*types.TipSet) error {
+		cc.purge()
+		return nil
+	})
+
+	return nil
+}
@@ -0,0 +1,93 @@
+// stm: #unit
+package stmgr
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func testCallResult(returnSize int) *api.InvocResult {
+	return &api.InvocResult{
+		MsgRct: &types.MessageReceipt{Return: make([]byte, returnSize)},
+		ExecutionTrace: types.ExecutionTrace{
+			Subcalls: []types.ExecutionTrace{{GasCharges: []*types.GasTrace{{Name: "OnMethodInvocation"}}}},
+		},
+	}
+}
+
+func testCallKey(nonce uint64) callCacheKey {
+	msg := &types.Message{
+		To:     mock.Address(1000),
+		From:   mock.Address(1001),
+		Nonce:  nonce,
+		Method: abi.MethodNum(2),
+	}
+	return callCacheKey{
+		tsk: types.NewTipSetKey(mock.MkBlock(nil, 0, 0).Cid()),
+		msg: msg.Cid(),
+	}
+}
+
+func TestCallCache(t *testing.T) {
+	ctx := context.Background()
+
+	cc, err := newCallCache(20 * callCacheEntryOverhead)
+	require.NoError(t, err)
+
+	k1, k2, k3 := testCallKey(1), testCallKey(2), testCallKey(3)
+
+	_, ok := cc.get(ctx, k1)
+	require.False(t, ok)
+
+	cc.add(k1, testCallResult(100))
+	res, ok := cc.get(ctx, k1)
+	require.True(t, ok)
+	require.Len(t, res.MsgRct.Return, 100)
+
+	// results are copied out of the cache
+	res.Error = "modified"
+	res.MsgRct.Return[0] = 1
+	res.ExecutionTrace.Subcalls[0].GasCharges[0].TotalGas = 1
+	res, ok = cc.get(ctx, k1)
+	require.True(t, ok)
+	require.Empty(t, res.Error)
+	require.Zero(t, res.MsgRct.Return[0])
+	require.Zero(t, res.ExecutionTrace.Subcalls[0].GasCharges[0].TotalGas)
+
+	// results larger than the whole cache are never stored
+	cc.add(k2, testCallResult(20*callCacheEntryOverhead))
+	_, ok = cc.get(ctx, k2)
+	require.False(t, ok)
+
+	// adding past the memory bound evicts the least recently used entries
+	cc.add(k2, testCallResult(8*callCacheEntryOverhead))
+	cc.add(k3, testCallResult(8*callCacheEntryOverhead))
+	_, ok = cc.get(ctx, k1)
+	require.False(t, ok)
+	_, ok = cc.get(ctx, k2)
+	require.True(t, ok)
+	_, ok = cc.get(ctx, k3)
+	require.True(t, ok)
+	require.LessOrEqual(t, cc.size, cc.maxBytes)
+
+	cc.purge()
+	_, ok = cc.get(ctx, k3)
+	require.False(t, ok)
+	require.Zero(t, cc.size)
+
+	// results are copied into the cache too
+	added := testCallResult(100)
+	cc.add(k1, added)
+	added.MsgRct.Return[0] = 1
+	res, ok = cc.get(ctx, k1)
+	require.True(t, ok)
+	require.Zero(t, res.MsgRct.Return[0])
+}
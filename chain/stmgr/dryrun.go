@@ -0,0 +1,170 @@
+package stmgr
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	dstore "github.com/ipfs/go-datastore"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/index"
+	"github.com/filecoin-project/lotus/chain/state"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// MigrationDryRun runs the migration of the next network upgrade after the
+// given tipset against the state computed by the tipset, as if the upgrade
+// happened right after it. The migrated state is written to an in-memory
+// buffer on top of the chain blockstore and discarded once done, so nothing is
+// persisted. Migrating the state of a large network takes a lot of time and
+// memory.
+func (sm *StateManager) MigrationDryRun(ctx context.Context, ts *types.TipSet) (*api.MigrationDryRun, error) {
+	height := abi.ChainEpoch(-1)
+	for h, m := range sm.stateMigrations {
+		if h > ts.Height() && m.upgrade != nil && (height < 0 || h < height) {
+			height = h
+		}
+	}
+	if height < 0 {
+		return nil, xerrors.Errorf("no pending network upgrade with a migration after epoch %d", ts.Height())
+	}
+	u := sm.stateMigrations[height]
+
+	root, _, err := sm.TipSetState(ctx, ts)
+	if err != nil {
+		return nil, xerrors.Errorf("computing tipset state: %w", err)
+	}
+
+	// a state manager writing to memory, with no migrations of its own
+	bs := blockstore.NewBuffered(sm.cs.StateBlockstore())
+	weight := func(ctx context.Context, _ blockstore.Blockstore, ts *types.TipSet) (types.BigInt, error) {
+		return sm.cs.Weight(ctx, ts)
+	}
+	cs := store.NewChainStore(blockstore.NewBuffered(sm.cs.ChainBlockstore()), bs, dstore.NewMapDatastore(), weight, nil)
+	defer cs.Close() //nolint:errcheck
+
+	dry := &StateManager{
+		cs:              cs,
+		networkVersions: sm.networkVersions,
+		latestVersion:   sm.latestVersion,
+		newVM:           sm.newVM,
+		Syscalls:        sm.Syscalls,
+		tsExec:          sm.tsExec,
+		beacon:          sm.beacon,
+		msgIndex:        index.DummyMsgIndex,
+		stCache:         make(map[string][]cid.Cid),
+		compWait:        make(map[string]chan struct{}),
+		genesisPledge:   sm.genesisPledge,
+	}
+
+	out := &api.MigrationDryRun{
+		TipSet:       ts.Key(),
+		Height:       height,
+		Network:      sm.GetNetworkVersion(ctx, height+1),
+		OldStateRoot: root,
+	}
+
+	// the pre-migration results speed up the migration, the cache is cloned
+	// so they aren't updated
+	start := time.Now()
+	out.NewStateRoot, err = u.upgrade(ctx, dry, u.cache.Clone(), nil, root, height, ts)
+	if err != nil {
+		return nil, xerrors.Errorf("running migration at epoch %d: %w", height, err)
+	}
+	out.Duration = time.Since(start)
+
+	if err := diffMigratedActors(ctx, cbor.NewCborStore(bs), out); err != nil {
+		return nil, xerrors.Errorf("comparing the migrated state: %w", err)
+	}
+	return out, nil
+}
+
+func diffMigratedActors(ctx context.Context, cst cbor.IpldStore, out *api.MigrationDryRun) error {
+	oldTree, err := state.LoadStateTree(cst, out.OldStateRoot)
+	if err != nil {
+		return xerrors.Errorf("loading old state tree: %w", err)
+	}
+	newTree, err := state.LoadStateTree(cst, out.NewStateRoot)
+	if err != nil {
+		return xerrors.Errorf("loading new state tree: %w", err)
+	}
+
+	type codes struct{ old, new cid.Cid }
+	changes := map[codes]*api.MigrationActorChanges{}
+
+	err = oldTree.ForEach(func(addr address.Address, oldAct *types.Actor) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		newAct, err := newTree.GetActor(addr)
+		if xerrors.Is(err, types.ErrActorNotFound) {
+			out.Removed = append(out.Removed, addr)
+			return nil
+		}
+		if err != nil {
+			return xerrors.Errorf("getting migrated actor %s: %w", addr, err)
+		}
+
+		k := codes{oldAct.Code, newAct.Code}
+		c, ok := changes[k]
+		if !ok {
+			c = &api.MigrationActorChanges{OldCode: oldAct.Code, NewCode: newAct.Code}
+			if name, av, ok := actors.GetActorMetaByCode(oldAct.Code); ok {
+				c.Name, c.OldVersion = name, int(av)
+			}
+			if name, av, ok := actors.GetActorMetaByCode(newAct.Code); ok {
+				c.Name, c.NewVersion = name, int(av)
+			}
+			changes[k] = c
+		}
+		c.Actors++
+		if oldAct.Head != newAct.Head {
+			c.StateChanged++
+		}
+		if !oldAct.Balance.Equals(newAct.Balance) {
+			c.BalanceChanged++
+		}
+		return nil
+	})
+	if err != nil {
+		return xerrors.Errorf("iterating old actors: %w", err)
+	}
+
+	err = newTree.ForEach(func(addr address.Address, _ *types.Actor) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		_, err := oldTree.GetActor(addr)
+		if xerrors.Is(err, types.ErrActorNotFound) {
+			out.Added = append(out.Added, addr)
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return xerrors.Errorf("iterating migrated actors: %w", err)
+	}
+
+	for _, c := range changes {
+		out.Actors = append(out.Actors, *c)
+	}
+	sort.Slice(out.Actors, func(i, j int) bool {
+		if out.Actors[i].Name != out.Actors[j].Name {
+			return out.Actors[i].Name < out.Actors[j].Name
+		}
+		return out.Actors[i].OldCode.KeyString() < out.Actors[j].OldCode.KeyString()
+	})
+	return nil
+}
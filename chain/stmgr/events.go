@@ -0,0 +1,64 @@
+package stmgr
+
+import (
+	"context"
+
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/vm"
+)
+
+type returnEventsKey struct{}
+
+// WithReturnEvents makes the tipsets executed with the returned context return
+// the actor events emitted by the messages to the ExecMonitor, even when the
+// chain store doesn't store events.
+func WithReturnEvents(ctx context.Context) context.Context {
+	return context.WithValue(ctx, returnEventsKey{}, true)
+}
+
+// ReturnEvents returns whether the events must be returned by the tipsets
+// executed with the context, see WithReturnEvents.
+func ReturnEvents(ctx context.Context) bool {
+	v, _ := ctx.Value(returnEventsKey{}).(bool)
+	return v
+}
+
+// ReplayEvents re-executes the messages of the tipset, and returns the events
+// they emitted, in the order of their receipts. Nothing is stored.
+func (sm *StateManager) ReplayEvents(ctx context.Context, ts *types.TipSet) ([]api.ReplayedEvent, error) {
+	ec := &eventCollector{}
+	if _, err := sm.ExecutionTraceWithMonitor(WithReturnEvents(ctx), ts, ec); err != nil {
+		return nil, xerrors.Errorf("executing tipset %s: %w", ts.Key(), err)
+	}
+	return ec.events, nil
+}
+
+var _ ExecMonitor = (*eventCollector)(nil)
+
+type eventCollector struct {
+	msgs   int
+	events []api.ReplayedEvent
+}
+
+func (e *eventCollector) MessageApplied(ctx context.Context, ts *types.TipSet, mcid cid.Cid, msg *types.Message, ret *vm.ApplyRet, implicit bool) error {
+	// the implicit messages don't have receipts
+	if implicit {
+		return nil
+	}
+
+	for i, ev := range ret.Events {
+		e.events = append(e.events, api.ReplayedEvent{
+			MsgCid:   mcid,
+			MsgIdx:   e.msgs,
+			EventIdx: i,
+			Emitter:  ev.Emitter,
+			Entries:  ev.Entries,
+		})
+	}
+	e.msgs++
+	return nil
+}
@@ -0,0 +1,97 @@
+// stm: #unit
+package stmgr_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/consensus/filcns"
+	"github.com/filecoin-project/lotus/chain/index"
+	"github.com/filecoin-project/lotus/chain/stmgr"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+	"github.com/filecoin-project/lotus/chain/vm"
+)
+
+// eventsExecutor "executes" a tipset by applying messages emitting the given
+// events, when asked to return the events.
+type eventsExecutor struct {
+	msgs   []*types.Message
+	events [][]types.Event
+}
+
+func (e *eventsExecutor) NewActorRegistry() *vm.ActorRegistry {
+	return nil
+}
+
+func (e *eventsExecutor) ExecuteTipSet(ctx context.Context, _ *stmgr.StateManager, ts *types.TipSet, em stmgr.ExecMonitor, _ bool) (cid.Cid, cid.Cid, error) {
+	if !stmgr.ReturnEvents(ctx) {
+		return cid.Undef, cid.Undef, xerrors.New("events not returned")
+	}
+
+	for i, msg := range e.msgs {
+		ret := &vm.ApplyRet{Events: e.events[i]}
+		implicit := msg.From == msg.To
+		if err := em.MessageApplied(ctx, ts, msg.Cid(), msg, ret, implicit); err != nil {
+			return cid.Undef, cid.Undef, err
+		}
+	}
+	return ts.ParentState(), ts.Blocks()[0].ParentMessageReceipts, nil
+}
+
+func TestReplayEvents(t *testing.T) {
+	ctx := context.Background()
+
+	event := func(emitter abi.ActorID, key string) types.Event {
+		return types.Event{
+			Emitter: emitter,
+			Entries: []types.EventEntry{{Flags: 0x03, Key: key, Codec: 0x55, Value: []byte(key)}},
+		}
+	}
+
+	msg := func(nonce uint64, implicit bool) *types.Message {
+		m := &types.Message{To: mock.Address(1000), From: mock.Address(1001), Nonce: nonce}
+		if implicit {
+			m.From = m.To
+		}
+		return m
+	}
+	msgs := []*types.Message{msg(0, false), msg(1, true), msg(2, false), msg(3, false)}
+
+	exec := &eventsExecutor{
+		msgs: msgs,
+		events: [][]types.Event{
+			{event(1000, "a"), event(1000, "b")},
+			{event(1001, "implicit")},
+			nil,
+			{event(1002, "c")},
+		},
+	}
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, datastore.NewMapDatastore(), filcns.Weight, nil)
+	sm, err := stmgr.NewStateManager(cs, exec, nil, stmgr.UpgradeSchedule{}, nil, datastore.NewMapDatastore(), index.DummyMsgIndex)
+	require.NoError(t, err)
+
+	ts := mock.TipSet(mock.MkBlock(nil, 1, 1))
+	events, err := sm.ReplayEvents(ctx, ts)
+	require.NoError(t, err)
+
+	// the events of the implicit messages are skipped, the indexes match the
+	// receipts of the tipset
+	require.Equal(t, []api.ReplayedEvent{
+		{MsgCid: msgs[0].Cid(), MsgIdx: 0, EventIdx: 0, Emitter: 1000, Entries: exec.events[0][0].Entries},
+		{MsgCid: msgs[0].Cid(), MsgIdx: 0, EventIdx: 1, Emitter: 1000, Entries: exec.events[0][1].Entries},
+		{MsgCid: msgs[3].Cid(), MsgIdx: 2, EventIdx: 0, Emitter: 1002, Entries: exec.events[3][0].Entries},
+	}, events)
+}
@@ -709,3 +709,72 @@ func TestMigrtionCache(t *testing.T) {
 		require.Equal(t, 1, len(counter))
 	}
 }
+
+func TestMigrationDryRun(t *testing.T) {
+	ctx := context.Background()
+
+	cg, err := gen.NewGenerator()
+	require.NoError(t, err)
+
+	added, err := address.NewIDAddress(10000)
+	require.NoError(t, err)
+
+	sm, err := NewStateManager(
+		cg.ChainStore(), consensus.NewTipSetExecutor(filcns.RewardFunc), cg.StateManager().VMSys(), UpgradeSchedule{{
+			Network: network.Version1,
+			Height:  testForkHeight,
+			Migration: func(ctx context.Context, sm *StateManager, cache MigrationCache, cb ExecMonitor,
+				root cid.Cid, height abi.ChainEpoch, ts *types.TipSet) (cid.Cid, error) {
+				st, err := sm.StateTree(root)
+				if err != nil {
+					return cid.Undef, err
+				}
+
+				act, err := st.GetActor(builtin.BurntFundsActorAddr)
+				if err != nil {
+					return cid.Undef, err
+				}
+				if err := st.SetActor(added, &types.Actor{Code: act.Code, Head: act.Head, Balance: types.NewInt(0)}); err != nil {
+					return cid.Undef, err
+				}
+				act.Balance = types.BigAdd(act.Balance, types.NewInt(1))
+				if err := st.SetActor(builtin.BurntFundsActorAddr, act); err != nil {
+					return cid.Undef, err
+				}
+
+				return st.Flush(ctx)
+			}}},
+		cg.BeaconSchedule(), datastore.NewMapDatastore(), index.DummyMsgIndex)
+	require.NoError(t, err)
+
+	ts, err := types.NewTipSet([]*types.BlockHeader{cg.Genesis()})
+	require.NoError(t, err)
+
+	res, err := sm.MigrationDryRun(ctx, ts)
+	require.NoError(t, err)
+	require.Equal(t, abi.ChainEpoch(testForkHeight), res.Height)
+	require.Equal(t, network.Version1, res.Network)
+	require.Equal(t, ts.ParentState(), res.OldStateRoot)
+	require.NotEqual(t, res.OldStateRoot, res.NewStateRoot)
+	require.Equal(t, []address.Address{added}, res.Added)
+	require.Empty(t, res.Removed)
+
+	var balanceChanged int
+	for _, c := range res.Actors {
+		require.Equal(t, c.OldCode, c.NewCode)
+		balanceChanged += c.BalanceChanged
+	}
+	require.Equal(t, 1, balanceChanged)
+
+	// the migrated state was only written in memory
+	has, err := cg.ChainStore().StateBlockstore().Has(ctx, res.NewStateRoot)
+	require.NoError(t, err)
+	require.False(t, has)
+
+	// no pending upgrade
+	sm, err = NewStateManager(cg.ChainStore(), consensus.NewTipSetExecutor(filcns.RewardFunc), cg.StateManager().VMSys(), nil,
+		cg.BeaconSchedule(), datastore.NewMapDatastore(), index.DummyMsgIndex)
+	require.NoError(t, err)
+	_, err = sm.MigrationDryRun(ctx, ts)
+	require.Error(t, err)
+}
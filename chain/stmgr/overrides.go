@@ -0,0 +1,111 @@
+package stmgr
+
+import (
+	"context"
+	"errors"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	cbg "github.com/whyrusleeping/cbor-gen"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/state"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/vm"
+)
+
+// applyStateOverrides applies the actor overrides to the state tree at root,
+// writing to bs, and returns the root of the resulting state tree. Actors
+// which don't exist are created if their code is overridden.
+func applyStateOverrides(ctx context.Context, bs blockstore.Blockstore, root cid.Cid, overrides []api.ActorOverride) (cid.Cid, error) {
+	tree, err := state.LoadStateTree(cbor.NewCborStore(bs), root)
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("loading state tree: %w", err)
+	}
+
+	for _, o := range overrides {
+		if err := overrideActor(ctx, bs, tree, o); err != nil {
+			return cid.Undef, xerrors.Errorf("overriding actor %s: %w", o.Address, err)
+		}
+	}
+
+	return tree.Flush(ctx)
+}
+
+func overrideActor(ctx context.Context, bs blockstore.Blockstore, tree *state.StateTree, o api.ActorOverride) error {
+	if o.Head != nil && o.State != nil {
+		return xerrors.Errorf("only one of head and state can be overridden")
+	}
+
+	act, err := tree.GetActor(o.Address)
+	create := errors.Is(err, types.ErrActorNotFound)
+	switch {
+	case create:
+		if o.Code == nil {
+			return xerrors.Errorf("actor doesn't exist, its code must be set to create it")
+		}
+		act = &types.Actor{
+			Head:    vm.EmptyObjectCid,
+			Balance: big.Zero(),
+		}
+	case err != nil:
+		return xerrors.Errorf("getting actor: %w", err)
+	}
+
+	if o.Code != nil {
+		act.Code = *o.Code
+	}
+	if o.Balance != nil {
+		act.Balance = *o.Balance
+	}
+	if o.Nonce != nil {
+		act.Nonce = *o.Nonce
+	}
+	if o.Head != nil {
+		has, err := bs.Has(ctx, *o.Head)
+		if err != nil {
+			return xerrors.Errorf("checking head: %w", err)
+		}
+		if !has {
+			return xerrors.Errorf("head %s not found", *o.Head)
+		}
+		act.Head = *o.Head
+	}
+	if o.State != nil {
+		if err := cbg.ValidateCBOR(o.State); err != nil {
+			return xerrors.Errorf("state isn't valid cbor: %w", err)
+		}
+		head, err := abi.CidBuilder.Sum(o.State)
+		if err != nil {
+			return xerrors.Errorf("computing state cid: %w", err)
+		}
+		blk, err := blocks.NewBlockWithCid(o.State, head)
+		if err != nil {
+			return err
+		}
+		if err := bs.Put(ctx, blk); err != nil {
+			return xerrors.Errorf("storing state: %w", err)
+		}
+		act.Head = head
+	}
+
+	addr := o.Address
+	if create && addr.Protocol() != address.ID {
+		if addr.Protocol() == address.Delegated {
+			act.Address = &o.Address
+		}
+		addr, err = tree.RegisterNewAddress(o.Address)
+		if err != nil {
+			return xerrors.Errorf("registering address: %w", err)
+		}
+	}
+
+	return tree.SetActor(addr, act)
+}
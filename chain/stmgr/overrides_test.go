@@ -0,0 +1,109 @@
+// stm: #unit
+package stmgr
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/state"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/vm"
+)
+
+func TestApplyStateOverrides(t *testing.T) {
+	ctx := context.Background()
+	bs := blockstore.NewMemory()
+	cst := cbor.NewCborStore(bs)
+
+	code, err := abi.CidBuilder.Sum([]byte("code"))
+	require.NoError(t, err)
+	otherCode, err := abi.CidBuilder.Sum([]byte("other code"))
+	require.NoError(t, err)
+
+	existing, err := address.NewIDAddress(1000)
+	require.NoError(t, err)
+	missing, err := address.NewIDAddress(1001)
+	require.NoError(t, err)
+
+	tree, err := state.NewStateTree(cst, types.StateTreeVersion5)
+	require.NoError(t, err)
+	require.NoError(t, tree.SetActor(existing, &types.Actor{
+		Code:    code,
+		Head:    vm.EmptyObjectCid,
+		Nonce:   3,
+		Balance: big.NewInt(100),
+	}))
+	root, err := tree.Flush(ctx)
+	require.NoError(t, err)
+
+	getActor := func(root cid.Cid, addr address.Address) *types.Actor {
+		tree, err := state.LoadStateTree(cst, root)
+		require.NoError(t, err)
+		act, err := tree.GetActor(addr)
+		require.NoError(t, err)
+		return act
+	}
+
+	// no overrides leave the state as is
+	newRoot, err := applyStateOverrides(ctx, bs, root, nil)
+	require.NoError(t, err)
+	require.Equal(t, root, newRoot)
+
+	// fields which aren't overridden are kept
+	balance := big.NewInt(500)
+	nonce := uint64(7)
+	actorState := []byte{0x82, 0x01, 0x02} // [1, 2]
+	newRoot, err = applyStateOverrides(ctx, bs, root, []api.ActorOverride{{
+		Address: existing,
+		Balance: &balance,
+		State:   actorState,
+	}, {
+		Address: missing,
+		Code:    &otherCode,
+		Nonce:   &nonce,
+	}})
+	require.NoError(t, err)
+
+	act := getActor(newRoot, existing)
+	require.Equal(t, code, act.Code)
+	require.Equal(t, uint64(3), act.Nonce)
+	require.Equal(t, balance, act.Balance)
+	raw, err := bs.Get(ctx, act.Head)
+	require.NoError(t, err)
+	require.Equal(t, actorState, raw.RawData())
+
+	act = getActor(newRoot, missing)
+	require.Equal(t, otherCode, act.Code)
+	require.Equal(t, nonce, act.Nonce)
+	require.True(t, act.Balance.IsZero())
+	require.Equal(t, vm.EmptyObjectCid, act.Head)
+
+	// the original state is untouched
+	require.Equal(t, big.NewInt(100), getActor(root, existing).Balance)
+
+	// invalid overrides
+	_, err = applyStateOverrides(ctx, bs, root, []api.ActorOverride{{Address: missing, Balance: &balance}})
+	require.Error(t, err)
+
+	head := vm.EmptyObjectCid
+	_, err = applyStateOverrides(ctx, bs, root, []api.ActorOverride{{Address: existing, Head: &head, State: actorState}})
+	require.Error(t, err)
+
+	_, err = applyStateOverrides(ctx, bs, root, []api.ActorOverride{{Address: existing, State: []byte{0x82, 0x01}}})
+	require.Error(t, err)
+
+	unknownHead, err := abi.CidBuilder.Sum([]byte("unknown"))
+	require.NoError(t, err)
+	_, err = applyStateOverrides(ctx, bs, root, []api.ActorOverride{{Address: existing, Head: &unknownHead}})
+	require.Error(t, err)
+}
@@ -0,0 +1,249 @@
+package stmgr
+
+import (
+	"context"
+	"sync"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	blockadt "github.com/filecoin-project/specs-actors/actors/util/adt"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/actors/adt"
+	"github.com/filecoin-project/lotus/chain/state"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// proofRecorder records every block read through it, in the order they are
+// first read. Reading a value out of a HAMT or AMT through it records the
+// path from the root to the value, which is a merkle proof of the value.
+type proofRecorder struct {
+	bs cbor.IpldBlockstore
+
+	lk     sync.Mutex
+	seen   map[cid.Cid]struct{}
+	blocks []api.ProofBlock
+}
+
+func newProofRecorder(bs cbor.IpldBlockstore) *proofRecorder {
+	return &proofRecorder{
+		bs:   bs,
+		seen: map[cid.Cid]struct{}{},
+	}
+}
+
+func (r *proofRecorder) Get(ctx context.Context, c cid.Cid) (blocks.Block, error) {
+	blk, err := r.bs.Get(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	if _, ok := r.seen[c]; !ok {
+		r.seen[c] = struct{}{}
+		r.blocks = append(r.blocks, api.ProofBlock{Cid: c, Data: blk.RawData()})
+	}
+
+	return blk, nil
+}
+
+func (r *proofRecorder) Put(context.Context, blocks.Block) error {
+	return xerrors.Errorf("proof recorder is read-only")
+}
+
+func (r *proofRecorder) proof() []api.ProofBlock {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	return append([]api.ProofBlock(nil), r.blocks...)
+}
+
+// proofStore returns a store holding the blocks of a proof, after checking
+// that every block matches its CID.
+func proofStore(ctx context.Context, proof []api.ProofBlock) (adt.Store, error) {
+	bs := blockstore.NewMemory()
+	for _, pb := range proof {
+		c, err := pb.Cid.Prefix().Sum(pb.Data)
+		if err != nil {
+			return nil, xerrors.Errorf("hashing proof block %s: %w", pb.Cid, err)
+		}
+		if !c.Equals(pb.Cid) {
+			return nil, xerrors.Errorf("proof block %s doesn't match its cid", pb.Cid)
+		}
+
+		blk, err := blocks.NewBlockWithCid(pb.Data, pb.Cid)
+		if err != nil {
+			return nil, err
+		}
+		if err := bs.Put(ctx, blk); err != nil {
+			return nil, err
+		}
+	}
+	return adt.WrapStore(ctx, cbor.NewCborStore(bs)), nil
+}
+
+// GetActorProof returns the actor with the given address in the parent state
+// of the tipset, with the blocks proving it against the state root.
+func (sm *StateManager) GetActorProof(ctx context.Context, addr address.Address, ts *types.TipSet) (*api.ActorStateProof, error) {
+	p, err := actorProof(sm.cs.StateBlockstore(), ts.ParentState(), addr)
+	if err != nil {
+		return nil, err
+	}
+	p.TipSet = ts.Key()
+	return p, nil
+}
+
+func actorProof(bs cbor.IpldBlockstore, root cid.Cid, addr address.Address) (*api.ActorStateProof, error) {
+	rec := newProofRecorder(bs)
+
+	st, err := state.LoadStateTree(cbor.NewCborStore(rec), root)
+	if err != nil {
+		return nil, xerrors.Errorf("load state tree: %w", err)
+	}
+
+	act, err := st.GetActor(addr)
+	if err != nil {
+		return nil, xerrors.Errorf("load actor: %w", err)
+	}
+
+	return &api.ActorStateProof{
+		StateRoot: root,
+		Address:   addr,
+		Actor:     act,
+		Proof:     rec.proof(),
+	}, nil
+}
+
+// VerifyActorProof checks that the actor of an actor state proof is the one
+// found at its address in the proven state root.
+func VerifyActorProof(ctx context.Context, p *api.ActorStateProof) error {
+	if p.Actor == nil {
+		return xerrors.Errorf("proof has no actor")
+	}
+
+	store, err := proofStore(ctx, p.Proof)
+	if err != nil {
+		return err
+	}
+
+	st, err := state.LoadStateTree(store, p.StateRoot)
+	if err != nil {
+		return xerrors.Errorf("load state tree from proof: %w", err)
+	}
+
+	act, err := st.GetActor(p.Address)
+	if err != nil {
+		return xerrors.Errorf("load actor from proof: %w", err)
+	}
+
+	if act.Code != p.Actor.Code || act.Head != p.Actor.Head || act.Nonce != p.Actor.Nonce || !act.Balance.Equals(p.Actor.Balance) {
+		return xerrors.Errorf("proven actor doesn't match the actor in the proof")
+	}
+	if (act.Address == nil) != (p.Actor.Address == nil) || (act.Address != nil && *act.Address != *p.Actor.Address) {
+		return xerrors.Errorf("proven actor address doesn't match the actor in the proof")
+	}
+
+	return nil
+}
+
+// GetReceiptProof returns the receipt of the message at the given index in
+// the parent messages of the tipset, with the blocks proving it against the
+// receipts root.
+func (sm *StateManager) GetReceiptProof(ctx context.Context, ts *types.TipSet, msg cid.Cid, idx uint64) (*api.ReceiptProof, error) {
+	p, err := receiptProof(ctx, sm.cs.ChainBlockstore(), ts.Blocks()[0].ParentMessageReceipts, idx)
+	if err != nil {
+		return nil, err
+	}
+	p.TipSet = ts.Key()
+	p.Message = msg
+	return p, nil
+}
+
+// SearchForReceiptProof looks back from head for the execution of a message,
+// which may have been replaced, and returns a proof of its receipt.
+func (sm *StateManager) SearchForReceiptProof(ctx context.Context, head *types.TipSet, msg cid.Cid) (*api.ReceiptProof, error) {
+	ts, _, found, err := sm.SearchForMessage(ctx, head, msg, LookbackNoLimit, true)
+	if err != nil {
+		return nil, xerrors.Errorf("searching for message: %w", err)
+	}
+	if ts == nil {
+		return nil, xerrors.Errorf("message %s wasn't executed", msg)
+	}
+
+	pts, err := sm.cs.LoadTipSet(ctx, ts.Parents())
+	if err != nil {
+		return nil, xerrors.Errorf("loading parent tipset: %w", err)
+	}
+
+	msgs, err := sm.cs.MessagesForTipset(ctx, pts)
+	if err != nil {
+		return nil, xerrors.Errorf("loading messages: %w", err)
+	}
+
+	for i, m := range msgs {
+		if m.Cid() == found {
+			return sm.GetReceiptProof(ctx, ts, found, uint64(i))
+		}
+	}
+
+	return nil, xerrors.Errorf("message %s not found in tipset %s", found, pts.Key())
+}
+
+func receiptProof(ctx context.Context, bs cbor.IpldBlockstore, root cid.Cid, idx uint64) (*api.ReceiptProof, error) {
+	rec := newProofRecorder(bs)
+
+	arr, err := blockadt.AsArray(adt.WrapStore(ctx, cbor.NewCborStore(rec)), root)
+	if err != nil {
+		return nil, xerrors.Errorf("load receipts amt: %w", err)
+	}
+
+	var rct types.MessageReceipt
+	found, err := arr.Get(idx, &rct)
+	if err != nil {
+		return nil, xerrors.Errorf("load receipt: %w", err)
+	}
+	if !found {
+		return nil, xerrors.Errorf("receipt %d not found", idx)
+	}
+
+	return &api.ReceiptProof{
+		ReceiptsRoot: root,
+		Index:        idx,
+		Receipt:      rct,
+		Proof:        rec.proof(),
+	}, nil
+}
+
+// VerifyReceiptProof checks that the receipt of a receipt proof is the one
+// found at its index in the proven receipts root.
+func VerifyReceiptProof(ctx context.Context, p *api.ReceiptProof) error {
+	store, err := proofStore(ctx, p.Proof)
+	if err != nil {
+		return err
+	}
+
+	arr, err := blockadt.AsArray(store, p.ReceiptsRoot)
+	if err != nil {
+		return xerrors.Errorf("load receipts amt from proof: %w", err)
+	}
+
+	var rct types.MessageReceipt
+	found, err := arr.Get(p.Index, &rct)
+	if err != nil {
+		return xerrors.Errorf("load receipt from proof: %w", err)
+	}
+	if !found {
+		return xerrors.Errorf("receipt %d not found in proof", p.Index)
+	}
+
+	if !rct.Equals(&p.Receipt) {
+		return xerrors.Errorf("proven receipt doesn't match the receipt in the proof")
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+// stm: #unit
+package stmgr
+
+import (
+	"context"
+	"testing"
+
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/exitcode"
+	blockadt "github.com/filecoin-project/specs-actors/actors/util/adt"
+	builtin2 "github.com/filecoin-project/specs-actors/v2/actors/builtin"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/actors/adt"
+	"github.com/filecoin-project/lotus/chain/state"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+func TestActorProof(t *testing.T) {
+	ctx := context.Background()
+	bs := blockstore.NewMemory()
+
+	st, err := state.NewStateTree(cbor.NewCborStore(bs), types.StateTreeVersion5)
+	require.NoError(t, err)
+
+	for i := uint64(100); i < 2100; i++ {
+		addr, err := address.NewIDAddress(i)
+		require.NoError(t, err)
+		require.NoError(t, st.SetActor(addr, &types.Actor{
+			Code:    builtin2.AccountActorCodeID,
+			Head:    builtin2.AccountActorCodeID,
+			Nonce:   i,
+			Balance: types.NewInt(i),
+		}))
+	}
+	root, err := st.Flush(ctx)
+	require.NoError(t, err)
+
+	addr, err := address.NewIDAddress(1234)
+	require.NoError(t, err)
+
+	p, err := actorProof(bs, root, addr)
+	require.NoError(t, err)
+	require.EqualValues(t, 1234, p.Actor.Nonce)
+	require.NoError(t, VerifyActorProof(ctx, p))
+
+	// the proof only contains the path to the actor
+	keys, err := bs.AllKeysChan(ctx)
+	require.NoError(t, err)
+	var total int
+	for range keys {
+		total++
+	}
+	require.Less(t, len(p.Proof), total/10)
+
+	// a proof for a different actor fails
+	forged := *p
+	forged.Actor = &types.Actor{Code: p.Actor.Code, Head: p.Actor.Head, Nonce: 1, Balance: p.Actor.Balance}
+	require.Error(t, VerifyActorProof(ctx, &forged))
+
+	// tampered blocks are rejected
+	forged = *p
+	forged.Proof = append([]api.ProofBlock(nil), p.Proof...)
+	forged.Proof[len(forged.Proof)-1].Data = append([]byte{0}, forged.Proof[len(forged.Proof)-1].Data...)
+	require.ErrorContains(t, VerifyActorProof(ctx, &forged), "doesn't match its cid")
+
+	// missing blocks are detected
+	forged = *p
+	forged.Proof = p.Proof[:len(p.Proof)-1]
+	require.Error(t, VerifyActorProof(ctx, &forged))
+}
+
+func TestReceiptProof(t *testing.T) {
+	ctx := context.Background()
+	bs := blockstore.NewMemory()
+	store := adt.WrapStore(ctx, cbor.NewCborStore(bs))
+
+	arr := blockadt.MakeEmptyArray(store)
+	for i := uint64(0); i < 500; i++ {
+		require.NoError(t, arr.Set(i, &types.MessageReceipt{
+			ExitCode: exitcode.Ok,
+			Return:   []byte{byte(i)},
+			GasUsed:  int64(i),
+		}))
+	}
+	root, err := arr.Root()
+	require.NoError(t, err)
+
+	p, err := receiptProof(ctx, bs, root, 321)
+	require.NoError(t, err)
+	require.EqualValues(t, 321, p.Receipt.GasUsed)
+	require.NoError(t, VerifyReceiptProof(ctx, p))
+
+	forged := *p
+	forged.Receipt.GasUsed++
+	require.Error(t, VerifyReceiptProof(ctx, &forged))
+
+	forged = *p
+	forged.Index = 322
+	require.Error(t, VerifyReceiptProof(ctx, &forged))
+}
@@ -0,0 +1,151 @@
+package stmgr
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// SetReplayWorkers sets the number of tipsets executed concurrently by
+// ExecuteTipSetRange, 1 by default.
+func (sm *StateManager) SetReplayWorkers(workers int) {
+	if workers < 1 {
+		workers = 1
+	}
+	sm.replayWorkers = workers
+}
+
+type tipSetResult struct {
+	state    cid.Cid
+	receipts cid.Cid
+}
+
+// ExecuteTipSetRange executes a range of tipsets, ordered by increasing
+// height with each tipset being the parent of the next, and checks the
+// state and receipts computed for every tipset against the ones declared by
+// its child. It returns the state and receipts computed for the last tipset.
+//
+// Every tipset is executed on top of the parent state declared in its
+// headers. When that state is already available locally the tipset doesn't
+// depend on the execution of its parent, so such tipsets are executed by up
+// to SetReplayWorkers goroutines in parallel. Tipsets whose parent state is
+// missing are executed in order once their parent's state has been computed.
+//
+// The tipsets are executed with TipSetState, sharing its cache and waiting
+// for the executions of the same tipsets already in progress.
+func (sm *StateManager) ExecuteTipSetRange(ctx context.Context, tss []*types.TipSet) (cid.Cid, cid.Cid, error) {
+	if len(tss) == 0 {
+		return cid.Undef, cid.Undef, xerrors.Errorf("no tipsets to execute")
+	}
+	workers := sm.replayWorkers
+	if workers < 1 {
+		workers = 1
+	}
+
+	for i := 1; i < len(tss); i++ {
+		if tss[i].Parents() != tss[i-1].Key() {
+			return cid.Undef, cid.Undef, xerrors.Errorf("tipset at height %d is not the parent of tipset at height %d", tss[i-1].Height(), tss[i].Height())
+		}
+	}
+
+	results := make([]tipSetResult, len(tss))
+	done := make([]bool, len(tss))
+
+	execute := func(i int) error {
+		st, rec, err := sm.TipSetState(ctx, tss[i])
+		if err != nil {
+			return xerrors.Errorf("executing tipset at height %d: %w", tss[i].Height(), err)
+		}
+		results[i] = tipSetResult{state: st, receipts: rec}
+		done[i] = true
+		return nil
+	}
+
+	// execute all tipsets whose parent state we already have in parallel
+	var independent []int
+	for i, ts := range tss {
+		has, err := sm.cs.StateBlockstore().Has(ctx, ts.ParentState())
+		if err != nil {
+			return cid.Undef, cid.Undef, xerrors.Errorf("checking for parent state at height %d: %w", ts.Height(), err)
+		}
+		if has {
+			independent = append(independent, i)
+		}
+	}
+
+	if len(independent) > 0 {
+		log.Infow("executing tipsets in parallel", "tipsets", len(independent), "total", len(tss), "workers", workers)
+
+		var (
+			wg      sync.WaitGroup
+			errLk   sync.Mutex
+			execErr error
+		)
+		throttle := make(chan struct{}, workers)
+		for _, i := range independent {
+			select {
+			case throttle <- struct{}{}:
+			case <-ctx.Done():
+				return cid.Undef, cid.Undef, ctx.Err()
+			}
+
+			errLk.Lock()
+			failed := execErr != nil
+			errLk.Unlock()
+			if failed {
+				<-throttle
+				break
+			}
+
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				defer func() { <-throttle }()
+
+				if err := execute(i); err != nil {
+					errLk.Lock()
+					if execErr == nil {
+						execErr = err
+					}
+					errLk.Unlock()
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		if execErr != nil {
+			return cid.Undef, cid.Undef, execErr
+		}
+	}
+
+	// execute the remaining tipsets in order, each of them depends on the
+	// state computed for its parent
+	for i := range tss {
+		if done[i] {
+			continue
+		}
+		if i > 0 && results[i-1].state != tss[i].ParentState() {
+			return cid.Undef, cid.Undef, xerrors.Errorf("tipset chain had state mismatch at height %d", tss[i].Height())
+		}
+		if err := execute(i); err != nil {
+			return cid.Undef, cid.Undef, err
+		}
+	}
+
+	for i := 0; i < len(tss)-1; i++ {
+		next := tss[i+1]
+		if results[i].state != next.ParentState() {
+			return cid.Undef, cid.Undef, xerrors.Errorf("tipset chain had state mismatch at height %d: computed %s, expected %s", next.Height(), results[i].state, next.ParentState())
+		}
+		if results[i].receipts != next.ParentMessageReceipts() {
+			return cid.Undef, cid.Undef, xerrors.Errorf("tipset chain had receipts mismatch at height %d: computed %s, expected %s", next.Height(), results[i].receipts, next.ParentMessageReceipts())
+		}
+	}
+
+	last := results[len(results)-1]
+	return last.state, last.receipts, nil
+}
@@ -0,0 +1,123 @@
+// stm: #unit
+package stmgr_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/consensus/filcns"
+	"github.com/filecoin-project/lotus/chain/index"
+	"github.com/filecoin-project/lotus/chain/stmgr"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+	"github.com/filecoin-project/lotus/chain/vm"
+)
+
+func testStateBlock(height int) blocks.Block {
+	return blocks.NewBlock([]byte(fmt.Sprintf("state-%d", height)))
+}
+
+// fakeExecutor "executes" a tipset by returning the state block for the
+// following height, and storing it like a real execution would.
+type fakeExecutor struct {
+	bs blockstore.Blockstore
+
+	lk       sync.Mutex
+	executed map[types.TipSetKey]int
+}
+
+func (e *fakeExecutor) NewActorRegistry() *vm.ActorRegistry {
+	return nil
+}
+
+func (e *fakeExecutor) ExecuteTipSet(ctx context.Context, _ *stmgr.StateManager, ts *types.TipSet, _ stmgr.ExecMonitor, _ bool) (cid.Cid, cid.Cid, error) {
+	e.lk.Lock()
+	e.executed[ts.Key()]++
+	e.lk.Unlock()
+
+	has, err := e.bs.Has(ctx, ts.ParentState())
+	if err != nil {
+		return cid.Undef, cid.Undef, err
+	}
+	if !has {
+		return cid.Undef, cid.Undef, fmt.Errorf("missing parent state for height %d", ts.Height())
+	}
+
+	st := testStateBlock(int(ts.Height()) + 1)
+	if err := e.bs.Put(ctx, st); err != nil {
+		return cid.Undef, cid.Undef, err
+	}
+	return st.Cid(), ts.Blocks()[0].ParentMessageReceipts, nil
+}
+
+func TestExecuteTipSetRange(t *testing.T) {
+	ctx := context.Background()
+
+	bs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(bs, bs, datastore.NewMapDatastore(), filcns.Weight, nil)
+	exec := &fakeExecutor{bs: bs, executed: map[types.TipSetKey]int{}}
+	sm, err := stmgr.NewStateManager(cs, exec, nil, stmgr.UpgradeSchedule{}, nil, datastore.NewMapDatastore(), index.DummyMsgIndex)
+	require.NoError(t, err)
+	sm.SetReplayWorkers(4)
+
+	// build a chain of tipsets where each tipset declares the state
+	// returned by the fake executor for its parent, the genesis isn't
+	// executed
+	var tss []*types.TipSet
+	var parent *types.TipSet
+	for h := 0; h < 10; h++ {
+		blk := mock.MkBlock(parent, 1, uint64(h))
+		blk.ParentStateRoot = testStateBlock(h).Cid()
+		parent = mock.TipSet(blk)
+		tss = append(tss, parent)
+	}
+	require.NoError(t, cs.ForceHeadSilent(ctx, tss[0]))
+	tss = tss[1:]
+
+	// only some of the parent states are available locally, the tipsets
+	// depending on the missing ones are executed once their parent is
+	for _, h := range []int{0, 1, 2, 5, 6, 9} {
+		require.NoError(t, bs.Put(ctx, testStateBlock(h)))
+	}
+
+	st, _, err := sm.ExecuteTipSetRange(ctx, tss)
+	require.NoError(t, err)
+	require.Equal(t, testStateBlock(10).Cid(), st)
+
+	for _, ts := range tss {
+		require.Equal(t, 1, exec.executed[ts.Key()], "height %d", ts.Height())
+	}
+
+	// the states are cached like the ones computed with TipSetState
+	st, _, err = sm.ExecuteTipSetRange(ctx, tss[2:])
+	require.NoError(t, err)
+	require.Equal(t, testStateBlock(10).Cid(), st)
+	st, _, err = sm.TipSetState(ctx, tss[4])
+	require.NoError(t, err)
+	require.Equal(t, testStateBlock(6).Cid(), st)
+	for _, ts := range tss {
+		require.Equal(t, 1, exec.executed[ts.Key()], "height %d", ts.Height())
+	}
+
+	// a tipset declaring a state that doesn't match the execution of its
+	// parent fails validation
+	bad := mock.MkBlock(tss[3], 1, 100)
+	bad.ParentStateRoot = testStateBlock(100).Cid()
+	require.NoError(t, bs.Put(ctx, testStateBlock(100)))
+
+	_, _, err = sm.ExecuteTipSetRange(ctx, []*types.TipSet{tss[3], mock.TipSet(bad)})
+	require.ErrorContains(t, err, "state mismatch at height 5")
+
+	// tipsets must form a chain
+	_, _, err = sm.ExecuteTipSetRange(ctx, []*types.TipSet{tss[1], tss[3]})
+	require.ErrorContains(t, err, "is not the parent of")
+}
@@ -148,6 +148,13 @@ type StateManager struct {
 	// We need a lock while making the copy as to prevent other callers
 	// overwrite the cache while making the copy
 	execTraceCacheLock sync.Mutex
+
+	// Optional cache of read-only call results, see EnableCallCache.
+	callCache *callCache
+
+	// replayWorkers is the number of tipsets ExecuteTipSetRange executes
+	// concurrently.
+	replayWorkers int
 }
 
 // Caches a single state tree
@@ -223,6 +230,7 @@ func NewStateManager(cs *store.ChainStore, exec Executor, sys vm.SyscallBuilder,
 		compWait:       make(map[string]chan struct{}),
 		msgIndex:       msgIndex,
 		execTraceCache: execTraceCache,
+		replayWorkers:  1,
 	}, nil
 }
 
@@ -442,21 +450,14 @@ func (sm *StateManager) ValidateChain(ctx context.Context, ts *types.TipSet) err
 		ts = next
 	}
 
-	lastState := tschain[len(tschain)-1].ParentState()
-	for i := len(tschain) - 1; i >= 0; i-- {
-		cur := tschain[i]
-		log.Infof("computing state (height: %d, ts=%s)", cur.Height(), cur.Cids())
-		if cur.ParentState() != lastState {
-			return xerrors.Errorf("tipset chain had state mismatch at height %d", cur.Height())
-		}
-		st, _, err := sm.TipSetState(ctx, cur)
-		if err != nil {
-			return err
-		}
-		lastState = st
+	// execute from genesis up to the given tipset
+	for i, j := 0, len(tschain)-1; i < j; i, j = i+1, j-1 {
+		tschain[i], tschain[j] = tschain[j], tschain[i]
 	}
 
-	return nil
+	log.Infof("computing state (height: %d-%d)", tschain[0].Height(), tschain[len(tschain)-1].Height())
+	_, _, err := sm.ExecuteTipSetRange(ctx, tschain)
+	return err
 }
 
 func (sm *StateManager) SetVMConstructor(nvm func(context.Context, *vm.VMOpts) (vm.Interface, error)) {
@@ -0,0 +1,90 @@
+// stm: #unit
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	syncds "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/consensus/filcns"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestGetPathPage(t *testing.T) {
+	ctx := context.Background()
+
+	nbs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(nbs, nbs, syncds.MutexWrap(datastore.NewMapDatastore()), filcns.Weight, nil)
+	defer cs.Close() //nolint:errcheck
+
+	mk := func(parent *types.TipSet, nonce uint64, nulls int) *types.TipSet {
+		b := mock.MkBlock(parent, 1, nonce)
+		b.Height += abi.ChainEpoch(nulls)
+		ts := mock.TipSet(b)
+		require.NoError(t, cs.PutTipSet(ctx, ts))
+		return ts
+	}
+
+	base := mk(nil, 0, 0)
+	for i := 0; i < 5; i++ {
+		base = mk(base, 1, 0)
+	}
+
+	// two forks from base, with null rounds
+	a, b := base, base
+	for i := 0; i < 4; i++ {
+		a = mk(a, 2, i%2)
+	}
+	for i := 0; i < 6; i++ {
+		b = mk(b, 3, i%3)
+	}
+
+	collect := func(from, to types.TipSetKey, limit int) []*api.HeadChange {
+		var out []*api.HeadChange
+		cursor := ""
+		for {
+			page, next, err := cs.GetPathPage(ctx, from, to, cursor, limit)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(page), limit)
+			out = append(out, page...)
+			if next == "" {
+				return out
+			}
+			cursor = next
+		}
+	}
+
+	for _, c := range []struct{ from, to *types.TipSet }{
+		{a, b},
+		{b, a},
+		{base, b},
+		{a, base},
+		{a, a},
+	} {
+		expected, err := cs.GetPath(ctx, c.from.Key(), c.to.Key())
+		require.NoError(t, err)
+
+		for _, limit := range []int{1, 2, 3, 100} {
+			path := collect(c.from.Key(), c.to.Key(), limit)
+			require.Len(t, path, len(expected))
+			for i := range expected {
+				require.Equal(t, expected[i].Type, path[i].Type)
+				require.Equal(t, expected[i].Val.Key(), path[i].Val.Key())
+			}
+		}
+	}
+
+	_, _, err := cs.GetPathPage(ctx, a.Key(), b.Key(), "", 0)
+	require.Error(t, err)
+	_, _, err = cs.GetPathPage(ctx, a.Key(), b.Key(), "bogus", 10)
+	require.Error(t, err)
+}
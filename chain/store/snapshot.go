@@ -21,11 +21,13 @@ import (
 	"golang.org/x/sync/errgroup"
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/abi"
 
 	bstore "github.com/filecoin-project/lotus/blockstore"
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/state"
 	"github.com/filecoin-project/lotus/chain/types"
 )
 
@@ -60,6 +62,82 @@ func (cs *ChainStore) Export(ctx context.Context, ts *types.TipSet, inclRecentRo
 	})
 }
 
+// ExportActorStates exports the chain like Export, except that the recent state
+// trees only include the state of the given actors. The other actors only have
+// their entry in the state tree: their code, nonce, balance and the CID of
+// their state. The genesis state is exported entirely.
+func (cs *ChainStore) ExportActorStates(ctx context.Context, ts *types.TipSet, inclRecentRoots abi.ChainEpoch, skipOldMsgs bool, actors []address.Address, w io.Writer) error {
+	h := &car.CarHeader{
+		Roots:   ts.Cids(),
+		Version: 1,
+	}
+
+	if err := car.WriteHeader(h, w); err != nil {
+		return xerrors.Errorf("failed to write car header: %s", err)
+	}
+
+	unionBs := cs.UnionStore()
+	walkState := func(root cid.Cid, walked *cid.Set) ([]cid.Cid, error) {
+		return cs.walkActorStates(ctx, root, walked, actors)
+	}
+	return cs.walkSnapshot(ctx, ts, inclRecentRoots, skipOldMsgs, true, walkState, func(c cid.Cid) error {
+		blk, err := unionBs.Get(ctx, c)
+		if err != nil {
+			return xerrors.Errorf("writing object to car, bs.Get: %w", err)
+		}
+
+		if err := carutil.LdWrite(w, c.Bytes(), blk.RawData()); err != nil {
+			return xerrors.Errorf("failed to write block to car output: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// walkActorStates returns the objects of the state tree, without the states of
+// the actors other than the given ones.
+func (cs *ChainStore) walkActorStates(ctx context.Context, root cid.Cid, walked *cid.Set, actors []address.Address) ([]cid.Cid, error) {
+	st, err := state.LoadStateTree(cs.ActorStore(ctx), root)
+	if err != nil {
+		return nil, xerrors.Errorf("loading state tree %s: %w", root, err)
+	}
+
+	// the actors may not exist yet in the older state trees
+	selected := map[address.Address]struct{}{}
+	for _, a := range actors {
+		id, err := st.LookupID(a)
+		if err != nil {
+			if xerrors.Is(err, types.ErrActorNotFound) {
+				continue
+			}
+			return nil, xerrors.Errorf("looking up id of %s: %w", a, err)
+		}
+		selected[id] = struct{}{}
+	}
+
+	// several actors can have the same state, it's only left out when none of
+	// the selected actors has it
+	excluded := cid.NewSet()
+	included := cid.NewSet()
+	err = st.ForEach(func(a address.Address, act *types.Actor) error {
+		if _, ok := selected[a]; ok {
+			included.Add(act.Head)
+		} else {
+			excluded.Add(act.Head)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("listing actors: %w", err)
+	}
+	_ = included.ForEach(func(c cid.Cid) error {
+		excluded.Remove(c)
+		return nil
+	})
+
+	return recurseLinksExcept(ctx, cs.stateBlockstore, walked, excluded, root, []cid.Cid{root})
+}
+
 func (cs *ChainStore) Import(ctx context.Context, r io.Reader) (*types.TipSet, error) {
 	// TODO: writing only to the state blockstore is incorrect.
 	//  At this time, both the state and chain blockstores are backed by the
@@ -603,6 +681,12 @@ func (cs *ChainStore) ExportRange(
 }
 
 func (cs *ChainStore) WalkSnapshot(ctx context.Context, ts *types.TipSet, inclRecentRoots abi.ChainEpoch, skipOldMsgs, skipMsgReceipts bool, cb func(cid.Cid) error) error {
+	return cs.walkSnapshot(ctx, ts, inclRecentRoots, skipOldMsgs, skipMsgReceipts, nil, cb)
+}
+
+// walkSnapshot walks the chain like WalkSnapshot, walkState, when set, returns
+// the objects of the recent state trees to include instead of all of them.
+func (cs *ChainStore) walkSnapshot(ctx context.Context, ts *types.TipSet, inclRecentRoots abi.ChainEpoch, skipOldMsgs, skipMsgReceipts bool, walkState func(root cid.Cid, walked *cid.Set) ([]cid.Cid, error), cb func(cid.Cid) error) error {
 	if ts == nil {
 		ts = cs.GetHeaviestTipSet()
 	}
@@ -663,7 +747,13 @@ func (cs *ChainStore) WalkSnapshot(ctx context.Context, ts *types.TipSet, inclRe
 
 		if b.Height == 0 || b.Height > ts.Height()-inclRecentRoots {
 			if walked.Visit(b.ParentStateRoot) {
-				cids, err := recurseLinks(ctx, cs.stateBlockstore, walked, b.ParentStateRoot, []cid.Cid{b.ParentStateRoot})
+				var cids []cid.Cid
+				var err error
+				if walkState != nil && b.Height > 0 {
+					cids, err = walkState(b.ParentStateRoot, walked)
+				} else {
+					cids, err = recurseLinks(ctx, cs.stateBlockstore, walked, b.ParentStateRoot, []cid.Cid{b.ParentStateRoot})
+				}
 				if err != nil {
 					return xerrors.Errorf("recursing genesis state failed: %w", err)
 				}
@@ -720,6 +810,12 @@ func (cs *ChainStore) WalkSnapshot(ctx context.Context, ts *types.TipSet, inclRe
 }
 
 func recurseLinks(ctx context.Context, bs bstore.Blockstore, walked *cid.Set, root cid.Cid, in []cid.Cid) ([]cid.Cid, error) {
+	return recurseLinksExcept(ctx, bs, walked, nil, root, in)
+}
+
+// recurseLinksExcept is recurseLinks, without following the links in the
+// excluded set, when set.
+func recurseLinksExcept(ctx context.Context, bs bstore.Blockstore, walked, excluded *cid.Set, root cid.Cid, in []cid.Cid) ([]cid.Cid, error) {
 	if root.Prefix().Codec != cid.DagCBOR {
 		return in, nil
 	}
@@ -736,6 +832,10 @@ func recurseLinks(ctx context.Context, bs bstore.Blockstore, walked *cid.Set, ro
 			return
 		}
 
+		if excluded != nil && excluded.Has(c) {
+			return
+		}
+
 		// traversed this already...
 		if !walked.Visit(c) {
 			return
@@ -743,7 +843,7 @@ func recurseLinks(ctx context.Context, bs bstore.Blockstore, walked *cid.Set, ro
 
 		in = append(in, c)
 		var err error
-		in, err = recurseLinks(ctx, bs, walked, c, in)
+		in, err = recurseLinksExcept(ctx, bs, walked, excluded, c, in)
 		if err != nil {
 			rerr = err
 		}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"strconv"
 	"strings"
@@ -1096,6 +1097,124 @@ func (cs *ChainStore) GetPath(ctx context.Context, from types.TipSetKey, to type
 	return path, nil
 }
 
+// GetPathPage returns up to limit entries of the path returned by GetPath,
+// loading only the tipsets of the page, and a cursor to get the next page
+// with, empty on the last page. The first page is requested with an empty
+// cursor.
+func (cs *ChainStore) GetPathPage(ctx context.Context, from types.TipSetKey, to types.TipSetKey, cursor string, limit int) ([]*api.HeadChange, string, error) {
+	if limit <= 0 {
+		return nil, "", xerrors.Errorf("invalid page limit %d", limit)
+	}
+
+	fts, err := cs.LoadTipSet(ctx, from)
+	if err != nil {
+		return nil, "", xerrors.Errorf("loading from tipset %s: %w", from, err)
+	}
+	tts, err := cs.LoadTipSet(ctx, to)
+	if err != nil {
+		return nil, "", xerrors.Errorf("loading to tipset %s: %w", to, err)
+	}
+
+	var c pathCursor
+	if cursor == "" {
+		anc, err := cs.commonAncestor(ctx, fts, tts)
+		if err != nil {
+			return nil, "", xerrors.Errorf("finding common ancestor: %w", err)
+		}
+		c = pathCursor{ancestor: anc.Height(), height: fts.Height()}
+		if c.height <= c.ancestor {
+			c.apply, c.height = true, c.ancestor+1
+		}
+	} else if c, err = parsePathCursor(cursor); err != nil {
+		return nil, "", err
+	}
+
+	var path []*api.HeadChange
+	for len(path) < limit && !(c.apply && c.height > tts.Height()) {
+		if !c.apply {
+			// the highest tipset of the old chain at or below the cursor
+			ts, err := cs.GetTipsetByHeight(ctx, c.height, fts, true)
+			if err != nil {
+				return nil, "", xerrors.Errorf("getting tipset to revert at %d: %w", c.height, err)
+			}
+			path = append(path, &api.HeadChange{Type: HCRevert, Val: ts})
+
+			pts, err := cs.LoadTipSet(ctx, ts.Parents())
+			if err != nil {
+				return nil, "", xerrors.Errorf("loading parent of %s: %w", ts.Key(), err)
+			}
+			c.height = pts.Height()
+			if c.height <= c.ancestor {
+				c.apply, c.height = true, c.ancestor+1
+			}
+			continue
+		}
+
+		// the lowest tipset of the new chain at or above the cursor
+		ts, err := cs.GetTipsetByHeight(ctx, c.height, tts, false)
+		if err != nil {
+			return nil, "", xerrors.Errorf("getting tipset to apply at %d: %w", c.height, err)
+		}
+		path = append(path, &api.HeadChange{Type: HCApply, Val: ts})
+		c.height = ts.Height() + 1
+	}
+
+	if c.apply && c.height > tts.Height() {
+		return path, "", nil
+	}
+	return path, c.String(), nil
+}
+
+// commonAncestor walks back from a and b to their common ancestor, like
+// ReorgOps, without keeping the tipsets on the way.
+func (cs *ChainStore) commonAncestor(ctx context.Context, a, b *types.TipSet) (*types.TipSet, error) {
+	var err error
+	for !a.Equals(b) {
+		if a.Height() > b.Height() {
+			a, err = cs.LoadTipSet(ctx, a.Parents())
+		} else {
+			b, err = cs.LoadTipSet(ctx, b.Parents())
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// pathCursor is the position in a path between two tipsets: the height of the
+// next tipset to revert, or to apply once all the tipsets above the common
+// ancestor are reverted.
+type pathCursor struct {
+	ancestor abi.ChainEpoch
+	apply    bool
+	height   abi.ChainEpoch
+}
+
+func (c pathCursor) String() string {
+	phase := "r"
+	if c.apply {
+		phase = "a"
+	}
+	return fmt.Sprintf("%d:%s:%d", c.ancestor, phase, c.height)
+}
+
+func parsePathCursor(s string) (pathCursor, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 || (parts[1] != "r" && parts[1] != "a") {
+		return pathCursor{}, xerrors.Errorf("invalid path cursor %q", s)
+	}
+	anc, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return pathCursor{}, xerrors.Errorf("invalid path cursor %q: %w", s, err)
+	}
+	h, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return pathCursor{}, xerrors.Errorf("invalid path cursor %q: %w", s, err)
+	}
+	return pathCursor{ancestor: abi.ChainEpoch(anc), apply: parts[1] == "a", height: abi.ChainEpoch(h)}, nil
+}
+
 // ChainBlockstore returns the chain blockstore. Currently the chain and state
 // // stores are both backed by the same physical store, albeit with different
 // // caching policies, but in the future they will segregate.
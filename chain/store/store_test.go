@@ -10,15 +10,19 @@ import (
 	"github.com/ipfs/go-datastore"
 	"github.com/stretchr/testify/require"
 
+	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-state-types/crypto"
 
 	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
 	"github.com/filecoin-project/lotus/chain/actors/policy"
 	"github.com/filecoin-project/lotus/chain/consensus"
 	"github.com/filecoin-project/lotus/chain/consensus/filcns"
 	"github.com/filecoin-project/lotus/chain/gen"
 	"github.com/filecoin-project/lotus/chain/index"
+	"github.com/filecoin-project/lotus/chain/state"
 	"github.com/filecoin-project/lotus/chain/stmgr"
 	"github.com/filecoin-project/lotus/chain/store"
 	"github.com/filecoin-project/lotus/chain/types"
@@ -172,6 +176,50 @@ func TestChainImportTipsetKeyCid(t *testing.T) {
 	}
 }
 
+func TestChainExportActorStates(t *testing.T) {
+	ctx := context.Background()
+	cg, err := gen.NewGenerator()
+	require.NoError(t, err)
+
+	var last *types.TipSet
+	for i := 0; i < 10; i++ {
+		ts, err := cg.NextTipSet()
+		require.NoError(t, err)
+		last = ts.TipSet.TipSet()
+	}
+
+	maddr := cg.Miners[0]
+	buf := new(bytes.Buffer)
+	require.NoError(t, cg.ChainStore().ExportActorStates(ctx, last, 1, false, []address.Address{maddr}, buf))
+
+	nbs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(nbs, nbs, datastore.NewMapDatastore(), filcns.Weight, nil)
+	defer cs.Close() //nolint:errcheck
+
+	root, err := cs.Import(ctx, buf)
+	require.NoError(t, err)
+	require.True(t, root.Equals(last))
+
+	st, err := state.LoadStateTree(cs.ActorStore(ctx), last.ParentState())
+	require.NoError(t, err)
+
+	// the state of the miner is included
+	mact, err := st.GetActor(maddr)
+	require.NoError(t, err)
+	has, err := nbs.Has(ctx, mact.Head)
+	require.NoError(t, err)
+	require.True(t, has)
+	_, err = miner.Load(cs.ActorStore(ctx), mact)
+	require.NoError(t, err)
+
+	// only the entry of the reward actor, which changes every epoch, is
+	ract, err := st.GetActor(builtin.RewardActorAddr)
+	require.NoError(t, err)
+	has, err = nbs.Has(ctx, ract.Head)
+	require.NoError(t, err)
+	require.False(t, has)
+}
+
 func TestChainExportImportFull(t *testing.T) {
 	//stm: @CHAIN_GEN_NEXT_TIPSET_001
 	//stm: @CHAIN_STORE_IMPORT_001, @CHAIN_STORE_EXPORT_001, @CHAIN_STORE_SET_HEAD_001
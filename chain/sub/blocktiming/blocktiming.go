@@ -0,0 +1,202 @@
+// Package blocktiming keeps the history of the arrival times of the blocks
+// relayed by each pubsub peer, and lowers the pubsub score of the peers which
+// consistently relay blocks late or relay equivocating blocks.
+package blocktiming
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"go.opencensus.io/stats"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/metrics"
+)
+
+const (
+	// HistoryLen is the number of recent blocks kept for each peer
+	HistoryLen = 32
+
+	// Penalty is the application specific pubsub score of the deprioritized
+	// peers. Below zero, gossipsub prunes the peer from the mesh of the node,
+	// above the gossip threshold the peer still exchanges gossip with it.
+	Penalty = -100
+
+	maxPeers = 4096
+)
+
+// Policy selects the peers whose blocks aren't relayed through the mesh of
+// the node anymore.
+type Policy struct {
+	Enable bool
+	// LateThreshold is the delay after the start of an epoch past which a
+	// block counts as late
+	LateThreshold time.Duration
+	// LateRatio is the share of the recent blocks of a peer which must be late
+	// for the peer to be deprioritized
+	LateRatio float64
+	// MinBlocks is the number of recent blocks needed to judge a peer
+	MinBlocks int
+}
+
+type history struct {
+	arrivals      []api.BlockArrival
+	late          int
+	equivocations int
+}
+
+// Tracker records the blocks relayed by the pubsub peers.
+type Tracker struct {
+	policy Policy
+
+	lk    sync.Mutex
+	peers *lru.Cache[peer.ID, *history]
+}
+
+func New(policy Policy) *Tracker {
+	if policy.LateThreshold == 0 {
+		policy.LateThreshold = time.Duration(build.PropagationDelaySecs) * time.Second
+	}
+
+	peers, _ := lru.New[peer.ID, *history](maxPeers)
+	return &Tracker{
+		policy: policy,
+		peers:  peers,
+	}
+}
+
+// Record adds a block relayed by a peer to its history.
+func (t *Tracker) Record(p peer.ID, blk *types.BlockHeader, at time.Time) {
+	offset := at.Sub(time.Unix(int64(blk.Timestamp), 0))
+	stats.Record(context.TODO(), metrics.BlockArrivalOffset.M(float64(offset.Milliseconds())))
+
+	a := api.BlockArrival{
+		Block:  blk.Cid(),
+		Height: blk.Height,
+		Miner:  blk.Miner,
+		Time:   at,
+		Offset: offset,
+		Late:   offset > t.policy.LateThreshold,
+	}
+
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	h, ok := t.peers.Get(p)
+	if !ok {
+		h = &history{}
+		t.peers.Add(p, h)
+	}
+
+	if len(h.arrivals) == HistoryLen {
+		old := h.arrivals[0]
+		if old.Late {
+			h.late--
+		}
+		if old.Equivocation {
+			h.equivocations--
+		}
+		h.arrivals = h.arrivals[1:]
+	}
+	h.arrivals = append(h.arrivals, a)
+	if a.Late {
+		h.late++
+	}
+
+	t.recordDeprioritized()
+}
+
+// Equivocation flags a block relayed by a peer as an equivocation of its
+// miner.
+func (t *Tracker) Equivocation(p peer.ID, blk cid.Cid) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	h, ok := t.peers.Get(p)
+	if !ok {
+		return
+	}
+	for i := range h.arrivals {
+		if h.arrivals[i].Block == blk && !h.arrivals[i].Equivocation {
+			h.arrivals[i].Equivocation = true
+			h.equivocations++
+		}
+	}
+
+	t.recordDeprioritized()
+}
+
+func (t *Tracker) deprioritized(h *history) bool {
+	if !t.policy.Enable {
+		return false
+	}
+	if h.equivocations > 0 {
+		return true
+	}
+	return len(h.arrivals) >= t.policy.MinBlocks && float64(h.late) >= t.policy.LateRatio*float64(len(h.arrivals))
+}
+
+// recordDeprioritized updates the deprioritized peers metric, the caller
+// holds the lock
+func (t *Tracker) recordDeprioritized() {
+	if !t.policy.Enable {
+		return
+	}
+
+	var n int64
+	for _, p := range t.peers.Keys() {
+		if h, ok := t.peers.Peek(p); ok && t.deprioritized(h) {
+			n++
+		}
+	}
+	stats.Record(context.TODO(), metrics.BlockRelayDeprioritizedPeers.M(n))
+}
+
+// Score is the application specific pubsub score of a peer.
+func (t *Tracker) Score(p peer.ID) float64 {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	h, ok := t.peers.Peek(p)
+	if ok && t.deprioritized(h) {
+		return Penalty
+	}
+	return 0
+}
+
+// Timing returns the history of the peers, the deprioritized peers first, then
+// the peers relaying the most late blocks.
+func (t *Tracker) Timing() []api.PeerBlockTiming {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	out := make([]api.PeerBlockTiming, 0, t.peers.Len())
+	for _, p := range t.peers.Keys() {
+		h, ok := t.peers.Peek(p)
+		if !ok {
+			continue
+		}
+		out = append(out, api.PeerBlockTiming{
+			Peer:          p,
+			Late:          h.late,
+			Equivocations: h.equivocations,
+			Deprioritized: t.deprioritized(h),
+			History:       append([]api.BlockArrival{}, h.arrivals...),
+		})
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Deprioritized != out[j].Deprioritized {
+			return out[i].Deprioritized
+		}
+		return out[i].Late > out[j].Late
+	})
+	return out
+}
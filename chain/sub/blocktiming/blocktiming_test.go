@@ -0,0 +1,89 @@
+// stm: #unit
+package blocktiming
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	tpeer "github.com/libp2p/go-libp2p/core/test"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var dummyCid, _ = cid.Parse("bafkqaaa")
+
+func TestTracker(t *testing.T) {
+	tr := New(Policy{
+		Enable:        true,
+		LateThreshold: 6 * time.Second,
+		LateRatio:     0.5,
+		MinBlocks:     4,
+	})
+
+	maddr, err := address.NewIDAddress(1000)
+	require.NoError(t, err)
+
+	block := func(h abi.ChainEpoch) *types.BlockHeader {
+		return &types.BlockHeader{
+			Miner:                 maddr,
+			Height:                h,
+			Timestamp:             uint64(h) * 30,
+			ParentStateRoot:       dummyCid,
+			ParentMessageReceipts: dummyCid,
+			Messages:              dummyCid,
+		}
+	}
+
+	timely, late := tpeer.RandPeerIDFatal(t), tpeer.RandPeerIDFatal(t)
+	for h := abi.ChainEpoch(1); h <= 4; h++ {
+		blk := block(h)
+		start := time.Unix(int64(blk.Timestamp), 0)
+
+		tr.Record(timely, blk, start.Add(time.Second))
+		tr.Record(late, blk, start.Add(8*time.Second))
+
+		if h < 4 {
+			// not enough blocks to judge the peer yet
+			require.Zero(t, tr.Score(late))
+		}
+	}
+
+	require.Zero(t, tr.Score(timely))
+	require.Equal(t, float64(Penalty), tr.Score(late))
+
+	timing := tr.Timing()
+	require.Len(t, timing, 2)
+	require.Equal(t, late, timing[0].Peer)
+	require.True(t, timing[0].Deprioritized)
+	require.Equal(t, 4, timing[0].Late)
+	require.Len(t, timing[0].History, 4)
+	require.Equal(t, 8*time.Second, timing[0].History[0].Offset)
+	require.False(t, timing[1].Deprioritized)
+
+	// a single equivocation deprioritizes the peer
+	blk := timing[1].History[3].Block
+	tr.Equivocation(timely, blk)
+	require.Equal(t, float64(Penalty), tr.Score(timely))
+
+	// the history of a peer is bounded
+	for i := 0; i < HistoryLen; i++ {
+		b := block(abi.ChainEpoch(100 + i))
+		tr.Record(timely, b, time.Unix(int64(b.Timestamp), 0))
+	}
+	require.Zero(t, tr.Score(timely))
+	for _, pt := range tr.Timing() {
+		if pt.Peer == timely {
+			require.Len(t, pt.History, HistoryLen)
+			require.Zero(t, pt.Equivocations)
+		}
+	}
+
+	disabled := New(Policy{})
+	disabled.Record(late, block(1), time.Now())
+	require.Zero(t, disabled.Score(late))
+}
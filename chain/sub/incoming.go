@@ -28,6 +28,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/messagepool"
 	"github.com/filecoin-project/lotus/chain/store"
 	"github.com/filecoin-project/lotus/chain/sub/bcast"
+	"github.com/filecoin-project/lotus/chain/sub/blocktiming"
 	"github.com/filecoin-project/lotus/chain/sub/ratelimit"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/metrics"
@@ -44,7 +45,7 @@ var msgCidPrefix = cid.Prefix{
 	MhLength: 32,
 }
 
-func HandleIncomingBlocks(ctx context.Context, bsub *pubsub.Subscription, self peer.ID, s *chain.Syncer, bs bserv.BlockService, cmgr connmgr.ConnManager) {
+func HandleIncomingBlocks(ctx context.Context, bsub *pubsub.Subscription, self peer.ID, s *chain.Syncer, bs bserv.BlockService, cmgr connmgr.ConnManager, bt *blocktiming.Tracker) {
 	// Timeout after (block time + propagation delay). This is useless at
 	// this point.
 	timeout := time.Duration(build.BlockDelaySecs+build.PropagationDelaySecs) * time.Second
@@ -114,6 +115,9 @@ func HandleIncomingBlocks(ctx context.Context, bsub *pubsub.Subscription, self p
 				log.Debugf("Waiting for consistent broadcast of block in height: %v", blk.Header.Height)
 				if err := cb.WaitForDelivery(blk.Header); err != nil {
 					log.Errorf("not informing syncer about new block, potential equivocation detected (cid: %s, source: %s): %s; ", blk.Header.Cid(), src, err)
+					if bt != nil {
+						bt.Equivocation(msg.ReceivedFrom, blk.Header.Cid())
+					}
 					return
 				}
 			}
@@ -242,6 +246,9 @@ type BlockValidator struct {
 
 	recvBlocks *blockReceiptCache
 
+	// arrival times of the blocks relayed by each peer
+	timing *blocktiming.Tracker
+
 	blacklist func(peer.ID)
 
 	// necessary for block validation
@@ -249,7 +256,7 @@ type BlockValidator struct {
 	consensus consensus.Consensus
 }
 
-func NewBlockValidator(self peer.ID, chain *store.ChainStore, cns consensus.Consensus, blacklist func(peer.ID)) *BlockValidator {
+func NewBlockValidator(self peer.ID, chain *store.ChainStore, cns consensus.Consensus, timing *blocktiming.Tracker, blacklist func(peer.ID)) *BlockValidator {
 	p, _ := lru.New2Q[peer.ID, int](4096)
 	return &BlockValidator{
 		self:       self,
@@ -257,6 +264,7 @@ func NewBlockValidator(self peer.ID, chain *store.ChainStore, cns consensus.Cons
 		killThresh: 10,
 		blacklist:  blacklist,
 		recvBlocks: newBlockReceiptCache(),
+		timing:     timing,
 		chain:      chain,
 		consensus:  cns,
 	}
@@ -293,7 +301,8 @@ func (bv *BlockValidator) Validate(ctx context.Context, pid peer.ID, msg *pubsub
 	res, what = consensus.ValidateBlockPubsub(ctx, bv.consensus, pid == bv.self, msg)
 	if res == pubsub.ValidationAccept {
 		// it's a good block! make sure we've only seen it once
-		if count := bv.recvBlocks.add(msg.ValidatorData.(*types.BlockMsg).Cid()); count > 0 {
+		blk := msg.ValidatorData.(*types.BlockMsg)
+		if count := bv.recvBlocks.add(blk.Cid()); count > 0 {
 			if pid == bv.self {
 				log.Warnf("local block has been seen %d times; ignoring", count)
 			}
@@ -302,6 +311,10 @@ func (bv *BlockValidator) Validate(ctx context.Context, pid peer.ID, msg *pubsub
 			// dropping peers who send us the same block multiple times
 			return pubsub.ValidationIgnore
 		}
+
+		if bv.timing != nil && pid != bv.self {
+			bv.timing.Record(pid, blk.Header, build.Clock.Now())
+		}
 	} else {
 		recordFailure(ctx, metrics.BlockValidationFailure, what)
 	}
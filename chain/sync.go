@@ -23,6 +23,7 @@ import (
 	"go.opencensus.io/trace"
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-state-types/crypto"
 	"github.com/filecoin-project/pubsub"
 
@@ -943,12 +944,23 @@ func (syncer *Syncer) syncMessagesAndCheckState(ctx context.Context, headers []*
 	ss := extractSyncState(ctx)
 	ss.SetHeight(headers[len(headers)-1].Height())
 
+	// verify the proofs of the blocks ahead, while their parents are validated
+	var validated abi.ChainEpoch = -1
+	if base, err := syncer.store.LoadTipSet(ctx, headers[len(headers)-1].Parents()); err == nil {
+		validated = base.Height()
+	}
+	proofs := startProofPipeline(ctx, syncer.consensus, headers, validated)
+	defer proofs.stop()
+
+	syncer.executeAhead(ctx, headers)
+
 	return syncer.iterFullTipsets(ctx, headers, func(ctx context.Context, fts *store.FullTipSet) error {
 		log.Debugw("validating tipset", "height", fts.TipSet().Height(), "size", len(fts.TipSet().Cids()))
 		if err := syncer.ValidateTipSet(ctx, fts, true); err != nil {
 			log.Errorf("failed to validate tipset: %+v", err)
 			return xerrors.Errorf("message processing failed: %w", err)
 		}
+		proofs.setValidated(fts.TipSet().Height())
 
 		stats.Record(ctx, metrics.ChainNodeWorkerHeight.M(int64(fts.TipSet().Height())))
 		ss.SetHeight(fts.TipSet().Height())
@@ -957,6 +969,34 @@ func (syncer *Syncer) syncMessagesAndCheckState(ctx context.Context, headers []*
 	})
 }
 
+// executeAhead executes the oldest of the headers in parallel, for as long as
+// their messages and parent states are available locally, e.g. when syncing a
+// range of the chain executed before. Validating their children then finds
+// their states in the state cache. Failures are left to the validation of the
+// tipsets to report.
+func (syncer *Syncer) executeAhead(ctx context.Context, headers []*types.TipSet) {
+	// the state of the newest tipset isn't needed to validate the headers
+	var run []*types.TipSet
+	for i := len(headers) - 1; i > 0; i-- {
+		ts := headers[i]
+		if has, err := syncer.store.StateBlockstore().Has(ctx, ts.ParentState()); err != nil || !has {
+			break
+		}
+		if fts, err := syncer.store.TryFillTipSet(ctx, ts); err != nil || fts == nil {
+			break
+		}
+		run = append(run, ts)
+	}
+	if len(run) < 2 {
+		return
+	}
+
+	log.Infow("executing tipsets ahead of validation", "from", run[0].Height(), "to", run[len(run)-1].Height())
+	if _, _, err := syncer.sm.ExecuteTipSetRange(ctx, run); err != nil {
+		log.Warnw("executing tipsets ahead of validation", "from", run[0].Height(), "to", run[len(run)-1].Height(), "error", err)
+	}
+}
+
 // fills out each of the given tipsets with messages and calls the callback with it
 func (syncer *Syncer) iterFullTipsets(ctx context.Context, headers []*types.TipSet, cb func(context.Context, *store.FullTipSet) error) error {
 	ss := extractSyncState(ctx)
@@ -0,0 +1,144 @@
+package chain
+
+import (
+	"context"
+	"runtime"
+	"sync"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var (
+	// proofWorkers is the number of goroutines verifying block proofs ahead of
+	// the validation of their tipsets.
+	proofWorkers = runtime.NumCPU()
+
+	// proofWindow is how many epochs ahead of the validated tipsets the block
+	// proofs are verified, bounding the memory used by the pipeline.
+	proofWindow abi.ChainEpoch = 200
+)
+
+// proofValidator verifies the proofs of block headers, see consensus.Consensus.
+type proofValidator interface {
+	ValidateBlockProofs(ctx context.Context, h *types.BlockHeader) error
+	ProofsLookback(ctx context.Context, round abi.ChainEpoch) abi.ChainEpoch
+}
+
+// proofPipeline verifies the proofs of the blocks being synced on parallel
+// workers, while their parents are being validated. The proofs of a tipset are
+// verified once the state at its proofs lookback has been validated, and at
+// most proofWindow epochs ahead of the validated tipsets.
+type proofPipeline struct {
+	pv proofValidator
+
+	lk        sync.Mutex
+	validated abi.ChainEpoch
+	notify    chan struct{}
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// startProofPipeline starts verifying the proofs of the headers, which are
+// ordered from the highest tipset down, as in syncMessagesAndCheckState.
+// validated is the height of the last validated tipset.
+func startProofPipeline(ctx context.Context, pv proofValidator, headers []*types.TipSet, validated abi.ChainEpoch) *proofPipeline {
+	ctx, cancel := context.WithCancel(ctx)
+	p := &proofPipeline{
+		pv:        pv,
+		validated: validated,
+		notify:    make(chan struct{}, 1),
+		cancel:    cancel,
+	}
+
+	work := make(chan *types.BlockHeader, proofWorkers)
+
+	p.wg.Add(proofWorkers + 1)
+	go func() {
+		defer p.wg.Done()
+		defer close(work)
+
+		for i := len(headers) - 1; i >= 0; i-- {
+			ts := headers[i]
+
+			// the lookback state is only known once the tipset after the
+			// lookback is validated
+			ready := ts.Height() - pv.ProofsLookback(ctx, ts.Height()) + 1
+			if window := ts.Height() - proofWindow; window > ready {
+				ready = window
+			}
+			if !p.waitValidated(ctx, ready) {
+				return
+			}
+			// the validation caught up with the pipeline
+			if p.isValidated(ts.Height()) {
+				continue
+			}
+
+			for _, h := range ts.Blocks() {
+				select {
+				case work <- h:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	for i := 0; i < proofWorkers; i++ {
+		go func() {
+			defer p.wg.Done()
+
+			for h := range work {
+				// failures are reported by the validation of the block
+				if err := pv.ValidateBlockProofs(ctx, h); err != nil {
+					log.Debugw("verifying block proofs ahead failed", "block", h.Cid(), "height", h.Height, "error", err)
+				}
+			}
+		}()
+	}
+
+	return p
+}
+
+// setValidated records that the tipsets up to height have been validated.
+func (p *proofPipeline) setValidated(height abi.ChainEpoch) {
+	p.lk.Lock()
+	if height > p.validated {
+		p.validated = height
+	}
+	p.lk.Unlock()
+
+	select {
+	case p.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (p *proofPipeline) isValidated(height abi.ChainEpoch) bool {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	return p.validated >= height
+}
+
+func (p *proofPipeline) waitValidated(ctx context.Context, height abi.ChainEpoch) bool {
+	for {
+		if p.isValidated(height) {
+			return true
+		}
+
+		select {
+		case <-p.notify:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// stop stops the pipeline and waits for its workers to exit.
+func (p *proofPipeline) stop() {
+	p.cancel()
+	p.wg.Wait()
+}
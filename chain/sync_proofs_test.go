@@ -0,0 +1,68 @@
+// stm: #unit
+package chain
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+type fakeProofValidator struct {
+	lk       sync.Mutex
+	verified map[abi.ChainEpoch]int
+}
+
+func (f *fakeProofValidator) ValidateBlockProofs(_ context.Context, h *types.BlockHeader) error {
+	f.lk.Lock()
+	defer f.lk.Unlock()
+	f.verified[h.Height]++
+	return nil
+}
+
+func (f *fakeProofValidator) ProofsLookback(context.Context, abi.ChainEpoch) abi.ChainEpoch {
+	return 10
+}
+
+func (f *fakeProofValidator) count() int {
+	f.lk.Lock()
+	defer f.lk.Unlock()
+	return len(f.verified)
+}
+
+func TestProofPipeline(t *testing.T) {
+	// headers are ordered from the highest tipset down
+	var headers []*types.TipSet
+	ts := mock.TipSet(mock.MkBlock(nil, 1, 0))
+	for i := 1; i <= 50; i++ {
+		ts = mock.TipSet(mock.MkBlock(ts, 1, uint64(i)))
+		headers = append([]*types.TipSet{ts}, headers...)
+	}
+
+	pv := &fakeProofValidator{verified: map[abi.ChainEpoch]int{}}
+	p := startProofPipeline(context.Background(), pv, headers, 0)
+	defer p.stop()
+
+	// the lookback state of the first tipsets is validated
+	require.Eventually(t, func() bool { return pv.count() == 9 }, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, 9, pv.count())
+
+	p.setValidated(5)
+	require.Eventually(t, func() bool { return pv.count() == 14 }, 5*time.Second, 10*time.Millisecond)
+
+	// tipsets already validated are skipped
+	p.setValidated(50)
+	p.stop()
+	require.Equal(t, 14, pv.count())
+	for h, n := range pv.verified {
+		require.Equal(t, 1, n, "height %d", h)
+	}
+}
@@ -518,6 +518,62 @@ type EthFeeHistory struct {
 	Reward        *[][]EthBigInt `json:"reward,omitempty"`
 }
 
+// EthSyncingResult is the response of eth_syncing, false once the node is in
+// sync, otherwise the progress of the sync.
+type EthSyncingResult struct {
+	DoneSync      bool
+	StartingBlock EthUint64
+	CurrentBlock  EthUint64
+	HighestBlock  EthUint64
+
+	// Stages is the progress of each stage of the sync, as reported by erigon.
+	// Not set for the other compatibility profiles.
+	Stages []EthSyncStage
+}
+
+type EthSyncStage struct {
+	StageName   string    `json:"stage_name"`
+	BlockNumber EthUint64 `json:"block_number"`
+}
+
+func (sr EthSyncingResult) MarshalJSON() ([]byte, error) {
+	if sr.DoneSync {
+		return []byte("false"), nil
+	}
+
+	return json.Marshal(struct {
+		StartingBlock EthUint64      `json:"startingBlock"`
+		CurrentBlock  EthUint64      `json:"currentBlock"`
+		HighestBlock  EthUint64      `json:"highestBlock"`
+		Stages        []EthSyncStage `json:"stages,omitempty"`
+	}{sr.StartingBlock, sr.CurrentBlock, sr.HighestBlock, sr.Stages})
+}
+
+func (sr *EthSyncingResult) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("false")) {
+		*sr = EthSyncingResult{DoneSync: true}
+		return nil
+	}
+
+	var res struct {
+		StartingBlock EthUint64      `json:"startingBlock"`
+		CurrentBlock  EthUint64      `json:"currentBlock"`
+		HighestBlock  EthUint64      `json:"highestBlock"`
+		Stages        []EthSyncStage `json:"stages"`
+	}
+	if err := json.Unmarshal(b, &res); err != nil {
+		return err
+	}
+
+	*sr = EthSyncingResult{
+		StartingBlock: res.StartingBlock,
+		CurrentBlock:  res.CurrentBlock,
+		HighestBlock:  res.HighestBlock,
+		Stages:        res.Stages,
+	}
+	return nil
+}
+
 type EthFilterID EthHash
 
 func (h EthFilterID) MarshalJSON() ([]byte, error) {
@@ -445,3 +445,32 @@ func TestEthHashListUnmarshalJSON(t *testing.T) {
 		require.Equal(t, tc.want, got)
 	}
 }
+
+func TestEthSyncingResultMarshalJSON(t *testing.T) {
+	testcases := []TestCase{
+		{EthSyncingResult{DoneSync: true, CurrentBlock: 10}, `false`},
+		{
+			EthSyncingResult{StartingBlock: 1, CurrentBlock: 2, HighestBlock: 3},
+			`{"startingBlock":"0x1","currentBlock":"0x2","highestBlock":"0x3"}`,
+		},
+		{
+			EthSyncingResult{StartingBlock: 1, CurrentBlock: 2, HighestBlock: 3, Stages: []EthSyncStage{{StageName: "Headers", BlockNumber: 3}}},
+			`{"startingBlock":"0x1","currentBlock":"0x2","highestBlock":"0x3","stages":[{"stage_name":"Headers","block_number":"0x3"}]}`,
+		},
+	}
+
+	for _, tc := range testcases {
+		j, err := json.Marshal(tc.Input)
+		require.NoError(t, err)
+		require.Equal(t, tc.Output, string(j))
+
+		var res EthSyncingResult
+		require.NoError(t, json.Unmarshal(j, &res))
+		in := tc.Input.(EthSyncingResult)
+		if in.DoneSync {
+			require.True(t, res.DoneSync)
+			continue
+		}
+		require.Equal(t, in, res)
+	}
+}
@@ -85,6 +85,17 @@ func (ar *ActorRegistry) Invoke(codeCid cid.Cid, rt vmr.Runtime, method abi.Meth
 
 }
 
+// StateType returns an empty instance of the state type of the actor with the
+// given code, if the actor is known to the registry.
+func (ar *ActorRegistry) StateType(code cid.Cid) (cbg.CBORUnmarshaler, bool) {
+	act, ok := ar.actors[code]
+	if !ok || act.vmActor.State() == nil {
+		return nil, false
+	}
+
+	return reflect.New(reflect.TypeOf(act.vmActor.State()).Elem()).Interface().(cbg.CBORUnmarshaler), true
+}
+
 func (ar *ActorRegistry) Register(av actorstypes.Version, pred ActorPredicate, vmactors []builtin.RegistryEntry) {
 	if pred == nil {
 		pred = func(vmr.Runtime, cid.Cid) error { return nil }
@@ -0,0 +1,256 @@
+// Package hd derives wallet keys from the seed of a BIP-39 mnemonic, the
+// secp256k1 keys along BIP-32 paths and the BLS keys along EIP-2333 paths, so
+// the keys of other wallets and HSMs can be used by lotus.
+package hd
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	_ "embed"
+	"encoding/binary"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/text/unicode/norm"
+	"golang.org/x/xerrors"
+
+	gocrypto "github.com/filecoin-project/go-crypto"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+const (
+	// CoinType is the SLIP-44 coin type of Filecoin
+	CoinType = 461
+
+	DefaultSecp256k1Path = "m/44'/461'/0'/0/0"
+	// DefaultDelegatedPath is the path of the first Ethereum account, so the
+	// mnemonics of Ethereum wallets derive the same f4 address
+	DefaultDelegatedPath = "m/44'/60'/0'/0/0"
+	// DefaultBLSPath follows the EIP-2334 layout with the Filecoin coin type
+	DefaultBLSPath = "m/12381/461/0/0"
+
+	hardened = uint32(1) << 31
+)
+
+// englishWords is the BIP-39 English wordlist
+//
+//go:embed english.txt
+var englishWords string
+
+var wordIndex = func() map[string]int {
+	idx := make(map[string]int, 2048)
+	for i, w := range strings.Fields(englishWords) {
+		idx[w] = i
+	}
+	return idx
+}()
+
+var (
+	secp256k1N, _ = new(big.Int).SetString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141", 16)
+	bls12381R, _  = new(big.Int).SetString("73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001", 16)
+)
+
+// DefaultPath returns the derivation path used for the keys of the given type
+// when none is given.
+func DefaultPath(typ types.KeyType) (string, error) {
+	switch typ {
+	case types.KTSecp256k1:
+		return DefaultSecp256k1Path, nil
+	case types.KTDelegated:
+		return DefaultDelegatedPath, nil
+	case types.KTBLS:
+		return DefaultBLSPath, nil
+	default:
+		return "", xerrors.Errorf("unsupported key type: %s", typ)
+	}
+}
+
+// ValidateMnemonic checks that the mnemonic is made of 12 to 24 words of the
+// BIP-39 English wordlist, and that its checksum matches.
+func ValidateMnemonic(mnemonic string) error {
+	words := strings.Fields(norm.NFKD.String(mnemonic))
+	if n := len(words); n < 12 || n > 24 || n%3 != 0 {
+		return xerrors.Errorf("expected 12, 15, 18, 21 or 24 words, got %d", n)
+	}
+
+	bits := new(big.Int)
+	for i, w := range words {
+		// the words are secret, only their position is reported
+		idx, ok := wordIndex[w]
+		if !ok {
+			return xerrors.Errorf("word %d isn't in the BIP-39 English wordlist", i+1)
+		}
+		bits.Lsh(bits, 11).Or(bits, big.NewInt(int64(idx)))
+	}
+
+	// the mnemonic encodes the entropy followed by the first entropy/32 bits
+	// of its sha256
+	csBits := len(words) * 11 / 33
+	cs := new(big.Int).And(bits, big.NewInt(1<<csBits-1)).Uint64()
+	entropy := new(big.Int).Rsh(bits, uint(csBits)).FillBytes(make([]byte, csBits*4))
+	if h := sha256.Sum256(entropy); uint64(h[0]>>(8-csBits)) != cs {
+		return xerrors.Errorf("invalid mnemonic checksum")
+	}
+	return nil
+}
+
+// SeedFromMnemonic returns the BIP-39 seed of a mnemonic and its optional
+// passphrase. The words of the mnemonic aren't checked against the wordlist,
+// see ValidateMnemonic.
+func SeedFromMnemonic(mnemonic, passphrase string) []byte {
+	words := strings.Join(strings.Fields(norm.NFKD.String(mnemonic)), " ")
+	salt := "mnemonic" + norm.NFKD.String(passphrase)
+	return pbkdf2.Key([]byte(words), []byte(salt), 2048, 64, sha512.New)
+}
+
+// ParsePath parses a derivation path like m/44'/461'/0'/0/0, the hardened
+// indexes are marked with ' or h, or are above 2^31.
+func ParsePath(path string) ([]uint32, error) {
+	parts := strings.Split(strings.TrimSpace(path), "/")
+	if parts[0] != "m" {
+		return nil, xerrors.Errorf("derivation path %q doesn't start with m", path)
+	}
+
+	out := make([]uint32, 0, len(parts)-1)
+	for _, p := range parts[1:] {
+		bits, h := 32, uint32(0)
+		if strings.HasSuffix(p, "'") || strings.HasSuffix(p, "h") {
+			bits, h = 31, hardened
+			p = p[:len(p)-1]
+		}
+		i, err := strconv.ParseUint(p, 10, bits)
+		if err != nil {
+			return nil, xerrors.Errorf("parsing index %q of derivation path %q: %w", p, path, err)
+		}
+		out = append(out, uint32(i)|h)
+	}
+	return out, nil
+}
+
+// Secp256k1 derives the secp256k1 private key of a seed along a BIP-32 path.
+func Secp256k1(seed []byte, path string) ([]byte, error) {
+	idx, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	k, c, err := bip32Key([]byte("Bitcoin seed"), seed, nil)
+	if err != nil {
+		return nil, xerrors.Errorf("deriving master key: %w", err)
+	}
+	for _, i := range idx {
+		data := make([]byte, 0, 37)
+		if i >= hardened {
+			data = append(data, 0)
+			data = append(data, k...)
+		} else {
+			data = append(data, compressPub(gocrypto.PublicKey(k))...)
+		}
+		data = binary.BigEndian.AppendUint32(data, i)
+
+		k, c, err = bip32Key(c, data, k)
+		if err != nil {
+			return nil, xerrors.Errorf("deriving child key %d: %w", i&^hardened, err)
+		}
+	}
+	return k, nil
+}
+
+// bip32Key computes the key and chain code of the HMAC of data, added to the
+// parent key if any
+func bip32Key(key, data, parent []byte) ([]byte, []byte, error) {
+	mac := hmac.New(sha512.New, key)
+	mac.Write(data) //nolint:errcheck
+	sum := mac.Sum(nil)
+
+	il := new(big.Int).SetBytes(sum[:32])
+	if il.Cmp(secp256k1N) >= 0 {
+		return nil, nil, xerrors.Errorf("invalid key, use the next index")
+	}
+	if parent != nil {
+		il.Add(il, new(big.Int).SetBytes(parent))
+		il.Mod(il, secp256k1N)
+	}
+	if il.Sign() == 0 {
+		return nil, nil, xerrors.Errorf("invalid key, use the next index")
+	}
+	return il.FillBytes(make([]byte, 32)), sum[32:], nil
+}
+
+// compressPub compresses an uncompressed secp256k1 public key
+func compressPub(pub []byte) []byte {
+	out := make([]byte, 33)
+	out[0] = 2 + pub[64]&1
+	copy(out[1:], pub[1:33])
+	return out
+}
+
+// BLS derives the BLS private key of a seed along an EIP-2333 path, the
+// indexes of which aren't marked hardened since they all are. The key is
+// returned in the big-endian byte order of EIP-2333, the reverse of the byte
+// order of lotus.
+func BLS(seed []byte, path string) ([]byte, error) {
+	if len(seed) < 32 {
+		return nil, xerrors.Errorf("seed must be at least 32 bytes, got %d", len(seed))
+	}
+	if strings.ContainsAny(path, "'h") {
+		return nil, xerrors.Errorf("EIP-2333 path %q can't mark hardened indexes", path)
+	}
+	idx, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	sk := hkdfModR(seed)
+	for _, i := range idx {
+		sk = hkdfModR(lamportPK(sk, i))
+	}
+	return sk.FillBytes(make([]byte, 32)), nil
+}
+
+func hkdfModR(ikm []byte) *big.Int {
+	const l = 48
+
+	salt := []byte("BLS-SIG-KEYGEN-SALT-")
+	sk := new(big.Int)
+	for sk.Sign() == 0 {
+		s := sha256.Sum256(salt)
+		salt = s[:]
+
+		prk := hkdf.Extract(sha256.New, append(append([]byte{}, ikm...), 0), salt)
+		okm := make([]byte, l)
+		if _, err := io.ReadFull(hkdf.Expand(sha256.New, prk, []byte{0, l}), okm); err != nil {
+			panic(err) // can't fail below 255 blocks
+		}
+		sk.SetBytes(okm).Mod(sk, bls12381R)
+	}
+	return sk
+}
+
+func lamportPK(parent *big.Int, index uint32) []byte {
+	salt := binary.BigEndian.AppendUint32(nil, index)
+	ikm := parent.FillBytes(make([]byte, 32))
+	notIkm := make([]byte, 32)
+	for i := range ikm {
+		notIkm[i] = ^ikm[i]
+	}
+
+	pk := sha256.New()
+	for _, k := range [][]byte{ikm, notIkm} {
+		okm := make([]byte, 255*32)
+		if _, err := io.ReadFull(hkdf.Expand(sha256.New, hkdf.Extract(sha256.New, k, salt), nil), okm); err != nil {
+			panic(err) // can't fail below 255 blocks
+		}
+		for i := 0; i < len(okm); i += 32 {
+			h := sha256.Sum256(okm[i : i+32])
+			pk.Write(h[:]) //nolint:errcheck
+		}
+	}
+	return pk.Sum(nil)
+}
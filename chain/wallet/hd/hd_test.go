@@ -0,0 +1,118 @@
+// stm: #unit
+package hd
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeedFromMnemonic(t *testing.T) {
+	// BIP-39 reference vector
+	seed := SeedFromMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "TREZOR")
+	require.Equal(t, "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04", hex.EncodeToString(seed))
+}
+
+func TestValidateMnemonic(t *testing.T) {
+	// BIP-39 reference vectors
+	for _, m := range []string{
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+		"legal winner thank year wave sausage worth useful legal winner thank yellow",
+		"letter advice cage absurd amount doctor acoustic avoid letter advice cage above",
+		"zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon art",
+		"zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo vote",
+	} {
+		require.NoError(t, ValidateMnemonic(m), m)
+	}
+
+	// bad checksum
+	require.Error(t, ValidateMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon"))
+	// not in the wordlist
+	require.Error(t, ValidateMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandonn"))
+	// wrong length
+	require.Error(t, ValidateMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"))
+	require.Error(t, ValidateMnemonic(""))
+}
+
+func TestParsePath(t *testing.T) {
+	idx, err := ParsePath("m/44'/461h/0'/0/1")
+	require.NoError(t, err)
+	require.Equal(t, []uint32{44 | hardened, 461 | hardened, hardened, 0, 1}, idx)
+
+	_, err = ParsePath("44'/461'")
+	require.Error(t, err)
+	_, err = ParsePath("m/x")
+	require.Error(t, err)
+}
+
+func TestSecp256k1(t *testing.T) {
+	// BIP-32 test vector 1
+	seed, err := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	require.NoError(t, err)
+
+	for path, key := range map[string]string{
+		"m":                      "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35",
+		"m/0'":                   "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea",
+		"m/0'/1/2'/2/1000000000": "471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8",
+	} {
+		k, err := Secp256k1(seed, path)
+		require.NoError(t, err)
+		require.Equal(t, key, hex.EncodeToString(k), path)
+	}
+}
+
+func TestBLS(t *testing.T) {
+	// EIP-2333 test cases 0 to 3, and the default path derived from the seed
+	// of test case 0 with an independent implementation of EIP-2333
+	for _, tc := range []struct {
+		seed, master, path, child string
+	}{
+		{
+			seed:   "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+			master: "6083874454709270928345386274498605044986640685124978867557563392430687146096",
+			path:   "m/0",
+			child:  "20397789859736650942317412262472558107875392172444076792671091975210932703118",
+		},
+		{
+			seed:   "3141592653589793238462643383279502884197169399375105820974944592",
+			master: "29757020647961307431480504535336562678282505419141012933316116377660817309383",
+			path:   "m/3141592653",
+			child:  "25457201688850691947727629385191704516744796114925897962676248250929345014287",
+		},
+		{
+			seed:   "0099ff991111002299dd7744ee3355bbdd8844115566cc55663355668888cc00",
+			master: "27580842291869792442942448775674722299803720648445448686099262467207037398656",
+			path:   "m/4294967295",
+			child:  "29358610794459428860402234341874281240803786294062035874021252734817515685787",
+		},
+		{
+			seed:   "d4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3",
+			master: "19022158461524446591288038168518313374041767046816487870552872741050760015818",
+			path:   "m/42",
+			child:  "31372231650479070279774297061823572166496564838472787488249775572789064611981",
+		},
+		{
+			seed:   "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+			master: "6083874454709270928345386274498605044986640685124978867557563392430687146096",
+			path:   DefaultBLSPath,
+			child:  "12244709762950607758300092248015787090395669581731908504139525798858898830481",
+		},
+	} {
+		seed, err := hex.DecodeString(tc.seed)
+		require.NoError(t, err)
+
+		master, err := BLS(seed, "m")
+		require.NoError(t, err)
+		require.Equal(t, tc.master, new(big.Int).SetBytes(master).String())
+
+		child, err := BLS(seed, tc.path)
+		require.NoError(t, err)
+		require.Equal(t, tc.child, new(big.Int).SetBytes(child).String())
+	}
+
+	_, err := BLS(make([]byte, 32), "m/0'")
+	require.Error(t, err)
+}
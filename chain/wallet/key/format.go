@@ -0,0 +1,179 @@
+package key
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/wallet/hd"
+)
+
+// The formats keys are exported to and imported from.
+const (
+	// FormatHexLotus is the hex encoding of the JSON KeyInfo
+	FormatHexLotus  = "hex-lotus"
+	FormatJSONLotus = "json-lotus"
+	// FormatGFCJSON is the key file of go-filecoin, only imported
+	FormatGFCJSON = "gfc-json"
+	// FormatRaw is the hex encoding of the private key in the byte order used
+	// by other wallets: big-endian for both secp256k1 and BLS keys
+	FormatRaw = "raw"
+	// FormatMnemonic is a BIP-39 mnemonic, from which the key is derived along
+	// a BIP-32 path for secp256k1 and delegated keys, or an EIP-2333 path for
+	// BLS keys, only imported
+	FormatMnemonic = "mnemonic"
+)
+
+var (
+	ExportFormats = []string{FormatHexLotus, FormatJSONLotus, FormatRaw}
+	ImportFormats = []string{FormatHexLotus, FormatJSONLotus, FormatGFCJSON, FormatRaw, FormatMnemonic}
+)
+
+// DecodeOptions completes the formats which don't carry the key type.
+type DecodeOptions struct {
+	// Type is the type of the key, required by the raw and mnemonic formats
+	Type types.KeyType
+	// Path is the derivation path of the mnemonic format, the default path of
+	// the key type when empty
+	Path       string
+	Passphrase string
+}
+
+// Encode encodes a key in one of the export formats.
+func Encode(ki types.KeyInfo, format string) (string, error) {
+	switch format {
+	case FormatHexLotus:
+		b, err := json.Marshal(ki)
+		if err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(b), nil
+	case FormatJSONLotus:
+		b, err := json.Marshal(ki)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	case FormatRaw:
+		pk := ki.PrivateKey
+		if ki.Type == types.KTBLS {
+			pk = reverse(pk)
+		}
+		return hex.EncodeToString(pk), nil
+	case FormatMnemonic:
+		return "", xerrors.Errorf("keys can't be exported as a mnemonic, they aren't derived from one")
+	default:
+		return "", xerrors.Errorf("unrecognized format: %s", format)
+	}
+}
+
+// Decode decodes a key in one of the import formats.
+func Decode(data []byte, format string, opts DecodeOptions) (*types.KeyInfo, error) {
+	var ki types.KeyInfo
+	switch format {
+	case FormatHexLotus:
+		b, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(b, &ki); err != nil {
+			return nil, err
+		}
+	case FormatJSONLotus:
+		if err := json.Unmarshal(data, &ki); err != nil {
+			return nil, err
+		}
+	case FormatGFCJSON:
+		var f struct {
+			KeyInfo []struct {
+				PrivateKey []byte
+				SigType    int
+			}
+		}
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, xerrors.Errorf("failed to parse go-filecoin key: %s", err)
+		}
+		if len(f.KeyInfo) == 0 {
+			return nil, xerrors.Errorf("no key in go-filecoin key file")
+		}
+
+		gk := f.KeyInfo[0]
+		ki.PrivateKey = gk.PrivateKey
+		switch gk.SigType {
+		case 1:
+			ki.Type = types.KTSecp256k1
+		case 2:
+			ki.Type = types.KTBLS
+		default:
+			return nil, xerrors.Errorf("unrecognized key type: %d", gk.SigType)
+		}
+	case FormatRaw:
+		if err := checkType(opts.Type); err != nil {
+			return nil, err
+		}
+		pk, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(string(data)), "0x"))
+		if err != nil {
+			return nil, err
+		}
+		if len(pk) != 32 {
+			return nil, xerrors.Errorf("expected a 32 bytes private key, got %d bytes", len(pk))
+		}
+		if opts.Type == types.KTBLS {
+			pk = reverse(pk)
+		}
+		ki = types.KeyInfo{Type: opts.Type, PrivateKey: pk}
+	case FormatMnemonic:
+		if err := checkType(opts.Type); err != nil {
+			return nil, err
+		}
+		path := opts.Path
+		if path == "" {
+			path, _ = hd.DefaultPath(opts.Type)
+		}
+
+		if err := hd.ValidateMnemonic(string(data)); err != nil {
+			return nil, xerrors.Errorf("invalid mnemonic: %w", err)
+		}
+
+		seed := hd.SeedFromMnemonic(string(data), opts.Passphrase)
+		var pk []byte
+		var err error
+		if opts.Type == types.KTBLS {
+			pk, err = hd.BLS(seed, path)
+			pk = reverse(pk)
+		} else {
+			pk, err = hd.Secp256k1(seed, path)
+		}
+		if err != nil {
+			return nil, xerrors.Errorf("deriving key: %w", err)
+		}
+		ki = types.KeyInfo{Type: opts.Type, PrivateKey: pk}
+	default:
+		return nil, xerrors.Errorf("unrecognized format: %s", format)
+	}
+	return &ki, nil
+}
+
+func checkType(typ types.KeyType) error {
+	switch typ {
+	case types.KTSecp256k1, types.KTDelegated, types.KTBLS:
+		return nil
+	case "":
+		return xerrors.Errorf("the key type must be given")
+	default:
+		return xerrors.Errorf("unsupported key type: %s", typ)
+	}
+}
+
+// reverse returns a copy of b in the reverse byte order, lotus serializes the
+// BLS private keys little-endian
+func reverse(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[len(b)-1-i] = b[i]
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+// stm: #unit
+package key
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/ethtypes"
+	"github.com/filecoin-project/lotus/chain/wallet/hd"
+	_ "github.com/filecoin-project/lotus/lib/sigs/delegated"
+)
+
+func TestFormats(t *testing.T) {
+	pk := make([]byte, 32)
+	for i := range pk {
+		pk[i] = byte(i)
+	}
+
+	for _, typ := range []types.KeyType{types.KTSecp256k1, types.KTBLS, types.KTDelegated} {
+		ki := types.KeyInfo{Type: typ, PrivateKey: pk}
+
+		for _, format := range ExportFormats {
+			s, err := Encode(ki, format)
+			require.NoError(t, err)
+
+			out, err := Decode([]byte(s+"\n"), format, DecodeOptions{Type: typ})
+			require.NoError(t, err)
+			require.Equal(t, ki, *out, "%s %s", typ, format)
+		}
+	}
+
+	// raw BLS keys are big-endian
+	s, err := Encode(types.KeyInfo{Type: types.KTBLS, PrivateKey: pk}, FormatRaw)
+	require.NoError(t, err)
+	require.Equal(t, "1f1e1d1c1b1a191817161514131211100f0e0d0c0b0a09080706050403020100", s)
+
+	_, err = Decode([]byte(s), FormatRaw, DecodeOptions{})
+	require.Error(t, err)
+	_, err = Encode(types.KeyInfo{Type: types.KTBLS, PrivateKey: pk}, FormatMnemonic)
+	require.Error(t, err)
+}
+
+func TestDecodeMnemonic(t *testing.T) {
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	seed := hd.SeedFromMnemonic(mnemonic, "")
+
+	ki, err := Decode([]byte(mnemonic), FormatMnemonic, DecodeOptions{Type: types.KTSecp256k1})
+	require.NoError(t, err)
+	exp, err := hd.Secp256k1(seed, hd.DefaultSecp256k1Path)
+	require.NoError(t, err)
+	require.Equal(t, exp, ki.PrivateKey)
+
+	ki, err = Decode([]byte(mnemonic), FormatMnemonic, DecodeOptions{Type: types.KTBLS, Path: "m/12381/461/0/1"})
+	require.NoError(t, err)
+	exp, err = hd.BLS(seed, "m/12381/461/0/1")
+	require.NoError(t, err)
+	require.Equal(t, hex.EncodeToString(exp), hex.EncodeToString(reverse(ki.PrivateKey)))
+
+	// mistyped words are caught by the checksum instead of deriving another key
+	_, err = Decode([]byte("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon"), FormatMnemonic, DecodeOptions{Type: types.KTSecp256k1})
+	require.Error(t, err)
+}
+
+func TestDecodeMnemonicDelegated(t *testing.T) {
+	// the first account of the mnemonic in Ethereum wallets
+	ki, err := Decode([]byte("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"), FormatMnemonic, DecodeOptions{Type: types.KTDelegated})
+	require.NoError(t, err)
+
+	k, err := NewKey(*ki)
+	require.NoError(t, err)
+
+	ea, err := ethtypes.ParseEthAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94")
+	require.NoError(t, err)
+	addr, err := ea.ToFilecoinAddress()
+	require.NoError(t, err)
+	require.Equal(t, addr, k.Address)
+}
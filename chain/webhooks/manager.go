@@ -0,0 +1,418 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/events"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var log = logging.Logger("webhooks")
+
+var dsPrefix = datastore.NewKey("/webhooks")
+
+const (
+	// SignatureHeader is the header carrying the hex encoded HMAC-SHA256 of the
+	// notification body, keyed with the subscription secret.
+	SignatureHeader = "X-Lotus-Signature"
+	// AttemptHeader is the header carrying the delivery attempt number,
+	// starting at 1.
+	AttemptHeader = "X-Lotus-Delivery-Attempt"
+
+	// maximum number of notifications queued per subscription, the oldest
+	// notifications are dropped first
+	maxQueued = 10000
+)
+
+// ChainAPI is the subset of the full node API used to find executed messages.
+type ChainAPI interface {
+	ChainGetParentMessages(context.Context, cid.Cid) ([]api.Message, error)
+	ChainGetParentReceipts(context.Context, cid.Cid) ([]*types.MessageReceipt, error)
+}
+
+// Config is the delivery configuration of a Manager.
+type Config struct {
+	// MaxAttempts is the number of times a notification is posted before
+	// giving up.
+	MaxAttempts int
+	// MinBackoff and MaxBackoff bound the exponential delay between attempts.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// Timeout is the timeout of each HTTP request.
+	Timeout time.Duration
+}
+
+// Manager keeps persistent webhook subscriptions, and posts notifications to
+// them when matching messages are executed or reverted. It is a tipset
+// observer, see events.Events.Observe.
+type Manager struct {
+	api    ChainAPI
+	ds     datastore.Batching
+	cfg    Config
+	client *http.Client
+
+	lk      sync.Mutex
+	subs    map[uuid.UUID]*subscriber
+	started bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+var _ events.TipSetObserver = (*Manager)(nil)
+
+// NewManager loads the subscriptions from the datastore. Deliveries start
+// with Start.
+func NewManager(ctx context.Context, a ChainAPI, ds datastore.Batching, cfg Config) (*Manager, error) {
+	if cfg.MaxAttempts < 1 {
+		return nil, xerrors.Errorf("max attempts must be at least 1")
+	}
+	if cfg.MinBackoff <= 0 || cfg.MaxBackoff < cfg.MinBackoff {
+		return nil, xerrors.Errorf("invalid backoff range %s-%s", cfg.MinBackoff, cfg.MaxBackoff)
+	}
+
+	m := &Manager{
+		api:    a,
+		ds:     ds,
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+		subs:   map[uuid.UUID]*subscriber{},
+	}
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+
+	res, err := ds.Query(ctx, query.Query{Prefix: dsPrefix.String()})
+	if err != nil {
+		return nil, xerrors.Errorf("querying webhook subscriptions: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, xerrors.Errorf("iterating webhook subscriptions: %w", r.Error)
+		}
+
+		var sub api.WebhookSubscription
+		if err := json.Unmarshal(r.Value, &sub); err != nil {
+			return nil, xerrors.Errorf("decoding webhook subscription %s: %w", r.Key, err)
+		}
+		m.subs[sub.ID] = newSubscriber(sub)
+	}
+
+	return m, nil
+}
+
+// Start starts delivering notifications.
+func (m *Manager) Start() {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	m.started = true
+	for _, s := range m.subs {
+		m.run(s)
+	}
+}
+
+// Close stops delivering notifications, queued notifications are dropped.
+func (m *Manager) Close() error {
+	m.cancel()
+	m.wg.Wait()
+	return nil
+}
+
+// Subscribe adds a subscription posting notifications to the given URL for the
+// messages matching any of the patterns.
+func (m *Manager) Subscribe(ctx context.Context, target, secret string, patterns []api.WebhookPattern) (uuid.UUID, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return uuid.Nil, xerrors.Errorf("parsing webhook url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return uuid.Nil, xerrors.Errorf("webhook url must be http or https, got %q", u.Scheme)
+	}
+	if len(patterns) == 0 {
+		return uuid.Nil, xerrors.Errorf("no patterns given")
+	}
+
+	sub := api.WebhookSubscription{
+		ID:       uuid.New(),
+		URL:      target,
+		Secret:   secret,
+		Patterns: patterns,
+	}
+
+	b, err := json.Marshal(sub)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if err := m.ds.Put(ctx, dsPrefix.ChildString(sub.ID.String()), b); err != nil {
+		return uuid.Nil, xerrors.Errorf("storing webhook subscription: %w", err)
+	}
+
+	s := newSubscriber(sub)
+
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	m.subs[sub.ID] = s
+	if m.started {
+		m.run(s)
+	}
+	return sub.ID, nil
+}
+
+// Unsubscribe removes a subscription, its queued notifications are dropped.
+func (m *Manager) Unsubscribe(ctx context.Context, id uuid.UUID) error {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	s, ok := m.subs[id]
+	if !ok {
+		return xerrors.Errorf("webhook subscription %s not found", id)
+	}
+	if err := m.ds.Delete(ctx, dsPrefix.ChildString(id.String())); err != nil {
+		return xerrors.Errorf("deleting webhook subscription: %w", err)
+	}
+
+	delete(m.subs, id)
+	s.stop()
+	return nil
+}
+
+// List returns the subscriptions, without their secrets.
+func (m *Manager) List() []api.WebhookSubscription {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	out := make([]api.WebhookSubscription, 0, len(m.subs))
+	for _, s := range m.subs {
+		sub := s.sub
+		sub.Secret = ""
+		out = append(out, sub)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].URL < out[j].URL || (out[i].URL == out[j].URL && out[i].ID.String() < out[j].ID.String())
+	})
+	return out
+}
+
+// Apply notifies the messages executed in the to tipset, whose receipts are
+// in to.
+func (m *Manager) Apply(ctx context.Context, from, to *types.TipSet) error {
+	return m.notify(ctx, to, api.WebhookApplied)
+}
+
+// Revert notifies the messages whose receipts were in the reverted from
+// tipset.
+func (m *Manager) Revert(ctx context.Context, from, to *types.TipSet) error {
+	return m.notify(ctx, from, api.WebhookReverted)
+}
+
+func (m *Manager) notify(ctx context.Context, ts *types.TipSet, typ string) error {
+	m.lk.Lock()
+	empty := len(m.subs) == 0
+	m.lk.Unlock()
+	if empty {
+		return nil
+	}
+
+	msgs, err := m.api.ChainGetParentMessages(ctx, ts.Cids()[0])
+	if err != nil {
+		return xerrors.Errorf("loading executed messages: %w", err)
+	}
+	rcts, err := m.api.ChainGetParentReceipts(ctx, ts.Cids()[0])
+	if err != nil {
+		return xerrors.Errorf("loading receipts: %w", err)
+	}
+	if len(msgs) != len(rcts) {
+		return xerrors.Errorf("got %d messages but %d receipts", len(msgs), len(rcts))
+	}
+
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	for i, msg := range msgs {
+		for _, s := range m.subs {
+			if !s.matches(msg.Message) {
+				continue
+			}
+			s.push(api.WebhookNotification{
+				Subscription: s.sub.ID,
+				Type:         typ,
+				Message:      msg.Cid,
+				From:         msg.Message.From,
+				To:           msg.Message.To,
+				Method:       msg.Message.Method,
+				TipSet:       ts.Key(),
+				Height:       ts.Height(),
+				Receipt:      *rcts[i],
+			})
+		}
+	}
+	return nil
+}
+
+// run starts the delivery loop of a subscriber, m.lk must be held.
+func (m *Manager) run(s *subscriber) {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+
+		for {
+			n, ok := s.pop(m.ctx)
+			if !ok {
+				return
+			}
+			m.deliver(s, n)
+		}
+	}()
+}
+
+func (m *Manager) deliver(s *subscriber, n api.WebhookNotification) {
+	body, err := json.Marshal(n)
+	if err != nil {
+		log.Errorw("encoding webhook notification", "subscription", s.sub.ID, "error", err)
+		return
+	}
+
+	backoff := m.cfg.MinBackoff
+	for attempt := 1; ; attempt++ {
+		err := m.post(s, body, attempt)
+		if err == nil {
+			return
+		}
+		if attempt >= m.cfg.MaxAttempts {
+			log.Warnw("giving up on webhook notification", "subscription", s.sub.ID, "message", n.Message, "attempts", attempt, "error", err)
+			return
+		}
+		log.Debugw("webhook delivery failed, retrying", "subscription", s.sub.ID, "attempt", attempt, "error", err)
+
+		select {
+		case <-time.After(backoff):
+		case <-s.done:
+			return
+		case <-m.ctx.Done():
+			return
+		}
+
+		backoff *= 2
+		if backoff > m.cfg.MaxBackoff {
+			backoff = m.cfg.MaxBackoff
+		}
+	}
+}
+
+func (m *Manager) post(s *subscriber, body []byte, attempt int) error {
+	req, err := http.NewRequestWithContext(m.ctx, http.MethodPost, s.sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(AttemptHeader, strconv.Itoa(attempt))
+	if s.sub.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(s.sub.Secret, body))
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return xerrors.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the signature header value of a notification body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type subscriber struct {
+	sub api.WebhookSubscription
+
+	lk     sync.Mutex
+	queue  []api.WebhookNotification
+	notify chan struct{}
+	done   chan struct{}
+}
+
+func newSubscriber(sub api.WebhookSubscription) *subscriber {
+	return &subscriber{
+		sub:    sub,
+		notify: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+}
+
+func (s *subscriber) matches(msg *types.Message) bool {
+	for _, p := range s.sub.Patterns {
+		if p.Matches(msg) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *subscriber) push(n api.WebhookNotification) {
+	s.lk.Lock()
+	if len(s.queue) >= maxQueued {
+		log.Warnw("webhook notification queue full, dropping oldest notification", "subscription", s.sub.ID)
+		s.queue = s.queue[1:]
+	}
+	s.queue = append(s.queue, n)
+	s.lk.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (s *subscriber) pop(ctx context.Context) (api.WebhookNotification, bool) {
+	for {
+		s.lk.Lock()
+		if len(s.queue) > 0 {
+			n := s.queue[0]
+			s.queue = s.queue[1:]
+			s.lk.Unlock()
+			return n, true
+		}
+		s.lk.Unlock()
+
+		select {
+		case <-s.notify:
+		case <-s.done:
+			return api.WebhookNotification{}, false
+		case <-ctx.Done():
+			return api.WebhookNotification{}, false
+		}
+	}
+}
+
+func (s *subscriber) stop() {
+	close(s.done)
+}
@@ -0,0 +1,161 @@
+// stm: #unit
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+type fakeChain struct {
+	msgs []api.Message
+}
+
+func (fc *fakeChain) ChainGetParentMessages(context.Context, cid.Cid) ([]api.Message, error) {
+	return fc.msgs, nil
+}
+
+func (fc *fakeChain) ChainGetParentReceipts(context.Context, cid.Cid) ([]*types.MessageReceipt, error) {
+	out := make([]*types.MessageReceipt, len(fc.msgs))
+	for i := range fc.msgs {
+		out[i] = &types.MessageReceipt{GasUsed: int64(i)}
+	}
+	return out, nil
+}
+
+type receiver struct {
+	lk        sync.Mutex
+	failFirst int
+	received  []api.WebhookNotification
+	attempts  int
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	r.attempts++
+	if r.attempts <= r.failFirst {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	body, _ := io.ReadAll(req.Body)
+	if req.Header.Get(SignatureHeader) != Sign("secret", body) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var n api.WebhookNotification
+	if err := json.Unmarshal(body, &n); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.received = append(r.received, n)
+}
+
+func (r *receiver) notifications() []api.WebhookNotification {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	return append([]api.WebhookNotification{}, r.received...)
+}
+
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+
+	rcv := &receiver{failFirst: 2}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	from, to, other := mock.Address(100), mock.Address(101), mock.Address(102)
+	send := mock.UnsignedMessage(from, to, 0)
+	call := mock.UnsignedMessage(from, to, 1)
+	call.Method = builtin.MustGenerateFRCMethodNum("Transfer")
+	unrelated := mock.UnsignedMessage(other, other, 0)
+
+	fc := &fakeChain{}
+	for _, msg := range []*types.Message{send, call, unrelated} {
+		fc.msgs = append(fc.msgs, api.Message{Cid: msg.Cid(), Message: msg})
+	}
+
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	cfg := Config{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond, Timeout: time.Second}
+	m, err := NewManager(ctx, fc, ds, cfg)
+	require.NoError(t, err)
+	m.Start()
+
+	_, err = m.Subscribe(ctx, "ftp://example.com", "", []api.WebhookPattern{{}})
+	require.Error(t, err)
+
+	method := call.Method
+	id, err := m.Subscribe(ctx, srv.URL, "secret", []api.WebhookPattern{{Address: to, Method: &method}})
+	require.NoError(t, err)
+
+	subs := m.List()
+	require.Len(t, subs, 1)
+	require.Equal(t, id, subs[0].ID)
+	require.Empty(t, subs[0].Secret)
+
+	ts := mock.TipSet(mock.MkBlock(nil, 1, 1))
+	require.NoError(t, m.Apply(ctx, nil, ts))
+	require.NoError(t, m.Revert(ctx, ts, nil))
+
+	// the first two attempts fail, and are retried
+	require.Eventually(t, func() bool {
+		return len(rcv.notifications()) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	ns := rcv.notifications()
+	for i, typ := range []string{api.WebhookApplied, api.WebhookReverted} {
+		require.Equal(t, typ, ns[i].Type)
+		require.Equal(t, id, ns[i].Subscription)
+		require.Equal(t, call.Cid(), ns[i].Message)
+		require.Equal(t, ts.Key(), ns[i].TipSet)
+		require.EqualValues(t, 1, ns[i].Receipt.GasUsed)
+	}
+	require.NoError(t, m.Close())
+
+	// subscriptions are persisted
+	m, err = NewManager(ctx, fc, ds, cfg)
+	require.NoError(t, err)
+	require.Len(t, m.List(), 1)
+
+	require.NoError(t, m.Unsubscribe(ctx, id))
+	require.Error(t, m.Unsubscribe(ctx, id))
+
+	m, err = NewManager(ctx, fc, ds, cfg)
+	require.NoError(t, err)
+	require.Empty(t, m.List())
+}
+
+func TestPatternMatches(t *testing.T) {
+	a, b := mock.Address(100), mock.Address(101)
+	msg := mock.UnsignedMessage(a, b, 0)
+	method := abi.MethodNum(2)
+
+	require.True(t, api.WebhookPattern{}.Matches(msg))
+	require.True(t, api.WebhookPattern{Address: a}.Matches(msg))
+	require.True(t, api.WebhookPattern{Address: b}.Matches(msg))
+	require.False(t, api.WebhookPattern{Address: mock.Address(102)}.Matches(msg))
+	require.False(t, api.WebhookPattern{Address: b, Method: &method}.Matches(msg))
+
+	msg.Method = method
+	require.True(t, api.WebhookPattern{Address: b, Method: &method}.Matches(msg))
+}
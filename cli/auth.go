@@ -2,13 +2,17 @@ package cli
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/urfave/cli/v2"
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-jsonrpc/auth"
 
 	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/lib/tablewriter"
 	"github.com/filecoin-project/lotus/node/repo"
 )
 
@@ -18,18 +22,99 @@ var AuthCmd = &cli.Command{
 	Subcommands: []*cli.Command{
 		AuthCreateAdminToken,
 		AuthApiInfoToken,
+		AuthListTokensCmd,
+		AuthTenantsCmd,
 	},
 }
 
+var authTokenFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "perm",
+		Usage: "permission to assign to the token, one of: read, write, sign, admin",
+	},
+	&cli.StringFlag{
+		Name:  "namespace",
+		Usage: "bind the token to a tenant namespace (full node only, admin permission not allowed)",
+	},
+	&cli.StringSliceFlag{
+		Name:  "method",
+		Usage: "restrict the token to the method, e.g. ChainHead (full node only, can be repeated)",
+	},
+	&cli.StringSliceFlag{
+		Name:  "wallet",
+		Usage: "restrict the wallet keys the token can use to the key address (full node only, can be repeated)",
+	},
+	&cli.StringFlag{
+		Name:  "label",
+		Usage: "describe a token restricted with --method or --wallet, e.g. with the name of its bot",
+	},
+}
+
+// authNewToken creates a token with the permissions, bound to the namespace
+// set with the --namespace flag, or restricted to the scope set with the
+// --method and --wallet flags.
+func authNewToken(cctx *cli.Context, napi api.Common, perms []auth.Permission) ([]byte, error) {
+	ctx := ReqContext(cctx)
+
+	ns := cctx.String("namespace")
+	scoped := cctx.IsSet("method") || cctx.IsSet("wallet")
+	if ns == "" && !scoped {
+		if cctx.IsSet("label") {
+			return nil, xerrors.Errorf("--label can only be set with --method or --wallet")
+		}
+		return napi.AuthNew(ctx, perms)
+	}
+	if ns != "" && scoped {
+		return nil, xerrors.Errorf("--namespace can't be combined with --method or --wallet")
+	}
+
+	if t := authRepoType(cctx); t.Type() != repo.FullNode.Type() {
+		return nil, xerrors.Errorf("--namespace, --method and --wallet are only supported by the full node, not by the %s", t.Type())
+	}
+
+	fapi, closer, err := GetFullNodeAPIV1(cctx)
+	if err != nil {
+		return nil, err
+	}
+	defer closer()
+
+	if ns != "" {
+		return fapi.AuthNewNamespaced(ctx, perms, ns)
+	}
+
+	scope := api.AuthScope{
+		Label:   cctx.String("label"),
+		Methods: cctx.StringSlice("method"),
+	}
+	for _, w := range cctx.StringSlice("wallet") {
+		addr, err := address.NewFromString(w)
+		if err != nil {
+			return nil, xerrors.Errorf("parsing wallet address %q: %w", w, err)
+		}
+		scope.Wallets = append(scope.Wallets, addr)
+	}
+	return fapi.AuthNewWithScope(ctx, perms, scope)
+}
+
+// authRepoType returns the type of the node the command is run for.
+func authRepoType(cctx *cli.Context) repo.RepoType {
+	ti, ok := cctx.App.Metadata["repoType"]
+	if !ok {
+		log.Errorf("unknown repo type, are you sure you want to use GetCommonAPI?")
+		ti = repo.FullNode
+	}
+	t, ok := ti.(repo.RepoType)
+	if !ok {
+		log.Errorf("repoType type does not match the type of repo.RepoType")
+		t = repo.FullNode
+	}
+	return t
+}
+
 var AuthCreateAdminToken = &cli.Command{
 	Name:  "create-token",
 	Usage: "Create token",
-	Flags: []cli.Flag{
-		&cli.StringFlag{
-			Name:  "perm",
-			Usage: "permission to assign to the token, one of: read, write, sign, admin",
-		},
-	},
+	Flags: authTokenFlags,
 
 	Action: func(cctx *cli.Context) error {
 		napi, closer, err := GetAPI(cctx)
@@ -38,8 +123,6 @@ var AuthCreateAdminToken = &cli.Command{
 		}
 		defer closer()
 
-		ctx := ReqContext(cctx)
-
 		if !cctx.IsSet("perm") {
 			return xerrors.New("--perm flag not set")
 		}
@@ -57,7 +140,7 @@ var AuthCreateAdminToken = &cli.Command{
 		}
 
 		// slice on [:idx] so for example: 'sign' gives you [read, write, sign]
-		token, err := napi.AuthNew(ctx, api.AllPermissions[:idx])
+		token, err := authNewToken(cctx, napi, api.AllPermissions[:idx])
 		if err != nil {
 			return err
 		}
@@ -72,12 +155,7 @@ var AuthCreateAdminToken = &cli.Command{
 var AuthApiInfoToken = &cli.Command{
 	Name:  "api-info",
 	Usage: "Get token with API info required to connect to this node",
-	Flags: []cli.Flag{
-		&cli.StringFlag{
-			Name:  "perm",
-			Usage: "permission to assign to the token, one of: read, write, sign, admin",
-		},
-	},
+	Flags: authTokenFlags,
 
 	Action: func(cctx *cli.Context) error {
 		napi, closer, err := GetAPI(cctx)
@@ -86,8 +164,6 @@ var AuthApiInfoToken = &cli.Command{
 		}
 		defer closer()
 
-		ctx := ReqContext(cctx)
-
 		if !cctx.IsSet("perm") {
 			return xerrors.New("--perm flag not set, use with one of: read, write, sign, admin")
 		}
@@ -105,21 +181,12 @@ var AuthApiInfoToken = &cli.Command{
 		}
 
 		// slice on [:idx] so for example: 'sign' gives you [read, write, sign]
-		token, err := napi.AuthNew(ctx, api.AllPermissions[:idx])
+		token, err := authNewToken(cctx, napi, api.AllPermissions[:idx])
 		if err != nil {
 			return err
 		}
 
-		ti, ok := cctx.App.Metadata["repoType"]
-		if !ok {
-			log.Errorf("unknown repo type, are you sure you want to use GetCommonAPI?")
-			ti = repo.FullNode
-		}
-		t, ok := ti.(repo.RepoType)
-		if !ok {
-			log.Errorf("repoType type does not match the type of repo.RepoType")
-		}
-
+		t := authRepoType(cctx)
 		ainfo, err := GetAPIInfo(cctx, t)
 		if err != nil {
 			return xerrors.Errorf("could not get API info for %s: %w", t, err)
@@ -132,3 +199,117 @@ var AuthApiInfoToken = &cli.Command{
 		return nil
 	},
 }
+
+var AuthListTokensCmd = &cli.Command{
+	Name:  "list-tokens",
+	Usage: "List the tokens restricted to methods or wallet keys",
+	Action: func(cctx *cli.Context) error {
+		napi, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		tokens, err := napi.AuthListTokens(ReqContext(cctx))
+		if err != nil {
+			return err
+		}
+
+		tw := tablewriter.New(
+			tablewriter.Col("ID"),
+			tablewriter.Col("Label"),
+			tablewriter.Col("Created"),
+			tablewriter.Col("Perms"),
+			tablewriter.Col("Methods"),
+			tablewriter.Col("Wallets"),
+		)
+		for _, t := range tokens {
+			perms := make([]string, len(t.Perms))
+			for i, p := range t.Perms {
+				perms[i] = string(p)
+			}
+			methods, wallets := "all", "all"
+			if len(t.Scope.Methods) > 0 {
+				methods = strings.Join(t.Scope.Methods, ",")
+			}
+			if len(t.Scope.Wallets) > 0 {
+				ws := make([]string, len(t.Scope.Wallets))
+				for i, w := range t.Scope.Wallets {
+					ws[i] = w.String()
+				}
+				wallets = strings.Join(ws, ",")
+			}
+			tw.Write(map[string]interface{}{
+				"ID":      t.ID,
+				"Label":   t.Scope.Label,
+				"Created": t.Created.Format(time.RFC3339),
+				"Perms":   strings.Join(perms, ","),
+				"Methods": methods,
+				"Wallets": wallets,
+			})
+		}
+		return tw.Flush(cctx.App.Writer)
+	},
+}
+
+var AuthTenantsCmd = &cli.Command{
+	Name:  "tenants",
+	Usage: "Manage the tenant namespaces of the full node",
+	Subcommands: []*cli.Command{
+		authTenantsListCmd,
+		authTenantsAssignCmd,
+	},
+}
+
+var authTenantsListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "List the tenant namespaces and their wallet keys",
+	Action: func(cctx *cli.Context) error {
+		napi, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		tenants, err := napi.TenantList(ReqContext(cctx))
+		if err != nil {
+			return err
+		}
+
+		for _, t := range tenants {
+			fmt.Printf("%s:\n", t.Namespace)
+			for _, addr := range t.Wallets {
+				if addr == t.Default {
+					fmt.Printf("  %s (default)\n", addr)
+				} else {
+					fmt.Printf("  %s\n", addr)
+				}
+			}
+		}
+		return nil
+	},
+}
+
+var authTenantsAssignCmd = &cli.Command{
+	Name:      "assign",
+	Usage:     "Assign a wallet key to a tenant namespace, an empty namespace removes the key from its namespace",
+	ArgsUsage: "<address> <namespace>",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 2 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		napi, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		addr, err := address.NewFromString(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("parsing address: %w", err)
+		}
+
+		return napi.TenantAssignWallet(ReqContext(cctx), addr, cctx.Args().Get(1))
+	},
+}
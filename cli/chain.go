@@ -15,6 +15,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/ipfs/go-cid"
@@ -38,6 +39,7 @@ import (
 	"github.com/filecoin-project/lotus/api/v0api"
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/analytics"
 	"github.com/filecoin-project/lotus/chain/consensus"
 	"github.com/filecoin-project/lotus/chain/types"
 )
@@ -58,13 +60,19 @@ var ChainCmd = &cli.Command{
 		ChainBisectCmd,
 		ChainExportCmd,
 		ChainExportRangeCmd,
+		ChainExportAnalyticsCmd,
 		SlashConsensusFault,
+		ChainConsensusFaultsCmd,
 		ChainGasPriceCmd,
 		ChainInspectUsage,
 		ChainDecodeCmd,
 		ChainEncodeCmd,
 		ChainDisputeSetCmd,
 		ChainPruneCmd,
+		ChainBlockstoreMaintainCmd,
+		ChainBlockstoreScrubCmd,
+		ChainReplicaCmd,
+		ChainCachesCmd,
 	},
 }
 
@@ -1084,9 +1092,13 @@ var ChainExportCmd = &cli.Command{
 		&cli.BoolFlag{
 			Name: "skip-old-msgs",
 		},
+		&cli.StringSliceFlag{
+			Name:  "actor",
+			Usage: "only include the state of the actor in the recent state roots, the other actors only keep their entry in the state tree and the export can't be imported (can be repeated)",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
-		api, closer, err := GetFullNodeAPI(cctx)
+		api, closer, err := GetFullNodeAPIV1(cctx)
 		if err != nil {
 			return err
 		}
@@ -1097,8 +1109,22 @@ var ChainExportCmd = &cli.Command{
 			return IncorrectNumArgs(cctx)
 		}
 
+		var actors []address.Address
+		for _, s := range cctx.StringSlice("actor") {
+			a, err := address.NewFromString(s)
+			if err != nil {
+				return xerrors.Errorf("parsing actor address %s: %w", s, err)
+			}
+			actors = append(actors, a)
+		}
+
 		rsrs := abi.ChainEpoch(cctx.Int64("recent-stateroots"))
-		if cctx.IsSet("recent-stateroots") && rsrs < build.Finality {
+		if len(actors) > 0 {
+			// the export can't be imported, any number of state roots can be included
+			if rsrs <= 0 {
+				return fmt.Errorf("must pass recent stateroots along with actor")
+			}
+		} else if cctx.IsSet("recent-stateroots") && rsrs < build.Finality {
 			return fmt.Errorf("\"recent-stateroots\" has to be greater than %d", build.Finality)
 		}
 
@@ -1124,7 +1150,12 @@ var ChainExportCmd = &cli.Command{
 			return fmt.Errorf("must pass recent stateroots along with skip-old-msgs")
 		}
 
-		stream, err := api.ChainExport(ctx, rsrs, skipold, ts.Key())
+		var stream <-chan []byte
+		if len(actors) > 0 {
+			stream, err = api.ChainExportActorStates(ctx, rsrs, skipold, actors, ts.Key())
+		} else {
+			stream, err = api.ChainExport(ctx, rsrs, skipold, ts.Key())
+		}
 		if err != nil {
 			return err
 		}
@@ -1250,6 +1281,129 @@ var ChainExportRangeCmd = &cli.Command{
 	},
 }
 
+var ChainExportAnalyticsCmd = &cli.Command{
+	Name:      "export-analytics",
+	Usage:     "export messages, receipts and balance changes to parquet files",
+	ArgsUsage: "[outputDir]",
+	Description: `Writes the messages, receipts and actor balance changes of the tipsets in an
+epoch range to parquet files in the output directory, one file per table per
+chunk of epochs. The export can be interrupted and is resumed from the cursor
+stored in the output directory.`,
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:  "from",
+			Usage: "first epoch to export",
+		},
+		&cli.Int64Flag{
+			Name:        "to",
+			Usage:       "last epoch to export",
+			DefaultText: "the last final epoch",
+		},
+		&cli.Int64Flag{
+			Name:  "chunk-size",
+			Usage: "number of epochs per output file",
+			Value: 2880,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		// pin the head so that the whole export sees the same chain
+		head, err := api.ChainHead(ctx)
+		if err != nil {
+			return err
+		}
+
+		from := abi.ChainEpoch(cctx.Int64("from"))
+		to := head.Height() - build.Finality
+		if cctx.IsSet("to") {
+			to = abi.ChainEpoch(cctx.Int64("to"))
+		}
+		if to >= head.Height() {
+			return xerrors.Errorf("--to must be lower than the head height %d", head.Height())
+		}
+		if to < from {
+			return xerrors.Errorf("--to must be greater or equal to --from")
+		}
+
+		e := analytics.NewExporter(api, cctx.Args().First())
+		e.ChunkSize = abi.ChainEpoch(cctx.Int64("chunk-size"))
+		afmt := NewAppFmt(cctx.App)
+		e.Progress = func(start, end abi.ChainEpoch) {
+			afmt.Printf("exported epochs %d to %d (%.1f%%)\n", start, end, float64(end-from+1)*100/float64(to-from+1))
+		}
+
+		cur, err := e.Cursor()
+		if err != nil {
+			return err
+		}
+		if cur != nil && cur.NextEpoch > from {
+			afmt.Printf("resuming export from epoch %d\n", cur.NextEpoch)
+		}
+
+		return e.Export(ctx, head.Key(), from, to)
+	},
+}
+
+var ChainConsensusFaultsCmd = &cli.Command{
+	Name:  "consensus-faults",
+	Usage: "List the consensus faults detected by the consensus fault reporter",
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:  "since",
+			Usage: "list the faults at or after this epoch",
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the faults as json",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		afmt := NewAppFmt(cctx.App)
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		faults, err := api.ChainConsensusFaults(ctx, abi.ChainEpoch(cctx.Int64("since")))
+		if err != nil {
+			return err
+		}
+
+		if cctx.Bool("json") {
+			out, err := json.MarshalIndent(faults, "", "  ")
+			if err != nil {
+				return err
+			}
+			afmt.Println(string(out))
+			return nil
+		}
+
+		for _, f := range faults {
+			afmt.Printf("%d\t%s\t%s\t%s %s\n", f.Epoch, f.Miner, f.Type, f.Block1, f.Block2)
+			switch {
+			case f.Report != nil:
+				afmt.Printf("\treported in %s\n", *f.Report)
+			case f.ReportError != "":
+				afmt.Printf("\treport failed: %s\n", f.ReportError)
+			}
+		}
+		return nil
+	},
+}
+
 var SlashConsensusFault = &cli.Command{
 	Name:      "slash-consensus",
 	Usage:     "Report consensus fault",
@@ -1686,3 +1840,359 @@ var chainPruneColdCmd = &cli.Command{
 		return api.ChainPrune(ctx, opts)
 	},
 }
+
+var ChainBlockstoreMaintainCmd = &cli.Command{
+	Name:  "blockstore-maintain",
+	Usage: "run online maintenance of the badger chain blockstore",
+	Subcommands: []*cli.Command{
+		chainBlockstoreMaintainStartCmd,
+		chainBlockstoreMaintainStatusCmd,
+	},
+}
+
+var chainBlockstoreMaintainStartCmd = &cli.Command{
+	Name:  "start",
+	Usage: "start a maintenance run",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "mode",
+			Value: "vlog-gc",
+			Usage: "'vlog-gc' to garbage collect the value log, 'compaction' to fully compact the blockstore first",
+		},
+		&cli.Float64Flag{
+			Name:  "threshold",
+			Usage: "fraction of garbage of the value log files to rewrite (default 0.125)",
+		},
+		&cli.BoolFlag{
+			Name:  "wait",
+			Usage: "wait for the run to finish, printing its progress",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		run, err := api.ChainBlockstoreMaintain(ctx, lapi.BlockstoreMaintainOpts{
+			Mode:      cctx.String("mode"),
+			Threshold: cctx.Float64("threshold"),
+		})
+		if err != nil {
+			return err
+		}
+		afmt := NewAppFmt(cctx.App)
+		afmt.Printf("Started %s maintenance at %s\n", run.Mode, run.Started.Format(time.RFC3339))
+
+		if !cctx.Bool("wait") {
+			return nil
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(5 * time.Second):
+			}
+
+			st, err := api.ChainBlockstoreMaintenanceStatus(ctx)
+			if err != nil {
+				return err
+			}
+			if st.Last == nil {
+				return xerrors.Errorf("maintenance run not found")
+			}
+			if st.Last.State != lapi.BlockstoreMaintenanceRunning {
+				printBlockstoreMaintenanceRun(afmt, st.Last)
+				if st.Last.State == lapi.BlockstoreMaintenanceFailed {
+					return xerrors.Errorf("maintenance failed: %s", st.Last.Error)
+				}
+				return nil
+			}
+			afmt.Printf("%s: %d value log files rewritten\n", st.Last.Phase, st.Last.ValueLogsRewritten)
+		}
+	},
+}
+
+var chainBlockstoreMaintainStatusCmd = &cli.Command{
+	Name:  "status",
+	Usage: "print the state of the last maintenance run",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		st, err := api.ChainBlockstoreMaintenanceStatus(ctx)
+		if err != nil {
+			return err
+		}
+
+		afmt := NewAppFmt(cctx.App)
+		if st.Last == nil {
+			afmt.Println("No maintenance run yet")
+		} else {
+			printBlockstoreMaintenanceRun(afmt, st.Last)
+		}
+		if st.NextScheduled != nil {
+			afmt.Printf("Next scheduled: %s\n", st.NextScheduled.Format(time.RFC3339))
+		} else {
+			afmt.Println("Next scheduled: none, no maintenance windows configured")
+		}
+		return nil
+	},
+}
+
+func printBlockstoreMaintenanceRun(afmt *AppFmt, run *lapi.BlockstoreMaintenanceRun) {
+	afmt.Printf("Mode: %s (%s)\n", run.Mode, run.Trigger)
+	afmt.Printf("State: %s\n", run.State)
+	if run.Phase != "" {
+		afmt.Printf("Phase: %s\n", run.Phase)
+	}
+	afmt.Printf("Started: %s\n", run.Started.Format(time.RFC3339))
+	if !run.Finished.IsZero() {
+		afmt.Printf("Finished: %s (took %s)\n", run.Finished.Format(time.RFC3339), run.Finished.Sub(run.Started).Round(time.Second))
+	}
+	afmt.Printf("Value log files rewritten: %d\n", run.ValueLogsRewritten)
+	afmt.Printf("Size before: %s\n", types.SizeStr(types.NewInt(uint64(run.SizeBefore))))
+	if !run.Finished.IsZero() {
+		afmt.Printf("Size after: %s\n", types.SizeStr(types.NewInt(uint64(run.SizeAfter))))
+	}
+	if run.Error != "" {
+		afmt.Printf("Error: %s\n", run.Error)
+	}
+}
+
+var ChainBlockstoreScrubCmd = &cli.Command{
+	Name:  "blockstore-scrub",
+	Usage: "print the progress of the blockstore scrubber and the corrupt blocks it quarantined",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the status as json",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		afmt := NewAppFmt(cctx.App)
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		st, err := api.ChainBlockstoreScrubStatus(ctx)
+		if err != nil {
+			return err
+		}
+
+		if cctx.Bool("json") {
+			out, err := json.MarshalIndent(st, "", "  ")
+			if err != nil {
+				return err
+			}
+			afmt.Println(string(out))
+			return nil
+		}
+
+		switch {
+		case st.Running:
+			afmt.Printf("Pass running since %s, %d blocks checked\n", st.PassStarted.Format(time.RFC3339), st.Checked)
+		case st.PassStarted.IsZero():
+			afmt.Println("No pass yet")
+		default:
+			afmt.Printf("Last pass finished at %s, %d blocks checked\n", st.PassFinished.Format(time.RFC3339), st.Checked)
+		}
+
+		afmt.Printf("%d incidents\n", len(st.Incidents))
+		for _, inc := range st.Incidents {
+			state := "quarantined"
+			if inc.Repaired {
+				state = "repaired"
+			}
+			afmt.Printf("%s\t%s\t%s\t%s\n", inc.Detected.Format(time.RFC3339), inc.Cid, inc.Kind, state)
+			afmt.Printf("\terror: %s\n", inc.Error)
+			if !inc.Repaired && inc.RepairError != "" {
+				afmt.Printf("\trefetch failed (%d attempts): %s\n", inc.RepairAttempts, inc.RepairError)
+			}
+		}
+		return nil
+	},
+}
+
+var ChainReplicaCmd = &cli.Command{
+	Name:  "replica",
+	Usage: "manage the replication of the chain to hot standby nodes",
+	Subcommands: []*cli.Command{
+		ChainReplicaStatusCmd,
+		ChainReplicaPromoteCmd,
+	},
+}
+
+var ChainReplicaStatusCmd = &cli.Command{
+	Name:  "status",
+	Usage: "print the replication role of the node, and its primary or standbys",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the status as json",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		afmt := NewAppFmt(cctx.App)
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		st, err := api.ChainReplicaStatus(ctx)
+		if err != nil {
+			return err
+		}
+
+		if cctx.Bool("json") {
+			out, err := json.MarshalIndent(st, "", "  ")
+			if err != nil {
+				return err
+			}
+			afmt.Println(string(out))
+			return nil
+		}
+
+		afmt.Printf("Role: %s\n", st.Role)
+		if st.Role == "standby" {
+			state := "disconnected"
+			if st.Connected {
+				state = "connected"
+			}
+			afmt.Printf("Primary: %s (%s)\n", st.Primary, state)
+			if !st.LastUpdate.IsZero() {
+				afmt.Printf("Primary head: %d %s\n", st.PrimaryHeight, st.PrimaryHead)
+				afmt.Printf("Last update: %s\n", st.LastUpdate.Format(time.RFC3339))
+			}
+		}
+
+		if len(st.Standbys) > 0 {
+			afmt.Printf("%d standbys\n", len(st.Standbys))
+			for _, sb := range st.Standbys {
+				afmt.Printf("%s\tsince %s\thead %d\n", sb.Peer, sb.Since.Format(time.RFC3339), sb.Height)
+			}
+		}
+		return nil
+	},
+}
+
+var ChainReplicaPromoteCmd = &cli.Command{
+	Name:  "promote",
+	Usage: "promote a hot standby to a primary, syncing from the network on its own",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "really-do-it",
+			Usage: "stop following the primary",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Bool("really-do-it") {
+			return xerrors.Errorf("pass --really-do-it to confirm the promotion, the standby can't follow its primary again until it's restarted")
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		if err := api.ChainReplicaPromote(ctx); err != nil {
+			return err
+		}
+		fmt.Println("Promoted to primary")
+		return nil
+	},
+}
+
+var ChainCachesCmd = &cli.Command{
+	Name:  "caches",
+	Usage: "inspect and resize the in-memory caches of the chain and state",
+	Subcommands: []*cli.Command{
+		ChainCachesListCmd,
+		ChainCachesResizeCmd,
+	},
+}
+
+var ChainCachesListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "list the caches with their sizes, hits and misses",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the caches as json",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		caches, err := api.ChainCacheStats(ctx)
+		if err != nil {
+			return err
+		}
+
+		if cctx.Bool("json") {
+			out, err := json.MarshalIndent(caches, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+			return nil
+		}
+
+		w := tabwriter.NewWriter(cctx.App.Writer, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(w, "Name\tSize\tBase Size\tEntries\tHits\tMisses\tHit Ratio\tEvictions\n")
+		for _, c := range caches {
+			ratio := "-"
+			if lookups := c.Hits + c.Misses; lookups > 0 {
+				ratio = fmt.Sprintf("%.1f%%", float64(c.Hits)*100/float64(lookups))
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%s\t%d\n", c.Name, c.Size, c.BaseSize, c.Entries, c.Hits, c.Misses, ratio, c.Evictions)
+		}
+		return w.Flush()
+	},
+}
+
+var ChainCachesResizeCmd = &cli.Command{
+	Name:      "resize",
+	Usage:     "set the size of a cache until the node restarts",
+	ArgsUsage: "[name size]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 2 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		size, err := strconv.Atoi(cctx.Args().Get(1))
+		if err != nil {
+			return xerrors.Errorf("parsing size: %w", err)
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		return api.ChainCacheResize(ctx, cctx.Args().Get(0), size)
+	},
+}
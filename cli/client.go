@@ -85,13 +85,22 @@ var clientCmd = &cli.Command{
 		WithCategory("storage", clientListAsksCmd),
 		WithCategory("storage", clientDealStatsCmd),
 		WithCategory("storage", clientInspectDealCmd),
+		WithCategory("storage", clientReplicateCmd),
+		WithCategory("storage", clientReplicationsCmd),
+		WithCategory("storage", clientProviderReputationCmd),
+		WithCategory("storage", clientProviderTagsCmd),
+		WithCategory("storage", clientDealTemplatesCmd),
 		WithCategory("data", clientImportCmd),
 		WithCategory("data", clientDropCmd),
 		WithCategory("data", clientLocalCmd),
 		WithCategory("data", clientStat),
+		WithCategory("data", clientPrepareDataCmd),
+		WithCategory("data", clientDataPrepsCmd),
+		WithCategory("data", clientAggregatePiecesCmd),
 		WithCategory("retrieval", clientFindCmd),
 		WithCategory("retrieval", clientQueryRetrievalAskCmd),
 		WithCategory("retrieval", clientRetrieveCmd),
+		WithCategory("retrieval", clientResumeRetrievalCmd),
 		WithCategory("retrieval", clientRetrieveCatCmd),
 		WithCategory("retrieval", clientRetrieveLsCmd),
 		WithCategory("retrieval", clientCancelRetrievalDealCmd),
@@ -316,7 +325,9 @@ price is measured in FIL/Epoch. Miners usually don't accept a bid
 lower than their advertised ask (which is in FIL/GiB/Epoch). You can check a miners listed price
 with 'lotus client query-ask <miner address>'.
 duration is how long the miner should store the data for, in blocks.
-The minimum value is 518400 (6 months).`,
+The minimum value is 518400 (6 months).
+With --template, the deal parameters not given are taken from the deal
+template, and duration can be left out.`,
 	ArgsUsage: "[dataCid miner price duration]",
 	Flags: []cli.Flag{
 		&cli.StringFlag{
@@ -354,6 +365,10 @@ The minimum value is 518400 (6 months).`,
 			Name:  "provider-collateral",
 			Usage: "specify the requested provider collateral the miner should put up",
 		},
+		&cli.StringFlag{
+			Name:  "template",
+			Usage: "name of the deal template to make the deal with, see 'lotus client deal-templates'",
+		},
 		&CidBaseFlag,
 	},
 	Action: func(cctx *cli.Context) error {
@@ -375,7 +390,8 @@ The minimum value is 518400 (6 months).`,
 		ctx := ReqContext(cctx)
 		afmt := NewAppFmt(cctx.App)
 
-		if cctx.NArg() != 4 {
+		tmpl := cctx.String("template")
+		if cctx.NArg() != 4 && (tmpl == "" || cctx.NArg() != 3) {
 			return IncorrectNumArgs(cctx)
 		}
 
@@ -396,9 +412,12 @@ The minimum value is 518400 (6 months).`,
 			return err
 		}
 
-		dur, err := strconv.ParseInt(cctx.Args().Get(3), 10, 32)
-		if err != nil {
-			return err
+		var dur int64
+		if cctx.NArg() == 4 {
+			dur, err = strconv.ParseInt(cctx.Args().Get(3), 10, 32)
+			if err != nil {
+				return err
+			}
 		}
 
 		var provCol big.Int
@@ -410,11 +429,14 @@ The minimum value is 518400 (6 months).`,
 			provCol = pc
 		}
 
-		if abi.ChainEpoch(dur) < build.MinDealDuration {
-			return xerrors.Errorf("minimum deal duration is %d blocks", build.MinDealDuration)
-		}
-		if abi.ChainEpoch(dur) > build.MaxDealDuration {
-			return xerrors.Errorf("maximum deal duration is %d blocks", build.MaxDealDuration)
+		// the duration of the template is checked by the node
+		if dur != 0 || tmpl == "" {
+			if abi.ChainEpoch(dur) < build.MinDealDuration {
+				return xerrors.Errorf("minimum deal duration is %d blocks", build.MinDealDuration)
+			}
+			if abi.ChainEpoch(dur) > build.MaxDealDuration {
+				return xerrors.Errorf("maximum deal duration is %d blocks", build.MaxDealDuration)
+			}
 		}
 
 		var a address.Address
@@ -486,6 +508,7 @@ The minimum value is 518400 (6 months).`,
 			FastRetrieval:      cctx.Bool("fast-retrieval"),
 			VerifiedDeal:       isVerified,
 			ProviderCollateral: provCol,
+			Template:           tmpl,
 		}
 
 		var proposal *cid.Cid
@@ -0,0 +1,205 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/google/uuid"
+	"github.com/ipfs/go-cid"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	lapi "github.com/filecoin-project/lotus/api"
+)
+
+var clientPrepareDataCmd = &cli.Command{
+	Name:  "prepare-data",
+	Usage: "Split a file into CAR files fitting into pieces, and import them",
+	Description: `Build the UnixFS DAGs of a file, split into CAR files which each fit into a piece
+of --piece-size, and import them. Each CAR can be used in a deal with its root, e.g.
+with 'lotus client deal'. The manifest of the prepared data, with the piece CID of each
+CAR, is kept by the node, see 'lotus client data-preps'.`,
+	ArgsUsage: "[inputPath]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "piece-size",
+			Usage: "size of the pieces the CAR files must fit into",
+			Value: "32GiB",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		size, err := units.RAMInBytes(cctx.String("piece-size"))
+		if err != nil {
+			return xerrors.Errorf("parsing piece size: %w", err)
+		}
+
+		absPath, err := filepath.Abs(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		m, err := api.ClientPrepareData(ctx, absPath, abi.PaddedPieceSize(size))
+		if err != nil {
+			return err
+		}
+		return printDataPrep(m)
+	},
+}
+
+var clientDataPrepsCmd = &cli.Command{
+	Name:      "data-preps",
+	Usage:     "Show the manifests of the data prepared with 'lotus client prepare-data'",
+	ArgsUsage: "[prepID]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() > 1 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		preps, err := api.ClientListDataPreps(ctx)
+		if err != nil {
+			return err
+		}
+
+		if cctx.Args().Present() {
+			id, err := uuid.Parse(cctx.Args().First())
+			if err != nil {
+				return xerrors.Errorf("parsing data preparation id: %w", err)
+			}
+
+			for _, m := range preps {
+				if m.ID == id {
+					return printDataPrep(&m)
+				}
+			}
+			return xerrors.Errorf("data preparation %s not found", id)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(w, "ID\tCreated\tSource\tSize\tPiece Size\tPieces\n")
+		for _, m := range preps {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\n", m.ID, m.Created.Format(time.RFC3339), m.Source,
+				units.BytesSize(float64(m.SourceSize)), units.BytesSize(float64(m.TargetPieceSize)), len(m.Pieces))
+		}
+		return w.Flush()
+	},
+}
+
+func printDataPrep(m *lapi.DataPrepManifest) error {
+	fmt.Printf("Data preparation: %s\n", m.ID)
+	fmt.Printf("Source: %s (%s)\n", m.Source, units.BytesSize(float64(m.SourceSize)))
+	fmt.Printf("Piece size: %s\n\n", units.BytesSize(float64(m.TargetPieceSize)))
+
+	w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "Import\tOffset\tLength\tRoot\tPiece CID\tPiece Size\tCAR Size\n")
+	for _, p := range m.Pieces {
+		_, _ = fmt.Fprintf(w, "%d\t%d\t%d\t%s\t%s\t%s\t%s\n", p.ImportID, p.Offset, p.Length, p.Root, p.PieceCid,
+			units.BytesSize(float64(p.PieceSize)), units.BytesSize(float64(p.CarSize)))
+	}
+	return w.Flush()
+}
+
+var clientAggregatePiecesCmd = &cli.Command{
+	Name:  "aggregate-pieces",
+	Usage: "Combine imported CARs into a single piece with a data segment index",
+	Description: `Lay out the imported CARs with the given roots into a single piece, with a data
+segment index (FRC-0058) at its end, and write the data of the aggregate to outputPath.
+The aggregate can be used in an offline deal with its piece CID, e.g. with
+'lotus client deal --manual-piece-cid'. The inclusion proof of each of the CARs in
+the aggregate is printed with --json.`,
+	ArgsUsage: "[outputPath] [root ...]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "deal-size",
+			Usage: "size of the aggregate, the smallest size fitting the pieces if not set",
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the aggregate, with the inclusion proofs, as json",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() < 2 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		var dealSize abi.PaddedPieceSize
+		if cctx.IsSet("deal-size") {
+			size, err := units.RAMInBytes(cctx.String("deal-size"))
+			if err != nil {
+				return xerrors.Errorf("parsing deal size: %w", err)
+			}
+			dealSize = abi.PaddedPieceSize(size)
+		}
+
+		outPath, err := filepath.Abs(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		var roots []cid.Cid
+		for _, s := range cctx.Args().Tail() {
+			c, err := cid.Parse(s)
+			if err != nil {
+				return xerrors.Errorf("parsing root %s: %w", s, err)
+			}
+			roots = append(roots, c)
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		agg, err := api.ClientAggregatePieces(ctx, roots, dealSize, outPath)
+		if err != nil {
+			return err
+		}
+
+		if cctx.Bool("json") {
+			out, err := json.MarshalIndent(agg, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+			return nil
+		}
+
+		fmt.Printf("Aggregate: %s\n", agg.Path)
+		fmt.Printf("Piece CID: %s\n", agg.PieceCid)
+		fmt.Printf("Piece size: %s\n\n", units.BytesSize(float64(agg.PieceSize)))
+
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(w, "Root\tPiece CID\tPiece Size\tOffset\n")
+		for _, s := range agg.Segments {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", s.Root, s.PieceCid, units.BytesSize(float64(s.PieceSize)), s.Offset)
+		}
+		return w.Flush()
+	},
+}
@@ -0,0 +1,403 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ipfs/go-cid"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-fil-markets/storagemarket"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	lapi "github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var clientReplicateCmd = &cli.Command{
+	Name:  "replicate",
+	Usage: "Make deals for data with several storage providers",
+	Description: `Make deals for the data with the number of storage providers given by --replicas.
+Providers are chosen among the --candidate providers, or the providers in the local
+reputation store and the providers of previous deals. The cheapest providers matching
+all the --tag tags, the minimum retrieval success rate and the minimum score are chosen,
+preferring the best scored providers at the same price.
+duration is how long the providers should store the data for, in blocks.`,
+	ArgsUsage: "[dataCid duration]",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "replicas",
+			Usage: "number of storage providers to make deals with",
+			Value: 3,
+		},
+		&cli.StringSliceFlag{
+			Name:  "candidate",
+			Usage: "storage provider to choose from, can be repeated",
+		},
+		&cli.StringSliceFlag{
+			Name:  "exclude",
+			Usage: "storage provider not to choose, can be repeated",
+		},
+		&cli.StringSliceFlag{
+			Name:  "tag",
+			Usage: "tag the storage providers must have, can be repeated",
+		},
+		&cli.Float64Flag{
+			Name:  "min-retrieval-success",
+			Usage: "minimum fraction of successful retrievals from the storage providers, between 0 and 1",
+		},
+		&cli.Float64Flag{
+			Name:  "min-score",
+			Usage: "minimum score of the storage providers, between 0 and 1, see 'lotus client provider-reputation scores'",
+		},
+		&cli.StringFlag{
+			Name:  "max-price",
+			Usage: "maximum deal price in FIL/Epoch",
+		},
+		&cli.StringFlag{
+			Name:  "from",
+			Usage: "specify address to fund the deals with",
+		},
+		&cli.Int64Flag{
+			Name:  "start-epoch",
+			Usage: "specify the epoch that the deals should start at",
+			Value: -1,
+		},
+		&cli.BoolFlag{
+			Name:  "fast-retrieval",
+			Usage: "indicates that data should be available for fast retrieval",
+			Value: true,
+		},
+		&cli.BoolFlag{
+			Name:  "verified-deal",
+			Usage: "indicate that the deals count towards verified client total",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 2 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		data, err := cid.Parse(cctx.Args().Get(0))
+		if err != nil {
+			return err
+		}
+
+		dur, err := strconv.ParseInt(cctx.Args().Get(1), 10, 32)
+		if err != nil {
+			return err
+		}
+		if abi.ChainEpoch(dur) < build.MinDealDuration {
+			return xerrors.Errorf("minimum deal duration is %d blocks", build.MinDealDuration)
+		}
+		if abi.ChainEpoch(dur) > build.MaxDealDuration {
+			return xerrors.Errorf("maximum deal duration is %d blocks", build.MaxDealDuration)
+		}
+
+		var maxPrice types.FIL
+		if mp := cctx.String("max-price"); mp != "" {
+			maxPrice, err = types.ParseFIL(mp)
+			if err != nil {
+				return xerrors.Errorf("parsing max price: %w", err)
+			}
+		}
+
+		var from address.Address
+		if f := cctx.String("from"); f != "" {
+			from, err = address.NewFromString(f)
+			if err != nil {
+				return xerrors.Errorf("failed to parse 'from' address: %w", err)
+			}
+		} else {
+			from, err = api.WalletDefaultAddress(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		parseAddrs := func(flag string) ([]address.Address, error) {
+			var out []address.Address
+			for _, s := range cctx.StringSlice(flag) {
+				a, err := address.NewFromString(s)
+				if err != nil {
+					return nil, xerrors.Errorf("parsing %s address %s: %w", flag, s, err)
+				}
+				out = append(out, a)
+			}
+			return out, nil
+		}
+
+		sel := lapi.ProviderSelector{
+			Tags:                    cctx.StringSlice("tag"),
+			MinRetrievalSuccessRate: cctx.Float64("min-retrieval-success"),
+			MinScore:                cctx.Float64("min-score"),
+		}
+		if sel.Candidates, err = parseAddrs("candidate"); err != nil {
+			return err
+		}
+		if sel.Exclude, err = parseAddrs("exclude"); err != nil {
+			return err
+		}
+
+		st, err := api.ClientReplicate(ctx, &lapi.StartDealParams{
+			Data: &storagemarket.DataRef{
+				TransferType: storagemarket.TTGraphsync,
+				Root:         data,
+			},
+			Wallet:            from,
+			EpochPrice:        types.BigInt(maxPrice),
+			MinBlocksDuration: uint64(dur),
+			DealStartEpoch:    abi.ChainEpoch(cctx.Int64("start-epoch")),
+			FastRetrieval:     cctx.Bool("fast-retrieval"),
+			VerifiedDeal:      cctx.Bool("verified-deal"),
+		}, cctx.Int("replicas"), sel)
+		if err != nil {
+			return err
+		}
+
+		return printReplication(st)
+	},
+}
+
+var clientReplicationsCmd = &cli.Command{
+	Name:      "replications",
+	Usage:     "Show the status of deals made with 'lotus client replicate'",
+	ArgsUsage: "[replicationID]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() > 1 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		if cctx.Args().Present() {
+			id, err := uuid.Parse(cctx.Args().First())
+			if err != nil {
+				return xerrors.Errorf("parsing replication id: %w", err)
+			}
+
+			st, err := api.ClientGetReplication(ctx, id)
+			if err != nil {
+				return err
+			}
+			return printReplication(st)
+		}
+
+		reps, err := api.ClientListReplications(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(w, "ID\tRoot\tCreated\tRequested\tActive\tIn Progress\tFailed\n")
+		for _, st := range reps {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\t%d\n", st.ID, st.Root, st.Created.Format(time.RFC3339), st.Requested, st.Active, st.InProgress, st.Failed)
+		}
+		return w.Flush()
+	},
+}
+
+func printReplication(st *lapi.ReplicationStatus) error {
+	fmt.Printf("Replication: %s\n", st.ID)
+	fmt.Printf("Root: %s\n", st.Root)
+	fmt.Printf("Requested: %d (active: %d, in progress: %d, failed: %d)\n\n", st.Requested, st.Active, st.InProgress, st.Failed)
+
+	w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "Provider\tProposal CID\tState\tMessage\n")
+	for _, d := range st.Deals {
+		prop, state := "-", "-"
+		if d.ProposalCid != nil {
+			prop = d.ProposalCid.String()
+			state = storagemarket.DealStates[d.State]
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", d.Miner, prop, state, d.Message)
+	}
+	return w.Flush()
+}
+
+var clientProviderReputationCmd = &cli.Command{
+	Name:  "provider-reputation",
+	Usage: "Manage the local records of the outcome of deals and retrievals with storage providers",
+	Subcommands: []*cli.Command{
+		clientProviderReputationListCmd,
+		clientProviderScoresCmd,
+		clientProviderReputationExportCmd,
+		clientProviderReputationImportCmd,
+	},
+}
+
+var clientProviderReputationListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "List the storage provider records",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		recs, err := api.ClientListProviderReputation(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(w, "Provider\tTags\tAccepted\tRejected\tSealed\tSealing Failed\tRetrievals\tRetrievals Failed\n")
+		for _, r := range recs {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\n", r.Miner, strings.Join(r.Tags, ","),
+				r.DealsAccepted, r.DealsRejected, r.SealingSuccesses, r.SealingFailures, r.RetrievalSuccesses, r.RetrievalFailures)
+		}
+		return w.Flush()
+	},
+}
+
+var clientProviderScoresCmd = &cli.Command{
+	Name:      "scores",
+	Usage:     "Score storage providers from their records, best first",
+	ArgsUsage: "[providers...]",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		var miners []address.Address
+		for _, s := range cctx.Args().Slice() {
+			m, err := address.NewFromString(s)
+			if err != nil {
+				return xerrors.Errorf("parsing provider address %s: %w", s, err)
+			}
+			miners = append(miners, m)
+		}
+
+		scores, err := api.ClientProviderScores(ctx, miners)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(w, "Provider\tScore\tAcceptance\tSealing\tRetrieval\tAvg Acceptance Latency\tAvg Retrieval Speed\n")
+		for _, sc := range scores {
+			speed := "-"
+			if sc.AvgRetrievalSpeed > 0 {
+				speed = types.SizeStr(types.NewInt(uint64(sc.AvgRetrievalSpeed))) + "/s"
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%.3f\t%.1f%%\t%.1f%%\t%.1f%%\t%s\t%s\n", sc.Miner, sc.Score,
+				sc.AcceptanceRate*100, sc.SealingSuccessRate*100, sc.RetrievalSuccessRate*100,
+				sc.AvgAcceptanceLatency.Truncate(time.Second), speed)
+		}
+		return w.Flush()
+	},
+}
+
+var clientProviderReputationExportCmd = &cli.Command{
+	Name:  "export",
+	Usage: "Export the storage provider records as JSON",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		recs, err := api.ClientListProviderReputation(ctx)
+		if err != nil {
+			return err
+		}
+
+		enc := json.NewEncoder(cctx.App.Writer)
+		enc.SetIndent("", "  ")
+		return enc.Encode(recs)
+	},
+}
+
+var clientProviderReputationImportCmd = &cli.Command{
+	Name:      "import",
+	Usage:     "Import storage provider records exported by another node",
+	ArgsUsage: "[file]",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "replace",
+			Usage: "replace the existing records instead of adding the imported outcomes to them",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		b, err := os.ReadFile(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		var recs []lapi.ProviderReputation
+		if err := json.Unmarshal(b, &recs); err != nil {
+			return xerrors.Errorf("parsing records: %w", err)
+		}
+
+		if err := api.ClientImportProviderReputation(ctx, recs, cctx.Bool("replace")); err != nil {
+			return err
+		}
+
+		fmt.Printf("imported %d records\n", len(recs))
+		return nil
+	},
+}
+
+var clientProviderTagsCmd = &cli.Command{
+	Name:      "provider-tags",
+	Usage:     "Set the tags of a storage provider, e.g. its region, replacing the existing ones",
+	ArgsUsage: "[provider tags...]",
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Args().Present() {
+			return IncorrectNumArgs(cctx)
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		miner, err := address.NewFromString(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("parsing provider address: %w", err)
+		}
+
+		return api.ClientSetProviderTags(ctx, miner, cctx.Args().Tail())
+	},
+}
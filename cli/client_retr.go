@@ -7,6 +7,7 @@ import (
 	"io"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -154,49 +155,8 @@ func retrieve(ctx context.Context, cctx *cli.Context, fapi lapi.FullNode, sel *l
 			return nil, xerrors.Errorf("error setting up retrieval: %w", err)
 		}
 
-		start := time.Now()
-	readEvents:
-		for {
-			var evt lapi.RetrievalInfo
-			select {
-			case <-ctx.Done():
-				return nil, xerrors.New("Retrieval Timed Out")
-			case evt = <-subscribeEvents:
-				if evt.ID != retrievalRes.DealID {
-					// we can't check the deal ID ahead of time because:
-					// 1. We need to subscribe before retrieving.
-					// 2. We won't know the deal ID until after retrieving.
-					continue
-				}
-			}
-
-			event := "New"
-			if evt.Event != nil {
-				event = retrievalmarket.ClientEvents[*evt.Event]
-			}
-
-			printf("Recv %s, Paid %s, %s (%s), %s [%d|%d]\n",
-				types.SizeStr(types.NewInt(evt.BytesReceived)),
-				types.FIL(evt.TotalPaid),
-				strings.TrimPrefix(event, "ClientEvent"),
-				strings.TrimPrefix(retrievalmarket.DealStatuses[evt.Status], "DealStatus"),
-				time.Now().Sub(start).Truncate(time.Millisecond),
-				evt.ID,
-				types.NewInt(evt.BytesReceived),
-			)
-
-			switch evt.Status {
-			case retrievalmarket.DealStatusCompleted:
-				break readEvents
-			case retrievalmarket.DealStatusRejected:
-				return nil, xerrors.Errorf("Retrieval Proposal Rejected: %s", evt.Message)
-			case retrievalmarket.DealStatusCancelled:
-				return nil, xerrors.Errorf("Retrieval Proposal Cancelled: %s", evt.Message)
-			case
-				retrievalmarket.DealStatusDealNotFound,
-				retrievalmarket.DealStatusErrored:
-				return nil, xerrors.Errorf("Retrieval Error: %s", evt.Message)
-			}
+		if err := waitRetrieval(ctx, subscribeEvents, retrievalRes.DealID, printf); err != nil {
+			return nil, err
 		}
 
 		eref = &lapi.ExportRef{
@@ -208,6 +168,53 @@ func retrieve(ctx context.Context, cctx *cli.Context, fapi lapi.FullNode, sel *l
 	return eref, nil
 }
 
+// waitRetrieval prints the updates of a retrieval deal until it completes
+func waitRetrieval(ctx context.Context, subscribeEvents <-chan lapi.RetrievalInfo, dealID retrievalmarket.DealID, printf func(string, ...interface{})) error {
+	start := time.Now()
+	for {
+		var evt lapi.RetrievalInfo
+		select {
+		case <-ctx.Done():
+			return xerrors.New("Retrieval Timed Out")
+		case evt = <-subscribeEvents:
+			if evt.ID != dealID {
+				// we can't check the deal ID ahead of time because:
+				// 1. We need to subscribe before retrieving.
+				// 2. We won't know the deal ID until after retrieving.
+				continue
+			}
+		}
+
+		event := "New"
+		if evt.Event != nil {
+			event = retrievalmarket.ClientEvents[*evt.Event]
+		}
+
+		printf("Recv %s, Paid %s, %s (%s), %s [%d|%d]\n",
+			types.SizeStr(types.NewInt(evt.BytesReceived)),
+			types.FIL(evt.TotalPaid),
+			strings.TrimPrefix(event, "ClientEvent"),
+			strings.TrimPrefix(retrievalmarket.DealStatuses[evt.Status], "DealStatus"),
+			time.Now().Sub(start).Truncate(time.Millisecond),
+			evt.ID,
+			types.NewInt(evt.BytesReceived),
+		)
+
+		switch evt.Status {
+		case retrievalmarket.DealStatusCompleted:
+			return nil
+		case retrievalmarket.DealStatusRejected:
+			return xerrors.Errorf("Retrieval Proposal Rejected: %s", evt.Message)
+		case retrievalmarket.DealStatusCancelled:
+			return xerrors.Errorf("Retrieval Proposal Cancelled: %s", evt.Message)
+		case
+			retrievalmarket.DealStatusDealNotFound,
+			retrievalmarket.DealStatusErrored:
+			return xerrors.Errorf("Retrieval Error: %s", evt.Message)
+		}
+	}
+}
+
 var retrFlagsCommon = []cli.Flag{
 	&cli.StringFlag{
 		Name:  "from",
@@ -331,6 +338,84 @@ Examples:
 	},
 }
 
+var clientResumeRetrievalCmd = &cli.Command{
+	Name:      "resume-retrieval",
+	Usage:     "Resume a failed retrieval",
+	ArgsUsage: "[dealID outputPath]",
+	Description: `Resume a failed retrieval from the last block it verified.
+
+The blocks received by the failed retrieval are kept, and a new retrieval deal
+is made with the same provider for the missing blocks only. Once the new deal
+completes the data is exported to outputPath.
+
+Only retrievals of whole DAGs made with the retrieve command can be resumed.`,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "car",
+			Usage: "Export to a car file instead of a regular file",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 2 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		dealID, err := strconv.ParseUint(cctx.Args().First(), 10, 64)
+		if err != nil {
+			return xerrors.Errorf("parsing deal ID: %w", err)
+		}
+
+		fapi, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+		afmt := NewAppFmt(cctx.App)
+
+		var root cid.Cid
+		retrievals, err := fapi.ClientListRetrievals(ctx)
+		if err != nil {
+			return xerrors.Errorf("listing retrievals: %w", err)
+		}
+		for _, r := range retrievals {
+			if r.ID == retrievalmarket.DealID(dealID) {
+				root = r.PayloadCID
+			}
+		}
+		if !root.Defined() {
+			return xerrors.Errorf("retrieval %d not found", dealID)
+		}
+
+		subscribeEvents, err := fapi.ClientGetRetrievalUpdates(ctx)
+		if err != nil {
+			return xerrors.Errorf("error setting up retrieval updates: %w", err)
+		}
+		res, err := fapi.ClientRetrievalResume(ctx, retrievalmarket.DealID(dealID))
+		if err != nil {
+			return xerrors.Errorf("resuming retrieval: %w", err)
+		}
+		afmt.Printf("Resuming retrieval %d with retrieval %d\n", dealID, res.DealID)
+
+		if err := waitRetrieval(ctx, subscribeEvents, res.DealID, afmt.Printf); err != nil {
+			return err
+		}
+
+		err = fapi.ClientExport(ctx, lapi.ExportRef{
+			Root:   root,
+			DealID: res.DealID,
+		}, lapi.FileRef{
+			Path:  cctx.Args().Get(1),
+			IsCAR: cctx.Bool("car"),
+		})
+		if err != nil {
+			return err
+		}
+		afmt.Println("Success")
+		return nil
+	},
+}
+
 var clientRetrieveCatCmd = &cli.Command{
 	Name:      "cat",
 	Usage:     "Show data from network",
@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	lapi "github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var clientDealTemplatesCmd = &cli.Command{
+	Name:  "deal-templates",
+	Usage: "Manage the templates deals can be made with, see 'lotus client deal --template'",
+	Subcommands: []*cli.Command{
+		clientDealTemplatesSetCmd,
+		clientDealTemplatesListCmd,
+		clientDealTemplatesRemoveCmd,
+	},
+}
+
+var clientDealTemplatesSetCmd = &cli.Command{
+	Name:      "set",
+	Usage:     "Create or replace a deal template",
+	ArgsUsage: "[name]",
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:  "duration",
+			Usage: "deal duration in blocks, used when the deal doesn't set one",
+		},
+		&cli.BoolFlag{
+			Name:  "verified-deal",
+			Usage: "make the deals verified",
+		},
+		&cli.BoolFlag{
+			Name:  "fast-retrieval",
+			Usage: "request an unsealed copy of the data from the provider",
+		},
+		&cli.StringFlag{
+			Name:  "max-price",
+			Usage: "highest price the deals can pay, in FIL per GiB per epoch",
+		},
+		&cli.StringFlag{
+			Name:  "min-provider-collateral",
+			Usage: "lowest provider collateral, in FIL, used when the deal doesn't set one",
+		},
+		&cli.StringFlag{
+			Name:  "max-provider-collateral",
+			Usage: "highest provider collateral, in FIL",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		tmpl := lapi.DealTemplate{
+			Name:          cctx.Args().First(),
+			Duration:      abi.ChainEpoch(cctx.Int64("duration")),
+			Verified:      cctx.Bool("verified-deal"),
+			FastRetrieval: cctx.Bool("fast-retrieval"),
+		}
+
+		for _, f := range []struct {
+			flag string
+			v    **types.BigInt
+		}{
+			{"max-price", &tmpl.MaxPricePerGiB},
+			{"min-provider-collateral", &tmpl.MinProviderCollateral},
+			{"max-provider-collateral", &tmpl.MaxProviderCollateral},
+		} {
+			if !cctx.IsSet(f.flag) {
+				continue
+			}
+			v, err := types.ParseFIL(cctx.String(f.flag))
+			if err != nil {
+				return xerrors.Errorf("parsing %s: %w", f.flag, err)
+			}
+			bi := types.BigInt(v)
+			*f.v = &bi
+		}
+
+		return api.ClientDealTemplateSet(ctx, tmpl)
+	},
+}
+
+var clientDealTemplatesListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "List the deal templates",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		tmpls, err := api.ClientDealTemplateList(ctx)
+		if err != nil {
+			return err
+		}
+
+		fil := func(v *types.BigInt) string {
+			if v == nil {
+				return "-"
+			}
+			return types.FIL(*v).String()
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(w, "Name\tDuration\tVerified\tFastRetrieval\tMax Price (FIL/GiB/Epoch)\tProvider Collateral\n")
+		for _, t := range tmpls {
+			dur := "-"
+			if t.Duration != 0 {
+				dur = fmt.Sprint(t.Duration)
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%t\t%t\t%s\t%s - %s\n", t.Name, dur, t.Verified, t.FastRetrieval,
+				fil(t.MaxPricePerGiB), fil(t.MinProviderCollateral), fil(t.MaxProviderCollateral))
+		}
+		return w.Flush()
+	},
+}
+
+var clientDealTemplatesRemoveCmd = &cli.Command{
+	Name:      "remove",
+	Usage:     "Remove a deal template",
+	ArgsUsage: "[name]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		return api.ClientDealTemplateRemove(ctx, cctx.Args().First())
+	},
+}
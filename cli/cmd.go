@@ -64,6 +64,7 @@ var CommonCommands = []*cli.Command{
 	FetchParamCmd,
 	PprofCmd,
 	VersionCmd,
+	DiskCmd,
 }
 
 var Commands = []*cli.Command{
@@ -85,6 +86,7 @@ var Commands = []*cli.Command{
 	WithCategory("network", NetCmd),
 	WithCategory("network", SyncCmd),
 	WithCategory("status", StatusCmd),
+	WithCategory("status", DiskCmd),
 	PprofCmd,
 	VersionCmd,
 }
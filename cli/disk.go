@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v2"
+
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/lib/tablewriter"
+)
+
+var DiskCmd = &cli.Command{
+	Name:  "disk",
+	Usage: "Inspect the disk usage of the node",
+	Subcommands: []*cli.Command{
+		DiskForecastCmd,
+	},
+}
+
+var DiskForecastCmd = &cli.Command{
+	Name:  "forecast",
+	Usage: "Show the growth of the node directories and when their disks will be full",
+	Flags: []cli.Flag{
+		&cli.DurationFlag{
+			Name:  "warn",
+			Usage: "highlight the directories forecast to be full within this duration",
+			Value: 7 * 24 * time.Hour,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		forecasts, err := api.DiskForecast(ctx)
+		if err != nil {
+			return err
+		}
+
+		tw := tablewriter.New(
+			tablewriter.Col("Name"),
+			tablewriter.Col("Used"),
+			tablewriter.Col("Available"),
+			tablewriter.Col("Growth/Day"),
+			tablewriter.Col("Full In"),
+			tablewriter.Col("Samples"),
+			tablewriter.NewLineCol("Path"),
+		)
+
+		for _, f := range forecasts {
+			fullIn := "-"
+			switch {
+			case f.Available <= 0:
+				fullIn = color.RedString("full")
+			case f.FullIn > 0:
+				fullIn = formatFullIn(f.FullIn)
+				if f.FullIn <= cctx.Duration("warn") {
+					fullIn = color.RedString(fullIn)
+				}
+			}
+
+			growth := types.SizeStr(types.NewInt(uint64(abs(f.GrowthRate))))
+			if f.GrowthRate < 0 {
+				growth = "-" + growth
+			}
+
+			tw.Write(map[string]interface{}{
+				"Name":       f.Name,
+				"Used":       types.SizeStr(types.NewInt(uint64(f.Used))),
+				"Available":  types.SizeStr(types.NewInt(uint64(f.Available))),
+				"Growth/Day": growth,
+				"Full In":    fullIn,
+				"Samples":    f.Samples,
+				"Path":       f.Path,
+			})
+		}
+
+		return tw.Flush(cctx.App.Writer)
+	},
+}
+
+func formatFullIn(d time.Duration) string {
+	if d < 48*time.Hour {
+		return d.Round(time.Minute).String()
+	}
+	return fmt.Sprintf("%.1f days", d.Hours()/24)
+}
+
+func abs(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -7,7 +7,10 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
+	"github.com/docker/go-units"
+	"github.com/ipfs/go-cid"
 	cbor "github.com/ipfs/go-ipld-cbor"
 	"github.com/urfave/cli/v2"
 	"golang.org/x/xerrors"
@@ -17,14 +20,17 @@ import (
 	actorstypes "github.com/filecoin-project/go-state-types/actors"
 	"github.com/filecoin-project/go-state-types/big"
 	verifregtypes8 "github.com/filecoin-project/go-state-types/builtin/v8/verifreg"
+	datacaptypes "github.com/filecoin-project/go-state-types/builtin/v9/datacap"
 	verifregtypes9 "github.com/filecoin-project/go-state-types/builtin/v9/verifreg"
 	"github.com/filecoin-project/go-state-types/network"
 
+	lapi "github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/api/v0api"
 	"github.com/filecoin-project/lotus/blockstore"
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/actors"
 	"github.com/filecoin-project/lotus/chain/actors/adt"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/datacap"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/verifreg"
 	"github.com/filecoin-project/lotus/chain/types"
@@ -46,6 +52,9 @@ var filplusCmd = &cli.Command{
 		filplusListClaimsCmd,
 		filplusRemoveExpiredAllocationsCmd,
 		filplusRemoveExpiredClaimsCmd,
+		filplusCreateAllocationCmd,
+		filplusClientAllocationsCmd,
+		filplusExtendClaimsCmd,
 	},
 }
 
@@ -799,3 +808,293 @@ var filplusSignRemoveDataCapProposal = &cli.Command{
 		return nil
 	},
 }
+
+var filplusCreateAllocationCmd = &cli.Command{
+	Name:      "create-allocation",
+	Usage:     "allocate datacap for a piece to be stored by one or more providers",
+	ArgsUsage: "pieceCid pieceSize",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "client",
+			Usage:    "verified client address to allocate datacap from",
+			Required: true,
+		},
+		&cli.StringSliceFlag{
+			Name:     "provider",
+			Usage:    "provider to store the piece, one allocation is created per provider",
+			Required: true,
+		},
+		&cli.Int64Flag{
+			Name:  "term-min",
+			Usage: "minimum term of the claim in epochs",
+			Value: verifregtypes9.MinimumVerifiedAllocationTerm,
+		},
+		&cli.Int64Flag{
+			Name:  "term-max",
+			Usage: "maximum term of the claim in epochs",
+			Value: verifregtypes9.MaximumVerifiedAllocationTerm,
+		},
+		&cli.Int64Flag{
+			Name:  "expiration",
+			Usage: "number of epochs from now in which the providers must claim the allocations",
+			Value: 30 * builtin.EpochsInDay,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 2 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		pieceCid, err := cid.Parse(cctx.Args().Get(0))
+		if err != nil {
+			return xerrors.Errorf("parsing piece cid: %w", err)
+		}
+
+		size, err := units.RAMInBytes(cctx.Args().Get(1))
+		if err != nil {
+			return xerrors.Errorf("parsing piece size: %w", err)
+		}
+		pieceSize := abi.PaddedPieceSize(size)
+		if err := pieceSize.Validate(); err != nil {
+			return xerrors.Errorf("invalid piece size: %w", err)
+		}
+
+		clientAddr, err := address.NewFromString(cctx.String("client"))
+		if err != nil {
+			return err
+		}
+
+		head, err := api.ChainHead(ctx)
+		if err != nil {
+			return err
+		}
+
+		var reqs []verifregtypes9.AllocationRequest
+		for _, p := range cctx.StringSlice("provider") {
+			paddr, err := address.NewFromString(p)
+			if err != nil {
+				return err
+			}
+			pid, err := api.StateLookupID(ctx, paddr, types.EmptyTSK)
+			if err != nil {
+				return xerrors.Errorf("looking up provider %s: %w", paddr, err)
+			}
+			id, err := address.IDFromAddress(pid)
+			if err != nil {
+				return err
+			}
+
+			reqs = append(reqs, verifregtypes9.AllocationRequest{
+				Provider:   abi.ActorID(id),
+				Data:       pieceCid,
+				Size:       pieceSize,
+				TermMin:    abi.ChainEpoch(cctx.Int64("term-min")),
+				TermMax:    abi.ChainEpoch(cctx.Int64("term-max")),
+				Expiration: head.Height() + abi.ChainEpoch(cctx.Int64("expiration")),
+			})
+		}
+
+		mcid, err := api.ClientCreateAllocations(ctx, clientAddr, reqs)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("message sent, now waiting on cid: %s\n", mcid)
+
+		mwait, err := api.StateWaitMsg(ctx, mcid, build.MessageConfidence, abi.ChainEpoch(-1), true)
+		if err != nil {
+			return err
+		}
+
+		if mwait.Receipt.ExitCode.IsError() {
+			return fmt.Errorf("failed to create allocations: %d", mwait.Receipt.ExitCode)
+		}
+
+		var ret datacaptypes.TransferReturn
+		if err := ret.UnmarshalCBOR(bytes.NewReader(mwait.Receipt.Return)); err != nil {
+			return xerrors.Errorf("decoding transfer return: %w", err)
+		}
+		var resp verifregtypes9.AllocationsResponse
+		if err := resp.UnmarshalCBOR(bytes.NewReader(ret.RecipientData)); err != nil {
+			return xerrors.Errorf("decoding allocations response: %w", err)
+		}
+
+		for _, id := range resp.NewAllocations {
+			fmt.Printf("created allocation %d\n", id)
+		}
+		for _, fc := range resp.AllocationResults.FailCodes {
+			fmt.Printf("allocation to %s failed: %d\n", cctx.StringSlice("provider")[fc.Idx], fc.Code)
+		}
+		return nil
+	},
+}
+
+var filplusClientAllocationsCmd = &cli.Command{
+	Name:      "client-allocations",
+	Usage:     "List the allocations of a client, with the claims made with them",
+	ArgsUsage: "clientAddress",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		clientAddr, err := address.NewFromString(cctx.Args().Get(0))
+		if err != nil {
+			return err
+		}
+
+		infos, err := api.ClientListAllocations(ctx, clientAddr)
+		if err != nil {
+			return err
+		}
+
+		tw := tablewriter.New(
+			tablewriter.Col("ID"),
+			tablewriter.Col("State"),
+			tablewriter.Col("Provider"),
+			tablewriter.Col("Data"),
+			tablewriter.Col("Size"),
+			tablewriter.Col("TermMin"),
+			tablewriter.Col("TermMax"),
+			tablewriter.Col("Expiration"),
+			tablewriter.Col("TermStart"),
+			tablewriter.Col("Sector"),
+		)
+
+		for _, info := range infos {
+			row := map[string]interface{}{
+				"ID":       info.ID,
+				"State":    info.State,
+				"Provider": info.Provider,
+				"Data":     info.Data,
+				"Size":     info.Size,
+				"TermMin":  info.TermMin,
+				"TermMax":  info.TermMax,
+			}
+			switch info.State {
+			case lapi.AllocationClaimed, lapi.AllocationClaimExpired:
+				row["TermStart"] = info.TermStart
+				row["Sector"] = info.Sector
+			default:
+				row["Expiration"] = info.Expiration
+			}
+			tw.Write(row)
+		}
+		return tw.Flush(os.Stdout)
+	},
+}
+
+var filplusExtendClaimsCmd = &cli.Command{
+	Name:      "extend-claims",
+	Usage:     "extend the term of claims made with the allocations of a client (if no claims are specified all the claims of the client are extended)",
+	ArgsUsage: "clientAddress Optional[...claimId]",
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:  "term-max",
+			Usage: "new maximum term of the claims in epochs, from the start of the claim",
+			Value: verifregtypes9.MaximumVerifiedAllocationTerm,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() < 1 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		clientAddr, err := address.NewFromString(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		claimIDs := map[verifregtypes9.ClaimId]struct{}{}
+		for _, s := range cctx.Args().Slice()[1:] {
+			id, err := strconv.ParseUint(s, 10, 64)
+			if err != nil {
+				return err
+			}
+			claimIDs[verifregtypes9.ClaimId(id)] = struct{}{}
+		}
+
+		infos, err := api.ClientListAllocations(ctx, clientAddr)
+		if err != nil {
+			return err
+		}
+
+		all := len(claimIDs) == 0
+		termMax := abi.ChainEpoch(cctx.Int64("term-max"))
+		var terms []verifregtypes9.ClaimTerm
+		for _, info := range infos {
+			id := verifregtypes9.ClaimId(info.ID)
+			if _, ok := claimIDs[id]; !all && !ok {
+				continue
+			}
+			delete(claimIDs, id)
+			if info.State != lapi.AllocationClaimed || info.TermMax >= termMax {
+				continue
+			}
+			terms = append(terms, verifregtypes9.ClaimTerm{
+				Provider: info.Provider,
+				ClaimId:  id,
+				TermMax:  termMax,
+			})
+		}
+		if len(claimIDs) > 0 {
+			missing := make([]string, 0, len(claimIDs))
+			for id := range claimIDs {
+				missing = append(missing, fmt.Sprint(id))
+			}
+			return xerrors.Errorf("claims not found: %s", strings.Join(missing, ", "))
+		}
+		if len(terms) == 0 {
+			fmt.Println("no claim to extend")
+			return nil
+		}
+
+		mcid, err := api.ClientExtendClaims(ctx, clientAddr, terms)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("message sent, now waiting on cid: %s\n", mcid)
+
+		mwait, err := api.StateWaitMsg(ctx, mcid, build.MessageConfidence, abi.ChainEpoch(-1), true)
+		if err != nil {
+			return err
+		}
+
+		if mwait.Receipt.ExitCode.IsError() {
+			return fmt.Errorf("failed to extend claims: %d", mwait.Receipt.ExitCode)
+		}
+
+		var ret verifregtypes9.BatchReturn
+		if err := ret.UnmarshalCBOR(bytes.NewReader(mwait.Receipt.Return)); err != nil {
+			return xerrors.Errorf("decoding extend claim terms return: %w", err)
+		}
+
+		fmt.Printf("extended %d claims\n", ret.SuccessCount)
+		for _, fc := range ret.FailCodes {
+			fmt.Printf("extending claim %d failed: %d\n", terms[fc.Idx].ClaimId, fc.Code)
+		}
+		return nil
+	},
+}
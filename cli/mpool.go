@@ -6,6 +6,7 @@ import (
 	stdbig "math/big"
 	"sort"
 	"strconv"
+	"text/tabwriter"
 
 	"github.com/ipfs/go-cid"
 	"github.com/urfave/cli/v2"
@@ -17,9 +18,7 @@ import (
 
 	lapi "github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/build"
-	"github.com/filecoin-project/lotus/chain/messagepool"
 	"github.com/filecoin-project/lotus/chain/types"
-	"github.com/filecoin-project/lotus/node/config"
 )
 
 var MpoolCmd = &cli.Command{
@@ -34,6 +33,8 @@ var MpoolCmd = &cli.Command{
 		MpoolFindCmd,
 		MpoolConfig,
 		MpoolGasPerfCmd,
+		MpoolSelectPreviewCmd,
+		MpoolGasMarketCmd,
 		mpoolManage,
 	},
 }
@@ -436,6 +437,35 @@ var MpoolReplaceCmd = &cli.Command{
 			return cli.ShowCommandHelp(cctx, cctx.Command.Name)
 		}
 
+		if cctx.Bool("auto") {
+			v1api, closer, err := GetFullNodeAPIV1(cctx)
+			if err != nil {
+				return err
+			}
+			defer closer()
+
+			spec := lapi.MpoolReplaceSpec{
+				From:  from,
+				Nonce: nonce,
+			}
+			if cctx.IsSet("fee-limit") {
+				maxFee, err := types.ParseFIL(cctx.String("fee-limit"))
+				if err != nil {
+					return xerrors.Errorf("parsing max-spend: %w", err)
+				}
+				spec.MaxFee = abi.TokenAmount(maxFee)
+			}
+
+			res, err := v1api.MpoolReplace(ctx, spec)
+			if err != nil {
+				return xerrors.Errorf("replacing message: %w", err)
+			}
+
+			afmt.Printf("old message cid: %s, gas premium: %s, gas feecap: %s, max fee: %s\n", res.Old.Cid, res.Old.GasPremium, res.Old.GasFeeCap, types.FIL(res.Old.MaxFee))
+			afmt.Printf("new message cid: %s, gas premium: %s, gas feecap: %s, max fee: %s\n", res.New.Cid, res.New.GasPremium, res.New.GasFeeCap, types.FIL(res.New.MaxFee))
+			return nil
+		}
+
 		ts, err := api.ChainHead(ctx)
 		if err != nil {
 			return xerrors.Errorf("getting chain head: %w", err)
@@ -460,54 +490,17 @@ var MpoolReplaceCmd = &cli.Command{
 
 		msg := found.Message
 
-		if cctx.Bool("auto") {
-			cfg, err := api.MpoolGetConfig(ctx)
-			if err != nil {
-				return xerrors.Errorf("failed to lookup the message pool config: %w", err)
-			}
-
-			defaultRBF := messagepool.ComputeRBF(msg.GasPremium, cfg.ReplaceByFeeRatio)
-
-			var mss *lapi.MessageSendSpec
-			if cctx.IsSet("fee-limit") {
-				maxFee, err := types.ParseFIL(cctx.String("fee-limit"))
-				if err != nil {
-					return xerrors.Errorf("parsing max-spend: %w", err)
-				}
-				mss = &lapi.MessageSendSpec{
-					MaxFee: abi.TokenAmount(maxFee),
-				}
-			}
-
-			// msg.GasLimit = 0 // TODO: need to fix the way we estimate gas limits to account for the messages already being in the mempool
-			msg.GasFeeCap = abi.NewTokenAmount(0)
-			msg.GasPremium = abi.NewTokenAmount(0)
-			retm, err := api.GasEstimateMessageGas(ctx, &msg, mss, types.EmptyTSK)
-			if err != nil {
-				return xerrors.Errorf("failed to estimate gas values: %w", err)
-			}
-
-			msg.GasPremium = big.Max(retm.GasPremium, defaultRBF)
-			msg.GasFeeCap = big.Max(retm.GasFeeCap, msg.GasPremium)
-
-			mff := func() (abi.TokenAmount, error) {
-				return abi.TokenAmount(config.DefaultDefaultMaxFee), nil
-			}
-
-			messagepool.CapGasFee(mff, &msg, mss)
-		} else {
-			if cctx.IsSet("gas-limit") {
-				msg.GasLimit = cctx.Int64("gas-limit")
-			}
-			msg.GasPremium, err = types.BigFromString(cctx.String("gas-premium"))
-			if err != nil {
-				return xerrors.Errorf("parsing gas-premium: %w", err)
-			}
-			// TODO: estimate fee cap here
-			msg.GasFeeCap, err = types.BigFromString(cctx.String("gas-feecap"))
-			if err != nil {
-				return xerrors.Errorf("parsing gas-feecap: %w", err)
-			}
+		if cctx.IsSet("gas-limit") {
+			msg.GasLimit = cctx.Int64("gas-limit")
+		}
+		msg.GasPremium, err = types.BigFromString(cctx.String("gas-premium"))
+		if err != nil {
+			return xerrors.Errorf("parsing gas-premium: %w", err)
+		}
+		// TODO: estimate fee cap here
+		msg.GasFeeCap, err = types.BigFromString(cctx.String("gas-feecap"))
+		if err != nil {
+			return xerrors.Errorf("parsing gas-feecap: %w", err)
 		}
 
 		smsg, err := api.WalletSignMessage(ctx, msg.From, &msg)
@@ -741,3 +734,157 @@ var MpoolGasPerfCmd = &cli.Command{
 		return nil
 	},
 }
+
+var MpoolSelectPreviewCmd = &cli.Command{
+	Name:  "select-preview",
+	Usage: "Preview the messages a miner would select for a block on the chain head, and why the others were left out",
+	Flags: []cli.Flag{
+		&cli.Float64Flag{
+			Name:  "ticket-quality",
+			Usage: "quality of the winning ticket; above 0.84 the greedy selection is used",
+			Value: 1,
+		},
+		&cli.BoolFlag{
+			Name:  "local",
+			Usage: "only print messages from addresses in the local wallet",
+		},
+		&cli.StringFlag{
+			Name:  "from",
+			Usage: "only print messages from the given address",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		afmt := NewAppFmt(cctx.App)
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		var filter map[address.Address]struct{}
+		if froms := cctx.String("from"); froms != "" {
+			a, err := address.NewFromString(froms)
+			if err != nil {
+				return xerrors.Errorf("given 'from' address %q was invalid: %w", froms, err)
+			}
+			filter = map[address.Address]struct{}{a: {}}
+		}
+		if cctx.Bool("local") {
+			if filter == nil {
+				filter = map[address.Address]struct{}{}
+			}
+
+			addrss, err := api.WalletList(ctx)
+			if err != nil {
+				return xerrors.Errorf("getting local addresses: %w", err)
+			}
+
+			for _, a := range addrss {
+				filter[a] = struct{}{}
+			}
+		}
+		show := func(from address.Address) bool {
+			if filter == nil {
+				return true
+			}
+			_, has := filter[from]
+			return has
+		}
+
+		preview, err := api.MpoolSelectPreview(ctx, types.EmptyTSK, cctx.Float64("ticket-quality"))
+		if err != nil {
+			return err
+		}
+
+		afmt.Printf("Strategy: %s\n", preview.Strategy)
+		afmt.Printf("Base fee: %s\n", types.FIL(preview.BaseFee))
+		afmt.Printf("Selected: %d messages, gas limit %d, reward %s\n", len(preview.Selected), preview.GasLimit, types.FIL(preview.Reward))
+		afmt.Printf("Excluded: %d messages\n", len(preview.Excluded))
+
+		afmt.Println("\nSelected messages:")
+		for i, m := range preview.Selected {
+			if !show(m.Message.Message.From) {
+				continue
+			}
+			afmt.Printf("%d\t%s\t%s\t%d\t%s\t%f\n", i, m.Cid, m.Message.Message.From, m.Message.Message.Nonce, types.FIL(m.Reward), m.GasPerf)
+		}
+
+		afmt.Println("\nExcluded messages:")
+		for _, m := range preview.Excluded {
+			if !show(m.From) {
+				continue
+			}
+			afmt.Printf("%s\t%s\t%d\t%s\t%f\t%s\n", m.Cid, m.From, m.Nonce, types.FIL(m.Reward), m.GasPerf, m.Reason)
+		}
+
+		return nil
+	},
+}
+
+var MpoolGasMarketCmd = &cli.Command{
+	Name:  "gas-market",
+	Usage: "Show the gas premiums offered by the pending messages, and paid in the recent tipsets",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "lookback",
+			Usage: "number of recent tipsets to summarize",
+			Value: 10,
+		},
+		&cli.BoolFlag{
+			Name:  "watch",
+			Usage: "print the summary again on each new head",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		if !cctx.Bool("watch") {
+			gm, err := api.MpoolGasMarket(ctx, cctx.Int("lookback"))
+			if err != nil {
+				return err
+			}
+			return printGasMarket(cctx, gm)
+		}
+
+		updates, err := api.MpoolGasMarketSub(ctx, cctx.Int("lookback"))
+		if err != nil {
+			return err
+		}
+		for gm := range updates {
+			gm := gm
+			if err := printGasMarket(cctx, &gm); err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintln(cctx.App.Writer)
+		}
+		return nil
+	},
+}
+
+func printGasMarket(cctx *cli.Context, gm *lapi.MpoolGasMarket) error {
+	afmt := NewAppFmt(cctx.App)
+
+	afmt.Printf("Head: %d, next base fee: %s\n", gm.Height, gm.BaseFee)
+	afmt.Printf("Pending: %d messages, gas limit %d, %d with a fee cap below the base fee\n\n", gm.Pending.Messages, gm.Pending.GasLimit, gm.Underpriced)
+
+	w := tabwriter.NewWriter(cctx.App.Writer, 2, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "Height\tBase Fee\tMessages\tGas Limit\tMin\tP25\tMedian\tP75\tP90\tMax\n")
+	row := func(height string, baseFee abi.TokenAmount, d lapi.GasPremiumDistribution) {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\t%s\t%s\t%s\t%s\t%s\n", height, baseFee, d.Messages, d.GasLimit,
+			d.Min, d.P25, d.Median, d.P75, d.P90, d.Max)
+	}
+	row("pending", gm.BaseFee, gm.Pending)
+	for _, e := range gm.Included {
+		row(strconv.FormatInt(int64(e.Height), 10), e.BaseFee, e.Premiums)
+	}
+	return w.Flush()
+}
@@ -7,6 +7,7 @@ import (
 	"math"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 	"time"
@@ -39,6 +40,7 @@ var NetCmd = &cli.Command{
 		NetId,
 		NetFindPeer,
 		NetScores,
+		NetPubsubRejectionsCmd,
 		NetReachability,
 		NetBandwidthCmd,
 		NetBlockCmd,
@@ -47,6 +49,10 @@ var NetCmd = &cli.Command{
 		NetProtectAdd,
 		NetProtectRemove,
 		NetProtectList,
+		NetAllowCmd,
+		NetPeerScoresCmd,
+		NetTagCmd,
+		NetReconnectPolicyCmd,
 	},
 }
 
@@ -240,6 +246,69 @@ var NetScores = &cli.Command{
 	},
 }
 
+var NetPubsubRejectionsCmd = &cli.Command{
+	Name:  "pubsub-rejections",
+	Usage: "Print rejected pubsub messages by topic, reason and peer",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "samples",
+			Usage: "print the most recently rejected messages in json",
+		},
+		&cli.StringFlag{
+			Name:  "topic",
+			Usage: "only print samples of the given topic",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		if cctx.Bool("samples") {
+			samples, err := api.NetPubsubRejectedMessages(ctx, cctx.String("topic"))
+			if err != nil {
+				return err
+			}
+
+			enc := json.NewEncoder(os.Stdout)
+			for _, sample := range samples {
+				if err := enc.Encode(sample); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		rejections, err := api.NetPubsubRejections(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range rejections {
+			fmt.Printf("%s: %d rejected\n", r.Topic, r.Total)
+
+			reasons := make([]string, 0, len(r.Reasons))
+			for reason := range r.Reasons {
+				reasons = append(reasons, reason)
+			}
+			sort.Strings(reasons)
+			for _, reason := range reasons {
+				fmt.Printf("\t%s: %d\n", reason, r.Reasons[reason])
+			}
+
+			fmt.Println("\tTop peers:")
+			for _, p := range r.TopPeers {
+				fmt.Printf("\t\t%s: %d\n", p.ID, p.Rejected)
+			}
+		}
+
+		return nil
+	},
+}
+
 var NetListen = &cli.Command{
 	Name:  "listen",
 	Usage: "List listen addresses",
@@ -448,6 +517,31 @@ var NetReachability = &cli.Command{
 		if i.PublicAddr != "" {
 			fmt.Println("Public address: ", i.PublicAddr)
 		}
+
+		if len(i.Relays) > 0 {
+			fmt.Println("Relays:")
+			for _, r := range i.Relays {
+				fmt.Printf("\t%s\n", r)
+			}
+			fmt.Println("Relayed addresses:")
+			for _, a := range i.RelayAddrs {
+				fmt.Printf("\t%s\n", a)
+			}
+		}
+
+		if i.HolePunch.Enabled {
+			fmt.Printf("Hole punching: %d attempts, %d succeeded, %d failed\n", i.HolePunch.Attempts, i.HolePunch.Successes, i.HolePunch.Failures)
+			if i.HolePunch.LastError != "" {
+				fmt.Println("Last hole punch error: ", i.HolePunch.LastError)
+			}
+		}
+
+		if len(i.ReachabilityHistory) > 0 {
+			fmt.Println("Reachability history:")
+			for _, c := range i.ReachabilityHistory {
+				fmt.Printf("\t%s %s\n", c.Time.Format(time.RFC3339), c.Reachability)
+			}
+		}
 		return nil
 	},
 }
@@ -1026,3 +1120,222 @@ var NetProtectList = &cli.Command{
 		return nil
 	},
 }
+
+var NetAllowCmd = &cli.Command{
+	Name:  "allow",
+	Usage: "Manage the persistent list of allowed peers, which are protected and reconnected to",
+	Subcommands: []*cli.Command{
+		NetAllowAddCmd,
+		NetAllowRemoveCmd,
+		NetAllowListCmd,
+	},
+}
+
+var NetAllowAddCmd = &cli.Command{
+	Name:      "add",
+	Usage:     "Add peers to the allow-list",
+	ArgsUsage: "[peerMultiaddr|minerActorAddress]",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		pis, err := AddrInfoFromArg(ctx, cctx)
+		if err != nil {
+			return err
+		}
+
+		if err := api.NetAllowAdd(ctx, pis); err != nil {
+			return err
+		}
+
+		fmt.Println("added to allowed peers:")
+		for _, pi := range pis {
+			fmt.Printf(" %s\n", pi.ID)
+		}
+		return nil
+	},
+}
+
+var NetAllowRemoveCmd = &cli.Command{
+	Name:      "remove",
+	Usage:     "Remove peers from the allow-list",
+	ArgsUsage: "<peer-id> [<peer-id>...]",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		pids, err := decodePeerIDsFromArgs(cctx)
+		if err != nil {
+			return err
+		}
+
+		if err := api.NetAllowRemove(ctx, pids); err != nil {
+			return err
+		}
+
+		fmt.Println("removed from allowed peers:")
+		for _, pid := range pids {
+			fmt.Printf(" %s\n", pid)
+		}
+		return nil
+	},
+}
+
+var NetAllowListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "List allowed peers",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		pis, err := api.NetAllowList(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, pi := range pis {
+			connectedness, err := api.NetConnectedness(ctx, pi.ID)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s, %s, %s\n", pi.ID, connectedness, pi.Addrs)
+		}
+		return nil
+	},
+}
+
+var NetPeerScoresCmd = &cli.Command{
+	Name:  "peer-scores",
+	Usage: "Print connected peers' connection manager values and pubsub scores",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:    "extended",
+			Aliases: []string{"x"},
+			Usage:   "print peer scores with their tags in json",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		scores, err := api.NetPeerScores(ctx)
+		if err != nil {
+			return err
+		}
+
+		if cctx.Bool("extended") {
+			enc := json.NewEncoder(os.Stdout)
+			for _, score := range scores {
+				if err := enc.Encode(score); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 4, 0, 2, ' ', 0)
+		fmt.Fprintf(tw, "Peer\tValue\tPubsub\tProtected\n")
+		for _, score := range scores {
+			fmt.Fprintf(tw, "%s\t%d\t%f\t%t\n", score.ID, score.ConnMgrValue, score.PubsubScore, score.Protected)
+		}
+		return tw.Flush()
+	},
+}
+
+var NetTagCmd = &cli.Command{
+	Name:      "tag",
+	Usage:     "Set a connection manager tag on a peer, a value of 0 removes the tag",
+	ArgsUsage: "<peer-id> <tag> <value>",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 3 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		api, closer, err := GetAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		pid, err := peer.Decode(cctx.Args().Get(0))
+		if err != nil {
+			return xerrors.Errorf("parsing peer id: %w", err)
+		}
+
+		value, err := strconv.Atoi(cctx.Args().Get(2))
+		if err != nil {
+			return xerrors.Errorf("parsing tag value: %w", err)
+		}
+
+		return api.NetSetPeerTag(ctx, pid, cctx.Args().Get(1), value)
+	},
+}
+
+var NetReconnectPolicyCmd = &cli.Command{
+	Name:  "reconnect-policy",
+	Usage: "Get or set the policy for reconnecting to allowed peers",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "enable",
+			Usage: "enable or disable reconnecting",
+		},
+		&cli.DurationFlag{
+			Name:  "min-backoff",
+			Usage: "delay before retrying after a failed attempt",
+		},
+		&cli.DurationFlag{
+			Name:  "max-backoff",
+			Usage: "maximum delay between attempts",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		policy, err := api.NetGetReconnectPolicy(ctx)
+		if err != nil {
+			return err
+		}
+
+		if cctx.IsSet("enable") || cctx.IsSet("min-backoff") || cctx.IsSet("max-backoff") {
+			if cctx.IsSet("enable") {
+				policy.Enabled = cctx.Bool("enable")
+			}
+			if cctx.IsSet("min-backoff") {
+				policy.MinBackoff = cctx.Duration("min-backoff")
+			}
+			if cctx.IsSet("max-backoff") {
+				policy.MaxBackoff = cctx.Duration("max-backoff")
+			}
+
+			if err := api.NetSetReconnectPolicy(ctx, policy); err != nil {
+				return err
+			}
+		}
+
+		fmt.Printf("Enabled: %t\n", policy.Enabled)
+		fmt.Printf("Backoff: %s - %s\n", policy.MinBackoff, policy.MaxBackoff)
+		return nil
+	},
+}
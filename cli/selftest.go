@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/lib/tablewriter"
+)
+
+// PrintSelfTestReport writes a self-test report as a table of checks.
+func PrintSelfTestReport(w io.Writer, r *api.SelfTestReport) error {
+	tw := tablewriter.New(
+		tablewriter.Col("Check"),
+		tablewriter.Col("Result"),
+		tablewriter.Col("Took"),
+		tablewriter.NewLineCol("Message"),
+	)
+
+	for _, c := range r.Checks {
+		res := string(c.Result)
+		switch c.Result {
+		case api.SelfTestPass:
+			res = color.GreenString(res)
+		case api.SelfTestWarn:
+			res = color.YellowString(res)
+		case api.SelfTestFail:
+			res = color.RedString(res)
+		}
+
+		tw.Write(map[string]interface{}{
+			"Check":   c.Name,
+			"Result":  res,
+			"Took":    c.Duration.Round(time.Millisecond),
+			"Message": c.Message,
+		})
+	}
+
+	if err := tw.Flush(w); err != nil {
+		return err
+	}
+
+	if r.Passed {
+		_, err := fmt.Fprintln(w, "\nself-test passed")
+		return err
+	}
+	_, err := fmt.Fprintln(w, "\nself-test failed")
+	return err
+}
@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+// PrintShutdownInFlight prints the tasks which the node will wait for when
+// shutting down.
+func PrintShutdownInFlight(ctx context.Context, w io.Writer, a api.Common) error {
+	st, err := a.ShutdownStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(st.InFlight) == 0 {
+		return nil
+	}
+
+	_, _ = fmt.Fprintf(w, "Waiting for %d in-flight tasks to shut down:\n", len(st.InFlight))
+	for _, t := range st.InFlight {
+		running := ""
+		if !t.Started.IsZero() {
+			running = fmt.Sprintf(" (running for %s)", time.Since(t.Started).Round(time.Second))
+		}
+		_, _ = fmt.Fprintf(w, "  %s: %s %s%s\n", t.Component, t.Kind, t.Description, running)
+	}
+	return nil
+}
@@ -81,6 +81,10 @@ var StateCmd = &cli.Command{
 		StateNtwkVersionCmd,
 		StateMinerProvingDeadlineCmd,
 		StateSysActorCIDsCmd,
+		StateGasStatsCmd,
+		StatePowerHistoryCmd,
+		StateReplayEventsCmd,
+		StateMigrationDryRunCmd,
 	},
 }
 
@@ -938,6 +942,10 @@ var StateListMessagesCmd = &cli.Command{
 			Name:  "cids",
 			Usage: "print message CIDs instead of messages",
 		},
+		&cli.BoolFlag{
+			Name:  "index",
+			Usage: "list the messages from the address index of the node instead of walking the chain, requires Index.EnableAddressIndex",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
 		api, closer, err := GetFullNodeAPI(cctx)
@@ -972,6 +980,10 @@ var StateListMessagesCmd = &cli.Command{
 			return err
 		}
 
+		if cctx.Bool("index") {
+			return listMessagesFromIndex(cctx, toa, froma, ts.Height(), toh)
+		}
+
 		windowSize := abi.ChainEpoch(100)
 
 		cur := ts
@@ -1557,13 +1569,17 @@ var StateCallCmd = &cli.Command{
 			Value: "base64",
 			Usage: "specify params encoding to parse (base64, hex)",
 		},
+		&cli.StringFlag{
+			Name:  "overrides",
+			Usage: "path to a JSON file with a list of actor overrides to apply to the state before the call",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
 		if cctx.NArg() < 2 {
 			return ShowHelp(cctx, fmt.Errorf("must specify at least actor and method to invoke"))
 		}
 
-		api, closer, err := GetFullNodeAPI(cctx)
+		api, closer, err := GetFullNodeAPIV1(cctx)
 		if err != nil {
 			return err
 		}
@@ -1615,13 +1631,31 @@ var StateCallCmd = &cli.Command{
 			}
 		}
 
-		ret, err := api.StateCall(ctx, &types.Message{
+		var overrides []lapi.ActorOverride
+		if path := cctx.String("overrides"); path != "" {
+			b, err := os.ReadFile(path)
+			if err != nil {
+				return xerrors.Errorf("reading overrides: %w", err)
+			}
+			if err := json.Unmarshal(b, &overrides); err != nil {
+				return xerrors.Errorf("parsing overrides: %w", err)
+			}
+		}
+
+		msg := &types.Message{
 			From:   froma,
 			To:     toa,
 			Value:  types.BigInt(value),
 			Method: abi.MethodNum(method),
 			Params: params,
-		}, ts.Key())
+		}
+
+		var ret *lapi.InvocResult
+		if len(overrides) > 0 {
+			ret, err = api.StateCallWithOverrides(ctx, msg, overrides, ts.Key())
+		} else {
+			ret, err = api.StateCall(ctx, msg, ts.Key())
+		}
 		if err != nil {
 			return fmt.Errorf("state call failed: %w", err)
 		}
@@ -1916,3 +1950,347 @@ var StateSysActorCIDsCmd = &cli.Command{
 		return tw.Flush()
 	},
 }
+
+var StateGasStatsCmd = &cli.Command{
+	Name:  "gas-stats",
+	Usage: "Show the gas used by the messages executed on chain, per actor code and method",
+	Description: `Requires Index.EnableGasStats to be set in the node config. The node keeps
+the statistics of the last Index.GasStatsRetention epochs it observed.`,
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:  "from",
+			Usage: "first epoch of the range",
+		},
+		&cli.Int64Flag{
+			Name:  "to",
+			Usage: "last epoch of the range (default: chain head)",
+		},
+		&cli.Int64Flag{
+			Name:  "last",
+			Usage: "select the last n epochs, instead of --from and --to",
+		},
+		&cli.StringFlag{
+			Name:  "actor-code",
+			Usage: "only show the messages sent to actors with this code CID",
+		},
+		&cli.Uint64Flag{
+			Name:  "method",
+			Usage: "only show the messages calling this method number",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Present() {
+			return ShowHelp(cctx, fmt.Errorf("doesn't expect any arguments"))
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		filter := lapi.GasStatsFilter{
+			FromHeight: abi.ChainEpoch(cctx.Int64("from")),
+			ToHeight:   abi.ChainEpoch(cctx.Int64("to")),
+		}
+		if cctx.IsSet("last") {
+			head, err := api.ChainHead(ctx)
+			if err != nil {
+				return err
+			}
+			filter.FromHeight = head.Height() - abi.ChainEpoch(cctx.Int64("last")) + 1
+			filter.ToHeight = 0
+		}
+		if cctx.IsSet("actor-code") {
+			c, err := cid.Decode(cctx.String("actor-code"))
+			if err != nil {
+				return xerrors.Errorf("parsing actor code: %w", err)
+			}
+			filter.ActorCode = &c
+		}
+		if cctx.IsSet("method") {
+			m := abi.MethodNum(cctx.Uint64("method"))
+			filter.Method = &m
+		}
+
+		res, err := api.StateGasStats(ctx, filter)
+		if err != nil {
+			return err
+		}
+
+		if res.TipSets == 0 {
+			fmt.Println("No gas statistics in the range")
+			return nil
+		}
+		fmt.Printf("Epochs %d to %d, %d tipsets\n\n", res.FromHeight, res.ToHeight, res.TipSets)
+
+		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "Actor\tMethod\tMessages\tFailed\tAvg Gas\tMin Gas\tMax Gas\tTotal Gas\tBurnt\tTips")
+		for _, st := range res.Stats {
+			actor := st.ActorName
+			if actor == "" {
+				actor = "<unknown>"
+			}
+			method := fmt.Sprint(st.Method)
+			if st.MethodName != "" {
+				method = fmt.Sprintf("%s (%d)", st.MethodName, st.Method)
+			}
+			burnt := big.Add(st.BaseFeeBurn, st.OverEstimationBurn)
+
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\t%s\t%s\n", actor, method, st.Messages, st.Failed,
+				st.AvgGasUsed, st.MinGasUsed, st.MaxGasUsed, st.GasUsed, types.FIL(burnt).Short(), types.FIL(st.MinerTip).Short())
+		}
+		return tw.Flush()
+	},
+}
+
+var StatePowerHistoryCmd = &cli.Command{
+	Name:      "power-history",
+	Usage:     "Show the sampled history of the power, pledge and rewards of a miner",
+	ArgsUsage: "[minerAddress]",
+	Description: `Requires Index.EnablePowerHistory to be set in the node config, and the miner
+to be listed in Index.PowerHistoryMiners. The miners are sampled every
+Index.PowerHistoryInterval epochs, the blocks won are counted since the
+previous sample.`,
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:  "from",
+			Usage: "first epoch of the range",
+		},
+		&cli.Int64Flag{
+			Name:  "to",
+			Usage: "last epoch of the range (default: chain head)",
+		},
+		&cli.Int64Flag{
+			Name:  "last",
+			Usage: "select the last n epochs, instead of --from and --to",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		maddr, err := address.NewFromString(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		from, to := abi.ChainEpoch(cctx.Int64("from")), abi.ChainEpoch(cctx.Int64("to"))
+		if cctx.IsSet("last") {
+			head, err := api.ChainHead(ctx)
+			if err != nil {
+				return err
+			}
+			from, to = head.Height()-abi.ChainEpoch(cctx.Int64("last"))+1, 0
+		}
+
+		samples, err := api.StateMinerPowerHistory(ctx, maddr, from, to)
+		if err != nil {
+			return err
+		}
+
+		if len(samples) == 0 {
+			fmt.Println("No samples in the range")
+			return nil
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "Epoch\tRaw Power\tQA Power\tNetwork Share\tInitial Pledge\tVesting\tBalance\tBlocks\tRewards")
+		for _, s := range samples {
+			share := "0%"
+			if s.TotalQualityAdjPower.GreaterThan(big.Zero()) {
+				share = fmt.Sprintf("%.4f%%", float64(big.Div(big.Mul(s.QualityAdjPower, big.NewInt(1000000)), s.TotalQualityAdjPower).Int64())/10000)
+			}
+
+			_, _ = fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%d\t%s\n", s.Height,
+				types.SizeStr(s.RawBytePower), types.SizeStr(s.QualityAdjPower), share,
+				types.FIL(s.InitialPledge).Short(), types.FIL(s.VestingFunds).Short(), types.FIL(s.Balance).Short(),
+				s.BlocksWon, types.FIL(s.Rewards).Short())
+		}
+		return tw.Flush()
+	},
+}
+
+var StateReplayEventsCmd = &cli.Command{
+	Name:      "replay-events",
+	Usage:     "Re-derive the actor events emitted in a range of epochs",
+	ArgsUsage: "[from] [to]",
+	Description: `Re-executes the tipsets with heights in the [from, to] range, and prints the
+actor events emitted by their messages as JSON, one event per line. The progress
+is printed to stderr. The events don't need to be stored by the node, at most
+2880 epochs can be replayed at once.`,
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 2 {
+			return IncorrectNumArgs(cctx)
+		}
+
+		from, err := strconv.ParseInt(cctx.Args().Get(0), 10, 64)
+		if err != nil {
+			return xerrors.Errorf("parsing from epoch: %w", err)
+		}
+		to, err := strconv.ParseInt(cctx.Args().Get(1), 10, 64)
+		if err != nil {
+			return xerrors.Errorf("parsing to epoch: %w", err)
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		updates, err := api.StateReplayEvents(ctx, abi.ChainEpoch(from), abi.ChainEpoch(to))
+		if err != nil {
+			return err
+		}
+
+		type replayedEvent struct {
+			Height abi.ChainEpoch
+			TipSet types.TipSetKey
+			lapi.ReplayedEvent
+		}
+
+		enc := json.NewEncoder(cctx.App.Writer)
+		var replayed, total int
+		for upd := range updates {
+			if upd.Error != "" {
+				return xerrors.Errorf("replaying height %d: %s", upd.Height, upd.Error)
+			}
+
+			for _, ev := range upd.Events {
+				if err := enc.Encode(replayedEvent{Height: upd.Height, TipSet: upd.TipSet, ReplayedEvent: ev}); err != nil {
+					return err
+				}
+			}
+
+			replayed, total = upd.Replayed, upd.Total
+			_, _ = fmt.Fprintf(cctx.App.ErrWriter, "replayed %d/%d tipsets (height %d, %d events)\n", upd.Replayed, upd.Total, upd.Height, len(upd.Events))
+		}
+
+		if replayed < total {
+			return xerrors.Errorf("replay interrupted after %d/%d tipsets", replayed, total)
+		}
+		return nil
+	},
+}
+
+var StateMigrationDryRunCmd = &cli.Command{
+	Name:  "upgrade-dry-run",
+	Usage: "Run the state migration of the next network upgrade in memory",
+	Description: `Migrates the state computed by the tipset (default: chain head) as if the next
+network upgrade happened right after it, and reports the migration time, the
+migrated state root and the changes to actors. Nothing is persisted. Migrating
+the state of a large network takes a lot of time and memory.`,
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Present() {
+			return ShowHelp(cctx, fmt.Errorf("doesn't expect any arguments"))
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		ts, err := LoadTipSet(ctx, cctx, api)
+		if err != nil {
+			return err
+		}
+
+		res, err := api.StateMigrationDryRun(ctx, ts.Key())
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Upgrade to network version %d at epoch %d, from tipset %s at epoch %d\n", res.Network, res.Height, res.TipSet, ts.Height())
+		fmt.Printf("State root: %s -> %s\n", res.OldStateRoot, res.NewStateRoot)
+		fmt.Printf("Migration took %s\n", res.Duration.Truncate(time.Millisecond))
+		fmt.Printf("Actors added: %d, removed: %d\n\n", len(res.Added), len(res.Removed))
+
+		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "Actor\tOld Version\tNew Version\tActors\tState Changed\tBalance Changed")
+		for _, c := range res.Actors {
+			name := c.Name
+			if name == "" {
+				name = c.OldCode.String()
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\n", name, c.OldVersion, c.NewVersion, c.Actors, c.StateChanged, c.BalanceChanged)
+		}
+		return tw.Flush()
+	},
+}
+
+func listMessagesFromIndex(cctx *cli.Context, toa, froma address.Address, fromHeight, toHeight abi.ChainEpoch) error {
+	api, closer, err := GetFullNodeAPIV1(cctx)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	ctx := ReqContext(cctx)
+
+	query := lapi.AddressMessageQuery{
+		Address:    froma,
+		Direction:  lapi.MessageDirectionFrom,
+		FromHeight: toHeight,
+		ToHeight:   fromHeight,
+	}
+	if froma == address.Undef {
+		query.Address, query.Direction = toa, lapi.MessageDirectionTo
+	}
+	if query.Address == address.Undef {
+		return xerrors.Errorf("must specify at least --to or --from")
+	}
+
+	for {
+		page, err := api.StateListMessagesFast(ctx, query)
+		if err != nil {
+			return err
+		}
+		if query.Cursor == "" && page.IndexedFrom > toHeight {
+			_, _ = fmt.Fprintf(cctx.App.ErrWriter, "the address index starts at epoch %d\n", page.IndexedFrom)
+		}
+
+		for _, im := range page.Messages {
+			// with both addresses, the recipient is matched here
+			if froma != address.Undef && toa != address.Undef && im.To != toa {
+				continue
+			}
+
+			if cctx.Bool("cids") {
+				fmt.Println(im.Cid.String())
+				continue
+			}
+
+			m, err := api.ChainGetMessage(ctx, im.Cid)
+			if err != nil {
+				return err
+			}
+			b, err := json.MarshalIndent(m, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(b))
+		}
+
+		if page.Cursor == "" {
+			return nil
+		}
+		query.Cursor = page.Cursor
+	}
+}
@@ -143,6 +143,10 @@ type HttpHead struct {
 
 func GetRawAPIMulti(ctx *cli.Context, t repo.RepoType, version string) ([]HttpHead, error) {
 
+	if err := configureWebsocketClient(ctx); err != nil {
+		return nil, xerrors.Errorf("configuring websocket client: %w", err)
+	}
+
 	var httpHeads []HttpHead
 	ainfos, err := GetAPIInfoMulti(ctx, t)
 	if err != nil || len(ainfos) == 0 {
@@ -309,13 +313,11 @@ func GetFullNodeAPIV1Single(ctx *cli.Context) (v1api.FullNode, jsonrpc.ClientClo
 		return nil, nil, err
 	}
 
-	v, err := v1API.Version(ctx.Context)
+	caps, err := client.NegotiateCommon(ctx.Context, v1API, api.FullAPIVersion1)
 	if err != nil {
 		return nil, nil, err
 	}
-	if !v.APIVersion.EqMajorMinor(api.FullAPIVersion1) {
-		return nil, nil, xerrors.Errorf("Remote API version didn't match (expected %s, remote %s)", api.FullAPIVersion1, v.APIVersion)
-	}
+	client.WarnDeprecated(caps, v1API)
 	return v1API, closer, nil
 }
 
@@ -383,13 +385,11 @@ func GetFullNodeAPIV1(ctx *cli.Context, opts ...GetFullNodeOption) (v1api.FullNo
 	var v1API api.FullNodeStruct
 	FullNodeProxy(fullNodes, &v1API)
 
-	v, err := v1API.Version(ctx.Context)
+	caps, err := client.NegotiateCommon(ctx.Context, &v1API, api.FullAPIVersion1)
 	if err != nil {
 		return nil, nil, err
 	}
-	if !v.APIVersion.EqMajorMinor(api.FullAPIVersion1) {
-		return nil, nil, xerrors.Errorf("Remote API version didn't match (expected %s, remote %s)", api.FullAPIVersion1, v.APIVersion)
-	}
+	client.WarnDeprecated(caps, &v1API)
 	return &v1API, finalCloser, nil
 }
 
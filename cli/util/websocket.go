@@ -0,0 +1,63 @@
+package cliutil
+
+import (
+	"github.com/urfave/cli/v2"
+
+	"github.com/filecoin-project/lotus/lib/rpcws"
+)
+
+// WebsocketServerFlags configure the websocket connections of an API server.
+// They should be included as flags on the command starting the server (e.g.
+// lotus daemon, lotus-gateway run).
+var WebsocketServerFlags = []cli.Flag{
+	&cli.BoolFlag{
+		Name:  "api-ws-compression",
+		Usage: "negotiate permessage-deflate compression with the API websocket clients supporting it",
+	},
+	&cli.IntFlag{
+		Name:        "api-ws-compression-level",
+		Usage:       "flate compression level (1-9) of the API websocket messages",
+		DefaultText: "default level",
+	},
+	&cli.IntFlag{
+		Name:        "api-ws-max-frame-size",
+		Usage:       "maximum size in bytes of the API websocket frames sent, larger messages are split",
+		DefaultText: "4096",
+	},
+}
+
+// WebsocketServerOptions returns the websocket options set with the
+// WebsocketServerFlags.
+func WebsocketServerOptions(cctx *cli.Context) rpcws.Options {
+	return rpcws.Options{
+		Compression:      cctx.Bool("api-ws-compression"),
+		CompressionLevel: cctx.Int("api-ws-compression-level"),
+		MaxFrameSize:     cctx.Int("api-ws-max-frame-size"),
+	}
+}
+
+// FlagWebsocketCompression makes the API clients negotiate permessage-deflate
+// compression with the server. It should be included as a flag on the
+// top-level command (e.g. lotus, lotus-miner).
+var FlagWebsocketCompression = &cli.BoolFlag{
+	Name:    "ws-compression",
+	Usage:   "compress the messages of the API websocket connections when the server supports it",
+	EnvVars: []string{"LOTUS_WS_COMPRESSION"},
+}
+
+// FlagWebsocketMaxFrameSize sets the maximum size of the websocket frames
+// sent by the API clients. It should be included as a flag on the top-level
+// command (e.g. lotus, lotus-miner).
+var FlagWebsocketMaxFrameSize = &cli.IntFlag{
+	Name:        "ws-max-frame-size",
+	Usage:       "maximum size in bytes of the API websocket frames sent, larger messages are split",
+	EnvVars:     []string{"LOTUS_WS_MAX_FRAME_SIZE"},
+	DefaultText: "4096",
+}
+
+func configureWebsocketClient(cctx *cli.Context) error {
+	return rpcws.ConfigureClient(rpcws.Options{
+		Compression:  cctx.Bool(FlagWebsocketCompression.Name),
+		MaxFrameSize: cctx.Int(FlagWebsocketMaxFrameSize.Name),
+	})
+}
@@ -4,12 +4,13 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/hex"
-	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/urfave/cli/v2"
+	"golang.org/x/term"
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-address"
@@ -20,6 +21,8 @@ import (
 
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/wallet/hd"
+	"github.com/filecoin-project/lotus/chain/wallet/key"
 	"github.com/filecoin-project/lotus/lib/tablewriter"
 )
 
@@ -268,6 +271,13 @@ var walletExport = &cli.Command{
 	Name:      "export",
 	Usage:     "export keys",
 	ArgsUsage: "[address]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "specify output format for key: " + strings.Join(key.ExportFormats, ", ") + ". raw is the hex private key in the byte order of other wallets",
+			Value: key.FormatHexLotus,
+		},
+	},
 	Action: func(cctx *cli.Context) error {
 		api, closer, err := GetFullNodeAPI(cctx)
 		if err != nil {
@@ -292,12 +302,12 @@ var walletExport = &cli.Command{
 			return err
 		}
 
-		b, err := json.Marshal(ki)
+		out, err := key.Encode(*ki, cctx.String("format"))
 		if err != nil {
 			return err
 		}
 
-		afmt.Println(hex.EncodeToString(b))
+		afmt.Println(out)
 		return nil
 	},
 }
@@ -309,8 +319,20 @@ var walletImport = &cli.Command{
 	Flags: []cli.Flag{
 		&cli.StringFlag{
 			Name:  "format",
-			Usage: "specify input format for key",
-			Value: "hex-lotus",
+			Usage: "specify input format for key: " + strings.Join(key.ImportFormats, ", "),
+			Value: key.FormatHexLotus,
+		},
+		&cli.StringFlag{
+			Name:  "key-type",
+			Usage: "type of the key for the raw and mnemonic formats: secp256k1, bls or delegated",
+		},
+		&cli.StringFlag{
+			Name:  "path",
+			Usage: "derivation path of the key for the mnemonic format, BIP-32 for secp256k1 and delegated keys, EIP-2333 for bls keys (default: " + hd.DefaultSecp256k1Path + ", " + hd.DefaultBLSPath + " or " + hd.DefaultDelegatedPath + ")",
+		},
+		&cli.BoolFlag{
+			Name:  "passphrase",
+			Usage: "prompt for the passphrase of the mnemonic",
 		},
 		&cli.BoolFlag{
 			Name:  "as-default",
@@ -325,10 +347,15 @@ var walletImport = &cli.Command{
 		defer closer()
 		ctx := ReqContext(cctx)
 
+		reader := bufio.NewReader(os.Stdin)
+
 		var inpdata []byte
 		if !cctx.Args().Present() || cctx.Args().First() == "-" {
-			reader := bufio.NewReader(os.Stdin)
-			fmt.Print("Enter private key: ")
+			if cctx.String("format") == key.FormatMnemonic {
+				fmt.Print("Enter mnemonic: ")
+			} else {
+				fmt.Print("Enter private key: ")
+			}
 			indata, err := reader.ReadBytes('\n')
 			if err != nil {
 				return err
@@ -343,47 +370,35 @@ var walletImport = &cli.Command{
 			inpdata = fdata
 		}
 
-		var ki types.KeyInfo
-		switch cctx.String("format") {
-		case "hex-lotus":
-			data, err := hex.DecodeString(strings.TrimSpace(string(inpdata)))
-			if err != nil {
-				return err
-			}
-
-			if err := json.Unmarshal(data, &ki); err != nil {
-				return err
-			}
-		case "json-lotus":
-			if err := json.Unmarshal(inpdata, &ki); err != nil {
-				return err
-			}
-		case "gfc-json":
-			var f struct {
-				KeyInfo []struct {
-					PrivateKey []byte
-					SigType    int
+		opts := key.DecodeOptions{
+			Type: types.KeyType(cctx.String("key-type")),
+			Path: cctx.String("path"),
+		}
+		if cctx.Bool("passphrase") {
+			fmt.Print("Enter mnemonic passphrase: ")
+			if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+				// don't echo the passphrase
+				pass, err := term.ReadPassword(fd)
+				fmt.Println()
+				if err != nil {
+					return err
 				}
+				opts.Passphrase = string(pass)
+			} else {
+				pass, err := reader.ReadString('\n')
+				if err != nil {
+					return err
+				}
+				opts.Passphrase = strings.TrimRight(pass, "\r\n")
 			}
-			if err := json.Unmarshal(inpdata, &f); err != nil {
-				return xerrors.Errorf("failed to parse go-filecoin key: %s", err)
-			}
+		}
 
-			gk := f.KeyInfo[0]
-			ki.PrivateKey = gk.PrivateKey
-			switch gk.SigType {
-			case 1:
-				ki.Type = types.KTSecp256k1
-			case 2:
-				ki.Type = types.KTBLS
-			default:
-				return fmt.Errorf("unrecognized key type: %d", gk.SigType)
-			}
-		default:
-			return fmt.Errorf("unrecognized format: %s", cctx.String("format"))
+		ki, err := key.Decode(inpdata, cctx.String("format"), opts)
+		if err != nil {
+			return err
 		}
 
-		addr, err := api.WalletImport(ctx, &ki)
+		addr, err := api.WalletImport(ctx, ki)
 		if err != nil {
 			return err
 		}
@@ -529,6 +544,53 @@ var walletMarket = &cli.Command{
 	Subcommands: []*cli.Command{
 		walletMarketWithdraw,
 		walletMarketAdd,
+		walletMarketStatus,
+	},
+}
+
+var walletMarketStatus = &cli.Command{
+	Name:      "status",
+	Usage:     "Show the market escrow of an address, split between locked, reserved and available funds",
+	ArgsUsage: "[address (optional, defaults to the default wallet address)]",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return xerrors.Errorf("getting node API: %w", err)
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		afmt := NewAppFmt(cctx.App)
+
+		var addr address.Address
+		if cctx.Args().Present() {
+			addr, err = address.NewFromString(cctx.Args().First())
+			if err != nil {
+				return xerrors.Errorf("parsing address: %w", err)
+			}
+		} else {
+			addr, err = api.WalletDefaultAddress(ctx)
+			if err != nil {
+				return xerrors.Errorf("getting default wallet address: %w", err)
+			}
+		}
+
+		st, err := api.MarketFundsStatus(ctx, addr)
+		if err != nil {
+			return err
+		}
+
+		afmt.Printf("Escrow:    %s\n", types.FIL(st.Escrow))
+		afmt.Printf("Locked:    %s\n", types.FIL(st.Locked))
+		afmt.Printf("Reserved:  %s\n", types.FIL(st.Reserved))
+		afmt.Printf("Available: %s\n", types.FIL(st.Available))
+		if st.PendingMessage != nil {
+			afmt.Printf("Pending message: %s\n", st.PendingMessage)
+		}
+		if st.LastWithdrawal != nil {
+			afmt.Printf("Last automatic withdrawal: %s in %s (%s)\n", types.FIL(st.LastWithdrawal.Amount), st.LastWithdrawal.Message, st.LastWithdrawal.Time.Format(time.RFC3339))
+		}
+		return nil
 	},
 }
 
@@ -23,6 +23,7 @@ import (
 	cliutil "github.com/filecoin-project/lotus/cli/util"
 	"github.com/filecoin-project/lotus/gateway"
 	"github.com/filecoin-project/lotus/lib/lotuslog"
+	"github.com/filecoin-project/lotus/lib/rpcws"
 	"github.com/filecoin-project/lotus/metrics"
 	"github.com/filecoin-project/lotus/node"
 )
@@ -111,7 +112,7 @@ var checkCmd = &cli.Command{
 var runCmd = &cli.Command{
 	Name:  "run",
 	Usage: "Start api server",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.StringFlag{
 			Name:  "listen",
 			Usage: "host address and port the api server will listen on",
@@ -151,7 +152,7 @@ var runCmd = &cli.Command{
 			Usage: "The number of incomming connections to accept from a single IP per minute.  Use 0 to disable",
 			Value: 0,
 		},
-	},
+	}, cliutil.WebsocketServerFlags...),
 	Action: func(cctx *cli.Context) error {
 		log.Info("Starting lotus gateway")
 
@@ -203,6 +204,11 @@ var runCmd = &cli.Command{
 			return xerrors.Errorf("failed to set up gateway HTTP handler")
 		}
 
+		h, err = rpcws.Handler(h, cliutil.WebsocketServerOptions(cctx))
+		if err != nil {
+			return xerrors.Errorf("configuring websocket connections: %w", err)
+		}
+
 		stopFunc, err := node.ServeRPC(h, "lotus-gateway", maddr)
 		if err != nil {
 			return xerrors.Errorf("failed to serve rpc endpoint: %w", err)
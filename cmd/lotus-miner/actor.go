@@ -6,6 +6,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/ipfs/go-cid"
@@ -51,6 +52,7 @@ var actorCmd = &cli.Command{
 		actorCompactAllocatedCmd,
 		actorProposeChangeBeneficiary,
 		actorConfirmChangeBeneficiary,
+		actorRotateCmd,
 	},
 }
 
@@ -429,6 +431,7 @@ var actorControl = &cli.Command{
 	Subcommands: []*cli.Command{
 		actorControlList,
 		actorControlSet,
+		actorControlBalances,
 	},
 }
 
@@ -714,6 +717,100 @@ var actorControlSet = &cli.Command{
 	},
 }
 
+var actorControlBalances = &cli.Command{
+	Name:  "balances",
+	Usage: "Show the balances checked by the balance monitor and the refills of the last 24 hours",
+	Action: func(cctx *cli.Context) error {
+		minerApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := lcli.ReqContext(cctx)
+
+		st, err := minerApi.ActorAddressBalances(ctx)
+		if err != nil {
+			return err
+		}
+
+		if st.Checked.IsZero() {
+			fmt.Println("The balances weren't checked yet, is Addresses.BalanceMonitor.CheckInterval set?")
+			return nil
+		}
+
+		fmt.Printf("Checked: %s ago\n", time.Since(st.Checked).Round(time.Second))
+		if st.Error != "" {
+			fmt.Printf("Error: %s\n", color.RedString(st.Error))
+		}
+		fmt.Printf("Low-water mark: %s\n", types.FIL(st.LowWater))
+		if st.RefillTo.GreaterThan(big.Zero()) {
+			fmt.Printf("Refills from %s up to %s, %s of %s sent in the last 24 hours\n", st.Owner, types.FIL(st.RefillTo), types.FIL(st.Refilled), types.FIL(st.DailyRefillCap))
+		} else {
+			fmt.Println("Refills disabled")
+		}
+		fmt.Println()
+
+		tw := tablewriter.New(
+			tablewriter.Col("address"),
+			tablewriter.Col("use"),
+			tablewriter.Col("balance"),
+			tablewriter.Col("refill"),
+		)
+		for _, ab := range st.Addresses {
+			bstr := types.FIL(ab.Balance).String()
+			if ab.Low {
+				bstr = color.RedString(bstr)
+			} else {
+				bstr = color.GreenString(bstr)
+			}
+
+			refill := ""
+			if ab.RefillPending.Defined() {
+				refill = ab.RefillPending.String()
+			}
+
+			tw.Write(map[string]interface{}{
+				"address": ab.Address,
+				"use":     strings.Join(ab.Roles, " "),
+				"balance": bstr,
+				"refill":  refill,
+			})
+		}
+		if err := tw.Flush(os.Stdout); err != nil {
+			return err
+		}
+
+		if len(st.Refills) == 0 {
+			return nil
+		}
+
+		fmt.Println()
+		tw = tablewriter.New(
+			tablewriter.Col("time"),
+			tablewriter.Col("to"),
+			tablewriter.Col("amount"),
+			tablewriter.Col("message"),
+			tablewriter.Col("state"),
+		)
+		for _, r := range st.Refills {
+			state := color.YellowString("pending")
+			if r.Executed {
+				state = color.GreenString("executed")
+			}
+
+			tw.Write(map[string]interface{}{
+				"time":    r.Time.Format(time.Stamp),
+				"to":      r.To,
+				"amount":  types.FIL(r.Amount),
+				"message": r.Message,
+				"state":   state,
+			})
+		}
+		return tw.Flush(os.Stdout)
+	},
+}
+
 var actorSetOwnerCmd = &cli.Command{
 	Name:      "set-owner",
 	Usage:     "Set owner address (this command should be invoked twice, first with the old owner as the senderAddress, and then with the new owner)",
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/ipfs/go-cid"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/storage/ctladdr"
+)
+
+var rotationFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "owner",
+		Usage: "new owner address",
+	},
+	&cli.StringFlag{
+		Name:  "worker",
+		Usage: "new worker address, must be a BLS account",
+	},
+	&cli.StringSliceFlag{
+		Name:  "control",
+		Usage: "new control addresses, replacing all the current ones",
+	},
+	&cli.BoolFlag{
+		Name:  "clear-control",
+		Usage: "remove all the control addresses",
+	},
+}
+
+var actorRotateCmd = &cli.Command{
+	Name:  "rotate",
+	Usage: "Change the owner, worker and control addresses step by step",
+	Description: `Rotating the addresses of a miner takes up to four messages: the worker
+   change with the new control addresses, its confirmation once the worker change
+   delay has passed, the nomination of the new owner by the current owner, and its
+   acceptance by the new owner.
+
+   'plan' shows the steps, their state on chain and the checks of the next step,
+   'execute' sends the next step when it is ready. Run 'execute' again for each step.
+   Owners which are multisigs propose the steps through one of their signers.`,
+	Subcommands: []*cli.Command{
+		actorRotatePlanCmd,
+		actorRotateExecuteCmd,
+	},
+}
+
+var actorRotatePlanCmd = &cli.Command{
+	Name:  "plan",
+	Usage: "Plan and dry-run an address rotation",
+	Flags: rotationFlags,
+	Action: func(cctx *cli.Context) error {
+		ctx := lcli.ReqContext(cctx)
+
+		rot, err := parseRotation(cctx)
+		if err != nil {
+			return err
+		}
+
+		plan, err := planRotation(ctx, cctx, rot)
+		if err != nil {
+			return err
+		}
+
+		printRotationPlan(cctx, plan)
+		return nil
+	},
+}
+
+var actorRotateExecuteCmd = &cli.Command{
+	Name:  "execute",
+	Usage: "Send the message of the next step of an address rotation",
+	Flags: append([]cli.Flag{
+		&cli.StringFlag{
+			Name:  "proposer",
+			Usage: "signer proposing the step when it's sent by a multisig",
+		},
+		&cli.BoolFlag{
+			Name:  "really-do-it",
+			Usage: "Actually send transaction performing the action",
+			Value: false,
+		},
+	}, rotationFlags...),
+	Action: func(cctx *cli.Context) error {
+		ctx := lcli.ReqContext(cctx)
+
+		rot, err := parseRotation(cctx)
+		if err != nil {
+			return err
+		}
+
+		var proposer address.Address
+		if cctx.IsSet("proposer") {
+			if proposer, err = address.NewFromString(cctx.String("proposer")); err != nil {
+				return xerrors.Errorf("parsing proposer: %w", err)
+			}
+		}
+
+		plan, err := planRotation(ctx, cctx, rot)
+		if err != nil {
+			return err
+		}
+
+		step := plan.Next()
+		if step == nil {
+			_, _ = fmt.Fprintln(cctx.App.Writer, "The rotation is complete")
+			return nil
+		}
+
+		afmt := lcli.NewAppFmt(cctx.App)
+		afmt.Printf("Next step: ")
+		printRotationStep(cctx, step)
+
+		if step.State != api.RotationStepReady {
+			return xerrors.Errorf("step %d is %s", step.Index, step.State)
+		}
+
+		if !cctx.Bool("really-do-it") {
+			afmt.Println("Pass --really-do-it to actually execute this action. Review what you're about to approve CAREFULLY please")
+			return nil
+		}
+
+		var mcid cid.Cid
+		if cctx.IsSet("actor") {
+			fapi, closer, err := lcli.GetFullNodeAPIV1(cctx)
+			if err != nil {
+				return err
+			}
+			defer closer()
+
+			mcid, err = ctladdr.ExecuteRotationStep(ctx, fapi, plan.Miner, rot, proposer)
+			if err != nil {
+				return err
+			}
+		} else {
+			mapi, closer, err := lcli.GetStorageMinerAPI(cctx)
+			if err != nil {
+				return err
+			}
+			defer closer()
+
+			mcid, err = mapi.ActorAddressRotationStep(ctx, rot, proposer)
+			if err != nil {
+				return err
+			}
+		}
+
+		afmt.Println("Message CID:", mcid)
+
+		fapi, closer, err := lcli.GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		wait, err := fapi.StateWaitMsg(ctx, mcid, build.MessageConfidence)
+		if err != nil {
+			return err
+		}
+		if wait.Receipt.ExitCode.IsError() {
+			return xerrors.Errorf("step %d (%s) failed: exit code %d", step.Index, step.Kind, wait.Receipt.ExitCode)
+		}
+
+		if step.Multisig {
+			afmt.Printf("Step proposed to multisig %s, it executes once approved by %d signers ('lotus msig approve')\n", step.From, step.MsigThreshold)
+		} else {
+			afmt.Printf("Step %d (%s) executed\n", step.Index, step.Kind)
+		}
+		if step.Rollback != "" {
+			afmt.Println("Rollback:", step.Rollback)
+		}
+		afmt.Println("Run 'lotus-miner actor rotate plan' with the same addresses to see the next step")
+
+		return nil
+	},
+}
+
+func parseRotation(cctx *cli.Context) (api.AddressRotation, error) {
+	var rot api.AddressRotation
+	var err error
+
+	if cctx.IsSet("owner") {
+		if rot.NewOwner, err = address.NewFromString(cctx.String("owner")); err != nil {
+			return rot, xerrors.Errorf("parsing owner: %w", err)
+		}
+	}
+	if cctx.IsSet("worker") {
+		if rot.NewWorker, err = address.NewFromString(cctx.String("worker")); err != nil {
+			return rot, xerrors.Errorf("parsing worker: %w", err)
+		}
+	}
+
+	if cctx.Bool("clear-control") {
+		if cctx.IsSet("control") {
+			return rot, xerrors.Errorf("--control and --clear-control are mutually exclusive")
+		}
+		rot.NewControl = []address.Address{}
+	}
+	for _, s := range cctx.StringSlice("control") {
+		a, err := address.NewFromString(s)
+		if err != nil {
+			return rot, xerrors.Errorf("parsing control address %q: %w", s, err)
+		}
+		rot.NewControl = append(rot.NewControl, a)
+	}
+
+	if rot.NewOwner == address.Undef && rot.NewWorker == address.Undef && rot.NewControl == nil {
+		return rot, xerrors.Errorf("specify at least one of --owner, --worker, --control or --clear-control")
+	}
+
+	return rot, nil
+}
+
+// planRotation plans on the full node directly for the miners specified with
+// --actor, which aren't served by the miner node.
+func planRotation(ctx context.Context, cctx *cli.Context, rot api.AddressRotation) (*api.AddressRotationPlan, error) {
+	if cctx.IsSet("actor") {
+		maddr, err := getActorAddress(ctx, cctx)
+		if err != nil {
+			return nil, err
+		}
+
+		fapi, closer, err := lcli.GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return nil, err
+		}
+		defer closer()
+
+		return ctladdr.PlanRotation(ctx, fapi, maddr, rot)
+	}
+
+	mapi, closer, err := lcli.GetStorageMinerAPI(cctx)
+	if err != nil {
+		return nil, err
+	}
+	defer closer()
+
+	return mapi.ActorAddressRotationPlan(ctx, rot)
+}
+
+func printRotationPlan(cctx *cli.Context, plan *api.AddressRotationPlan) {
+	afmt := lcli.NewAppFmt(cctx.App)
+
+	change := func(name string, cur, target address.Address) {
+		if target == address.Undef {
+			afmt.Printf("%s:\t%s\n", name, cur)
+			return
+		}
+		afmt.Printf("%s:\t%s -> %s\n", name, cur, target)
+	}
+
+	afmt.Printf("Miner:\t%s (height %d)\n", plan.Miner, plan.Height)
+	change("Owner", plan.Owner, plan.Target.NewOwner)
+	change("Worker", plan.Worker, plan.Target.NewWorker)
+	if plan.Target.NewControl != nil {
+		afmt.Printf("Control:\t%v -> %v\n", plan.Control, plan.Target.NewControl)
+	} else {
+		afmt.Printf("Control:\t%v\n", plan.Control)
+	}
+	if plan.PendingOwner != address.Undef {
+		afmt.Printf("Nominated owner:\t%s\n", plan.PendingOwner)
+	}
+	if plan.PendingWorker != address.Undef {
+		afmt.Printf("Proposed worker:\t%s (effective at %d)\n", plan.PendingWorker, plan.WorkerChangeEpoch)
+	}
+
+	if len(plan.Checks) > 0 {
+		afmt.Println("\nChecks:")
+		printRotationChecks(cctx, plan.Checks)
+	}
+
+	afmt.Println("\nSteps:")
+	for i := range plan.Steps {
+		printRotationStep(cctx, &plan.Steps[i])
+	}
+}
+
+func printRotationStep(cctx *cli.Context, step *api.AddressRotationStep) {
+	afmt := lcli.NewAppFmt(cctx.App)
+
+	state := string(step.State)
+	switch step.State {
+	case api.RotationStepDone:
+		state = color.GreenString(state)
+	case api.RotationStepReady:
+		state = color.CyanString(state)
+	case api.RotationStepPending, api.RotationStepWaiting:
+		state = color.YellowString(state)
+	case api.RotationStepBlocked:
+		state = color.RedString(state)
+	}
+
+	from := step.From.String()
+	if step.Multisig {
+		from += " (multisig)"
+	}
+	afmt.Printf("%d. %s\t%s\tfrom %s\n", step.Index, step.Kind, state, from)
+	afmt.Printf("   %s\n", step.Description)
+
+	switch {
+	case step.MsigTxID >= 0:
+		afmt.Printf("   multisig proposal %d: %d of %d approvals\n", step.MsigTxID, step.MsigApprovals, step.MsigThreshold)
+	case len(step.PendingMessages) > 0:
+		afmt.Printf("   pending messages: %v\n", step.PendingMessages)
+	}
+	if step.State == api.RotationStepWaiting && step.NotBefore > 0 {
+		afmt.Printf("   not before epoch %d\n", step.NotBefore)
+	}
+	if step.EstimatedFee.GreaterThan(big.Zero()) {
+		afmt.Printf("   fee: up to %s\n", types.FIL(step.EstimatedFee))
+	}
+	printRotationChecks(cctx, step.Checks)
+	if step.Rollback != "" && step.State != api.RotationStepDone {
+		afmt.Printf("   rollback: %s\n", step.Rollback)
+	}
+}
+
+func printRotationChecks(cctx *cli.Context, checks []api.AddressRotationCheck) {
+	afmt := lcli.NewAppFmt(cctx.App)
+
+	for _, c := range checks {
+		mark := color.GreenString("ok")
+		switch {
+		case c.OK:
+		case c.Warning:
+			mark = color.YellowString("warning")
+		default:
+			mark = color.RedString("failed")
+		}
+		afmt.Printf("   [%s] %s: %s\n", mark, c.Name, c.Detail)
+	}
+}
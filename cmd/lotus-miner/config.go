@@ -2,10 +2,13 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"text/tabwriter"
 
 	"github.com/urfave/cli/v2"
 	"golang.org/x/xerrors"
 
+	lcli "github.com/filecoin-project/lotus/cli"
 	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/repo"
 )
@@ -16,6 +19,8 @@ var configCmd = &cli.Command{
 	Subcommands: []*cli.Command{
 		configDefaultCmd,
 		configUpdateCmd,
+		configProfilesCmd,
+		configProfileCmd,
 	},
 }
 
@@ -92,3 +97,135 @@ var configUpdateCmd = &cli.Command{
 		return nil
 	},
 }
+
+var configProfilesCmd = &cli.Command{
+	Name:  "profiles",
+	Usage: "List the config profiles for common miner topologies",
+	Action: func(cctx *cli.Context) error {
+		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(tw, "Profile\tDescription\n")
+		for _, p := range config.MinerProfiles() {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\n", p.Name, p.Description)
+		}
+		return tw.Flush()
+	},
+}
+
+var configProfileCmd = &cli.Command{
+	Name:      "profile",
+	Usage:     "Show the changes a config profile makes to the node config",
+	ArgsUsage: "[profile name]",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "apply",
+			Usage: "write the changes to the node config, the miner must not be running",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return lcli.IncorrectNumArgs(cctx)
+		}
+		name := cctx.Args().First()
+
+		r, err := repo.NewFS(cctx.String(FlagMinerRepo))
+		if err != nil {
+			return err
+		}
+
+		ok, err := r.Exists()
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return xerrors.Errorf("repo not initialized")
+		}
+
+		if cctx.Bool("apply") {
+			lr, err := r.Lock(repo.StorageMiner)
+			if err != nil {
+				return xerrors.Errorf("locking repo: %w", err)
+			}
+			defer lr.Close() //nolint:errcheck
+
+			return applyConfigProfile(lr, name)
+		}
+
+		lr, err := r.LockRO(repo.StorageMiner)
+		if err != nil {
+			return xerrors.Errorf("locking repo: %w", err)
+		}
+
+		cfgNode, err := lr.Config()
+		if err != nil {
+			_ = lr.Close()
+			return xerrors.Errorf("getting node config: %w", err)
+		}
+
+		if err := lr.Close(); err != nil {
+			return err
+		}
+
+		cfg, ok := cfgNode.(*config.StorageMiner)
+		if !ok {
+			return xerrors.Errorf("expected miner config, got %T", cfgNode)
+		}
+
+		updated, err := config.WithMinerProfile(cfg, name)
+		if err != nil {
+			return err
+		}
+
+		diff, err := config.ConfigDiff(cfg, updated, "current", name)
+		if err != nil {
+			return err
+		}
+
+		if diff == "" {
+			fmt.Printf("profile %s doesn't change the config\n", name)
+			return nil
+		}
+		fmt.Print(diff)
+		return nil
+	},
+}
+
+// applyConfigProfile applies the config profile to the config of the repo,
+// printing the changes.
+func applyConfigProfile(lr repo.LockedRepo, name string) error {
+	var diff string
+	var cerr error
+	err := lr.SetConfig(func(raw interface{}) {
+		cfg, ok := raw.(*config.StorageMiner)
+		if !ok {
+			cerr = xerrors.New("expected miner config")
+			return
+		}
+
+		updated, err := config.WithMinerProfile(cfg, name)
+		if err != nil {
+			cerr = err
+			return
+		}
+
+		diff, err = config.ConfigDiff(cfg, updated, "current", name)
+		if err != nil {
+			cerr = err
+			return
+		}
+		*cfg = *updated
+	})
+	if err != nil {
+		return xerrors.Errorf("setting config: %w", err)
+	}
+	if cerr != nil {
+		return xerrors.Errorf("applying config profile %s: %w", name, cerr)
+	}
+
+	if diff == "" {
+		fmt.Printf("profile %s doesn't change the config\n", name)
+		return nil
+	}
+	fmt.Printf("Applied config profile %s:\n%s", name, diff)
+	return nil
+}
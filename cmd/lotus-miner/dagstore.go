@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
@@ -25,6 +26,7 @@ var dagstoreCmd = &cli.Command{
 		dagstoreInitializeAllCmd,
 		dagstoreGcCmd,
 		dagstoreLookupPiecesCmd,
+		dagstoreDiagnosticsCmd,
 	},
 }
 
@@ -280,3 +282,39 @@ var dagstoreLookupPiecesCmd = &cli.Command{
 		return printTableShards(shards)
 	},
 }
+
+var dagstoreDiagnosticsCmd = &cli.Command{
+	Name:      "diagnostics",
+	Usage:     "Print the state of the shard of a piece and of its resources as JSON, to attach to bug reports",
+	ArgsUsage: "<piece CID>",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return lcli.IncorrectNumArgs(cctx)
+		}
+
+		pieceCid, err := cid.Parse(cctx.Args().First())
+		if err != nil {
+			return fmt.Errorf("invalid piece CID: %w", err)
+		}
+
+		marketsApi, closer, err := lcli.GetMarketsAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := lcli.ReqContext(cctx)
+
+		diag, err := marketsApi.DagstoreShardDiagnostics(ctx, pieceCid)
+		if err != nil {
+			return err
+		}
+
+		b, err := json.MarshalIndent(diag, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	},
+}
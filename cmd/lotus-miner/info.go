@@ -2,11 +2,13 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"math"
 	corebig "math/big"
 	"os"
 	"sort"
+	"text/tabwriter"
 	"time"
 
 	"github.com/fatih/color"
@@ -40,6 +42,8 @@ var infoCmd = &cli.Command{
 	Usage: "Print miner info",
 	Subcommands: []*cli.Command{
 		infoAllCmd,
+		infoBlocksReportCmd,
+		infoSpendReportCmd,
 	},
 	Flags: []cli.Flag{
 		&cli.BoolFlag{
@@ -559,3 +563,186 @@ func producedBlocks(ctx context.Context, count int, maddr address.Address, napi
 
 	return nil
 }
+
+var infoBlocksReportCmd = &cli.Command{
+	Name:  "blocks-report",
+	Usage: "Report the elections won by the miner, the blocks produced and their rewards",
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:  "from",
+			Usage: "first epoch of the report, defaults to a day before the end",
+		},
+		&cli.Int64Flag{
+			Name:  "to",
+			Usage: "last epoch of the report, defaults to the chain head",
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "output the report as json",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		minerApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		fullapi, acloser, err := lcli.GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer acloser()
+
+		ctx := lcli.ReqContext(cctx)
+
+		to := abi.ChainEpoch(cctx.Int64("to"))
+		if to <= 0 {
+			head, err := fullapi.ChainHead(ctx)
+			if err != nil {
+				return xerrors.Errorf("getting chain head: %w", err)
+			}
+			to = head.Height()
+		}
+		from := to - builtin.EpochsInDay
+		if cctx.IsSet("from") {
+			from = abi.ChainEpoch(cctx.Int64("from"))
+		}
+
+		report, err := minerApi.MinerBlocksReport(ctx, from, to)
+		if err != nil {
+			return err
+		}
+
+		if cctx.Bool("json") {
+			out, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+			return nil
+		}
+
+		fmt.Printf("Epochs %d - %d\n", report.From, report.To)
+		fmt.Printf("Elections won: %d, blocks produced: %d, included: %d, orphaned: %d\n",
+			report.Won, report.Produced, report.Included, report.Orphaned)
+		fmt.Printf("Block rewards: %s\n", types.FIL(report.BlockRewards))
+		fmt.Printf("Gas rewards:   %s\n", types.FIL(report.GasRewards))
+		fmt.Printf("Penalty risk:  %s\n", types.FIL(report.PenaltyRisk))
+
+		if len(report.Blocks) == 0 {
+			return nil
+		}
+
+		fmt.Println()
+		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "Epoch\tStatus\tWins\tMessages\tBlock Reward\tGas Reward\tPenalty Risk\tBlock")
+		for _, b := range report.Blocks {
+			blk := "-"
+			if b.Block != nil {
+				blk = b.Block.String()
+			}
+			if b.Error != "" {
+				blk = b.Error
+			}
+			_, _ = fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%s\t%s\t%s\t%s\n",
+				b.Epoch, b.Status, b.WinCount, b.Messages,
+				types.FIL(b.BlockReward).Short(), types.FIL(b.GasReward).Short(), types.FIL(b.PenaltyRisk).Short(), blk)
+		}
+		return tw.Flush()
+	},
+}
+
+var infoSpendReportCmd = &cli.Command{
+	Name:  "spend-report",
+	Usage: "Report the gas spent by the messages of the miner, per subsystem",
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:  "from",
+			Usage: "first epoch of the report, defaults to a day before the end",
+		},
+		&cli.Int64Flag{
+			Name:  "to",
+			Usage: "last epoch of the report, defaults to the chain head",
+		},
+		&cli.Int64Flag{
+			Name:        "bucket",
+			Usage:       "number of epochs aggregated in each bucket",
+			Value:       int64(builtin.EpochsInDay),
+			DefaultText: "a day",
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "output the report as json",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		minerApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		fullapi, acloser, err := lcli.GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer acloser()
+
+		ctx := lcli.ReqContext(cctx)
+
+		to := abi.ChainEpoch(cctx.Int64("to"))
+		if to <= 0 {
+			head, err := fullapi.ChainHead(ctx)
+			if err != nil {
+				return xerrors.Errorf("getting chain head: %w", err)
+			}
+			to = head.Height()
+		}
+		from := to - builtin.EpochsInDay
+		if cctx.IsSet("from") {
+			from = abi.ChainEpoch(cctx.Int64("from"))
+		}
+
+		report, err := minerApi.MinerSpendReport(ctx, from, to, abi.ChainEpoch(cctx.Int64("bucket")))
+		if err != nil {
+			return err
+		}
+
+		if cctx.Bool("json") {
+			out, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+			return nil
+		}
+
+		fmt.Printf("Epochs %d - %d\n", report.From, report.To)
+		fmt.Printf("Messages not executed yet: %d\n", report.Pending)
+
+		printTotals := func(totals []api.SpendTotal) error {
+			tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+			_, _ = fmt.Fprintln(tw, "Subsystem\tMessages\tGas Used\tBase Fee Burn\tOverestimation Burn\tMiner Tip\tTotal")
+			for _, t := range totals {
+				_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t%s\t%s\n",
+					t.Subsystem, t.Messages, t.GasUsed,
+					types.FIL(t.BaseFeeBurn).Short(), types.FIL(t.OverEstimationBurn).Short(), types.FIL(t.MinerTip).Short(), types.FIL(t.TotalCost).Short())
+			}
+			return tw.Flush()
+		}
+
+		fmt.Println()
+		if err := printTotals(report.Totals); err != nil {
+			return err
+		}
+
+		for _, b := range report.Buckets {
+			fmt.Printf("\nEpochs %d - %d:\n", b.Start, b.Start+report.Bucket-1)
+			if err := printTotals(b.Totals); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
@@ -35,6 +35,7 @@ import (
 	power6 "github.com/filecoin-project/specs-actors/v6/actors/builtin/power"
 
 	lapi "github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/api/client"
 	"github.com/filecoin-project/lotus/api/v0api"
 	"github.com/filecoin-project/lotus/api/v1api"
 	"github.com/filecoin-project/lotus/build"
@@ -120,6 +121,10 @@ var initCmd = &cli.Command{
 			Name:  "from",
 			Usage: "select which address to send actor creation message from",
 		},
+		&cli.StringFlag{
+			Name:  "profile",
+			Usage: "apply a config profile for the miner topology (see 'lotus-miner config profiles')",
+		},
 	},
 	Subcommands: []*cli.Command{
 		restoreCmd,
@@ -146,6 +151,12 @@ var initCmd = &cli.Command{
 			return xerrors.Errorf("failed to parse gas-price flag: %s", err)
 		}
 
+		if profile := cctx.String("profile"); profile != "" {
+			if _, err := config.GetMinerProfile(profile); err != nil {
+				return err
+			}
+		}
+
 		symlink := cctx.Bool("symlink-imported-sectors")
 		if symlink {
 			log.Info("will attempt to symlink to imported sectors")
@@ -197,15 +208,10 @@ var initCmd = &cli.Command{
 
 		log.Info("Checking full node version")
 
-		v, err := api.Version(ctx)
-		if err != nil {
+		if _, err := client.NegotiateCommon(ctx, api, lapi.FullAPIVersion1); err != nil {
 			return err
 		}
 
-		if !v.APIVersion.EqMajorMinor(lapi.FullAPIVersion1) {
-			return xerrors.Errorf("Remote API version didn't match (expected %s, remote %s)", lapi.FullAPIVersion1, v.APIVersion)
-		}
-
 		log.Info("Initializing repo")
 
 		if err := r.Init(repo.StorageMiner); err != nil {
@@ -260,6 +266,12 @@ var initCmd = &cli.Command{
 				return xerrors.Errorf("set storage config: %w", err)
 			}
 
+			if profile := cctx.String("profile"); profile != "" {
+				if err := applyConfigProfile(lr, profile); err != nil {
+					return err
+				}
+			}
+
 			if err := lr.Close(); err != nil {
 				return err
 			}
@@ -495,7 +507,7 @@ func storageMinerInit(ctx context.Context, cctx *cli.Context, api v1api.FullNode
 				return fmt.Errorf("failed to open filesystem journal: %w", err)
 			}
 
-			m := storageminer.NewMiner(api, epp, a, slashfilter.New(mds), j)
+			m := storageminer.NewMiner(api, epp, a, slashfilter.New(mds), storageminer.NewBlockJournal(mds), j)
 			{
 				if err := m.Start(ctx); err != nil {
 					return xerrors.Errorf("failed to start up genesis miner: %w", err)
@@ -19,6 +19,7 @@ import (
 
 	"github.com/filecoin-project/lotus/api"
 	lapi "github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/api/client"
 	"github.com/filecoin-project/lotus/api/v0api"
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/types"
@@ -109,15 +110,10 @@ func restore(ctx context.Context, cctx *cli.Context, targetPath string, strConfi
 
 	log.Info("Checking full node version")
 
-	v, err := api.Version(ctx)
-	if err != nil {
+	if _, err := client.NegotiateCommon(ctx, api, lapi.FullAPIVersion1); err != nil {
 		return err
 	}
 
-	if !v.APIVersion.EqMajorMinor(lapi.FullAPIVersion1) {
-		return xerrors.Errorf("Remote API version didn't match (expected %s, remote %s)", lapi.FullAPIVersion1, v.APIVersion)
-	}
-
 	if !cctx.Bool("nosync") {
 		if err := lcli.SyncWait(ctx, &v0api.WrapperV1Full{FullNode: api}, false); err != nil {
 			return xerrors.Errorf("sync wait: %w", err)
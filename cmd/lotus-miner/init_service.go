@@ -146,13 +146,8 @@ func checkApiInfo(ctx context.Context, ai string) (string, error) {
 	}
 	defer closer()
 
-	v, err := api.Version(ctx)
-	if err != nil {
-		return "", xerrors.Errorf("checking version: %w", err)
-	}
-
-	if !v.APIVersion.EqMajorMinor(lapi.MinerAPIVersion0) {
-		return "", xerrors.Errorf("remote service API version didn't match (expected %s, remote %s)", lapi.MinerAPIVersion0, v.APIVersion)
+	if _, err := client.NegotiateCommon(ctx, api, lapi.MinerAPIVersion0); err != nil {
+		return "", xerrors.Errorf("checking remote service version: %w", err)
 	}
 
 	return ai, nil
@@ -41,6 +41,7 @@ func main() {
 		stopCmd,
 		configCmd,
 		backupCmd,
+		selfTestCmd,
 		lcli.WithCategory("chain", actorCmd),
 		lcli.WithCategory("chain", infoCmd),
 		lcli.WithCategory("market", storageDealsCmd),
@@ -52,6 +53,7 @@ func main() {
 		lcli.WithCategory("storage", provingCmd),
 		lcli.WithCategory("storage", storageCmd),
 		lcli.WithCategory("storage", sealingCmd),
+		lcli.WithCategory("storage", paramsCmd),
 		lcli.WithCategory("retrieval", piecesCmd),
 	}
 
@@ -144,6 +146,8 @@ func main() {
 				Usage: "(experimental; may be removed) call this command against a markets node; use only with common commands like net, auth, pprof, etc. whose target may be ambiguous",
 			},
 			cliutil.FlagVeryVerbose,
+			cliutil.FlagWebsocketCompression,
+			cliutil.FlagWebsocketMaxFrameSize,
 		},
 		Commands: append(local, append(lcli.CommonCommands, &netCmd)...),
 		Before: func(c *cli.Context) error {
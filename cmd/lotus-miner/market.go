@@ -11,11 +11,13 @@ import (
 	"path/filepath"
 	"sort"
 	"strconv"
+	"strings"
 	"text/tabwriter"
 	"time"
 
 	tm "github.com/buger/goterm"
 	"github.com/docker/go-units"
+	"github.com/fatih/color"
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-cidutil/cidenc"
 	"github.com/libp2p/go-libp2p/core/peer"
@@ -32,6 +34,7 @@ import (
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/types"
 	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/lib/tablewriter"
 )
 
 var CidBaseFlag = cli.StringFlag{
@@ -213,6 +216,14 @@ var setAskCmd = &cli.Command{
 			DefaultText: "miner sector size",
 			Value:       "0",
 		},
+		&cli.StringFlag{
+			Name:  "price-curve",
+			Usage: "Scale the prices by piece size, as a comma separated list of `SIZE=MULT[/VERIFIED-MULT]` points, e.g. '1GiB=1.5,32GiB=1/0'; the multipliers of the largest point not above the size of a piece apply to it",
+		},
+		&cli.Int64Flag{
+			Name:  "at-epoch",
+			Usage: "Schedule the ask to take effect at `EPOCH` instead of setting it immediately",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
 		ctx := lcli.DaemonContext(cctx)
@@ -280,10 +291,85 @@ var setAskCmd = &cli.Command{
 			return xerrors.Errorf("max piece size (w/bit-padding) %s cannot exceed miner sector size %s", types.SizeStr(types.NewInt(uint64(max))), types.SizeStr(types.NewInt(uint64(smax))))
 		}
 
-		return marketsApi.MarketSetAsk(ctx, types.BigInt(pri), types.BigInt(vpri), abi.ChainEpoch(qty), abi.PaddedPieceSize(min), abi.PaddedPieceSize(max))
+		curve, err := parsePriceCurve(cctx.String("price-curve"))
+		if err != nil {
+			return xerrors.Errorf("parsing price-curve: %w", err)
+		}
+
+		ask := api.StorageAskSpec{
+			Price:         types.BigInt(pri),
+			VerifiedPrice: types.BigInt(vpri),
+			Duration:      abi.ChainEpoch(qty),
+			MinPieceSize:  abi.PaddedPieceSize(min),
+			MaxPieceSize:  abi.PaddedPieceSize(max),
+			PriceCurve:    curve,
+		}
+
+		switch {
+		case cctx.IsSet("at-epoch"):
+			return marketsApi.MarketScheduleAsk(ctx, abi.ChainEpoch(cctx.Int64("at-epoch")), ask)
+		case len(curve) > 0:
+			return marketsApi.MarketSetAskSpec(ctx, ask)
+		default:
+			return marketsApi.MarketSetAsk(ctx, ask.Price, ask.VerifiedPrice, ask.Duration, ask.MinPieceSize, ask.MaxPieceSize)
+		}
 	},
 }
 
+// parsePriceCurve parses the points of a price curve in the
+// 'SIZE=MULT[/VERIFIED-MULT],...' format. When the verified multiplier is
+// omitted it is the same as the unverified one.
+func parsePriceCurve(s string) ([]api.AskPricePoint, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var out []api.AskPricePoint
+	for _, point := range strings.Split(s, ",") {
+		size, mults, ok := strings.Cut(strings.TrimSpace(point), "=")
+		if !ok {
+			return nil, xerrors.Errorf("point %q must be in the SIZE=MULT[/VERIFIED-MULT] format", point)
+		}
+
+		sz, err := units.RAMInBytes(size)
+		if err != nil {
+			return nil, xerrors.Errorf("parsing size of point %q: %w", point, err)
+		}
+
+		mult, vmult, hasVerified := strings.Cut(mults, "/")
+		m, err := strconv.ParseFloat(mult, 64)
+		if err != nil {
+			return nil, xerrors.Errorf("parsing multiplier of point %q: %w", point, err)
+		}
+		vm := m
+		if hasVerified {
+			vm, err = strconv.ParseFloat(vmult, 64)
+			if err != nil {
+				return nil, xerrors.Errorf("parsing verified multiplier of point %q: %w", point, err)
+			}
+		}
+
+		out = append(out, api.AskPricePoint{
+			MinPieceSize:       abi.PaddedPieceSize(sz),
+			Multiplier:         m,
+			VerifiedMultiplier: vm,
+		})
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].MinPieceSize < out[j].MinPieceSize
+	})
+	return out, nil
+}
+
+func formatPriceCurve(curve []api.AskPricePoint) string {
+	points := make([]string, 0, len(curve))
+	for _, p := range curve {
+		points = append(points, fmt.Sprintf("%s=%g/%g", types.SizeStr(types.NewInt(uint64(p.MinPieceSize))), p.Multiplier, p.VerifiedMultiplier))
+	}
+	return strings.Join(points, ",")
+}
+
 var getAskCmd = &cli.Command{
 	Name:  "get-ask",
 	Usage: "Print the miner's ask",
@@ -334,6 +420,110 @@ var getAskCmd = &cli.Command{
 
 		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\t%d\n", types.FIL(ask.Price), types.FIL(ask.VerifiedPrice), types.SizeStr(types.NewInt(uint64(ask.MinPieceSize))), types.SizeStr(types.NewInt(uint64(ask.MaxPieceSize))), ask.Expiry, rem, ask.SeqNo)
 
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		spec, err := smapi.MarketGetAskSpec(ctx)
+		if err != nil {
+			return err
+		}
+		if spec != nil && len(spec.PriceCurve) > 0 {
+			fmt.Printf("\nPrice curve: %s\n", formatPriceCurve(spec.PriceCurve))
+		}
+
+		return nil
+	},
+}
+
+var askScheduleCmd = &cli.Command{
+	Name:  "ask-schedule",
+	Usage: "Manage the asks scheduled to take effect at future epochs",
+	Subcommands: []*cli.Command{
+		askScheduleListCmd,
+		askScheduleCancelCmd,
+	},
+}
+
+var askScheduleListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "List the scheduled asks",
+	Action: func(cctx *cli.Context) error {
+		ctx := lcli.DaemonContext(cctx)
+
+		smapi, closer, err := lcli.GetMarketsAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		scheduled, err := smapi.MarketListScheduledAsks(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		fmt.Fprintf(w, "Epoch\tPrice per GiB/Epoch\tVerified\tMin. Piece Size (padded)\tMax. Piece Size (padded)\tPrice Curve\n")
+		for _, s := range scheduled {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", s.Epoch, types.FIL(s.Ask.Price), types.FIL(s.Ask.VerifiedPrice),
+				types.SizeStr(types.NewInt(uint64(s.Ask.MinPieceSize))), types.SizeStr(types.NewInt(uint64(s.Ask.MaxPieceSize))), formatPriceCurve(s.Ask.PriceCurve))
+		}
+		return w.Flush()
+	},
+}
+
+var askScheduleCancelCmd = &cli.Command{
+	Name:      "cancel",
+	Usage:     "Cancel the ask scheduled at an epoch",
+	ArgsUsage: "<epoch>",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return lcli.IncorrectNumArgs(cctx)
+		}
+
+		epoch, err := strconv.ParseInt(cctx.Args().First(), 10, 64)
+		if err != nil {
+			return xerrors.Errorf("parsing epoch: %w", err)
+		}
+
+		smapi, closer, err := lcli.GetMarketsAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		return smapi.MarketCancelScheduledAsk(lcli.DaemonContext(cctx), abi.ChainEpoch(epoch))
+	},
+}
+
+var askHistoryCmd = &cli.Command{
+	Name:  "ask-history",
+	Usage: "Print the history of the changes to the miner's ask",
+	Action: func(cctx *cli.Context) error {
+		ctx := lcli.DaemonContext(cctx)
+
+		smapi, closer, err := lcli.GetMarketsAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		history, err := smapi.MarketAskHistory(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		fmt.Fprintf(w, "Time\tAction\tEpoch\tScheduled At\tPrice per GiB/Epoch\tVerified\tMin. Piece Size (padded)\tMax. Piece Size (padded)\tPrice Curve\n")
+		for _, c := range history {
+			scheduled := "-"
+			if c.ScheduledEpoch != 0 {
+				scheduled = fmt.Sprint(c.ScheduledEpoch)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\n", c.Time.Format(time.RFC3339), c.Action, c.Epoch, scheduled,
+				types.FIL(c.Ask.Price), types.FIL(c.Ask.VerifiedPrice),
+				types.SizeStr(types.NewInt(uint64(c.Ask.MinPieceSize))), types.SizeStr(types.NewInt(uint64(c.Ask.MaxPieceSize))), formatPriceCurve(c.Ask.PriceCurve))
+		}
 		return w.Flush()
 	},
 }
@@ -347,12 +537,167 @@ var storageDealsCmd = &cli.Command{
 		storageDealSelectionCmd,
 		setAskCmd,
 		getAskCmd,
+		askScheduleCmd,
+		askHistoryCmd,
 		setBlocklistCmd,
 		getBlocklistCmd,
 		resetBlocklistCmd,
 		setSealDurationCmd,
 		dealsPendingPublish,
 		dealsRetryPublish,
+		dealsCommPDiagnosticsCmd,
+		dealsSLACmd,
+		dealsLabelsCmd,
+		dealsExportCmd,
+	},
+}
+
+var dealsCommPDiagnosticsCmd = &cli.Command{
+	Name:      "commp-diagnostics",
+	Usage:     "Show the diagnostics of deals whose data didn't match the piece CID of the proposal",
+	ArgsUsage: "[proposal CID]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() > 1 {
+			return lcli.IncorrectNumArgs(cctx)
+		}
+
+		api, closer, err := lcli.GetMarketsAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.DaemonContext(cctx)
+
+		if !cctx.Args().Present() {
+			diags, err := api.MarketListCommPDiagnostics(ctx)
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+			_, _ = fmt.Fprintf(w, "Time\tProposal CID\tOffline\tData Size\tPiece Size\tFinding\n")
+			for _, d := range diags {
+				finding := d.Error
+				if len(d.Findings) > 0 {
+					finding = d.Findings[0]
+				}
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%t\t%s\t%s\t%s\n", d.Time.Format(time.RFC3339), d.ProposalCid, d.Offline,
+					units.BytesSize(float64(d.DataSize)), units.BytesSize(float64(d.ProposalPieceSize)), finding)
+			}
+			return w.Flush()
+		}
+
+		propCid, err := cid.Parse(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("parsing proposal cid: %w", err)
+		}
+
+		d, err := api.MarketCommPDiagnostics(ctx, propCid)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Proposal: %s\n", d.ProposalCid)
+		fmt.Printf("Time: %s\n", d.Time.Format(time.RFC3339))
+		fmt.Printf("Offline: %t\n", d.Offline)
+		fmt.Printf("Payload CID: %s\n", d.PayloadCid)
+		fmt.Printf("Proposal piece: %s (%d bytes)\n", d.ProposalPieceCid, d.ProposalPieceSize)
+		fmt.Printf("Calculated piece: %s\n", d.CalculatedPieceCid)
+		fmt.Printf("Natural piece: %s (%d bytes)\n", d.NaturalPieceCid, d.NaturalPieceSize)
+		if d.PayloadPieceCid != nil {
+			fmt.Printf("CARv1 payload piece: %s\n", *d.PayloadPieceCid)
+		}
+		fmt.Printf("Data size: %d bytes\n", d.DataSize)
+		fmt.Printf("CAR version: %d, roots: %v\n", d.CarVersion, d.CarRoots)
+		fmt.Printf("Blocks: %d\n", d.Blocks)
+		if d.FirstBlock != nil {
+			fmt.Printf("First block: %s at offset %d (%d bytes)\n", d.FirstBlock.Cid, d.FirstBlock.Offset, d.FirstBlock.Size)
+			fmt.Printf("Last block: %s at offset %d (%d bytes)\n", d.LastBlock.Cid, d.LastBlock.Offset, d.LastBlock.Size)
+		}
+		fmt.Printf("Trailing bytes: %d\n", d.TrailingBytes)
+		if d.Error != "" {
+			fmt.Printf("Error: %s\n", d.Error)
+		}
+		fmt.Println("Findings:")
+		for _, f := range d.Findings {
+			fmt.Printf("  - %s\n", f)
+		}
+		return nil
+	},
+}
+
+var dealsSLACmd = &cli.Command{
+	Name:  "sla",
+	Usage: "Compare the time deals took from the receipt of their data to their sector being Proving with the sealing SLA",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "breaches",
+			Usage: "only show the deals which breached the SLA or are at risk of breaching it",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		smapi, closer, err := lcli.GetMarketsAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.DaemonContext(cctx)
+
+		rep, err := smapi.MarketDealSLAReport(ctx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("SLA: %s\n", rep.SLA)
+		fmt.Printf("Deals: %s, %s, %d sealing, %d failed, %s\n",
+			color.RedString("%d breached", rep.Breached), color.YellowString("%d at risk", rep.AtRisk),
+			rep.Sealing, rep.Failed, color.GreenString("%d met", rep.Met))
+
+		tw := tablewriter.New(
+			tablewriter.Col("Received"),
+			tablewriter.Col("ProposalCid"),
+			tablewriter.Col("DealID"),
+			tablewriter.Col("Client"),
+			tablewriter.Col("Sector"),
+			tablewriter.Col("Elapsed"),
+			tablewriter.Col("State"),
+			tablewriter.NewLineCol("Message"))
+
+		for _, d := range rep.Deals {
+			if cctx.Bool("breaches") && d.State != api.DealSLABreached && d.State != api.DealSLAAtRisk {
+				continue
+			}
+
+			state := string(d.State)
+			switch d.State {
+			case api.DealSLABreached:
+				state = color.RedString(state)
+			case api.DealSLAAtRisk:
+				state = color.YellowString(state)
+			case api.DealSLAMet:
+				state = color.GreenString(state)
+			}
+
+			row := map[string]interface{}{
+				"Received":    d.DataReceived.Format(time.RFC3339),
+				"ProposalCid": d.ProposalCid,
+				"Client":      d.Client,
+				"Elapsed":     d.Elapsed.Truncate(time.Minute),
+				"State":       state,
+			}
+			if d.DealID != 0 {
+				row["DealID"] = d.DealID
+			}
+			if d.SectorNumber != 0 {
+				row["Sector"] = d.SectorNumber
+			}
+			if d.Message != "" {
+				row["Message"] = d.Message
+			}
+			tw.Write(row)
+		}
+
+		return tw.Flush(os.Stdout)
 	},
 }
 
@@ -639,9 +984,133 @@ var dataTransfersCmd = &cli.Command{
 		marketRestartTransfer,
 		marketCancelTransfer,
 		transfersDiagnosticsCmd,
+		transfersRestartsCmd,
+		transfersProgressCmd,
+	},
+}
+
+var transfersRestartsCmd = &cli.Command{
+	Name:  "restarts",
+	Usage: "List automatic restarts of stalled data transfers",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:    "verbose",
+			Aliases: []string{"v"},
+			Usage:   "list each restart",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := lcli.GetMarketsAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		hist, err := api.MarketDataTransferRestarts(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(w, "ID\tInitiator\tStatus\tLast Progress\tRestarts\tNext Restart\n")
+		for _, h := range hist {
+			next := "-"
+			switch {
+			case h.GaveUp:
+				next = "gave up"
+			case !h.NextRestart.IsZero():
+				next = h.NextRestart.Format(time.RFC3339)
+			}
+
+			_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%d\t%s\n", h.ChannelID.ID, h.ChannelID.Initiator, datatransfer.Statuses[h.Status], h.LastProgress.Format(time.RFC3339), len(h.Restarts), next)
+
+			if cctx.Bool("verbose") {
+				for _, r := range h.Restarts {
+					res := "ok"
+					if r.Error != "" {
+						res = r.Error
+					}
+					_, _ = fmt.Fprintf(w, "\t%s\tstalled %s\t%s\t\t\n", r.Time.Format(time.RFC3339), r.StalledFor.Truncate(time.Second), res)
+				}
+			}
+		}
+		return w.Flush()
 	},
 }
 
+var transfersProgressCmd = &cli.Command{
+	Name:      "progress",
+	Usage:     "Show the progress of storage deal data transfers",
+	ArgsUsage: "[proposal CID]",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "watch",
+			Usage: "print progress updates as they happen",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() > 1 || (!cctx.Args().Present() && !cctx.Bool("watch")) {
+			return lcli.IncorrectNumArgs(cctx)
+		}
+
+		api, closer, err := lcli.GetMarketsAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		var propCid cid.Cid
+		if cctx.Args().Present() {
+			propCid, err = cid.Parse(cctx.Args().First())
+			if err != nil {
+				return xerrors.Errorf("parsing proposal cid: %w", err)
+			}
+		}
+
+		if !cctx.Bool("watch") {
+			st, err := api.MarketDealTransferStatus(ctx, propCid)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Proposal: %s\n", st.ProposalCid)
+			fmt.Printf("Channel: %d (initiator %s)\n", st.ChannelID.ID, st.ChannelID.Initiator)
+			fmt.Printf("Status: %s\n", datatransferStatus(*st))
+			fmt.Printf("Received: %s in %d blocks\n", units.BytesSize(float64(st.BytesReceived)), st.BlocksVerified)
+			if !st.Finished {
+				fmt.Printf("Throughput: %s/s\n", units.BytesSize(float64(st.Throughput)))
+			}
+			if !st.LastProgress.IsZero() {
+				fmt.Printf("Last progress: %s\n", st.LastProgress.Format(time.RFC3339))
+			}
+			return nil
+		}
+
+		updates, err := api.MarketDealTransferUpdates(ctx)
+		if err != nil {
+			return err
+		}
+		for st := range updates {
+			if propCid.Defined() && st.ProposalCid != propCid {
+				continue
+			}
+			fmt.Printf("%s %s %s: %s in %d blocks, %s/s\n", time.Now().Format(time.RFC3339), st.ProposalCid,
+				datatransferStatus(st), units.BytesSize(float64(st.BytesReceived)), st.BlocksVerified, units.BytesSize(float64(st.Throughput)))
+		}
+		return nil
+	},
+}
+
+func datatransferStatus(st api.DealTransferStatus) string {
+	status := datatransfer.Statuses[st.Status]
+	if st.Stalled {
+		status += " (stalled)"
+	}
+	return status
+}
+
 var marketRestartTransfer = &cli.Command{
 	Name:  "restart",
 	Usage: "Force restart a stalled data transfer",
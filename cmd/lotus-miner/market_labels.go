@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ipfs/go-cid"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/markets/labels"
+)
+
+var dealsLabelsCmd = &cli.Command{
+	Name:  "labels",
+	Usage: "Manage the labels of pieces and deals",
+	Description: `Labels are user-defined key=value pairs attached to a piece, given its piece CID,
+   or to a deal, given its proposal CID. The labels of a deal are the labels of its
+   piece, overridden by the labels set on the deal.`,
+	Subcommands: []*cli.Command{
+		dealsLabelsSetCmd,
+		dealsLabelsGetCmd,
+	},
+}
+
+var dealsLabelsSetCmd = &cli.Command{
+	Name:      "set",
+	Usage:     "Set labels on a piece or a deal, an empty value removes the label",
+	ArgsUsage: "<piece or proposal CID> <key=value>...",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() < 2 {
+			return lcli.IncorrectNumArgs(cctx)
+		}
+
+		target, err := cid.Parse(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("parsing CID: %w", err)
+		}
+		l, err := parseLabels(cctx.Args().Tail())
+		if err != nil {
+			return err
+		}
+
+		api, closer, err := lcli.GetMarketsAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.DaemonContext(cctx)
+
+		return api.MarketSetLabels(ctx, target, l)
+	},
+}
+
+var dealsLabelsGetCmd = &cli.Command{
+	Name:      "get",
+	Usage:     "Get the labels set on a piece or a deal",
+	ArgsUsage: "<piece or proposal CID>",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return lcli.IncorrectNumArgs(cctx)
+		}
+
+		target, err := cid.Parse(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("parsing CID: %w", err)
+		}
+
+		api, closer, err := lcli.GetMarketsAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.DaemonContext(cctx)
+
+		l, err := api.MarketGetLabels(ctx, target)
+		if err != nil {
+			return err
+		}
+
+		keys := make([]string, 0, len(l))
+		for k := range l {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Printf("%s=%s\n", k, l[k])
+		}
+		return nil
+	},
+}
+
+var dealsExportCmd = &cli.Command{
+	Name:  "export",
+	Usage: "Export the deals of the miner on chain with their labels, as JSON lines",
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "label",
+			Usage: "only export the deals with this label, as key=value, or key to match any value",
+		},
+		&cli.StringFlag{
+			Name:  "piece",
+			Usage: "only export the deals of this piece CID",
+		},
+		&cli.StringFlag{
+			Name:  "output",
+			Usage: "file to write the deals to, instead of stdout",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		filter := api.MarketDealFilter{Labels: map[string]string{}}
+		for _, s := range cctx.StringSlice("label") {
+			k, v, _ := strings.Cut(s, "=")
+			if k == "" {
+				return xerrors.Errorf("invalid label filter %q", s)
+			}
+			filter.Labels[k] = v
+		}
+		if cctx.IsSet("piece") {
+			pc, err := cid.Parse(cctx.String("piece"))
+			if err != nil {
+				return xerrors.Errorf("parsing piece CID: %w", err)
+			}
+			filter.PieceCID = &pc
+		}
+
+		mapi, closer, err := lcli.GetMarketsAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.DaemonContext(cctx)
+
+		deals, err := mapi.MarketListDealsFiltered(ctx, filter)
+		if err != nil {
+			return err
+		}
+
+		var w io.Writer = os.Stdout
+		if cctx.IsSet("output") {
+			f, err := os.Create(cctx.String("output"))
+			if err != nil {
+				return err
+			}
+			defer f.Close() //nolint:errcheck
+			w = f
+		}
+
+		enc := json.NewEncoder(w)
+		for _, d := range deals {
+			if err := enc.Encode(d); err != nil {
+				return err
+			}
+		}
+
+		if cctx.IsSet("output") {
+			fmt.Printf("Exported %d deals to %s\n", len(deals), cctx.String("output"))
+		}
+		return nil
+	},
+}
+
+func parseLabels(args []string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, a := range args {
+		k, v, ok := strings.Cut(a, "=")
+		if !ok || k == "" {
+			return nil, xerrors.Errorf("invalid label %q, expected key=value", a)
+		}
+		if len(k) > labels.MaxLabelLength || len(v) > labels.MaxLabelLength {
+			return nil, xerrors.Errorf("label %q longer than %d bytes", k, labels.MaxLabelLength)
+		}
+		out[k] = v
+	}
+	return out, nil
+}
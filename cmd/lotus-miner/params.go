@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/docker/go-units"
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/lib/tablewriter"
+)
+
+var paramsCmd = &cli.Command{
+	Name:  "params",
+	Usage: "Manage proof parameter files",
+	Subcommands: []*cli.Command{
+		paramsStatusCmd,
+		paramsRepairCmd,
+	},
+}
+
+var paramsStatusCmd = &cli.Command{
+	Name:  "status",
+	Usage: "Show which proof parameter files the miner needs are present, missing or corrupt",
+	Description: `Files which weren't verified since the miner started are read in full to check
+their digest, which can take a while.`,
+	Action: func(cctx *cli.Context) error {
+		minerApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		files, err := minerApi.ParamsStatus(ctx)
+		if err != nil {
+			return err
+		}
+
+		tw := tablewriter.New(
+			tablewriter.Col("File"),
+			tablewriter.Col("SectorSize"),
+			tablewriter.Col("Size"),
+			tablewriter.Col("State"),
+			tablewriter.NewLineCol("Error"),
+		)
+
+		var bad int
+		for _, f := range files {
+			ssize := "all"
+			if f.SectorSize != 0 {
+				ssize = units.BytesSize(float64(f.SectorSize))
+			}
+
+			state := color.GreenString(string(f.State))
+			if f.State != api.ProofParamPresent {
+				state = color.RedString(string(f.State))
+				bad++
+			}
+
+			row := map[string]interface{}{
+				"File":       f.Name,
+				"SectorSize": ssize,
+				"Size":       units.BytesSize(float64(f.Size)),
+				"State":      state,
+			}
+			if f.Error != "" {
+				row["Error"] = f.Error
+			}
+			tw.Write(row)
+		}
+
+		if err := tw.Flush(os.Stdout); err != nil {
+			return err
+		}
+
+		if bad > 0 {
+			fmt.Printf("\n%d files need to be fetched, run 'lotus-miner params repair'\n", bad)
+		}
+		return nil
+	},
+}
+
+var paramsRepairCmd = &cli.Command{
+	Name:  "repair",
+	Usage: "Verify the proof parameter files, and fetch the missing and corrupt ones",
+	Description: `The files are fetched by the miner, from the mirrors configured in
+ProofParams.Mirrors, or the default gateway.`,
+	Action: func(cctx *cli.Context) error {
+		minerApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		if err := minerApi.ParamsRepair(ctx); err != nil {
+			return xerrors.Errorf("repairing proof parameters: %w", err)
+		}
+
+		fmt.Println("proof parameters ok")
+		return nil
+	},
+}
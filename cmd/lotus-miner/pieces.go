@@ -21,6 +21,7 @@ var piecesCmd = &cli.Command{
 		piecesListCidInfosCmd,
 		piecesInfoCmd,
 		piecesCidInfoCmd,
+		piecesRootCidsCmd,
 	},
 }
 
@@ -191,3 +192,36 @@ var piecesCidInfoCmd = &cli.Command{
 		return w.Flush()
 	},
 }
+
+var piecesRootCidsCmd = &cli.Command{
+	Name:      "root-cids",
+	Usage:     "get the payload root CIDs of a given piece CID",
+	ArgsUsage: "<piece CID>",
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Args().Present() {
+			return lcli.ShowHelp(cctx, fmt.Errorf("must specify piece cid"))
+		}
+
+		nodeApi, closer, err := lcli.GetMarketsAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		c, err := cid.Decode(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		roots, err := nodeApi.PiecesGetRootCids(ctx, c)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range roots {
+			fmt.Println(r)
+		}
+		return nil
+	},
+}
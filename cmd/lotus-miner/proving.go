@@ -21,6 +21,7 @@ import (
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-state-types/proof"
 
+	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/blockstore"
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
@@ -28,6 +29,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/types"
 	lcli "github.com/filecoin-project/lotus/cli"
 	cliutil "github.com/filecoin-project/lotus/cli/util"
+	"github.com/filecoin-project/lotus/lib/tablewriter"
 	"github.com/filecoin-project/lotus/storage/sealer/storiface"
 )
 
@@ -43,6 +45,10 @@ var provingCmd = &cli.Command{
 		workersCmd(false),
 		provingComputeCmd,
 		provingRecoverFaultsCmd,
+		provingDetectedFaultsCmd,
+		provingDisputesCmd,
+		provingLoadCmd,
+		provingRebalanceCmd,
 	},
 }
 
@@ -298,6 +304,121 @@ var provingDeadlinesCmd = &cli.Command{
 	},
 }
 
+var provingLoadCmd = &cli.Command{
+	Name:  "load",
+	Usage: "View the WindowPoSt load of each deadline and how unevenly it is spread",
+	Action: func(cctx *cli.Context) error {
+		minerApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := lcli.ReqContext(cctx)
+
+		report, err := minerApi.ProvingDeadlineLoad(ctx)
+		if err != nil {
+			return xerrors.Errorf("getting deadline load: %w", err)
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "deadline\tpartitions\tlive sectors\tactive\tfaulty\tterminated\tmutable\tcompactable")
+
+		for _, dl := range report.Deadlines {
+			var cur string
+			if report.CurrentDeadline == dl.Index {
+				cur = "\t(current)"
+			}
+			_, _ = fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%d\t%d\t%s\t%s%s\n", dl.Index, dl.Partitions, dl.LiveSectors, dl.ActiveSectors,
+				dl.FaultySectors, dl.TotalSectors-dl.LiveSectors, yesno(dl.Mutable), yesno(dl.Compactable), cur)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+
+		fmt.Println()
+		fmt.Printf("Live sectors per deadline: min %d, max %d, mean %.1f\n", report.MinLiveSectors, report.MaxLiveSectors, report.MeanLiveSectors)
+		fmt.Printf("Max partitions per deadline: %d (%d sectors per partition)\n", report.MaxPartitions, report.PartitionSectors)
+		fmt.Printf("Imbalance: %.1f%%\n", report.Imbalance*100)
+
+		return nil
+	},
+}
+
+var provingRebalanceCmd = &cli.Command{
+	Name:  "rebalance",
+	Usage: "Plan how new sectors and partition compactions even out the deadlines",
+	Description: `The miner actor assigns new sectors to the deadlines itself, choosing among the
+deadlines which don't open within a challenge window. The plan shows where it
+would put the given number of new sectors if they were committed now, and which
+partitions holding terminated sectors could be compacted.
+
+With --really-do-it, the compactions of the deadlines which can be compacted
+now are sent.`,
+	Flags: []cli.Flag{
+		&cli.Uint64Flag{
+			Name:  "new-sectors",
+			Usage: "number of new sectors to project the assignment of",
+		},
+		&cli.BoolFlag{
+			Name:  "really-do-it",
+			Usage: "send the compactions which can be sent now",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		minerApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := lcli.ReqContext(cctx)
+
+		plan, err := minerApi.ProvingRebalancePlan(ctx, cctx.Uint64("new-sectors"))
+		if err != nil {
+			return xerrors.Errorf("planning rebalance: %w", err)
+		}
+
+		if len(plan.Assignments) > 0 {
+			fmt.Printf("New sectors (%d):\n", plan.NewSectors)
+			for _, a := range plan.Assignments {
+				fmt.Printf("\tdeadline %d: %d sectors\n", a.Deadline, a.Sectors)
+			}
+		}
+
+		if len(plan.Compactions) > 0 {
+			fmt.Println("Compactions:")
+			for _, c := range plan.Compactions {
+				var when string
+				if !c.Compactable {
+					when = " (not compactable now)"
+				}
+				fmt.Printf("\tdeadline %d: partitions %v, saves %d partitions%s\n", c.Deadline, c.Partitions, c.SavedPartitions, when)
+			}
+		}
+
+		fmt.Printf("Imbalance: %.1f%% -> %.1f%%\n", plan.ImbalanceBefore*100, plan.ImbalanceAfter*100)
+		fmt.Printf("Max partitions per deadline: %d -> %d\n", plan.MaxPartitionsBefore, plan.MaxPartitionsAfter)
+
+		if !cctx.Bool("really-do-it") {
+			return nil
+		}
+
+		msgs, err := minerApi.ProvingRebalanceCompact(ctx)
+		for _, m := range msgs {
+			fmt.Printf("Sent compaction in message %s\n", m)
+		}
+		if err != nil {
+			return xerrors.Errorf("compacting partitions: %w", err)
+		}
+		if len(msgs) == 0 {
+			fmt.Println("No compactions to send now")
+		}
+
+		return nil
+	},
+}
+
 var provingDeadlineInfoCmd = &cli.Command{
 	Name:  "deadline",
 	Usage: "View the current proving period deadline information by its index",
@@ -768,3 +889,337 @@ var provingRecoverFaultsCmd = &cli.Command{
 		return nil
 	},
 }
+
+var provingDetectedFaultsCmd = &cli.Command{
+	Name:  "detected-faults",
+	Usage: "manage the faults detected from the storage index",
+	Description: `When Proving.EnableFaultDetection is set in the miner config, the miner
+watches the storage index for live sectors which lost their sealed copy, for
+example when the storage path holding it was declared dead. When
+Proving.DeclareDetectedFaults is set, the faults are declared before the fault
+declaration cutoff of their deadline, and when
+Proving.DeclareDetectedFaultsApproval is set, every declaration must be approved.`,
+	Subcommands: []*cli.Command{
+		provingDetectedFaultsListCmd,
+		provingDetectedFaultsApproveCmd,
+		provingDetectedFaultsRejectCmd,
+	},
+}
+
+var provingDetectedFaultsListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "list the sectors without a healthy sealed copy",
+	Action: func(cctx *cli.Context) error {
+		minerAPI, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		faults, err := minerAPI.ProvingDetectedFaults(ctx)
+		if err != nil {
+			return err
+		}
+
+		tw := tablewriter.New(
+			tablewriter.Col("Sector"),
+			tablewriter.Col("Deadline"),
+			tablewriter.Col("Partition"),
+			tablewriter.Col("Cutoff"),
+			tablewriter.Col("State"),
+			tablewriter.Col("Since"),
+			tablewriter.Col("Message"),
+			tablewriter.NewLineCol("Error"))
+
+		for _, f := range faults {
+			m := map[string]interface{}{
+				"Sector":    f.Sector,
+				"Deadline":  f.Deadline,
+				"Partition": f.Partition,
+				"Cutoff":    f.Cutoff,
+				"State":     f.State,
+				"Since":     time.Since(f.Since).Truncate(time.Second),
+			}
+			if f.Message != nil {
+				m["Message"] = f.Message.String()
+			}
+			if f.LastError != "" {
+				m["Error"] = f.LastError
+			}
+			tw.Write(m)
+		}
+
+		return tw.Flush(os.Stdout)
+	},
+}
+
+var provingDetectedFaultsApproveCmd = &cli.Command{
+	Name:      "approve",
+	Usage:     "approve the declaration of the detected faults of sectors",
+	ArgsUsage: "[sector numbers...]",
+	Action: func(cctx *cli.Context) error {
+		sectors, err := parseSectorNumberArgs(cctx)
+		if err != nil {
+			return err
+		}
+
+		minerAPI, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		return minerAPI.ProvingDetectedFaultsApprove(lcli.ReqContext(cctx), sectors)
+	},
+}
+
+var provingDetectedFaultsRejectCmd = &cli.Command{
+	Name:      "reject",
+	Usage:     "don't declare the detected faults of sectors until approved",
+	ArgsUsage: "[sector numbers...]",
+	Action: func(cctx *cli.Context) error {
+		sectors, err := parseSectorNumberArgs(cctx)
+		if err != nil {
+			return err
+		}
+
+		minerAPI, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		return minerAPI.ProvingDetectedFaultsReject(lcli.ReqContext(cctx), sectors)
+	},
+}
+
+var provingDisputesCmd = &cli.Command{
+	Name:  "disputes",
+	Usage: "inspect the disputes of the WindowPoSts of the miner",
+	Description: `When Proving.EnableDisputeWatcher is set in the miner config, the miner
+watches the chain for DisputeWindowedPoSt messages targeting it. Each dispute
+raises an alert until it is acknowledged, and the WindowPoSt submissions of the
+disputed deadline, with their proofs, are collected when it is detected.`,
+	Subcommands: []*cli.Command{
+		provingDisputesListCmd,
+		provingDisputesReportCmd,
+		provingDisputesAckCmd,
+	},
+}
+
+var provingDisputesListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "list the disputes, the latest first",
+	Action: func(cctx *cli.Context) error {
+		minerAPI, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		disputes, err := minerAPI.ProvingDisputes(ctx)
+		if err != nil {
+			return err
+		}
+
+		tw := tablewriter.New(
+			tablewriter.Col("Message"),
+			tablewriter.Col("Height"),
+			tablewriter.Col("Deadline"),
+			tablewriter.Col("PoSt"),
+			tablewriter.Col("Result"),
+			tablewriter.Col("Acknowledged"),
+			tablewriter.NewLineCol("Evidence"))
+
+		for _, d := range disputes {
+			m := map[string]interface{}{
+				"Message":      d.Message.String(),
+				"Height":       d.Height,
+				"Deadline":     d.Deadline,
+				"PoSt":         d.PoStIndex,
+				"Result":       disputeResult(d),
+				"Acknowledged": d.Acknowledged,
+			}
+			if d.Evidence != nil {
+				m["Evidence"] = fmt.Sprintf("%d submissions", len(d.Evidence.Submissions))
+			}
+			if d.EvidenceError != "" {
+				m["Evidence"] = d.EvidenceError
+			}
+			tw.Write(m)
+		}
+
+		return tw.Flush(os.Stdout)
+	},
+}
+
+var provingDisputesReportCmd = &cli.Command{
+	Name:      "report",
+	Usage:     "print what is known about a dispute",
+	ArgsUsage: "[message cid]",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the dispute, with the collected proofs, as json",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return lcli.IncorrectNumArgs(cctx)
+		}
+
+		mcid, err := cid.Parse(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("parsing message cid: %w", err)
+		}
+
+		minerAPI, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		disputes, err := minerAPI.ProvingDisputes(ctx)
+		if err != nil {
+			return err
+		}
+
+		var d *api.WdPoStDispute
+		for i := range disputes {
+			if disputes[i].Message == mcid {
+				d = &disputes[i]
+			}
+		}
+		if d == nil {
+			return xerrors.Errorf("no dispute sent in message %s", mcid)
+		}
+
+		if cctx.Bool("json") {
+			out, err := json.MarshalIndent(d, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+			return nil
+		}
+
+		fmt.Printf("Dispute: %s\n", d.Message)
+		fmt.Printf("Disputer: %s\n", d.From)
+		fmt.Printf("Executed at: %d (%s)\n", d.Height, d.TipSet)
+		fmt.Printf("Deadline: %d, proof %d\n", d.Deadline, d.PoStIndex)
+		fmt.Printf("Result: %s\n", disputeResult(*d))
+		fmt.Printf("Detected: %s\n", d.Detected.Format(time.RFC3339))
+		fmt.Printf("Acknowledged: %t\n", d.Acknowledged)
+
+		if d.EvidenceError != "" {
+			fmt.Printf("\nCollecting the evidence failed: %s\n", d.EvidenceError)
+		}
+		if d.Evidence == nil {
+			return nil
+		}
+
+		fmt.Printf("\nSubmissions of the proving window %d-%d:\n", d.Evidence.Open, d.Evidence.Close)
+		tw := tablewriter.New(
+			tablewriter.Col("Message"),
+			tablewriter.Col("Height"),
+			tablewriter.Col("From"),
+			tablewriter.Col("Exit Code"),
+			tablewriter.Col("Partitions"),
+			tablewriter.Col("Skipped"),
+			tablewriter.Col("Commit Epoch"),
+			tablewriter.Col("Disputed"))
+		for _, sub := range d.Evidence.Submissions {
+			var parts []string
+			var skipped uint64
+			for _, p := range sub.Params.Partitions {
+				parts = append(parts, strconv.FormatUint(p.Index, 10))
+				n, err := p.Skipped.Count()
+				if err != nil {
+					return err
+				}
+				skipped += n
+			}
+			m := map[string]interface{}{
+				"Message":      sub.Message.String(),
+				"Height":       sub.Height,
+				"From":         sub.From,
+				"Exit Code":    sub.ExitCode,
+				"Partitions":   strings.Join(parts, ","),
+				"Skipped":      skipped,
+				"Commit Epoch": sub.Params.ChainCommitEpoch,
+			}
+			if sub.Disputed {
+				m["Disputed"] = color.RedString("yes")
+			}
+			tw.Write(m)
+		}
+		if err := tw.Flush(os.Stdout); err != nil {
+			return err
+		}
+
+		fmt.Printf("\nPartitions before the dispute:\n")
+		tw = tablewriter.New(
+			tablewriter.Col("Partition"),
+			tablewriter.Col("Sectors"),
+			tablewriter.Col("Live"),
+			tablewriter.Col("Active"),
+			tablewriter.Col("Faulty"),
+			tablewriter.Col("Recovering"))
+		for i, p := range d.Evidence.Partitions {
+			m := map[string]interface{}{"Partition": i}
+			for col, bf := range map[string]bitfield.BitField{
+				"Sectors":    p.AllSectors,
+				"Live":       p.LiveSectors,
+				"Active":     p.ActiveSectors,
+				"Faulty":     p.FaultySectors,
+				"Recovering": p.RecoveringSectors,
+			} {
+				n, err := bf.Count()
+				if err != nil {
+					return err
+				}
+				m[col] = n
+			}
+			tw.Write(m)
+		}
+		return tw.Flush(os.Stdout)
+	},
+}
+
+func disputeResult(d api.WdPoStDispute) string {
+	if d.Successful {
+		return color.RedString("proof invalid")
+	}
+	return fmt.Sprintf("rejected (%s)", d.ExitCode)
+}
+
+var provingDisputesAckCmd = &cli.Command{
+	Name:      "ack",
+	Usage:     "acknowledge disputes, the dispute alert is resolved once all are acknowledged",
+	ArgsUsage: "[message cids...]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() == 0 {
+			return lcli.IncorrectNumArgs(cctx)
+		}
+
+		var msgs []cid.Cid
+		for _, s := range cctx.Args().Slice() {
+			c, err := cid.Parse(s)
+			if err != nil {
+				return xerrors.Errorf("parsing message cid %s: %w", s, err)
+			}
+			msgs = append(msgs, c)
+		}
+
+		minerAPI, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		return minerAPI.ProvingDisputesAck(lcli.ReqContext(cctx), msgs)
+	},
+}
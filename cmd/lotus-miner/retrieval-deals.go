@@ -3,13 +3,19 @@ package main
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"text/tabwriter"
+	"time"
 
 	"github.com/docker/go-units"
+	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
 	"github.com/filecoin-project/go-state-types/abi"
 
+	lapi "github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/chain/types"
 	lcli "github.com/filecoin-project/lotus/cli"
 )
@@ -21,6 +27,9 @@ var retrievalDealsCmd = &cli.Command{
 		retrievalDealSelectionCmd,
 		retrievalSetAskCmd,
 		retrievalGetAskCmd,
+		retrievalStatsCmd,
+		retrievalTimelineCmd,
+		retrievalRetrievabilityCmd,
 	},
 }
 
@@ -229,3 +238,205 @@ var retrievalGetAskCmd = &cli.Command{
 
 	},
 }
+
+var retrievalStatsCmd = &cli.Command{
+	Name:  "stats",
+	Usage: "Show the most retrieved pieces, the most active clients and hourly retrieval activity",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "top",
+			Usage: "number of pieces and clients to show",
+			Value: 10,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := lcli.DaemonContext(cctx)
+
+		api, closer, err := lcli.GetMarketsAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		st, err := api.MarketRetrievalStats(ctx, cctx.Int("top"))
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Since: %s\n", st.Since.Format(time.RFC3339))
+		fmt.Printf("Retrievals: %d (%s served)\n", st.TotalRetrievals, units.BytesSize(float64(st.TotalBytesServed)))
+
+		fmt.Println("\nTop pieces:")
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(w, "Piece CID\tRetrievals\tServed\tLast Retrieval\n")
+		for _, p := range st.TopPieces {
+			_, _ = fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", p.PieceCID, p.Retrievals, units.BytesSize(float64(p.BytesServed)), p.LastRetrieval.Format(time.RFC3339))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		fmt.Println("\nTop clients:")
+		w = tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(w, "Client\tRetrievals\tServed\tLast Retrieval\n")
+		for _, c := range st.TopClients {
+			_, _ = fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", c.Client, c.Retrievals, units.BytesSize(float64(c.BytesServed)), c.LastRetrieval.Format(time.RFC3339))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		fmt.Println("\nActivity:")
+		w = tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(w, "Hour\tRetrievals\tServed\n")
+		for _, b := range st.Buckets {
+			_, _ = fmt.Fprintf(w, "%s\t%d\t%s\n", b.Start.Format(time.RFC3339), b.Retrievals, units.BytesSize(float64(b.BytesServed)))
+		}
+		return w.Flush()
+	},
+}
+
+var retrievalTimelineCmd = &cli.Command{
+	Name:      "timeline",
+	Usage:     "Show the timeline of a retrieval, or list the most recent retrievals",
+	ArgsUsage: "[client peer ID] [deal ID]",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "limit",
+			Usage: "number of retrievals to list",
+			Value: 20,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 0 && cctx.NArg() != 2 {
+			return lcli.IncorrectNumArgs(cctx)
+		}
+
+		ctx := lcli.ReqContext(cctx)
+
+		api, closer, err := lcli.GetMarketsAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		if cctx.NArg() == 0 {
+			tls, err := api.MarketListRetrievalTimelines(ctx, cctx.Int("limit"))
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+			_, _ = fmt.Fprintf(w, "Start\tClient\tDeal\tPayload CID\tStatus\tSent\tDuration\n")
+			for _, tl := range tls {
+				duration := "ongoing"
+				if !tl.End.IsZero() {
+					duration = tl.End.Sub(tl.Start).Truncate(time.Millisecond).String()
+				}
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t%s\n", tl.Start.Format(time.RFC3339), tl.Receiver, tl.DealID, tl.PayloadCID,
+					retrievalmarket.DealStatuses[tl.Status], units.BytesSize(float64(tl.BytesSent)), duration)
+			}
+			return w.Flush()
+		}
+
+		receiver, err := peer.Decode(cctx.Args().Get(0))
+		if err != nil {
+			return xerrors.Errorf("parsing client peer ID: %w", err)
+		}
+		dealID, err := strconv.ParseUint(cctx.Args().Get(1), 10, 64)
+		if err != nil {
+			return xerrors.Errorf("parsing deal ID: %w", err)
+		}
+
+		tl, err := api.MarketRetrievalTimeline(ctx, receiver, retrievalmarket.DealID(dealID))
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Client:  %s\n", tl.Receiver)
+		fmt.Printf("Deal:    %d\n", tl.DealID)
+		fmt.Printf("Payload: %s\n", tl.PayloadCID)
+		if tl.PieceCID != nil {
+			fmt.Printf("Piece:   %s\n", tl.PieceCID)
+		}
+		fmt.Printf("Status:  %s\n", retrievalmarket.DealStatuses[tl.Status])
+		if tl.Message != "" {
+			fmt.Printf("Message: %s\n", tl.Message)
+		}
+		fmt.Printf("Sent:    %s\n", units.BytesSize(float64(tl.BytesSent)))
+
+		fmt.Println("\nEvents:")
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(w, "Time\tSince Start\tEvent\tMessage\n")
+		for _, e := range tl.Events {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.Time.Format(time.RFC3339), e.Time.Sub(tl.Start).Truncate(time.Millisecond), e.Event, e.Message)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		if len(tl.Samples) == 0 {
+			return nil
+		}
+
+		fmt.Println("\nThroughput:")
+		w = tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(w, "Time\tSent\tRate\n")
+		for _, smp := range tl.Samples {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s/s\n", smp.Time.Format(time.RFC3339), units.BytesSize(float64(smp.BytesSent)), units.BytesSize(float64(smp.BytesPerSecond)))
+		}
+		return w.Flush()
+	},
+}
+
+var retrievalRetrievabilityCmd = &cli.Command{
+	Name:  "retrievability",
+	Usage: "Show the results of the sampling of the retrievability of the deal data",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "limit",
+			Usage: "number of samples to show",
+			Value: 20,
+		},
+		&cli.BoolFlag{
+			Name:  "check",
+			Usage: "sample pieces now and show the results",
+		},
+		&cli.IntFlag{
+			Name:        "pieces",
+			Usage:       "number of pieces to sample with --check",
+			DefaultText: "configured number",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := lcli.ReqContext(cctx)
+
+		api, closer, err := lcli.GetMarketsAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		var samples []lapi.RetrievabilitySample
+		if cctx.Bool("check") {
+			samples, err = api.MarketRetrievabilityCheck(ctx, cctx.Int("pieces"))
+		} else {
+			samples, err = api.MarketRetrievabilitySamples(ctx, cctx.Int("limit"))
+		}
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(w, "Time\tPiece CID\tSector\tDeal\tUnsealed\tBlocks\tRead\tDuration\tResult\n")
+		for _, smp := range samples {
+			res := "ok"
+			if !smp.OK {
+				res = "failed: " + smp.Error
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%t\t%d\t%s\t%s\t%s\n", smp.Time.Format(time.RFC3339), smp.PieceCID, smp.Sector, smp.Deal, smp.Unsealed,
+				smp.Blocks, units.BytesSize(float64(smp.Bytes)), smp.Duration.Truncate(time.Millisecond), res)
+		}
+		return w.Flush()
+	},
+}
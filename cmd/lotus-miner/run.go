@@ -13,10 +13,12 @@ import (
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/api/client"
 	"github.com/filecoin-project/lotus/api/v0api"
 	"github.com/filecoin-project/lotus/api/v1api"
 	"github.com/filecoin-project/lotus/build"
 	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/lib/shutdown"
 	"github.com/filecoin-project/lotus/lib/ulimit"
 	"github.com/filecoin-project/lotus/metrics"
 	"github.com/filecoin-project/lotus/node"
@@ -91,8 +93,8 @@ var runCmd = &cli.Command{
 			}
 		}
 
-		if v.APIVersion != api.FullAPIVersion1 {
-			return xerrors.Errorf("lotus-daemon API version doesn't match: expected: %s", api.APIVersion{APIVersion: api.FullAPIVersion1})
+		if _, err := client.NegotiateCommon(ctx, nodeApi, api.FullAPIVersion1); err != nil {
+			return xerrors.Errorf("lotus-daemon API version doesn't match: %w", err)
 		}
 
 		log.Info("Checking full node sync status")
@@ -138,11 +140,13 @@ var runCmd = &cli.Command{
 		}
 
 		shutdownChan := make(chan struct{})
+		shutdowns := shutdown.NewCoordinator()
 
 		var minerapi api.StorageMiner
 		stop, err := node.New(ctx,
 			node.StorageMiner(&minerapi, cfg.Subsystems),
 			node.Override(new(dtypes.ShutdownChan), shutdownChan),
+			node.Override(new(*shutdown.Coordinator), shutdowns),
 			node.Base(),
 			node.Repo(r),
 
@@ -190,9 +194,9 @@ var runCmd = &cli.Command{
 		}
 
 		// Monitor for shutdown.
-		finishCh := node.MonitorShutdown(shutdownChan,
-			node.ShutdownHandler{Component: "rpc server", StopFunc: rpcStopper},
-			node.ShutdownHandler{Component: "miner", StopFunc: stop},
+		finishCh := node.MonitorShutdownWith(shutdowns, shutdownChan,
+			node.ShutdownHandler{Component: shutdown.ComponentRPC, StopFunc: rpcStopper},
+			node.ShutdownHandler{Component: shutdown.ComponentNode, StopFunc: stop},
 		)
 
 		<-finishCh
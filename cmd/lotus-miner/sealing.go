@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 	"time"
@@ -34,9 +35,13 @@ var sealingCmd = &cli.Command{
 	Usage: "interact with sealing pipeline",
 	Subcommands: []*cli.Command{
 		sealingJobsCmd,
+		sealingJobsTreeCmd,
+		sealingTaskLogsCmd,
 		workersCmd(true),
 		sealingSchedDiagCmd,
 		sealingAbortCmd,
+		sealingDrainCmd,
+		sealingUndrainCmd,
 		sealingDataCidCmd,
 	},
 }
@@ -98,6 +103,9 @@ func workersCmd(sealing bool) *cli.Command {
 				if !stat.Enabled {
 					disabled = color.RedString(" (disabled)")
 				}
+				if stat.Draining {
+					disabled += color.YellowString(" (draining)")
+				}
 
 				fmt.Printf("Worker %s, host %s%s\n", stat.id, color.MagentaString(stat.Info.Hostname), disabled)
 
@@ -308,6 +316,110 @@ var sealingJobsCmd = &cli.Command{
 	},
 }
 
+var sealingJobsTreeCmd = &cli.Command{
+	Name:  "jobs-tree",
+	Usage: "show queued, running and returned jobs of the miner and all workers",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "output in json format",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		minerApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := lcli.ReqContext(cctx)
+
+		tree, err := minerApi.SealingJobsTree(ctx)
+		if err != nil {
+			return xerrors.Errorf("getting jobs tree: %w", err)
+		}
+
+		if cctx.Bool("json") {
+			return json.NewEncoder(os.Stdout).Encode(tree)
+		}
+
+		dur := func(d time.Duration) string {
+			if d == 0 {
+				return "n/a"
+			}
+			return d.Truncate(time.Millisecond * 100).String()
+		}
+
+		fmt.Printf("Queued (%d):\n", len(tree.Queued))
+		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(tw, "  SchedId\tSector\tTask\tPriority\tWaiting\tBlocked\n")
+		for _, job := range tree.Queued {
+			blocked := "-"
+			if job.BlockedBy != "" {
+				blocked = color.RedString("%s: %s", job.BlockedBy, job.BlockedDetail)
+			}
+			_, _ = fmt.Fprintf(tw, "  %s\t%d\t%s\t%d\t%s\t%s\n",
+				hex.EncodeToString(job.SchedId[:4]),
+				job.Sector.Number,
+				job.Task.Short(),
+				job.Priority,
+				dur(job.Waiting),
+				blocked)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+
+		state := func(job storiface.TreeJob) string {
+			switch {
+			case job.RunWait > 1:
+				return fmt.Sprintf("assigned(%d)", job.RunWait-1)
+			case job.RunWait == storiface.RWPrepared:
+				return "prepared"
+			case job.RunWait == storiface.RWRetDone:
+				return "ret-done"
+			case job.RunWait == storiface.RWReturned:
+				return "returned"
+			case job.RunWait == storiface.RWRetWait:
+				return "ret-wait"
+			}
+			return "running"
+		}
+
+		printJobs := func(jobs []storiface.TreeJob) error {
+			tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+			for _, job := range jobs {
+				_, _ = fmt.Fprintf(tw, "    %s\t%d\t%s\t%s\t%s\n",
+					hex.EncodeToString(job.ID.ID[:4]),
+					job.Sector.Number,
+					job.Task.Short(),
+					state(job),
+					dur(job.Elapsed))
+			}
+			return tw.Flush()
+		}
+
+		fmt.Printf("\nWorkers (%d):\n", len(tree.Workers))
+		for _, w := range tree.Workers {
+			var flags string
+			if !w.Enabled {
+				flags += color.RedString(" (disabled)")
+			}
+			if w.Draining {
+				flags += color.YellowString(" (draining)")
+			}
+
+			fmt.Printf("  %s (%s)%s, %d jobs\n", w.Hostname, w.Worker, flags, len(w.Jobs))
+			if err := printJobs(w.Jobs); err != nil {
+				return err
+			}
+		}
+
+		fmt.Printf("\nReturned (%d):\n", len(tree.Returned))
+		return printJobs(tree.Returned)
+	},
+}
+
 var sealingSchedDiagCmd = &cli.Command{
 	Name:  "sched-diag",
 	Usage: "Dump internal scheduler state",
@@ -341,6 +453,175 @@ var sealingSchedDiagCmd = &cli.Command{
 	},
 }
 
+// the task types the logs can be requested for by their short name
+var taskLogTypes = []sealtasks.TaskType{
+	sealtasks.TTDataCid, sealtasks.TTAddPiece,
+	sealtasks.TTPreCommit1, sealtasks.TTPreCommit2, sealtasks.TTCommit1, sealtasks.TTCommit2,
+	sealtasks.TTFinalize, sealtasks.TTFinalizeUnsealed, sealtasks.TTFetch, sealtasks.TTUnseal,
+	sealtasks.TTReplicaUpdate, sealtasks.TTProveReplicaUpdate1, sealtasks.TTProveReplicaUpdate2,
+	sealtasks.TTRegenSectorKey, sealtasks.TTFinalizeReplicaUpdate, sealtasks.TTDownloadSector,
+}
+
+var sealingTaskLogsCmd = &cli.Command{
+	Name:      "task-logs",
+	Usage:     "show the output captured by the workers while running the tasks of a sector",
+	ArgsUsage: "[sector number] [task type, e.g. PC2]",
+	Description: `The workers started with a non-zero --task-log-retention send the output of
+   their tasks to the miner, which keeps it for Storage.TaskLogRetention. The output
+   of the tasks running at the same time on a worker can't be told apart, so it's
+   shown for each of them.`,
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "tail",
+			Usage: "only show the last lines of each task (0 = all)",
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "output in json format",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() < 1 || cctx.NArg() > 2 {
+			return lcli.IncorrectNumArgs(cctx)
+		}
+
+		sn, err := strconv.ParseUint(cctx.Args().First(), 10, 64)
+		if err != nil {
+			return xerrors.Errorf("parsing sector number: %w", err)
+		}
+
+		var tt sealtasks.TaskType
+		if cctx.NArg() == 2 {
+			arg := cctx.Args().Get(1)
+			for _, t := range taskLogTypes {
+				if strings.EqualFold(t.Short(), arg) || string(t) == arg {
+					tt = t
+					break
+				}
+			}
+			if tt == "" {
+				return xerrors.Errorf("unknown task type '%s'", arg)
+			}
+		}
+
+		minerApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := lcli.ReqContext(cctx)
+
+		logs, err := minerApi.SealingTaskLogs(ctx, abi.SectorNumber(sn), tt)
+		if err != nil {
+			return xerrors.Errorf("getting task logs: %w", err)
+		}
+
+		if tail := cctx.Int("tail"); tail > 0 {
+			for i := range logs {
+				if len(logs[i].Lines) > tail {
+					logs[i].Dropped += len(logs[i].Lines) - tail
+					logs[i].Lines = logs[i].Lines[len(logs[i].Lines)-tail:]
+				}
+			}
+		}
+
+		if cctx.Bool("json") {
+			return json.NewEncoder(os.Stdout).Encode(logs)
+		}
+
+		if len(logs) == 0 {
+			fmt.Println("No task logs")
+			return nil
+		}
+
+		for i, tl := range logs {
+			if i > 0 {
+				fmt.Println()
+			}
+
+			task := tl.Method
+			if tl.Task != "" {
+				task = tl.Task.Short()
+			}
+			res := color.GreenString("ok")
+			if tl.Error != "" {
+				res = color.RedString("failed: %s", tl.Error)
+			}
+			fmt.Printf("%s %s on %s, %s, took %s: %s\n",
+				task,
+				hex.EncodeToString(tl.CallID.ID[:4]),
+				tl.Worker,
+				tl.Start.Format(time.RFC3339),
+				tl.End.Sub(tl.Start).Truncate(time.Millisecond),
+				res)
+
+			if tl.Dropped > 0 {
+				fmt.Printf("  ... %d lines not shown\n", tl.Dropped)
+			}
+			for _, l := range tl.Lines {
+				fmt.Printf("  %s %-6s %s\n", l.Time.Format("15:04:05.000"), l.Stream, l.Message)
+			}
+		}
+
+		return nil
+	},
+}
+
+var sealingDrainCmd = &cli.Command{
+	Name:      "drain",
+	Usage:     "Stop assigning tasks to a worker, and detach it once its running tasks are done",
+	ArgsUsage: "[worker id]",
+	Description: `Draining workers don't get new tasks assigned. Once the tasks already assigned
+   to the worker are done, the sector files in its sealing-only storage paths are
+   moved to other workers, and the worker is detached from the miner.
+
+   Draining can be cancelled with 'lotus-miner sealing undrain' until the worker
+   is detached.`,
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return lcli.IncorrectNumArgs(cctx)
+		}
+
+		wid, err := uuid.Parse(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("parsing worker id: %w", err)
+		}
+
+		minerApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		return minerApi.WorkerDrain(lcli.ReqContext(cctx), wid)
+	},
+}
+
+var sealingUndrainCmd = &cli.Command{
+	Name:      "undrain",
+	Usage:     "Cancel draining a worker",
+	ArgsUsage: "[worker id]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return lcli.IncorrectNumArgs(cctx)
+		}
+
+		wid, err := uuid.Parse(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("parsing worker id: %w", err)
+		}
+
+		minerApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		return minerApi.WorkerUndrain(lcli.ReqContext(cctx), wid)
+	},
+}
+
 var sealingAbortCmd = &cli.Command{
 	Name:      "abort",
 	Usage:     "Abort a running job",
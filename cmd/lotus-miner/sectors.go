@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -26,6 +27,7 @@ import (
 	"github.com/filecoin-project/go-state-types/network"
 
 	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/api/v0api"
 	"github.com/filecoin-project/lotus/blockstore"
 	"github.com/filecoin-project/lotus/chain/actors"
 	"github.com/filecoin-project/lotus/chain/actors/adt"
@@ -39,6 +41,7 @@ import (
 	"github.com/filecoin-project/lotus/lib/strle"
 	"github.com/filecoin-project/lotus/lib/tablewriter"
 	sealing "github.com/filecoin-project/lotus/storage/pipeline"
+	"github.com/filecoin-project/lotus/storage/pipeline/sealiface"
 )
 
 const parallelSectorChecks = 300
@@ -51,6 +54,8 @@ var sectorsCmd = &cli.Command{
 		sectorsListCmd,
 		sectorsRefsCmd,
 		sectorsUpdateCmd,
+		sectorsRetryPolicyCmd,
+		sectorsImportPresealedCmd,
 		sectorsPledgeCmd,
 		sectorsNumbersCmd,
 		sectorPreCommitsCmd,
@@ -68,20 +73,47 @@ var sectorsCmd = &cli.Command{
 		sectorsRefreshPieceMatchingCmd,
 		sectorsCompactPartitionsCmd,
 		sectorsUnsealCmd,
+		sectorsUnsealRegenCmd,
 	},
 }
 
 var sectorsPledgeCmd = &cli.Command{
 	Name:  "pledge",
 	Usage: "store random data in a sector",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "only print what the sector is expected to cost, don't pledge it",
+		},
+	},
 	Action: func(cctx *cli.Context) error {
 		minerApi, closer, err := lcli.GetStorageMinerAPI(cctx)
 		if err != nil {
 			return err
 		}
 		defer closer()
+
+		nodeApi, ncloser, err := lcli.GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer ncloser()
 		ctx := lcli.ReqContext(cctx)
 
+		est, err := pledgeCostEstimate(ctx, minerApi, nodeApi)
+		if err != nil {
+			if cctx.Bool("dry-run") {
+				return err
+			}
+			fmt.Printf("Could not estimate the cost of the sector: %s\n", err)
+		} else {
+			printSectorCostEstimate(est)
+		}
+
+		if cctx.Bool("dry-run") {
+			return nil
+		}
+
 		id, err := minerApi.PledgeSector(ctx)
 		if err != nil {
 			return err
@@ -93,6 +125,55 @@ var sectorsPledgeCmd = &cli.Command{
 	},
 }
 
+// pledgeCostEstimate estimates the cost of a committed capacity sector sealed
+// with the proof type the miner uses for new sectors
+func pledgeCostEstimate(ctx context.Context, minerApi api.StorageMiner, nodeApi v0api.FullNode) (*api.SectorCostEstimate, error) {
+	maddr, err := minerApi.ActorAddress(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	mi, err := nodeApi.StateMinerInfo(ctx, maddr, types.EmptyTSK)
+	if err != nil {
+		return nil, err
+	}
+
+	nv, err := nodeApi.StateNetworkVersion(ctx, types.EmptyTSK)
+	if err != nil {
+		return nil, err
+	}
+
+	spt, err := lminer.PreferredSealProofTypeFromWindowPoStType(nv, mi.WindowPoStProofType)
+	if err != nil {
+		return nil, err
+	}
+
+	return minerApi.SectorsCostEstimate(ctx, spt, api.SectorDealMix{}, 0)
+}
+
+func printSectorCostEstimate(est *api.SectorCostEstimate) {
+	ssize, _ := est.SealProof.SectorSize()
+	fees := big.Sum(est.PreCommitFee, est.CommitFee, est.WindowPoStFee)
+
+	gasSource := "defaults"
+	if est.GasFromStats {
+		gasSource = "gas statistics of the last day"
+	}
+
+	fmt.Printf("Sector: %s committed for %d epochs (%d days), QA power %s\n", units.BytesSize(float64(ssize)), est.Duration,
+		est.Duration/builtin.EpochsInDay, types.SizeStr(est.QAPower))
+	fmt.Printf("Base fee at height %d: %s\n", est.Height, types.FIL(est.BaseFee).Short())
+	fmt.Printf("PreCommit deposit: %s\n", types.FIL(est.PreCommitDeposit))
+	fmt.Printf("Initial pledge: %s\n", types.FIL(est.InitialPledge))
+	fmt.Printf("Fees (%s): %s\n", gasSource, types.FIL(fees))
+	fmt.Printf("  PreCommit: %s\n", types.FIL(est.PreCommitFee))
+	fmt.Printf("  Commit: %s\n", types.FIL(est.CommitFee))
+	fmt.Printf("  WindowPoSt: %s\n", types.FIL(est.WindowPoStFee))
+	fmt.Printf("Expected reward: %s\n", types.FIL(est.ExpectedReward))
+	fmt.Printf("Termination penalty: %s, at most %s\n", types.FIL(est.TerminationPenalty), types.FIL(est.MaxTerminationPenalty))
+	fmt.Printf("Total needed: %s\n", types.FIL(big.Add(big.Max(est.PreCommitDeposit, est.InitialPledge), fees)))
+}
+
 var sectorsStatusCmd = &cli.Command{
 	Name:      "status",
 	Usage:     "Get the seal status of a sector by its number",
@@ -159,6 +240,11 @@ var sectorsStatusCmd = &cli.Command{
 		if status.LastErr != "" {
 			fmt.Printf("Last Error:\t\t%s\n", status.LastErr)
 		}
+		if e := status.LastErrExplanation; e != nil {
+			fmt.Printf("Error Category:\t\t%s\n", e.Category)
+			fmt.Printf("Explanation:\t\t%s\n", e.Summary)
+			fmt.Printf("Remediation:\t\t%s\n", e.Remediation)
+		}
 
 		if onChainInfo {
 			fmt.Printf("\nSector On Chain Info\n")
@@ -1654,6 +1740,70 @@ var sectorsUpdateCmd = &cli.Command{
 	},
 }
 
+var sectorsRetryPolicyCmd = &cli.Command{
+	Name:      "retry-policy",
+	Usage:     "show the retry policy applying to the failures of a sector",
+	ArgsUsage: "<sectorNum>",
+	Description: `The failures of the sectors are retried following the policy of their class,
+   set in Sealing.RetryPolicies in the miner config. A sector which exhausted its
+   retries stays in its failed state until it's moved out of it with
+   'lotus-miner sectors update-state', which also resets its count of failures.`,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "output in json format",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return lcli.IncorrectNumArgs(cctx)
+		}
+
+		id, err := strconv.ParseUint(cctx.Args().First(), 10, 64)
+		if err != nil {
+			return xerrors.Errorf("could not parse sector number: %w", err)
+		}
+
+		minerAPI, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		rp, err := minerAPI.SectorsRetryPolicy(ctx, abi.SectorNumber(id))
+		if err != nil {
+			return err
+		}
+
+		if cctx.Bool("json") {
+			return json.NewEncoder(os.Stdout).Encode(rp)
+		}
+
+		fmt.Printf("Sector:\t\t%d\n", rp.SectorID)
+		fmt.Printf("State:\t\t%s\n", rp.State)
+		if rp.Class == "" {
+			fmt.Println("Failures:\tnone since the sector last started proving")
+			return nil
+		}
+		fmt.Printf("Failures:\t%d consecutive %s failures\n", rp.Failures, rp.Class)
+
+		maxRetries := "no limit"
+		if rp.Policy.MaxRetries > 0 {
+			maxRetries = fmt.Sprint(rp.Policy.MaxRetries)
+		}
+		fmt.Printf("Max retries:\t%s\n", maxRetries)
+		fmt.Printf("Auto abort:\t%t\n", rp.Policy.AutoAbort)
+
+		if rp.Exhausted {
+			fmt.Println(color.RedString("Retries exhausted, the last failure isn't retried"))
+		} else {
+			fmt.Printf("Backoff:\t%s\n", rp.Backoff)
+		}
+		return nil
+	},
+}
+
 var sectorsExpiredCmd = &cli.Command{
 	Name:  "expired",
 	Usage: "Get or cleanup expired sectors",
@@ -1865,6 +2015,8 @@ var sectorsBatching = &cli.Command{
 	Subcommands: []*cli.Command{
 		sectorsBatchingPendingCommit,
 		sectorsBatchingPendingPreCommit,
+		sectorsBatchingSubmissions,
+		sectorsBatchingSimulate,
 	},
 }
 
@@ -1988,6 +2140,126 @@ var sectorsBatchingPendingPreCommit = &cli.Command{
 	},
 }
 
+var sectorsBatchingSubmissions = &cli.Command{
+	Name:  "submissions",
+	Usage: "list pending precommit, commit and window post messages, and whether they are deferred by the base fee cap",
+	Action: func(cctx *cli.Context) error {
+		minerAPI, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		pending, err := minerAPI.SectorsPendingSubmission(ctx)
+		if err != nil {
+			return xerrors.Errorf("getting pending submissions: %w", err)
+		}
+
+		tw := tablewriter.New(
+			tablewriter.Col("Class"),
+			tablewriter.Col("Sectors"),
+			tablewriter.Col("Deadline"),
+			tablewriter.Col("Deferred"),
+			tablewriter.Col("BaseFee"),
+			tablewriter.Col("FeeCap"),
+			tablewriter.Col("ForceAt"))
+
+		for _, ps := range pending {
+			if ps.Class != api.SubmissionWindowPoSt && len(ps.Sectors) == 0 {
+				continue
+			}
+
+			m := map[string]interface{}{
+				"Class": ps.Class,
+			}
+			if ps.Class == api.SubmissionWindowPoSt {
+				m["Deadline"] = ps.Deadline
+			} else {
+				m["Sectors"] = len(ps.Sectors)
+			}
+			if ps.Deferred {
+				m["Deferred"] = time.Since(ps.DeferredSince).Truncate(time.Second)
+				m["BaseFee"] = types.FIL(ps.BaseFee).Short()
+				m["FeeCap"] = types.FIL(ps.FeeCap).Short()
+				if ps.Class == api.SubmissionWindowPoSt {
+					m["ForceAt"] = fmt.Sprintf("epoch %d", ps.ForceEpoch)
+				} else {
+					m["ForceAt"] = ps.ForceAt.Format(time.Stamp)
+				}
+			} else {
+				m["Deferred"] = "no"
+			}
+			tw.Write(m)
+		}
+
+		return tw.Flush(os.Stdout)
+	},
+}
+
+var sectorsBatchingSimulate = &cli.Command{
+	Name:  "simulate",
+	Usage: "project the fees of the pending commits under each aggregation policy",
+	Action: func(cctx *cli.Context) error {
+		minerAPI, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		sim, err := minerAPI.SectorCommitAggregationSimulate(ctx)
+		if err != nil {
+			return xerrors.Errorf("simulating commit aggregation: %w", err)
+		}
+
+		if len(sim.Sectors) == 0 {
+			fmt.Println("No sectors queued to be committed")
+			return nil
+		}
+
+		fmt.Printf("Sectors: %d\n", len(sim.Sectors))
+		fmt.Printf("Height: %d, BaseFee: %s\n", sim.Height, types.FIL(sim.BaseFee).Short())
+		fmt.Println()
+
+		for _, p := range []struct {
+			name string
+			fee  sealiface.CommitFeeProjection
+		}{{"Individual", sim.Individual}, {"Aggregate", sim.Aggregate}} {
+			fmt.Printf("%s: %d message(s), gas limit %d, gas fee %s, network fee %s, total %s\n",
+				p.name, p.fee.Messages, p.fee.GasLimit, types.FIL(p.fee.GasFee).Short(), types.FIL(p.fee.NetworkFee).Short(), types.FIL(p.fee.Total).Short())
+		}
+		fmt.Println()
+
+		tw := tablewriter.New(
+			tablewriter.Col("Policy"),
+			tablewriter.Col("Decision"),
+			tablewriter.Col("Fee"),
+			tablewriter.Col("Reason"))
+
+		for _, d := range sim.Decisions {
+			policy := string(d.Policy)
+			if d.Policy == sim.Policy {
+				policy += " (configured)"
+			}
+
+			decision := "individual"
+			if d.Aggregate {
+				decision = "aggregate"
+			}
+
+			tw.Write(map[string]interface{}{
+				"Policy":   policy,
+				"Decision": decision,
+				"Fee":      types.FIL(d.Fee).Short(),
+				"Reason":   d.Reason,
+			})
+		}
+
+		return tw.Flush(os.Stdout)
+	},
+}
+
 var sectorsRefreshPieceMatchingCmd = &cli.Command{
 	Name:  "match-pending-pieces",
 	Usage: "force a refreshed match of pending pieces to open sectors without manually waiting for more deals",
@@ -2279,3 +2551,115 @@ var sectorsUnsealCmd = &cli.Command{
 		return minerAPI.SectorUnseal(ctx, abi.SectorNumber(sectorNum))
 	},
 }
+
+var sectorsUnsealRegenCmd = &cli.Command{
+	Name:  "unseal-regen",
+	Usage: "manage the regeneration of lost unsealed sector copies",
+	Description: `When Sealing.RegenerateUnsealed is set in the miner config, the miner
+regenerates the unsealed copies of sectors with active deals which were lost,
+for example when the storage path holding them died. When
+Sealing.RegenerateUnsealedApproval is set, every regeneration must be approved.`,
+	Subcommands: []*cli.Command{
+		sectorsUnsealRegenListCmd,
+		sectorsUnsealRegenApproveCmd,
+		sectorsUnsealRegenRejectCmd,
+	},
+}
+
+var sectorsUnsealRegenListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "list the sectors whose unsealed copy is lost",
+	Action: func(cctx *cli.Context) error {
+		minerAPI, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		jobs, err := minerAPI.SectorsUnsealRegenList(ctx)
+		if err != nil {
+			return err
+		}
+
+		tw := tablewriter.New(
+			tablewriter.Col("Sector"),
+			tablewriter.Col("State"),
+			tablewriter.Col("Deals"),
+			tablewriter.Col("Attempts"),
+			tablewriter.Col("Since"),
+			tablewriter.NewLineCol("Error"))
+
+		for _, j := range jobs {
+			m := map[string]interface{}{
+				"Sector":   j.Sector,
+				"State":    j.State,
+				"Deals":    j.Deals,
+				"Attempts": j.Attempts,
+				"Since":    time.Since(j.Since).Truncate(time.Second),
+			}
+			if j.LastError != "" {
+				m["Error"] = j.LastError
+			}
+			tw.Write(m)
+		}
+
+		return tw.Flush(os.Stdout)
+	},
+}
+
+func parseSectorNumberArgs(cctx *cli.Context) ([]abi.SectorNumber, error) {
+	if cctx.NArg() == 0 {
+		return nil, lcli.IncorrectNumArgs(cctx)
+	}
+
+	sectors := make([]abi.SectorNumber, cctx.NArg())
+	for i, a := range cctx.Args().Slice() {
+		n, err := strconv.ParseUint(a, 10, 64)
+		if err != nil {
+			return nil, xerrors.Errorf("could not parse sector number %q: %w", a, err)
+		}
+		sectors[i] = abi.SectorNumber(n)
+	}
+	return sectors, nil
+}
+
+var sectorsUnsealRegenApproveCmd = &cli.Command{
+	Name:      "approve",
+	Usage:     "approve the regeneration of the unsealed copy of sectors",
+	ArgsUsage: "[sector numbers...]",
+	Action: func(cctx *cli.Context) error {
+		sectors, err := parseSectorNumberArgs(cctx)
+		if err != nil {
+			return err
+		}
+
+		minerAPI, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		return minerAPI.SectorsUnsealRegenApprove(lcli.ReqContext(cctx), sectors)
+	},
+}
+
+var sectorsUnsealRegenRejectCmd = &cli.Command{
+	Name:      "reject",
+	Usage:     "don't regenerate the unsealed copy of sectors until approved",
+	ArgsUsage: "[sector numbers...]",
+	Action: func(cctx *cli.Context) error {
+		sectors, err := parseSectorNumberArgs(cctx)
+		if err != nil {
+			return err
+		}
+
+		minerAPI, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		return minerAPI.SectorsUnsealRegenReject(lcli.ReqContext(cctx), sectors)
+	},
+}
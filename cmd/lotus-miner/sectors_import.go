@@ -0,0 +1,281 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/ipfs/go-cid"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-commp-utils/zerocomm"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	lminer "github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/actors/policy"
+	"github.com/filecoin-project/lotus/chain/types"
+	lcli "github.com/filecoin-project/lotus/cli"
+	sealing "github.com/filecoin-project/lotus/storage/pipeline"
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+// presealManifest lists committed capacity sectors sealed up to PreCommit2
+// outside of the miner, like the genesis pre-seal manifests written by
+// lotus-seed, with the tickets the sectors were sealed with.
+type presealManifest struct {
+	Miner     address.Address
+	ProofType abi.RegisteredSealProof
+
+	Sectors []presealSector
+}
+
+type presealSector struct {
+	SectorID abi.SectorNumber
+
+	CommR cid.Cid
+	CommD cid.Cid
+
+	TicketValue abi.SealRandomness
+	TicketEpoch abi.ChainEpoch
+
+	// Locations of the sector files. When not set, the files are expected to
+	// be in the storage paths attached to the miner already.
+	Unsealed *storiface.SectorLocation `json:",omitempty"`
+	Sealed   *storiface.SectorLocation `json:",omitempty"`
+	Cache    *storiface.SectorLocation `json:",omitempty"`
+}
+
+// verifyManifest checks the manifest against the miner actor and the chain
+// head, the sectors whose tickets expire can't be precommitted anymore.
+func verifyManifest(m *presealManifest, maddr address.Address, spt abi.RegisteredSealProof, height abi.ChainEpoch) error {
+	if m.Miner != maddr {
+		return xerrors.Errorf("manifest is for miner %s, not %s", m.Miner, maddr)
+	}
+	if m.ProofType != spt {
+		return xerrors.Errorf("manifest seal proof type doesn't match the seal proof type of the miner (%d!=%d)", m.ProofType, spt)
+	}
+
+	ssize, err := spt.SectorSize()
+	if err != nil {
+		return err
+	}
+	zeroCommD := zerocomm.ZeroPieceCommitment(abi.PaddedPieceSize(ssize).Unpadded())
+
+	seen := map[abi.SectorNumber]struct{}{}
+	for _, s := range m.Sectors {
+		if _, dup := seen[s.SectorID]; dup {
+			return xerrors.Errorf("sector %d is listed more than once", s.SectorID)
+		}
+		seen[s.SectorID] = struct{}{}
+
+		if s.CommD != zeroCommD {
+			return xerrors.Errorf("sector %d: CommD %s isn't the CommD of a committed capacity sector", s.SectorID, s.CommD)
+		}
+		if !s.CommR.Defined() || s.CommR.Prefix().Codec != cid.FilCommitmentSealed {
+			return xerrors.Errorf("sector %d: CommR %s isn't a sealed sector commitment", s.SectorID, s.CommR)
+		}
+
+		if len(s.TicketValue) != abi.RandomnessLength {
+			return xerrors.Errorf("sector %d: ticket randomness had wrong length %d", s.SectorID, len(s.TicketValue))
+		}
+		if s.TicketEpoch > height-policy.SealRandomnessLookback {
+			return xerrors.Errorf("sector %d: ticket epoch %d is too recent", s.SectorID, s.TicketEpoch)
+		}
+		if s.TicketEpoch < height-policy.MaxPreCommitRandomnessLookback {
+			return xerrors.Errorf("sector %d: ticket epoch %d expired", s.SectorID, s.TicketEpoch)
+		}
+	}
+
+	return nil
+}
+
+func (s *presealSector) remoteMeta(mid abi.ActorID, spt abi.RegisteredSealProof, ssize abi.SectorSize) api.RemoteSectorMeta {
+	local := func(l *storiface.SectorLocation) *storiface.SectorLocation {
+		if l == nil {
+			return &storiface.SectorLocation{Local: true}
+		}
+		return l
+	}
+
+	commD, commR := s.CommD, s.CommR
+	return api.RemoteSectorMeta{
+		State:  api.SectorState(sealing.PreCommitting),
+		Sector: abi.SectorID{Miner: mid, Number: s.SectorID},
+		Type:   spt,
+
+		Pieces: []api.SectorPiece{{
+			Piece: abi.PieceInfo{
+				Size:     abi.PaddedPieceSize(ssize),
+				PieceCID: commD,
+			},
+		}},
+
+		TicketValue: s.TicketValue,
+		TicketEpoch: s.TicketEpoch,
+
+		CommD: &commD,
+		CommR: &commR,
+
+		DataUnsealed: local(s.Unsealed),
+		DataSealed:   local(s.Sealed),
+		DataCache:    local(s.Cache),
+	}
+}
+
+var sectorsImportPresealedCmd = &cli.Command{
+	Name:      "import-presealed",
+	Usage:     "import committed capacity sectors sealed outside of the miner",
+	ArgsUsage: "<manifest.json>",
+	Description: `The manifest lists CC sectors sealed up to PreCommit2 by an external tool. The sectors
+   are verified against the manifest and the chain, then imported in batches, and continue
+   sealing from PreCommitting into the proving set of the miner.
+
+   The cache of the sectors must not be finalized, as the miner computes Commit1 from it.
+   The sector files without a location in the manifest must be in a storage path attached
+   to the miner, the sector numbers should be reserved with 'sectors numbers reserve'.`,
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "batch-size",
+			Usage: "number of sectors imported with each call to the miner",
+			Value: 256,
+		},
+		&cli.BoolFlag{
+			Name:  "skip-file-check",
+			Usage: "don't check that the local sector files are in the storage of the miner",
+		},
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "only verify the manifest",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return lcli.IncorrectNumArgs(cctx)
+		}
+		if cctx.Int("batch-size") <= 0 {
+			return xerrors.Errorf("batch-size must be positive")
+		}
+
+		mb, err := os.ReadFile(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("reading manifest: %w", err)
+		}
+		var manifest presealManifest
+		if err := json.Unmarshal(mb, &manifest); err != nil {
+			return xerrors.Errorf("parsing manifest: %w", err)
+		}
+
+		minerAPI, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		fullAPI, nCloser, err := lcli.GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer nCloser()
+
+		ctx := lcli.ReqContext(cctx)
+
+		maddr, err := minerAPI.ActorAddress(ctx)
+		if err != nil {
+			return err
+		}
+		mid, err := address.IDFromAddress(maddr)
+		if err != nil {
+			return err
+		}
+
+		mi, err := fullAPI.StateMinerInfo(ctx, maddr, types.EmptyTSK)
+		if err != nil {
+			return xerrors.Errorf("getting miner info: %w", err)
+		}
+		nv, err := fullAPI.StateNetworkVersion(ctx, types.EmptyTSK)
+		if err != nil {
+			return xerrors.Errorf("getting network version: %w", err)
+		}
+		spt, err := lminer.PreferredSealProofTypeFromWindowPoStType(nv, mi.WindowPoStProofType)
+		if err != nil {
+			return err
+		}
+		ssize, err := spt.SectorSize()
+		if err != nil {
+			return err
+		}
+
+		head, err := fullAPI.ChainHead(ctx)
+		if err != nil {
+			return xerrors.Errorf("getting chain head: %w", err)
+		}
+
+		if err := verifyManifest(&manifest, maddr, spt, head.Height()); err != nil {
+			return xerrors.Errorf("verifying manifest: %w", err)
+		}
+
+		metas := make([]api.RemoteSectorMeta, len(manifest.Sectors))
+		for i, s := range manifest.Sectors {
+			metas[i] = s.remoteMeta(abi.ActorID(mid), spt, ssize)
+		}
+
+		if !cctx.Bool("skip-file-check") {
+			for _, meta := range metas {
+				ft := storiface.FTNone
+				if meta.DataSealed.Local {
+					ft |= storiface.FTSealed
+				}
+				if meta.DataCache.Local {
+					ft |= storiface.FTCache
+				}
+				for _, fileType := range ft.AllSet() {
+					si, err := minerAPI.StorageFindSector(ctx, meta.Sector, fileType, ssize, false)
+					if err != nil {
+						return xerrors.Errorf("finding sector %d: %w", meta.Sector.Number, err)
+					}
+					if len(si) == 0 {
+						return xerrors.Errorf("sector %d: %s file not found in the storage of the miner", meta.Sector.Number, fileType)
+					}
+				}
+			}
+		}
+
+		fmt.Printf("Manifest OK: %d sectors\n", len(metas))
+		if cctx.Bool("dry-run") {
+			return nil
+		}
+
+		var imported int
+		batchSize := cctx.Int("batch-size")
+		for start := 0; start < len(metas); start += batchSize {
+			end := start + batchSize
+			if end > len(metas) {
+				end = len(metas)
+			}
+
+			res, err := minerAPI.SectorReceiveBatch(ctx, metas[start:end])
+			if err != nil {
+				return xerrors.Errorf("importing sectors %d-%d of the manifest: %w", start, end-1, err)
+			}
+
+			for _, r := range res {
+				if r.Error != "" {
+					fmt.Printf("%s sector %d: %s\n", color.RedString("failed"), r.Sector, r.Error)
+					continue
+				}
+				imported++
+			}
+
+			fmt.Printf("imported %d/%d sectors\n", imported, end)
+		}
+
+		if imported != len(metas) {
+			return xerrors.Errorf("%d of %d sectors failed to import", len(metas)-imported, len(metas))
+		}
+		return nil
+	},
+}
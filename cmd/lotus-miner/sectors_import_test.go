@@ -0,0 +1,65 @@
+// stm: #unit
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-commp-utils/zerocomm"
+	commcid "github.com/filecoin-project/go-fil-commcid"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/actors/policy"
+)
+
+func TestVerifyManifest(t *testing.T) {
+	maddr, err := address.NewIDAddress(1000)
+	require.NoError(t, err)
+
+	spt := abi.RegisteredSealProof_StackedDrg2KiBV1_1
+	commR, err := commcid.ReplicaCommitmentV1ToCID(make([]byte, 32))
+	require.NoError(t, err)
+
+	height := abi.ChainEpoch(10000)
+	sector := func(n abi.SectorNumber) presealSector {
+		return presealSector{
+			SectorID:    n,
+			CommR:       commR,
+			CommD:       zerocomm.ZeroPieceCommitment(abi.PaddedPieceSize(2048).Unpadded()),
+			TicketValue: make(abi.SealRandomness, abi.RandomnessLength),
+			TicketEpoch: height - policy.SealRandomnessLookback - 10,
+		}
+	}
+	manifest := func(sectors ...presealSector) *presealManifest {
+		return &presealManifest{
+			Miner:     maddr,
+			ProofType: spt,
+			Sectors:   sectors,
+		}
+	}
+
+	require.NoError(t, verifyManifest(manifest(sector(1), sector(2)), maddr, spt, height))
+
+	other, err := address.NewIDAddress(1001)
+	require.NoError(t, err)
+	require.Error(t, verifyManifest(manifest(sector(1)), other, spt, height))
+
+	require.Error(t, verifyManifest(manifest(sector(1), sector(1)), maddr, spt, height))
+
+	withDeals := sector(1)
+	withDeals.CommD = commR
+	require.Error(t, verifyManifest(manifest(withDeals), maddr, spt, height))
+
+	expired := sector(1)
+	expired.TicketEpoch = height - policy.MaxPreCommitRandomnessLookback - 1
+	require.Error(t, verifyManifest(manifest(expired), maddr, spt, height))
+
+	s := sector(3)
+	meta := s.remoteMeta(1000, spt, 2048)
+	require.Equal(t, abi.SectorID{Miner: 1000, Number: 3}, meta.Sector)
+	require.True(t, meta.DataSealed.Local)
+	require.Len(t, meta.Pieces, 1)
+	require.Nil(t, meta.Pieces[0].DealInfo)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	lcli "github.com/filecoin-project/lotus/cli"
+)
+
+var selfTestCmd = &cli.Command{
+	Name:  "selftest",
+	Usage: "Check the clock, disks, proof parameters, reachability, peers and API tokens of the node",
+	Description: `The disk throughput is measured by writing a 256MiB file to the repo and to
+each local storage path. The clock is compared to pool.ntp.org, or to the server
+set in LOTUS_SELFTEST_NTP_SERVER.`,
+	Action: func(cctx *cli.Context) error {
+		minerApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		r, err := minerApi.MinerSelfTest(ctx)
+		if err != nil {
+			return err
+		}
+
+		if err := lcli.PrintSelfTestReport(os.Stdout, r); err != nil {
+			return err
+		}
+		if !r.Passed {
+			return xerrors.Errorf("some checks failed")
+		}
+		return nil
+	},
+}
@@ -19,7 +19,13 @@ var stopCmd = &cli.Command{
 		}
 		defer closer()
 
-		err = api.Shutdown(lcli.ReqContext(cctx))
+		ctx := lcli.ReqContext(cctx)
+
+		if err := lcli.PrintShutdownInFlight(ctx, cctx.App.Writer, api); err != nil {
+			log.Warnf("listing in-flight tasks: %s", err)
+		}
+
+		err = api.Shutdown(ctx)
 		if err != nil {
 			return err
 		}
@@ -48,6 +48,7 @@ stored while moving through the sealing pipeline (references as 'seal').`,
 		storageRedeclareCmd,
 		storageListCmd,
 		storageFindCmd,
+		storagePlacementCmd,
 		storageCleanupCmd,
 		storageLocks,
 	},
@@ -106,6 +107,10 @@ over time
 			Name:  "allow-to",
 			Usage: "path groups allowed to pull data from this path (allow all if not specified)",
 		},
+		&cli.StringSliceFlag{
+			Name:  "label",
+			Usage: "(for init) path label as key=value, the region, rack and host labels are the failure domains over which the copies of sector data are spread (can be repeated)",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
 		minerApi, closer, err := lcli.GetStorageMinerAPI(cctx)
@@ -139,6 +144,11 @@ over time
 				return err
 			}
 
+			labels, err := storiface.ParseLabels(cctx.StringSlice("label"))
+			if err != nil {
+				return err
+			}
+
 			var maxStor int64
 			if cctx.IsSet("max-storage") {
 				maxStor, err = units.RAMInBytes(cctx.String("max-storage"))
@@ -155,6 +165,7 @@ over time
 				MaxStorage: uint64(maxStor),
 				Groups:     cctx.StringSlice("groups"),
 				AllowTo:    cctx.StringSlice("allow-to"),
+				Labels:     labels,
 			}
 
 			if !(cfg.CanStore || cfg.CanSeal) {
@@ -418,6 +429,9 @@ var storageListCmd = &cli.Command{
 			if len(si.AllowTo) > 0 {
 				fmt.Printf("\tAllowTo: %s\n", strings.Join(si.AllowTo, ", "))
 			}
+			if len(si.Labels) > 0 {
+				fmt.Printf("\tLabels: %s\n", formatPathLabels(si.Labels))
+			}
 
 			if len(si.AllowTypes) > 0 || len(si.DenyTypes) > 0 {
 				denied := storiface.FTAll.SubAllowed(si.AllowTypes, si.DenyTypes)
@@ -946,6 +960,99 @@ func cleanupRemovedSectorData(ctx context.Context, api api.StorageMiner, napi v0
 	return nil
 }
 
+func formatPathLabels(labels map[string]string) string {
+	kv := make([]string, 0, len(labels))
+	for k, v := range labels {
+		kv = append(kv, k+"="+v)
+	}
+	sort.Strings(kv)
+	return strings.Join(kv, ", ")
+}
+
+var storagePlacementCmd = &cli.Command{
+	Name:  "placement",
+	Usage: "check how the copies of the sector data are spread over failure domains",
+	Description: `The copies of the data of a sector are its sealed replica and its unsealed
+copy, each can be regenerated from the other. When the long-term storage paths
+have "region", "rack" and "host" labels, new copies are preferably placed in a
+different failure domain than the other copies of the sector.
+
+This command lists the failure domains of the long-term storage paths, and the
+sectors with copies sharing a failure domain, the narrowest shared domain first.`,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the report as json",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		minerApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		r, err := minerApi.StoragePlacementReport(ctx)
+		if err != nil {
+			return err
+		}
+
+		if cctx.Bool("json") {
+			out, err := json.MarshalIndent(r, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+			return nil
+		}
+
+		fmt.Println("Failure domains:")
+		for _, d := range r.Domains {
+			fmt.Printf("\t%s: %d paths, %d copies\n", d.Domain, len(d.Paths), d.Copies)
+		}
+		if len(r.Unlabeled) > 0 {
+			fmt.Printf("\t%s\n", color.YellowString("%d paths without failure domain labels", len(r.Unlabeled)))
+			for _, id := range r.Unlabeled {
+				fmt.Printf("\t\t%s\n", id)
+			}
+		}
+		fmt.Println()
+
+		fmt.Printf("%d sectors with several copies, %d with copies sharing a failure domain\n", r.Sectors, len(r.CoLocated))
+		if len(r.CoLocated) == 0 {
+			return nil
+		}
+
+		tw := tablewriter.New(
+			tablewriter.Col("Sector"),
+			tablewriter.Col("Shared"),
+			tablewriter.Col("Copies"),
+		)
+		for _, sp := range r.CoLocated {
+			copies := make([]string, 0, len(sp.Copies))
+			for _, c := range sp.Copies {
+				cp := fmt.Sprintf("%s in %s", c.FileType, c.Storage)
+				if c.Domain != "" {
+					cp += fmt.Sprintf(" (%s)", c.Domain)
+				}
+				copies = append(copies, cp)
+			}
+
+			col := color.FgYellow
+			if sp.Shared == storiface.SamePath || sp.Shared == storiface.LabelHost {
+				col = color.FgRed
+			}
+			tw.Write(map[string]interface{}{
+				"Sector": sp.Sector.Number,
+				"Shared": color.New(col).Sprint(sp.Shared),
+				"Copies": strings.Join(copies, "; "),
+			})
+		}
+		return tw.Flush(os.Stdout)
+	},
+}
+
 var storageLocks = &cli.Command{
 	Name:  "locks",
 	Usage: "show active sector locks",
@@ -4,7 +4,10 @@ import (
 	"fmt"
 
 	"github.com/urfave/cli/v2"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/lotus/chain/types"
 	lcli "github.com/filecoin-project/lotus/cli"
 )
 
@@ -54,6 +57,13 @@ var computeStateRangeCmd = &cli.Command{
 	Name:      "compute-state-range",
 	Usage:     "forces the computation of a range of tipsets",
 	ArgsUsage: "[START_TIPSET_REF] [END_TIPSET_REF]",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "workers",
+			Usage: "number of tipsets computed in parallel; each tipset is computed on top of its parent state, so tipsets can be computed independently",
+			Value: 1,
+		},
+	},
 	Action: func(cctx *cli.Context) error {
 		if cctx.NArg() != 2 {
 			return lcli.IncorrectNumArgs(cctx)
@@ -77,32 +87,38 @@ var computeStateRangeCmd = &cli.Command{
 			return err
 		}
 
-		fmt.Printf("computing tipset at height %d (start)\n", startTs.Height())
-		if _, err := api.StateCompute(ctx, startTs.Height(), nil, startTs.Key()); err != nil {
-			return err
+		if cctx.Int("workers") < 1 {
+			return xerrors.Errorf("workers must be at least 1")
 		}
 
-		for height := startTs.Height() + 1; height < endTs.Height(); height++ {
-			fmt.Printf("computing tipset at height %d\n", height)
+		g, gctx := errgroup.WithContext(ctx)
+		g.SetLimit(cctx.Int("workers"))
+
+		compute := func(ts *types.TipSet, note string) {
+			g.Go(func() error {
+				fmt.Printf("computing tipset at height %d%s\n", ts.Height(), note)
+				_, err := api.StateCompute(gctx, ts.Height(), nil, ts.Key())
+				return err
+			})
+		}
 
+		compute(startTs, " (start)")
+
+		for height := startTs.Height() + 1; height < endTs.Height(); height++ {
 			// The fact that the tipset lookup method takes a tipset is rather annoying.
 			// This is because we walk back from the supplied tipset (which could be the HEAD)
 			// to locate the desired one.
 			ts, err := api.ChainGetTipSetByHeight(ctx, height, endTs.Key())
 			if err != nil {
+				_ = g.Wait()
 				return err
 			}
 
-			if _, err := api.StateCompute(ctx, height, nil, ts.Key()); err != nil {
-				return err
-			}
+			compute(ts, "")
 		}
 
-		fmt.Printf("computing tipset at height %d (end)\n", endTs.Height())
-		if _, err := api.StateCompute(ctx, endTs.Height(), nil, endTs.Key()); err != nil {
-			return err
-		}
+		compute(endTs, " (end)")
 
-		return nil
+		return g.Wait()
 	},
 }
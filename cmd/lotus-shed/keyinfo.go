@@ -48,6 +48,7 @@ var keyinfoCmd = &cli.Command{
 		keyinfoInfoCmd,
 		keyinfoImportCmd,
 		keyinfoVerifyCmd,
+		keyinfoConvertCmd,
 	},
 }
 
@@ -230,6 +231,95 @@ var keyinfoImportCmd = &cli.Command{
 	},
 }
 
+var keyinfoConvertCmd = &cli.Command{
+	Name:  "convert",
+	Usage: "convert a wallet key between formats",
+	Description: `The convert command migrates wallet keys between the lotus keyinfo format and the formats
+   of other wallets and HSMs, without the lotus daemon. The converted key is printed to stdout and its
+   address to stderr.
+
+   Input formats: ` + strings.Join(key.ImportFormats, ", ") + `
+   Output formats: ` + strings.Join(key.ExportFormats, ", ") + `
+
+   The raw format is the hex private key, big-endian for BLS keys unlike lotus. The mnemonic format is
+   a BIP-39 mnemonic the key is derived from, along a BIP-32 path for secp256k1 and delegated keys and
+   an EIP-2333 path for BLS keys.
+
+   Examples
+
+   Import the first Ethereum account of a mnemonic as a delegated key
+   lotus-shed keyinfo convert --input-format mnemonic --key-type delegated mnemonic.txt > wallet.keyinfo
+
+   Export a BLS key for another wallet
+   lotus-shed keyinfo convert --output-format raw wallet.keyinfo
+   `,
+	ArgsUsage: "[input file (optional, will read from stdin if omitted)]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "input-format",
+			Value: key.FormatHexLotus,
+			Usage: "format of the input key",
+		},
+		&cli.StringFlag{
+			Name:  "output-format",
+			Value: key.FormatHexLotus,
+			Usage: "format of the output key",
+		},
+		&cli.StringFlag{
+			Name:  "key-type",
+			Usage: "type of the key for the raw and mnemonic input formats: secp256k1, bls or delegated",
+		},
+		&cli.StringFlag{
+			Name:  "path",
+			Usage: "derivation path of the key for the mnemonic input format",
+		},
+		&cli.StringFlag{
+			Name:    "passphrase",
+			Usage:   "passphrase of the mnemonic",
+			EnvVars: []string{"LOTUS_MNEMONIC_PASSPHRASE"},
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		var input io.Reader = os.Stdin
+		if cctx.Args().Present() {
+			inputFile, err := os.Open(cctx.Args().First())
+			if err != nil {
+				return err
+			}
+			defer inputFile.Close() //nolint:errcheck
+			input = inputFile
+		}
+
+		data, err := io.ReadAll(input)
+		if err != nil {
+			return err
+		}
+
+		ki, err := key.Decode(data, cctx.String("input-format"), key.DecodeOptions{
+			Type:       types.KeyType(cctx.String("key-type")),
+			Path:       cctx.String("path"),
+			Passphrase: cctx.String("passphrase"),
+		})
+		if err != nil {
+			return xerrors.Errorf("decoding key: %w", err)
+		}
+
+		k, err := key.NewKey(*ki)
+		if err != nil {
+			return xerrors.Errorf("loading key: %w", err)
+		}
+
+		out, err := key.Encode(*ki, cctx.String("output-format"))
+		if err != nil {
+			return xerrors.Errorf("encoding key: %w", err)
+		}
+
+		fmt.Fprintln(os.Stderr, k.Address) //nolint:errcheck
+		fmt.Println(out)
+		return nil
+	},
+}
+
 var keyinfoInfoCmd = &cli.Command{
 	Name:  "info",
 	Usage: "print information about a keyinfo file",
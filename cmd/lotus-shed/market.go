@@ -28,6 +28,8 @@ import (
 	"github.com/filecoin-project/lotus/chain/types"
 	lcli "github.com/filecoin-project/lotus/cli"
 	"github.com/filecoin-project/lotus/lib/backupds"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/modules"
 	"github.com/filecoin-project/lotus/node/repo"
 )
 
@@ -39,6 +41,7 @@ var marketCmd = &cli.Command{
 		marketDealFeesCmd,
 		marketExportDatastoreCmd,
 		marketImportDatastoreCmd,
+		marketMigrateSQLDatastoreCmd,
 		marketDealsTotalStorageCmd,
 		marketCronStateCmd,
 	},
@@ -366,6 +369,66 @@ var marketImportDatastoreCmd = &cli.Command{
 	},
 }
 
+var marketMigrateSQLDatastoreCmd = &cli.Command{
+	Name:  "migrate-sql-datastore",
+	Usage: "Copy the state of the provider deals and data transfers to the SQL datastore configured in Dealmaking.Datastore",
+	Description: `The markets node must be stopped. The state is copied from the metadata
+   datastore to the SQL datastore, or back with --reverse before switching the
+   Dealmaking.Datastore.Backend to "metadata". The source state is left in place.`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "repo",
+			Usage: "path to the repo",
+		},
+		&cli.BoolFlag{
+			Name:  "reverse",
+			Usage: "copy the state from the SQL datastore to the metadata datastore",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		logging.SetLogLevel("badger", "ERROR") // nolint:errcheck
+
+		lr, err := openLockedRepo(cctx.String("repo"))
+		if err != nil {
+			return err
+		}
+		defer lr.Close() //nolint:errcheck
+
+		c, err := lr.Config()
+		if err != nil {
+			return xerrors.Errorf("getting config: %w", err)
+		}
+		cfg, ok := c.(*config.StorageMiner)
+		if !ok {
+			return xerrors.Errorf("wrong config type: %T", c)
+		}
+
+		sqlDs, err := modules.OpenMarketsSQLDatastore(cfg.Dealmaking.Datastore, lr.Path())
+		if err != nil {
+			return err
+		}
+		defer sqlDs.Close() //nolint:errcheck
+
+		repoDs, err := lr.Datastore(cctx.Context, datastore.NewKey(mktsMetadataNamespace).String())
+		if err != nil {
+			return xerrors.Errorf("opening datastore %s: %w", mktsMetadataNamespace, err)
+		}
+
+		var from, to datastore.Batching = repoDs, sqlDs
+		if cctx.Bool("reverse") {
+			from, to = sqlDs, repoDs
+		}
+
+		n, err := modules.CopyMarketsState(cctx.Context, from, to)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Copied %d keys\n", n)
+		return nil
+	},
+}
+
 var marketDealsTotalStorageCmd = &cli.Command{
 	Name:  "get-deals-total-storage",
 	Usage: "View the total storage available in all active market deals",
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/ipfs/go-datastore"
 	"github.com/ipfs/go-datastore/namespace"
 	logging "github.com/ipfs/go-log/v2"
 	manet "github.com/multiformats/go-multiaddr/net"
@@ -20,11 +21,14 @@ import (
 	"go.opencensus.io/tag"
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-jsonrpc/auth"
 	"github.com/filecoin-project/go-paramfetch"
+	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-statestore"
 
 	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/api/client"
 	"github.com/filecoin-project/lotus/build"
 	lcli "github.com/filecoin-project/lotus/cli"
 	cliutil "github.com/filecoin-project/lotus/cli/util"
@@ -36,8 +40,10 @@ import (
 	"github.com/filecoin-project/lotus/node/repo"
 	"github.com/filecoin-project/lotus/storage/paths"
 	"github.com/filecoin-project/lotus/storage/sealer"
+	"github.com/filecoin-project/lotus/storage/sealer/sealprovider"
 	"github.com/filecoin-project/lotus/storage/sealer/sealtasks"
 	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+	"github.com/filecoin-project/lotus/storage/sealer/tasklog"
 )
 
 var log = logging.Logger("main")
@@ -61,6 +67,7 @@ func main() {
 		waitQuietCmd,
 		resourcesCmd,
 		tasksCmd,
+		sealingServiceTokenCmd,
 	}
 
 	app := &cli.App{
@@ -116,6 +123,37 @@ func main() {
 	}
 }
 
+var sealingServiceTokenCmd = &cli.Command{
+	Name:      "sealing-service-token",
+	Usage:     "Print the token of an external miner to the sealing service, giving access to the sectors of that miner only",
+	ArgsUsage: "<miner address>",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "secret",
+			Usage:    "secret of the sealing service, as set with 'run --sealing-service-secret'",
+			EnvVars:  []string{"LOTUS_WORKER_SEALING_SERVICE_SECRET"},
+			Required: true,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return lcli.IncorrectNumArgs(cctx)
+		}
+
+		maddr, err := address.NewFromString(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("parsing miner address: %w", err)
+		}
+		mid, err := address.IDFromAddress(maddr)
+		if err != nil {
+			return xerrors.Errorf("miner address must be an ID address: %w", err)
+		}
+
+		fmt.Println(sealprovider.MinerToken(cctx.String("secret"), abi.ActorID(mid)))
+		return nil
+	},
+}
+
 var stopCmd = &cli.Command{
 	Name:  "stop",
 	Usage: "Stop a running lotus worker",
@@ -187,6 +225,12 @@ var runCmd = &cli.Command{
 			Value:   true,
 			EnvVars: []string{"LOTUS_WORKER_PRECOMMIT1"},
 		},
+		&cli.BoolFlag{
+			Name:    "precommit1-shared-params",
+			Usage:   "keep the SDR parent cache mapped in shared memory while precommit1 tasks run, and account for its memory once for all parallel precommit1 tasks",
+			Value:   false,
+			EnvVars: []string{"LOTUS_WORKER_PRECOMMIT1_SHARED_PARAMS"},
+		},
 		&cli.BoolFlag{
 			Name:    "unseal",
 			Usage:   "enable unsealing",
@@ -275,6 +319,28 @@ var runCmd = &cli.Command{
 			Name:  "http-server-timeout",
 			Value: "30s",
 		},
+		&cli.StringFlag{
+			Name:    "sealing-service-listen",
+			Usage:   "serve sectors in local storage to external miners importing them with SectorReceive on this address",
+			EnvVars: []string{"LOTUS_WORKER_SEALING_SERVICE_LISTEN"},
+		},
+		&cli.StringFlag{
+			Name:    "sealing-service-secret",
+			Usage:   "secret the tokens of external miners to the sealing service are issued with, see 'sealing-service-token'",
+			EnvVars: []string{"LOTUS_WORKER_SEALING_SERVICE_SECRET"},
+		},
+		&cli.DurationFlag{
+			Name:    "task-log-retention",
+			Usage:   "how long to keep the output captured while running tasks, which is also sent to the miner (0 = don't capture)",
+			Value:   7 * 24 * time.Hour,
+			EnvVars: []string{"LOTUS_WORKER_TASK_LOG_RETENTION"},
+		},
+		&cli.IntFlag{
+			Name:    "task-log-max-lines",
+			Usage:   "maximum number of lines kept in the log of a task, the oldest are dropped first",
+			Value:   10000,
+			EnvVars: []string{"LOTUS_WORKER_TASK_LOG_MAX_LINES"},
+		},
 	},
 	Before: func(cctx *cli.Context) error {
 		if cctx.IsSet("address") {
@@ -340,8 +406,8 @@ var runCmd = &cli.Command{
 		if err != nil {
 			return err
 		}
-		if v.APIVersion != api.MinerAPIVersion0 {
-			return xerrors.Errorf("lotus-miner API version doesn't match: expected: %s", api.APIVersion{APIVersion: api.MinerAPIVersion0})
+		if _, err := client.NegotiateCommon(ctx, nodeApi, api.MinerAPIVersion0); err != nil {
+			return xerrors.Errorf("lotus-miner API version doesn't match: %w", err)
 		}
 		log.Infof("Remote version %s", v)
 
@@ -551,6 +617,21 @@ var runCmd = &cli.Command{
 
 		wsts := statestore.New(namespace.Wrap(ds, modules.WorkerCallsPrefix))
 
+		var taskLogs *tasklog.Recorder
+		if retention := cctx.Duration("task-log-retention"); retention > 0 {
+			tls := tasklog.NewStore(namespace.Wrap(ds, datastore.NewKey("/tasklogs")), retention)
+			if err := tls.Start(ctx); err != nil {
+				return err
+			}
+			defer tls.Stop(context.TODO()) //nolint:errcheck
+
+			taskLogs = tasklog.NewRecorder(tls, cctx.Int("task-log-max-lines"))
+			if err := taskLogs.CaptureOutput(); err != nil {
+				return xerrors.Errorf("capturing output for task logs: %w", err)
+			}
+			defer taskLogs.CaptureLogs().Close() //nolint:errcheck
+		}
+
 		workerApi := &sealworker.Worker{
 			LocalWorker: sealer.NewLocalWorker(sealer.WorkerConfig{
 				TaskTypes:                 taskTypes,
@@ -558,6 +639,8 @@ var runCmd = &cli.Command{
 				MaxParallelChallengeReads: cctx.Int("post-parallel-reads"),
 				ChallengeReadTimeout:      cctx.Duration("post-read-timeout"),
 				Name:                      cctx.String("name"),
+				SharedPC1Params:           cctx.Bool("precommit1-shared-params"),
+				TaskLogs:                  taskLogs,
 			}, remote, localStore, nodeApi, nodeApi, wsts),
 			LocalStore: localStore,
 			Storage:    lr,
@@ -588,6 +671,38 @@ var runCmd = &cli.Command{
 			log.Warn("Graceful shutdown successful")
 		}()
 
+		if listen := cctx.String("sealing-service-listen"); listen != "" {
+			provider, err := sealprovider.New(localStore, nodeApi, cctx.String("sealing-service-secret"))
+			if err != nil {
+				return xerrors.Errorf("setting up sealing service: %w", err)
+			}
+
+			ssrv := &http.Server{
+				Handler:           provider,
+				ReadHeaderTimeout: timeout,
+			}
+
+			sl, err := net.Listen("tcp", listen)
+			if err != nil {
+				return xerrors.Errorf("listening for sealing service requests: %w", err)
+			}
+
+			log.Infof("Serving sealing service at %s", listen)
+
+			go func() {
+				if err := ssrv.Serve(sl); err != nil && err != http.ErrServerClosed {
+					log.Errorf("sealing service server failed: %s", err)
+				}
+			}()
+
+			go func() {
+				<-ctx.Done()
+				if err := ssrv.Shutdown(context.TODO()); err != nil {
+					log.Errorf("shutting down sealing service server failed: %s", err)
+				}
+			}()
+		}
+
 		nl, err := net.Listen("tcp", address)
 		if err != nil {
 			return err
@@ -662,15 +777,30 @@ var runCmd = &cli.Command{
 					readyCh = waitQuietCh()
 				}
 
+				var lost bool
 				for {
 					curSession, err := nodeApi.Session(ctx)
 					if err != nil {
 						log.Errorf("heartbeat: checking remote session failed: %+v", err)
+						lost = true
 					} else {
 						if curSession != minerSession {
 							minerSession = curSession
 							break
 						}
+
+						// The miner doesn't reconnect to the worker when the
+						// connection drops, register again over a new connection.
+						// The miner resumes the worker as its session didn't
+						// change, and running tasks aren't affected.
+						if lost && readyCh == nil {
+							if err := nodeApi.WorkerConnect(ctx, "http://"+address+"/rpc/v0"); err != nil {
+								log.Errorf("Registering worker again failed: %+v", err)
+							} else {
+								log.Info("Worker registered again after losing the connection to the miner")
+								lost = false
+							}
+						}
 					}
 
 					select {
@@ -68,6 +68,10 @@ func (w *Worker) Version(context.Context) (api.Version, error) {
 	return api.WorkerAPIVersion0, nil
 }
 
+func (w *Worker) Capabilities(context.Context) (api.APICapabilities, error) {
+	return api.CapabilitiesForType(api.NodeWorker)
+}
+
 func (w *Worker) StorageLocal(ctx context.Context) (map[storiface.ID]string, error) {
 	l, err := w.LocalStore.Local(ctx)
 	if err != nil {
@@ -60,6 +60,10 @@ var storageAttachCmd = &cli.Command{
 			Name:  "allow-to",
 			Usage: "path groups allowed to pull data from this path (allow all if not specified)",
 		},
+		&cli.StringSliceFlag{
+			Name:  "label",
+			Usage: "(for init) path label as key=value, the region, rack and host labels are the failure domains over which the copies of sector data are spread (can be repeated)",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
 		nodeApi, closer, err := lcli.GetWorkerAPI(cctx)
@@ -93,6 +97,11 @@ var storageAttachCmd = &cli.Command{
 				return err
 			}
 
+			labels, err := storiface.ParseLabels(cctx.StringSlice("label"))
+			if err != nil {
+				return err
+			}
+
 			var maxStor int64
 			if cctx.IsSet("max-storage") {
 				maxStor, err = units.RAMInBytes(cctx.String("max-storage"))
@@ -109,6 +118,7 @@ var storageAttachCmd = &cli.Command{
 				MaxStorage: uint64(maxStor),
 				Groups:     cctx.StringSlice("groups"),
 				AllowTo:    cctx.StringSlice("allow-to"),
+				Labels:     labels,
 			}
 
 			if !(cfg.CanStore || cfg.CanSeal) {
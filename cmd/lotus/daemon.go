@@ -40,10 +40,13 @@ import (
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/chain/vm"
 	lcli "github.com/filecoin-project/lotus/cli"
+	cliutil "github.com/filecoin-project/lotus/cli/util"
 	"github.com/filecoin-project/lotus/journal"
 	"github.com/filecoin-project/lotus/journal/fsjournal"
 	"github.com/filecoin-project/lotus/lib/httpreader"
 	"github.com/filecoin-project/lotus/lib/peermgr"
+	"github.com/filecoin-project/lotus/lib/rpcws"
+	"github.com/filecoin-project/lotus/lib/shutdown"
 	"github.com/filecoin-project/lotus/lib/ulimit"
 	"github.com/filecoin-project/lotus/metrics"
 	"github.com/filecoin-project/lotus/node"
@@ -71,7 +74,13 @@ var daemonStopCmd = &cli.Command{
 		}
 		defer closer()
 
-		err = api.Shutdown(lcli.ReqContext(cctx))
+		ctx := lcli.ReqContext(cctx)
+
+		if err := lcli.PrintShutdownInFlight(ctx, cctx.App.Writer, api); err != nil {
+			log.Warnf("listing in-flight tasks: %s", err)
+		}
+
+		err = api.Shutdown(ctx)
 		if err != nil {
 			return err
 		}
@@ -84,7 +93,7 @@ var daemonStopCmd = &cli.Command{
 var DaemonCmd = &cli.Command{
 	Name:  "daemon",
 	Usage: "Start a lotus daemon process",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.StringFlag{
 			Name:  "api",
 			Value: "1234",
@@ -160,7 +169,11 @@ var DaemonCmd = &cli.Command{
 			Name:  "restore-config",
 			Usage: "config file to use when restoring from backup",
 		},
-	},
+		&cli.BoolFlag{
+			Name:  "selftest",
+			Usage: "check the clock, repo disk, proof parameters, listen addresses, bootstrap peers and API token before starting, and don't start when a check fails",
+		},
+	}, cliutil.WebsocketServerFlags...),
 	Action: func(cctx *cli.Context) error {
 		isLite := cctx.Bool("lite")
 
@@ -284,6 +297,19 @@ var DaemonCmd = &cli.Command{
 			}
 		}
 
+		if cctx.Bool("selftest") {
+			report, err := daemonSelfTest(ctx, cctx, r, isLite)
+			if err != nil {
+				return xerrors.Errorf("running self-test: %w", err)
+			}
+			if err := lcli.PrintSelfTestReport(os.Stdout, report); err != nil {
+				return err
+			}
+			if !report.Passed {
+				return xerrors.Errorf("self-test failed, not starting the node")
+			}
+		}
+
 		genesis := node.Options()
 		if len(genBytes) > 0 {
 			genesis = node.Override(new(modules.Genesis), modules.LoadGenesis(genBytes))
@@ -296,6 +322,7 @@ var DaemonCmd = &cli.Command{
 		}
 
 		shutdownChan := make(chan struct{})
+		shutdowns := shutdown.NewCoordinator()
 
 		// If the daemon is started in "lite mode", provide a  Gateway
 		// for RPC calls
@@ -326,6 +353,7 @@ var DaemonCmd = &cli.Command{
 
 			node.Override(new(dtypes.Bootstrapper), isBootstrapper),
 			node.Override(new(dtypes.ShutdownChan), shutdownChan),
+			node.Override(new(*shutdown.Coordinator), shutdowns),
 
 			genesis,
 			liteModeDeps,
@@ -375,6 +403,11 @@ var DaemonCmd = &cli.Command{
 			return fmt.Errorf("failed to instantiate rpc handler: %s", err)
 		}
 
+		h, err = rpcws.Handler(h, cliutil.WebsocketServerOptions(cctx))
+		if err != nil {
+			return xerrors.Errorf("configuring websocket connections: %w", err)
+		}
+
 		// Serve the RPC.
 		rpcStopper, err := node.ServeRPC(h, "lotus-daemon", endpoint)
 		if err != nil {
@@ -382,9 +415,9 @@ var DaemonCmd = &cli.Command{
 		}
 
 		// Monitor for shutdown.
-		finishCh := node.MonitorShutdown(shutdownChan,
-			node.ShutdownHandler{Component: "rpc server", StopFunc: rpcStopper},
-			node.ShutdownHandler{Component: "node", StopFunc: stop},
+		finishCh := node.MonitorShutdownWith(shutdowns, shutdownChan,
+			node.ShutdownHandler{Component: shutdown.ComponentRPC, StopFunc: rpcStopper},
+			node.ShutdownHandler{Component: shutdown.ComponentNode, StopFunc: stop},
 		)
 		<-finishCh // fires when shutdown is complete.
 
@@ -0,0 +1,144 @@
+//go:build !nodaemon
+// +build !nodaemon
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	lapi "github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/lib/paramfetch"
+	"github.com/filecoin-project/lotus/lib/selftest"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/modules"
+	"github.com/filecoin-project/lotus/node/repo"
+)
+
+// size of the file written to measure the repo disk throughput, and the write
+// rate below which a warning is reported
+const (
+	selfTestDiskSize = 256 << 20
+	selfTestRepoRate = 50 << 20
+)
+
+// daemonSelfTest checks the environment of the daemon before the node starts.
+func daemonSelfTest(ctx context.Context, cctx *cli.Context, r *repo.FsRepo, isLite bool) (*lapi.SelfTestReport, error) {
+	lr, err := r.Lock(repo.FullNode)
+	if err != nil {
+		return nil, xerrors.Errorf("locking repo: %w", err)
+	}
+	defer lr.Close() //nolint:errcheck
+
+	c, err := lr.Config()
+	if err != nil {
+		return nil, xerrors.Errorf("getting config: %w", err)
+	}
+	cfg, ok := c.(*config.FullNode)
+	if !ok {
+		return nil, xerrors.Errorf("wrong config type: %T", c)
+	}
+
+	checks := []selftest.Check{
+		selftest.ClockSkew(selftest.NTPServer(), time.Duration(build.AllowableClockDriftSecs)*time.Second),
+		selftest.DiskThroughput("repo", lr.Path(), selfTestDiskSize, selfTestRepoRate),
+	}
+
+	if !isLite {
+		pf, err := paramfetch.New(paramfetch.DefaultDir(), nil, build.ParametersJSON(), build.SrsJSON())
+		if err != nil {
+			return nil, xerrors.Errorf("creating proof parameter fetcher: %w", err)
+		}
+		checks = append(checks, selftest.Params(pf, 0))
+	}
+
+	var listen []multiaddr.Multiaddr
+	for _, a := range cfg.Libp2p.ListenAddresses {
+		ma, err := multiaddr.NewMultiaddr(a)
+		if err != nil {
+			return nil, xerrors.Errorf("parsing listen address %s: %w", a, err)
+		}
+		listen = append(listen, ma)
+	}
+	checks = append(checks, selftest.Bindable("libp2p", listen))
+
+	apiAddr := cfg.API.ListenAddress
+	if cctx.IsSet("api") {
+		apiAddr = "/ip4/127.0.0.1/tcp/" + cctx.String("api")
+	}
+	apima, err := multiaddr.NewMultiaddr(apiAddr)
+	if err != nil {
+		return nil, xerrors.Errorf("parsing api address %s: %w", apiAddr, err)
+	}
+	checks = append(checks, selftest.Bindable("api", []multiaddr.Multiaddr{apima}))
+
+	if cctx.Bool("bootstrap") {
+		peers, err := build.BuiltinBootstrap()
+		if err != nil {
+			return nil, xerrors.Errorf("getting bootstrap peers: %w", err)
+		}
+		checks = append(checks, bootstrapPeersCheck(peers, 30*time.Second))
+	}
+
+	checks = append(checks, modules.SelfTestAPIToken(lr))
+
+	report := selftest.Run(ctx, checks)
+	return &report, nil
+}
+
+// bootstrapPeersCheck dials the bootstrap peers from a temporary libp2p host.
+// The check fails when no peer can be reached, and warns when less than half
+// of them can.
+func bootstrapPeersCheck(peers []peer.AddrInfo, timeout time.Duration) selftest.Check {
+	return selftest.Check{
+		Name: "peer connectivity",
+		Run: func(ctx context.Context) (lapi.SelfTestResult, string) {
+			if len(peers) == 0 {
+				return lapi.SelfTestSkip, "no bootstrap peers"
+			}
+
+			h, err := libp2p.New(libp2p.NoListenAddrs)
+			if err != nil {
+				return lapi.SelfTestFail, fmt.Sprintf("creating libp2p host: %s", err)
+			}
+			defer h.Close() //nolint:errcheck
+
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			var lk sync.Mutex
+			var wg sync.WaitGroup
+			connected := 0
+			for _, p := range peers {
+				wg.Add(1)
+				go func(p peer.AddrInfo) {
+					defer wg.Done()
+					if err := h.Connect(ctx, p); err == nil {
+						lk.Lock()
+						connected++
+						lk.Unlock()
+					}
+				}(p)
+			}
+			wg.Wait()
+
+			msg := fmt.Sprintf("connected to %d of %d bootstrap peers", connected, len(peers))
+			switch {
+			case connected == 0:
+				return lapi.SelfTestFail, msg
+			case connected*2 < len(peers):
+				return lapi.SelfTestWarn, msg
+			}
+			return lapi.SelfTestPass, msg
+		},
+	}
+}
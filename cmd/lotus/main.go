@@ -102,6 +102,8 @@ func main() {
 				Usage: "if true, will ignore pre-send checks",
 			},
 			cliutil.FlagVeryVerbose,
+			cliutil.FlagWebsocketCompression,
+			cliutil.FlagWebsocketMaxFrameSize,
 		},
 		After: func(c *cli.Context) error {
 			if r := recover(); r != nil {
@@ -41,6 +41,14 @@ func (gw *Node) EthBlockNumber(ctx context.Context) (ethtypes.EthUint64, error)
 	return gw.target.EthBlockNumber(ctx)
 }
 
+func (gw *Node) EthSyncing(ctx context.Context) (ethtypes.EthSyncingResult, error) {
+	if err := gw.limit(ctx, basicRateLimitTokens); err != nil {
+		return ethtypes.EthSyncingResult{}, err
+	}
+
+	return gw.target.EthSyncing(ctx)
+}
+
 func (gw *Node) EthGetBlockTransactionCountByNumber(ctx context.Context, blkNum ethtypes.EthUint64) (ethtypes.EthUint64, error) {
 	if err := gw.limit(ctx, stateRateLimitTokens); err != nil {
 		return 0, err
@@ -208,6 +216,26 @@ func (gw *Node) EthGetTransactionReceiptLimited(ctx context.Context, txHash etht
 	return gw.target.EthGetTransactionReceiptLimited(ctx, txHash, limit)
 }
 
+func (gw *Node) EthGetBlockReceipts(ctx context.Context, blkParam string) ([]*api.EthTxReceipt, error) {
+	if err := gw.limit(ctx, stateRateLimitTokens); err != nil {
+		return nil, err
+	}
+
+	if len(blkParam) == 2+2*ethtypes.EthHashLength {
+		blkHash, err := ethtypes.ParseEthHash(blkParam)
+		if err != nil {
+			return nil, err
+		}
+		if err := gw.checkBlkHash(ctx, blkHash); err != nil {
+			return nil, err
+		}
+	} else if err := gw.checkBlkParam(ctx, blkParam, 0); err != nil {
+		return nil, err
+	}
+
+	return gw.target.EthGetBlockReceipts(ctx, blkParam)
+}
+
 func (gw *Node) EthGetCode(ctx context.Context, address ethtypes.EthAddress, blkOpt string) (ethtypes.EthBytes, error) {
 	if err := gw.limit(ctx, stateRateLimitTokens); err != nil {
 		return nil, err
@@ -345,6 +373,14 @@ func (gw *Node) EthSendRawTransaction(ctx context.Context, rawTx ethtypes.EthByt
 	return gw.target.EthSendRawTransaction(ctx, rawTx)
 }
 
+func (gw *Node) EthSimulateRawTransaction(ctx context.Context, rawTx ethtypes.EthBytes) (*api.EthTxSimulation, error) {
+	if err := gw.limit(ctx, stateRateLimitTokens); err != nil {
+		return nil, err
+	}
+
+	return gw.target.EthSimulateRawTransaction(ctx, rawTx)
+}
+
 func (gw *Node) EthGetLogs(ctx context.Context, filter *ethtypes.EthFilterSpec) (*ethtypes.EthFilterResult, error) {
 	if err := gw.limit(ctx, stateRateLimitTokens); err != nil {
 		return nil, err
@@ -78,6 +78,18 @@ func (gw *Node) ChainGetParentReceipts(ctx context.Context, c cid.Cid) ([]*types
 	return gw.target.ChainGetParentReceipts(ctx, c)
 }
 
+func (gw *Node) ChainGetParentReceiptsBatch(ctx context.Context, tsks []types.TipSetKey) ([]api.ParentReceipts, error) {
+	if err := gw.limit(ctx, stateRateLimitTokens); err != nil {
+		return nil, err
+	}
+	for _, tsk := range tsks {
+		if err := gw.checkTipsetKey(ctx, tsk); err != nil {
+			return nil, err
+		}
+	}
+	return gw.target.ChainGetParentReceiptsBatch(ctx, tsks)
+}
+
 func (gw *Node) ChainGetBlockMessages(ctx context.Context, c cid.Cid) (*api.BlockMessages, error) {
 	if err := gw.limit(ctx, chainRateLimitTokens); err != nil {
 		return nil, err
@@ -12,6 +12,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/market"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/cmd/lotus-shed/shedgen"
+	"github.com/filecoin-project/lotus/markets/allocations"
 	"github.com/filecoin-project/lotus/node/hello"
 	"github.com/filecoin-project/lotus/paychmgr"
 	sectorstorage "github.com/filecoin-project/lotus/storage/sealer"
@@ -66,6 +67,17 @@ func main() {
 		api.PieceDealInfo{},
 		api.SectorPiece{},
 		api.DealSchedule{},
+		api.MessageAttestation{},
+	)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	err = gen.WriteTupleEncodersToFile("./markets/allocations/cbor_gen.go", "allocations",
+		allocations.AllocationRequests{},
+		allocations.AllocationRequest{},
+		allocations.ClaimExtensionRequest{},
 	)
 	if err != nil {
 		fmt.Println(err)
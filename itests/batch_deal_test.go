@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ipfs/go-cid"
 	"github.com/stretchr/testify/require"
 
 	"github.com/filecoin-project/go-state-types/abi"
@@ -139,6 +140,31 @@ func TestBatchDealInput(t *testing.T) {
 			require.NoError(t, err)
 			require.Equal(t, len(sl), expectSectors)
 
+			// batch lookups return the same info, and errors for the unknown items
+			statuses, err := miner.SectorsStatusBatch(ctx, append(sl, 12345), false)
+			require.NoError(t, err)
+			require.Len(t, statuses, len(sl)+1)
+			for i, snum := range sl {
+				si, err := miner.SectorsStatus(ctx, snum, false)
+				require.NoError(t, err)
+				require.Empty(t, statuses[i].Error)
+				require.Equal(t, snum, statuses[i].SectorID)
+				require.Equal(t, si.State, statuses[i].Info.State)
+			}
+			require.NotEmpty(t, statuses[len(sl)].Error)
+			require.Nil(t, statuses[len(sl)].Info)
+
+			pieces, err := miner.PiecesListPieces(ctx)
+			require.NoError(t, err)
+			pinfos, err := miner.PiecesGetBatch(ctx, append(pieces, cid.MustParse("baga6ea4seaqao7s73y24kcutaosvacpdjgfe5pw76ooefnyqw4ynr3d2y6x2mpq")))
+			require.NoError(t, err)
+			require.Len(t, pinfos, len(pieces)+1)
+			for i, pc := range pieces {
+				require.Empty(t, pinfos[i].Error)
+				require.Equal(t, pc, pinfos[i].Info.PieceCID)
+			}
+			require.NotEmpty(t, pinfos[len(pieces)].Error)
+
 			t.Logf("batchtest done")
 		}
 	}
@@ -0,0 +1,198 @@
+// stm: #integration
+package itests
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/dagstore"
+	"github.com/filecoin-project/go-data-segment/datasegment"
+	"github.com/filecoin-project/go-fil-markets/storagemarket"
+
+	"github.com/filecoin-project/lotus/itests/kit"
+)
+
+// TestDagstoreShards follows the shard of a deal through the DAG store: the
+// registration on deal handoff, the GC of its transient, the lazy registration
+// by a retrieval, and the recovery after the mount failed.
+func TestDagstoreShards(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	kit.QuietMiningLogs()
+
+	hooks := kit.NewDagstoreHooks()
+	client, miner, ens := kit.EnsembleMinimal(t, kit.MockProofs(), hooks.Opt())
+	ens.InterconnectAll().BeginMining(50 * time.Millisecond)
+
+	dh := kit.NewDealHarness(t, client, miner, miner)
+	deal, res, inPath := dh.MakeOnlineDeal(ctx, kit.MakeFullDealParams{Rseed: 7})
+
+	di, err := client.ClientGetDealInfo(ctx, *deal)
+	require.NoError(t, err)
+	pieceCid := di.PieceCID
+	key := pieceCid.String()
+
+	retrieve := func() {
+		outPath := dh.PerformRetrieval(ctx, deal, res.Root, false)
+		kit.AssertFilesEqual(t, inPath, outPath)
+	}
+
+	// the shard is registered on handoff, and indexed from the staged CAR
+	miner.WaitDagstoreShardState(ctx, key, dagstore.ShardStateAvailable)
+
+	diag, err := miner.DagstoreShardDiagnostics(ctx, pieceCid)
+	require.NoError(t, err)
+	require.False(t, diag.Registration.Lazy)
+	require.True(t, diag.Index.Exists)
+
+	pieces, err := miner.DagstoreLookupPieces(ctx, res.Root)
+	require.NoError(t, err)
+	require.Len(t, pieces, 1)
+	require.Equal(t, key, pieces[0].Key)
+
+	t.Run("gc", func(t *testing.T) {
+		results, err := miner.DagstoreGC(ctx)
+		require.NoError(t, err)
+
+		var reclaimed bool
+		for _, r := range results {
+			if r.Key == key {
+				require.True(t, r.Success, r.Error)
+				reclaimed = true
+			}
+		}
+		require.True(t, reclaimed, "transient of shard %s not reclaimed", key)
+
+		// the index outlives the transient, the data is fetched again from the
+		// mount when needed
+		diag, err := miner.DagstoreShardDiagnostics(ctx, pieceCid)
+		require.NoError(t, err)
+		require.Equal(t, dagstore.ShardStateAvailable.String(), diag.State)
+		require.False(t, diag.Transient.Exists)
+		require.True(t, diag.Index.Exists)
+
+		retrieve()
+	})
+
+	t.Run("lazy init", func(t *testing.T) {
+		hooks.DestroyShard(ctx, t, pieceCid)
+		_, ok := miner.DagstoreShard(ctx, key)
+		require.False(t, ok)
+
+		// the retrieval registers the unknown shard lazily, and initializes it
+		// from the mount when acquiring it
+		retrieve()
+
+		miner.WaitDagstoreShardState(ctx, key, dagstore.ShardStateAvailable)
+		diag, err := miner.DagstoreShardDiagnostics(ctx, pieceCid)
+		require.NoError(t, err)
+		require.True(t, diag.Registration.Lazy)
+	})
+
+	t.Run("recover", func(t *testing.T) {
+		hooks.DestroyShard(ctx, t, pieceCid)
+		hooks.FailMounts(xerrors.New("simulated mount failure"))
+
+		// the eager registration fetches the piece from the failing mount
+		require.Error(t, miner.DagstoreRegisterShard(ctx, key))
+		info := miner.WaitDagstoreShardState(ctx, key, dagstore.ShardStateErrored)
+		require.Contains(t, info.Error, "simulated mount failure")
+
+		// the shard can't be recovered while the mount fails
+		require.Error(t, miner.DagstoreRecoverShard(ctx, key))
+		miner.WaitDagstoreShardState(ctx, key, dagstore.ShardStateErrored)
+
+		hooks.FailMounts(nil)
+		require.NoError(t, miner.DagstoreRecoverShard(ctx, key))
+		miner.WaitDagstoreShardState(ctx, key, dagstore.ShardStateAvailable)
+
+		retrieve()
+	})
+}
+
+// TestDagstoreAggregatedPiece makes an offline deal for a PoDSI aggregate of
+// two imports, and follows its shard through the DAG store.
+func TestDagstoreAggregatedPiece(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	kit.QuietMiningLogs()
+
+	client, miner, ens := kit.EnsembleMinimal(t, kit.MockProofs())
+	ens.InterconnectAll().BeginMining(50 * time.Millisecond)
+
+	dh := kit.NewDealHarness(t, client, miner, miner)
+
+	// the CARs are aggregated as they were imported, so they can't be
+	// filestore imports; the aggregate fits into the 2KiB sectors of the
+	// ensemble
+	res1, _, inPath1 := client.ClientImportCARFile(ctx, 5, 300)
+	res2, _, _ := client.ClientImportCARFile(ctx, 6, 100)
+
+	aggPath := filepath.Join(t.TempDir(), "aggregate")
+	agg, err := client.ClientAggregatePieces(ctx, []cid.Cid{res1.Root, res2.Root}, 0, aggPath)
+	require.NoError(t, err)
+	require.Len(t, agg.Segments, 2)
+
+	// the largest piece comes first in the aggregate
+	first, other := agg.Segments[0], agg.Segments[1]
+	require.Zero(t, first.Offset)
+
+	dp := dh.DefaultStartDealParams()
+	dp.Data = &storagemarket.DataRef{
+		TransferType: storagemarket.TTManual,
+		Root:         first.Root,
+		PieceCid:     &agg.PieceCid,
+		PieceSize:    agg.PieceSize.Unpadded(),
+	}
+	deal := dh.StartDeal(ctx, dp)
+
+	require.Eventually(t, func() bool {
+		di, err := client.ClientGetDealInfo(ctx, *deal)
+		return err == nil && di.State == storagemarket.StorageDealCheckForAcceptance
+	}, 30*time.Second, time.Second)
+
+	require.NoError(t, miner.DealsImportData(ctx, *deal, aggPath))
+	dh.WaitDealSealed(ctx, deal, false, false, nil)
+
+	// the inclusion proofs of the segments prove them to be part of the piece
+	// of the deal
+	di, err := client.ClientGetDealInfo(ctx, *deal)
+	require.NoError(t, err)
+	require.Equal(t, agg.PieceCid, di.PieceCID)
+
+	for _, seg := range agg.Segments {
+		var ip datasegment.InclusionProof
+		require.NoError(t, ip.UnmarshalCBOR(bytes.NewReader(seg.InclusionProof)))
+
+		aux, err := ip.ComputeExpectedAuxData(datasegment.InclusionVerifierData{CommPc: seg.PieceCid, SizePc: seg.PieceSize})
+		require.NoError(t, err)
+		require.Equal(t, di.PieceCID, aux.CommPa)
+		require.Equal(t, agg.PieceSize, aux.SizePa)
+	}
+
+	key := di.PieceCID.String()
+	miner.WaitDagstoreShardState(ctx, key, dagstore.ShardStateAvailable)
+
+	// the DAG store indexes the piece as a CAR, which covers the segment at
+	// the start of the aggregate only: the zero padding after it ends the CAR
+	pieces, err := miner.DagstoreLookupPieces(ctx, first.Root)
+	require.NoError(t, err)
+	require.Len(t, pieces, 1)
+	require.Equal(t, key, pieces[0].Key)
+
+	pieces, err = miner.DagstoreLookupPieces(ctx, other.Root)
+	require.NoError(t, err)
+	require.Empty(t, pieces)
+
+	outPath := dh.PerformRetrieval(ctx, deal, first.Root, false)
+	kit.AssertFilesEqual(t, inPath1, outPath)
+}
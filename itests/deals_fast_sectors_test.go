@@ -0,0 +1,60 @@
+// stm: #integration
+package itests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/dagstore"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/itests/kit"
+)
+
+// TestDealFastSectors makes a deal for a piece which doesn't fit in the default
+// 2KiB test sectors, sealed in 8MiB sectors, and indexed by the DAG store.
+func TestDealFastSectors(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	kit.QuietMiningLogs()
+
+	client, miner, ens := kit.EnsembleMinimal(t, kit.MockProofs(), kit.SectorSize(8<<20))
+	ens.InterconnectAll().BeginMining(50 * time.Millisecond)
+
+	dh := kit.NewDealHarness(t, client, miner, miner)
+	deal, res, inPath := dh.MakeOnlineDeal(ctx, kit.MakeFullDealParams{
+		Rseed:    8,
+		FileSize: 3 << 20,
+	})
+
+	di, err := client.ClientGetDealInfo(ctx, *deal)
+	require.NoError(t, err)
+
+	sectors, err := miner.SectorsListNonGenesis(ctx)
+	require.NoError(t, err)
+	require.Len(t, sectors, 1)
+
+	si, err := miner.SectorsStatus(ctx, sectors[0], false)
+	require.NoError(t, err)
+	require.Equal(t, []abi.DealID{di.DealID}, si.Deals)
+
+	ssize, err := si.SealProof.SectorSize()
+	require.NoError(t, err)
+	require.Equal(t, abi.SectorSize(8<<20), ssize)
+
+	// the piece is indexed like any other
+	key := di.PieceCID.String()
+	miner.WaitDagstoreShardState(ctx, key, dagstore.ShardStateAvailable)
+
+	pieces, err := miner.DagstoreLookupPieces(ctx, res.Root)
+	require.NoError(t, err)
+	require.Len(t, pieces, 1)
+	require.Equal(t, key, pieces[0].Key)
+
+	outPath := dh.PerformRetrieval(ctx, deal, res.Root, false)
+	kit.AssertFilesEqual(t, inPath, outPath)
+}
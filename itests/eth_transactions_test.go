@@ -9,6 +9,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-state-types/big"
 	"github.com/filecoin-project/go-state-types/manifest"
 
@@ -85,6 +86,30 @@ func TestValueTransferValidSignature(t *testing.T) {
 	// Success.
 	require.EqualValues(t, ethtypes.EthUint64(0x1), receipt.Status)
 
+	// The block receipts include the same receipt, by block hash and by number.
+	for _, blkParam := range []string{receipt.BlockHash.String(), receipt.BlockNumber.Hex()} {
+		blockReceipts, err := client.EthGetBlockReceipts(ctx, blkParam)
+		require.NoError(t, err)
+		require.Greater(t, len(blockReceipts), int(receipt.TransactionIndex))
+		require.Equal(t, receipt, blockReceipts[receipt.TransactionIndex])
+	}
+
+	// The parent receipts of the tipset executing the transaction include it.
+	execTs, err := client.ChainGetTipSetAfterHeight(ctx, abi.ChainEpoch(receipt.BlockNumber)+1, types.EmptyTSK)
+	require.NoError(t, err)
+	msgCid, err := client.EthGetMessageCidByTransactionHash(ctx, &hash)
+	require.NoError(t, err)
+	batch, err := client.ChainGetParentReceiptsBatch(ctx, []types.TipSetKey{execTs.Key()})
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+	parentCid, err := batch[0].Parent.Cid()
+	require.NoError(t, err)
+	parentHash, err := ethtypes.EthHashFromCid(parentCid)
+	require.NoError(t, err)
+	require.Equal(t, receipt.BlockHash, parentHash)
+	require.Equal(t, *msgCid, batch[0].Messages[receipt.TransactionIndex].Cid)
+	require.EqualValues(t, receipt.GasUsed, batch[0].Receipts[receipt.TransactionIndex].GasUsed)
+
 	ethTx, err := client.EthGetTransactionByHash(ctx, &hash)
 	require.Nil(t, err)
 	require.EqualValues(t, ethAddr, ethTx.From)
@@ -271,6 +296,89 @@ func TestContractInvocation(t *testing.T) {
 	require.EqualValues(t, ethtypes.EthUint64(0x1), receipt.Status)
 }
 
+func TestSimulateRawTransaction(t *testing.T) {
+	blockTime := 100 * time.Millisecond
+	client, _, ens := kit.EnsembleMinimal(t, kit.MockProofs(), kit.ThroughRPC())
+
+	ens.InterconnectAll().BeginMining(blockTime)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// install contract
+	contractHex, err := os.ReadFile("./contracts/SimpleCoin.hex")
+	require.NoError(t, err)
+
+	contract, err := hex.DecodeString(string(contractHex))
+	require.NoError(t, err)
+
+	// create a new Ethereum account
+	key, ethAddr, deployer := client.EVM().NewAccount()
+	// send some funds to the f410 address
+	kit.SendFunds(ctx, t, client, deployer, types.FromFil(10))
+
+	contractAddr := client.EVM().ComputeContractAddress(ethAddr, 0)
+
+	// simulate the deployment before submitting it
+	tx, err := deployContractTx(ctx, client, ethAddr, contract)
+	require.NoError(t, err)
+
+	client.EVM().SignTransaction(tx, key.PrivateKey)
+	signed, err := tx.ToRlpSignedMsg()
+	require.NoError(t, err)
+
+	sim, err := client.EthSimulateRawTransaction(ctx, signed)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, sim.Status)
+	require.Equal(t, ethAddr, sim.From)
+	require.EqualValues(t, 0, sim.Nonce)
+	require.EqualValues(t, 0, sim.ExpectedNonce)
+	require.NotZero(t, sim.GasUsed)
+	require.NotNil(t, sim.ContractAddress)
+	require.Equal(t, contractAddr, *sim.ContractAddress)
+
+	// nothing was submitted
+	nonce, err := client.MpoolGetNonce(ctx, deployer)
+	require.NoError(t, err)
+	require.EqualValues(t, 0, nonce)
+
+	deployHash := client.EVM().SubmitTransaction(ctx, tx)
+
+	// simulate an invocation of the contract, executed on top of the pending
+	// deployment
+	// entry point for getBalance - f8b2cb4f
+	// address - ff00000000000000000000000000000000000064
+	params, err := hex.DecodeString("f8b2cb4f000000000000000000000000ff00000000000000000000000000000000000064")
+	require.NoError(t, err)
+
+	invokeTx := ethtypes.EthTxArgs{
+		ChainID:              build.Eip155ChainId,
+		To:                   &contractAddr,
+		Value:                big.Zero(),
+		Nonce:                1,
+		MaxFeePerGas:         tx.MaxFeePerGas,
+		MaxPriorityFeePerGas: tx.MaxPriorityFeePerGas,
+		GasLimit:             tx.GasLimit,
+		Input:                params,
+		V:                    big.Zero(),
+		R:                    big.Zero(),
+		S:                    big.Zero(),
+	}
+	client.EVM().SignTransaction(&invokeTx, key.PrivateKey)
+	signed, err = invokeTx.ToRlpSignedMsg()
+	require.NoError(t, err)
+
+	sim, err = client.EthSimulateRawTransaction(ctx, signed)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, sim.Status)
+	require.EqualValues(t, 1, sim.ExpectedNonce)
+	require.Len(t, sim.ReturnData, 32)
+
+	receipt, err := waitForEthTxReceipt(ctx, client, deployHash)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, receipt.Status)
+}
+
 func TestGetBlockByNumber(t *testing.T) {
 	blockTime := 100 * time.Millisecond
 	client, _, ens := kit.EnsembleMinimal(t, kit.MockProofs(), kit.ThroughRPC())
@@ -0,0 +1,152 @@
+package kit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/dagstore"
+	"github.com/filecoin-project/dagstore/mount"
+	"github.com/filecoin-project/dagstore/shard"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	mdagstore "github.com/filecoin-project/lotus/markets/dagstore"
+	"github.com/filecoin-project/lotus/node"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/modules"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+	"github.com/filecoin-project/lotus/node/repo"
+)
+
+// DagstoreHooks gives the tests control over the DAG store of a miner, which
+// the miner API doesn't expose: destroying shards, so that they are registered
+// again, and failing the mounts, to simulate lost unsealed copies.
+type DagstoreHooks struct {
+	lk       sync.Mutex
+	ds       *dagstore.DAGStore
+	mountErr error
+}
+
+func NewDagstoreHooks() *DagstoreHooks {
+	return &DagstoreHooks{}
+}
+
+// Opt installs the hooks in the miners the option is applied to.
+func (h *DagstoreHooks) Opt() NodeOpt {
+	cfg := config.DefaultStorageMiner().DAGStore
+
+	return ConstructorOpts(node.ApplyIf(node.IsType(repo.StorageMiner), node.Options(
+		node.Override(new(mdagstore.MinerAPI), func(lc fx.Lifecycle, r repo.LockedRepo, ps dtypes.ProviderPieceStore, sa mdagstore.SectorAccessor) (mdagstore.MinerAPI, error) {
+			mapi, err := modules.NewMinerAPI(cfg)(lc, r, ps, sa)
+			if err != nil {
+				return nil, err
+			}
+			return &hookedMinerAPI{MinerAPI: mapi, hooks: h}, nil
+		}),
+		node.Override(node.DAGStoreKey, func(lc fx.Lifecycle, r repo.LockedRepo, ks types.KeyStore, mapi mdagstore.MinerAPI, hst host.Host) (*dagstore.DAGStore, *mdagstore.Wrapper, error) {
+			ds, w, err := modules.DAGStore(cfg)(lc, r, ks, mapi, hst)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			h.lk.Lock()
+			h.ds = ds
+			h.lk.Unlock()
+			return ds, w, nil
+		}),
+	)))
+}
+
+// FailMounts makes fetching the data of the shards fail with err, until called
+// with a nil error.
+func (h *DagstoreHooks) FailMounts(err error) {
+	h.lk.Lock()
+	defer h.lk.Unlock()
+
+	h.mountErr = err
+}
+
+// DestroyShard removes the shard of the piece from the DAG store.
+func (h *DagstoreHooks) DestroyShard(ctx context.Context, t require.TestingT, pieceCid cid.Cid) {
+	h.lk.Lock()
+	ds := h.ds
+	h.lk.Unlock()
+	require.NotNil(t, ds, "dagstore hooks not installed")
+
+	key := shard.KeyFromCID(pieceCid)
+	resch := make(chan dagstore.ShardResult, 1)
+	require.NoError(t, ds.DestroyShard(ctx, key, resch, dagstore.DestroyOpts{}))
+
+	// the DAG store only sends a result when the shard can't be destroyed
+	for {
+		_, err := ds.GetShardInfo(key)
+		if errors.Is(err, dagstore.ErrShardUnknown) {
+			return
+		}
+
+		select {
+		case res := <-resch:
+			require.NoError(t, res.Error)
+		case <-time.After(100 * time.Millisecond):
+		case <-ctx.Done():
+			require.NoError(t, ctx.Err())
+		}
+	}
+}
+
+type hookedMinerAPI struct {
+	mdagstore.MinerAPI
+
+	hooks *DagstoreHooks
+}
+
+func (m *hookedMinerAPI) FetchUnsealedPiece(ctx context.Context, pieceCid cid.Cid) (mount.Reader, error) {
+	m.hooks.lk.Lock()
+	err := m.hooks.mountErr
+	m.hooks.lk.Unlock()
+
+	if err != nil {
+		return nil, xerrors.Errorf("fetching unsealed piece %s: %w", pieceCid, err)
+	}
+	return m.MinerAPI.FetchUnsealedPiece(ctx, pieceCid)
+}
+
+// DagstoreShard returns the shard with the given key, if the DAG store of the
+// miner knows it.
+func (tm *TestMiner) DagstoreShard(ctx context.Context, key string) (api.DagstoreShardInfo, bool) {
+	shards, err := tm.DagstoreListShards(ctx)
+	require.NoError(tm.t, err)
+
+	for _, s := range shards {
+		if s.Key == key {
+			return s, true
+		}
+	}
+	return api.DagstoreShardInfo{}, false
+}
+
+// WaitDagstoreShardState waits until the shard with the given key reaches the
+// state.
+func (tm *TestMiner) WaitDagstoreShardState(ctx context.Context, key string, state dagstore.ShardState) api.DagstoreShardInfo {
+	for {
+		s, ok := tm.DagstoreShard(ctx, key)
+		if ok && s.State == state.String() {
+			return s
+		}
+		tm.t.Logf("waiting for shard %s to reach %s, current state: %s (registered: %t)", key, state, s.State, ok)
+
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-ctx.Done():
+			tm.t.Fatalf("shard %s didn't reach %s: %s", key, state, ctx.Err())
+		}
+	}
+}
@@ -44,6 +44,10 @@ type MakeFullDealParams struct {
 	StartEpoch               abi.ChainEpoch
 	UseCARFileForStorageDeal bool
 
+	// FileSize is the size of the generated file, which must fit in the
+	// sectors of the miner. Defaults to a small file fitting in 2KiB sectors.
+	FileSize int
+
 	// SuspendUntilCryptoeconStable suspends deal-making, until cryptoecon
 	// parameters are stabilised. This affects projected collateral, and tests
 	// will fail in network version 13 and higher if deals are started too soon
@@ -82,13 +86,15 @@ func NewDealHarness(t *testing.T, client *TestFullNode, main *TestMiner, market
 // MakeOnlineDeal makes an online deal, generating a random file with the
 // supplied seed, and setting the specified fast retrieval flag and start epoch
 // on the storage deal. It returns when the deal is sealed.
-//
-// TODO: convert input parameters to struct, and add size as an input param.
 func (dh *DealHarness) MakeOnlineDeal(ctx context.Context, params MakeFullDealParams) (deal *cid.Cid, res *api.ImportRes, path string) {
 	if params.UseCARFileForStorageDeal {
-		res, _, path = dh.client.ClientImportCARFile(ctx, params.Rseed, 200)
+		size := params.FileSize
+		if size == 0 {
+			size = 200
+		}
+		res, _, path = dh.client.ClientImportCARFile(ctx, params.Rseed, size)
 	} else {
-		res, path = dh.client.CreateImportFile(ctx, params.Rseed, 0)
+		res, path = dh.client.CreateImportFile(ctx, params.Rseed, params.FileSize)
 	}
 
 	dh.t.Logf("FILE CID: %s", res.Root)
@@ -259,7 +259,7 @@ func (n *Ensemble) MinerEnroll(minerNode *TestMiner, full *TestFullNode, opts ..
 
 		// Will use 2KiB sectors by default (default value of sectorSize).
 		proofType, err := miner.SealProofTypeFromSectorSize(options.sectorSize, n.genesis.version)
-		require.NoError(n.t, err)
+		require.NoErrorf(n.t, err, "no seal proof for %s sectors in network version %d", options.sectorSize.ShortString(), n.genesis.version)
 
 		// Create the preseal commitment.
 		if n.options.mockProofs {
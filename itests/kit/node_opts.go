@@ -217,6 +217,10 @@ func MutateSealingConfig(mut func(sc *config.SealingConfig)) NodeOpt {
 // corresponding proof type depending on the network version (genesis network
 // version if the Ensemble is unstarted, or the current network version
 // if started).
+//
+// Only the sizes which have seal proofs can be used: 2KiB, 8MiB, 512MiB, 32GiB
+// and 64GiB. 8MiB sectors are the smallest ones fitting realistic pieces while
+// still sealing quickly with mock proofs, see MakeFullDealParams.FileSize.
 func SectorSize(sectorSize abi.SectorSize) NodeOpt {
 	return func(opts *nodeOpts) error {
 		opts.sectorSize = sectorSize
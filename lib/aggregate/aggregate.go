@@ -0,0 +1,189 @@
+package aggregate
+
+import (
+	"bytes"
+	"io"
+	"sort"
+
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-data-segment/datasegment"
+	"github.com/filecoin-project/go-state-types/abi"
+)
+
+// MaxDealSize is the largest aggregate picked when no deal size is given
+const MaxDealSize = abi.PaddedPieceSize(64 << 30)
+
+// Aggregate is a piece made of several pieces, laid out as described in
+// FRC-0058: the pieces are placed at offsets aligned to their size, and a data
+// segment index describing them is written at the end of the aggregate, where
+// datasegment.ParseDataSegmentIndex expects it.
+type Aggregate struct {
+	agg *datasegment.Aggregate
+
+	// pos[i] is the position in the index of the i-th piece passed to New
+	pos []int
+}
+
+// New computes the layout of an aggregate of dealSize holding the pieces. The
+// pieces are placed from the largest to the smallest, so that as little space
+// as possible is lost to alignment. When dealSize is zero, the smallest
+// aggregate fitting the pieces is used.
+func New(dealSize abi.PaddedPieceSize, pieces []abi.PieceInfo) (*Aggregate, error) {
+	if len(pieces) == 0 {
+		return nil, xerrors.Errorf("no pieces to aggregate")
+	}
+
+	order := make([]int, len(pieces))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return pieces[order[i]].Size > pieces[order[j]].Size
+	})
+
+	sorted := make([]abi.PieceInfo, len(pieces))
+	pos := make([]int, len(pieces))
+	for p, i := range order {
+		sorted[p] = pieces[i]
+		pos[i] = p
+	}
+
+	if dealSize == 0 {
+		var err error
+		dealSize, err = minDealSize(sorted)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	agg, err := datasegment.NewAggregate(dealSize, sorted)
+	if err != nil {
+		return nil, xerrors.Errorf("creating aggregate: %w", err)
+	}
+
+	return &Aggregate{
+		agg: agg,
+		pos: pos,
+	}, nil
+}
+
+// minDealSize returns the smallest deal size into which the pieces, and the
+// index describing them, fit
+func minDealSize(pieces []abi.PieceInfo) (abi.PaddedPieceSize, error) {
+	_, dataSize, err := datasegment.ComputeDealPlacement(pieces)
+	if err != nil {
+		return 0, xerrors.Errorf("computing placement of the pieces: %w", err)
+	}
+
+	for size := abi.PaddedPieceSize(128); size <= MaxDealSize; size <<= 1 {
+		entries := datasegment.MaxIndexEntriesInDeal(size)
+		if uint(len(pieces)) > entries {
+			continue
+		}
+		if dataSize+uint64(entries)*datasegment.EntrySize <= uint64(size) {
+			return size, nil
+		}
+	}
+
+	return 0, xerrors.Errorf("pieces of %d bytes don't fit into an aggregate of at most %d", dataSize, MaxDealSize)
+}
+
+// DealSize returns the padded size of the aggregate
+func (a *Aggregate) DealSize() abi.PaddedPieceSize {
+	return a.agg.DealSize
+}
+
+// PieceCID returns the piece CID of the aggregate, index included
+func (a *Aggregate) PieceCID() (cid.Cid, error) {
+	return a.agg.PieceCID()
+}
+
+// Offset returns the padded offset of the i-th piece in the aggregate
+func (a *Aggregate) Offset(i int) uint64 {
+	return a.agg.Index.Entries[a.pos[i]].Offset
+}
+
+// InclusionProof returns the CBOR encoded datasegment.InclusionProof of the
+// i-th piece, proving it is part of the aggregate and of its index
+func (a *Aggregate) InclusionProof(i int) ([]byte, error) {
+	ip, err := a.agg.ProofForIndexEntry(a.pos[i])
+	if err != nil {
+		return nil, xerrors.Errorf("computing inclusion proof: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := ip.MarshalCBOR(&buf); err != nil {
+		return nil, xerrors.Errorf("marshaling inclusion proof: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteTo writes the unpadded data of the aggregate to w. open returns a
+// reader of the unpadded data of the i-th piece; the pieces are opened one
+// after the other, as they get written.
+func (a *Aggregate) WriteTo(w io.Writer, open func(i int) (io.ReadCloser, error)) (int64, error) {
+	readers := make([]io.Reader, len(a.pos))
+	for i, p := range a.pos {
+		i := i
+		readers[p] = &lazyReader{open: func() (io.ReadCloser, error) {
+			return open(i)
+		}}
+	}
+
+	r, err := a.agg.AggregateObjectReader(readers)
+	if err != nil {
+		return 0, xerrors.Errorf("creating aggregate reader: %w", err)
+	}
+
+	n, err := io.Copy(w, r)
+	for _, lr := range readers {
+		if cerr := lr.(*lazyReader).close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		return n, xerrors.Errorf("writing aggregate: %w", err)
+	}
+	return n, nil
+}
+
+// lazyReader opens the underlying reader on the first read, and closes it
+// once it's drained
+type lazyReader struct {
+	open func() (io.ReadCloser, error)
+	r    io.ReadCloser
+	done bool
+}
+
+func (l *lazyReader) Read(p []byte) (int, error) {
+	if l.done {
+		return 0, io.EOF
+	}
+	if l.r == nil {
+		r, err := l.open()
+		if err != nil {
+			return 0, err
+		}
+		l.r = r
+	}
+
+	n, err := l.r.Read(p)
+	if err == io.EOF {
+		l.done = true
+		if cerr := l.close(); cerr != nil {
+			return n, xerrors.Errorf("closing piece reader: %w", cerr)
+		}
+	}
+	return n, err
+}
+
+func (l *lazyReader) close() error {
+	if l.r == nil {
+		return nil
+	}
+	r := l.r
+	l.r = nil
+	return r.Close()
+}
@@ -0,0 +1,93 @@
+// stm: #unit
+package aggregate
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-data-segment/datasegment"
+	commcid "github.com/filecoin-project/go-fil-commcid"
+	commp "github.com/filecoin-project/go-fil-commp-hashhash"
+	"github.com/filecoin-project/go-state-types/abi"
+)
+
+func pieceInfo(t *testing.T, data []byte) abi.PieceInfo {
+	cp := new(commp.Calc)
+	_, err := cp.Write(data)
+	require.NoError(t, err)
+	raw, size, err := cp.Digest()
+	require.NoError(t, err)
+	c, err := commcid.DataCommitmentV1ToCID(raw)
+	require.NoError(t, err)
+	return abi.PieceInfo{PieceCID: c, Size: abi.PaddedPieceSize(size)}
+}
+
+func TestAggregate(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+
+	var datas [][]byte
+	var pieces []abi.PieceInfo
+	for _, size := range []int{1000, 30000, 200, 7000} {
+		data := make([]byte, size)
+		_, _ = rng.Read(data)
+		datas = append(datas, data)
+		pieces = append(pieces, pieceInfo(t, data))
+	}
+
+	agg, err := New(0, pieces)
+	require.NoError(t, err)
+	require.Equal(t, abi.PaddedPieceSize(64<<10), agg.DealSize())
+
+	var buf bytes.Buffer
+	opened := map[int]bool{}
+	n, err := agg.WriteTo(&buf, func(i int) (io.ReadCloser, error) {
+		opened[i] = true
+		return io.NopCloser(bytes.NewReader(datas[i])), nil
+	})
+	require.NoError(t, err)
+	require.Len(t, opened, len(pieces))
+	require.Equal(t, int64(agg.DealSize().Unpadded()), n)
+
+	// the written data matches the piece CID of the aggregate
+	pc, err := agg.PieceCID()
+	require.NoError(t, err)
+	require.Equal(t, abi.PieceInfo{PieceCID: pc, Size: agg.DealSize()}, pieceInfo(t, buf.Bytes()))
+
+	// the largest piece comes first
+	require.Zero(t, agg.Offset(1))
+
+	for i, p := range pieces {
+		off := agg.Offset(i)
+		require.Zero(t, off%uint64(p.Size))
+
+		unpadded := off / 128 * 127
+		require.Equal(t, datas[i], buf.Bytes()[unpadded:unpadded+uint64(len(datas[i]))])
+
+		b, err := agg.InclusionProof(i)
+		require.NoError(t, err)
+		var ip datasegment.InclusionProof
+		require.NoError(t, ip.UnmarshalCBOR(bytes.NewReader(b)))
+
+		aux, err := ip.ComputeExpectedAuxData(datasegment.InclusionVerifierData{CommPc: p.PieceCID, SizePc: p.Size})
+		require.NoError(t, err)
+		require.Equal(t, pc, aux.CommPa)
+		require.Equal(t, agg.DealSize(), aux.SizePa)
+	}
+
+	// the index can be read back from the end of the aggregate
+	start := datasegment.DataSegmentIndexStartOffset(agg.DealSize())
+	idx, err := datasegment.ParseDataSegmentIndex(bytes.NewReader(buf.Bytes()[start:]))
+	require.NoError(t, err)
+	valid, err := idx.ValidEntries()
+	require.NoError(t, err)
+	require.Len(t, valid, len(pieces))
+
+	_, err = New(abi.PaddedPieceSize(32<<10), pieces)
+	require.Error(t, err)
+	_, err = New(0, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,223 @@
+// Package diskforecast tracks the growth of the space used by node
+// directories, and forecasts when their filesystems will be full.
+package diskforecast
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/storage/sealer/fsutil"
+)
+
+var log = logging.Logger("diskforecast")
+
+// Target is a directory whose used space is tracked.
+type Target struct {
+	Name string
+	Path string
+}
+
+// TargetsFunc returns the tracked directories. It is called on every sample,
+// so that directories can be added and removed while the node runs.
+type TargetsFunc func(ctx context.Context) ([]Target, error)
+
+type sample struct {
+	at        time.Time
+	used      int64
+	available int64
+}
+
+type Tracker struct {
+	targets  TargetsFunc
+	interval time.Duration
+	window   time.Duration
+	horizon  time.Duration
+
+	// usage returns the used space of a directory and the space available on
+	// its filesystem, replaced in tests
+	usage func(path string) (used int64, available int64, err error)
+	now   func() time.Time
+
+	lk      sync.Mutex
+	current []Target
+	samples map[string][]sample
+}
+
+// New creates a tracker sampling the targets every interval, and computing the
+// growth rates over the samples of the window. When the horizon is set, Admit
+// rejects new work once a filesystem is forecast to be full within it.
+func New(interval, window, horizon time.Duration, targets TargetsFunc) *Tracker {
+	return &Tracker{
+		targets:  targets,
+		interval: interval,
+		window:   window,
+		horizon:  horizon,
+
+		usage: dirUsage,
+		now:   time.Now,
+
+		samples: map[string][]sample{},
+	}
+}
+
+func dirUsage(path string) (int64, int64, error) {
+	si, err := fsutil.FileSize(path)
+	if err != nil {
+		return 0, 0, xerrors.Errorf("getting used space: %w", err)
+	}
+	st, err := fsutil.Statfs(path)
+	if err != nil {
+		return 0, 0, xerrors.Errorf("getting filesystem stats: %w", err)
+	}
+	return si.OnDisk, st.FSAvailable, nil
+}
+
+// Run samples the targets until the context is canceled.
+func (t *Tracker) Run(ctx context.Context) {
+	tick := time.NewTicker(t.interval)
+	defer tick.Stop()
+
+	for {
+		if err := t.Sample(ctx); err != nil {
+			log.Warnw("sampling disk usage", "error", err)
+		}
+
+		select {
+		case <-tick.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Sample records the used and available space of the targets. Targets which
+// don't exist yet are skipped.
+func (t *Tracker) Sample(ctx context.Context) error {
+	targets, err := t.targets(ctx)
+	if err != nil {
+		return xerrors.Errorf("getting tracked directories: %w", err)
+	}
+
+	now := t.now()
+	measured := map[string]sample{}
+	for _, tgt := range targets {
+		used, avail, err := t.usage(tgt.Path)
+		if err != nil {
+			if !xerrors.Is(err, os.ErrNotExist) {
+				log.Warnw("measuring disk usage", "target", tgt.Name, "path", tgt.Path, "error", err)
+			}
+			continue
+		}
+		measured[tgt.Name] = sample{at: now, used: used, available: avail}
+	}
+
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	t.current = t.current[:0]
+	for _, tgt := range targets {
+		s, ok := measured[tgt.Name]
+		if !ok {
+			continue
+		}
+		t.current = append(t.current, tgt)
+
+		samples := append(t.samples[tgt.Name], s)
+		for len(samples) > 0 && now.Sub(samples[0].at) > t.window {
+			samples = samples[1:]
+		}
+		t.samples[tgt.Name] = samples
+	}
+
+	// forget the targets which were removed
+	for name := range t.samples {
+		if _, ok := measured[name]; !ok {
+			delete(t.samples, name)
+		}
+	}
+
+	return nil
+}
+
+// Forecast returns the forecasts of the targets measured by the last sample.
+func (t *Tracker) Forecast() []api.DiskForecast {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	out := make([]api.DiskForecast, 0, len(t.current))
+	for _, tgt := range t.current {
+		out = append(out, forecast(tgt, t.samples[tgt.Name]))
+	}
+	return out
+}
+
+func forecast(tgt Target, samples []sample) api.DiskForecast {
+	last := samples[len(samples)-1]
+	f := api.DiskForecast{
+		Name:      tgt.Name,
+		Path:      tgt.Path,
+		Used:      last.used,
+		Available: last.available,
+		Samples:   len(samples),
+	}
+
+	rate := growthRate(samples)
+	f.GrowthRate = int64(rate * float64(24*time.Hour/time.Second))
+	if rate > 0 {
+		f.FullIn = time.Duration(float64(last.available) / rate * float64(time.Second))
+	}
+	return f
+}
+
+// growthRate returns the least squares slope of the used space over time, in
+// bytes per second.
+func growthRate(samples []sample) float64 {
+	if len(samples) < 2 {
+		return 0
+	}
+
+	start := samples[0].at
+	var mt, mu float64
+	for _, s := range samples {
+		mt += s.at.Sub(start).Seconds()
+		mu += float64(s.used)
+	}
+	n := float64(len(samples))
+	mt /= n
+	mu /= n
+
+	var cov, vt float64
+	for _, s := range samples {
+		dt := s.at.Sub(start).Seconds() - mt
+		cov += dt * (float64(s.used) - mu)
+		vt += dt * dt
+	}
+	if vt == 0 {
+		return 0
+	}
+	return cov / vt
+}
+
+// Admit returns an error when a tracked filesystem is full, or forecast to be
+// full within the horizon.
+func (t *Tracker) Admit() error {
+	if t == nil || t.horizon <= 0 {
+		return nil
+	}
+
+	for _, f := range t.Forecast() {
+		if f.Available <= 0 {
+			return xerrors.Errorf("no space left on the filesystem of %s (%s)", f.Name, f.Path)
+		}
+		if f.FullIn > 0 && f.FullIn <= t.horizon {
+			return xerrors.Errorf("filesystem of %s (%s) forecast to be full in %s", f.Name, f.Path, f.FullIn.Round(time.Minute))
+		}
+	}
+	return nil
+}
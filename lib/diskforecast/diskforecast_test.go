@@ -0,0 +1,66 @@
+// stm: #unit
+package diskforecast
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestForecast(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Unix(1600000000, 0)
+	used := map[string]int64{"/chain": 100 << 30, "/scratch": 10 << 30}
+	const avail = 100 << 30
+
+	targets := []Target{{Name: "chain", Path: "/chain"}, {Name: "scratch", Path: "/scratch"}}
+	tr := New(time.Hour, 24*time.Hour, 7*24*time.Hour, func(ctx context.Context) ([]Target, error) {
+		return targets, nil
+	})
+	tr.now = func() time.Time { return now }
+	tr.usage = func(path string) (int64, int64, error) {
+		u, ok := used[path]
+		if !ok {
+			return 0, 0, os.ErrNotExist
+		}
+		return u, avail, nil
+	}
+
+	require.NoError(t, tr.Sample(ctx))
+	f := tr.Forecast()
+	require.Len(t, f, 2)
+	require.Equal(t, int64(0), f[0].GrowthRate)
+	require.Equal(t, time.Duration(0), f[0].FullIn)
+	require.NoError(t, tr.Admit())
+
+	// the chain grows by 10GiB a day, the scratch space doesn't grow
+	for i := 0; i < 48; i++ {
+		now = now.Add(time.Hour)
+		used["/chain"] += 10 << 30 / 24
+		require.NoError(t, tr.Sample(ctx))
+	}
+
+	f = tr.Forecast()
+	require.Equal(t, "chain", f[0].Name)
+	require.Equal(t, 25, f[0].Samples) // samples older than the window are dropped
+	require.InDelta(t, 10<<30, f[0].GrowthRate, 1<<20)
+	require.InDelta(t, 10*24*time.Hour, f[0].FullIn, float64(time.Minute))
+	require.Equal(t, time.Duration(0), f[1].FullIn)
+	require.NoError(t, tr.Admit())
+
+	tr.horizon = 11 * 24 * time.Hour
+	require.ErrorContains(t, tr.Admit(), "forecast to be full")
+
+	// removed targets are forgotten
+	delete(used, "/scratch")
+	require.NoError(t, tr.Sample(ctx))
+	require.Len(t, tr.Forecast(), 1)
+	require.Len(t, tr.samples, 1)
+
+	var nilTracker *Tracker
+	require.NoError(t, nilTracker.Admit())
+}
@@ -0,0 +1,368 @@
+// Package paramfetch checks and fetches proof parameter files from a list of
+// prioritised mirrors.
+//
+// It uses the same parameter directory and fetch lock as
+// github.com/filecoin-project/go-paramfetch, so it can be used alongside it.
+package paramfetch
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	fslock "github.com/ipfs/go-fs-lock"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/minio/blake2b-simd"
+	"golang.org/x/xerrors"
+)
+
+var log = logging.Logger("paramfetch")
+
+const (
+	// DefaultGateway is the mirror used when none is configured.
+	DefaultGateway = "https://proofs.filecoin.io/ipfs/"
+
+	defaultDir = "/var/tmp/filecoin-proof-parameters"
+	dirEnv     = "FIL_PROOFS_PARAMETER_CACHE"
+	lockFile   = "fetch.lock"
+)
+
+var lockRetry = 10 * time.Second
+
+// DefaultDir returns the directory the proofs library loads parameters from.
+func DefaultDir() string {
+	if dir := os.Getenv(dirEnv); dir != "" {
+		return dir
+	}
+	return defaultDir
+}
+
+// Mirror is a source of parameter files.
+type Mirror struct {
+	// URL is the prefix the parameter file CIDs are appended to, e.g.
+	// https://proofs.filecoin.io/ipfs/
+	URL string
+	// Mirrors with higher priority are tried first.
+	Priority int
+}
+
+type FileState string
+
+const (
+	FilePresent FileState = "present"
+	FileMissing FileState = "missing"
+	FileCorrupt FileState = "corrupt"
+)
+
+type FileStatus struct {
+	Name string
+	// SectorSize is the sector size the file is used for, zero for files used
+	// for all sector sizes.
+	SectorSize uint64
+	State      FileState
+	// Size is the size of the file on disk.
+	Size int64
+	// Error explains why the file is corrupt.
+	Error string
+}
+
+type paramFile struct {
+	Cid        string `json:"cid"`
+	Digest     string `json:"digest"`
+	SectorSize uint64 `json:"sector_size"`
+}
+
+// Fetcher checks and fetches the parameter files in a directory.
+type Fetcher struct {
+	dir     string
+	mirrors []Mirror
+	files   map[string]paramFile
+
+	// files verified since they were last modified
+	verifiedLk sync.Mutex
+	verified   map[string]time.Time
+}
+
+// New creates a fetcher for the parameter files listed in the parameters and
+// SRS JSON files (see build.ParametersJSON), which are stored in dir. With no
+// mirrors, files are fetched from DefaultGateway, or IPFS_GATEWAY if set.
+func New(dir string, mirrors []Mirror, paramBytes, srsBytes []byte) (*Fetcher, error) {
+	files := map[string]paramFile{}
+	for _, b := range [][]byte{paramBytes, srsBytes} {
+		var pf map[string]paramFile
+		if err := json.Unmarshal(b, &pf); err != nil {
+			return nil, xerrors.Errorf("parsing parameter list: %w", err)
+		}
+		for name, info := range pf {
+			files[name] = info
+		}
+	}
+
+	if len(mirrors) == 0 {
+		gw := os.Getenv("IPFS_GATEWAY")
+		if gw == "" {
+			gw = DefaultGateway
+		}
+		mirrors = []Mirror{{URL: gw}}
+	}
+
+	mirrors = append([]Mirror{}, mirrors...)
+	for _, m := range mirrors {
+		if m.URL == "" {
+			return nil, xerrors.New("parameter mirror URL not set")
+		}
+	}
+	sort.SliceStable(mirrors, func(i, j int) bool {
+		return mirrors[i].Priority > mirrors[j].Priority
+	})
+
+	return &Fetcher{
+		dir:      dir,
+		mirrors:  mirrors,
+		files:    files,
+		verified: map[string]time.Time{},
+	}, nil
+}
+
+// Status checks the files needed for the storage size, see Fetch. Files which
+// weren't verified before are read in full to check their digest.
+func (f *Fetcher) Status(ctx context.Context, storageSize uint64) ([]FileStatus, error) {
+	var out []FileStatus
+	for _, name := range f.needed(storageSize) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		st := FileStatus{
+			Name:       name,
+			SectorSize: f.files[name].SectorSize,
+			State:      FilePresent,
+		}
+
+		size, err := f.check(name)
+		st.Size = size
+		switch {
+		case os.IsNotExist(err):
+			st.State = FileMissing
+		case err != nil:
+			st.State = FileCorrupt
+			st.Error = err.Error()
+		}
+
+		out = append(out, st)
+	}
+
+	return out, nil
+}
+
+// Fetch fetches the files needed for the storage size which are missing or
+// corrupt. With a zero storage size, only the verifying keys for all sector
+// sizes are needed, otherwise the proving parameters for the storage size are
+// needed too. All files are verified again, and each file needing a fetch is
+// fetched from the mirrors in priority order until its digest matches.
+func (f *Fetcher) Fetch(ctx context.Context, storageSize uint64) error {
+	f.verifiedLk.Lock()
+	f.verified = map[string]time.Time{}
+	f.verifiedLk.Unlock()
+
+	if err := os.MkdirAll(f.dir, 0755); err != nil {
+		return xerrors.Errorf("creating parameter directory: %w", err)
+	}
+
+	unlock, err := f.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := unlock.Close(); err != nil {
+			log.Errorw("unlock fs lock", "error", err)
+		}
+	}()
+
+	for _, name := range f.needed(storageSize) {
+		_, err := f.check(name)
+		if err == nil {
+			continue
+		}
+		if !os.IsNotExist(err) {
+			log.Warnw("parameter file corrupt, fetching it again", "file", name, "error", err)
+		}
+
+		if err := f.fetch(ctx, name); err != nil {
+			return xerrors.Errorf("fetching %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+func (f *Fetcher) needed(storageSize uint64) []string {
+	var out []string
+	for name, info := range f.files {
+		if storageSize != info.SectorSize && strings.HasSuffix(name, ".params") {
+			continue
+		}
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (f *Fetcher) lock(ctx context.Context) (io.Closer, error) {
+	for {
+		unlock, err := fslock.Lock(f.dir, lockFile)
+		if err == nil {
+			return unlock, nil
+		}
+
+		le := fslock.LockedError("")
+		if !xerrors.As(err, &le) {
+			return nil, xerrors.Errorf("acquiring filesystem fetch lock: %w", err)
+		}
+
+		log.Warnf("acquiring filesystem fetch lock: %s; will retry in %s", err, lockRetry)
+		select {
+		case <-time.After(lockRetry):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// check returns the size of the file, and an error if it's missing or its
+// digest doesn't match.
+func (f *Fetcher) check(name string) (int64, error) {
+	path := filepath.Join(f.dir, name)
+
+	st, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+
+	f.verifiedLk.Lock()
+	mtime, ok := f.verified[name]
+	f.verifiedLk.Unlock()
+	if ok && mtime.Equal(st.ModTime()) {
+		return st.Size(), nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return st.Size(), err
+	}
+	defer file.Close() // nolint:errcheck
+
+	h := blake2b.New512()
+	if _, err := io.Copy(h, file); err != nil {
+		return st.Size(), xerrors.Errorf("reading file: %w", err)
+	}
+
+	sum := hex.EncodeToString(h.Sum(nil)[:16])
+	if sum != f.files[name].Digest {
+		return st.Size(), xerrors.Errorf("checksum mismatch, %s != %s", sum, f.files[name].Digest)
+	}
+
+	f.verifiedLk.Lock()
+	f.verified[name] = st.ModTime()
+	f.verifiedLk.Unlock()
+
+	return st.Size(), nil
+}
+
+func (f *Fetcher) fetch(ctx context.Context, name string) error {
+	path := filepath.Join(f.dir, name)
+
+	var errs []error
+	for _, m := range f.mirrors {
+		// resume partial downloads first, then retry from scratch
+		for _, resume := range []bool{true, false} {
+			if !resume {
+				if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+					return xerrors.Errorf("removing file: %w", err)
+				}
+			}
+
+			err := f.download(ctx, m.URL+f.files[name].Cid, path)
+			if err == nil {
+				_, err = f.check(name)
+			}
+			if err == nil {
+				log.Infow("fetched parameter file", "file", name, "mirror", m.URL)
+				return nil
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			log.Warnw("fetching parameter file from mirror failed", "file", name, "mirror", m.URL, "resume", resume, "error", err)
+			errs = append(errs, xerrors.Errorf("mirror %s: %w", m.URL, err))
+		}
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		log.Errorw("removing corrupt parameter file", "file", name, "error", err)
+	}
+
+	return xerrors.Errorf("all mirrors failed: %v", errs)
+}
+
+func (f *Fetcher) download(ctx context.Context, url, path string) error {
+	out, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer out.Close() // nolint:errcheck
+
+	st, err := out.Stat()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	if st.Size() > 0 {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(st.Size(), 10)+"-")
+	}
+
+	log.Infow("fetching parameter file", "url", url, "offset", st.Size())
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint:errcheck
+
+	var offset int64
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		offset = st.Size()
+	case http.StatusOK:
+		// the mirror doesn't support ranges, start over
+	default:
+		return xerrors.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+
+	if err := out.Truncate(offset); err != nil {
+		return err
+	}
+	if _, err := out.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		return xerrors.Errorf("downloading: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+// stm: #unit
+package paramfetch
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/minio/blake2b-simd"
+	"github.com/stretchr/testify/require"
+)
+
+func digest(data []byte) string {
+	sum := blake2b.Sum512(data)
+	return hex.EncodeToString(sum[:16])
+}
+
+func TestFetcher(t *testing.T) {
+	ctx := context.Background()
+
+	content := map[string][]byte{
+		"cidvk":     []byte("verifying key"),
+		"cidparams": bytes.Repeat([]byte("params"), 1000),
+		"cidsrs":    []byte("srs"),
+	}
+
+	params, err := json.Marshal(map[string]paramFile{
+		"v28-2k.vk":     {Cid: "cidvk", Digest: digest(content["cidvk"]), SectorSize: 2048},
+		"v28-2k.params": {Cid: "cidparams", Digest: digest(content["cidparams"]), SectorSize: 2048},
+	})
+	require.NoError(t, err)
+	srs, err := json.Marshal(map[string]paramFile{
+		"v28-srs": {Cid: "cidsrs", Digest: digest(content["cidsrs"])},
+	})
+	require.NoError(t, err)
+
+	var badHits int64
+	var lastRange atomic.Value
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&badHits, 1)
+		_, _ = w.Write([]byte("garbage"))
+	}))
+	defer bad.Close()
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastRange.Store(r.Header.Get("Range"))
+		data, ok := content[strings.TrimPrefix(r.URL.Path, "/ipfs/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+	}))
+	defer good.Close()
+
+	dir := t.TempDir()
+	f, err := New(dir, []Mirror{
+		{URL: good.URL + "/ipfs/", Priority: 1},
+		{URL: bad.URL + "/ipfs/", Priority: 10},
+	}, params, srs)
+	require.NoError(t, err)
+
+	states := func(storageSize uint64) map[string]FileState {
+		st, err := f.Status(ctx, storageSize)
+		require.NoError(t, err)
+		out := map[string]FileState{}
+		for _, s := range st {
+			out[s.Name] = s.State
+		}
+		return out
+	}
+
+	require.Equal(t, map[string]FileState{"v28-2k.vk": FileMissing, "v28-srs": FileMissing}, states(0))
+
+	// the bad mirror has priority, but its files don't match the digests
+	require.NoError(t, f.Fetch(ctx, 0))
+	require.Equal(t, int64(4), atomic.LoadInt64(&badHits))
+	require.Equal(t, map[string]FileState{"v28-2k.vk": FilePresent, "v28-srs": FilePresent}, states(0))
+	require.Equal(t, FileMissing, states(2048)["v28-2k.params"])
+
+	// partial files are resumed
+	path := filepath.Join(dir, "v28-2k.params")
+	require.NoError(t, os.WriteFile(path, content["cidparams"][:100], 0644))
+	require.Equal(t, FileCorrupt, states(2048)["v28-2k.params"])
+
+	goodOnly, err := New(dir, []Mirror{{URL: good.URL + "/ipfs/"}}, params, srs)
+	require.NoError(t, err)
+	require.NoError(t, goodOnly.Fetch(ctx, 2048))
+	require.Equal(t, "bytes=100-", lastRange.Load())
+	require.Equal(t, FilePresent, states(2048)["v28-2k.params"])
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, content["cidparams"], data)
+
+	// corrupt files are fetched again
+	corrupt := append([]byte{}, content["cidvk"]...)
+	corrupt[0] = 'V'
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "v28-2k.vk"), corrupt, 0644))
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "v28-2k.vk"), time.Now(), time.Now().Add(time.Hour)))
+	require.Equal(t, FileCorrupt, states(0)["v28-2k.vk"])
+
+	require.NoError(t, f.Fetch(ctx, 0))
+	require.Equal(t, FilePresent, states(0)["v28-2k.vk"])
+
+	// files which can't be fetched from any mirror are removed
+	content["cidsrs"] = []byte("changed")
+	require.NoError(t, os.Remove(filepath.Join(dir, "v28-srs")))
+	require.Error(t, f.Fetch(ctx, 0))
+	require.NoFileExists(t, filepath.Join(dir, "v28-srs"))
+}
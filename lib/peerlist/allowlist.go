@@ -0,0 +1,311 @@
+// Package peerlist keeps a persistent list of allowed peers, which are
+// protected from connection trimming and reconnected to when disconnected.
+package peerlist
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+	"github.com/libp2p/go-libp2p/p2p/net/conngater"
+	"github.com/libp2p/go-libp2p/p2p/net/swarm"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+var log = logging.Logger("peerlist")
+
+// ProtectTag is the connection manager protection tag of allowed peers.
+const ProtectTag = "allowlist"
+
+const (
+	checkInterval = 5 * time.Second
+	dialTimeout   = 30 * time.Second
+)
+
+var (
+	allowPrefix = datastore.NewKey("/peerlist/allow")
+	policyKey   = datastore.NewKey("/peerlist/reconnect-policy")
+)
+
+// DefaultReconnectPolicy is the reconnection policy used until one is set.
+var DefaultReconnectPolicy = api.NetReconnectPolicy{
+	Enabled:    true,
+	MinBackoff: 10 * time.Second,
+	MaxBackoff: 10 * time.Minute,
+}
+
+type allowedPeer struct {
+	addrs peer.AddrInfo
+
+	dialing  bool
+	backoff  time.Duration
+	nextDial time.Time
+}
+
+// AllowList is a persistent list of peers the node keeps connections to.
+type AllowList struct {
+	h     host.Host
+	ds    datastore.Datastore
+	gater *conngater.BasicConnectionGater
+
+	lk     sync.Mutex
+	peers  map[peer.ID]*allowedPeer
+	policy api.NetReconnectPolicy
+
+	interval time.Duration
+	ctx      context.Context
+	cancel   context.CancelFunc
+	closed   chan struct{}
+}
+
+// NewAllowList loads the allow-list and reconnection policy from the
+// datastore, and protects the allowed peers.
+func NewAllowList(ctx context.Context, h host.Host, ds datastore.Batching, gater *conngater.BasicConnectionGater) (*AllowList, error) {
+	al := &AllowList{
+		h:     h,
+		ds:    ds,
+		gater: gater,
+
+		peers:  map[peer.ID]*allowedPeer{},
+		policy: DefaultReconnectPolicy,
+
+		interval: checkInterval,
+		closed:   make(chan struct{}),
+	}
+	al.ctx, al.cancel = context.WithCancel(context.Background())
+
+	res, err := ds.Query(ctx, query.Query{Prefix: allowPrefix.String()})
+	if err != nil {
+		return nil, xerrors.Errorf("querying allowed peers: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, xerrors.Errorf("reading allowed peers: %w", r.Error)
+		}
+
+		var ai peer.AddrInfo
+		if err := json.Unmarshal(r.Value, &ai); err != nil {
+			return nil, xerrors.Errorf("decoding allowed peer %s: %w", r.Key, err)
+		}
+		al.allow(ai)
+	}
+
+	b, err := ds.Get(ctx, policyKey)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(b, &al.policy); err != nil {
+			return nil, xerrors.Errorf("decoding reconnect policy: %w", err)
+		}
+	case !xerrors.Is(err, datastore.ErrNotFound):
+		return nil, xerrors.Errorf("reading reconnect policy: %w", err)
+	}
+
+	return al, nil
+}
+
+func (al *AllowList) allow(ai peer.AddrInfo) {
+	al.h.ConnManager().Protect(ai.ID, ProtectTag)
+	al.h.Peerstore().AddAddrs(ai.ID, ai.Addrs, peerstore.PermanentAddrTTL)
+
+	al.lk.Lock()
+	al.peers[ai.ID] = &allowedPeer{addrs: ai}
+	al.lk.Unlock()
+}
+
+// Add adds peers to the allow-list. Blocked peers can't be allowed.
+func (al *AllowList) Add(ctx context.Context, peers []peer.AddrInfo) error {
+	for _, ai := range peers {
+		if !al.gater.InterceptPeerDial(ai.ID) {
+			return xerrors.Errorf("peer %s is blocked", ai.ID)
+		}
+	}
+
+	for _, ai := range peers {
+		b, err := json.Marshal(ai)
+		if err != nil {
+			return xerrors.Errorf("encoding allowed peer %s: %w", ai.ID, err)
+		}
+		if err := al.ds.Put(ctx, allowKey(ai.ID), b); err != nil {
+			return xerrors.Errorf("storing allowed peer %s: %w", ai.ID, err)
+		}
+		al.allow(ai)
+	}
+
+	return nil
+}
+
+// Remove removes peers from the allow-list, existing connections to them
+// are kept.
+func (al *AllowList) Remove(ctx context.Context, peers []peer.ID) error {
+	for _, p := range peers {
+		if err := al.ds.Delete(ctx, allowKey(p)); err != nil {
+			return xerrors.Errorf("removing allowed peer %s: %w", p, err)
+		}
+
+		al.h.ConnManager().Unprotect(p, ProtectTag)
+
+		al.lk.Lock()
+		delete(al.peers, p)
+		al.lk.Unlock()
+	}
+
+	return nil
+}
+
+// List returns the allowed peers, with the addresses they were added with.
+func (al *AllowList) List() []peer.AddrInfo {
+	al.lk.Lock()
+	defer al.lk.Unlock()
+
+	out := make([]peer.AddrInfo, 0, len(al.peers))
+	for _, ap := range al.peers {
+		out = append(out, ap.addrs)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// ReconnectPolicy returns the current reconnection policy.
+func (al *AllowList) ReconnectPolicy() api.NetReconnectPolicy {
+	al.lk.Lock()
+	defer al.lk.Unlock()
+	return al.policy
+}
+
+// SetReconnectPolicy changes and persists the reconnection policy. Pending
+// backoffs are reset.
+func (al *AllowList) SetReconnectPolicy(ctx context.Context, policy api.NetReconnectPolicy) error {
+	if policy.MinBackoff <= 0 || policy.MaxBackoff < policy.MinBackoff {
+		return xerrors.Errorf("invalid backoff range %s-%s", policy.MinBackoff, policy.MaxBackoff)
+	}
+
+	b, err := json.Marshal(policy)
+	if err != nil {
+		return xerrors.Errorf("encoding reconnect policy: %w", err)
+	}
+	if err := al.ds.Put(ctx, policyKey, b); err != nil {
+		return xerrors.Errorf("storing reconnect policy: %w", err)
+	}
+
+	al.lk.Lock()
+	defer al.lk.Unlock()
+
+	al.policy = policy
+	for _, ap := range al.peers {
+		ap.backoff = 0
+		ap.nextDial = time.Time{}
+	}
+	return nil
+}
+
+// Start starts reconnecting to disconnected allowed peers.
+func (al *AllowList) Start() {
+	go al.run()
+}
+
+// Close stops reconnecting to allowed peers.
+func (al *AllowList) Close() error {
+	al.cancel()
+	<-al.closed
+	return nil
+}
+
+func (al *AllowList) run() {
+	defer close(al.closed)
+
+	ticker := time.NewTicker(al.interval)
+	defer ticker.Stop()
+
+	for {
+		al.reconnect()
+
+		select {
+		case <-ticker.C:
+		case <-al.ctx.Done():
+			return
+		}
+	}
+}
+
+func (al *AllowList) reconnect() {
+	al.lk.Lock()
+	defer al.lk.Unlock()
+
+	if !al.policy.Enabled {
+		return
+	}
+
+	now := time.Now()
+	for p, ap := range al.peers {
+		if ap.dialing || now.Before(ap.nextDial) {
+			continue
+		}
+		if al.h.Network().Connectedness(p) == network.Connected {
+			ap.backoff = 0
+			continue
+		}
+		if !al.gater.InterceptPeerDial(p) {
+			continue
+		}
+
+		ap.dialing = true
+		go al.dial(p)
+	}
+}
+
+func (al *AllowList) dial(p peer.ID) {
+	// clear the swarm's own dial backoff, the reconnect policy applies instead
+	if swrm, ok := al.h.Network().(*swarm.Swarm); ok {
+		swrm.Backoff().Clear(p)
+	}
+
+	ctx, cancel := context.WithTimeout(al.ctx, dialTimeout)
+	defer cancel()
+	err := al.h.Connect(ctx, peer.AddrInfo{ID: p})
+
+	al.lk.Lock()
+	defer al.lk.Unlock()
+
+	ap, ok := al.peers[p]
+	if !ok {
+		return
+	}
+	ap.dialing = false
+
+	if err == nil {
+		log.Infow("reconnected to allowed peer", "peer", p)
+		ap.backoff = 0
+		return
+	}
+
+	switch {
+	case ap.backoff == 0:
+		ap.backoff = al.policy.MinBackoff
+	case ap.backoff*2 > al.policy.MaxBackoff:
+		ap.backoff = al.policy.MaxBackoff
+	default:
+		ap.backoff *= 2
+	}
+	ap.nextDial = time.Now().Add(ap.backoff)
+
+	log.Debugw("failed to reconnect to allowed peer", "peer", p, "retry", ap.backoff, "error", err)
+}
+
+func allowKey(p peer.ID) datastore.Key {
+	return allowPrefix.ChildString(p.String())
+}
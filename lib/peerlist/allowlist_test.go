@@ -0,0 +1,80 @@
+// stm: #unit
+package peerlist
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/net/conngater"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+func TestAllowList(t *testing.T) {
+	ctx := context.Background()
+
+	mn := mocknet.New()
+	defer mn.Close() //nolint:errcheck
+
+	h, err := mn.GenPeer()
+	require.NoError(t, err)
+	allowed, err := mn.GenPeer()
+	require.NoError(t, err)
+	blocked, err := mn.GenPeer()
+	require.NoError(t, err)
+	require.NoError(t, mn.LinkAll())
+
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	cg, err := conngater.NewBasicConnectionGater(ds)
+	require.NoError(t, err)
+	require.NoError(t, cg.BlockPeer(blocked.ID()))
+
+	al, err := NewAllowList(ctx, h, ds, cg)
+	require.NoError(t, err)
+	al.interval = 10 * time.Millisecond
+	al.Start()
+
+	require.Error(t, al.Add(ctx, []peer.AddrInfo{{ID: blocked.ID()}}))
+
+	ai := peer.AddrInfo{ID: allowed.ID(), Addrs: allowed.Addrs()}
+	require.NoError(t, al.Add(ctx, []peer.AddrInfo{ai}))
+	require.Equal(t, []peer.AddrInfo{ai}, al.List())
+
+	connected := func() bool {
+		return h.Network().Connectedness(allowed.ID()) == network.Connected
+	}
+	require.Eventually(t, connected, 5*time.Second, 10*time.Millisecond)
+
+	// disconnected peers are reconnected to
+	require.NoError(t, h.Network().ClosePeer(allowed.ID()))
+	require.Eventually(t, connected, 5*time.Second, 10*time.Millisecond)
+
+	// unless reconnecting is disabled
+	policy := DefaultReconnectPolicy
+	policy.Enabled = false
+	require.NoError(t, al.SetReconnectPolicy(ctx, policy))
+	require.NoError(t, h.Network().ClosePeer(allowed.ID()))
+	time.Sleep(100 * time.Millisecond)
+	require.False(t, connected())
+
+	require.Error(t, al.SetReconnectPolicy(ctx, api.NetReconnectPolicy{MinBackoff: time.Minute, MaxBackoff: time.Second}))
+	require.NoError(t, al.Close())
+
+	// the allow-list and policy are persisted
+	al, err = NewAllowList(ctx, h, ds, cg)
+	require.NoError(t, err)
+	require.Equal(t, []peer.AddrInfo{ai}, al.List())
+	require.Equal(t, policy, al.ReconnectPolicy())
+
+	require.NoError(t, al.Remove(ctx, []peer.ID{allowed.ID()}))
+	al, err = NewAllowList(ctx, h, ds, cg)
+	require.NoError(t, err)
+	require.Empty(t, al.List())
+}
@@ -0,0 +1,334 @@
+// Package rpcws adds permessage-deflate compression and a configurable frame
+// size to the websocket connections of the JSON-RPC servers and clients.
+//
+// The RPC library upgrades and dials websocket connections with fixed
+// settings, so on the server side the connections are terminated by Handler,
+// which relays the messages to the RPC server over an in-memory connection.
+package rpcws
+
+import (
+	"compress/flate"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+)
+
+var log = logging.Logger("rpcws")
+
+const (
+	// pingInterval is how often the relay pings the remote peer.
+	pingInterval = 10 * time.Second
+	// pongTimeout is how long the relay waits for a message or pong from the
+	// remote peer before closing the connection.
+	pongTimeout = 60 * time.Second
+)
+
+// Options of websocket connections.
+type Options struct {
+	// Compression negotiates permessage-deflate compression with the peers
+	// supporting it.
+	Compression bool
+	// CompressionLevel is the flate compression level of the messages sent,
+	// 0 for the default. Only used by servers.
+	CompressionLevel int
+	// MaxFrameSize is the maximum payload size of the frames sent, larger
+	// messages are split in several frames. 0 for the default of 4KiB.
+	MaxFrameSize int
+}
+
+func (o Options) enabled() bool {
+	return o.Compression || o.MaxFrameSize > 0
+}
+
+func (o Options) validate() error {
+	if o.CompressionLevel != 0 && (o.CompressionLevel < flate.HuffmanOnly || o.CompressionLevel > flate.BestCompression) {
+		return xerrors.Errorf("invalid compression level %d, must be between %d and %d", o.CompressionLevel, flate.HuffmanOnly, flate.BestCompression)
+	}
+	if o.MaxFrameSize < 0 {
+		return xerrors.Errorf("invalid max frame size %d", o.MaxFrameSize)
+	}
+	return nil
+}
+
+// ConfigureClient applies the options to the websocket connections dialed by
+// the RPC clients of the process. The RPC library dials with the default
+// websocket dialer, so the options apply to all the clients.
+func ConfigureClient(o Options) error {
+	if err := o.validate(); err != nil {
+		return err
+	}
+
+	d := *websocket.DefaultDialer
+	d.EnableCompression = o.Compression
+	d.WriteBufferSize = o.MaxFrameSize
+	websocket.DefaultDialer = &d
+	return nil
+}
+
+// Handler serves the websocket connections to next with the options. Other
+// requests are passed to next as is. When the options don't change the
+// defaults, next is returned.
+func Handler(next http.Handler, o Options) (http.Handler, error) {
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+	if !o.enabled() {
+		return next, nil
+	}
+
+	return &handler{
+		next: next,
+		opts: o,
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool {
+				return true
+			},
+			WriteBufferSize:   o.MaxFrameSize,
+			EnableCompression: o.Compression,
+		},
+	}, nil
+}
+
+type handler struct {
+	next     http.Handler
+	opts     Options
+	upgrader websocket.Upgrader
+}
+
+// hopHeaders are set by the websocket dialer itself.
+var hopHeaders = []string{
+	"Upgrade",
+	"Connection",
+	"Sec-Websocket-Key",
+	"Sec-Websocket-Version",
+	"Sec-Websocket-Extensions",
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !websocket.IsWebSocketUpgrade(r) {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	// connect to next first, so requests it rejects (e.g. unauthorized) are
+	// answered with its response
+	inner, resp, err := h.dialNext(r)
+	if err != nil {
+		if resp != nil {
+			for k, vs := range resp.Header {
+				w.Header()[k] = vs
+			}
+			w.WriteHeader(resp.StatusCode)
+			_, _ = io.Copy(w, resp.Body)
+			return
+		}
+		log.Errorw("connecting to rpc handler", "error", err)
+		http.Error(w, "connecting to rpc handler", http.StatusInternalServerError)
+		return
+	}
+
+	respHeader := http.Header{}
+	if p := resp.Header.Get("Sec-Websocket-Protocol"); p != "" {
+		respHeader.Set("Sec-Websocket-Protocol", p)
+	}
+	if o := resp.Header.Get("Access-Control-Allow-Origin"); o != "" {
+		respHeader.Set("Access-Control-Allow-Origin", o)
+	}
+
+	outer, err := h.upgrader.Upgrade(w, r, respHeader)
+	if err != nil {
+		// the upgrader responds with an http error
+		log.Errorw("upgrading connection", "error", err)
+		_ = inner.Close()
+		return
+	}
+	if h.opts.Compression && h.opts.CompressionLevel != 0 {
+		// the level is validated above
+		_ = outer.SetCompressionLevel(h.opts.CompressionLevel)
+	}
+
+	relay(outer, inner)
+}
+
+// dialNext opens a websocket connection to next with the request headers,
+// over an in-memory connection with the addresses of the request.
+func (h *handler) dialNext(r *http.Request) (*websocket.Conn, *http.Response, error) {
+	client, server := net.Pipe()
+	l := &connListener{
+		conns: make(chan net.Conn, 1),
+		done:  make(chan struct{}),
+	}
+	l.conns <- &addrConn{Conn: server, local: strAddr(r.Host), remote: strAddr(r.RemoteAddr)}
+
+	srv := &http.Server{
+		Handler:           h.next,
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+	go func() {
+		// returns once the listener is closed, the connection is hijacked
+		// by the websocket upgrade of next
+		_ = srv.Serve(l)
+	}()
+
+	d := websocket.Dialer{
+		NetDialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return client, nil
+		},
+		HandshakeTimeout: 45 * time.Second,
+	}
+
+	header := r.Header.Clone()
+	for _, k := range hopHeaders {
+		header.Del(k)
+	}
+
+	u := url.URL{Scheme: "ws", Host: r.Host, Path: r.URL.Path, RawQuery: r.URL.RawQuery}
+	conn, resp, err := d.DialContext(r.Context(), u.String(), header)
+	_ = l.Close()
+	if err != nil {
+		_ = client.Close()
+		if err == websocket.ErrBadHandshake {
+			return nil, resp, err
+		}
+		return nil, nil, err
+	}
+	return conn, resp, nil
+}
+
+// relay copies the messages between the remote connection and the connection
+// to the rpc handler until one of them is closed.
+func relay(remote, local *websocket.Conn) {
+	done := make(chan struct{})
+	defer close(done)
+
+	_ = remote.SetReadDeadline(time.Now().Add(pongTimeout))
+	remote.SetPongHandler(func(string) error {
+		return remote.SetReadDeadline(time.Now().Add(pongTimeout))
+	})
+	go func() {
+		t := time.NewTicker(pingInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				if err := remote.WriteControl(websocket.PingMessage, nil, time.Now().Add(pingInterval)); err != nil {
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	errs := make(chan error, 2)
+	go func() {
+		errs <- copyMessages(local, remote, func() error {
+			return remote.SetReadDeadline(time.Now().Add(pongTimeout))
+		})
+	}()
+	go func() {
+		errs <- copyMessages(remote, local, nil)
+	}()
+
+	if err := <-errs; err != nil && !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+		log.Debugw("relaying websocket messages", "error", err)
+	}
+
+	// closing both connections stops the other copy
+	_ = remote.Close()
+	_ = local.Close()
+	<-errs
+}
+
+// copyMessages copies the messages read from src to dst. A close message
+// read from src is forwarded to dst.
+func copyMessages(dst, src *websocket.Conn, onMessage func() error) error {
+	for {
+		mt, r, err := src.NextReader()
+		if err != nil {
+			if ce, ok := err.(*websocket.CloseError); ok {
+				msg := websocket.FormatCloseMessage(ce.Code, ce.Text)
+				if ce.Code == websocket.CloseNoStatusReceived {
+					msg = websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+				}
+				_ = dst.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+			}
+			return err
+		}
+		if onMessage != nil {
+			if err := onMessage(); err != nil {
+				return err
+			}
+		}
+
+		w, err := dst.NextWriter(mt)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(w, r); err != nil {
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+	}
+}
+
+// connListener accepts a single connection.
+type connListener struct {
+	conns chan net.Conn
+	done  chan struct{}
+	once  sync.Once
+}
+
+func (l *connListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *connListener) Close() error {
+	l.once.Do(func() {
+		close(l.done)
+	})
+	return nil
+}
+
+func (l *connListener) Addr() net.Addr {
+	return strAddr("pipe")
+}
+
+// addrConn is a connection with the addresses of another one.
+type addrConn struct {
+	net.Conn
+	local, remote net.Addr
+}
+
+func (c *addrConn) LocalAddr() net.Addr {
+	return c.local
+}
+
+func (c *addrConn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+type strAddr string
+
+func (a strAddr) Network() string {
+	return "tcp"
+}
+
+func (a strAddr) String() string {
+	return string(a)
+}
@@ -0,0 +1,105 @@
+// stm: #unit
+package rpcws
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-jsonrpc"
+)
+
+type testHandler struct{}
+
+func (h *testHandler) Repeat(ctx context.Context, s string, n int) (string, error) {
+	return strings.Repeat(s, n), nil
+}
+
+type testClient struct {
+	Repeat func(ctx context.Context, s string, n int) (string, error)
+}
+
+func TestHandler(t *testing.T) {
+	rpcServer := jsonrpc.NewServer()
+	rpcServer.Register("Test", &testHandler{})
+
+	// reject requests without a token, like the auth handler
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		rpcServer.ServeHTTP(w, r)
+	})
+
+	h, err := Handler(next, Options{Compression: true, CompressionLevel: 9, MaxFrameSize: 512})
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	addr := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer token")
+
+	// compression is negotiated with clients supporting it
+	d := websocket.Dialer{EnableCompression: true}
+	conn, resp, err := d.Dial(addr, header)
+	require.NoError(t, err)
+	require.Contains(t, resp.Header.Get("Sec-Websocket-Extensions"), "permessage-deflate")
+	require.NoError(t, conn.Close())
+
+	conn, resp, err = websocket.DefaultDialer.Dial(addr, header)
+	require.NoError(t, err)
+	require.Empty(t, resp.Header.Get("Sec-Websocket-Extensions"))
+	require.NoError(t, conn.Close())
+
+	// responses of next rejecting the connection are relayed
+	_, resp, err = d.Dial(addr, nil)
+	require.ErrorIs(t, err, websocket.ErrBadHandshake)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// rpc calls over a compressed connection
+	defaultDialer := websocket.DefaultDialer
+	defer func() {
+		websocket.DefaultDialer = defaultDialer
+	}()
+	require.NoError(t, ConfigureClient(Options{Compression: true, MaxFrameSize: 256}))
+	require.True(t, websocket.DefaultDialer.EnableCompression)
+
+	var client testClient
+	closer, err := jsonrpc.NewMergeClient(context.Background(), addr, "Test", []interface{}{&client}, header)
+	require.NoError(t, err)
+	defer closer()
+
+	for _, n := range []int{1, 100, 100000} {
+		s, err := client.Repeat(context.Background(), "lotus", n)
+		require.NoError(t, err)
+		require.Equal(t, strings.Repeat("lotus", n), s)
+	}
+
+	// plain http requests are passed to next
+	r, err := http.Post(srv.URL, "application/json", strings.NewReader(`{"jsonrpc":"2.0","method":"Test.Repeat","params":["a",3],"id":1}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnauthorized, r.StatusCode)
+	require.NoError(t, r.Body.Close())
+}
+
+func TestOptions(t *testing.T) {
+	next := http.NewServeMux()
+
+	h, err := Handler(next, Options{})
+	require.NoError(t, err)
+	require.Equal(t, next, h)
+
+	_, err = Handler(next, Options{Compression: true, CompressionLevel: 10})
+	require.Error(t, err)
+	_, err = Handler(next, Options{MaxFrameSize: -1})
+	require.Error(t, err)
+	require.Error(t, ConfigureClient(Options{CompressionLevel: -3}))
+}
@@ -0,0 +1,320 @@
+// Package selftest checks that the environment of a node lets it take part in
+// the network: its clock, disks, proof parameters, network reachability, peers
+// and API token.
+package selftest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gbrlsnchs/jwt/v3"
+	ma "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-jsonrpc/auth"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/lib/paramfetch"
+)
+
+// DefaultNTPServer is the server the clock is compared to, unless the
+// LOTUS_SELFTEST_NTP_SERVER environment variable is set.
+const DefaultNTPServer = "pool.ntp.org:123"
+
+func NTPServer() string {
+	if s := os.Getenv("LOTUS_SELFTEST_NTP_SERVER"); s != "" {
+		return s
+	}
+	return DefaultNTPServer
+}
+
+// Check is a check of a self-test. Run returns the result of the check and a
+// message explaining it.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) (api.SelfTestResult, string)
+}
+
+// Run runs the checks in order.
+func Run(ctx context.Context, checks []Check) api.SelfTestReport {
+	r := api.SelfTestReport{
+		Time:   time.Now(),
+		Passed: true,
+		Checks: []api.SelfTestCheck{},
+	}
+
+	for _, c := range checks {
+		start := time.Now()
+		res, msg := c.Run(ctx)
+		r.Checks = append(r.Checks, api.SelfTestCheck{
+			Name:     c.Name,
+			Result:   res,
+			Message:  msg,
+			Duration: time.Since(start),
+		})
+		if res == api.SelfTestFail {
+			r.Passed = false
+		}
+	}
+
+	return r
+}
+
+// Failed is a check failing with the error which prevented it from running.
+func Failed(name string, err error) Check {
+	return Check{
+		Name: name,
+		Run: func(ctx context.Context) (api.SelfTestResult, string) {
+			return api.SelfTestFail, err.Error()
+		},
+	}
+}
+
+// ClockSkew compares the clock to an NTP server. The check fails when the
+// clocks differ by more than maxSkew, and warns past half of it or when the
+// server can't be queried.
+func ClockSkew(server string, maxSkew time.Duration) Check {
+	return Check{
+		Name: "clock skew",
+		Run: func(ctx context.Context) (api.SelfTestResult, string) {
+			skew, err := queryNTP(ctx, server)
+			if err != nil {
+				return api.SelfTestWarn, fmt.Sprintf("querying ntp server %s: %s", server, err)
+			}
+
+			msg := fmt.Sprintf("local clock is %s off %s", skew.Round(time.Millisecond), server)
+			if skew < 0 {
+				skew = -skew
+			}
+			switch {
+			case skew > maxSkew:
+				return api.SelfTestFail, msg + fmt.Sprintf(", blocks are rejected past %s", maxSkew)
+			case skew > maxSkew/2:
+				return api.SelfTestWarn, msg
+			}
+			return api.SelfTestPass, msg
+		},
+	}
+}
+
+// seconds between the NTP epoch (1900) and the unix epoch
+const ntpEpochOffset = 2208988800
+
+// queryNTP returns the offset of the server clock to the local clock, using
+// the SNTP client protocol (RFC 4330).
+func queryNTP(ctx context.Context, server string) (time.Duration, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", server)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close() //nolint:errcheck
+
+	deadline := time.Now().Add(5 * time.Second)
+	if dl, ok := ctx.Deadline(); ok && dl.Before(deadline) {
+		deadline = dl
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return 0, err
+	}
+
+	req := make([]byte, 48)
+	req[0] = 0x1b // no leap indicator, version 3, client mode
+
+	t0 := time.Now()
+	if _, err := conn.Write(req); err != nil {
+		return 0, err
+	}
+	resp := make([]byte, 48)
+	n, err := conn.Read(resp)
+	if err != nil {
+		return 0, err
+	}
+	t3 := time.Now()
+
+	if n < len(resp) {
+		return 0, xerrors.Errorf("short ntp response of %d bytes", n)
+	}
+
+	if mode := resp[0] & 0x7; mode != 4 {
+		return 0, xerrors.Errorf("unexpected ntp mode %d", mode)
+	}
+	if resp[1] == 0 {
+		return 0, xerrors.Errorf("ntp server refused the request")
+	}
+
+	t1 := ntpTime(resp[32:40]) // server receive time
+	t2 := ntpTime(resp[40:48]) // server transmit time
+	return (t1.Sub(t0) + t2.Sub(t3)) / 2, nil
+}
+
+func ntpTime(b []byte) time.Time {
+	sec := binary.BigEndian.Uint32(b[0:4])
+	frac := binary.BigEndian.Uint32(b[4:8])
+	return time.Unix(int64(sec)-ntpEpochOffset, (int64(frac)*1e9)>>32)
+}
+
+// DiskThroughput writes and syncs a test file of the given size in the
+// directory. The check fails when the file can't be written, and warns when
+// the write rate in bytes per second is below minRate.
+func DiskThroughput(name, dir string, size int64, minRate float64) Check {
+	return Check{
+		Name: "disk throughput: " + name,
+		Run: func(ctx context.Context) (api.SelfTestResult, string) {
+			rate, err := writeRate(ctx, dir, size)
+			if err != nil {
+				return api.SelfTestFail, fmt.Sprintf("writing to %s: %s", dir, err)
+			}
+
+			msg := fmt.Sprintf("%s written at %.1f MiB/s", dir, rate/(1<<20))
+			if rate < minRate {
+				return api.SelfTestWarn, msg + fmt.Sprintf(", below %.1f MiB/s", minRate/(1<<20))
+			}
+			return api.SelfTestPass, msg
+		},
+	}
+}
+
+func writeRate(ctx context.Context, dir string, size int64) (float64, error) {
+	f, err := os.CreateTemp(dir, ".lotus-selftest-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(f.Name()) //nolint:errcheck
+	defer f.Close()           //nolint:errcheck
+
+	// random data, so that compressing filesystems write all of it
+	buf := make([]byte, 1<<20)
+	if _, err := rand.Read(buf); err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	for written := int64(0); written < size; written += int64(len(buf)) {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		if _, err := f.Write(buf); err != nil {
+			return 0, err
+		}
+	}
+	if err := f.Sync(); err != nil {
+		return 0, err
+	}
+
+	return float64(size) / time.Since(start).Seconds(), nil
+}
+
+// Params checks the proof parameter files needed for the storage size, see
+// paramfetch.Fetcher.Status.
+func Params(f *paramfetch.Fetcher, storageSize uint64) Check {
+	return Check{
+		Name: "proof parameters",
+		Run: func(ctx context.Context) (api.SelfTestResult, string) {
+			files, err := f.Status(ctx, storageSize)
+			if err != nil {
+				return api.SelfTestFail, fmt.Sprintf("checking parameter files: %s", err)
+			}
+
+			var bad []string
+			for _, st := range files {
+				if st.State != paramfetch.FilePresent {
+					bad = append(bad, fmt.Sprintf("%s (%s)", st.Name, st.State))
+				}
+			}
+			if len(bad) > 0 {
+				return api.SelfTestFail, fmt.Sprintf("%d of %d parameter files are unusable: %s", len(bad), len(files), strings.Join(bad, ", "))
+			}
+			return api.SelfTestPass, fmt.Sprintf("%d parameter files verified", len(files))
+		},
+	}
+}
+
+// Bindable checks that the node can listen on the TCP and UDP addresses, i.e.
+// that they are local addresses and no other process is using the ports.
+func Bindable(name string, addrs []ma.Multiaddr) Check {
+	return Check{
+		Name: "listen addresses: " + name,
+		Run: func(ctx context.Context) (api.SelfTestResult, string) {
+			if len(addrs) == 0 {
+				return api.SelfTestSkip, "no listen address"
+			}
+
+			var failed []string
+			for _, a := range addrs {
+				if err := bind(a); err != nil {
+					failed = append(failed, fmt.Sprintf("%s: %s", a, err))
+				}
+			}
+			if len(failed) > 0 {
+				return api.SelfTestFail, strings.Join(failed, "; ")
+			}
+			return api.SelfTestPass, fmt.Sprintf("%d addresses available", len(addrs))
+		},
+	}
+}
+
+func bind(a ma.Multiaddr) error {
+	// the network part of the address, e.g. /ip4/0.0.0.0/udp/1234 of a quic
+	// address
+	parts := ma.Split(a)
+	if len(parts) < 2 {
+		return xerrors.Errorf("not a network address")
+	}
+	na, err := manet.ToNetAddr(ma.Join(parts[:2]...))
+	if err != nil {
+		return err
+	}
+
+	switch na := na.(type) {
+	case *net.TCPAddr:
+		l, err := net.ListenTCP("tcp", na)
+		if err != nil {
+			return err
+		}
+		return l.Close()
+	case *net.UDPAddr:
+		l, err := net.ListenUDP("udp", na)
+		if err != nil {
+			return err
+		}
+		return l.Close()
+	default:
+		return xerrors.Errorf("unsupported address type %T", na)
+	}
+}
+
+type jwtPayload struct {
+	Allow []auth.Permission
+}
+
+// APIToken checks that the API token is signed with the secret, and grants
+// admin permissions.
+func APIToken(token, secret []byte) Check {
+	return Check{
+		Name: "api token",
+		Run: func(ctx context.Context) (api.SelfTestResult, string) {
+			if len(token) == 0 {
+				return api.SelfTestSkip, "no api token, it is created on first start"
+			}
+
+			var p jwtPayload
+			if _, err := jwt.Verify([]byte(strings.TrimSpace(string(token))), jwt.NewHS256(secret), &p); err != nil {
+				return api.SelfTestFail, fmt.Sprintf("api token doesn't match the api secret: %s", err)
+			}
+			for _, perm := range p.Allow {
+				if perm == api.PermAdmin {
+					return api.SelfTestPass, fmt.Sprintf("api token valid with permissions %v", p.Allow)
+				}
+			}
+			return api.SelfTestWarn, fmt.Sprintf("api token valid but only grants %v", p.Allow)
+		},
+	}
+}
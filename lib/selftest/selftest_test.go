@@ -0,0 +1,113 @@
+// stm: #unit
+package selftest
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gbrlsnchs/jwt/v3"
+	ma "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-jsonrpc/auth"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+func putNTPTime(b []byte, t time.Time) {
+	binary.BigEndian.PutUint32(b[0:4], uint32(t.Unix()+ntpEpochOffset))
+	binary.BigEndian.PutUint32(b[4:8], uint32((int64(t.Nanosecond())<<32)/1e9))
+}
+
+// fakeNTP answers SNTP requests with a clock offset from the local clock
+func fakeNTP(t *testing.T, offset time.Duration) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	go func() {
+		buf := make([]byte, 48)
+		for {
+			_, from, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			resp := make([]byte, 48)
+			resp[0] = 0x1c // server mode
+			resp[1] = 1
+			now := time.Now().Add(offset)
+			putNTPTime(resp[32:40], now)
+			putNTPTime(resp[40:48], now)
+			_, _ = conn.WriteTo(resp, from)
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func TestClockSkew(t *testing.T) {
+	ctx := context.Background()
+
+	res, _ := ClockSkew(fakeNTP(t, 0), time.Second).Run(ctx)
+	require.Equal(t, api.SelfTestPass, res)
+
+	res, _ = ClockSkew(fakeNTP(t, 700*time.Millisecond), time.Second).Run(ctx)
+	require.Equal(t, api.SelfTestWarn, res)
+
+	res, msg := ClockSkew(fakeNTP(t, -3*time.Second), time.Second).Run(ctx)
+	require.Equal(t, api.SelfTestFail, res, msg)
+}
+
+func TestChecks(t *testing.T) {
+	ctx := context.Background()
+
+	res, _ := DiskThroughput("tmp", t.TempDir(), 4<<20, 0).Run(ctx)
+	require.Equal(t, api.SelfTestPass, res)
+	res, _ = DiskThroughput("missing", "/nonexistent/selftest", 4<<20, 0).Run(ctx)
+	require.Equal(t, api.SelfTestFail, res)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close() //nolint:errcheck
+	used, err := manet.FromNetAddr(l.Addr())
+	require.NoError(t, err)
+	free := ma.StringCast("/ip4/127.0.0.1/udp/0/quic-v1")
+
+	res, _ = Bindable("test", []ma.Multiaddr{free}).Run(ctx)
+	require.Equal(t, api.SelfTestPass, res)
+	res, _ = Bindable("test", []ma.Multiaddr{free, used}).Run(ctx)
+	require.Equal(t, api.SelfTestFail, res)
+
+	secret := []byte("secret")
+	admin, err := jwt.Sign(&jwtPayload{Allow: api.AllPermissions}, jwt.NewHS256(secret))
+	require.NoError(t, err)
+	read, err := jwt.Sign(&jwtPayload{Allow: []auth.Permission{api.PermRead}}, jwt.NewHS256(secret))
+	require.NoError(t, err)
+
+	res, _ = APIToken(admin, secret).Run(ctx)
+	require.Equal(t, api.SelfTestPass, res)
+	res, _ = APIToken(read, secret).Run(ctx)
+	require.Equal(t, api.SelfTestWarn, res)
+	res, _ = APIToken(admin, []byte("other")).Run(ctx)
+	require.Equal(t, api.SelfTestFail, res)
+	res, _ = APIToken(nil, secret).Run(ctx)
+	require.Equal(t, api.SelfTestSkip, res)
+
+	report := Run(ctx, []Check{
+		APIToken(admin, secret),
+		APIToken(nil, secret),
+	})
+	require.True(t, report.Passed)
+	require.Len(t, report.Checks, 2)
+
+	report = Run(ctx, []Check{
+		APIToken(admin, secret),
+		APIToken(admin, []byte("other")),
+	})
+	require.False(t, report.Passed)
+	require.Equal(t, "api token", report.Checks[1].Name)
+}
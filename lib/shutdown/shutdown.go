@@ -0,0 +1,289 @@
+// Package shutdown coordinates the shutdown of a node: it stops the node
+// components in order, each within its grace period, and accounts for the
+// tasks which are still in flight so that what blocks a shutdown is reported.
+package shutdown
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"go.uber.org/fx/fxevent"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+var log = logging.Logger("shutdown")
+
+// Names of the components stopped by the node commands.
+const (
+	ComponentRPC  = "rpc server"
+	ComponentNode = "node"
+)
+
+// how often the tasks blocking a shutdown are logged
+var reportInterval = 30 * time.Second
+
+// Handler stops a component. Grace is how long Stop is given, 0 means the
+// grace period set on the coordinator for the component.
+type Handler struct {
+	Component string
+	Stop      func(context.Context) error
+	Grace     time.Duration
+}
+
+// ProbeFunc lists the in-flight tasks of a component which aren't tracked
+// with Track, e.g. the running sealing jobs.
+type ProbeFunc func(ctx context.Context) ([]api.ShutdownTask, error)
+
+type probe struct {
+	component string
+	fn        ProbeFunc
+}
+
+type Coordinator struct {
+	lk sync.Mutex
+
+	grace      map[string]time.Duration
+	started    time.Time
+	components []api.ShutdownComponent
+
+	nextTask uint64
+	tasks    map[uint64]api.ShutdownTask
+	// OnStop hooks of the fx app being executed, by function name
+	hooks  map[string]api.ShutdownTask
+	probes []probe
+}
+
+func NewCoordinator() *Coordinator {
+	return &Coordinator{
+		grace: map[string]time.Duration{},
+		tasks: map[uint64]api.ShutdownTask{},
+		hooks: map[string]api.ShutdownTask{},
+	}
+}
+
+// SetGrace sets the grace period of a component, 0 for no limit.
+func (c *Coordinator) SetGrace(component string, grace time.Duration) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	c.grace[component] = grace
+}
+
+// Track records an in-flight task until the returned function is called.
+func (c *Coordinator) Track(component, kind, description string) func() {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	id := c.nextTask
+	c.nextTask++
+	c.tasks[id] = api.ShutdownTask{
+		Component:   component,
+		Kind:        kind,
+		Description: description,
+		Started:     time.Now(),
+	}
+
+	return func() {
+		c.lk.Lock()
+		defer c.lk.Unlock()
+
+		delete(c.tasks, id)
+	}
+}
+
+// AddProbe registers a function listing in-flight tasks of a component.
+func (c *Coordinator) AddProbe(component string, fn ProbeFunc) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	c.probes = append(c.probes, probe{component: component, fn: fn})
+}
+
+// ShuttingDown returns true once the shutdown has started.
+func (c *Coordinator) ShuttingDown() bool {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	return !c.started.IsZero()
+}
+
+// Status returns the state of the components and the in-flight tasks.
+func (c *Coordinator) Status(ctx context.Context) api.ShutdownStatus {
+	c.lk.Lock()
+	st := api.ShutdownStatus{
+		ShuttingDown: !c.started.IsZero(),
+		Started:      c.started,
+		Components:   append([]api.ShutdownComponent{}, c.components...),
+		InFlight:     []api.ShutdownTask{},
+	}
+	for _, t := range c.hooks {
+		st.InFlight = append(st.InFlight, t)
+	}
+	for _, t := range c.tasks {
+		st.InFlight = append(st.InFlight, t)
+	}
+	probes := append([]probe{}, c.probes...)
+	c.lk.Unlock()
+
+	for _, p := range probes {
+		tasks, err := p.fn(ctx)
+		if err != nil {
+			log.Warnw("listing in-flight tasks", "component", p.component, "error", err)
+			continue
+		}
+		for _, t := range tasks {
+			t.Component = p.component
+			st.InFlight = append(st.InFlight, t)
+		}
+	}
+
+	sort.SliceStable(st.InFlight, func(i, j int) bool {
+		if st.InFlight[i].Component != st.InFlight[j].Component {
+			return st.InFlight[i].Component < st.InFlight[j].Component
+		}
+		return st.InFlight[i].Started.Before(st.InFlight[j].Started)
+	})
+
+	return st
+}
+
+// Run stops the components in order. A component which doesn't stop within
+// its grace period is left behind, and the shutdown carries on with the next
+// one. While a component stops, the in-flight tasks are logged periodically.
+func (c *Coordinator) Run(handlers []Handler) {
+	c.lk.Lock()
+	c.started = time.Now()
+	c.components = make([]api.ShutdownComponent, len(handlers))
+	for i, h := range handlers {
+		grace := h.Grace
+		if grace == 0 {
+			grace = c.grace[h.Component]
+		}
+		handlers[i].Grace = grace
+		c.components[i] = api.ShutdownComponent{
+			Name:  h.Component,
+			State: api.ShutdownPending,
+			Grace: grace,
+		}
+	}
+	c.lk.Unlock()
+
+	for i, h := range handlers {
+		c.update(i, func(sc *api.ShutdownComponent) {
+			sc.State = api.ShutdownStopping
+			sc.Started = time.Now()
+		})
+
+		state, err := c.stop(h)
+
+		c.update(i, func(sc *api.ShutdownComponent) {
+			sc.State = state
+			sc.Took = time.Since(sc.Started)
+			if err != nil {
+				sc.Error = err.Error()
+			}
+		})
+
+		switch state {
+		case api.ShutdownStopped:
+			log.Infof("%s shut down successfully ", h.Component)
+		case api.ShutdownTimedOut:
+			log.Errorf("%s didn't shut down within %s, carrying on without it", h.Component, h.Grace)
+			c.logInFlight()
+		default:
+			log.Errorf("shutting down %s failed: %s", h.Component, err)
+		}
+	}
+}
+
+func (c *Coordinator) stop(h Handler) (api.ShutdownState, error) {
+	ctx, cancel := context.Background(), context.CancelFunc(func() {})
+	if h.Grace > 0 {
+		ctx, cancel = context.WithTimeout(ctx, h.Grace)
+	}
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- h.Stop(ctx)
+	}()
+
+	report := time.NewTicker(reportInterval)
+	defer report.Stop()
+
+	for {
+		select {
+		case err := <-done:
+			switch {
+			case err == nil:
+				return api.ShutdownStopped, nil
+			case ctx.Err() != nil:
+				return api.ShutdownTimedOut, err
+			default:
+				return api.ShutdownFailed, err
+			}
+		case <-ctx.Done():
+			return api.ShutdownTimedOut, xerrors.Errorf("not stopped within %s", h.Grace)
+		case <-report.C:
+			log.Warnf("still shutting down %s", h.Component)
+			c.logInFlight()
+		}
+	}
+}
+
+func (c *Coordinator) update(i int, f func(*api.ShutdownComponent)) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	f(&c.components[i])
+}
+
+func (c *Coordinator) logInFlight() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for _, t := range c.Status(ctx).InFlight {
+		args := []interface{}{"component", t.Component, "kind", t.Kind, "task", t.Description}
+		if !t.Started.IsZero() {
+			args = append(args, "running", time.Since(t.Started).Round(time.Second))
+		}
+		log.Warnw("shutdown waiting for task", args...)
+	}
+}
+
+// FxLogger returns an fx event logger recording the OnStop hooks being
+// executed, so that the hooks holding the shutdown of the node are reported.
+// Other events are discarded.
+func (c *Coordinator) FxLogger() fxevent.Logger {
+	return fxLogger{c}
+}
+
+type fxLogger struct {
+	c *Coordinator
+}
+
+func (l fxLogger) LogEvent(event fxevent.Event) {
+	switch e := event.(type) {
+	case *fxevent.OnStopExecuting:
+		l.c.lk.Lock()
+		l.c.hooks[e.FunctionName] = api.ShutdownTask{
+			Component:   ComponentNode,
+			Kind:        "stop hook",
+			Description: e.FunctionName,
+			Started:     time.Now(),
+		}
+		l.c.lk.Unlock()
+	case *fxevent.OnStopExecuted:
+		l.c.lk.Lock()
+		delete(l.c.hooks, e.FunctionName)
+		l.c.lk.Unlock()
+		if e.Err != nil {
+			log.Warnw("stop hook failed", "hook", e.FunctionName, "error", e.Err)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// stm: #unit
+package shutdown
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx/fxevent"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+func TestCoordinator(t *testing.T) {
+	ctx := context.Background()
+	c := NewCoordinator()
+	c.SetGrace("slow", 50*time.Millisecond)
+
+	done := c.Track("api", "message wait", "bafy")
+	c.AddProbe("sealing", func(ctx context.Context) ([]api.ShutdownTask, error) {
+		return []api.ShutdownTask{{Kind: "sealing task", Description: "PC1 s-t01000-1"}}, nil
+	})
+
+	st := c.Status(ctx)
+	require.False(t, st.ShuttingDown)
+	require.Len(t, st.InFlight, 2)
+	require.Equal(t, "api", st.InFlight[0].Component)
+	require.Equal(t, "sealing", st.InFlight[1].Component)
+
+	done()
+	require.Len(t, c.Status(ctx).InFlight, 1)
+
+	fl := c.FxLogger()
+	fl.LogEvent(&fxevent.OnStopExecuting{FunctionName: "modules.Hook"})
+	require.Len(t, c.Status(ctx).InFlight, 2)
+	fl.LogEvent(&fxevent.OnStopExecuted{FunctionName: "modules.Hook"})
+	require.Len(t, c.Status(ctx).InFlight, 1)
+
+	var lk sync.Mutex
+	var order []string
+	stopped := func(name string) {
+		lk.Lock()
+		defer lk.Unlock()
+		order = append(order, name)
+	}
+	c.Run([]Handler{
+		{Component: "fast", Stop: func(ctx context.Context) error {
+			stopped("fast")
+			return nil
+		}},
+		{Component: "slow", Stop: func(ctx context.Context) error {
+			stopped("slow")
+			<-make(chan struct{}) // never stops
+			return nil
+		}},
+		{Component: "failing", Stop: func(ctx context.Context) error {
+			stopped("failing")
+			return xerrors.New("boom")
+		}},
+	})
+
+	lk.Lock()
+	require.Equal(t, []string{"fast", "slow", "failing"}, order)
+	lk.Unlock()
+
+	st = c.Status(ctx)
+	require.True(t, st.ShuttingDown)
+	require.True(t, c.ShuttingDown())
+	require.Len(t, st.Components, 3)
+	require.Equal(t, api.ShutdownStopped, st.Components[0].State)
+	require.Equal(t, api.ShutdownTimedOut, st.Components[1].State)
+	require.Equal(t, 50*time.Millisecond, st.Components[1].Grace)
+	require.Equal(t, api.ShutdownFailed, st.Components[2].State)
+	require.Equal(t, "boom", st.Components[2].Error)
+}
@@ -0,0 +1,260 @@
+// Package sqlds implements a datastore backed by a table of an SQLite or
+// Postgres database, making its content available to external SQL queries.
+package sqlds
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+	"golang.org/x/xerrors"
+)
+
+const (
+	DriverSQLite   = "sqlite3"
+	DriverPostgres = "postgres"
+)
+
+var tableName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Datastore stores the keys and values in the key and value columns of a
+// table. Keys are stored as their string representation, so keys sharing a
+// prefix can be selected with a range query on the key column.
+type Datastore struct {
+	db     *sql.DB
+	driver string
+	table  string
+}
+
+var _ datastore.Batching = (*Datastore)(nil)
+
+// Open opens the database with the given driver, one of DriverSQLite and
+// DriverPostgres, and creates the table if it doesn't exist. The dsn of
+// SQLite databases is the path of the database file.
+func Open(driver, dsn, table string) (*Datastore, error) {
+	if !tableName.MatchString(table) {
+		return nil, xerrors.Errorf("invalid table name '%s'", table)
+	}
+
+	keyType, valueType := "TEXT", "BLOB"
+	switch driver {
+	case DriverSQLite:
+		// concurrent writers wait for each other instead of failing
+		dsn += "?mode=rwc&_journal_mode=WAL&_busy_timeout=10000"
+	case DriverPostgres:
+		// keys are compared byte by byte for the prefix range queries
+		keyType, valueType = `TEXT COLLATE "C"`, "BYTEA"
+	default:
+		return nil, xerrors.Errorf("unsupported sql driver '%s'", driver)
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, xerrors.Errorf("opening %s database: %w", driver, err)
+	}
+
+	stmt := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		key %s PRIMARY KEY NOT NULL,
+		value %s NOT NULL
+	)`, table, keyType, valueType)
+	if _, err := db.Exec(stmt); err != nil {
+		_ = db.Close()
+		return nil, xerrors.Errorf("creating table %s: %w", table, err)
+	}
+
+	return &Datastore{db: db, driver: driver, table: table}, nil
+}
+
+// q formats a statement on the table, numbering its ? placeholders for
+// Postgres.
+func (d *Datastore) q(format string) string {
+	s := fmt.Sprintf(format, d.table)
+	if d.driver != DriverPostgres {
+		return s
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, c := range s {
+		if c == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// execer is implemented by both the database and its transactions
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func (d *Datastore) put(ctx context.Context, e execer, key datastore.Key, value []byte) error {
+	if value == nil {
+		// the column doesn't accept NULL
+		value = []byte{}
+	}
+	_, err := e.ExecContext(ctx, d.q(`INSERT INTO %s (key, value) VALUES (?, ?)
+		ON CONFLICT (key) DO UPDATE SET value = excluded.value`), key.String(), value)
+	return err
+}
+
+func (d *Datastore) delete(ctx context.Context, e execer, key datastore.Key) error {
+	_, err := e.ExecContext(ctx, d.q(`DELETE FROM %s WHERE key = ?`), key.String())
+	return err
+}
+
+func (d *Datastore) Get(ctx context.Context, key datastore.Key) ([]byte, error) {
+	var value []byte
+	err := d.db.QueryRowContext(ctx, d.q(`SELECT value FROM %s WHERE key = ?`), key.String()).Scan(&value)
+	if err == sql.ErrNoRows {
+		return nil, datastore.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (d *Datastore) Has(ctx context.Context, key datastore.Key) (bool, error) {
+	_, err := d.GetSize(ctx, key)
+	if err == datastore.ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (d *Datastore) GetSize(ctx context.Context, key datastore.Key) (int, error) {
+	var size int
+	err := d.db.QueryRowContext(ctx, d.q(`SELECT length(value) FROM %s WHERE key = ?`), key.String()).Scan(&size)
+	if err == sql.ErrNoRows {
+		return -1, datastore.ErrNotFound
+	}
+	if err != nil {
+		return -1, err
+	}
+	return size, nil
+}
+
+func (d *Datastore) Put(ctx context.Context, key datastore.Key, value []byte) error {
+	return d.put(ctx, d.db, key, value)
+}
+
+func (d *Datastore) Delete(ctx context.Context, key datastore.Key) error {
+	return d.delete(ctx, d.db, key)
+}
+
+// Query selects the keys under the prefix of the query in the database, and
+// applies the filters, orders, offset and limit of the query to them.
+func (d *Datastore) Query(ctx context.Context, q query.Query) (query.Results, error) {
+	cols := "key, value"
+	if q.KeysOnly {
+		cols = "key, ''"
+	}
+	stmt := fmt.Sprintf(`SELECT %s FROM %%s`, cols)
+
+	var args []interface{}
+	prefix := datastore.NewKey(q.Prefix).String()
+	if prefix != "/" {
+		// '0' follows '/', so the range holds all the keys under the prefix
+		stmt += ` WHERE key > ? AND key < ?`
+		args = append(args, prefix+"/", prefix+"0")
+	}
+	stmt += ` ORDER BY key`
+
+	rows, err := d.db.QueryContext(ctx, d.q(stmt), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	it := query.Iterator{
+		Next: func() (query.Result, bool) {
+			if !rows.Next() {
+				if err := rows.Err(); err != nil {
+					return query.Result{Error: err}, true
+				}
+				return query.Result{}, false
+			}
+
+			var e query.Entry
+			if err := rows.Scan(&e.Key, &e.Value); err != nil {
+				return query.Result{Error: err}, true
+			}
+			e.Size = len(e.Value)
+			if q.KeysOnly {
+				e.Value = nil
+				e.Size = -1
+			}
+			return query.Result{Entry: e}, true
+		},
+		Close: rows.Close,
+	}
+
+	return query.NaiveQueryApply(q, query.ResultsFromIterator(q, it)), nil
+}
+
+// Sync is a no-op, writes are durable once their statement completes.
+func (d *Datastore) Sync(ctx context.Context, prefix datastore.Key) error {
+	return nil
+}
+
+func (d *Datastore) Close() error {
+	return d.db.Close()
+}
+
+func (d *Datastore) Batch(ctx context.Context) (datastore.Batch, error) {
+	return &batch{d: d, ops: map[datastore.Key][]byte{}}, nil
+}
+
+// batch applies its operations in a single transaction. A nil value marks
+// a deletion.
+type batch struct {
+	d   *Datastore
+	ops map[datastore.Key][]byte
+}
+
+func (b *batch) Put(ctx context.Context, key datastore.Key, value []byte) error {
+	if value == nil {
+		value = []byte{}
+	}
+	b.ops[key] = value
+	return nil
+}
+
+func (b *batch) Delete(ctx context.Context, key datastore.Key) error {
+	b.ops[key] = nil
+	return nil
+}
+
+func (b *batch) Commit(ctx context.Context) error {
+	tx, err := b.d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return xerrors.Errorf("starting batch transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	for k, v := range b.ops {
+		if v == nil {
+			err = b.d.delete(ctx, tx, k)
+		} else {
+			err = b.d.put(ctx, tx, k, v)
+		}
+		if err != nil {
+			return xerrors.Errorf("batch operation on %s: %w", k, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return xerrors.Errorf("committing batch: %w", err)
+	}
+	b.ops = map[datastore.Key][]byte{}
+	return nil
+}
@@ -0,0 +1,76 @@
+// stm: #unit
+package sqlds
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatastore(t *testing.T) {
+	ctx := context.Background()
+
+	ds, err := Open(DriverSQLite, filepath.Join(t.TempDir(), "ds.sqlite"), "test")
+	require.NoError(t, err)
+	defer ds.Close() //nolint:errcheck
+
+	_, err = ds.Get(ctx, datastore.NewKey("/a"))
+	require.Equal(t, datastore.ErrNotFound, err)
+
+	require.NoError(t, ds.Put(ctx, datastore.NewKey("/a"), []byte("1")))
+	require.NoError(t, ds.Put(ctx, datastore.NewKey("/a"), []byte("22")))
+	v, err := ds.Get(ctx, datastore.NewKey("/a"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("22"), v)
+
+	size, err := ds.GetSize(ctx, datastore.NewKey("/a"))
+	require.NoError(t, err)
+	require.Equal(t, 2, size)
+
+	has, err := ds.Has(ctx, datastore.NewKey("/a"))
+	require.NoError(t, err)
+	require.True(t, has)
+
+	require.NoError(t, ds.Delete(ctx, datastore.NewKey("/a")))
+	has, err = ds.Has(ctx, datastore.NewKey("/a"))
+	require.NoError(t, err)
+	require.False(t, has)
+
+	b, err := ds.Batch(ctx)
+	require.NoError(t, err)
+	for _, k := range []string{"/deals/1", "/deals/2", "/deals/2/x", "/deals0", "/dealsx/1", "/other"} {
+		require.NoError(t, b.Put(ctx, datastore.NewKey(k), []byte(k)))
+	}
+	require.NoError(t, b.Delete(ctx, datastore.NewKey("/other")))
+	require.NoError(t, b.Commit(ctx))
+
+	has, err = ds.Has(ctx, datastore.NewKey("/other"))
+	require.NoError(t, err)
+	require.False(t, has)
+
+	keys := func(ds datastore.Read, q query.Query) []string {
+		res, err := ds.Query(ctx, q)
+		require.NoError(t, err)
+		all, err := res.Rest()
+		require.NoError(t, err)
+		var out []string
+		for _, e := range all {
+			out = append(out, e.Key)
+		}
+		return out
+	}
+
+	require.Equal(t, []string{"/deals/1", "/deals/2", "/deals/2/x"}, keys(ds, query.Query{Prefix: "/deals"}))
+	require.Equal(t, []string{"/deals/2/x"}, keys(ds, query.Query{Prefix: "/deals/2", KeysOnly: true}))
+	require.Len(t, keys(ds, query.Query{}), 5)
+	require.Equal(t, []string{"/deals/2"}, keys(ds, query.Query{Prefix: "/deals", Offset: 1, Limit: 1}))
+
+	// namespaced datastores only see their keys
+	nds := namespace.Wrap(ds, datastore.NewKey("/deals"))
+	require.Equal(t, []string{"/1", "/2", "/2/x"}, keys(nds, query.Query{}))
+}
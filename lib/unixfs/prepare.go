@@ -0,0 +1,145 @@
+package unixfs
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/ipfs/go-cid"
+	carv2 "github.com/ipld/go-car/v2"
+	"github.com/ipld/go-car/v2/blockstore"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	lblockstore "github.com/filecoin-project/lotus/blockstore"
+)
+
+// carOverheadMargin is the room left in each piece for the CAR header and the
+// intermediate nodes of the DAG, on top of 1/64th of the piece for the
+// framing of the blocks
+const carOverheadMargin = 512
+
+// PreparedCAR is a CARv1 file holding the UnixFS DAG of a range of a file,
+// fitting into a piece
+type PreparedCAR struct {
+	// Offset and Length are the range of the file in the CAR
+	Offset uint64
+	Length uint64
+
+	Root    cid.Cid
+	Path    string
+	CarSize uint64
+}
+
+// MaxPieceData returns the size of the data the UnixFS DAG of which fits into
+// a piece of the given size, once written as a CARv1.
+func MaxPieceData(pieceSize abi.PaddedPieceSize) (uint64, error) {
+	if err := pieceSize.Validate(); err != nil {
+		return 0, xerrors.Errorf("invalid piece size: %w", err)
+	}
+
+	up := uint64(pieceSize.Unpadded())
+	overhead := up/64 + carOverheadMargin
+	if up <= overhead {
+		return 0, xerrors.Errorf("piece size %d too small to hold any data", pieceSize)
+	}
+	return up - overhead, nil
+}
+
+// PrepareCARs splits the file at srcPath into ranges the UnixFS DAG of which
+// fits into a piece of pieceSize, and writes the DAG of each range to a CARv1
+// file at the path returned by carPath.
+func PrepareCARs(ctx context.Context, srcPath string, pieceSize abi.PaddedPieceSize, carPath func(i int) (string, error)) ([]PreparedCAR, error) {
+	maxData, err := MaxPieceData(pieceSize)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to open input file: %w", err)
+	}
+	defer src.Close() //nolint:errcheck
+
+	stat, err := src.Stat()
+	if err != nil {
+		return nil, xerrors.Errorf("failed to stat file: %w", err)
+	}
+	if !stat.Mode().IsRegular() {
+		return nil, xerrors.Errorf("%s is not a regular file", srcPath)
+	}
+	size := uint64(stat.Size())
+	if size == 0 {
+		return nil, xerrors.Errorf("%s is empty", srcPath)
+	}
+
+	var out []PreparedCAR
+	for offset := uint64(0); offset < size; offset += maxData {
+		length := maxData
+		if size-offset < length {
+			length = size - offset
+		}
+
+		path, err := carPath(len(out))
+		if err != nil {
+			return nil, xerrors.Errorf("getting path of CAR %d: %w", len(out), err)
+		}
+
+		root, err := writeCAR(ctx, io.NewSectionReader(src, int64(offset), int64(length)), path)
+		if err != nil {
+			return nil, xerrors.Errorf("writing CAR of range %d-%d: %w", offset, offset+length, err)
+		}
+
+		cst, err := os.Stat(path)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to stat CAR: %w", err)
+		}
+		if uint64(cst.Size()) > uint64(pieceSize.Unpadded()) {
+			return nil, xerrors.Errorf("CAR of range %d-%d is %d bytes, larger than a piece of %d", offset, offset+length, cst.Size(), pieceSize)
+		}
+
+		out = append(out, PreparedCAR{
+			Offset:  offset,
+			Length:  length,
+			Root:    root,
+			Path:    path,
+			CarSize: uint64(cst.Size()),
+		})
+	}
+
+	return out, nil
+}
+
+// writeCAR writes the UnixFS DAG of the data to a CARv1 file at dstPath. The
+// DAG is built twice, as the root of the CAR must be known before the blocks
+// are written.
+func writeCAR(ctx context.Context, data io.ReadSeeker, dstPath string) (cid.Cid, error) {
+	root, err := Build(ctx, data, lblockstore.NewDiscardStore(lblockstore.NewMemory()), false)
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("computing root of UnixFS DAG: %w", err)
+	}
+
+	if _, err := data.Seek(0, io.SeekStart); err != nil {
+		return cid.Undef, xerrors.Errorf("failed to rewind data: %w", err)
+	}
+
+	bs, err := blockstore.OpenReadWrite(dstPath, []cid.Cid{root}, carv2.WriteAsCarV1(true), blockstore.UseWholeCIDs(true))
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("failed to create CAR blockstore: %w", err)
+	}
+
+	root2, err := Build(ctx, data, bs, false)
+	if err != nil {
+		bs.Discard()
+		return cid.Undef, xerrors.Errorf("failed to create UnixFS DAG with CAR blockstore: %w", err)
+	}
+	if err := bs.Finalize(); err != nil {
+		return cid.Undef, xerrors.Errorf("failed to finalize CAR blockstore: %w", err)
+	}
+
+	if root != root2 {
+		return cid.Undef, xerrors.New("roots do not match")
+	}
+	return root, nil
+}
@@ -0,0 +1,86 @@
+// stm: #unit
+package unixfs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ipfs/boxo/files"
+	"github.com/ipfs/go-blockservice"
+	offline "github.com/ipfs/go-ipfs-exchange-offline"
+	"github.com/ipfs/go-merkledag"
+	unixfile "github.com/ipfs/go-unixfs/file"
+	carv2 "github.com/ipld/go-car/v2"
+	"github.com/ipld/go-car/v2/blockstore"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+)
+
+func TestPrepareCARs(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	// 9MiB of data, split into pieces of 4MiB holding several blocks each
+	contents := make([]byte, 9<<20)
+	for i := range contents {
+		contents[i] = byte(i * 7 / 5)
+	}
+	src := filepath.Join(dir, "input")
+	require.NoError(t, os.WriteFile(src, contents, 0644))
+
+	pieceSize := abi.PaddedPieceSize(4 << 20)
+	maxData, err := MaxPieceData(pieceSize)
+	require.NoError(t, err)
+
+	cars, err := PrepareCARs(ctx, src, pieceSize, func(i int) (string, error) {
+		return filepath.Join(dir, fmt.Sprintf("%d.car", i)), nil
+	})
+	require.NoError(t, err)
+	require.Len(t, cars, 3)
+
+	var joined []byte
+	for i, c := range cars {
+		require.Equal(t, uint64(i)*maxData, c.Offset)
+		require.LessOrEqual(t, c.CarSize, uint64(pieceSize.Unpadded()))
+
+		f, err := os.Open(c.Path)
+		require.NoError(t, err)
+		version, err := carv2.ReadVersion(f)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+		require.Equal(t, uint64(1), version)
+
+		// each CAR holds the complete DAG of its range
+		bs, err := blockstore.OpenReadOnly(c.Path, blockstore.UseWholeCIDs(true))
+		require.NoError(t, err)
+		roots, err := bs.Roots()
+		require.NoError(t, err)
+		require.Equal(t, c.Root, roots[0])
+
+		dags := merkledag.NewDAGService(blockservice.New(bs, offline.Exchange(bs)))
+		nd, err := dags.Get(ctx, c.Root)
+		require.NoError(t, err)
+		file, err := unixfile.NewUnixfsFile(ctx, dags, nd)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		_, err = io.Copy(&buf, files.ToFile(file))
+		require.NoError(t, err)
+		require.Equal(t, c.Length, uint64(buf.Len()))
+		joined = append(joined, buf.Bytes()...)
+
+		require.NoError(t, bs.Close())
+	}
+	require.Equal(t, contents, joined)
+
+	_, err = MaxPieceData(abi.PaddedPieceSize(512))
+	require.ErrorContains(t, err, "too small")
+	_, err = PrepareCARs(ctx, dir, pieceSize, nil)
+	require.ErrorContains(t, err, "not a regular file")
+}
@@ -0,0 +1,502 @@
+// Package allocations manages the datacap allocations of verified clients
+// (FIP-45): it creates allocations for pieces to be stored by providers,
+// tracks them until they're claimed by the provider sealing the piece, and
+// extends the terms of the resulting claims.
+package allocations
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	datacaptypes "github.com/filecoin-project/go-state-types/builtin/v9/datacap"
+	verifregtypes "github.com/filecoin-project/go-state-types/builtin/v9/verifreg"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/datacap"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/verifreg"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/journal/alerting"
+)
+
+var log = logging.Logger("allocations")
+
+// NodeAPI is the subset of the full node API used to manage allocations.
+type NodeAPI interface {
+	ChainHead(context.Context) (*types.TipSet, error)
+	StateLookupID(context.Context, address.Address, types.TipSetKey) (address.Address, error)
+	StateGetAllocations(context.Context, address.Address, types.TipSetKey) (map[verifregtypes.AllocationId]verifregtypes.Allocation, error)
+	StateGetClaims(context.Context, address.Address, types.TipSetKey) (map[verifregtypes.ClaimId]verifregtypes.Claim, error)
+	MpoolPushMessage(context.Context, *types.Message, *api.MessageSendSpec) (*types.SignedMessage, error)
+}
+
+type Config struct {
+	// Clients are monitored in addition to the clients which created
+	// allocations through the manager.
+	Clients []address.Address
+	// CheckInterval is how often the allocations and claims of the monitored
+	// clients are checked.
+	CheckInterval time.Duration
+	// ExpiryWarning is the number of epochs before the expiration of an
+	// unclaimed allocation from which it's reported as expiring.
+	ExpiryWarning abi.ChainEpoch
+	// ExtendBefore is the number of epochs before the end of the term of a
+	// claim from which it's extended. Zero disables automatic extensions.
+	ExtendBefore abi.ChainEpoch
+	// TermMax is the maximum term claims are extended to.
+	TermMax abi.ChainEpoch
+}
+
+// Manager creates allocations and extends claims, and, once started,
+// periodically checks the allocations of the monitored clients: it raises an
+// alert while some are about to expire unclaimed, and extends the claims
+// about to reach the end of their term.
+type Manager struct {
+	api NodeAPI
+	ds  datastore.Batching
+	cfg Config
+
+	al    *alerting.Alerting
+	alert alerting.AlertType
+
+	lk sync.Mutex
+	// the term claims were last extended to, so that pending extensions
+	// aren't sent again
+	extending map[verifregtypes.ClaimId]abi.ChainEpoch
+
+	closing chan struct{}
+	closed  chan struct{}
+}
+
+// NewManager creates a manager recording the providers of the allocations of
+// each client in the datastore, to find their claims. The alerting system is
+// optional.
+func NewManager(a NodeAPI, ds datastore.Batching, al *alerting.Alerting, cfg Config) *Manager {
+	if cfg.TermMax <= 0 || cfg.TermMax > verifregtypes.MaximumVerifiedAllocationTerm {
+		cfg.TermMax = verifregtypes.MaximumVerifiedAllocationTerm
+	}
+
+	m := &Manager{
+		api:       a,
+		ds:        ds,
+		cfg:       cfg,
+		al:        al,
+		extending: map[verifregtypes.ClaimId]abi.ChainEpoch{},
+		closing:   make(chan struct{}),
+		closed:    make(chan struct{}),
+	}
+	if al != nil {
+		m.alert = al.AddAlertType("allocations", "expiring-unclaimed")
+	}
+	return m
+}
+
+// Start starts checking the allocations of the monitored clients.
+func (m *Manager) Start() {
+	go m.run()
+}
+
+func (m *Manager) Stop(ctx context.Context) error {
+	close(m.closing)
+
+	select {
+	case <-m.closed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m *Manager) run() {
+	defer close(m.closed)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-m.closing
+		cancel()
+	}()
+
+	ticker := time.NewTicker(m.cfg.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.check(ctx); err != nil {
+			log.Errorw("checking allocations", "error", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-m.closing:
+			return
+		}
+	}
+}
+
+// Create sends a message transferring the datacap needed for the requested
+// allocations from the client to the verified registry, which creates them.
+func (m *Manager) Create(ctx context.Context, client address.Address, reqs []verifregtypes.AllocationRequest) (cid.Cid, error) {
+	if len(reqs) == 0 {
+		return cid.Undef, xerrors.Errorf("no allocation requested")
+	}
+
+	total := big.Zero()
+	for i, r := range reqs {
+		if r.Size == 0 {
+			return cid.Undef, xerrors.Errorf("allocation %d: zero size", i)
+		}
+		if r.TermMin > r.TermMax {
+			return cid.Undef, xerrors.Errorf("allocation %d: term min %d above term max %d", i, r.TermMin, r.TermMax)
+		}
+		total = big.Add(total, big.NewInt(int64(r.Size)))
+	}
+
+	clientID, err := m.api.StateLookupID(ctx, client, types.EmptyTSK)
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("looking up client id: %w", err)
+	}
+	for _, r := range reqs {
+		if err := m.recordProvider(ctx, clientID, r.Provider); err != nil {
+			return cid.Undef, err
+		}
+	}
+
+	allocReqs := &AllocationRequests{
+		Allocations: make([]AllocationRequest, len(reqs)),
+	}
+	for i, r := range reqs {
+		allocReqs.Allocations[i] = AllocationRequest(r)
+	}
+
+	operatorData, err := actors.SerializeParams(allocReqs)
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("serializing allocation requests: %w", err)
+	}
+
+	params, err := actors.SerializeParams(&datacaptypes.TransferParams{
+		To:           verifreg.Address,
+		Amount:       big.Mul(total, verifregtypes.DataCapGranularity),
+		OperatorData: operatorData,
+	})
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("serializing transfer params: %w", err)
+	}
+
+	smsg, err := m.api.MpoolPushMessage(ctx, &types.Message{
+		To:     datacap.Address,
+		From:   client,
+		Method: datacap.Methods.TransferExported,
+		Params: params,
+	}, nil)
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("pushing datacap transfer message: %w", err)
+	}
+
+	return smsg.Cid(), nil
+}
+
+// ExtendClaims sends a message extending the terms of claims made with the
+// allocations of the client.
+func (m *Manager) ExtendClaims(ctx context.Context, client address.Address, terms []verifregtypes.ClaimTerm) (cid.Cid, error) {
+	if len(terms) == 0 {
+		return cid.Undef, xerrors.Errorf("no claim to extend")
+	}
+
+	params, aerr := actors.SerializeParams(&verifregtypes.ExtendClaimTermsParams{Terms: terms})
+	if aerr != nil {
+		return cid.Undef, xerrors.Errorf("serializing extend claim terms params: %w", aerr)
+	}
+
+	smsg, err := m.api.MpoolPushMessage(ctx, &types.Message{
+		To:     verifreg.Address,
+		From:   client,
+		Method: verifreg.Methods.ExtendClaimTerms,
+		Params: params,
+	}, nil)
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("pushing extend claim terms message: %w", err)
+	}
+
+	return smsg.Cid(), nil
+}
+
+// List returns the allocations of the client: those not claimed yet, and the
+// claims made with the allocations to the providers known to the manager.
+func (m *Manager) List(ctx context.Context, client address.Address) ([]api.AllocationInfo, error) {
+	head, err := m.api.ChainHead(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("getting chain head: %w", err)
+	}
+
+	clientIDAddr, err := m.api.StateLookupID(ctx, client, head.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("looking up client id: %w", err)
+	}
+	clientID, err := address.IDFromAddress(clientIDAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	allocs, err := m.api.StateGetAllocations(ctx, clientIDAddr, head.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting allocations: %w", err)
+	}
+
+	out := make([]api.AllocationInfo, 0, len(allocs))
+	for id, a := range allocs {
+		out = append(out, allocationInfo(id, a, head.Height(), m.cfg.ExpiryWarning))
+		// also find the claims of allocations created elsewhere
+		if err := m.recordProvider(ctx, clientIDAddr, a.Provider); err != nil {
+			return nil, err
+		}
+	}
+
+	providers, err := m.providers(ctx, clientIDAddr)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range providers {
+		paddr, err := address.NewIDAddress(uint64(p))
+		if err != nil {
+			return nil, err
+		}
+		claims, err := m.api.StateGetClaims(ctx, paddr, head.Key())
+		if err != nil {
+			return nil, xerrors.Errorf("getting claims of %s: %w", paddr, err)
+		}
+		for id, c := range claims {
+			if c.Client != abi.ActorID(clientID) {
+				continue
+			}
+			out = append(out, claimInfo(id, c, head.Height()))
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
+
+func allocationInfo(id verifregtypes.AllocationId, a verifregtypes.Allocation, head, warning abi.ChainEpoch) api.AllocationInfo {
+	state := api.AllocationPending
+	switch {
+	case a.Expiration < head:
+		state = api.AllocationExpired
+	case a.Expiration-head <= warning:
+		state = api.AllocationExpiring
+	}
+
+	return api.AllocationInfo{
+		ID:         id,
+		State:      state,
+		Client:     a.Client,
+		Provider:   a.Provider,
+		Data:       a.Data,
+		Size:       a.Size,
+		TermMin:    a.TermMin,
+		TermMax:    a.TermMax,
+		Expiration: a.Expiration,
+	}
+}
+
+func claimInfo(id verifregtypes.ClaimId, c verifregtypes.Claim, head abi.ChainEpoch) api.AllocationInfo {
+	state := api.AllocationClaimed
+	if c.TermStart+c.TermMax < head {
+		state = api.AllocationClaimExpired
+	}
+
+	return api.AllocationInfo{
+		// claims keep the id of the allocation they were made with
+		ID:        verifregtypes.AllocationId(id),
+		State:     state,
+		Client:    c.Client,
+		Provider:  c.Provider,
+		Data:      c.Data,
+		Size:      c.Size,
+		TermMin:   c.TermMin,
+		TermMax:   c.TermMax,
+		TermStart: c.TermStart,
+		Sector:    c.Sector,
+	}
+}
+
+// toExtend returns the claims whose term ends within the extendBefore epochs
+// and can be extended to termMax.
+func toExtend(infos []api.AllocationInfo, head, extendBefore, termMax abi.ChainEpoch) []verifregtypes.ClaimTerm {
+	var out []verifregtypes.ClaimTerm
+	for _, info := range infos {
+		if info.State != api.AllocationClaimed || info.TermMax >= termMax {
+			continue
+		}
+		if info.TermStart+info.TermMax-head > extendBefore {
+			continue
+		}
+		out = append(out, verifregtypes.ClaimTerm{
+			Provider: info.Provider,
+			ClaimId:  verifregtypes.ClaimId(info.ID),
+			TermMax:  termMax,
+		})
+	}
+	return out
+}
+
+// check reports the allocations of the monitored clients about to expire
+// unclaimed, and extends their claims about to reach the end of their term.
+func (m *Manager) check(ctx context.Context) error {
+	clients, err := m.clients(ctx)
+	if err != nil {
+		return err
+	}
+
+	head, err := m.api.ChainHead(ctx)
+	if err != nil {
+		return xerrors.Errorf("getting chain head: %w", err)
+	}
+
+	var expiring []string
+	for _, client := range clients {
+		infos, err := m.List(ctx, client)
+		if err != nil {
+			log.Errorw("listing allocations", "client", client, "error", err)
+			continue
+		}
+
+		for _, info := range infos {
+			if info.State == api.AllocationExpiring {
+				expiring = append(expiring, fmt.Sprintf("%s: allocation %d of %s to f0%d expires at %d", client, info.ID, info.Data, info.Provider, info.Expiration))
+			}
+		}
+
+		if m.cfg.ExtendBefore > 0 {
+			m.extend(ctx, client, toExtend(infos, head.Height(), m.cfg.ExtendBefore, m.cfg.TermMax))
+		}
+	}
+
+	if m.al == nil {
+		return nil
+	}
+	if len(expiring) > 0 {
+		m.al.Raise(m.alert, map[string]interface{}{
+			"message":     "datacap allocations expire soon without being claimed",
+			"allocations": expiring,
+		})
+	} else if m.al.IsRaised(m.alert) {
+		m.al.Resolve(m.alert, map[string]string{
+			"message": "no datacap allocation expires soon without being claimed",
+		})
+	}
+	return nil
+}
+
+func (m *Manager) extend(ctx context.Context, client address.Address, terms []verifregtypes.ClaimTerm) {
+	m.lk.Lock()
+	pending := terms[:0]
+	for _, t := range terms {
+		if m.extending[t.ClaimId] == t.TermMax {
+			continue
+		}
+		pending = append(pending, t)
+	}
+	m.lk.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+
+	c, err := m.ExtendClaims(ctx, client, pending)
+	if err != nil {
+		log.Errorw("extending claims", "client", client, "claims", len(pending), "error", err)
+		return
+	}
+	log.Infow("extending claims", "client", client, "claims", len(pending), "termMax", m.cfg.TermMax, "message", c)
+
+	m.lk.Lock()
+	for _, t := range pending {
+		m.extending[t.ClaimId] = t.TermMax
+	}
+	m.lk.Unlock()
+}
+
+// clients returns the monitored clients.
+func (m *Manager) clients(ctx context.Context) ([]address.Address, error) {
+	seen := map[address.Address]struct{}{}
+	var out []address.Address
+	add := func(a address.Address) {
+		if _, ok := seen[a]; ok {
+			return
+		}
+		seen[a] = struct{}{}
+		out = append(out, a)
+	}
+
+	for _, c := range m.cfg.Clients {
+		id, err := m.api.StateLookupID(ctx, c, types.EmptyTSK)
+		if err != nil {
+			log.Warnw("looking up client id", "client", c, "error", err)
+			continue
+		}
+		add(id)
+	}
+
+	res, err := m.ds.Query(ctx, query.Query{KeysOnly: true})
+	if err != nil {
+		return nil, xerrors.Errorf("listing clients: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, xerrors.Errorf("listing clients: %w", r.Error)
+		}
+		c, err := address.NewFromString(datastore.NewKey(r.Key).Parent().BaseNamespace())
+		if err != nil {
+			return nil, xerrors.Errorf("parsing client of %s: %w", r.Key, err)
+		}
+		add(c)
+	}
+
+	return out, nil
+}
+
+func (m *Manager) recordProvider(ctx context.Context, clientID address.Address, provider abi.ActorID) error {
+	key := datastore.NewKey(clientID.String()).ChildString(fmt.Sprint(provider))
+	if err := m.ds.Put(ctx, key, []byte{}); err != nil {
+		return xerrors.Errorf("recording allocation provider: %w", err)
+	}
+	return nil
+}
+
+// providers returns the providers known to have allocations of the client.
+func (m *Manager) providers(ctx context.Context, clientID address.Address) ([]abi.ActorID, error) {
+	res, err := m.ds.Query(ctx, query.Query{Prefix: datastore.NewKey(clientID.String()).String(), KeysOnly: true})
+	if err != nil {
+		return nil, xerrors.Errorf("listing allocation providers: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	var out []abi.ActorID
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, xerrors.Errorf("listing allocation providers: %w", r.Error)
+		}
+		var p abi.ActorID
+		if _, err := fmt.Sscan(datastore.NewKey(r.Key).BaseNamespace(), &p); err != nil {
+			return nil, xerrors.Errorf("parsing provider of %s: %w", r.Key, err)
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
@@ -0,0 +1,154 @@
+// stm: #unit
+package allocations
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	datacaptypes "github.com/filecoin-project/go-state-types/builtin/v9/datacap"
+	verifregtypes "github.com/filecoin-project/go-state-types/builtin/v9/verifreg"
+	"github.com/filecoin-project/go-state-types/crypto"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/datacap"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/verifreg"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+type fakeNode struct {
+	allocs map[verifregtypes.AllocationId]verifregtypes.Allocation
+	claims map[abi.ActorID]map[verifregtypes.ClaimId]verifregtypes.Claim
+	pushed []*types.Message
+}
+
+func (f *fakeNode) ChainHead(context.Context) (*types.TipSet, error) {
+	return mock.TipSet(mock.MkBlock(nil, 0, 0)), nil
+}
+
+func (f *fakeNode) StateLookupID(_ context.Context, a address.Address, _ types.TipSetKey) (address.Address, error) {
+	return a, nil
+}
+
+func (f *fakeNode) StateGetAllocations(context.Context, address.Address, types.TipSetKey) (map[verifregtypes.AllocationId]verifregtypes.Allocation, error) {
+	return f.allocs, nil
+}
+
+func (f *fakeNode) StateGetClaims(_ context.Context, p address.Address, _ types.TipSetKey) (map[verifregtypes.ClaimId]verifregtypes.Claim, error) {
+	id, err := address.IDFromAddress(p)
+	if err != nil {
+		return nil, err
+	}
+	return f.claims[abi.ActorID(id)], nil
+}
+
+func (f *fakeNode) MpoolPushMessage(_ context.Context, msg *types.Message, _ *api.MessageSendSpec) (*types.SignedMessage, error) {
+	f.pushed = append(f.pushed, msg)
+	return &types.SignedMessage{Message: *msg, Signature: crypto.Signature{Type: crypto.SigTypeBLS}}, nil
+}
+
+func TestAllocationState(t *testing.T) {
+	alloc := verifregtypes.Allocation{Expiration: 1000}
+
+	require.Equal(t, api.AllocationPending, allocationInfo(1, alloc, 100, 500).State)
+	require.Equal(t, api.AllocationExpiring, allocationInfo(1, alloc, 600, 500).State)
+	require.Equal(t, api.AllocationExpiring, allocationInfo(1, alloc, 1000, 500).State)
+	require.Equal(t, api.AllocationExpired, allocationInfo(1, alloc, 1001, 500).State)
+
+	claim := verifregtypes.Claim{TermStart: 100, TermMax: 1000}
+	require.Equal(t, api.AllocationClaimed, claimInfo(1, claim, 1100).State)
+	require.Equal(t, api.AllocationClaimExpired, claimInfo(1, claim, 1101).State)
+}
+
+func TestToExtend(t *testing.T) {
+	infos := []api.AllocationInfo{
+		// pending allocations can't be extended
+		{ID: 1, State: api.AllocationPending, Provider: 1000, Expiration: 1100},
+		// ends at 1100, within the extension window
+		{ID: 2, State: api.AllocationClaimed, Provider: 1000, TermStart: 100, TermMax: 1000},
+		// ends at 2100, outside of the window
+		{ID: 3, State: api.AllocationClaimed, Provider: 1001, TermStart: 100, TermMax: 2000},
+		// already extended to the maximum
+		{ID: 4, State: api.AllocationClaimed, Provider: 1001, TermStart: -3900, TermMax: 5000},
+		// expired claims can't be extended
+		{ID: 5, State: api.AllocationClaimExpired, Provider: 1001, TermStart: 0, TermMax: 900},
+	}
+
+	terms := toExtend(infos, 1000, 500, 5000)
+	require.Equal(t, []verifregtypes.ClaimTerm{{Provider: 1000, ClaimId: 2, TermMax: 5000}}, terms)
+}
+
+func TestCreateAndList(t *testing.T) {
+	ctx := context.Background()
+
+	client, err := address.NewIDAddress(100)
+	require.NoError(t, err)
+	data, err := cid.Parse("baga6ea4seaqao7s73y24kcutaosvacpdjgfe5pw76ooefnyqw4ynr3d2y6x2mpq")
+	require.NoError(t, err)
+
+	node := &fakeNode{
+		claims: map[abi.ActorID]map[verifregtypes.ClaimId]verifregtypes.Claim{
+			1000: {
+				// made with an allocation of the client
+				7: {Provider: 1000, Client: 100, Data: data, Size: 2048, TermMax: 100000, TermStart: 10},
+				// of another client
+				8: {Provider: 1000, Client: 101, Data: data, Size: 2048, TermMax: 100000, TermStart: 10},
+			},
+		},
+	}
+	m := NewManager(node, dssync.MutexWrap(datastore.NewMapDatastore()), nil, Config{ExpiryWarning: 100})
+
+	reqs := []verifregtypes.AllocationRequest{
+		{Provider: 1000, Data: data, Size: 2048, TermMin: 10, TermMax: 20, Expiration: 30},
+		{Provider: 1001, Data: data, Size: 4096, TermMin: 10, TermMax: 20, Expiration: 30},
+	}
+	_, err = m.Create(ctx, client, reqs)
+	require.NoError(t, err)
+
+	require.Len(t, node.pushed, 1)
+	msg := node.pushed[0]
+	require.Equal(t, datacap.Address, msg.To)
+	require.Equal(t, client, msg.From)
+	require.Equal(t, datacap.Methods.TransferExported, msg.Method)
+
+	var params datacaptypes.TransferParams
+	require.NoError(t, params.UnmarshalCBOR(bytes.NewReader(msg.Params)))
+	require.Equal(t, verifreg.Address, params.To)
+	require.Equal(t, big.Mul(big.NewInt(6144), verifregtypes.DataCapGranularity), params.Amount)
+
+	var buf bytes.Buffer
+	expected := AllocationRequests{Allocations: []AllocationRequest{AllocationRequest(reqs[0]), AllocationRequest(reqs[1])}}
+	require.NoError(t, expected.MarshalCBOR(&buf))
+	require.Equal(t, buf.Bytes(), params.OperatorData)
+
+	// claim 7 is found through the provider of the created allocations, and
+	// the pending allocation of a provider not seen yet is listed
+	node.allocs = map[verifregtypes.AllocationId]verifregtypes.Allocation{
+		9: {Provider: 1002, Client: 100, Data: data, Size: 2048, Expiration: 50},
+	}
+
+	infos, err := m.List(ctx, client)
+	require.NoError(t, err)
+	require.Len(t, infos, 2)
+	require.Equal(t, verifregtypes.AllocationId(7), infos[0].ID)
+	require.Equal(t, api.AllocationClaimed, infos[0].State)
+	require.Equal(t, verifregtypes.AllocationId(9), infos[1].ID)
+	require.Equal(t, api.AllocationExpiring, infos[1].State)
+
+	providers, err := m.providers(ctx, client)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []abi.ActorID{1000, 1001, 1002}, providers)
+
+	clients, err := m.clients(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []address.Address{client}, clients)
+}
@@ -0,0 +1,33 @@
+package allocations
+
+import (
+	"github.com/ipfs/go-cid"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	verifregtypes "github.com/filecoin-project/go-state-types/builtin/v9/verifreg"
+)
+
+// AllocationRequests is the operator data of datacap transfers to the
+// verified registry, which creates the requested allocations and extends the
+// requested claims. It mirrors the go-state-types type, which has no CBOR
+// encoding.
+type AllocationRequests struct {
+	Allocations []AllocationRequest
+	Extensions  []ClaimExtensionRequest
+}
+
+type AllocationRequest struct {
+	Provider   abi.ActorID
+	Data       cid.Cid
+	Size       abi.PaddedPieceSize
+	TermMin    abi.ChainEpoch
+	TermMax    abi.ChainEpoch
+	Expiration abi.ChainEpoch
+}
+
+type ClaimExtensionRequest struct {
+	Provider address.Address
+	Claim    verifregtypes.ClaimId
+	TermMax  abi.ChainEpoch
+}
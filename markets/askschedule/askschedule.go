@@ -0,0 +1,449 @@
+package askschedule
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-fil-markets/storagemarket"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var log = logging.Logger("askschedule")
+
+var (
+	currentKey    = datastore.NewKey("/current")
+	scheduledKey  = datastore.NewKey("/scheduled")
+	historyPrefix = datastore.NewKey("/history")
+)
+
+// multipliers are applied with this precision
+const multiplierScale = 1_000_000
+
+// AskStore publishes the storage ask of the provider, it is implemented by
+// storedask.StoredAsk.
+type AskStore interface {
+	SetAsk(price abi.TokenAmount, verifiedPrice abi.TokenAmount, duration abi.ChainEpoch, options ...storagemarket.StorageAskOption) error
+	GetAsk() *storagemarket.SignedStorageAsk
+}
+
+// ChainAPI is the chain API needed to follow the chain head.
+type ChainAPI interface {
+	ChainNotify(context.Context) (<-chan []*api.HeadChange, error)
+}
+
+// Manager manages the storage ask of the provider: it publishes the asks set
+// through the API, applies the scheduled asks when the chain reaches their
+// epoch, enforces the price curve of the ask in effect on incoming deals, and
+// keeps an audit log of all the changes.
+type Manager struct {
+	ds   datastore.Datastore
+	asks AskStore
+	now  func() time.Time
+
+	lk        sync.Mutex
+	epoch     abi.ChainEpoch
+	current   *api.StorageAskSpec
+	scheduled []api.ScheduledStorageAsk
+	nextSeq   uint64
+}
+
+// New creates a Manager persisting its state in ds, and publishing the asks
+// to the ask store.
+func New(ds datastore.Datastore, asks AskStore) (*Manager, error) {
+	m := &Manager{
+		ds:   ds,
+		asks: asks,
+		now:  time.Now,
+	}
+
+	ctx := context.TODO()
+
+	b, err := ds.Get(ctx, currentKey)
+	switch {
+	case err == nil:
+		var cur api.StorageAskSpec
+		if err := json.Unmarshal(b, &cur); err != nil {
+			return nil, xerrors.Errorf("decoding current ask: %w", err)
+		}
+		m.current = &cur
+	case xerrors.Is(err, datastore.ErrNotFound):
+		// the ask was never set through the manager, start from the published
+		// one
+		if sa := asks.GetAsk(); sa != nil && sa.Ask != nil {
+			m.current = &api.StorageAskSpec{
+				Price:         sa.Ask.Price,
+				VerifiedPrice: sa.Ask.VerifiedPrice,
+				Duration:      sa.Ask.Expiry - sa.Ask.Timestamp,
+				MinPieceSize:  sa.Ask.MinPieceSize,
+				MaxPieceSize:  sa.Ask.MaxPieceSize,
+			}
+		}
+	default:
+		return nil, xerrors.Errorf("getting current ask: %w", err)
+	}
+
+	b, err = ds.Get(ctx, scheduledKey)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(b, &m.scheduled); err != nil {
+			return nil, xerrors.Errorf("decoding scheduled asks: %w", err)
+		}
+	case xerrors.Is(err, datastore.ErrNotFound):
+	default:
+		return nil, xerrors.Errorf("getting scheduled asks: %w", err)
+	}
+
+	res, err := ds.Query(ctx, query.Query{Prefix: historyPrefix.String(), KeysOnly: true})
+	if err != nil {
+		return nil, xerrors.Errorf("querying ask history: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, xerrors.Errorf("querying ask history: %w", r.Error)
+		}
+		seq, err := strconv.ParseUint(datastore.NewKey(r.Key).BaseNamespace(), 10, 64)
+		if err != nil {
+			return nil, xerrors.Errorf("parsing ask history key %s: %w", r.Key, err)
+		}
+		if seq >= m.nextSeq {
+			m.nextSeq = seq + 1
+		}
+	}
+
+	return m, nil
+}
+
+// Current returns the ask in effect, nil if no ask was ever set.
+func (m *Manager) Current() *api.StorageAskSpec {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	if m.current == nil {
+		return nil
+	}
+	cur := *m.current
+	return &cur
+}
+
+// Set publishes the ask immediately.
+func (m *Manager) Set(ctx context.Context, ask api.StorageAskSpec) error {
+	if err := Validate(ask); err != nil {
+		return err
+	}
+
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	return m.apply(ctx, ask, api.AskChangeSet, 0)
+}
+
+// Schedule schedules the ask to take effect at the epoch, replacing the ask
+// already scheduled at that epoch.
+func (m *Manager) Schedule(ctx context.Context, epoch abi.ChainEpoch, ask api.StorageAskSpec) error {
+	if err := Validate(ask); err != nil {
+		return err
+	}
+
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	if epoch <= m.epoch {
+		return xerrors.Errorf("can't schedule an ask at epoch %d, the chain is already at %d", epoch, m.epoch)
+	}
+
+	scheduled := make([]api.ScheduledStorageAsk, 0, len(m.scheduled)+1)
+	for _, s := range m.scheduled {
+		if s.Epoch != epoch {
+			scheduled = append(scheduled, s)
+		}
+	}
+	scheduled = append(scheduled, api.ScheduledStorageAsk{Epoch: epoch, Ask: ask})
+	sort.Slice(scheduled, func(i, j int) bool {
+		return scheduled[i].Epoch < scheduled[j].Epoch
+	})
+
+	if err := m.setScheduled(ctx, scheduled); err != nil {
+		return err
+	}
+	return m.record(ctx, api.AskChangeScheduled, epoch, ask)
+}
+
+// Cancel cancels the ask scheduled at the epoch.
+func (m *Manager) Cancel(ctx context.Context, epoch abi.ChainEpoch) error {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	for i, s := range m.scheduled {
+		if s.Epoch != epoch {
+			continue
+		}
+
+		scheduled := append(append([]api.ScheduledStorageAsk{}, m.scheduled[:i]...), m.scheduled[i+1:]...)
+		if err := m.setScheduled(ctx, scheduled); err != nil {
+			return err
+		}
+		return m.record(ctx, api.AskChangeCancelled, epoch, s.Ask)
+	}
+
+	return xerrors.Errorf("no ask scheduled at epoch %d", epoch)
+}
+
+// Scheduled returns the scheduled asks, in the order they take effect.
+func (m *Manager) Scheduled() []api.ScheduledStorageAsk {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	return append([]api.ScheduledStorageAsk{}, m.scheduled...)
+}
+
+// History returns the audit log of the ask changes, oldest first.
+func (m *Manager) History(ctx context.Context) ([]api.StorageAskChange, error) {
+	res, err := m.ds.Query(ctx, query.Query{Prefix: historyPrefix.String(), Orders: []query.Order{query.OrderByKey{}}})
+	if err != nil {
+		return nil, xerrors.Errorf("querying ask history: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	var out []api.StorageAskChange
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, xerrors.Errorf("querying ask history: %w", r.Error)
+		}
+
+		var change api.StorageAskChange
+		if err := json.Unmarshal(r.Value, &change); err != nil {
+			return nil, xerrors.Errorf("decoding ask history entry %s: %w", r.Key, err)
+		}
+		out = append(out, change)
+	}
+	return out, nil
+}
+
+// HeadChange applies the asks scheduled up to the epoch. An ask which fails
+// to be published stays scheduled, and is retried on the next head change.
+func (m *Manager) HeadChange(ctx context.Context, epoch abi.ChainEpoch) {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	m.epoch = epoch
+
+	for len(m.scheduled) > 0 && m.scheduled[0].Epoch <= epoch {
+		next := m.scheduled[0]
+		if err := m.apply(ctx, next.Ask, api.AskChangeApplied, next.Epoch); err != nil {
+			log.Errorw("applying scheduled ask", "epoch", next.Epoch, "error", err)
+			return
+		}
+
+		if err := m.setScheduled(ctx, m.scheduled[1:]); err != nil {
+			log.Errorw("removing applied ask from the schedule", "epoch", next.Epoch, "error", err)
+			return
+		}
+		log.Infow("applied scheduled ask", "epoch", next.Epoch, "price", next.Ask.Price, "verified-price", next.Ask.VerifiedPrice)
+	}
+}
+
+// Run follows the chain head, applying the scheduled asks, until the context
+// is cancelled.
+func (m *Manager) Run(ctx context.Context, capi ChainAPI) {
+	var notifs <-chan []*api.HeadChange
+	for {
+		if notifs == nil {
+			var err error
+			notifs, err = capi.ChainNotify(ctx)
+			if err != nil {
+				log.Errorf("ChainNotify error: %+v", err)
+
+				select {
+				case <-build.Clock.After(10 * time.Second):
+				case <-ctx.Done():
+					return
+				}
+				continue
+			}
+		}
+
+		select {
+		case changes, ok := <-notifs:
+			if !ok {
+				log.Warn("ask schedule notifs channel closed")
+				notifs = nil
+				continue
+			}
+
+			var head *types.TipSet
+			for _, chg := range changes {
+				if chg.Type != store.HCRevert {
+					head = chg.Val
+				}
+			}
+			if head != nil {
+				m.HeadChange(ctx, head.Height())
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// CheckDeal checks that the deal pays the price of the ask in effect for its
+// piece size. Deals below the flat prices are rejected by the storage
+// provider itself, so this only matters for asks with a price curve.
+func (m *Manager) CheckDeal(deal storagemarket.MinerDeal) (bool, string) {
+	cur := m.Current()
+	if cur == nil || len(cur.PriceCurve) == 0 {
+		return true, ""
+	}
+
+	proposal := deal.Proposal
+	price := RequiredPrice(*cur, proposal.PieceSize, proposal.VerifiedDeal)
+	minPrice := big.Div(big.Mul(price, abi.NewTokenAmount(int64(proposal.PieceSize))), abi.NewTokenAmount(1<<30))
+	if proposal.StoragePricePerEpoch.LessThan(minPrice) {
+		return false, fmt.Sprintf("storage price per epoch less than asking price for %s pieces: %s < %s",
+			types.SizeStr(types.NewInt(uint64(proposal.PieceSize))), proposal.StoragePricePerEpoch, minPrice)
+	}
+	return true, ""
+}
+
+// apply publishes the ask and records the change, m.lk must be held.
+func (m *Manager) apply(ctx context.Context, ask api.StorageAskSpec, action api.StorageAskChangeAction, scheduledEpoch abi.ChainEpoch) error {
+	price, verifiedPrice := PublishedPrices(ask)
+	if err := m.asks.SetAsk(price, verifiedPrice, ask.Duration,
+		storagemarket.MinPieceSize(ask.MinPieceSize),
+		storagemarket.MaxPieceSize(ask.MaxPieceSize)); err != nil {
+		return xerrors.Errorf("publishing ask: %w", err)
+	}
+
+	b, err := json.Marshal(ask)
+	if err != nil {
+		return xerrors.Errorf("encoding ask: %w", err)
+	}
+	if err := m.ds.Put(ctx, currentKey, b); err != nil {
+		return xerrors.Errorf("storing current ask: %w", err)
+	}
+	m.current = &ask
+
+	return m.record(ctx, action, scheduledEpoch, ask)
+}
+
+// setScheduled persists the schedule, m.lk must be held.
+func (m *Manager) setScheduled(ctx context.Context, scheduled []api.ScheduledStorageAsk) error {
+	b, err := json.Marshal(scheduled)
+	if err != nil {
+		return xerrors.Errorf("encoding scheduled asks: %w", err)
+	}
+	if err := m.ds.Put(ctx, scheduledKey, b); err != nil {
+		return xerrors.Errorf("storing scheduled asks: %w", err)
+	}
+	m.scheduled = scheduled
+	return nil
+}
+
+// record appends a change to the audit log, m.lk must be held.
+func (m *Manager) record(ctx context.Context, action api.StorageAskChangeAction, scheduledEpoch abi.ChainEpoch, ask api.StorageAskSpec) error {
+	b, err := json.Marshal(api.StorageAskChange{
+		Time:           m.now(),
+		Action:         action,
+		Epoch:          m.epoch,
+		ScheduledEpoch: scheduledEpoch,
+		Ask:            ask,
+	})
+	if err != nil {
+		return xerrors.Errorf("encoding ask history entry: %w", err)
+	}
+
+	key := historyPrefix.ChildString(fmt.Sprintf("%020d", m.nextSeq))
+	if err := m.ds.Put(ctx, key, b); err != nil {
+		return xerrors.Errorf("storing ask history entry: %w", err)
+	}
+	m.nextSeq++
+	return nil
+}
+
+// Validate checks that the ask is well formed.
+func Validate(ask api.StorageAskSpec) error {
+	if ask.Price.Int == nil || ask.VerifiedPrice.Int == nil {
+		return xerrors.Errorf("ask prices must be set")
+	}
+	if ask.Price.Sign() < 0 || ask.VerifiedPrice.Sign() < 0 {
+		return xerrors.Errorf("ask prices can't be negative")
+	}
+	if ask.Duration <= 0 {
+		return xerrors.Errorf("ask duration must be positive")
+	}
+	if ask.MinPieceSize > ask.MaxPieceSize {
+		return xerrors.Errorf("min piece size %d is larger than max piece size %d", ask.MinPieceSize, ask.MaxPieceSize)
+	}
+
+	for i, p := range ask.PriceCurve {
+		if i > 0 && p.MinPieceSize <= ask.PriceCurve[i-1].MinPieceSize {
+			return xerrors.Errorf("price curve points must be sorted by strictly increasing piece size")
+		}
+		for _, mult := range []float64{p.Multiplier, p.VerifiedMultiplier} {
+			if mult < 0 || math.IsNaN(mult) || math.IsInf(mult, 0) {
+				return xerrors.Errorf("invalid price curve multiplier %f for %d pieces", mult, p.MinPieceSize)
+			}
+		}
+	}
+	return nil
+}
+
+// RequiredPrice returns the price per GiB per epoch the ask requires for a
+// piece of the given size.
+func RequiredPrice(ask api.StorageAskSpec, size abi.PaddedPieceSize, verified bool) abi.TokenAmount {
+	price := ask.Price
+	if verified {
+		price = ask.VerifiedPrice
+	}
+
+	for i := len(ask.PriceCurve) - 1; i >= 0; i-- {
+		p := ask.PriceCurve[i]
+		if p.MinPieceSize > size {
+			continue
+		}
+		if verified {
+			return scale(price, p.VerifiedMultiplier)
+		}
+		return scale(price, p.Multiplier)
+	}
+	return price
+}
+
+// PublishedPrices returns the prices of the signed ask, which are the lowest
+// prices of the curve within the piece size range of the ask. The storage
+// provider rejects deals below the published prices, deals between these and
+// the curve are rejected by CheckDeal.
+func PublishedPrices(ask api.StorageAskSpec) (price abi.TokenAmount, verifiedPrice abi.TokenAmount) {
+	price, verifiedPrice = RequiredPrice(ask, ask.MinPieceSize, false), RequiredPrice(ask, ask.MinPieceSize, true)
+
+	for _, p := range ask.PriceCurve {
+		if p.MinPieceSize <= ask.MinPieceSize || p.MinPieceSize > ask.MaxPieceSize {
+			continue
+		}
+		price = big.Min(price, RequiredPrice(ask, p.MinPieceSize, false))
+		verifiedPrice = big.Min(verifiedPrice, RequiredPrice(ask, p.MinPieceSize, true))
+	}
+	return price, verifiedPrice
+}
+
+func scale(price abi.TokenAmount, mult float64) abi.TokenAmount {
+	return big.Div(big.Mul(price, big.NewInt(int64(math.Round(mult*multiplierScale)))), big.NewInt(multiplierScale))
+}
@@ -0,0 +1,163 @@
+// stm: #unit
+package askschedule
+
+import (
+	"context"
+	"testing"
+
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-fil-markets/storagemarket"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin/v9/market"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+type testAskStore struct {
+	ask *storagemarket.StorageAsk
+}
+
+func (s *testAskStore) SetAsk(price abi.TokenAmount, verifiedPrice abi.TokenAmount, duration abi.ChainEpoch, options ...storagemarket.StorageAskOption) error {
+	ask := &storagemarket.StorageAsk{
+		Price:         price,
+		VerifiedPrice: verifiedPrice,
+		Expiry:        duration,
+	}
+	for _, o := range options {
+		o(ask)
+	}
+	s.ask = ask
+	return nil
+}
+
+func (s *testAskStore) GetAsk() *storagemarket.SignedStorageAsk {
+	if s.ask == nil {
+		return nil
+	}
+	return &storagemarket.SignedStorageAsk{Ask: s.ask}
+}
+
+func testAsk(price int64) api.StorageAskSpec {
+	return api.StorageAskSpec{
+		Price:         types.NewInt(uint64(price)),
+		VerifiedPrice: types.NewInt(uint64(price / 10)),
+		Duration:      1000,
+		MinPieceSize:  256,
+		MaxPieceSize:  32 << 30,
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	ctx := context.Background()
+	mds := dssync.MutexWrap(ds.NewMapDatastore())
+	store := &testAskStore{}
+
+	m, err := New(mds, store)
+	require.NoError(t, err)
+	require.Nil(t, m.Current())
+
+	require.NoError(t, m.Set(ctx, testAsk(1000)))
+	require.Equal(t, types.NewInt(1000), store.ask.Price)
+
+	m.HeadChange(ctx, 10)
+	require.Error(t, m.Schedule(ctx, 10, testAsk(2000)))
+	require.NoError(t, m.Schedule(ctx, 30, testAsk(3000)))
+	require.NoError(t, m.Schedule(ctx, 20, testAsk(2000)))
+	require.NoError(t, m.Schedule(ctx, 40, testAsk(4000)))
+	require.NoError(t, m.Cancel(ctx, 40))
+	require.Error(t, m.Cancel(ctx, 40))
+
+	scheduled := m.Scheduled()
+	require.Len(t, scheduled, 2)
+	require.Equal(t, abi.ChainEpoch(20), scheduled[0].Epoch)
+
+	// both asks are due, they're applied in order
+	m.HeadChange(ctx, 35)
+	require.Empty(t, m.Scheduled())
+	require.Equal(t, types.NewInt(3000), store.ask.Price)
+	require.Equal(t, types.NewInt(3000), m.Current().Price)
+
+	history, err := m.History(ctx)
+	require.NoError(t, err)
+	var actions []api.StorageAskChangeAction
+	for _, c := range history {
+		actions = append(actions, c.Action)
+	}
+	require.Equal(t, []api.StorageAskChangeAction{
+		api.AskChangeSet,
+		api.AskChangeScheduled, api.AskChangeScheduled, api.AskChangeScheduled,
+		api.AskChangeCancelled,
+		api.AskChangeApplied, api.AskChangeApplied,
+	}, actions)
+	require.Equal(t, abi.ChainEpoch(20), history[5].ScheduledEpoch)
+	require.Equal(t, abi.ChainEpoch(35), history[5].Epoch)
+
+	// the state survives restarts
+	require.NoError(t, m.Schedule(ctx, 50, testAsk(5000)))
+	m, err = New(mds, store)
+	require.NoError(t, err)
+	require.Equal(t, types.NewInt(3000), m.Current().Price)
+	require.Len(t, m.Scheduled(), 1)
+
+	require.NoError(t, m.Set(ctx, testAsk(6000)))
+	history, err = m.History(ctx)
+	require.NoError(t, err)
+	require.Len(t, history, 9)
+	require.Equal(t, types.NewInt(6000), history[8].Ask.Price)
+}
+
+func TestPriceCurve(t *testing.T) {
+	ctx := context.Background()
+	store := &testAskStore{}
+
+	m, err := New(dssync.MutexWrap(ds.NewMapDatastore()), store)
+	require.NoError(t, err)
+
+	ask := testAsk(1000)
+	ask.PriceCurve = []api.AskPricePoint{
+		{MinPieceSize: 1 << 30, Multiplier: 0.5, VerifiedMultiplier: 0},
+		{MinPieceSize: 16 << 30, Multiplier: 2, VerifiedMultiplier: 1},
+	}
+	require.NoError(t, m.Set(ctx, ask))
+
+	// the published ask carries the lowest prices of the curve
+	require.Equal(t, types.NewInt(500), store.ask.Price)
+	require.Equal(t, types.NewInt(0), store.ask.VerifiedPrice)
+
+	deal := func(size abi.PaddedPieceSize, pricePerGiB int64, verified bool) storagemarket.MinerDeal {
+		var d storagemarket.MinerDeal
+		d.ClientDealProposal = market.ClientDealProposal{Proposal: market.DealProposal{
+			PieceSize:            size,
+			VerifiedDeal:         verified,
+			StoragePricePerEpoch: abi.NewTokenAmount(pricePerGiB * int64(size) / (1 << 30)),
+		}}
+		return d
+	}
+
+	for _, tc := range []struct {
+		size     abi.PaddedPieceSize
+		price    int64
+		verified bool
+		ok       bool
+	}{
+		{512 << 20, 1000, false, true},
+		{512 << 20, 999, false, false},
+		{512 << 20, 100, true, true},
+		{512 << 20, 99, true, false},
+		{1 << 30, 500, false, true},
+		{1 << 30, 0, true, true},
+		{32 << 30, 1999, false, false},
+		{32 << 30, 2000, false, true},
+		{32 << 30, 99, true, false},
+	} {
+		ok, reason := m.CheckDeal(deal(tc.size, tc.price, tc.verified))
+		require.Equal(t, tc.ok, ok, "size %d price %d verified %t: %s", tc.size, tc.price, tc.verified, reason)
+	}
+
+	ask.PriceCurve = append(ask.PriceCurve, api.AskPricePoint{MinPieceSize: 2 << 30, Multiplier: -1})
+	require.Error(t, m.Set(ctx, ask))
+}
@@ -0,0 +1,138 @@
+// stm: #unit
+package bitswapserver
+
+import (
+	"context"
+	"testing"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	ipld "github.com/ipfs/go-ipld-format"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	tut "github.com/filecoin-project/go-fil-markets/shared_testutil"
+	"github.com/filecoin-project/go-fil-markets/storagemarket"
+	"github.com/filecoin-project/go-fil-markets/stores"
+	"github.com/filecoin-project/go-state-types/builtin/v9/market"
+)
+
+type closableBlockstore struct {
+	bstore.Blockstore
+}
+
+func (closableBlockstore) Close() error { return nil }
+
+type mockDAGStore struct {
+	stores.DAGStoreWrapper
+
+	blockPieces map[cid.Cid][]cid.Cid
+	shards      map[cid.Cid]bstore.Blockstore
+}
+
+func (m *mockDAGStore) GetPiecesContainingBlock(c cid.Cid) ([]cid.Cid, error) {
+	pieces, ok := m.blockPieces[c]
+	if !ok {
+		return nil, xerrors.Errorf("looking up block: %w", ds.ErrNotFound)
+	}
+	return pieces, nil
+}
+
+func (m *mockDAGStore) LoadShard(_ context.Context, piece cid.Cid) (stores.ClosableBlockstore, error) {
+	return closableBlockstore{m.shards[piece]}, nil
+}
+
+func dealWithState(piece cid.Cid, state storagemarket.StorageDealStatus) storagemarket.MinerDeal {
+	return storagemarket.MinerDeal{
+		ClientDealProposal: market.ClientDealProposal{
+			Proposal: market.DealProposal{PieceCID: piece},
+		},
+		State: state,
+	}
+}
+
+func TestPolicyRateLimit(t *testing.T) {
+	p := NewPolicy(1, 2)
+	c := tut.GenerateCids(1)[0]
+
+	require.True(t, p.Filter(peer.ID("a"), c))
+	require.True(t, p.Filter(peer.ID("a"), c))
+	require.False(t, p.Filter(peer.ID("a"), c))
+
+	// limits are tracked per peer
+	require.True(t, p.Filter(peer.ID("b"), c))
+
+	unlimited := NewPolicy(0, 0)
+	for i := 0; i < 100; i++ {
+		require.True(t, unlimited.Filter(peer.ID("a"), c))
+	}
+}
+
+func TestBlockstoreServesActiveDeals(t *testing.T) {
+	ctx := context.Background()
+
+	pieces := tut.GenerateCids(2)
+	activePiece, inactivePiece := pieces[0], pieces[1]
+
+	activeBs := bstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+	inactiveBs := bstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+
+	shared := blocks.NewBlock([]byte("shared"))
+	onlyInactive := blocks.NewBlock([]byte("only-inactive"))
+	require.NoError(t, activeBs.Put(ctx, shared))
+	require.NoError(t, inactiveBs.Put(ctx, shared))
+	require.NoError(t, inactiveBs.Put(ctx, onlyInactive))
+
+	dagst := &mockDAGStore{
+		blockPieces: map[cid.Cid][]cid.Cid{
+			shared.Cid():       {inactivePiece, activePiece},
+			onlyInactive.Cid(): {inactivePiece},
+		},
+		shards: map[cid.Cid]bstore.Blockstore{
+			activePiece:   activeBs,
+			inactivePiece: inactiveBs,
+		},
+	}
+
+	p := NewPolicy(0, 0)
+	p.SetDeals([]storagemarket.MinerDeal{
+		dealWithState(activePiece, storagemarket.StorageDealActive),
+		dealWithState(inactivePiece, storagemarket.StorageDealAwaitingPreCommit),
+	})
+	bs := NewBlockstore(dagst, p)
+
+	has, err := bs.Has(ctx, shared.Cid())
+	require.NoError(t, err)
+	require.True(t, has)
+
+	blk, err := bs.Get(ctx, shared.Cid())
+	require.NoError(t, err)
+	require.Equal(t, shared.RawData(), blk.RawData())
+
+	size, err := bs.GetSize(ctx, shared.Cid())
+	require.NoError(t, err)
+	require.Equal(t, len(shared.RawData()), size)
+
+	// blocks only present in pieces without an active deal are not served
+	has, err = bs.Has(ctx, onlyInactive.Cid())
+	require.NoError(t, err)
+	require.False(t, has)
+
+	_, err = bs.Get(ctx, onlyInactive.Cid())
+	require.True(t, ipld.IsNotFound(err))
+
+	// unknown blocks are reported as not found
+	_, err = bs.Get(ctx, blocks.NewBlock([]byte("unknown")).Cid())
+	require.True(t, ipld.IsNotFound(err))
+
+	// once the deal is no longer active the block isn't served anymore
+	p.SetDeals([]storagemarket.MinerDeal{
+		dealWithState(activePiece, storagemarket.StorageDealExpired),
+	})
+	_, err = bs.Get(ctx, shared.Cid())
+	require.True(t, ipld.IsNotFound(err))
+}
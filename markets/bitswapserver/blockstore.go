@@ -0,0 +1,147 @@
+package bitswapserver
+
+import (
+	"context"
+	"errors"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	ipld "github.com/ipfs/go-ipld-format"
+	"go.opencensus.io/stats"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-fil-markets/stores"
+
+	"github.com/filecoin-project/lotus/metrics"
+)
+
+// Blockstore is a read-only blockstore that serves blocks out of the shards
+// registered with the DAG store. Only shards for pieces accepted by the
+// supplied policy are read.
+type Blockstore struct {
+	dagst  stores.DAGStoreWrapper
+	policy *Policy
+}
+
+var _ bstore.Blockstore = (*Blockstore)(nil)
+
+func NewBlockstore(dagst stores.DAGStoreWrapper, policy *Policy) *Blockstore {
+	return &Blockstore{
+		dagst:  dagst,
+		policy: policy,
+	}
+}
+
+// allowedPieces returns the pieces that contain the given block and are
+// allowed to be served by the policy.
+func (b *Blockstore) allowedPieces(c cid.Cid) ([]cid.Cid, error) {
+	pieces, err := b.dagst.GetPiecesContainingBlock(c)
+	if err != nil {
+		if errors.Is(err, ds.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	out := make([]cid.Cid, 0, len(pieces))
+	for _, piece := range pieces {
+		if b.policy.PieceAllowed(piece) {
+			out = append(out, piece)
+		}
+	}
+	if len(pieces) > 0 && len(out) == 0 {
+		recordOutcome(OutcomeDenied)
+	}
+	return out, nil
+}
+
+// withBlock calls cb with the block from the first allowed piece that
+// can be loaded.
+func (b *Blockstore) withBlock(ctx context.Context, c cid.Cid, cb func(bs stores.ClosableBlockstore) error) error {
+	pieces, err := b.allowedPieces(c)
+	if err != nil {
+		return xerrors.Errorf("getting pieces containing block %s: %w", c, err)
+	}
+
+	var lastErr error
+	for _, piece := range pieces {
+		bs, err := b.dagst.LoadShard(ctx, piece)
+		if err != nil {
+			log.Warnw("failed to load shard for bitswap request", "piece", piece, "block", c, "error", err)
+			lastErr = err
+			continue
+		}
+
+		err = cb(bs)
+		if cerr := bs.Close(); cerr != nil {
+			log.Warnw("failed to close shard blockstore", "piece", piece, "error", cerr)
+		}
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+	}
+
+	if lastErr != nil && !ipld.IsNotFound(lastErr) {
+		return xerrors.Errorf("reading block %s from dagstore: %w", c, lastErr)
+	}
+	return ipld.ErrNotFound{Cid: c}
+}
+
+func (b *Blockstore) Has(ctx context.Context, c cid.Cid) (bool, error) {
+	pieces, err := b.allowedPieces(c)
+	if err != nil {
+		return false, xerrors.Errorf("getting pieces containing block %s: %w", c, err)
+	}
+	return len(pieces) > 0, nil
+}
+
+func (b *Blockstore) Get(ctx context.Context, c cid.Cid) (blocks.Block, error) {
+	var blk blocks.Block
+	err := b.withBlock(ctx, c, func(bs stores.ClosableBlockstore) error {
+		var err error
+		blk, err = bs.Get(ctx, c)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stats.Record(ctx, metrics.BitswapBytesServed.M(int64(len(blk.RawData()))))
+	return blk, nil
+}
+
+func (b *Blockstore) GetSize(ctx context.Context, c cid.Cid) (int, error) {
+	var size int
+	err := b.withBlock(ctx, c, func(bs stores.ClosableBlockstore) error {
+		var err error
+		size, err = bs.GetSize(ctx, c)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+func (b *Blockstore) AllKeysChan(context.Context) (<-chan cid.Cid, error) {
+	return nil, xerrors.Errorf("AllKeysChan called but not implemented")
+}
+
+func (b *Blockstore) HashOnRead(bool) {
+	log.Warn("HashOnRead called but not implemented")
+}
+
+func (b *Blockstore) DeleteBlock(context.Context, cid.Cid) error {
+	return xerrors.Errorf("DeleteBlock called but not implemented")
+}
+
+func (b *Blockstore) Put(context.Context, blocks.Block) error {
+	return xerrors.Errorf("Put called but not implemented")
+}
+
+func (b *Blockstore) PutMany(context.Context, []blocks.Block) error {
+	return xerrors.Errorf("PutMany called but not implemented")
+}
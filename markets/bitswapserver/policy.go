@@ -0,0 +1,124 @@
+package bitswapserver
+
+import (
+	"context"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"golang.org/x/time/rate"
+
+	"github.com/filecoin-project/go-fil-markets/storagemarket"
+
+	"github.com/filecoin-project/lotus/metrics"
+)
+
+var log = logging.Logger("bitswapserver")
+
+// maxTrackedPeers bounds the number of per-peer rate limiters kept in memory.
+const maxTrackedPeers = 4096
+
+// Request outcomes, recorded with the metrics.BitswapOutcome tag.
+const (
+	OutcomeAccepted    = "accepted"
+	OutcomeRateLimited = "rate_limited"
+	OutcomeDenied      = "denied"
+)
+
+// Policy decides which block requests the bitswap server answers. Peers are
+// rate limited individually, and only blocks from pieces with at least one
+// active storage deal are served.
+type Policy struct {
+	limit rate.Limit
+	burst int
+
+	limitersLk sync.Mutex
+	limiters   *lru.Cache[peer.ID, *rate.Limiter]
+
+	piecesLk sync.RWMutex
+	pieces   map[cid.Cid]struct{}
+}
+
+// NewPolicy creates a policy allowing each peer requestsPerSecond block
+// requests per second, with bursts of up to burst requests. A
+// requestsPerSecond of 0 disables rate limiting.
+func NewPolicy(requestsPerSecond, burst int) *Policy {
+	limiters, _ := lru.New[peer.ID, *rate.Limiter](maxTrackedPeers)
+
+	limit := rate.Inf
+	if requestsPerSecond > 0 {
+		limit = rate.Limit(requestsPerSecond)
+	}
+	if burst < requestsPerSecond {
+		burst = requestsPerSecond
+	}
+
+	return &Policy{
+		limit:    limit,
+		burst:    burst,
+		limiters: limiters,
+		pieces:   map[cid.Cid]struct{}{},
+	}
+}
+
+// Filter is a bitswap PeerBlockRequestFilter. It returns false for requests
+// from peers that exceeded their rate limit.
+func (p *Policy) Filter(pid peer.ID, c cid.Cid) bool {
+	if !p.allowRequest(pid) {
+		recordOutcome(OutcomeRateLimited)
+		return false
+	}
+
+	recordOutcome(OutcomeAccepted)
+	return true
+}
+
+func (p *Policy) allowRequest(pid peer.ID) bool {
+	if p.limit == rate.Inf {
+		return true
+	}
+
+	p.limitersLk.Lock()
+	l, ok := p.limiters.Get(pid)
+	if !ok {
+		l = rate.NewLimiter(p.limit, p.burst)
+		p.limiters.Add(pid, l)
+	}
+	p.limitersLk.Unlock()
+
+	return l.Allow()
+}
+
+// PieceAllowed returns whether blocks from the given piece may be served.
+func (p *Policy) PieceAllowed(piece cid.Cid) bool {
+	p.piecesLk.RLock()
+	defer p.piecesLk.RUnlock()
+
+	_, ok := p.pieces[piece]
+	return ok
+}
+
+// SetDeals rebuilds the set of servable pieces from the given storage deals.
+func (p *Policy) SetDeals(deals []storagemarket.MinerDeal) {
+	pieces := make(map[cid.Cid]struct{}, len(deals))
+	for _, deal := range deals {
+		if deal.State == storagemarket.StorageDealActive {
+			pieces[deal.Proposal.PieceCID] = struct{}{}
+		}
+	}
+
+	p.piecesLk.Lock()
+	p.pieces = pieces
+	p.piecesLk.Unlock()
+
+	log.Debugw("updated bitswap piece allow-list", "pieces", len(pieces))
+}
+
+func recordOutcome(outcome string) {
+	ctx, _ := tag.New(context.Background(), tag.Upsert(metrics.BitswapOutcome, outcome))
+	stats.Record(ctx, metrics.BitswapBlockRequests.M(1))
+}
@@ -0,0 +1,353 @@
+// Package commpdiag records diagnostics of storage deals whose data doesn't
+// match the piece CID of the proposal, to tell whether the padding of the
+// piece, the CAR version or the data itself caused the mismatch.
+package commpdiag
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+	carv2 "github.com/ipld/go-car/v2"
+	"github.com/multiformats/go-varint"
+	"golang.org/x/xerrors"
+
+	commcid "github.com/filecoin-project/go-fil-commcid"
+	commp "github.com/filecoin-project/go-fil-commp-hashhash"
+	"github.com/filecoin-project/go-fil-markets/storagemarket"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+var log = logging.Logger("commpdiag")
+
+// maxRecords is the number of diagnostics kept, the oldest ones are removed
+const maxRecords = 256
+
+// linkSuffix is appended to the path of the inbound CAR of a deal to name the
+// link keeping the data around until it is verified
+const linkSuffix = ".commp-diag"
+
+// mismatchMessage is part of the message of the deals failing because their
+// data doesn't match the piece CID of the proposal
+const mismatchMessage = "CommP doesn't match"
+
+// Recorder captures diagnostics of the deals failing piece CID verification.
+//
+// The provider removes the data of a deal as soon as the verification fails,
+// so the recorder links the inbound CAR of each deal when its transfer
+// completes, and removes the link once the data is verified or analysed.
+type Recorder struct {
+	ds datastore.Batching
+}
+
+func New(ds datastore.Batching) *Recorder {
+	return &Recorder{ds: ds}
+}
+
+// OnDealEvent is a storage provider subscriber capturing the diagnostics of
+// the deals failing verification.
+func (r *Recorder) OnDealEvent(event storagemarket.ProviderEvent, deal storagemarket.MinerDeal) {
+	if deal.InboundCAR == "" {
+		return
+	}
+	link := deal.InboundCAR + linkSuffix
+
+	switch event {
+	case storagemarket.ProviderEventDataTransferCompleted:
+		_ = os.Remove(link)
+		if err := os.Link(deal.InboundCAR, link); err != nil {
+			log.Warnw("keeping deal data for commp diagnostics", "proposal", deal.ProposalCid, "error", err)
+		}
+
+	case storagemarket.ProviderEventDataVerificationFailed:
+		if !strings.Contains(deal.Message, mismatchMessage) {
+			_ = os.Remove(link)
+			return
+		}
+
+		go func() {
+			defer os.Remove(link) //nolint:errcheck
+
+			d := Analyze(link, false, deal.Proposal.PieceCID, deal.Proposal.PieceSize, deal.Ref.Root)
+			d.ProposalCid = deal.ProposalCid
+			if err := r.Put(context.Background(), d); err != nil {
+				log.Errorw("saving commp diagnostics", "proposal", deal.ProposalCid, "error", err)
+				return
+			}
+			log.Warnw("deal data doesn't match the piece cid of the proposal", "proposal", deal.ProposalCid, "findings", d.Findings)
+		}()
+
+	case storagemarket.ProviderEventVerifiedData, storagemarket.ProviderEventFailed:
+		_ = os.Remove(link)
+	}
+}
+
+// Put saves diagnostics, replacing those of the same deal.
+func (r *Recorder) Put(ctx context.Context, d api.CommPDiagnostics) error {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return xerrors.Errorf("marshaling commp diagnostics: %w", err)
+	}
+	if err := r.ds.Put(ctx, datastore.NewKey(d.ProposalCid.String()), b); err != nil {
+		return xerrors.Errorf("saving commp diagnostics: %w", err)
+	}
+
+	all, err := r.List(ctx)
+	if err != nil {
+		return err
+	}
+	for len(all) > maxRecords {
+		if err := r.ds.Delete(ctx, datastore.NewKey(all[0].ProposalCid.String())); err != nil {
+			return xerrors.Errorf("removing old commp diagnostics: %w", err)
+		}
+		all = all[1:]
+	}
+	return nil
+}
+
+// Get returns the diagnostics of a deal.
+func (r *Recorder) Get(ctx context.Context, propCid cid.Cid) (*api.CommPDiagnostics, error) {
+	b, err := r.ds.Get(ctx, datastore.NewKey(propCid.String()))
+	if err == datastore.ErrNotFound {
+		return nil, xerrors.Errorf("no commp diagnostics for deal %s", propCid)
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("getting commp diagnostics: %w", err)
+	}
+
+	var d api.CommPDiagnostics
+	if err := json.Unmarshal(b, &d); err != nil {
+		return nil, xerrors.Errorf("unmarshaling commp diagnostics: %w", err)
+	}
+	return &d, nil
+}
+
+// List returns the diagnostics of all deals, oldest first.
+func (r *Recorder) List(ctx context.Context) ([]api.CommPDiagnostics, error) {
+	res, err := r.ds.Query(ctx, query.Query{})
+	if err != nil {
+		return nil, xerrors.Errorf("querying commp diagnostics: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	out := []api.CommPDiagnostics{}
+	for e := range res.Next() {
+		if e.Error != nil {
+			return nil, xerrors.Errorf("reading commp diagnostics: %w", e.Error)
+		}
+		var d api.CommPDiagnostics
+		if err := json.Unmarshal(e.Value, &d); err != nil {
+			return nil, xerrors.Errorf("unmarshaling commp diagnostics %s: %w", e.Key, err)
+		}
+		out = append(out, d)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Time.Before(out[j].Time)
+	})
+	return out, nil
+}
+
+// IsMismatch returns whether the error of a data import is a mismatch of the
+// piece CID of the data and of the proposal.
+func IsMismatch(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "does not match expected commP")
+}
+
+// Analyze inspects the data of a deal failing piece CID verification. The
+// piece of online deals is the CARv1 payload of the inbound CARv2 file, the
+// piece of offline deals is the whole imported file.
+func Analyze(path string, offline bool, pieceCid cid.Cid, pieceSize abi.PaddedPieceSize, root cid.Cid) api.CommPDiagnostics {
+	d := api.CommPDiagnostics{
+		Time:              time.Now(),
+		Offline:           offline,
+		ProposalPieceCid:  pieceCid,
+		ProposalPieceSize: pieceSize,
+		PayloadCid:        root,
+	}
+	if err := analyze(&d, path); err != nil {
+		d.Error = err.Error()
+	}
+	return d
+}
+
+func analyze(d *api.CommPDiagnostics, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return xerrors.Errorf("opening deal data: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	st, err := f.Stat()
+	if err != nil {
+		return xerrors.Errorf("getting deal data size: %w", err)
+	}
+
+	version, err := carv2.ReadVersion(io.NewSectionReader(f, 0, st.Size()))
+	if err != nil {
+		d.Findings = append(d.Findings, "the data isn't a CAR file: "+err.Error())
+	}
+	d.CarVersion = version
+
+	// the range of the piece data in the file, and of its CARv1 payload
+	pieceOff, pieceLen := int64(0), st.Size()
+	payloadOff, payloadLen := int64(0), st.Size()
+	if version == 2 {
+		rd, err := carv2.NewReader(f)
+		if err != nil {
+			return xerrors.Errorf("reading CARv2 header: %w", err)
+		}
+		payloadOff, payloadLen = int64(rd.Header.DataOffset), int64(rd.Header.DataSize)
+		if !d.Offline {
+			pieceOff, pieceLen = payloadOff, payloadLen
+		}
+	}
+	d.DataSize = uint64(pieceLen)
+
+	natural, naturalSize, err := pieceCommitment(io.NewSectionReader(f, pieceOff, pieceLen))
+	if err != nil {
+		return xerrors.Errorf("computing piece cid: %w", err)
+	}
+	d.NaturalPieceCid, d.NaturalPieceSize = natural, naturalSize
+	d.CalculatedPieceCid = natural
+	if naturalSize < d.ProposalPieceSize {
+		d.CalculatedPieceCid, err = padCommitment(natural, naturalSize, d.ProposalPieceSize)
+		if err != nil {
+			return xerrors.Errorf("padding piece cid: %w", err)
+		}
+	}
+
+	if version == 2 && d.Offline {
+		c, size, err := pieceCommitment(io.NewSectionReader(f, payloadOff, payloadLen))
+		if err == nil && size < d.ProposalPieceSize {
+			c, err = padCommitment(c, size, d.ProposalPieceSize)
+		}
+		if err == nil {
+			d.PayloadPieceCid = &c
+		}
+	}
+
+	if version != 0 {
+		inspectBlocks(d, io.NewSectionReader(f, payloadOff, payloadLen), uint64(payloadLen))
+	}
+
+	d.Findings = append(d.Findings, findings(d)...)
+	return nil
+}
+
+func inspectBlocks(d *api.CommPDiagnostics, r io.Reader, size uint64) {
+	// zeroes after the last block are reported as trailing bytes
+	br, err := carv2.NewBlockReader(r, carv2.ZeroLengthSectionAsEOF(true))
+	if err != nil {
+		d.Findings = append(d.Findings, "the CAR header can't be read: "+err.Error())
+		return
+	}
+	d.CarRoots = br.Roots
+
+	var end uint64
+	for {
+		b, err := br.SkipNext()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			d.Findings = append(d.Findings, fmt.Sprintf("the CAR data is truncated or corrupted after offset %d: %s", end, err))
+			return
+		}
+
+		blk := &api.CommPBlock{Cid: b.Cid, Offset: b.Offset, Size: b.Size}
+		if d.FirstBlock == nil {
+			d.FirstBlock = blk
+		}
+		d.LastBlock = blk
+		d.Blocks++
+		sectionLen := uint64(b.Cid.ByteLen()) + b.Size
+		end = b.Offset + uint64(varint.UvarintSize(sectionLen)) + sectionLen
+	}
+
+	if end < size && d.FirstBlock != nil {
+		d.TrailingBytes = size - end
+	}
+}
+
+func findings(d *api.CommPDiagnostics) []string {
+	var out []string
+
+	if d.CalculatedPieceCid == d.ProposalPieceCid {
+		out = append(out, "the piece cid of the data matches the proposal, the mismatch didn't happen again")
+		return out
+	}
+
+	if d.NaturalPieceSize > d.ProposalPieceSize {
+		out = append(out, fmt.Sprintf("the data padded to a piece is %d bytes, larger than the piece size of the proposal of %d bytes", d.NaturalPieceSize, d.ProposalPieceSize))
+	}
+	if d.NaturalPieceCid == d.ProposalPieceCid && d.NaturalPieceSize != d.ProposalPieceSize {
+		out = append(out, fmt.Sprintf("the piece cid of the proposal is the piece cid of the data at its natural piece size of %d bytes: the proposal has the wrong piece size", d.NaturalPieceSize))
+	}
+	if d.PayloadPieceCid != nil && *d.PayloadPieceCid == d.ProposalPieceCid {
+		out = append(out, "the piece cid of the proposal is the piece cid of the CARv1 payload of the imported CARv2 file: import the CARv1 payload instead")
+	}
+	if d.Offline && d.CarVersion == 2 && d.PayloadPieceCid != nil && *d.PayloadPieceCid != d.ProposalPieceCid {
+		out = append(out, "the imported file is a CARv2 file, deal data is usually a CARv1 file")
+	}
+	if d.TrailingBytes > 0 {
+		out = append(out, fmt.Sprintf("the data has %d bytes after the last block, possibly padding added to the CAR file", d.TrailingBytes))
+	}
+	if d.PayloadCid.Defined() && len(d.CarRoots) > 0 {
+		found := false
+		for _, r := range d.CarRoots {
+			if r == d.PayloadCid {
+				found = true
+			}
+		}
+		if !found {
+			out = append(out, "the roots of the CAR file don't include the payload cid of the deal")
+		}
+	}
+
+	if len(out) == 0 {
+		out = append(out, "no padding or CAR version issue found: the data differs from the data the piece cid was computed for, e.g. the blocks were written in a different order")
+	}
+	return out
+}
+
+// pieceCommitment returns the piece cid of the data, padded to the smallest
+// piece holding it.
+func pieceCommitment(r io.Reader) (cid.Cid, abi.PaddedPieceSize, error) {
+	cp := &commp.Calc{}
+	if _, err := io.Copy(cp, r); err != nil {
+		return cid.Undef, 0, err
+	}
+	raw, size, err := cp.Digest()
+	if err != nil {
+		return cid.Undef, 0, err
+	}
+	c, err := commcid.DataCommitmentV1ToCID(raw)
+	if err != nil {
+		return cid.Undef, 0, err
+	}
+	return c, abi.PaddedPieceSize(size), nil
+}
+
+func padCommitment(c cid.Cid, size, target abi.PaddedPieceSize) (cid.Cid, error) {
+	raw, err := commcid.CIDToDataCommitmentV1(c)
+	if err != nil {
+		return cid.Undef, err
+	}
+	padded, err := commp.PadCommP(raw, uint64(size), uint64(target))
+	if err != nil {
+		return cid.Undef, err
+	}
+	return commcid.DataCommitmentV1ToCID(padded)
+}
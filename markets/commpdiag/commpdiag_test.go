@@ -0,0 +1,145 @@
+// stm: #unit
+package commpdiag
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/ipld/go-car"
+	"github.com/ipld/go-car/util"
+	carv2 "github.com/ipld/go-car/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+)
+
+func writeCarV1(t *testing.T, dir string, trailing int) (string, cid.Cid) {
+	var buf bytes.Buffer
+	var root cid.Cid
+	var blocks [][]byte
+	for i := 0; i < 4; i++ {
+		data := bytes.Repeat([]byte{byte('a' + i)}, 100)
+		c, err := cid.V1Builder{Codec: cid.Raw, MhType: 0x12}.Sum(data)
+		require.NoError(t, err)
+		if i == 0 {
+			root = c
+		}
+		blocks = append(blocks, append(c.Bytes(), data...))
+	}
+
+	require.NoError(t, car.WriteHeader(&car.CarHeader{Roots: []cid.Cid{root}, Version: 1}, &buf))
+	for _, b := range blocks {
+		require.NoError(t, util.LdWrite(&buf, b))
+	}
+	buf.Write(make([]byte, trailing))
+
+	path := filepath.Join(dir, "data.car")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0644))
+	return path, root
+}
+
+func hasFinding(findings []string, s string) bool {
+	for _, f := range findings {
+		if strings.Contains(f, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func pieceOf(t *testing.T, path string) (cid.Cid, abi.PaddedPieceSize) {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close() //nolint:errcheck
+	c, size, err := pieceCommitment(f)
+	require.NoError(t, err)
+	return c, size
+}
+
+func TestAnalyze(t *testing.T) {
+	dir := t.TempDir()
+	v1, root := writeCarV1(t, dir, 0)
+	piece, size := pieceOf(t, v1)
+
+	// the client proposed a larger piece size than the one it computed the
+	// piece cid for
+	d := Analyze(v1, true, piece, size*4, root)
+	require.Empty(t, d.Error)
+	require.Equal(t, uint64(1), d.CarVersion)
+	require.Equal(t, []cid.Cid{root}, d.CarRoots)
+	require.Equal(t, uint64(4), d.Blocks)
+	require.Equal(t, root, d.FirstBlock.Cid)
+	require.Zero(t, d.TrailingBytes)
+	require.Equal(t, piece, d.NaturalPieceCid)
+	require.NotEqual(t, piece, d.CalculatedPieceCid)
+	require.True(t, hasFinding(d.Findings, "the proposal has the wrong piece size"), d.Findings)
+
+	// zeroes appended to the CAR file, growing the data past the piece size
+	padded, _ := writeCarV1(t, t.TempDir(), 600)
+	d = Analyze(padded, true, piece, size, root)
+	require.Equal(t, uint64(600), d.TrailingBytes)
+	require.True(t, hasFinding(d.Findings, "bytes after the last block"), d.Findings)
+	require.True(t, hasFinding(d.Findings, "larger than the piece size of the proposal"), d.Findings)
+
+	// a CARv2 file imported for a piece cid of its CARv1 payload
+	v2 := filepath.Join(dir, "data.v2.car")
+	require.NoError(t, carv2.WrapV1File(v1, v2))
+	d = Analyze(v2, true, piece, size, root)
+	require.Equal(t, uint64(2), d.CarVersion)
+	require.Equal(t, piece, *d.PayloadPieceCid)
+	require.Equal(t, uint64(4), d.Blocks)
+	require.True(t, hasFinding(d.Findings, "import the CARv1 payload"), d.Findings)
+
+	// online deals are checked against the CARv1 payload of the inbound file
+	d = Analyze(v2, false, piece, size, root)
+	require.Equal(t, piece, d.CalculatedPieceCid)
+	require.True(t, hasFinding(d.Findings, "didn't happen again"), d.Findings)
+
+	// the data isn't the data of the proposal
+	b, err := os.ReadFile(v1)
+	require.NoError(t, err)
+	b[len(b)-1] ^= 0xff
+	other := filepath.Join(dir, "other.car")
+	require.NoError(t, os.WriteFile(other, b, 0644))
+	otherPiece, _ := pieceOf(t, other)
+	d = Analyze(v1, true, otherPiece, size, cid.Undef)
+	require.True(t, hasFinding(d.Findings, "no padding or CAR version issue found"), d.Findings)
+
+	d = Analyze(filepath.Join(dir, "missing.car"), true, piece, size, root)
+	require.NotEmpty(t, d.Error)
+}
+
+func TestRecorder(t *testing.T) {
+	ctx := context.Background()
+	r := New(dssync.MutexWrap(ds.NewMapDatastore()))
+
+	dir := t.TempDir()
+	v1, root := writeCarV1(t, dir, 0)
+	piece, size := pieceOf(t, v1)
+
+	prop, err := cid.V1Builder{Codec: cid.Raw, MhType: 0x12}.Sum([]byte("proposal"))
+	require.NoError(t, err)
+
+	_, err = r.Get(ctx, prop)
+	require.Error(t, err)
+
+	d := Analyze(v1, true, piece, size*2, root)
+	d.ProposalCid = prop
+	require.NoError(t, r.Put(ctx, d))
+
+	got, err := r.Get(ctx, prop)
+	require.NoError(t, err)
+	require.Equal(t, d.NaturalPieceCid, got.NaturalPieceCid)
+	require.Equal(t, d.Findings, got.Findings)
+
+	all, err := r.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+}
@@ -0,0 +1,209 @@
+package dagstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/dagstore"
+	"github.com/filecoin-project/dagstore/shard"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+const (
+	// maxTraces is the number of trace events of all the shards kept in
+	// memory for diagnostics.
+	maxTraces = 4096
+	// maxShardTraces is the number of trace events of a shard included in
+	// its diagnostics.
+	maxShardTraces = 32
+	// mountStatTimeout bounds the time spent resolving the mount of a shard.
+	mountStatTimeout = 30 * time.Second
+)
+
+type traceEvent struct {
+	time  time.Time
+	trace dagstore.Trace
+}
+
+// traceLog is a ring buffer of the last trace events of the DAG store.
+type traceLog struct {
+	lk     sync.Mutex
+	events []traceEvent
+	next   int
+}
+
+func newTraceLog(size int) *traceLog {
+	return &traceLog{events: make([]traceEvent, 0, size)}
+}
+
+func (l *traceLog) add(tr dagstore.Trace) {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+
+	ev := traceEvent{time: time.Now(), trace: tr}
+	if len(l.events) < cap(l.events) {
+		l.events = append(l.events, ev)
+		return
+	}
+	l.events[l.next] = ev
+	l.next = (l.next + 1) % len(l.events)
+}
+
+// shard returns the last n events of the shard, oldest first.
+func (l *traceLog) shard(key shard.Key, n int) []traceEvent {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+
+	var out []traceEvent
+	for i := len(l.events) - 1; i >= 0 && len(out) < n; i-- {
+		ev := l.events[(l.next+i)%len(l.events)]
+		if ev.trace.Key == key {
+			out = append(out, ev)
+		}
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out
+}
+
+// ShardDiagnostics collects the state of the shard of the piece, and of the
+// resources backing it. Failures to inspect a resource are reported in the
+// bundle rather than returned, so the bundle is as complete as possible.
+func (w *Wrapper) ShardDiagnostics(ctx context.Context, pieceCid cid.Cid) (*api.DagstoreShardDiagnostics, error) {
+	key := shard.KeyFromCID(pieceCid)
+	out := &api.DagstoreShardDiagnostics{Key: key.String()}
+
+	if dss, ok := w.dagst.(*dagstore.DAGStore); ok {
+		info, err := dss.GetShardInfo(key)
+		switch {
+		case err == nil:
+			out.State = info.ShardState.String()
+			if info.Error != nil {
+				out.Error = info.Error.Error()
+			}
+		case errors.Is(err, dagstore.ErrShardUnknown):
+			out.State = dagstore.ShardStateUnknown.String()
+		default:
+			return nil, xerrors.Errorf("getting shard info for piece CID %s: %w", pieceCid, err)
+		}
+	}
+
+	ps, err := w.persistedShard(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if ps != nil {
+		out.Registration = api.DagstoreShardRegistration{
+			Persisted:     true,
+			MountURL:      ps.URL,
+			TransientPath: ps.TransientPath,
+			Lazy:          ps.Lazy,
+			State:         ps.State.String(),
+			Error:         ps.Error,
+		}
+		out.Mount = w.mountDiagnostics(ctx, ps.URL)
+		transientPath := ps.TransientPath
+		if w.transients != nil {
+			transientPath = w.transients.Path(pieceCid)
+		}
+		out.Transient = transientDiagnostics(transientPath)
+	}
+
+	if st, err := w.irepo.StatFullIndex(key); err != nil {
+		out.Index.Error = err.Error()
+	} else {
+		out.Index.Exists = st.Exists
+		out.Index.Size = st.Size
+	}
+
+	for _, ev := range w.traces.shard(key, maxShardTraces) {
+		tr := api.DagstoreShardTrace{
+			Time:  ev.time,
+			Op:    ev.trace.Op.String(),
+			State: ev.trace.After.ShardState.String(),
+		}
+		if ev.trace.After.Error != nil {
+			tr.Error = ev.trace.After.Error.Error()
+		}
+		out.Traces = append(out.Traces, tr)
+	}
+
+	return out, nil
+}
+
+// persistedShard reads the shard as persisted by the DAG store, nil if it
+// isn't registered.
+func (w *Wrapper) persistedShard(ctx context.Context, key shard.Key) (*dagstore.PersistedShard, error) {
+	b, err := w.dstore.Get(ctx, dagstore.StoreNamespace.ChildString(key.String()))
+	if err != nil {
+		if errors.Is(err, ds.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, xerrors.Errorf("reading persisted shard %s: %w", key, err)
+	}
+
+	var ps dagstore.PersistedShard
+	if err := json.Unmarshal(b, &ps); err != nil {
+		return nil, xerrors.Errorf("decoding persisted shard %s: %w", key, err)
+	}
+	return &ps, nil
+}
+
+func (w *Wrapper) mountDiagnostics(ctx context.Context, mountURL string) api.DagstoreShardMount {
+	var out api.DagstoreShardMount
+
+	u, err := url.Parse(mountURL)
+	if err != nil {
+		out.Error = xerrors.Errorf("parsing mount url: %w", err).Error()
+		return out
+	}
+	mnt, err := w.registry.Instantiate(u)
+	if err != nil {
+		out.Error = err.Error()
+		return out
+	}
+	out.Resolved = true
+
+	ctx, cancel := context.WithTimeout(ctx, mountStatTimeout)
+	defer cancel()
+
+	st, err := mnt.Stat(ctx)
+	if err != nil {
+		out.Error = xerrors.Errorf("stat mount: %w", err).Error()
+		return out
+	}
+	out.Exists = st.Exists
+	out.Size = st.Size
+	out.Ready = st.Ready
+	return out
+}
+
+func transientDiagnostics(path string) api.DagstoreShardTransient {
+	out := api.DagstoreShardTransient{Path: path}
+	if path == "" {
+		return out
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			out.Error = err.Error()
+		}
+		return out
+	}
+	out.Exists = true
+	out.Size = fi.Size()
+	out.ModTime = fi.ModTime()
+	return out
+}
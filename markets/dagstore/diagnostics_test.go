@@ -0,0 +1,102 @@
+// stm: #unit
+package dagstore
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/ipfs/go-cid"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/dagstore"
+	"github.com/filecoin-project/dagstore/shard"
+
+	mock_dagstore "github.com/filecoin-project/lotus/markets/dagstore/mocks"
+	"github.com/filecoin-project/lotus/node/config"
+)
+
+func TestShardDiagnostics(t *testing.T) {
+	ctx := context.Background()
+	pieceCid, err := cid.Parse("bafkqaaa")
+	require.NoError(t, err)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	minerAPI := mock_dagstore.NewMockMinerAPI(mockCtrl)
+	minerAPI.EXPECT().IsUnsealed(gomock.Any(), pieceCid).Return(true, nil).AnyTimes()
+	minerAPI.EXPECT().GetUnpaddedCARSize(gomock.Any(), pieceCid).Return(uint64(100), nil).AnyTimes()
+
+	h, err := mocknet.New().GenPeer()
+	require.NoError(t, err)
+	_, w, err := NewDAGStore(config.DAGStoreConfig{
+		RootDir:    t.TempDir(),
+		GCInterval: config.Duration(time.Hour),
+	}, minerAPI, h, nil)
+	require.NoError(t, err)
+	require.NoError(t, w.Start(ctx))
+	defer w.Close() //nolint:errcheck
+
+	// unknown shard
+	diag, err := w.ShardDiagnostics(ctx, pieceCid)
+	require.NoError(t, err)
+	require.Equal(t, pieceCid.String(), diag.Key)
+	require.Equal(t, dagstore.ShardStateUnknown.String(), diag.State)
+	require.False(t, diag.Registration.Persisted)
+	require.False(t, diag.Index.Exists)
+	require.Empty(t, diag.Traces)
+
+	// lazily registered shard
+	resch := make(chan dagstore.ShardResult, 1)
+	require.NoError(t, w.RegisterShard(ctx, pieceCid, "", false, resch))
+	require.NoError(t, (<-resch).Error)
+
+	require.Eventually(t, func() bool {
+		diag, err = w.ShardDiagnostics(ctx, pieceCid)
+		require.NoError(t, err)
+		return len(diag.Traces) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.Equal(t, dagstore.ShardStateNew.String(), diag.State)
+	require.True(t, diag.Registration.Persisted)
+	require.True(t, diag.Registration.Lazy)
+	require.True(t, strings.HasPrefix(diag.Registration.MountURL, lotusScheme+"://"))
+	require.True(t, diag.Mount.Resolved)
+	require.Empty(t, diag.Mount.Error)
+	require.True(t, diag.Mount.Exists)
+	require.EqualValues(t, 100, diag.Mount.Size)
+	require.False(t, diag.Index.Exists)
+	require.False(t, diag.Transient.Exists)
+	require.Equal(t, dagstore.OpShardRegister.String(), diag.Traces[0].Op)
+}
+
+func TestTraceLog(t *testing.T) {
+	k1, k2 := shard.KeyFromString("a"), shard.KeyFromString("b")
+
+	l := newTraceLog(3)
+	require.Empty(t, l.shard(k1, 10))
+
+	ops := []dagstore.OpType{dagstore.OpShardRegister, dagstore.OpShardInitialize, dagstore.OpShardMakeAvailable, dagstore.OpShardAcquire, dagstore.OpShardRelease}
+	for i, op := range ops {
+		k := k1
+		if i == 2 {
+			k = k2
+		}
+		l.add(dagstore.Trace{Key: k, Op: op})
+	}
+
+	// the oldest events are dropped
+	evs := l.shard(k1, 10)
+	require.Len(t, evs, 2)
+	require.Equal(t, dagstore.OpShardAcquire, evs[0].trace.Op)
+	require.Equal(t, dagstore.OpShardRelease, evs[1].trace.Op)
+
+	evs = l.shard(k1, 1)
+	require.Len(t, evs, 1)
+	require.Equal(t, dagstore.OpShardRelease, evs[0].trace.Op)
+
+	require.Len(t, l.shard(k2, 10), 1)
+}
@@ -31,6 +31,9 @@ func mountTemplate(api MinerAPI) *LotusMount {
 type LotusMount struct {
 	API      MinerAPI
 	PieceCid cid.Cid
+
+	// Transients caches the fetched pieces encrypted when set.
+	Transients *Transients
 }
 
 func NewLotusMount(pieceCid cid.Cid, api MinerAPI) (*LotusMount, error) {
@@ -56,6 +59,13 @@ func (l *LotusMount) Deserialize(u *url.URL) error {
 }
 
 func (l *LotusMount) Fetch(ctx context.Context) (mount.Reader, error) {
+	if l.Transients != nil {
+		return l.Transients.Fetch(ctx, l.PieceCid, l.fetch)
+	}
+	return l.fetch(ctx)
+}
+
+func (l *LotusMount) fetch(ctx context.Context) (mount.Reader, error) {
 	return l.API.FetchUnsealedPiece(ctx, l.PieceCid)
 }
 
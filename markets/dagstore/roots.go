@@ -0,0 +1,111 @@
+package dagstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	carv2 "github.com/ipld/go-car/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/dagstore/shard"
+)
+
+// rootsNamespace is the namespace of the DAG store datastore under which the
+// payload roots of the pieces are kept.
+var rootsNamespace = ds.NewKey("/piece-roots")
+
+// ErrRootsNotFound is returned when the payload roots of a piece aren't known.
+var ErrRootsNotFound = errors.New("payload roots of piece not found")
+
+// PieceRootCids returns the roots of the payload DAG of the piece, as found in
+// the CAR header when the shard of the piece was indexed. The roots of pieces
+// indexed before the mapping was kept are read from the piece when it's
+// unsealed.
+func (w *Wrapper) PieceRootCids(ctx context.Context, pieceCid cid.Cid) ([]cid.Cid, error) {
+	key := shard.KeyFromCID(pieceCid)
+
+	b, err := w.dstore.Get(ctx, rootsNamespace.ChildString(key.String()))
+	switch {
+	case err == nil:
+		var roots []cid.Cid
+		if err := json.Unmarshal(b, &roots); err != nil {
+			return nil, xerrors.Errorf("decoding payload roots of piece %s: %w", pieceCid, err)
+		}
+		return roots, nil
+	case !errors.Is(err, ds.ErrNotFound):
+		return nil, xerrors.Errorf("reading payload roots of piece %s: %w", pieceCid, err)
+	}
+
+	roots, err := w.recordRoots(ctx, key)
+	if err != nil {
+		return nil, xerrors.Errorf("reading payload roots of piece %s: %w", pieceCid, err)
+	}
+	if roots == nil {
+		return nil, xerrors.Errorf("piece %s: %w", pieceCid, ErrRootsNotFound)
+	}
+	return roots, nil
+}
+
+// recordRoots reads the roots of the payload of the shard from the header of
+// its CAR, and stores them. It returns nil roots when the shard isn't
+// registered, or its piece isn't unsealed.
+func (w *Wrapper) recordRoots(ctx context.Context, key shard.Key) ([]cid.Cid, error) {
+	ps, err := w.persistedShard(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if ps == nil {
+		return nil, nil
+	}
+
+	u, err := url.Parse(ps.URL)
+	if err != nil {
+		return nil, xerrors.Errorf("parsing mount url: %w", err)
+	}
+	mnt, err := w.registry.Instantiate(u)
+	if err != nil {
+		return nil, xerrors.Errorf("instantiating mount: %w", err)
+	}
+
+	// don't trigger an unseal just to read the header
+	st, err := mnt.Stat(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("stat mount: %w", err)
+	}
+	if !st.Ready {
+		return nil, nil
+	}
+
+	r, err := mnt.Fetch(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("fetching piece: %w", err)
+	}
+	defer r.Close() //nolint:errcheck
+
+	rd, err := carv2.NewReader(r)
+	if err != nil {
+		return nil, xerrors.Errorf("reading CAR: %w", err)
+	}
+	roots, err := rd.Roots()
+	if err != nil {
+		return nil, xerrors.Errorf("reading CAR roots: %w", err)
+	}
+
+	b, err := json.Marshal(roots)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.dstore.Put(ctx, rootsNamespace.ChildString(key.String()), b); err != nil {
+		return nil, xerrors.Errorf("storing payload roots: %w", err)
+	}
+	return roots, nil
+}
+
+// dropRoots forgets the payload roots of the shard.
+func (w *Wrapper) dropRoots(ctx context.Context, key shard.Key) error {
+	return w.dstore.Delete(ctx, rootsNamespace.ChildString(key.String()))
+}
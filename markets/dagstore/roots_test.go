@@ -0,0 +1,83 @@
+// stm: #unit
+package dagstore
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/ipfs/go-cid"
+	carv2 "github.com/ipld/go-car/v2"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/dagstore"
+	"github.com/filecoin-project/dagstore/mount"
+
+	mock_dagstore "github.com/filecoin-project/lotus/markets/dagstore/mocks"
+	"github.com/filecoin-project/lotus/node/config"
+)
+
+func TestPieceRootCids(t *testing.T) {
+	ctx := context.Background()
+	pieceCid, err := cid.Parse("bafkqaaa")
+	require.NoError(t, err)
+
+	data, err := os.ReadFile("./fixtures/sample-rw-bs-v2.car")
+	require.NoError(t, err)
+
+	rd, err := carv2.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	expected, err := rd.Roots()
+	require.NoError(t, err)
+	require.NotEmpty(t, expected)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	minerAPI := mock_dagstore.NewMockMinerAPI(mockCtrl)
+	minerAPI.EXPECT().IsUnsealed(gomock.Any(), pieceCid).Return(true, nil).AnyTimes()
+	minerAPI.EXPECT().GetUnpaddedCARSize(gomock.Any(), pieceCid).Return(uint64(len(data)), nil).AnyTimes()
+	minerAPI.EXPECT().FetchUnsealedPiece(gomock.Any(), pieceCid).DoAndReturn(func(context.Context, cid.Cid) (mount.Reader, error) {
+		buf := bytes.NewReader(data)
+		return &mount.NopCloser{Reader: buf, ReaderAt: buf, Seeker: buf}, nil
+	}).AnyTimes()
+
+	h, err := mocknet.New().GenPeer()
+	require.NoError(t, err)
+	_, w, err := NewDAGStore(config.DAGStoreConfig{
+		RootDir:    t.TempDir(),
+		GCInterval: config.Duration(time.Hour),
+	}, minerAPI, h, nil)
+	require.NoError(t, err)
+	require.NoError(t, w.Start(ctx))
+	defer w.Close() //nolint:errcheck
+
+	_, err = w.PieceRootCids(ctx, pieceCid)
+	require.ErrorIs(t, err, ErrRootsNotFound)
+
+	// the roots are recorded once the shard is indexed
+	resch := make(chan dagstore.ShardResult, 1)
+	require.NoError(t, w.RegisterShard(ctx, pieceCid, "", true, resch))
+	require.NoError(t, (<-resch).Error)
+
+	require.Eventually(t, func() bool {
+		b, err := w.dstore.Has(ctx, rootsNamespace.ChildString(pieceCid.String()))
+		require.NoError(t, err)
+		return b
+	}, 5*time.Second, 10*time.Millisecond)
+
+	roots, err := w.PieceRootCids(ctx, pieceCid)
+	require.NoError(t, err)
+	require.Equal(t, expected, roots)
+
+	// and forgotten when the shard is destroyed
+	require.NoError(t, w.DestroyShard(ctx, pieceCid, make(chan dagstore.ShardResult, 1)))
+	require.Eventually(t, func() bool {
+		b, err := w.dstore.Has(ctx, rootsNamespace.ChildString(pieceCid.String()))
+		require.NoError(t, err)
+		return !b
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,505 @@
+package dagstore
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/dagstore/mount"
+)
+
+// Encrypted transients are made of a header, followed by the segments of the
+// piece sealed one by one, so that they can be read at random offsets:
+//
+//	header:  magic | nonce prefix
+//	segment: AES-GCM(nonce prefix | last segment flag | segment index, data)
+//
+// The flag of the last segment makes truncated transients fail to decrypt.
+const (
+	transientMagic       = "LTE1"
+	transientPrefixSize  = 7
+	transientHeaderSize  = len(transientMagic) + transientPrefixSize
+	transientSegmentSize = 64 << 10
+
+	transientPrefix = "transient-"
+	transientSuffix = ".enc"
+	partialSuffix   = ".partial"
+)
+
+// Transients caches the pieces fetched from the storage subsystem in files
+// encrypted with AES-GCM, so that the piece payloads cached on a shared
+// scratch disk aren't readable by the other users of the disk.
+type Transients struct {
+	dir  string
+	aead cipher.AEAD
+
+	lk    sync.Mutex
+	locks map[cid.Cid]*sync.Mutex
+}
+
+// NewTransients creates the encrypted transients in dir, with the 32 bytes
+// AES-256 key. The transients left partially written are removed.
+func NewTransients(dir string, key []byte) (*Transients, error) {
+	if len(key) != 32 {
+		return nil, xerrors.Errorf("transient encryption key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, xerrors.Errorf("creating cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, xerrors.Errorf("creating GCM cipher: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, xerrors.Errorf("creating transients directory %s: %w", dir, err)
+	}
+	partials, err := filepath.Glob(filepath.Join(dir, transientPrefix+"*"+transientSuffix+partialSuffix))
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range partials {
+		if err := os.Remove(p); err != nil {
+			log.Warnw("failed to remove partial transient", "path", p, "error", err)
+		}
+	}
+
+	return &Transients{
+		dir:   dir,
+		aead:  aead,
+		locks: map[cid.Cid]*sync.Mutex{},
+	}, nil
+}
+
+// Path returns the path of the transient of the piece.
+func (t *Transients) Path(pieceCid cid.Cid) string {
+	return filepath.Join(t.dir, transientPrefix+pieceCid.String()+transientSuffix)
+}
+
+func (t *Transients) pieceLock(pieceCid cid.Cid) *sync.Mutex {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	l, ok := t.locks[pieceCid]
+	if !ok {
+		l = new(sync.Mutex)
+		t.locks[pieceCid] = l
+	}
+	return l
+}
+
+// Fetch returns a reader of the piece. When the piece has a transient it's
+// read from it, otherwise it's streamed from the reader returned by fetch, and
+// the transient is only created the first time the piece is read at random
+// offsets, e.g. to serve blocks, so that indexing a piece doesn't copy it.
+func (t *Transients) Fetch(ctx context.Context, pieceCid cid.Cid, fetch func(context.Context) (mount.Reader, error)) (mount.Reader, error) {
+	l := t.pieceLock(pieceCid)
+	l.Lock()
+	r, err := t.open(t.Path(pieceCid))
+	l.Unlock()
+	if err == nil {
+		return r, nil
+	}
+
+	src, err := fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &lazyTransientReader{
+		t:        t,
+		pieceCid: pieceCid,
+		fetch:    fetch,
+		src:      src,
+	}, nil
+}
+
+// materialise returns a reader of the transient of the piece, creating it with
+// the data read from fetch when it doesn't exist or can't be decrypted.
+func (t *Transients) materialise(ctx context.Context, pieceCid cid.Cid, fetch func(context.Context) (mount.Reader, error)) (mount.Reader, error) {
+	l := t.pieceLock(pieceCid)
+	l.Lock()
+	defer l.Unlock()
+
+	path := t.Path(pieceCid)
+	r, err := t.open(path)
+	switch {
+	case err == nil:
+		return r, nil
+	case os.IsNotExist(err):
+	default:
+		log.Warnw("failed to open transient, fetching the piece again", "piece", pieceCid, "path", path, "error", err)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, xerrors.Errorf("removing transient %s: %w", path, err)
+		}
+	}
+
+	src, err := fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close() //nolint:errcheck
+
+	partial := path + partialSuffix
+	if err := t.write(partial, src); err != nil {
+		_ = os.Remove(partial)
+		return nil, xerrors.Errorf("writing transient of piece %s: %w", pieceCid, err)
+	}
+	if err := os.Rename(partial, path); err != nil {
+		_ = os.Remove(partial)
+		return nil, xerrors.Errorf("renaming transient of piece %s: %w", pieceCid, err)
+	}
+
+	return t.open(path)
+}
+
+// Remove removes the transient of the piece, if any.
+func (t *Transients) Remove(pieceCid cid.Cid) error {
+	l := t.pieceLock(pieceCid)
+	l.Lock()
+	defer l.Unlock()
+
+	if err := os.Remove(t.Path(pieceCid)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// GC removes the transients of the pieces for which keep returns false.
+func (t *Transients) GC(keep func(pieceCid cid.Cid) bool) error {
+	entries, err := os.ReadDir(t.dir)
+	if err != nil {
+		return xerrors.Errorf("listing transients: %w", err)
+	}
+
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, transientPrefix) || !strings.HasSuffix(name, transientSuffix) {
+			continue
+		}
+		pieceCid, err := cid.Decode(strings.TrimSuffix(strings.TrimPrefix(name, transientPrefix), transientSuffix))
+		if err != nil || keep(pieceCid) {
+			continue
+		}
+		if err := t.Remove(pieceCid); err != nil {
+			log.Warnw("failed to remove transient", "piece", pieceCid, "error", err)
+		}
+	}
+	return nil
+}
+
+func (t *Transients) write(path string, src io.Reader) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if err := encryptTransient(f, t.aead, src); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (t *Transients) open(path string) (mount.Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := newTransientReader(f, t.aead)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func transientNonce(prefix []byte, idx uint32, last bool) []byte {
+	nonce := make([]byte, transientPrefixSize+1+4)
+	copy(nonce, prefix)
+	if last {
+		nonce[transientPrefixSize] = 1
+	}
+	binary.BigEndian.PutUint32(nonce[transientPrefixSize+1:], idx)
+	return nonce
+}
+
+// encryptTransient writes the data read from src to w, encrypted.
+func encryptTransient(w io.Writer, aead cipher.AEAD, src io.Reader) error {
+	prefix := make([]byte, transientPrefixSize)
+	if _, err := rand.Read(prefix); err != nil {
+		return xerrors.Errorf("generating nonce prefix: %w", err)
+	}
+	if _, err := w.Write(append([]byte(transientMagic), prefix...)); err != nil {
+		return err
+	}
+
+	cur := make([]byte, transientSegmentSize)
+	next := make([]byte, transientSegmentSize)
+	sealed := make([]byte, 0, transientSegmentSize+aead.Overhead())
+
+	n, err := readSegment(src, cur)
+	if err != nil {
+		return err
+	}
+	for idx := uint32(0); ; idx++ {
+		var nn int
+		last := n < transientSegmentSize
+		if !last {
+			if nn, err = readSegment(src, next); err != nil {
+				return err
+			}
+			last = nn == 0
+		}
+
+		sealed = aead.Seal(sealed[:0], transientNonce(prefix, idx, last), cur[:n], nil)
+		if _, err := w.Write(sealed); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+		cur, next, n = next, cur, nn
+	}
+}
+
+// readSegment reads up to a full segment, it only returns less at the end of
+// the data.
+func readSegment(r io.Reader, buf []byte) (int, error) {
+	n, err := io.ReadFull(r, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	return n, err
+}
+
+var _ mount.Reader = (*lazyTransientReader)(nil)
+
+// lazyTransientReader streams a piece from the reader of the mount, until it's
+// read at random offsets, from then on it's read from the transient.
+type lazyTransientReader struct {
+	t        *Transients
+	pieceCid cid.Cid
+	fetch    func(context.Context) (mount.Reader, error)
+
+	lk  sync.Mutex
+	src mount.Reader
+	tr  mount.Reader
+}
+
+func (r *lazyTransientReader) reader() mount.Reader {
+	if r.tr != nil {
+		return r.tr
+	}
+	return r.src
+}
+
+func (r *lazyTransientReader) Read(p []byte) (int, error) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	return r.reader().Read(p)
+}
+
+func (r *lazyTransientReader) Seek(offset int64, whence int) (int64, error) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	return r.reader().Seek(offset, whence)
+}
+
+func (r *lazyTransientReader) ReadAt(p []byte, off int64) (int, error) {
+	r.lk.Lock()
+	if r.tr == nil {
+		if err := r.materialise(); err != nil {
+			r.lk.Unlock()
+			return 0, err
+		}
+	}
+	tr := r.tr
+	r.lk.Unlock()
+
+	return tr.ReadAt(p, off)
+}
+
+// materialise switches to reading the transient, at the current offset of the
+// stream, it's called with lk held.
+func (r *lazyTransientReader) materialise() error {
+	off, err := r.src.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return xerrors.Errorf("getting the offset of the piece reader: %w", err)
+	}
+
+	// the context the mount was fetched with may be done by now, as the DAG
+	// store keeps reading the piece after acquiring it
+	tr, err := r.t.materialise(context.Background(), r.pieceCid, r.fetch)
+	if err != nil {
+		return err
+	}
+	if _, err := tr.Seek(off, io.SeekStart); err != nil {
+		_ = tr.Close()
+		return err
+	}
+
+	if err := r.src.Close(); err != nil {
+		log.Warnw("failed to close piece reader", "piece", r.pieceCid, "error", err)
+	}
+	r.src, r.tr = nil, tr
+	return nil
+}
+
+func (r *lazyTransientReader) Close() error {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	return r.reader().Close()
+}
+
+var _ mount.Reader = (*transientReader)(nil)
+
+// transientReader decrypts a transient, keeping the last segment read.
+type transientReader struct {
+	f        *os.File
+	aead     cipher.AEAD
+	prefix   []byte
+	size     int64
+	segments int64
+
+	lk     sync.Mutex
+	offset int64
+	segIdx int64
+	seg    []byte
+}
+
+func newTransientReader(f *os.File, aead cipher.AEAD) (*transientReader, error) {
+	header := make([]byte, transientHeaderSize)
+	if _, err := io.ReadFull(f, header); err != nil {
+		return nil, xerrors.Errorf("reading transient header: %w", err)
+	}
+	if string(header[:len(transientMagic)]) != transientMagic {
+		return nil, xerrors.Errorf("not an encrypted transient")
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	sealedSize := int64(transientSegmentSize + aead.Overhead())
+	body := fi.Size() - int64(transientHeaderSize)
+	segments := (body + sealedSize - 1) / sealedSize
+	if segments == 0 || body-(segments-1)*sealedSize < int64(aead.Overhead()) {
+		return nil, xerrors.Errorf("transient truncated")
+	}
+
+	r := &transientReader{
+		f:        f,
+		aead:     aead,
+		prefix:   header[len(transientMagic):],
+		size:     body - segments*int64(aead.Overhead()),
+		segments: segments,
+		segIdx:   -1,
+	}
+	// fail early when the transient was encrypted with another key
+	if err := r.loadSegment(0); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// loadSegment decrypts the segment, it's called with lk held.
+func (r *transientReader) loadSegment(idx int64) error {
+	if idx == r.segIdx {
+		return nil
+	}
+
+	sealedSize := int64(transientSegmentSize + r.aead.Overhead())
+	length := sealedSize
+	if idx == r.segments-1 {
+		length = r.size + r.segments*int64(r.aead.Overhead()) - idx*sealedSize
+	}
+
+	sealed := make([]byte, length)
+	if _, err := r.f.ReadAt(sealed, int64(transientHeaderSize)+idx*sealedSize); err != nil {
+		return xerrors.Errorf("reading transient segment %d: %w", idx, err)
+	}
+	seg, err := r.aead.Open(r.seg[:0], transientNonce(r.prefix, uint32(idx), idx == r.segments-1), sealed, nil)
+	if err != nil {
+		r.segIdx = -1
+		return xerrors.Errorf("decrypting transient segment %d: %w", idx, err)
+	}
+	r.seg = seg
+	r.segIdx = idx
+	return nil
+}
+
+func (r *transientReader) ReadAt(p []byte, off int64) (int, error) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	return r.readAt(p, off)
+}
+
+func (r *transientReader) readAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, xerrors.Errorf("negative offset %d", off)
+	}
+
+	var n int
+	for n < len(p) {
+		if off >= r.size {
+			return n, io.EOF
+		}
+		if err := r.loadSegment(off / transientSegmentSize); err != nil {
+			return n, err
+		}
+		c := copy(p[n:], r.seg[off%transientSegmentSize:])
+		n += c
+		off += int64(c)
+	}
+	return n, nil
+}
+
+func (r *transientReader) Read(p []byte) (int, error) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	n, err := r.readAt(p, r.offset)
+	r.offset += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+func (r *transientReader) Seek(offset int64, whence int) (int64, error) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return 0, xerrors.Errorf("invalid whence %d", whence)
+	}
+	if offset < 0 {
+		return 0, xerrors.Errorf("negative offset %d", offset)
+	}
+	r.offset = offset
+	return offset, nil
+}
+
+func (r *transientReader) Close() error {
+	return r.f.Close()
+}
@@ -0,0 +1,160 @@
+// stm: #unit
+package dagstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/dagstore/mount"
+)
+
+type bytesReader struct {
+	*bytes.Reader
+}
+
+func (bytesReader) Close() error { return nil }
+
+func TestTransients(t *testing.T) {
+	ctx := context.Background()
+
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	tr, err := NewTransients(dir, key)
+	require.NoError(t, err)
+
+	pieceCid, err := cid.Parse("bafkqaaa")
+	require.NoError(t, err)
+
+	for _, size := range []int{0, 1, transientSegmentSize, transientSegmentSize + 1, 3*transientSegmentSize + 5} {
+		data := make([]byte, size)
+		_, err := rand.Read(data)
+		require.NoError(t, err)
+
+		var fetches int
+		fetch := func(context.Context) (mount.Reader, error) {
+			fetches++
+			return bytesReader{bytes.NewReader(data)}, nil
+		}
+
+		// streamed without a transient
+		r, err := tr.Fetch(ctx, pieceCid, fetch)
+		require.NoError(t, err)
+		read, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, data, read)
+		require.NoError(t, r.Close())
+		require.NoFileExists(t, tr.Path(pieceCid))
+
+		// the transient is created when the piece is read at random offsets,
+		// and the stream continues from it
+		r, err = tr.Fetch(ctx, pieceCid, fetch)
+		require.NoError(t, err)
+		head := make([]byte, size/2)
+		_, err = io.ReadFull(r, head)
+		require.NoError(t, err)
+		_, _ = r.ReadAt(make([]byte, 1), 0)
+		require.Equal(t, 3, fetches)
+		rest, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, data, append(head, rest...))
+		require.NoError(t, r.Close())
+
+		// the piece isn't readable in the transient
+		enc, err := os.ReadFile(tr.Path(pieceCid))
+		require.NoError(t, err)
+		if size > 1 { // a single byte may well appear in the ciphertext
+			require.False(t, bytes.Contains(enc, data))
+		}
+
+		// served from the transient
+		r, err = tr.Fetch(ctx, pieceCid, fetch)
+		require.NoError(t, err)
+		require.Equal(t, 3, fetches)
+
+		if size > 2 {
+			buf := make([]byte, size/2)
+			n, err := r.ReadAt(buf, int64(size/3))
+			require.NoError(t, err)
+			require.Equal(t, data[size/3:size/3+n], buf)
+
+			off, err := r.Seek(-1, io.SeekEnd)
+			require.NoError(t, err)
+			require.Equal(t, int64(size-1), off)
+			last, err := io.ReadAll(r)
+			require.NoError(t, err)
+			require.Equal(t, data[size-1:], last)
+		}
+
+		n, err := r.ReadAt(make([]byte, 10), int64(size))
+		require.Equal(t, 0, n)
+		require.Equal(t, io.EOF, err)
+		require.NoError(t, r.Close())
+
+		require.NoError(t, tr.Remove(pieceCid))
+	}
+}
+
+func TestTransientsTampering(t *testing.T) {
+	ctx := context.Background()
+
+	pieceCid, err := cid.Parse("bafkqaaa")
+	require.NoError(t, err)
+
+	data := make([]byte, 2*transientSegmentSize)
+	_, err = rand.Read(data)
+	require.NoError(t, err)
+
+	var fetches int
+	fetch := func(context.Context) (mount.Reader, error) {
+		fetches++
+		return bytesReader{bytes.NewReader(data)}, nil
+	}
+
+	dir := t.TempDir()
+	tr, err := NewTransients(dir, bytes.Repeat([]byte{1}, 32))
+	require.NoError(t, err)
+	r, err := tr.Fetch(ctx, pieceCid, fetch)
+	require.NoError(t, err)
+	_, err = r.ReadAt(make([]byte, 1), 0)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+
+	// truncated transients fail to decrypt
+	path := tr.Path(pieceCid)
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(path, fi.Size()-int64(transientSegmentSize)-16))
+	_, err = tr.open(path)
+	require.Error(t, err)
+
+	// transients encrypted with another key are fetched again
+	other, err := NewTransients(dir, bytes.Repeat([]byte{2}, 32))
+	require.NoError(t, err)
+	r, err = other.Fetch(ctx, pieceCid, fetch)
+	require.NoError(t, err)
+	read, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, data, read)
+	buf := make([]byte, 10)
+	_, err = r.ReadAt(buf, 5)
+	require.NoError(t, err)
+	require.Equal(t, data[5:15], buf)
+	require.NoError(t, r.Close())
+	require.Equal(t, 4, fetches)
+
+	// gc
+	require.NoError(t, other.GC(func(cid.Cid) bool { return true }))
+	require.FileExists(t, path)
+	require.NoError(t, other.GC(func(cid.Cid) bool { return false }))
+	require.NoFileExists(t, path)
+}
@@ -50,14 +50,41 @@ type Wrapper struct {
 	failureCh  chan dagstore.ShardResult
 	traceCh    chan dagstore.Trace
 	gcInterval time.Duration
+
+	// kept to inspect the shards
+	dstore   ds.Batching
+	irepo    index.FullIndexRepo
+	registry *mount.Registry
+	traces   *traceLog
+
+	// set when the transients are encrypted
+	transients *Transients
 }
 
 var _ stores.DAGStoreWrapper = (*Wrapper)(nil)
 
-func NewDAGStore(cfg config.DAGStoreConfig, minerApi MinerAPI, h host.Host) (*dagstore.DAGStore, *Wrapper, error) {
+// NewDAGStore creates the DAG store and its wrapper. The transient key is the
+// AES-256 key the transients are encrypted with, it must be set when the
+// transient encryption is enabled.
+func NewDAGStore(cfg config.DAGStoreConfig, minerApi MinerAPI, h host.Host, transientKey []byte) (*dagstore.DAGStore, *Wrapper, error) {
+	// The DAG store doesn't create transients of the lotus mounts, which
+	// support random access. When encryption is enabled, the mounts cache the
+	// pieces themselves, in a directory the DAG store doesn't manage as it
+	// removes the files it doesn't know in its transients directory.
+	var transients *Transients
+	if cfg.TransientEncryption {
+		var err error
+		transients, err = NewTransients(filepath.Join(cfg.RootDir, "transients-encrypted"), transientKey)
+		if err != nil {
+			return nil, nil, xerrors.Errorf("failed to create encrypted transients: %w", err)
+		}
+	}
+
 	// construct the DAG Store.
+	template := mountTemplate(minerApi)
+	template.Transients = transients
 	registry := mount.NewRegistry()
-	if err := registry.Register(lotusScheme, mountTemplate(minerApi)); err != nil {
+	if err := registry.Register(lotusScheme, template); err != nil {
 		return nil, nil, xerrors.Errorf("failed to create registry: %w", err)
 	}
 
@@ -111,6 +138,11 @@ func NewDAGStore(cfg config.DAGStoreConfig, minerApi MinerAPI, h host.Host) (*da
 		failureCh:  failureCh,
 		traceCh:    traceCh,
 		gcInterval: time.Duration(cfg.GCInterval),
+		dstore:     dstore,
+		irepo:      irepo,
+		registry:   registry,
+		traces:     newTraceLog(maxTraces),
+		transients: transients,
 	}
 
 	return dagst, w, nil
@@ -170,6 +202,14 @@ func (w *Wrapper) traceLoop() {
 				"shard-key", tr.Key.String(),
 				"op-type", tr.Op.String(),
 				"after", tr.After.String())
+			w.traces.add(tr)
+
+			switch tr.Op {
+			case dagstore.OpShardMakeAvailable, dagstore.OpShardDestroy:
+				// not blocking the event loop of the DAG store
+				w.backgroundWg.Add(1)
+				go w.updateRoots(tr)
+			}
 
 		case <-w.ctx.Done():
 			return
@@ -177,6 +217,24 @@ func (w *Wrapper) traceLoop() {
 	}
 }
 
+// updateRoots records the payload roots of the shards once they're indexed,
+// and forgets them when they're destroyed.
+func (w *Wrapper) updateRoots(tr dagstore.Trace) {
+	defer w.backgroundWg.Done()
+
+	if tr.Op == dagstore.OpShardDestroy {
+		if err := w.dropRoots(w.ctx, tr.Key); err != nil {
+			log.Warnw("failed to drop payload roots of shard", "shard-key", tr.Key.String(), "error", err)
+		}
+		w.removeTransient(tr.Key)
+		return
+	}
+
+	if _, err := w.recordRoots(w.ctx, tr.Key); err != nil {
+		log.Warnw("failed to record payload roots of shard", "shard-key", tr.Key.String(), "error", err)
+	}
+}
+
 func (w *Wrapper) gcLoop() {
 	defer w.backgroundWg.Done()
 
@@ -188,6 +246,7 @@ func (w *Wrapper) gcLoop() {
 		// GC the DAG store on every tick
 		case <-ticker.C:
 			_, _ = w.dagst.GC(w.ctx)
+			w.gcTransients()
 
 		// Exit when the DAG store wrapper is shutdown
 		case <-w.ctx.Done():
@@ -196,6 +255,37 @@ func (w *Wrapper) gcLoop() {
 	}
 }
 
+// gcTransients removes the encrypted transients of the shards which aren't
+// being initialized or served, like the DAG store GC does for its transients.
+func (w *Wrapper) gcTransients() {
+	if w.transients == nil {
+		return
+	}
+
+	shards := w.dagst.AllShardsInfo()
+	err := w.transients.GC(func(pieceCid cid.Cid) bool {
+		info, ok := shards[shard.KeyFromCID(pieceCid)]
+		return ok && info.ShardState != dagstore.ShardStateAvailable && info.ShardState != dagstore.ShardStateErrored
+	})
+	if err != nil {
+		log.Warnw("failed to gc encrypted transients", "error", err)
+	}
+}
+
+func (w *Wrapper) removeTransient(key shard.Key) {
+	if w.transients == nil {
+		return
+	}
+
+	pieceCid, err := cid.Parse(key.String())
+	if err != nil {
+		return
+	}
+	if err := w.transients.Remove(pieceCid); err != nil {
+		log.Warnw("failed to remove encrypted transient", "shard-key", key.String(), "error", err)
+	}
+}
+
 func (w *Wrapper) LoadShard(ctx context.Context, pieceCid cid.Cid) (stores.ClosableBlockstore, error) {
 	log.Debugf("acquiring shard for piece CID %s", pieceCid)
 
@@ -257,6 +347,7 @@ func (w *Wrapper) RegisterShard(ctx context.Context, pieceCid cid.Cid, carPath s
 	if err != nil {
 		return xerrors.Errorf("failed to create lotus mount for piece CID %s: %w", pieceCid, err)
 	}
+	mt.Transients = w.transients
 
 	// Register the shard
 	opts := dagstore.RegisterOpts{
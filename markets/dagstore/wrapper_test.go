@@ -35,7 +35,7 @@ func TestWrapperAcquireRecoveryDestroy(t *testing.T) {
 	dagst, w, err := NewDAGStore(config.DAGStoreConfig{
 		RootDir:    t.TempDir(),
 		GCInterval: config.Duration(1 * time.Millisecond),
-	}, mockLotusMount{}, h)
+	}, mockLotusMount{}, h, nil)
 	require.NoError(t, err)
 
 	defer dagst.Close() //nolint:errcheck
@@ -112,7 +112,7 @@ func TestWrapperBackground(t *testing.T) {
 	dagst, w, err := NewDAGStore(config.DAGStoreConfig{
 		RootDir:    t.TempDir(),
 		GCInterval: config.Duration(1 * time.Millisecond),
-	}, mockLotusMount{}, h)
+	}, mockLotusMount{}, h, nil)
 	require.NoError(t, err)
 
 	defer dagst.Close() //nolint:errcheck
@@ -6,6 +6,9 @@ import (
 	"encoding/json"
 	"os/exec"
 
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/peer"
+
 	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
 	"github.com/filecoin-project/go-fil-markets/storagemarket"
 
@@ -25,17 +28,27 @@ func CliStorageDealFilter(cmd string) dtypes.StorageDealFilter {
 	}
 }
 
-func CliRetrievalDealFilter(cmd string) dtypes.RetrievalDealFilter {
-	return func(ctx context.Context, deal retrievalmarket.ProviderDealState) (bool, string, error) {
+// CliRetrievalFilter runs the command for each retrieval deal, passing it the
+// deal as JSON on stdin along with the client, the piece and the transfer size
+// of the retrieval. A non-zero exit status rejects the deal, the output of the
+// command being the reason.
+func CliRetrievalFilter(cmd string) RetrievalFilter {
+	return RetrievalFilterFunc(func(ctx context.Context, req RetrievalRequest) (bool, string, error) {
 		d := struct {
 			retrievalmarket.ProviderDealState
-			DealType string
+			Client       peer.ID
+			PieceCID     cid.Cid
+			TransferSize uint64
+			DealType     string
 		}{
-			ProviderDealState: deal,
+			ProviderDealState: req.Deal,
+			Client:            req.Client,
+			PieceCID:          req.PieceCID,
+			TransferSize:      req.TransferSize,
 			DealType:          "retrieval",
 		}
 		return runDealFilter(ctx, cmd, d)
-	}
+	})
 }
 
 func runDealFilter(ctx context.Context, cmd string, deal interface{}) (bool, string, error) {
@@ -0,0 +1,84 @@
+package dealfilter
+
+import (
+	"context"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-fil-markets/piecestore"
+	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
+)
+
+// RetrievalRequest describes a retrieval deal proposal being considered by the
+// retrieval provider.
+type RetrievalRequest struct {
+	// Deal is the proposal as received from the client
+	Deal retrievalmarket.ProviderDealState
+
+	// Client is the peer ID of the client retrieving the data
+	Client peer.ID
+	// PieceCID is the piece the data is retrieved from. When the client
+	// doesn't ask for a specific piece, this is the first piece found to
+	// contain the payload.
+	PieceCID cid.Cid
+	// TransferSize is the unpadded size of the piece, the amount of data
+	// transferred when retrieving all of it. Retrievals with a selector
+	// transfer less.
+	TransferSize uint64
+}
+
+// RetrievalFilter decides whether to accept retrieval deals. It is called for
+// each new retrieval proposal, after the proposal was checked against the
+// retrieval ask. Returning false rejects the deal, with the reason sent back
+// to the client.
+type RetrievalFilter interface {
+	FilterRetrieval(ctx context.Context, req RetrievalRequest) (accept bool, reason string, err error)
+}
+
+// RetrievalFilterFunc is a RetrievalFilter implemented by a function.
+type RetrievalFilterFunc func(ctx context.Context, req RetrievalRequest) (bool, string, error)
+
+func (f RetrievalFilterFunc) FilterRetrieval(ctx context.Context, req RetrievalRequest) (bool, string, error) {
+	return f(ctx, req)
+}
+
+// NewRetrievalRequest resolves the piece the deal retrieves from, and the
+// size of the transfer.
+func NewRetrievalRequest(ps piecestore.PieceStore, deal retrievalmarket.ProviderDealState) (RetrievalRequest, error) {
+	req := RetrievalRequest{
+		Deal:   deal,
+		Client: deal.Receiver,
+	}
+
+	switch {
+	case deal.PieceInfo != nil:
+		req.PieceCID = deal.PieceInfo.PieceCID
+	case deal.PieceCID != nil:
+		req.PieceCID = *deal.PieceCID
+	default:
+		ci, err := ps.GetCIDInfo(deal.PayloadCID)
+		if err != nil {
+			return RetrievalRequest{}, xerrors.Errorf("getting pieces containing payload %s: %w", deal.PayloadCID, err)
+		}
+		if len(ci.PieceBlockLocations) == 0 {
+			return RetrievalRequest{}, xerrors.Errorf("no piece contains payload %s", deal.PayloadCID)
+		}
+		req.PieceCID = ci.PieceBlockLocations[0].PieceCID
+	}
+
+	pi := deal.PieceInfo
+	if pi == nil {
+		info, err := ps.GetPieceInfo(req.PieceCID)
+		if err != nil {
+			return RetrievalRequest{}, xerrors.Errorf("getting piece info for %s: %w", req.PieceCID, err)
+		}
+		pi = &info
+	}
+	if len(pi.Deals) > 0 {
+		req.TransferSize = uint64(pi.Deals[0].Length.Unpadded())
+	}
+
+	return req, nil
+}
@@ -0,0 +1,71 @@
+// stm: #unit
+package dealfilter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-fil-markets/piecestore"
+	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
+	tut "github.com/filecoin-project/go-fil-markets/shared_testutil"
+)
+
+func TestRetrievalFilter(t *testing.T) {
+	ctx := context.Background()
+
+	cids := tut.GenerateCids(3)
+	payload, piece, other := cids[0], cids[1], cids[2]
+
+	ps := tut.NewTestPieceStore()
+	ps.StubCID(payload, piecestore.CIDInfo{PieceBlockLocations: []piecestore.PieceBlockLocation{{PieceCID: piece}}})
+	ps.StubPiece(piece, piecestore.PieceInfo{PieceCID: piece, Deals: []piecestore.DealInfo{{Length: 2048}}})
+	ps.StubPiece(other, piecestore.PieceInfo{PieceCID: other, Deals: []piecestore.DealInfo{{Length: 1024}}})
+	ps.ExpectMissingCID(other)
+
+	client := peer.ID("client")
+	deal := retrievalmarket.ProviderDealState{
+		DealProposal: retrievalmarket.DealProposal{PayloadCID: payload},
+		Receiver:     client,
+	}
+
+	// the piece is looked up from the payload
+	req, err := NewRetrievalRequest(ps, deal)
+	require.NoError(t, err)
+	require.Equal(t, client, req.Client)
+	require.Equal(t, piece, req.PieceCID)
+	require.Equal(t, uint64(2032), req.TransferSize)
+
+	// the piece asked for by the client is used as is
+	deal.PieceCID = &other
+	req, err = NewRetrievalRequest(ps, deal)
+	require.NoError(t, err)
+	require.Equal(t, other, req.PieceCID)
+	require.Equal(t, uint64(1016), req.TransferSize)
+
+	_, err = NewRetrievalRequest(ps, retrievalmarket.ProviderDealState{
+		DealProposal: retrievalmarket.DealProposal{PayloadCID: other},
+	})
+	require.Error(t, err)
+
+	var blocked RetrievalFilter = RetrievalFilterFunc(func(ctx context.Context, req RetrievalRequest) (bool, string, error) {
+		if req.TransferSize > 1024 {
+			return false, "too large", nil
+		}
+		return true, "", nil
+	})
+	ok, reason, err := blocked.FilterRetrieval(ctx, req)
+	require.NoError(t, err)
+	require.True(t, ok, reason)
+
+	// the external command gets the resolved request on stdin
+	ok, reason, err = CliRetrievalFilter(`grep -q '"TransferSize": 1016'`).FilterRetrieval(ctx, req)
+	require.NoError(t, err)
+	require.True(t, ok, reason)
+
+	ok, _, err = CliRetrievalFilter(`echo rejected; exit 1`).FilterRetrieval(ctx, req)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
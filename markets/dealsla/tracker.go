@@ -0,0 +1,312 @@
+// Package dealsla tracks the time the storage deals of the provider take from
+// the receipt of their data to the sector holding them being Proving, and
+// compares it with the sealing SLA of the provider.
+package dealsla
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-fil-markets/storagemarket"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+var log = logging.Logger("dealsla")
+
+const (
+	// AtRiskFraction is the fraction of the SLA after which the deals still
+	// sealing are reported at risk of breaching it
+	AtRiskFraction = 0.8
+
+	// Retention is how long the records of the deals are kept after they
+	// reached Proving or failed
+	Retention = 30 * 24 * time.Hour
+	// gcInterval is the minimum interval between removals of old records
+	gcInterval = time.Hour
+)
+
+type record struct {
+	ProposalCid  cid.Cid
+	DealID       abi.DealID
+	Client       address.Address
+	PieceCid     cid.Cid
+	SectorNumber abi.SectorNumber
+
+	DataReceived time.Time
+	Activated    time.Time
+	Proving      time.Time
+	Failed       time.Time
+	Message      string `json:",omitempty"`
+}
+
+// Tracker records the progress of the storage deals, built from the events of
+// the storage provider and, when the sealing pipeline runs in the same
+// process, of the state changes of the sectors.
+type Tracker struct {
+	ds  datastore.Batching
+	sla time.Duration
+	now func() time.Time
+
+	// followSectors is set when OnSectorProving is called by the sealing
+	// pipeline, otherwise the activation of the deals is used instead of the
+	// sectors being Proving
+	followSectors bool
+
+	lk      sync.Mutex
+	records map[cid.Cid]*record
+	byDeal  map[abi.DealID]cid.Cid
+	lastGC  time.Time
+}
+
+func New(ctx context.Context, ds datastore.Batching, sla time.Duration, followSectors bool) (*Tracker, error) {
+	return newTracker(ctx, ds, sla, followSectors, time.Now)
+}
+
+func newTracker(ctx context.Context, ds datastore.Batching, sla time.Duration, followSectors bool, now func() time.Time) (*Tracker, error) {
+	t := &Tracker{
+		ds:            ds,
+		sla:           sla,
+		now:           now,
+		followSectors: followSectors,
+		records:       map[cid.Cid]*record{},
+		byDeal:        map[abi.DealID]cid.Cid{},
+		lastGC:        now(),
+	}
+
+	res, err := ds.Query(ctx, query.Query{})
+	if err != nil {
+		return nil, xerrors.Errorf("querying deal SLA records: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	for e := range res.Next() {
+		if e.Error != nil {
+			return nil, xerrors.Errorf("reading deal SLA records: %w", e.Error)
+		}
+		var r record
+		if err := json.Unmarshal(e.Value, &r); err != nil {
+			return nil, xerrors.Errorf("unmarshaling deal SLA record %s: %w", e.Key, err)
+		}
+		t.records[r.ProposalCid] = &r
+		if r.DealID != 0 {
+			t.byDeal[r.DealID] = r.ProposalCid
+		}
+	}
+
+	return t, nil
+}
+
+// OnStorageProviderEvent is a storage provider subscriber following the deals
+// from the receipt of their data.
+func (t *Tracker) OnStorageProviderEvent(event storagemarket.ProviderEvent, deal storagemarket.MinerDeal) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	now := t.now()
+
+	r, ok := t.records[deal.ProposalCid]
+	if !ok {
+		// the data of online deals is received with the transfer, the data of
+		// offline deals when it is imported
+		if event != storagemarket.ProviderEventDataTransferCompleted && event != storagemarket.ProviderEventVerifiedData {
+			return
+		}
+
+		r = &record{
+			ProposalCid:  deal.ProposalCid,
+			Client:       deal.Proposal.Client,
+			PieceCid:     deal.Proposal.PieceCID,
+			DataReceived: now,
+		}
+		t.records[deal.ProposalCid] = r
+	}
+	if !r.Proving.IsZero() || !r.Failed.IsZero() {
+		return
+	}
+
+	changed := !ok
+	if deal.DealID != 0 && r.DealID != deal.DealID {
+		r.DealID = deal.DealID
+		t.byDeal[deal.DealID] = deal.ProposalCid
+		changed = true
+	}
+	if deal.SectorNumber != 0 && r.SectorNumber != deal.SectorNumber {
+		r.SectorNumber = deal.SectorNumber
+		changed = true
+	}
+
+	switch {
+	case event == storagemarket.ProviderEventDealActivated:
+		r.Activated = now
+		changed = true
+	case event == storagemarket.ProviderEventFailed, deal.State == storagemarket.StorageDealError, deal.State == storagemarket.StorageDealFailing:
+		r.Failed = now
+		r.Message = deal.Message
+		changed = true
+	}
+
+	if changed {
+		t.put(r)
+	}
+	if !r.Failed.IsZero() {
+		t.maybeGC(now)
+	}
+}
+
+// OnSectorProving is called when a sector holding the given deals reached
+// Proving.
+func (t *Tracker) OnSectorProving(sector abi.SectorNumber, deals []abi.DealID) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	now := t.now()
+	for _, id := range deals {
+		pcid, ok := t.byDeal[id]
+		if !ok {
+			continue
+		}
+		r := t.records[pcid]
+		if !r.Proving.IsZero() {
+			continue
+		}
+		r.SectorNumber = sector
+		r.Proving = now
+		t.put(r)
+	}
+	t.maybeGC(now)
+}
+
+// put saves a record, the caller holds the lock
+func (t *Tracker) put(r *record) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		log.Errorw("marshaling deal SLA record", "proposal", r.ProposalCid, "error", err)
+		return
+	}
+	if err := t.ds.Put(context.TODO(), datastore.NewKey(r.ProposalCid.String()), b); err != nil {
+		log.Errorw("saving deal SLA record", "proposal", r.ProposalCid, "error", err)
+	}
+}
+
+// maybeGC removes the old records at most every gcInterval, the caller holds
+// the lock
+func (t *Tracker) maybeGC(now time.Time) {
+	if now.Sub(t.lastGC) < gcInterval {
+		return
+	}
+	t.lastGC = now
+
+	cutoff := now.Add(-Retention)
+	for pcid, r := range t.records {
+		end := t.completed(r)
+		if end.IsZero() {
+			end = r.Failed
+		}
+		if end.IsZero() || end.After(cutoff) {
+			continue
+		}
+		if err := t.ds.Delete(context.TODO(), datastore.NewKey(pcid.String())); err != nil {
+			log.Errorw("removing deal SLA record", "proposal", pcid, "error", err)
+			continue
+		}
+		delete(t.records, pcid)
+		if t.byDeal[r.DealID] == pcid {
+			delete(t.byDeal, r.DealID)
+		}
+	}
+}
+
+// completed returns when the sector of the deal reached Proving, zero while
+// it is still sealing
+func (t *Tracker) completed(r *record) time.Time {
+	if t.followSectors {
+		return r.Proving
+	}
+	return r.Activated
+}
+
+// Report compares the time the deals took, or have been taking so far, from
+// the receipt of their data to their sector being Proving, with the SLA.
+// Breaches come first, then the deals at risk of breaching the SLA.
+func (t *Tracker) Report() *api.DealSLAReport {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	now := t.now()
+	rep := &api.DealSLAReport{
+		SLA:   t.sla,
+		Deals: make([]api.DealSLAStatus, 0, len(t.records)),
+	}
+
+	for _, r := range t.records {
+		st := api.DealSLAStatus{
+			ProposalCid:  r.ProposalCid,
+			DealID:       r.DealID,
+			Client:       r.Client,
+			PieceCid:     r.PieceCid,
+			SectorNumber: r.SectorNumber,
+			DataReceived: r.DataReceived,
+			Proving:      t.completed(r),
+			Message:      r.Message,
+		}
+
+		end := now
+		switch {
+		case !st.Proving.IsZero():
+			end = st.Proving
+		case !r.Failed.IsZero():
+			end = r.Failed
+		}
+		st.Elapsed = end.Sub(r.DataReceived)
+
+		switch {
+		case st.Elapsed > t.sla:
+			// the deals which failed past the SLA breached it too
+			st.State = api.DealSLABreached
+			rep.Breached++
+		case !r.Failed.IsZero():
+			st.State = api.DealSLAFailed
+			rep.Failed++
+		case !st.Proving.IsZero():
+			st.State = api.DealSLAMet
+			rep.Met++
+		case float64(st.Elapsed) > AtRiskFraction*float64(t.sla):
+			st.State = api.DealSLAAtRisk
+			rep.AtRisk++
+		default:
+			st.State = api.DealSLASealing
+			rep.Sealing++
+		}
+
+		rep.Deals = append(rep.Deals, st)
+	}
+
+	order := map[api.DealSLAState]int{
+		api.DealSLABreached: 0,
+		api.DealSLAAtRisk:   1,
+		api.DealSLASealing:  2,
+		api.DealSLAFailed:   3,
+		api.DealSLAMet:      4,
+	}
+	sort.Slice(rep.Deals, func(i, j int) bool {
+		a, b := rep.Deals[i], rep.Deals[j]
+		if order[a.State] != order[b.State] {
+			return order[a.State] < order[b.State]
+		}
+		return a.DataReceived.Before(b.DataReceived)
+	})
+
+	return rep
+}
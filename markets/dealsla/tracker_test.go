@@ -0,0 +1,139 @@
+// stm: #unit
+package dealsla
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/raulk/clock"
+	"github.com/stretchr/testify/require"
+
+	tut "github.com/filecoin-project/go-fil-markets/shared_testutil"
+	"github.com/filecoin-project/go-fil-markets/storagemarket"
+	"github.com/filecoin-project/go-state-types/abi"
+	tutils "github.com/filecoin-project/specs-actors/v5/support/testing"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+func testDeal(t *testing.T) storagemarket.MinerDeal {
+	cids := tut.GenerateCids(2)
+
+	var deal storagemarket.MinerDeal
+	deal.ProposalCid = cids[0]
+	deal.Proposal.Client = tutils.NewIDAddr(t, 1000)
+	deal.Proposal.PieceCID = cids[1]
+	return deal
+}
+
+func TestTracker(t *testing.T) {
+	ctx := context.Background()
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+
+	// the records are read back in UTC
+	clk := clock.NewMock()
+	now := func() time.Time { return clk.Now().UTC() }
+
+	tr, err := newTracker(ctx, ds, 10*time.Hour, true, now)
+	require.NoError(t, err)
+
+	// deals are followed from the receipt of their data
+	early := testDeal(t)
+	tr.OnStorageProviderEvent(storagemarket.ProviderEventOpen, early)
+	require.Empty(t, tr.Report().Deals)
+
+	met, late, pending, failed := testDeal(t), testDeal(t), testDeal(t), testDeal(t)
+	tr.OnStorageProviderEvent(storagemarket.ProviderEventDataTransferCompleted, met)
+	tr.OnStorageProviderEvent(storagemarket.ProviderEventDataTransferCompleted, late)
+	tr.OnStorageProviderEvent(storagemarket.ProviderEventDataTransferCompleted, failed)
+
+	// the data of the offline deal is imported later
+	clk.Add(time.Hour)
+	tr.OnStorageProviderEvent(storagemarket.ProviderEventVerifiedData, pending)
+	met.DealID, late.DealID = 11, 12
+	tr.OnStorageProviderEvent(storagemarket.ProviderEventDealPublished, met)
+	tr.OnStorageProviderEvent(storagemarket.ProviderEventDealPublished, late)
+
+	failed.State = storagemarket.StorageDealError
+	failed.Message = "publishing failed"
+	tr.OnStorageProviderEvent(storagemarket.ProviderEventFailed, failed)
+
+	clk.Add(4 * time.Hour)
+	tr.OnSectorProving(5, []abi.DealID{11, 99})
+
+	// the activation doesn't count when the sectors are followed
+	clk.Add(4 * time.Hour)
+	tr.OnStorageProviderEvent(storagemarket.ProviderEventDealActivated, late)
+
+	rep := tr.Report()
+	require.Equal(t, 1, rep.Met)
+	require.Equal(t, 1, rep.AtRisk)
+	require.Equal(t, 1, rep.Failed)
+	require.Equal(t, 1, rep.Sealing)
+	require.Zero(t, rep.Breached)
+
+	clk.Add(3 * time.Hour)
+	tr.OnSectorProving(6, []abi.DealID{12})
+
+	rep = tr.Report()
+	require.Equal(t, 10*time.Hour, rep.SLA)
+	require.Len(t, rep.Deals, 4)
+	require.Equal(t, 2, rep.Breached)
+
+	// breaches come first
+	require.Equal(t, late.ProposalCid, rep.Deals[0].ProposalCid)
+	require.Equal(t, api.DealSLABreached, rep.Deals[0].State)
+	require.Equal(t, 12*time.Hour, rep.Deals[0].Elapsed)
+	require.Equal(t, abi.SectorNumber(6), rep.Deals[0].SectorNumber)
+
+	// the pending deal is sealing for 11h now
+	require.Equal(t, pending.ProposalCid, rep.Deals[1].ProposalCid)
+	require.Equal(t, api.DealSLABreached, rep.Deals[1].State)
+	require.True(t, rep.Deals[1].Proving.IsZero())
+
+	require.Equal(t, failed.ProposalCid, rep.Deals[2].ProposalCid)
+	require.Equal(t, api.DealSLAFailed, rep.Deals[2].State)
+	require.Equal(t, "publishing failed", rep.Deals[2].Message)
+
+	require.Equal(t, met.ProposalCid, rep.Deals[3].ProposalCid)
+	require.Equal(t, api.DealSLAMet, rep.Deals[3].State)
+	require.Equal(t, 5*time.Hour, rep.Deals[3].Elapsed)
+	require.Equal(t, abi.SectorNumber(5), rep.Deals[3].SectorNumber)
+
+	// the records survive restarts
+	tr, err = newTracker(ctx, ds, 10*time.Hour, true, now)
+	require.NoError(t, err)
+	require.Equal(t, rep, tr.Report())
+
+	// the old records are removed
+	clk.Add(Retention + time.Hour)
+	tr.OnSectorProving(7, nil)
+	rep = tr.Report()
+	require.Len(t, rep.Deals, 1)
+	require.Equal(t, pending.ProposalCid, rep.Deals[0].ProposalCid)
+}
+
+func TestTrackerActivation(t *testing.T) {
+	ctx := context.Background()
+
+	clk := clock.NewMock()
+	tr, err := newTracker(ctx, datastore.NewMapDatastore(), 10*time.Hour, false, clk.Now)
+	require.NoError(t, err)
+
+	deal := testDeal(t)
+	tr.OnStorageProviderEvent(storagemarket.ProviderEventDataTransferCompleted, deal)
+
+	clk.Add(3 * time.Hour)
+	deal.DealID, deal.SectorNumber = 11, 5
+	tr.OnStorageProviderEvent(storagemarket.ProviderEventDealActivated, deal)
+
+	// without the sealing pipeline, the activation of the deals counts
+	rep := tr.Report()
+	require.Equal(t, 1, rep.Met)
+	require.Equal(t, 3*time.Hour, rep.Deals[0].Elapsed)
+	require.Equal(t, abi.SectorNumber(5), rep.Deals[0].SectorNumber)
+	require.True(t, clk.Now().Equal(rep.Deals[0].Proving))
+}
@@ -0,0 +1,193 @@
+package dhtprovider
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/libp2p/go-libp2p/core/routing"
+	"go.opencensus.io/stats"
+	"golang.org/x/time/rate"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/metrics"
+)
+
+var log = logging.Logger("dhtprovider")
+
+// provideTimeout bounds the time spent publishing a single provider record.
+const provideTimeout = 2 * time.Minute
+
+// announceQueueSize is the number of announcements that can be queued before
+// new announcements are dropped. Dropped announcements are picked up by the
+// next reprovide.
+const announceQueueSize = 1024
+
+// Source returns the full set of CIDs that should be announced on every
+// reprovide.
+type Source func(ctx context.Context) ([]cid.Cid, error)
+
+type Config struct {
+	// ProvidesPerSecond limits the rate at which records are published. 0
+	// means unlimited.
+	ProvidesPerSecond int
+	// Workers is the number of records published in parallel.
+	Workers int
+	// ReprovideInterval is the interval at which all CIDs returned by the
+	// source are announced again.
+	ReprovideInterval time.Duration
+}
+
+// Provider publishes provider records for CIDs to a content router. CIDs can
+// be announced individually as they become available, and the complete set
+// returned by the source is periodically reprovided.
+type Provider struct {
+	router  routing.ContentRouting
+	source  Source
+	cfg     Config
+	limiter *rate.Limiter
+
+	announce chan cid.Cid
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func New(router routing.ContentRouting, source Source, cfg Config) *Provider {
+	limit := rate.Inf
+	if cfg.ProvidesPerSecond > 0 {
+		limit = rate.Limit(cfg.ProvidesPerSecond)
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+
+	return &Provider{
+		router:   router,
+		source:   source,
+		cfg:      cfg,
+		limiter:  rate.NewLimiter(limit, 1),
+		announce: make(chan cid.Cid, announceQueueSize),
+	}
+}
+
+// Start starts the provide workers and the reprovide loop. The first
+// reprovide runs immediately.
+func (p *Provider) Start(ctx context.Context) {
+	ctx, p.cancel = context.WithCancel(ctx)
+
+	queue := make(chan cid.Cid)
+	for i := 0; i < p.cfg.Workers; i++ {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			for {
+				select {
+				case c := <-queue:
+					p.provide(ctx, c)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.run(ctx, queue)
+	}()
+}
+
+func (p *Provider) run(ctx context.Context, queue chan<- cid.Cid) {
+	reprovide := time.NewTimer(0)
+	defer reprovide.Stop()
+
+	// pending holds the CIDs of the reprovide in progress; individual
+	// announcements are interleaved with it so that new data doesn't wait
+	// for a full reprovide to complete.
+	var pending []cid.Cid
+	for {
+		var (
+			next cid.Cid
+			out  chan<- cid.Cid
+		)
+		if len(pending) > 0 {
+			next, out = pending[0], queue
+		}
+
+		select {
+		case <-reprovide.C:
+			reprovide.Reset(p.cfg.ReprovideInterval)
+
+			// the previous reprovide is still being published, which would
+			// publish the same CIDs twice
+			if len(pending) > 0 {
+				log.Warnw("skipping reprovide, the previous one is still in progress", "pending", len(pending))
+				continue
+			}
+
+			cids, err := p.source(ctx)
+			if err != nil {
+				log.Errorw("listing cids to reprovide", "error", err)
+				continue
+			}
+			log.Infow("starting reprovide", "cids", len(cids))
+			pending = cids
+		case c := <-p.announce:
+			select {
+			case queue <- c:
+			case <-ctx.Done():
+				return
+			}
+		case out <- next:
+			pending = pending[1:]
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *Provider) provide(ctx context.Context, c cid.Cid) {
+	if err := p.limiter.Wait(ctx); err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, provideTimeout)
+	defer cancel()
+
+	start := time.Now()
+	if err := p.router.Provide(ctx, c, true); err != nil {
+		log.Warnw("failed to provide cid to the dht", "cid", c, "error", err)
+		stats.Record(ctx, metrics.DHTProvideFailureCount.M(1))
+		return
+	}
+
+	stats.Record(ctx,
+		metrics.DHTProvideCount.M(1),
+		metrics.DHTProvideDuration.M(metrics.SinceInMilliseconds(start)))
+}
+
+// Announce queues a CID to be published ahead of the next reprovide. If the
+// queue is full the CID is dropped, and only published on the next
+// reprovide.
+func (p *Provider) Announce(c cid.Cid) {
+	select {
+	case p.announce <- c:
+	default:
+		log.Warnw("dht announce queue full, dropping announcement", "cid", c)
+	}
+}
+
+// Close stops publishing records and waits for in-flight publishes to
+// finish.
+func (p *Provider) Close() error {
+	if p.cancel == nil {
+		return xerrors.Errorf("dht provider not started")
+	}
+	p.cancel()
+	p.wg.Wait()
+	return nil
+}
@@ -0,0 +1,149 @@
+// stm: #unit
+package dhtprovider
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+
+	tut "github.com/filecoin-project/go-fil-markets/shared_testutil"
+)
+
+type mockRouter struct {
+	lk       sync.Mutex
+	provided map[cid.Cid]int
+}
+
+func (m *mockRouter) Provide(_ context.Context, c cid.Cid, _ bool) error {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	m.provided[c]++
+	return nil
+}
+
+func (m *mockRouter) FindProvidersAsync(context.Context, cid.Cid, int) <-chan peer.AddrInfo {
+	return nil
+}
+
+func (m *mockRouter) count(c cid.Cid) int {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	return m.provided[c]
+}
+
+func TestProviderReprovide(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cids := tut.GenerateCids(3)
+	c1, c2, c3 := cids[0], cids[1], cids[2]
+
+	router := &mockRouter{provided: map[cid.Cid]int{}}
+	source := func(context.Context) ([]cid.Cid, error) {
+		return []cid.Cid{c1, c2}, nil
+	}
+
+	p := New(router, source, Config{
+		Workers:           2,
+		ReprovideInterval: 100 * time.Millisecond,
+	})
+	p.Start(ctx)
+
+	p.Announce(c3)
+
+	require.Eventually(t, func() bool {
+		return router.count(c1) >= 2 && router.count(c2) >= 2 && router.count(c3) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, p.Close())
+}
+
+func TestProviderRateLimit(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cids := tut.GenerateCids(5)
+
+	router := &mockRouter{provided: map[cid.Cid]int{}}
+	source := func(context.Context) ([]cid.Cid, error) {
+		return cids, nil
+	}
+
+	p := New(router, source, Config{
+		ProvidesPerSecond: 20,
+		Workers:           4,
+		ReprovideInterval: time.Hour,
+	})
+
+	start := time.Now()
+	p.Start(ctx)
+	require.Eventually(t, func() bool {
+		for _, c := range cids {
+			if router.count(c) != 1 {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, 5*time.Millisecond)
+
+	// the first provide is immediate, the remaining four are spaced 50ms apart
+	require.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+	require.NoError(t, p.Close())
+}
+
+type blockingRouter struct {
+	mockRouter
+	release chan struct{}
+}
+
+func (b *blockingRouter) Provide(ctx context.Context, c cid.Cid, brdcst bool) error {
+	select {
+	case <-b.release:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return b.mockRouter.Provide(ctx, c, brdcst)
+}
+
+func TestProviderSkipsOverlappingReprovides(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cids := tut.GenerateCids(2)
+	c1, c2 := cids[0], cids[1]
+
+	router := &blockingRouter{mockRouter: mockRouter{provided: map[cid.Cid]int{}}, release: make(chan struct{})}
+	var lk sync.Mutex
+	var listed int
+	source := func(context.Context) ([]cid.Cid, error) {
+		lk.Lock()
+		defer lk.Unlock()
+		listed++
+		return cids, nil
+	}
+
+	p := New(router, source, Config{
+		Workers:           1,
+		ReprovideInterval: 10 * time.Millisecond,
+	})
+	p.Start(ctx)
+
+	// c2 stays pending while the only worker is stuck on c1, so the ticks in
+	// the meantime don't list the CIDs again
+	time.Sleep(200 * time.Millisecond)
+	lk.Lock()
+	require.Equal(t, 1, listed)
+	lk.Unlock()
+
+	close(router.release)
+	require.Eventually(t, func() bool {
+		return router.count(c1) >= 1 && router.count(c2) >= 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, p.Close())
+}
@@ -0,0 +1,313 @@
+// Package dtprogress tracks the byte-level progress of the data transfers of
+// storage deals on the provider.
+package dtprogress
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	datatransfer "github.com/filecoin-project/go-data-transfer/v2"
+	"github.com/filecoin-project/go-fil-markets/storagemarket/impl/requestvalidation"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+var log = logging.Logger("dtprogress")
+
+const (
+	// throughputWindow is the period over which the throughput of a transfer
+	// is averaged
+	throughputWindow = time.Minute
+	// updateInterval limits how often progress updates of a transfer are
+	// sent to subscribers; status changes are always sent
+	updateInterval = time.Second
+	// finishedHistory is the number of finished transfers kept
+	finishedHistory = 256
+	// subscriberBuffer is the number of updates queued for a subscriber
+	// before updates are dropped
+	subscriberBuffer = 128
+)
+
+// DefaultStallTimeout is how long a transfer goes without receiving data
+// before it is reported as stalled, unless configured otherwise.
+const DefaultStallTimeout = 5 * time.Minute
+
+type sample struct {
+	at       time.Time
+	received uint64
+}
+
+type transfer struct {
+	proposal cid.Cid
+	chid     datatransfer.ChannelID
+	status   datatransfer.Status
+	paused   bool
+
+	started      time.Time
+	lastProgress time.Time
+	lastUpdate   time.Time
+	received     uint64
+	blocks       int64
+	samples      []sample
+	finished     bool
+}
+
+// Tracker follows the storage deal data transfers through data transfer
+// events.
+type Tracker struct {
+	dt           datatransfer.Manager
+	stallTimeout time.Duration
+	now          func() time.Time
+
+	lk        sync.Mutex
+	transfers map[cid.Cid]*transfer
+	channels  map[datatransfer.ChannelID]*transfer
+	finished  []*transfer
+
+	subs   map[int]chan api.DealTransferStatus
+	nextID int
+
+	unsub datatransfer.Unsubscribe
+}
+
+// New creates a tracker of the storage deal transfers of the data transfer
+// manager. It must be started with Start.
+func New(dt datatransfer.Manager, stallTimeout time.Duration) *Tracker {
+	t := newTracker(stallTimeout, time.Now)
+	t.dt = dt
+	return t
+}
+
+func newTracker(stallTimeout time.Duration, now func() time.Time) *Tracker {
+	if stallTimeout <= 0 {
+		stallTimeout = DefaultStallTimeout
+	}
+	return &Tracker{
+		stallTimeout: stallTimeout,
+		now:          now,
+		transfers:    map[cid.Cid]*transfer{},
+		channels:     map[datatransfer.ChannelID]*transfer{},
+		subs:         map[int]chan api.DealTransferStatus{},
+	}
+}
+
+// Start begins tracking the transfers in progress, and those opened later.
+func (t *Tracker) Start(ctx context.Context) error {
+	t.unsub = t.dt.SubscribeToEvents(t.OnDataTransferEvent)
+
+	channels, err := t.dt.InProgressChannels(ctx)
+	if err != nil {
+		t.unsub()
+		return xerrors.Errorf("listing data transfers in progress: %w", err)
+	}
+	for _, state := range channels {
+		t.update(state, false)
+	}
+	return nil
+}
+
+func (t *Tracker) Stop(context.Context) error {
+	if t.unsub != nil {
+		t.unsub()
+	}
+
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	for id, ch := range t.subs {
+		close(ch)
+		delete(t.subs, id)
+	}
+	return nil
+}
+
+// OnDataTransferEvent is a data transfer subscriber recording the progress of
+// the storage deal transfers.
+func (t *Tracker) OnDataTransferEvent(event datatransfer.Event, state datatransfer.ChannelState) {
+	progress := false
+	switch event.Code {
+	case datatransfer.DataReceived, datatransfer.DataReceivedProgress:
+		progress = true
+	}
+
+	t.update(state, progress)
+}
+
+func (t *Tracker) update(state datatransfer.ChannelState, progress bool) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	now := t.now()
+	chid := state.ChannelID()
+
+	tr, ok := t.channels[chid]
+	if !ok {
+		if state.Status().TransferComplete() {
+			return
+		}
+
+		proposal, err := dealProposal(state.Voucher())
+		if err != nil {
+			// not a storage deal transfer
+			return
+		}
+
+		tr = &transfer{
+			proposal:     proposal,
+			chid:         chid,
+			started:      now,
+			lastProgress: now,
+		}
+		// a restarted transfer replaces the previous channel of the deal
+		if prev, ok := t.transfers[proposal]; ok {
+			delete(t.channels, prev.chid)
+		}
+		t.transfers[proposal] = tr
+		t.channels[chid] = tr
+	}
+
+	paused := state.InitiatorPaused() || state.ResponderPaused()
+	statusChanged := tr.status != state.Status() || tr.paused != paused
+	if progress || (tr.paused && !paused) {
+		tr.lastProgress = now
+	}
+	tr.status = state.Status()
+	tr.paused = paused
+	tr.received = state.Received()
+	tr.blocks = state.ReceivedCidsTotal()
+	tr.addSample(now)
+
+	if tr.status.TransferComplete() {
+		tr.finished = true
+		delete(t.channels, chid)
+		t.finished = append(t.finished, tr)
+		if len(t.finished) > finishedHistory {
+			old := t.finished[0]
+			t.finished = t.finished[1:]
+			if t.transfers[old.proposal] == old {
+				delete(t.transfers, old.proposal)
+			}
+		}
+	}
+
+	if !statusChanged && now.Sub(tr.lastUpdate) < updateInterval {
+		return
+	}
+	tr.lastUpdate = now
+
+	st := tr.toAPI(now, t.stallTimeout)
+	for _, ch := range t.subs {
+		select {
+		case ch <- st:
+		default:
+			log.Warnw("dropping deal transfer update, subscriber too slow", "proposal", tr.proposal)
+		}
+	}
+}
+
+func (tr *transfer) addSample(now time.Time) {
+	if n := len(tr.samples); n > 0 && now.Sub(tr.samples[n-1].at) < time.Second {
+		tr.samples[n-1].received = tr.received
+	} else {
+		tr.samples = append(tr.samples, sample{at: now, received: tr.received})
+	}
+
+	// keep the last sample before the window as the base of the throughput
+	drop := 0
+	for drop+1 < len(tr.samples) && now.Sub(tr.samples[drop+1].at) >= throughputWindow {
+		drop++
+	}
+	tr.samples = tr.samples[drop:]
+}
+
+// throughput returns the average bytes per second received since the last
+// sample before the throughput window.
+func (tr *transfer) throughput(now time.Time) uint64 {
+	if tr.finished || len(tr.samples) == 0 {
+		return 0
+	}
+
+	base := tr.samples[0]
+	for _, s := range tr.samples[1:] {
+		if now.Sub(s.at) < throughputWindow {
+			break
+		}
+		base = s
+	}
+
+	elapsed := now.Sub(base.at)
+	if elapsed < time.Second {
+		return 0
+	}
+	return uint64(float64(tr.received-base.received) / elapsed.Seconds())
+}
+
+func (tr *transfer) toAPI(now time.Time, stallTimeout time.Duration) api.DealTransferStatus {
+	return api.DealTransferStatus{
+		ProposalCid:    tr.proposal,
+		ChannelID:      tr.chid,
+		Status:         tr.status,
+		Started:        tr.started,
+		LastProgress:   tr.lastProgress,
+		BytesReceived:  tr.received,
+		BlocksVerified: tr.blocks,
+		Throughput:     tr.throughput(now),
+		Stalled:        !tr.finished && !tr.paused && now.Sub(tr.lastProgress) >= stallTimeout,
+		Finished:       tr.finished,
+	}
+}
+
+// Status returns the progress of the transfer of the deal with the given
+// proposal, for transfers in progress and the most recently finished ones.
+func (t *Tracker) Status(proposal cid.Cid) (api.DealTransferStatus, bool) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	tr, ok := t.transfers[proposal]
+	if !ok {
+		return api.DealTransferStatus{}, false
+	}
+	return tr.toAPI(t.now(), t.stallTimeout), true
+}
+
+// Subscribe returns a channel receiving the progress of the deal transfers,
+// at most once a second for each transfer, and on each status change. The
+// channel is closed when the context is cancelled.
+func (t *Tracker) Subscribe(ctx context.Context) <-chan api.DealTransferStatus {
+	ch := make(chan api.DealTransferStatus, subscriberBuffer)
+
+	t.lk.Lock()
+	id := t.nextID
+	t.nextID++
+	t.subs[id] = ch
+	t.lk.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		t.lk.Lock()
+		defer t.lk.Unlock()
+		if _, ok := t.subs[id]; ok {
+			close(ch)
+			delete(t.subs, id)
+		}
+	}()
+
+	return ch
+}
+
+func dealProposal(voucher datatransfer.TypedVoucher) (cid.Cid, error) {
+	if voucher.Type != requestvalidation.StorageDataTransferVoucherType || voucher.Voucher == nil {
+		return cid.Undef, xerrors.Errorf("not a storage deal voucher")
+	}
+
+	v, err := requestvalidation.BindnodeRegistry.TypeFromNode(voucher.Voucher, &requestvalidation.StorageDataTransferVoucher{})
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("decoding storage deal voucher: %w", err)
+	}
+	return v.(*requestvalidation.StorageDataTransferVoucher).Proposal, nil
+}
@@ -0,0 +1,105 @@
+// stm: #unit
+package dtprogress
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/raulk/clock"
+	"github.com/stretchr/testify/require"
+
+	datatransfer "github.com/filecoin-project/go-data-transfer/v2"
+	"github.com/filecoin-project/go-data-transfer/v2/testutil"
+	"github.com/filecoin-project/go-fil-markets/storagemarket/impl/requestvalidation"
+)
+
+// voucherState sets the voucher of the mock channel state, which ignores the
+// voucher it is created with
+type voucherState struct {
+	*testutil.MockChannelState
+	voucher datatransfer.TypedVoucher
+}
+
+func (s voucherState) Voucher() datatransfer.TypedVoucher {
+	return s.voucher
+}
+
+func TestTransferProgress(t *testing.T) {
+	clk := clock.NewMock()
+	tr := newTracker(5*time.Minute, clk.Now)
+
+	proposal, err := cid.V1Builder{Codec: cid.Raw, MhType: 0x12}.Sum([]byte("proposal"))
+	require.NoError(t, err)
+	node := requestvalidation.BindnodeRegistry.TypeToNode(&requestvalidation.StorageDataTransferVoucher{Proposal: proposal})
+	voucher := datatransfer.TypedVoucher{Voucher: node, Type: requestvalidation.StorageDataTransferVoucherType}
+
+	chid := datatransfer.ChannelID{Initiator: peer.ID("client"), Responder: peer.ID("provider"), ID: 1}
+	event := func(code datatransfer.EventCode, received uint64, blocks int64, complete bool) {
+		tr.OnDataTransferEvent(datatransfer.Event{Code: code}, voucherState{testutil.NewMockChannelState(testutil.MockChannelStateParams{
+			ChannelID:         chid,
+			Received:          received,
+			ReceivedCidsTotal: blocks,
+			Complete:          complete,
+		}), voucher})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	updates := tr.Subscribe(ctx)
+
+	// transfers of other vouchers are ignored
+	tr.OnDataTransferEvent(datatransfer.Event{Code: datatransfer.Open}, testutil.NewMockChannelState(testutil.MockChannelStateParams{
+		ChannelID: datatransfer.ChannelID{Initiator: peer.ID("other"), ID: 2},
+	}))
+
+	event(datatransfer.Open, 0, 0, false)
+	st := <-updates
+	require.Equal(t, proposal, st.ProposalCid)
+
+	// 60 MiB received over the last minute
+	for i := 1; i <= 6; i++ {
+		clk.Add(10 * time.Second)
+		event(datatransfer.DataReceivedProgress, uint64(i)*10<<20, int64(i)*40, false)
+	}
+	st, ok := tr.Status(proposal)
+	require.True(t, ok)
+	require.Equal(t, uint64(60<<20), st.BytesReceived)
+	require.Equal(t, int64(240), st.BlocksVerified)
+	require.Equal(t, uint64(1<<20), st.Throughput)
+	require.False(t, st.Stalled)
+	require.Len(t, updates, 6)
+
+	// updates are sent at most once a second
+	event(datatransfer.DataReceivedProgress, 61<<20, 241, false)
+	require.Len(t, updates, 6)
+
+	// no data for a while
+	clk.Add(2 * time.Minute)
+	st, _ = tr.Status(proposal)
+	require.Zero(t, st.Throughput)
+	require.False(t, st.Stalled)
+
+	clk.Add(3 * time.Minute)
+	st, _ = tr.Status(proposal)
+	require.True(t, st.Stalled)
+
+	event(datatransfer.Complete, 61<<20, 241, true)
+	st, ok = tr.Status(proposal)
+	require.True(t, ok)
+	require.True(t, st.Finished)
+	require.False(t, st.Stalled)
+	require.Equal(t, uint64(61<<20), st.BytesReceived)
+
+	cancel()
+	// the channel is closed once the subscriber goes away
+	require.Eventually(t, func() bool {
+		select {
+		case _, ok := <-updates:
+			return !ok
+		default:
+			return false
+		}
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,319 @@
+// Package dtrestart restarts market data transfers which stopped making
+// progress.
+package dtrestart
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	datatransfer "github.com/filecoin-project/go-data-transfer/v2"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+var log = logging.Logger("dtrestart")
+
+// finishedHistory is the number of finished transfers with restarts for which
+// the restart history is kept.
+const finishedHistory = 256
+
+type Config struct {
+	// StallTimeout is how long a transfer can go without progress before it
+	// is restarted.
+	StallTimeout time.Duration
+	// InitialBackoff is the time to wait after a restart before restarting
+	// the transfer again if it still makes no progress. It doubles with each
+	// restart, up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// MaxRestarts is the number of times a transfer is restarted without
+	// making progress before giving up on it. Zero means no limit.
+	MaxRestarts int
+}
+
+type transfer struct {
+	chid   datatransfer.ChannelID
+	status datatransfer.Status
+	paused bool
+
+	lastProgress time.Time
+	nextRestart  time.Time
+	backoff      time.Duration
+	// restarts since the transfer last made progress
+	attempts int
+	gaveUp   bool
+
+	restarts []api.DataTransferRestart
+}
+
+// Monitor tracks the progress of data transfers through data transfer events,
+// and restarts the transfers which stall.
+type Monitor struct {
+	dt      datatransfer.Manager
+	cfg     Config
+	now     func() time.Time
+	restart func(context.Context, datatransfer.ChannelID) error
+
+	lk        sync.Mutex
+	transfers map[datatransfer.ChannelID]*transfer
+	finished  []*transfer
+
+	closing chan struct{}
+	closed  chan struct{}
+}
+
+// New creates a monitor restarting stalled transfers of the data transfer
+// manager. It must be started with Start.
+func New(cfg Config, dt datatransfer.Manager) (*Monitor, error) {
+	if cfg.StallTimeout <= 0 {
+		return nil, xerrors.New("stall timeout must be positive")
+	}
+	if cfg.InitialBackoff <= 0 {
+		return nil, xerrors.New("initial backoff must be positive")
+	}
+	if cfg.MaxBackoff < cfg.InitialBackoff {
+		cfg.MaxBackoff = cfg.InitialBackoff
+	}
+
+	m := newMonitor(cfg, dt.RestartDataTransferChannel, time.Now)
+	m.dt = dt
+	return m, nil
+}
+
+func newMonitor(cfg Config, restart func(context.Context, datatransfer.ChannelID) error, now func() time.Time) *Monitor {
+	return &Monitor{
+		cfg:       cfg,
+		now:       now,
+		restart:   restart,
+		transfers: map[datatransfer.ChannelID]*transfer{},
+		closing:   make(chan struct{}),
+		closed:    make(chan struct{}),
+	}
+}
+
+// Start begins tracking the transfers in progress, and those opened later.
+func (m *Monitor) Start(ctx context.Context) error {
+	unsub := m.dt.SubscribeToEvents(m.OnDataTransferEvent)
+
+	channels, err := m.dt.InProgressChannels(ctx)
+	if err != nil {
+		unsub()
+		return xerrors.Errorf("listing data transfers in progress: %w", err)
+	}
+	for _, state := range channels {
+		m.track(state)
+	}
+
+	go m.run(unsub)
+	return nil
+}
+
+func (m *Monitor) Stop(ctx context.Context) error {
+	close(m.closing)
+
+	select {
+	case <-m.closed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m *Monitor) run(unsub datatransfer.Unsubscribe) {
+	defer close(m.closed)
+	defer unsub()
+
+	// check a few times per stall timeout, so that transfers are restarted
+	// soon after stalling
+	interval := m.cfg.StallTimeout / 4
+	if interval > m.cfg.InitialBackoff {
+		interval = m.cfg.InitialBackoff
+	}
+	if interval < time.Second {
+		interval = time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-m.closing
+		cancel()
+	}()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.check(ctx)
+		case <-m.closing:
+			return
+		}
+	}
+}
+
+// OnDataTransferEvent is a data transfer subscriber which records the progress
+// of each transfer.
+func (m *Monitor) OnDataTransferEvent(event datatransfer.Event, state datatransfer.ChannelState) {
+	progress := false
+	switch event.Code {
+	case datatransfer.DataSent, datatransfer.DataSentProgress,
+		datatransfer.DataReceived, datatransfer.DataReceivedProgress,
+		datatransfer.DataQueued, datatransfer.DataQueuedProgress:
+		progress = true
+	}
+
+	m.update(state.ChannelID(), state.Status(), state.InitiatorPaused() || state.ResponderPaused(), progress)
+}
+
+func (m *Monitor) track(state datatransfer.ChannelState) {
+	m.update(state.ChannelID(), state.Status(), state.InitiatorPaused() || state.ResponderPaused(), false)
+}
+
+func (m *Monitor) update(chid datatransfer.ChannelID, status datatransfer.Status, paused, progress bool) {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	now := m.now()
+
+	t, ok := m.transfers[chid]
+	if !ok {
+		if status.TransferComplete() {
+			return
+		}
+
+		t = &transfer{
+			chid:         chid,
+			lastProgress: now,
+			backoff:      m.cfg.InitialBackoff,
+		}
+		m.transfers[chid] = t
+	}
+
+	// paused transfers, e.g. waiting for a payment, aren't stalled; the
+	// stall timeout runs again from when they resume
+	if progress || (t.paused && !paused) {
+		t.lastProgress = now
+		t.nextRestart = time.Time{}
+		t.backoff = m.cfg.InitialBackoff
+		t.attempts = 0
+		t.gaveUp = false
+	}
+	t.status = status
+	t.paused = paused
+
+	if status.TransferComplete() {
+		delete(m.transfers, chid)
+		if len(t.restarts) > 0 {
+			m.finished = append(m.finished, t)
+			if len(m.finished) > finishedHistory {
+				m.finished = m.finished[len(m.finished)-finishedHistory:]
+			}
+		}
+	}
+}
+
+// check restarts the transfers which made no progress for the stall timeout,
+// and whose backoff since the last restart expired.
+func (m *Monitor) check(ctx context.Context) {
+	now := m.now()
+
+	type pending struct {
+		chid    datatransfer.ChannelID
+		attempt int
+		restart api.DataTransferRestart
+		t       *transfer
+	}
+
+	var restart []pending
+	m.lk.Lock()
+	for _, t := range m.transfers {
+		if t.paused || t.gaveUp || now.Sub(t.lastProgress) < m.cfg.StallTimeout {
+			continue
+		}
+		if now.Before(t.nextRestart) {
+			continue
+		}
+		if m.cfg.MaxRestarts > 0 && t.attempts >= m.cfg.MaxRestarts {
+			log.Warnw("data transfer still stalled after restarts, giving up", "channel", t.chid, "restarts", t.attempts, "lastProgress", t.lastProgress)
+			t.gaveUp = true
+			continue
+		}
+
+		t.attempts++
+		t.nextRestart = now.Add(t.backoff)
+		t.backoff *= 2
+		if t.backoff > m.cfg.MaxBackoff {
+			t.backoff = m.cfg.MaxBackoff
+		}
+		restart = append(restart, pending{
+			chid:    t.chid,
+			attempt: t.attempts,
+			restart: api.DataTransferRestart{
+				Time:       now,
+				StalledFor: now.Sub(t.lastProgress),
+			},
+			t: t,
+		})
+	}
+	m.lk.Unlock()
+
+	for _, p := range restart {
+		log.Infow("restarting stalled data transfer", "channel", p.chid, "stalledFor", p.restart.StalledFor, "attempt", p.attempt)
+
+		if err := m.restart(ctx, p.chid); err != nil {
+			log.Warnw("restarting stalled data transfer", "channel", p.chid, "error", err)
+			p.restart.Error = err.Error()
+		}
+
+		m.lk.Lock()
+		p.t.restarts = append(p.t.restarts, p.restart)
+		m.lk.Unlock()
+	}
+}
+
+// History returns the restart history of the transfers in progress which were
+// restarted, and of the most recently finished ones, oldest first.
+func (m *Monitor) History() []api.DataTransferRestartHistory {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	out := make([]api.DataTransferRestartHistory, 0, len(m.finished))
+	for _, t := range m.finished {
+		out = append(out, t.history(true))
+	}
+
+	var active []api.DataTransferRestartHistory
+	for _, t := range m.transfers {
+		if len(t.restarts) == 0 {
+			continue
+		}
+		active = append(active, t.history(false))
+	}
+	sort.Slice(active, func(i, j int) bool {
+		return active[i].Restarts[0].Time.Before(active[j].Restarts[0].Time)
+	})
+
+	return append(out, active...)
+}
+
+func (t *transfer) history(finished bool) api.DataTransferRestartHistory {
+	h := api.DataTransferRestartHistory{
+		ChannelID:    t.chid,
+		Status:       t.status,
+		LastProgress: t.lastProgress,
+		GaveUp:       t.gaveUp,
+		Finished:     finished,
+		Restarts:     append([]api.DataTransferRestart{}, t.restarts...),
+	}
+	if !finished && !t.gaveUp && t.attempts > 0 {
+		h.NextRestart = t.nextRestart
+	}
+	return h
+}
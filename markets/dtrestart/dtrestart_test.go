@@ -0,0 +1,138 @@
+// stm: #unit
+package dtrestart
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/raulk/clock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	datatransfer "github.com/filecoin-project/go-data-transfer/v2"
+	"github.com/filecoin-project/go-data-transfer/v2/testutil"
+)
+
+func TestRestartStalled(t *testing.T) {
+	clk := clock.NewMock()
+
+	var restarted []datatransfer.ChannelID
+	var restartErr error
+	m := newMonitor(Config{
+		StallTimeout:   10 * time.Minute,
+		InitialBackoff: time.Minute,
+		MaxBackoff:     3 * time.Minute,
+		MaxRestarts:    3,
+	}, func(ctx context.Context, chid datatransfer.ChannelID) error {
+		restarted = append(restarted, chid)
+		return restartErr
+	}, clk.Now)
+
+	chid := datatransfer.ChannelID{Initiator: peer.ID("client"), Responder: peer.ID("provider"), ID: 1}
+	state := testutil.NewMockChannelState(testutil.MockChannelStateParams{ChannelID: chid})
+	ctx := context.Background()
+
+	m.OnDataTransferEvent(datatransfer.Event{Code: datatransfer.Open}, state)
+
+	// not stalled yet
+	clk.Add(9 * time.Minute)
+	m.check(ctx)
+	require.Empty(t, restarted)
+	require.Empty(t, m.History())
+
+	// stalled, restarted with backoffs of 1, 2 and 3 minutes
+	clk.Add(time.Minute)
+	m.check(ctx)
+	require.Len(t, restarted, 1)
+
+	clk.Add(30 * time.Second)
+	m.check(ctx)
+	require.Len(t, restarted, 1)
+
+	clk.Add(30 * time.Second)
+	restartErr = xerrors.New("peer unreachable")
+	m.check(ctx)
+	require.Len(t, restarted, 2)
+
+	h := m.History()
+	require.Len(t, h, 1)
+	require.Equal(t, chid, h[0].ChannelID)
+	require.False(t, h[0].Finished)
+	require.Len(t, h[0].Restarts, 2)
+	require.Equal(t, 10*time.Minute, h[0].Restarts[0].StalledFor)
+	require.Empty(t, h[0].Restarts[0].Error)
+	require.Equal(t, "peer unreachable", h[0].Restarts[1].Error)
+	require.True(t, clk.Now().Add(2*time.Minute).Equal(h[0].NextRestart))
+
+	clk.Add(2 * time.Minute)
+	m.check(ctx)
+	require.Len(t, restarted, 3)
+
+	// gives up after MaxRestarts
+	clk.Add(time.Hour)
+	m.check(ctx)
+	require.Len(t, restarted, 3)
+	h = m.History()
+	require.True(t, h[0].GaveUp)
+	require.True(t, h[0].NextRestart.IsZero())
+
+	// progress resets the backoff and restart count
+	m.OnDataTransferEvent(datatransfer.Event{Code: datatransfer.DataReceived}, state)
+	h = m.History()
+	require.False(t, h[0].GaveUp)
+	require.True(t, clk.Now().Equal(h[0].LastProgress))
+
+	clk.Add(10 * time.Minute)
+	m.check(ctx)
+	require.Len(t, restarted, 4)
+	require.Len(t, m.History()[0].Restarts, 4)
+
+	// finished transfers keep their history
+	done := testutil.NewMockChannelState(testutil.MockChannelStateParams{ChannelID: chid, Complete: true})
+	m.OnDataTransferEvent(datatransfer.Event{Code: datatransfer.Complete}, done)
+	h = m.History()
+	require.Len(t, h, 1)
+	require.True(t, h[0].Finished)
+	require.Equal(t, datatransfer.Completed, h[0].Status)
+
+	clk.Add(time.Hour)
+	m.check(ctx)
+	require.Len(t, restarted, 4)
+}
+
+func TestPausedNotStalled(t *testing.T) {
+	clk := clock.NewMock()
+
+	restarts := 0
+	m := newMonitor(Config{
+		StallTimeout:   10 * time.Minute,
+		InitialBackoff: time.Minute,
+		MaxBackoff:     time.Minute,
+	}, func(ctx context.Context, chid datatransfer.ChannelID) error {
+		restarts++
+		return nil
+	}, clk.Now)
+
+	chid := datatransfer.ChannelID{Initiator: peer.ID("client"), Responder: peer.ID("provider"), ID: 2}
+	paused := testutil.NewMockChannelState(testutil.MockChannelStateParams{ChannelID: chid, ResponderPaused: true})
+	resumed := testutil.NewMockChannelState(testutil.MockChannelStateParams{ChannelID: chid})
+	ctx := context.Background()
+
+	m.OnDataTransferEvent(datatransfer.Event{Code: datatransfer.PauseResponder}, paused)
+
+	clk.Add(time.Hour)
+	m.check(ctx)
+	require.Zero(t, restarts)
+
+	// the stall timeout starts when the transfer resumes
+	m.OnDataTransferEvent(datatransfer.Event{Code: datatransfer.ResumeResponder}, resumed)
+	clk.Add(5 * time.Minute)
+	m.check(ctx)
+	require.Zero(t, restarts)
+
+	clk.Add(5 * time.Minute)
+	m.check(ctx)
+	require.Equal(t, 1, restarts)
+}
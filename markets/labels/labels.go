@@ -0,0 +1,163 @@
+package labels
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// MaxLabels is the maximum number of labels of a piece or a deal
+	MaxLabels = 64
+	// MaxLabelLength is the maximum length of the keys and values of labels
+	MaxLabelLength = 256
+)
+
+// Store keeps user-defined labels on pieces and deals, identified by the piece
+// CID or the deal proposal CID.
+type Store struct {
+	ds datastore.Batching
+
+	lk sync.Mutex
+}
+
+func NewStore(ds datastore.Batching) *Store {
+	return &Store{ds: ds}
+}
+
+func targetKey(target cid.Cid) datastore.Key {
+	return datastore.NewKey(target.String())
+}
+
+// Set merges labels into the labels of the target. Labels with an empty value
+// are removed.
+func (s *Store) Set(ctx context.Context, target cid.Cid, labels map[string]string) error {
+	if !target.Defined() {
+		return xerrors.Errorf("undefined label target")
+	}
+	for k, v := range labels {
+		if k == "" {
+			return xerrors.Errorf("empty label key")
+		}
+		if len(k) > MaxLabelLength || len(v) > MaxLabelLength {
+			return xerrors.Errorf("label %q longer than %d bytes", k, MaxLabelLength)
+		}
+	}
+
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	cur, err := s.get(ctx, target)
+	if err != nil {
+		return err
+	}
+	for k, v := range labels {
+		if v == "" {
+			delete(cur, k)
+			continue
+		}
+		cur[k] = v
+	}
+
+	if len(cur) == 0 {
+		if err := s.ds.Delete(ctx, targetKey(target)); err != nil {
+			return xerrors.Errorf("deleting labels of %s: %w", target, err)
+		}
+		return nil
+	}
+	if len(cur) > MaxLabels {
+		return xerrors.Errorf("%s would have %d labels, more than the maximum of %d", target, len(cur), MaxLabels)
+	}
+
+	b, err := json.Marshal(cur)
+	if err != nil {
+		return xerrors.Errorf("encoding labels: %w", err)
+	}
+	if err := s.ds.Put(ctx, targetKey(target), b); err != nil {
+		return xerrors.Errorf("saving labels of %s: %w", target, err)
+	}
+	return nil
+}
+
+// Get returns the labels of the target, empty when it has none.
+func (s *Store) Get(ctx context.Context, target cid.Cid) (map[string]string, error) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	return s.get(ctx, target)
+}
+
+func (s *Store) get(ctx context.Context, target cid.Cid) (map[string]string, error) {
+	out := map[string]string{}
+
+	b, err := s.ds.Get(ctx, targetKey(target))
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(b, &out); err != nil {
+			return nil, xerrors.Errorf("decoding labels of %s: %w", target, err)
+		}
+	case xerrors.Is(err, datastore.ErrNotFound):
+	default:
+		return nil, xerrors.Errorf("getting labels of %s: %w", target, err)
+	}
+	return out, nil
+}
+
+// List returns the labels of all the labeled pieces and deals.
+func (s *Store) List(ctx context.Context) (map[cid.Cid]map[string]string, error) {
+	res, err := s.ds.Query(ctx, query.Query{})
+	if err != nil {
+		return nil, xerrors.Errorf("querying labels: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	out := map[cid.Cid]map[string]string{}
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, xerrors.Errorf("iterating labels: %w", r.Error)
+		}
+
+		target, err := cid.Parse(strings.TrimPrefix(r.Key, "/"))
+		if err != nil {
+			return nil, xerrors.Errorf("parsing label target %q: %w", r.Key, err)
+		}
+
+		var labels map[string]string
+		if err := json.Unmarshal(r.Value, &labels); err != nil {
+			return nil, xerrors.Errorf("decoding labels of %s: %w", target, err)
+		}
+		out[target] = labels
+	}
+	return out, nil
+}
+
+// Merge returns the labels of a deal: the labels of its piece, overridden by
+// the labels of the deal itself.
+func Merge(piece, deal map[string]string) map[string]string {
+	out := make(map[string]string, len(piece)+len(deal))
+	for k, v := range piece {
+		out[k] = v
+	}
+	for k, v := range deal {
+		out[k] = v
+	}
+	return out
+}
+
+// Match returns whether the labels contain all the labels of the filter. A
+// filter label with an empty value matches any value.
+func Match(labels, filter map[string]string) bool {
+	for k, v := range filter {
+		lv, ok := labels[k]
+		if !ok || (v != "" && lv != v) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,58 @@
+// stm: #unit
+package labels
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewStore(dssync.MutexWrap(datastore.NewMapDatastore()))
+
+	piece := cid.MustParse("baga6ea4seaqao7s73y24kcutaosvacpdjgfe5pw76ooefnyqw4ynr3d2y6x2mpq")
+	deal := cid.MustParse("bafyreicmaj5hhoy5mgqvamfhgexxyergw7hdeshizghodwkjg6qmpoco7i")
+
+	require.NoError(t, s.Set(ctx, piece, map[string]string{"customer": "acme", "dataset": "weather"}))
+	require.NoError(t, s.Set(ctx, deal, map[string]string{"customer": "globex"}))
+
+	l, err := s.Get(ctx, piece)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"customer": "acme", "dataset": "weather"}, l)
+
+	// empty values remove labels
+	require.NoError(t, s.Set(ctx, piece, map[string]string{"dataset": "", "tier": "hot"}))
+	l, err = s.Get(ctx, piece)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"customer": "acme", "tier": "hot"}, l)
+
+	all, err := s.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	require.Equal(t, map[string]string{"customer": "globex"}, all[deal])
+
+	require.NoError(t, s.Set(ctx, deal, map[string]string{"customer": ""}))
+	all, err = s.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+
+	require.Error(t, s.Set(ctx, piece, map[string]string{"": "x"}))
+	require.Error(t, s.Set(ctx, piece, map[string]string{"k": strings.Repeat("x", MaxLabelLength+1)}))
+}
+
+func TestMatch(t *testing.T) {
+	labels := Merge(map[string]string{"customer": "acme", "tier": "hot"}, map[string]string{"customer": "globex"})
+	require.Equal(t, map[string]string{"customer": "globex", "tier": "hot"}, labels)
+
+	require.True(t, Match(labels, nil))
+	require.True(t, Match(labels, map[string]string{"customer": "globex"}))
+	require.True(t, Match(labels, map[string]string{"tier": ""}))
+	require.False(t, Match(labels, map[string]string{"customer": "acme"}))
+	require.False(t, Match(labels, map[string]string{"dataset": ""}))
+}
@@ -0,0 +1,348 @@
+// Package reputation keeps a local record of the storage providers a client
+// works with: tags set by the user, e.g. the region of a provider, and the
+// outcome of the deals and retrievals made with each provider.
+package reputation
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	rm "github.com/filecoin-project/go-fil-markets/retrievalmarket"
+	"github.com/filecoin-project/go-fil-markets/storagemarket"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+var log = logging.Logger("reputation")
+
+// Store persists provider records in a datastore.
+type Store struct {
+	ds  datastore.Batching
+	now func() time.Time
+
+	lk sync.Mutex
+	// deal and retrieval outcomes already recorded
+	recorded map[string]struct{}
+	// when in-progress retrievals were first seen
+	retrievalStart map[rm.DealID]time.Time
+}
+
+func New(ds datastore.Batching) *Store {
+	return newStore(ds, time.Now)
+}
+
+func newStore(ds datastore.Batching, now func() time.Time) *Store {
+	return &Store{
+		ds:             ds,
+		now:            now,
+		recorded:       map[string]struct{}{},
+		retrievalStart: map[rm.DealID]time.Time{},
+	}
+}
+
+// Get returns the record of a provider, an empty record if there is none.
+func (s *Store) Get(ctx context.Context, miner address.Address) (api.ProviderReputation, error) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	return s.get(ctx, miner)
+}
+
+func (s *Store) get(ctx context.Context, miner address.Address) (api.ProviderReputation, error) {
+	b, err := s.ds.Get(ctx, datastore.NewKey(miner.String()))
+	if err == datastore.ErrNotFound {
+		return api.ProviderReputation{Miner: miner}, nil
+	}
+	if err != nil {
+		return api.ProviderReputation{}, xerrors.Errorf("getting provider record: %w", err)
+	}
+
+	var rec api.ProviderReputation
+	if err := json.Unmarshal(b, &rec); err != nil {
+		return api.ProviderReputation{}, xerrors.Errorf("decoding provider record: %w", err)
+	}
+	return rec, nil
+}
+
+func (s *Store) put(ctx context.Context, rec api.ProviderReputation) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return xerrors.Errorf("encoding provider record: %w", err)
+	}
+	if err := s.ds.Put(ctx, datastore.NewKey(rec.Miner.String()), b); err != nil {
+		return xerrors.Errorf("storing provider record: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) update(ctx context.Context, miner address.Address, cb func(*api.ProviderReputation)) error {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	rec, err := s.get(ctx, miner)
+	if err != nil {
+		return err
+	}
+	cb(&rec)
+	return s.put(ctx, rec)
+}
+
+// List returns the records of all known providers, ordered by address.
+func (s *Store) List(ctx context.Context) ([]api.ProviderReputation, error) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	res, err := s.ds.Query(ctx, query.Query{})
+	if err != nil {
+		return nil, xerrors.Errorf("listing provider records: %w", err)
+	}
+	defer res.Close() // nolint:errcheck
+
+	out := []api.ProviderReputation{}
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, xerrors.Errorf("listing provider records: %w", r.Error)
+		}
+
+		var rec api.ProviderReputation
+		if err := json.Unmarshal(r.Value, &rec); err != nil {
+			return nil, xerrors.Errorf("decoding provider record %s: %w", r.Key, err)
+		}
+		out = append(out, rec)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Miner.String() < out[j].Miner.String()
+	})
+	return out, nil
+}
+
+// SetTags replaces the tags of a provider.
+func (s *Store) SetTags(ctx context.Context, miner address.Address, tags []string) error {
+	return s.update(ctx, miner, func(rec *api.ProviderReputation) {
+		rec.Tags = append([]string{}, tags...)
+		sort.Strings(rec.Tags)
+	})
+}
+
+// Import imports records, e.g. exported by another node. The outcomes of the
+// imported records are added to the existing records and their tags merged,
+// unless replace is set.
+func (s *Store) Import(ctx context.Context, recs []api.ProviderReputation, replace bool) error {
+	for _, in := range recs {
+		if in.Miner == address.Undef {
+			return xerrors.Errorf("provider record without an address")
+		}
+
+		err := s.update(ctx, in.Miner, func(rec *api.ProviderReputation) {
+			if replace {
+				*rec = in
+			} else {
+				merge(rec, in)
+			}
+			rec.Tags = dedupTags(rec.Tags)
+		})
+		if err != nil {
+			return xerrors.Errorf("importing record of %s: %w", in.Miner, err)
+		}
+	}
+	return nil
+}
+
+func merge(rec *api.ProviderReputation, in api.ProviderReputation) {
+	rec.Tags = append(rec.Tags, in.Tags...)
+
+	rec.DealsAccepted += in.DealsAccepted
+	rec.DealsRejected += in.DealsRejected
+	rec.AcceptanceLatency += in.AcceptanceLatency
+	rec.SealingSuccesses += in.SealingSuccesses
+	rec.SealingFailures += in.SealingFailures
+	if in.LastDeal.After(rec.LastDeal) {
+		rec.LastDeal = in.LastDeal
+	}
+
+	rec.RetrievalSuccesses += in.RetrievalSuccesses
+	rec.RetrievalFailures += in.RetrievalFailures
+	rec.RetrievalBytes += in.RetrievalBytes
+	rec.RetrievalDuration += in.RetrievalDuration
+	if in.LastRetrieval.After(rec.LastRetrieval) {
+		rec.LastRetrieval = in.LastRetrieval
+	}
+}
+
+func dedupTags(tags []string) []string {
+	sort.Strings(tags)
+	out := tags[:0]
+	for i, t := range tags {
+		if i > 0 && t == tags[i-1] {
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+// once returns true the first time it's called with a key.
+func (s *Store) once(key string) bool {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	if _, ok := s.recorded[key]; ok {
+		return false
+	}
+	s.recorded[key] = struct{}{}
+	return true
+}
+
+// OnStorageClientEvent is a storage client subscriber which records whether
+// providers accept deals, how long they take to, and whether the accepted
+// deals are activated.
+func (s *Store) OnStorageClientEvent(event storagemarket.ClientEvent, deal storagemarket.ClientDeal) {
+	miner := deal.Proposal.Provider
+	if miner == address.Undef {
+		return
+	}
+
+	var outcome string
+	var cb func(*api.ProviderReputation)
+	switch {
+	case event == storagemarket.ClientEventDealAccepted:
+		outcome = "accepted"
+		latency := s.now().Sub(deal.CreationTime.Time())
+		cb = func(rec *api.ProviderReputation) {
+			rec.DealsAccepted++
+			if latency > 0 {
+				rec.AcceptanceLatency += latency
+			}
+		}
+	case event == storagemarket.ClientEventDealRejected:
+		outcome = "rejected"
+		cb = func(rec *api.ProviderReputation) {
+			rec.DealsRejected++
+		}
+	case deal.State == storagemarket.StorageDealActive:
+		outcome = "active"
+		cb = func(rec *api.ProviderReputation) {
+			rec.SealingSuccesses++
+		}
+	case deal.State == storagemarket.StorageDealError && deal.PublishMessage != nil:
+		// only count the deals which failed once accepted by the provider
+		outcome = "failed"
+		cb = func(rec *api.ProviderReputation) {
+			rec.SealingFailures++
+		}
+	default:
+		return
+	}
+
+	if !s.once(deal.ProposalCid.String() + "/" + outcome) {
+		return
+	}
+
+	err := s.update(context.TODO(), miner, func(rec *api.ProviderReputation) {
+		cb(rec)
+		rec.LastDeal = s.now()
+	})
+	if err != nil {
+		log.Errorw("recording deal outcome", "proposal", deal.ProposalCid, "miner", miner, "error", err)
+	}
+}
+
+// OnRetrievalClientEvent is a retrieval client subscriber which records the
+// outcome of retrievals once they reach a terminal state, and the speed of
+// the successful ones. Retrievals cancelled by the client aren't counted.
+func (s *Store) OnRetrievalClientEvent(event rm.ClientEvent, deal rm.ClientDealState) {
+	now := s.now()
+
+	s.lk.Lock()
+	start, ok := s.retrievalStart[deal.ID]
+	if !ok {
+		start = now
+		s.retrievalStart[deal.ID] = now
+	}
+	s.lk.Unlock()
+
+	var success bool
+	switch deal.Status {
+	case rm.DealStatusCompleted:
+		success = true
+	case rm.DealStatusErrored, rm.DealStatusRejected, rm.DealStatusDealNotFound:
+	case rm.DealStatusCancelled:
+		s.lk.Lock()
+		delete(s.retrievalStart, deal.ID)
+		s.lk.Unlock()
+		return
+	default:
+		return
+	}
+
+	s.lk.Lock()
+	delete(s.retrievalStart, deal.ID)
+	s.lk.Unlock()
+
+	if deal.MinerWallet == address.Undef || !s.once("retrieval/"+deal.ID.String()) {
+		return
+	}
+
+	err := s.update(context.TODO(), deal.MinerWallet, func(rec *api.ProviderReputation) {
+		if success {
+			rec.RetrievalSuccesses++
+			// the start of retrievals first seen once finished is unknown
+			if ok {
+				rec.RetrievalBytes += deal.TotalReceived
+				rec.RetrievalDuration += now.Sub(start)
+			}
+		} else {
+			rec.RetrievalFailures++
+		}
+		rec.LastRetrieval = now
+	})
+	if err != nil {
+		log.Errorw("recording retrieval outcome", "deal", deal.ID, "miner", deal.MinerWallet, "error", err)
+	}
+}
+
+// SuccessRate returns the fraction of retrievals from the provider which
+// succeeded, and false if no retrieval was made from it.
+func SuccessRate(rec api.ProviderReputation) (float64, bool) {
+	total := rec.RetrievalSuccesses + rec.RetrievalFailures
+	if total == 0 {
+		return 0, false
+	}
+	return float64(rec.RetrievalSuccesses) / float64(total), true
+}
+
+// smoothedRate is the success rate with one success and one failure added,
+// so that a few outcomes don't rate a provider at either extreme.
+func smoothedRate(successes, failures uint64) float64 {
+	return float64(successes+1) / float64(successes+failures+2)
+}
+
+// Score scores a provider from its record, see api.ProviderScore.
+func Score(rec api.ProviderReputation) api.ProviderScore {
+	sc := api.ProviderScore{
+		Miner:                rec.Miner,
+		AcceptanceRate:       smoothedRate(rec.DealsAccepted, rec.DealsRejected),
+		SealingSuccessRate:   smoothedRate(rec.SealingSuccesses, rec.SealingFailures),
+		RetrievalSuccessRate: smoothedRate(rec.RetrievalSuccesses, rec.RetrievalFailures),
+	}
+	sc.Score = 0.2*sc.AcceptanceRate + 0.4*sc.SealingSuccessRate + 0.4*sc.RetrievalSuccessRate
+
+	if rec.DealsAccepted > 0 {
+		sc.AvgAcceptanceLatency = rec.AcceptanceLatency / time.Duration(rec.DealsAccepted)
+	}
+	if rec.RetrievalDuration > 0 {
+		sc.AvgRetrievalSpeed = float64(rec.RetrievalBytes) / rec.RetrievalDuration.Seconds()
+	}
+
+	return sc
+}
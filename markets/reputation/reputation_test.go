@@ -0,0 +1,162 @@
+// stm: #unit
+package reputation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/raulk/clock"
+	"github.com/stretchr/testify/require"
+	cbg "github.com/whyrusleeping/cbor-gen"
+
+	rm "github.com/filecoin-project/go-fil-markets/retrievalmarket"
+	tut "github.com/filecoin-project/go-fil-markets/shared_testutil"
+	"github.com/filecoin-project/go-fil-markets/storagemarket"
+	tutils "github.com/filecoin-project/specs-actors/v5/support/testing"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+func TestRetrievalOutcomes(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewMock()
+	s := newStore(dssync.MutexWrap(datastore.NewMapDatastore()), clk.Now)
+
+	m1, m2 := tutils.NewIDAddr(t, 1000), tutils.NewIDAddr(t, 1001)
+
+	rec, err := s.Get(ctx, m1)
+	require.NoError(t, err)
+	require.Equal(t, m1, rec.Miner)
+	_, ok := SuccessRate(rec)
+	require.False(t, ok)
+
+	require.NoError(t, s.SetTags(ctx, m2, []string{"us-east", "fast"}))
+
+	deal := rm.ClientDealState{MinerWallet: m1, Status: rm.DealStatusOngoing}
+	deal.ID = 1
+	s.OnRetrievalClientEvent(rm.ClientEventOpen, deal)
+
+	clk.Add(10 * time.Second)
+	deal.Status = rm.DealStatusCompleted
+	deal.TotalReceived = 1000
+	s.OnRetrievalClientEvent(rm.ClientEventComplete, deal)
+	// terminal states are only counted once per deal
+	s.OnRetrievalClientEvent(rm.ClientEventComplete, deal)
+
+	failed := rm.ClientDealState{MinerWallet: m1, Status: rm.DealStatusErrored}
+	failed.ID = 2
+	s.OnRetrievalClientEvent(rm.ClientEventDataTransferError, failed)
+
+	cancelled := rm.ClientDealState{MinerWallet: m1, Status: rm.DealStatusCancelled}
+	cancelled.ID = 3
+	s.OnRetrievalClientEvent(rm.ClientEventCancelComplete, cancelled)
+
+	recs, err := s.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, recs, 2)
+
+	require.Equal(t, m1, recs[0].Miner)
+	require.Equal(t, uint64(1), recs[0].RetrievalSuccesses)
+	require.Equal(t, uint64(1), recs[0].RetrievalFailures)
+	require.Equal(t, uint64(1000), recs[0].RetrievalBytes)
+	require.Equal(t, 10*time.Second, recs[0].RetrievalDuration)
+	require.True(t, clk.Now().Equal(recs[0].LastRetrieval))
+	rate, ok := SuccessRate(recs[0])
+	require.True(t, ok)
+	require.Equal(t, 0.5, rate)
+	require.Equal(t, float64(100), Score(recs[0]).AvgRetrievalSpeed)
+
+	require.Equal(t, m2, recs[1].Miner)
+	require.Equal(t, []string{"fast", "us-east"}, recs[1].Tags)
+}
+
+func TestDealOutcomes(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewMock()
+	s := newStore(dssync.MutexWrap(datastore.NewMapDatastore()), clk.Now)
+
+	m := tutils.NewIDAddr(t, 1000)
+	deal := func(state storagemarket.StorageDealStatus) storagemarket.ClientDeal {
+		d := storagemarket.ClientDeal{
+			ProposalCid:  tut.GenerateCids(1)[0],
+			State:        state,
+			CreationTime: cbg.CborTime(clk.Now().Add(-time.Minute)),
+		}
+		d.Proposal.Provider = m
+		return d
+	}
+
+	// accepted after a minute, then activated
+	d1 := deal(storagemarket.StorageDealProposalAccepted)
+	s.OnStorageClientEvent(storagemarket.ClientEventDealAccepted, d1)
+	pub := tut.GenerateCids(1)[0]
+	d1.PublishMessage = &pub
+	d1.State = storagemarket.StorageDealActive
+	s.OnStorageClientEvent(storagemarket.ClientEventDealActivated, d1)
+	s.OnStorageClientEvent(storagemarket.ClientEventDealActivated, d1)
+
+	// accepted, then failed
+	d2 := deal(storagemarket.StorageDealProposalAccepted)
+	s.OnStorageClientEvent(storagemarket.ClientEventDealAccepted, d2)
+	d2.PublishMessage = &pub
+	d2.State = storagemarket.StorageDealError
+	s.OnStorageClientEvent(storagemarket.ClientEventFailed, d2)
+
+	// rejected, the failure isn't a sealing failure
+	d3 := deal(storagemarket.StorageDealProposalRejected)
+	s.OnStorageClientEvent(storagemarket.ClientEventDealRejected, d3)
+	d3.State = storagemarket.StorageDealError
+	s.OnStorageClientEvent(storagemarket.ClientEventFailed, d3)
+
+	rec, err := s.Get(ctx, m)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), rec.DealsAccepted)
+	require.Equal(t, uint64(1), rec.DealsRejected)
+	require.Equal(t, 2*time.Minute, rec.AcceptanceLatency)
+	require.Equal(t, uint64(1), rec.SealingSuccesses)
+	require.Equal(t, uint64(1), rec.SealingFailures)
+	require.True(t, clk.Now().Equal(rec.LastDeal))
+
+	sc := Score(rec)
+	require.Equal(t, time.Minute, sc.AvgAcceptanceLatency)
+	require.Equal(t, 0.6, sc.AcceptanceRate)
+	require.Equal(t, 0.5, sc.SealingSuccessRate)
+	require.Equal(t, 0.5, sc.RetrievalSuccessRate)
+	require.InDelta(t, 0.52, sc.Score, 1e-9)
+
+	// providers without outcomes score in the middle
+	require.InDelta(t, 0.5, Score(api.ProviderReputation{Miner: m}).Score, 1e-9)
+}
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+	s := New(dssync.MutexWrap(datastore.NewMapDatastore()))
+
+	m := tutils.NewIDAddr(t, 1000)
+	require.NoError(t, s.Import(ctx, []api.ProviderReputation{{
+		Miner:              m,
+		Tags:               []string{"eu"},
+		RetrievalSuccesses: 1,
+	}}, false))
+
+	exported := []api.ProviderReputation{{
+		Miner:             m,
+		Tags:              []string{"eu", "fast"},
+		RetrievalFailures: 1,
+	}}
+
+	require.NoError(t, s.Import(ctx, exported, false))
+	rec, err := s.Get(ctx, m)
+	require.NoError(t, err)
+	require.Equal(t, []string{"eu", "fast"}, rec.Tags)
+	require.Equal(t, uint64(1), rec.RetrievalSuccesses)
+	require.Equal(t, uint64(1), rec.RetrievalFailures)
+
+	require.NoError(t, s.Import(ctx, exported, true))
+	rec, err = s.Get(ctx, m)
+	require.NoError(t, err)
+	require.Equal(t, exported[0], rec)
+}
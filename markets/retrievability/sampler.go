@@ -0,0 +1,414 @@
+// Package retrievability periodically samples the deal data of the provider:
+// it reads random pieces of deal sectors through the DAG store, the path
+// retrievals take, and verifies the hashes of their blocks, so that data which
+// can't be retrieved is noticed before clients complain.
+package retrievability
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-fil-markets/piecestore"
+	"github.com/filecoin-project/go-fil-markets/stores"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/journal/alerting"
+)
+
+var log = logging.Logger("retrievability")
+
+// samplesKey is the datastore namespace of the samples.
+var samplesKey = datastore.NewKey("/retrievability/samples")
+
+// defaultHistory is the number of samples kept when not configured.
+const defaultHistory = 1000
+
+// PieceStore is the subset of the piece store used to pick the pieces.
+type PieceStore interface {
+	ListPieceInfoKeys() ([]cid.Cid, error)
+	GetPieceInfo(pieceCID cid.Cid) (piecestore.PieceInfo, error)
+}
+
+// ShardLoader loads the blockstore of a piece through the DAG store.
+type ShardLoader interface {
+	LoadShard(ctx context.Context, pieceCid cid.Cid) (stores.ClosableBlockstore, error)
+}
+
+// UnsealedChecker tells whether a piece has an unsealed copy.
+type UnsealedChecker interface {
+	IsUnsealed(ctx context.Context, pieceCid cid.Cid) (bool, error)
+}
+
+type Config struct {
+	// Interval is how often pieces are sampled.
+	Interval time.Duration
+	// Pieces is the number of pieces read on every round.
+	Pieces int
+	// Blocks is the maximum number of blocks of a piece verified.
+	Blocks int
+	// Unseal allows sampling pieces without an unsealed copy, which get
+	// unsealed to be read.
+	Unseal bool
+	// History is the number of samples kept, 1000 when zero.
+	History int
+}
+
+// Sampler samples the retrievability of the deal data. It raises an alert
+// while the last round had failing samples.
+type Sampler struct {
+	cfg      Config
+	pieces   PieceStore
+	shards   ShardLoader
+	unsealed UnsealedChecker
+	ds       datastore.Batching
+
+	al    *alerting.Alerting
+	alert alerting.AlertType
+
+	// serializes the rounds
+	roundLk sync.Mutex
+
+	lk sync.Mutex
+	// the samples kept, oldest first
+	samples []api.RetrievabilitySample
+
+	closing chan struct{}
+	closed  chan struct{}
+}
+
+// NewSampler creates a sampler recording the samples in the datastore. The
+// alerting system is optional.
+func NewSampler(cfg Config, ps PieceStore, sl ShardLoader, uc UnsealedChecker, ds datastore.Batching, al *alerting.Alerting) *Sampler {
+	if cfg.History <= 0 {
+		cfg.History = defaultHistory
+	}
+
+	s := &Sampler{
+		cfg:      cfg,
+		pieces:   ps,
+		shards:   sl,
+		unsealed: uc,
+		ds:       ds,
+		al:       al,
+		closing:  make(chan struct{}),
+		closed:   make(chan struct{}),
+	}
+	if al != nil {
+		s.alert = al.AddAlertType("retrievability", "sample-failed")
+	}
+	return s
+}
+
+// Start loads the samples kept, and starts sampling periodically.
+func (s *Sampler) Start(ctx context.Context) error {
+	if err := s.load(ctx); err != nil {
+		return err
+	}
+	go s.run()
+	return nil
+}
+
+func (s *Sampler) Stop(ctx context.Context) error {
+	close(s.closing)
+
+	select {
+	case <-s.closed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Sampler) run() {
+	defer close(s.closed)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-s.closing
+		cancel()
+	}()
+
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := s.Check(ctx, 0); err != nil {
+				log.Errorw("sampling retrievability", "error", err)
+			}
+		case <-s.closing:
+			return
+		}
+	}
+}
+
+// Samples returns the last limit samples kept, newest first. Zero returns all
+// of them.
+func (s *Sampler) Samples(limit int) []api.RetrievabilitySample {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	n := len(s.samples)
+	if limit > 0 && limit < n {
+		n = limit
+	}
+	out := make([]api.RetrievabilitySample, n)
+	for i := range out {
+		out[i] = s.samples[len(s.samples)-1-i]
+	}
+	return out
+}
+
+// Check samples the given number of pieces, the configured number when zero,
+// records the results and returns them.
+func (s *Sampler) Check(ctx context.Context, pieces int) ([]api.RetrievabilitySample, error) {
+	if pieces <= 0 {
+		pieces = s.cfg.Pieces
+	}
+
+	s.roundLk.Lock()
+	defer s.roundLk.Unlock()
+
+	candidates, err := s.candidates()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []api.RetrievabilitySample
+	for _, c := range candidates {
+		if len(out) >= pieces {
+			break
+		}
+		if ctx.Err() != nil {
+			return out, ctx.Err()
+		}
+
+		unsealed, err := s.unsealed.IsUnsealed(ctx, c.piece)
+		if err != nil {
+			log.Warnw("checking whether piece is unsealed", "piece", c.piece, "error", err)
+			continue
+		}
+		if !unsealed && !s.cfg.Unseal {
+			continue
+		}
+
+		smp := s.sample(ctx, c)
+		smp.Unsealed = unsealed
+		if err := s.record(ctx, smp); err != nil {
+			return out, err
+		}
+		out = append(out, smp)
+	}
+
+	s.report(out)
+	return out, nil
+}
+
+type candidate struct {
+	piece  cid.Cid
+	sector abi.SectorNumber
+	deal   abi.DealID
+}
+
+// candidates returns a piece of every deal sector, in random order.
+func (s *Sampler) candidates() ([]candidate, error) {
+	keys, err := s.pieces.ListPieceInfoKeys()
+	if err != nil {
+		return nil, xerrors.Errorf("listing pieces: %w", err)
+	}
+
+	bySector := map[abi.SectorNumber][]candidate{}
+	for _, k := range keys {
+		pi, err := s.pieces.GetPieceInfo(k)
+		if err != nil {
+			log.Warnw("getting piece info", "piece", k, "error", err)
+			continue
+		}
+		for _, d := range pi.Deals {
+			bySector[d.SectorID] = append(bySector[d.SectorID], candidate{piece: pi.PieceCID, sector: d.SectorID, deal: d.DealID})
+		}
+	}
+
+	out := make([]candidate, 0, len(bySector))
+	for _, cs := range bySector {
+		out = append(out, cs[rand.Intn(len(cs))])
+	}
+	// map iteration order isn't uniformly random
+	rand.Shuffle(len(out), func(i, j int) {
+		out[i], out[j] = out[j], out[i]
+	})
+	return out, nil
+}
+
+// sample reads random blocks of the piece and verifies their hashes.
+func (s *Sampler) sample(ctx context.Context, c candidate) api.RetrievabilitySample {
+	smp := api.RetrievabilitySample{
+		Time:     time.Now(),
+		PieceCID: c.piece,
+		Sector:   c.sector,
+		Deal:     c.deal,
+	}
+
+	err := s.readBlocks(ctx, c.piece, &smp)
+	smp.Duration = time.Since(smp.Time)
+	if err != nil {
+		smp.Error = err.Error()
+		log.Warnw("retrievability sample failed", "piece", c.piece, "sector", c.sector, "error", err)
+		return smp
+	}
+	smp.OK = true
+	return smp
+}
+
+func (s *Sampler) readBlocks(ctx context.Context, piece cid.Cid, smp *api.RetrievabilitySample) error {
+	bs, err := s.shards.LoadShard(ctx, piece)
+	if err != nil {
+		return xerrors.Errorf("loading shard: %w", err)
+	}
+	defer bs.Close() //nolint:errcheck
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ch, err := bs.AllKeysChan(ctx)
+	if err != nil {
+		return xerrors.Errorf("listing blocks: %w", err)
+	}
+
+	// reservoir sampling of the blocks
+	var picked []cid.Cid
+	var seen int
+	for k := range ch {
+		seen++
+		if len(picked) < s.cfg.Blocks {
+			picked = append(picked, k)
+		} else if i := rand.Intn(seen); i < s.cfg.Blocks {
+			picked[i] = k
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if seen == 0 {
+		return xerrors.Errorf("piece has no blocks")
+	}
+
+	for _, k := range picked {
+		blk, err := bs.Get(ctx, k)
+		if err != nil {
+			return xerrors.Errorf("reading block %s: %w", k, err)
+		}
+		sum, err := k.Prefix().Sum(blk.RawData())
+		if err != nil {
+			return xerrors.Errorf("hashing block %s: %w", k, err)
+		}
+		if !sum.Equals(k) {
+			return xerrors.Errorf("block %s doesn't match its hash, got %s", k, sum)
+		}
+		smp.Blocks++
+		smp.Bytes += int64(len(blk.RawData()))
+	}
+	return nil
+}
+
+// report raises the alert when some samples failed, and resolves it when
+// they all succeeded.
+func (s *Sampler) report(samples []api.RetrievabilitySample) {
+	if s.al == nil || len(samples) == 0 {
+		return
+	}
+
+	var failed []string
+	for _, smp := range samples {
+		if !smp.OK {
+			failed = append(failed, fmt.Sprintf("piece %s in sector %d: %s", smp.PieceCID, smp.Sector, smp.Error))
+		}
+	}
+
+	if len(failed) == 0 {
+		if s.al.IsRaised(s.alert) {
+			s.al.Resolve(s.alert, map[string]interface{}{
+				"message": "sampled pieces are retrievable",
+			})
+		}
+		return
+	}
+	s.al.Raise(s.alert, map[string]interface{}{
+		"message": "sampled pieces couldn't be retrieved",
+		"failed":  failed,
+	})
+}
+
+func sampleKey(t time.Time, piece cid.Cid) datastore.Key {
+	// zero padded, so that the keys sort by time
+	return samplesKey.ChildString(fmt.Sprintf("%020d-%s", t.UnixNano(), piece))
+}
+
+func (s *Sampler) record(ctx context.Context, smp api.RetrievabilitySample) error {
+	b, err := json.Marshal(smp)
+	if err != nil {
+		return err
+	}
+	if err := s.ds.Put(ctx, sampleKey(smp.Time, smp.PieceCID), b); err != nil {
+		return xerrors.Errorf("storing sample: %w", err)
+	}
+
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	s.samples = append(s.samples, smp)
+	for len(s.samples) > s.cfg.History {
+		old := s.samples[0]
+		if err := s.ds.Delete(ctx, sampleKey(old.Time, old.PieceCID)); err != nil {
+			return xerrors.Errorf("deleting old sample: %w", err)
+		}
+		s.samples = s.samples[1:]
+	}
+	return nil
+}
+
+func (s *Sampler) load(ctx context.Context) error {
+	res, err := s.ds.Query(ctx, query.Query{Prefix: samplesKey.String()})
+	if err != nil {
+		return xerrors.Errorf("querying samples: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	var samples []api.RetrievabilitySample
+	for r := range res.Next() {
+		if r.Error != nil {
+			return xerrors.Errorf("reading samples: %w", r.Error)
+		}
+		var smp api.RetrievabilitySample
+		if err := json.Unmarshal(r.Value, &smp); err != nil {
+			return xerrors.Errorf("decoding sample %s: %w", r.Key, err)
+		}
+		samples = append(samples, smp)
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i].Time.Before(samples[j].Time)
+	})
+
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	if len(samples) > s.cfg.History {
+		samples = samples[len(samples)-s.cfg.History:]
+	}
+	s.samples = samples
+	return nil
+}
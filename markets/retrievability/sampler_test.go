@@ -0,0 +1,149 @@
+// stm: #unit
+package retrievability
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-fil-markets/piecestore"
+	tut "github.com/filecoin-project/go-fil-markets/shared_testutil"
+	"github.com/filecoin-project/go-fil-markets/stores"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/journal"
+	"github.com/filecoin-project/lotus/journal/alerting"
+)
+
+type testPieces map[cid.Cid]piecestore.PieceInfo
+
+func (p testPieces) ListPieceInfoKeys() ([]cid.Cid, error) {
+	var out []cid.Cid
+	for k := range p {
+		out = append(out, k)
+	}
+	return out, nil
+}
+
+func (p testPieces) GetPieceInfo(pieceCID cid.Cid) (piecestore.PieceInfo, error) {
+	pi, ok := p[pieceCID]
+	if !ok {
+		return piecestore.PieceInfo{}, xerrors.Errorf("piece %s not found", pieceCID)
+	}
+	return pi, nil
+}
+
+type closableMemory struct {
+	blockstore.MemBlockstore
+}
+
+func (closableMemory) Close() error { return nil }
+
+type testShards map[cid.Cid]blockstore.MemBlockstore
+
+func (s testShards) LoadShard(ctx context.Context, pieceCid cid.Cid) (stores.ClosableBlockstore, error) {
+	bs, ok := s[pieceCid]
+	if !ok {
+		return nil, xerrors.Errorf("shard %s not found", pieceCid)
+	}
+	return closableMemory{bs}, nil
+}
+
+type testUnsealed map[cid.Cid]bool
+
+func (u testUnsealed) IsUnsealed(ctx context.Context, pieceCid cid.Cid) (bool, error) {
+	return u[pieceCid], nil
+}
+
+func TestSampler(t *testing.T) {
+	ctx := context.Background()
+
+	pieces := testPieces{}
+	shards := testShards{}
+	unsealed := testUnsealed{}
+
+	cids := tut.GenerateCids(4)
+	good, corrupt, sealed := cids[0], cids[1], cids[2]
+	for i, p := range []cid.Cid{good, corrupt, sealed} {
+		pieces[p] = piecestore.PieceInfo{
+			PieceCID: p,
+			Deals:    []piecestore.DealInfo{{DealID: abi.DealID(i), SectorID: abi.SectorNumber(i)}},
+		}
+
+		bs := blockstore.NewMemory()
+		for j := 0; j < 5; j++ {
+			require.NoError(t, bs.Put(ctx, blocks.NewBlock([]byte(fmt.Sprintf("%s-%d", p, j)))))
+		}
+		shards[p] = bs
+		unsealed[p] = p != sealed
+	}
+
+	// a block which doesn't match its hash
+	blk, err := blocks.NewBlockWithCid([]byte("garbage"), cids[3])
+	require.NoError(t, err)
+	require.NoError(t, shards[corrupt].Put(ctx, blk))
+
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	al := alerting.NewAlertingSystem(journal.NilJournal())
+	s := NewSampler(Config{Interval: time.Hour, Pieces: 3, Blocks: 100, History: 3}, pieces, shards, unsealed, ds, al)
+	require.NoError(t, s.Start(ctx))
+
+	// the sealed piece isn't unsealed to be sampled
+	samples, err := s.Check(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, samples, 2)
+
+	byPiece := map[cid.Cid]int{}
+	for i, smp := range samples {
+		byPiece[smp.PieceCID] = i
+	}
+	ok := samples[byPiece[good]]
+	require.True(t, ok.OK)
+	require.True(t, ok.Unsealed)
+	require.Equal(t, 5, ok.Blocks)
+	require.Empty(t, ok.Error)
+
+	bad := samples[byPiece[corrupt]]
+	require.False(t, bad.OK)
+	require.Contains(t, bad.Error, "doesn't match its hash")
+	require.Equal(t, abi.SectorNumber(1), bad.Sector)
+
+	require.True(t, al.IsRaised(s.alert))
+
+	// the alert is resolved once the samples succeed
+	shards[corrupt] = shards[good]
+	_, err = s.Check(ctx, 0)
+	require.NoError(t, err)
+	require.False(t, al.IsRaised(s.alert))
+
+	// only the configured history is kept, newest first
+	kept := s.Samples(0)
+	require.Len(t, kept, 3)
+	require.False(t, kept[0].Time.Before(kept[1].Time))
+	require.False(t, kept[1].Time.Before(kept[2].Time))
+	require.Len(t, s.Samples(1), 1)
+
+	require.NoError(t, s.Stop(ctx))
+
+	// the samples are reloaded from the datastore
+	s = NewSampler(Config{Interval: time.Hour, Pieces: 3, Blocks: 100, History: 3}, pieces, shards, unsealed, ds, nil)
+	require.NoError(t, s.Start(ctx))
+	defer s.Stop(ctx) //nolint:errcheck
+
+	reloaded := s.Samples(0)
+	require.Len(t, reloaded, len(kept))
+	for i := range kept {
+		require.True(t, kept[i].Time.Equal(reloaded[i].Time))
+		require.Equal(t, kept[i].PieceCID, reloaded[i].PieceCID)
+		require.Equal(t, kept[i].OK, reloaded[i].OK)
+	}
+}
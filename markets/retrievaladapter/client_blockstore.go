@@ -1,6 +1,7 @@
 package retrievaladapter
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
 	"sync"
@@ -164,3 +165,73 @@ func (c *CARBlockstoreAccessor) Done(id retrievalmarket.DealID) error {
 func (c *CARBlockstoreAccessor) PathFor(id retrievalmarket.DealID) string {
 	return filepath.Join(c.rootdir, fmt.Sprintf("%d.car", id))
 }
+
+// OpenRetrieved opens the blocks received by a retrieval read-only.
+func (c *CARBlockstoreAccessor) OpenRetrieved(id retrievalmarket.DealID, payloadCid retrievalmarket.PayloadCID) (*blockstore.ReadOnly, error) {
+	c.lk.Lock()
+	_, open := c.open[id]
+	c.lk.Unlock()
+	if open {
+		return nil, xerrors.Errorf("retrieval %d in progress", id)
+	}
+
+	path := c.PathFor(id)
+	bs, err := blockstore.OpenReadOnly(path, blockstore.UseWholeCIDs(true))
+	if err == nil {
+		return bs, nil
+	}
+
+	// the file of a retrieval interrupted by a node shutdown isn't finalized,
+	// resume writing it to finalize it
+	rw, rwErr := blockstore.OpenReadWrite(path, []cid.Cid{payloadCid}, blockstore.UseWholeCIDs(true))
+	if rwErr != nil {
+		return nil, xerrors.Errorf("opening retrieval car: %w", err)
+	}
+	if err := rw.Finalize(); err != nil {
+		return nil, xerrors.Errorf("finalizing retrieval car: %w", err)
+	}
+	return blockstore.OpenReadOnly(path, blockstore.UseWholeCIDs(true))
+}
+
+// Resume opens the blockstore of a retrieval with the blocks received by an
+// earlier retrieval of the same payload, which the new retrieval resumes.
+func (c *CARBlockstoreAccessor) Resume(id, from retrievalmarket.DealID, payloadCid retrievalmarket.PayloadCID) error {
+	src, err := c.OpenRetrieved(from, payloadCid)
+	if err != nil {
+		return err
+	}
+	defer src.Close() //nolint:errcheck
+
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	if _, ok := c.open[id]; ok {
+		return xerrors.Errorf("blockstore of retrieval %d already open", id)
+	}
+
+	dst, err := blockstore.OpenReadWrite(c.PathFor(id), []cid.Cid{payloadCid}, blockstore.UseWholeCIDs(true))
+	if err != nil {
+		return err
+	}
+
+	ctx := context.TODO()
+	keys, err := src.AllKeysChan(ctx)
+	if err != nil {
+		dst.Discard()
+		return xerrors.Errorf("listing retrieved blocks: %w", err)
+	}
+	for k := range keys {
+		blk, err := src.Get(ctx, k)
+		if err != nil {
+			dst.Discard()
+			return xerrors.Errorf("reading retrieved block %s: %w", k, err)
+		}
+		if err := dst.Put(ctx, blk); err != nil {
+			dst.Discard()
+			return xerrors.Errorf("copying retrieved block %s: %w", k, err)
+		}
+	}
+
+	c.open[id] = dst
+	return nil
+}
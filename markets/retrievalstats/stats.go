@@ -0,0 +1,210 @@
+package retrievalstats
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"go.opencensus.io/stats"
+
+	datatransfer "github.com/filecoin-project/go-data-transfer/v2"
+	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/metrics"
+)
+
+var log = logging.Logger("retrievalstats")
+
+const (
+	// BucketDuration is the width of a single time bucket in the retrieval
+	// activity histogram.
+	BucketDuration = time.Hour
+	// BucketCount is the number of time buckets retained.
+	BucketCount = 24
+)
+
+// Tracker aggregates per-piece and per-client retrieval statistics on the
+// retrieval provider side. Bytes served are taken from the data transfer
+// channel backing each retrieval, and attributed to the piece and client
+// once the retrieval completes.
+type Tracker struct {
+	lk sync.Mutex
+
+	now   func() time.Time
+	since time.Time
+
+	// bytes sent so far on each in-progress retrieval channel
+	sent map[datatransfer.ChannelID]uint64
+
+	totalRetrievals uint64
+	totalBytes      uint64
+
+	pieces  map[cid.Cid]*api.RetrievalPieceStats
+	clients map[peer.ID]*api.RetrievalClientStats
+	buckets []api.RetrievalStatsBucket
+}
+
+// NewTracker creates an empty retrieval statistics tracker.
+func NewTracker() *Tracker {
+	return newTracker(time.Now)
+}
+
+func newTracker(now func() time.Time) *Tracker {
+	return &Tracker{
+		now:     now,
+		since:   now(),
+		sent:    map[datatransfer.ChannelID]uint64{},
+		pieces:  map[cid.Cid]*api.RetrievalPieceStats{},
+		clients: map[peer.ID]*api.RetrievalClientStats{},
+	}
+}
+
+// OnDataTransferEvent is a data transfer subscriber which keeps track of the
+// number of bytes sent on each channel.
+func (t *Tracker) OnDataTransferEvent(event datatransfer.Event, state datatransfer.ChannelState) {
+	switch event.Code {
+	case datatransfer.DataSent, datatransfer.DataSentProgress, datatransfer.Complete:
+	default:
+		return
+	}
+
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	t.sent[state.ChannelID()] = state.Sent()
+}
+
+// OnRetrievalProviderEvent is a retrieval provider subscriber which records a
+// retrieval once the deal completes. The bytes sent on the channel of the deal
+// are forgotten on every terminal state.
+func (t *Tracker) OnRetrievalProviderEvent(event retrievalmarket.ProviderEvent, deal retrievalmarket.ProviderDealState) {
+	switch deal.Status {
+	case retrievalmarket.DealStatusCompleted, retrievalmarket.DealStatusErrored, retrievalmarket.DealStatusCancelled,
+		retrievalmarket.DealStatusRejected, retrievalmarket.DealStatusDealNotFound:
+	default:
+		return
+	}
+
+	var sent uint64
+	if deal.ChannelID != nil {
+		t.lk.Lock()
+		sent = t.sent[*deal.ChannelID]
+		delete(t.sent, *deal.ChannelID)
+		t.lk.Unlock()
+	}
+
+	if deal.Status != retrievalmarket.DealStatusCompleted {
+		return
+	}
+
+	var piece cid.Cid
+	switch {
+	case deal.PieceInfo != nil:
+		piece = deal.PieceInfo.PieceCID
+	case deal.PieceCID != nil:
+		piece = *deal.PieceCID
+	default:
+		log.Debugw("completed retrieval without a piece cid", "deal", deal.ID, "payload", deal.PayloadCID)
+		return
+	}
+
+	t.Record(piece, deal.Receiver, sent)
+}
+
+// Record accounts a single completed retrieval of the given piece by the
+// given client.
+func (t *Tracker) Record(piece cid.Cid, client peer.ID, bytes uint64) {
+	stats.Record(context.TODO(), metrics.RetrievalCompletedCount.M(1), metrics.RetrievalBytesServed.M(int64(bytes)))
+
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	now := t.now()
+
+	t.totalRetrievals++
+	t.totalBytes += bytes
+
+	ps, ok := t.pieces[piece]
+	if !ok {
+		ps = &api.RetrievalPieceStats{PieceCID: piece}
+		t.pieces[piece] = ps
+	}
+	ps.Retrievals++
+	ps.BytesServed += bytes
+	ps.LastRetrieval = now
+
+	cs, ok := t.clients[client]
+	if !ok {
+		cs = &api.RetrievalClientStats{Client: client}
+		t.clients[client] = cs
+	}
+	cs.Retrievals++
+	cs.BytesServed += bytes
+	cs.LastRetrieval = now
+
+	start := now.Truncate(BucketDuration)
+	if n := len(t.buckets); n == 0 || !t.buckets[n-1].Start.Equal(start) {
+		t.buckets = append(t.buckets, api.RetrievalStatsBucket{Start: start})
+	}
+	b := &t.buckets[len(t.buckets)-1]
+	b.Retrievals++
+	b.BytesServed += bytes
+
+	cutoff := start.Add(-BucketDuration * (BucketCount - 1))
+	for len(t.buckets) > 0 && t.buckets[0].Start.Before(cutoff) {
+		t.buckets = t.buckets[1:]
+	}
+}
+
+// Stats returns a snapshot of the collected statistics. Pieces and clients
+// are ordered by the number of bytes served; topN limits the number of
+// entries returned, zero returns all of them.
+func (t *Tracker) Stats(topN int) *api.RetrievalStats {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	out := &api.RetrievalStats{
+		Since:            t.since,
+		TotalRetrievals:  t.totalRetrievals,
+		TotalBytesServed: t.totalBytes,
+		TopPieces:        make([]api.RetrievalPieceStats, 0, len(t.pieces)),
+		TopClients:       make([]api.RetrievalClientStats, 0, len(t.clients)),
+		Buckets:          append([]api.RetrievalStatsBucket{}, t.buckets...),
+	}
+
+	for _, ps := range t.pieces {
+		out.TopPieces = append(out.TopPieces, *ps)
+	}
+	sort.Slice(out.TopPieces, func(i, j int) bool {
+		if out.TopPieces[i].BytesServed != out.TopPieces[j].BytesServed {
+			return out.TopPieces[i].BytesServed > out.TopPieces[j].BytesServed
+		}
+		return out.TopPieces[i].Retrievals > out.TopPieces[j].Retrievals
+	})
+
+	for _, cs := range t.clients {
+		out.TopClients = append(out.TopClients, *cs)
+	}
+	sort.Slice(out.TopClients, func(i, j int) bool {
+		if out.TopClients[i].BytesServed != out.TopClients[j].BytesServed {
+			return out.TopClients[i].BytesServed > out.TopClients[j].BytesServed
+		}
+		return out.TopClients[i].Retrievals > out.TopClients[j].Retrievals
+	})
+
+	if topN > 0 {
+		if len(out.TopPieces) > topN {
+			out.TopPieces = out.TopPieces[:topN]
+		}
+		if len(out.TopClients) > topN {
+			out.TopClients = out.TopClients[:topN]
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,109 @@
+// stm: #unit
+package retrievalstats
+
+import (
+	"testing"
+
+	"github.com/raulk/clock"
+	"github.com/stretchr/testify/require"
+
+	datatransfer "github.com/filecoin-project/go-data-transfer/v2"
+	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
+	tut "github.com/filecoin-project/go-fil-markets/shared_testutil"
+)
+
+func TestTrackerStats(t *testing.T) {
+	// half way into a bucket
+	clk := clock.NewMock()
+	clk.Add(BucketDuration / 2)
+	tr := newTracker(clk.Now)
+
+	pieces, clients := tut.GenerateCids(2), tut.GeneratePeers(2)
+	p1, p2 := pieces[0], pieces[1]
+	c1, c2 := clients[0], clients[1]
+
+	tr.Record(p1, c1, 100)
+	tr.Record(p1, c2, 100)
+	tr.Record(p2, c1, 50)
+
+	clk.Add(BucketDuration)
+	tr.Record(p2, c1, 500)
+
+	st := tr.Stats(0)
+	require.Equal(t, uint64(4), st.TotalRetrievals)
+	require.Equal(t, uint64(750), st.TotalBytesServed)
+
+	require.Len(t, st.TopPieces, 2)
+	require.Equal(t, p2, st.TopPieces[0].PieceCID)
+	require.Equal(t, uint64(550), st.TopPieces[0].BytesServed)
+	require.Equal(t, uint64(2), st.TopPieces[0].Retrievals)
+	require.Equal(t, clk.Now(), st.TopPieces[0].LastRetrieval)
+
+	require.Len(t, st.TopClients, 2)
+	require.Equal(t, c1, st.TopClients[0].Client)
+	require.Equal(t, uint64(3), st.TopClients[0].Retrievals)
+
+	require.Len(t, st.Buckets, 2)
+	require.Equal(t, uint64(3), st.Buckets[0].Retrievals)
+	require.Equal(t, uint64(500), st.Buckets[1].BytesServed)
+
+	st = tr.Stats(1)
+	require.Len(t, st.TopPieces, 1)
+	require.Len(t, st.TopClients, 1)
+
+	// old buckets age out
+	clk.Add(BucketCount * BucketDuration)
+	tr.Record(p1, c2, 1)
+	st = tr.Stats(0)
+	require.Len(t, st.Buckets, 1)
+}
+
+type mockChannelState struct {
+	datatransfer.ChannelState
+	id   datatransfer.ChannelID
+	sent uint64
+}
+
+func (m mockChannelState) ChannelID() datatransfer.ChannelID { return m.id }
+func (m mockChannelState) Sent() uint64                      { return m.sent }
+
+func TestTrackerEvents(t *testing.T) {
+	tr := NewTracker()
+
+	piece := tut.GenerateCids(1)[0]
+	chid := datatransfer.ChannelID{Initiator: "client", Responder: "provider", ID: 1}
+
+	tr.OnDataTransferEvent(datatransfer.Event{Code: datatransfer.DataSent}, mockChannelState{id: chid, sent: 10})
+	tr.OnDataTransferEvent(datatransfer.Event{Code: datatransfer.DataSent}, mockChannelState{id: chid, sent: 1024})
+
+	deal := retrievalmarket.ProviderDealState{
+		ChannelID: &chid,
+		Receiver:  "client",
+	}
+	deal.PieceCID = &piece
+
+	deal.Status = retrievalmarket.DealStatusOngoing
+	tr.OnRetrievalProviderEvent(retrievalmarket.ProviderEventBlockSent, deal)
+	require.Zero(t, tr.Stats(0).TotalRetrievals)
+
+	deal.Status = retrievalmarket.DealStatusCompleted
+	tr.OnRetrievalProviderEvent(retrievalmarket.ProviderEventComplete, deal)
+
+	st := tr.Stats(0)
+	require.Equal(t, uint64(1), st.TotalRetrievals)
+	require.Equal(t, uint64(1024), st.TotalBytesServed)
+	require.Equal(t, piece, st.TopPieces[0].PieceCID)
+	require.Empty(t, tr.sent)
+
+	// the bytes sent are forgotten when the deal fails, or completes without a piece cid
+	for _, status := range []retrievalmarket.DealStatus{retrievalmarket.DealStatusErrored, retrievalmarket.DealStatusCompleted} {
+		tr.OnDataTransferEvent(datatransfer.Event{Code: datatransfer.DataSent}, mockChannelState{id: chid, sent: 10})
+		require.Len(t, tr.sent, 1)
+
+		deal.Status = status
+		deal.PieceCID = nil
+		tr.OnRetrievalProviderEvent(retrievalmarket.ProviderEventComplete, deal)
+		require.Empty(t, tr.sent)
+	}
+	require.Equal(t, uint64(1), tr.Stats(0).TotalRetrievals)
+}
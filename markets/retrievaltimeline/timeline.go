@@ -0,0 +1,331 @@
+// Package retrievaltimeline records a timeline of each retrieval served by the
+// retrieval provider, to tell where the time of slow retrievals goes.
+package retrievaltimeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"golang.org/x/xerrors"
+
+	datatransfer "github.com/filecoin-project/go-data-transfer/v2"
+	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+var log = logging.Logger("retrievaltimeline")
+
+const (
+	// SampleInterval is the initial interval of the throughput samples
+	SampleInterval = 10 * time.Second
+	// maxSamples is the number of samples kept for a retrieval, the interval
+	// of the samples doubles when it's reached
+	maxSamples = 360
+
+	// Retention is how long the timelines of finished retrievals are kept
+	Retention = 7 * 24 * time.Hour
+	// gcInterval is the minimum interval between removals of old timelines
+	gcInterval = time.Hour
+)
+
+type live struct {
+	tl api.RetrievalTimeline
+
+	interval time.Duration
+	// sent and time of the last sample
+	lastSent uint64
+	lastTime time.Time
+}
+
+// Recorder persists the timelines of the retrievals, built from the events of
+// the retrieval provider and of the data transfers backing the retrievals.
+type Recorder struct {
+	ds  datastore.Batching
+	now func() time.Time
+
+	lk       sync.Mutex
+	live     map[retrievalmarket.ProviderDealIdentifier]*live
+	channels map[datatransfer.ChannelID]retrievalmarket.ProviderDealIdentifier
+	lastGC   time.Time
+}
+
+func New(ds datastore.Batching) *Recorder {
+	return newRecorder(ds, time.Now)
+}
+
+func newRecorder(ds datastore.Batching, now func() time.Time) *Recorder {
+	return &Recorder{
+		ds:       ds,
+		now:      now,
+		live:     map[retrievalmarket.ProviderDealIdentifier]*live{},
+		channels: map[datatransfer.ChannelID]retrievalmarket.ProviderDealIdentifier{},
+		lastGC:   now(),
+	}
+}
+
+// OnRetrievalProviderEvent is a retrieval provider subscriber adding the
+// changes of the status of the retrievals to their timelines.
+func (r *Recorder) OnRetrievalProviderEvent(event retrievalmarket.ProviderEvent, deal retrievalmarket.ProviderDealState) {
+	id := deal.Identifier()
+
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	now := r.now()
+
+	l, ok := r.live[id]
+	if !ok {
+		if event != retrievalmarket.ProviderEventOpen && event != retrievalmarket.ProviderEventDealAccepted {
+			// the retrieval started before the node, or its timeline is finished
+			return
+		}
+
+		l = &live{
+			tl: api.RetrievalTimeline{
+				Receiver:   deal.Receiver,
+				DealID:     deal.ID,
+				PayloadCID: deal.PayloadCID,
+				Start:      now,
+			},
+			interval: SampleInterval,
+		}
+		r.live[id] = l
+	}
+
+	if deal.ChannelID != nil {
+		r.channels[*deal.ChannelID] = id
+	}
+	switch {
+	case deal.PieceInfo != nil:
+		pc := deal.PieceInfo.PieceCID
+		l.tl.PieceCID = &pc
+	case deal.PieceCID != nil:
+		l.tl.PieceCID = deal.PieceCID
+	}
+
+	prev := l.tl.Status
+	l.tl.Status = deal.Status
+	l.tl.Message = deal.Message
+
+	var evt api.RetrievalTimelineEventType
+	switch {
+	case event == retrievalmarket.ProviderEventDealAccepted:
+		evt = api.RetrievalAccepted
+	case event == retrievalmarket.ProviderEventUnsealComplete:
+		evt = api.RetrievalUnsealed
+	case deal.Status == retrievalmarket.DealStatusUnsealing && prev != retrievalmarket.DealStatusUnsealing:
+		evt = api.RetrievalUnsealingStarted
+	case deal.Status == retrievalmarket.DealStatusCompleted:
+		evt = api.RetrievalCompleted
+	case deal.Status == retrievalmarket.DealStatusErrored, deal.Status == retrievalmarket.DealStatusRejected, deal.Status == retrievalmarket.DealStatusDealNotFound:
+		evt = api.RetrievalFailed
+	case deal.Status == retrievalmarket.DealStatusCancelled:
+		evt = api.RetrievalCancelled
+	}
+	if evt != "" {
+		e := api.RetrievalTimelineEvent{Time: now, Event: evt}
+		if evt == api.RetrievalFailed {
+			e.Message = deal.Message
+		}
+		l.tl.Events = append(l.tl.Events, e)
+
+		// accepting a retrieval moves it straight to unsealing
+		if evt == api.RetrievalAccepted && deal.Status == retrievalmarket.DealStatusUnsealing {
+			l.tl.Events = append(l.tl.Events, api.RetrievalTimelineEvent{Time: now, Event: api.RetrievalUnsealingStarted})
+		}
+	}
+
+	finished := evt == api.RetrievalCompleted || evt == api.RetrievalFailed || evt == api.RetrievalCancelled
+	if finished {
+		l.tl.End = now
+		delete(r.live, id)
+		if deal.ChannelID != nil {
+			delete(r.channels, *deal.ChannelID)
+		}
+	}
+
+	if evt != "" {
+		r.put(l.tl)
+	}
+
+	if finished && now.Sub(r.lastGC) > gcInterval {
+		r.lastGC = now
+		go func() {
+			if err := r.GC(context.Background()); err != nil {
+				log.Errorw("removing old retrieval timelines", "error", err)
+			}
+		}()
+	}
+}
+
+// OnDataTransferEvent is a data transfer subscriber adding the first byte
+// sent and samples of the throughput to the timelines of the retrievals.
+func (r *Recorder) OnDataTransferEvent(event datatransfer.Event, state datatransfer.ChannelState) {
+	switch event.Code {
+	case datatransfer.DataSent, datatransfer.DataSentProgress, datatransfer.Complete:
+	default:
+		return
+	}
+
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	id, ok := r.channels[state.ChannelID()]
+	if !ok {
+		return
+	}
+	l, ok := r.live[id]
+	if !ok {
+		return
+	}
+
+	sent := state.Sent()
+	if sent == 0 {
+		return
+	}
+	l.tl.BytesSent = sent
+
+	now := r.now()
+	if l.lastTime.IsZero() {
+		l.lastSent, l.lastTime = sent, now
+		l.tl.Events = append(l.tl.Events, api.RetrievalTimelineEvent{Time: now, Event: api.RetrievalFirstByte})
+		r.put(l.tl)
+		return
+	}
+
+	elapsed := now.Sub(l.lastTime)
+	if elapsed < l.interval && event.Code != datatransfer.Complete {
+		return
+	}
+
+	var rate uint64
+	if elapsed > 0 {
+		rate = uint64(float64(sent-l.lastSent) / elapsed.Seconds())
+	}
+	l.tl.Samples = append(l.tl.Samples, api.RetrievalThroughputSample{
+		Time:           now,
+		BytesSent:      sent,
+		BytesPerSecond: rate,
+	})
+	l.lastSent, l.lastTime = sent, now
+
+	if len(l.tl.Samples) > maxSamples {
+		l.tl.Samples = downsample(l.tl.Samples)
+		l.interval *= 2
+	}
+
+	r.put(l.tl)
+}
+
+// downsample halves the number of samples, merging the throughput of each
+// pair of samples
+func downsample(samples []api.RetrievalThroughputSample) []api.RetrievalThroughputSample {
+	out := make([]api.RetrievalThroughputSample, 0, (len(samples)+1)/2)
+	for i := 0; i < len(samples); i += 2 {
+		if i+1 == len(samples) {
+			out = append(out, samples[i])
+			break
+		}
+		s := samples[i+1]
+		s.BytesPerSecond = (samples[i].BytesPerSecond + s.BytesPerSecond) / 2
+		out = append(out, s)
+	}
+	return out
+}
+
+func key(receiver peer.ID, dealID retrievalmarket.DealID) datastore.Key {
+	return datastore.NewKey(fmt.Sprintf("%s/%d", receiver, dealID))
+}
+
+// put saves a timeline, the caller holds the lock
+func (r *Recorder) put(tl api.RetrievalTimeline) {
+	b, err := json.Marshal(tl)
+	if err != nil {
+		log.Errorw("marshaling retrieval timeline", "receiver", tl.Receiver, "deal", tl.DealID, "error", err)
+		return
+	}
+	if err := r.ds.Put(context.TODO(), key(tl.Receiver, tl.DealID), b); err != nil {
+		log.Errorw("saving retrieval timeline", "receiver", tl.Receiver, "deal", tl.DealID, "error", err)
+	}
+}
+
+// Get returns the timeline of a retrieval.
+func (r *Recorder) Get(ctx context.Context, receiver peer.ID, dealID retrievalmarket.DealID) (*api.RetrievalTimeline, error) {
+	b, err := r.ds.Get(ctx, key(receiver, dealID))
+	if err == datastore.ErrNotFound {
+		return nil, xerrors.Errorf("no timeline for retrieval %d of %s", dealID, receiver)
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("getting retrieval timeline: %w", err)
+	}
+
+	var tl api.RetrievalTimeline
+	if err := json.Unmarshal(b, &tl); err != nil {
+		return nil, xerrors.Errorf("unmarshaling retrieval timeline: %w", err)
+	}
+	return &tl, nil
+}
+
+// List returns the timelines of the most recent retrievals, newest first,
+// limit is the number of timelines returned, zero returns all of them.
+func (r *Recorder) List(ctx context.Context, limit int) ([]api.RetrievalTimeline, error) {
+	res, err := r.ds.Query(ctx, query.Query{})
+	if err != nil {
+		return nil, xerrors.Errorf("querying retrieval timelines: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	out := []api.RetrievalTimeline{}
+	for e := range res.Next() {
+		if e.Error != nil {
+			return nil, xerrors.Errorf("reading retrieval timelines: %w", e.Error)
+		}
+		var tl api.RetrievalTimeline
+		if err := json.Unmarshal(e.Value, &tl); err != nil {
+			return nil, xerrors.Errorf("unmarshaling retrieval timeline %s: %w", e.Key, err)
+		}
+		out = append(out, tl)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Start.After(out[j].Start)
+	})
+
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// GC removes the timelines of the retrievals which finished longer than
+// Retention ago, and of those which never finished, as the node restarted
+// while they were ongoing.
+func (r *Recorder) GC(ctx context.Context) error {
+	all, err := r.List(ctx, 0)
+	if err != nil {
+		return err
+	}
+
+	cutoff := r.now().Add(-Retention)
+	for _, tl := range all {
+		end := tl.End
+		if end.IsZero() {
+			end = tl.Start
+		}
+		if end.After(cutoff) {
+			continue
+		}
+		if err := r.ds.Delete(ctx, key(tl.Receiver, tl.DealID)); err != nil {
+			return xerrors.Errorf("removing retrieval timeline: %w", err)
+		}
+	}
+	return nil
+}
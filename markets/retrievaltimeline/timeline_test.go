@@ -0,0 +1,134 @@
+// stm: #unit
+package retrievaltimeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	tpeer "github.com/libp2p/go-libp2p/core/test"
+	"github.com/raulk/clock"
+	"github.com/stretchr/testify/require"
+
+	datatransfer "github.com/filecoin-project/go-data-transfer/v2"
+	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
+	tut "github.com/filecoin-project/go-fil-markets/shared_testutil"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+type mockChannelState struct {
+	datatransfer.ChannelState
+	id   datatransfer.ChannelID
+	sent uint64
+}
+
+func (m mockChannelState) ChannelID() datatransfer.ChannelID { return m.id }
+func (m mockChannelState) Sent() uint64                      { return m.sent }
+
+func eventTypes(tl *api.RetrievalTimeline) []api.RetrievalTimelineEventType {
+	var out []api.RetrievalTimelineEventType
+	for _, e := range tl.Events {
+		out = append(out, e.Event)
+	}
+	return out
+}
+
+func TestTimeline(t *testing.T) {
+	ctx := context.Background()
+
+	clk := clock.NewMock()
+	r := newRecorder(dssync.MutexWrap(datastore.NewMapDatastore()), clk.Now)
+
+	client, client2 := tpeer.RandPeerIDFatal(t), tpeer.RandPeerIDFatal(t)
+	cids := tut.GenerateCids(2)
+	piece := cids[0]
+	chid := datatransfer.ChannelID{Initiator: "client", Responder: "provider", ID: 1}
+
+	deal := retrievalmarket.ProviderDealState{Receiver: client}
+	deal.ID = 3
+	deal.PayloadCID = cids[1]
+	deal.PieceCID = &piece
+
+	r.OnRetrievalProviderEvent(retrievalmarket.ProviderEventOpen, deal)
+
+	deal.Status = retrievalmarket.DealStatusUnsealing
+	deal.ChannelID = &chid
+	r.OnRetrievalProviderEvent(retrievalmarket.ProviderEventDealAccepted, deal)
+
+	clk.Add(time.Minute)
+	deal.Status = retrievalmarket.DealStatusUnsealed
+	r.OnRetrievalProviderEvent(retrievalmarket.ProviderEventUnsealComplete, deal)
+
+	clk.Add(time.Second)
+	r.OnDataTransferEvent(datatransfer.Event{Code: datatransfer.DataSent}, mockChannelState{id: chid, sent: 100})
+
+	// below the sampling interval
+	clk.Add(time.Second)
+	r.OnDataTransferEvent(datatransfer.Event{Code: datatransfer.DataSent}, mockChannelState{id: chid, sent: 200})
+
+	clk.Add(SampleInterval)
+	r.OnDataTransferEvent(datatransfer.Event{Code: datatransfer.DataSent}, mockChannelState{id: chid, sent: 1200})
+
+	tl, err := r.Get(ctx, client, 3)
+	require.NoError(t, err)
+	require.Equal(t, []api.RetrievalTimelineEventType{api.RetrievalAccepted, api.RetrievalUnsealingStarted, api.RetrievalUnsealed, api.RetrievalFirstByte}, eventTypes(tl))
+	require.Equal(t, time.Minute, tl.Events[2].Time.Sub(tl.Events[1].Time))
+	require.Len(t, tl.Samples, 1)
+	require.Equal(t, uint64(1200), tl.Samples[0].BytesSent)
+	require.Equal(t, uint64(100), tl.Samples[0].BytesPerSecond)
+	require.Equal(t, piece, *tl.PieceCID)
+	require.True(t, tl.End.IsZero())
+
+	clk.Add(time.Second)
+	deal.Status = retrievalmarket.DealStatusCompleted
+	r.OnRetrievalProviderEvent(retrievalmarket.ProviderEventCleanupComplete, deal)
+
+	tl, err = r.Get(ctx, client, 3)
+	require.NoError(t, err)
+	require.Equal(t, api.RetrievalCompleted, tl.Events[len(tl.Events)-1].Event)
+	require.True(t, clk.Now().Equal(tl.End))
+	require.Empty(t, r.live)
+	require.Empty(t, r.channels)
+
+	// events of finished retrievals are ignored
+	deal.Status = retrievalmarket.DealStatusErrored
+	r.OnRetrievalProviderEvent(retrievalmarket.ProviderEventDataTransferError, deal)
+	tl, err = r.Get(ctx, client, 3)
+	require.NoError(t, err)
+	require.Equal(t, retrievalmarket.DealStatusCompleted, tl.Status)
+
+	failed := retrievalmarket.ProviderDealState{Receiver: client2, Message: "unseal failed"}
+	failed.ID = 4
+	r.OnRetrievalProviderEvent(retrievalmarket.ProviderEventDealAccepted, failed)
+	clk.Add(time.Second)
+	failed.Status = retrievalmarket.DealStatusErrored
+	r.OnRetrievalProviderEvent(retrievalmarket.ProviderEventCancelComplete, failed)
+
+	all, err := r.List(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	require.Equal(t, retrievalmarket.DealID(4), all[0].DealID)
+	require.Equal(t, "unseal failed", all[0].Events[1].Message)
+
+	clk.Add(Retention + time.Minute)
+	require.NoError(t, r.GC(ctx))
+	all, err = r.List(ctx, 0)
+	require.NoError(t, err)
+	require.Empty(t, all)
+}
+
+func TestDownsample(t *testing.T) {
+	in := []api.RetrievalThroughputSample{
+		{BytesSent: 1, BytesPerSecond: 10},
+		{BytesSent: 2, BytesPerSecond: 20},
+		{BytesSent: 3, BytesPerSecond: 30},
+	}
+	out := downsample(in)
+	require.Len(t, out, 2)
+	require.Equal(t, uint64(2), out[0].BytesSent)
+	require.Equal(t, uint64(15), out[0].BytesPerSecond)
+	require.Equal(t, uint64(3), out[1].BytesSent)
+}
@@ -43,6 +43,12 @@ var (
 	MsgValid, _     = tag.NewKey("message_valid")
 	Endpoint, _     = tag.NewKey("endpoint")
 	APIInterface, _ = tag.NewKey("api") // to distinguish between gateway api and full node api endpoint calls
+	PubsubTopic, _  = tag.NewKey("topic")
+
+	ChainExchangeOutcome, _ = tag.NewKey("chainxchg_outcome")
+
+	ConsensusFaultType, _ = tag.NewKey("fault_type")
+	CorruptionType, _     = tag.NewKey("corruption_type")
 
 	// miner
 	TaskType, _       = tag.NewKey("task_type")
@@ -50,6 +56,8 @@ var (
 	StorageID, _      = tag.NewKey("storage_id")
 	SectorState, _    = tag.NewKey("sector_state")
 
+	BitswapOutcome, _ = tag.NewKey("bitswap_outcome")
+
 	PathSeal, _    = tag.NewKey("path_seal")
 	PathStorage, _ = tag.NewKey("path_storage")
 
@@ -108,6 +116,10 @@ var (
 	BlockValidationSuccess              = stats.Int64("block/success", "Counter for block validation successes", stats.UnitDimensionless)
 	BlockValidationDurationMilliseconds = stats.Float64("block/validation_ms", "Duration for Block Validation in ms", stats.UnitMilliseconds)
 	BlockDelay                          = stats.Int64("block/delay", "Delay of accepted blocks, where delay is >5s", stats.UnitMilliseconds)
+	BlockArrivalOffset                  = stats.Float64("block/arrival_offset_ms", "Arrival time of the blocks received over pubsub, relative to the start of their epoch", stats.UnitMilliseconds)
+	BlockRelayDeprioritizedPeers        = stats.Int64("block/relay_deprioritized_peers", "Number of peers deprioritized for relaying blocks late or relaying equivocating blocks", stats.UnitDimensionless)
+	ChainExchangeRequests               = stats.Int64("chainxchg/requests", "Counter of requests received by the chain exchange server", stats.UnitDimensionless)
+	ChainExchangeThrottledPeers         = stats.Int64("chainxchg/throttled_peers", "Number of peers throttled by the chain exchange server in the last minute", stats.UnitDimensionless)
 	PubsubPublishMessage                = stats.Int64("pubsub/published", "Counter for total published messages", stats.UnitDimensionless)
 	PubsubDeliverMessage                = stats.Int64("pubsub/delivered", "Counter for total delivered messages", stats.UnitDimensionless)
 	PubsubRejectMessage                 = stats.Int64("pubsub/rejected", "Counter for total rejected messages", stats.UnitDimensionless)
@@ -162,6 +174,29 @@ var (
 	DagStorePRSeekBackBytes    = stats.Int64("dagstore/pr_seek_back_bytes", "PieceReader seek back bytes", stats.UnitBytes)
 	DagStorePRSeekForwardBytes = stats.Int64("dagstore/pr_seek_forward_bytes", "PieceReader seek forward bytes", stats.UnitBytes)
 
+	RetrievalCompletedCount = stats.Int64("retrieval/completed_count", "Counter of completed retrievals", stats.UnitDimensionless)
+	RetrievalBytesServed    = stats.Int64("retrieval/bytes_served", "Bytes served by completed retrievals", stats.UnitBytes)
+
+	BitswapBlockRequests = stats.Int64("bitswap/block_requests", "Counter of block requests received by the deal data bitswap server", stats.UnitDimensionless)
+	BitswapBytesServed   = stats.Int64("bitswap/bytes_served", "Bytes read from the dagstore to serve bitswap requests", stats.UnitBytes)
+
+	DHTProvideCount        = stats.Int64("dht/provide_count", "Counter of provider records published to the public DHT", stats.UnitDimensionless)
+	DHTProvideFailureCount = stats.Int64("dht/provide_failure_count", "Counter of failures to publish provider records to the public DHT", stats.UnitDimensionless)
+	DHTProvideDuration     = stats.Float64("dht/provide_duration_ms", "Duration of publishing a provider record to the public DHT", stats.UnitMilliseconds)
+
+	// consensus fault reporter
+	ConsensusFaultDetected = stats.Int64("consensus_fault/detected", "Counter of consensus faults detected in incoming blocks", stats.UnitDimensionless)
+	ConsensusFaultReported = stats.Int64("consensus_fault/reported", "Counter of consensus faults reported to the miner actor", stats.UnitDimensionless)
+
+	// blockstore scrubber
+	BlockstoreScrubChecked  = stats.Int64("blockstore/scrub/checked", "Counter of blocks checked by the blockstore scrubber", stats.UnitDimensionless)
+	BlockstoreScrubCorrupt  = stats.Int64("blockstore/scrub/corrupt", "Counter of corrupt blocks quarantined by the blockstore scrubber", stats.UnitDimensionless)
+	BlockstoreScrubRepaired = stats.Int64("blockstore/scrub/repaired", "Counter of quarantined blocks refetched from the network", stats.UnitDimensionless)
+
+	// call cache
+	CallCacheHit  = stats.Int64("call_cache/hit", "Counter of read-only call results served from the call cache", stats.UnitDimensionless)
+	CallCacheMiss = stats.Int64("call_cache/miss", "Counter of read-only calls not found in the call cache", stats.UnitDimensionless)
+
 	// splitstore
 	SplitstoreMiss                  = stats.Int64("splitstore/miss", "Number of misses in hotstre access", stats.UnitDimensionless)
 	SplitstoreCompactionTimeSeconds = stats.Float64("splitstore/compaction_time", "Compaction time in seconds", stats.UnitSeconds)
@@ -244,6 +279,30 @@ var (
 			return view.Distribution(bounds...)
 		}(),
 	}
+	BlockArrivalOffsetView = &view.View{
+		Measure: BlockArrivalOffset,
+		Aggregation: func() *view.Aggregation {
+			var bounds []float64
+			for i := 0; i < 30; i++ { // 0-29s, step 1s
+				bounds = append(bounds, float64(i*1000))
+			}
+			bounds = append(bounds, 45*1000, 60*1000)
+			return view.Distribution(bounds...)
+		}(),
+	}
+	BlockRelayDeprioritizedPeersView = &view.View{
+		Measure:     BlockRelayDeprioritizedPeers,
+		Aggregation: view.LastValue(),
+	}
+	ChainExchangeRequestsView = &view.View{
+		Measure:     ChainExchangeRequests,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{ChainExchangeOutcome},
+	}
+	ChainExchangeThrottledPeersView = &view.View{
+		Measure:     ChainExchangeThrottledPeers,
+		Aggregation: view.LastValue(),
+	}
 	IndexerMessageValidationFailureView = &view.View{
 		Measure:     IndexerMessageValidationFailure,
 		Aggregation: view.Count(),
@@ -310,6 +369,7 @@ var (
 	PubsubRejectMessageView = &view.View{
 		Measure:     PubsubRejectMessage,
 		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{PubsubTopic, FailureType},
 	}
 	PubsubDuplicateMessageView = &view.View{
 		Measure:     PubsubDuplicateMessage,
@@ -513,6 +573,75 @@ var (
 		Aggregation: view.Sum(),
 	}
 
+	RetrievalCompletedCountView = &view.View{
+		Measure:     RetrievalCompletedCount,
+		Aggregation: view.Count(),
+	}
+	RetrievalBytesServedView = &view.View{
+		Measure:     RetrievalBytesServed,
+		Aggregation: view.Sum(),
+	}
+
+	BitswapBlockRequestsView = &view.View{
+		Measure:     BitswapBlockRequests,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{BitswapOutcome},
+	}
+	BitswapBytesServedView = &view.View{
+		Measure:     BitswapBytesServed,
+		Aggregation: view.Sum(),
+	}
+
+	DHTProvideCountView = &view.View{
+		Measure:     DHTProvideCount,
+		Aggregation: view.Count(),
+	}
+	DHTProvideFailureCountView = &view.View{
+		Measure:     DHTProvideFailureCount,
+		Aggregation: view.Count(),
+	}
+	DHTProvideDurationView = &view.View{
+		Measure:     DHTProvideDuration,
+		Aggregation: defaultMillisecondsDistribution,
+	}
+
+	// consensus fault reporter
+	ConsensusFaultDetectedView = &view.View{
+		Measure:     ConsensusFaultDetected,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{ConsensusFaultType},
+	}
+	ConsensusFaultReportedView = &view.View{
+		Measure:     ConsensusFaultReported,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{ConsensusFaultType},
+	}
+
+	// blockstore scrubber
+	BlockstoreScrubCheckedView = &view.View{
+		Measure:     BlockstoreScrubChecked,
+		Aggregation: view.Sum(),
+	}
+	BlockstoreScrubCorruptView = &view.View{
+		Measure:     BlockstoreScrubCorrupt,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{CorruptionType},
+	}
+	BlockstoreScrubRepairedView = &view.View{
+		Measure:     BlockstoreScrubRepaired,
+		Aggregation: view.Count(),
+	}
+
+	// call cache
+	CallCacheHitView = &view.View{
+		Measure:     CallCacheHit,
+		Aggregation: view.Count(),
+	}
+	CallCacheMissView = &view.View{
+		Measure:     CallCacheMiss,
+		Aggregation: view.Count(),
+	}
+
 	// splitstore
 	SplitstoreMissView = &view.View{
 		Measure:     SplitstoreMiss,
@@ -709,6 +838,10 @@ var ChainNodeViews = append([]*view.View{
 	BlockValidationSuccessView,
 	BlockValidationDurationView,
 	BlockDelayView,
+	BlockArrivalOffsetView,
+	BlockRelayDeprioritizedPeersView,
+	ChainExchangeRequestsView,
+	ChainExchangeThrottledPeersView,
 	IndexerMessageValidationFailureView,
 	IndexerMessageValidationSuccessView,
 	MessagePublishedView,
@@ -735,6 +868,13 @@ var ChainNodeViews = append([]*view.View{
 	SplitstoreCompactionHotView,
 	SplitstoreCompactionColdView,
 	SplitstoreCompactionDeadView,
+	CallCacheHitView,
+	CallCacheMissView,
+	ConsensusFaultDetectedView,
+	ConsensusFaultReportedView,
+	BlockstoreScrubCheckedView,
+	BlockstoreScrubCorruptView,
+	BlockstoreScrubRepairedView,
 	VMApplyBlocksTotalView,
 	VMApplyMessagesView,
 	VMApplyEarlyView,
@@ -779,6 +919,16 @@ var MinerNodeViews = append([]*view.View{
 	DagStorePRSeekForwardCountView,
 	DagStorePRSeekBackBytesView,
 	DagStorePRSeekForwardBytesView,
+
+	RetrievalCompletedCountView,
+	RetrievalBytesServedView,
+
+	BitswapBlockRequestsView,
+	BitswapBytesServedView,
+
+	DHTProvideCountView,
+	DHTProvideFailureCountView,
+	DHTProvideDurationView,
 }, DefaultViews...)
 
 var GatewayNodeViews = append([]*view.View{
@@ -0,0 +1,306 @@
+package miner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	"github.com/ipfs/go-datastore/query"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors/adt"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/reward"
+	"github.com/filecoin-project/lotus/chain/actors/policy"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// BlockJournalAPI is the chain access needed to resolve the status of mined
+// blocks.
+type BlockJournalAPI interface {
+	ChainHead(context.Context) (*types.TipSet, error)
+	ChainGetTipSetByHeight(context.Context, abi.ChainEpoch, types.TipSetKey) (*types.TipSet, error)
+}
+
+// BlockJournal persists a record of every election won by the miner.
+type BlockJournal struct {
+	lk sync.Mutex
+	ds datastore.Batching
+}
+
+func NewBlockJournal(ds datastore.Batching) *BlockJournal {
+	return &BlockJournal{
+		ds: namespace.Wrap(ds, datastore.NewKey("/blockjournal")),
+	}
+}
+
+func blockJournalKey(epoch abi.ChainEpoch) datastore.Key {
+	return datastore.NewKey(fmt.Sprint(epoch))
+}
+
+// Record stores the record of an election. A failure to publish a block
+// doesn't replace the record of a block already published at the same epoch.
+func (bj *BlockJournal) Record(ctx context.Context, rec api.MinedBlockRecord) error {
+	bj.lk.Lock()
+	defer bj.lk.Unlock()
+
+	if rec.Error != "" {
+		prev, err := bj.get(ctx, rec.Epoch)
+		if err != nil {
+			return err
+		}
+		if prev != nil && prev.Error == "" && prev.Block != nil {
+			return nil
+		}
+	}
+
+	return bj.put(ctx, rec)
+}
+
+func (bj *BlockJournal) get(ctx context.Context, epoch abi.ChainEpoch) (*api.MinedBlockRecord, error) {
+	b, err := bj.ds.Get(ctx, blockJournalKey(epoch))
+	if err == datastore.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("getting record of epoch %d: %w", epoch, err)
+	}
+
+	var rec api.MinedBlockRecord
+	if err := json.Unmarshal(b, &rec); err != nil {
+		return nil, xerrors.Errorf("decoding record of epoch %d: %w", epoch, err)
+	}
+	return &rec, nil
+}
+
+func (bj *BlockJournal) put(ctx context.Context, rec api.MinedBlockRecord) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return xerrors.Errorf("encoding record of epoch %d: %w", rec.Epoch, err)
+	}
+	if err := bj.ds.Put(ctx, blockJournalKey(rec.Epoch), b); err != nil {
+		return xerrors.Errorf("storing record of epoch %d: %w", rec.Epoch, err)
+	}
+	return nil
+}
+
+// Report returns the records of the elections won between from and to, with
+// the status of their blocks resolved against the chain. Statuses past
+// finality are persisted, so they don't have to be resolved again.
+func (bj *BlockJournal) Report(ctx context.Context, capi BlockJournalAPI, from, to abi.ChainEpoch) (*api.MinerBlocksReport, error) {
+	head, err := capi.ChainHead(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("getting chain head: %w", err)
+	}
+	if to <= 0 {
+		to = head.Height()
+	}
+	if from > to {
+		return nil, xerrors.Errorf("from epoch %d is after to epoch %d", from, to)
+	}
+
+	bj.lk.Lock()
+	defer bj.lk.Unlock()
+
+	res, err := bj.ds.Query(ctx, query.Query{})
+	if err != nil {
+		return nil, xerrors.Errorf("querying block journal: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	out := &api.MinerBlocksReport{
+		From:         from,
+		To:           to,
+		BlockRewards: big.Zero(),
+		GasRewards:   big.Zero(),
+		PenaltyRisk:  big.Zero(),
+		Blocks:       []api.MinedBlockRecord{},
+	}
+
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, xerrors.Errorf("iterating block journal: %w", r.Error)
+		}
+
+		var rec api.MinedBlockRecord
+		if err := json.Unmarshal(r.Value, &rec); err != nil {
+			return nil, xerrors.Errorf("decoding record %s: %w", r.Key, err)
+		}
+		if rec.Epoch < from || rec.Epoch > to {
+			continue
+		}
+
+		if !rec.Final {
+			if err := resolveBlockStatus(ctx, capi, head, &rec); err != nil {
+				return nil, err
+			}
+			if rec.Final {
+				if err := bj.put(ctx, rec); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		out.Won++
+		if rec.Block != nil && rec.Error == "" {
+			out.Produced++
+		}
+		switch rec.Status {
+		case api.MinedBlockIncluded:
+			out.Included++
+			out.BlockRewards = big.Add(out.BlockRewards, rec.BlockReward)
+			out.GasRewards = big.Add(out.GasRewards, rec.GasReward)
+			out.PenaltyRisk = big.Add(out.PenaltyRisk, rec.PenaltyRisk)
+		case api.MinedBlockOrphaned:
+			out.Orphaned++
+		}
+
+		out.Blocks = append(out.Blocks, rec)
+	}
+
+	sort.Slice(out.Blocks, func(i, j int) bool {
+		return out.Blocks[i].Epoch < out.Blocks[j].Epoch
+	})
+
+	return out, nil
+}
+
+func resolveBlockStatus(ctx context.Context, capi BlockJournalAPI, head *types.TipSet, rec *api.MinedBlockRecord) error {
+	if rec.Block == nil || rec.Error != "" {
+		rec.Status = api.MinedBlockFailed
+		rec.Final = true
+		return nil
+	}
+
+	if rec.Epoch > head.Height() {
+		rec.Status = api.MinedBlockPending
+		return nil
+	}
+
+	ts, err := capi.ChainGetTipSetByHeight(ctx, rec.Epoch, head.Key())
+	if err != nil {
+		return xerrors.Errorf("getting tipset at epoch %d: %w", rec.Epoch, err)
+	}
+
+	rec.Status = api.MinedBlockOrphaned
+	// a null round at the epoch returns the tipset before it
+	if ts.Height() == rec.Epoch {
+		for _, c := range ts.Cids() {
+			if c == *rec.Block {
+				rec.Status = api.MinedBlockIncluded
+				break
+			}
+		}
+	}
+
+	rec.Final = rec.Epoch+policy.ChainFinality < head.Height()
+	return nil
+}
+
+// blockMessageFees returns the premiums the messages pay to the miner of the
+// block including them, and the penalty the miner pays if the messages with a
+// fee cap below the base fee use all their gas.
+func blockMessageFees(msgs []*types.Message, baseFee abi.TokenAmount) (gasReward, penaltyRisk abi.TokenAmount) {
+	gasReward, penaltyRisk = big.Zero(), big.Zero()
+	for _, m := range msgs {
+		gasLimit := big.NewInt(m.GasLimit)
+
+		premium := big.Min(m.GasPremium, big.Sub(m.GasFeeCap, baseFee))
+		if premium.GreaterThan(big.Zero()) {
+			gasReward = big.Add(gasReward, big.Mul(premium, gasLimit))
+		}
+
+		if m.GasFeeCap.LessThan(baseFee) {
+			penaltyRisk = big.Add(penaltyRisk, big.Mul(big.Sub(baseFee, m.GasFeeCap), gasLimit))
+		}
+	}
+	return gasReward, penaltyRisk
+}
+
+// recordBlock records a won election in the block journal; b is the produced
+// block, if any, and err what prevented it from being published.
+func (m *Miner) recordBlock(ctx context.Context, base *MiningBase, winCount int64, b *types.BlockMsg, err error) {
+	if m.blocks == nil {
+		return
+	}
+
+	rec := api.MinedBlockRecord{
+		Epoch:       base.TipSet.Height() + base.NullRounds + 1,
+		Parents:     base.TipSet.Key(),
+		NullRounds:  base.NullRounds,
+		WinCount:    winCount,
+		Timestamp:   build.Clock.Now(),
+		BlockReward: big.Zero(),
+		GasReward:   big.Zero(),
+		PenaltyRisk: big.Zero(),
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+
+	if b != nil {
+		c := b.Header.Cid()
+		rec.Block = &c
+		rec.Messages = len(b.BlsMessages) + len(b.SecpkMessages)
+
+		if err == nil {
+			if err := m.blockRewards(ctx, base, b, &rec); err != nil {
+				log.Warnw("failed to compute rewards of mined block", "cid", c, "error", err)
+			}
+		}
+	}
+
+	if err := m.blocks.Record(ctx, rec); err != nil {
+		log.Errorw("failed to record mined block", "epoch", rec.Epoch, "error", err)
+	}
+}
+
+func (m *Miner) blockRewards(ctx context.Context, base *MiningBase, b *types.BlockMsg, rec *api.MinedBlockRecord) error {
+	rewardActor, err := m.api.StateGetActor(ctx, reward.Address, base.TipSet.Key())
+	if err != nil {
+		return xerrors.Errorf("getting reward actor: %w", err)
+	}
+
+	tbs := blockstore.NewTieredBstore(blockstore.NewAPIBlockstore(m.api), blockstore.NewMemory())
+	rewardState, err := reward.Load(adt.WrapStore(ctx, cbor.NewCborStore(tbs)), rewardActor)
+	if err != nil {
+		return xerrors.Errorf("loading reward actor state: %w", err)
+	}
+
+	epochReward, err := rewardState.ThisEpochReward()
+	if err != nil {
+		return xerrors.Errorf("getting epoch reward: %w", err)
+	}
+	rec.BlockReward = big.Div(big.Mul(epochReward, big.NewInt(rec.WinCount)), big.NewInt(int64(build.BlocksPerEpoch)))
+
+	var msgs []*types.Message
+	for _, mc := range append(append([]cid.Cid{}, b.BlsMessages...), b.SecpkMessages...) {
+		msg, err := m.api.ChainGetMessage(ctx, mc)
+		if err != nil {
+			return xerrors.Errorf("getting message %s: %w", mc, err)
+		}
+		msgs = append(msgs, msg)
+	}
+	rec.GasReward, rec.PenaltyRisk = blockMessageFees(msgs, b.Header.ParentBaseFee)
+
+	return nil
+}
+
+// BlocksReport returns the block production journal between the given epochs.
+func (m *Miner) BlocksReport(ctx context.Context, from, to abi.ChainEpoch) (*api.MinerBlocksReport, error) {
+	if m.blocks == nil {
+		return nil, xerrors.Errorf("block journal not enabled")
+	}
+	return m.blocks.Report(ctx, m.api, from, to)
+}
@@ -0,0 +1,139 @@
+// stm: #unit
+package miner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/policy"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+type fakeChain struct {
+	head    *types.TipSet
+	heights map[abi.ChainEpoch]*types.TipSet
+}
+
+func (fc *fakeChain) ChainHead(context.Context) (*types.TipSet, error) {
+	return fc.head, nil
+}
+
+func (fc *fakeChain) ChainGetTipSetByHeight(_ context.Context, h abi.ChainEpoch, _ types.TipSetKey) (*types.TipSet, error) {
+	for ; h >= 0; h-- {
+		if ts, ok := fc.heights[h]; ok {
+			return ts, nil
+		}
+	}
+	return nil, nil
+}
+
+func TestBlockJournalReport(t *testing.T) {
+	ctx := context.Background()
+
+	genesis := mock.TipSet(mock.MkBlock(nil, 1, 0))
+	ts1 := mock.TipSet(mock.MkBlock(genesis, 1, 1))
+	ts2 := mock.TipSet(mock.MkBlock(ts1, 1, 2))
+	// a block at the height of ts2 which didn't make it into the chain
+	orphan := mock.MkBlock(ts1, 1, 3).Cid()
+
+	fc := &fakeChain{
+		head: ts2,
+		heights: map[abi.ChainEpoch]*types.TipSet{
+			0: genesis,
+			1: ts1,
+			2: ts2,
+		},
+	}
+
+	bj := NewBlockJournal(ds.NewMapDatastore())
+	record := func(epoch abi.ChainEpoch, blk *cid.Cid, errMsg string) {
+		require.NoError(t, bj.Record(ctx, api.MinedBlockRecord{
+			Epoch:       epoch,
+			WinCount:    1,
+			Block:       blk,
+			Error:       errMsg,
+			BlockReward: big.NewInt(100),
+			GasReward:   big.NewInt(10),
+			PenaltyRisk: big.NewInt(1),
+		}))
+	}
+
+	included := ts1.Cids()[0]
+	record(1, &included, "")
+	record(2, &orphan, "")
+	record(3, nil, "computing winning post failed")
+	record(4, &orphan, "")
+	// a failure doesn't replace a block already published at the same epoch
+	record(1, nil, "duplicate")
+
+	rep, err := bj.Report(ctx, fc, 0, 4)
+	require.NoError(t, err)
+	require.Equal(t, 4, rep.Won)
+	require.Equal(t, 3, rep.Produced)
+	require.Equal(t, 1, rep.Included)
+	require.Equal(t, 1, rep.Orphaned)
+	require.Equal(t, big.NewInt(100), rep.BlockRewards)
+	require.Equal(t, big.NewInt(10), rep.GasRewards)
+	require.Equal(t, big.NewInt(1), rep.PenaltyRisk)
+
+	require.Len(t, rep.Blocks, 4)
+	require.Equal(t, api.MinedBlockIncluded, rep.Blocks[0].Status)
+	require.Equal(t, api.MinedBlockOrphaned, rep.Blocks[1].Status)
+	require.Equal(t, api.MinedBlockFailed, rep.Blocks[2].Status)
+	require.True(t, rep.Blocks[2].Final)
+	require.Equal(t, api.MinedBlockPending, rep.Blocks[3].Status)
+	require.False(t, rep.Blocks[0].Final)
+
+	rep, err = bj.Report(ctx, fc, 2, 3)
+	require.NoError(t, err)
+	require.Len(t, rep.Blocks, 2)
+	require.Equal(t, abi.ChainEpoch(2), rep.Blocks[0].Epoch)
+
+	_, err = bj.Report(ctx, fc, 3, 2)
+	require.Error(t, err)
+
+	// past finality the status is final and isn't resolved again
+	far := mock.MkBlock(ts2, 1, 4)
+	far.Height = policy.ChainFinality + 10
+	fc.head = mock.TipSet(far)
+
+	rep, err = bj.Report(ctx, fc, 1, 1)
+	require.NoError(t, err)
+	require.True(t, rep.Blocks[0].Final)
+
+	fc.heights = nil
+	rep, err = bj.Report(ctx, fc, 1, 1)
+	require.NoError(t, err)
+	require.Equal(t, api.MinedBlockIncluded, rep.Blocks[0].Status)
+}
+
+func TestBlockMessageFees(t *testing.T) {
+	baseFee := big.NewInt(100)
+	msg := func(feeCap, premium, gasLimit int64) *types.Message {
+		return &types.Message{
+			GasFeeCap:  big.NewInt(feeCap),
+			GasPremium: big.NewInt(premium),
+			GasLimit:   gasLimit,
+		}
+	}
+
+	gasReward, penaltyRisk := blockMessageFees([]*types.Message{
+		// full premium
+		msg(200, 10, 1000),
+		// premium capped by the fee cap
+		msg(105, 10, 1000),
+		// fee cap below the base fee
+		msg(90, 10, 1000),
+	}, baseFee)
+	require.Equal(t, big.NewInt(15000), gasReward)
+	require.Equal(t, big.NewInt(10000), penaltyRisk)
+}
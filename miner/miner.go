@@ -60,7 +60,7 @@ func randTimeOffset(width time.Duration) time.Duration {
 
 // NewMiner instantiates a miner with a concrete WinningPoStProver and a miner
 // address (which can be different from the worker's address).
-func NewMiner(api v1api.FullNode, epp gen.WinningPoStProver, addr address.Address, sf *slashfilter.SlashFilter, j journal.Journal) *Miner {
+func NewMiner(api v1api.FullNode, epp gen.WinningPoStProver, addr address.Address, sf *slashfilter.SlashFilter, bj *BlockJournal, j journal.Journal) *Miner {
 	arc, err := lru.NewARC[abi.ChainEpoch, bool](10000)
 	if err != nil {
 		panic(err)
@@ -92,6 +92,7 @@ func NewMiner(api v1api.FullNode, epp gen.WinningPoStProver, addr address.Addres
 		},
 
 		sf:                sf,
+		blocks:            bj,
 		minedBlockHeights: arc,
 		evtTypes: [...]journal.EventType{
 			evtTypeBlockMined: j.RegisterEventType("miner", "block_mined"),
@@ -124,6 +125,9 @@ type Miner struct {
 	// intended to avoid slashings in case of a bug.
 	minedBlockHeights *lru.ARCCache[abi.ChainEpoch, bool]
 
+	// blocks records the won elections and the blocks produced for them.
+	blocks *BlockJournal
+
 	evtTypes [1]journal.EventType
 	journal  journal.Journal
 }
@@ -327,6 +331,7 @@ minerLoop:
 			if err := m.sf.MinedBlock(ctx, b.Header, base.TipSet.Height()+base.NullRounds); err != nil {
 				log.Errorf("<!!> SLASH FILTER ERROR: %s", err)
 				if os.Getenv("LOTUS_MINER_NO_SLASHFILTER") != "_yes_i_know_i_can_and_probably_will_lose_all_my_fil_and_power_" {
+					m.recordBlock(ctx, base, b.Header.ElectionProof.WinCount, b, xerrors.Errorf("slash filter: %w", err))
 					continue
 				}
 			}
@@ -338,9 +343,11 @@ minerLoop:
 
 			m.minedBlockHeights.Add(b.Header.Height, true)
 
-			if err := m.api.SyncSubmitBlock(ctx, b); err != nil {
+			err := m.api.SyncSubmitBlock(ctx, b)
+			if err != nil {
 				log.Errorf("failed to submit newly mined block: %+v", err)
 			}
+			m.recordBlock(ctx, base, b.Header.ElectionProof.WinCount, b, err)
 		} else {
 			base.NullRounds++
 
@@ -469,6 +476,11 @@ func (m *Miner) mineOne(ctx context.Context, base *MiningBase) (minedBlock *type
 			"error", err,
 		}
 
+		// record the elections we won but failed to produce a block for
+		if winner != nil && err != nil {
+			m.recordBlock(ctx, base, winner.WinCount, nil, err)
+		}
+
 		if err != nil {
 			log.Errorw("completed mineOne", logStruct...)
 		} else if isLate || (hasMinPower && !mbi.EligibleForMining) {
@@ -34,6 +34,7 @@ func NewTestMiner(nextCh <-chan MineReq, addr address.Address) func(v1api.FullNo
 			minedBlockHeights: arc,
 			address:           addr,
 			sf:                slashfilter.New(ds.NewMapDatastore()),
+			blocks:            NewBlockJournal(ds.NewMapDatastore()),
 			journal:           journal.NilJournal(),
 		}
 
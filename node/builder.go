@@ -19,6 +19,7 @@ import (
 	"github.com/libp2p/go-libp2p/p2p/net/conngater"
 	"github.com/multiformats/go-multiaddr"
 	"go.uber.org/fx"
+	"go.uber.org/fx/fxevent"
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/lotus/api"
@@ -29,7 +30,9 @@ import (
 	"github.com/filecoin-project/lotus/journal"
 	"github.com/filecoin-project/lotus/journal/alerting"
 	"github.com/filecoin-project/lotus/lib/lotuslog"
+	"github.com/filecoin-project/lotus/lib/peerlist"
 	"github.com/filecoin-project/lotus/lib/peermgr"
+	"github.com/filecoin-project/lotus/lib/shutdown"
 	_ "github.com/filecoin-project/lotus/lib/sigs/bls"
 	_ "github.com/filecoin-project/lotus/lib/sigs/delegated"
 	_ "github.com/filecoin-project/lotus/lib/sigs/secp"
@@ -72,6 +75,8 @@ var (
 	DAGStoreKey          = special{13} // constructor returns multiple values
 	ResourceManagerKey   = special{14} // Libp2p option
 	UserAgentKey         = special{15} // Libp2p option
+	ReachabilityKey      = special{16} // Libp2p option
+	HolePunchingKey      = special{17} // Libp2p option
 )
 
 type invoke int
@@ -86,6 +91,7 @@ const (
 
 	// System processes.
 	InitMemoryWatchdog
+	ConfigureShutdownKey
 
 	// health checks
 	CheckFDLimit
@@ -109,6 +115,8 @@ const (
 	HandlePaymentChannelManagerKey
 
 	RelayIndexerMessagesKey
+	EnableCallCacheKey
+	SetReplayWorkersKey
 
 	// miner
 	PreflightChecksKey
@@ -116,7 +124,12 @@ const (
 	HandleMigrateProviderFundsKey
 	HandleDealsKey
 	HandleRetrievalKey
+	RunBitswapServerKey
+	RunDHTProviderKey
+	HandleAskScheduleKey
 	RunSectorServiceKey
+	ProbeSealingJobsKey
+	ProbeRetrievalsKey
 
 	// daemon
 	ExtractApiKey
@@ -173,6 +186,7 @@ func defaults() []Option {
 		}),
 
 		Override(new(dtypes.ShutdownChan), make(chan struct{})),
+		Override(new(*shutdown.Coordinator), shutdown.NewCoordinator),
 
 		// the great context in the sky, otherwise we can't DI build genesis; there has to be a better
 		// solution than this hack.
@@ -199,6 +213,7 @@ var LibP2P = Options(
 	Override(SecurityKey, lp2p.Security(true, false)),
 
 	// Host
+	Override(new(*lp2p.NATTracker), lp2p.NewNATTracker),
 	Override(new(lp2p.RawHost), lp2p.Host),
 	Override(new(host.Host), lp2p.RoutedHost),
 	Override(new(lp2p.BaseIpfsRouting), lp2p.DHTRouting(dht.ModeAuto)),
@@ -216,6 +231,7 @@ var LibP2P = Options(
 
 	// Services (pubsub)
 	Override(new(*dtypes.ScoreKeeper), lp2p.ScoreKeeper),
+	Override(new(*lp2p.RejectTracker), lp2p.NewRejectTracker),
 	Override(new(*pubsub.PubSub), lp2p.GossipSub),
 	Override(new(*config.Pubsub), func(bs dtypes.Bootstrapper) *config.Pubsub {
 		return &config.Pubsub{
@@ -227,6 +243,7 @@ var LibP2P = Options(
 	Override(ConnectionManagerKey, lp2p.ConnectionManager(50, 200, 20*time.Second, nil)),
 	Override(new(*conngater.BasicConnectionGater), lp2p.ConnGater),
 	Override(ConnGaterKey, lp2p.ConnGaterOption),
+	Override(new(*peerlist.AllowList), lp2p.AllowList),
 
 	// Services (resource management)
 	Override(new(network.ResourceManager), lp2p.ResourceManager(200)),
@@ -269,6 +286,7 @@ func ConfigCommon(cfg *config.Common, enableLibp2pNode bool) Option {
 		Override(new(dtypes.APIEndpoint), func() (dtypes.APIEndpoint, error) {
 			return multiaddr.NewMultiaddr(cfg.API.ListenAddress)
 		}),
+		Override(new(dtypes.ReadOnlyAPI), dtypes.ReadOnlyAPI(cfg.API.ReadOnly)),
 		Override(SetApiEndpointKey, func(lr repo.LockedRepo, e dtypes.APIEndpoint) error {
 			return lr.SetAPIEndpoint(e)
 		}),
@@ -306,8 +324,17 @@ func ConfigCommon(cfg *config.Common, enableLibp2pNode bool) Option {
 				cfg.Libp2p.NoAnnounceAddresses)),
 
 			If(!cfg.Libp2p.DisableNatPortMap, Override(NatPortMapKey, lp2p.NatPortMap)),
+			If(cfg.Libp2p.ForceReachability != "", Override(ReachabilityKey, lp2p.ForceReachability(cfg.Libp2p.ForceReachability))),
+			If(cfg.Libp2p.DisableAutoNATService, Unset(AutoNATSvcKey)),
+
+			Override(RelayKey, lp2p.Relay(
+				cfg.Libp2p.EnableRelayClient,
+				cfg.Libp2p.StaticRelays,
+				cfg.Libp2p.EnableRelayService)),
+			If(cfg.Libp2p.EnableHolePunching, Override(HolePunchingKey, lp2p.HolePunching(cfg.Libp2p.EnableRelayClient))),
 		),
 		Override(new(dtypes.MetadataDS), modules.Datastore(cfg.Backup.DisableMetadataLog)),
+		Override(ConfigureShutdownKey, modules.ConfigureShutdown(cfg.Shutdown)),
 	)
 }
 
@@ -369,14 +396,16 @@ func New(ctx context.Context, opts ...Option) (StopFunc, error) {
 		fx.Options(ctors...),
 		fx.Options(settings.invokes...),
 
-		fx.NopLogger,
+		// only records the stop hooks being executed, replace with
+		// fx.WithLogger(func() fxevent.Logger { return &fxevent.ConsoleLogger{W: os.Stderr} })
+		// for easier debugging
+		fx.WithLogger(func(c *shutdown.Coordinator) fxevent.Logger { return c.FxLogger() }),
 	)
 
 	// TODO: we probably should have a 'firewall' for Closing signal
 	//  on this context, and implement closing logic through lifecycles
 	//  correctly
 	if err := app.Start(ctx); err != nil {
-		// comment fx.NopLogger few lines above for easier debugging
 		return nil, xerrors.Errorf("starting node: %w", err)
 	}
 
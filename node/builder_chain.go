@@ -13,26 +13,42 @@ import (
 	"github.com/filecoin-project/go-fil-markets/storagemarket"
 
 	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/blockstore/maintenance"
+	"github.com/filecoin-project/lotus/blockstore/scrub"
 	"github.com/filecoin-project/lotus/chain"
+	"github.com/filecoin-project/lotus/chain/addrindex"
+	"github.com/filecoin-project/lotus/chain/attestation"
 	"github.com/filecoin-project/lotus/chain/beacon"
 	"github.com/filecoin-project/lotus/chain/consensus"
 	"github.com/filecoin-project/lotus/chain/consensus/filcns"
+	"github.com/filecoin-project/lotus/chain/eventjournal"
 	"github.com/filecoin-project/lotus/chain/events"
 	"github.com/filecoin-project/lotus/chain/exchange"
+	"github.com/filecoin-project/lotus/chain/gasstats"
 	"github.com/filecoin-project/lotus/chain/gen/slashfilter"
+	"github.com/filecoin-project/lotus/chain/gen/slashfilter/slashsvc"
 	"github.com/filecoin-project/lotus/chain/index"
 	"github.com/filecoin-project/lotus/chain/market"
 	"github.com/filecoin-project/lotus/chain/messagepool"
 	"github.com/filecoin-project/lotus/chain/messagesigner"
+	"github.com/filecoin-project/lotus/chain/powerhistory"
+	"github.com/filecoin-project/lotus/chain/replica"
+	"github.com/filecoin-project/lotus/chain/snapshot"
 	"github.com/filecoin-project/lotus/chain/stmgr"
 	rpcstmgr "github.com/filecoin-project/lotus/chain/stmgr/rpc"
 	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/sub/blocktiming"
 	"github.com/filecoin-project/lotus/chain/vm"
 	"github.com/filecoin-project/lotus/chain/wallet"
 	ledgerwallet "github.com/filecoin-project/lotus/chain/wallet/ledger"
 	"github.com/filecoin-project/lotus/chain/wallet/remotewallet"
+	"github.com/filecoin-project/lotus/chain/webhooks"
 	raftcns "github.com/filecoin-project/lotus/lib/consensus/raft"
+	"github.com/filecoin-project/lotus/lib/diskforecast"
 	"github.com/filecoin-project/lotus/lib/peermgr"
+	"github.com/filecoin-project/lotus/markets/allocations"
+	"github.com/filecoin-project/lotus/markets/reputation"
 	"github.com/filecoin-project/lotus/markets/retrievaladapter"
 	"github.com/filecoin-project/lotus/markets/storageadapter"
 	"github.com/filecoin-project/lotus/node/config"
@@ -42,6 +58,7 @@ import (
 	"github.com/filecoin-project/lotus/node/modules"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 	"github.com/filecoin-project/lotus/node/repo"
+	"github.com/filecoin-project/lotus/node/tenancy"
 	"github.com/filecoin-project/lotus/paychmgr"
 	"github.com/filecoin-project/lotus/paychmgr/settler"
 	"github.com/filecoin-project/lotus/storage/sealer/ffiwrapper"
@@ -114,6 +131,7 @@ var ChainNode = Options(
 	Override(new(*wallet.LocalWallet), wallet.NewWallet),
 	Override(new(wallet.Default), From(new(*wallet.LocalWallet))),
 	Override(new(api.Wallet), From(new(wallet.MultiWallet))),
+	Override(new(*tenancy.Registry), tenancy.NewRegistry),
 
 	// Service: Payment channels
 	Override(new(paychmgr.PaychAPI), From(new(modules.PaychAPI))),
@@ -132,8 +150,14 @@ var ChainNode = Options(
 	Override(new(dtypes.ClientDataTransfer), modules.NewClientGraphsyncDataTransfer),
 
 	// Markets (storage)
+	Override(new(market.FundManagerConfig), modules.ClientFundManagerConfig(config.DefaultFullNode().Client.Funds)),
 	Override(new(*market.FundManager), market.NewFundManager),
 	Override(new(dtypes.ClientDatastore), modules.NewClientDatastore),
+	Override(new(dtypes.ClientReplicationDatastore), modules.NewClientReplicationDatastore),
+	Override(new(dtypes.ClientRetrievalCheckpointDatastore), modules.NewClientRetrievalCheckpointDatastore),
+	Override(new(dtypes.ClientDealTemplatesDatastore), modules.NewClientDealTemplatesDatastore),
+	Override(new(dtypes.ClientDataPrepDatastore), modules.NewClientDataPrepDatastore),
+	Override(new(*reputation.Store), modules.ClientReputationStore),
 	Override(new(storagemarket.BlockstoreAccessor), modules.StorageBlockstoreAccessor),
 	Override(new(*retrievaladapter.APIBlockstoreAccessor), retrievaladapter.NewAPIBlockstoreAdapter),
 	Override(new(storagemarket.StorageClient), modules.StorageClient),
@@ -171,6 +195,7 @@ var ChainNode = Options(
 		Override(RunChainExchangeKey, modules.RunChainExchange),
 		Override(RunPeerMgrKey, modules.RunPeerMgr),
 		Override(HandleIncomingMessagesKey, modules.HandleIncomingMessages),
+		Override(new(*blocktiming.Tracker), modules.BlockTiming(config.BlockRelayConfig{})),
 		Override(HandleIncomingBlocksKey, modules.HandleIncomingBlocks),
 	),
 )
@@ -188,6 +213,7 @@ func ConfigFullNode(c interface{}) Option {
 		ConfigCommon(&cfg.Common, enableLibp2pNode),
 
 		Override(new(dtypes.UniversalBlockstore), modules.UniversalBlockstore),
+		Override(new(*diskforecast.Tracker), modules.FullNodeDiskForecast(cfg.DiskForecast)),
 
 		If(cfg.Chainstore.EnableSplitstore,
 			If(cfg.Chainstore.Splitstore.ColdStoreType == "universal" || cfg.Chainstore.Splitstore.ColdStoreType == "messages",
@@ -211,12 +237,18 @@ func ConfigFullNode(c interface{}) Option {
 			Override(new(dtypes.GCReferenceProtector), modules.NoopGCReferenceProtector),
 		),
 
-		Override(new(dtypes.ChainBlockstore), From(new(dtypes.BasicChainBlockstore))),
-		Override(new(dtypes.StateBlockstore), From(new(dtypes.BasicStateBlockstore))),
+		Override(new(*maintenance.Scheduler), modules.BlockstoreMaintenance(&cfg.Chainstore)),
+		If(cfg.Chainstore.Scrubber.EnableScrubber,
+			Override(new(*scrub.Scrubber), modules.BlockstoreScrubber(&cfg.Chainstore))),
+
+		Override(new(dtypes.CarOverlayBlockstore), modules.CarOverlayBlockstore(cfg.Chainstore.CarOverlays)),
+		Override(new(dtypes.ChainBlockstore), modules.OverlayChainBlockstore),
+		Override(new(dtypes.StateBlockstore), modules.OverlayStateBlockstore(cfg.Chainstore.Caches)),
+		Override(new(*blockstore.CacheTuner), modules.ChainCaches(cfg.Chainstore.Caches)),
 
 		If(os.Getenv("LOTUS_ENABLE_CHAINSTORE_FALLBACK") == "1",
 			Override(new(dtypes.ChainBlockstore), modules.FallbackChainBlockstore),
-			Override(new(dtypes.StateBlockstore), modules.FallbackStateBlockstore),
+			Override(new(dtypes.StateBlockstore), modules.FallbackStateBlockstore(cfg.Chainstore.Caches)),
 			Override(SetupFallbackBlockstoresKey, modules.InitFallbackBlockstores),
 		),
 
@@ -239,6 +271,8 @@ func ConfigFullNode(c interface{}) Option {
 		Override(new(dtypes.Graphsync), modules.Graphsync(cfg.Client.SimultaneousTransfersForStorage, cfg.Client.SimultaneousTransfersForRetrieval)),
 
 		Override(new(retrievalmarket.RetrievalClient), modules.RetrievalClient(cfg.Client.OffChainRetrieval)),
+		Override(new(*allocations.Manager), modules.ClientAllocations(cfg.Client.Allocations)),
+		Override(new(market.FundManagerConfig), modules.ClientFundManagerConfig(cfg.Client.Funds)),
 
 		If(cfg.Wallet.RemoteBackend != "",
 			Override(new(*remotewallet.RemoteWallet), remotewallet.SetupRemoteWallet(cfg.Wallet.RemoteBackend)),
@@ -269,6 +303,7 @@ func ConfigFullNode(c interface{}) Option {
 		ApplyIf(isFullNode,
 			If(cfg.Fevm.EnableEthRPC,
 				Override(new(full.EthModuleAPI), modules.EthModuleAPI(cfg.Fevm)),
+				Override(new(dtypes.DisabledEthNamespaces), modules.DisabledEthNamespaces(cfg.Fevm)),
 				Override(new(full.EthEventAPI), modules.EthEventAPI(cfg.Fevm)),
 			),
 			If(!cfg.Fevm.EnableEthRPC,
@@ -280,6 +315,58 @@ func ConfigFullNode(c interface{}) Option {
 		// enable message index for full node when configured by the user, otherwise use dummy.
 		If(cfg.Index.EnableMsgIndex, Override(new(index.MsgIndex), modules.MsgIndex)),
 		If(!cfg.Index.EnableMsgIndex, Override(new(index.MsgIndex), modules.DummyMsgIndex)),
+		If(cfg.Index.EnableChainJournal, Override(new(*eventjournal.Journal), modules.ChainJournal)),
+		If(cfg.Index.EnableGasStats, Override(new(*gasstats.Stats), modules.GasStats(cfg.Index))),
+		If(cfg.Index.EnablePowerHistory, Override(new(*powerhistory.History), modules.PowerHistory(cfg.Index))),
+		If(cfg.Index.EnableAddressIndex, Override(new(*addrindex.Index), modules.AddressIndex(cfg.Index))),
+
+		// memoize read-only calls when configured by the user.
+		If(cfg.CallCache.EnableCallCache, Override(EnableCallCacheKey, modules.StateManagerCallCache(cfg.CallCache))),
+		Override(SetReplayWorkersKey, modules.StateManagerReplayWorkers(cfg.Execution)),
+
+		// sign message attestations when configured by the user.
+		If(cfg.Attestation.EnableAttestation, Override(new(*attestation.Attestor), modules.Attestor(cfg.Attestation))),
+		If(cfg.Webhooks.EnableWebhooks, Override(new(*webhooks.Manager), modules.Webhooks(cfg.Webhooks))),
+
+		// watch the incoming blocks for consensus faults when configured by the user.
+		ApplyIf(isFullNode,
+			If(cfg.FaultReporter.EnableConsensusFaultReporter,
+				Override(new(*slashsvc.Reporter), modules.ConsensusFaultReporter(cfg.FaultReporter)),
+			),
+		),
+
+		// record the arrival times of the blocks relayed by each peer, and deprioritize the peers
+		// relaying blocks late when configured by the user.
+		ApplyIf(isFullNode,
+			Override(new(*blocktiming.Tracker), modules.BlockTiming(cfg.BlockRelay)),
+		),
+
+		// replicate the chain and the message pool to hot standbys, or follow a primary, when
+		// configured by the user.
+		ApplyIf(isFullNode,
+			If(cfg.Replication.EnableServer,
+				Override(new(*replica.Server), modules.ReplicaServer(cfg.Replication)),
+			),
+			If(cfg.Replication.Primary != "",
+				Override(new(*replica.Follower), modules.ReplicaFollower(cfg.Replication)),
+				Override(new(*chain.Syncer), modules.StandbySyncer),
+				Override(RunHelloKey, modules.RunStandbyHello),
+				Override(HandleIncomingBlocksKey, modules.HandleStandbyIncomingBlocks),
+				Override(HandleIncomingMessagesKey, modules.HandleStandbyIncomingMessages),
+			),
+		),
+
+		// rate limit the chain exchange requests of each peer when configured by the user.
+		ApplyIf(isFullNode,
+			Override(new(exchange.Server), modules.ChainExchangeServer(cfg.ChainExchange)),
+		),
+
+		// export snapshots of the chain on a schedule and serve them when configured by the user.
+		ApplyIf(isFullNode,
+			If(cfg.Snapshots.EnableSnapshots,
+				Override(new(*snapshot.Service), modules.Snapshots(cfg.Snapshots)),
+			),
+		),
 	)
 }
 
@@ -19,10 +19,20 @@ import (
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/gen"
 	"github.com/filecoin-project/lotus/chain/gen/slashfilter"
+	"github.com/filecoin-project/lotus/lib/diskforecast"
+	"github.com/filecoin-project/lotus/markets/askschedule"
+	"github.com/filecoin-project/lotus/markets/commpdiag"
 	"github.com/filecoin-project/lotus/markets/dagstore"
 	"github.com/filecoin-project/lotus/markets/dealfilter"
+	"github.com/filecoin-project/lotus/markets/dealsla"
+	"github.com/filecoin-project/lotus/markets/dtprogress"
+	"github.com/filecoin-project/lotus/markets/dtrestart"
 	"github.com/filecoin-project/lotus/markets/idxprov"
+	"github.com/filecoin-project/lotus/markets/labels"
+	"github.com/filecoin-project/lotus/markets/retrievability"
 	"github.com/filecoin-project/lotus/markets/retrievaladapter"
+	"github.com/filecoin-project/lotus/markets/retrievalstats"
+	"github.com/filecoin-project/lotus/markets/retrievaltimeline"
 	"github.com/filecoin-project/lotus/markets/sectoraccessor"
 	"github.com/filecoin-project/lotus/markets/storageadapter"
 	"github.com/filecoin-project/lotus/miner"
@@ -33,12 +43,18 @@ import (
 	"github.com/filecoin-project/lotus/node/repo"
 	"github.com/filecoin-project/lotus/storage"
 	"github.com/filecoin-project/lotus/storage/ctladdr"
+	"github.com/filecoin-project/lotus/storage/disputewatch"
+	"github.com/filecoin-project/lotus/storage/faultdetect"
 	"github.com/filecoin-project/lotus/storage/paths"
 	sealing "github.com/filecoin-project/lotus/storage/pipeline"
+	"github.com/filecoin-project/lotus/storage/pipeline/sectordb"
 	sectorstorage "github.com/filecoin-project/lotus/storage/sealer"
 	"github.com/filecoin-project/lotus/storage/sealer/ffiwrapper"
 	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+	"github.com/filecoin-project/lotus/storage/sealer/tasklog"
 	"github.com/filecoin-project/lotus/storage/sectorblocks"
+	"github.com/filecoin-project/lotus/storage/spend"
+	"github.com/filecoin-project/lotus/storage/unsealregen"
 	"github.com/filecoin-project/lotus/storage/wdpost"
 )
 
@@ -78,7 +94,8 @@ func ConfigStorageMiner(c interface{}) Option {
 
 	return Options(
 
-		Override(new(v1api.FullNode), modules.MakeUuidWrapper),
+		Override(new(*spend.Ledger), modules.SpendLedger),
+		Override(new(v1api.FullNode), modules.MakeSpendTrackingUuidWrapper),
 		// Needed to instantiate pubsub used by index provider via ConfigCommon
 		Override(new(dtypes.DrandSchedule), modules.BuiltinDrandConfig),
 		Override(new(dtypes.BootstrapPeers), modules.BuiltinBootstrap),
@@ -93,9 +110,11 @@ func ConfigStorageMiner(c interface{}) Option {
 		Override(new(*paths.Remote), modules.RemoteStorage),
 		Override(new(paths.Store), From(new(*paths.Remote))),
 		Override(new(dtypes.RetrievalPricingFunc), modules.RetrievalPricingFunc(cfg.Dealmaking)),
+		Override(new(*diskforecast.Tracker), modules.MinerDiskForecast(cfg.DiskForecast, cfg.DAGStore)),
 
 		If(cfg.Subsystems.EnableMining || cfg.Subsystems.EnableSealing,
-			Override(GetParamsKey, modules.GetParams(!cfg.Proving.DisableBuiltinWindowPoSt || !cfg.Proving.DisableBuiltinWinningPoSt || cfg.Storage.AllowCommit || cfg.Storage.AllowProveReplicaUpdate2)),
+			Override(new(*modules.ProofParams), modules.NewProofParams(!cfg.Proving.DisableBuiltinWindowPoSt || !cfg.Proving.DisableBuiltinWinningPoSt || cfg.Storage.AllowCommit || cfg.Storage.AllowProveReplicaUpdate2, cfg.ProofParams)),
+			Override(GetParamsKey, modules.GetParams),
 		),
 
 		If(!cfg.Subsystems.EnableMining,
@@ -120,8 +139,10 @@ func ConfigStorageMiner(c interface{}) Option {
 			Override(new(gen.WinningPoStProver), storage.NewWinningPoStProver),
 			Override(PreflightChecksKey, modules.PreflightChecks),
 			Override(new(*sealing.Sealing), modules.SealingPipeline(cfg.Fees)),
+			If(cfg.Sealing.EnableSectorDB, Override(new(*sectordb.DB), modules.SectorDB)),
 
 			Override(new(*wdpost.WindowPoStScheduler), modules.WindowPostScheduler(cfg.Fees, cfg.Proving)),
+			Override(new(*ctladdr.BalanceMonitor), modules.BalanceMonitor(cfg.Addresses.BalanceMonitor)),
 			Override(new(sectorblocks.SectorBuilder), From(new(*sealing.Sealing))),
 		),
 
@@ -133,6 +154,20 @@ func ConfigStorageMiner(c interface{}) Option {
 			Override(new(sectorstorage.Unsealer), From(new(*sectorstorage.Manager))),
 			Override(new(sectorstorage.SectorManager), From(new(*sectorstorage.Manager))),
 			Override(new(storiface.WorkerReturn), From(new(sectorstorage.SectorManager))),
+			Override(ProbeSealingJobsKey, modules.ProbeSealingJobs),
+			If(cfg.Storage.TaskLogRetention > 0, Override(new(*tasklog.Store), modules.TaskLogStore(cfg.Storage))),
+		),
+
+		If(cfg.Subsystems.EnableSealing && cfg.Subsystems.EnableSectorStorage && cfg.Sealing.RegenerateUnsealed,
+			Override(new(*unsealregen.Regenerator), modules.UnsealRegenerator(cfg.Sealing)),
+		),
+
+		If(cfg.Subsystems.EnableMining && cfg.Proving.EnableFaultDetection,
+			Override(new(*faultdetect.Detector), modules.FaultDetector(cfg.Proving)),
+		),
+
+		If(cfg.Subsystems.EnableMining && cfg.Proving.EnableDisputeWatcher,
+			Override(new(*disputewatch.Watcher), modules.DisputeWatcher),
 		),
 
 		If(!cfg.Subsystems.EnableSectorStorage,
@@ -166,6 +201,12 @@ func ConfigStorageMiner(c interface{}) Option {
 			// DAG Store
 			Override(new(dagstore.MinerAPI), modules.NewMinerAPI(cfg.DAGStore)),
 			Override(DAGStoreKey, modules.DAGStore(cfg.DAGStore)),
+			If(cfg.DAGStore.RetrievabilitySampling,
+				Override(new(*retrievability.Sampler), modules.RetrievabilitySampler(cfg.DAGStore)),
+			),
+
+			// Markets state
+			Override(new(dtypes.MarketsDS), modules.MarketsDatastore(cfg.Dealmaking.Datastore)),
 
 			// Markets (retrieval)
 			Override(new(dagstore.SectorAccessor), sectoraccessor.NewSectorAccessor),
@@ -173,21 +214,41 @@ func ConfigStorageMiner(c interface{}) Option {
 			Override(new(retrievalmarket.RetrievalProviderNode), retrievaladapter.NewRetrievalProviderNode),
 			Override(new(rmnet.RetrievalMarketNetwork), modules.RetrievalNetwork),
 			Override(new(retrievalmarket.RetrievalProvider), modules.RetrievalProvider),
-			Override(new(dtypes.RetrievalDealFilter), modules.RetrievalDealFilter(nil)),
+			Override(new(dtypes.RetrievalDealFilter), modules.RetrievalDealFilter),
+			Override(new(*retrievalstats.Tracker), retrievalstats.NewTracker),
+			Override(new(*retrievaltimeline.Recorder), modules.NewRetrievalTimelines),
 			Override(HandleRetrievalKey, modules.HandleRetrieval),
+			Override(ProbeRetrievalsKey, modules.ProbeRetrievals),
 
 			// Markets (storage)
 			Override(new(dtypes.ProviderTransferNetwork), modules.NewProviderTransferNetwork),
 			Override(new(dtypes.ProviderTransport), modules.NewProviderTransport),
 			Override(new(dtypes.ProviderDataTransfer), modules.NewProviderDataTransfer),
+			If(cfg.Dealmaking.TransferRestart.StallTimeout > 0,
+				Override(new(*dtrestart.Monitor), modules.DataTransferRestarter(cfg.Dealmaking.TransferRestart)),
+			),
+			Override(new(*dtprogress.Tracker), modules.DealTransferProgress(cfg.Dealmaking.TransferRestart)),
 			Override(new(idxprov.MeshCreator), idxprov.NewMeshCreator),
 			Override(new(provider.Interface), modules.IndexProvider(cfg.IndexProvider)),
 			Override(new(*storedask.StoredAsk), modules.NewStorageAsk),
+			Override(new(*askschedule.Manager), modules.NewAskSchedule),
+			Override(HandleAskScheduleKey, modules.HandleAskSchedule),
 			Override(new(dtypes.StorageDealFilter), modules.BasicDealFilter(cfg.Dealmaking, nil)),
 			Override(new(storagemarket.StorageProvider), modules.StorageProvider),
 			Override(new(*storageadapter.DealPublisher), storageadapter.NewDealPublisher(nil, storageadapter.PublishMsgConfig{})),
 			Override(HandleMigrateProviderFundsKey, modules.HandleMigrateProviderFunds),
+			Override(new(*commpdiag.Recorder), modules.NewCommPDiagnostics),
+			If(cfg.Dealmaking.SealingSLA > 0,
+				Override(new(*dealsla.Tracker), modules.DealSLATracker(cfg.Dealmaking)),
+			),
+			Override(new(*labels.Store), modules.NewLabelStore),
 			Override(HandleDealsKey, modules.HandleDeals),
+			If(cfg.Bitswap.Enable,
+				Override(RunBitswapServerKey, modules.BitswapServer(cfg.Bitswap)),
+			),
+			If(cfg.DHTProvider.Enable,
+				Override(RunDHTProviderKey, modules.DHTProvider(cfg.DHTProvider)),
+			),
 
 			// Config (todo: get a real property system)
 			Override(new(dtypes.ConsiderOnlineStorageDealsConfigFunc), modules.NewConsiderOnlineStorageDealsConfigFunc),
@@ -214,7 +275,7 @@ func ConfigStorageMiner(c interface{}) Option {
 			),
 
 			If(cfg.Dealmaking.RetrievalFilter != "",
-				Override(new(dtypes.RetrievalDealFilter), modules.RetrievalDealFilter(dealfilter.CliRetrievalDealFilter(cfg.Dealmaking.RetrievalFilter))),
+				Override(new(dealfilter.RetrievalFilter), dealfilter.CliRetrievalFilter(cfg.Dealmaking.RetrievalFilter)),
 			),
 			Override(new(*storageadapter.DealPublisher), storageadapter.NewDealPublisher(&cfg.Fees, storageadapter.PublishMsgConfig{
 				Period:                  time.Duration(cfg.Dealmaking.PublishMsgPeriod),
@@ -25,6 +25,15 @@ const (
 	RetrievalPricingExternalMode = "external"
 )
 
+const (
+	// MarketsDatastoreMetadata keeps the markets state in the metadata datastore of the repo.
+	MarketsDatastoreMetadata = "metadata"
+	// MarketsDatastoreSQLite keeps the markets state in a table of an SQLite database.
+	MarketsDatastoreSQLite = "sqlite"
+	// MarketsDatastorePostgres keeps the markets state in a table of a Postgres database.
+	MarketsDatastorePostgres = "postgres"
+)
+
 // MaxTraversalLinks configures the maximum number of links to traverse in a DAG while calculating
 // CommP and traversing a DAG with graphsync; invokes a budget on DAG depth and density.
 var MaxTraversalLinks uint64 = 32 * (1 << 20)
@@ -69,6 +78,14 @@ func defCommon() Common {
 			Bootstrapper: false,
 			DirectPeers:  nil,
 		},
+		DiskForecast: DiskForecast{
+			SampleInterval: Duration(10 * time.Minute),
+			Window:         Duration(72 * time.Hour),
+		},
+		Shutdown: Shutdown{
+			RPCGracePeriod:  Duration(30 * time.Second),
+			NodeGracePeriod: Duration(10 * time.Minute),
+		},
 	}
 }
 
@@ -87,6 +104,15 @@ func DefaultFullNode() *FullNode {
 		Client: Client{
 			SimultaneousTransfersForStorage:   DefaultSimultaneousTransfers,
 			SimultaneousTransfersForRetrieval: DefaultSimultaneousTransfers,
+			Allocations: ClientAllocationsConfig{
+				CheckInterval: Duration(time.Hour),
+				ExpiryWarning: Duration(7 * 24 * time.Hour),
+			},
+			Funds: ClientFundsConfig{
+				TopUpBuffer:  types.MustParseFIL("0"),
+				WithdrawKeep: types.MustParseFIL("0"),
+				WithdrawMin:  types.MustParseFIL("0.01"),
+			},
 		},
 		Chainstore: Chainstore{
 			EnableSplitstore: true,
@@ -100,11 +126,29 @@ func DefaultFullNode() *FullNode {
 				HotStoreMaxSpaceThreshold:    150_000_000_000,
 				HotstoreMaxSpaceSafetyBuffer: 50_000_000_000,
 			},
+			Maintenance: BlockstoreMaintenanceConfig{
+				Mode:        "vlog-gc",
+				MinInterval: Duration(12 * time.Hour),
+			},
+			Scrubber: BlockstoreScrubberConfig{
+				EnableScrubber:     false,
+				Interval:           Duration(7 * 24 * time.Hour),
+				MaxBlocksPerSecond: 2000,
+			},
+			Caches: ChainCachesConfig{
+				StateBlockCacheSize:    0,
+				EnableAdaptive:         false,
+				AdaptiveMaxFactor:      4,
+				AdaptiveMinLookupRate:  1000,
+				AdaptiveTargetHitRatio: 0.9,
+			},
 		},
 		Cluster: *DefaultUserRaftConfig(),
 		Fevm: FevmConfig{
 			EnableEthRPC:                 false,
 			EthTxHashMappingLifetimeDays: 0,
+			DisabledNamespaces:           []string{},
+			CompatibilityProfile:         "geth",
 			Events: Events{
 				DisableRealTimeFilterAPI: false,
 				DisableHistoricFilterAPI: false,
@@ -114,6 +158,61 @@ func DefaultFullNode() *FullNode {
 				MaxFilterHeightRange:     2880, // conservative limit of one day
 			},
 		},
+		Index: IndexConfig{
+			GasStatsRetention:    20160, // a week
+			AddressIndexBackfill: 2880,  // a day
+			PowerHistoryInterval: 120,   // an hour
+			PowerHistoryBackfill: 2880,  // a day
+		},
+		CallCache: CallCacheConfig{
+			EnableCallCache: false,
+			MaxMemoryBytes:  256 << 20,
+		},
+		Attestation: AttestationConfig{
+			EnableAttestation: false,
+			FinalityEpochs:    uint64(policy.ChainFinality),
+		},
+		Webhooks: WebhooksConfig{
+			EnableWebhooks: false,
+			MaxAttempts:    10,
+			MinBackoff:     Duration(time.Second),
+			MaxBackoff:     Duration(10 * time.Minute),
+			RequestTimeout: Duration(30 * time.Second),
+		},
+		FaultReporter: FaultReporterConfig{
+			EnableConsensusFaultReporter:  false,
+			ConsensusFaultReporterAddress: "",
+		},
+		Replication: ReplicationConfig{
+			EnableServer:      false,
+			AllowedPeers:      []string{},
+			MaxFollowsPerHour: 60,
+			Primary:           "",
+		},
+		BlockRelay: BlockRelayConfig{
+			EnableRelayPolicy: false,
+			LateThreshold:     0,
+			LateRatio:         0.5,
+			MinBlocks:         10,
+		},
+		Snapshots: SnapshotsConfig{
+			EnableSnapshots:  false,
+			Path:             "",
+			Interval:         Duration(24 * time.Hour),
+			Keep:             3,
+			RecentStateRoots: 2000,
+			ListenAddress:    "127.0.0.1:1237",
+		},
+		ChainExchange: ChainExchangeConfig{
+			RequestsPerSecond: 0,
+			RequestBurst:      10,
+			TipsetsPerSecond:  0,
+			TipsetBurst:       2000,
+			MaxRequestLength:  0,
+		},
+		Execution: ExecutionConfig{
+			ReplayWorkers: 1,
+		},
 	}
 }
 
@@ -150,17 +249,24 @@ func DefaultStorageMiner() *StorageMiner {
 
 			BatchPreCommitAboveBaseFee: types.FIL(types.BigMul(types.PicoFil, types.NewInt(320))), // 0.32 nFIL
 			AggregateAboveBaseFee:      types.FIL(types.BigMul(types.PicoFil, types.NewInt(320))), // 0.32 nFIL
+			CommitAggregationPolicy:    "basefee",
 
 			TerminateBatchMin:                      1,
 			TerminateBatchMax:                      100,
 			TerminateBatchWait:                     Duration(5 * time.Minute),
 			MaxSectorProveCommitsSubmittedPerEpoch: 20,
+
+			RegenerateUnsealedMaxConcurrent: 1,
+			RegenerateUnsealedCheckInterval: Duration(30 * time.Minute),
 		},
 
 		Proving: ProvingConfig{
 			ParallelCheckLimit:    32,
 			PartitionCheckTimeout: Duration(20 * time.Minute),
 			SingleCheckTimeout:    Duration(10 * time.Minute),
+
+			FaultDetectionGracePeriod:   Duration(10 * time.Minute),
+			FaultDetectionCheckInterval: Duration(2 * time.Minute),
 		},
 
 		Storage: SealerConfig{
@@ -182,6 +288,8 @@ func DefaultStorageMiner() *StorageMiner {
 
 			// By default use the hardware resource filtering strategy.
 			ResourceFiltering: ResourceFilteringHardware,
+
+			TaskLogRetention: Duration(7 * 24 * time.Hour),
 		},
 
 		Dealmaking: DealmakingConfig{
@@ -204,6 +312,7 @@ func DefaultStorageMiner() *StorageMiner {
 			SimultaneousTransfersForRetrieval:        DefaultSimultaneousTransfers,
 
 			StartEpochSealingBuffer: 480, // 480 epochs buffer == 4 hours from adding deal to sector to sector being sealed
+			SealingSLA:              Duration(72 * time.Hour),
 
 			RetrievalPricing: &RetrievalPricing{
 				Strategy: RetrievalPricingDefaultMode,
@@ -214,6 +323,19 @@ func DefaultStorageMiner() *StorageMiner {
 					Path: "",
 				},
 			},
+
+			TransferRestart: DataTransferRestartConfig{
+				StallTimeout:   0,
+				InitialBackoff: Duration(time.Minute),
+				MaxBackoff:     Duration(30 * time.Minute),
+				MaxRestarts:    10,
+			},
+
+			Datastore: DealmakingDatastoreConfig{
+				Backend:    MarketsDatastoreMetadata,
+				SQLitePath: "markets.sqlite",
+				Table:      "markets_datastore",
+			},
 		},
 
 		IndexProvider: IndexProviderConfig{
@@ -224,6 +346,27 @@ func DefaultStorageMiner() *StorageMiner {
 			// format: "/indexer/ingest/<network-name>"
 			TopicName:         "",
 			PurgeCacheOnStart: false,
+			HttpPublisher: IndexProviderHttpPublisherConfig{
+				Enabled:        false,
+				PublicHostname: "",
+				Port:           3104,
+			},
+		},
+
+		Bitswap: MinerBitswapConfig{
+			Enable:                     false,
+			RequestsPerPeerPerSecond:   100,
+			RequestBurstPerPeer:        200,
+			MaxOutstandingBytesPerPeer: 1 << 20,
+		},
+
+		DHTProvider: DHTProviderConfig{
+			Enable:              false,
+			AnnouncePieceCIDs:   true,
+			AnnouncePayloadCIDs: true,
+			ProvidesPerSecond:   10,
+			Workers:             4,
+			ReprovideInterval:   Duration(22 * time.Hour),
 		},
 
 		Subsystems: MinerSubsystemConfig{
@@ -250,6 +393,10 @@ func DefaultStorageMiner() *StorageMiner {
 			MaxWindowPoStGasFee:    types.MustParseFIL("5"),
 			MaxPublishDealsFee:     types.MustParseFIL("0.05"),
 			MaxMarketBalanceAddFee: types.MustParseFIL("0.007"),
+
+			PreCommitBaseFeeCap:  types.MustParseFIL("0"),
+			CommitBaseFeeCap:     types.MustParseFIL("0"),
+			WindowPoStBaseFeeCap: types.MustParseFIL("0"),
 		},
 
 		Addresses: MinerAddressConfig{
@@ -257,6 +404,14 @@ func DefaultStorageMiner() *StorageMiner {
 			CommitControl:      []string{},
 			TerminateControl:   []string{},
 			DealPublishControl: []string{},
+
+			BalanceMonitor: MinerBalanceMonitorConfig{
+				CheckInterval:  Duration(10 * time.Minute),
+				LowWater:       types.MustParseFIL("1"),
+				RefillTo:       types.MustParseFIL("0"),
+				DailyRefillCap: types.MustParseFIL("10"),
+				MaxRefillFee:   types.MustParseFIL("0.01"),
+			},
 		},
 
 		DAGStore: DAGStoreConfig{
@@ -264,6 +419,10 @@ func DefaultStorageMiner() *StorageMiner {
 			MaxConcurrencyStorageCalls: 100,
 			MaxConcurrentUnseals:       5,
 			GCInterval:                 Duration(1 * time.Minute),
+
+			RetrievabilitySamplingInterval: Duration(6 * time.Hour),
+			RetrievabilitySamplingPieces:   3,
+			RetrievabilitySamplingBlocks:   32,
 		},
 	}
 
@@ -0,0 +1,200 @@
+package config
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pmezard/go-difflib/difflib"
+	"golang.org/x/xerrors"
+)
+
+// MinerProfile is a named set of defaults for a common miner topology,
+// applied on top of a miner config.
+type MinerProfile struct {
+	Name        string
+	Description string
+
+	Apply func(cfg *StorageMiner)
+}
+
+var minerProfiles = map[string]MinerProfile{
+	"single-box": {
+		Name:        "single-box",
+		Description: "mining, sealing, storage and markets in a single process, sealing with the builtin worker",
+		Apply: func(cfg *StorageMiner) {
+			cfg.Subsystems.EnableMining = true
+			cfg.Subsystems.EnableSealing = true
+			cfg.Subsystems.EnableSectorStorage = true
+			cfg.Subsystems.EnableMarkets = true
+
+			// the builtin worker shares the machine with everything else
+			cfg.Sealing.MaxSealingSectors = 4
+			cfg.Sealing.MaxSealingSectorsForDeals = 2
+			cfg.Sealing.AlwaysKeepUnsealedCopy = true
+
+			cfg.Storage.Assigner = "utilization"
+			cfg.Storage.ParallelFetchLimit = 4
+			allowSealingTasks(&cfg.Storage, true)
+
+			cfg.DAGStore.MaxConcurrentIndex = 2
+			cfg.DAGStore.MaxConcurrentReadyFetches = 2
+			cfg.DAGStore.MaxConcurrentUnseals = 2
+		},
+	},
+	"split-markets": {
+		Name:        "split-markets",
+		Description: "mining, sealing and storage, with the markets running in a separate process (lotus-miner init service)",
+		Apply: func(cfg *StorageMiner) {
+			cfg.Subsystems.EnableMining = true
+			cfg.Subsystems.EnableSealing = true
+			cfg.Subsystems.EnableSectorStorage = true
+			cfg.Subsystems.EnableMarkets = false
+
+			// the markets process serves retrievals from the unsealed copies
+			cfg.Sealing.AlwaysKeepUnsealedCopy = true
+			cfg.Sealing.MakeNewSectorForDeals = true
+
+			cfg.Storage.Assigner = "utilization"
+
+			// the DAG store fetches the pieces over the sealing API
+			cfg.DAGStore.MaxConcurrentReadyFetches = 5
+			cfg.DAGStore.MaxConcurrencyStorageCalls = 50
+		},
+	},
+	"sealing-farm": {
+		Name:        "sealing-farm",
+		Description: "sealing with a fleet of remote workers, the builtin worker only moves data",
+		Apply: func(cfg *StorageMiner) {
+			cfg.Subsystems.EnableMining = true
+			cfg.Subsystems.EnableSealing = true
+			cfg.Subsystems.EnableSectorStorage = true
+
+			cfg.Sealing.MaxSealingSectors = 0
+			cfg.Sealing.MaxSealingSectorsForDeals = 0
+			cfg.Sealing.FinalizeEarly = true
+			cfg.Sealing.AlwaysKeepUnsealedCopy = false
+			cfg.Sealing.BatchPreCommits = true
+			cfg.Sealing.AggregateCommits = true
+
+			cfg.Storage.Assigner = "spread"
+			cfg.Storage.ParallelFetchLimit = 20
+			allowSealingTasks(&cfg.Storage, false)
+
+			cfg.DAGStore.MaxConcurrentIndex = 10
+			cfg.DAGStore.MaxConcurrentUnseals = 10
+		},
+	},
+	"snap-only": {
+		Name:        "snap-only",
+		Description: "deals only go into committed capacity sectors upgraded with snap deals, no new sectors are sealed for deals",
+		Apply: func(cfg *StorageMiner) {
+			cfg.Sealing.MakeNewSectorForDeals = false
+			cfg.Sealing.PreferNewSectorsForDeals = false
+			cfg.Sealing.MakeCCSectorsAvailable = true
+			cfg.Sealing.MaxUpgradingSectors = 16
+			cfg.Sealing.AlwaysKeepUnsealedCopy = true
+			cfg.Sealing.WaitDealsDelay = Duration(time.Hour)
+
+			cfg.Storage.AllowPreCommit1 = false
+			cfg.Storage.AllowPreCommit2 = false
+			cfg.Storage.AllowCommit = false
+			cfg.Storage.AllowReplicaUpdate = true
+			cfg.Storage.AllowProveReplicaUpdate2 = true
+			cfg.Storage.AllowRegenSectorKey = true
+
+			cfg.DAGStore.MaxConcurrentUnseals = 5
+		},
+	},
+}
+
+func allowSealingTasks(sc *SealerConfig, allow bool) {
+	sc.AllowAddPiece = allow
+	sc.AllowPreCommit1 = allow
+	sc.AllowPreCommit2 = allow
+	sc.AllowCommit = allow
+	sc.AllowUnseal = allow
+	sc.AllowReplicaUpdate = allow
+	sc.AllowProveReplicaUpdate2 = allow
+	sc.AllowRegenSectorKey = allow
+}
+
+// MinerProfiles returns the miner config profiles, sorted by name.
+func MinerProfiles() []MinerProfile {
+	out := make([]MinerProfile, 0, len(minerProfiles))
+	for _, p := range minerProfiles {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// GetMinerProfile returns the miner config profile with the given name.
+func GetMinerProfile(name string) (MinerProfile, error) {
+	p, ok := minerProfiles[name]
+	if !ok {
+		names := make([]string, 0, len(minerProfiles))
+		for _, p := range MinerProfiles() {
+			names = append(names, p.Name)
+		}
+		return MinerProfile{}, xerrors.Errorf("unknown miner config profile %q, available profiles: %s", name, strings.Join(names, ", "))
+	}
+	return p, nil
+}
+
+// WithMinerProfile returns a copy of the config with the profile applied.
+func WithMinerProfile(cfg *StorageMiner, name string) (*StorageMiner, error) {
+	p, err := GetMinerProfile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// the profiles only set scalar fields, but the copy must not share the
+	// slices and maps of the original config
+	out, err := copyConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	p.Apply(out)
+	return out, nil
+}
+
+func copyConfig(cfg *StorageMiner) (*StorageMiner, error) {
+	b, err := ConfigUpdate(cfg, nil)
+	if err != nil {
+		return nil, xerrors.Errorf("encoding config: %w", err)
+	}
+
+	// decoded over the defaults, which initialize the FIL amounts, but not
+	// with FromReader, which would pick up the env var overrides
+	out := DefaultStorageMiner()
+	if _, err := toml.NewDecoder(bytes.NewReader(b)).Decode(out); err != nil {
+		return nil, xerrors.Errorf("decoding config: %w", err)
+	}
+	return out, nil
+}
+
+// ConfigDiff returns a unified diff of the TOML encodings of two configs, or
+// an empty string if they are the same.
+func ConfigDiff(from, to interface{}, fromName, toName string) (string, error) {
+	a, err := ConfigUpdate(from, nil)
+	if err != nil {
+		return "", xerrors.Errorf("encoding %s: %w", fromName, err)
+	}
+	b, err := ConfigUpdate(to, nil)
+	if err != nil {
+		return "", xerrors.Errorf("encoding %s: %w", toName, err)
+	}
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(a)),
+		B:        difflib.SplitLines(string(b)),
+		FromFile: fromName,
+		ToFile:   toName,
+		Context:  2,
+	})
+}
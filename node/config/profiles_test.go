@@ -0,0 +1,55 @@
+// stm: #unit
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMinerProfiles(t *testing.T) {
+	profiles := MinerProfiles()
+	require.Len(t, profiles, 4)
+
+	for _, p := range profiles {
+		t.Run(p.Name, func(t *testing.T) {
+			def := DefaultStorageMiner()
+			def.Addresses.PreCommitControl = []string{"f01234"}
+
+			cfg, err := WithMinerProfile(def, p.Name)
+			require.NoError(t, err)
+
+			// the profile is applied to a copy
+			require.Equal(t, DefaultStorageMiner().Sealing, def.Sealing)
+			require.Equal(t, []string{"f01234"}, cfg.Addresses.PreCommitControl)
+			cfg.Addresses.PreCommitControl[0] = "f05678"
+			require.Equal(t, "f01234", def.Addresses.PreCommitControl[0])
+
+			diff, err := ConfigDiff(def, cfg, "current", p.Name)
+			require.NoError(t, err)
+			require.NotEmpty(t, diff)
+			require.Contains(t, diff, "--- current")
+			require.Contains(t, diff, "+++ "+p.Name)
+
+			// applying the profile again doesn't change anything
+			again, err := WithMinerProfile(cfg, p.Name)
+			require.NoError(t, err)
+			diff, err = ConfigDiff(cfg, again, "current", p.Name)
+			require.NoError(t, err)
+			require.Empty(t, diff)
+		})
+	}
+
+	cfg, err := WithMinerProfile(DefaultStorageMiner(), "sealing-farm")
+	require.NoError(t, err)
+	require.Equal(t, "spread", cfg.Storage.Assigner)
+	require.False(t, cfg.Storage.AllowPreCommit1)
+	require.True(t, cfg.Storage.AllowSectorDownload)
+
+	cfg, err = WithMinerProfile(DefaultStorageMiner(), "split-markets")
+	require.NoError(t, err)
+	require.False(t, cfg.Subsystems.EnableMarkets)
+
+	_, err = WithMinerProfile(DefaultStorageMiner(), "nope")
+	require.ErrorContains(t, err, "sealing-farm, single-box, snap-only, split-markets")
+}
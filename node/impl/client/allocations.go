@@ -0,0 +1,36 @@
+package client
+
+import (
+	"context"
+
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	verifregtypes "github.com/filecoin-project/go-state-types/builtin/v9/verifreg"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+var errAllocationsUnavailable = xerrors.Errorf("datacap allocations not available on this node")
+
+func (a *API) ClientCreateAllocations(ctx context.Context, client address.Address, reqs []verifregtypes.AllocationRequest) (cid.Cid, error) {
+	if a.Allocations == nil {
+		return cid.Undef, errAllocationsUnavailable
+	}
+	return a.Allocations.Create(ctx, client, reqs)
+}
+
+func (a *API) ClientListAllocations(ctx context.Context, client address.Address) ([]api.AllocationInfo, error) {
+	if a.Allocations == nil {
+		return nil, errAllocationsUnavailable
+	}
+	return a.Allocations.List(ctx, client)
+}
+
+func (a *API) ClientExtendClaims(ctx context.Context, client address.Address, terms []verifregtypes.ClaimTerm) (cid.Cid, error) {
+	if a.Allocations == nil {
+		return cid.Undef, errAllocationsUnavailable
+	}
+	return a.Allocations.ExtendClaims(ctx, client, terms)
+}
@@ -62,6 +62,8 @@ import (
 	"github.com/filecoin-project/lotus/chain/store"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/lib/unixfs"
+	"github.com/filecoin-project/lotus/markets/allocations"
+	"github.com/filecoin-project/lotus/markets/reputation"
 	"github.com/filecoin-project/lotus/markets/retrievaladapter"
 	"github.com/filecoin-project/lotus/markets/storageadapter"
 	"github.com/filecoin-project/lotus/markets/utils"
@@ -103,6 +105,13 @@ type API struct {
 	DataTransfer dtypes.ClientDataTransfer
 	Host         host.Host
 
+	Reputation           *reputation.Store                         `optional:"true"`
+	Replications         dtypes.ClientReplicationDatastore         `optional:"true"`
+	RetrievalCheckpoints dtypes.ClientRetrievalCheckpointDatastore `optional:"true"`
+	DealTemplates        dtypes.ClientDealTemplatesDatastore       `optional:"true"`
+	DataPreps            dtypes.ClientDataPrepDatastore            `optional:"true"`
+	Allocations          *allocations.Manager                      `optional:"true"`
+
 	Repo repo.LockedRepo
 }
 
@@ -134,6 +143,14 @@ func (a *API) ClientStatelessDeal(ctx context.Context, params *api.StartDealPara
 }
 
 func (a *API) dealStarter(ctx context.Context, params *api.StartDealParams, isStateless bool) (*cid.Cid, error) {
+	if params.Template != "" {
+		var err error
+		params, err = a.applyDealTemplate(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if isStateless {
 		if params.Data.TransferType != storagemarket.TTManual {
 			return nil, xerrors.Errorf("invalid transfer type %s for stateless storage deal", params.Data.TransferType)
@@ -808,7 +825,9 @@ func (a *API) ClientRetrieve(ctx context.Context, params api.RetrievalOrder) (*a
 		return nil, err
 	}
 
-	di, err := a.doRetrieval(ctx, params, sel)
+	di, err := a.doRetrieval(ctx, params, sel, func(id rm.DealID) error {
+		return a.saveRetrievalCheckpoint(ctx, id, &retrievalCheckpoint{Order: params})
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -818,7 +837,9 @@ func (a *API) ClientRetrieve(ctx context.Context, params api.RetrievalOrder) (*a
 	}, nil
 }
 
-func (a *API) doRetrieval(ctx context.Context, order api.RetrievalOrder, sel datamodel.Node) (rm.DealID, error) {
+// doRetrieval starts a retrieval deal for the order. The prepare callback, if
+// set, is called with the ID of the deal before the deal is started.
+func (a *API) doRetrieval(ctx context.Context, order api.RetrievalOrder, sel datamodel.Node, prepare func(rm.DealID) error) (rm.DealID, error) {
 	if order.MinerPeer == nil || order.MinerPeer.ID == "" {
 		mi, err := a.StateMinerInfo(ctx, order.Miner, types.EmptyTSK)
 		if err != nil {
@@ -854,6 +875,12 @@ func (a *API) doRetrieval(ctx context.Context, order api.RetrievalOrder, sel dat
 		}
 	}
 
+	if prepare != nil {
+		if err := prepare(id); err != nil {
+			return 0, err
+		}
+	}
+
 	id, err = a.Retrieval.Retrieve(
 		ctx,
 		id,
@@ -866,6 +893,7 @@ func (a *API) doRetrieval(ctx context.Context, order api.RetrievalOrder, sel dat
 	)
 
 	if err != nil {
+		a.deleteRetrievalCheckpoint(ctx, id)
 		return 0, xerrors.Errorf("Retrieve failed: %w", err)
 	}
 
@@ -0,0 +1,207 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/lib/aggregate"
+	"github.com/filecoin-project/lotus/lib/unixfs"
+	"github.com/filecoin-project/lotus/node/repo/imports"
+)
+
+func (a *API) ClientPrepareData(ctx context.Context, path string, targetPieceSize abi.PaddedPieceSize) (res *api.DataPrepManifest, err error) {
+	if a.DataPreps == nil {
+		return nil, xerrors.Errorf("data preparation not available on this node")
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to stat input file: %w", err)
+	}
+
+	imgr := a.importManager()
+
+	// each CAR is imported, remove the imports if something went wrong.
+	var ids []imports.ID
+	defer func() {
+		if err != nil {
+			for _, id := range ids {
+				_ = a.ClientRemoveImport(ctx, id)
+			}
+		}
+	}()
+
+	cars, err := unixfs.PrepareCARs(ctx, path, targetPieceSize, func(int) (string, error) {
+		id, err := imgr.CreateImport()
+		if err != nil {
+			return "", xerrors.Errorf("failed to create import: %w", err)
+		}
+		ids = append(ids, id)
+		return imgr.AllocateCAR(id)
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("failed to prepare data: %w", err)
+	}
+
+	m := &api.DataPrepManifest{
+		ID:              uuid.New(),
+		Source:          path,
+		SourceSize:      uint64(stat.Size()),
+		TargetPieceSize: targetPieceSize,
+		Created:         time.Now(),
+	}
+
+	for i, c := range cars {
+		commp, err := a.ClientCalcCommP(ctx, c.Path)
+		if err != nil {
+			return nil, xerrors.Errorf("computing piece CID of CAR %d: %w", i, err)
+		}
+
+		id := ids[i]
+		if err = imgr.AddLabel(id, imports.LSource, "prepare"); err != nil {
+			return nil, err
+		}
+		if err = imgr.AddLabel(id, imports.LFileName, path); err != nil {
+			return nil, err
+		}
+		if err = imgr.AddLabel(id, imports.LRootCid, c.Root.String()); err != nil {
+			return nil, err
+		}
+
+		m.Pieces = append(m.Pieces, api.DataPrepPiece{
+			Offset:    c.Offset,
+			Length:    c.Length,
+			Root:      c.Root,
+			ImportID:  id,
+			CarPath:   c.Path,
+			CarSize:   c.CarSize,
+			PieceCid:  commp.Root,
+			PieceSize: commp.Size.Padded(),
+		})
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, xerrors.Errorf("marshaling data preparation manifest: %w", err)
+	}
+	if err := a.DataPreps.Put(ctx, datastore.NewKey(m.ID.String()), b); err != nil {
+		return nil, xerrors.Errorf("saving data preparation manifest: %w", err)
+	}
+
+	return m, nil
+}
+
+func (a *API) ClientListDataPreps(ctx context.Context) ([]api.DataPrepManifest, error) {
+	if a.DataPreps == nil {
+		return nil, xerrors.Errorf("data preparation not available on this node")
+	}
+
+	res, err := a.DataPreps.Query(ctx, query.Query{})
+	if err != nil {
+		return nil, xerrors.Errorf("querying data preparation manifests: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	out := []api.DataPrepManifest{}
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, xerrors.Errorf("reading data preparation manifests: %w", r.Error)
+		}
+		var m api.DataPrepManifest
+		if err := json.Unmarshal(r.Value, &m); err != nil {
+			return nil, xerrors.Errorf("unmarshaling data preparation manifest %s: %w", r.Key, err)
+		}
+		out = append(out, m)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Created.After(out[j].Created)
+	})
+	return out, nil
+}
+
+func (a *API) ClientAggregatePieces(ctx context.Context, roots []cid.Cid, dealSize abi.PaddedPieceSize, outpath string) (*api.PieceAggregate, error) {
+	imgr := a.importManager()
+
+	res := &api.PieceAggregate{
+		Path:     outpath,
+		Segments: make([]api.AggregateSegment, len(roots)),
+	}
+
+	carPaths := make([]string, len(roots))
+	pieces := make([]abi.PieceInfo, len(roots))
+	for i, root := range roots {
+		p, err := imgr.CARPathFor(root)
+		if err != nil {
+			return nil, xerrors.Errorf("finding CAR of root %s: %w", root, err)
+		}
+		if p == "" {
+			return nil, xerrors.Errorf("no imported CAR with root %s", root)
+		}
+
+		commp, err := a.ClientCalcCommP(ctx, p)
+		if err != nil {
+			return nil, xerrors.Errorf("computing piece CID of root %s: %w", root, err)
+		}
+
+		carPaths[i] = p
+		pieces[i] = abi.PieceInfo{
+			PieceCID: commp.Root,
+			Size:     commp.Size.Padded(),
+		}
+	}
+
+	agg, err := aggregate.New(dealSize, pieces)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := os.Create(outpath)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create output file: %w", err)
+	}
+	defer out.Close() //nolint:errcheck
+
+	if _, err := agg.WriteTo(out, func(i int) (io.ReadCloser, error) {
+		return os.Open(carPaths[i])
+	}); err != nil {
+		return nil, err
+	}
+	if err := out.Close(); err != nil {
+		return nil, xerrors.Errorf("failed to close output file: %w", err)
+	}
+
+	res.PieceSize = agg.DealSize()
+	if res.PieceCid, err = agg.PieceCID(); err != nil {
+		return nil, xerrors.Errorf("computing piece CID of the aggregate: %w", err)
+	}
+
+	for i, root := range roots {
+		proof, err := agg.InclusionProof(i)
+		if err != nil {
+			return nil, xerrors.Errorf("piece of root %s: %w", root, err)
+		}
+
+		res.Segments[i] = api.AggregateSegment{
+			Root:           root,
+			PieceCid:       pieces[i].PieceCID,
+			PieceSize:      pieces[i].Size,
+			Offset:         agg.Offset(i),
+			InclusionProof: proof,
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,448 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-fil-markets/storagemarket"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/markets/reputation"
+)
+
+// replicationAskTimeout bounds the time to get the storage ask of a candidate
+// provider
+const replicationAskTimeout = 30 * time.Second
+
+type replicationRecord struct {
+	ID        uuid.UUID
+	Root      cid.Cid
+	Requested int
+	Created   time.Time
+	Deals     []api.ReplicationDeal
+}
+
+type replicationCandidate struct {
+	miner address.Address
+	// ask price per GiB per epoch, and the resulting deal price per epoch
+	price     abi.TokenAmount
+	dealPrice abi.TokenAmount
+	score     float64
+	minPiece  abi.PaddedPieceSize
+	maxPiece  abi.PaddedPieceSize
+}
+
+func (a *API) ClientReplicate(ctx context.Context, params *api.StartDealParams, n int, sel api.ProviderSelector) (*api.ReplicationStatus, error) {
+	if n <= 0 {
+		return nil, xerrors.Errorf("number of replicas must be positive")
+	}
+	if params.Data == nil {
+		return nil, xerrors.Errorf("no data to replicate")
+	}
+	if a.Reputation == nil || a.Replications == nil {
+		return nil, xerrors.Errorf("deal replication not available on this node")
+	}
+
+	p := *params
+	data := *params.Data
+	p.Data = &data
+
+	// compute the piece once for all deals
+	if data.PieceCid == nil {
+		dc, err := a.ClientDealPieceCID(ctx, data.Root)
+		if err != nil {
+			return nil, xerrors.Errorf("computing piece cid: %w", err)
+		}
+		data.PieceCid = &dc.PieceCID
+		data.PieceSize = dc.PieceSize.Unpadded()
+	}
+
+	cands, err := a.replicationCandidates(ctx, sel)
+	if err != nil {
+		return nil, err
+	}
+	if len(cands) == 0 {
+		return nil, xerrors.Errorf("no storage provider matches the selector")
+	}
+
+	ranked := rankReplicationCandidates(a.queryReplicationAsks(ctx, cands, p.VerifiedDeal), data.PieceSize.Padded(), p.EpochPrice)
+	if len(ranked) == 0 {
+		return nil, xerrors.Errorf("none of the %d matching storage providers accepts the deal", len(cands))
+	}
+
+	rec := replicationRecord{
+		ID:        uuid.New(),
+		Root:      data.Root,
+		Requested: n,
+		Created:   time.Now(),
+	}
+
+	started := 0
+	for _, c := range ranked {
+		if started == n {
+			break
+		}
+
+		p.Miner = c.miner
+		p.EpochPrice = c.dealPrice
+
+		deal := api.ReplicationDeal{Miner: c.miner}
+		propCid, err := a.dealStarter(ctx, &p, false)
+		if err != nil {
+			log.Warnw("replication deal failed, trying the next provider", "root", data.Root, "miner", c.miner, "error", err)
+			deal.Message = err.Error()
+		} else {
+			deal.ProposalCid = propCid
+			started++
+		}
+		rec.Deals = append(rec.Deals, deal)
+	}
+
+	if started < n {
+		log.Warnw("not enough storage providers to replicate data", "root", data.Root, "requested", n, "started", started)
+	}
+
+	if err := a.putReplication(ctx, rec); err != nil {
+		return nil, err
+	}
+
+	return a.replicationStatus(ctx, rec)
+}
+
+func (a *API) ClientGetReplication(ctx context.Context, id uuid.UUID) (*api.ReplicationStatus, error) {
+	if a.Replications == nil {
+		return nil, xerrors.Errorf("deal replication not available on this node")
+	}
+
+	b, err := a.Replications.Get(ctx, datastore.NewKey(id.String()))
+	if err == datastore.ErrNotFound {
+		return nil, xerrors.Errorf("replication %s not found", id)
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("getting replication: %w", err)
+	}
+
+	var rec replicationRecord
+	if err := json.Unmarshal(b, &rec); err != nil {
+		return nil, xerrors.Errorf("decoding replication: %w", err)
+	}
+
+	return a.replicationStatus(ctx, rec)
+}
+
+func (a *API) ClientListReplications(ctx context.Context) ([]api.ReplicationStatus, error) {
+	if a.Replications == nil {
+		return nil, xerrors.Errorf("deal replication not available on this node")
+	}
+
+	res, err := a.Replications.Query(ctx, query.Query{})
+	if err != nil {
+		return nil, xerrors.Errorf("listing replications: %w", err)
+	}
+	defer res.Close() // nolint:errcheck
+
+	out := []api.ReplicationStatus{}
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, xerrors.Errorf("listing replications: %w", r.Error)
+		}
+
+		var rec replicationRecord
+		if err := json.Unmarshal(r.Value, &rec); err != nil {
+			return nil, xerrors.Errorf("decoding replication %s: %w", r.Key, err)
+		}
+
+		st, err := a.replicationStatus(ctx, rec)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *st)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Created.Before(out[j].Created)
+	})
+	return out, nil
+}
+
+func (a *API) ClientSetProviderTags(ctx context.Context, miner address.Address, tags []string) error {
+	if a.Reputation == nil {
+		return xerrors.Errorf("provider reputation not available on this node")
+	}
+
+	return a.Reputation.SetTags(ctx, miner, tags)
+}
+
+func (a *API) ClientListProviderReputation(ctx context.Context) ([]api.ProviderReputation, error) {
+	if a.Reputation == nil {
+		return nil, xerrors.Errorf("provider reputation not available on this node")
+	}
+
+	return a.Reputation.List(ctx)
+}
+
+func (a *API) ClientImportProviderReputation(ctx context.Context, recs []api.ProviderReputation, replace bool) error {
+	if a.Reputation == nil {
+		return xerrors.Errorf("provider reputation not available on this node")
+	}
+
+	return a.Reputation.Import(ctx, recs, replace)
+}
+
+func (a *API) ClientProviderScores(ctx context.Context, miners []address.Address) ([]api.ProviderScore, error) {
+	if a.Reputation == nil {
+		return nil, xerrors.Errorf("provider reputation not available on this node")
+	}
+
+	var recs []api.ProviderReputation
+	if len(miners) == 0 {
+		var err error
+		recs, err = a.Reputation.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, m := range miners {
+		rec, err := a.Reputation.Get(ctx, m)
+		if err != nil {
+			return nil, err
+		}
+		recs = append(recs, rec)
+	}
+
+	out := make([]api.ProviderScore, 0, len(recs))
+	for _, rec := range recs {
+		out = append(out, reputation.Score(rec))
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Score > out[j].Score
+	})
+	return out, nil
+}
+
+func (a *API) putReplication(ctx context.Context, rec replicationRecord) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return xerrors.Errorf("encoding replication: %w", err)
+	}
+	if err := a.Replications.Put(ctx, datastore.NewKey(rec.ID.String()), b); err != nil {
+		return xerrors.Errorf("storing replication: %w", err)
+	}
+	return nil
+}
+
+func (a *API) replicationStatus(ctx context.Context, rec replicationRecord) (*api.ReplicationStatus, error) {
+	st := &api.ReplicationStatus{
+		ID:        rec.ID,
+		Root:      rec.Root,
+		Requested: rec.Requested,
+		Created:   rec.Created,
+		Deals:     make([]api.ReplicationDeal, 0, len(rec.Deals)),
+	}
+
+	for _, d := range rec.Deals {
+		if d.ProposalCid != nil {
+			deal, err := a.SMDealClient.GetLocalDeal(ctx, *d.ProposalCid)
+			if err != nil {
+				return nil, xerrors.Errorf("getting deal %s: %w", d.ProposalCid, err)
+			}
+			d.State = deal.State
+			d.Message = deal.Message
+		}
+
+		switch {
+		case d.ProposalCid == nil:
+			st.Failed++
+		case d.State == storagemarket.StorageDealActive:
+			st.Active++
+		case replicationDealFailed(d.State):
+			st.Failed++
+		default:
+			st.InProgress++
+		}
+
+		st.Deals = append(st.Deals, d)
+	}
+
+	return st, nil
+}
+
+func replicationDealFailed(state storagemarket.StorageDealStatus) bool {
+	switch state {
+	case storagemarket.StorageDealFailing,
+		storagemarket.StorageDealError,
+		storagemarket.StorageDealProposalRejected,
+		storagemarket.StorageDealProposalNotFound,
+		storagemarket.StorageDealExpired,
+		storagemarket.StorageDealSlashed:
+		return true
+	}
+	return false
+}
+
+// replicationCandidates returns the providers matching the reputation
+// requirements of the selector.
+func (a *API) replicationCandidates(ctx context.Context, sel api.ProviderSelector) ([]replicationCandidate, error) {
+	miners := sel.Candidates
+	if len(miners) == 0 {
+		known, err := a.Reputation.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, rec := range known {
+			miners = append(miners, rec.Miner)
+		}
+
+		deals, err := a.SMDealClient.ListLocalDeals(ctx)
+		if err != nil {
+			return nil, xerrors.Errorf("listing deals: %w", err)
+		}
+		for _, d := range deals {
+			miners = append(miners, d.Proposal.Provider)
+		}
+	}
+
+	skip := map[address.Address]struct{}{}
+	for _, m := range sel.Exclude {
+		skip[m] = struct{}{}
+	}
+
+	var out []replicationCandidate
+	for _, m := range miners {
+		if _, ok := skip[m]; ok {
+			continue
+		}
+		skip[m] = struct{}{}
+
+		rec, err := a.Reputation.Get(ctx, m)
+		if err != nil {
+			return nil, err
+		}
+		if !matchesReputation(rec, sel) {
+			continue
+		}
+
+		out = append(out, replicationCandidate{
+			miner: m,
+			score: reputation.Score(rec).Score,
+		})
+	}
+
+	return out, nil
+}
+
+func matchesReputation(rec api.ProviderReputation, sel api.ProviderSelector) bool {
+	for _, tag := range sel.Tags {
+		i := sort.SearchStrings(rec.Tags, tag)
+		if i == len(rec.Tags) || rec.Tags[i] != tag {
+			return false
+		}
+	}
+
+	if sel.MinRetrievalSuccessRate > 0 {
+		rate, ok := reputation.SuccessRate(rec)
+		if !ok || rate < sel.MinRetrievalSuccessRate {
+			return false
+		}
+	}
+
+	if sel.MinScore > 0 && reputation.Score(rec).Score < sel.MinScore {
+		return false
+	}
+
+	return true
+}
+
+// queryReplicationAsks gets the storage ask of each candidate, dropping the
+// candidates which can't be reached.
+func (a *API) queryReplicationAsks(ctx context.Context, cands []replicationCandidate, verified bool) []replicationCandidate {
+	var wg sync.WaitGroup
+	ok := make([]bool, len(cands))
+
+	for i := range cands {
+		wg.Add(1)
+		go func(c *replicationCandidate, ok *bool) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, replicationAskTimeout)
+			defer cancel()
+
+			mi, err := a.StateMinerInfo(ctx, c.miner, types.EmptyTSK)
+			if err != nil {
+				log.Infow("getting replication candidate info", "miner", c.miner, "error", err)
+				return
+			}
+			if mi.PeerId == nil {
+				return
+			}
+
+			ask, err := a.ClientQueryAsk(ctx, *mi.PeerId, c.miner)
+			if err != nil {
+				log.Infow("querying replication candidate ask", "miner", c.miner, "error", err)
+				return
+			}
+
+			c.price = ask.Response.Price
+			if verified {
+				c.price = ask.Response.VerifiedPrice
+			}
+			c.minPiece = ask.Response.MinPieceSize
+			c.maxPiece = ask.Response.MaxPieceSize
+			*ok = true
+		}(&cands[i], &ok[i])
+	}
+	wg.Wait()
+
+	var out []replicationCandidate
+	for i, c := range cands {
+		if ok[i] {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// rankReplicationCandidates drops the candidates whose ask doesn't accept the
+// piece, or whose deal price per epoch is above the maximum price if set, and
+// orders the others by price, then by score.
+func rankReplicationCandidates(cands []replicationCandidate, pieceSize abi.PaddedPieceSize, maxPrice abi.TokenAmount) []replicationCandidate {
+	gib := types.NewInt(1 << 30)
+
+	var out []replicationCandidate
+	for _, c := range cands {
+		if pieceSize < c.minPiece || pieceSize > c.maxPiece {
+			continue
+		}
+
+		c.dealPrice = types.BigDiv(types.BigMul(c.price, types.NewInt(uint64(pieceSize))), gib)
+		if !maxPrice.Nil() && !maxPrice.IsZero() && c.dealPrice.GreaterThan(maxPrice) {
+			continue
+		}
+		out = append(out, c)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if cmp := big.Cmp(out[i].price, out[j].price); cmp != 0 {
+			return cmp < 0
+		}
+		if out[i].score != out[j].score {
+			return out[i].score > out[j].score
+		}
+		return out[i].miner.String() < out[j].miner.String()
+	})
+
+	return out
+}
@@ -0,0 +1,83 @@
+// stm: #unit
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+func TestMatchesReputation(t *testing.T) {
+	rec := api.ProviderReputation{
+		Tags:               []string{"eu", "fast"},
+		RetrievalSuccesses: 9,
+		RetrievalFailures:  1,
+	}
+
+	require.True(t, matchesReputation(rec, api.ProviderSelector{}))
+	require.True(t, matchesReputation(rec, api.ProviderSelector{Tags: []string{"eu"}}))
+	require.True(t, matchesReputation(rec, api.ProviderSelector{Tags: []string{"fast", "eu"}, MinRetrievalSuccessRate: 0.9}))
+	require.False(t, matchesReputation(rec, api.ProviderSelector{Tags: []string{"eu", "us"}}))
+	require.False(t, matchesReputation(rec, api.ProviderSelector{MinRetrievalSuccessRate: 0.95}))
+	require.True(t, matchesReputation(rec, api.ProviderSelector{MinScore: 0.5}))
+	require.False(t, matchesReputation(api.ProviderReputation{RetrievalFailures: 5}, api.ProviderSelector{MinScore: 0.5}))
+
+	// providers without retrieval history don't meet a success rate
+	require.False(t, matchesReputation(api.ProviderReputation{}, api.ProviderSelector{MinRetrievalSuccessRate: 0.1}))
+}
+
+func TestRankReplicationCandidates(t *testing.T) {
+	miner := func(id uint64) address.Address {
+		a, err := address.NewIDAddress(id)
+		require.NoError(t, err)
+		return a
+	}
+
+	cand := func(id uint64, price int64, score float64) replicationCandidate {
+		return replicationCandidate{
+			miner:    miner(id),
+			price:    big.NewInt(price),
+			score:    score,
+			minPiece: 256,
+			maxPiece: 32 << 30,
+		}
+	}
+
+	large := cand(1005, 1, 0.9)
+	large.minPiece = 4 << 30
+
+	cands := []replicationCandidate{
+		cand(1000, 20, 0.5),
+		cand(1001, 10, 0.4),
+		cand(1002, 10, 0.9),
+		cand(1003, 10, 0.5),
+		cand(1004, 50, 1),
+		large,
+	}
+
+	order := func(cs []replicationCandidate) []address.Address {
+		var out []address.Address
+		for _, c := range cs {
+			out = append(out, c.miner)
+		}
+		return out
+	}
+
+	ranked := rankReplicationCandidates(cands, abi.PaddedPieceSize(1<<30), big.Zero())
+	require.Equal(t, []address.Address{miner(1002), miner(1003), miner(1001), miner(1000), miner(1004)}, order(ranked))
+	require.Equal(t, big.NewInt(10), ranked[0].dealPrice)
+
+	// the maximum price applies to the deal price per epoch
+	ranked = rankReplicationCandidates(cands, abi.PaddedPieceSize(1<<30), big.NewInt(20))
+	require.Equal(t, []address.Address{miner(1002), miner(1003), miner(1001), miner(1000)}, order(ranked))
+
+	ranked = rankReplicationCandidates(cands, abi.PaddedPieceSize(4<<30), big.NewInt(4))
+	require.Equal(t, []address.Address{miner(1005)}, order(ranked))
+	require.Equal(t, big.NewInt(4), ranked[0].dealPrice)
+}
@@ -0,0 +1,354 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	format "github.com/ipfs/go-ipld-format"
+	dagpb "github.com/ipld/go-codec-dagpb"
+	"github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/datamodel"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	basicnode "github.com/ipld/go-ipld-prime/node/basic"
+	"github.com/ipld/go-ipld-prime/traversal"
+	"github.com/ipld/go-ipld-prime/traversal/selector"
+	"github.com/ipld/go-ipld-prime/traversal/selector/builder"
+	"golang.org/x/xerrors"
+
+	rm "github.com/filecoin-project/go-fil-markets/retrievalmarket"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/markets/retrievaladapter"
+)
+
+// retrievalCheckpoint is persisted for every retrieval started by the client,
+// so that a failed retrieval can be resumed after a restart.
+type retrievalCheckpoint struct {
+	Order api.RetrievalOrder
+
+	// ResumedFrom is the retrieval this retrieval resumes
+	ResumedFrom *rm.DealID `json:",omitempty"`
+	// ResumedBy is the retrieval started to resume this retrieval
+	ResumedBy *rm.DealID `json:",omitempty"`
+}
+
+// resumeLk prevents resuming a retrieval twice concurrently
+var resumeLk sync.Mutex
+
+func checkpointKey(id rm.DealID) datastore.Key {
+	return datastore.NewKey(fmt.Sprint(id))
+}
+
+func (a *API) saveRetrievalCheckpoint(ctx context.Context, id rm.DealID, ckpt *retrievalCheckpoint) error {
+	if a.RetrievalCheckpoints == nil {
+		return nil
+	}
+
+	b, err := json.Marshal(ckpt)
+	if err != nil {
+		return xerrors.Errorf("marshaling retrieval checkpoint: %w", err)
+	}
+	if err := a.RetrievalCheckpoints.Put(ctx, checkpointKey(id), b); err != nil {
+		return xerrors.Errorf("saving retrieval checkpoint: %w", err)
+	}
+	return nil
+}
+
+func (a *API) getRetrievalCheckpoint(ctx context.Context, id rm.DealID) (*retrievalCheckpoint, error) {
+	b, err := a.RetrievalCheckpoints.Get(ctx, checkpointKey(id))
+	if err != nil {
+		if xerrors.Is(err, datastore.ErrNotFound) {
+			return nil, xerrors.Errorf("no checkpoint for retrieval %d", id)
+		}
+		return nil, xerrors.Errorf("getting retrieval checkpoint: %w", err)
+	}
+
+	var ckpt retrievalCheckpoint
+	if err := json.Unmarshal(b, &ckpt); err != nil {
+		return nil, xerrors.Errorf("unmarshaling retrieval checkpoint: %w", err)
+	}
+	return &ckpt, nil
+}
+
+func (a *API) deleteRetrievalCheckpoint(ctx context.Context, id rm.DealID) {
+	if a.RetrievalCheckpoints == nil {
+		return
+	}
+	if err := a.RetrievalCheckpoints.Delete(ctx, checkpointKey(id)); err != nil {
+		log.Warnw("deleting retrieval checkpoint", "deal", id, "error", err)
+	}
+}
+
+func (a *API) ClientRetrievalResume(ctx context.Context, dealID rm.DealID) (*api.RestrievalRes, error) {
+	if a.RetrievalCheckpoints == nil {
+		return nil, xerrors.Errorf("retrieval checkpoints not available on this node")
+	}
+	carBss, ok := a.RtvlBlockstoreAccessor.(*retrievaladapter.CARBlockstoreAccessor)
+	if !ok {
+		return nil, xerrors.Errorf("resuming retrievals requires retrieving into car files")
+	}
+
+	resumeLk.Lock()
+	defer resumeLk.Unlock()
+
+	ckpt, err := a.getRetrievalCheckpoint(ctx, dealID)
+	if err != nil {
+		return nil, err
+	}
+	if ckpt.ResumedBy != nil {
+		return nil, xerrors.Errorf("retrieval %d already resumed by retrieval %d", dealID, *ckpt.ResumedBy)
+	}
+	if ckpt.Order.DataSelector != nil {
+		return nil, xerrors.Errorf("resuming partial retrievals is not supported")
+	}
+	if ckpt.Order.RemoteStore != nil {
+		return nil, xerrors.Errorf("resuming retrievals into remote stores is not supported")
+	}
+
+	deal, err := a.Retrieval.GetDeal(dealID)
+	if err != nil {
+		return nil, xerrors.Errorf("getting retrieval %d: %w", dealID, err)
+	}
+	switch deal.Status {
+	case rm.DealStatusErrored, rm.DealStatusCancelled, rm.DealStatusDealNotFound, rm.DealStatusRejected:
+	case rm.DealStatusCompleted:
+		return nil, xerrors.Errorf("retrieval %d completed", dealID)
+	default:
+		return nil, xerrors.Errorf("retrieval %d is still in progress (%s), cancel it first", dealID, rm.DealStatuses[deal.Status])
+	}
+
+	retrieved, err := carBss.OpenRetrieved(dealID, ckpt.Order.Root)
+	if err != nil {
+		return nil, xerrors.Errorf("opening blocks of retrieval %d: %w", dealID, err)
+	}
+	progress, err := findMissing(ctx, retrieved, ckpt.Order.Root)
+	_ = retrieved.Close()
+	if err != nil {
+		return nil, xerrors.Errorf("checking blocks of retrieval %d: %w", dealID, err)
+	}
+	if progress.remaining == nil {
+		return nil, xerrors.Errorf("all blocks of retrieval %d were received, export it with its deal ID", dealID)
+	}
+
+	log.Infow("resuming retrieval", "deal", dealID, "root", ckpt.Order.Root,
+		"verifiedBlocks", progress.blocks, "verifiedBytes", progress.bytes, "missing", progress.missing)
+
+	id, err := a.doRetrieval(ctx, ckpt.Order, progress.remaining, func(id rm.DealID) error {
+		if err := carBss.Resume(id, dealID, ckpt.Order.Root); err != nil {
+			return xerrors.Errorf("copying blocks of retrieval %d: %w", dealID, err)
+		}
+		return a.saveRetrievalCheckpoint(ctx, id, &retrievalCheckpoint{Order: ckpt.Order, ResumedFrom: &dealID})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ckpt.ResumedBy = &id
+	if err := a.saveRetrievalCheckpoint(ctx, dealID, ckpt); err != nil {
+		return nil, err
+	}
+
+	return &api.RestrievalRes{DealID: id}, nil
+}
+
+type retrievalProgress struct {
+	// blocks and bytes verified before the first missing block
+	blocks int
+	bytes  uint64
+
+	// missing is the first missing block, and remaining the selector
+	// retrieving it and the blocks after it, nil if no block is missing
+	missing   cid.Cid
+	remaining datamodel.Node
+}
+
+// findMissing walks the DAG under root in the order it is retrieved in, and
+// finds the first missing block.
+func findMissing(ctx context.Context, bs interface {
+	Get(context.Context, cid.Cid) (blocks.Block, error)
+}, root cid.Cid) (*retrievalProgress, error) {
+	var (
+		progress retrievalProgress
+		missing  *ipld.LinkContext
+		// set once the walk stops at the first missing block
+		done bool
+	)
+
+	chooser := dagpb.AddSupportToChooser(func(ipld.Link, ipld.LinkContext) (ipld.NodePrototype, error) {
+		return basicnode.Prototype.Any, nil
+	})
+
+	lsys := cidlink.DefaultLinkSystem()
+	lsys.StorageReadOpener = func(lctx ipld.LinkContext, lnk ipld.Link) (io.Reader, error) {
+		c := lnk.(cidlink.Link).Cid
+		if missing != nil && !done {
+			return nil, xerrors.Errorf("block %s missing", progress.missing)
+		}
+
+		blk, err := bs.Get(lctx.Ctx, c)
+		if err != nil {
+			if format.IsNotFound(err) && missing == nil {
+				missing = &lctx
+				progress.missing = c
+			}
+			return nil, err
+		}
+		if done {
+			return bytes.NewReader(blk.RawData()), nil
+		}
+
+		progress.blocks++
+		progress.bytes += uint64(len(blk.RawData()))
+		return bytes.NewReader(blk.RawData()), nil
+	}
+
+	all := allSelector()
+
+	rootLnk := cidlink.Link{Cid: root}
+	proto, err := chooser(rootLnk, ipld.LinkContext{Ctx: ctx})
+	if err != nil {
+		return nil, err
+	}
+	rootNd, err := lsys.Load(ipld.LinkContext{Ctx: ctx}, rootLnk, proto)
+	if err != nil {
+		if missing != nil {
+			// nothing was retrieved, retrieve everything
+			progress.remaining = all.Node()
+			return &progress, nil
+		}
+		return nil, xerrors.Errorf("loading root: %w", err)
+	}
+
+	sel, err := selector.CompileSelector(all.Node())
+	if err != nil {
+		return nil, err
+	}
+
+	err = traversal.Progress{
+		Cfg: &traversal.Config{
+			Ctx:                            ctx,
+			LinkSystem:                     lsys,
+			LinkTargetNodePrototypeChooser: chooser,
+		},
+	}.WalkAdv(rootNd, sel, func(traversal.Progress, datamodel.Node, traversal.VisitReason) error {
+		return nil
+	})
+	done = true
+	if missing == nil {
+		if err != nil {
+			return nil, err
+		}
+		return &progress, nil
+	}
+
+	remaining, err := remainingSelector(ctx, lsys, chooser, rootNd, missing.LinkPath)
+	if err != nil {
+		return nil, xerrors.Errorf("building selector of the missing blocks: %w", err)
+	}
+	progress.remaining = remaining.Node()
+	return &progress, nil
+}
+
+func allSelector() builder.SelectorSpec {
+	ssb := builder.NewSelectorSpecBuilder(basicnode.Prototype.Any)
+	return ssb.ExploreRecursive(selector.RecursionLimitNone(), ssb.ExploreAll(ssb.ExploreRecursiveEdge()))
+}
+
+// remainingSelector builds a selector matching the blocks a depth-first
+// traversal of the whole DAG visits starting from the link at the given path:
+// at every node on the path, the selector explores the next node on the path
+// and everything after it.
+func remainingSelector(ctx context.Context, lsys ipld.LinkSystem, chooser traversal.LinkTargetNodePrototypeChooser, root datamodel.Node, path datamodel.Path) (builder.SelectorSpec, error) {
+	ssb := builder.NewSelectorSpecBuilder(basicnode.Prototype.Any)
+	segs := path.Segments()
+
+	// the nodes on the path, each exploring the segment with the same index
+	nodes := make([]datamodel.Node, len(segs))
+	nd := root
+	for i, seg := range segs {
+		nodes[i] = nd
+		if i == len(segs)-1 {
+			break
+		}
+
+		child, err := nd.LookupBySegment(seg)
+		if err != nil {
+			return nil, xerrors.Errorf("looking up %s: %w", path.Truncate(i+1), err)
+		}
+		if child.Kind() == datamodel.Kind_Link {
+			lnk, err := child.AsLink()
+			if err != nil {
+				return nil, err
+			}
+			lctx := ipld.LinkContext{Ctx: ctx, LinkPath: path.Truncate(i + 1), LinkNode: child}
+			proto, err := chooser(lnk, lctx)
+			if err != nil {
+				return nil, err
+			}
+			if child, err = lsys.Load(lctx, lnk, proto); err != nil {
+				return nil, xerrors.Errorf("loading %s: %w", path.Truncate(i+1), err)
+			}
+		}
+		nd = child
+	}
+
+	next := allSelector()
+	for i := len(segs) - 1; i >= 0; i-- {
+		nd, seg := nodes[i], segs[i]
+
+		switch nd.Kind() {
+		case datamodel.Kind_List:
+			idx, err := seg.Index()
+			if err != nil {
+				return nil, err
+			}
+			spec := ssb.ExploreIndex(idx, next)
+			if idx+1 < nd.Length() {
+				spec = ssb.ExploreUnion(spec, ssb.ExploreRange(idx+1, nd.Length(), allSelector()))
+			}
+			next = spec
+		case datamodel.Kind_Map:
+			var later []string
+			found := false
+			it := nd.MapIterator()
+			for !it.Done() {
+				k, _, err := it.Next()
+				if err != nil {
+					return nil, err
+				}
+				ks, err := k.AsString()
+				if err != nil {
+					return nil, err
+				}
+				if found {
+					later = append(later, ks)
+				}
+				if ks == seg.String() {
+					found = true
+				}
+			}
+			if !found {
+				return nil, xerrors.Errorf("no field %q at %s", seg.String(), path.Truncate(i))
+			}
+
+			explore := next
+			next = ssb.ExploreFields(func(efsb builder.ExploreFieldsSpecBuilder) {
+				efsb.Insert(seg.String(), explore)
+				for _, k := range later {
+					efsb.Insert(k, allSelector())
+				}
+			})
+		default:
+			return nil, xerrors.Errorf("can't explore %s node at %s", nd.Kind(), path.Truncate(i))
+		}
+	}
+
+	return next, nil
+}
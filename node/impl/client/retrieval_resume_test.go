@@ -0,0 +1,140 @@
+// stm: #unit
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	blockstore "github.com/ipfs/go-ipfs-blockstore"
+	format "github.com/ipfs/go-ipld-format"
+	"github.com/ipfs/go-merkledag"
+	dagpb "github.com/ipld/go-codec-dagpb"
+	"github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/datamodel"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	basicnode "github.com/ipld/go-ipld-prime/node/basic"
+	"github.com/ipld/go-ipld-prime/traversal"
+	"github.com/ipld/go-ipld-prime/traversal/selector"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBlockstore() blockstore.Blockstore {
+	return blockstore.NewBlockstore(dssync.MutexWrap(datastore.NewMapDatastore()))
+}
+
+// testDag builds root -> [a -> [a1, a2], b -> [b1, b2], c], and returns the
+// nodes in the order a full-DAG traversal visits them.
+func testDag(t *testing.T) []format.Node {
+	leaf := func(s string) format.Node {
+		return merkledag.NewRawNode([]byte(s))
+	}
+	parent := func(children ...format.Node) format.Node {
+		nd := new(merkledag.ProtoNode)
+		for i, c := range children {
+			require.NoError(t, nd.AddNodeLink(string(rune('a'+i)), c))
+		}
+		return nd
+	}
+
+	a1, a2, b1, b2, c := leaf("a1"), leaf("a2"), leaf("b1"), leaf("b2"), leaf("c")
+	a, b := parent(a1, a2), parent(b1, b2)
+	root := parent(a, b, c)
+
+	return []format.Node{root, a, a1, a2, b, b1, b2, c}
+}
+
+// loadedBy returns the blocks a traversal of the selector loads.
+func loadedBy(t *testing.T, bs blockstore.Blockstore, root cid.Cid, sel datamodel.Node) []cid.Cid {
+	ctx := context.Background()
+
+	var loaded []cid.Cid
+	chooser := dagpb.AddSupportToChooser(func(ipld.Link, ipld.LinkContext) (ipld.NodePrototype, error) {
+		return basicnode.Prototype.Any, nil
+	})
+	lsys := cidlink.DefaultLinkSystem()
+	lsys.StorageReadOpener = func(lctx ipld.LinkContext, lnk ipld.Link) (io.Reader, error) {
+		c := lnk.(cidlink.Link).Cid
+		blk, err := bs.Get(lctx.Ctx, c)
+		if err != nil {
+			return nil, err
+		}
+		loaded = append(loaded, c)
+		return bytes.NewReader(blk.RawData()), nil
+	}
+
+	rootLnk := cidlink.Link{Cid: root}
+	proto, err := chooser(rootLnk, ipld.LinkContext{Ctx: ctx})
+	require.NoError(t, err)
+	rootNd, err := lsys.Load(ipld.LinkContext{Ctx: ctx}, rootLnk, proto)
+	require.NoError(t, err)
+
+	compiled, err := selector.CompileSelector(sel)
+	require.NoError(t, err)
+	err = traversal.Progress{
+		Cfg: &traversal.Config{
+			Ctx:                            ctx,
+			LinkSystem:                     lsys,
+			LinkTargetNodePrototypeChooser: chooser,
+		},
+	}.WalkAdv(rootNd, compiled, func(traversal.Progress, datamodel.Node, traversal.VisitReason) error {
+		return nil
+	})
+	require.NoError(t, err)
+
+	return loaded
+}
+
+func TestFindMissing(t *testing.T) {
+	ctx := context.Background()
+	nodes := testDag(t)
+	root, a, a1, a2, b, b1, b2, c := nodes[0], nodes[1], nodes[2], nodes[3], nodes[4], nodes[5], nodes[6], nodes[7]
+
+	full := newTestBlockstore()
+	for _, nd := range nodes {
+		require.NoError(t, full.Put(ctx, nd))
+	}
+
+	// the traversal order the test relies on
+	require.Equal(t, []cid.Cid{root.Cid(), a.Cid(), a1.Cid(), a2.Cid(), b.Cid(), b1.Cid(), b2.Cid(), c.Cid()},
+		loadedBy(t, full, root.Cid(), allSelector().Node()))
+
+	t.Run("complete", func(t *testing.T) {
+		progress, err := findMissing(ctx, full, root.Cid())
+		require.NoError(t, err)
+		require.Nil(t, progress.remaining)
+		require.Equal(t, len(nodes), progress.blocks)
+	})
+
+	t.Run("nothing retrieved", func(t *testing.T) {
+		progress, err := findMissing(ctx, newTestBlockstore(), root.Cid())
+		require.NoError(t, err)
+		require.Equal(t, root.Cid(), progress.missing)
+		require.Zero(t, progress.blocks)
+		require.Len(t, loadedBy(t, full, root.Cid(), progress.remaining), len(nodes))
+	})
+
+	t.Run("partial", func(t *testing.T) {
+		// the retrieval failed after receiving b1
+		partial := newTestBlockstore()
+		var size uint64
+		for _, nd := range nodes[:6] {
+			require.NoError(t, partial.Put(ctx, nd))
+			size += uint64(len(nd.RawData()))
+		}
+
+		progress, err := findMissing(ctx, partial, root.Cid())
+		require.NoError(t, err)
+		require.Equal(t, b2.Cid(), progress.missing)
+		require.Equal(t, 6, progress.blocks)
+		require.Equal(t, size, progress.bytes)
+
+		// only the missing blocks, and the parents of the first one, are
+		// retrieved again
+		require.Equal(t, []cid.Cid{root.Cid(), b.Cid(), b2.Cid(), c.Cid()}, loadedBy(t, full, root.Cid(), progress.remaining))
+	})
+}
@@ -0,0 +1,192 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+func (a *API) ClientDealTemplateSet(ctx context.Context, tmpl api.DealTemplate) error {
+	if a.DealTemplates == nil {
+		return xerrors.Errorf("deal templates not available on this node")
+	}
+	if err := validateDealTemplate(tmpl); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(tmpl)
+	if err != nil {
+		return xerrors.Errorf("marshaling deal template: %w", err)
+	}
+	return a.DealTemplates.Put(ctx, datastore.NewKey(tmpl.Name), b)
+}
+
+func (a *API) ClientDealTemplateList(ctx context.Context) ([]api.DealTemplate, error) {
+	if a.DealTemplates == nil {
+		return nil, xerrors.Errorf("deal templates not available on this node")
+	}
+
+	res, err := a.DealTemplates.Query(ctx, query.Query{})
+	if err != nil {
+		return nil, xerrors.Errorf("querying deal templates: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	out := []api.DealTemplate{}
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, xerrors.Errorf("reading deal templates: %w", r.Error)
+		}
+		var tmpl api.DealTemplate
+		if err := json.Unmarshal(r.Value, &tmpl); err != nil {
+			return nil, xerrors.Errorf("unmarshaling deal template %s: %w", r.Key, err)
+		}
+		out = append(out, tmpl)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name < out[j].Name
+	})
+	return out, nil
+}
+
+func (a *API) ClientDealTemplateRemove(ctx context.Context, name string) error {
+	if a.DealTemplates == nil {
+		return xerrors.Errorf("deal templates not available on this node")
+	}
+
+	k := datastore.NewKey(name)
+	has, err := a.DealTemplates.Has(ctx, k)
+	if err != nil {
+		return xerrors.Errorf("checking deal template: %w", err)
+	}
+	if !has {
+		return xerrors.Errorf("deal template %q not found", name)
+	}
+	return a.DealTemplates.Delete(ctx, k)
+}
+
+func (a *API) getDealTemplate(ctx context.Context, name string) (api.DealTemplate, error) {
+	if a.DealTemplates == nil {
+		return api.DealTemplate{}, xerrors.Errorf("deal templates not available on this node")
+	}
+
+	b, err := a.DealTemplates.Get(ctx, datastore.NewKey(name))
+	if err == datastore.ErrNotFound {
+		return api.DealTemplate{}, xerrors.Errorf("deal template %q not found", name)
+	}
+	if err != nil {
+		return api.DealTemplate{}, xerrors.Errorf("getting deal template: %w", err)
+	}
+
+	var tmpl api.DealTemplate
+	if err := json.Unmarshal(b, &tmpl); err != nil {
+		return api.DealTemplate{}, xerrors.Errorf("unmarshaling deal template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// applyDealTemplate returns a copy of the deal parameters, completed with the
+// template the deal is made with, and checks them against its limits.
+func (a *API) applyDealTemplate(ctx context.Context, params *api.StartDealParams) (*api.StartDealParams, error) {
+	tmpl, err := a.getDealTemplate(ctx, params.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	p := *params
+	if tmpl.MaxPricePerGiB != nil && p.Data != nil && p.Data.PieceSize == 0 {
+		// the piece size is needed to check the price
+		data := *p.Data
+		dc, err := a.ClientDealPieceCID(ctx, data.Root)
+		if err != nil {
+			return nil, xerrors.Errorf("computing piece size: %w", err)
+		}
+		data.PieceCid = &dc.PieceCID
+		data.PieceSize = dc.PieceSize.Unpadded()
+		p.Data = &data
+	}
+
+	if err := completeDealParams(tmpl, &p); err != nil {
+		return nil, xerrors.Errorf("deal template %q: %w", tmpl.Name, err)
+	}
+	return &p, nil
+}
+
+func validateDealTemplate(tmpl api.DealTemplate) error {
+	if tmpl.Name == "" {
+		return xerrors.Errorf("deal template has no name")
+	}
+	if tmpl.Duration != 0 && (tmpl.Duration < build.MinDealDuration || tmpl.Duration > build.MaxDealDuration) {
+		return xerrors.Errorf("deal duration must be between %d and %d blocks", build.MinDealDuration, build.MaxDealDuration)
+	}
+	for _, v := range []*types.BigInt{tmpl.MaxPricePerGiB, tmpl.MinProviderCollateral, tmpl.MaxProviderCollateral} {
+		if v != nil && v.Int != nil && v.Sign() < 0 {
+			return xerrors.Errorf("negative price or collateral")
+		}
+	}
+	if isSet(tmpl.MinProviderCollateral) && isSet(tmpl.MaxProviderCollateral) &&
+		tmpl.MinProviderCollateral.GreaterThan(*tmpl.MaxProviderCollateral) {
+		return xerrors.Errorf("minimum provider collateral above the maximum")
+	}
+	return nil
+}
+
+// completeDealParams fills the parameters left unset from the template, and
+// checks the deal fits the limits of the template.
+func completeDealParams(tmpl api.DealTemplate, p *api.StartDealParams) error {
+	if p.MinBlocksDuration == 0 {
+		if tmpl.Duration == 0 {
+			return xerrors.Errorf("no deal duration set")
+		}
+		p.MinBlocksDuration = uint64(tmpl.Duration)
+	}
+	if tmpl.Verified {
+		p.VerifiedDeal = true
+	}
+	if tmpl.FastRetrieval {
+		p.FastRetrieval = true
+	}
+
+	collateral := p.ProviderCollateral
+	if !isSet(&collateral) || collateral.IsZero() {
+		if isSet(tmpl.MinProviderCollateral) {
+			p.ProviderCollateral = *tmpl.MinProviderCollateral
+		}
+	} else {
+		if isSet(tmpl.MinProviderCollateral) && collateral.LessThan(*tmpl.MinProviderCollateral) {
+			return xerrors.Errorf("provider collateral %s below the minimum of %s", types.FIL(collateral), types.FIL(*tmpl.MinProviderCollateral))
+		}
+		if isSet(tmpl.MaxProviderCollateral) && collateral.GreaterThan(*tmpl.MaxProviderCollateral) {
+			return xerrors.Errorf("provider collateral %s above the maximum of %s", types.FIL(collateral), types.FIL(*tmpl.MaxProviderCollateral))
+		}
+	}
+
+	if isSet(tmpl.MaxPricePerGiB) {
+		if p.Data == nil || p.Data.PieceSize == 0 {
+			return xerrors.Errorf("piece size needed to check the deal price")
+		}
+		size := p.Data.PieceSize.Padded()
+		max := big.Div(big.Mul(*tmpl.MaxPricePerGiB, big.NewInt(int64(size))), big.NewInt(1<<30))
+		if isSet(&p.EpochPrice) && p.EpochPrice.GreaterThan(max) {
+			return xerrors.Errorf("price per epoch %s above the ceiling of %s for a %s piece",
+				types.FIL(p.EpochPrice), types.FIL(max), types.SizeStr(types.NewInt(uint64(size))))
+		}
+	}
+
+	return nil
+}
+
+func isSet(v *abi.TokenAmount) bool {
+	return v != nil && v.Int != nil
+}
@@ -0,0 +1,66 @@
+// stm: #unit
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-fil-markets/storagemarket"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+func TestCompleteDealParams(t *testing.T) {
+	price := types.NewInt(1000)
+	minColl, maxColl := types.NewInt(10), types.NewInt(100)
+	tmpl := api.DealTemplate{
+		Name:                  "archive",
+		Duration:              518400,
+		Verified:              true,
+		MaxPricePerGiB:        &price,
+		MinProviderCollateral: &minColl,
+		MaxProviderCollateral: &maxColl,
+	}
+	require.NoError(t, validateDealTemplate(tmpl))
+
+	params := func(size abi.PaddedPieceSize, epochPrice uint64) api.StartDealParams {
+		return api.StartDealParams{
+			Data:       &storagemarket.DataRef{PieceSize: size.Unpadded()},
+			EpochPrice: types.NewInt(epochPrice),
+		}
+	}
+
+	// unset parameters are taken from the template
+	p := params(1<<30, 1000)
+	require.NoError(t, completeDealParams(tmpl, &p))
+	require.Equal(t, uint64(518400), p.MinBlocksDuration)
+	require.True(t, p.VerifiedDeal)
+	require.Equal(t, minColl, p.ProviderCollateral)
+
+	// the price ceiling scales with the piece size
+	p = params(4<<30, 4000)
+	require.NoError(t, completeDealParams(tmpl, &p))
+	p = params(4<<30, 4001)
+	require.Error(t, completeDealParams(tmpl, &p))
+
+	// an explicit duration is kept, the collateral must fit the bounds
+	p = params(1<<30, 10)
+	p.MinBlocksDuration = 200000
+	p.ProviderCollateral = types.NewInt(50)
+	require.NoError(t, completeDealParams(tmpl, &p))
+	require.Equal(t, uint64(200000), p.MinBlocksDuration)
+	require.Equal(t, types.NewInt(50), p.ProviderCollateral)
+
+	p = params(1<<30, 10)
+	p.ProviderCollateral = types.NewInt(101)
+	require.Error(t, completeDealParams(tmpl, &p))
+	p = params(1<<30, 10)
+	p.ProviderCollateral = types.NewInt(9)
+	require.Error(t, completeDealParams(tmpl, &p))
+
+	require.Error(t, validateDealTemplate(api.DealTemplate{}))
+	require.Error(t, validateDealTemplate(api.DealTemplate{Name: "bad", MinProviderCollateral: &maxColl, MaxProviderCollateral: &minColl}))
+}
@@ -2,10 +2,14 @@ package common
 
 import (
 	"context"
+	"encoding/json"
+	"sort"
 	"time"
 
 	"github.com/gbrlsnchs/jwt/v3"
 	"github.com/google/uuid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
 	logging "github.com/ipfs/go-log/v2"
 	"go.uber.org/fx"
 	"golang.org/x/xerrors"
@@ -16,7 +20,10 @@ import (
 	apitypes "github.com/filecoin-project/lotus/api/types"
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/journal/alerting"
+	"github.com/filecoin-project/lotus/lib/diskforecast"
+	"github.com/filecoin-project/lotus/lib/shutdown"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
+	"github.com/filecoin-project/lotus/node/tenancy"
 )
 
 var session = uuid.New()
@@ -27,24 +34,67 @@ type CommonAPI struct {
 	Alerting     *alerting.Alerting
 	APISecret    *dtypes.APIAlg
 	ShutdownChan dtypes.ShutdownChan
+	Shutdowns    *shutdown.Coordinator
 
 	Start dtypes.NodeStartTime
+	DS    dtypes.MetadataDS
+
+	DiskForecastTracker *diskforecast.Tracker `optional:"true"`
+
+	ReadOnly dtypes.ReadOnlyAPI `optional:"true"`
 }
 
 type jwtPayload struct {
 	Allow []auth.Permission
+	// Namespace is set for tokens bound to a tenant namespace
+	Namespace string `json:",omitempty"`
+	// Scope is set for tokens created with AuthNewWithScope
+	Scope *api.AuthScope `json:",omitempty"`
 }
 
+var tokensPrefix = datastore.NewKey("/auth/tokens")
+
 func (a *CommonAPI) AuthVerify(ctx context.Context, token string) ([]auth.Permission, error) {
+	t, err := a.AuthVerifyToken(ctx, token)
+	return t.Perms, err
+}
+
+// AuthVerifyToken verifies the token like AuthVerify, and returns the
+// namespace and the scope of the token too, if any.
+func (a *CommonAPI) AuthVerifyToken(ctx context.Context, token string) (tenancy.Token, error) {
 	var payload jwtPayload
 	if _, err := jwt.Verify([]byte(token), (*jwt.HMACSHA)(a.APISecret), &payload); err != nil {
-		return nil, xerrors.Errorf("JWT Verification failed: %w", err)
+		return tenancy.Token{}, xerrors.Errorf("JWT Verification failed: %w", err)
+	}
+
+	if payload.Namespace != "" {
+		// namespaced tokens are never issued with the admin permission
+		perms := make([]auth.Permission, 0, len(payload.Allow))
+		for _, p := range payload.Allow {
+			if p != api.PermAdmin {
+				perms = append(perms, p)
+			}
+		}
+		return tenancy.Token{Perms: perms, Namespace: payload.Namespace}, nil
 	}
 
-	return payload.Allow, nil
+	return tenancy.Token{Perms: payload.Allow, Scope: payload.Scope}, nil
+}
+
+// checkUnscoped rejects the requests made with a token restricted to a scope,
+// the tokens they would create wouldn't be restricted to it.
+func checkUnscoped(ctx context.Context) error {
+	if tenancy.Scope(ctx) != nil {
+		return xerrors.Errorf("tokens restricted to a scope can't create tokens")
+	}
+	return nil
 }
 
 func (a *CommonAPI) AuthNew(ctx context.Context, perms []auth.Permission) ([]byte, error) {
+	if err := checkUnscoped(ctx); err != nil {
+		return nil, err
+	}
+
 	p := jwtPayload{
 		Allow: perms, // TODO: consider checking validity
 	}
@@ -52,6 +102,80 @@ func (a *CommonAPI) AuthNew(ctx context.Context, perms []auth.Permission) ([]byt
 	return jwt.Sign(&p, (*jwt.HMACSHA)(a.APISecret))
 }
 
+func (a *CommonAPI) AuthNewNamespaced(ctx context.Context, perms []auth.Permission, namespace string) ([]byte, error) {
+	if err := checkUnscoped(ctx); err != nil {
+		return nil, err
+	}
+	if err := tenancy.ValidateNamespace(namespace); err != nil {
+		return nil, err
+	}
+	for _, p := range perms {
+		if p == api.PermAdmin {
+			return nil, xerrors.Errorf("namespaced tokens can't have the admin permission")
+		}
+	}
+
+	p := jwtPayload{
+		Allow:     perms,
+		Namespace: namespace,
+	}
+
+	return jwt.Sign(&p, (*jwt.HMACSHA)(a.APISecret))
+}
+
+func (a *CommonAPI) AuthNewWithScope(ctx context.Context, perms []auth.Permission, scope api.AuthScope) ([]byte, error) {
+	if err := checkUnscoped(ctx); err != nil {
+		return nil, err
+	}
+	if err := tenancy.ValidateScope(scope); err != nil {
+		return nil, xerrors.Errorf("invalid scope: %w", err)
+	}
+
+	info := api.AuthToken{
+		ID:      uuid.New().String(),
+		Perms:   perms,
+		Scope:   scope,
+		Created: time.Now(),
+	}
+	b, err := json.Marshal(info)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.DS.Put(ctx, tokensPrefix.ChildString(info.ID), b); err != nil {
+		return nil, xerrors.Errorf("recording token: %w", err)
+	}
+
+	p := jwtPayload{
+		Allow: perms,
+		Scope: &scope,
+	}
+	return jwt.Sign(&p, (*jwt.HMACSHA)(a.APISecret))
+}
+
+func (a *CommonAPI) AuthListTokens(ctx context.Context) ([]api.AuthToken, error) {
+	res, err := a.DS.Query(ctx, query.Query{Prefix: tokensPrefix.String()})
+	if err != nil {
+		return nil, xerrors.Errorf("querying tokens: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	out := []api.AuthToken{}
+	for e := range res.Next() {
+		if e.Error != nil {
+			return nil, xerrors.Errorf("iterating tokens: %w", e.Error)
+		}
+		var info api.AuthToken
+		if err := json.Unmarshal(e.Value, &info); err != nil {
+			return nil, xerrors.Errorf("decoding token %s: %w", e.Key, err)
+		}
+		out = append(out, info)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Created.Before(out[j].Created)
+	})
+	return out, nil
+}
+
 func (a *CommonAPI) Discover(ctx context.Context) (apitypes.OpenRPCDocument, error) {
 	return build.OpenRPCDiscoverJSON_Full(), nil
 }
@@ -70,6 +194,10 @@ func (a *CommonAPI) Version(context.Context) (api.APIVersion, error) {
 	}, nil
 }
 
+func (a *CommonAPI) Capabilities(context.Context) (api.APICapabilities, error) {
+	return api.CapabilitiesForType(api.RunningNodeType)
+}
+
 func (a *CommonAPI) LogList(context.Context) ([]string, error) {
 	return logging.GetSubsystems(), nil
 }
@@ -87,6 +215,10 @@ func (a *CommonAPI) Shutdown(ctx context.Context) error {
 	return nil
 }
 
+func (a *CommonAPI) ShutdownStatus(ctx context.Context) (api.ShutdownStatus, error) {
+	return a.Shutdowns.Status(ctx), nil
+}
+
 func (a *CommonAPI) Session(ctx context.Context) (uuid.UUID, error) {
 	return session, nil
 }
@@ -98,3 +230,10 @@ func (a *CommonAPI) Closing(ctx context.Context) (<-chan struct{}, error) {
 func (a *CommonAPI) StartTime(context.Context) (time.Time, error) {
 	return time.Time(a.Start), nil
 }
+
+func (a *CommonAPI) DiskForecast(context.Context) ([]api.DiskForecast, error) {
+	if a.DiskForecastTracker == nil {
+		return nil, xerrors.Errorf("the node doesn't track disk usage")
+	}
+	return a.DiskForecastTracker.Forecast(), nil
+}
@@ -0,0 +1,45 @@
+// stm: #unit
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gbrlsnchs/jwt/v3"
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+	"github.com/filecoin-project/lotus/node/tenancy"
+)
+
+func TestScopedTokenCantCreateTokens(t *testing.T) {
+	ctx := context.Background()
+	a := &CommonAPI{
+		APISecret: (*dtypes.APIAlg)(jwt.NewHS256([]byte("secret"))),
+		DS:        dssync.MutexWrap(ds.NewMapDatastore()),
+	}
+
+	scope := api.AuthScope{Methods: []string{"ChainHead", "AuthNew"}}
+	token, err := a.AuthNewWithScope(ctx, api.AllPermissions, scope)
+	require.NoError(t, err)
+
+	info, err := a.AuthVerifyToken(ctx, string(token))
+	require.NoError(t, err)
+	require.NotNil(t, info.Scope)
+
+	// the requests made with the scoped token can't escape the scope by
+	// creating tokens, even with the admin permission
+	scopedCtx := tenancy.WithScope(ctx, info.Scope)
+	_, err = a.AuthNew(scopedCtx, api.AllPermissions)
+	require.Error(t, err)
+	_, err = a.AuthNewWithScope(scopedCtx, api.AllPermissions, api.AuthScope{})
+	require.Error(t, err)
+	_, err = a.AuthNewNamespaced(scopedCtx, api.AllPermissions[:1], "tenant")
+	require.Error(t, err)
+
+	_, err = a.AuthNew(ctx, api.AllPermissions)
+	require.NoError(t, err)
+}
@@ -35,10 +35,24 @@ type FullNodeAPI struct {
 	full.WalletAPI
 	full.SyncAPI
 	full.RaftAPI
+	full.AttestationAPI
+	full.WebhookAPI
+	full.ChainJournalAPI
+	full.GasStatsAPI
+	full.PowerHistoryAPI
+	full.AddressIndexAPI
+	full.ConsensusFaultAPI
+	full.BlockTimingAPI
+	full.ReplicaAPI
+	full.SnapshotAPI
+	full.BlockstoreScrubAPI
+	full.TenancyAPI
 	full.EthAPI
 
 	DS          dtypes.MetadataDS
 	NetworkName dtypes.NetworkName
+
+	DisabledEthNamespaces dtypes.DisabledEthNamespaces `optional:"true"`
 }
 
 func (n *FullNodeAPI) CreateBackup(ctx context.Context, fpath string) error {
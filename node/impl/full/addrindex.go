@@ -0,0 +1,123 @@
+package full
+
+import (
+	"context"
+
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/addrindex"
+	"github.com/filecoin-project/lotus/chain/stmgr"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/ethtypes"
+)
+
+type AddressIndexAPI struct {
+	fx.In
+
+	StateManager *stmgr.StateManager
+	Chain        *store.ChainStore
+	Index        *addrindex.Index `optional:"true"`
+}
+
+func (a *AddressIndexAPI) StateListMessagesFast(ctx context.Context, query api.AddressMessageQuery) (*api.AddressMessagePage, error) {
+	if a.Index == nil {
+		return nil, xerrors.Errorf("address index not enabled. Please check your configuration")
+	}
+	if query.Address == address.Undef {
+		return nil, xerrors.Errorf("no address to list the messages of")
+	}
+
+	// messages carry the addresses given by their senders, so the ID and the
+	// robust forms of the actor are both matched
+	addrs := []address.Address{query.Address}
+	head := a.Chain.GetHeaviestTipSet()
+	if query.Address.Protocol() == address.ID {
+		if robust, err := a.StateManager.LookupRobustAddress(ctx, query.Address, head); err == nil {
+			addrs = append(addrs, robust)
+		}
+	} else if id, err := a.StateManager.LookupID(ctx, query.Address, head); err == nil {
+		addrs = append(addrs, id)
+	}
+
+	return a.Index.List(ctx, addrs, query)
+}
+
+func (a *AddressIndexAPI) StateResolveAddresses(ctx context.Context, addrs []address.Address) ([]api.ResolvedAddress, error) {
+	if len(addrs) > api.MaxResolveAddresses {
+		return nil, xerrors.Errorf("%d addresses is above the maximum of %d", len(addrs), api.MaxResolveAddresses)
+	}
+
+	head := a.Chain.GetHeaviestTipSet()
+	out := make([]api.ResolvedAddress, len(addrs))
+	for i, addr := range addrs {
+		res, err := a.resolveAddress(ctx, addr, head)
+		if err != nil {
+			res.Error = err.Error()
+		}
+		out[i] = res
+	}
+	return out, nil
+}
+
+func (a *AddressIndexAPI) resolveAddress(ctx context.Context, addr address.Address, head *types.TipSet) (api.ResolvedAddress, error) {
+	res := api.ResolvedAddress{Address: addr}
+
+	if a.Index != nil && addr.Protocol() == address.Delegated {
+		id, delegated, found, err := a.Index.LookupDelegated(ctx, addr, head)
+		if err != nil {
+			return res, err
+		}
+		if found {
+			res.ID, res.Delegated = id, &delegated
+			setEthAddress(&res)
+			return res, nil
+		}
+	}
+
+	id, err := a.StateManager.LookupID(ctx, addr, head)
+	if err != nil {
+		return res, xerrors.Errorf("looking up actor ID: %w", err)
+	}
+	res.ID = id
+
+	if a.Index != nil {
+		_, delegated, found, err := a.Index.LookupDelegated(ctx, id, head)
+		if err != nil {
+			return res, err
+		}
+		if found {
+			res.Delegated = &delegated
+		}
+	} else {
+		act, err := a.StateManager.LoadActor(ctx, id, head)
+		if err != nil {
+			return res, xerrors.Errorf("loading actor: %w", err)
+		}
+		if act.Address != nil && act.Address.Protocol() == address.Delegated {
+			delegated := *act.Address
+			res.Delegated = &delegated
+		}
+	}
+
+	setEthAddress(&res)
+	return res, nil
+}
+
+// setEthAddress sets the Ethereum address of a resolved actor, derived from its
+// delegated address when it's in the EAM namespace, otherwise from its ID.
+func setEthAddress(res *api.ResolvedAddress) {
+	if res.Delegated != nil {
+		if ea, err := ethtypes.EthAddressFromFilecoinAddress(*res.Delegated); err == nil {
+			res.EthAddress = &ea
+			return
+		}
+	}
+	if ea, err := ethtypes.EthAddressFromFilecoinAddress(res.ID); err == nil {
+		res.EthAddress = &ea
+	}
+}
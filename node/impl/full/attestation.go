@@ -0,0 +1,25 @@
+package full
+
+import (
+	"context"
+
+	"github.com/ipfs/go-cid"
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/attestation"
+)
+
+type AttestationAPI struct {
+	fx.In
+
+	Attestor *attestation.Attestor `optional:"true"`
+}
+
+func (a *AttestationAPI) ChainGetAttestation(ctx context.Context, msg cid.Cid) (*api.AttestationBundle, error) {
+	if a.Attestor == nil {
+		return nil, xerrors.Errorf("attestation service not enabled. Please check your configuration")
+	}
+	return a.Attestor.Attest(ctx, msg)
+}
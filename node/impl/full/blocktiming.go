@@ -0,0 +1,24 @@
+package full
+
+import (
+	"context"
+
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/sub/blocktiming"
+)
+
+type BlockTimingAPI struct {
+	fx.In
+
+	Tracker *blocktiming.Tracker `optional:"true"`
+}
+
+func (a *BlockTimingAPI) ChainBlockTiming(ctx context.Context) ([]api.PeerBlockTiming, error) {
+	if a.Tracker == nil {
+		return nil, xerrors.Errorf("block timing not available on this node")
+	}
+	return a.Tracker.Timing(), nil
+}
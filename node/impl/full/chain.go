@@ -35,6 +35,7 @@ import (
 
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/blockstore/maintenance"
 	"github.com/filecoin-project/lotus/chain/stmgr"
 	"github.com/filecoin-project/lotus/chain/store"
 	"github.com/filecoin-project/lotus/chain/types"
@@ -84,8 +85,9 @@ type ChainAPI struct {
 	WalletAPI
 	ChainModuleAPI
 
-	Chain  *store.ChainStore
-	TsExec stmgr.Executor
+	Chain        *store.ChainStore
+	TsExec       stmgr.Executor
+	StateManager *stmgr.StateManager
 
 	// ExposedBlockstore is the global monolith blockstore that is safe to
 	// expose externally. In the future, this will be segregated into two
@@ -95,6 +97,13 @@ type ChainAPI struct {
 	// BaseBlockstore is the underlying blockstore
 	BaseBlockstore dtypes.BaseBlockstore
 
+	// Maintenance runs the maintenance of the badger blockstore, if any
+	Maintenance *maintenance.Scheduler `optional:"true"`
+
+	// CacheTuner reports the metrics of the chain and state caches, and grows
+	// them under load in adaptive mode
+	CacheTuner *blockstore.CacheTuner `optional:"true"`
+
 	Repo repo.LockedRepo
 }
 
@@ -150,6 +159,14 @@ func (a *ChainAPI) ChainGetPath(ctx context.Context, from types.TipSetKey, to ty
 	return a.Chain.GetPath(ctx, from, to)
 }
 
+func (a *ChainAPI) ChainGetPathPage(ctx context.Context, from types.TipSetKey, to types.TipSetKey, cursor string, limit int) (*api.HeadChangePage, error) {
+	changes, next, err := a.Chain.GetPathPage(ctx, from, to, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+	return &api.HeadChangePage{Changes: changes, Cursor: next}, nil
+}
+
 func (a *ChainAPI) ChainGetParentMessages(ctx context.Context, bcid cid.Cid) ([]api.Message, error) {
 	b, err := a.Chain.GetBlock(ctx, bcid)
 	if err != nil {
@@ -206,6 +223,73 @@ func (a *ChainAPI) ChainGetParentReceipts(ctx context.Context, bcid cid.Cid) ([]
 	return out, nil
 }
 
+// maxParentReceiptsBatch is the number of tipsets ChainGetParentReceiptsBatch
+// accepts in a single call.
+const maxParentReceiptsBatch = 100
+
+func (a *ChainAPI) ChainGetParentReceiptsBatch(ctx context.Context, tsks []types.TipSetKey) ([]api.ParentReceipts, error) {
+	if len(tsks) > maxParentReceiptsBatch {
+		return nil, xerrors.Errorf("requested %d tipsets, at most %d can be requested at once", len(tsks), maxParentReceiptsBatch)
+	}
+
+	out := make([]api.ParentReceipts, 0, len(tsks))
+	for _, tsk := range tsks {
+		ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
+		if err != nil {
+			return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
+		}
+
+		pr := api.ParentReceipts{
+			TipSet: ts.Key(),
+			Height: ts.Height(),
+			Parent: ts.Parents(),
+		}
+
+		// genesis has no parent messages
+		if ts.Height() == 0 {
+			out = append(out, pr)
+			continue
+		}
+
+		pts, err := a.Chain.LoadTipSet(ctx, ts.Parents())
+		if err != nil {
+			return nil, xerrors.Errorf("loading parent of tipset %s: %w", tsk, err)
+		}
+
+		cm, err := a.Chain.MessagesForTipset(ctx, pts)
+		if err != nil {
+			return nil, xerrors.Errorf("loading messages of tipset %s: %w", pts.Key(), err)
+		}
+
+		receipts, err := a.Chain.ReadReceipts(ctx, ts.Blocks()[0].ParentMessageReceipts)
+		if err != nil {
+			return nil, xerrors.Errorf("loading receipts of tipset %s: %w", pts.Key(), err)
+		}
+
+		if len(cm) != len(receipts) {
+			return nil, xerrors.Errorf("tipset %s has %d messages but %d receipts", pts.Key(), len(cm), len(receipts))
+		}
+
+		pr.Messages = make([]api.Message, len(cm))
+		pr.Receipts = make([]*types.MessageReceipt, len(receipts))
+		for i, m := range cm {
+			pr.Messages[i] = api.Message{
+				Cid:     m.Cid(),
+				Message: m.VMMessage(),
+			}
+			pr.Receipts[i] = &receipts[i]
+		}
+
+		out = append(out, pr)
+	}
+
+	return out, nil
+}
+
+func (a *ChainAPI) ChainGetReceiptProof(ctx context.Context, msg cid.Cid) (*api.ReceiptProof, error) {
+	return a.StateManager.SearchForReceiptProof(ctx, a.Chain.GetHeaviestTipSet(), msg)
+}
+
 func (a *ChainAPI) ChainGetMessagesInTipset(ctx context.Context, tsk types.TipSetKey) ([]api.Message, error) {
 	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
 	if err != nil {
@@ -638,12 +722,33 @@ func (a *ChainAPI) ChainExport(ctx context.Context, nroots abi.ChainEpoch, skipo
 	if err != nil {
 		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
 	}
+	return exportStream(ctx, func(w io.Writer) error {
+		return a.Chain.Export(ctx, ts, nroots, skipoldmsgs, w)
+	}), nil
+}
+
+func (a *ChainAPI) ChainExportActorStates(ctx context.Context, nroots abi.ChainEpoch, skipoldmsgs bool, actors []address.Address, tsk types.TipSetKey) (<-chan []byte, error) {
+	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
+	}
+	if len(actors) == 0 {
+		return nil, xerrors.Errorf("no actors to export the state of")
+	}
+	return exportStream(ctx, func(w io.Writer) error {
+		return a.Chain.ExportActorStates(ctx, ts, nroots, skipoldmsgs, actors, w)
+	}), nil
+}
+
+// exportStream streams what export writes, the end of the stream is signaled
+// with an empty slice.
+func exportStream(ctx context.Context, export func(io.Writer) error) <-chan []byte {
 	r, w := io.Pipe()
 	out := make(chan []byte)
 	go func() {
 		bw := bufio.NewWriterSize(w, 1<<20)
 
-		err := a.Chain.Export(ctx, ts, nroots, skipoldmsgs, bw)
+		err := export(bw)
 		bw.Flush()            //nolint:errcheck // it is a write to a pipe
 		w.CloseWithError(err) //nolint:errcheck // it is a pipe
 	}()
@@ -679,7 +784,7 @@ func (a *ChainAPI) ChainExport(ctx context.Context, nroots abi.ChainEpoch, skipo
 		}
 	}()
 
-	return out, nil
+	return out
 }
 
 func (a *ChainAPI) ChainCheckBlockstore(ctx context.Context) error {
@@ -748,3 +853,31 @@ func (a *ChainAPI) ChainHotGC(ctx context.Context, opts api.HotGCOpts) error {
 
 	return pruner.GCHotStore(opts)
 }
+
+func (a *ChainAPI) ChainBlockstoreMaintain(ctx context.Context, opts api.BlockstoreMaintainOpts) (*api.BlockstoreMaintenanceRun, error) {
+	if a.Maintenance == nil {
+		return nil, xerrors.Errorf("blockstore maintenance is not supported by the blockstore (%T)", a.BaseBlockstore)
+	}
+	return a.Maintenance.Maintain(blockstore.MaintenanceMode(opts.Mode), opts.Threshold)
+}
+
+func (a *ChainAPI) ChainBlockstoreMaintenanceStatus(ctx context.Context) (*api.BlockstoreMaintenanceStatus, error) {
+	if a.Maintenance == nil {
+		return nil, xerrors.Errorf("blockstore maintenance is not supported by the blockstore (%T)", a.BaseBlockstore)
+	}
+	return a.Maintenance.Status(), nil
+}
+
+func (a *ChainAPI) ChainCacheStats(ctx context.Context) ([]blockstore.CacheStats, error) {
+	if a.CacheTuner == nil {
+		return []blockstore.CacheStats{}, nil
+	}
+	return a.CacheTuner.Caches(), nil
+}
+
+func (a *ChainAPI) ChainCacheResize(ctx context.Context, name string, size int) error {
+	if a.CacheTuner == nil {
+		return xerrors.Errorf("resizing cache %s: %w", name, blockstore.ErrCacheNotFound)
+	}
+	return a.CacheTuner.ResizeCache(name, size)
+}
@@ -34,6 +34,10 @@ func (e *EthModuleDummy) EthBlockNumber(ctx context.Context) (ethtypes.EthUint64
 	return 0, ErrModuleDisabled
 }
 
+func (e *EthModuleDummy) EthSyncing(ctx context.Context) (ethtypes.EthSyncingResult, error) {
+	return ethtypes.EthSyncingResult{}, ErrModuleDisabled
+}
+
 func (e *EthModuleDummy) EthAccounts(ctx context.Context) ([]ethtypes.EthAddress, error) {
 	return nil, ErrModuleDisabled
 }
@@ -74,6 +78,10 @@ func (e *EthModuleDummy) EthGetTransactionReceiptLimited(ctx context.Context, tx
 	return nil, ErrModuleDisabled
 }
 
+func (e *EthModuleDummy) EthGetBlockReceipts(ctx context.Context, blkParam string) ([]*api.EthTxReceipt, error) {
+	return nil, ErrModuleDisabled
+}
+
 func (e *EthModuleDummy) EthGetTransactionByBlockHashAndIndex(ctx context.Context, blkHash ethtypes.EthHash, txIndex ethtypes.EthUint64) (ethtypes.EthTx, error) {
 	return ethtypes.EthTx{}, ErrModuleDisabled
 }
@@ -134,6 +142,10 @@ func (e *EthModuleDummy) EthSendRawTransaction(ctx context.Context, rawTx ethtyp
 	return ethtypes.EthHash{}, ErrModuleDisabled
 }
 
+func (e *EthModuleDummy) EthSimulateRawTransaction(ctx context.Context, rawTx ethtypes.EthBytes) (*api.EthTxSimulation, error) {
+	return nil, ErrModuleDisabled
+}
+
 func (e *EthModuleDummy) Web3ClientVersion(ctx context.Context) (string, error) {
 	return "", ErrModuleDisabled
 }
@@ -174,5 +186,17 @@ func (e *EthModuleDummy) EthUnsubscribe(ctx context.Context, id ethtypes.EthSubs
 	return false, ErrModuleDisabled
 }
 
+func (e *EthModuleDummy) EthEventWatchList(ctx context.Context) (*api.EthEventWatchList, error) {
+	return nil, ErrModuleDisabled
+}
+
+func (e *EthModuleDummy) EthEventWatchListAdd(ctx context.Context, watch api.EthEventWatchList, backfillFrom abi.ChainEpoch) error {
+	return ErrModuleDisabled
+}
+
+func (e *EthModuleDummy) EthEventWatchListRemove(ctx context.Context, watch api.EthEventWatchList) error {
+	return ErrModuleDisabled
+}
+
 var _ EthModuleAPI = &EthModuleDummy{}
 var _ EthEventAPI = &EthModuleDummy{}
@@ -31,6 +31,7 @@ import (
 
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain"
 	"github.com/filecoin-project/lotus/chain/actors"
 	builtinactors "github.com/filecoin-project/lotus/chain/actors/builtin"
 	builtinevm "github.com/filecoin-project/lotus/chain/actors/builtin/evm"
@@ -49,6 +50,7 @@ var ErrUnsupported = errors.New("unsupported method")
 
 type EthModuleAPI interface {
 	EthBlockNumber(ctx context.Context) (ethtypes.EthUint64, error)
+	EthSyncing(ctx context.Context) (ethtypes.EthSyncingResult, error)
 	EthAccounts(ctx context.Context) ([]ethtypes.EthAddress, error)
 	EthGetBlockTransactionCountByNumber(ctx context.Context, blkNum ethtypes.EthUint64) (ethtypes.EthUint64, error)
 	EthGetBlockTransactionCountByHash(ctx context.Context, blkHash ethtypes.EthHash) (ethtypes.EthUint64, error)
@@ -61,6 +63,7 @@ type EthModuleAPI interface {
 	EthGetTransactionCount(ctx context.Context, sender ethtypes.EthAddress, blkOpt string) (ethtypes.EthUint64, error)
 	EthGetTransactionReceipt(ctx context.Context, txHash ethtypes.EthHash) (*api.EthTxReceipt, error)
 	EthGetTransactionReceiptLimited(ctx context.Context, txHash ethtypes.EthHash, limit abi.ChainEpoch) (*api.EthTxReceipt, error)
+	EthGetBlockReceipts(ctx context.Context, blkParam string) ([]*api.EthTxReceipt, error)
 	EthGetCode(ctx context.Context, address ethtypes.EthAddress, blkOpt string) (ethtypes.EthBytes, error)
 	EthGetStorageAt(ctx context.Context, address ethtypes.EthAddress, position ethtypes.EthBytes, blkParam string) (ethtypes.EthBytes, error)
 	EthGetBalance(ctx context.Context, address ethtypes.EthAddress, blkParam string) (ethtypes.EthBigInt, error)
@@ -74,6 +77,7 @@ type EthModuleAPI interface {
 	EthCall(ctx context.Context, tx ethtypes.EthCall, blkParam string) (ethtypes.EthBytes, error)
 	EthMaxPriorityFeePerGas(ctx context.Context) (ethtypes.EthBigInt, error)
 	EthSendRawTransaction(ctx context.Context, rawTx ethtypes.EthBytes) (ethtypes.EthHash, error)
+	EthSimulateRawTransaction(ctx context.Context, rawTx ethtypes.EthBytes) (*api.EthTxSimulation, error)
 	Web3ClientVersion(ctx context.Context) (string, error)
 }
 
@@ -87,6 +91,9 @@ type EthEventAPI interface {
 	EthUninstallFilter(ctx context.Context, id ethtypes.EthFilterID) (bool, error)
 	EthSubscribe(ctx context.Context, params jsonrpc.RawParams) (ethtypes.EthSubscriptionID, error)
 	EthUnsubscribe(ctx context.Context, id ethtypes.EthSubscriptionID) (bool, error)
+	EthEventWatchList(ctx context.Context) (*api.EthEventWatchList, error)
+	EthEventWatchListAdd(ctx context.Context, watch api.EthEventWatchList, backfillFrom abi.ChainEpoch) error
+	EthEventWatchListRemove(ctx context.Context, watch api.EthEventWatchList) error
 }
 
 var (
@@ -123,11 +130,22 @@ var (
 // "Latest executed epoch" refers to the tipset that this node currently
 // accepts as the best parent tipset, based on the blocks it is accumulating
 // within the HEAD tipset.
+// EthCompatProfile selects the Ethereum client mimicked by the Eth APIs, where
+// the responses of the clients differ.
+type EthCompatProfile string
+
+const (
+	EthCompatGeth   EthCompatProfile = "geth"
+	EthCompatErigon EthCompatProfile = "erigon"
+)
+
 type EthModule struct {
 	Chain            *store.ChainStore
 	Mpool            *messagepool.MessagePool
 	StateManager     *stmgr.StateManager
+	Syncer           *chain.Syncer
 	EthTxHashManager *EthTxHashManager
+	CompatProfile    EthCompatProfile
 
 	ChainAPI
 	MpoolAPI
@@ -145,6 +163,13 @@ type EthEvent struct {
 	SubManager           *EthSubscriptionManager
 	MaxFilterHeightRange abi.ChainEpoch
 	SubscribtionCtx      context.Context
+	// EventRetention is the number of epochs unwatched events are kept in
+	// the event index, zero if they are kept forever.
+	EventRetention abi.ChainEpoch
+
+	// backfills run one at a time, so that overlapping ones don't both index
+	// the same events
+	backfillLk sync.Mutex
 }
 
 var _ EthEventAPI = (*EthEvent)(nil)
@@ -184,6 +209,53 @@ func (a *EthModule) EthBlockNumber(ctx context.Context) (ethtypes.EthUint64, err
 	return ethtypes.EthUint64(parent.Height()), nil
 }
 
+func (a *EthModule) EthSyncing(ctx context.Context) (ethtypes.EthSyncingResult, error) {
+	states := a.Syncer.State()
+	if len(states) == 0 {
+		return ethtypes.EthSyncingResult{}, xerrors.Errorf("no active syncs, try again")
+	}
+
+	// report the last sync worker still working, if any
+	ss := states[len(states)-1]
+	for _, s := range states {
+		if s.Stage != api.StageIdle && s.Stage != api.StageSyncComplete {
+			ss = s
+		}
+	}
+
+	if ss.Stage == api.StageIdle || ss.Stage == api.StageSyncComplete {
+		return ethtypes.EthSyncingResult{DoneSync: true}, nil
+	}
+	if ss.Base == nil || ss.Target == nil {
+		return ethtypes.EthSyncingResult{}, xerrors.Errorf("missing sync information, try again")
+	}
+
+	res := ethtypes.EthSyncingResult{
+		StartingBlock: ethtypes.EthUint64(ss.Base.Height()),
+		CurrentBlock:  ethtypes.EthUint64(ss.Base.Height()),
+		HighestBlock:  ethtypes.EthUint64(ss.Target.Height()),
+	}
+
+	// the headers are fetched from the target down to the base, then the
+	// messages are validated from the base up to the target
+	headers := res.StartingBlock
+	if ss.Stage != api.StageHeaders {
+		headers = res.HighestBlock
+	}
+	if ss.Stage == api.StageMessages {
+		res.CurrentBlock = ethtypes.EthUint64(ss.Height)
+	}
+
+	if a.CompatProfile == EthCompatErigon {
+		res.Stages = []ethtypes.EthSyncStage{
+			{StageName: "Headers", BlockNumber: headers},
+			{StageName: "Execution", BlockNumber: res.CurrentBlock},
+		}
+	}
+
+	return res, nil
+}
+
 func (a *EthModule) EthAccounts(context.Context) ([]ethtypes.EthAddress, error) {
 	// The lotus node is not expected to hold manage accounts, so we'll always return an empty array
 	return []ethtypes.EthAddress{}, nil
@@ -450,7 +522,17 @@ func (a *EthModule) EthGetTransactionReceiptLimited(ctx context.Context, txHash
 		}
 	}
 
-	receipt, err := newEthTxReceipt(ctx, tx, msgLookup, events, a.Chain, a.StateAPI)
+	// the message was executed with the base fee of the tipset including it
+	ts, err := a.Chain.GetTipSetFromKey(ctx, msgLookup.TipSet)
+	if err != nil {
+		return nil, nil
+	}
+	pts, err := a.Chain.GetTipSetFromKey(ctx, ts.Parents())
+	if err != nil {
+		return nil, nil
+	}
+
+	receipt, err := newEthTxReceipt(ctx, tx, pts.Blocks()[0].ParentBaseFee, msgLookup.Receipt, events, a.StateAPI)
 	if err != nil {
 		return nil, nil
 	}
@@ -458,6 +540,87 @@ func (a *EthModule) EthGetTransactionReceiptLimited(ctx context.Context, txHash
 	return &receipt, nil
 }
 
+func (a *EthModule) EthGetBlockReceipts(ctx context.Context, blkParam string) ([]*api.EthTxReceipt, error) {
+	var ts *types.TipSet
+	if blkHash, ok := parseEthBlockHash(blkParam); ok {
+		var err error
+		ts, err = a.Chain.GetTipSetByCid(ctx, blkHash.ToCid())
+		if err != nil {
+			return nil, xerrors.Errorf("error loading tipset %s: %w", blkHash, err)
+		}
+	} else {
+		var err error
+		ts, err = a.parseBlkParam(ctx, blkParam, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	blkCid, err := ts.Key().Cid()
+	if err != nil {
+		return nil, err
+	}
+	blkHash, err := ethtypes.EthHashFromCid(blkCid)
+	if err != nil {
+		return nil, err
+	}
+
+	msgs, rcpts, err := messagesAndReceipts(ctx, ts, a.Chain, a.StateAPI)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to retrieve messages and receipts: %w", err)
+	}
+
+	bn := ethtypes.EthUint64(ts.Height())
+	baseFee := ts.Blocks()[0].ParentBaseFee
+
+	receipts := make([]*api.EthTxReceipt, 0, len(msgs))
+	for i, msg := range msgs {
+		smsg, err := toSignedMessage(msg)
+		if err != nil {
+			return nil, err
+		}
+		tx, err := newEthTxFromSignedMessage(ctx, smsg, a.StateAPI)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to convert msg to ethTx: %w", err)
+		}
+
+		ti := ethtypes.EthUint64(i)
+		tx.ChainID = ethtypes.EthUint64(build.Eip155ChainId)
+		tx.BlockHash = &blkHash
+		tx.BlockNumber = &bn
+		tx.TransactionIndex = &ti
+
+		var events []types.Event
+		if rct := rcpts[i]; rct.EventsRoot != nil {
+			events, err = a.ChainAPI.ChainGetEvents(ctx, *rct.EventsRoot)
+			if err != nil {
+				return nil, xerrors.Errorf("failed to load events of msg %s: %w", msg.Cid(), err)
+			}
+		}
+
+		receipt, err := newEthTxReceipt(ctx, tx, baseFee, rcpts[i], events, a.StateAPI)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to build receipt of msg %s: %w", msg.Cid(), err)
+		}
+		receipts = append(receipts, &receipt)
+	}
+
+	return receipts, nil
+}
+
+// parseEthBlockHash returns the block hash in blkParam, if it holds one rather
+// than a block number or tag.
+func parseEthBlockHash(blkParam string) (ethtypes.EthHash, bool) {
+	if len(blkParam) != 2+2*ethtypes.EthHashLength {
+		return ethtypes.EthHash{}, false
+	}
+	h, err := ethtypes.ParseEthHash(blkParam)
+	if err != nil {
+		return ethtypes.EthHash{}, false
+	}
+	return h, true
+}
+
 func (a *EthAPI) EthGetTransactionByBlockHashAndIndex(context.Context, ethtypes.EthHash, ethtypes.EthUint64) (ethtypes.EthTx, error) {
 	return ethtypes.EthTx{}, ErrUnsupported
 }
@@ -821,6 +984,70 @@ func (a *EthModule) EthSendRawTransaction(ctx context.Context, rawTx ethtypes.Et
 	return ethtypes.EthHashFromTxBytes(rawTx), nil
 }
 
+func (a *EthModule) EthSimulateRawTransaction(ctx context.Context, rawTx ethtypes.EthBytes) (*api.EthTxSimulation, error) {
+	txArgs, err := ethtypes.ParseEthTxArgs(rawTx)
+	if err != nil {
+		return nil, err
+	}
+
+	smsg, err := txArgs.ToSignedMessage()
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := ethtypes.EthAddressFromFilecoinAddress(smsg.Message.From)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to translate sender address: %w", err)
+	}
+
+	expectedNonce, err := a.Mpool.GetNonce(ctx, smsg.Message.From, types.EmptyTSK)
+	if err != nil {
+		return nil, xerrors.Errorf("getting sender nonce: %w", err)
+	}
+
+	// execute on top of the pending messages from the same sender, the
+	// transaction replaces the pending message with the same nonce, if any
+	ts := a.Chain.GetHeaviestTipSet()
+	res, priorMsgs, _, err := gasEstimateCallWithGas(ctx, a.Chain, a.StateManager, a.Mpool, &smsg.Message, ts)
+	if err != nil {
+		return nil, xerrors.Errorf("simulating transaction: %w", err)
+	}
+
+	sim := &api.EthTxSimulation{
+		TransactionHash: ethtypes.EthHashFromTxBytes(rawTx),
+		From:            from,
+		To:              txArgs.To,
+		Nonce:           ethtypes.EthUint64(txArgs.Nonce),
+		ExpectedNonce:   ethtypes.EthUint64(expectedNonce),
+		PendingMessages: ethtypes.EthUint64(len(priorMsgs)),
+		GasLimit:        ethtypes.EthUint64(smsg.Message.GasLimit),
+		GasUsed:         ethtypes.EthUint64(res.MsgRct.GasUsed),
+		Error:           res.Error,
+	}
+
+	if res.MsgRct.ExitCode.IsError() {
+		sim.RevertReason = parseEthRevert(res.MsgRct.Return)
+		return sim, nil
+	}
+	sim.Status = 1
+
+	if txArgs.To == nil {
+		var ret eam.CreateExternalReturn
+		if err := ret.UnmarshalCBOR(bytes.NewReader(res.MsgRct.Return)); err != nil {
+			return nil, xerrors.Errorf("failed to parse contract creation result: %w", err)
+		}
+		addr := ethtypes.EthAddress(ret.EthAddress)
+		sim.ContractAddress = &addr
+	} else if len(res.MsgRct.Return) > 0 {
+		sim.ReturnData, err = cbg.ReadByteArray(bytes.NewReader(res.MsgRct.Return), uint64(len(res.MsgRct.Return)))
+		if err != nil {
+			return nil, xerrors.Errorf("failed to decode return data: %w", err)
+		}
+	}
+
+	return sim, nil
+}
+
 func (a *EthModule) Web3ClientVersion(ctx context.Context) (string, error) {
 	return build.UserVersion(), nil
 }
@@ -1474,6 +1701,146 @@ func (e *EthEvent) GC(ctx context.Context, ttl time.Duration) {
 	}
 }
 
+// PruneEvents runs a loop deleting indexed events that are older than the
+// retention window, unless they match the watch-list.
+func (e *EthEvent) PruneEvents(ctx context.Context) {
+	if e.EventRetention <= 0 || e.EventFilterManager == nil || e.EventFilterManager.EventIndex == nil {
+		return
+	}
+
+	tt := time.NewTicker(time.Hour)
+	defer tt.Stop()
+
+	for {
+		before := e.Chain.GetHeaviestTipSet().Height() - e.EventRetention
+		deleted, err := e.EventFilterManager.EventIndex.PruneEvents(ctx, before)
+		if err != nil {
+			log.Errorf("error pruning event index: %s", err)
+		} else {
+			log.Infof("pruned %d events emitted before height %d from the event index", deleted, before)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-tt.C:
+		}
+	}
+}
+
+func (e *EthEvent) EthEventWatchList(ctx context.Context) (*api.EthEventWatchList, error) {
+	if e.EventFilterManager == nil || e.EventFilterManager.EventIndex == nil {
+		return nil, api.ErrNotSupported
+	}
+
+	addrs, topics, err := e.EventFilterManager.EventIndex.WatchList(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &api.EthEventWatchList{
+		Addresses: make([]ethtypes.EthAddress, 0, len(addrs)),
+		Topics:    make([]ethtypes.EthHash, 0, len(topics)),
+	}
+	for _, addr := range addrs {
+		ethAddr, err := ethtypes.EthAddressFromFilecoinAddress(addr)
+		if err != nil {
+			return nil, xerrors.Errorf("converting watched address %s: %w", addr, err)
+		}
+		out.Addresses = append(out.Addresses, ethAddr)
+	}
+	for _, topic := range topics {
+		var h ethtypes.EthHash
+		copy(h[:], topic)
+		out.Topics = append(out.Topics, h)
+	}
+
+	return out, nil
+}
+
+func (e *EthEvent) EthEventWatchListAdd(ctx context.Context, watch api.EthEventWatchList, backfillFrom abi.ChainEpoch) error {
+	if e.EventFilterManager == nil || e.EventFilterManager.EventIndex == nil {
+		return api.ErrNotSupported
+	}
+
+	addrs, topics, err := parseEventWatchList(watch)
+	if err != nil {
+		return err
+	}
+
+	if err := e.EventFilterManager.EventIndex.Watch(ctx, addrs, topics); err != nil {
+		return err
+	}
+
+	if e.EventRetention <= 0 || backfillFrom < 0 {
+		// nothing was pruned, or the caller doesn't want to backfill
+		return nil
+	}
+
+	// events emitted within the retention window are still indexed
+	head := e.Chain.GetHeaviestTipSet()
+	horizon := head.Height() - e.EventRetention
+	if horizon <= backfillFrom {
+		return nil
+	}
+
+	ts, err := e.Chain.GetTipsetByHeight(ctx, horizon, head, false)
+	if err != nil {
+		return xerrors.Errorf("loading tipset at height %d: %w", horizon, err)
+	}
+
+	// backfilling large ranges takes long, queries for the pruned range fail
+	// until the events are indexed again
+	go func() {
+		e.backfillLk.Lock()
+		defer e.backfillLk.Unlock()
+
+		log.Infof("backfilling the event index from height %d to %d", backfillFrom, horizon)
+		if err := e.EventFilterManager.Backfill(e.SubscribtionCtx, ts, backfillFrom, addrs, topics); err != nil {
+			log.Errorf("error backfilling the event index from height %d to %d: %s", backfillFrom, horizon, err)
+			return
+		}
+		log.Infof("backfilled the event index from height %d to %d", backfillFrom, horizon)
+	}()
+
+	return nil
+}
+
+func (e *EthEvent) EthEventWatchListRemove(ctx context.Context, watch api.EthEventWatchList) error {
+	if e.EventFilterManager == nil || e.EventFilterManager.EventIndex == nil {
+		return api.ErrNotSupported
+	}
+
+	addrs, topics, err := parseEventWatchList(watch)
+	if err != nil {
+		return err
+	}
+
+	return e.EventFilterManager.EventIndex.Unwatch(ctx, addrs, topics)
+}
+
+func parseEventWatchList(watch api.EthEventWatchList) ([]address.Address, [][]byte, error) {
+	addrs := make([]address.Address, 0, len(watch.Addresses))
+	for _, ethAddr := range watch.Addresses {
+		addr, err := ethAddr.ToFilecoinAddress()
+		if err != nil {
+			return nil, nil, xerrors.Errorf("converting address %s: %w", ethAddr, err)
+		}
+		if addr.Protocol() != address.Delegated {
+			return nil, nil, xerrors.Errorf("address %s is not a contract address", ethAddr)
+		}
+		addrs = append(addrs, addr)
+	}
+
+	topics := make([][]byte, 0, len(watch.Topics))
+	for _, topic := range watch.Topics {
+		topic := topic
+		topics = append(topics, topic[:])
+	}
+
+	return addrs, topics, nil
+}
+
 type filterEventCollector interface {
 	TakeCollectedEvents(context.Context) []*filter.CollectedEvent
 }
@@ -1868,19 +2235,9 @@ func newEthBlockFromFilecoinTipSet(ctx context.Context, ts *types.TipSet, fullTx
 		rcpt := rcpts[i]
 		ti := ethtypes.EthUint64(i)
 		gasUsed += rcpt.GasUsed
-		var smsg *types.SignedMessage
-		switch msg := msg.(type) {
-		case *types.SignedMessage:
-			smsg = msg
-		case *types.Message:
-			smsg = &types.SignedMessage{
-				Message: *msg,
-				Signature: crypto.Signature{
-					Type: crypto.SigTypeBLS,
-				},
-			}
-		default:
-			return ethtypes.EthBlock{}, xerrors.Errorf("failed to get signed msg %s: %w", msg.Cid(), err)
+		smsg, err := toSignedMessage(msg)
+		if err != nil {
+			return ethtypes.EthBlock{}, err
 		}
 		tx, err := newEthTxFromSignedMessage(ctx, smsg, sa)
 		if err != nil {
@@ -1908,6 +2265,24 @@ func newEthBlockFromFilecoinTipSet(ctx context.Context, ts *types.TipSet, fullTx
 	return block, nil
 }
 
+// toSignedMessage wraps the unsigned BLS messages of a tipset, so that all of
+// its messages can be converted to transactions alike.
+func toSignedMessage(msg types.ChainMsg) (*types.SignedMessage, error) {
+	switch msg := msg.(type) {
+	case *types.SignedMessage:
+		return msg, nil
+	case *types.Message:
+		return &types.SignedMessage{
+			Message: *msg,
+			Signature: crypto.Signature{
+				Type: crypto.SigTypeBLS,
+			},
+		}, nil
+	default:
+		return nil, xerrors.Errorf("failed to get signed msg %s: unexpected message type %T", msg.Cid(), msg)
+	}
+}
+
 func messagesAndReceipts(ctx context.Context, ts *types.TipSet, cs *store.ChainStore, sa StateAPI) ([]types.ChainMsg, []types.MessageReceipt, error) {
 	msgs, err := cs.MessagesForTipset(ctx, ts)
 	if err != nil {
@@ -2126,7 +2501,7 @@ func newEthTxFromMessageLookup(ctx context.Context, msgLookup *api.MsgLookup, tx
 	return tx, nil
 }
 
-func newEthTxReceipt(ctx context.Context, tx ethtypes.EthTx, lookup *api.MsgLookup, events []types.Event, cs *store.ChainStore, sa StateAPI) (api.EthTxReceipt, error) {
+func newEthTxReceipt(ctx context.Context, tx ethtypes.EthTx, baseFee big.Int, rct types.MessageReceipt, events []types.Event, sa StateAPI) (api.EthTxReceipt, error) {
 	var (
 		transactionIndex ethtypes.EthUint64
 		blockHash        ethtypes.EthHash
@@ -2155,37 +2530,30 @@ func newEthTxReceipt(ctx context.Context, tx ethtypes.EthTx, lookup *api.MsgLook
 		LogsBloom:        ethtypes.EmptyEthBloom[:],
 	}
 
-	if lookup.Receipt.ExitCode.IsSuccess() {
+	if rct.ExitCode.IsSuccess() {
 		receipt.Status = 1
 	} else {
 		receipt.Status = 0
 	}
 
-	receipt.GasUsed = ethtypes.EthUint64(lookup.Receipt.GasUsed)
+	receipt.GasUsed = ethtypes.EthUint64(rct.GasUsed)
 
 	// TODO: handle CumulativeGasUsed
 	receipt.CumulativeGasUsed = ethtypes.EmptyEthInt
 
-	// TODO: avoid loading the tipset twice (once here, once when we convert the message to a txn)
-	ts, err := cs.GetTipSetFromKey(ctx, lookup.TipSet)
-	if err != nil {
-		return api.EthTxReceipt{}, xerrors.Errorf("failed to lookup tipset %s when constructing the eth txn receipt: %w", lookup.TipSet, err)
-	}
-
-	baseFee := ts.Blocks()[0].ParentBaseFee
-	gasOutputs := vm.ComputeGasOutputs(lookup.Receipt.GasUsed, int64(tx.Gas), baseFee, big.Int(tx.MaxFeePerGas), big.Int(tx.MaxPriorityFeePerGas), true)
+	gasOutputs := vm.ComputeGasOutputs(rct.GasUsed, int64(tx.Gas), baseFee, big.Int(tx.MaxFeePerGas), big.Int(tx.MaxPriorityFeePerGas), true)
 	totalSpent := big.Sum(gasOutputs.BaseFeeBurn, gasOutputs.MinerTip, gasOutputs.OverEstimationBurn)
 
 	effectiveGasPrice := big.Zero()
-	if lookup.Receipt.GasUsed > 0 {
-		effectiveGasPrice = big.Div(totalSpent, big.NewInt(lookup.Receipt.GasUsed))
+	if rct.GasUsed > 0 {
+		effectiveGasPrice = big.Div(totalSpent, big.NewInt(rct.GasUsed))
 	}
 	receipt.EffectiveGasPrice = ethtypes.EthBigInt(effectiveGasPrice)
 
-	if receipt.To == nil && lookup.Receipt.ExitCode.IsSuccess() {
+	if receipt.To == nil && rct.ExitCode.IsSuccess() {
 		// Create and Create2 return the same things.
 		var ret eam.CreateExternalReturn
-		if err := ret.UnmarshalCBOR(bytes.NewReader(lookup.Receipt.Return)); err != nil {
+		if err := ret.UnmarshalCBOR(bytes.NewReader(rct.Return)); err != nil {
 			return api.EthTxReceipt{}, xerrors.Errorf("failed to parse contract creation result: %w", err)
 		}
 		addr := ethtypes.EthAddress(ret.EthAddress)
@@ -1,6 +1,7 @@
 package full
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/ipfs/go-cid"
@@ -161,3 +162,16 @@ func TestRewardPercentiles(t *testing.T) {
 		require.Equal(t, ans, rewards)
 	}
 }
+
+func TestParseEthBlockHash(t *testing.T) {
+	hash := "0x" + strings.Repeat("ab", ethtypes.EthHashLength)
+	h, ok := parseEthBlockHash(hash)
+	require.True(t, ok)
+	require.Equal(t, hash, h.String())
+
+	// block numbers and tags aren't hashes
+	for _, blkParam := range []string{"latest", "pending", "0x10", "0x" + strings.Repeat("ab", ethtypes.EthHashLength-1)} {
+		_, ok := parseEthBlockHash(blkParam)
+		require.False(t, ok, blkParam)
+	}
+}
@@ -0,0 +1,24 @@
+package full
+
+import (
+	"context"
+
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/eventjournal"
+)
+
+type ChainJournalAPI struct {
+	fx.In
+
+	Journal *eventjournal.Journal `optional:"true"`
+}
+
+func (a *ChainJournalAPI) ChainJournalSince(ctx context.Context, cursor uint64, limit int) (*api.ChainJournalPage, error) {
+	if a.Journal == nil {
+		return nil, xerrors.Errorf("chain journal not enabled. Please check your configuration")
+	}
+	return a.Journal.EventsSince(ctx, cursor, limit)
+}
@@ -0,0 +1,24 @@
+package full
+
+import (
+	"context"
+
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/gasstats"
+)
+
+type GasStatsAPI struct {
+	fx.In
+
+	Stats *gasstats.Stats `optional:"true"`
+}
+
+func (a *GasStatsAPI) StateGasStats(ctx context.Context, filter api.GasStatsFilter) (*api.GasStatsResult, error) {
+	if a.Stats == nil {
+		return nil, xerrors.Errorf("gas stats not enabled. Please check your configuration")
+	}
+	return a.Stats.Query(ctx, filter)
+}
@@ -10,6 +10,7 @@ import (
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-state-types/big"
 
 	"github.com/filecoin-project/lotus/api"
@@ -49,6 +50,7 @@ type MpoolAPI struct {
 	MessageSigner messagesigner.MsgSigner
 
 	PushLocks *dtypes.MpoolLocker
+	GetMaxFee dtypes.DefaultMaxFeeFunc
 }
 
 func (a *MpoolAPI) MpoolGetConfig(context.Context) (*types.MpoolConfig, error) {
@@ -68,6 +70,15 @@ func (a *MpoolAPI) MpoolSelect(ctx context.Context, tsk types.TipSetKey, ticketQ
 	return a.Mpool.SelectMessages(ctx, ts, ticketQuality)
 }
 
+func (a *MpoolAPI) MpoolSelectPreview(ctx context.Context, tsk types.TipSetKey, ticketQuality float64) (*api.MpoolSelectPreview, error) {
+	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
+	}
+
+	return a.Mpool.SelectMessagesPreview(ctx, ts, ticketQuality)
+}
+
 func (a *MpoolAPI) MpoolPending(ctx context.Context, tsk types.TipSetKey) ([]*types.SignedMessage, error) {
 	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
 	if err != nil {
@@ -133,7 +144,12 @@ func (a *MpoolAPI) MpoolClear(ctx context.Context, local bool) error {
 }
 
 func (m *MpoolModule) MpoolPush(ctx context.Context, smsg *types.SignedMessage) (cid.Cid, error) {
-	return m.Mpool.Push(ctx, smsg, true)
+	c, err := m.Mpool.Push(ctx, smsg, true)
+	if xerrors.Is(err, messagepool.ErrExistingNonce) {
+		// the very same message was pushed before
+		return cid.Undef, &api.ErrMessageInMpool{}
+	}
+	return c, err
 }
 
 func (a *MpoolAPI) MpoolPushUntrusted(ctx context.Context, smsg *types.SignedMessage) (cid.Cid, error) {
@@ -238,6 +254,102 @@ func (a *MpoolAPI) MpoolPushMessage(ctx context.Context, msg *types.Message, spe
 	return signedMsg, nil
 }
 
+func (a *MpoolAPI) MpoolPredictCid(ctx context.Context, msg *types.Message, key address.Address) (cid.Cid, error) {
+	keyAddr, err := a.Stmgr.ResolveToDeterministicAddress(ctx, key, nil)
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("getting key address: %w", err)
+	}
+
+	// BLS messages are included unsigned, signatures are aggregated in blocks
+	if keyAddr.Protocol() == address.BLS {
+		return msg.Cid(), nil
+	}
+
+	// secp256k1 signatures are deterministic, so is the CID of the signed message
+	smsg, err := a.WalletSignMessage(ctx, keyAddr, msg)
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("signing message: %w", err)
+	}
+	return smsg.Cid(), nil
+}
+
+func (a *MpoolAPI) MpoolReplace(ctx context.Context, spec api.MpoolReplaceSpec) (*api.MpoolReplaceResult, error) {
+	found, err := a.findPending(ctx, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := found.Message
+	rbf := messagepool.ComputeRBF(msg.GasPremium, a.Mpool.GetConfig().ReplaceByFeeRatio)
+
+	var mss *api.MessageSendSpec
+	if spec.MaxFee.Int != nil && !spec.MaxFee.IsZero() {
+		mss = &api.MessageSendSpec{MaxFee: spec.MaxFee}
+	}
+
+	msg.GasFeeCap = abi.NewTokenAmount(0)
+	msg.GasPremium = abi.NewTokenAmount(0)
+	est, err := a.GasAPI.GasEstimateMessageGas(ctx, &msg, mss, types.EmptyTSK)
+	if err != nil {
+		return nil, xerrors.Errorf("estimating gas values: %w", err)
+	}
+
+	// keep the gas limit of the pending message, the estimate doesn't account
+	// for the messages of the sender already in the mempool
+	msg.GasPremium = big.Max(est.GasPremium, rbf)
+	msg.GasFeeCap = big.Max(est.GasFeeCap, msg.GasPremium)
+	messagepool.CapGasFee(a.GetMaxFee, &msg, mss)
+
+	if msg.GasPremium.LessThan(rbf) {
+		return nil, xerrors.Errorf("max fee %s doesn't allow the gas premium %s needed to replace the message", types.FIL(types.BigMul(msg.GasFeeCap, types.NewInt(uint64(msg.GasLimit)))), rbf)
+	}
+
+	smsg, err := a.WalletSignMessage(ctx, msg.From, &msg)
+	if err != nil {
+		return nil, xerrors.Errorf("signing message: %w", err)
+	}
+
+	c, err := a.MpoolModuleAPI.MpoolPush(ctx, smsg)
+	if err != nil {
+		return nil, xerrors.Errorf("pushing replacing message: %w", err)
+	}
+
+	return &api.MpoolReplaceResult{
+		Old: messageFees(found.Cid(), &found.Message),
+		New: messageFees(c, &smsg.Message),
+	}, nil
+}
+
+func (a *MpoolAPI) findPending(ctx context.Context, spec api.MpoolReplaceSpec) (*types.SignedMessage, error) {
+	pending, _ := a.Mpool.Pending(ctx)
+	for _, p := range pending {
+		if spec.Message != nil {
+			if p.Cid() == *spec.Message || p.Message.Cid() == *spec.Message {
+				return p, nil
+			}
+			continue
+		}
+		if p.Message.From == spec.From && p.Message.Nonce == spec.Nonce {
+			return p, nil
+		}
+	}
+
+	if spec.Message != nil {
+		return nil, xerrors.Errorf("no pending message found with cid %s", spec.Message)
+	}
+	return nil, xerrors.Errorf("no pending message found from %s with nonce %d", spec.From, spec.Nonce)
+}
+
+func messageFees(c cid.Cid, msg *types.Message) api.MessageFees {
+	return api.MessageFees{
+		Cid:        c,
+		GasLimit:   msg.GasLimit,
+		GasFeeCap:  msg.GasFeeCap,
+		GasPremium: msg.GasPremium,
+		MaxFee:     types.BigMul(msg.GasFeeCap, types.NewInt(uint64(msg.GasLimit))),
+	}
+}
+
 func (a *MpoolAPI) MpoolBatchPush(ctx context.Context, smsgs []*types.SignedMessage) ([]cid.Cid, error) {
 	var messageCids []cid.Cid
 	for _, smsg := range smsgs {
@@ -293,3 +405,11 @@ func (a *MpoolAPI) MpoolGetNonce(ctx context.Context, addr address.Address) (uin
 func (a *MpoolAPI) MpoolSub(ctx context.Context) (<-chan api.MpoolUpdate, error) {
 	return a.Mpool.Updates(ctx)
 }
+
+func (a *MpoolAPI) MpoolGasMarket(ctx context.Context, lookback int) (*api.MpoolGasMarket, error) {
+	return a.Mpool.GasMarket(ctx, lookback)
+}
+
+func (a *MpoolAPI) MpoolGasMarketSub(ctx context.Context, lookback int) (<-chan api.MpoolGasMarket, error) {
+	return a.Mpool.GasMarketUpdates(ctx, lookback)
+}
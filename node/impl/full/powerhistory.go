@@ -0,0 +1,40 @@
+package full
+
+import (
+	"context"
+
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/powerhistory"
+	"github.com/filecoin-project/lotus/chain/stmgr"
+	"github.com/filecoin-project/lotus/chain/store"
+)
+
+type PowerHistoryAPI struct {
+	fx.In
+
+	StateManager *stmgr.StateManager
+	Chain        *store.ChainStore
+	History      *powerhistory.History `optional:"true"`
+}
+
+func (a *PowerHistoryAPI) StateMinerPowerHistory(ctx context.Context, miner address.Address, from, to abi.ChainEpoch) ([]api.MinerPowerSample, error) {
+	if a.History == nil {
+		return nil, xerrors.Errorf("power history not enabled. Please check your configuration")
+	}
+
+	id, err := a.StateManager.LookupID(ctx, miner, a.Chain.GetHeaviestTipSet())
+	if err != nil {
+		return nil, xerrors.Errorf("looking up miner ID: %w", err)
+	}
+	if !a.History.Watched(miner, id) {
+		return nil, xerrors.Errorf("miner %s is not sampled, it must be listed in Index.PowerHistoryMiners", miner)
+	}
+
+	return a.History.Query(ctx, id, from, to)
+}
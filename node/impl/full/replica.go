@@ -0,0 +1,40 @@
+package full
+
+import (
+	"context"
+
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/replica"
+)
+
+type ReplicaAPI struct {
+	fx.In
+
+	Server   *replica.Server   `optional:"true"`
+	Follower *replica.Follower `optional:"true"`
+}
+
+func (a *ReplicaAPI) ChainReplicaStatus(ctx context.Context) (*api.ReplicaStatus, error) {
+	if a.Server == nil && a.Follower == nil {
+		return nil, xerrors.Errorf("chain replication not enabled. Please check your configuration")
+	}
+
+	st := &api.ReplicaStatus{Role: replica.RolePrimary}
+	if a.Follower != nil {
+		st = a.Follower.Status()
+	}
+	if a.Server != nil {
+		st.Standbys = a.Server.Standbys()
+	}
+	return st, nil
+}
+
+func (a *ReplicaAPI) ChainReplicaPromote(ctx context.Context) error {
+	if a.Follower == nil {
+		return xerrors.Errorf("node isn't a standby. Please check your configuration")
+	}
+	return a.Follower.Promote(ctx)
+}
@@ -0,0 +1,24 @@
+package full
+
+import (
+	"context"
+
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore/scrub"
+)
+
+type BlockstoreScrubAPI struct {
+	fx.In
+
+	Scrubber *scrub.Scrubber `optional:"true"`
+}
+
+func (a *BlockstoreScrubAPI) ChainBlockstoreScrubStatus(ctx context.Context) (*api.BlockstoreScrubStatus, error) {
+	if a.Scrubber == nil {
+		return nil, xerrors.Errorf("blockstore scrubber not enabled. Please check your configuration")
+	}
+	return a.Scrubber.Status(ctx)
+}
@@ -0,0 +1,26 @@
+package full
+
+import (
+	"context"
+
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/gen/slashfilter/slashsvc"
+)
+
+type ConsensusFaultAPI struct {
+	fx.In
+
+	Reporter *slashsvc.Reporter `optional:"true"`
+}
+
+func (a *ConsensusFaultAPI) ChainConsensusFaults(ctx context.Context, since abi.ChainEpoch) ([]api.ConsensusFault, error) {
+	if a.Reporter == nil {
+		return nil, xerrors.Errorf("consensus fault reporter not enabled. Please check your configuration")
+	}
+	return a.Reporter.Faults(ctx, since)
+}
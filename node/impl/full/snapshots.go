@@ -0,0 +1,24 @@
+package full
+
+import (
+	"context"
+
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/snapshot"
+)
+
+type SnapshotAPI struct {
+	fx.In
+
+	Snapshots *snapshot.Service `optional:"true"`
+}
+
+func (a *SnapshotAPI) ChainSnapshots(ctx context.Context) ([]api.ChainSnapshot, error) {
+	if a.Snapshots == nil {
+		return nil, xerrors.Errorf("snapshot service not enabled. Please check your configuration")
+	}
+	return a.Snapshots.List(), nil
+}
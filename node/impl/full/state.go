@@ -48,6 +48,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/chain/vm"
 	"github.com/filecoin-project/lotus/chain/wallet"
+	"github.com/filecoin-project/lotus/lib/shutdown"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 	"github.com/filecoin-project/lotus/storage/sealer/storiface"
 )
@@ -83,6 +84,7 @@ type StateModule struct {
 
 	StateManager *stmgr.StateManager
 	Chain        *store.ChainStore
+	Shutdowns    *shutdown.Coordinator `optional:"true"`
 }
 
 var _ StateModuleAPI = (*StateModule)(nil)
@@ -419,6 +421,24 @@ func (a *StateAPI) StateCall(ctx context.Context, msg *types.Message, tsk types.
 	return res, err
 }
 
+func (a *StateAPI) StateCallWithOverrides(ctx context.Context, msg *types.Message, overrides []api.ActorOverride, tsk types.TipSetKey) (res *api.InvocResult, err error) {
+	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
+	}
+	for {
+		res, err = a.StateManager.CallWithOverrides(ctx, msg, overrides, ts)
+		if err != stmgr.ErrExpensiveFork {
+			break
+		}
+		ts, err = a.Chain.GetTipSetFromKey(ctx, ts.Parents())
+		if err != nil {
+			return nil, xerrors.Errorf("getting parent tipset: %w", err)
+		}
+	}
+	return res, err
+}
+
 func (a *StateAPI) StateReplay(ctx context.Context, tsk types.TipSetKey, mc cid.Cid) (*api.InvocResult, error) {
 	msgToReplay := mc
 	var ts *types.TipSet
@@ -471,6 +491,77 @@ func (a *StateAPI) StateReplay(ctx context.Context, tsk types.TipSetKey, mc cid.
 	}, nil
 }
 
+// maxEventReplayRange is the maximum number of epochs replayed by a single
+// StateReplayEvents call.
+const maxEventReplayRange = abi.ChainEpoch(builtin.EpochsInDay)
+
+func (a *StateAPI) StateReplayEvents(ctx context.Context, from, to abi.ChainEpoch) (<-chan api.EventReplayUpdate, error) {
+	if from < 0 || to < from {
+		return nil, xerrors.Errorf("invalid epoch range [%d, %d]", from, to)
+	}
+	if to-from >= maxEventReplayRange {
+		return nil, xerrors.Errorf("epoch range [%d, %d] too large, at most %d epochs can be replayed at once", from, to, maxEventReplayRange)
+	}
+
+	head := a.Chain.GetHeaviestTipSet()
+	if to > head.Height() {
+		return nil, xerrors.Errorf("epoch %d is above the head of the chain (%d)", to, head.Height())
+	}
+
+	// the tipsets in the range, the null rounds are skipped
+	ts, err := a.Chain.GetTipsetByHeight(ctx, to, head, true)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset at height %d: %w", to, err)
+	}
+	var tipsets []*types.TipSet
+	for ts.Height() >= from {
+		tipsets = append(tipsets, ts)
+		if ts.Height() == 0 {
+			break
+		}
+
+		ts, err = a.Chain.LoadTipSet(ctx, ts.Parents())
+		if err != nil {
+			return nil, xerrors.Errorf("loading parent tipset: %w", err)
+		}
+	}
+
+	out := make(chan api.EventReplayUpdate)
+	go func() {
+		defer close(out)
+
+		for i := range tipsets {
+			ts := tipsets[len(tipsets)-1-i]
+			upd := api.EventReplayUpdate{
+				Height:   ts.Height(),
+				TipSet:   ts.Key(),
+				Replayed: i + 1,
+				Total:    len(tipsets),
+			}
+
+			events, err := a.StateManager.ReplayEvents(ctx, ts)
+			if err != nil {
+				upd.Replayed = i
+				upd.Error = err.Error()
+			}
+			upd.Events = events
+
+			select {
+			case out <- upd:
+			case <-ctx.Done():
+				log.Warnf("replaying events: %s", ctx.Err())
+				return
+			}
+
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
 func (m *StateModule) StateGetActor(ctx context.Context, actor address.Address, tsk types.TipSetKey) (a *types.Actor, err error) {
 	ts, err := m.Chain.GetTipSetFromKey(ctx, tsk)
 	if err != nil {
@@ -479,6 +570,14 @@ func (m *StateModule) StateGetActor(ctx context.Context, actor address.Address,
 	return m.StateManager.LoadActor(ctx, actor, ts)
 }
 
+func (a *StateAPI) StateGetProof(ctx context.Context, actor address.Address, tsk types.TipSetKey) (*api.ActorStateProof, error) {
+	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
+	}
+	return a.StateManager.GetActorProof(ctx, actor, ts)
+}
+
 func (m *StateModule) StateLookupID(ctx context.Context, addr address.Address, tsk types.TipSetKey) (address.Address, error) {
 	ts, err := m.Chain.GetTipSetFromKey(ctx, tsk)
 	if err != nil {
@@ -502,6 +601,26 @@ func (a *StateAPI) StateLookupRobustAddress(ctx context.Context, addr address.Ad
 	return a.StateManager.LookupRobustAddress(ctx, addr, ts)
 }
 
+func (a *StateAPI) StateLookupDelegatedAddress(ctx context.Context, addr address.Address, tsk types.TipSetKey) (address.Address, error) {
+	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
+	if err != nil {
+		return address.Undef, xerrors.Errorf("loading tipset %s: %w", tsk, err)
+	}
+
+	act, err := a.StateManager.LoadActor(ctx, addr, ts)
+	if err != nil {
+		if xerrors.Is(err, types.ErrActorNotFound) {
+			return address.Undef, &api.ErrActorNotFound{}
+		}
+		return address.Undef, xerrors.Errorf("loading actor: %w", err)
+	}
+	if act.Address == nil || act.Address.Protocol() != address.Delegated {
+		return address.Undef, xerrors.Errorf("actor %s has no delegated address", addr)
+	}
+
+	return *act.Address, nil
+}
+
 func (m *StateModule) StateAccountKey(ctx context.Context, addr address.Address, tsk types.TipSetKey) (address.Address, error) {
 	ts, err := m.Chain.GetTipSetFromKey(ctx, tsk)
 	if err != nil {
@@ -574,6 +693,42 @@ func (a *StateAPI) StateEncodeParams(ctx context.Context, toActCode cid.Cid, met
 	return cbb.Bytes(), nil
 }
 
+func (a *StateAPI) StateActorSchema(ctx context.Context, code cid.Cid) (*api.ActorSchema, error) {
+	return stmgr.ActorSchema(a.TsExec.NewActorRegistry(), code)
+}
+
+func (a *StateAPI) StateEncodeMethodParams(ctx context.Context, method api.ActorMethod, params json.RawMessage) ([]byte, error) {
+	nv, err := a.StateNetworkVersion(ctx, types.EmptyTSK)
+	if err != nil {
+		return nil, err
+	}
+	return stmgr.EncodeMethodParams(a.TsExec.NewActorRegistry(), method, nv, params)
+}
+
+func (a *StateAPI) StateDecodeMethodParams(ctx context.Context, method api.ActorMethod, params []byte) (interface{}, error) {
+	nv, err := a.StateNetworkVersion(ctx, types.EmptyTSK)
+	if err != nil {
+		return nil, err
+	}
+	return stmgr.DecodeMethodParams(a.TsExec.NewActorRegistry(), method, nv, params)
+}
+
+func (a *StateAPI) StateDecodeMethodReturn(ctx context.Context, method api.ActorMethod, ret []byte) (interface{}, error) {
+	nv, err := a.StateNetworkVersion(ctx, types.EmptyTSK)
+	if err != nil {
+		return nil, err
+	}
+	return stmgr.DecodeMethodReturn(a.TsExec.NewActorRegistry(), method, nv, ret)
+}
+
+func (a *StateAPI) StateMigrationDryRun(ctx context.Context, tsk types.TipSetKey) (*api.MigrationDryRun, error) {
+	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
+	}
+	return a.StateManager.MigrationDryRun(ctx, ts)
+}
+
 // This is on StateAPI because miner.Miner requires this, and MinerAPI requires miner.Miner
 func (a *StateAPI) MinerGetBaseInfo(ctx context.Context, maddr address.Address, epoch abi.ChainEpoch, tsk types.TipSetKey) (*api.MiningBaseInfo, error) {
 	// XXX: Gets the state by computing the tipset state, instead of looking at the parent.
@@ -602,6 +757,10 @@ func (a *StateAPI) MinerCreateBlock(ctx context.Context, bt *api.BlockTemplate)
 }
 
 func (m *StateModule) StateWaitMsg(ctx context.Context, msg cid.Cid, confidence uint64, lookbackLimit abi.ChainEpoch, allowReplaced bool) (*api.MsgLookup, error) {
+	if m.Shutdowns != nil {
+		defer m.Shutdowns.Track(shutdown.ComponentRPC, "message wait", msg.String())()
+	}
+
 	ts, recpt, found, err := m.StateManager.WaitForMessage(ctx, msg, confidence, lookbackLimit, allowReplaced)
 	if err != nil {
 		return nil, err
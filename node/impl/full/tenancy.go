@@ -0,0 +1,37 @@
+package full
+
+import (
+	"context"
+
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/node/tenancy"
+)
+
+type TenancyAPI struct {
+	fx.In
+
+	Wallet  api.Wallet
+	Tenants *tenancy.Registry
+}
+
+func (a *TenancyAPI) TenantAssignWallet(ctx context.Context, addr address.Address, namespace string) error {
+	if namespace != "" {
+		has, err := a.Wallet.WalletHas(ctx, addr)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return xerrors.Errorf("address %s not found in the wallet", addr)
+		}
+	}
+	return a.Tenants.Assign(ctx, addr, namespace)
+}
+
+func (a *TenancyAPI) TenantList(ctx context.Context) ([]api.Tenant, error) {
+	return a.Tenants.Tenants(ctx)
+}
@@ -0,0 +1,41 @@
+package full
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/webhooks"
+)
+
+type WebhookAPI struct {
+	fx.In
+
+	Webhooks *webhooks.Manager `optional:"true"`
+}
+
+var errWebhooksDisabled = xerrors.Errorf("webhooks not enabled. Please check your configuration")
+
+func (a *WebhookAPI) WebhookSubscribe(ctx context.Context, url string, secret string, patterns []api.WebhookPattern) (uuid.UUID, error) {
+	if a.Webhooks == nil {
+		return uuid.Nil, errWebhooksDisabled
+	}
+	return a.Webhooks.Subscribe(ctx, url, secret, patterns)
+}
+
+func (a *WebhookAPI) WebhookUnsubscribe(ctx context.Context, id uuid.UUID) error {
+	if a.Webhooks == nil {
+		return errWebhooksDisabled
+	}
+	return a.Webhooks.Unsubscribe(ctx, id)
+}
+
+func (a *WebhookAPI) WebhookList(ctx context.Context) ([]api.WebhookSubscription, error) {
+	if a.Webhooks == nil {
+		return nil, errWebhooksDisabled
+	}
+	return a.Webhooks.List(), nil
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/filecoin-project/go-address"
 
+	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/chain/actors"
 	marketactor "github.com/filecoin-project/lotus/chain/actors/builtin/market"
 	"github.com/filecoin-project/lotus/chain/market"
@@ -58,3 +59,7 @@ func (a *MarketAPI) MarketReleaseFunds(ctx context.Context, addr address.Address
 func (a *MarketAPI) MarketWithdraw(ctx context.Context, wallet, addr address.Address, amt types.BigInt) (cid.Cid, error) {
 	return a.FMgr.Withdraw(ctx, wallet, addr, amt)
 }
+
+func (a *MarketAPI) MarketFundsStatus(ctx context.Context, addr address.Address) (api.MarketFundsStatus, error) {
+	return a.FMgr.FundsStatus(ctx, addr)
+}
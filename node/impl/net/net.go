@@ -20,6 +20,7 @@ import (
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/lib/peerlist"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 	"github.com/filecoin-project/lotus/node/modules/lp2p"
 )
@@ -34,6 +35,9 @@ type NetAPI struct {
 	ResourceManager network.ResourceManager
 	Reporter        metrics.Reporter
 	Sk              *dtypes.ScoreKeeper
+	AllowList       *peerlist.AllowList
+	Rejects         *lp2p.RejectTracker
+	NATTracker      *lp2p.NATTracker `optional:"true"`
 }
 
 func (a *NetAPI) ID(context.Context) (peer.ID, error) {
@@ -60,6 +64,14 @@ func (a *NetAPI) NetPubsubScores(context.Context) ([]api.PubsubScore, error) {
 	return out, nil
 }
 
+func (a *NetAPI) NetPubsubRejections(context.Context) ([]api.PubsubTopicRejections, error) {
+	return a.Rejects.Rejections(), nil
+}
+
+func (a *NetAPI) NetPubsubRejectedMessages(_ context.Context, topic string) ([]api.PubsubRejectedMessage, error) {
+	return a.Rejects.RejectedMessages(topic), nil
+}
+
 func (a *NetAPI) NetPeers(context.Context) ([]peer.AddrInfo, error) {
 	conns := a.Host.Network().Conns()
 	out := make([]peer.AddrInfo, len(conns))
@@ -135,27 +147,60 @@ func (a *NetAPI) NetFindPeer(ctx context.Context, p peer.ID) (peer.AddrInfo, err
 }
 
 func (a *NetAPI) NetAutoNatStatus(ctx context.Context) (i api.NatInfo, err error) {
-	autonat := a.RawHost.(*basichost.BasicHost).GetAutoNat()
+	i.Reachability = network.ReachabilityUnknown
+	i.Relays, i.RelayAddrs = relayAddrs(a.Host.Addrs())
+	if a.NATTracker != nil {
+		i.ReachabilityHistory = a.NATTracker.ReachabilityHistory()
+		i.HolePunch = a.NATTracker.HolePunchStats()
+	}
 
+	autonat := a.RawHost.(*basichost.BasicHost).GetAutoNat()
 	if autonat == nil {
-		return api.NatInfo{
-			Reachability: network.ReachabilityUnknown,
-		}, nil
+		return i, nil
 	}
 
-	var maddr string
-	if autonat.Status() == network.ReachabilityPublic {
+	i.Reachability = autonat.Status()
+	if i.Reachability == network.ReachabilityPublic {
 		pa, err := autonat.PublicAddr()
 		if err != nil {
 			return api.NatInfo{}, err
 		}
-		maddr = pa.String()
+		i.PublicAddr = pa.String()
 	}
 
-	return api.NatInfo{
-		Reachability: autonat.Status(),
-		PublicAddr:   maddr,
-	}, nil
+	return i, nil
+}
+
+// relayAddrs returns the circuit relay addresses among the given ones, and
+// the relays they go through.
+func relayAddrs(addrs []ma.Multiaddr) ([]peer.ID, []string) {
+	var relays []peer.ID
+	var out []string
+	seen := map[peer.ID]struct{}{}
+
+	for _, addr := range addrs {
+		if _, err := addr.ValueForProtocol(ma.P_CIRCUIT); err != nil {
+			continue
+		}
+		out = append(out, addr.String())
+
+		relayAddr, _ := ma.SplitFunc(addr, func(c ma.Component) bool {
+			return c.Protocol().Code == ma.P_CIRCUIT
+		})
+		if relayAddr == nil {
+			continue
+		}
+		info, err := peer.AddrInfoFromP2pAddr(relayAddr)
+		if err != nil {
+			continue
+		}
+		if _, ok := seen[info.ID]; !ok {
+			seen[info.ID] = struct{}{}
+			relays = append(relays, info.ID)
+		}
+	}
+
+	return relays, out
 }
 
 func (a *NetAPI) NetAgentVersion(ctx context.Context, p peer.ID) (string, error) {
@@ -0,0 +1,67 @@
+package net
+
+import (
+	"context"
+	"sort"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+func (a *NetAPI) NetPeerScores(context.Context) ([]api.PeerScore, error) {
+	pubsubScores := a.Sk.Get()
+	cm := a.Host.ConnManager()
+
+	var out []api.PeerScore
+	for _, p := range a.Host.Network().Peers() {
+		ps := api.PeerScore{
+			ID:        p,
+			Protected: cm.IsProtected(p, ""),
+		}
+		if ti := cm.GetTagInfo(p); ti != nil {
+			ps.ConnMgrValue = ti.Value
+			ps.Tags = ti.Tags
+		}
+		if s, ok := pubsubScores[p]; ok && s != nil {
+			ps.PubsubScore = s.Score
+		}
+		out = append(out, ps)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].ConnMgrValue > out[j].ConnMgrValue
+	})
+
+	return out, nil
+}
+
+func (a *NetAPI) NetSetPeerTag(ctx context.Context, p peer.ID, tag string, value int) error {
+	if value == 0 {
+		a.Host.ConnManager().UntagPeer(p, tag)
+		return nil
+	}
+
+	a.Host.ConnManager().TagPeer(p, tag, value)
+	return nil
+}
+
+func (a *NetAPI) NetAllowAdd(ctx context.Context, peers []peer.AddrInfo) error {
+	return a.AllowList.Add(ctx, peers)
+}
+
+func (a *NetAPI) NetAllowRemove(ctx context.Context, peers []peer.ID) error {
+	return a.AllowList.Remove(ctx, peers)
+}
+
+func (a *NetAPI) NetAllowList(context.Context) ([]peer.AddrInfo, error) {
+	return a.AllowList.List(), nil
+}
+
+func (a *NetAPI) NetGetReconnectPolicy(context.Context) (api.NetReconnectPolicy, error) {
+	return a.AllowList.ReconnectPolicy(), nil
+}
+
+func (a *NetAPI) NetSetReconnectPolicy(ctx context.Context, policy api.NetReconnectPolicy) error {
+	return a.AllowList.SetReconnectPolicy(ctx, policy)
+}
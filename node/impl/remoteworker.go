@@ -38,15 +38,12 @@ func connectRemoteWorker(ctx context.Context, fa api.Common, url string) (*remot
 		return nil, xerrors.Errorf("creating jsonrpc client: %w", err)
 	}
 
-	wver, err := wapi.Version(ctx)
+	caps, err := client.NegotiateWorker(ctx, wapi, api.WorkerAPIVersion0)
 	if err != nil {
 		closer()
-		return nil, err
-	}
-
-	if !wver.EqMajorMinor(api.WorkerAPIVersion0) {
-		return nil, xerrors.Errorf("unsupported worker api version: %s (expected %s)", wver, api.WorkerAPIVersion0)
+		return nil, xerrors.Errorf("unsupported worker api: %w", err)
 	}
+	client.WarnDeprecated(caps, wapi)
 
 	return &remoteWorker{wapi, closer}, nil
 }
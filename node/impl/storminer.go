@@ -9,6 +9,7 @@ import (
 	"os"
 	"sort"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,6 +18,7 @@ import (
 	gsimpl "github.com/ipfs/go-graphsync/impl"
 	"github.com/ipfs/go-graphsync/peerstate"
 	"github.com/libp2p/go-libp2p/core/host"
+	inet "github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"go.uber.org/fx"
 	"golang.org/x/xerrors"
@@ -45,19 +47,39 @@ import (
 	"github.com/filecoin-project/lotus/chain/actors/builtin"
 	"github.com/filecoin-project/lotus/chain/gen"
 	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/lib/diskforecast"
+	"github.com/filecoin-project/lotus/lib/selftest"
+	"github.com/filecoin-project/lotus/markets/askschedule"
+	"github.com/filecoin-project/lotus/markets/commpdiag"
 	mktsdagstore "github.com/filecoin-project/lotus/markets/dagstore"
+	"github.com/filecoin-project/lotus/markets/dealsla"
+	"github.com/filecoin-project/lotus/markets/dtprogress"
+	"github.com/filecoin-project/lotus/markets/dtrestart"
+	"github.com/filecoin-project/lotus/markets/labels"
+	"github.com/filecoin-project/lotus/markets/retrievability"
+	"github.com/filecoin-project/lotus/markets/retrievalstats"
+	"github.com/filecoin-project/lotus/markets/retrievaltimeline"
 	"github.com/filecoin-project/lotus/markets/storageadapter"
 	"github.com/filecoin-project/lotus/miner"
 	"github.com/filecoin-project/lotus/node/modules"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
+	"github.com/filecoin-project/lotus/node/repo"
 	"github.com/filecoin-project/lotus/storage/ctladdr"
+	"github.com/filecoin-project/lotus/storage/disputewatch"
+	"github.com/filecoin-project/lotus/storage/faultdetect"
 	"github.com/filecoin-project/lotus/storage/paths"
 	sealing "github.com/filecoin-project/lotus/storage/pipeline"
 	"github.com/filecoin-project/lotus/storage/pipeline/sealiface"
+	"github.com/filecoin-project/lotus/storage/pipeline/sectordb"
 	"github.com/filecoin-project/lotus/storage/sealer"
 	"github.com/filecoin-project/lotus/storage/sealer/fsutil"
+	"github.com/filecoin-project/lotus/storage/sealer/sealtasks"
 	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+	"github.com/filecoin-project/lotus/storage/sealer/tasklog"
 	"github.com/filecoin-project/lotus/storage/sectorblocks"
+	"github.com/filecoin-project/lotus/storage/sectorcost"
+	"github.com/filecoin-project/lotus/storage/spend"
+	"github.com/filecoin-project/lotus/storage/unsealregen"
 	"github.com/filecoin-project/lotus/storage/wdpost"
 )
 
@@ -68,6 +90,7 @@ type StorageMinerAPI struct {
 	api.Net
 
 	EnabledSubsystems api.MinerSubsystems
+	ReadOnly          dtypes.ReadOnlyAPI `optional:"true"`
 
 	Full        api.FullNode
 	LocalStore  *paths.Local
@@ -86,20 +109,40 @@ type StorageMinerAPI struct {
 	Host              host.Host                         `optional:"true"`
 	DAGStore          *dagstore.DAGStore                `optional:"true"`
 	DAGStoreWrapper   *mktsdagstore.Wrapper             `optional:"true"`
+	RetrievalStats    *retrievalstats.Tracker           `optional:"true"`
+	RetrievalTimeline *retrievaltimeline.Recorder       `optional:"true"`
+	DealSLA           *dealsla.Tracker                  `optional:"true"`
+	Retrievability    *retrievability.Sampler           `optional:"true"`
+	TransferRestarts  *dtrestart.Monitor                `optional:"true"`
+	TransferProgress  *dtprogress.Tracker               `optional:"true"`
+	CommPDiagnostics  *commpdiag.Recorder               `optional:"true"`
+	AskSchedule       *askschedule.Manager              `optional:"true"`
+	Labels            *labels.Store                     `optional:"true"`
 
 	// Miner / storage
-	Miner       *sealing.Sealing     `optional:"true"`
-	BlockMiner  *miner.Miner         `optional:"true"`
-	StorageMgr  *sealer.Manager      `optional:"true"`
-	IStorageMgr sealer.SectorManager `optional:"true"`
+	Miner       *sealing.Sealing         `optional:"true"`
+	SectorDB    *sectordb.DB             `optional:"true"`
+	UnsealRegen *unsealregen.Regenerator `optional:"true"`
+	FaultDetect *faultdetect.Detector    `optional:"true"`
+	Disputes    *disputewatch.Watcher    `optional:"true"`
+	ProofParams *modules.ProofParams     `optional:"true"`
+	BlockMiner  *miner.Miner             `optional:"true"`
+	StorageMgr  *sealer.Manager          `optional:"true"`
+	IStorageMgr sealer.SectorManager     `optional:"true"`
+	TaskLogs    *tasklog.Store           `optional:"true"`
 	paths.SectorIndex
 	storiface.WorkerReturn `optional:"true"`
 	AddrSel                *ctladdr.AddressSelector
+	BalanceMonitor         *ctladdr.BalanceMonitor `optional:"true"`
+	SpendLedger            *spend.Ledger
 
 	WdPoSt *wdpost.WindowPoStScheduler `optional:"true"`
 
-	Epp gen.WinningPoStProver `optional:"true"`
-	DS  dtypes.MetadataDS
+	Epp  gen.WinningPoStProver `optional:"true"`
+	DS   dtypes.MetadataDS
+	Repo repo.LockedRepo
+
+	DiskForecastTracker *diskforecast.Tracker `optional:"true"`
 
 	// StorageService is populated when we're not the main storage node (e.g. we're a markets node)
 	StorageService modules.MinerStorageService `optional:"true"`
@@ -156,6 +199,24 @@ func (sm *StorageMinerAPI) WorkerJobs(ctx context.Context) (map[uuid.UUID][]stor
 	return sm.StorageMgr.WorkerJobs(), nil
 }
 
+func (sm *StorageMinerAPI) WorkerDrain(ctx context.Context, worker uuid.UUID) error {
+	return sm.StorageMgr.WorkerDrain(ctx, storiface.WorkerID(worker), func(ctx context.Context, sector abi.SectorID) (abi.RegisteredSealProof, error) {
+		if sm.Miner == nil {
+			return 0, xerrors.Errorf("sealing pipeline not running")
+		}
+
+		si, err := sm.Miner.GetSectorInfo(sector.Number)
+		if err != nil {
+			return 0, err
+		}
+		return si.SectorType, nil
+	})
+}
+
+func (sm *StorageMinerAPI) WorkerUndrain(ctx context.Context, worker uuid.UUID) error {
+	return sm.StorageMgr.WorkerUndrain(ctx, storiface.WorkerID(worker))
+}
+
 func (sm *StorageMinerAPI) ActorAddress(context.Context) (address.Address, error) {
 	return sm.Miner.Address(), nil
 }
@@ -168,6 +229,17 @@ func (sm *StorageMinerAPI) MiningBase(ctx context.Context) (*types.TipSet, error
 	return mb.TipSet, nil
 }
 
+func (sm *StorageMinerAPI) MinerBlocksReport(ctx context.Context, from, to abi.ChainEpoch) (*api.MinerBlocksReport, error) {
+	if sm.BlockMiner == nil {
+		return nil, xerrors.Errorf("block production is disabled on this node")
+	}
+	return sm.BlockMiner.BlocksReport(ctx, from, to)
+}
+
+func (sm *StorageMinerAPI) MinerSpendReport(ctx context.Context, from, to, bucket abi.ChainEpoch) (*api.SpendReport, error) {
+	return sm.SpendLedger.Report(ctx, from, to, bucket)
+}
+
 func (sm *StorageMinerAPI) ActorSectorSize(ctx context.Context, addr address.Address) (abi.SectorSize, error) {
 	mi, err := sm.Full.StateMinerInfo(ctx, addr, types.EmptyTSK)
 	if err != nil {
@@ -177,6 +249,10 @@ func (sm *StorageMinerAPI) ActorSectorSize(ctx context.Context, addr address.Add
 }
 
 func (sm *StorageMinerAPI) PledgeSector(ctx context.Context) (abi.SectorID, error) {
+	if err := sm.DiskForecastTracker.Admit(); err != nil {
+		return abi.SectorID{}, xerrors.Errorf("not pledging a sector: %w", err)
+	}
+
 	sr, err := sm.Miner.PledgeSector(ctx)
 	if err != nil {
 		return abi.SectorID{}, err
@@ -240,6 +316,29 @@ func (sm *StorageMinerAPI) SectorsStatus(ctx context.Context, sid abi.SectorNumb
 	return sInfo, nil
 }
 
+func (sm *StorageMinerAPI) SectorsStatusBatch(ctx context.Context, sids []abi.SectorNumber, showOnChainInfo bool) ([]api.SectorStatusResult, error) {
+	out := make([]api.SectorStatusResult, len(sids))
+	forEachParallel(ctx, len(sids), func(i int, ctxErr error) {
+		out[i].SectorID = sids[i]
+		if ctxErr != nil {
+			out[i].Error = ctxErr.Error()
+			return
+		}
+
+		si, err := sm.SectorsStatus(ctx, sids[i], showOnChainInfo)
+		if err != nil {
+			out[i].Error = err.Error()
+			return
+		}
+		out[i].Info = &si
+	})
+	return out, nil
+}
+
+func (sm *StorageMinerAPI) SectorsRetryPolicy(ctx context.Context, sid abi.SectorNumber) (api.SectorRetryPolicy, error) {
+	return sm.Miner.SectorRetryPolicy(sid)
+}
+
 func (sm *StorageMinerAPI) SectorAddPieceToAny(ctx context.Context, size abi.UnpaddedPieceSize, r storiface.Data, d api.PieceDealInfo) (api.SectorOffset, error) {
 	so, err := sm.Miner.SectorAddPieceToAny(ctx, size, r, d)
 	if err != nil {
@@ -299,6 +398,69 @@ func (sm *StorageMinerAPI) SectorsList(context.Context) ([]abi.SectorNumber, err
 	return out, nil
 }
 
+func (sm *StorageMinerAPI) SectorsQuery(ctx context.Context, q api.SectorQuery) ([]api.SectorQueryResult, error) {
+	if sm.SectorDB == nil {
+		return nil, xerrors.Errorf("sector database not enabled. Please check your configuration")
+	}
+	return sm.SectorDB.Query(ctx, q)
+}
+
+func (sm *StorageMinerAPI) SectorsUnsealRegenList(ctx context.Context) ([]api.UnsealRegenJob, error) {
+	if sm.UnsealRegen == nil {
+		return nil, xerrors.Errorf("unsealed copy regeneration not enabled. Please check your configuration")
+	}
+	return sm.UnsealRegen.List(), nil
+}
+
+func (sm *StorageMinerAPI) SectorsUnsealRegenApprove(ctx context.Context, sectors []abi.SectorNumber) error {
+	if sm.UnsealRegen == nil {
+		return xerrors.Errorf("unsealed copy regeneration not enabled. Please check your configuration")
+	}
+	return sm.UnsealRegen.Approve(sectors)
+}
+
+func (sm *StorageMinerAPI) SectorsUnsealRegenReject(ctx context.Context, sectors []abi.SectorNumber) error {
+	if sm.UnsealRegen == nil {
+		return xerrors.Errorf("unsealed copy regeneration not enabled. Please check your configuration")
+	}
+	return sm.UnsealRegen.Reject(sectors)
+}
+
+func (sm *StorageMinerAPI) ProvingDetectedFaults(ctx context.Context) ([]api.DetectedFault, error) {
+	if sm.FaultDetect == nil {
+		return nil, xerrors.Errorf("fault detection not enabled. Please check your configuration")
+	}
+	return sm.FaultDetect.List(), nil
+}
+
+func (sm *StorageMinerAPI) ProvingDetectedFaultsApprove(ctx context.Context, sectors []abi.SectorNumber) error {
+	if sm.FaultDetect == nil {
+		return xerrors.Errorf("fault detection not enabled. Please check your configuration")
+	}
+	return sm.FaultDetect.Approve(sectors)
+}
+
+func (sm *StorageMinerAPI) ProvingDetectedFaultsReject(ctx context.Context, sectors []abi.SectorNumber) error {
+	if sm.FaultDetect == nil {
+		return xerrors.Errorf("fault detection not enabled. Please check your configuration")
+	}
+	return sm.FaultDetect.Reject(sectors)
+}
+
+func (sm *StorageMinerAPI) ProvingDisputes(ctx context.Context) ([]api.WdPoStDispute, error) {
+	if sm.Disputes == nil {
+		return nil, xerrors.Errorf("dispute watcher not enabled. Please check your configuration")
+	}
+	return sm.Disputes.List(), nil
+}
+
+func (sm *StorageMinerAPI) ProvingDisputesAck(ctx context.Context, msgs []cid.Cid) error {
+	if sm.Disputes == nil {
+		return xerrors.Errorf("dispute watcher not enabled. Please check your configuration")
+	}
+	return sm.Disputes.Ack(ctx, msgs)
+}
+
 func (sm *StorageMinerAPI) SectorsListInStates(ctx context.Context, states []api.SectorState) ([]abi.SectorNumber, error) {
 	filterStates := make(map[sealing.SectorState]struct{})
 	for _, state := range states {
@@ -451,10 +613,38 @@ func (sm *StorageMinerAPI) SectorCommitFlush(ctx context.Context) ([]sealiface.C
 	return sm.Miner.CommitFlush(ctx)
 }
 
+func (sm *StorageMinerAPI) SectorsPendingSubmission(ctx context.Context) ([]api.PendingSubmission, error) {
+	out := sm.Miner.PendingSubmissions()
+	if sm.WdPoSt != nil {
+		out = append(out, sm.WdPoSt.PendingSubmissions()...)
+	}
+	return out, nil
+}
+
+func (sm *StorageMinerAPI) SectorsCostEstimate(ctx context.Context, spt abi.RegisteredSealProof, mix api.SectorDealMix, duration abi.ChainEpoch) (*api.SectorCostEstimate, error) {
+	if duration == 0 {
+		cfg, err := sm.GetSealingConfigFunc()
+		if err != nil {
+			return nil, xerrors.Errorf("get config: %w", err)
+		}
+		duration = abi.ChainEpoch(uint64(cfg.CommittedCapacitySectorLifetime.Seconds()) / builtin.EpochDurationSeconds)
+	}
+
+	maddr, err := sm.ActorAddress(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return sectorcost.Estimate(ctx, sm.Full, maddr, spt, mix, duration)
+}
+
 func (sm *StorageMinerAPI) SectorCommitPending(ctx context.Context) ([]abi.SectorID, error) {
 	return sm.Miner.CommitPending(ctx)
 }
 
+func (sm *StorageMinerAPI) SectorCommitAggregationSimulate(ctx context.Context) (*sealiface.CommitAggregationSimulation, error) {
+	return sm.Miner.CommitAggregationSimulate(ctx)
+}
+
 func (sm *StorageMinerAPI) SectorMatchPendingPiecesToOpenSectors(ctx context.Context) error {
 	return sm.Miner.SectorMatchPendingPiecesToOpenSectors(ctx)
 }
@@ -481,6 +671,9 @@ func (sm *StorageMinerAPI) SectorNumFree(ctx context.Context, name string) error
 
 func (sm *StorageMinerAPI) SectorReceive(ctx context.Context, meta api.RemoteSectorMeta) error {
 	if err := sm.Miner.Receive(ctx, meta); err != nil {
+		if xerrors.Is(err, sealing.ErrSectorExists) {
+			return &api.ErrSectorExists{}
+		}
 		return err
 	}
 
@@ -488,6 +681,28 @@ func (sm *StorageMinerAPI) SectorReceive(ctx context.Context, meta api.RemoteSec
 	return err
 }
 
+func (sm *StorageMinerAPI) SectorReceiveBatch(ctx context.Context, metas []api.RemoteSectorMeta) ([]api.SectorReceiveResult, error) {
+	errs, err := sm.Miner.ReceiveBatch(ctx, metas)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]api.SectorReceiveResult, len(metas))
+	for i, meta := range metas {
+		out[i].Sector = meta.Sector.Number
+		if errs[i] != nil {
+			out[i].Error = errs[i].Error()
+			continue
+		}
+
+		if _, err := sm.waitSectorStarted(ctx, meta.Sector); err != nil {
+			out[i].Error = err.Error()
+		}
+	}
+
+	return out, nil
+}
+
 func (sm *StorageMinerAPI) ComputeWindowPoSt(ctx context.Context, dlIdx uint64, tsk types.TipSetKey) ([]minertypes.SubmitWindowedPoStParams, error) {
 	var ts *types.TipSet
 	var err error
@@ -522,6 +737,10 @@ func (sm *StorageMinerAPI) SealingSchedDiag(ctx context.Context, doSched bool) (
 	return sm.StorageMgr.SchedDiag(ctx, doSched)
 }
 
+func (sm *StorageMinerAPI) SealingJobsTree(ctx context.Context) (storiface.JobsTree, error) {
+	return sm.StorageMgr.JobsTree(ctx)
+}
+
 func (sm *StorageMinerAPI) SealingAbort(ctx context.Context, call storiface.CallID) error {
 	return sm.StorageMgr.Abort(ctx, call)
 }
@@ -530,6 +749,33 @@ func (sm *StorageMinerAPI) SealingRemoveRequest(ctx context.Context, schedId uui
 	return sm.StorageMgr.RemoveSchedRequest(ctx, schedId)
 }
 
+func (sm *StorageMinerAPI) ReturnTaskLogs(ctx context.Context, log storiface.TaskLog) error {
+	if sm.TaskLogs == nil {
+		return xerrors.Errorf("task logs not enabled. Please check your configuration")
+	}
+	return sm.TaskLogs.Put(ctx, log)
+}
+
+func (sm *StorageMinerAPI) SealingTaskLogs(ctx context.Context, sector abi.SectorNumber, task sealtasks.TaskType) ([]storiface.TaskLog, error) {
+	if sm.TaskLogs == nil {
+		return nil, xerrors.Errorf("task logs not enabled. Please check your configuration")
+	}
+
+	minerAddr, err := sm.ActorAddress(ctx)
+	if err != nil {
+		return nil, err
+	}
+	minerID, err := address.IDFromAddress(minerAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	return sm.TaskLogs.Get(ctx, abi.SectorID{
+		Miner:  abi.ActorID(minerID),
+		Number: sector,
+	}, task)
+}
+
 func (sm *StorageMinerAPI) MarketImportDealData(ctx context.Context, propCid cid.Cid, path string) error {
 	fi, err := os.Open(path)
 	if err != nil {
@@ -566,6 +812,73 @@ func (sm *StorageMinerAPI) MarketListDeals(ctx context.Context) ([]*api.MarketDe
 	return sm.listDeals(ctx)
 }
 
+func (sm *StorageMinerAPI) MarketSetLabels(ctx context.Context, target cid.Cid, l map[string]string) error {
+	if sm.Labels == nil {
+		return xerrors.Errorf("labels not available on this node")
+	}
+	return sm.Labels.Set(ctx, target, l)
+}
+
+func (sm *StorageMinerAPI) MarketGetLabels(ctx context.Context, target cid.Cid) (map[string]string, error) {
+	if sm.Labels == nil {
+		return nil, xerrors.Errorf("labels not available on this node")
+	}
+	return sm.Labels.Get(ctx, target)
+}
+
+func (sm *StorageMinerAPI) MarketListDealsFiltered(ctx context.Context, filter api.MarketDealFilter) ([]api.LabeledMarketDeal, error) {
+	if sm.Labels == nil {
+		return nil, xerrors.Errorf("labels not available on this node")
+	}
+
+	all, err := sm.Labels.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deals, err := sm.Full.StateMarketDeals(ctx, types.EmptyTSK)
+	if err != nil {
+		return nil, err
+	}
+
+	maddr := sm.Miner.Address()
+	var out []api.LabeledMarketDeal
+	for k, deal := range deals {
+		if deal.Proposal.Provider != maddr {
+			continue
+		}
+		if filter.PieceCID != nil && deal.Proposal.PieceCID != *filter.PieceCID {
+			continue
+		}
+
+		id, err := strconv.ParseUint(k, 10, 64)
+		if err != nil {
+			return nil, xerrors.Errorf("parsing deal ID %q: %w", k, err)
+		}
+		propCid, err := deal.Proposal.Cid()
+		if err != nil {
+			return nil, xerrors.Errorf("computing proposal CID of deal %d: %w", id, err)
+		}
+
+		dl := labels.Merge(all[deal.Proposal.PieceCID], all[propCid])
+		if !labels.Match(dl, filter.Labels) {
+			continue
+		}
+
+		out = append(out, api.LabeledMarketDeal{
+			DealID:      abi.DealID(id),
+			ProposalCID: propCid,
+			MarketDeal:  *deal,
+			Labels:      dl,
+		})
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].DealID < out[j].DealID
+	})
+	return out, nil
+}
+
 func (sm *StorageMinerAPI) MarketListRetrievalDeals(ctx context.Context) ([]struct{}, error) {
 	return []struct{}{}, nil
 }
@@ -591,6 +904,16 @@ func (sm *StorageMinerAPI) MarketListIncompleteDeals(ctx context.Context) ([]sto
 }
 
 func (sm *StorageMinerAPI) MarketSetAsk(ctx context.Context, price types.BigInt, verifiedPrice types.BigInt, duration abi.ChainEpoch, minPieceSize abi.PaddedPieceSize, maxPieceSize abi.PaddedPieceSize) error {
+	if sm.AskSchedule != nil {
+		return sm.AskSchedule.Set(ctx, api.StorageAskSpec{
+			Price:         price,
+			VerifiedPrice: verifiedPrice,
+			Duration:      duration,
+			MinPieceSize:  minPieceSize,
+			MaxPieceSize:  maxPieceSize,
+		})
+	}
+
 	options := []storagemarket.StorageAskOption{
 		storagemarket.MinPieceSize(minPieceSize),
 		storagemarket.MaxPieceSize(maxPieceSize),
@@ -603,6 +926,54 @@ func (sm *StorageMinerAPI) MarketGetAsk(ctx context.Context) (*storagemarket.Sig
 	return sm.StorageProvider.GetAsk(), nil
 }
 
+func (sm *StorageMinerAPI) MarketSetAskSpec(ctx context.Context, ask api.StorageAskSpec) error {
+	if sm.AskSchedule == nil {
+		return xerrors.Errorf("ask management not available on this node")
+	}
+
+	return sm.AskSchedule.Set(ctx, ask)
+}
+
+func (sm *StorageMinerAPI) MarketGetAskSpec(ctx context.Context) (*api.StorageAskSpec, error) {
+	if sm.AskSchedule == nil {
+		return nil, xerrors.Errorf("ask management not available on this node")
+	}
+
+	return sm.AskSchedule.Current(), nil
+}
+
+func (sm *StorageMinerAPI) MarketScheduleAsk(ctx context.Context, epoch abi.ChainEpoch, ask api.StorageAskSpec) error {
+	if sm.AskSchedule == nil {
+		return xerrors.Errorf("ask management not available on this node")
+	}
+
+	return sm.AskSchedule.Schedule(ctx, epoch, ask)
+}
+
+func (sm *StorageMinerAPI) MarketListScheduledAsks(ctx context.Context) ([]api.ScheduledStorageAsk, error) {
+	if sm.AskSchedule == nil {
+		return nil, xerrors.Errorf("ask management not available on this node")
+	}
+
+	return sm.AskSchedule.Scheduled(), nil
+}
+
+func (sm *StorageMinerAPI) MarketCancelScheduledAsk(ctx context.Context, epoch abi.ChainEpoch) error {
+	if sm.AskSchedule == nil {
+		return xerrors.Errorf("ask management not available on this node")
+	}
+
+	return sm.AskSchedule.Cancel(ctx, epoch)
+}
+
+func (sm *StorageMinerAPI) MarketAskHistory(ctx context.Context) ([]api.StorageAskChange, error) {
+	if sm.AskSchedule == nil {
+		return nil, xerrors.Errorf("ask management not available on this node")
+	}
+
+	return sm.AskSchedule.History(ctx)
+}
+
 func (sm *StorageMinerAPI) MarketSetRetrievalAsk(ctx context.Context, rask *retrievalmarket.Ask) error {
 	sm.RetrievalProvider.SetAsk(rask)
 	return nil
@@ -829,6 +1200,116 @@ func (sm *StorageMinerAPI) MarketRetryPublishDeal(ctx context.Context, propcid c
 	return sm.StorageProvider.RetryDealPublishing(propcid)
 }
 
+func (sm *StorageMinerAPI) MarketRetrievalStats(ctx context.Context, topN int) (*api.RetrievalStats, error) {
+	if sm.RetrievalStats == nil {
+		return nil, xerrors.Errorf("retrieval stats not available on this node")
+	}
+
+	return sm.RetrievalStats.Stats(topN), nil
+}
+
+func (sm *StorageMinerAPI) MarketRetrievalTimeline(ctx context.Context, receiver peer.ID, dealID retrievalmarket.DealID) (*api.RetrievalTimeline, error) {
+	if sm.RetrievalTimeline == nil {
+		return nil, xerrors.Errorf("retrieval timelines not available on this node")
+	}
+
+	return sm.RetrievalTimeline.Get(ctx, receiver, dealID)
+}
+
+func (sm *StorageMinerAPI) MarketListRetrievalTimelines(ctx context.Context, limit int) ([]api.RetrievalTimeline, error) {
+	if sm.RetrievalTimeline == nil {
+		return []api.RetrievalTimeline{}, nil
+	}
+
+	return sm.RetrievalTimeline.List(ctx, limit)
+}
+
+func (sm *StorageMinerAPI) MarketDealSLAReport(ctx context.Context) (*api.DealSLAReport, error) {
+	if sm.DealSLA == nil {
+		return nil, xerrors.Errorf("deal SLA tracking not enabled. Please check your configuration")
+	}
+
+	return sm.DealSLA.Report(), nil
+}
+
+func (sm *StorageMinerAPI) MarketRetrievabilitySamples(ctx context.Context, limit int) ([]api.RetrievabilitySample, error) {
+	if sm.Retrievability == nil {
+		return nil, xerrors.Errorf("retrievability sampling not enabled. Please check your configuration")
+	}
+	return sm.Retrievability.Samples(limit), nil
+}
+
+func (sm *StorageMinerAPI) MarketRetrievabilityCheck(ctx context.Context, pieces int) ([]api.RetrievabilitySample, error) {
+	if sm.Retrievability == nil {
+		return nil, xerrors.Errorf("retrievability sampling not enabled. Please check your configuration")
+	}
+	return sm.Retrievability.Check(ctx, pieces)
+}
+
+func (sm *StorageMinerAPI) MarketDataTransferRestarts(ctx context.Context) ([]api.DataTransferRestartHistory, error) {
+	if sm.TransferRestarts == nil {
+		return []api.DataTransferRestartHistory{}, nil
+	}
+
+	return sm.TransferRestarts.History(), nil
+}
+
+func (sm *StorageMinerAPI) MarketDealTransferStatus(ctx context.Context, propCid cid.Cid) (*api.DealTransferStatus, error) {
+	if sm.TransferProgress == nil {
+		return nil, xerrors.Errorf("deal transfer progress not available on this node")
+	}
+
+	if st, ok := sm.TransferProgress.Status(propCid); ok {
+		return &st, nil
+	}
+
+	// the transfer finished long ago, or before the node started
+	deal, err := sm.StorageProvider.GetLocalDeal(propCid)
+	if err != nil {
+		return nil, xerrors.Errorf("getting deal %s: %w", propCid, err)
+	}
+	if deal.TransferChannelId == nil {
+		return nil, xerrors.Errorf("no data transfer for deal %s", propCid)
+	}
+	state, err := sm.DataTransfer.ChannelState(ctx, *deal.TransferChannelId)
+	if err != nil {
+		return nil, xerrors.Errorf("getting data transfer state for deal %s: %w", propCid, err)
+	}
+
+	return &api.DealTransferStatus{
+		ProposalCid:    propCid,
+		ChannelID:      state.ChannelID(),
+		Status:         state.Status(),
+		BytesReceived:  state.Received(),
+		BlocksVerified: state.ReceivedCidsTotal(),
+		Finished:       state.Status().TransferComplete(),
+	}, nil
+}
+
+func (sm *StorageMinerAPI) MarketDealTransferUpdates(ctx context.Context) (<-chan api.DealTransferStatus, error) {
+	if sm.TransferProgress == nil {
+		return nil, xerrors.Errorf("deal transfer progress not available on this node")
+	}
+
+	return sm.TransferProgress.Subscribe(ctx), nil
+}
+
+func (sm *StorageMinerAPI) MarketCommPDiagnostics(ctx context.Context, propCid cid.Cid) (*api.CommPDiagnostics, error) {
+	if sm.CommPDiagnostics == nil {
+		return nil, xerrors.Errorf("commp diagnostics not available on this node")
+	}
+
+	return sm.CommPDiagnostics.Get(ctx, propCid)
+}
+
+func (sm *StorageMinerAPI) MarketListCommPDiagnostics(ctx context.Context) ([]api.CommPDiagnostics, error) {
+	if sm.CommPDiagnostics == nil {
+		return []api.CommPDiagnostics{}, nil
+	}
+
+	return sm.CommPDiagnostics.List(ctx)
+}
+
 func (sm *StorageMinerAPI) MarketPublishPendingDeals(ctx context.Context) error {
 	sm.DealPublisher.ForcePublishPendingDeals()
 	return nil
@@ -1175,6 +1656,14 @@ func (sm *StorageMinerAPI) DagstoreLookupPieces(ctx context.Context, cid cid.Cid
 	return ret, nil
 }
 
+func (sm *StorageMinerAPI) DagstoreShardDiagnostics(ctx context.Context, pieceCID cid.Cid) (*api.DagstoreShardDiagnostics, error) {
+	if sm.DAGStoreWrapper == nil {
+		return nil, fmt.Errorf("dagstore not available on this node")
+	}
+
+	return sm.DAGStoreWrapper.ShardDiagnostics(ctx, pieceCID)
+}
+
 func (sm *StorageMinerAPI) DealsList(ctx context.Context) ([]*api.MarketDeal, error) {
 	return sm.listDeals(ctx)
 }
@@ -1246,7 +1735,18 @@ func (sm *StorageMinerAPI) DealsImportData(ctx context.Context, deal cid.Cid, fn
 	}
 	defer fi.Close() //nolint:errcheck
 
-	return sm.StorageProvider.ImportDataForDeal(ctx, deal, fi)
+	err = sm.StorageProvider.ImportDataForDeal(ctx, deal, fi)
+	if commpdiag.IsMismatch(err) && sm.CommPDiagnostics != nil {
+		if md, derr := sm.StorageProvider.GetLocalDeal(deal); derr == nil {
+			d := commpdiag.Analyze(fname, true, md.Proposal.PieceCID, md.Proposal.PieceSize, md.Ref.Root)
+			d.ProposalCid = deal
+			if derr := sm.CommPDiagnostics.Put(ctx, d); derr != nil {
+				log.Errorw("saving commp diagnostics", "proposal", deal, "error", derr)
+			}
+			return xerrors.Errorf("%w; see 'lotus-miner storage-deals commp-diagnostics %s'", err, deal)
+		}
+	}
+	return err
 }
 
 func (sm *StorageMinerAPI) DealsPieceCidBlocklist(ctx context.Context) ([]cid.Cid, error) {
@@ -1281,6 +1781,10 @@ func (sm *StorageMinerAPI) StorageRedeclareLocal(ctx context.Context, id *storif
 	return sm.StorageMgr.RedeclareLocalStorage(ctx, id, dropMissing)
 }
 
+func (sm *StorageMinerAPI) StoragePlacementReport(ctx context.Context) (*storiface.PlacementReport, error) {
+	return paths.PlacementReport(ctx, sm.SectorIndex)
+}
+
 func (sm *StorageMinerAPI) PiecesListPieces(ctx context.Context) ([]cid.Cid, error) {
 	return sm.PieceStore.ListPieceInfoKeys()
 }
@@ -1306,6 +1810,61 @@ func (sm *StorageMinerAPI) PiecesGetCIDInfo(ctx context.Context, payloadCid cid.
 	return &ci, nil
 }
 
+func (sm *StorageMinerAPI) PiecesGetBatch(ctx context.Context, pieceCids []cid.Cid) ([]api.PieceInfoResult, error) {
+	out := make([]api.PieceInfoResult, len(pieceCids))
+	forEachParallel(ctx, len(pieceCids), func(i int, ctxErr error) {
+		out[i].PieceCID = pieceCids[i]
+		if ctxErr != nil {
+			out[i].Error = ctxErr.Error()
+			return
+		}
+
+		pi, err := sm.PieceStore.GetPieceInfo(pieceCids[i])
+		if err != nil {
+			out[i].Error = err.Error()
+			return
+		}
+		out[i].Info = &pi
+	})
+	return out, nil
+}
+
+func (sm *StorageMinerAPI) PiecesGetRootCids(ctx context.Context, pieceCid cid.Cid) ([]cid.Cid, error) {
+	if sm.DAGStoreWrapper == nil {
+		return nil, fmt.Errorf("dagstore not available on this node")
+	}
+
+	return sm.DAGStoreWrapper.PieceRootCids(ctx, pieceCid)
+}
+
+// batchParallelism is the number of items of the batch APIs looked up at a
+// time
+const batchParallelism = 16
+
+// forEachParallel calls cb for each index up to n, with up to
+// batchParallelism calls at a time. Once the context is canceled cb is called
+// with the context error for the remaining indexes.
+func forEachParallel(ctx context.Context, n int, cb func(i int, ctxErr error)) {
+	throttle := make(chan struct{}, batchParallelism)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		select {
+		case throttle <- struct{}{}:
+		case <-ctx.Done():
+			cb(i, ctx.Err())
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-throttle }()
+			cb(i, ctx.Err())
+		}(i)
+	}
+	wg.Wait()
+}
+
 func (sm *StorageMinerAPI) CreateBackup(ctx context.Context, fpath string) error {
 	return backup(ctx, sm.DS, fpath)
 }
@@ -1340,6 +1899,29 @@ func (sm *StorageMinerAPI) ActorAddressConfig(ctx context.Context) (api.AddressC
 	return sm.AddrSel.AddressConfig, nil
 }
 
+func (sm *StorageMinerAPI) ActorAddressRotationPlan(ctx context.Context, rot api.AddressRotation) (*api.AddressRotationPlan, error) {
+	maddr, err := sm.ActorAddress(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ctladdr.PlanRotation(ctx, sm.Full, maddr, rot)
+}
+
+func (sm *StorageMinerAPI) ActorAddressRotationStep(ctx context.Context, rot api.AddressRotation, proposer address.Address) (cid.Cid, error) {
+	maddr, err := sm.ActorAddress(ctx)
+	if err != nil {
+		return cid.Undef, err
+	}
+	return ctladdr.ExecuteRotationStep(ctx, sm.Full, maddr, rot, proposer)
+}
+
+func (sm *StorageMinerAPI) ActorAddressBalances(ctx context.Context) (api.AddressBalanceStatus, error) {
+	if sm.BalanceMonitor == nil {
+		return api.AddressBalanceStatus{}, xerrors.Errorf("balance monitor not available on this node")
+	}
+	return sm.BalanceMonitor.Status(), nil
+}
+
 func (sm *StorageMinerAPI) Discover(ctx context.Context) (apitypes.OpenRPCDocument, error) {
 	return build.OpenRPCDiscoverJSON_Miner(), nil
 }
@@ -1369,6 +1951,63 @@ func (sm *StorageMinerAPI) RecoverFault(ctx context.Context, sectors []abi.Secto
 	return sm.WdPoSt.ManualFaultRecovery(ctx, sm.Miner.Address(), sectors)
 }
 
+func (sm *StorageMinerAPI) ProvingDeadlineLoad(ctx context.Context) (*api.DeadlineLoadReport, error) {
+	loads, err := wdpost.LoadDeadlines(ctx, sm.Full, sm.Miner.Address())
+	if err != nil {
+		return nil, err
+	}
+	return loads.Report(), nil
+}
+
+func (sm *StorageMinerAPI) ProvingRebalancePlan(ctx context.Context, newSectors uint64) (*api.DeadlineRebalancePlan, error) {
+	loads, err := wdpost.LoadDeadlines(ctx, sm.Full, sm.Miner.Address())
+	if err != nil {
+		return nil, err
+	}
+	return loads.Plan(newSectors), nil
+}
+
+func (sm *StorageMinerAPI) ProvingRebalanceCompact(ctx context.Context) ([]cid.Cid, error) {
+	maddr := sm.Miner.Address()
+
+	loads, err := wdpost.LoadDeadlines(ctx, sm.Full, maddr)
+	if err != nil {
+		return nil, err
+	}
+
+	mi, err := sm.Full.StateMinerInfo(ctx, maddr, types.EmptyTSK)
+	if err != nil {
+		return nil, xerrors.Errorf("getting miner info: %w", err)
+	}
+
+	var out []cid.Cid
+	for _, c := range loads.Compactions() {
+		sp, aerr := actors.SerializeParams(&minertypes.CompactPartitionsParams{
+			Deadline:   c.Deadline,
+			Partitions: bitfield.NewFromSet(c.Partitions),
+		})
+		if aerr != nil {
+			return out, xerrors.Errorf("serializing params: %w", aerr)
+		}
+
+		smsg, err := sm.Full.MpoolPushMessage(ctx, &types.Message{
+			From:   mi.Worker,
+			To:     maddr,
+			Method: builtintypes.MethodsMiner.CompactPartitions,
+			Value:  big.Zero(),
+			Params: sp,
+		}, nil)
+		if err != nil {
+			return out, xerrors.Errorf("compacting partitions of deadline %d: %w", c.Deadline, err)
+		}
+
+		log.Infow("compacting partitions", "deadline", c.Deadline, "partitions", c.Partitions, "saved", c.SavedPartitions, "message", smsg.Cid())
+		out = append(out, smsg.Cid())
+	}
+
+	return out, nil
+}
+
 func (sm *StorageMinerAPI) RuntimeSubsystems(context.Context) (res api.MinerSubsystems, err error) {
 	return sm.EnabledSubsystems, nil
 }
@@ -1427,3 +2066,157 @@ func (sm *StorageMinerAPI) withdrawBalance(ctx context.Context, amount abi.Token
 
 	return smsg.Cid(), nil
 }
+
+func (sm *StorageMinerAPI) ParamsStatus(ctx context.Context) ([]api.ProofParamStatus, error) {
+	if sm.ProofParams == nil {
+		return nil, xerrors.Errorf("proof parameters are not managed by this node")
+	}
+
+	files, err := sm.ProofParams.Status(ctx, sm.ProofParams.StorageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]api.ProofParamStatus, len(files))
+	for i, f := range files {
+		out[i] = api.ProofParamStatus{
+			Name:       f.Name,
+			SectorSize: abi.SectorSize(f.SectorSize),
+			State:      api.ProofParamState(f.State),
+			Size:       f.Size,
+			Error:      f.Error,
+		}
+	}
+	return out, nil
+}
+
+func (sm *StorageMinerAPI) ParamsRepair(ctx context.Context) error {
+	if sm.ProofParams == nil {
+		return xerrors.Errorf("proof parameters are not managed by this node")
+	}
+
+	return sm.ProofParams.Fetch(ctx, sm.ProofParams.StorageSize)
+}
+
+// size of the files written to measure the disk throughput in self-tests, and
+// the write rates below which a warning is reported
+const (
+	selfTestDiskSize      = 256 << 20
+	selfTestRepoRate      = 50 << 20
+	selfTestSealingRate   = 200 << 20
+	selfTestStorageRate   = 50 << 20
+	selfTestMinPeers      = 4
+	selfTestMaxHeadEpochs = 5
+)
+
+func (sm *StorageMinerAPI) MinerSelfTest(ctx context.Context) (*api.SelfTestReport, error) {
+	checks := []selftest.Check{
+		selftest.ClockSkew(selftest.NTPServer(), time.Duration(build.AllowableClockDriftSecs)*time.Second),
+		selftest.DiskThroughput("repo", sm.Repo.Path(), selfTestDiskSize, selfTestRepoRate),
+	}
+
+	local, err := sm.LocalStore.Local(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("getting local storage paths: %w", err)
+	}
+	for _, p := range local {
+		if p.CanSeal {
+			checks = append(checks, selftest.DiskThroughput("sealing path "+string(p.ID), p.LocalPath, selfTestDiskSize, selfTestSealingRate))
+		} else if p.CanStore {
+			checks = append(checks, selftest.DiskThroughput("storage path "+string(p.ID), p.LocalPath, selfTestDiskSize, selfTestStorageRate))
+		}
+	}
+
+	if sm.ProofParams != nil {
+		checks = append(checks, selftest.Params(sm.ProofParams.Fetcher, sm.ProofParams.StorageSize))
+	}
+
+	checks = append(checks,
+		selftest.Check{Name: "reachability", Run: sm.selfTestReachability},
+		selftest.Check{Name: "peer connectivity", Run: sm.selfTestPeers},
+		selftest.Check{Name: "full node", Run: sm.selfTestFullNode},
+		modules.SelfTestAPIToken(sm.Repo),
+	)
+
+	r := selftest.Run(ctx, checks)
+	return &r, nil
+}
+
+func (sm *StorageMinerAPI) selfTestReachability(ctx context.Context) (api.SelfTestResult, string) {
+	addrs, err := sm.NetAddrsListen(ctx)
+	if err != nil {
+		return api.SelfTestFail, fmt.Sprintf("getting listen addresses: %s", err)
+	}
+	if len(addrs.Addrs) == 0 {
+		return api.SelfTestFail, "the node doesn't listen on any address"
+	}
+
+	nat, err := sm.NetAutoNatStatus(ctx)
+	if err != nil {
+		return api.SelfTestFail, fmt.Sprintf("getting autonat status: %s", err)
+	}
+	switch nat.Reachability {
+	case inet.ReachabilityPublic:
+		return api.SelfTestPass, fmt.Sprintf("publicly reachable at %s", nat.PublicAddr)
+	case inet.ReachabilityPrivate:
+		return api.SelfTestFail, fmt.Sprintf("peers can't dial the node on %v, check the port forwarding and Libp2p.AnnounceAddresses", addrs.Addrs)
+	default:
+		return api.SelfTestWarn, "reachability not determined yet"
+	}
+}
+
+func (sm *StorageMinerAPI) selfTestPeers(ctx context.Context) (api.SelfTestResult, string) {
+	peers, err := sm.NetPeers(ctx)
+	if err != nil {
+		return api.SelfTestFail, fmt.Sprintf("listing peers: %s", err)
+	}
+
+	msg := fmt.Sprintf("connected to %d peers", len(peers))
+	switch {
+	case len(peers) == 0:
+		return api.SelfTestFail, msg
+	case len(peers) < selfTestMinPeers:
+		return api.SelfTestWarn, msg
+	}
+	return api.SelfTestPass, msg
+}
+
+// selfTestFullNode checks that the full node is synced, and that the miner
+// token lets it use the worker key.
+func (sm *StorageMinerAPI) selfTestFullNode(ctx context.Context) (api.SelfTestResult, string) {
+	v, err := sm.Full.Version(ctx)
+	if err != nil {
+		return api.SelfTestFail, fmt.Sprintf("calling the full node: %s", err)
+	}
+
+	head, err := sm.Full.ChainHead(ctx)
+	if err != nil {
+		return api.SelfTestFail, fmt.Sprintf("getting chain head: %s", err)
+	}
+	behind := time.Since(time.Unix(int64(head.MinTimestamp()), 0))
+	if behind > selfTestMaxHeadEpochs*time.Duration(build.BlockDelaySecs)*time.Second {
+		return api.SelfTestFail, fmt.Sprintf("full node %s is %s behind, at epoch %d", v.Version, behind.Round(time.Second), head.Height())
+	}
+
+	if sm.Miner == nil {
+		return api.SelfTestPass, fmt.Sprintf("full node %s synced at epoch %d", v.Version, head.Height())
+	}
+
+	mi, err := sm.Full.StateMinerInfo(ctx, sm.Miner.Address(), types.EmptyTSK)
+	if err != nil {
+		return api.SelfTestFail, fmt.Sprintf("getting miner info: %s", err)
+	}
+	worker, err := sm.Full.StateAccountKey(ctx, mi.Worker, types.EmptyTSK)
+	if err != nil {
+		return api.SelfTestFail, fmt.Sprintf("resolving worker address: %s", err)
+	}
+	has, err := sm.Full.WalletHas(ctx, worker)
+	if err != nil {
+		return api.SelfTestFail, fmt.Sprintf("checking the worker key, the full node api token may lack write permissions: %s", err)
+	}
+	if !has {
+		return api.SelfTestFail, fmt.Sprintf("the full node wallet doesn't have the worker key %s", worker)
+	}
+
+	return api.SelfTestPass, fmt.Sprintf("full node %s synced at epoch %d, holds the worker key", v.Version, head.Height())
+}
@@ -3,10 +3,12 @@ package modules
 import (
 	"context"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/multiformats/go-varint"
 	"go.uber.org/fx"
+	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/abi"
@@ -18,6 +20,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/stmgr"
 	"github.com/filecoin-project/lotus/chain/store"
 	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/ethtypes"
 	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/impl/full"
 	"github.com/filecoin-project/lotus/node/modules/helpers"
@@ -89,6 +92,27 @@ func EthEventAPI(cfg config.FevmConfig) func(helpers.MetricsCtx, repo.LockedRepo
 					return eventIndex.Close()
 				},
 			})
+
+			addrs, topics, err := parseWatchedEvents(cfg.Events)
+			if err != nil {
+				return nil, err
+			}
+			// entries removed from the config stop being watched, unless
+			// they were added with the API
+			if err := eventIndex.WatchConfigured(ctx, addrs, topics); err != nil {
+				return nil, xerrors.Errorf("updating configured event watch-list: %w", err)
+			}
+
+			if cfg.Events.RetentionEpochs > 0 {
+				ee.EventRetention = abi.ChainEpoch(cfg.Events.RetentionEpochs)
+
+				lc.Append(fx.Hook{
+					OnStart: func(context.Context) error {
+						go ee.PruneEvents(ctx)
+						return nil
+					},
+				})
+			}
 		}
 
 		ee.EventFilterManager = &filter.EventFilterManager{
@@ -151,3 +175,39 @@ func EthEventAPI(cfg config.FevmConfig) func(helpers.MetricsCtx, repo.LockedRepo
 		return ee, nil
 	}
 }
+
+func parseWatchedEvents(cfg config.Events) ([]address.Address, [][]byte, error) {
+	var addrs []address.Address
+	for _, s := range cfg.WatchedAddresses {
+		var addr address.Address
+		if strings.HasPrefix(s, "0x") {
+			ethAddr, err := ethtypes.ParseEthAddress(s)
+			if err != nil {
+				return nil, nil, xerrors.Errorf("parsing watched address %q: %w", s, err)
+			}
+			if addr, err = ethAddr.ToFilecoinAddress(); err != nil {
+				return nil, nil, xerrors.Errorf("converting watched address %q: %w", s, err)
+			}
+		} else {
+			var err error
+			if addr, err = address.NewFromString(s); err != nil {
+				return nil, nil, xerrors.Errorf("parsing watched address %q: %w", s, err)
+			}
+		}
+		if addr.Protocol() != address.Delegated {
+			return nil, nil, xerrors.Errorf("watched address %q is not an f410 address", s)
+		}
+		addrs = append(addrs, addr)
+	}
+
+	var topics [][]byte
+	for _, s := range cfg.WatchedTopics {
+		topic, err := ethtypes.ParseEthHash(s)
+		if err != nil {
+			return nil, nil, xerrors.Errorf("parsing watched topic %q: %w", s, err)
+		}
+		topics = append(topics, topic[:])
+	}
+
+	return addrs, topics, nil
+}
@@ -0,0 +1,53 @@
+package modules
+
+import (
+	"context"
+	"path/filepath"
+
+	"go.uber.org/fx"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/addrindex"
+	"github.com/filecoin-project/lotus/chain/events"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/modules/helpers"
+	"github.com/filecoin-project/lotus/node/repo"
+)
+
+func AddressIndex(cfg config.IndexConfig) func(helpers.MetricsCtx, fx.Lifecycle, repo.LockedRepo, EventAPI) (*addrindex.Index, error) {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, r repo.LockedRepo, evapi EventAPI) (*addrindex.Index, error) {
+		sqlitePath, err := r.SqlitePath()
+		if err != nil {
+			return nil, err
+		}
+
+		x, err := addrindex.NewIndex(filepath.Join(sqlitePath, addrindex.DBName), &evapi)
+		if err != nil {
+			return nil, err
+		}
+
+		ctx := helpers.LifecycleCtx(mctx, lc)
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				ev, err := events.NewEvents(ctx, &evapi)
+				if err != nil {
+					return err
+				}
+				head := ev.Observe(x)
+
+				go func() {
+					if err := x.Backfill(ctx, head, head.Height()-abi.ChainEpoch(cfg.AddressIndexBackfill)); err != nil {
+						log.Errorf("backfilling address index: %s", err)
+					}
+				}()
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				return x.Close()
+			},
+		})
+
+		return x, nil
+	}
+}
@@ -0,0 +1,36 @@
+package modules
+
+import (
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/attestation"
+	"github.com/filecoin-project/lotus/chain/stmgr"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+	"github.com/filecoin-project/lotus/node/modules/helpers"
+)
+
+func Attestor(cfg config.AttestationConfig) func(mctx helpers.MetricsCtx, lc fx.Lifecycle, sm *stmgr.StateManager, cs *store.ChainStore, w api.Wallet, nn dtypes.NetworkName) (*attestation.Attestor, error) {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, sm *stmgr.StateManager, cs *store.ChainStore, w api.Wallet, nn dtypes.NetworkName) (*attestation.Attestor, error) {
+		signer, err := address.NewFromString(cfg.SigningAddress)
+		if err != nil {
+			return nil, xerrors.Errorf("parsing attestation signing address: %w", err)
+		}
+
+		has, err := w.WalletHas(helpers.LifecycleCtx(mctx, lc), signer)
+		if err != nil {
+			return nil, xerrors.Errorf("checking attestation signing address: %w", err)
+		}
+		if !has {
+			return nil, xerrors.Errorf("attestation signing address %s not found in wallet", signer)
+		}
+
+		return attestation.NewAttestor(sm, cs, w, signer, string(nn), abi.ChainEpoch(cfg.FinalityEpochs)), nil
+	}
+}
@@ -5,13 +5,20 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"time"
 
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
 	bstore "github.com/ipfs/go-ipfs-blockstore"
+	carv2 "github.com/ipld/go-car/v2"
+	carbs "github.com/ipld/go-car/v2/blockstore"
 	"go.uber.org/fx"
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/lotus/blockstore"
 	badgerbs "github.com/filecoin-project/lotus/blockstore/badger"
+	"github.com/filecoin-project/lotus/blockstore/maintenance"
+	"github.com/filecoin-project/lotus/blockstore/scrub"
 	"github.com/filecoin-project/lotus/blockstore/splitstore"
 	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
@@ -133,12 +140,103 @@ func ChainSplitBlockstore(_ fx.Lifecycle, _ helpers.MetricsCtx, bs dtypes.SplitB
 	return bs, nil
 }
 
-func FallbackChainBlockstore(cbs dtypes.BasicChainBlockstore) dtypes.ChainBlockstore {
-	return &blockstore.FallbackStore{Blockstore: cbs}
+// CarOverlayBlockstore opens the CAR files mounted as read-only overlays of
+// the chain and state blockstores.
+func CarOverlayBlockstore(paths []string) func(lc fx.Lifecycle) (dtypes.CarOverlayBlockstore, error) {
+	return func(lc fx.Lifecycle) (dtypes.CarOverlayBlockstore, error) {
+		if len(paths) == 0 {
+			return nil, nil
+		}
+
+		var overlays []blockstore.Blockstore
+		for _, path := range paths {
+			ro, err := carbs.OpenReadOnly(path, carv2.ZeroLengthSectionAsEOF(true))
+			if err != nil {
+				for _, o := range overlays {
+					_ = o.(io.Closer).Close()
+				}
+				return nil, xerrors.Errorf("opening CAR overlay %s: %w", path, err)
+			}
+			log.Infow("mounted CAR overlay blockstore", "path", path)
+			overlays = append(overlays, blockstore.Adapt(ro))
+
+			lc.Append(fx.Hook{
+				OnStop: func(context.Context) error {
+					return ro.Close()
+				},
+			})
+		}
+
+		return blockstore.Union(overlays...), nil
+	}
+}
+
+func withOverlay(bs blockstore.Blockstore, ov dtypes.CarOverlayBlockstore) blockstore.Blockstore {
+	if ov == nil {
+		return bs
+	}
+	return blockstore.NewOverlay(bs, ov)
+}
+
+func OverlayChainBlockstore(cbs dtypes.BasicChainBlockstore, ov dtypes.CarOverlayBlockstore) dtypes.ChainBlockstore {
+	return withOverlay(cbs, ov)
+}
+
+// StateBlockCacheName is the name of the cache of the state blockstore, see
+// blockstore.CacheTuner.
+const StateBlockCacheName = "state-blocks"
+
+// withStateCache caches the blocks read from the state blockstore, when
+// configured. The cache is purged when the splitstore starts a compaction or a
+// prune, so that the blocks read from then on go through its transactional
+// protection.
+func withStateCache(lc fx.Lifecycle, t *blockstore.CacheTuner, protector dtypes.GCReferenceProtector, bs blockstore.Blockstore, cfg config.ChainCachesConfig) (blockstore.Blockstore, error) {
+	if cfg.StateBlockCacheSize <= 0 {
+		return bs, nil
+	}
+
+	cache, err := blockstore.NewCache[cid.Cid, blocks.Block](StateBlockCacheName, cfg.StateBlockCacheSize)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.Register(cache); err != nil {
+		return nil, err
+	}
+	lc.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			t.Unregister(StateBlockCacheName)
+			return nil
+		},
+	})
+
+	cbs := blockstore.NewCachedBlockstore(bs, cache)
+	protector.AddProtector(func(func(cid.Cid) error) error {
+		cbs.Purge()
+		return nil
+	})
+	return cbs, nil
+}
+
+func OverlayStateBlockstore(cfg config.ChainCachesConfig) func(fx.Lifecycle, *blockstore.CacheTuner, dtypes.GCReferenceProtector, dtypes.BasicStateBlockstore, dtypes.CarOverlayBlockstore) (dtypes.StateBlockstore, error) {
+	return func(lc fx.Lifecycle, t *blockstore.CacheTuner, protector dtypes.GCReferenceProtector, sbs dtypes.BasicStateBlockstore, ov dtypes.CarOverlayBlockstore) (dtypes.StateBlockstore, error) {
+		return withStateCache(lc, t, protector, withOverlay(sbs, ov), cfg)
+	}
 }
 
-func FallbackStateBlockstore(sbs dtypes.BasicStateBlockstore) dtypes.StateBlockstore {
-	return &blockstore.FallbackStore{Blockstore: sbs}
+// FallbackChainBlockstore falls back to the network for the blocks missing
+// from the blockstore and its CAR overlays.
+func FallbackChainBlockstore(cbs dtypes.BasicChainBlockstore, ov dtypes.CarOverlayBlockstore) dtypes.ChainBlockstore {
+	return &blockstore.FallbackStore{Blockstore: withOverlay(cbs, ov)}
+}
+
+func FallbackStateBlockstore(cfg config.ChainCachesConfig) func(fx.Lifecycle, *blockstore.CacheTuner, dtypes.GCReferenceProtector, dtypes.BasicStateBlockstore, dtypes.CarOverlayBlockstore) (dtypes.StateBlockstore, error) {
+	return func(lc fx.Lifecycle, t *blockstore.CacheTuner, protector dtypes.GCReferenceProtector, sbs dtypes.BasicStateBlockstore, ov dtypes.CarOverlayBlockstore) (dtypes.StateBlockstore, error) {
+		bs, err := withStateCache(lc, t, protector, withOverlay(sbs, ov), cfg)
+		if err != nil {
+			return nil, err
+		}
+		return &blockstore.FallbackStore{Blockstore: bs}, nil
+	}
 }
 
 func InitFallbackBlockstores(cbs dtypes.ChainBlockstore, sbs dtypes.StateBlockstore, rem dtypes.ChainBitswap) error {
@@ -151,3 +249,145 @@ func InitFallbackBlockstores(cbs dtypes.ChainBlockstore, sbs dtypes.StateBlockst
 	}
 	return nil
 }
+
+type ChainBlockstoreParams struct {
+	fx.In
+
+	Universal dtypes.UniversalBlockstore
+	Hot       dtypes.HotBlockstore `optional:"true"`
+}
+
+// blockstore returns the blockstore holding the chain, which is the hotstore
+// when using the splitstore.
+func (p *ChainBlockstoreParams) blockstore(cfg *config.Chainstore) blockstore.Blockstore {
+	if cfg.EnableSplitstore {
+		return p.Hot
+	}
+	return p.Universal
+}
+
+// BlockstoreMaintenance returns the scheduler of the maintenance of the
+// hotstore when using the splitstore, and of the universal blockstore
+// otherwise. It returns nil when the blockstore doesn't support maintenance.
+func BlockstoreMaintenance(cfg *config.Chainstore) func(helpers.MetricsCtx, fx.Lifecycle, ChainBlockstoreParams) (*maintenance.Scheduler, error) {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, p ChainBlockstoreParams) (*maintenance.Scheduler, error) {
+		bs := p.blockstore(cfg)
+
+		mbs, ok := bs.(blockstore.BlockstoreMaintain)
+		if !ok {
+			if len(cfg.Maintenance.MaintenanceWindows) > 0 {
+				return nil, xerrors.Errorf("maintenance windows are configured, but the blockstore (%T) doesn't support maintenance", bs)
+			}
+			return nil, nil
+		}
+
+		mcfg := maintenance.Config{
+			Mode:      blockstore.MaintenanceMode(cfg.Maintenance.Mode),
+			Threshold: cfg.Maintenance.GCThreshold,
+			Interval:  time.Duration(cfg.Maintenance.MinInterval),
+		}
+		if mcfg.Mode != blockstore.MaintainValueLogGC && mcfg.Mode != blockstore.MaintainCompaction {
+			return nil, xerrors.Errorf("invalid blockstore maintenance mode %q", cfg.Maintenance.Mode)
+		}
+		for _, w := range cfg.Maintenance.MaintenanceWindows {
+			win, err := maintenance.ParseWindow(w)
+			if err != nil {
+				return nil, err
+			}
+			mcfg.Windows = append(mcfg.Windows, win)
+		}
+
+		ctx, cancel := context.WithCancel(helpers.LifecycleCtx(mctx, lc))
+		s := maintenance.NewScheduler(ctx, mbs, mcfg)
+
+		done := make(chan struct{})
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				go func() {
+					defer close(done)
+					s.Run()
+				}()
+				return nil
+			},
+			OnStop: func(stopCtx context.Context) error {
+				cancel()
+				select {
+				case <-done:
+					return nil
+				case <-stopCtx.Done():
+					return stopCtx.Err()
+				}
+			},
+		})
+
+		return s, nil
+	}
+}
+
+// BlockstoreScrubber returns the scrubber of the hotstore when using the
+// splitstore, and of the universal blockstore otherwise.
+func BlockstoreScrubber(cfg *config.Chainstore) func(helpers.MetricsCtx, fx.Lifecycle, ChainBlockstoreParams, dtypes.MetadataDS, dtypes.ChainBitswap) (*scrub.Scrubber, error) {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, p ChainBlockstoreParams, ds dtypes.MetadataDS, bitswap dtypes.ChainBitswap) (*scrub.Scrubber, error) {
+		bs := p.blockstore(cfg)
+		sbs, ok := bs.(scrub.Blockstore)
+		if !ok {
+			return nil, xerrors.Errorf("the blockstore (%T) doesn't support scrubbing", bs)
+		}
+
+		var fetcher scrub.Fetcher
+		if !cfg.Scrubber.DisableRefetch {
+			fetcher = bitswap
+		}
+
+		s := scrub.NewScrubber(sbs, fetcher, ds, scrub.Config{
+			Interval: time.Duration(cfg.Scrubber.Interval),
+			Rate:     cfg.Scrubber.MaxBlocksPerSecond,
+		})
+
+		ctx, cancel := context.WithCancel(helpers.LifecycleCtx(mctx, lc))
+		done := make(chan struct{})
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				go func() {
+					defer close(done)
+					s.Run(ctx)
+				}()
+				return nil
+			},
+			OnStop: func(stopCtx context.Context) error {
+				cancel()
+				select {
+				case <-done:
+					return nil
+				case <-stopCtx.Done():
+					return stopCtx.Err()
+				}
+			},
+		})
+
+		return s, nil
+	}
+}
+
+// ChainCaches reports the metrics of the chain and state caches, and grows them
+// under load in adaptive mode.
+func ChainCaches(cfg config.ChainCachesConfig) func(fx.Lifecycle) (*blockstore.CacheTuner, error) {
+	return func(lc fx.Lifecycle) (*blockstore.CacheTuner, error) {
+		tcfg := blockstore.CacheTunerConfig{
+			Adaptive:       cfg.EnableAdaptive,
+			MaxFactor:      cfg.AdaptiveMaxFactor,
+			MinLookupRate:  cfg.AdaptiveMinLookupRate,
+			TargetHitRatio: cfg.AdaptiveTargetHitRatio,
+		}
+		if tcfg.Adaptive && tcfg.MaxFactor < 1 {
+			return nil, xerrors.Errorf("adaptive caches max factor must be at least 1, got %d", tcfg.MaxFactor)
+		}
+
+		t := blockstore.NewCacheTuner(tcfg)
+		lc.Append(fx.Hook{
+			OnStart: t.Start,
+			OnStop:  t.Stop,
+		})
+		return t, nil
+	}
+}
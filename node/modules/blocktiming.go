@@ -0,0 +1,21 @@
+package modules
+
+import (
+	"time"
+
+	"github.com/filecoin-project/lotus/chain/sub/blocktiming"
+	"github.com/filecoin-project/lotus/node/config"
+)
+
+// BlockTiming records the arrival times of the blocks relayed by each pubsub
+// peer, and applies the block relay policy when enabled.
+func BlockTiming(cfg config.BlockRelayConfig) func() *blocktiming.Tracker {
+	return func() *blocktiming.Tracker {
+		return blocktiming.New(blocktiming.Policy{
+			Enable:        cfg.EnableRelayPolicy,
+			LateThreshold: time.Duration(cfg.LateThreshold),
+			LateRatio:     cfg.LateRatio,
+			MinBlocks:     cfg.MinBlocks,
+		})
+	}
+}
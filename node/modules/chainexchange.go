@@ -0,0 +1,25 @@
+package modules
+
+import (
+	"github.com/filecoin-project/lotus/chain/exchange"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/node/config"
+)
+
+// ChainExchangeServer serves the chain exchange protocol, rate limiting the
+// requests of each peer when configured.
+func ChainExchangeServer(cfg config.ChainExchangeConfig) func(cs *store.ChainStore) exchange.Server {
+	return func(cs *store.ChainStore) exchange.Server {
+		if cfg.RequestsPerSecond <= 0 && cfg.TipsetsPerSecond <= 0 && cfg.MaxRequestLength == 0 {
+			return exchange.NewServer(cs)
+		}
+
+		return exchange.NewLimitedServer(cs, exchange.ServerLimits{
+			RequestsPerSecond: cfg.RequestsPerSecond,
+			RequestBurst:      cfg.RequestBurst,
+			TipsetsPerSecond:  cfg.TipsetsPerSecond,
+			TipsetBurst:       cfg.TipsetBurst,
+			MaxRequestLength:  cfg.MaxRequestLength,
+		})
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"go.uber.org/fx"
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-data-transfer/v2/channelmonitor"
 	dtimpl "github.com/filecoin-project/go-data-transfer/v2/impl"
 	dtnet "github.com/filecoin-project/go-data-transfer/v2/network"
@@ -28,10 +29,14 @@ import (
 	"github.com/filecoin-project/go-state-types/abi"
 
 	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/market"
 	"github.com/filecoin-project/lotus/journal"
+	"github.com/filecoin-project/lotus/journal/alerting"
 	"github.com/filecoin-project/lotus/markets"
+	"github.com/filecoin-project/lotus/markets/allocations"
 	marketevents "github.com/filecoin-project/lotus/markets/loggers"
+	"github.com/filecoin-project/lotus/markets/reputation"
 	"github.com/filecoin-project/lotus/markets/retrievaladapter"
 	"github.com/filecoin-project/lotus/markets/storageadapter"
 	"github.com/filecoin-project/lotus/node/config"
@@ -146,6 +151,109 @@ func NewClientDatastore(ds dtypes.MetadataDS) dtypes.ClientDatastore {
 	return namespace.Wrap(ds, datastore.NewKey("/deals/client"))
 }
 
+// NewClientReplicationDatastore creates a datastore for the client to store
+// the deals made by ClientReplicate
+func NewClientReplicationDatastore(ds dtypes.MetadataDS) dtypes.ClientReplicationDatastore {
+	return namespace.Wrap(ds, datastore.NewKey("/deals/client-replications"))
+}
+
+// NewClientDealTemplatesDatastore creates a datastore for the client to store
+// its deal templates
+func NewClientDealTemplatesDatastore(ds dtypes.MetadataDS) dtypes.ClientDealTemplatesDatastore {
+	return namespace.Wrap(ds, datastore.NewKey("/deals/client-templates"))
+}
+
+// NewClientDataPrepDatastore creates a datastore for the client to store the
+// manifests of the data prepared by ClientPrepareData
+func NewClientDataPrepDatastore(ds dtypes.MetadataDS) dtypes.ClientDataPrepDatastore {
+	return namespace.Wrap(ds, datastore.NewKey("/deals/client-data-prep"))
+}
+
+// NewClientRetrievalCheckpointDatastore creates a datastore for the client to
+// store the checkpoints of its retrievals, used to resume failed retrievals
+func NewClientRetrievalCheckpointDatastore(ds dtypes.MetadataDS) dtypes.ClientRetrievalCheckpointDatastore {
+	return namespace.Wrap(ds, datastore.NewKey("/retrievals/client-checkpoints"))
+}
+
+// ClientReputationStore creates the local store of storage provider records,
+// recording the outcome of the deals and retrievals made by the clients
+func ClientReputationStore(lc fx.Lifecycle, ds dtypes.MetadataDS, sc storagemarket.StorageClient, rc retrievalmarket.RetrievalClient) *reputation.Store {
+	s := reputation.New(namespace.Wrap(ds, datastore.NewKey("/client/reputation")))
+
+	var unsubDeals, unsubRetrievals func()
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			unsubDeals = sc.SubscribeToEvents(s.OnStorageClientEvent)
+			unsubRetrievals = rc.SubscribeToEvents(s.OnRetrievalClientEvent)
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			unsubDeals()
+			unsubRetrievals()
+			return nil
+		},
+	})
+	return s
+}
+
+type ClientAllocationsAPI struct {
+	fx.In
+
+	full.ChainAPI
+	full.StateAPI
+	full.MpoolAPI
+}
+
+// ClientAllocations creates the datacap allocations manager, which checks the
+// allocations of the monitored clients when the monitor is enabled.
+func ClientAllocations(cfg config.ClientAllocationsConfig) func(lc fx.Lifecycle, ds dtypes.MetadataDS, al *alerting.Alerting, a ClientAllocationsAPI) (*allocations.Manager, error) {
+	return func(lc fx.Lifecycle, ds dtypes.MetadataDS, al *alerting.Alerting, a ClientAllocationsAPI) (*allocations.Manager, error) {
+		epochs := func(d config.Duration) abi.ChainEpoch {
+			return abi.ChainEpoch(time.Duration(d) / (time.Duration(build.BlockDelaySecs) * time.Second))
+		}
+
+		mcfg := allocations.Config{
+			CheckInterval: time.Duration(cfg.CheckInterval),
+			ExpiryWarning: epochs(cfg.ExpiryWarning),
+			ExtendBefore:  epochs(cfg.ExtendClaimsBefore),
+			TermMax:       epochs(cfg.ClaimTermMax),
+		}
+		for _, c := range cfg.Clients {
+			addr, err := address.NewFromString(c)
+			if err != nil {
+				return nil, xerrors.Errorf("parsing allocations client %q: %w", c, err)
+			}
+			mcfg.Clients = append(mcfg.Clients, addr)
+		}
+		if cfg.EnableMonitor && mcfg.CheckInterval <= 0 {
+			return nil, xerrors.Errorf("allocations check interval must be positive")
+		}
+
+		m := allocations.NewManager(&a, namespace.Wrap(ds, datastore.NewKey("/client/allocations")), al, mcfg)
+		if cfg.EnableMonitor {
+			lc.Append(fx.Hook{
+				OnStart: func(context.Context) error {
+					m.Start()
+					return nil
+				},
+				OnStop: m.Stop,
+			})
+		}
+		return m, nil
+	}
+}
+
+// ClientFundManagerConfig returns the configuration of the automatic
+// management of the market escrow of the clients.
+func ClientFundManagerConfig(cfg config.ClientFundsConfig) market.FundManagerConfig {
+	return market.FundManagerConfig{
+		TopUpBuffer:      abi.TokenAmount(cfg.TopUpBuffer),
+		WithdrawInterval: time.Duration(cfg.WithdrawInterval),
+		WithdrawKeep:     abi.TokenAmount(cfg.WithdrawKeep),
+		WithdrawMin:      abi.TokenAmount(cfg.WithdrawMin),
+	}
+}
+
 // StorageBlockstoreAccessor returns the default storage blockstore accessor
 // from the import manager.
 func StorageBlockstoreAccessor(importmgr dtypes.ClientImportMgr) storagemarket.BlockstoreAccessor {
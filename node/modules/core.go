@@ -25,6 +25,7 @@ import (
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/lib/addrutil"
+	"github.com/filecoin-project/lotus/lib/selftest"
 	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 	"github.com/filecoin-project/lotus/node/repo"
@@ -180,6 +181,30 @@ func APISecret(keystore types.KeyStore, lr repo.LockedRepo) (*dtypes.APIAlg, err
 	return (*dtypes.APIAlg)(jwt.NewHS256(key.PrivateKey)), nil
 }
 
+// SelfTestAPIToken checks the API token of the repo against its API secret.
+// There is no token to check before the node first starts.
+func SelfTestAPIToken(lr repo.LockedRepo) selftest.Check {
+	token, err := os.ReadFile(filepath.Join(lr.Path(), "token"))
+	if err != nil && !os.IsNotExist(err) {
+		return selftest.Failed("api token", xerrors.Errorf("reading api token: %w", err))
+	}
+
+	ks, err := lr.KeyStore()
+	if err != nil {
+		return selftest.Failed("api token", xerrors.Errorf("opening keystore: %w", err))
+	}
+	key, err := ks.Get(JWTSecretName)
+	if errors.Is(err, types.ErrKeyInfoNotFound) {
+		if len(token) > 0 {
+			return selftest.Failed("api token", xerrors.Errorf("the repo has an api token but no api secret"))
+		}
+	} else if err != nil {
+		return selftest.Failed("api token", xerrors.Errorf("getting api secret: %w", err))
+	}
+
+	return selftest.APIToken(token, key.PrivateKey)
+}
+
 func ConfigBootstrap(peers []string) func() (dtypes.BootstrapPeers, error) {
 	return func() (dtypes.BootstrapPeers, error) {
 		return addrutil.ParseAddresses(context.TODO(), peers)
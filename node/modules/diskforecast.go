@@ -0,0 +1,76 @@
+package modules
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"go.uber.org/fx"
+
+	"github.com/filecoin-project/lotus/lib/diskforecast"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/modules/helpers"
+	"github.com/filecoin-project/lotus/node/repo"
+	"github.com/filecoin-project/lotus/storage/paths"
+)
+
+func startDiskForecast(mctx helpers.MetricsCtx, lc fx.Lifecycle, cfg config.DiskForecast, targets diskforecast.TargetsFunc) *diskforecast.Tracker {
+	t := diskforecast.New(time.Duration(cfg.SampleInterval), time.Duration(cfg.Window), time.Duration(cfg.AdmissionHorizon), targets)
+
+	ctx := helpers.LifecycleCtx(mctx, lc)
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go t.Run(ctx)
+			return nil
+		},
+	})
+
+	return t
+}
+
+// FullNodeDiskForecast tracks the chain store, and the hot store of the
+// splitstore when enabled.
+func FullNodeDiskForecast(cfg config.DiskForecast) func(mctx helpers.MetricsCtx, lc fx.Lifecycle, lr repo.LockedRepo) *diskforecast.Tracker {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, lr repo.LockedRepo) *diskforecast.Tracker {
+		return startDiskForecast(mctx, lc, cfg, func(ctx context.Context) ([]diskforecast.Target, error) {
+			return []diskforecast.Target{
+				{Name: "chain store", Path: filepath.Join(lr.Path(), "datastore", "chain")},
+				{Name: "splitstore", Path: filepath.Join(lr.Path(), "datastore", "splitstore")},
+			}, nil
+		})
+	}
+}
+
+// MinerDiskForecast tracks the deal staging area, the DAGStore transients and
+// the local sealing paths.
+func MinerDiskForecast(cfg config.DiskForecast, dcfg config.DAGStoreConfig) func(mctx helpers.MetricsCtx, lc fx.Lifecycle, lr repo.LockedRepo, ls *paths.Local) *diskforecast.Tracker {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, lr repo.LockedRepo, ls *paths.Local) *diskforecast.Tracker {
+		dagstoreDir := dcfg.RootDir
+		if dagstoreDir == "" {
+			dagstoreDir = filepath.Join(lr.Path(), DefaultDAGStoreDir)
+		}
+
+		return startDiskForecast(mctx, lc, cfg, func(ctx context.Context) ([]diskforecast.Target, error) {
+			targets := []diskforecast.Target{
+				{Name: "deal staging", Path: filepath.Join(lr.Path(), StagingAreaDirName)},
+				{Name: "dagstore transients", Path: filepath.Join(dagstoreDir, "transients")},
+			}
+
+			local, err := ls.Local(ctx)
+			if err != nil {
+				return nil, err
+			}
+			sort.Slice(local, func(i, j int) bool {
+				return local[i].ID < local[j].ID
+			})
+			for _, p := range local {
+				if p.CanSeal {
+					targets = append(targets, diskforecast.Target{Name: "sealing scratch " + string(p.ID), Path: p.LocalPath})
+				}
+			}
+
+			return targets, nil
+		})
+	}
+}
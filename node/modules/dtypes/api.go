@@ -11,4 +11,11 @@ type APIAlg jwt.HMACSHA
 
 type APIEndpoint multiaddr.Multiaddr
 
+// ReadOnlyAPI is set when the node serves its API in read-only mode.
+type ReadOnlyAPI bool
+
 type NodeStartTime time.Time
+
+// DisabledEthNamespaces are the Ethereum JSON-RPC namespaces whose methods
+// aren't served under their Ethereum names.
+type DisabledEthNamespaces []string
@@ -20,6 +20,11 @@ import (
 // main repo datastore.
 type MetadataDS datastore.Batching
 
+// MarketsDS stores the state of the provider storage deals, retrieval deals
+// and data transfers. It's the MetadataDS unless Dealmaking.Datastore
+// configures an SQL backend.
+type MarketsDS datastore.Batching
+
 type (
 	// UniversalBlockstore is the universal blockstore backend.
 	UniversalBlockstore blockstore.Blockstore
@@ -36,6 +41,10 @@ type (
 	// BaseBlockstore is something, coz DI
 	BaseBlockstore blockstore.Blockstore
 
+	// CarOverlayBlockstore is the read-only union of the CAR files configured
+	// as overlays of the chain and state blockstores; nil when none is.
+	CarOverlayBlockstore blockstore.Blockstore
+
 	// BasicChainBlockstore is like ChainBlockstore, but without the optional
 	// network fallback support
 	BasicChainBlockstore blockstore.Blockstore
@@ -73,6 +82,10 @@ type ClientBlockstore blockstore.BasicBlockstore
 type ClientDealStore *statestore.StateStore
 type ClientRequestValidator *requestvalidation.UnifiedRequestValidator
 type ClientDatastore datastore.Batching
+type ClientReplicationDatastore datastore.Batching
+type ClientRetrievalCheckpointDatastore datastore.Batching
+type ClientDealTemplatesDatastore datastore.Batching
+type ClientDataPrepDatastore datastore.Batching
 
 type Graphsync graphsync.GraphExchange
 
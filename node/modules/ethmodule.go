@@ -6,7 +6,10 @@ import (
 	"path/filepath"
 
 	"go.uber.org/fx"
+	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain"
 	"github.com/filecoin-project/lotus/chain/ethhashlookup"
 	"github.com/filecoin-project/lotus/chain/events"
 	"github.com/filecoin-project/lotus/chain/messagepool"
@@ -14,12 +17,20 @@ import (
 	"github.com/filecoin-project/lotus/chain/store"
 	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/impl/full"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
 	"github.com/filecoin-project/lotus/node/modules/helpers"
 	"github.com/filecoin-project/lotus/node/repo"
 )
 
-func EthModuleAPI(cfg config.FevmConfig) func(helpers.MetricsCtx, repo.LockedRepo, fx.Lifecycle, *store.ChainStore, *stmgr.StateManager, EventAPI, *messagepool.MessagePool, full.StateAPI, full.ChainAPI, full.MpoolAPI) (*full.EthModule, error) {
-	return func(mctx helpers.MetricsCtx, r repo.LockedRepo, lc fx.Lifecycle, cs *store.ChainStore, sm *stmgr.StateManager, evapi EventAPI, mp *messagepool.MessagePool, stateapi full.StateAPI, chainapi full.ChainAPI, mpoolapi full.MpoolAPI) (*full.EthModule, error) {
+func EthModuleAPI(cfg config.FevmConfig) func(helpers.MetricsCtx, repo.LockedRepo, fx.Lifecycle, *store.ChainStore, *stmgr.StateManager, *chain.Syncer, EventAPI, *messagepool.MessagePool, full.StateAPI, full.ChainAPI, full.MpoolAPI) (*full.EthModule, error) {
+	return func(mctx helpers.MetricsCtx, r repo.LockedRepo, lc fx.Lifecycle, cs *store.ChainStore, sm *stmgr.StateManager, syncer *chain.Syncer, evapi EventAPI, mp *messagepool.MessagePool, stateapi full.StateAPI, chainapi full.ChainAPI, mpoolapi full.MpoolAPI) (*full.EthModule, error) {
+		profile := full.EthCompatProfile(cfg.CompatibilityProfile)
+		switch profile {
+		case full.EthCompatGeth, full.EthCompatErigon:
+		default:
+			return nil, xerrors.Errorf("unknown Fevm.CompatibilityProfile %q, expected \"geth\" or \"erigon\"", cfg.CompatibilityProfile)
+		}
+
 		sqlitePath, err := r.SqlitePath()
 		if err != nil {
 			return nil, err
@@ -80,12 +91,31 @@ func EthModuleAPI(cfg config.FevmConfig) func(helpers.MetricsCtx, repo.LockedRep
 			Chain:        cs,
 			Mpool:        mp,
 			StateManager: sm,
+			Syncer:       syncer,
 
 			ChainAPI: chainapi,
 			MpoolAPI: mpoolapi,
 			StateAPI: stateapi,
 
 			EthTxHashManager: &ethTxHashManager,
+			CompatProfile:    profile,
 		}, nil
 	}
 }
+
+// DisabledEthNamespaces checks the Ethereum JSON-RPC namespaces disabled by the
+// user exist.
+func DisabledEthNamespaces(cfg config.FevmConfig) func() (dtypes.DisabledEthNamespaces, error) {
+	return func() (dtypes.DisabledEthNamespaces, error) {
+		known := map[string]struct{}{}
+		for _, ns := range api.EthNamespaces() {
+			known[ns] = struct{}{}
+		}
+		for _, ns := range cfg.DisabledNamespaces {
+			if _, ok := known[ns]; !ok {
+				return nil, xerrors.Errorf("unknown Ethereum JSON-RPC namespace %q in Fevm.DisabledNamespaces, expected one of %v", ns, api.EthNamespaces())
+			}
+		}
+		return cfg.DisabledNamespaces, nil
+	}
+}
@@ -0,0 +1,42 @@
+package modules
+
+import (
+	"context"
+	"path/filepath"
+
+	"go.uber.org/fx"
+
+	"github.com/filecoin-project/lotus/chain/eventjournal"
+	"github.com/filecoin-project/lotus/chain/events"
+	"github.com/filecoin-project/lotus/node/modules/helpers"
+	"github.com/filecoin-project/lotus/node/repo"
+)
+
+func ChainJournal(mctx helpers.MetricsCtx, lc fx.Lifecycle, r repo.LockedRepo, evapi EventAPI) (*eventjournal.Journal, error) {
+	sqlitePath, err := r.SqlitePath()
+	if err != nil {
+		return nil, err
+	}
+
+	j, err := eventjournal.NewJournal(filepath.Join(sqlitePath, eventjournal.DBName), &evapi)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := helpers.LifecycleCtx(mctx, lc)
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			ev, err := events.NewEvents(ctx, &evapi)
+			if err != nil {
+				return err
+			}
+			_ = ev.Observe(j)
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			return j.Close()
+		},
+	})
+
+	return j, nil
+}
@@ -0,0 +1,54 @@
+package modules
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/ipfs/go-cid"
+	"go.uber.org/fx"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/consensus"
+	"github.com/filecoin-project/lotus/chain/events"
+	"github.com/filecoin-project/lotus/chain/gasstats"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/modules/helpers"
+	"github.com/filecoin-project/lotus/node/repo"
+)
+
+func GasStats(cfg config.IndexConfig) func(helpers.MetricsCtx, fx.Lifecycle, repo.LockedRepo, EventAPI) (*gasstats.Stats, error) {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, r repo.LockedRepo, evapi EventAPI) (*gasstats.Stats, error) {
+		sqlitePath, err := r.SqlitePath()
+		if err != nil {
+			return nil, err
+		}
+
+		registry := consensus.NewActorRegistry()
+		names := func(code cid.Cid, method abi.MethodNum) string {
+			return registry.Methods[code][method].Name
+		}
+
+		s, err := gasstats.NewStats(filepath.Join(sqlitePath, gasstats.DBName), &evapi, abi.ChainEpoch(cfg.GasStatsRetention), names)
+		if err != nil {
+			return nil, err
+		}
+
+		ctx := helpers.LifecycleCtx(mctx, lc)
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				ev, err := events.NewEvents(ctx, &evapi)
+				if err != nil {
+					return err
+				}
+				_ = ev.Observe(s)
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				return s.Close()
+			},
+		})
+
+		return s, nil
+	}
+}
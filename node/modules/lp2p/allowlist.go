@@ -0,0 +1,32 @@
+package lp2p
+
+import (
+	"context"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/p2p/net/conngater"
+	"go.uber.org/fx"
+
+	"github.com/filecoin-project/lotus/lib/peerlist"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+	"github.com/filecoin-project/lotus/node/modules/helpers"
+)
+
+func AllowList(mctx helpers.MetricsCtx, lc fx.Lifecycle, h host.Host, ds dtypes.MetadataDS, cg *conngater.BasicConnectionGater) (*peerlist.AllowList, error) {
+	al, err := peerlist.NewAllowList(helpers.LifecycleCtx(mctx, lc), h, ds, cg)
+	if err != nil {
+		return nil, err
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			al.Start()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			return al.Close()
+		},
+	})
+
+	return al, nil
+}
@@ -28,6 +28,8 @@ type P2PHostIn struct {
 	Peerstore peerstore.Peerstore
 
 	Opts [][]libp2p.Option `group:"libp2p"`
+
+	NATTracker *NATTracker `optional:"true"`
 }
 
 // ////////////////////////
@@ -66,6 +68,12 @@ func Host(mctx helpers.MetricsCtx, lc fx.Lifecycle, params P2PHostIn) (RawHost,
 		},
 	})
 
+	if params.NATTracker != nil {
+		if err := params.NATTracker.watch(lc, h); err != nil {
+			return nil, err
+		}
+	}
+
 	return h, nil
 }
 
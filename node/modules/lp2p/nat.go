@@ -2,6 +2,8 @@ package lp2p
 
 import (
 	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/p2p/protocol/holepunch"
+	"golang.org/x/xerrors"
 )
 
 /*import (
@@ -36,3 +38,32 @@ func AutoNATService(quic bool) func(repo repo.Repo, mctx helpers.MetricsCtx, lc
 var AutoNATService = simpleOpt(libp2p.EnableNATService())
 
 var NatPortMap = simpleOpt(libp2p.NATPortMap())
+
+// ForceReachability makes AutoNAT assume the given reachability instead of
+// probing it through other peers.
+func ForceReachability(reachability string) func() (opts Libp2pOpts, err error) {
+	return func() (opts Libp2pOpts, err error) {
+		switch reachability {
+		case "public":
+			opts.Opts = append(opts.Opts, libp2p.ForceReachabilityPublic())
+		case "private":
+			opts.Opts = append(opts.Opts, libp2p.ForceReachabilityPrivate())
+		default:
+			return opts, xerrors.Errorf("invalid ForceReachability %q, expected \"public\" or \"private\"", reachability)
+		}
+		return
+	}
+}
+
+func HolePunching(relayClient bool) func(tr *NATTracker) (opts Libp2pOpts, err error) {
+	return func(tr *NATTracker) (opts Libp2pOpts, err error) {
+		// hole punches are coordinated over relayed connections
+		if !relayClient {
+			return opts, xerrors.Errorf("hole punching requires the relay client, set EnableRelayClient")
+		}
+
+		tr.enableHolePunching()
+		opts.Opts = append(opts.Opts, libp2p.EnableHolePunching(holepunch.WithTracer(tr)))
+		return
+	}
+}
@@ -0,0 +1,146 @@
+package lp2p
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/event"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	circuitproto "github.com/libp2p/go-libp2p/p2p/protocol/circuitv2/proto"
+	"github.com/libp2p/go-libp2p/p2p/protocol/holepunch"
+	"go.uber.org/fx"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+const maxReachabilityHistory = 32
+
+// NATTracker keeps track of the reachability changes reported by AutoNAT and
+// of the outcome of hole punches, for NetAutoNatStatus. It also serves as the
+// relay candidate source when no static relays are configured.
+type NATTracker struct {
+	lk        sync.Mutex
+	host      host.Host
+	history   []api.ReachabilityChange
+	holePunch api.HolePunchStats
+}
+
+var _ holepunch.EventTracer = (*NATTracker)(nil)
+
+func NewNATTracker() *NATTracker {
+	return &NATTracker{}
+}
+
+// watch subscribes to the reachability changes of the host. It's called once
+// the host is constructed, as the tracker is needed to build its options.
+func (t *NATTracker) watch(lc fx.Lifecycle, h host.Host) error {
+	sub, err := h.EventBus().Subscribe(new(event.EvtLocalReachabilityChanged))
+	if err != nil {
+		return err
+	}
+
+	t.lk.Lock()
+	t.host = h
+	t.lk.Unlock()
+
+	go func() {
+		for evt := range sub.Out() {
+			t.recordReachability(evt.(event.EvtLocalReachabilityChanged).Reachability, time.Now())
+		}
+	}()
+
+	lc.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			return sub.Close()
+		},
+	})
+	return nil
+}
+
+func (t *NATTracker) recordReachability(r network.Reachability, at time.Time) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	t.history = append(t.history, api.ReachabilityChange{
+		Reachability: r,
+		Time:         at,
+	})
+	if len(t.history) > maxReachabilityHistory {
+		t.history = t.history[len(t.history)-maxReachabilityHistory:]
+	}
+}
+
+func (t *NATTracker) enableHolePunching() {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	t.holePunch.Enabled = true
+}
+
+func (t *NATTracker) Trace(evt *holepunch.Event) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	switch e := evt.Evt.(type) {
+	case *holepunch.StartHolePunchEvt:
+		t.holePunch.Attempts++
+	case *holepunch.EndHolePunchEvt:
+		if e.Success {
+			t.holePunch.Successes++
+			return
+		}
+		t.holePunch.Failures++
+		t.holePunch.LastError = e.Error
+	}
+}
+
+// ReachabilityHistory returns the latest reachability changes, oldest first.
+func (t *NATTracker) ReachabilityHistory() []api.ReachabilityChange {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	return append([]api.ReachabilityChange(nil), t.history...)
+}
+
+func (t *NATTracker) HolePunchStats() api.HolePunchStats {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	return t.holePunch
+}
+
+// relayCandidates is an autorelay peer source returning the connected peers
+// which offer the circuit relay v2 service.
+func (t *NATTracker) relayCandidates(ctx context.Context, num int) <-chan peer.AddrInfo {
+	t.lk.Lock()
+	h := t.host
+	t.lk.Unlock()
+
+	if num < 0 {
+		num = 0
+	}
+	out := make(chan peer.AddrInfo, num)
+	defer close(out)
+
+	if h == nil {
+		return out
+	}
+
+	for _, p := range h.Network().Peers() {
+		if len(out) == num {
+			break
+		}
+
+		protos, err := h.Peerstore().SupportsProtocols(p, circuitproto.ProtoIDv2Hop)
+		if err != nil || len(protos) == 0 {
+			continue
+		}
+
+		out <- peer.AddrInfo{ID: p, Addrs: h.Peerstore().Addrs(p)}
+	}
+
+	return out
+}
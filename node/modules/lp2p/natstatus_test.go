@@ -0,0 +1,54 @@
+// stm: #unit
+package lp2p
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/p2p/protocol/holepunch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNATTracker(t *testing.T) {
+	tr := NewNATTracker()
+
+	start := time.Unix(1000, 0)
+	for i := 0; i < maxReachabilityHistory+5; i++ {
+		r := network.ReachabilityPrivate
+		if i%2 == 0 {
+			r = network.ReachabilityPublic
+		}
+		tr.recordReachability(r, start.Add(time.Duration(i)*time.Minute))
+	}
+
+	history := tr.ReachabilityHistory()
+	require.Len(t, history, maxReachabilityHistory)
+	require.Equal(t, start.Add(5*time.Minute), history[0].Time)
+	require.Equal(t, network.ReachabilityPrivate, history[0].Reachability)
+	require.Equal(t, start.Add(time.Duration(maxReachabilityHistory+4)*time.Minute), history[len(history)-1].Time)
+
+	require.False(t, tr.HolePunchStats().Enabled)
+	tr.enableHolePunching()
+
+	tr.Trace(&holepunch.Event{Type: holepunch.StartHolePunchEvtT, Evt: &holepunch.StartHolePunchEvt{}})
+	tr.Trace(&holepunch.Event{Type: holepunch.EndHolePunchEvtT, Evt: &holepunch.EndHolePunchEvt{Success: true}})
+	tr.Trace(&holepunch.Event{Type: holepunch.StartHolePunchEvtT, Evt: &holepunch.StartHolePunchEvt{}})
+	tr.Trace(&holepunch.Event{Type: holepunch.EndHolePunchEvtT, Evt: &holepunch.EndHolePunchEvt{Error: "all retries failed"}})
+	tr.Trace(&holepunch.Event{Type: holepunch.DirectDialEvtT, Evt: &holepunch.DirectDialEvt{Success: true}})
+
+	stats := tr.HolePunchStats()
+	require.True(t, stats.Enabled)
+	require.EqualValues(t, 2, stats.Attempts)
+	require.EqualValues(t, 1, stats.Successes)
+	require.EqualValues(t, 1, stats.Failures)
+	require.Equal(t, "all retries failed", stats.LastError)
+
+	// no host yet, no relay candidates
+	var candidates int
+	for range tr.relayCandidates(context.Background(), 4) {
+		candidates++
+	}
+	require.Zero(t, candidates)
+}
@@ -13,10 +13,12 @@ import (
 	"github.com/minio/blake2b-simd"
 	ma "github.com/multiformats/go-multiaddr"
 	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
 	"go.uber.org/fx"
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/sub/blocktiming"
 	"github.com/filecoin-project/lotus/metrics"
 	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
@@ -84,7 +86,9 @@ type GossipIn struct {
 	Db   dtypes.DrandBootstrap
 	Cfg  *config.Pubsub
 	Sk   *dtypes.ScoreKeeper
+	Rt   *RejectTracker
 	Dr   dtypes.DrandSchedule
+	Bt   *blocktiming.Tracker `optional:"true"`
 }
 
 func getDrandTopic(chainInfoJSON string) (string, error) {
@@ -283,8 +287,10 @@ func GossipSub(in GossipIn) (service *pubsub.PubSub, err error) {
 						return 1500
 					}
 
-					// TODO: we want to  plug the application specific score to the node itself in order
-					//       to provide feedback to the pubsub system based on observed behaviour
+					// deprioritize the peers which relay blocks late or relay equivocating blocks
+					if in.Bt != nil {
+						return in.Bt.Score(p)
+					}
 					return 0
 				},
 				AppSpecificWeight: 1,
@@ -437,6 +443,9 @@ func GossipSub(in GossipIn) (service *pubsub.PubSub, err error) {
 		options = append(options, pubsub.WithPeerScoreInspect(pst.UpdatePeerScore, 10*time.Second))
 	}
 
+	// keep track of rejected messages for the NetPubsubRejections API
+	options = append(options, pubsub.WithRawTracer(in.Rt))
+
 	return pubsub.NewGossipSub(helpers.LifecycleCtx(in.Mctx, in.Lc), in.Host, options...)
 }
 
@@ -503,7 +512,12 @@ func (trw *tracerWrapper) Trace(evt *pubsub_pb.TraceEvent) {
 			}
 		}
 	case pubsub_pb.TraceEvent_REJECT_MESSAGE:
-		stats.Record(context.TODO(), metrics.PubsubRejectMessage.M(1))
+		_ = stats.RecordWithTags(context.TODO(),
+			[]tag.Mutator{
+				tag.Upsert(metrics.PubsubTopic, evt.GetRejectMessage().GetTopic()),
+				tag.Upsert(metrics.FailureType, evt.GetRejectMessage().GetReason()),
+			},
+			metrics.PubsubRejectMessage.M(1))
 		if trw.traceMessage(evt.GetRejectMessage().GetTopic()) {
 			if trw.lp2pTracer != nil {
 				trw.lp2pTracer.Trace(evt)
@@ -0,0 +1,165 @@
+package lp2p
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+const (
+	// maximum number of peers whose rejections are counted per topic, the
+	// peers with the fewest rejections are forgotten first
+	maxRejectPeers = 1000
+	// number of recent rejected messages kept per topic
+	maxRejectSamples = 64
+	// number of peers returned per topic
+	topRejectPeers = 10
+)
+
+type topicRejects struct {
+	reasons map[string]int64
+	peers   map[peer.ID]int64
+
+	// ring buffer of the most recent rejected messages
+	samples []api.PubsubRejectedMessage
+	next    int
+}
+
+// RejectTracker is a pubsub raw tracer keeping track of rejected messages,
+// per topic.
+type RejectTracker struct {
+	lk     sync.Mutex
+	topics map[string]*topicRejects
+}
+
+var _ pubsub.RawTracer = (*RejectTracker)(nil)
+
+func NewRejectTracker() *RejectTracker {
+	return &RejectTracker{
+		topics: map[string]*topicRejects{},
+	}
+}
+
+func (rt *RejectTracker) RejectMessage(msg *pubsub.Message, reason string) {
+	topic := msg.GetTopic()
+	from := msg.ReceivedFrom
+
+	rt.lk.Lock()
+	defer rt.lk.Unlock()
+
+	tr, ok := rt.topics[topic]
+	if !ok {
+		tr = &topicRejects{
+			reasons: map[string]int64{},
+			peers:   map[peer.ID]int64{},
+		}
+		rt.topics[topic] = tr
+	}
+
+	tr.reasons[reason]++
+
+	if _, ok := tr.peers[from]; !ok && len(tr.peers) >= maxRejectPeers {
+		var least peer.ID
+		for p, n := range tr.peers {
+			if least == "" || n < tr.peers[least] {
+				least = p
+			}
+		}
+		delete(tr.peers, least)
+	}
+	tr.peers[from]++
+
+	sample := api.PubsubRejectedMessage{
+		Topic:        topic,
+		ReceivedFrom: from,
+		Reason:       reason,
+		Time:         time.Now(),
+		Data:         msg.GetData(),
+	}
+	if len(tr.samples) < maxRejectSamples {
+		tr.samples = append(tr.samples, sample)
+	} else {
+		tr.samples[tr.next] = sample
+	}
+	tr.next = (tr.next + 1) % maxRejectSamples
+}
+
+// Rejections returns the rejection counts by reason of each topic, and the
+// peers whose messages were rejected the most.
+func (rt *RejectTracker) Rejections() []api.PubsubTopicRejections {
+	rt.lk.Lock()
+	defer rt.lk.Unlock()
+
+	out := make([]api.PubsubTopicRejections, 0, len(rt.topics))
+	for topic, tr := range rt.topics {
+		r := api.PubsubTopicRejections{
+			Topic:   topic,
+			Reasons: make(map[string]int64, len(tr.reasons)),
+		}
+		for reason, n := range tr.reasons {
+			r.Reasons[reason] = n
+			r.Total += n
+		}
+
+		for p, n := range tr.peers {
+			r.TopPeers = append(r.TopPeers, api.PubsubPeerRejections{ID: p, Rejected: n})
+		}
+		sort.Slice(r.TopPeers, func(i, j int) bool {
+			return r.TopPeers[i].Rejected > r.TopPeers[j].Rejected
+		})
+		if len(r.TopPeers) > topRejectPeers {
+			r.TopPeers = r.TopPeers[:topRejectPeers]
+		}
+
+		out = append(out, r)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Topic < out[j].Topic
+	})
+	return out
+}
+
+// RejectedMessages returns the most recent rejected messages of a topic, or
+// of all topics if topic is empty, from the newest to the oldest.
+func (rt *RejectTracker) RejectedMessages(topic string) []api.PubsubRejectedMessage {
+	rt.lk.Lock()
+	defer rt.lk.Unlock()
+
+	var out []api.PubsubRejectedMessage
+	for t, tr := range rt.topics {
+		if topic != "" && t != topic {
+			continue
+		}
+		// walk the ring buffer back from the newest sample
+		for i := 1; i <= len(tr.samples); i++ {
+			out = append(out, tr.samples[(tr.next-i+len(tr.samples))%len(tr.samples)])
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Time.After(out[j].Time)
+	})
+	return out
+}
+
+func (rt *RejectTracker) AddPeer(peer.ID, protocol.ID)         {}
+func (rt *RejectTracker) RemovePeer(peer.ID)                   {}
+func (rt *RejectTracker) Join(string)                          {}
+func (rt *RejectTracker) Leave(string)                         {}
+func (rt *RejectTracker) Graft(peer.ID, string)                {}
+func (rt *RejectTracker) Prune(peer.ID, string)                {}
+func (rt *RejectTracker) ValidateMessage(*pubsub.Message)      {}
+func (rt *RejectTracker) DeliverMessage(*pubsub.Message)       {}
+func (rt *RejectTracker) DuplicateMessage(*pubsub.Message)     {}
+func (rt *RejectTracker) ThrottlePeer(peer.ID)                 {}
+func (rt *RejectTracker) RecvRPC(*pubsub.RPC)                  {}
+func (rt *RejectTracker) SendRPC(*pubsub.RPC, peer.ID)         {}
+func (rt *RejectTracker) DropRPC(*pubsub.RPC, peer.ID)         {}
+func (rt *RejectTracker) UndeliverableMessage(*pubsub.Message) {}
@@ -0,0 +1,52 @@
+// stm: #unit
+package lp2p
+
+import (
+	"testing"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	pb "github.com/libp2p/go-libp2p-pubsub/pb"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRejectTracker(t *testing.T) {
+	rt := NewRejectTracker()
+
+	reject := func(topic string, from peer.ID, data byte, reason string) {
+		rt.RejectMessage(&pubsub.Message{
+			Message:      &pb.Message{Topic: &topic, Data: []byte{data}},
+			ReceivedFrom: from,
+		}, reason)
+	}
+
+	for i := 0; i < maxRejectSamples+10; i++ {
+		reject("msgs", "spammer", byte(i), pubsub.RejectValidationFailed)
+	}
+	reject("msgs", "peer", 0, pubsub.RejectValidationIgnored)
+	reject("blocks", "peer", 1, pubsub.RejectValidationFailed)
+
+	rejections := rt.Rejections()
+	require.Len(t, rejections, 2)
+	require.Equal(t, "blocks", rejections[0].Topic)
+	require.EqualValues(t, 1, rejections[0].Total)
+
+	msgs := rejections[1]
+	require.EqualValues(t, maxRejectSamples+11, msgs.Total)
+	require.EqualValues(t, maxRejectSamples+10, msgs.Reasons[pubsub.RejectValidationFailed])
+	require.EqualValues(t, 1, msgs.Reasons[pubsub.RejectValidationIgnored])
+	require.Len(t, msgs.TopPeers, 2)
+	require.Equal(t, peer.ID("spammer"), msgs.TopPeers[0].ID)
+	require.EqualValues(t, maxRejectSamples+10, msgs.TopPeers[0].Rejected)
+
+	// only the most recent samples are kept
+	samples := rt.RejectedMessages("msgs")
+	require.Len(t, samples, maxRejectSamples)
+	require.Equal(t, peer.ID("peer"), samples[0].ReceivedFrom)
+	for _, s := range samples[1:] {
+		require.GreaterOrEqual(t, s.Data[0], byte(11))
+	}
+
+	require.Len(t, rt.RejectedMessages(""), maxRejectSamples+1)
+	require.Empty(t, rt.RejectedMessages("drand"))
+}
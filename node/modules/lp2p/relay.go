@@ -1,12 +1,16 @@
 package lp2p
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/libp2p/go-libp2p"
 	coredisc "github.com/libp2p/go-libp2p/core/discovery"
 	"github.com/libp2p/go-libp2p/core/routing"
 	routingdisc "github.com/libp2p/go-libp2p/p2p/discovery/routing"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/lib/addrutil"
 )
 
 func NoRelay() func() (opts Libp2pOpts, err error) {
@@ -17,6 +21,36 @@ func NoRelay() func() (opts Libp2pOpts, err error) {
 	}
 }
 
+// Relay configures the circuit relay client and service. Both are disabled
+// unless explicitly enabled, as relays can be used to eclipse a node.
+func Relay(client bool, staticRelays []string, service bool) func(tr *NATTracker) (opts Libp2pOpts, err error) {
+	return func(tr *NATTracker) (opts Libp2pOpts, err error) {
+		if !client && !service {
+			opts.Opts = append(opts.Opts, libp2p.DisableRelay())
+			return
+		}
+
+		opts.Opts = append(opts.Opts, libp2p.EnableRelay())
+
+		if client {
+			if len(staticRelays) > 0 {
+				relays, err := addrutil.ParseAddresses(context.TODO(), staticRelays)
+				if err != nil {
+					return opts, xerrors.Errorf("parsing static relays: %w", err)
+				}
+				opts.Opts = append(opts.Opts, libp2p.EnableAutoRelayWithStaticRelays(relays))
+			} else {
+				opts.Opts = append(opts.Opts, libp2p.EnableAutoRelayWithPeerSource(tr.relayCandidates))
+			}
+		}
+
+		if service {
+			opts.Opts = append(opts.Opts, libp2p.EnableRelayService())
+		}
+		return
+	}
+}
+
 // TODO: should be use baseRouting or can we use higher level router here?
 func Discovery(router BaseIpfsRouting) (coredisc.Discovery, error) {
 	crouter, ok := router.(routing.ContentRouting)
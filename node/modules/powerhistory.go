@@ -0,0 +1,65 @@
+package modules
+
+import (
+	"context"
+	"path/filepath"
+
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/events"
+	"github.com/filecoin-project/lotus/chain/powerhistory"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/modules/helpers"
+	"github.com/filecoin-project/lotus/node/repo"
+)
+
+func PowerHistory(cfg config.IndexConfig) func(helpers.MetricsCtx, fx.Lifecycle, repo.LockedRepo, EventAPI) (*powerhistory.History, error) {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, r repo.LockedRepo, evapi EventAPI) (*powerhistory.History, error) {
+		miners := make([]address.Address, 0, len(cfg.PowerHistoryMiners))
+		for _, s := range cfg.PowerHistoryMiners {
+			a, err := address.NewFromString(s)
+			if err != nil {
+				return nil, xerrors.Errorf("parsing power history miner %q: %w", s, err)
+			}
+			miners = append(miners, a)
+		}
+
+		sqlitePath, err := r.SqlitePath()
+		if err != nil {
+			return nil, err
+		}
+
+		ctx := helpers.LifecycleCtx(mctx, lc)
+		h, err := powerhistory.NewHistory(filepath.Join(sqlitePath, powerhistory.DBName), powerhistory.NewChain(ctx, &evapi),
+			miners, abi.ChainEpoch(cfg.PowerHistoryInterval))
+		if err != nil {
+			return nil, err
+		}
+
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				ev, err := events.NewEvents(ctx, &evapi)
+				if err != nil {
+					return err
+				}
+				head := ev.Observe(h)
+
+				go func() {
+					if err := h.Backfill(ctx, head, head.Height()-abi.ChainEpoch(cfg.PowerHistoryBackfill)); err != nil {
+						log.Errorf("backfilling power history: %s", err)
+					}
+				}()
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				return h.Close()
+			},
+		})
+
+		return h, nil
+	}
+}
@@ -0,0 +1,112 @@
+package modules
+
+import (
+	"context"
+	"sync"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/chain"
+	"github.com/filecoin-project/lotus/chain/consensus"
+	"github.com/filecoin-project/lotus/chain/messagepool"
+	"github.com/filecoin-project/lotus/chain/replica"
+	"github.com/filecoin-project/lotus/chain/stmgr"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/sub/blocktiming"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/hello"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+	"github.com/filecoin-project/lotus/node/modules/helpers"
+)
+
+func ReplicaServer(cfg config.ReplicationConfig) func(fx.Lifecycle, host.Host, *store.ChainStore, *messagepool.MessagePool) (*replica.Server, error) {
+	return func(lc fx.Lifecycle, h host.Host, cs *store.ChainStore, mp *messagepool.MessagePool) (*replica.Server, error) {
+		allowed := make([]peer.ID, 0, len(cfg.AllowedPeers))
+		for _, s := range cfg.AllowedPeers {
+			p, err := peer.Decode(s)
+			if err != nil {
+				return nil, xerrors.Errorf("parsing allowed standby peer %q: %w", s, err)
+			}
+			allowed = append(allowed, p)
+		}
+
+		srv, err := replica.NewServer(h, cs, mp, allowed, cfg.MaxFollowsPerHour)
+		if err != nil {
+			return nil, xerrors.Errorf("creating replication server, set Replication.AllowedPeers: %w", err)
+		}
+		lc.Append(fx.Hook{
+			OnStart: srv.Start,
+			OnStop:  srv.Stop,
+		})
+		return srv, nil
+	}
+}
+
+// ReplicaFollower makes the node a hot standby of the configured primary. The
+// syncer is started when the standby is promoted, see StandbySyncer.
+func ReplicaFollower(cfg config.ReplicationConfig) func(fx.Lifecycle, host.Host, *store.ChainStore, *stmgr.StateManager, *chain.Syncer, *messagepool.MessagePool) (*replica.Follower, error) {
+	return func(lc fx.Lifecycle, h host.Host, cs *store.ChainStore, sm *stmgr.StateManager, syncer *chain.Syncer, mp *messagepool.MessagePool) (*replica.Follower, error) {
+		f, err := replica.NewFollower(cfg.Primary, h, cs, sm, syncer, mp)
+		if err != nil {
+			return nil, err
+		}
+
+		var lk sync.Mutex
+		var syncing bool
+		f.OnPromote(func() {
+			lk.Lock()
+			defer lk.Unlock()
+			syncer.Start()
+			syncing = true
+		})
+
+		lc.Append(fx.Hook{
+			OnStart: f.Start,
+			OnStop: func(ctx context.Context) error {
+				lk.Lock()
+				if syncing {
+					syncer.Stop()
+				}
+				lk.Unlock()
+				return f.Stop(ctx)
+			},
+		})
+		return f, nil
+	}
+}
+
+// StandbySyncer creates a syncer which isn't started with the node, the
+// replica follower starts it when the standby is promoted.
+func StandbySyncer(params SyncerParams) (*chain.Syncer, error) {
+	h := params.Host
+	return chain.NewSyncer(params.MetadataDS, params.StateManager, params.ChainXchg, params.SyncMgrCtor, h.ConnManager(), h.ID(), params.Beacon, params.Gent, params.Consensus)
+}
+
+// RunStandbyHello says hello to the peers once the standby is promoted, hello
+// messages from peers feed the syncer.
+func RunStandbyHello(mctx helpers.MetricsCtx, lc fx.Lifecycle, h host.Host, svc *hello.Service, f *replica.Follower) {
+	ctx := helpers.LifecycleCtx(mctx, lc)
+	f.OnPromote(func() {
+		if err := runHello(ctx, h, svc); err != nil {
+			log.Errorw("starting hello service", "error", err)
+		}
+	})
+}
+
+func HandleStandbyIncomingBlocks(mctx helpers.MetricsCtx, lc fx.Lifecycle, ps *pubsub.PubSub, s *chain.Syncer, bserv dtypes.ChainBlockService, cs *store.ChainStore, cns consensus.Consensus, h host.Host, nn dtypes.NetworkName, bt *blocktiming.Tracker, f *replica.Follower) {
+	ctx := helpers.LifecycleCtx(mctx, lc)
+	f.OnPromote(func() {
+		handleIncomingBlocks(ctx, ps, s, bserv, cs, cns, h, nn, bt)
+	})
+}
+
+func HandleStandbyIncomingMessages(mctx helpers.MetricsCtx, lc fx.Lifecycle, ps *pubsub.PubSub, stmgr *stmgr.StateManager, mpool *messagepool.MessagePool, h host.Host, nn dtypes.NetworkName, bootstrapper dtypes.Bootstrapper, f *replica.Follower) {
+	ctx := helpers.LifecycleCtx(mctx, lc)
+	f.OnPromote(func() {
+		handleIncomingMessages(ctx, ps, stmgr, mpool, h, nn, bootstrapper)
+	})
+}
@@ -30,6 +30,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/stmgr"
 	"github.com/filecoin-project/lotus/chain/store"
 	"github.com/filecoin-project/lotus/chain/sub"
+	"github.com/filecoin-project/lotus/chain/sub/blocktiming"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/journal"
 	"github.com/filecoin-project/lotus/journal/fsjournal"
@@ -56,6 +57,10 @@ func init() {
 }
 
 func RunHello(mctx helpers.MetricsCtx, lc fx.Lifecycle, h host.Host, svc *hello.Service) error {
+	return runHello(helpers.LifecycleCtx(mctx, lc), h, svc)
+}
+
+func runHello(ctx context.Context, h host.Host, svc *hello.Service) error {
 	h.SetStreamHandler(hello.ProtocolID, svc.HandleStream)
 
 	sub, err := h.EventBus().Subscribe(new(event.EvtPeerIdentificationCompleted), eventbus.BufSize(1024))
@@ -63,8 +68,6 @@ func RunHello(mctx helpers.MetricsCtx, lc fx.Lifecycle, h host.Host, svc *hello.
 		return xerrors.Errorf("failed to subscribe to event bus: %w", err)
 	}
 
-	ctx := helpers.LifecycleCtx(mctx, lc)
-
 	go func() {
 		for evt := range sub.Out() {
 			pic := evt.(event.EvtPeerIdentificationCompleted)
@@ -146,11 +149,14 @@ func HandleIncomingBlocks(mctx helpers.MetricsCtx,
 	chain *store.ChainStore,
 	cns consensus.Consensus,
 	h host.Host,
-	nn dtypes.NetworkName) {
-	ctx := helpers.LifecycleCtx(mctx, lc)
+	nn dtypes.NetworkName,
+	bt *blocktiming.Tracker) {
+	handleIncomingBlocks(helpers.LifecycleCtx(mctx, lc), ps, s, bserv, chain, cns, h, nn, bt)
+}
 
+func handleIncomingBlocks(ctx context.Context, ps *pubsub.PubSub, s *chain.Syncer, bserv dtypes.ChainBlockService, chain *store.ChainStore, cns consensus.Consensus, h host.Host, nn dtypes.NetworkName, bt *blocktiming.Tracker) {
 	v := sub.NewBlockValidator(
-		h.ID(), chain, cns,
+		h.ID(), chain, cns, bt,
 		func(p peer.ID) {
 			ps.BlacklistPeer(p)
 			h.ConnManager().TagPeer(p, "badblock", -1000)
@@ -167,12 +173,14 @@ func HandleIncomingBlocks(mctx helpers.MetricsCtx,
 		panic(err)
 	}
 
-	go sub.HandleIncomingBlocks(ctx, blocksub, h.ID(), s, bserv, h.ConnManager())
+	go sub.HandleIncomingBlocks(ctx, blocksub, h.ID(), s, bserv, h.ConnManager(), bt)
 }
 
 func HandleIncomingMessages(mctx helpers.MetricsCtx, lc fx.Lifecycle, ps *pubsub.PubSub, stmgr *stmgr.StateManager, mpool *messagepool.MessagePool, h host.Host, nn dtypes.NetworkName, bootstrapper dtypes.Bootstrapper) {
-	ctx := helpers.LifecycleCtx(mctx, lc)
+	handleIncomingMessages(helpers.LifecycleCtx(mctx, lc), ps, stmgr, mpool, h, nn, bootstrapper)
+}
 
+func handleIncomingMessages(ctx context.Context, ps *pubsub.PubSub, stmgr *stmgr.StateManager, mpool *messagepool.MessagePool, h host.Host, nn dtypes.NetworkName, bootstrapper dtypes.Bootstrapper) {
 	v := sub.NewMessageValidator(h.ID(), mpool)
 
 	if err := ps.RegisterTopicValidator(build.MessagesTopic(nn), v.Validate); err != nil {
@@ -0,0 +1,64 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/lib/shutdown"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/storage/sealer"
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+// ConfigureShutdown sets the grace periods of the components stopped on
+// shutdown.
+func ConfigureShutdown(cfg config.Shutdown) func(c *shutdown.Coordinator) {
+	return func(c *shutdown.Coordinator) {
+		c.SetGrace(shutdown.ComponentRPC, time.Duration(cfg.RPCGracePeriod))
+		c.SetGrace(shutdown.ComponentNode, time.Duration(cfg.NodeGracePeriod))
+	}
+}
+
+// ProbeSealingJobs reports the sealing jobs being executed by the workers as
+// in-flight on shutdown.
+func ProbeSealingJobs(c *shutdown.Coordinator, m *sealer.Manager) {
+	c.AddProbe("sealing", func(ctx context.Context) ([]api.ShutdownTask, error) {
+		var out []api.ShutdownTask
+		for wid, jobs := range m.WorkerJobs() {
+			for _, j := range jobs {
+				// prepared, running or waiting for the result to be returned
+				if j.RunWait > 1 || j.RunWait < -1 {
+					continue
+				}
+				out = append(out, api.ShutdownTask{
+					Kind:        "sealing task",
+					Description: fmt.Sprintf("%s %s on worker %s", j.Task.Short(), storiface.SectorName(j.Sector), wid),
+					Started:     j.Start,
+				})
+			}
+		}
+		return out, nil
+	})
+}
+
+// ProbeRetrievals reports the retrieval deals being served as in-flight on
+// shutdown.
+func ProbeRetrievals(c *shutdown.Coordinator, rp retrievalmarket.RetrievalProvider) {
+	c.AddProbe("retrieval", func(ctx context.Context) ([]api.ShutdownTask, error) {
+		var out []api.ShutdownTask
+		for id, deal := range rp.ListDeals() {
+			if retrievalmarket.IsTerminalStatus(deal.Status) {
+				continue
+			}
+			out = append(out, api.ShutdownTask{
+				Kind:        "retrieval",
+				Description: fmt.Sprintf("%s (%s)", id, retrievalmarket.DealStatuses[deal.Status]),
+			})
+		}
+		return out, nil
+	})
+}
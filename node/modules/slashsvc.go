@@ -0,0 +1,58 @@
+package modules
+
+import (
+	"context"
+
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/chain"
+	"github.com/filecoin-project/lotus/chain/gen/slashfilter/slashsvc"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/impl/full"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+	"github.com/filecoin-project/lotus/node/modules/helpers"
+)
+
+type ConsensusFaultReporterAPI struct {
+	fx.In
+
+	full.ChainAPI
+	full.MpoolAPI
+}
+
+var _ slashsvc.API = &ConsensusFaultReporterAPI{}
+
+func ConsensusFaultReporter(cfg config.FaultReporterConfig) func(helpers.MetricsCtx, fx.Lifecycle, dtypes.MetadataDS, *chain.Syncer, ConsensusFaultReporterAPI) (*slashsvc.Reporter, error) {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, ds dtypes.MetadataDS, s *chain.Syncer, fapi ConsensusFaultReporterAPI) (*slashsvc.Reporter, error) {
+		from := address.Undef
+		if cfg.ConsensusFaultReporterAddress != "" {
+			var err error
+			from, err = address.NewFromString(cfg.ConsensusFaultReporterAddress)
+			if err != nil {
+				return nil, xerrors.Errorf("parsing consensus fault reporter address: %w", err)
+			}
+		}
+
+		r, err := slashsvc.NewReporter(&fapi, ds, from)
+		if err != nil {
+			return nil, err
+		}
+
+		ctx := helpers.LifecycleCtx(mctx, lc)
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				incoming, err := s.IncomingBlocks(ctx)
+				if err != nil {
+					return xerrors.Errorf("subscribing to incoming blocks: %w", err)
+				}
+				go r.Run(ctx, incoming)
+				return nil
+			},
+		})
+
+		return r, nil
+	}
+}
@@ -0,0 +1,85 @@
+package modules
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/snapshot"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/modules/helpers"
+	"github.com/filecoin-project/lotus/node/repo"
+)
+
+// Snapshots runs the snapshot service, serving the snapshots on the listen
+// address when set.
+func Snapshots(cfg config.SnapshotsConfig) func(helpers.MetricsCtx, fx.Lifecycle, repo.LockedRepo, *store.ChainStore) (*snapshot.Service, error) {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, r repo.LockedRepo, cs *store.ChainStore) (*snapshot.Service, error) {
+		if abi.ChainEpoch(cfg.RecentStateRoots) < build.Finality {
+			return nil, xerrors.Errorf("Snapshots.RecentStateRoots must be at least %d, got %d", build.Finality, cfg.RecentStateRoots)
+		}
+		if cfg.Interval <= 0 {
+			return nil, xerrors.Errorf("Snapshots.Interval must be positive")
+		}
+
+		dir := cfg.Path
+		if dir == "" {
+			dir = filepath.Join(r.Path(), "snapshots")
+		}
+
+		s, err := snapshot.New(snapshot.Config{
+			Dir:              dir,
+			Interval:         time.Duration(cfg.Interval),
+			Keep:             cfg.Keep,
+			RecentStateRoots: abi.ChainEpoch(cfg.RecentStateRoots),
+		}, cs)
+		if err != nil {
+			return nil, err
+		}
+
+		var srv *http.Server
+		ctx := helpers.LifecycleCtx(mctx, lc)
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				if cfg.ListenAddress != "" {
+					lst, err := net.Listen("tcp", cfg.ListenAddress)
+					if err != nil {
+						return xerrors.Errorf("listening on %s: %w", cfg.ListenAddress, err)
+					}
+
+					srv = &http.Server{
+						Handler:           s,
+						ReadHeaderTimeout: 30 * time.Second,
+					}
+					go func() {
+						if err := srv.Serve(lst); err != http.ErrServerClosed {
+							log.Warnf("snapshot server failed: %s", err)
+						}
+					}()
+				}
+
+				s.Start(ctx)
+				return nil
+			},
+			OnStop: func(ctx context.Context) error {
+				if srv != nil {
+					if err := srv.Shutdown(ctx); err != nil {
+						log.Warnf("stopping snapshot server: %s", err)
+					}
+				}
+				return s.Close()
+			},
+		})
+
+		return s, nil
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/stmgr"
 	"github.com/filecoin-project/lotus/chain/store"
 	"github.com/filecoin-project/lotus/chain/vm"
+	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 )
 
@@ -22,3 +23,15 @@ func StateManager(lc fx.Lifecycle, cs *store.ChainStore, exec stmgr.Executor, sy
 	})
 	return sm, nil
 }
+
+func StateManagerCallCache(cfg config.CallCacheConfig) func(sm *stmgr.StateManager) error {
+	return func(sm *stmgr.StateManager) error {
+		return sm.EnableCallCache(cfg.MaxMemoryBytes)
+	}
+}
+
+func StateManagerReplayWorkers(cfg config.ExecutionConfig) func(sm *stmgr.StateManager) {
+	return func(sm *stmgr.StateManager) {
+		sm.SetReplayWorkers(cfg.ReplayWorkers)
+	}
+}
@@ -39,7 +39,7 @@ import (
 	"github.com/filecoin-project/go-fil-markets/storagemarket/impl/storedask"
 	smnet "github.com/filecoin-project/go-fil-markets/storagemarket/network"
 	"github.com/filecoin-project/go-jsonrpc/auth"
-	"github.com/filecoin-project/go-paramfetch"
+	gparamfetch "github.com/filecoin-project/go-paramfetch"
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-state-types/big"
 	"github.com/filecoin-project/go-statestore"
@@ -55,22 +55,40 @@ import (
 	"github.com/filecoin-project/lotus/chain/gen/slashfilter"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/journal"
+	"github.com/filecoin-project/lotus/journal/alerting"
+	"github.com/filecoin-project/lotus/lib/diskforecast"
+	"github.com/filecoin-project/lotus/lib/paramfetch"
 	"github.com/filecoin-project/lotus/markets"
+	"github.com/filecoin-project/lotus/markets/askschedule"
+	"github.com/filecoin-project/lotus/markets/commpdiag"
 	"github.com/filecoin-project/lotus/markets/dagstore"
+	"github.com/filecoin-project/lotus/markets/dealfilter"
+	"github.com/filecoin-project/lotus/markets/dealsla"
+	"github.com/filecoin-project/lotus/markets/dtprogress"
+	"github.com/filecoin-project/lotus/markets/dtrestart"
 	"github.com/filecoin-project/lotus/markets/idxprov"
+	"github.com/filecoin-project/lotus/markets/labels"
 	marketevents "github.com/filecoin-project/lotus/markets/loggers"
 	"github.com/filecoin-project/lotus/markets/pricing"
+	"github.com/filecoin-project/lotus/markets/retrievalstats"
+	"github.com/filecoin-project/lotus/markets/retrievaltimeline"
 	lotusminer "github.com/filecoin-project/lotus/miner"
 	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 	"github.com/filecoin-project/lotus/node/modules/helpers"
 	"github.com/filecoin-project/lotus/node/repo"
 	"github.com/filecoin-project/lotus/storage/ctladdr"
+	"github.com/filecoin-project/lotus/storage/disputewatch"
+	"github.com/filecoin-project/lotus/storage/faultdetect"
 	"github.com/filecoin-project/lotus/storage/paths"
 	sealing "github.com/filecoin-project/lotus/storage/pipeline"
 	"github.com/filecoin-project/lotus/storage/pipeline/sealiface"
+	"github.com/filecoin-project/lotus/storage/pipeline/sectordb"
 	"github.com/filecoin-project/lotus/storage/sealer"
 	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+	"github.com/filecoin-project/lotus/storage/sealer/tasklog"
+	"github.com/filecoin-project/lotus/storage/spend"
+	"github.com/filecoin-project/lotus/storage/unsealregen"
 	"github.com/filecoin-project/lotus/storage/wdpost"
 )
 
@@ -80,6 +98,9 @@ var (
 
 type UuidWrapper struct {
 	v1api.FullNode
+
+	// records the messages pushed, when set
+	spend *spend.Ledger
 }
 
 func (a *UuidWrapper) MpoolPushMessage(ctx context.Context, msg *types.Message, spec *api.MessageSendSpec) (*types.SignedMessage, error) {
@@ -87,11 +108,36 @@ func (a *UuidWrapper) MpoolPushMessage(ctx context.Context, msg *types.Message,
 		spec = new(api.MessageSendSpec)
 	}
 	spec.MsgUuid = uuid.New()
-	return a.FullNode.MpoolPushMessage(ctx, msg, spec)
+	sm, err := a.FullNode.MpoolPushMessage(ctx, msg, spec)
+	if err != nil || a.spend == nil {
+		return sm, err
+	}
+
+	if err := a.spend.Track(ctx, sm); err != nil {
+		log.Errorw("recording message in spend ledger", "message", sm.Cid(), "error", err)
+	}
+	return sm, nil
 }
 
 func MakeUuidWrapper(a v1api.RawFullNodeAPI) v1api.FullNode {
-	return &UuidWrapper{a}
+	return &UuidWrapper{FullNode: a}
+}
+
+// MakeSpendTrackingUuidWrapper is MakeUuidWrapper recording the messages
+// pushed in the spend ledger.
+func MakeSpendTrackingUuidWrapper(a v1api.RawFullNodeAPI, l *spend.Ledger) v1api.FullNode {
+	return &UuidWrapper{FullNode: a, spend: l}
+}
+
+func SpendLedger(lc fx.Lifecycle, a v1api.RawFullNodeAPI, maddr dtypes.MinerAddress, ds dtypes.MetadataDS) *spend.Ledger {
+	l := spend.NewLedger(address.Address(maddr), a, ds)
+
+	lc.Append(fx.Hook{
+		OnStart: l.Start,
+		OnStop:  l.Stop,
+	})
+
+	return l
 }
 
 func minerAddrFromDS(ds dtypes.MetadataDS) (address.Address, error) {
@@ -103,31 +149,69 @@ func minerAddrFromDS(ds dtypes.MetadataDS) (address.Address, error) {
 	return address.NewFromBytes(maddrb)
 }
 
-func GetParams(prover bool) func(spt abi.RegisteredSealProof) error {
-	return func(spt abi.RegisteredSealProof) error {
+// ProofParams checks and fetches the proof parameter files of the miner.
+type ProofParams struct {
+	*paramfetch.Fetcher
+
+	// StorageSize is the sector size the miner needs proving parameters for,
+	// zero if it only needs verifying keys.
+	StorageSize uint64
+
+	mirrors bool
+}
+
+func NewProofParams(prover bool, cfg config.ProofParamsConfig) func(spt abi.RegisteredSealProof) (*ProofParams, error) {
+	return func(spt abi.RegisteredSealProof) (*ProofParams, error) {
 		ssize, err := spt.SectorSize()
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		// If built-in assets are disabled, we expect the user to have placed the right
-		// parameters in the right location on the filesystem (/var/tmp/filecoin-proof-parameters).
-		if build.DisableBuiltinAssets {
-			return nil
+		var mirrors []paramfetch.Mirror
+		for _, m := range cfg.Mirrors {
+			mirrors = append(mirrors, paramfetch.Mirror{
+				URL:      m.URL,
+				Priority: m.Priority,
+			})
 		}
 
-		var provingSize uint64
+		f, err := paramfetch.New(paramfetch.DefaultDir(), mirrors, build.ParametersJSON(), build.SrsJSON())
+		if err != nil {
+			return nil, xerrors.Errorf("creating proof parameter fetcher: %w", err)
+		}
+
+		pp := &ProofParams{
+			Fetcher: f,
+			mirrors: len(mirrors) > 0,
+		}
 		if prover {
-			provingSize = uint64(ssize)
+			pp.StorageSize = uint64(ssize)
 		}
 
-		// TODO: We should fetch the params for the actual proof type, not just based on the size.
-		if err := paramfetch.GetParams(context.TODO(), build.ParametersJSON(), build.SrsJSON(), provingSize); err != nil {
+		return pp, nil
+	}
+}
+
+func GetParams(pp *ProofParams) error {
+	// If built-in assets are disabled, we expect the user to have placed the right
+	// parameters in the right location on the filesystem (/var/tmp/filecoin-proof-parameters).
+	if build.DisableBuiltinAssets {
+		return nil
+	}
+
+	// TODO: We should fetch the params for the actual proof type, not just based on the size.
+	if pp.mirrors {
+		if err := pp.Fetch(context.TODO(), pp.StorageSize); err != nil {
 			return xerrors.Errorf("fetching proof parameters: %w", err)
 		}
-
 		return nil
 	}
+
+	if err := gparamfetch.GetParams(context.TODO(), build.ParametersJSON(), build.SrsJSON(), pp.StorageSize); err != nil {
+		return xerrors.Errorf("fetching proof parameters: %w", err)
+	}
+
+	return nil
 }
 
 func MinerAddress(ds dtypes.MetadataDS) (dtypes.MinerAddress, error) {
@@ -210,6 +294,34 @@ func AddressSelector(addrConf *config.MinerAddressConfig) func() (*ctladdr.Addre
 	}
 }
 
+// BalanceMonitor creates the monitor of the balances of the worker and control
+// addresses, which checks them when its check interval is set.
+func BalanceMonitor(cfg config.MinerBalanceMonitorConfig) func(lc fx.Lifecycle, ds dtypes.MetadataDS, al *alerting.Alerting, fapi v1api.FullNode, maddr dtypes.MinerAddress, as *ctladdr.AddressSelector) (*ctladdr.BalanceMonitor, error) {
+	return func(lc fx.Lifecycle, ds dtypes.MetadataDS, al *alerting.Alerting, fapi v1api.FullNode, maddr dtypes.MinerAddress, as *ctladdr.AddressSelector) (*ctladdr.BalanceMonitor, error) {
+		m, err := ctladdr.NewBalanceMonitor(fapi, address.Address(maddr), as, namespace.Wrap(ds, datastore.NewKey("/miner/balancemon")), al, ctladdr.BalanceMonitorConfig{
+			CheckInterval:  time.Duration(cfg.CheckInterval),
+			LowWater:       abi.TokenAmount(cfg.LowWater),
+			RefillTo:       abi.TokenAmount(cfg.RefillTo),
+			DailyRefillCap: abi.TokenAmount(cfg.DailyRefillCap),
+			MaxRefillFee:   abi.TokenAmount(cfg.MaxRefillFee),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if cfg.CheckInterval > 0 {
+			lc.Append(fx.Hook{
+				OnStart: func(context.Context) error {
+					m.Start()
+					return nil
+				},
+				OnStop: m.Stop,
+			})
+		}
+		return m, nil
+	}
+}
+
 func PreflightChecks(mctx helpers.MetricsCtx, lc fx.Lifecycle, api v1api.FullNode, maddr dtypes.MinerAddress) error {
 	ctx := helpers.LifecycleCtx(mctx, lc)
 
@@ -332,13 +444,19 @@ func WindowPostScheduler(fc config.MinerFeeConfig, pc config.ProvingConfig) func
 	}
 }
 
-func HandleRetrieval(host host.Host, lc fx.Lifecycle, m retrievalmarket.RetrievalProvider, j journal.Journal) {
+func HandleRetrieval(host host.Host, lc fx.Lifecycle, m retrievalmarket.RetrievalProvider, dt dtypes.ProviderDataTransfer, rs *retrievalstats.Tracker, rt *retrievaltimeline.Recorder, j journal.Journal) {
 	m.OnReady(marketevents.ReadyLogger("retrieval provider"))
 	lc.Append(fx.Hook{
 
 		OnStart: func(ctx context.Context) error {
 			m.SubscribeToEvents(marketevents.RetrievalProviderLogger)
 
+			dt.SubscribeToEvents(rs.OnDataTransferEvent)
+			m.SubscribeToEvents(rs.OnRetrievalProviderEvent)
+
+			dt.SubscribeToEvents(rt.OnDataTransferEvent)
+			m.SubscribeToEvents(rt.OnRetrievalProviderEvent)
+
 			evtType := j.RegisterEventType("markets/retrieval/provider", "state_change")
 			m.SubscribeToEvents(markets.RetrievalProviderJournaler(j, evtType))
 
@@ -350,12 +468,13 @@ func HandleRetrieval(host host.Host, lc fx.Lifecycle, m retrievalmarket.Retrieva
 	})
 }
 
-func HandleDeals(mctx helpers.MetricsCtx, lc fx.Lifecycle, host host.Host, h storagemarket.StorageProvider, j journal.Journal) {
+func HandleDeals(mctx helpers.MetricsCtx, lc fx.Lifecycle, host host.Host, h storagemarket.StorageProvider, diag *commpdiag.Recorder, j journal.Journal) {
 	ctx := helpers.LifecycleCtx(mctx, lc)
 	h.OnReady(marketevents.ReadyLogger("storage provider"))
 	lc.Append(fx.Hook{
 		OnStart: func(context.Context) error {
 			h.SubscribeToEvents(marketevents.StorageProviderLogger)
+			h.SubscribeToEvents(diag.OnDealEvent)
 
 			evtType := j.RegisterEventType("markets/storage/provider", "state_change")
 			h.SubscribeToEvents(markets.StorageProviderJournaler(j, evtType))
@@ -418,7 +537,7 @@ func NewProviderTransport(h host.Host, gs dtypes.StagingGraphsync) dtypes.Provid
 }
 
 // NewProviderDataTransfer returns a data transfer manager
-func NewProviderDataTransfer(lc fx.Lifecycle, net dtypes.ProviderTransferNetwork, transport dtypes.ProviderTransport, ds dtypes.MetadataDS, r repo.LockedRepo) (dtypes.ProviderDataTransfer, error) {
+func NewProviderDataTransfer(lc fx.Lifecycle, net dtypes.ProviderTransferNetwork, transport dtypes.ProviderTransport, ds dtypes.MarketsDS, r repo.LockedRepo) (dtypes.ProviderDataTransfer, error) {
 	dtDs := namespace.Wrap(ds, datastore.NewKey("/datatransfer/provider/transfers"))
 
 	dt, err := dtimpl.NewDataTransfer(dtDs, net, transport)
@@ -439,6 +558,106 @@ func NewProviderDataTransfer(lc fx.Lifecycle, net dtypes.ProviderTransferNetwork
 	return dt, nil
 }
 
+// DataTransferRestarter returns a monitor restarting stalled provider data
+// transfers
+func DataTransferRestarter(cfg config.DataTransferRestartConfig) func(lc fx.Lifecycle, dt dtypes.ProviderDataTransfer) (*dtrestart.Monitor, error) {
+	return func(lc fx.Lifecycle, dt dtypes.ProviderDataTransfer) (*dtrestart.Monitor, error) {
+		m, err := dtrestart.New(dtrestart.Config{
+			StallTimeout:   time.Duration(cfg.StallTimeout),
+			InitialBackoff: time.Duration(cfg.InitialBackoff),
+			MaxBackoff:     time.Duration(cfg.MaxBackoff),
+			MaxRestarts:    cfg.MaxRestarts,
+		}, dt)
+		if err != nil {
+			return nil, xerrors.Errorf("creating data transfer restart monitor: %w", err)
+		}
+
+		lc.Append(fx.Hook{
+			OnStart: m.Start,
+			OnStop:  m.Stop,
+		})
+		return m, nil
+	}
+}
+
+// NewCommPDiagnostics creates the recorder of the deals whose data doesn't
+// match the piece CID of the proposal
+func NewCommPDiagnostics(ds dtypes.MetadataDS) *commpdiag.Recorder {
+	return commpdiag.New(namespace.Wrap(ds, datastore.NewKey("/deals/provider/commp-diagnostics")))
+}
+
+type DealSLAParams struct {
+	fx.In
+
+	Lifecycle  fx.Lifecycle
+	MetricsCtx helpers.MetricsCtx
+	MetadataDS dtypes.MetadataDS
+	Provider   storagemarket.StorageProvider
+
+	// Pipeline is only set when the sealing pipeline runs in the same
+	// process, the sectors reaching Proving are followed then
+	Pipeline *sealing.Sealing `optional:"true"`
+}
+
+// DealSLATracker creates the tracker of the time the storage deals take from
+// the receipt of their data to their sector being Proving
+func DealSLATracker(cfg config.DealmakingConfig) func(p DealSLAParams) (*dealsla.Tracker, error) {
+	return func(p DealSLAParams) (*dealsla.Tracker, error) {
+		ds := namespace.Wrap(p.MetadataDS, datastore.NewKey("/deals/provider/sla"))
+		t, err := dealsla.New(helpers.LifecycleCtx(p.MetricsCtx, p.Lifecycle), ds, time.Duration(cfg.SealingSLA), p.Pipeline != nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if p.Pipeline != nil {
+			p.Pipeline.AddNotifee(func(before, after sealing.SectorInfo) {
+				if after.State != sealing.Proving || before.State == sealing.Proving {
+					return
+				}
+				var deals []abi.DealID
+				for _, piece := range after.Pieces {
+					if piece.DealInfo != nil && piece.DealInfo.DealID != 0 {
+						deals = append(deals, piece.DealInfo.DealID)
+					}
+				}
+				if len(deals) > 0 {
+					t.OnSectorProving(after.SectorNumber, deals)
+				}
+			})
+		}
+
+		unsubscribe := p.Provider.SubscribeToEvents(t.OnStorageProviderEvent)
+		p.Lifecycle.Append(fx.Hook{
+			OnStop: func(context.Context) error {
+				unsubscribe()
+				return nil
+			},
+		})
+
+		return t, nil
+	}
+}
+
+// NewRetrievalTimelines creates the recorder of the timelines of the
+// retrievals served by the retrieval provider
+func NewRetrievalTimelines(ds dtypes.MetadataDS) *retrievaltimeline.Recorder {
+	return retrievaltimeline.New(namespace.Wrap(ds, datastore.NewKey("/retrievals/provider/timelines")))
+}
+
+// DealTransferProgress returns a tracker of the progress of the provider
+// storage deal transfers. Transfers are reported as stalled after the stall
+// timeout of the transfer restarts, when set.
+func DealTransferProgress(cfg config.DataTransferRestartConfig) func(lc fx.Lifecycle, dt dtypes.ProviderDataTransfer) *dtprogress.Tracker {
+	return func(lc fx.Lifecycle, dt dtypes.ProviderDataTransfer) *dtprogress.Tracker {
+		t := dtprogress.New(dt, time.Duration(cfg.StallTimeout))
+		lc.Append(fx.Hook{
+			OnStart: t.Start,
+			OnStop:  t.Stop,
+		})
+		return t
+	}
+}
+
 // NewProviderPieceStore creates a statestore for storing metadata about pieces
 // shared by the storage and retrieval providers
 func NewProviderPieceStore(lc fx.Lifecycle, ds dtypes.MetadataDS) (dtypes.ProviderPieceStore, error) {
@@ -495,7 +714,7 @@ func SetupBlockProducer(lc fx.Lifecycle, ds dtypes.MetadataDS, api v1api.FullNod
 		return nil, err
 	}
 
-	m := lotusminer.NewMiner(api, epp, minerAddr, sf, j)
+	m := lotusminer.NewMiner(api, epp, minerAddr, sf, lotusminer.NewBlockJournal(ds), j)
 
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
@@ -529,6 +748,29 @@ func NewStorageAsk(ctx helpers.MetricsCtx, fapi v1api.FullNode, ds dtypes.Metada
 		storagemarket.MaxPieceSize(abi.PaddedPieceSize(mi.SectorSize)))
 }
 
+// NewAskSchedule creates the manager of the storage ask, which applies the
+// scheduled asks and keeps the history of the ask changes.
+func NewAskSchedule(ds dtypes.MetadataDS, sa *storedask.StoredAsk) (*askschedule.Manager, error) {
+	return askschedule.New(namespace.Wrap(ds, datastore.NewKey("/deals/provider/ask-schedule")), sa)
+}
+
+// NewLabelStore creates the store of the labels set on pieces and deals.
+func NewLabelStore(ds dtypes.MetadataDS) *labels.Store {
+	return labels.NewStore(namespace.Wrap(ds, datastore.NewKey("/deals/provider/labels")))
+}
+
+// HandleAskSchedule applies the scheduled storage asks as the chain advances.
+func HandleAskSchedule(mctx helpers.MetricsCtx, lc fx.Lifecycle, m *askschedule.Manager, fapi v1api.FullNode) {
+	ctx := helpers.LifecycleCtx(mctx, lc)
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go m.Run(ctx, fapi)
+			return nil
+		},
+	})
+}
+
 func BasicDealFilter(cfg config.DealmakingConfig, user dtypes.StorageDealFilter) func(onlineOk dtypes.ConsiderOnlineStorageDealsConfigFunc,
 	offlineOk dtypes.ConsiderOfflineStorageDealsConfigFunc,
 	verifiedOk dtypes.ConsiderVerifiedStorageDealsConfigFunc,
@@ -538,6 +780,8 @@ func BasicDealFilter(cfg config.DealmakingConfig, user dtypes.StorageDealFilter)
 	startDelay dtypes.GetMaxDealStartDelayFunc,
 	spn storagemarket.StorageProviderNode,
 	r repo.LockedRepo,
+	asks *askschedule.Manager,
+	forecast *diskforecast.Tracker,
 ) dtypes.StorageDealFilter {
 	return func(onlineOk dtypes.ConsiderOnlineStorageDealsConfigFunc,
 		offlineOk dtypes.ConsiderOfflineStorageDealsConfigFunc,
@@ -548,6 +792,8 @@ func BasicDealFilter(cfg config.DealmakingConfig, user dtypes.StorageDealFilter)
 		startDelay dtypes.GetMaxDealStartDelayFunc,
 		spn storagemarket.StorageProviderNode,
 		r repo.LockedRepo,
+		asks *askschedule.Manager,
+		forecast *diskforecast.Tracker,
 	) dtypes.StorageDealFilter {
 
 		return func(ctx context.Context, deal storagemarket.MinerDeal) (bool, string, error) {
@@ -635,6 +881,11 @@ func BasicDealFilter(cfg config.DealmakingConfig, user dtypes.StorageDealFilter)
 				return false, "cannot accept deal as miner is overloaded at the moment - there are too many staging deals being processed", nil
 			}
 
+			if err := forecast.Admit(); err != nil {
+				log.Errorw("proposed deal rejected because the miner is running out of disk space", "error", err)
+				return false, "cannot accept deal as miner is running out of disk space", nil
+			}
+
 			// Reject if it's more than 7 days in the future
 			// TODO: read from cfg
 			maxStartEpoch := earliest + abi.ChainEpoch(uint64(sd.Seconds())/build.BlockDelaySecs)
@@ -642,6 +893,11 @@ func BasicDealFilter(cfg config.DealmakingConfig, user dtypes.StorageDealFilter)
 				return false, fmt.Sprintf("deal start epoch is too far in the future: %s > %s", deal.Proposal.StartEpoch, maxStartEpoch), nil
 			}
 
+			if ok, reason := asks.CheckDeal(deal); !ok {
+				log.Warnw("proposed deal is below the price curve of the ask; rejecting storage deal proposal", "client", deal.Client.String(), "reason", reason)
+				return false, reason, nil
+			}
+
 			if user != nil {
 				return user(ctx, deal)
 			}
@@ -653,7 +909,7 @@ func BasicDealFilter(cfg config.DealmakingConfig, user dtypes.StorageDealFilter)
 
 func StorageProvider(minerAddress dtypes.MinerAddress,
 	storedAsk *storedask.StoredAsk,
-	h host.Host, ds dtypes.MetadataDS,
+	h host.Host, ds dtypes.MarketsDS,
 	r repo.LockedRepo,
 	pieceStore dtypes.ProviderPieceStore,
 	indexer provider.Interface,
@@ -697,36 +953,53 @@ func StorageProvider(minerAddress dtypes.MinerAddress,
 	)
 }
 
-func RetrievalDealFilter(userFilter dtypes.RetrievalDealFilter) func(onlineOk dtypes.ConsiderOnlineRetrievalDealsConfigFunc,
-	offlineOk dtypes.ConsiderOfflineRetrievalDealsConfigFunc) dtypes.RetrievalDealFilter {
-	return func(onlineOk dtypes.ConsiderOnlineRetrievalDealsConfigFunc,
-		offlineOk dtypes.ConsiderOfflineRetrievalDealsConfigFunc) dtypes.RetrievalDealFilter {
-		return func(ctx context.Context, state retrievalmarket.ProviderDealState) (bool, string, error) {
-			b, err := onlineOk()
-			if err != nil {
-				return false, "miner error", err
-			}
+type RetrievalDealFilterParams struct {
+	fx.In
 
-			if !b {
-				log.Warn("online retrieval deal consideration disabled; rejecting retrieval deal proposal from client")
-				return false, "miner is not accepting online retrieval deals", nil
-			}
+	OnlineOk   dtypes.ConsiderOnlineRetrievalDealsConfigFunc
+	OfflineOk  dtypes.ConsiderOfflineRetrievalDealsConfigFunc
+	PieceStore dtypes.ProviderPieceStore
 
-			b, err = offlineOk()
-			if err != nil {
-				return false, "miner error", err
-			}
+	// User is the filter configured with Dealmaking.RetrievalFilter, or
+	// provided in-process by overriding dealfilter.RetrievalFilter
+	User dealfilter.RetrievalFilter `optional:"true"`
+}
 
-			if !b {
-				log.Info("offline retrieval has not been implemented yet")
-			}
+func RetrievalDealFilter(p RetrievalDealFilterParams) dtypes.RetrievalDealFilter {
+	return func(ctx context.Context, state retrievalmarket.ProviderDealState) (bool, string, error) {
+		b, err := p.OnlineOk()
+		if err != nil {
+			return false, "miner error", err
+		}
 
-			if userFilter != nil {
-				return userFilter(ctx, state)
-			}
+		if !b {
+			log.Warn("online retrieval deal consideration disabled; rejecting retrieval deal proposal from client")
+			return false, "miner is not accepting online retrieval deals", nil
+		}
+
+		b, err = p.OfflineOk()
+		if err != nil {
+			return false, "miner error", err
+		}
+
+		if !b {
+			log.Info("offline retrieval has not been implemented yet")
+		}
 
+		if p.User == nil {
 			return true, "", nil
 		}
+
+		req, err := dealfilter.NewRetrievalRequest(p.PieceStore, state)
+		if err != nil {
+			return false, "miner error", err
+		}
+
+		accept, reason, err := p.User.FilterRetrieval(ctx, req)
+		if err == nil && !accept {
+			log.Warnw("retrieval filter rejected retrieval deal proposal", "client", req.Client, "piece", req.PieceCID, "size", req.TransferSize, "reason", reason)
+		}
+		return accept, reason, err
 	}
 }
 
@@ -754,7 +1027,7 @@ func RetrievalProvider(
 	adapter retrievalmarket.RetrievalProviderNode,
 	sa retrievalmarket.SectorAccessor,
 	netwk rmnet.RetrievalMarketNetwork,
-	ds dtypes.MetadataDS,
+	ds dtypes.MarketsDS,
 	pieceStore dtypes.ProviderPieceStore,
 	dt dtypes.ProviderDataTransfer,
 	pricingFnc dtypes.RetrievalPricingFunc,
@@ -1012,12 +1285,15 @@ func NewSetSealConfigFunc(r repo.LockedRepo) (dtypes.SetSealingConfigFunc, error
 				CommitBatchWait:            config.Duration(cfg.CommitBatchWait),
 				CommitBatchSlack:           config.Duration(cfg.CommitBatchSlack),
 				AggregateAboveBaseFee:      types.FIL(cfg.AggregateAboveBaseFee),
+				CommitAggregationPolicy:    string(cfg.CommitAggregationPolicy),
 				BatchPreCommitAboveBaseFee: types.FIL(cfg.BatchPreCommitAboveBaseFee),
 
 				TerminateBatchMax:                      cfg.TerminateBatchMax,
 				TerminateBatchMin:                      cfg.TerminateBatchMin,
 				TerminateBatchWait:                     config.Duration(cfg.TerminateBatchWait),
 				MaxSectorProveCommitsSubmittedPerEpoch: cfg.MaxSectorProveCommitsSubmittedPerEpoch,
+
+				RetryPolicies: fromRetryPolicies(cfg.RetryPolicies),
 			}
 			c.SetSealingConfig(newCfg)
 		})
@@ -1058,15 +1334,55 @@ func ToSealingConfig(dealmakingCfg config.DealmakingConfig, sealingCfg config.Se
 		CommitBatchWait:                        time.Duration(sealingCfg.CommitBatchWait),
 		CommitBatchSlack:                       time.Duration(sealingCfg.CommitBatchSlack),
 		AggregateAboveBaseFee:                  types.BigInt(sealingCfg.AggregateAboveBaseFee),
+		CommitAggregationPolicy:                sealiface.CommitAggregationPolicy(sealingCfg.CommitAggregationPolicy),
 		BatchPreCommitAboveBaseFee:             types.BigInt(sealingCfg.BatchPreCommitAboveBaseFee),
 		MaxSectorProveCommitsSubmittedPerEpoch: sealingCfg.MaxSectorProveCommitsSubmittedPerEpoch,
 
 		TerminateBatchMax:  sealingCfg.TerminateBatchMax,
 		TerminateBatchMin:  sealingCfg.TerminateBatchMin,
 		TerminateBatchWait: time.Duration(sealingCfg.TerminateBatchWait),
+
+		RetryPolicies: toRetryPolicies(sealingCfg.RetryPolicies),
+	}
+}
+
+func retryPolicyFields(cfg *config.SealingRetryPolicies) map[sealiface.FailureClass]*config.RetryPolicy {
+	return map[sealiface.FailureClass]*config.RetryPolicy{
+		sealiface.FailTicketExpired: &cfg.TicketExpired,
+		sealiface.FailSealing:       &cfg.Sealing,
+		sealiface.FailStorage:       &cfg.Storage,
+		sealiface.FailProof:         &cfg.Proof,
+		sealiface.FailChain:         &cfg.Chain,
+		sealiface.FailOther:         &cfg.Other,
 	}
 }
 
+func toRetryPolicies(cfg config.SealingRetryPolicies) map[sealiface.FailureClass]sealiface.RetryPolicy {
+	out := map[sealiface.FailureClass]sealiface.RetryPolicy{}
+	for class, p := range retryPolicyFields(&cfg) {
+		out[class] = sealiface.RetryPolicy{
+			MaxRetries: p.MaxRetries,
+			MinBackoff: time.Duration(p.MinBackoff),
+			MaxBackoff: time.Duration(p.MaxBackoff),
+			AutoAbort:  p.AutoAbort,
+		}
+	}
+	return out
+}
+
+func fromRetryPolicies(policies map[sealiface.FailureClass]sealiface.RetryPolicy) config.SealingRetryPolicies {
+	var out config.SealingRetryPolicies
+	for class, p := range retryPolicyFields(&out) {
+		*p = config.RetryPolicy{
+			MaxRetries: policies[class].MaxRetries,
+			MinBackoff: config.Duration(policies[class].MinBackoff),
+			MaxBackoff: config.Duration(policies[class].MaxBackoff),
+			AutoAbort:  policies[class].AutoAbort,
+		}
+	}
+	return out
+}
+
 func NewGetSealConfigFunc(r repo.LockedRepo) (dtypes.GetSealingConfigFunc, error) {
 	return func() (out sealiface.Config, err error) {
 		err = readSealingCfg(r, func(dc config.DealmakingConfiger, sc config.SealingConfiger) {
@@ -1257,3 +1573,118 @@ func ExtractEnabledMinerSubsystems(cfg config.MinerSubsystemConfig) (res api.Min
 	}
 	return res
 }
+
+func SectorDB(mctx helpers.MetricsCtx, lc fx.Lifecycle, r repo.LockedRepo, api v1api.FullNode, maddr dtypes.MinerAddress, pipeline *sealing.Sealing) (*sectordb.DB, error) {
+	sqlitePath, err := r.SqlitePath()
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sectordb.Open(filepath.Join(sqlitePath, sectordb.DBName), api, address.Address(maddr))
+	if err != nil {
+		return nil, err
+	}
+
+	// the pipeline isn't running yet, mirror the current sectors before
+	// following their state transitions
+	sectors, err := pipeline.ListSectors()
+	if err != nil {
+		return nil, xerrors.Errorf("listing sectors: %w", err)
+	}
+	if err := db.Sync(helpers.LifecycleCtx(mctx, lc), sectors); err != nil {
+		return nil, xerrors.Errorf("syncing sector database: %w", err)
+	}
+	pipeline.AddNotifee(db.Update)
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			db.Start(helpers.LifecycleCtx(mctx, lc))
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			return db.Close()
+		},
+	})
+
+	return db, nil
+}
+
+func UnsealRegenerator(cfg config.SealingConfig) func(lc fx.Lifecycle, api v1api.FullNode, maddr dtypes.MinerAddress, pipeline *sealing.Sealing, idx *paths.Index, mgr *sealer.Manager) (*unsealregen.Regenerator, error) {
+	return func(lc fx.Lifecycle, api v1api.FullNode, maddr dtypes.MinerAddress, pipeline *sealing.Sealing, idx *paths.Index, mgr *sealer.Manager) (*unsealregen.Regenerator, error) {
+		mid, err := address.IDFromAddress(address.Address(maddr))
+		if err != nil {
+			return nil, err
+		}
+
+		r := unsealregen.New(unsealregen.Config{
+			Approval:           cfg.RegenerateUnsealedApproval,
+			MaxConcurrent:      cfg.RegenerateUnsealedMaxConcurrent,
+			CheckInterval:      time.Duration(cfg.RegenerateUnsealedCheckInterval),
+			AlwaysKeepUnsealed: cfg.AlwaysKeepUnsealedCopy,
+		}, abi.ActorID(mid), api, pipeline, idx, mgr)
+
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				r.Start()
+				return nil
+			},
+			OnStop: r.Stop,
+		})
+
+		return r, nil
+	}
+}
+
+func FaultDetector(cfg config.ProvingConfig) func(lc fx.Lifecycle, api v1api.FullNode, maddr dtypes.MinerAddress, idx *paths.Index, wdp *wdpost.WindowPoStScheduler) (*faultdetect.Detector, error) {
+	return func(lc fx.Lifecycle, api v1api.FullNode, maddr dtypes.MinerAddress, idx *paths.Index, wdp *wdpost.WindowPoStScheduler) (*faultdetect.Detector, error) {
+		d, err := faultdetect.New(faultdetect.Config{
+			Declare:       cfg.DeclareDetectedFaults,
+			Approval:      cfg.DeclareDetectedFaultsApproval,
+			GracePeriod:   time.Duration(cfg.FaultDetectionGracePeriod),
+			CheckInterval: time.Duration(cfg.FaultDetectionCheckInterval),
+		}, address.Address(maddr), api, idx, wdp)
+		if err != nil {
+			return nil, err
+		}
+
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				d.Start()
+				return nil
+			},
+			OnStop: d.Stop,
+		})
+
+		return d, nil
+	}
+}
+
+func DisputeWatcher(lc fx.Lifecycle, api v1api.FullNode, ds dtypes.MetadataDS, maddr dtypes.MinerAddress, al *alerting.Alerting) (*disputewatch.Watcher, error) {
+	w, err := disputewatch.New(api, namespace.Wrap(ds, datastore.NewKey("/wdpost/disputes")), address.Address(maddr), al)
+	if err != nil {
+		return nil, err
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			w.Start()
+			return nil
+		},
+		OnStop: w.Stop,
+	})
+
+	return w, nil
+}
+
+func TaskLogStore(cfg config.SealerConfig) func(lc fx.Lifecycle, ds dtypes.MetadataDS) *tasklog.Store {
+	return func(lc fx.Lifecycle, ds dtypes.MetadataDS) *tasklog.Store {
+		s := tasklog.NewStore(namespace.Wrap(ds, datastore.NewKey("/sealing/tasklogs")), time.Duration(cfg.TaskLogRetention))
+
+		lc.Append(fx.Hook{
+			OnStart: s.Start,
+			OnStop:  s.Stop,
+		})
+
+		return s
+	}
+}
@@ -0,0 +1,105 @@
+package modules
+
+import (
+	"context"
+
+	bsnetwork "github.com/ipfs/boxo/bitswap/network"
+	"github.com/ipfs/boxo/bitswap/server"
+	routinghelpers "github.com/libp2p/go-libp2p-routing-helpers"
+	"github.com/libp2p/go-libp2p/core/host"
+	"go.uber.org/fx"
+
+	"github.com/filecoin-project/go-fil-markets/storagemarket"
+
+	"github.com/filecoin-project/lotus/markets/bitswapserver"
+	mdagstore "github.com/filecoin-project/lotus/markets/dagstore"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/modules/helpers"
+)
+
+// BitswapServer serves deal data from the DAG store to IPFS clients over
+// bitswap, subject to the per-peer rate limits and the active deal
+// allow-list configured in cfg.
+func BitswapServer(cfg config.MinerBitswapConfig) func(mctx helpers.MetricsCtx, lc fx.Lifecycle, h host.Host, dagst *mdagstore.Wrapper, sp storagemarket.StorageProvider) {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, h host.Host, dagst *mdagstore.Wrapper, sp storagemarket.StorageProvider) {
+		ctx := helpers.LifecycleCtx(mctx, lc)
+
+		policy := bitswapserver.NewPolicy(cfg.RequestsPerPeerPerSecond, cfg.RequestBurstPerPeer)
+		bstore := bitswapserver.NewBlockstore(dagst, policy)
+
+		opts := []server.Option{
+			server.ProvideEnabled(false),
+			server.WithPeerBlockRequestFilter(policy.Filter),
+		}
+		if cfg.MaxOutstandingBytesPerPeer > 0 {
+			opts = append(opts, server.MaxOutstandingBytesPerPeer(cfg.MaxOutstandingBytesPerPeer))
+		}
+
+		// The allow-list is rebuilt whenever a deal enters or leaves the
+		// active state. Rebuilds are coalesced so that bursts of deal events
+		// only result in a single scan of the deal store.
+		refresh := make(chan struct{}, 1)
+		trigger := func() {
+			select {
+			case refresh <- struct{}{}:
+			default:
+			}
+		}
+
+		var (
+			net         bsnetwork.BitSwapNetwork
+			srv         *server.Server
+			unsubscribe func()
+		)
+
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				unsubscribe = sp.SubscribeToEvents(func(_ storagemarket.ProviderEvent, deal storagemarket.MinerDeal) {
+					switch deal.State {
+					case storagemarket.StorageDealActive,
+						storagemarket.StorageDealExpired,
+						storagemarket.StorageDealSlashed,
+						storagemarket.StorageDealError:
+						trigger()
+					}
+				})
+
+				go func() {
+					for {
+						deals, err := sp.ListLocalDeals()
+						if err != nil {
+							log.Errorf("bitswap server: listing local deals: %s", err)
+						} else {
+							policy.SetDeals(deals)
+						}
+
+						select {
+						case <-refresh:
+						case <-ctx.Done():
+							return
+						}
+					}
+				}()
+
+				net = bsnetwork.NewFromIpfsHost(h, routinghelpers.Null{})
+				srv = server.New(ctx, net, bstore, opts...)
+				net.Start(srv)
+
+				log.Infow("bitswap server started", "peer", h.ID())
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				if unsubscribe != nil {
+					unsubscribe()
+				}
+				if net != nil {
+					net.Stop()
+				}
+				if srv != nil {
+					return srv.Close()
+				}
+				return nil
+			},
+		})
+	}
+}
@@ -2,10 +2,16 @@ package modules
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/libp2p/go-libp2p/core/host"
 	"go.uber.org/fx"
@@ -13,7 +19,10 @@ import (
 
 	"github.com/filecoin-project/dagstore"
 
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/journal/alerting"
 	mdagstore "github.com/filecoin-project/lotus/markets/dagstore"
+	"github.com/filecoin-project/lotus/markets/retrievability"
 	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 	"github.com/filecoin-project/lotus/node/repo"
@@ -22,6 +31,10 @@ import (
 const (
 	EnvDAGStoreCopyConcurrency = "LOTUS_DAGSTORE_COPY_CONCURRENCY"
 	DefaultDAGStoreDir         = "dagstore"
+
+	// DAGStoreTransientKeyName is the name of the key the transients are
+	// encrypted with in the keystore.
+	DAGStoreTransientKeyName = "dagstore-transient-key"
 )
 
 // NewMinerAPI creates a new MinerAPI adaptor for the dagstore mounts.
@@ -60,8 +73,8 @@ func NewMinerAPI(cfg config.DAGStoreConfig) func(fx.Lifecycle, repo.LockedRepo,
 // DAGStore constructs a DAG store using the supplied minerAPI, and the
 // user configuration. It returns both the DAGStore and the Wrapper suitable for
 // passing to markets.
-func DAGStore(cfg config.DAGStoreConfig) func(lc fx.Lifecycle, r repo.LockedRepo, minerAPI mdagstore.MinerAPI, h host.Host) (*dagstore.DAGStore, *mdagstore.Wrapper, error) {
-	return func(lc fx.Lifecycle, r repo.LockedRepo, minerAPI mdagstore.MinerAPI, h host.Host) (*dagstore.DAGStore, *mdagstore.Wrapper, error) {
+func DAGStore(cfg config.DAGStoreConfig) func(lc fx.Lifecycle, r repo.LockedRepo, ks types.KeyStore, minerAPI mdagstore.MinerAPI, h host.Host) (*dagstore.DAGStore, *mdagstore.Wrapper, error) {
+	return func(lc fx.Lifecycle, r repo.LockedRepo, ks types.KeyStore, minerAPI mdagstore.MinerAPI, h host.Host) (*dagstore.DAGStore, *mdagstore.Wrapper, error) {
 		// fall back to default root directory if not explicitly set in the config.
 		if cfg.RootDir == "" {
 			cfg.RootDir = filepath.Join(r.Path(), DefaultDAGStoreDir)
@@ -75,7 +88,16 @@ func DAGStore(cfg config.DAGStoreConfig) func(lc fx.Lifecycle, r repo.LockedRepo
 			}
 		}
 
-		dagst, w, err := mdagstore.NewDAGStore(cfg, minerAPI, h)
+		var transientKey []byte
+		if cfg.TransientEncryption {
+			var err error
+			transientKey, err = dagStoreTransientKey(cfg, ks)
+			if err != nil {
+				return nil, nil, xerrors.Errorf("getting the transient encryption key: %w", err)
+			}
+		}
+
+		dagst, w, err := mdagstore.NewDAGStore(cfg, minerAPI, h, transientKey)
 		if err != nil {
 			return nil, nil, xerrors.Errorf("failed to create DAG store: %w", err)
 		}
@@ -92,3 +114,69 @@ func DAGStore(cfg config.DAGStoreConfig) func(lc fx.Lifecycle, r repo.LockedRepo
 		return dagst, w, nil
 	}
 }
+
+// dagStoreTransientKey returns the key the transients are encrypted with,
+// printed by the configured command, or kept in the keystore.
+func dagStoreTransientKey(cfg config.DAGStoreConfig, ks types.KeyStore) ([]byte, error) {
+	if cfg.TransientEncryptionKeyCommand != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		out, err := exec.CommandContext(ctx, "sh", "-c", cfg.TransientEncryptionKeyCommand).Output()
+		if err != nil {
+			return nil, xerrors.Errorf("running the key command: %w", err)
+		}
+		key, err := hex.DecodeString(strings.TrimSpace(string(out)))
+		if err != nil {
+			return nil, xerrors.Errorf("decoding the key printed by the key command: %w", err)
+		}
+		return key, nil
+	}
+
+	ki, err := ks.Get(DAGStoreTransientKeyName)
+	if err == nil {
+		return ki.PrivateKey, nil
+	}
+	if !errors.Is(err, types.ErrKeyInfoNotFound) {
+		return nil, err
+	}
+
+	log.Info("generating the dagstore transient encryption key")
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := ks.Put(DAGStoreTransientKeyName, types.KeyInfo{
+		Type:       DAGStoreTransientKeyName,
+		PrivateKey: key,
+	}); err != nil {
+		return nil, xerrors.Errorf("writing the key to the keystore: %w", err)
+	}
+	return key, nil
+}
+
+// RetrievabilitySampler creates the sampler periodically verifying that deal
+// data can be read through the DAG store.
+func RetrievabilitySampler(cfg config.DAGStoreConfig) func(lc fx.Lifecycle, ps dtypes.ProviderPieceStore, w *mdagstore.Wrapper, minerAPI mdagstore.MinerAPI, ds dtypes.MetadataDS, al *alerting.Alerting) (*retrievability.Sampler, error) {
+	return func(lc fx.Lifecycle, ps dtypes.ProviderPieceStore, w *mdagstore.Wrapper, minerAPI mdagstore.MinerAPI, ds dtypes.MetadataDS, al *alerting.Alerting) (*retrievability.Sampler, error) {
+		if cfg.RetrievabilitySamplingInterval <= 0 {
+			return nil, xerrors.Errorf("retrievability sampling interval must be positive")
+		}
+		if cfg.RetrievabilitySamplingPieces <= 0 || cfg.RetrievabilitySamplingBlocks <= 0 {
+			return nil, xerrors.Errorf("retrievability sampling pieces and blocks must be positive")
+		}
+
+		s := retrievability.NewSampler(retrievability.Config{
+			Interval: time.Duration(cfg.RetrievabilitySamplingInterval),
+			Pieces:   cfg.RetrievabilitySamplingPieces,
+			Blocks:   cfg.RetrievabilitySamplingBlocks,
+			Unseal:   cfg.RetrievabilitySamplingUnseal,
+		}, ps, w, minerAPI, ds, al)
+
+		lc.Append(fx.Hook{
+			OnStart: s.Start,
+			OnStop:  s.Stop,
+		})
+		return s, nil
+	}
+}
@@ -0,0 +1,125 @@
+package modules
+
+import (
+	"context"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	dht "github.com/libp2p/go-libp2p-kad-dht"
+	"github.com/libp2p/go-libp2p/core/host"
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/dagstore"
+	"github.com/filecoin-project/go-fil-markets/storagemarket"
+
+	"github.com/filecoin-project/lotus/markets/dhtprovider"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/modules/helpers"
+)
+
+// DHTProvider announces deal data to the public IPFS DHT. It joins the DHT
+// as a client with the default IPFS protocol and bootstrap peers, which is
+// separate from the Filecoin DHT used by the rest of the node.
+func DHTProvider(cfg config.DHTProviderConfig) func(mctx helpers.MetricsCtx, lc fx.Lifecycle, h host.Host, dagst *dagstore.DAGStore, sp storagemarket.StorageProvider) error {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, h host.Host, dagst *dagstore.DAGStore, sp storagemarket.StorageProvider) error {
+		ctx := helpers.LifecycleCtx(mctx, lc)
+
+		d, err := dht.New(ctx, h,
+			dht.Mode(dht.ModeClient),
+			dht.BootstrapPeers(dht.GetDefaultBootstrapPeerAddrInfos()...))
+		if err != nil {
+			return xerrors.Errorf("creating public dht client: %w", err)
+		}
+
+		source := func(ctx context.Context) ([]cid.Cid, error) {
+			return dhtProviderCids(cfg, dagst, sp)
+		}
+		p := dhtprovider.New(d, source, dhtprovider.Config{
+			ProvidesPerSecond: cfg.ProvidesPerSecond,
+			Workers:           cfg.Workers,
+			ReprovideInterval: time.Duration(cfg.ReprovideInterval),
+		})
+
+		var unsubscribe func()
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				if err := d.Bootstrap(ctx); err != nil {
+					return xerrors.Errorf("bootstrapping public dht client: %w", err)
+				}
+
+				// announce new deal data as soon as it is available for retrieval
+				unsubscribe = sp.SubscribeToEvents(func(_ storagemarket.ProviderEvent, deal storagemarket.MinerDeal) {
+					if deal.State != storagemarket.StorageDealActive {
+						return
+					}
+					if cfg.AnnouncePieceCIDs {
+						p.Announce(deal.Proposal.PieceCID)
+					}
+					if cfg.AnnouncePayloadCIDs && deal.Ref != nil {
+						p.Announce(deal.Ref.Root)
+					}
+				})
+
+				p.Start(ctx)
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				if unsubscribe != nil {
+					unsubscribe()
+				}
+				if err := p.Close(); err != nil {
+					return err
+				}
+				return d.Close()
+			},
+		})
+
+		return nil
+	}
+}
+
+// dhtProviderCids returns the piece and payload CIDs of all shards registered
+// with the DAG store, as selected by the config.
+func dhtProviderCids(cfg config.DHTProviderConfig, dagst *dagstore.DAGStore, sp storagemarket.StorageProvider) ([]cid.Cid, error) {
+	pieces := make(map[cid.Cid]struct{})
+	for k := range dagst.AllShardsInfo() {
+		c, err := cid.Parse(k.String())
+		if err != nil {
+			log.Warnw("failed to parse shard key as piece cid", "key", k, "error", err)
+			continue
+		}
+		pieces[c] = struct{}{}
+	}
+
+	var out []cid.Cid
+	if cfg.AnnouncePieceCIDs {
+		for c := range pieces {
+			out = append(out, c)
+		}
+	}
+
+	if cfg.AnnouncePayloadCIDs {
+		deals, err := sp.ListLocalDeals()
+		if err != nil {
+			return nil, xerrors.Errorf("listing local deals: %w", err)
+		}
+
+		roots := make(map[cid.Cid]struct{})
+		for _, deal := range deals {
+			if deal.Ref == nil {
+				continue
+			}
+			if _, ok := pieces[deal.Proposal.PieceCID]; !ok {
+				continue
+			}
+			if _, ok := roots[deal.Ref.Root]; ok {
+				continue
+			}
+			roots[deal.Ref.Root] = struct{}{}
+			out = append(out, deal.Ref.Root)
+		}
+	}
+
+	return out, nil
+}
@@ -1,14 +1,28 @@
 package modules
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"io"
+	"net"
 
+	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-datastore"
 	"github.com/ipfs/go-datastore/namespace"
+	"github.com/ipld/go-ipld-prime"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
 	provider "github.com/ipni/index-provider"
 	"github.com/ipni/index-provider/engine"
+	"github.com/ipni/index-provider/metadata"
+	"github.com/ipni/storetheindex/announce/p2psender"
+	"github.com/ipni/storetheindex/api/v0/ingest/schema"
+	"github.com/ipni/storetheindex/dagsync"
+	"github.com/ipni/storetheindex/dagsync/httpsync"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
 	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
 	"go.uber.org/fx"
 	"golang.org/x/xerrors"
 
@@ -61,6 +75,7 @@ func IndexProvider(cfg config.IndexProviderConfig) func(params IdxProv, marketHo
 			"pid", marketHost.ID(),
 			"topic", topicName,
 			"retAddrs", marketHost.Addrs())
+		var httpPub *httpIdxPublisher
 		// If announcements to the network are enabled, then set options for datatransfer publisher.
 		if cfg.Enable {
 			// Join the indexer topic using the market's pubsub instance. Otherwise, the provider
@@ -76,12 +91,38 @@ func IndexProvider(cfg config.IndexProviderConfig) func(params IdxProv, marketHo
 			// The extra data is required by the lotus-specific index-provider gossip message validators.
 			ma := address.Address(maddr)
 			opts = append(opts,
-				engine.WithPublisherKind(engine.DataTransferPublisher),
-				engine.WithDataTransfer(dt),
 				engine.WithExtraGossipData(ma.Bytes()),
 				engine.WithTopic(t),
 			)
+
+			// Advertisements are always served over data-transfer (graphsync). If enabled, they
+			// are also served over plain HTTP alongside it, see httpIdxPublisher.
+			opts = append(opts,
+				engine.WithPublisherKind(engine.DataTransferPublisher),
+				engine.WithDataTransfer(dt),
+			)
 			llog = llog.With("extraGossipData", ma, "publisher", "data-transfer")
+
+			if cfg.HttpPublisher.Enabled {
+				announceAddr, err := httpPublisherAnnounceAddr(cfg.HttpPublisher)
+				if err != nil {
+					return nil, err
+				}
+				announceMaddr, err := multiaddr.NewMultiaddr(announceAddr)
+				if err != nil {
+					return nil, xerrors.Errorf("parsing index provider http publisher announce address: %w", err)
+				}
+
+				httpPub = &httpIdxPublisher{
+					ds:           ipds,
+					listenAddr:   fmt.Sprintf("0.0.0.0:%d", cfg.HttpPublisher.Port),
+					announceAddr: announceMaddr,
+					host:         marketHost,
+					topic:        t,
+					extraData:    ma.Bytes(),
+				}
+				llog = llog.With("httpAnnounceAddr", announceAddr)
+			}
 		} else {
 			opts = append(opts, engine.WithPublisherKind(engine.NoPublisher))
 			llog = llog.With("publisher", "none")
@@ -94,6 +135,12 @@ func IndexProvider(cfg config.IndexProviderConfig) func(params IdxProv, marketHo
 		}
 		llog.Info("Instantiated index provider engine")
 
+		var idxProv provider.Interface = e
+		if httpPub != nil {
+			httpPub.Interface = e
+			idxProv = httpPub
+		}
+
 		args.Lifecycle.Append(fx.Hook{
 			OnStart: func(ctx context.Context) error {
 				// Note that the OnStart context is cancelled after startup. Its use in e.Start is
@@ -103,15 +150,155 @@ func IndexProvider(cfg config.IndexProviderConfig) func(params IdxProv, marketHo
 					return xerrors.Errorf("starting indexer provider engine: %w", err)
 				}
 				log.Infof("Started index provider engine")
+
+				if httpPub != nil {
+					if err := httpPub.start(ctx); err != nil {
+						return xerrors.Errorf("starting index provider http publisher: %w", err)
+					}
+					log.Infof("Started index provider http publisher")
+				}
 				return nil
 			},
 			OnStop: func(_ context.Context) error {
+				if httpPub != nil {
+					if err := httpPub.close(); err != nil {
+						log.Errorw("closing index provider http publisher", "err", err)
+					}
+				}
 				if err := e.Shutdown(); err != nil {
 					return xerrors.Errorf("shutting down indexer provider engine: %w", err)
 				}
 				return nil
 			},
 		})
-		return e, nil
+		return idxProv, nil
+	}
+}
+
+// httpPublisherAnnounceAddr builds the multiaddr put in announcement messages to tell indexers
+// where to sync advertisements from over HTTP.
+func httpPublisherAnnounceAddr(cfg config.IndexProviderHttpPublisherConfig) (string, error) {
+	if cfg.PublicHostname == "" {
+		return "", xerrors.New("index provider http publisher is enabled but PublicHostname is not set")
+	}
+	if cfg.Port <= 0 || cfg.Port > 65535 {
+		return "", xerrors.Errorf("invalid index provider http publisher port: %d", cfg.Port)
+	}
+
+	proto := "dns"
+	if ip := net.ParseIP(cfg.PublicHostname); ip != nil {
+		proto = "ip6"
+		if ip.To4() != nil {
+			proto = "ip4"
+		}
+	}
+
+	return fmt.Sprintf("/%s/%s/tcp/%d/http", proto, cfg.PublicHostname, cfg.Port), nil
+}
+
+// httpIdxPublisher serves the advertisement chain of the index provider engine over plain HTTP,
+// alongside the data-transfer publisher of the engine. New advertisements published through it
+// are announced over gossipsub with the HTTP address as well, so that indexers can pick either.
+//
+// Advertisements and entries chunks are read from the engine datastore. Entries chunks evicted
+// from the engine entries cache are only served over data-transfer, which regenerates them.
+type httpIdxPublisher struct {
+	provider.Interface
+
+	ds           datastore.Datastore
+	listenAddr   string
+	announceAddr multiaddr.Multiaddr
+	host         host.Host
+	topic        *pubsub.Topic
+	extraData    []byte
+
+	pub dagsync.Publisher
+}
+
+func (p *httpIdxPublisher) start(ctx context.Context) error {
+	sender, err := p2psender.New(p.host, p.topic.String(), p2psender.WithTopic(p.topic))
+	if err != nil {
+		return xerrors.Errorf("creating announce sender: %w", err)
+	}
+
+	p.pub, err = httpsync.NewPublisher(p.listenAddr, httpPublisherLinkSystem(p.ds), p.host.Peerstore().PrivKey(p.host.ID()),
+		httpsync.WithAnnounceSenders(sender),
+		httpsync.WithExtraData(p.extraData))
+	if err != nil {
+		return xerrors.Errorf("creating http publisher: %w", err)
+	}
+
+	latest, _, err := p.GetLatestAdv(ctx)
+	if err != nil {
+		return xerrors.Errorf("getting latest advertisement: %w", err)
+	}
+	if latest != cid.Undef {
+		if err := p.pub.SetRoot(ctx, latest); err != nil {
+			return xerrors.Errorf("setting http publisher root: %w", err)
+		}
+	}
+	return nil
+}
+
+func (p *httpIdxPublisher) close() error {
+	if p.pub == nil {
+		return nil
+	}
+	return p.pub.Close()
+}
+
+// updateRoot makes the advertisement the head of the chain served over HTTP. The advertisement
+// is already published over data-transfer at this point, so failing to announce it over HTTP is
+// only logged.
+func (p *httpIdxPublisher) updateRoot(ctx context.Context, c cid.Cid, err error) (cid.Cid, error) {
+	if err != nil || c == cid.Undef || p.pub == nil {
+		return c, err
+	}
+	if err := p.pub.UpdateRootWithAddrs(ctx, c, []multiaddr.Multiaddr{p.announceAddr}); err != nil {
+		log.Warnw("announcing advertisement published over http", "cid", c, "err", err)
+	}
+	return c, nil
+}
+
+func (p *httpIdxPublisher) Publish(ctx context.Context, adv schema.Advertisement) (cid.Cid, error) {
+	c, err := p.Interface.Publish(ctx, adv)
+	return p.updateRoot(ctx, c, err)
+}
+
+func (p *httpIdxPublisher) NotifyPut(ctx context.Context, prov *peer.AddrInfo, contextID []byte, md metadata.Metadata) (cid.Cid, error) {
+	c, err := p.Interface.NotifyPut(ctx, prov, contextID, md)
+	return p.updateRoot(ctx, c, err)
+}
+
+func (p *httpIdxPublisher) NotifyRemove(ctx context.Context, providerID peer.ID, contextID []byte) (cid.Cid, error) {
+	c, err := p.Interface.NotifyRemove(ctx, providerID, contextID)
+	return p.updateRoot(ctx, c, err)
+}
+
+// httpPublisherLinkSystem reads advertisements, and the entries chunks cached by the engine,
+// from the index provider engine datastore.
+func httpPublisherLinkSystem(ds datastore.Datastore) ipld.LinkSystem {
+	entries := namespace.Wrap(ds, datastore.NewKey("/cache/links"))
+
+	lsys := cidlink.DefaultLinkSystem()
+	lsys.StorageReadOpener = func(lctx ipld.LinkContext, lnk ipld.Link) (io.Reader, error) {
+		ctx := lctx.Ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		key := datastore.NewKey(lnk.(cidlink.Link).Cid.String())
+		val, err := ds.Get(ctx, key)
+		if xerrors.Is(err, datastore.ErrNotFound) {
+			val, err = entries.Get(ctx, key)
+		}
+		if xerrors.Is(err, datastore.ErrNotFound) {
+			return nil, ipld.ErrNotExists{}
+		}
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(val), nil
 	}
+	return lsys
 }
@@ -2,15 +2,21 @@ package modules_test
 
 import (
 	"context"
+	"fmt"
+	"net"
+	"net/http"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/ipfs/go-datastore"
 	provider "github.com/ipni/index-provider"
+	"github.com/ipni/index-provider/metadata"
 	"github.com/libp2p/go-libp2p"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
 	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multihash"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/fx"
 
@@ -97,3 +103,93 @@ func Test_IndexProviderTopic(t *testing.T) {
 		})
 	}
 }
+
+func Test_IndexProviderHttpPublisher(t *testing.T) {
+	tests := []struct {
+		name          string
+		givenHostname string
+		wantErr       string
+	}{
+		{
+			name:          "Serves advertisements over HTTP",
+			givenHostname: "127.0.0.1",
+		},
+		{
+			name:    "Fails without a public hostname",
+			wantErr: "index provider http publisher is enabled but PublicHostname is not set",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			h, err := libp2p.New()
+			require.NoError(t, err)
+			defer func() {
+				require.NoError(t, h.Close())
+			}()
+
+			ps, err := pubsub.NewGossipSub(ctx, h)
+			require.NoError(t, err)
+
+			// find a free port for the publisher to listen on
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			port := l.Addr().(*net.TCPAddr).Port
+			require.NoError(t, l.Close())
+
+			var prov provider.Interface
+			app := fx.New(
+				fx.Provide(
+					func() host.Host { return h },
+					func() dtypes.NetworkName { return "fish" },
+					func() dtypes.MinerAddress { return dtypes.MinerAddress(address.TestAddress) },
+					func() dtypes.ProviderDataTransfer { return nil },
+					func() *pubsub.PubSub { return ps },
+					func() dtypes.MetadataDS { return datastore.NewMapDatastore() },
+					modules.IndexProvider(config.IndexProviderConfig{
+						Enable:           true,
+						EntriesChunkSize: 16384,
+						HttpPublisher: config.IndexProviderHttpPublisherConfig{
+							Enabled:        true,
+							PublicHostname: test.givenHostname,
+							Port:           port,
+						},
+					}),
+				),
+				fx.Invoke(func(p provider.Interface) { prov = p }),
+			)
+			err = app.Start(ctx)
+
+			if test.wantErr == "" {
+				require.NoError(t, err)
+
+				resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/head", port))
+				require.NoError(t, err)
+				require.NoError(t, resp.Body.Close())
+
+				// advertisements published through the provider are served over HTTP too
+				mh, err := multihash.Sum([]byte("fish"), multihash.SHA2_256, -1)
+				require.NoError(t, err)
+				prov.RegisterMultihashLister(func(ctx context.Context, p peer.ID, contextID []byte) (provider.MultihashIterator, error) {
+					return provider.SliceMultihashIterator([]multihash.Multihash{mh}), nil
+				})
+				adCid, err := prov.NotifyPut(ctx, nil, []byte("lobster"), metadata.Default.New(metadata.Bitswap{}))
+				require.NoError(t, err)
+
+				resp, err = http.Get(fmt.Sprintf("http://127.0.0.1:%d/%s", port, adCid))
+				require.NoError(t, err)
+				require.Equal(t, http.StatusOK, resp.StatusCode)
+				require.NoError(t, resp.Body.Close())
+
+				err = app.Stop(ctx)
+				require.NoError(t, err)
+			} else {
+				require.True(t, strings.HasSuffix(err.Error(), test.wantErr))
+			}
+		})
+	}
+}
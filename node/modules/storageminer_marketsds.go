@@ -0,0 +1,127 @@
+package modules
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/lib/sqlds"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+	"github.com/filecoin-project/lotus/node/repo"
+)
+
+// MarketsStateNamespaces are the namespaces holding the state of the provider
+// storage deals, data transfers and retrieval deals.
+var MarketsStateNamespaces = []datastore.Key{
+	datastore.NewKey("/deals/provider"),
+	datastore.NewKey("/datatransfer/provider/transfers"),
+	datastore.NewKey("/retrievals/provider"),
+}
+
+// marketsStateExcluded are the namespaces of the storage ask state kept under
+// the storage deals namespace, which stays in the metadata datastore.
+var marketsStateExcluded = []datastore.Key{
+	datastore.NewKey("/deals/provider/latest-ask"),
+	datastore.NewKey("/deals/provider/storage-ask"),
+	datastore.NewKey("/deals/provider/ask-schedule"),
+	datastore.NewKey("/deals/provider/commp-diagnostics"),
+}
+
+// OpenMarketsSQLDatastore opens the SQL datastore configured to hold the
+// markets state.
+func OpenMarketsSQLDatastore(cfg config.DealmakingDatastoreConfig, repoPath string) (*sqlds.Datastore, error) {
+	switch cfg.Backend {
+	case config.MarketsDatastoreSQLite:
+		path := cfg.SQLitePath
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(repoPath, path)
+		}
+		return sqlds.Open(sqlds.DriverSQLite, path, cfg.Table)
+	case config.MarketsDatastorePostgres:
+		if cfg.PostgresURL == "" {
+			return nil, xerrors.Errorf("Dealmaking.Datastore.PostgresURL must be set for the postgres backend")
+		}
+		return sqlds.Open(sqlds.DriverPostgres, cfg.PostgresURL, cfg.Table)
+	default:
+		return nil, xerrors.Errorf("markets datastore backend '%s' isn't an sql backend", cfg.Backend)
+	}
+}
+
+// MarketsDatastore returns the datastore holding the markets state: the
+// metadata datastore, or the configured SQL datastore.
+func MarketsDatastore(cfg config.DealmakingDatastoreConfig) func(lc fx.Lifecycle, r repo.LockedRepo, ds dtypes.MetadataDS) (dtypes.MarketsDS, error) {
+	return func(lc fx.Lifecycle, r repo.LockedRepo, ds dtypes.MetadataDS) (dtypes.MarketsDS, error) {
+		if cfg.Backend == "" || cfg.Backend == config.MarketsDatastoreMetadata {
+			return ds, nil
+		}
+
+		sds, err := OpenMarketsSQLDatastore(cfg, r.Path())
+		if err != nil {
+			return nil, xerrors.Errorf("opening markets datastore: %w", err)
+		}
+
+		lc.Append(fx.Hook{
+			OnStop: func(context.Context) error {
+				return sds.Close()
+			},
+		})
+		return sds, nil
+	}
+}
+
+// CopyMarketsState copies the markets state between datastores, and returns
+// the number of copied keys. Keys of the destination are overwritten, but
+// keys missing from the source aren't removed.
+func CopyMarketsState(ctx context.Context, from, to datastore.Batching) (int, error) {
+	b, err := to.Batch(ctx)
+	if err != nil {
+		return 0, xerrors.Errorf("creating batch: %w", err)
+	}
+
+	n := 0
+	for _, ns := range MarketsStateNamespaces {
+		res, err := from.Query(ctx, query.Query{Prefix: ns.String()})
+		if err != nil {
+			return 0, xerrors.Errorf("querying %s: %w", ns, err)
+		}
+
+		for r := range res.Next() {
+			if r.Error != nil {
+				_ = res.Close()
+				return 0, xerrors.Errorf("reading %s: %w", ns, r.Error)
+			}
+
+			k := datastore.NewKey(r.Key)
+			if excludedMarketsState(k) {
+				continue
+			}
+			if err := b.Put(ctx, k, r.Value); err != nil {
+				_ = res.Close()
+				return 0, xerrors.Errorf("copying %s: %w", k, err)
+			}
+			n++
+		}
+		if err := res.Close(); err != nil {
+			return 0, xerrors.Errorf("closing %s query: %w", ns, err)
+		}
+	}
+
+	if err := b.Commit(ctx); err != nil {
+		return 0, xerrors.Errorf("committing copied state: %w", err)
+	}
+	return n, nil
+}
+
+func excludedMarketsState(k datastore.Key) bool {
+	for _, ex := range marketsStateExcluded {
+		if k.Equal(ex) || ex.IsAncestorOf(k) {
+			return true
+		}
+	}
+	return false
+}
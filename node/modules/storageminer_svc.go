@@ -35,14 +35,11 @@ func connectMinerService(apiInfo string) func(mctx helpers.MetricsCtx, lc fx.Lif
 		}
 		lc.Append(fx.Hook{
 			OnStart: func(ctx context.Context) error {
-				v, err := mapi.Version(ctx)
+				caps, err := client.NegotiateCommon(ctx, mapi, api.MinerAPIVersion0)
 				if err != nil {
-					return xerrors.Errorf("checking version: %w", err)
-				}
-
-				if !v.APIVersion.EqMajorMinor(api.MinerAPIVersion0) {
-					return xerrors.Errorf("remote service API version didn't match (expected %s, remote %s)", api.MinerAPIVersion0, v.APIVersion)
+					return xerrors.Errorf("checking remote service version: %w", err)
 				}
+				client.WarnDeprecated(caps, mapi)
 
 				return nil
 			},
@@ -0,0 +1,48 @@
+package modules
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/fx"
+
+	"github.com/filecoin-project/lotus/chain/events"
+	"github.com/filecoin-project/lotus/chain/webhooks"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+	"github.com/filecoin-project/lotus/node/modules/helpers"
+)
+
+func Webhooks(cfg config.WebhooksConfig) func(helpers.MetricsCtx, fx.Lifecycle, dtypes.MetadataDS, EventAPI) (*webhooks.Manager, error) {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, ds dtypes.MetadataDS, evapi EventAPI) (*webhooks.Manager, error) {
+		ctx := helpers.LifecycleCtx(mctx, lc)
+
+		m, err := webhooks.NewManager(ctx, &evapi, ds, webhooks.Config{
+			MaxAttempts: cfg.MaxAttempts,
+			MinBackoff:  time.Duration(cfg.MinBackoff),
+			MaxBackoff:  time.Duration(cfg.MaxBackoff),
+			Timeout:     time.Duration(cfg.RequestTimeout),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				ev, err := events.NewEvents(ctx, &evapi)
+				if err != nil {
+					return err
+				}
+				_ = ev.Observe(m)
+
+				m.Start()
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				return m.Close()
+			},
+		})
+
+		return m, nil
+	}
+}
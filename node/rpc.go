@@ -33,6 +33,7 @@ import (
 	"github.com/filecoin-project/lotus/metrics/proxy"
 	"github.com/filecoin-project/lotus/node/impl"
 	"github.com/filecoin-project/lotus/node/impl/client"
+	"github.com/filecoin-project/lotus/node/tenancy"
 )
 
 var rpclog = logging.Logger("rpc")
@@ -73,25 +74,29 @@ func ServeRPC(h http.Handler, id string, addr multiaddr.Multiaddr) (StopFunc, er
 // FullNodeHandler returns a full node handler, to be mounted as-is on the server.
 func FullNodeHandler(a v1api.FullNode, permissioned bool, opts ...jsonrpc.ServerOption) (http.Handler, error) {
 	m := mux.NewRouter()
+	fullNode := a.(*impl.FullNodeAPI)
 
 	serveRpc := func(path string, hnd interface{}) {
 		rpcServer := jsonrpc.NewServer(append(opts, jsonrpc.WithReverseClient[api.EthSubscriberMethods]("Filecoin"), jsonrpc.WithServerErrors(api.RPCErrors))...)
 		rpcServer.Register("Filecoin", hnd)
 		rpcServer.AliasMethod("rpc.discover", "Filecoin.Discover")
 
-		api.CreateEthRPCAliases(rpcServer)
+		api.CreateEthRPCAliases(api.FilterEthNamespaces(rpcServer, fullNode.DisabledEthNamespaces))
 
 		var handler http.Handler = rpcServer
 		if permissioned {
-			handler = &auth.Handler{Verify: a.AuthVerify, Next: rpcServer.ServeHTTP}
+			handler = &tenancy.Handler{Verify: fullNode.AuthVerifyToken, Next: rpcServer.ServeHTTP}
 		}
 
 		m.Handle(path, handler)
 	}
 
 	fnapi := proxy.MetricedFullAPI(a)
+	if fullNode.ReadOnly {
+		fnapi = api.ReadOnlyFullAPI(fnapi)
+	}
 	if permissioned {
-		fnapi = api.PermissionedFullAPI(fnapi)
+		fnapi = api.PermissionedFullAPI(tenancy.MethodScopedFullAPI(tenancy.ScopedFullAPI(fnapi, fullNode.Tenants)))
 	}
 
 	var v0 v0api.FullNode = &(struct{ v0api.FullNode }{&v0api.WrapperV1Full{FullNode: fnapi}})
@@ -99,23 +104,30 @@ func FullNodeHandler(a v1api.FullNode, permissioned bool, opts ...jsonrpc.Server
 	serveRpc("/rpc/v0", v0)
 
 	// Import handler
-	handleImportFunc := handleImport(a.(*impl.FullNodeAPI))
-	handleExportFunc := handleExport(a.(*impl.FullNodeAPI))
-	handleRemoteStoreFunc := handleRemoteStore(a.(*impl.FullNodeAPI))
+	handleImportFunc := handleImport(fullNode)
+	handleExportFunc := handleExport(fullNode)
+	handleRemoteStoreFunc := handleRemoteStore(fullNode)
+	if fullNode.ReadOnly {
+		// imports and remote stores write to the node
+		handleImportFunc = handleReadOnly
+		handleRemoteStoreFunc = handleReadOnly
+	}
 	if permissioned {
-		importAH := &auth.Handler{
-			Verify: a.AuthVerify,
+		// the REST endpoints check the scope of the token like the methods
+		// they stand for
+		importAH := &tenancy.Handler{
+			Verify: fullNode.AuthVerifyToken,
 			Next:   handleImportFunc,
 		}
 		m.Handle("/rest/v0/import", importAH)
-		exportAH := &auth.Handler{
-			Verify: a.AuthVerify,
+		exportAH := &tenancy.Handler{
+			Verify: fullNode.AuthVerifyToken,
 			Next:   handleExportFunc,
 		}
 		m.Handle("/rest/v0/export", exportAH)
 
-		storeAH := &auth.Handler{
-			Verify: a.AuthVerify,
+		storeAH := &tenancy.Handler{
+			Verify: fullNode.AuthVerifyToken,
 			Next:   handleRemoteStoreFunc,
 		}
 		m.Handle("/rest/v0/store/{uuid}", storeAH)
@@ -140,7 +152,12 @@ func FullNodeHandler(a v1api.FullNode, permissioned bool, opts ...jsonrpc.Server
 
 // MinerHandler returns a miner handler, to be mounted as-is on the server.
 func MinerHandler(a api.StorageMiner, permissioned bool) (http.Handler, error) {
+	minerNode := a.(*impl.StorageMinerAPI)
+
 	mapi := proxy.MetricedStorMinerAPI(a)
+	if minerNode.ReadOnly {
+		mapi = api.ReadOnlyStorMinerAPI(mapi)
+	}
 	if permissioned {
 		mapi = api.PermissionedStorMinerAPI(mapi)
 	}
@@ -155,7 +172,12 @@ func MinerHandler(a api.StorageMiner, permissioned bool) (http.Handler, error) {
 	// remote storage
 	{
 		m := mux.NewRouter()
-		m.PathPrefix("/remote").HandlerFunc(a.(*impl.StorageMinerAPI).ServeRemote(permissioned))
+		remote := minerNode.ServeRemote(permissioned)
+		if minerNode.ReadOnly {
+			// the remote storage keeps serving sectors, but doesn't remove them
+			remote = rejectWrites(remote)
+		}
+		m.PathPrefix("/remote").HandlerFunc(remote)
 
 		var hnd http.Handler = m
 		if permissioned {
@@ -191,6 +213,37 @@ func MinerHandler(a api.StorageMiner, permissioned bool) (http.Handler, error) {
 	return rootMux, nil
 }
 
+// handleReadOnly rejects the requests to the REST endpoints which aren't
+// served in read-only mode.
+func handleReadOnly(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(403)
+	_ = json.NewEncoder(w).Encode(struct{ Error string }{(&api.ErrReadOnly{}).Error()})
+}
+
+// rejectWrites wraps a handler, rejecting the DELETE, POST and PUT requests
+// like handleReadOnly.
+func rejectWrites(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodDelete, http.MethodPost, http.MethodPut:
+			handleReadOnly(w, r)
+		default:
+			next(w, r)
+		}
+	}
+}
+
+// checkScope rejects the request when the scope of its token doesn't allow
+// invoking the API method the REST endpoint stands for.
+func checkScope(w http.ResponseWriter, r *http.Request, method string) bool {
+	if tenancy.MethodAllowed(r.Context(), method) {
+		return true
+	}
+	w.WriteHeader(401)
+	_ = json.NewEncoder(w).Encode(struct{ Error string }{fmt.Sprintf("unauthorized: the scope of the token doesn't allow invoking '%s'", method)})
+	return false
+}
+
 func handleImport(a *impl.FullNodeAPI) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != "PUT" {
@@ -202,6 +255,9 @@ func handleImport(a *impl.FullNodeAPI) func(w http.ResponseWriter, r *http.Reque
 			_ = json.NewEncoder(w).Encode(struct{ Error string }{"unauthorized: missing write permission"})
 			return
 		}
+		if !checkScope(w, r, "ClientImport") {
+			return
+		}
 
 		c, err := a.ClientImportLocal(r.Context(), r.Body)
 		if err != nil {
@@ -229,6 +285,9 @@ func handleExport(a *impl.FullNodeAPI) func(w http.ResponseWriter, r *http.Reque
 			_ = json.NewEncoder(w).Encode(struct{ Error string }{"unauthorized: missing write permission"})
 			return
 		}
+		if !checkScope(w, r, "ClientExport") {
+			return
+		}
 
 		var eref api.ExportRef
 		if err := json.Unmarshal([]byte(r.FormValue("export")), &eref); err != nil {
@@ -281,6 +340,11 @@ var upgrader = websocket.Upgrader{
 
 func handleRemoteStore(a *impl.FullNodeAPI) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
+		// the remote stores are only used to retrieve into
+		if !checkScope(w, r, "ClientRetrieve") {
+			return
+		}
+
 		vars := mux.Vars(r)
 		id, err := uuid.Parse(vars["uuid"])
 		if err != nil {
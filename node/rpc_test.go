@@ -0,0 +1,28 @@
+// stm: #unit
+package node
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRejectWrites(t *testing.T) {
+	hnd := rejectWrites(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for method, code := range map[string]int{
+		http.MethodGet:    http.StatusOK,
+		http.MethodHead:   http.StatusOK,
+		http.MethodDelete: http.StatusForbidden,
+		http.MethodPost:   http.StatusForbidden,
+		http.MethodPut:    http.StatusForbidden,
+	} {
+		rec := httptest.NewRecorder()
+		hnd(rec, httptest.NewRequest(method, "/remote/sealed/s-t01000-1", nil))
+		require.Equal(t, code, rec.Code, method)
+	}
+}
@@ -1,15 +1,21 @@
 package node
 
 import (
-	"context"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
+
+	"github.com/filecoin-project/lotus/lib/shutdown"
 )
 
 type ShutdownHandler struct {
 	Component string
 	StopFunc  StopFunc
+
+	// Grace is how long the component is given to stop. When zero, the grace
+	// period configured for the component on the coordinator applies.
+	Grace time.Duration
 }
 
 // MonitorShutdown manages shutdown requests, by watching signals and invoking
@@ -22,6 +28,13 @@ type ShutdownHandler struct {
 // Once the shutdown has completed, it closes the returned channel. The caller
 // can watch this channel
 func MonitorShutdown(triggerCh <-chan struct{}, handlers ...ShutdownHandler) <-chan struct{} {
+	return MonitorShutdownWith(shutdown.NewCoordinator(), triggerCh, handlers...)
+}
+
+// MonitorShutdownWith is like MonitorShutdown, and stops the components with
+// the coordinator, which reports the progress of the shutdown and the tasks
+// holding it.
+func MonitorShutdownWith(c *shutdown.Coordinator, triggerCh <-chan struct{}, handlers ...ShutdownHandler) <-chan struct{} {
 	sigCh := make(chan os.Signal, 2)
 	out := make(chan struct{})
 
@@ -35,14 +48,15 @@ func MonitorShutdown(triggerCh <-chan struct{}, handlers ...ShutdownHandler) <-c
 
 		log.Warn("Shutting down...")
 
-		// Call all the handlers, logging on failure and success.
-		for _, h := range handlers {
-			if err := h.StopFunc(context.TODO()); err != nil {
-				log.Errorf("shutting down %s failed: %s", h.Component, err)
-				continue
+		hs := make([]shutdown.Handler, len(handlers))
+		for i, h := range handlers {
+			hs[i] = shutdown.Handler{
+				Component: h.Component,
+				Stop:      h.StopFunc,
+				Grace:     h.Grace,
 			}
-			log.Infof("%s shut down successfully ", h.Component)
 		}
+		c.Run(hs)
 
 		log.Warn("Graceful shutdown successful")
 
@@ -0,0 +1,54 @@
+package tenancy
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/filecoin-project/go-jsonrpc/auth"
+)
+
+// Handler authenticates the requests like the go-jsonrpc auth handler, and
+// binds the requests to the namespace and scope of their token.
+type Handler struct {
+	Verify func(ctx context.Context, token string) (Token, error)
+	Next   http.HandlerFunc
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	token := r.Header.Get("Authorization")
+	if token == "" {
+		token = r.FormValue("token")
+		if token != "" {
+			token = "Bearer " + token
+		}
+	}
+
+	if token != "" {
+		if !strings.HasPrefix(token, "Bearer ") {
+			log.Warn("missing Bearer prefix in auth header")
+			w.WriteHeader(401)
+			return
+		}
+		token = strings.TrimPrefix(token, "Bearer ")
+
+		t, err := h.Verify(ctx, token)
+		if err != nil {
+			log.Warnf("JWT Verification failed (originating from %s): %s", r.RemoteAddr, err)
+			w.WriteHeader(401)
+			return
+		}
+
+		ctx = auth.WithPerm(ctx, t.Perms)
+		if t.Namespace != "" {
+			ctx = WithNamespace(ctx, t.Namespace)
+		}
+		if t.Scope != nil {
+			ctx = WithScope(ctx, t.Scope)
+		}
+	}
+
+	h.Next(w, r.WithContext(ctx))
+}
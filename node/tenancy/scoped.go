@@ -0,0 +1,595 @@
+package tenancy
+
+import (
+	"context"
+
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
+	"github.com/filecoin-project/go-state-types/builtin/v8/paych"
+	verifregtypes "github.com/filecoin-project/go-state-types/builtin/v9/verifreg"
+	"github.com/filecoin-project/go-state-types/crypto"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// ScopedFullAPI wraps the FullNode API, scoping the requests bound to a
+// namespace to the wallet keys and deals of the namespace, and the requests
+// made with a token scoped to wallet keys to these keys. The other requests
+// are passed through.
+//
+// Namespaced tokens are never granted the admin permission, so only the
+// methods up to the sign permission need to be scoped for them. The tokens
+// scoped to wallet keys can have it, so the methods needing the admin
+// permission which use a wallet key are scoped too.
+func ScopedFullAPI(a api.FullNode, reg *Registry) api.FullNode {
+	return &scopedFullNode{FullNode: a, reg: reg}
+}
+
+type scopedFullNode struct {
+	api.FullNode
+
+	reg *Registry
+}
+
+// scopeWallets returns the wallet keys the token of the request is scoped to,
+// if any.
+func scopeWallets(ctx context.Context) []address.Address {
+	if scope := Scope(ctx); scope != nil {
+		return scope.Wallets
+	}
+	return nil
+}
+
+// restricted returns whether the wallet keys the request can use are
+// restricted.
+func restricted(ctx context.Context) bool {
+	return Namespace(ctx) != "" || len(scopeWallets(ctx)) > 0
+}
+
+// canUse returns whether the request can use addr, or the key address of an
+// ID address: it must be a wallet key of the namespace the request is bound
+// to, and one of the wallet keys its token is scoped to.
+func (s *scopedFullNode) canUse(ctx context.Context, addr address.Address) (bool, error) {
+	if !restricted(ctx) {
+		return true, nil
+	}
+
+	ok, err := s.allowed(ctx, addr)
+	if err != nil || ok {
+		return ok, err
+	}
+
+	if addr.Protocol() == address.ID {
+		key, err := s.FullNode.StateAccountKey(ctx, addr, types.EmptyTSK)
+		if err == nil {
+			return s.allowed(ctx, key)
+		}
+	}
+	return false, nil
+}
+
+func (s *scopedFullNode) allowed(ctx context.Context, addr address.Address) (bool, error) {
+	if wallets := scopeWallets(ctx); len(wallets) > 0 {
+		found := false
+		for _, w := range wallets {
+			if w == addr {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false, nil
+		}
+	}
+
+	ns := Namespace(ctx)
+	if ns == "" {
+		return true, nil
+	}
+	owner, err := s.reg.Owner(ctx, addr)
+	if err != nil {
+		return false, err
+	}
+	return owner == ns, nil
+}
+
+// checkWallet checks that the request can use addr.
+func (s *scopedFullNode) checkWallet(ctx context.Context, addr address.Address) error {
+	ok, err := s.canUse(ctx, addr)
+	if err != nil || ok {
+		return err
+	}
+	if ns := Namespace(ctx); ns != "" {
+		return xerrors.Errorf("address %s isn't a wallet of namespace %q", addr, ns)
+	}
+	return xerrors.Errorf("address %s isn't a wallet of the token scope", addr)
+}
+
+// checkChannel checks that the payment channel is controlled by a wallet key
+// the request can use.
+func (s *scopedFullNode) checkChannel(ctx context.Context, ch address.Address) error {
+	if !restricted(ctx) {
+		return nil
+	}
+
+	st, err := s.FullNode.PaychStatus(ctx, ch)
+	if err != nil {
+		return err
+	}
+	return s.checkWallet(ctx, st.ControlAddr)
+}
+
+func (s *scopedFullNode) WalletNew(ctx context.Context, kt types.KeyType) (address.Address, error) {
+	ns := Namespace(ctx)
+	addr, err := s.FullNode.WalletNew(ctx, kt)
+	if err != nil || ns == "" {
+		return addr, err
+	}
+	if err := s.reg.Assign(ctx, addr, ns); err != nil {
+		return address.Undef, err
+	}
+	log.Infow("created wallet key in namespace", "address", addr, "namespace", ns)
+	return addr, nil
+}
+
+func (s *scopedFullNode) WalletHas(ctx context.Context, addr address.Address) (bool, error) {
+	if restricted(ctx) {
+		ok, err := s.allowed(ctx, addr)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return s.FullNode.WalletHas(ctx, addr)
+}
+
+func (s *scopedFullNode) WalletList(ctx context.Context) ([]address.Address, error) {
+	addrs, err := s.namespaceWallets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	wallets := scopeWallets(ctx)
+	if len(wallets) == 0 {
+		return addrs, nil
+	}
+	out := make([]address.Address, 0, len(addrs))
+	for _, addr := range addrs {
+		for _, w := range wallets {
+			if w == addr {
+				out = append(out, addr)
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+func (s *scopedFullNode) namespaceWallets(ctx context.Context) ([]address.Address, error) {
+	ns := Namespace(ctx)
+	if ns == "" {
+		return s.FullNode.WalletList(ctx)
+	}
+
+	addrs, err := s.reg.Wallets(ctx, ns)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]address.Address, 0, len(addrs))
+	for _, addr := range addrs {
+		// the key may have been deleted from the wallet
+		has, err := s.FullNode.WalletHas(ctx, addr)
+		if err != nil {
+			return nil, err
+		}
+		if has {
+			out = append(out, addr)
+		}
+	}
+	return out, nil
+}
+
+func (s *scopedFullNode) WalletSign(ctx context.Context, addr address.Address, data []byte) (*crypto.Signature, error) {
+	if err := s.checkWallet(ctx, addr); err != nil {
+		return nil, err
+	}
+	return s.FullNode.WalletSign(ctx, addr, data)
+}
+
+func (s *scopedFullNode) WalletSignMessage(ctx context.Context, addr address.Address, msg *types.Message) (*types.SignedMessage, error) {
+	if err := s.checkWallet(ctx, addr); err != nil {
+		return nil, err
+	}
+	return s.FullNode.WalletSignMessage(ctx, addr, msg)
+}
+
+func (s *scopedFullNode) WalletExport(ctx context.Context, addr address.Address) (*types.KeyInfo, error) {
+	if err := s.checkWallet(ctx, addr); err != nil {
+		return nil, err
+	}
+	return s.FullNode.WalletExport(ctx, addr)
+}
+
+func (s *scopedFullNode) WalletDelete(ctx context.Context, addr address.Address) error {
+	if err := s.checkWallet(ctx, addr); err != nil {
+		return err
+	}
+	return s.FullNode.WalletDelete(ctx, addr)
+}
+
+func (s *scopedFullNode) WalletDefaultAddress(ctx context.Context) (address.Address, error) {
+	addr, err := s.namespaceDefault(ctx)
+	if err != nil {
+		return address.Undef, err
+	}
+	if err := s.checkWallet(ctx, addr); err != nil {
+		return address.Undef, xerrors.Errorf("failed to get default key: %w", err)
+	}
+	return addr, nil
+}
+
+func (s *scopedFullNode) namespaceDefault(ctx context.Context) (address.Address, error) {
+	ns := Namespace(ctx)
+	if ns == "" {
+		return s.FullNode.WalletDefaultAddress(ctx)
+	}
+
+	addr, err := s.reg.Default(ctx, ns)
+	if err != nil {
+		return address.Undef, err
+	}
+	if addr == address.Undef {
+		return address.Undef, xerrors.Errorf("failed to get default key: namespace %q has no default wallet", ns)
+	}
+	return addr, nil
+}
+
+func (s *scopedFullNode) WalletSetDefault(ctx context.Context, addr address.Address) error {
+	if err := s.checkWallet(ctx, addr); err != nil {
+		return err
+	}
+
+	ns := Namespace(ctx)
+	if ns == "" {
+		return s.FullNode.WalletSetDefault(ctx, addr)
+	}
+	return s.reg.SetDefault(ctx, ns, addr)
+}
+
+func (s *scopedFullNode) MpoolPushMessage(ctx context.Context, msg *types.Message, spec *api.MessageSendSpec) (*types.SignedMessage, error) {
+	if err := s.checkWallet(ctx, msg.From); err != nil {
+		return nil, err
+	}
+	return s.FullNode.MpoolPushMessage(ctx, msg, spec)
+}
+
+func (s *scopedFullNode) MpoolBatchPushMessage(ctx context.Context, msgs []*types.Message, spec *api.MessageSendSpec) ([]*types.SignedMessage, error) {
+	for _, msg := range msgs {
+		if err := s.checkWallet(ctx, msg.From); err != nil {
+			return nil, err
+		}
+	}
+	return s.FullNode.MpoolBatchPushMessage(ctx, msgs, spec)
+}
+
+func (s *scopedFullNode) MpoolPredictCid(ctx context.Context, msg *types.Message, key address.Address) (cid.Cid, error) {
+	if err := s.checkWallet(ctx, key); err != nil {
+		return cid.Undef, err
+	}
+	return s.FullNode.MpoolPredictCid(ctx, msg, key)
+}
+
+func (s *scopedFullNode) MpoolReplace(ctx context.Context, spec api.MpoolReplaceSpec) (*api.MpoolReplaceResult, error) {
+	if restricted(ctx) {
+		// the replacing message is signed with the key of the sender of the
+		// replaced one
+		from := spec.From
+		if spec.Message != nil {
+			pending, err := s.FullNode.MpoolPending(ctx, types.EmptyTSK)
+			if err != nil {
+				return nil, err
+			}
+			from = address.Undef
+			for _, p := range pending {
+				if p.Cid() == *spec.Message || p.Message.Cid() == *spec.Message {
+					from = p.Message.From
+					break
+				}
+			}
+			if from == address.Undef {
+				return nil, xerrors.Errorf("no pending message found with cid %s", spec.Message)
+			}
+		}
+		if err := s.checkWallet(ctx, from); err != nil {
+			return nil, err
+		}
+	}
+	return s.FullNode.MpoolReplace(ctx, spec)
+}
+
+func (s *scopedFullNode) MpoolClear(ctx context.Context, clearLocal bool) error {
+	if ns := Namespace(ctx); ns != "" {
+		// the local messages of all the namespaces would be cleared
+		return xerrors.Errorf("clearing the mpool isn't allowed in namespace %q", ns)
+	}
+	if len(scopeWallets(ctx)) > 0 {
+		return xerrors.Errorf("clearing the mpool isn't allowed with a token scoped to wallet keys")
+	}
+	return s.FullNode.MpoolClear(ctx, clearLocal)
+}
+
+func (s *scopedFullNode) ClientStartDeal(ctx context.Context, params *api.StartDealParams) (*cid.Cid, error) {
+	if err := s.checkWallet(ctx, params.Wallet); err != nil {
+		return nil, err
+	}
+	return s.FullNode.ClientStartDeal(ctx, params)
+}
+
+func (s *scopedFullNode) ClientReplicate(ctx context.Context, params *api.StartDealParams, n int, sel api.ProviderSelector) (*api.ReplicationStatus, error) {
+	if err := s.checkWallet(ctx, params.Wallet); err != nil {
+		return nil, err
+	}
+	return s.FullNode.ClientReplicate(ctx, params, n, sel)
+}
+
+func (s *scopedFullNode) ClientRetrieve(ctx context.Context, params api.RetrievalOrder) (*api.RestrievalRes, error) {
+	if err := s.checkWallet(ctx, params.Client); err != nil {
+		return nil, err
+	}
+
+	res, err := s.FullNode.ClientRetrieve(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	// resuming the retrieval pays with the same key
+	if err := s.reg.AddRetrieval(ctx, res.DealID, params.Client); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *scopedFullNode) ClientRetrievalResume(ctx context.Context, dealID retrievalmarket.DealID) (*api.RestrievalRes, error) {
+	client, err := s.reg.RetrievalClient(ctx, dealID)
+	if err != nil {
+		return nil, err
+	}
+	if restricted(ctx) {
+		if client == address.Undef {
+			return nil, xerrors.Errorf("the wallet key paying for retrieval %d is unknown", dealID)
+		}
+		if err := s.checkWallet(ctx, client); err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := s.FullNode.ClientRetrievalResume(ctx, dealID)
+	if err != nil || client == address.Undef {
+		return res, err
+	}
+	if err := s.reg.AddRetrieval(ctx, res.DealID, client); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *scopedFullNode) ClientRetrieveTryRestartInsufficientFunds(ctx context.Context, paymentChannel address.Address) error {
+	if err := s.checkChannel(ctx, paymentChannel); err != nil {
+		return err
+	}
+	return s.FullNode.ClientRetrieveTryRestartInsufficientFunds(ctx, paymentChannel)
+}
+
+func (s *scopedFullNode) ClientStatelessDeal(ctx context.Context, params *api.StartDealParams) (*cid.Cid, error) {
+	if err := s.checkWallet(ctx, params.Wallet); err != nil {
+		return nil, err
+	}
+
+	ns := Namespace(ctx)
+	proposal, err := s.FullNode.ClientStatelessDeal(ctx, params)
+	if err != nil || ns == "" {
+		return proposal, err
+	}
+	if err := s.reg.AddDeal(ctx, ns, *proposal); err != nil {
+		return nil, err
+	}
+	return proposal, nil
+}
+
+func (s *scopedFullNode) ClientGetDealInfo(ctx context.Context, proposal cid.Cid) (*api.DealInfo, error) {
+	if ns := Namespace(ctx); ns != "" {
+		owner, err := s.reg.DealOwner(ctx, proposal)
+		if err != nil {
+			return nil, err
+		}
+		if owner != ns {
+			return nil, xerrors.Errorf("deal %s not found in namespace %q", proposal, ns)
+		}
+	}
+	return s.FullNode.ClientGetDealInfo(ctx, proposal)
+}
+
+func (s *scopedFullNode) ClientListDeals(ctx context.Context) ([]api.DealInfo, error) {
+	ns := Namespace(ctx)
+	deals, err := s.FullNode.ClientListDeals(ctx)
+	if err != nil || ns == "" {
+		return deals, err
+	}
+
+	out := make([]api.DealInfo, 0, len(deals))
+	for _, d := range deals {
+		owner, err := s.reg.DealOwner(ctx, d.ProposalCid)
+		if err != nil {
+			return nil, err
+		}
+		if owner == ns {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+func (s *scopedFullNode) ClientGetDealUpdates(ctx context.Context) (<-chan api.DealInfo, error) {
+	ns := Namespace(ctx)
+	updates, err := s.FullNode.ClientGetDealUpdates(ctx)
+	if err != nil || ns == "" {
+		return updates, err
+	}
+
+	out := make(chan api.DealInfo)
+	go func() {
+		defer close(out)
+		for d := range updates {
+			owner, err := s.reg.DealOwner(ctx, d.ProposalCid)
+			if err != nil {
+				log.Errorw("getting namespace of deal", "deal", d.ProposalCid, "error", err)
+				continue
+			}
+			if owner != ns {
+				continue
+			}
+			select {
+			case out <- d:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (s *scopedFullNode) ClientListImports(ctx context.Context) ([]api.Import, error) {
+	if Namespace(ctx) != "" {
+		// imports need the admin permission, tenants have none
+		return []api.Import{}, nil
+	}
+	return s.FullNode.ClientListImports(ctx)
+}
+
+func (s *scopedFullNode) ClientListRetrievals(ctx context.Context) ([]api.RetrievalInfo, error) {
+	if Namespace(ctx) != "" {
+		// retrievals need the admin permission, tenants have none
+		return []api.RetrievalInfo{}, nil
+	}
+	return s.FullNode.ClientListRetrievals(ctx)
+}
+
+func (s *scopedFullNode) ClientListDataTransfers(ctx context.Context) ([]api.DataTransferChannel, error) {
+	ns := Namespace(ctx)
+	if ns == "" {
+		return s.FullNode.ClientListDataTransfers(ctx)
+	}
+
+	// the data transfers of the deals of the namespace
+	deals, err := s.ClientListDeals(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := []api.DataTransferChannel{}
+	for _, d := range deals {
+		if d.DataTransfer != nil {
+			out = append(out, *d.DataTransfer)
+		}
+	}
+	return out, nil
+}
+
+func (s *scopedFullNode) ClientCreateAllocations(ctx context.Context, client address.Address, reqs []verifregtypes.AllocationRequest) (cid.Cid, error) {
+	if err := s.checkWallet(ctx, client); err != nil {
+		return cid.Undef, err
+	}
+	return s.FullNode.ClientCreateAllocations(ctx, client, reqs)
+}
+
+func (s *scopedFullNode) ClientExtendClaims(ctx context.Context, client address.Address, terms []verifregtypes.ClaimTerm) (cid.Cid, error) {
+	if err := s.checkWallet(ctx, client); err != nil {
+		return cid.Undef, err
+	}
+	return s.FullNode.ClientExtendClaims(ctx, client, terms)
+}
+
+func (s *scopedFullNode) MarketAddBalance(ctx context.Context, wallet, addr address.Address, amt types.BigInt) (cid.Cid, error) {
+	if err := s.checkWallet(ctx, wallet); err != nil {
+		return cid.Undef, err
+	}
+	return s.FullNode.MarketAddBalance(ctx, wallet, addr, amt)
+}
+
+func (s *scopedFullNode) MarketReserveFunds(ctx context.Context, wallet address.Address, addr address.Address, amt types.BigInt) (cid.Cid, error) {
+	if err := s.checkWallet(ctx, wallet); err != nil {
+		return cid.Undef, err
+	}
+	return s.FullNode.MarketReserveFunds(ctx, wallet, addr, amt)
+}
+
+func (s *scopedFullNode) MarketReleaseFunds(ctx context.Context, addr address.Address, amt types.BigInt) error {
+	if err := s.checkWallet(ctx, addr); err != nil {
+		return err
+	}
+	return s.FullNode.MarketReleaseFunds(ctx, addr, amt)
+}
+
+func (s *scopedFullNode) MarketWithdraw(ctx context.Context, wallet, addr address.Address, amt types.BigInt) (cid.Cid, error) {
+	if err := s.checkWallet(ctx, wallet); err != nil {
+		return cid.Undef, err
+	}
+	return s.FullNode.MarketWithdraw(ctx, wallet, addr, amt)
+}
+
+func (s *scopedFullNode) PaychGet(ctx context.Context, from, to address.Address, amt types.BigInt, opts api.PaychGetOpts) (*api.ChannelInfo, error) {
+	if err := s.checkWallet(ctx, from); err != nil {
+		return nil, err
+	}
+	return s.FullNode.PaychGet(ctx, from, to, amt, opts)
+}
+
+func (s *scopedFullNode) PaychFund(ctx context.Context, from, to address.Address, amt types.BigInt) (*api.ChannelInfo, error) {
+	if err := s.checkWallet(ctx, from); err != nil {
+		return nil, err
+	}
+	return s.FullNode.PaychFund(ctx, from, to, amt)
+}
+
+func (s *scopedFullNode) PaychNewPayment(ctx context.Context, from, to address.Address, vouchers []api.VoucherSpec) (*api.PaymentInfo, error) {
+	if err := s.checkWallet(ctx, from); err != nil {
+		return nil, err
+	}
+	return s.FullNode.PaychNewPayment(ctx, from, to, vouchers)
+}
+
+func (s *scopedFullNode) PaychSettle(ctx context.Context, ch address.Address) (cid.Cid, error) {
+	if err := s.checkChannel(ctx, ch); err != nil {
+		return cid.Undef, err
+	}
+	return s.FullNode.PaychSettle(ctx, ch)
+}
+
+func (s *scopedFullNode) PaychCollect(ctx context.Context, ch address.Address) (cid.Cid, error) {
+	if err := s.checkChannel(ctx, ch); err != nil {
+		return cid.Undef, err
+	}
+	return s.FullNode.PaychCollect(ctx, ch)
+}
+
+func (s *scopedFullNode) PaychAllocateLane(ctx context.Context, ch address.Address) (uint64, error) {
+	if err := s.checkChannel(ctx, ch); err != nil {
+		return 0, err
+	}
+	return s.FullNode.PaychAllocateLane(ctx, ch)
+}
+
+func (s *scopedFullNode) PaychVoucherCreate(ctx context.Context, ch address.Address, amt types.BigInt, lane uint64) (*api.VoucherCreateResult, error) {
+	if err := s.checkChannel(ctx, ch); err != nil {
+		return nil, err
+	}
+	return s.FullNode.PaychVoucherCreate(ctx, ch, amt, lane)
+}
+
+func (s *scopedFullNode) PaychVoucherSubmit(ctx context.Context, ch address.Address, sv *paych.SignedVoucher, secret []byte, proof []byte) (cid.Cid, error) {
+	if err := s.checkChannel(ctx, ch); err != nil {
+		return cid.Undef, err
+	}
+	return s.FullNode.PaychVoucherSubmit(ctx, ch, sv, secret, proof)
+}
@@ -0,0 +1,266 @@
+// Package tenancy scopes the FullNode API to namespaces, so that a node can
+// serve several tenants. API tokens can be bound to a namespace, and the
+// requests authenticated with such tokens only see and use the wallet keys
+// assigned to the namespace, and the market client deals they started.
+package tenancy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+)
+
+var log = logging.Logger("tenancy")
+
+type namespaceKey struct{}
+
+// WithNamespace binds the requests made with ctx to the namespace.
+func WithNamespace(ctx context.Context, ns string) context.Context {
+	return context.WithValue(ctx, namespaceKey{}, ns)
+}
+
+// Namespace returns the namespace bound to ctx, or an empty string when the
+// request isn't scoped to a namespace.
+func Namespace(ctx context.Context) string {
+	ns, _ := ctx.Value(namespaceKey{}).(string)
+	return ns
+}
+
+// ValidateNamespace checks that ns can be used as a namespace name.
+func ValidateNamespace(ns string) error {
+	if ns == "" {
+		return xerrors.Errorf("empty namespace")
+	}
+	for _, r := range ns {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return xerrors.Errorf("invalid namespace %q: only letters, digits, '-', '_' and '.' are allowed", ns)
+		}
+	}
+	return nil
+}
+
+var (
+	walletsPrefix  = ds.NewKey("/wallets")
+	defaultsPrefix = ds.NewKey("/defaults")
+	dealsPrefix    = ds.NewKey("/deals")
+	retrievalsKey  = ds.NewKey("/retrievals")
+)
+
+// Registry records the namespace of the wallet keys and of the deals started
+// by the tenants.
+type Registry struct {
+	ds ds.Datastore
+
+	lk sync.Mutex
+}
+
+func NewRegistry(mds dtypes.MetadataDS) *Registry {
+	return &Registry{
+		ds: namespace.Wrap(mds, ds.NewKey("/tenancy")),
+	}
+}
+
+// Assign assigns the wallet key to the namespace, or removes it from its
+// namespace when ns is empty.
+func (r *Registry) Assign(ctx context.Context, addr address.Address, ns string) error {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	key := walletsPrefix.ChildString(addr.String())
+	if ns == "" {
+		old, err := r.owner(ctx, addr)
+		if err != nil {
+			return err
+		}
+		if old != "" {
+			if def, err := r.defaultAddr(ctx, old); err == nil && def == addr {
+				if err := r.ds.Delete(ctx, defaultsPrefix.ChildString(old)); err != nil {
+					return xerrors.Errorf("removing default wallet of namespace %q: %w", old, err)
+				}
+			}
+		}
+		if err := r.ds.Delete(ctx, key); err != nil {
+			return xerrors.Errorf("removing wallet %s from its namespace: %w", addr, err)
+		}
+		return nil
+	}
+
+	if err := ValidateNamespace(ns); err != nil {
+		return err
+	}
+	if err := r.ds.Put(ctx, key, []byte(ns)); err != nil {
+		return xerrors.Errorf("assigning wallet %s to namespace %q: %w", addr, ns, err)
+	}
+	return nil
+}
+
+// Owner returns the namespace of the wallet key, or an empty string when it
+// isn't assigned to any.
+func (r *Registry) Owner(ctx context.Context, addr address.Address) (string, error) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	return r.owner(ctx, addr)
+}
+
+func (r *Registry) owner(ctx context.Context, addr address.Address) (string, error) {
+	ns, err := r.ds.Get(ctx, walletsPrefix.ChildString(addr.String()))
+	switch {
+	case xerrors.Is(err, ds.ErrNotFound):
+		return "", nil
+	case err != nil:
+		return "", xerrors.Errorf("getting namespace of wallet %s: %w", addr, err)
+	}
+	return string(ns), nil
+}
+
+// Tenants returns the namespaces with their wallet keys, ordered by name.
+func (r *Registry) Tenants(ctx context.Context) ([]api.Tenant, error) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	res, err := r.ds.Query(ctx, query.Query{Prefix: walletsPrefix.String()})
+	if err != nil {
+		return nil, xerrors.Errorf("querying wallets: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	tenants := map[string]*api.Tenant{}
+	for e := range res.Next() {
+		if e.Error != nil {
+			return nil, xerrors.Errorf("iterating wallets: %w", e.Error)
+		}
+		addr, err := address.NewFromString(strings.TrimPrefix(e.Key, walletsPrefix.String()+"/"))
+		if err != nil {
+			return nil, xerrors.Errorf("parsing wallet key %s: %w", e.Key, err)
+		}
+
+		ns := string(e.Value)
+		t, ok := tenants[ns]
+		if !ok {
+			t = &api.Tenant{Namespace: ns}
+			if t.Default, err = r.defaultAddr(ctx, ns); err != nil {
+				return nil, err
+			}
+			tenants[ns] = t
+		}
+		t.Wallets = append(t.Wallets, addr)
+	}
+
+	out := make([]api.Tenant, 0, len(tenants))
+	for _, t := range tenants {
+		sort.Slice(t.Wallets, func(i, j int) bool { return t.Wallets[i].String() < t.Wallets[j].String() })
+		out = append(out, *t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Namespace < out[j].Namespace })
+	return out, nil
+}
+
+// Wallets returns the wallet keys of the namespace.
+func (r *Registry) Wallets(ctx context.Context, ns string) ([]address.Address, error) {
+	tenants, err := r.Tenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tenants {
+		if t.Namespace == ns {
+			return t.Wallets, nil
+		}
+	}
+	return []address.Address{}, nil
+}
+
+// Default returns the default wallet key of the namespace, or address.Undef
+// when it has none.
+func (r *Registry) Default(ctx context.Context, ns string) (address.Address, error) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	return r.defaultAddr(ctx, ns)
+}
+
+func (r *Registry) defaultAddr(ctx context.Context, ns string) (address.Address, error) {
+	b, err := r.ds.Get(ctx, defaultsPrefix.ChildString(ns))
+	switch {
+	case xerrors.Is(err, ds.ErrNotFound):
+		return address.Undef, nil
+	case err != nil:
+		return address.Undef, xerrors.Errorf("getting default wallet of namespace %q: %w", ns, err)
+	}
+	return address.NewFromBytes(b)
+}
+
+// SetDefault sets the default wallet key of the namespace, which must be
+// assigned to it.
+func (r *Registry) SetDefault(ctx context.Context, ns string, addr address.Address) error {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	owner, err := r.owner(ctx, addr)
+	if err != nil {
+		return err
+	}
+	if owner != ns {
+		return xerrors.Errorf("wallet %s isn't assigned to namespace %q", addr, ns)
+	}
+
+	if err := r.ds.Put(ctx, defaultsPrefix.ChildString(ns), addr.Bytes()); err != nil {
+		return xerrors.Errorf("setting default wallet of namespace %q: %w", ns, err)
+	}
+	return nil
+}
+
+// AddDeal records that the deal was started by the namespace.
+func (r *Registry) AddDeal(ctx context.Context, ns string, proposal cid.Cid) error {
+	if err := r.ds.Put(ctx, dealsPrefix.ChildString(proposal.String()), []byte(ns)); err != nil {
+		return xerrors.Errorf("recording deal %s of namespace %q: %w", proposal, ns, err)
+	}
+	return nil
+}
+
+// DealOwner returns the namespace which started the deal, or an empty string
+// when the deal wasn't started by a tenant.
+func (r *Registry) DealOwner(ctx context.Context, proposal cid.Cid) (string, error) {
+	ns, err := r.ds.Get(ctx, dealsPrefix.ChildString(proposal.String()))
+	switch {
+	case xerrors.Is(err, ds.ErrNotFound):
+		return "", nil
+	case err != nil:
+		return "", xerrors.Errorf("getting namespace of deal %s: %w", proposal, err)
+	}
+	return string(ns), nil
+}
+
+// AddRetrieval records the wallet key paying for the retrieval.
+func (r *Registry) AddRetrieval(ctx context.Context, id retrievalmarket.DealID, client address.Address) error {
+	if err := r.ds.Put(ctx, retrievalsKey.ChildString(fmt.Sprint(id)), client.Bytes()); err != nil {
+		return xerrors.Errorf("recording client of retrieval %d: %w", id, err)
+	}
+	return nil
+}
+
+// RetrievalClient returns the wallet key paying for the retrieval, or
+// address.Undef when the retrieval wasn't recorded.
+func (r *Registry) RetrievalClient(ctx context.Context, id retrievalmarket.DealID) (address.Address, error) {
+	b, err := r.ds.Get(ctx, retrievalsKey.ChildString(fmt.Sprint(id)))
+	switch {
+	case xerrors.Is(err, ds.ErrNotFound):
+		return address.Undef, nil
+	case err != nil:
+		return address.Undef, xerrors.Errorf("getting client of retrieval %d: %w", id, err)
+	}
+	return address.NewFromBytes(b)
+}
@@ -0,0 +1,361 @@
+// stm: #unit
+package tenancy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
+	"github.com/filecoin-project/go-state-types/crypto"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+type testFullNode struct {
+	api.FullNode
+
+	wallets []address.Address
+	deals   []api.DealInfo
+	pending []*types.SignedMessage
+	nextID  uint64
+
+	nextRetrieval retrievalmarket.DealID
+}
+
+func (tn *testFullNode) WalletNew(context.Context, types.KeyType) (address.Address, error) {
+	tn.nextID++
+	addr, err := address.NewIDAddress(1000 + tn.nextID)
+	if err != nil {
+		return address.Undef, err
+	}
+	tn.wallets = append(tn.wallets, addr)
+	return addr, nil
+}
+
+func (tn *testFullNode) WalletHas(_ context.Context, addr address.Address) (bool, error) {
+	for _, w := range tn.wallets {
+		if w == addr {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (tn *testFullNode) WalletList(context.Context) ([]address.Address, error) {
+	return tn.wallets, nil
+}
+
+func (tn *testFullNode) WalletSign(context.Context, address.Address, []byte) (*crypto.Signature, error) {
+	return &crypto.Signature{Type: crypto.SigTypeSecp256k1}, nil
+}
+
+func (tn *testFullNode) StateAccountKey(context.Context, address.Address, types.TipSetKey) (address.Address, error) {
+	return address.Undef, xerrors.Errorf("actor not found")
+}
+
+func (tn *testFullNode) ClientStatelessDeal(_ context.Context, params *api.StartDealParams) (*cid.Cid, error) {
+	proposal, err := cid.V1Builder{Codec: cid.Raw, MhType: 0x12}.Sum([]byte(params.Wallet.String()))
+	if err != nil {
+		return nil, err
+	}
+	tn.deals = append(tn.deals, api.DealInfo{ProposalCid: proposal})
+	return &proposal, nil
+}
+
+func (tn *testFullNode) ClientStartDeal(ctx context.Context, params *api.StartDealParams) (*cid.Cid, error) {
+	return tn.ClientStatelessDeal(ctx, params)
+}
+
+func (tn *testFullNode) ClientReplicate(context.Context, *api.StartDealParams, int, api.ProviderSelector) (*api.ReplicationStatus, error) {
+	return &api.ReplicationStatus{}, nil
+}
+
+func (tn *testFullNode) ClientRetrieve(context.Context, api.RetrievalOrder) (*api.RestrievalRes, error) {
+	tn.nextRetrieval++
+	return &api.RestrievalRes{DealID: tn.nextRetrieval}, nil
+}
+
+func (tn *testFullNode) ClientRetrievalResume(context.Context, retrievalmarket.DealID) (*api.RestrievalRes, error) {
+	tn.nextRetrieval++
+	return &api.RestrievalRes{DealID: tn.nextRetrieval}, nil
+}
+
+func (tn *testFullNode) MpoolPending(context.Context, types.TipSetKey) ([]*types.SignedMessage, error) {
+	return tn.pending, nil
+}
+
+func (tn *testFullNode) MpoolReplace(context.Context, api.MpoolReplaceSpec) (*api.MpoolReplaceResult, error) {
+	return &api.MpoolReplaceResult{}, nil
+}
+
+func (tn *testFullNode) MpoolPredictCid(_ context.Context, msg *types.Message, _ address.Address) (cid.Cid, error) {
+	return msg.Cid(), nil
+}
+
+func (tn *testFullNode) ClientListDeals(context.Context) ([]api.DealInfo, error) {
+	return tn.deals, nil
+}
+
+func TestRegistry(t *testing.T) {
+	ctx := context.Background()
+	reg := NewRegistry(dssync.MutexWrap(ds.NewMapDatastore()))
+
+	a1, err := address.NewIDAddress(1)
+	require.NoError(t, err)
+	a2, err := address.NewIDAddress(2)
+	require.NoError(t, err)
+
+	require.Error(t, reg.Assign(ctx, a1, "bad/namespace"))
+	require.NoError(t, reg.Assign(ctx, a1, "alice"))
+	require.NoError(t, reg.Assign(ctx, a2, "bob"))
+
+	// only the keys of the namespace can be made default
+	require.Error(t, reg.SetDefault(ctx, "alice", a2))
+	require.NoError(t, reg.SetDefault(ctx, "alice", a1))
+
+	tenants, err := reg.Tenants(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []api.Tenant{
+		{Namespace: "alice", Wallets: []address.Address{a1}, Default: a1},
+		{Namespace: "bob", Wallets: []address.Address{a2}},
+	}, tenants)
+
+	// unassigning the key clears the default of the namespace
+	require.NoError(t, reg.Assign(ctx, a1, ""))
+	owner, err := reg.Owner(ctx, a1)
+	require.NoError(t, err)
+	require.Empty(t, owner)
+	def, err := reg.Default(ctx, "alice")
+	require.NoError(t, err)
+	require.Equal(t, address.Undef, def)
+}
+
+func TestScopedFullAPI(t *testing.T) {
+	ctx := context.Background()
+	aliceCtx := WithNamespace(ctx, "alice")
+	bobCtx := WithNamespace(ctx, "bob")
+
+	tn := &testFullNode{}
+	reg := NewRegistry(dssync.MutexWrap(ds.NewMapDatastore()))
+	scoped := ScopedFullAPI(tn, reg)
+
+	node, err := scoped.WalletNew(ctx, types.KTSecp256k1)
+	require.NoError(t, err)
+	alice, err := scoped.WalletNew(aliceCtx, types.KTSecp256k1)
+	require.NoError(t, err)
+	bob, err := scoped.WalletNew(bobCtx, types.KTSecp256k1)
+	require.NoError(t, err)
+
+	// the requests without a namespace see all the keys
+	list, err := scoped.WalletList(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []address.Address{node, alice, bob}, list)
+
+	list, err = scoped.WalletList(aliceCtx)
+	require.NoError(t, err)
+	require.Equal(t, []address.Address{alice}, list)
+
+	has, err := scoped.WalletHas(aliceCtx, bob)
+	require.NoError(t, err)
+	require.False(t, has)
+
+	_, err = scoped.WalletSign(aliceCtx, alice, []byte("data"))
+	require.NoError(t, err)
+	_, err = scoped.WalletSign(aliceCtx, bob, []byte("data"))
+	require.Error(t, err)
+	_, err = scoped.WalletSign(ctx, bob, []byte("data"))
+	require.NoError(t, err)
+
+	_, err = scoped.MpoolPushMessage(aliceCtx, &types.Message{From: node}, nil)
+	require.Error(t, err)
+	require.Error(t, scoped.MpoolClear(aliceCtx, true))
+
+	// the default key is per namespace
+	_, err = scoped.WalletDefaultAddress(aliceCtx)
+	require.Error(t, err)
+	require.Error(t, scoped.WalletSetDefault(aliceCtx, bob))
+	require.NoError(t, scoped.WalletSetDefault(aliceCtx, alice))
+	def, err := scoped.WalletDefaultAddress(aliceCtx)
+	require.NoError(t, err)
+	require.Equal(t, alice, def)
+
+	// the tenants only see their own deals
+	_, err = scoped.ClientStatelessDeal(aliceCtx, &api.StartDealParams{Wallet: bob})
+	require.Error(t, err)
+	aliceDeal, err := scoped.ClientStatelessDeal(aliceCtx, &api.StartDealParams{Wallet: alice})
+	require.NoError(t, err)
+	_, err = scoped.ClientStatelessDeal(bobCtx, &api.StartDealParams{Wallet: bob})
+	require.NoError(t, err)
+
+	deals, err := scoped.ClientListDeals(aliceCtx)
+	require.NoError(t, err)
+	require.Len(t, deals, 1)
+	require.Equal(t, *aliceDeal, deals[0].ProposalCid)
+
+	deals, err = scoped.ClientListDeals(ctx)
+	require.NoError(t, err)
+	require.Len(t, deals, 2)
+}
+
+func TestTokenScope(t *testing.T) {
+	ctx := context.Background()
+
+	tn := &testFullNode{}
+	reg := NewRegistry(dssync.MutexWrap(ds.NewMapDatastore()))
+	scoped := MethodScopedFullAPI(ScopedFullAPI(tn, reg))
+
+	w1, err := scoped.WalletNew(ctx, types.KTSecp256k1)
+	require.NoError(t, err)
+	w2, err := scoped.WalletNew(ctx, types.KTSecp256k1)
+	require.NoError(t, err)
+
+	botCtx := WithScope(ctx, &api.AuthScope{
+		Methods: []string{"WalletList", "WalletSign", "MpoolPushMessage"},
+		Wallets: []address.Address{w2},
+	})
+
+	// the token only sees and uses the wallet keys of its scope
+	list, err := scoped.WalletList(botCtx)
+	require.NoError(t, err)
+	require.Equal(t, []address.Address{w2}, list)
+
+	_, err = scoped.WalletSign(botCtx, w2, []byte("data"))
+	require.NoError(t, err)
+	_, err = scoped.WalletSign(botCtx, w1, []byte("data"))
+	require.Error(t, err)
+	_, err = scoped.MpoolPushMessage(botCtx, &types.Message{From: w1}, nil)
+	require.Error(t, err)
+
+	// and only calls the methods of its scope
+	_, err = scoped.WalletHas(botCtx, w2)
+	require.ErrorContains(t, err, "doesn't allow invoking 'WalletHas'")
+	_, err = scoped.ClientStatelessDeal(botCtx, &api.StartDealParams{Wallet: w2})
+	require.Error(t, err)
+
+	// the REST endpoints are checked against the methods they stand for
+	require.False(t, MethodAllowed(botCtx, "ClientImport"))
+	require.True(t, MethodAllowed(ctx, "ClientImport"))
+
+	// a scope without methods allows all of them
+	walletCtx := WithScope(ctx, &api.AuthScope{Wallets: []address.Address{w2}})
+	has, err := scoped.WalletHas(walletCtx, w1)
+	require.NoError(t, err)
+	require.False(t, has)
+	require.Error(t, scoped.MpoolClear(walletCtx, true))
+
+	// the requests without a scope are passed through
+	_, err = scoped.WalletSign(ctx, w1, []byte("data"))
+	require.NoError(t, err)
+
+	require.NoError(t, ValidateScope(api.AuthScope{Methods: []string{"ChainHead", "AuthVerify"}}))
+	require.Error(t, ValidateScope(api.AuthScope{Methods: []string{"NoSuchMethod"}}))
+	require.Error(t, ValidateScope(api.AuthScope{Wallets: []address.Address{w1}}))
+}
+
+func TestScopedMpoolReplace(t *testing.T) {
+	ctx := context.Background()
+	aliceCtx := WithNamespace(ctx, "alice")
+	bobCtx := WithNamespace(ctx, "bob")
+
+	tn := &testFullNode{}
+	reg := NewRegistry(dssync.MutexWrap(ds.NewMapDatastore()))
+	scoped := ScopedFullAPI(tn, reg)
+
+	alice, err := scoped.WalletNew(aliceCtx, types.KTSecp256k1)
+	require.NoError(t, err)
+	bob, err := scoped.WalletNew(bobCtx, types.KTSecp256k1)
+	require.NoError(t, err)
+
+	bobMsg := &types.SignedMessage{
+		Message: types.Message{
+			To:         alice,
+			From:       bob,
+			Nonce:      3,
+			Value:      types.NewInt(0),
+			GasFeeCap:  types.NewInt(100),
+			GasPremium: types.NewInt(100),
+		},
+		Signature: crypto.Signature{Type: crypto.SigTypeSecp256k1},
+	}
+	tn.pending = append(tn.pending, bobMsg)
+	bobCid := bobMsg.Cid()
+
+	// the tenants can't replace, and so re-sign, the messages of the others
+	_, err = scoped.MpoolReplace(aliceCtx, api.MpoolReplaceSpec{From: bob, Nonce: 3})
+	require.Error(t, err)
+	_, err = scoped.MpoolReplace(aliceCtx, api.MpoolReplaceSpec{Message: &bobCid})
+	require.Error(t, err)
+	_, err = scoped.MpoolReplace(bobCtx, api.MpoolReplaceSpec{Message: &bobCid})
+	require.NoError(t, err)
+	_, err = scoped.MpoolReplace(ctx, api.MpoolReplaceSpec{From: bob, Nonce: 3})
+	require.NoError(t, err)
+
+	// nor sign messages with their keys to predict the CID
+	_, err = scoped.MpoolPredictCid(aliceCtx, &bobMsg.Message, bob)
+	require.Error(t, err)
+	_, err = scoped.MpoolPredictCid(aliceCtx, &types.Message{To: bob, From: alice, Value: types.NewInt(0), GasFeeCap: types.NewInt(0), GasPremium: types.NewInt(0)}, alice)
+	require.NoError(t, err)
+
+	// the same goes for the tokens scoped to wallet keys
+	walletCtx := WithScope(ctx, &api.AuthScope{Wallets: []address.Address{alice}})
+	_, err = scoped.MpoolReplace(walletCtx, api.MpoolReplaceSpec{Message: &bobCid})
+	require.Error(t, err)
+	_, err = scoped.MpoolPredictCid(walletCtx, &bobMsg.Message, bob)
+	require.Error(t, err)
+}
+
+func TestScopedAdminMethods(t *testing.T) {
+	ctx := context.Background()
+
+	tn := &testFullNode{}
+	reg := NewRegistry(dssync.MutexWrap(ds.NewMapDatastore()))
+	scoped := ScopedFullAPI(tn, reg)
+
+	w1, err := scoped.WalletNew(ctx, types.KTSecp256k1)
+	require.NoError(t, err)
+	w2, err := scoped.WalletNew(ctx, types.KTSecp256k1)
+	require.NoError(t, err)
+
+	// the tokens scoped to wallet keys can have the admin permission, they
+	// can't spend from the other keys with the admin methods
+	walletCtx := WithScope(ctx, &api.AuthScope{Wallets: []address.Address{w2}})
+
+	_, err = scoped.ClientStartDeal(walletCtx, &api.StartDealParams{Wallet: w1})
+	require.Error(t, err)
+	_, err = scoped.ClientStartDeal(walletCtx, &api.StartDealParams{Wallet: w2})
+	require.NoError(t, err)
+
+	_, err = scoped.ClientReplicate(walletCtx, &api.StartDealParams{Wallet: w1}, 2, api.ProviderSelector{})
+	require.Error(t, err)
+	_, err = scoped.ClientReplicate(walletCtx, &api.StartDealParams{Wallet: w2}, 2, api.ProviderSelector{})
+	require.NoError(t, err)
+
+	_, err = scoped.ClientRetrieve(walletCtx, api.RetrievalOrder{Client: w1})
+	require.Error(t, err)
+	own, err := scoped.ClientRetrieve(walletCtx, api.RetrievalOrder{Client: w2})
+	require.NoError(t, err)
+	other, err := scoped.ClientRetrieve(ctx, api.RetrievalOrder{Client: w1})
+	require.NoError(t, err)
+
+	// resuming a retrieval pays with the key of the resumed one
+	_, err = scoped.ClientRetrievalResume(walletCtx, other.DealID)
+	require.Error(t, err)
+	resumed, err := scoped.ClientRetrievalResume(walletCtx, own.DealID)
+	require.NoError(t, err)
+	_, err = scoped.ClientRetrievalResume(walletCtx, resumed.DealID)
+	require.NoError(t, err)
+
+	// the key of the retrievals which weren't recorded is unknown
+	_, err = scoped.ClientRetrievalResume(walletCtx, 100)
+	require.Error(t, err)
+	_, err = scoped.ClientRetrievalResume(ctx, 100)
+	require.NoError(t, err)
+}
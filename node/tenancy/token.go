@@ -0,0 +1,117 @@
+package tenancy
+
+import (
+	"context"
+	"reflect"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-jsonrpc/auth"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+// Token is what an API token grants.
+type Token struct {
+	Perms []auth.Permission
+	// Namespace is set for tokens bound to a namespace
+	Namespace string
+	// Scope is set for tokens created with a scope
+	Scope *api.AuthScope
+}
+
+type scopeKey struct{}
+
+// WithScope restricts the requests made with ctx to the scope.
+func WithScope(ctx context.Context, scope *api.AuthScope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope)
+}
+
+// Scope returns the scope the requests made with ctx are restricted to, or nil
+// when they aren't.
+func Scope(ctx context.Context) *api.AuthScope {
+	scope, _ := ctx.Value(scopeKey{}).(*api.AuthScope)
+	return scope
+}
+
+// ValidateScope checks that the methods of the scope are FullNode methods,
+// and that its wallets are key addresses.
+func ValidateScope(scope api.AuthScope) error {
+	methods := map[string]struct{}{}
+	var out api.FullNodeStruct
+	for _, o := range api.GetInternalStructs(&out) {
+		rt := reflect.TypeOf(o).Elem()
+		for f := 0; f < rt.NumField(); f++ {
+			methods[rt.Field(f).Name] = struct{}{}
+		}
+	}
+
+	for _, m := range scope.Methods {
+		if _, ok := methods[m]; !ok {
+			return xerrors.Errorf("unknown method %q", m)
+		}
+	}
+	for _, w := range scope.Wallets {
+		if w.Protocol() == address.ID || w.Protocol() == address.Actor {
+			return xerrors.Errorf("wallet %s isn't a key address", w)
+		}
+	}
+	return nil
+}
+
+// MethodScopedFullAPI wraps the FullNode API, rejecting the calls of the
+// requests restricted to a scope to the methods which aren't in the scope.
+func MethodScopedFullAPI(a api.FullNode) api.FullNode {
+	var out api.FullNodeStruct
+	for _, o := range api.GetInternalStructs(&out) {
+		methodScopedProxy(a, o)
+	}
+	return &out
+}
+
+func methodScopedProxy(in interface{}, out interface{}) {
+	rint := reflect.ValueOf(out).Elem()
+	ra := reflect.ValueOf(in)
+
+	for f := 0; f < rint.NumField(); f++ {
+		field := rint.Type().Field(f)
+		fn := ra.MethodByName(field.Name)
+
+		rint.Field(f).Set(reflect.MakeFunc(field.Type, func(args []reflect.Value) (results []reflect.Value) {
+			ctx := args[0].Interface().(context.Context)
+			if methodAllowed(Scope(ctx), field.Name) {
+				return fn.Call(args)
+			}
+
+			err := xerrors.Errorf("the scope of the token doesn't allow invoking '%s'", field.Name)
+			rerr := reflect.ValueOf(&err).Elem()
+
+			if field.Type.NumOut() == 2 {
+				return []reflect.Value{
+					reflect.Zero(field.Type.Out(0)),
+					rerr,
+				}
+			}
+			return []reflect.Value{rerr}
+		}))
+	}
+}
+
+// MethodAllowed returns whether the scope of the request allows invoking the
+// method, for the endpoints served outside of the JSON-RPC API.
+func MethodAllowed(ctx context.Context, method string) bool {
+	return methodAllowed(Scope(ctx), method)
+}
+
+func methodAllowed(scope *api.AuthScope, method string) bool {
+	if scope == nil || len(scope.Methods) == 0 {
+		return true
+	}
+	for _, m := range scope.Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
@@ -24,6 +24,12 @@ if [ -z "$DEVNET" ]; then
   DEVNET="yes"
 fi
 
+# the 2k build supports 2KiB and 8MiB sectors; 8MiB sectors fit realistic
+# pieces while still sealing quickly
+if [ -z "$SECTOR_SIZE" ]; then
+  SECTOR_SIZE="2KiB"
+fi
+
 BASEDIR=$(mktemp -d -t "lotus-interopnet.XXXX")
 
 if [ "$BUILD" == "yes" ]; then
@@ -65,7 +71,7 @@ cat > "${BASEDIR}/scripts/create_miner.bash" <<EOF
 set -x
 
 lotus wallet import --as-default ~/.genesis-sectors/pre-seal-t01000.key
-lotus-miner init --genesis-miner --actor=t01000 --sector-size=2KiB --pre-sealed-sectors=~/.genesis-sectors --pre-sealed-metadata=~/.genesis-sectors/pre-seal-t01000.json --nosync
+lotus-miner init --genesis-miner --actor=t01000 --sector-size=${SECTOR_SIZE} --pre-sealed-sectors=~/.genesis-sectors --pre-sealed-metadata=~/.genesis-sectors/pre-seal-t01000.json --nosync
 EOF
 
 cat > "${BASEDIR}/scripts/pledge_sectors.bash" <<EOF
@@ -178,7 +184,7 @@ tmux send-keys -t $session:$wcli      "source ${BASEDIR}/scripts/env.$shell" C-m
 tmux send-keys -t $session:$wpledging "source ${BASEDIR}/scripts/env.$shell" C-m
 tmux send-keys -t $session:$wshell "source ${BASEDIR}/scripts/env.$shell" C-m
 
-tmux send-keys -t $session:$wdaemon "lotus-seed pre-seal --sector-size 2KiB --num-sectors 2" C-m
+tmux send-keys -t $session:$wdaemon "lotus-seed pre-seal --sector-size ${SECTOR_SIZE} --num-sectors 2" C-m
 tmux send-keys -t $session:$wdaemon "lotus-seed genesis new devnet.json" C-m
 tmux send-keys -t $session:$wdaemon "lotus-seed genesis add-miner devnet.json ~/.genesis-sectors/pre-seal-t01000.json" C-m
 tmux send-keys -t $session:$wdaemon "lotus daemon --api 48010 --lotus-make-genesis=dev.gen --genesis-template=devnet.json --bootstrap=false 2>&1 | tee -a ${BASEDIR}/daemon.log" C-m
@@ -0,0 +1,453 @@
+package ctladdr
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/journal/alerting"
+)
+
+const (
+	// refillWindow is the window the daily refill cap applies to
+	refillWindow = 24 * time.Hour
+	// refillTimeout is how long a refill message which isn't found on chain
+	// is considered pending, after which it's assumed to be dropped
+	refillTimeout = time.Hour
+)
+
+var refillsKey = datastore.NewKey("/refills")
+
+type BalanceMonitorApi interface {
+	NodeApi
+
+	StateMinerInfo(context.Context, address.Address, types.TipSetKey) (api.MinerInfo, error)
+	StateSearchMsg(ctx context.Context, from types.TipSetKey, msg cid.Cid, limit abi.ChainEpoch, allowReplaced bool) (*api.MsgLookup, error)
+	MpoolPushMessage(context.Context, *types.Message, *api.MessageSendSpec) (*types.SignedMessage, error)
+}
+
+type BalanceMonitorConfig struct {
+	// CheckInterval is how often the balances are checked.
+	CheckInterval time.Duration
+	// LowWater is the balance under which an alert is raised for an address.
+	LowWater abi.TokenAmount
+	// RefillTo is the balance the addresses under LowWater are refilled to
+	// from the owner address. Zero disables refills.
+	RefillTo abi.TokenAmount
+	// DailyRefillCap is the most sent by the refills of any 24 hours.
+	DailyRefillCap abi.TokenAmount
+	// MaxRefillFee is the maximum fee of a refill message.
+	MaxRefillFee abi.TokenAmount
+}
+
+// BalanceMonitor periodically checks the balances of the worker and control
+// addresses of a miner, raises an alert for each address under the low-water
+// mark, and refills them from the owner address within a daily cap.
+type BalanceMonitor struct {
+	api   BalanceMonitorApi
+	maddr address.Address
+	as    *AddressSelector
+	ds    datastore.Batching
+	cfg   BalanceMonitorConfig
+
+	al     *alerting.Alerting
+	alerts map[address.Address]alerting.AlertType
+
+	lk      sync.Mutex
+	status  api.AddressBalanceStatus
+	refills []api.AddressRefill
+
+	closing chan struct{}
+	closed  chan struct{}
+}
+
+// NewBalanceMonitor creates a monitor recording its refills in the datastore,
+// so that the daily cap holds across restarts. The address selector provides
+// the control addresses configured for the messages of the miner, and the
+// alerting system is optional.
+func NewBalanceMonitor(a BalanceMonitorApi, maddr address.Address, as *AddressSelector, ds datastore.Batching, al *alerting.Alerting, cfg BalanceMonitorConfig) (*BalanceMonitor, error) {
+	m := &BalanceMonitor{
+		api:     a,
+		maddr:   maddr,
+		as:      as,
+		ds:      ds,
+		cfg:     cfg,
+		al:      al,
+		alerts:  map[address.Address]alerting.AlertType{},
+		closing: make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+
+	b, err := ds.Get(context.TODO(), refillsKey)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(b, &m.refills); err != nil {
+			return nil, xerrors.Errorf("decoding refills: %w", err)
+		}
+	case xerrors.Is(err, datastore.ErrNotFound):
+	default:
+		return nil, xerrors.Errorf("loading refills: %w", err)
+	}
+
+	return m, nil
+}
+
+// Start starts checking the balances.
+func (m *BalanceMonitor) Start() {
+	go m.run()
+}
+
+func (m *BalanceMonitor) Stop(ctx context.Context) error {
+	close(m.closing)
+
+	select {
+	case <-m.closed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m *BalanceMonitor) run() {
+	defer close(m.closed)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-m.closing
+		cancel()
+	}()
+
+	ticker := time.NewTicker(m.cfg.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		err := m.check(ctx)
+		if err != nil {
+			log.Errorw("checking address balances", "error", err)
+		}
+
+		m.lk.Lock()
+		m.status.Checked = time.Now()
+		m.status.Error = ""
+		if err != nil {
+			m.status.Error = err.Error()
+		}
+		m.lk.Unlock()
+
+		select {
+		case <-ticker.C:
+		case <-m.closing:
+			return
+		}
+	}
+}
+
+// Status returns the result of the last check, and the refills of the last 24
+// hours.
+func (m *BalanceMonitor) Status() api.AddressBalanceStatus {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	st := m.status
+	st.LowWater = m.cfg.LowWater
+	st.RefillTo = m.cfg.RefillTo
+	st.DailyRefillCap = m.cfg.DailyRefillCap
+	st.Refilled = m.refilled(time.Now())
+	st.Addresses = append([]api.AddressBalance{}, m.status.Addresses...)
+	st.Refills = append([]api.AddressRefill{}, m.refills...)
+	return st
+}
+
+func (m *BalanceMonitor) check(ctx context.Context) error {
+	mi, err := m.api.StateMinerInfo(ctx, m.maddr, types.EmptyTSK)
+	if err != nil {
+		return xerrors.Errorf("getting miner info: %w", err)
+	}
+
+	addrs, err := m.addresses(ctx, mi)
+	if err != nil {
+		return err
+	}
+
+	m.lk.Lock()
+	m.status.Owner = mi.Owner
+	m.lk.Unlock()
+
+	if err := m.updateRefills(ctx); err != nil {
+		return err
+	}
+
+	for i := range addrs {
+		ab := &addrs[i]
+
+		ab.Balance, err = m.api.WalletBalance(ctx, ab.Address)
+		if err != nil {
+			return xerrors.Errorf("getting balance of %s: %w", ab.Address, err)
+		}
+		ab.Low = ab.Balance.LessThan(m.cfg.LowWater)
+		ab.RefillPending = m.pendingRefill(ab.Address)
+
+		var refillErr error
+		if ab.Low && ab.RefillPending == cid.Undef && m.cfg.RefillTo.GreaterThan(ab.Balance) {
+			ab.RefillPending, refillErr = m.refill(ctx, mi.Owner, ab.Address, big.Sub(m.cfg.RefillTo, ab.Balance))
+			if refillErr != nil {
+				log.Errorw("refilling address", "address", ab.Address, "error", refillErr)
+			}
+		}
+
+		m.alert(*ab, refillErr)
+	}
+
+	m.lk.Lock()
+	m.status.Addresses = addrs
+	m.lk.Unlock()
+
+	if m.al == nil {
+		return nil
+	}
+
+	// resolve the alerts of the addresses which aren't used anymore
+	for a, at := range m.alerts {
+		found := false
+		for _, ab := range addrs {
+			found = found || ab.Address == a
+		}
+		if !found && m.al.IsRaised(at) {
+			m.al.Resolve(at, map[string]string{
+				"message": "address isn't a worker or control address anymore",
+			})
+		}
+	}
+
+	return nil
+}
+
+// addresses returns the worker and control addresses of the miner, and the
+// addresses configured to send its messages, except the owner.
+func (m *BalanceMonitor) addresses(ctx context.Context, mi api.MinerInfo) ([]api.AddressBalance, error) {
+	var out []api.AddressBalance
+	add := func(a address.Address, role string) error {
+		if a.Protocol() != address.ID {
+			id, err := m.api.StateLookupID(ctx, a, types.EmptyTSK)
+			if err != nil {
+				return xerrors.Errorf("looking up %s address %s: %w", role, a, err)
+			}
+			a = id
+		}
+		if a == mi.Owner {
+			return nil
+		}
+
+		for i := range out {
+			if out[i].Address == a {
+				out[i].Roles = append(out[i].Roles, role)
+				return nil
+			}
+		}
+		out = append(out, api.AddressBalance{Address: a, Roles: []string{role}})
+		return nil
+	}
+
+	if err := add(mi.Worker, "worker"); err != nil {
+		return nil, err
+	}
+	for _, a := range mi.ControlAddresses {
+		if err := add(a, "control"); err != nil {
+			return nil, err
+		}
+	}
+
+	if m.as != nil {
+		for _, c := range []struct {
+			role  string
+			addrs []address.Address
+		}{
+			{"precommit", m.as.PreCommitControl},
+			{"commit", m.as.CommitControl},
+			{"terminate", m.as.TerminateControl},
+			{"dealpublish", m.as.DealPublishControl},
+		} {
+			for _, a := range c.addrs {
+				if err := add(a, c.role); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// updateRefills drops the refills older than the cap window, and looks up the
+// messages of the refills which aren't executed yet.
+func (m *BalanceMonitor) updateRefills(ctx context.Context) error {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	now := time.Now()
+	refills := m.refills[:0]
+	for _, r := range m.refills {
+		if now.Sub(r.Time) > refillWindow {
+			continue
+		}
+
+		if !r.Executed {
+			lookup, err := m.api.StateSearchMsg(ctx, types.EmptyTSK, r.Message, api.LookbackNoLimit, true)
+			if err != nil {
+				return xerrors.Errorf("searching refill message %s: %w", r.Message, err)
+			}
+			switch {
+			case lookup != nil:
+				if lookup.Receipt.ExitCode.IsError() {
+					log.Errorw("refill message failed", "to", r.To, "message", r.Message, "exitcode", lookup.Receipt.ExitCode)
+				}
+				r.Executed = true
+			case now.Sub(r.Time) > refillTimeout:
+				log.Warnw("refill message not found on chain, assuming it was dropped", "to", r.To, "message", r.Message)
+				r.Executed = true
+			}
+		}
+
+		refills = append(refills, r)
+	}
+	m.refills = refills
+
+	return m.saveRefills(ctx)
+}
+
+func (m *BalanceMonitor) pendingRefill(a address.Address) cid.Cid {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	for _, r := range m.refills {
+		if r.To == a && !r.Executed {
+			return r.Message
+		}
+	}
+	return cid.Undef
+}
+
+// refilled returns the amount sent by the refills since the start of the cap
+// window.
+func (m *BalanceMonitor) refilled(now time.Time) abi.TokenAmount {
+	total := big.Zero()
+	for _, r := range m.refills {
+		if now.Sub(r.Time) <= refillWindow {
+			total = big.Add(total, r.Amount)
+		}
+	}
+	return total
+}
+
+// refill sends up to amount from the owner to the address, within what is
+// left of the daily cap.
+func (m *BalanceMonitor) refill(ctx context.Context, owner, to address.Address, amount abi.TokenAmount) (cid.Cid, error) {
+	m.lk.Lock()
+	left := big.Sub(m.cfg.DailyRefillCap, m.refilled(time.Now()))
+	m.lk.Unlock()
+
+	if !left.GreaterThan(big.Zero()) {
+		return cid.Undef, xerrors.Errorf("daily refill cap of %s reached", types.FIL(m.cfg.DailyRefillCap))
+	}
+	if amount.GreaterThan(left) {
+		amount = left
+	}
+
+	ownerKey, err := m.api.StateAccountKey(ctx, owner, types.EmptyTSK)
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("owner %s isn't an account, can't refill from it: %w", owner, err)
+	}
+	has, err := m.api.WalletHas(ctx, ownerKey)
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("checking wallet for owner key: %w", err)
+	}
+	if !has {
+		return cid.Undef, xerrors.Errorf("owner key %s not in the wallet, can't refill from it", ownerKey)
+	}
+
+	sm, err := m.api.MpoolPushMessage(ctx, &types.Message{
+		From:  owner,
+		To:    to,
+		Value: amount,
+	}, &api.MessageSendSpec{MaxFee: m.cfg.MaxRefillFee})
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("pushing refill message: %w", err)
+	}
+
+	log.Infow("refilled address", "address", to, "amount", types.FIL(amount), "message", sm.Cid())
+
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	m.refills = append(m.refills, api.AddressRefill{
+		To:      to,
+		Amount:  amount,
+		Message: sm.Cid(),
+		Time:    time.Now(),
+	})
+	sort.Slice(m.refills, func(i, j int) bool {
+		return m.refills[i].Time.Before(m.refills[j].Time)
+	})
+
+	return sm.Cid(), m.saveRefills(ctx)
+}
+
+func (m *BalanceMonitor) saveRefills(ctx context.Context) error {
+	b, err := json.Marshal(m.refills)
+	if err != nil {
+		return xerrors.Errorf("encoding refills: %w", err)
+	}
+	if err := m.ds.Put(ctx, refillsKey, b); err != nil {
+		return xerrors.Errorf("saving refills: %w", err)
+	}
+	return nil
+}
+
+func (m *BalanceMonitor) alert(ab api.AddressBalance, refillErr error) {
+	if m.al == nil {
+		return
+	}
+
+	at, ok := m.alerts[ab.Address]
+	if !ok {
+		at = m.al.AddAlertType("balance-monitor", ab.Address.String())
+		m.alerts[ab.Address] = at
+	}
+
+	if !ab.Low {
+		if m.al.IsRaised(at) {
+			m.al.Resolve(at, map[string]string{
+				"message": "balance is above the low-water mark",
+				"balance": types.FIL(ab.Balance).String(),
+			})
+		}
+		return
+	}
+
+	msg := map[string]interface{}{
+		"message":  "balance is under the low-water mark",
+		"roles":    ab.Roles,
+		"balance":  types.FIL(ab.Balance).String(),
+		"lowWater": types.FIL(m.cfg.LowWater).String(),
+	}
+	switch {
+	case refillErr != nil:
+		msg["refillError"] = refillErr.Error()
+	case ab.RefillPending != cid.Undef:
+		msg["refill"] = ab.RefillPending.String()
+	}
+	m.al.Raise(at, msg)
+}
@@ -0,0 +1,173 @@
+// stm: #unit
+package ctladdr
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/crypto"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/journal"
+	"github.com/filecoin-project/lotus/journal/alerting"
+)
+
+type fakeBalanceApi struct {
+	BalanceMonitorApi
+
+	mi       api.MinerInfo
+	keys     map[address.Address]address.Address
+	balances map[address.Address]big.Int
+	executed map[cid.Cid]bool
+	pushed   []*types.Message
+}
+
+func (f *fakeBalanceApi) StateMinerInfo(context.Context, address.Address, types.TipSetKey) (api.MinerInfo, error) {
+	return f.mi, nil
+}
+
+func (f *fakeBalanceApi) StateLookupID(_ context.Context, a address.Address, _ types.TipSetKey) (address.Address, error) {
+	for id, key := range f.keys {
+		if key == a {
+			return id, nil
+		}
+	}
+	return address.Undef, xerrors.Errorf("actor not found")
+}
+
+func (f *fakeBalanceApi) StateAccountKey(_ context.Context, a address.Address, _ types.TipSetKey) (address.Address, error) {
+	key, ok := f.keys[a]
+	if !ok {
+		return address.Undef, xerrors.Errorf("not an account")
+	}
+	return key, nil
+}
+
+func (f *fakeBalanceApi) WalletHas(context.Context, address.Address) (bool, error) {
+	return true, nil
+}
+
+func (f *fakeBalanceApi) WalletBalance(_ context.Context, a address.Address) (types.BigInt, error) {
+	if b, ok := f.balances[a]; ok {
+		return b, nil
+	}
+	return big.Zero(), nil
+}
+
+func (f *fakeBalanceApi) StateSearchMsg(_ context.Context, _ types.TipSetKey, c cid.Cid, _ abi.ChainEpoch, _ bool) (*api.MsgLookup, error) {
+	if !f.executed[c] {
+		return nil, nil
+	}
+	return &api.MsgLookup{Message: c}, nil
+}
+
+func (f *fakeBalanceApi) MpoolPushMessage(_ context.Context, m *types.Message, _ *api.MessageSendSpec) (*types.SignedMessage, error) {
+	m.Nonce = uint64(len(f.pushed))
+	f.pushed = append(f.pushed, m)
+	return &types.SignedMessage{Message: *m, Signature: crypto.Signature{Type: crypto.SigTypeSecp256k1}}, nil
+}
+
+func TestBalanceMonitor(t *testing.T) {
+	ctx := context.Background()
+
+	maddr, _ := address.NewIDAddress(1000)
+	owner, _ := address.NewIDAddress(100)
+	worker, _ := address.NewIDAddress(101)
+	control, _ := address.NewIDAddress(102)
+	commit, _ := address.NewIDAddress(103)
+
+	ownerKey, _ := address.NewSecp256k1Address([]byte("owner"))
+	commitKey, _ := address.NewSecp256k1Address([]byte("commit"))
+
+	a := &fakeBalanceApi{
+		mi: api.MinerInfo{
+			Owner:            owner,
+			Worker:           worker,
+			ControlAddresses: []address.Address{control, owner},
+		},
+		keys: map[address.Address]address.Address{
+			owner:  ownerKey,
+			commit: commitKey,
+		},
+		balances: map[address.Address]big.Int{
+			worker:  types.FromFil(2),
+			control: types.FromFil(3),
+		},
+		executed: map[cid.Cid]bool{},
+	}
+	as := &AddressSelector{}
+	as.CommitControl = []address.Address{commitKey, control}
+
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	al := alerting.NewAlertingSystem(journal.NilJournal())
+	cfg := BalanceMonitorConfig{
+		LowWater:       types.FromFil(1),
+		RefillTo:       types.FromFil(4),
+		DailyRefillCap: types.FromFil(5),
+		MaxRefillFee:   types.FromFil(1),
+	}
+
+	m, err := NewBalanceMonitor(a, maddr, as, ds, al, cfg)
+	require.NoError(t, err)
+
+	// the commit address is empty, it's refilled to 4 FIL
+	require.NoError(t, m.check(ctx))
+
+	st := m.Status()
+	require.Len(t, st.Addresses, 3)
+	require.Equal(t, []string{"worker"}, st.Addresses[0].Roles)
+	require.Equal(t, []string{"control", "commit"}, st.Addresses[1].Roles)
+	require.Equal(t, commit, st.Addresses[2].Address)
+	require.True(t, st.Addresses[2].Low)
+
+	require.Len(t, a.pushed, 1)
+	require.Equal(t, owner, a.pushed[0].From)
+	require.Equal(t, commit, a.pushed[0].To)
+	require.Equal(t, types.FromFil(4), a.pushed[0].Value)
+	require.Equal(t, a.pushed[0].Value, st.Refilled)
+	require.True(t, al.IsRaised(m.alerts[commit]))
+	require.False(t, al.IsRaised(m.alerts[worker]))
+
+	// the refill is pending, it isn't sent again
+	require.NoError(t, m.check(ctx))
+	require.Len(t, a.pushed, 1)
+	require.NotEqual(t, cid.Undef, m.Status().Addresses[2].RefillPending)
+
+	// once executed, the alert is resolved
+	c := m.Status().Refills[0].Message
+	a.executed[c] = true
+	a.balances[commit] = types.FromFil(4)
+	require.NoError(t, m.check(ctx))
+	require.True(t, m.Status().Refills[0].Executed)
+	require.False(t, al.IsRaised(m.alerts[commit]))
+
+	// the worker is refilled within what is left of the daily cap, from a
+	// new monitor loading the refills from the datastore
+	m, err = NewBalanceMonitor(a, maddr, as, ds, al, cfg)
+	require.NoError(t, err)
+
+	a.balances[worker] = big.Zero()
+	require.NoError(t, m.check(ctx))
+	require.Len(t, a.pushed, 2)
+	require.Equal(t, worker, a.pushed[1].To)
+	require.Equal(t, types.FromFil(1), a.pushed[1].Value)
+	require.Equal(t, types.FromFil(5), m.Status().Refilled)
+
+	// the cap is reached, the control address is left under the low-water
+	// mark with an alert
+	a.executed[m.Status().Refills[1].Message] = true
+	a.balances[control] = big.Zero()
+	require.NoError(t, m.check(ctx))
+	require.Len(t, a.pushed, 2)
+	require.True(t, al.IsRaised(m.alerts[control]))
+}
@@ -0,0 +1,642 @@
+package ctladdr
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+	builtintypes "github.com/filecoin-project/go-state-types/builtin"
+	minertypes "github.com/filecoin-project/go-state-types/builtin/v9/miner"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/actors/adt"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/multisig"
+	"github.com/filecoin-project/lotus/chain/actors/policy"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+type RotationApi interface {
+	NodeApi
+	blockstore.ChainIO
+
+	ChainHead(context.Context) (*types.TipSet, error)
+	StateGetActor(context.Context, address.Address, types.TipSetKey) (*types.Actor, error)
+	MpoolPending(context.Context, types.TipSetKey) ([]*types.SignedMessage, error)
+	MsigGetPending(context.Context, address.Address, types.TipSetKey) ([]*api.MsigTransaction, error)
+	GasEstimateMessageGas(context.Context, *types.Message, *api.MessageSendSpec, types.TipSetKey) (*types.Message, error)
+}
+
+type RotationExecApi interface {
+	RotationApi
+
+	MsigPropose(context.Context, address.Address, address.Address, types.BigInt, address.Address, uint64, []byte) (*api.MessagePrototype, error)
+	MpoolPushMessage(context.Context, *types.Message, *api.MessageSendSpec) (*types.SignedMessage, error)
+}
+
+// PlanRotation plans the address rotation of a miner from its state at the
+// chain head.
+func PlanRotation(ctx context.Context, a RotationApi, maddr address.Address, rot api.AddressRotation) (*api.AddressRotationPlan, error) {
+	head, err := a.ChainHead(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("getting chain head: %w", err)
+	}
+
+	act, err := a.StateGetActor(ctx, maddr, head.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting miner actor: %w", err)
+	}
+
+	store := adt.WrapStore(ctx, cbor.NewCborStore(blockstore.NewAPIBlockstore(a)))
+	mas, err := miner.Load(store, act)
+	if err != nil {
+		return nil, xerrors.Errorf("loading miner state: %w", err)
+	}
+
+	mi, err := mas.Info()
+	if err != nil {
+		return nil, xerrors.Errorf("getting miner info: %w", err)
+	}
+
+	rp := &rotationPlanner{
+		a:     a,
+		store: store,
+		maddr: maddr,
+		ts:    head,
+		mi:    mi,
+	}
+	return rp.plan(ctx, rot)
+}
+
+// ExecuteRotationStep sends the message of the next step of the rotation.
+func ExecuteRotationStep(ctx context.Context, a RotationExecApi, maddr address.Address, rot api.AddressRotation, proposer address.Address) (cid.Cid, error) {
+	plan, err := PlanRotation(ctx, a, maddr, rot)
+	if err != nil {
+		return cid.Undef, err
+	}
+
+	step := plan.Next()
+	if step == nil {
+		return cid.Undef, xerrors.Errorf("the rotation is complete")
+	}
+
+	switch step.State {
+	case api.RotationStepReady:
+	case api.RotationStepPending:
+		return cid.Undef, xerrors.Errorf("step %d (%s) is pending: %s", step.Index, step.Kind, pendingDetail(step))
+	case api.RotationStepWaiting:
+		return cid.Undef, xerrors.Errorf("step %d (%s) can't be executed before epoch %d, current height is %d", step.Index, step.Kind, step.NotBefore, plan.Height)
+	default:
+		var failed []string
+		for _, c := range append(plan.Checks, step.Checks...) {
+			if !c.OK && !c.Warning {
+				failed = append(failed, fmt.Sprintf("%s: %s", c.Name, c.Detail))
+			}
+		}
+		return cid.Undef, xerrors.Errorf("step %d (%s) is blocked: %s", step.Index, step.Kind, strings.Join(failed, "; "))
+	}
+
+	msg := &types.Message{
+		From:   step.From,
+		To:     maddr,
+		Value:  big.Zero(),
+		Method: step.Method,
+		Params: step.Params,
+	}
+
+	if step.Multisig {
+		if proposer == address.Undef {
+			return cid.Undef, xerrors.Errorf("step %d (%s) is sent by multisig %s, a proposer must be specified", step.Index, step.Kind, step.From)
+		}
+
+		proto, err := a.MsigPropose(ctx, step.From, maddr, big.Zero(), proposer, uint64(step.Method), step.Params)
+		if err != nil {
+			return cid.Undef, xerrors.Errorf("creating multisig proposal: %w", err)
+		}
+		msg = &proto.Message
+	} else if proposer != address.Undef && proposer != step.From {
+		return cid.Undef, xerrors.Errorf("step %d (%s) must be sent by %s, not %s", step.Index, step.Kind, step.From, proposer)
+	}
+
+	smsg, err := a.MpoolPushMessage(ctx, msg, nil)
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("mpool push: %w", err)
+	}
+
+	return smsg.Cid(), nil
+}
+
+func pendingDetail(step *api.AddressRotationStep) string {
+	if step.MsigTxID >= 0 {
+		return fmt.Sprintf("multisig %s proposal %d has %d of %d approvals", step.From, step.MsigTxID, step.MsigApprovals, step.MsigThreshold)
+	}
+	return fmt.Sprintf("waiting for messages %v", step.PendingMessages)
+}
+
+type rotationPlanner struct {
+	a     RotationApi
+	store adt.Store
+	maddr address.Address
+	ts    *types.TipSet
+	mi    miner.MinerInfo
+
+	pending []*types.SignedMessage
+}
+
+func (rp *rotationPlanner) plan(ctx context.Context, rot api.AddressRotation) (*api.AddressRotationPlan, error) {
+	if rot.NewOwner == address.Undef && rot.NewWorker == address.Undef && rot.NewControl == nil {
+		return nil, xerrors.Errorf("no address to rotate")
+	}
+
+	mi := rp.mi
+	plan := &api.AddressRotationPlan{
+		Miner:             rp.maddr,
+		Height:            rp.ts.Height(),
+		Owner:             mi.Owner,
+		Worker:            mi.Worker,
+		Control:           mi.ControlAddresses,
+		WorkerChangeEpoch: -1,
+	}
+	if mi.PendingOwnerAddress != nil {
+		plan.PendingOwner = *mi.PendingOwnerAddress
+	}
+	if mi.PendingWorkerKey != nil {
+		plan.PendingWorker = mi.PendingWorkerKey.NewWorker
+		plan.WorkerChangeEpoch = mi.PendingWorkerKey.EffectiveAt
+	}
+
+	check := func(c api.AddressRotationCheck) {
+		plan.Checks = append(plan.Checks, c)
+	}
+
+	if rot.NewOwner != address.Undef {
+		plan.Target.NewOwner = rp.resolve(ctx, "new owner", rot.NewOwner, check)
+	}
+	if rot.NewWorker != address.Undef {
+		plan.Target.NewWorker = rp.resolve(ctx, "new worker", rot.NewWorker, check)
+	}
+	if rot.NewControl != nil {
+		plan.Target.NewControl = []address.Address{}
+		for _, c := range rot.NewControl {
+			plan.Target.NewControl = append(plan.Target.NewControl, rp.resolve(ctx, "control address", c, check))
+		}
+	}
+	rp.checkTarget(ctx, plan, check)
+
+	var err error
+	rp.pending, err = rp.a.MpoolPending(ctx, rp.ts.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting pending messages: %w", err)
+	}
+
+	plan.Steps, err = rp.steps(plan)
+	if err != nil {
+		return nil, err
+	}
+
+	rp.checkPending(plan, check)
+
+	blocked := false
+	for _, c := range plan.Checks {
+		blocked = blocked || (!c.OK && !c.Warning)
+	}
+
+	prevDone := true
+	for i := range plan.Steps {
+		step := &plan.Steps[i]
+		if step.State == api.RotationStepDone {
+			continue
+		}
+
+		if err := rp.evaluate(ctx, step, prevDone, blocked); err != nil {
+			return nil, xerrors.Errorf("evaluating step %d (%s): %w", step.Index, step.Kind, err)
+		}
+		prevDone = false
+	}
+
+	return plan, nil
+}
+
+// resolve returns the ID address of a, Undef when it doesn't exist on chain.
+func (rp *rotationPlanner) resolve(ctx context.Context, name string, a address.Address, check func(api.AddressRotationCheck)) address.Address {
+	id, err := rp.a.StateLookupID(ctx, a, rp.ts.Key())
+	if err != nil {
+		check(api.AddressRotationCheck{
+			Name:   name + " exists",
+			Detail: fmt.Sprintf("%s has no actor on chain, send funds to it first: %s", a, err),
+		})
+		return address.Undef
+	}
+	return id
+}
+
+func (rp *rotationPlanner) checkTarget(ctx context.Context, plan *api.AddressRotationPlan, check func(api.AddressRotationCheck)) {
+	principal := func(name string, a address.Address) {
+		act, err := rp.a.StateGetActor(ctx, a, rp.ts.Key())
+		if err != nil {
+			check(api.AddressRotationCheck{Name: name + " type", Detail: err.Error()})
+			return
+		}
+		ok := builtin.IsAccountActor(act.Code) || builtin.IsMultisigActor(act.Code) || builtin.IsEthAccountActor(act.Code)
+		c := api.AddressRotationCheck{Name: name + " type", OK: ok, Detail: fmt.Sprintf("%s is an account or a multisig", a)}
+		if !ok {
+			c.Detail = fmt.Sprintf("%s must be an account or a multisig", a)
+		}
+		check(c)
+	}
+
+	if plan.Target.NewOwner != address.Undef {
+		principal("new owner", plan.Target.NewOwner)
+	}
+	for _, c := range plan.Target.NewControl {
+		if c != address.Undef {
+			principal("control address", c)
+		}
+	}
+
+	if w := plan.Target.NewWorker; w != address.Undef {
+		key, err := rp.a.StateAccountKey(ctx, w, rp.ts.Key())
+		switch {
+		case err != nil:
+			check(api.AddressRotationCheck{Name: "new worker type", Detail: fmt.Sprintf("%s must be an account: %s", w, err)})
+		case key.Protocol() != address.BLS:
+			check(api.AddressRotationCheck{Name: "new worker type", Detail: fmt.Sprintf("%s must be a BLS account, it's %s", w, key)})
+		default:
+			check(api.AddressRotationCheck{Name: "new worker type", OK: true, Detail: fmt.Sprintf("%s is a BLS account", key)})
+
+			has, err := rp.a.WalletHas(ctx, key)
+			if err != nil {
+				has = false
+			}
+			c := api.AddressRotationCheck{Name: "new worker key", OK: has, Warning: true, Detail: fmt.Sprintf("%s is in the wallet", key)}
+			if !has {
+				c.Detail = fmt.Sprintf("%s is not in the wallet of the node, the miner won't be able to send messages once the worker is changed", key)
+			}
+			check(c)
+
+			bal, err := rp.a.WalletBalance(ctx, key)
+			if err != nil {
+				bal = big.Zero()
+			}
+			c = api.AddressRotationCheck{Name: "new worker balance", OK: bal.GreaterThan(big.Zero()), Warning: true, Detail: fmt.Sprintf("%s holds %s", key, types.FIL(bal))}
+			if !c.OK {
+				c.Detail = fmt.Sprintf("%s holds no funds to pay for the miner messages once the worker is changed", key)
+			}
+			check(c)
+		}
+	}
+
+	if plan.PendingWorker != address.Undef && plan.Target.NewWorker != address.Undef && plan.PendingWorker != plan.Target.NewWorker {
+		check(api.AddressRotationCheck{
+			Name:    "pending worker change",
+			Warning: true,
+			Detail:  fmt.Sprintf("the pending change of the worker to %s will be replaced", plan.PendingWorker),
+		})
+	}
+	if plan.PendingOwner != address.Undef && plan.Target.NewOwner != address.Undef && plan.PendingOwner != plan.Target.NewOwner {
+		check(api.AddressRotationCheck{
+			Name:    "pending owner change",
+			Warning: true,
+			Detail:  fmt.Sprintf("the pending nomination of %s as owner will be replaced", plan.PendingOwner),
+		})
+	}
+}
+
+func (rp *rotationPlanner) steps(plan *api.AddressRotationPlan) ([]api.AddressRotationStep, error) {
+	mi, target := rp.mi, plan.Target
+
+	var steps []api.AddressRotationStep
+	add := func(s api.AddressRotationStep) {
+		s.Index = len(steps)
+		s.MsigTxID = -1
+		s.EstimatedFee = big.Zero()
+		if s.State == "" {
+			s.State = api.RotationStepWaiting
+		}
+		steps = append(steps, s)
+	}
+
+	wantWorker := target.NewWorker != address.Undef
+	wantControl := target.NewControl != nil
+
+	if wantWorker || wantControl {
+		params := &minertypes.ChangeWorkerAddressParams{
+			NewWorker:       mi.Worker,
+			NewControlAddrs: mi.ControlAddresses,
+		}
+		if wantWorker {
+			params.NewWorker = target.NewWorker
+		}
+		if wantControl {
+			params.NewControlAddrs = target.NewControl
+		}
+		sp, err := actors.SerializeParams(params)
+		if err != nil {
+			return nil, xerrors.Errorf("serializing params: %w", err)
+		}
+
+		s := api.AddressRotationStep{
+			Kind:   api.RotationChangeWorker,
+			From:   mi.Owner,
+			Method: builtintypes.MethodsMiner.ChangeWorkerAddress,
+			Params: sp,
+		}
+		if wantWorker {
+			s.Description = fmt.Sprintf("Propose %s as worker, effective %d epochs later", target.NewWorker, policy.ChainFinality)
+			s.Rollback = fmt.Sprintf("Until the change is confirmed, propose the current worker %s again to cancel it.", mi.Worker)
+		}
+		if wantControl {
+			if s.Description != "" {
+				s.Description += ", and set "
+			} else {
+				s.Description = "Set "
+			}
+			s.Description += fmt.Sprintf("the control addresses to %v", target.NewControl)
+			s.Rollback = strings.TrimSpace(s.Rollback + fmt.Sprintf(" Restore the previous control addresses with 'lotus-miner actor control set %s'.", joinAddrs(mi.ControlAddresses)))
+		}
+
+		workerDone := !wantWorker || mi.Worker == target.NewWorker || plan.PendingWorker == target.NewWorker
+		controlDone := !wantControl || equalAddrs(mi.ControlAddresses, target.NewControl)
+		if workerDone && controlDone {
+			s.State = api.RotationStepDone
+		}
+		add(s)
+	}
+
+	if wantWorker {
+		s := api.AddressRotationStep{
+			Kind:        api.RotationConfirmWorker,
+			From:        mi.Owner,
+			Method:      builtintypes.MethodsMiner.ConfirmChangeWorkerAddress,
+			NotBefore:   rp.ts.Height() + policy.ChainFinality,
+			Description: fmt.Sprintf("Confirm %s as worker. The change is also confirmed at the end of the first proving deadline after the change epoch.", target.NewWorker),
+			Rollback:    fmt.Sprintf("Restoring the previous worker %s takes a new worker change, effective %d epochs after it is proposed.", mi.Worker, policy.ChainFinality),
+		}
+		if plan.PendingWorker == target.NewWorker {
+			s.NotBefore = plan.WorkerChangeEpoch
+		}
+		if mi.Worker == target.NewWorker {
+			s.State = api.RotationStepDone
+		}
+		add(s)
+	}
+
+	if target.NewOwner != address.Undef {
+		sp, err := actors.SerializeParams(&target.NewOwner)
+		if err != nil {
+			return nil, xerrors.Errorf("serializing params: %w", err)
+		}
+
+		s := api.AddressRotationStep{
+			Kind:        api.RotationProposeOwner,
+			From:        mi.Owner,
+			Method:      builtintypes.MethodsMiner.ChangeOwnerAddress,
+			Params:      sp,
+			Description: fmt.Sprintf("Nominate %s as owner", target.NewOwner),
+			Rollback:    fmt.Sprintf("Until the nomination is accepted, withdraw it by nominating the current owner: 'lotus-miner actor set-owner %s %s'.", mi.Owner, mi.Owner),
+		}
+		if mi.Owner == target.NewOwner || plan.PendingOwner == target.NewOwner {
+			s.State = api.RotationStepDone
+		}
+		add(s)
+
+		s = api.AddressRotationStep{
+			Kind:        api.RotationConfirmOwner,
+			From:        target.NewOwner,
+			Method:      builtintypes.MethodsMiner.ChangeOwnerAddress,
+			Params:      sp,
+			Description: fmt.Sprintf("Accept the nomination as owner from %s", target.NewOwner),
+			Rollback:    fmt.Sprintf("The change is final, only the new owner %s can transfer the ownership back, nominating %s which must accept it.", target.NewOwner, mi.Owner),
+		}
+		if mi.Owner == target.NewOwner {
+			s.State = api.RotationStepDone
+		}
+		add(s)
+	}
+
+	return steps, nil
+}
+
+// checkPending reports the messages changing the miner addresses which
+// aren't part of the rotation.
+func (rp *rotationPlanner) checkPending(plan *api.AddressRotationPlan, check func(api.AddressRotationCheck)) {
+	for _, sm := range rp.pending {
+		m := sm.Message
+		if m.To != rp.maddr {
+			continue
+		}
+		switch m.Method {
+		case builtintypes.MethodsMiner.ChangeWorkerAddress, builtintypes.MethodsMiner.ConfirmChangeWorkerAddress, builtintypes.MethodsMiner.ChangeOwnerAddress:
+		default:
+			continue
+		}
+
+		ours := false
+		for _, s := range plan.Steps {
+			ours = ours || (s.Method == m.Method && bytes.Equal(s.Params, m.Params))
+		}
+		if !ours {
+			check(api.AddressRotationCheck{
+				Name:    "pending messages",
+				Warning: true,
+				Detail:  fmt.Sprintf("message %s from %s changing the miner addresses (method %d) is pending and not part of the rotation", sm.Cid(), m.From, m.Method),
+			})
+		}
+	}
+}
+
+func (rp *rotationPlanner) evaluate(ctx context.Context, step *api.AddressRotationStep, prevDone, blocked bool) error {
+	act, err := rp.a.StateGetActor(ctx, step.From, rp.ts.Key())
+	if err != nil {
+		step.State = api.RotationStepBlocked
+		step.Checks = append(step.Checks, api.AddressRotationCheck{Name: "sender", Detail: fmt.Sprintf("getting actor %s: %s", step.From, err)})
+		return nil
+	}
+	step.Multisig = builtin.IsMultisigActor(act.Code)
+
+	var msas multisig.State
+	if step.Multisig {
+		if msas, err = multisig.Load(rp.store, act); err != nil {
+			return xerrors.Errorf("loading multisig state: %w", err)
+		}
+		if step.MsigThreshold, err = msas.Threshold(); err != nil {
+			return xerrors.Errorf("getting multisig threshold: %w", err)
+		}
+	}
+
+	if err := rp.findPending(ctx, step); err != nil {
+		return err
+	}
+
+	switch {
+	case step.State == api.RotationStepPending:
+		return nil
+	case !prevDone:
+		step.State = api.RotationStepWaiting
+		return nil
+	case step.NotBefore > rp.ts.Height():
+		step.State = api.RotationStepWaiting
+		return nil
+	}
+
+	if step.Multisig {
+		rp.checkMultisig(ctx, step, msas)
+	} else {
+		rp.dryRun(ctx, step)
+	}
+
+	step.State = api.RotationStepReady
+	if blocked {
+		step.State = api.RotationStepBlocked
+	}
+	for _, c := range step.Checks {
+		if !c.OK && !c.Warning {
+			step.State = api.RotationStepBlocked
+		}
+	}
+	return nil
+}
+
+// findPending looks for the messages of the step in the mpool, and its
+// proposals on the sender multisig.
+func (rp *rotationPlanner) findPending(ctx context.Context, step *api.AddressRotationStep) error {
+	if step.Multisig {
+		txs, err := rp.a.MsigGetPending(ctx, step.From, rp.ts.Key())
+		if err != nil {
+			return xerrors.Errorf("getting pending multisig transactions: %w", err)
+		}
+		for _, tx := range txs {
+			if tx.To == rp.maddr && tx.Method == step.Method && bytes.Equal(tx.Params, step.Params) {
+				step.MsigTxID = tx.ID
+				step.MsigApprovals = len(tx.Approved)
+				step.State = api.RotationStepPending
+				break
+			}
+		}
+		return nil
+	}
+
+	senders := map[address.Address]struct{}{step.From: {}}
+	if key, err := rp.a.StateAccountKey(ctx, step.From, rp.ts.Key()); err == nil {
+		senders[key] = struct{}{}
+	}
+
+	for _, sm := range rp.pending {
+		m := sm.Message
+		if _, ok := senders[m.From]; !ok {
+			continue
+		}
+		if m.To == rp.maddr && m.Method == step.Method && bytes.Equal(m.Params, step.Params) {
+			step.PendingMessages = append(step.PendingMessages, sm.Cid())
+			step.State = api.RotationStepPending
+		}
+	}
+	return nil
+}
+
+func (rp *rotationPlanner) checkMultisig(ctx context.Context, step *api.AddressRotationStep, msas multisig.State) {
+	signers, err := msas.Signers()
+	if err != nil {
+		step.Checks = append(step.Checks, api.AddressRotationCheck{Name: "multisig signers", Detail: err.Error()})
+		return
+	}
+
+	var local []string
+	for _, s := range signers {
+		key, err := rp.a.StateAccountKey(ctx, s, rp.ts.Key())
+		if err != nil {
+			continue
+		}
+		if has, err := rp.a.WalletHas(ctx, key); err == nil && has {
+			local = append(local, key.String())
+		}
+	}
+
+	c := api.AddressRotationCheck{
+		Name:    "multisig signers",
+		OK:      len(local) > 0,
+		Warning: true,
+		Detail:  fmt.Sprintf("signers in the wallet: %s, %d approvals needed", strings.Join(local, ", "), step.MsigThreshold),
+	}
+	if !c.OK {
+		c.Detail = fmt.Sprintf("none of the signers of %s is in the wallet of the node", step.From)
+	}
+	step.Checks = append(step.Checks, c)
+	step.Description += fmt.Sprintf(", proposed through multisig %s; the other signers approve the proposal with 'lotus msig approve'", step.From)
+}
+
+// dryRun estimates the gas of the step message, which executes it on the head
+// state, and checks that the sender can pay for it.
+func (rp *rotationPlanner) dryRun(ctx context.Context, step *api.AddressRotationStep) {
+	msg := &types.Message{
+		From:   step.From,
+		To:     rp.maddr,
+		Value:  big.Zero(),
+		Method: step.Method,
+		Params: step.Params,
+	}
+
+	est, err := rp.a.GasEstimateMessageGas(ctx, msg, nil, types.EmptyTSK)
+	if err != nil {
+		step.Checks = append(step.Checks, api.AddressRotationCheck{Name: "dry run", Detail: err.Error()})
+		return
+	}
+	step.Checks = append(step.Checks, api.AddressRotationCheck{Name: "dry run", OK: true, Detail: fmt.Sprintf("estimated gas limit %d", est.GasLimit)})
+	step.EstimatedFee = big.Mul(est.GasFeeCap, big.NewInt(est.GasLimit))
+
+	bal, err := rp.a.WalletBalance(ctx, step.From)
+	if err != nil {
+		step.Checks = append(step.Checks, api.AddressRotationCheck{Name: "sender balance", Detail: err.Error()})
+		return
+	}
+	c := api.AddressRotationCheck{
+		Name:   "sender balance",
+		OK:     bal.GreaterThanEqual(step.EstimatedFee),
+		Detail: fmt.Sprintf("%s holds %s, the message costs up to %s", step.From, types.FIL(bal), types.FIL(step.EstimatedFee)),
+	}
+	step.Checks = append(step.Checks, c)
+
+	key, err := rp.a.StateAccountKey(ctx, step.From, rp.ts.Key())
+	if err != nil {
+		return
+	}
+	has, err := rp.a.WalletHas(ctx, key)
+	if err != nil {
+		has = false
+	}
+	c = api.AddressRotationCheck{Name: "sender key", OK: has, Warning: true, Detail: fmt.Sprintf("%s is in the wallet", key)}
+	if !has {
+		c.Detail = fmt.Sprintf("%s is not in the wallet of the node, sign the message where the key is kept", key)
+	}
+	step.Checks = append(step.Checks, c)
+}
+
+func equalAddrs(a, b []address.Address) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func joinAddrs(as []address.Address) string {
+	s := make([]string, len(as))
+	for i, a := range as {
+		s[i] = a.String()
+	}
+	return strings.Join(s, " ")
+}
@@ -0,0 +1,257 @@
+// stm: #unit
+package ctladdr
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	builtintypes "github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/go-state-types/crypto"
+	builtin7 "github.com/filecoin-project/specs-actors/v7/actors/builtin"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+type fakeRotationApi struct {
+	RotationApi
+
+	ids      map[address.Address]address.Address
+	keys     map[address.Address]address.Address
+	balances map[address.Address]big.Int
+	wallet   map[address.Address]bool
+	pending  []*types.SignedMessage
+}
+
+func (f *fakeRotationApi) StateLookupID(_ context.Context, a address.Address, _ types.TipSetKey) (address.Address, error) {
+	if a.Protocol() == address.ID {
+		return a, nil
+	}
+	id, ok := f.ids[a]
+	if !ok {
+		return address.Undef, xerrors.Errorf("actor not found")
+	}
+	return id, nil
+}
+
+func (f *fakeRotationApi) StateAccountKey(_ context.Context, a address.Address, _ types.TipSetKey) (address.Address, error) {
+	key, ok := f.keys[a]
+	if !ok {
+		return address.Undef, xerrors.Errorf("not an account")
+	}
+	return key, nil
+}
+
+func (f *fakeRotationApi) StateGetActor(_ context.Context, a address.Address, _ types.TipSetKey) (*types.Actor, error) {
+	if _, ok := f.keys[a]; !ok {
+		return nil, xerrors.Errorf("actor not found")
+	}
+	return &types.Actor{Code: builtin7.AccountActorCodeID}, nil
+}
+
+func (f *fakeRotationApi) WalletBalance(_ context.Context, a address.Address) (types.BigInt, error) {
+	if b, ok := f.balances[a]; ok {
+		return b, nil
+	}
+	return big.Zero(), nil
+}
+
+func (f *fakeRotationApi) WalletHas(_ context.Context, a address.Address) (bool, error) {
+	return f.wallet[a], nil
+}
+
+func (f *fakeRotationApi) MpoolPending(context.Context, types.TipSetKey) ([]*types.SignedMessage, error) {
+	return f.pending, nil
+}
+
+func (f *fakeRotationApi) GasEstimateMessageGas(_ context.Context, m *types.Message, _ *api.MessageSendSpec, _ types.TipSetKey) (*types.Message, error) {
+	out := *m
+	out.GasLimit = 1000
+	out.GasFeeCap = big.NewInt(100)
+	return &out, nil
+}
+
+func testTipSet(t *testing.T, h abi.ChainEpoch) *types.TipSet {
+	c, err := cid.Decode("bafyreicmaj5hhoy5mgqvamfhgexxyergw7hdeshizghodwkjg6qmpoco7i")
+	require.NoError(t, err)
+
+	ts, err := types.NewTipSet([]*types.BlockHeader{{
+		Miner:                 builtintypes.SystemActorAddr,
+		Height:                h,
+		Ticket:                &types.Ticket{VRFProof: []byte{1}},
+		ParentStateRoot:       c,
+		ParentMessageReceipts: c,
+		Messages:              c,
+		ParentWeight:          big.Zero(),
+		ParentBaseFee:         big.Zero(),
+		BLSAggregate:          &crypto.Signature{Type: crypto.SigTypeBLS},
+		BlockSig:              &crypto.Signature{Type: crypto.SigTypeBLS},
+	}})
+	require.NoError(t, err)
+	return ts
+}
+
+func TestPlanRotation(t *testing.T) {
+	ctx := context.Background()
+
+	maddr, _ := address.NewIDAddress(1000)
+	owner, _ := address.NewIDAddress(100)
+	worker, _ := address.NewIDAddress(101)
+	newWorker, _ := address.NewIDAddress(102)
+	newOwner, _ := address.NewIDAddress(103)
+
+	ownerKey, _ := address.NewSecp256k1Address([]byte("owner"))
+	workerKey, _ := address.NewBLSAddress(make([]byte, address.BlsPublicKeyBytes))
+	newWorkerKey, _ := address.NewBLSAddress(append(make([]byte, address.BlsPublicKeyBytes-1), 1))
+	newOwnerKey, _ := address.NewSecp256k1Address([]byte("new owner"))
+
+	newFake := func() *fakeRotationApi {
+		return &fakeRotationApi{
+			ids: map[address.Address]address.Address{
+				newWorkerKey: newWorker,
+				newOwnerKey:  newOwner,
+			},
+			keys: map[address.Address]address.Address{
+				owner:     ownerKey,
+				worker:    workerKey,
+				newWorker: newWorkerKey,
+				newOwner:  newOwnerKey,
+			},
+			balances: map[address.Address]big.Int{
+				owner:        types.FromFil(1),
+				newWorkerKey: types.FromFil(1),
+			},
+			wallet: map[address.Address]bool{
+				ownerKey:     true,
+				newWorkerKey: true,
+			},
+		}
+	}
+
+	plan := func(t *testing.T, a *fakeRotationApi, mi miner.MinerInfo, rot api.AddressRotation) *api.AddressRotationPlan {
+		rp := &rotationPlanner{
+			a:     a,
+			maddr: maddr,
+			ts:    testTipSet(t, 0),
+			mi:    mi,
+		}
+		p, err := rp.plan(ctx, rot)
+		require.NoError(t, err)
+		return p
+	}
+
+	states := func(p *api.AddressRotationPlan) []api.AddressRotationStepState {
+		var out []api.AddressRotationStepState
+		for _, s := range p.Steps {
+			out = append(out, s.State)
+		}
+		return out
+	}
+
+	info := miner.MinerInfo{
+		Owner:            owner,
+		Worker:           worker,
+		ControlAddresses: []address.Address{},
+	}
+	rot := api.AddressRotation{NewOwner: newOwnerKey, NewWorker: newWorkerKey}
+
+	t.Run("fresh", func(t *testing.T) {
+		p := plan(t, newFake(), info, rot)
+		require.Equal(t, newOwner, p.Target.NewOwner)
+		require.Equal(t, newWorker, p.Target.NewWorker)
+		for _, c := range p.Checks {
+			require.True(t, c.OK, c.Detail)
+		}
+
+		require.Len(t, p.Steps, 4)
+		require.Equal(t, []api.AddressRotationStepState{
+			api.RotationStepReady,
+			api.RotationStepWaiting,
+			api.RotationStepWaiting,
+			api.RotationStepWaiting,
+		}, states(p))
+		require.Equal(t, api.RotationChangeWorker, p.Steps[0].Kind)
+		require.Equal(t, owner, p.Steps[0].From)
+		require.Equal(t, big.NewInt(100000), p.Steps[0].EstimatedFee)
+		require.Equal(t, api.RotationConfirmOwner, p.Steps[3].Kind)
+		require.Equal(t, newOwner, p.Steps[3].From)
+		require.Equal(t, &p.Steps[0], p.Next())
+	})
+
+	t.Run("worker-proposed", func(t *testing.T) {
+		mi := info
+		mi.PendingWorkerKey = &miner.WorkerKeyChange{NewWorker: newWorker, EffectiveAt: 10}
+
+		p := plan(t, newFake(), mi, rot)
+		require.Equal(t, []api.AddressRotationStepState{
+			api.RotationStepDone,
+			api.RotationStepWaiting,
+			api.RotationStepWaiting,
+			api.RotationStepWaiting,
+		}, states(p))
+		require.EqualValues(t, 10, p.Steps[1].NotBefore)
+	})
+
+	t.Run("owner-nominated", func(t *testing.T) {
+		mi := info
+		mi.Worker = newWorker
+		mi.PendingOwnerAddress = &newOwner
+
+		a := newFake()
+		a.balances[newOwner] = types.FromFil(1)
+
+		p := plan(t, a, mi, rot)
+		require.Equal(t, []api.AddressRotationStepState{
+			api.RotationStepDone,
+			api.RotationStepDone,
+			api.RotationStepDone,
+			api.RotationStepReady,
+		}, states(p))
+	})
+
+	t.Run("pending", func(t *testing.T) {
+		a := newFake()
+		p := plan(t, a, info, rot)
+
+		a.pending = []*types.SignedMessage{{
+			Message: types.Message{
+				From:   ownerKey,
+				To:     maddr,
+				Method: builtintypes.MethodsMiner.ChangeWorkerAddress,
+				Params: p.Steps[0].Params,
+			},
+		}}
+		p = plan(t, a, info, rot)
+		require.Equal(t, api.RotationStepPending, p.Steps[0].State)
+		require.Len(t, p.Steps[0].PendingMessages, 1)
+	})
+
+	t.Run("secp-worker", func(t *testing.T) {
+		p := plan(t, newFake(), info, api.AddressRotation{NewWorker: newOwnerKey})
+		require.Len(t, p.Steps, 2)
+		require.Equal(t, api.RotationStepBlocked, p.Steps[0].State)
+	})
+
+	t.Run("no-funds", func(t *testing.T) {
+		a := newFake()
+		delete(a.balances, owner)
+
+		p := plan(t, a, info, rot)
+		require.Equal(t, api.RotationStepBlocked, p.Steps[0].State)
+	})
+
+	t.Run("control-only", func(t *testing.T) {
+		p := plan(t, newFake(), info, api.AddressRotation{NewControl: []address.Address{newOwnerKey}})
+		require.Len(t, p.Steps, 1)
+		require.Equal(t, api.RotationStepReady, p.Steps[0].State)
+		require.Equal(t, []address.Address{newOwner}, p.Target.NewControl)
+	})
+}
@@ -0,0 +1,493 @@
+// Package disputewatch watches the chain for DisputeWindowedPoSt messages
+// targeting the miner. Each dispute raises an alert until it's acknowledged,
+// and the WindowPoSt submissions of the disputed deadline are collected from
+// the chain when it's detected, so that the operator can find out afterwards
+// which proof was disputed and what it proved.
+package disputewatch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	builtintypes "github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/go-state-types/builtin/v9/miner"
+	"github.com/filecoin-project/go-state-types/dline"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/journal/alerting"
+)
+
+var log = logging.Logger("disputewatch")
+
+// maxCatchUp is the largest number of epochs checked for disputes missed while
+// the miner wasn't running; older disputes can't target a proof anymore.
+var maxCatchUp = miner.WPoStDisputeWindow
+
+var (
+	heightKey   = datastore.NewKey("/height")
+	disputesKey = datastore.NewKey("/disputes")
+)
+
+type ChainAPI interface {
+	ChainNotify(context.Context) (<-chan []*api.HeadChange, error)
+	ChainGetTipSetByHeight(context.Context, abi.ChainEpoch, types.TipSetKey) (*types.TipSet, error)
+	ChainGetParentMessages(ctx context.Context, blockCid cid.Cid) ([]api.Message, error)
+	ChainGetParentReceipts(ctx context.Context, blockCid cid.Cid) ([]*types.MessageReceipt, error)
+	StateLookupID(context.Context, address.Address, types.TipSetKey) (address.Address, error)
+	StateMinerProvingDeadline(context.Context, address.Address, types.TipSetKey) (*dline.Info, error)
+	StateMinerPartitions(ctx context.Context, m address.Address, dlIdx uint64, tsk types.TipSetKey) ([]api.Partition, error)
+}
+
+type Watcher struct {
+	api   ChainAPI
+	ds    datastore.Batching
+	maddr address.Address
+
+	al    *alerting.Alerting
+	alert alerting.AlertType
+
+	lk       sync.Mutex
+	disputes map[cid.Cid]*api.WdPoStDispute
+
+	// targets caches whether the robust addresses messages were sent to are
+	// the miner, only used by the run goroutine
+	targets map[address.Address]bool
+
+	closing chan struct{}
+	closed  chan struct{}
+}
+
+// New creates a watcher of the disputes targeting the miner, with the ID
+// address maddr, recording them in the datastore. The alerting system is
+// optional.
+func New(a ChainAPI, ds datastore.Batching, maddr address.Address, al *alerting.Alerting) (*Watcher, error) {
+	w := &Watcher{
+		api:      a,
+		ds:       ds,
+		maddr:    maddr,
+		al:       al,
+		disputes: map[cid.Cid]*api.WdPoStDispute{},
+		targets:  map[address.Address]bool{},
+		closing:  make(chan struct{}),
+		closed:   make(chan struct{}),
+	}
+
+	res, err := ds.Query(context.TODO(), query.Query{Prefix: disputesKey.String()})
+	if err != nil {
+		return nil, xerrors.Errorf("querying disputes: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, xerrors.Errorf("reading disputes: %w", r.Error)
+		}
+		var d api.WdPoStDispute
+		if err := json.Unmarshal(r.Value, &d); err != nil {
+			return nil, xerrors.Errorf("decoding dispute %s: %w", r.Key, err)
+		}
+		w.disputes[d.Message] = &d
+	}
+
+	if al != nil {
+		w.alert = al.AddAlertType("wdpost", "dispute")
+		w.lk.Lock()
+		w.updateAlert()
+		w.lk.Unlock()
+	}
+	return w, nil
+}
+
+func (w *Watcher) Start() {
+	go w.run()
+}
+
+func (w *Watcher) Stop(ctx context.Context) error {
+	close(w.closing)
+
+	select {
+	case <-w.closed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// List returns the disputes, the latest first.
+func (w *Watcher) List() []api.WdPoStDispute {
+	w.lk.Lock()
+	defer w.lk.Unlock()
+
+	out := make([]api.WdPoStDispute, 0, len(w.disputes))
+	for _, d := range w.disputes {
+		out = append(out, *d)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Height != out[j].Height {
+			return out[i].Height > out[j].Height
+		}
+		return out[i].Message.String() < out[j].Message.String()
+	})
+	return out
+}
+
+// Ack acknowledges the disputes sent in the messages.
+func (w *Watcher) Ack(ctx context.Context, msgs []cid.Cid) error {
+	w.lk.Lock()
+	defer w.lk.Unlock()
+
+	for _, m := range msgs {
+		if _, ok := w.disputes[m]; !ok {
+			return xerrors.Errorf("no dispute sent in message %s", m)
+		}
+	}
+	for _, m := range msgs {
+		d := w.disputes[m]
+		if d.Acknowledged {
+			continue
+		}
+		d.Acknowledged = true
+		if err := w.save(ctx, d); err != nil {
+			return err
+		}
+	}
+
+	w.updateAlert()
+	return nil
+}
+
+// updateAlert raises the alert while some disputes aren't acknowledged. Must
+// be called with w.lk held.
+func (w *Watcher) updateAlert() {
+	if w.al == nil {
+		return
+	}
+
+	var pending []map[string]interface{}
+	for _, d := range w.disputes {
+		if d.Acknowledged {
+			continue
+		}
+		pending = append(pending, map[string]interface{}{
+			"message":    d.Message,
+			"height":     d.Height,
+			"deadline":   d.Deadline,
+			"successful": d.Successful,
+		})
+	}
+
+	if len(pending) > 0 {
+		w.al.Raise(w.alert, map[string]interface{}{
+			"message":  "WindowPoSt disputed, see 'lotus-miner proving disputes'",
+			"disputes": pending,
+		})
+	} else if w.al.IsRaised(w.alert) {
+		w.al.Resolve(w.alert, map[string]string{
+			"message": "all WindowPoSt disputes acknowledged",
+		})
+	}
+}
+
+// save must be called with w.lk held.
+func (w *Watcher) save(ctx context.Context, d *api.WdPoStDispute) error {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return xerrors.Errorf("encoding dispute: %w", err)
+	}
+	if err := w.ds.Put(ctx, disputesKey.ChildString(d.Message.String()), b); err != nil {
+		return xerrors.Errorf("saving dispute: %w", err)
+	}
+	return nil
+}
+
+func (w *Watcher) run() {
+	defer close(w.closed)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-w.closing
+		cancel()
+	}()
+
+	var notifs <-chan []*api.HeadChange
+	for {
+		if notifs == nil {
+			var err error
+			notifs, err = w.api.ChainNotify(ctx)
+			if err != nil {
+				log.Errorf("ChainNotify error: %+v", err)
+
+				select {
+				case <-build.Clock.After(10 * time.Second):
+				case <-ctx.Done():
+					return
+				}
+				continue
+			}
+		}
+
+		select {
+		case changes, ok := <-notifs:
+			if !ok {
+				log.Warn("dispute watcher notifs channel closed")
+				notifs = nil
+				continue
+			}
+
+			var head *types.TipSet
+			for _, chg := range changes {
+				if chg.Type != store.HCRevert {
+					head = chg.Val
+				}
+			}
+			if head != nil {
+				if err := w.HeadChange(ctx, head); err != nil {
+					log.Errorw("checking disputes", "height", head.Height(), "error", err)
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// HeadChange checks the messages executed since the last checked tipset, up to
+// the head, for disputes.
+func (w *Watcher) HeadChange(ctx context.Context, head *types.TipSet) error {
+	from := head.Height()
+	b, err := w.ds.Get(ctx, heightKey)
+	switch {
+	case err == datastore.ErrNotFound:
+	case err != nil:
+		return xerrors.Errorf("getting last checked height: %w", err)
+	default:
+		var last abi.ChainEpoch
+		if err := json.Unmarshal(b, &last); err != nil {
+			return xerrors.Errorf("decoding last checked height: %w", err)
+		}
+		from = last + 1
+		if head.Height()-from > maxCatchUp {
+			from = head.Height() - maxCatchUp
+		}
+	}
+
+	for h := from; h <= head.Height(); h++ {
+		ts, err := w.api.ChainGetTipSetByHeight(ctx, h, head.Key())
+		if err != nil {
+			return xerrors.Errorf("getting tipset at %d: %w", h, err)
+		}
+		if ts.Height() != h {
+			// null round
+			continue
+		}
+		if err := w.checkTipSet(ctx, ts); err != nil {
+			return err
+		}
+	}
+
+	b, err = json.Marshal(head.Height())
+	if err != nil {
+		return err
+	}
+	return w.ds.Put(ctx, heightKey, b)
+}
+
+// executedMessage is a message executed in a tipset, with its receipt
+type executedMessage struct {
+	cid     cid.Cid
+	msg     *types.Message
+	receipt *types.MessageReceipt
+}
+
+// minerMessages returns the messages calling the method of the miner actor
+// executed in the tipset.
+func (w *Watcher) minerMessages(ctx context.Context, ts *types.TipSet, method abi.MethodNum) ([]executedMessage, error) {
+	msgs, err := w.api.ChainGetParentMessages(ctx, ts.Cids()[0])
+	if err != nil {
+		return nil, xerrors.Errorf("getting messages executed at %d: %w", ts.Height(), err)
+	}
+	rcpts, err := w.api.ChainGetParentReceipts(ctx, ts.Cids()[0])
+	if err != nil {
+		return nil, xerrors.Errorf("getting receipts of the messages executed at %d: %w", ts.Height(), err)
+	}
+	if len(msgs) != len(rcpts) {
+		return nil, xerrors.Errorf("%d messages but %d receipts executed at %d", len(msgs), len(rcpts), ts.Height())
+	}
+
+	var out []executedMessage
+	for i, m := range msgs {
+		if m.Message.Method != method {
+			continue
+		}
+		target, err := w.targetsMiner(ctx, m.Message.To, ts.Key())
+		if err != nil {
+			return nil, err
+		}
+		if target {
+			out = append(out, executedMessage{cid: m.Cid, msg: m.Message, receipt: rcpts[i]})
+		}
+	}
+	return out, nil
+}
+
+func (w *Watcher) targetsMiner(ctx context.Context, to address.Address, tsk types.TipSetKey) (bool, error) {
+	if to == w.maddr {
+		return true, nil
+	}
+	if to.Protocol() == address.ID {
+		return false, nil
+	}
+
+	target, ok := w.targets[to]
+	if !ok {
+		id, err := w.api.StateLookupID(ctx, to, tsk)
+		if err != nil {
+			return false, xerrors.Errorf("looking up id of %s: %w", to, err)
+		}
+		target = id == w.maddr
+		w.targets[to] = target
+	}
+	return target, nil
+}
+
+func (w *Watcher) checkTipSet(ctx context.Context, ts *types.TipSet) error {
+	msgs, err := w.minerMessages(ctx, ts, builtintypes.MethodsMiner.DisputeWindowedPoSt)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range msgs {
+		w.lk.Lock()
+		_, known := w.disputes[m.cid]
+		w.lk.Unlock()
+		if known {
+			continue
+		}
+
+		var params miner.DisputeWindowedPoStParams
+		if err := params.UnmarshalCBOR(bytes.NewReader(m.msg.Params)); err != nil {
+			log.Errorw("decoding dispute params", "message", m.cid, "error", err)
+			continue
+		}
+
+		d := &api.WdPoStDispute{
+			Message:    m.cid,
+			From:       m.msg.From,
+			Height:     ts.Height(),
+			TipSet:     ts.Key(),
+			Deadline:   params.Deadline,
+			PoStIndex:  params.PoStIndex,
+			Successful: m.receipt.ExitCode.IsSuccess(),
+			ExitCode:   m.receipt.ExitCode,
+			Detected:   time.Now(),
+		}
+		log.Errorw("WindowPoSt disputed", "message", d.Message, "from", d.From, "height", d.Height,
+			"deadline", d.Deadline, "post-index", d.PoStIndex, "successful", d.Successful)
+
+		ev, err := w.collectEvidence(ctx, ts, params)
+		if err != nil {
+			log.Errorw("collecting dispute evidence", "message", d.Message, "error", err)
+			d.EvidenceError = err.Error()
+		}
+		d.Evidence = ev
+
+		w.lk.Lock()
+		w.disputes[d.Message] = d
+		err = w.save(ctx, d)
+		w.updateAlert()
+		w.lk.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collectEvidence finds the WindowPoSt submissions of the last proving window
+// of the disputed deadline, which closed before the dispute, and the partitions
+// of the deadline before the dispute was executed.
+func (w *Watcher) collectEvidence(ctx context.Context, ts *types.TipSet, params miner.DisputeWindowedPoStParams) (*api.WdPoStDisputeEvidence, error) {
+	di, err := w.api.StateMinerProvingDeadline(ctx, w.maddr, ts.Parents())
+	if err != nil {
+		return nil, xerrors.Errorf("getting proving deadline: %w", err)
+	}
+
+	disputed := disputedWindow(di, params.Deadline)
+	ev := &api.WdPoStDisputeEvidence{
+		Open:  disputed.Open,
+		Close: disputed.Close,
+	}
+
+	// the messages included up to the close of the window are executed in the
+	// following tipset
+	var postIdx uint64
+	for h := disputed.Open + 1; h <= disputed.Close; h++ {
+		wts, err := w.api.ChainGetTipSetByHeight(ctx, h, ts.Key())
+		if err != nil {
+			return nil, xerrors.Errorf("getting tipset at %d: %w", h, err)
+		}
+		if wts.Height() != h {
+			continue
+		}
+
+		msgs, err := w.minerMessages(ctx, wts, builtintypes.MethodsMiner.SubmitWindowedPoSt)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range msgs {
+			var sp miner.SubmitWindowedPoStParams
+			if err := sp.UnmarshalCBOR(bytes.NewReader(m.msg.Params)); err != nil {
+				return nil, xerrors.Errorf("decoding submission %s: %w", m.cid, err)
+			}
+			if sp.Deadline != params.Deadline {
+				continue
+			}
+
+			sub := api.WdPoStSubmission{
+				Message:  m.cid,
+				From:     m.msg.From,
+				Height:   h,
+				ExitCode: m.receipt.ExitCode,
+				Params:   sp,
+			}
+			// the proofs of the accepted submissions are indexed in their order
+			if m.receipt.ExitCode.IsSuccess() {
+				sub.Disputed = postIdx == params.PoStIndex
+				postIdx++
+			}
+			ev.Submissions = append(ev.Submissions, sub)
+		}
+	}
+
+	ev.Partitions, err = w.api.StateMinerPartitions(ctx, w.maddr, params.Deadline, ts.Parents())
+	if err != nil {
+		return ev, xerrors.Errorf("getting partitions of deadline %d: %w", params.Deadline, err)
+	}
+	return ev, nil
+}
+
+// disputedWindow returns the last proving window of the deadline which closed
+// before the current epoch of di
+func disputedWindow(di *dline.Info, dlIdx uint64) *dline.Info {
+	info := dline.NewInfo(di.PeriodStart, dlIdx, di.CurrentEpoch, di.WPoStPeriodDeadlines, di.WPoStProvingPeriod, di.WPoStChallengeWindow, di.WPoStChallengeLookback, di.FaultDeclarationCutoff)
+	if !info.HasElapsed() {
+		info = dline.NewInfo(di.PeriodStart-di.WPoStProvingPeriod, dlIdx, di.CurrentEpoch, di.WPoStPeriodDeadlines, di.WPoStProvingPeriod, di.WPoStChallengeWindow, di.WPoStChallengeLookback, di.FaultDeclarationCutoff)
+	}
+	return info
+}
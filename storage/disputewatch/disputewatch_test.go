@@ -0,0 +1,208 @@
+// stm: #unit
+package disputewatch
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+	cbg "github.com/whyrusleeping/cbor-gen"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/abi"
+	builtintypes "github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/go-state-types/builtin/v9/miner"
+	"github.com/filecoin-project/go-state-types/dline"
+	"github.com/filecoin-project/go-state-types/exitcode"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/journal"
+	"github.com/filecoin-project/lotus/journal/alerting"
+)
+
+var (
+	maddr, _  = address.NewIDAddress(1000)
+	other, _  = address.NewIDAddress(2000)
+	sender, _ = address.NewIDAddress(3000)
+
+	// robust is the robust address of maddr
+	robust, _ = address.NewActorAddress([]byte("miner"))
+
+	dummyCid, _ = cid.Decode("bafy2bzacea3wsdh6y3a36tb3skempjoxqpuyompjbmfeyf34fi3uy6uue42v4")
+)
+
+type fakeChain struct {
+	tipsets  []*types.TipSet
+	executed map[abi.ChainEpoch][]executedMessage
+}
+
+func newFakeChain(height abi.ChainEpoch) *fakeChain {
+	fc := &fakeChain{executed: map[abi.ChainEpoch][]executedMessage{}}
+	var parents []cid.Cid
+	for h := abi.ChainEpoch(0); h <= height; h++ {
+		ts, err := types.NewTipSet([]*types.BlockHeader{{
+			Miner:                 maddr,
+			Ticket:                &types.Ticket{VRFProof: []byte{byte(h)}},
+			Parents:               parents,
+			ParentWeight:          types.NewInt(uint64(h)),
+			Height:                h,
+			ParentStateRoot:       dummyCid,
+			ParentMessageReceipts: dummyCid,
+			Messages:              dummyCid,
+		}})
+		if err != nil {
+			panic(err)
+		}
+		fc.tipsets = append(fc.tipsets, ts)
+		parents = ts.Cids()
+	}
+	return fc
+}
+
+func (fc *fakeChain) execute(t *testing.T, h abi.ChainEpoch, to address.Address, method abi.MethodNum, params cbg.CBORMarshaler, code exitcode.ExitCode) cid.Cid {
+	var buf bytes.Buffer
+	require.NoError(t, params.MarshalCBOR(&buf))
+	msg := &types.Message{
+		From:   sender,
+		To:     to,
+		Method: method,
+		Params: buf.Bytes(),
+		Nonce:  uint64(len(fc.executed[h])) + uint64(h)*100,
+	}
+	fc.executed[h] = append(fc.executed[h], executedMessage{cid: msg.Cid(), msg: msg, receipt: &types.MessageReceipt{ExitCode: code}})
+	return msg.Cid()
+}
+
+func (fc *fakeChain) height(blk cid.Cid) abi.ChainEpoch {
+	for _, ts := range fc.tipsets {
+		if ts.Cids()[0] == blk {
+			return ts.Height()
+		}
+	}
+	panic("unknown block")
+}
+
+func (fc *fakeChain) ChainNotify(context.Context) (<-chan []*api.HeadChange, error) {
+	return make(chan []*api.HeadChange), nil
+}
+
+func (fc *fakeChain) ChainGetTipSetByHeight(_ context.Context, h abi.ChainEpoch, _ types.TipSetKey) (*types.TipSet, error) {
+	return fc.tipsets[h], nil
+}
+
+func (fc *fakeChain) ChainGetParentMessages(_ context.Context, blk cid.Cid) ([]api.Message, error) {
+	var out []api.Message
+	for _, m := range fc.executed[fc.height(blk)] {
+		out = append(out, api.Message{Cid: m.cid, Message: m.msg})
+	}
+	return out, nil
+}
+
+func (fc *fakeChain) ChainGetParentReceipts(_ context.Context, blk cid.Cid) ([]*types.MessageReceipt, error) {
+	var out []*types.MessageReceipt
+	for _, m := range fc.executed[fc.height(blk)] {
+		out = append(out, m.receipt)
+	}
+	return out, nil
+}
+
+func (fc *fakeChain) StateLookupID(_ context.Context, a address.Address, _ types.TipSetKey) (address.Address, error) {
+	if a == robust {
+		return maddr, nil
+	}
+	return a, nil
+}
+
+// proving periods of 40 epochs, with 4 deadlines of 10 epochs
+func (fc *fakeChain) StateMinerProvingDeadline(_ context.Context, _ address.Address, tsk types.TipSetKey) (*dline.Info, error) {
+	ts := fc.tipsets[0]
+	for _, t := range fc.tipsets {
+		if t.Key() == tsk {
+			ts = t
+		}
+	}
+	return dline.NewInfo(0, uint64(ts.Height()%40/10), ts.Height(), 4, 40, 10, 5, 5), nil
+}
+
+func (fc *fakeChain) StateMinerPartitions(context.Context, address.Address, uint64, types.TipSetKey) ([]api.Partition, error) {
+	return []api.Partition{{AllSectors: bitfield.NewFromSet([]uint64{1, 2, 3})}}, nil
+}
+
+func post(dl uint64, parts ...uint64) *miner.SubmitWindowedPoStParams {
+	p := &miner.SubmitWindowedPoStParams{Deadline: dl}
+	for _, idx := range parts {
+		p.Partitions = append(p.Partitions, miner.PoStPartition{Index: idx, Skipped: bitfield.New()})
+	}
+	return p
+}
+
+func TestWatcher(t *testing.T) {
+	ctx := context.Background()
+	fc := newFakeChain(32)
+
+	// the proving window of deadline 1 is [10, 20)
+	sub0 := fc.execute(t, 11, maddr, builtintypes.MethodsMiner.SubmitWindowedPoSt, post(1, 0), exitcode.Ok)
+	fc.execute(t, 13, maddr, builtintypes.MethodsMiner.SubmitWindowedPoSt, post(2, 0), exitcode.Ok)
+	failed := fc.execute(t, 15, maddr, builtintypes.MethodsMiner.SubmitWindowedPoSt, post(1, 1), exitcode.ErrIllegalArgument)
+	sub1 := fc.execute(t, 20, robust, builtintypes.MethodsMiner.SubmitWindowedPoSt, post(1, 1), exitcode.Ok)
+	// executed after the window closed
+	fc.execute(t, 21, maddr, builtintypes.MethodsMiner.SubmitWindowedPoSt, post(1, 2), exitcode.Ok)
+
+	dispute := fc.execute(t, 30, robust, builtintypes.MethodsMiner.DisputeWindowedPoSt, &miner.DisputeWindowedPoStParams{Deadline: 1, PoStIndex: 1}, exitcode.Ok)
+	fc.execute(t, 30, other, builtintypes.MethodsMiner.DisputeWindowedPoSt, &miner.DisputeWindowedPoStParams{Deadline: 1}, exitcode.Ok)
+
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	al := alerting.NewAlertingSystem(journal.NilJournal())
+	w, err := New(fc, ds, maddr, al)
+	require.NoError(t, err)
+
+	// the first head is checked alone
+	require.NoError(t, w.HeadChange(ctx, fc.tipsets[29]))
+	require.Empty(t, w.List())
+
+	require.NoError(t, w.HeadChange(ctx, fc.tipsets[31]))
+	disputes := w.List()
+	require.Len(t, disputes, 1)
+	d := disputes[0]
+	require.Equal(t, dispute, d.Message)
+	require.Equal(t, abi.ChainEpoch(30), d.Height)
+	require.Equal(t, uint64(1), d.Deadline)
+	require.True(t, d.Successful)
+	require.Empty(t, d.EvidenceError)
+
+	require.Equal(t, abi.ChainEpoch(10), d.Evidence.Open)
+	require.Equal(t, abi.ChainEpoch(20), d.Evidence.Close)
+	require.Len(t, d.Evidence.Submissions, 3)
+	require.Equal(t, sub0, d.Evidence.Submissions[0].Message)
+	require.False(t, d.Evidence.Submissions[0].Disputed)
+	require.Equal(t, failed, d.Evidence.Submissions[1].Message)
+	require.False(t, d.Evidence.Submissions[1].Disputed)
+	require.Equal(t, sub1, d.Evidence.Submissions[2].Message)
+	require.True(t, d.Evidence.Submissions[2].Disputed)
+	require.Equal(t, uint64(1), d.Evidence.Submissions[2].Params.Partitions[0].Index)
+	require.Len(t, d.Evidence.Partitions, 1)
+
+	require.True(t, al.IsRaised(w.alert))
+
+	// the disputes are kept across restarts
+	require.NoError(t, w.HeadChange(ctx, fc.tipsets[32]))
+	w, err = New(fc, ds, maddr, al)
+	require.NoError(t, err)
+	reloaded := w.List()
+	require.Len(t, reloaded, 1)
+	require.Equal(t, dispute, reloaded[0].Message)
+	require.Len(t, reloaded[0].Evidence.Submissions, 3)
+	require.True(t, reloaded[0].Evidence.Submissions[2].Disputed)
+	require.True(t, al.IsRaised(w.alert))
+
+	require.Error(t, w.Ack(ctx, []cid.Cid{sub0}))
+	require.NoError(t, w.Ack(ctx, []cid.Cid{dispute}))
+	require.True(t, w.List()[0].Acknowledged)
+	require.False(t, al.IsRaised(w.alert))
+}
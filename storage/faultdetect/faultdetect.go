@@ -0,0 +1,504 @@
+// Package faultdetect watches the storage index for proving sectors which lost
+// their sealed copy, for example when the storage path holding it was declared
+// dead, and declares them faulty ahead of the proving window of their deadline.
+package faultdetect
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin/v9/miner"
+	"github.com/filecoin-project/go-state-types/dline"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+var log = logging.Logger("faultdetect")
+
+type ChainAPI interface {
+	ChainHead(context.Context) (*types.TipSet, error)
+	StateMinerProvingDeadline(context.Context, address.Address, types.TipSetKey) (*dline.Info, error)
+	StateMinerPartitions(ctx context.Context, m address.Address, dlIdx uint64, tsk types.TipSetKey) ([]api.Partition, error)
+	StateSearchMsg(ctx context.Context, from types.TipSetKey, msg cid.Cid, limit abi.ChainEpoch, allowReplaced bool) (*api.MsgLookup, error)
+}
+
+type SectorIndex interface {
+	StorageList(ctx context.Context) (map[storiface.ID][]storiface.Decl, error)
+	StorageHealthy(id storiface.ID) bool
+}
+
+type Declarer interface {
+	DeclareFaults(ctx context.Context, faults []miner.FaultDeclaration) (cid.Cid, error)
+}
+
+type Config struct {
+	// Declare sends DeclareFaults messages for the detected faults, otherwise
+	// they are only reported
+	Declare bool
+	// Approval requires the operator to approve the declarations
+	Approval bool
+	// GracePeriod is how long a sector stays without a healthy sealed copy
+	// before it is considered faulty
+	GracePeriod   time.Duration
+	CheckInterval time.Duration
+}
+
+type fault struct {
+	api.DetectedFault
+
+	rejected bool
+}
+
+func (f *fault) setState(st api.DetectedFaultState) {
+	if f.State == st {
+		return
+	}
+	f.State = st
+	f.Since = time.Now()
+}
+
+// location is where a lost sector is proven.
+type location struct {
+	deadline, partition uint64
+	open, cutoff        abi.ChainEpoch
+	cutoffPassed        bool
+}
+
+type Detector struct {
+	cfg      Config
+	maddr    address.Address
+	miner    abi.ActorID
+	chain    ChainAPI
+	index    SectorIndex
+	declarer Declarer
+
+	lk     sync.Mutex
+	faults map[abi.SectorNumber]*fault
+	// known are the sectors which had a healthy sealed copy since the start,
+	// only those are considered lost when the copy goes away, so that the paths
+	// not attached yet on startup don't look like faults.
+	known map[abi.SectorNumber]struct{}
+
+	kick   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func New(cfg Config, maddr address.Address, chain ChainAPI, index SectorIndex, declarer Declarer) (*Detector, error) {
+	mid, err := address.IDFromAddress(maddr)
+	if err != nil {
+		return nil, xerrors.Errorf("getting miner id: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Detector{
+		cfg:      cfg,
+		maddr:    maddr,
+		miner:    abi.ActorID(mid),
+		chain:    chain,
+		index:    index,
+		declarer: declarer,
+
+		faults: map[abi.SectorNumber]*fault{},
+		known:  map[abi.SectorNumber]struct{}{},
+
+		kick:   make(chan struct{}, 1),
+		ctx:    ctx,
+		cancel: cancel,
+	}, nil
+}
+
+func (d *Detector) Start() {
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+
+		tick := time.NewTicker(d.cfg.CheckInterval)
+		defer tick.Stop()
+
+		for {
+			if err := d.check(d.ctx); err != nil {
+				log.Errorw("checking sealed copies", "error", err)
+			}
+
+			select {
+			case <-tick.C:
+			case <-d.kick:
+			case <-d.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (d *Detector) Stop(ctx context.Context) error {
+	d.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// List returns the detected faults ordered by deadline and sector number.
+func (d *Detector) List() []api.DetectedFault {
+	d.lk.Lock()
+	defer d.lk.Unlock()
+
+	out := make([]api.DetectedFault, 0, len(d.faults))
+	for _, f := range d.faults {
+		out = append(out, f.DetectedFault)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Deadline != out[j].Deadline {
+			return out[i].Deadline < out[j].Deadline
+		}
+		return out[i].Sector < out[j].Sector
+	})
+	return out
+}
+
+func (d *Detector) Approve(sectors []abi.SectorNumber) error {
+	if !d.cfg.Declare {
+		return xerrors.Errorf("declaring detected faults not enabled. Please check your configuration")
+	}
+
+	d.lk.Lock()
+	defer d.lk.Unlock()
+
+	if err := d.checkSectors(sectors); err != nil {
+		return err
+	}
+	for _, s := range sectors {
+		f := d.faults[s]
+		f.Approved = true
+		f.rejected = false
+	}
+
+	// declare the approved faults right away, the cutoff may be close
+	select {
+	case d.kick <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (d *Detector) Reject(sectors []abi.SectorNumber) error {
+	d.lk.Lock()
+	defer d.lk.Unlock()
+
+	if err := d.checkSectors(sectors); err != nil {
+		return err
+	}
+	for _, s := range sectors {
+		if d.faults[s].State == api.DetectedFaultDeclared {
+			return xerrors.Errorf("fault of sector %d already declared", s)
+		}
+	}
+	for _, s := range sectors {
+		f := d.faults[s]
+		f.Approved = false
+		f.rejected = true
+		f.setState(api.DetectedFaultRejected)
+	}
+	return nil
+}
+
+// checkSectors must be called with d.lk held.
+func (d *Detector) checkSectors(sectors []abi.SectorNumber) error {
+	for _, s := range sectors {
+		if _, ok := d.faults[s]; !ok {
+			return xerrors.Errorf("no fault detected for sector %d", s)
+		}
+	}
+	return nil
+}
+
+// check finds the live sectors without a healthy sealed copy, updates the
+// faults and declares the ones ready to be declared.
+func (d *Detector) check(ctx context.Context) error {
+	lost, err := d.lostSectors(ctx)
+	if err != nil {
+		return err
+	}
+
+	head, err := d.chain.ChainHead(ctx)
+	if err != nil {
+		return xerrors.Errorf("getting chain head: %w", err)
+	}
+
+	var locs map[abi.SectorNumber]location
+	if len(lost) > 0 {
+		// the chain is only walked when the index lost sectors, which is rare
+		locs, err = d.locate(ctx, head.Key(), lost)
+		if err != nil {
+			return err
+		}
+	}
+
+	d.lk.Lock()
+	for s := range lost {
+		if _, ok := locs[s]; !ok {
+			// not live anymore, or already faulty
+			delete(d.known, s)
+		}
+	}
+	for s := range d.faults {
+		if _, ok := locs[s]; !ok {
+			log.Infow("detected fault resolved", "sector", s)
+			delete(d.faults, s)
+		}
+	}
+
+	now := time.Now()
+	for s, loc := range locs {
+		f, ok := d.faults[s]
+		if !ok {
+			f = &fault{DetectedFault: api.DetectedFault{
+				Sector:   s,
+				Detected: now,
+				Approved: !d.cfg.Approval,
+			}}
+			d.faults[s] = f
+			log.Warnw("sector lost its sealed copy", "sector", s, "deadline", loc.deadline, "partition", loc.partition)
+		}
+
+		f.Deadline, f.Partition = loc.deadline, loc.partition
+		f.Open, f.Cutoff = loc.open, loc.cutoff
+		d.updateState(f, loc, now)
+	}
+	declared := d.declaredFaults()
+	d.lk.Unlock()
+
+	d.checkMessages(ctx, head.Key(), declared)
+	return d.declare(ctx)
+}
+
+// updateState must be called with d.lk held.
+func (d *Detector) updateState(f *fault, loc location, now time.Time) {
+	switch {
+	case f.State == api.DetectedFaultDeclared:
+		// until it lands on chain, or fails
+	case now.Sub(f.Detected) < d.cfg.GracePeriod:
+		f.setState(api.DetectedFaultPending)
+	case !d.cfg.Declare:
+		f.setState(api.DetectedFaultReported)
+	case f.rejected:
+		f.setState(api.DetectedFaultRejected)
+	case !f.Approved:
+		f.setState(api.DetectedFaultAwaitingApproval)
+	case loc.cutoffPassed:
+		// the window post of the deadline will skip the sector
+		f.setState(api.DetectedFaultTooLate)
+	default:
+		// the failed declarations are retried once per check
+		f.setState(api.DetectedFaultQueued)
+	}
+}
+
+// declaredFaults returns the messages of the declared faults. Must be called
+// with d.lk held.
+func (d *Detector) declaredFaults() map[cid.Cid][]abi.SectorNumber {
+	out := map[cid.Cid][]abi.SectorNumber{}
+	for s, f := range d.faults {
+		if f.State == api.DetectedFaultDeclared && f.Message != nil {
+			out[*f.Message] = append(out[*f.Message], s)
+		}
+	}
+	return out
+}
+
+// checkMessages marks the faults declared by failed messages as failed.
+func (d *Detector) checkMessages(ctx context.Context, tsk types.TipSetKey, declared map[cid.Cid][]abi.SectorNumber) {
+	for mcid, sectors := range declared {
+		ml, err := d.chain.StateSearchMsg(ctx, tsk, mcid, api.LookbackNoLimit, true)
+		if err != nil {
+			log.Errorw("searching fault declaration message", "message", mcid, "error", err)
+			continue
+		}
+		if ml == nil || ml.Receipt.ExitCode.IsSuccess() {
+			continue
+		}
+
+		d.lk.Lock()
+		for _, s := range sectors {
+			if f, ok := d.faults[s]; ok && f.State == api.DetectedFaultDeclared {
+				f.LastError = xerrors.Errorf("declaration message %s exited with code %d", mcid, ml.Receipt.ExitCode).Error()
+				f.setState(api.DetectedFaultFailed)
+			}
+		}
+		d.lk.Unlock()
+	}
+}
+
+// declare sends a single DeclareFaults message for the queued faults.
+func (d *Detector) declare(ctx context.Context) error {
+	d.lk.Lock()
+	parts := map[uint64]map[uint64][]uint64{}
+	var sectors []abi.SectorNumber
+	for s, f := range d.faults {
+		if f.State != api.DetectedFaultQueued {
+			continue
+		}
+		if parts[f.Deadline] == nil {
+			parts[f.Deadline] = map[uint64][]uint64{}
+		}
+		parts[f.Deadline][f.Partition] = append(parts[f.Deadline][f.Partition], uint64(s))
+		sectors = append(sectors, s)
+	}
+	d.lk.Unlock()
+
+	if len(sectors) == 0 {
+		return nil
+	}
+
+	var decls []miner.FaultDeclaration
+	for dl, dlParts := range parts {
+		for p, nums := range dlParts {
+			decls = append(decls, miner.FaultDeclaration{
+				Deadline:  dl,
+				Partition: p,
+				Sectors:   bitfield.NewFromSet(nums),
+			})
+		}
+	}
+	sort.Slice(decls, func(i, j int) bool {
+		if decls[i].Deadline != decls[j].Deadline {
+			return decls[i].Deadline < decls[j].Deadline
+		}
+		return decls[i].Partition < decls[j].Partition
+	})
+
+	mcid, err := d.declarer.DeclareFaults(ctx, decls)
+
+	d.lk.Lock()
+	defer d.lk.Unlock()
+
+	for _, s := range sectors {
+		f, ok := d.faults[s]
+		if !ok || f.State != api.DetectedFaultQueued {
+			continue
+		}
+		if err != nil {
+			f.LastError = err.Error()
+			f.setState(api.DetectedFaultFailed)
+			continue
+		}
+		m := mcid
+		f.Message = &m
+		f.LastError = ""
+		f.setState(api.DetectedFaultDeclared)
+	}
+	if err != nil {
+		return xerrors.Errorf("declaring faults: %w", err)
+	}
+	log.Warnw("declared detected faults", "message", mcid, "sectors", len(sectors))
+	return nil
+}
+
+// lostSectors returns the known sectors without a healthy sealed copy, and
+// records the sectors with one as known.
+func (d *Detector) lostSectors(ctx context.Context) (map[abi.SectorNumber]struct{}, error) {
+	decls, err := d.index.StorageList(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("listing storage: %w", err)
+	}
+
+	files := map[abi.SectorNumber]storiface.SectorFileType{}
+	for id, sectors := range decls {
+		if !d.index.StorageHealthy(id) {
+			continue
+		}
+		for _, decl := range sectors {
+			if decl.Miner == d.miner {
+				files[decl.Number] |= decl.SectorFileType
+			}
+		}
+	}
+
+	d.lk.Lock()
+	defer d.lk.Unlock()
+
+	lost := map[abi.SectorNumber]struct{}{}
+	for s, ft := range files {
+		if provable(ft) {
+			d.known[s] = struct{}{}
+		}
+	}
+	for s := range d.known {
+		if !provable(files[s]) {
+			lost[s] = struct{}{}
+		}
+	}
+	return lost, nil
+}
+
+// provable returns whether the files can be used to prove the sector.
+func provable(ft storiface.SectorFileType) bool {
+	return (ft.Has(storiface.FTSealed) && ft.Has(storiface.FTCache)) || (ft.Has(storiface.FTUpdate) && ft.Has(storiface.FTUpdateCache))
+}
+
+// locate returns the deadline and partition of the lost sectors which are live
+// and not faulty on chain, with the next proving window of their deadline.
+func (d *Detector) locate(ctx context.Context, tsk types.TipSetKey, lost map[abi.SectorNumber]struct{}) (map[abi.SectorNumber]location, error) {
+	di, err := d.chain.StateMinerProvingDeadline(ctx, d.maddr, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("getting proving deadline: %w", err)
+	}
+
+	out := map[abi.SectorNumber]location{}
+	for dl := uint64(0); dl < di.WPoStPeriodDeadlines; dl++ {
+		parts, err := d.chain.StateMinerPartitions(ctx, d.maddr, dl, tsk)
+		if err != nil {
+			return nil, xerrors.Errorf("getting partitions of deadline %d: %w", dl, err)
+		}
+
+		next := dline.NewInfo(di.PeriodStart, dl, di.CurrentEpoch, di.WPoStPeriodDeadlines, di.WPoStProvingPeriod, di.WPoStChallengeWindow, di.WPoStChallengeLookback, di.FaultDeclarationCutoff).NextNotElapsed()
+
+		for pIdx, p := range parts {
+			// the recovering sectors are faulty too
+			healthy, err := bitfield.SubtractBitField(p.LiveSectors, p.FaultySectors)
+			if err != nil {
+				return nil, xerrors.Errorf("subtracting faulty sectors: %w", err)
+			}
+
+			err = healthy.ForEach(func(n uint64) error {
+				if _, ok := lost[abi.SectorNumber(n)]; !ok {
+					return nil
+				}
+				out[abi.SectorNumber(n)] = location{
+					deadline:     dl,
+					partition:    uint64(pIdx),
+					open:         next.Open,
+					cutoff:       next.FaultCutoff,
+					cutoffPassed: next.FaultCutoffPassed(),
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return out, nil
+}
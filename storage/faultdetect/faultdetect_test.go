@@ -0,0 +1,216 @@
+// stm: #unit
+package faultdetect
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin/v9/miner"
+	"github.com/filecoin-project/go-state-types/dline"
+	"github.com/filecoin-project/go-state-types/exitcode"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+var maddr = mock.Address(1000)
+
+type fakeChain struct {
+	partitions map[uint64][]api.Partition
+	failed     map[cid.Cid]bool
+}
+
+func (fakeChain) ChainHead(context.Context) (*types.TipSet, error) {
+	return mock.TipSet(mock.MkBlock(nil, 0, 0)), nil
+}
+
+// epoch 10, in the proving window of deadline 0, with a fault declaration
+// cutoff of 10 epochs
+func (fakeChain) StateMinerProvingDeadline(context.Context, address.Address, types.TipSetKey) (*dline.Info, error) {
+	return dline.NewInfo(0, 0, 10, 48, 2880, 60, 20, 10), nil
+}
+
+func (fc *fakeChain) StateMinerPartitions(_ context.Context, _ address.Address, dlIdx uint64, _ types.TipSetKey) ([]api.Partition, error) {
+	return fc.partitions[dlIdx], nil
+}
+
+func (fc *fakeChain) StateSearchMsg(_ context.Context, _ types.TipSetKey, msg cid.Cid, _ abi.ChainEpoch, _ bool) (*api.MsgLookup, error) {
+	ml := &api.MsgLookup{Message: msg}
+	if fc.failed[msg] {
+		ml.Receipt.ExitCode = exitcode.ErrIllegalArgument
+	}
+	return ml, nil
+}
+
+type fakeIndex struct {
+	decls   map[storiface.ID][]storiface.Decl
+	healthy map[storiface.ID]bool
+}
+
+func (f *fakeIndex) StorageList(context.Context) (map[storiface.ID][]storiface.Decl, error) {
+	return f.decls, nil
+}
+
+func (f *fakeIndex) StorageHealthy(id storiface.ID) bool {
+	return f.healthy[id]
+}
+
+type fakeDeclarer struct {
+	declared [][]miner.FaultDeclaration
+}
+
+func (f *fakeDeclarer) DeclareFaults(_ context.Context, faults []miner.FaultDeclaration) (cid.Cid, error) {
+	f.declared = append(f.declared, faults)
+	return (&types.Message{To: maddr, From: maddr, Nonce: uint64(len(f.declared))}).Cid(), nil
+}
+
+func sealed(n abi.SectorNumber) []storiface.Decl {
+	sid := abi.SectorID{Miner: 1000, Number: n}
+	return []storiface.Decl{
+		{SectorID: sid, SectorFileType: storiface.FTSealed},
+		{SectorID: sid, SectorFileType: storiface.FTCache},
+	}
+}
+
+func partition(live, faulty []uint64) api.Partition {
+	return api.Partition{
+		LiveSectors:   bitfield.NewFromSet(live),
+		FaultySectors: bitfield.NewFromSet(faulty),
+	}
+}
+
+func states(d *Detector) map[abi.SectorNumber]api.DetectedFaultState {
+	out := map[abi.SectorNumber]api.DetectedFaultState{}
+	for _, f := range d.List() {
+		out[f.Sector] = f.State
+	}
+	return out
+}
+
+func TestDetector(t *testing.T) {
+	ctx := context.Background()
+
+	fc := &fakeChain{
+		partitions: map[uint64][]api.Partition{
+			0: {partition([]uint64{4}, nil)},
+			1: {partition([]uint64{1, 2, 3}, []uint64{3})},
+		},
+		failed: map[cid.Cid]bool{},
+	}
+
+	var decls []storiface.Decl
+	for _, n := range []abi.SectorNumber{1, 2, 3, 4, 5} {
+		decls = append(decls, sealed(n)...)
+	}
+	idx := &fakeIndex{
+		decls:   map[storiface.ID][]storiface.Decl{"path-a": decls},
+		healthy: map[storiface.ID]bool{"path-a": true},
+	}
+	dec := &fakeDeclarer{}
+
+	d, err := New(Config{Declare: true, Approval: true}, maddr, fc, idx, dec)
+	require.NoError(t, err)
+
+	require.NoError(t, d.check(ctx))
+	require.Empty(t, d.List())
+
+	// the path holding the sealed copies is declared dead
+	idx.healthy["path-a"] = false
+	require.NoError(t, d.check(ctx))
+
+	// sector 3 is already faulty and sector 5 isn't live
+	require.Equal(t, map[abi.SectorNumber]api.DetectedFaultState{
+		1: api.DetectedFaultAwaitingApproval,
+		2: api.DetectedFaultAwaitingApproval,
+		4: api.DetectedFaultAwaitingApproval,
+	}, states(d))
+
+	faults := d.List()
+	require.Equal(t, abi.SectorNumber(4), faults[0].Sector)
+	require.Equal(t, uint64(1), faults[1].Deadline)
+	require.Equal(t, abi.ChainEpoch(60), faults[1].Open)
+	require.Equal(t, abi.ChainEpoch(50), faults[1].Cutoff)
+
+	require.Error(t, d.Approve([]abi.SectorNumber{1, 99}))
+	require.NoError(t, d.Approve([]abi.SectorNumber{1, 4}))
+	require.NoError(t, d.Reject([]abi.SectorNumber{2}))
+	require.NoError(t, d.check(ctx))
+
+	// deadline 0 is being proven, it's too late to declare sector 4 faulty
+	require.Equal(t, map[abi.SectorNumber]api.DetectedFaultState{
+		1: api.DetectedFaultDeclared,
+		2: api.DetectedFaultRejected,
+		4: api.DetectedFaultTooLate,
+	}, states(d))
+	require.Len(t, dec.declared, 1)
+	require.Len(t, dec.declared[0], 1)
+	require.Equal(t, uint64(1), dec.declared[0][0].Deadline)
+	n, err := dec.declared[0][0].Sectors.All(10)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{1}, n)
+
+	require.Error(t, d.Reject([]abi.SectorNumber{1}))
+
+	// the declaration fails on chain, and is retried on the next check
+	fc.failed[*d.List()[1].Message] = true
+	require.NoError(t, d.check(ctx))
+	require.Equal(t, api.DetectedFaultFailed, states(d)[1])
+	require.NoError(t, d.check(ctx))
+	require.Equal(t, api.DetectedFaultDeclared, states(d)[1])
+	require.Len(t, dec.declared, 2)
+
+	// the declaration landed
+	fc.partitions[1] = []api.Partition{partition([]uint64{1, 2, 3}, []uint64{1, 3})}
+	require.NoError(t, d.check(ctx))
+	require.Equal(t, map[abi.SectorNumber]api.DetectedFaultState{
+		2: api.DetectedFaultRejected,
+		4: api.DetectedFaultTooLate,
+	}, states(d))
+
+	// the path comes back
+	idx.healthy["path-a"] = true
+	require.NoError(t, d.check(ctx))
+	require.Empty(t, d.List())
+}
+
+func TestDetectorReportOnly(t *testing.T) {
+	ctx := context.Background()
+
+	fc := &fakeChain{partitions: map[uint64][]api.Partition{
+		1: {partition([]uint64{1}, nil)},
+	}}
+	idx := &fakeIndex{
+		decls:   map[storiface.ID][]storiface.Decl{"path-a": sealed(1)},
+		healthy: map[storiface.ID]bool{"path-a": true},
+	}
+	dec := &fakeDeclarer{}
+
+	d, err := New(Config{GracePeriod: time.Hour}, maddr, fc, idx, dec)
+	require.NoError(t, err)
+
+	// the sectors only stored in paths never seen healthy aren't faulty
+	idx.healthy["path-a"] = false
+	require.NoError(t, d.check(ctx))
+	require.Empty(t, d.List())
+
+	idx.healthy["path-a"] = true
+	require.NoError(t, d.check(ctx))
+	idx.healthy["path-a"] = false
+	require.NoError(t, d.check(ctx))
+	require.Equal(t, api.DetectedFaultPending, states(d)[1])
+
+	d.cfg.GracePeriod = 0
+	require.NoError(t, d.check(ctx))
+	require.Equal(t, api.DetectedFaultReported, states(d)[1])
+	require.Error(t, d.Approve([]abi.SectorNumber{1}))
+	require.Empty(t, dec.declared)
+}
@@ -206,6 +206,7 @@ func (i *Index) StorageAttach(ctx context.Context, si storiface.StorageInfo, st
 		i.stores[si.ID].info.AllowTo = si.AllowTo
 		i.stores[si.ID].info.AllowTypes = allow
 		i.stores[si.ID].info.DenyTypes = deny
+		i.stores[si.ID].info.Labels = si.Labels
 
 		return nil
 	}
@@ -349,6 +350,19 @@ func (i *Index) StorageReportHealth(ctx context.Context, id storiface.ID, report
 	return nil
 }
 
+// StorageHealthy returns whether the storage path is attached and reported a
+// heartbeat without an error recently.
+func (i *Index) StorageHealthy(id storiface.ID) bool {
+	i.lk.RLock()
+	defer i.lk.RUnlock()
+
+	ent, ok := i.stores[id]
+	if !ok {
+		return false
+	}
+	return ent.heartbeatErr == nil && time.Since(ent.lastHeartbeat) <= SkippedHeartbeatThresh
+}
+
 func (i *Index) StorageDeclareSector(ctx context.Context, storageID storiface.ID, s abi.SectorID, ft storiface.SectorFileType, primary bool) error {
 	i.lk.Lock()
 	defer i.lk.Unlock()
@@ -190,6 +190,7 @@ func (st *Local) OpenPath(ctx context.Context, p string) error {
 		AllowTo:    meta.AllowTo,
 		AllowTypes: meta.AllowTypes,
 		DenyTypes:  meta.DenyTypes,
+		Labels:     meta.Labels,
 	}, fst)
 	if err != nil {
 		return xerrors.Errorf("declaring storage in index: %w", err)
@@ -280,6 +281,7 @@ func (st *Local) Redeclare(ctx context.Context, filterId *storiface.ID, dropMiss
 			AllowTo:    meta.AllowTo,
 			AllowTypes: meta.AllowTypes,
 			DenyTypes:  meta.DenyTypes,
+			Labels:     meta.Labels,
 		}, fst)
 		if err != nil {
 			return xerrors.Errorf("redeclaring storage in index: %w", err)
@@ -514,6 +516,9 @@ func (st *Local) AcquireSector(ctx context.Context, sid storiface.SectorRef, exi
 		if err != nil {
 			return storiface.SectorPaths{}, storiface.SectorPaths{}, xerrors.Errorf("finding best storage for allocating : %w", err)
 		}
+		if pathType == storiface.PathStorage {
+			sis = preferSeparateDomains(ctx, st.index, sid.ID, fileType, sis)
+		}
 
 		var best string
 		var bestID storiface.ID
@@ -0,0 +1,159 @@
+package paths
+
+import (
+	"context"
+	"sort"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+// preferSeparateDomains orders the paths where a file type of a sector can be
+// allocated in long-term storage so that the paths sharing the narrowest
+// failure domain with the other copies of the sector data come last. The order
+// of the paths is kept otherwise.
+func preferSeparateDomains(ctx context.Context, index SectorIndex, sector abi.SectorID, ft storiface.SectorFileType, sis []storiface.StorageInfo) []storiface.StorageInfo {
+	other := ft.RedundantWith()
+	if other == storiface.FTNone || len(sis) < 2 {
+		return sis
+	}
+
+	found, err := index.StorageFindSector(ctx, sector, other, 0, false)
+	if err != nil {
+		log.Warnw("finding the copies of the sector data", "sector", sector, "error", err)
+		return sis
+	}
+
+	// copies in sealing paths are moved to long-term storage, or removed
+	var copies []storiface.StorageInfo
+	for _, f := range found {
+		if !f.CanStore {
+			continue
+		}
+		si, err := index.StorageInfo(ctx, f.ID)
+		if err != nil {
+			log.Warnw("getting the path of a copy of the sector data", "sector", sector, "path", f.ID, "error", err)
+			continue
+		}
+		copies = append(copies, si)
+	}
+	if len(copies) == 0 {
+		return sis
+	}
+
+	levels := make(map[storiface.ID]int, len(sis))
+	for _, si := range sis {
+		for _, c := range copies {
+			shared := storiface.SharedFailureDomain(si.Labels, c.Labels)
+			if si.ID == c.ID {
+				shared = storiface.SamePath
+			}
+			if l := storiface.FailureDomainLevel(shared); l > levels[si.ID] {
+				levels[si.ID] = l
+			}
+		}
+	}
+
+	out := append([]storiface.StorageInfo(nil), sis...)
+	sort.SliceStable(out, func(i, j int) bool {
+		return levels[out[i].ID] < levels[out[j].ID]
+	})
+	return out
+}
+
+// PlacementReport checks how the copies of the data of the sectors, sealed or
+// unsealed, are spread over the failure domains of the long-term storage paths.
+func PlacementReport(ctx context.Context, index SectorIndex) (*storiface.PlacementReport, error) {
+	decls, err := index.StorageList(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("listing storage: %w", err)
+	}
+
+	out := &storiface.PlacementReport{}
+	domains := map[string]*storiface.DomainPlacement{}
+	labels := map[storiface.ID]map[string]string{}
+	copies := map[abi.SectorID][]storiface.SectorCopy{}
+
+	for id, ds := range decls {
+		si, err := index.StorageInfo(ctx, id)
+		if err != nil {
+			return nil, xerrors.Errorf("getting storage info for %s: %w", id, err)
+		}
+		if !si.CanStore {
+			continue
+		}
+		labels[id] = si.Labels
+
+		domain := storiface.FailureDomain(si.Labels)
+		if domain == "" {
+			out.Unlabeled = append(out.Unlabeled, id)
+		} else {
+			if domains[domain] == nil {
+				domains[domain] = &storiface.DomainPlacement{Domain: domain}
+			}
+			domains[domain].Paths = append(domains[domain].Paths, id)
+		}
+
+		for _, d := range ds {
+			for _, ft := range storiface.PathTypes {
+				if d.SectorFileType&ft&storiface.FTCopies == 0 {
+					continue
+				}
+				copies[d.SectorID] = append(copies[d.SectorID], storiface.SectorCopy{
+					Storage:  id,
+					FileType: ft,
+					Domain:   domain,
+				})
+				if domain != "" {
+					domains[domain].Copies++
+				}
+			}
+		}
+	}
+
+	for sector, cs := range copies {
+		if len(cs) < 2 {
+			continue
+		}
+		out.Sectors++
+
+		var shared string
+		for i := range cs {
+			for j := i + 1; j < len(cs); j++ {
+				s := storiface.SharedFailureDomain(labels[cs[i].Storage], labels[cs[j].Storage])
+				if cs[i].Storage == cs[j].Storage {
+					s = storiface.SamePath
+				}
+				if storiface.FailureDomainLevel(s) > storiface.FailureDomainLevel(shared) {
+					shared = s
+				}
+			}
+		}
+		if shared != "" {
+			sort.Slice(cs, func(i, j int) bool {
+				if cs[i].Storage != cs[j].Storage {
+					return cs[i].Storage < cs[j].Storage
+				}
+				return cs[i].FileType < cs[j].FileType
+			})
+			out.CoLocated = append(out.CoLocated, storiface.SectorPlacement{
+				Sector: sector,
+				Shared: shared,
+				Copies: cs,
+			})
+		}
+	}
+
+	for _, d := range domains {
+		sort.Slice(d.Paths, func(i, j int) bool {
+			return d.Paths[i] < d.Paths[j]
+		})
+		out.Domains = append(out.Domains, *d)
+	}
+	out.SortPlacement()
+
+	return out, nil
+}
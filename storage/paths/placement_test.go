@@ -0,0 +1,105 @@
+// stm: #unit
+package paths
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/storage/sealer/fsutil"
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+func TestSharedFailureDomain(t *testing.T) {
+	a := map[string]string{"region": "eu", "rack": "r1", "host": "h1"}
+
+	require.Equal(t, "host", storiface.SharedFailureDomain(a, map[string]string{"region": "eu", "rack": "r1", "host": "h1"}))
+	require.Equal(t, "rack", storiface.SharedFailureDomain(a, map[string]string{"region": "eu", "rack": "r1", "host": "h2"}))
+	require.Equal(t, "region", storiface.SharedFailureDomain(a, map[string]string{"region": "eu", "rack": "r2", "host": "h1"}))
+	require.Equal(t, "", storiface.SharedFailureDomain(a, map[string]string{"region": "us", "rack": "r1", "host": "h1"}))
+	// the region isn't known
+	require.Equal(t, "rack", storiface.SharedFailureDomain(a, map[string]string{"rack": "r1", "host": "h2"}))
+	require.Equal(t, "", storiface.SharedFailureDomain(a, nil))
+}
+
+func TestPlacement(t *testing.T) {
+	ctx := context.Background()
+	idx := NewIndex(nil)
+
+	attach := func(id storiface.ID, canSeal bool, labels map[string]string) {
+		require.NoError(t, idx.StorageAttach(ctx, storiface.StorageInfo{
+			ID:       id,
+			Weight:   10,
+			CanSeal:  canSeal,
+			CanStore: !canSeal,
+			Labels:   labels,
+		}, fsutil.FsStat{Capacity: 1 << 40, Available: 1 << 40}))
+	}
+	attach("seal", true, map[string]string{"region": "eu", "rack": "r1", "host": "h1"})
+	attach("h1", false, map[string]string{"region": "eu", "rack": "r1", "host": "h1"})
+	attach("h2", false, map[string]string{"region": "eu", "rack": "r1", "host": "h2"})
+	attach("r2", false, map[string]string{"region": "eu", "rack": "r2", "host": "h3"})
+	attach("none", false, nil)
+
+	s1 := abi.SectorID{Miner: 1000, Number: 1}
+	s2 := abi.SectorID{Miner: 1000, Number: 2}
+	s3 := abi.SectorID{Miner: 1000, Number: 3}
+
+	// sector 1 is sealed in h1, with a copy in the sealing path
+	require.NoError(t, idx.StorageDeclareSector(ctx, "h1", s1, storiface.FTSealed|storiface.FTCache, true))
+	require.NoError(t, idx.StorageDeclareSector(ctx, "seal", s1, storiface.FTUnsealed, true))
+
+	sis := []storiface.StorageInfo{
+		{ID: "h1", Labels: map[string]string{"region": "eu", "rack": "r1", "host": "h1"}},
+		{ID: "h2", Labels: map[string]string{"region": "eu", "rack": "r1", "host": "h2"}},
+		{ID: "none"},
+		{ID: "r2", Labels: map[string]string{"region": "eu", "rack": "r2", "host": "h3"}},
+	}
+	ids := func(sis []storiface.StorageInfo) []storiface.ID {
+		var out []storiface.ID
+		for _, si := range sis {
+			out = append(out, si.ID)
+		}
+		return out
+	}
+
+	// the unsealed copy goes as far as possible from the sealed one
+	require.Equal(t, []storiface.ID{"none", "r2", "h2", "h1"}, ids(preferSeparateDomains(ctx, idx, s1, storiface.FTUnsealed, sis)))
+	// the cache follows the sealed file
+	require.Equal(t, ids(sis), ids(preferSeparateDomains(ctx, idx, s1, storiface.FTCache, sis)))
+	// the copy in the sealing path is ignored
+	require.Equal(t, ids(sis), ids(preferSeparateDomains(ctx, idx, s1, storiface.FTSealed, sis)))
+
+	require.NoError(t, idx.StorageDeclareSector(ctx, "h2", s1, storiface.FTUnsealed, true))
+	require.NoError(t, idx.StorageDropSector(ctx, "seal", s1, storiface.FTUnsealed))
+
+	// sector 2 has both copies in the same path, sector 3 in different racks of
+	// the same region
+	require.NoError(t, idx.StorageDeclareSector(ctx, "none", s2, storiface.FTSealed|storiface.FTUnsealed, true))
+	require.NoError(t, idx.StorageDeclareSector(ctx, "h1", s3, storiface.FTSealed, true))
+	require.NoError(t, idx.StorageDeclareSector(ctx, "r2", s3, storiface.FTUnsealed, true))
+
+	r, err := PlacementReport(ctx, idx)
+	require.NoError(t, err)
+
+	require.Equal(t, []storiface.ID{"none"}, r.Unlabeled)
+	require.Len(t, r.Domains, 3)
+	require.Equal(t, "region=eu,rack=r1,host=h1", r.Domains[0].Domain)
+	require.Equal(t, 2, r.Domains[0].Copies)
+
+	require.Equal(t, 3, r.Sectors)
+	require.Len(t, r.CoLocated, 3)
+	require.Equal(t, s2, r.CoLocated[0].Sector)
+	require.Equal(t, storiface.SamePath, r.CoLocated[0].Shared)
+	require.Equal(t, s1, r.CoLocated[1].Sector)
+	require.Equal(t, storiface.LabelRack, r.CoLocated[1].Shared)
+	require.Equal(t, []storiface.SectorCopy{
+		{Storage: "h1", FileType: storiface.FTSealed, Domain: "region=eu,rack=r1,host=h1"},
+		{Storage: "h2", FileType: storiface.FTUnsealed, Domain: "region=eu,rack=r1,host=h2"},
+	}, r.CoLocated[1].Copies)
+	require.Equal(t, s3, r.CoLocated[2].Sector)
+	require.Equal(t, storiface.LabelRegion, r.CoLocated[2].Shared)
+}
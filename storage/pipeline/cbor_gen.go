@@ -15,6 +15,7 @@ import (
 	abi "github.com/filecoin-project/go-state-types/abi"
 
 	api "github.com/filecoin-project/lotus/api"
+	sealiface "github.com/filecoin-project/lotus/storage/pipeline/sealiface"
 	storiface "github.com/filecoin-project/lotus/storage/sealer/storiface"
 )
 
@@ -31,7 +32,7 @@ func (t *SectorInfo) MarshalCBOR(w io.Writer) error {
 
 	cw := cbg.NewCborWriter(w)
 
-	if _, err := cw.Write([]byte{184, 38}); err != nil {
+	if _, err := cw.Write([]byte{184, 40}); err != nil {
 		return err
 	}
 
@@ -263,6 +264,22 @@ func (t *SectorInfo) MarshalCBOR(w io.Writer) error {
 		return err
 	}
 
+	// t.Failures (uint64) (uint64)
+	if len("Failures") > cbg.MaxLength {
+		return xerrors.Errorf("Value in field \"Failures\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("Failures"))); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, string("Failures")); err != nil {
+		return err
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajUnsignedInt, uint64(t.Failures)); err != nil {
+		return err
+	}
+
 	// t.SeedEpoch (abi.ChainEpoch) (int64)
 	if len("SeedEpoch") > cbg.MaxLength {
 		return xerrors.Errorf("Value in field \"SeedEpoch\" was too long")
@@ -399,6 +416,29 @@ func (t *SectorInfo) MarshalCBOR(w io.Writer) error {
 		}
 	}
 
+	// t.FailureClass (sealiface.FailureClass) (string)
+	if len("FailureClass") > cbg.MaxLength {
+		return xerrors.Errorf("Value in field \"FailureClass\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("FailureClass"))); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, string("FailureClass")); err != nil {
+		return err
+	}
+
+	if len(t.FailureClass) > cbg.MaxLength {
+		return xerrors.Errorf("Value in field t.FailureClass was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.FailureClass))); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, string(t.FailureClass)); err != nil {
+		return err
+	}
+
 	// t.SectorNumber (abi.SectorNumber) (uint64)
 	if len("SectorNumber") > cbg.MaxLength {
 		return xerrors.Errorf("Value in field \"SectorNumber\" was too long")
@@ -1085,6 +1125,21 @@ func (t *SectorInfo) UnmarshalCBOR(r io.Reader) (err error) {
 			default:
 				return fmt.Errorf("booleans are either major type 7, value 20 or 21 (got %d)", extra)
 			}
+			// t.Failures (uint64) (uint64)
+		case "Failures":
+
+			{
+
+				maj, extra, err = cr.ReadHeader()
+				if err != nil {
+					return err
+				}
+				if maj != cbg.MajUnsignedInt {
+					return fmt.Errorf("wrong type for uint64 field")
+				}
+				t.Failures = uint64(extra)
+
+			}
 			// t.SeedEpoch (abi.ChainEpoch) (int64)
 		case "SeedEpoch":
 			{
@@ -1233,6 +1288,17 @@ func (t *SectorInfo) UnmarshalCBOR(r io.Reader) (err error) {
 
 				t.CreationTime = int64(extraI)
 			}
+			// t.FailureClass (sealiface.FailureClass) (string)
+		case "FailureClass":
+
+			{
+				sval, err := cbg.ReadString(cr)
+				if err != nil {
+					return err
+				}
+
+				t.FailureClass = sealiface.FailureClass(sval)
+			}
 			// t.SectorNumber (abi.SectorNumber) (uint64)
 		case "SectorNumber":
 
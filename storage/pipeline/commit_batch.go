@@ -72,6 +72,9 @@ type CommitBatcher struct {
 	todo    map[abi.SectorNumber]AggregateInput
 	waiting map[abi.SectorNumber][]chan sealiface.CommitBatchRes
 
+	// deferral is set while the messages are deferred because of the base fee
+	deferral *feeDeferral
+
 	notify, stop, stopped chan struct{}
 	force                 chan chan []sealiface.CommitBatchRes
 	lk                    sync.Mutex
@@ -134,7 +137,7 @@ func (b *CommitBatcher) run() {
 		}
 
 		var err error
-		lastMsg, err = b.maybeStartBatch(sendAboveMax)
+		lastMsg, err = b.maybeStartBatch(sendAboveMax, forceRes != nil)
 		if err != nil {
 			log.Warnw("CommitBatcher processBatch error", "error", err)
 		}
@@ -160,20 +163,12 @@ func (b *CommitBatcher) batchWait(maxWait, slack time.Duration) time.Duration {
 		return maxWait
 	}
 
-	var cutoff time.Time
-	for sn := range b.todo {
-		sectorCutoff := b.cutoffs[sn]
-		if cutoff.IsZero() || (!sectorCutoff.IsZero() && sectorCutoff.Before(cutoff)) {
-			cutoff = sectorCutoff
-		}
-	}
-	for sn := range b.waiting {
-		sectorCutoff := b.cutoffs[sn]
-		if cutoff.IsZero() || (!sectorCutoff.IsZero() && sectorCutoff.Before(cutoff)) {
-			cutoff = sectorCutoff
-		}
+	if b.deferral != nil && FeeCapCheckInterval < maxWait {
+		// check the base fee again soon
+		maxWait = FeeCapCheckInterval
 	}
 
+	cutoff := b.cutoff()
 	if cutoff.IsZero() {
 		return maxWait
 	}
@@ -191,7 +186,26 @@ func (b *CommitBatcher) batchWait(maxWait, slack time.Duration) time.Duration {
 	return wait
 }
 
-func (b *CommitBatcher) maybeStartBatch(notif bool) ([]sealiface.CommitBatchRes, error) {
+// cutoff returns the earliest cutoff of the pending sectors. Must be called
+// with b.lk held.
+func (b *CommitBatcher) cutoff() time.Time {
+	var cutoff time.Time
+	for sn := range b.todo {
+		sectorCutoff := b.cutoffs[sn]
+		if cutoff.IsZero() || (!sectorCutoff.IsZero() && sectorCutoff.Before(cutoff)) {
+			cutoff = sectorCutoff
+		}
+	}
+	for sn := range b.waiting {
+		sectorCutoff := b.cutoffs[sn]
+		if cutoff.IsZero() || (!sectorCutoff.IsZero() && sectorCutoff.Before(cutoff)) {
+			cutoff = sectorCutoff
+		}
+	}
+	return cutoff
+}
+
+func (b *CommitBatcher) maybeStartBatch(notif, force bool) ([]sealiface.CommitBatchRes, error) {
 	b.lk.Lock()
 	defer b.lk.Unlock()
 
@@ -216,29 +230,37 @@ func (b *CommitBatcher) maybeStartBatch(notif bool) ([]sealiface.CommitBatchRes,
 		return nil, err
 	}
 
-	blackedOut := func() bool {
-		const nv16BlackoutWindow = abi.ChainEpoch(20) // a magik number
-		if ts.Height() <= build.UpgradeSkyrHeight && build.UpgradeSkyrHeight-ts.Height() < nv16BlackoutWindow {
-			return true
+	if force {
+		b.deferral = nil
+	} else {
+		b.deferral = deferForFee(b.deferral, b.feeCfg.CommitBaseFeeCap, ts.MinTicketBlock().ParentBaseFee, b.cutoff(), cfg.CommitBatchSlack, time.Now())
+		if b.deferral != nil {
+			return nil, nil
 		}
-		return false
 	}
 
-	individual := (total < cfg.MinCommitBatch) || (total < miner.MinAggregatedSectors) || blackedOut()
+	var sectors []abi.SectorNumber
+	for sn := range b.todo {
+		sectors = append(sectors, sn)
+	}
 
-	if !individual && !cfg.AggregateAboveBaseFee.Equals(big.Zero()) {
-		if ts.MinTicketBlock().ParentBaseFee.LessThan(cfg.AggregateAboveBaseFee) {
-			individual = true
+	pol := commitAggregationPolicy(cfg)
+
+	var fees *commitFees
+	if pol == sealiface.CommitAggregateCheapest {
+		fees, err = b.projectCommitFees(ts, sectors)
+		if err != nil {
+			log.Warnw("projecting commit fees failed, deciding on the base fee", "error", err)
 		}
 	}
 
+	aggregate, reason := decideAggregation(cfg, pol, total, ts.Height(), ts.MinTicketBlock().ParentBaseFee, fees)
+	individual := !aggregate
+	log.Debugw("commit aggregation decision", "policy", pol, "sectors", total, "aggregate", aggregate, "reason", reason)
+
 	if individual {
 		res, err = b.processIndividually(cfg)
 	} else {
-		var sectors []abi.SectorNumber
-		for sn := range b.todo {
-			sectors = append(sectors, sn)
-		}
 		res, err = b.processBatch(cfg, sectors)
 	}
 
@@ -600,6 +622,23 @@ func (b *CommitBatcher) Pending(ctx context.Context) ([]abi.SectorID, error) {
 	return res, nil
 }
 
+// PendingSubmission returns the sectors to be sent in the next messages, and
+// whether the messages are deferred because of the base fee.
+func (b *CommitBatcher) PendingSubmission() api.PendingSubmission {
+	b.lk.Lock()
+	defer b.lk.Unlock()
+
+	sectors := make([]abi.SectorNumber, 0, len(b.todo))
+	for sn := range b.todo {
+		sectors = append(sectors, sn)
+	}
+	sort.Slice(sectors, func(i, j int) bool {
+		return sectors[i] < sectors[j]
+	})
+
+	return pendingSubmission(api.SubmissionCommit, sectors, b.deferral)
+}
+
 func (b *CommitBatcher) Stop(ctx context.Context) error {
 	close(b.stop)
 
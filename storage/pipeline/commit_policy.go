@@ -0,0 +1,218 @@
+package sealing
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/go-state-types/builtin/v9/miner"
+	"github.com/filecoin-project/go-state-types/proof"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors/policy"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/vm"
+	"github.com/filecoin-project/lotus/storage/pipeline/sealiface"
+)
+
+// commitFees are the projected fees of sending the pending commits
+// individually and as one aggregate.
+type commitFees struct {
+	individual sealiface.CommitFeeProjection
+	aggregate  sealiface.CommitFeeProjection
+}
+
+func commitAggregationPolicy(cfg sealiface.Config) sealiface.CommitAggregationPolicy {
+	if cfg.CommitAggregationPolicy == "" {
+		return sealiface.CommitAggregateAboveBaseFee
+	}
+	return cfg.CommitAggregationPolicy
+}
+
+func commitBlackedOut(height abi.ChainEpoch) bool {
+	const nv16BlackoutWindow = abi.ChainEpoch(20) // a magik number
+	return height <= build.UpgradeSkyrHeight && build.UpgradeSkyrHeight-height < nv16BlackoutWindow
+}
+
+// decideAggregation returns whether the policy aggregates the pending commits,
+// and why. fees is only used by the cheapest policy, which falls back to the
+// basefee policy when it is nil.
+func decideAggregation(cfg sealiface.Config, pol sealiface.CommitAggregationPolicy, total int, height abi.ChainEpoch, baseFee abi.TokenAmount, fees *commitFees) (bool, string) {
+	switch {
+	case total < miner.MinAggregatedSectors:
+		return false, fmt.Sprintf("%d sectors, aggregates need at least %d", total, miner.MinAggregatedSectors)
+	case total < cfg.MinCommitBatch:
+		return false, fmt.Sprintf("%d sectors, below MinCommitBatch (%d)", total, cfg.MinCommitBatch)
+	case commitBlackedOut(height):
+		return false, "network upgrade blackout"
+	}
+
+	switch pol {
+	case sealiface.CommitAggregateNever:
+		return false, "never aggregating"
+	case sealiface.CommitAggregateAlways:
+		return true, "always aggregating"
+	case sealiface.CommitAggregateCheapest:
+		if fees != nil {
+			if fees.aggregate.Total.LessThan(fees.individual.Total) {
+				return true, "aggregate is cheaper"
+			}
+			return false, "individual messages are cheaper"
+		}
+	case sealiface.CommitAggregateAboveBaseFee, "":
+	default:
+		log.Warnw("unknown commit aggregation policy, using basefee", "policy", pol)
+	}
+
+	if !cfg.AggregateAboveBaseFee.Equals(big.Zero()) && baseFee.LessThan(cfg.AggregateAboveBaseFee) {
+		return false, fmt.Sprintf("base fee below AggregateAboveBaseFee (%s)", types.FIL(cfg.AggregateAboveBaseFee))
+	}
+	return true, "base fee above AggregateAboveBaseFee"
+}
+
+func feeProjection(messages int, gasLimit int64, baseFee, networkFee abi.TokenAmount) sealiface.CommitFeeProjection {
+	gasFee := big.Mul(baseFee, big.NewInt(gasLimit))
+	return sealiface.CommitFeeProjection{
+		Messages:   messages,
+		GasLimit:   gasLimit,
+		GasFee:     gasFee,
+		NetworkFee: networkFee,
+		Total:      big.Add(gasFee, networkFee),
+	}
+}
+
+// projectCommitFees estimates the fees of sending the sectors individually and
+// as one aggregate. The gas of a single ProveCommitSector message is estimated
+// with the first sector; the aggregate is assumed to use the same gas per
+// sector, minus the seal verification, plus the aggregate verification. Must
+// be called with b.lk held.
+func (b *CommitBatcher) projectCommitFees(ts *types.TipSet, sectors []abi.SectorNumber) (*commitFees, error) {
+	baseFee := ts.MinTicketBlock().ParentBaseFee
+
+	nv, err := b.api.StateNetworkVersion(b.mctx, ts.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting network version: %w", err)
+	}
+
+	mi, err := b.api.StateMinerInfo(b.mctx, b.maddr, ts.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't get miner info: %w", err)
+	}
+
+	first := sectors[0]
+	in := b.todo[first]
+
+	enc := new(bytes.Buffer)
+	params := &miner.ProveCommitSectorParams{
+		SectorNumber: first,
+		Proof:        in.Proof,
+	}
+	if err := params.MarshalCBOR(enc); err != nil {
+		return nil, xerrors.Errorf("marshaling commit params: %w", err)
+	}
+
+	collateral, err := b.getSectorCollateral(first, ts.Key())
+	if err != nil {
+		return nil, err
+	}
+
+	maxFee := big.Int(b.feeCfg.MaxCommitGasFee)
+	from, _, err := b.addrSel.AddressFor(b.mctx, b.api, mi, api.CommitAddr, big.Add(collateral, maxFee), collateral)
+	if err != nil {
+		return nil, xerrors.Errorf("no good address to send commit message from: %w", err)
+	}
+
+	msg, err := simulateMsgGas(b.mctx, b.api, from, b.maddr, builtin.MethodsMiner.ProveCommitSector, collateral, maxFee, enc.Bytes())
+	if err != nil {
+		return nil, xerrors.Errorf("estimating ProveCommitSector gas: %w", err)
+	}
+
+	n := int64(len(sectors))
+
+	pl := vm.PricelistByEpoch(ts.Height())
+	perSector := msg.GasLimit - pl.OnVerifySeal(proof.SealVerifyInfo{SealProof: in.Spt}).Total()
+	if perSector < 0 {
+		perSector = 0
+	}
+	aggVerify := pl.OnVerifyAggregateSeals(proof.AggregateSealVerifyProofAndInfos{
+		SealProof: in.Spt,
+		Infos:     make([]proof.AggregateSealVerifyInfo, n),
+	}).Total()
+
+	networkFee, err := policy.AggregateProveCommitNetworkFee(nv, len(sectors), baseFee)
+	if err != nil {
+		return nil, xerrors.Errorf("getting aggregate commit network fee: %w", err)
+	}
+
+	return &commitFees{
+		individual: feeProjection(len(sectors), msg.GasLimit*n, baseFee, big.Zero()),
+		aggregate:  feeProjection(1, perSector*n+aggVerify, baseFee, networkFee),
+	}, nil
+}
+
+// Simulate projects the fees of sending the pending commits individually and
+// as one aggregate, and what each policy would do with them.
+func (b *CommitBatcher) Simulate(ctx context.Context) (*sealiface.CommitAggregationSimulation, error) {
+	b.lk.Lock()
+	defer b.lk.Unlock()
+
+	cfg, err := b.getConfig()
+	if err != nil {
+		return nil, xerrors.Errorf("getting config: %w", err)
+	}
+
+	ts, err := b.api.ChainHead(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("getting chain head: %w", err)
+	}
+
+	sectors := make([]abi.SectorNumber, 0, len(b.todo))
+	for sn := range b.todo {
+		sectors = append(sectors, sn)
+	}
+	sort.Slice(sectors, func(i, j int) bool {
+		return sectors[i] < sectors[j]
+	})
+
+	sim := &sealiface.CommitAggregationSimulation{
+		Sectors: sectors,
+		Height:  ts.Height(),
+		BaseFee: ts.MinTicketBlock().ParentBaseFee,
+		Policy:  commitAggregationPolicy(cfg),
+	}
+	if len(sectors) == 0 {
+		return sim, nil
+	}
+
+	fees, err := b.projectCommitFees(ts, sectors)
+	if err != nil {
+		return nil, xerrors.Errorf("projecting commit fees: %w", err)
+	}
+	sim.Individual = fees.individual
+	sim.Aggregate = fees.aggregate
+
+	for _, pol := range sealiface.CommitAggregationPolicies {
+		aggregate, reason := decideAggregation(cfg, pol, len(sectors), ts.Height(), sim.BaseFee, fees)
+
+		fee := fees.individual.Total
+		if aggregate {
+			fee = fees.aggregate.Total
+		}
+
+		sim.Decisions = append(sim.Decisions, sealiface.CommitPolicyDecision{
+			Policy:    pol,
+			Aggregate: aggregate,
+			Reason:    reason,
+			Fee:       fee,
+		})
+	}
+
+	return sim, nil
+}
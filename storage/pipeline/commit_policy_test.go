@@ -0,0 +1,64 @@
+// stm: #unit
+package sealing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/storage/pipeline/sealiface"
+)
+
+func TestDecideAggregation(t *testing.T) {
+	cfg := sealiface.Config{
+		MinCommitBatch:        4,
+		AggregateAboveBaseFee: big.NewInt(100),
+	}
+	height := abi.ChainEpoch(build.UpgradeSkyrHeight + 1000)
+
+	cheaperAggregate := &commitFees{
+		individual: feeProjection(10, 1000, big.NewInt(100), big.Zero()),
+		aggregate:  feeProjection(1, 500, big.NewInt(100), big.NewInt(1000)),
+	}
+	cheaperIndividual := &commitFees{
+		individual: feeProjection(10, 1000, big.NewInt(100), big.Zero()),
+		aggregate:  feeProjection(1, 500, big.NewInt(100), big.NewInt(100000)),
+	}
+
+	decide := func(pol sealiface.CommitAggregationPolicy, total int, baseFee int64, fees *commitFees) bool {
+		aggregate, _ := decideAggregation(cfg, pol, total, height, big.NewInt(baseFee), fees)
+		return aggregate
+	}
+
+	for _, pol := range sealiface.CommitAggregationPolicies {
+		// batches too small to aggregate
+		require.False(t, decide(pol, 3, 1000, cheaperAggregate), pol)
+	}
+
+	// the default policy aggregates above AggregateAboveBaseFee
+	require.True(t, decide("", 10, 1000, nil))
+	require.False(t, decide("", 10, 10, nil))
+	require.False(t, decide(sealiface.CommitAggregateAboveBaseFee, 10, 10, nil))
+
+	require.True(t, decide(sealiface.CommitAggregateAlways, 10, 10, nil))
+	require.False(t, decide(sealiface.CommitAggregateNever, 10, 1000, nil))
+
+	require.True(t, decide(sealiface.CommitAggregateCheapest, 10, 10, cheaperAggregate))
+	require.False(t, decide(sealiface.CommitAggregateCheapest, 10, 1000, cheaperIndividual))
+	// without projected fees, the cheapest policy decides on the base fee
+	require.True(t, decide(sealiface.CommitAggregateCheapest, 10, 1000, nil))
+
+	// never aggregate during the upgrade blackout
+	aggregate, _ := decideAggregation(cfg, sealiface.CommitAggregateAlways, 10, abi.ChainEpoch(build.UpgradeSkyrHeight-5), big.NewInt(1000), nil)
+	require.False(t, aggregate)
+}
+
+func TestFeeProjection(t *testing.T) {
+	p := feeProjection(2, 1000, big.NewInt(3), big.NewInt(500))
+	require.Equal(t, big.NewInt(3000), p.GasFee)
+	require.Equal(t, big.NewInt(3500), p.Total)
+}
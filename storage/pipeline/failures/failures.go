@@ -0,0 +1,53 @@
+// Package failures explains the errors of the sectors to the operators: the
+// errors recorded by the sealing state machine are matched against a table of
+// rules, which give their category and the remediation of the operator.
+package failures
+
+import (
+	"regexp"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+// Rule matches the sector errors of a known cause.
+type Rule struct {
+	// Name identifies the rule in the explanations
+	Name     string
+	Category api.SectorErrorCategory
+	// Match is matched against the error message
+	Match       *regexp.Regexp
+	Summary     string
+	Remediation string
+}
+
+var unknown = api.SectorErrorExplanation{
+	Category:    api.SectorErrUnknown,
+	Summary:     "The error doesn't match any known cause.",
+	Remediation: "Check the logs of the miner and of the worker which ran the task around the time of the error, with 'lotus-miner sectors status --log' for the history of the sector.",
+}
+
+// Explain classifies the last error of a sector with the first matching rule
+// of the table, it returns nil when there is no error.
+func Explain(lastErr string) *api.SectorErrorExplanation {
+	return explain(Rules, lastErr)
+}
+
+func explain(rules []Rule, lastErr string) *api.SectorErrorExplanation {
+	if lastErr == "" {
+		return nil
+	}
+
+	for _, r := range rules {
+		if r.Match.MatchString(lastErr) {
+			return &api.SectorErrorExplanation{
+				Category:    r.Category,
+				Rule:        r.Name,
+				Summary:     r.Summary,
+				Remediation: r.Remediation,
+			}
+		}
+	}
+
+	e := unknown
+	return &e
+}
@@ -0,0 +1,59 @@
+// stm: #unit
+package failures
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+func TestRules(t *testing.T) {
+	seen := map[string]struct{}{}
+	for msg, rule := range map[string]string{
+		"finalize sector: storage call error 102: reserving storage space: not enough space":      "no-space",
+		"write /mnt/sealing/cache/s-t01000-1/sc-02-data-tree-r-last.dat: no space left on device": "no-space",
+		"open /mnt/store/sealed/s-t01000-1: permission denied":                                    "permission-denied",
+		"fetching sector files: do request: Get http://10.0.0.2:3456/remote/sealed/s-t01000-1":    "fetch",
+		"storage call error 101: worker restarted":                                                "worker-restart",
+		"computing seal proof failed(2): cuda error: out of memory":                               "memory",
+		"seal pre commit(2) failed: OpenCL error -5":                                              "gpu",
+		"ticket expired: seal height: 1000, head: 2000":                                           "ticket-expired",
+		"invalid proof (compute error?)":                                                          "invalid-proof",
+		"on chain CommD differs from sector: bafk1 != bafk2":                                      "bad-commd",
+		"piece 1 (of 2) of sector 3 refers expired deal 4 - should start at 5, head 6":            "deals",
+		"no good address to send precommit message from: not enough funds":                        "funds",
+		"handlePreCommitFailed: sector number already allocated, not proceeding: x":               "sector-number",
+		"commit message failed to execute: exit code 16":                                          "message",
+		"handleWaitSeed: api error, not proceeding: context canceled":                             "node",
+	} {
+		e := Explain(msg)
+		require.NotNil(t, e, msg)
+		require.Equal(t, rule, e.Rule, msg)
+		seen[rule] = struct{}{}
+	}
+
+	require.Nil(t, Explain(""))
+	require.Equal(t, api.SectorErrUnknown, Explain("something unexpected").Category)
+
+	// every rule has a sample above, and the names are unique
+	names := map[string]struct{}{}
+	for _, r := range Rules {
+		require.NotEmpty(t, r.Summary, r.Name)
+		require.NotEmpty(t, r.Remediation, r.Name)
+		require.NotContains(t, names, r.Name)
+		require.Contains(t, seen, r.Name)
+		names[r.Name] = struct{}{}
+	}
+}
+
+func TestRuleOrder(t *testing.T) {
+	rules := []Rule{
+		{Name: "specific", Match: regexp.MustCompile(`no space`)},
+		{Name: "generic", Match: regexp.MustCompile(`storage`)},
+	}
+	require.Equal(t, "specific", explain(rules, "storage: no space").Rule)
+	require.Equal(t, "generic", explain(rules, "storage: fail").Rule)
+}
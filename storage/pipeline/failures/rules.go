@@ -0,0 +1,114 @@
+package failures
+
+import (
+	"regexp"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+// Rules is the table of the known causes of sector errors. The rules are tried
+// in order so the specific ones come first, the errors of the sealing state
+// machine wrap the errors of the workers and of the proofs library, which can
+// match more than one rule.
+//
+// When adding a rule, add a sample of the error it matches to the tests.
+var Rules = []Rule{
+	{
+		Name:        "no-space",
+		Category:    api.SectorErrStorageSpace,
+		Match:       regexp.MustCompile(`(?i)no space left on device|storage call error 102|not enough space|couldn't find a suitable path|reserving storage space`),
+		Summary:     "A storage path ran out of space for the sector files.",
+		Remediation: "Free space on the storage paths or attach new ones with 'lotus-miner storage attach', then check the reservations with 'lotus-miner storage list'. The task is retried.",
+	},
+	{
+		Name:        "permission-denied",
+		Category:    api.SectorErrStorageAccess,
+		Match:       regexp.MustCompile(`(?i)permission denied|read-only file system`),
+		Summary:     "A worker isn't allowed to read or write the sector files.",
+		Remediation: "Check the ownership and the mount options of the storage paths on the worker which ran the task.",
+	},
+	{
+		Name:        "fetch",
+		Category:    api.SectorErrStorageAccess,
+		Match:       regexp.MustCompile(`(?i)fetching sector files|acquire sector|sector file.*not found|no such file or directory`),
+		Summary:     "The sector files couldn't be found on, or fetched to, the worker.",
+		Remediation: "Find the files of the sector with 'lotus-miner storage find <sector>', and check that the storage paths holding them are attached and reachable from the workers.",
+	},
+	{
+		Name:        "worker-restart",
+		Category:    api.SectorErrWorker,
+		Match:       regexp.MustCompile(`(?i)storage call error 101|worker restarted|worker disconnected|worker not found|no worker|connection refused|connection reset`),
+		Summary:     "The worker running the task restarted or went away.",
+		Remediation: "Check the workers with 'lotus-miner sealing workers' and their logs. The task is retried on an available worker.",
+	},
+	{
+		Name:        "memory",
+		Category:    api.SectorErrResources,
+		Match:       regexp.MustCompile(`(?i)out of memory|cannot allocate memory|memory allocation`),
+		Summary:     "The worker ran out of memory.",
+		Remediation: "Lower the number of tasks the worker runs in parallel, or raise the memory requirements of the task type in the worker resource table.",
+	},
+	{
+		Name:        "gpu",
+		Category:    api.SectorErrResources,
+		Match:       regexp.MustCompile(`(?i)cuda|opencl|gpu`),
+		Summary:     "The GPU of the worker failed to run the task.",
+		Remediation: "Check the GPU drivers and that the GPU isn't shared with another process, or set the worker to compute on the CPU.",
+	},
+	{
+		Name:        "ticket-expired",
+		Category:    api.SectorErrTicket,
+		Match:       regexp.MustCompile(`(?i)ticket expired|expired ticket`),
+		Summary:     "The sealing ticket expired before the precommit landed on chain.",
+		Remediation: "PreCommit1 is redone with a new ticket. Speed up PreCommit1 and PreCommit2, or the precommit message with the [Fees] config section, so they fit in the ticket lifetime.",
+	},
+	{
+		Name:        "invalid-proof",
+		Category:    api.SectorErrProof,
+		Match:       regexp.MustCompile(`(?i)invalid proof|porep proof.*invalid|consecutive invalid proofs|proof validation failed`),
+		Summary:     "The proof of the sector doesn't verify.",
+		Remediation: "The sealed files may be corrupt, check them with 'lotus-miner proving check --slow'. Sealing is redone after repeated invalid proofs, the sector can be removed if they persist.",
+	},
+	{
+		Name:        "bad-commd",
+		Category:    api.SectorErrData,
+		Match:       regexp.MustCompile(`(?i)commd differs|bad commd|wrong piececid|padding piece cid`),
+		Summary:     "The sector data doesn't match the commitments of its pieces.",
+		Remediation: "Check that the unsealed file of the sector holds the piece data of its deals. The sector is resealed from its pieces.",
+	},
+	{
+		Name:        "deals",
+		Category:    api.SectorErrDeals,
+		Match:       regexp.MustCompile(`(?i)expired deal|invalid deal|refers deal|getting deal`),
+		Summary:     "A deal in the sector is expired, or its proposal doesn't match the piece.",
+		Remediation: "The deals are listed by 'lotus-miner sectors status --log <sector>'. The sectors with expired deals can't be committed and should be removed.",
+	},
+	{
+		Name:        "funds",
+		Category:    api.SectorErrFunds,
+		Match:       regexp.MustCompile(`(?i)not enough funds|insufficient funds|no good address to send`),
+		Summary:     "No address of the miner has enough funds for the message or its collateral.",
+		Remediation: "Add funds to the worker or control addresses, see 'lotus-miner actor control list' and 'lotus-miner info', or lower the collateral sent with the message.",
+	},
+	{
+		Name:        "sector-number",
+		Category:    api.SectorErrChain,
+		Match:       regexp.MustCompile(`(?i)sector number already allocated|precommit already on chain`),
+		Summary:     "The sector number is already used on chain.",
+		Remediation: "Check the sector numbers reserved with 'lotus-miner sectors numbers info', the sector has to be removed and resealed under a new number.",
+	},
+	{
+		Name:        "message",
+		Category:    api.SectorErrChain,
+		Match:       regexp.MustCompile(`(?i)exit code|out of gas|pushing message to mpool|message failed|batch error`),
+		Summary:     "A message of the sector failed or couldn't be sent.",
+		Remediation: "Look up the message with 'lotus state search-msg', and check the fee limits in the [Fees] config section.",
+	},
+	{
+		Name:        "node",
+		Category:    api.SectorErrNode,
+		Match:       regexp.MustCompile(`(?i)api error|context deadline exceeded|getting chain head|handshake failed`),
+		Summary:     "The miner couldn't get the chain state from the lotus node.",
+		Remediation: "Check the connection to the lotus node and that it is in sync with 'lotus sync wait'. The task is retried.",
+	},
+}
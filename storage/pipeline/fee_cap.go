@@ -0,0 +1,70 @@
+package sealing
+
+import (
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// FeeCapCheckInterval is how often a batcher deferring its messages because of
+// the base fee checks the base fee again.
+var FeeCapCheckInterval = time.Duration(build.BlockDelaySecs) * time.Second
+
+// feeDeferral is the deferral of the messages of a batcher while the base fee
+// is above the cap configured for them.
+type feeDeferral struct {
+	since   time.Time
+	baseFee abi.TokenAmount
+	feeCap  abi.TokenAmount
+	forceAt time.Time
+}
+
+// deferForFee returns the deferral of the messages of a batcher, or nil if the
+// messages can be sent: when the base fee is below the cap, or when the
+// earliest cutoff of the sectors, minus the slack, has passed.
+func deferForFee(prev *feeDeferral, feeCap types.FIL, baseFee abi.TokenAmount, cutoff time.Time, slack time.Duration, now time.Time) *feeDeferral {
+	if feeCap.Int == nil || big.Int(feeCap).Equals(big.Zero()) || baseFee.LessThanEqual(big.Int(feeCap)) {
+		return nil
+	}
+
+	forceAt := cutoff.Add(-slack)
+	if !cutoff.IsZero() && !now.Before(forceAt) {
+		log.Warnw("sending messages with the base fee above the cap to meet the sector cutoff", "baseFee", types.FIL(baseFee), "cap", feeCap)
+		return nil
+	}
+
+	since := now
+	if prev != nil {
+		since = prev.since
+	} else {
+		log.Infow("deferring messages until the base fee falls below the cap", "baseFee", types.FIL(baseFee), "cap", feeCap, "forceAt", forceAt)
+	}
+
+	return &feeDeferral{
+		since:   since,
+		baseFee: baseFee,
+		feeCap:  big.Int(feeCap),
+		forceAt: forceAt,
+	}
+}
+
+// pendingSubmission describes the pending messages of a batcher.
+func pendingSubmission(class api.SubmissionClass, sectors []abi.SectorNumber, deferral *feeDeferral) api.PendingSubmission {
+	ps := api.PendingSubmission{
+		Class:   class,
+		Sectors: sectors,
+	}
+	if deferral != nil {
+		ps.Deferred = true
+		ps.DeferredSince = deferral.since
+		ps.BaseFee = deferral.baseFee
+		ps.FeeCap = deferral.feeCap
+		ps.ForceAt = deferral.forceAt
+	}
+	return ps
+}
@@ -0,0 +1,40 @@
+package sealing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+func TestDeferForFee(t *testing.T) {
+	now := time.Now()
+	feeCap := types.FIL(big.NewInt(100))
+	cutoff := now.Add(2 * time.Hour)
+
+	// no cap
+	require.Nil(t, deferForFee(nil, types.FIL{}, big.NewInt(1000), cutoff, time.Hour, now))
+	require.Nil(t, deferForFee(nil, types.FIL(big.Zero()), big.NewInt(1000), cutoff, time.Hour, now))
+
+	// below the cap
+	require.Nil(t, deferForFee(nil, feeCap, big.NewInt(100), cutoff, time.Hour, now))
+
+	d := deferForFee(nil, feeCap, big.NewInt(1000), cutoff, time.Hour, now)
+	require.NotNil(t, d)
+	require.Equal(t, now, d.since)
+	require.Equal(t, now.Add(time.Hour), d.forceAt)
+	require.Equal(t, big.NewInt(1000), d.baseFee)
+
+	// still deferred later, since the first deferral
+	d = deferForFee(d, feeCap, big.NewInt(2000), cutoff, time.Hour, now.Add(time.Minute))
+	require.NotNil(t, d)
+	require.Equal(t, now, d.since)
+	require.Equal(t, big.NewInt(2000), d.baseFee)
+
+	// the cutoff forces sending
+	require.Nil(t, deferForFee(d, feeCap, big.NewInt(2000), cutoff, time.Hour, now.Add(time.Hour)))
+}
@@ -403,6 +403,12 @@ func (m *Sealing) plan(events []statemachine.Event, state *SectorInfo) (func(sta
 
 	m.logEvents(events, state)
 
+	for _, event := range events {
+		if class, ok := failureClass(event.User); ok {
+			state.failed(class)
+		}
+	}
+
 	if m.notifee != nil {
 		defer func(before SectorInfo) {
 			m.notifee(before, *state)
@@ -427,6 +433,11 @@ func (m *Sealing) plan(events []statemachine.Event, state *SectorInfo) (func(sta
 		return nil, processed, xerrors.Errorf("running planner for state %s failed: %w", state.State, err)
 	}
 
+	if state.State == Proving {
+		// the retries of failures after sealing don't count the sealing failures
+		state.FailureClass, state.Failures = "", 0
+	}
+
 	/////
 	// Now decide what to do next
 
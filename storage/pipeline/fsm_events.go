@@ -54,6 +54,9 @@ type SectorForceState struct {
 
 func (evt SectorForceState) applyGlobal(state *SectorInfo) bool {
 	state.State = evt.State
+	// the sector is retried again when it's manually moved out of a failed
+	// state after exhausting its retries
+	state.Failures = 0
 	return true
 }
 
@@ -20,6 +20,7 @@ import (
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/actors/policy"
 	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/storage/pipeline/failures"
 	"github.com/filecoin-project/lotus/storage/pipeline/lib/nullreader"
 	"github.com/filecoin-project/lotus/storage/pipeline/sealiface"
 	"github.com/filecoin-project/lotus/storage/sealer"
@@ -938,8 +939,9 @@ func (m *Sealing) SectorsStatus(ctx context.Context, sid abi.SectorNumber, showO
 		ToUpgrade:            false,
 		ReplicaUpdateMessage: info.ReplicaUpdateMessage,
 
-		LastErr: info.LastErr,
-		Log:     log,
+		LastErr:            info.LastErr,
+		LastErrExplanation: failures.Explain(info.LastErr),
+		Log:                log,
 		// on chain info
 		SealProof:          info.SectorType,
 		Activation:         0,
@@ -62,6 +62,9 @@ type PreCommitBatcher struct {
 	todo    map[abi.SectorNumber]*preCommitEntry
 	waiting map[abi.SectorNumber][]chan sealiface.PreCommitBatchRes
 
+	// deferral is set while the messages are deferred because of the base fee
+	deferral *feeDeferral
+
 	notify, stop, stopped chan struct{}
 	force                 chan chan []sealiface.PreCommitBatchRes
 	lk                    sync.Mutex
@@ -122,7 +125,7 @@ func (b *PreCommitBatcher) run() {
 		}
 
 		var err error
-		lastRes, err = b.maybeStartBatch(sendAboveMax)
+		lastRes, err = b.maybeStartBatch(sendAboveMax, forceRes != nil)
 		if err != nil {
 			log.Warnw("PreCommitBatcher processBatch error", "error", err)
 		}
@@ -148,20 +151,12 @@ func (b *PreCommitBatcher) batchWait(maxWait, slack time.Duration) time.Duration
 		return maxWait
 	}
 
-	var cutoff time.Time
-	for sn := range b.todo {
-		sectorCutoff := b.cutoffs[sn]
-		if cutoff.IsZero() || (!sectorCutoff.IsZero() && sectorCutoff.Before(cutoff)) {
-			cutoff = sectorCutoff
-		}
-	}
-	for sn := range b.waiting {
-		sectorCutoff := b.cutoffs[sn]
-		if cutoff.IsZero() || (!sectorCutoff.IsZero() && sectorCutoff.Before(cutoff)) {
-			cutoff = sectorCutoff
-		}
+	if b.deferral != nil && FeeCapCheckInterval < maxWait {
+		// check the base fee again soon
+		maxWait = FeeCapCheckInterval
 	}
 
+	cutoff := b.cutoff()
 	if cutoff.IsZero() {
 		return maxWait
 	}
@@ -179,7 +174,26 @@ func (b *PreCommitBatcher) batchWait(maxWait, slack time.Duration) time.Duration
 	return wait
 }
 
-func (b *PreCommitBatcher) maybeStartBatch(notif bool) ([]sealiface.PreCommitBatchRes, error) {
+// cutoff returns the earliest cutoff of the pending sectors. Must be called
+// with b.lk held.
+func (b *PreCommitBatcher) cutoff() time.Time {
+	var cutoff time.Time
+	for sn := range b.todo {
+		sectorCutoff := b.cutoffs[sn]
+		if cutoff.IsZero() || (!sectorCutoff.IsZero() && sectorCutoff.Before(cutoff)) {
+			cutoff = sectorCutoff
+		}
+	}
+	for sn := range b.waiting {
+		sectorCutoff := b.cutoffs[sn]
+		if cutoff.IsZero() || (!sectorCutoff.IsZero() && sectorCutoff.Before(cutoff)) {
+			cutoff = sectorCutoff
+		}
+	}
+	return cutoff
+}
+
+func (b *PreCommitBatcher) maybeStartBatch(notif, force bool) ([]sealiface.PreCommitBatchRes, error) {
 	b.lk.Lock()
 	defer b.lk.Unlock()
 
@@ -202,6 +216,15 @@ func (b *PreCommitBatcher) maybeStartBatch(notif bool) ([]sealiface.PreCommitBat
 		return nil, err
 	}
 
+	if force {
+		b.deferral = nil
+	} else {
+		b.deferral = deferForFee(b.deferral, b.feeCfg.PreCommitBaseFeeCap, ts.MinTicketBlock().ParentBaseFee, b.cutoff(), cfg.PreCommitBatchSlack, time.Now())
+		if b.deferral != nil {
+			return nil, nil
+		}
+	}
+
 	// TODO: Drop this once nv14 has come and gone
 	nv, err := b.api.StateNetworkVersion(b.mctx, ts.Key())
 	if err != nil {
@@ -495,6 +518,23 @@ func (b *PreCommitBatcher) Pending(ctx context.Context) ([]abi.SectorID, error)
 	return res, nil
 }
 
+// PendingSubmission returns the sectors to be sent in the next messages, and
+// whether the messages are deferred because of the base fee.
+func (b *PreCommitBatcher) PendingSubmission() api.PendingSubmission {
+	b.lk.Lock()
+	defer b.lk.Unlock()
+
+	sectors := make([]abi.SectorNumber, 0, len(b.todo))
+	for sn := range b.todo {
+		sectors = append(sectors, sn)
+	}
+	sort.Slice(sectors, func(i, j int) bool {
+		return sectors[i] < sectors[j]
+	})
+
+	return pendingSubmission(api.SubmissionPreCommit, sectors, b.deferral)
+}
+
 func (b *PreCommitBatcher) Stop(ctx context.Context) error {
 	close(b.stop)
 
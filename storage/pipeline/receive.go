@@ -17,6 +17,7 @@ import (
 	"github.com/filecoin-project/go-statemachine"
 
 	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/storage/sealer/storiface"
 )
 
@@ -24,7 +25,43 @@ func (m *Sealing) Receive(ctx context.Context, meta api.RemoteSectorMeta) error
 	m.inputLk.Lock()
 	defer m.inputLk.Unlock()
 
-	si, err := m.checkSectorMeta(ctx, meta)
+	rc, err := m.newReceiveChecks(ctx)
+	if err != nil {
+		return err
+	}
+
+	return m.receive(ctx, rc, meta)
+}
+
+// ReceiveBatch imports a batch of sectors like Receive, checking all of them
+// against the same chain state. The returned errors are the errors importing
+// each of the sectors, nil for the sectors which were imported.
+func (m *Sealing) ReceiveBatch(ctx context.Context, metas []api.RemoteSectorMeta) ([]error, error) {
+	m.inputLk.Lock()
+	defer m.inputLk.Unlock()
+
+	rc, err := m.newReceiveChecks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]error, len(metas))
+	seen := map[abi.SectorNumber]struct{}{}
+	for i, meta := range metas {
+		if _, dup := seen[meta.Sector.Number]; dup {
+			out[i] = xerrors.Errorf("sector %d is imported more than once in the batch", meta.Sector.Number)
+			continue
+		}
+		seen[meta.Sector.Number] = struct{}{}
+
+		out[i] = m.receive(ctx, rc, meta)
+	}
+
+	return out, nil
+}
+
+func (m *Sealing) receive(ctx context.Context, rc *receiveChecks, meta api.RemoteSectorMeta) error {
+	si, err := m.checkSectorMeta(ctx, rc, meta)
 	if err != nil {
 		return err
 	}
@@ -34,7 +71,7 @@ func (m *Sealing) Receive(ctx context.Context, meta api.RemoteSectorMeta) error
 		return xerrors.Errorf("checking if sector exists: %w", err)
 	}
 	if exists {
-		return xerrors.Errorf("sector %d state already exists", meta.Sector.Number)
+		return xerrors.Errorf("sector %d state already exists: %w", meta.Sector.Number, ErrSectorExists)
 	}
 
 	err = m.sectors.Send(uint64(meta.Sector.Number), SectorReceive{
@@ -47,16 +84,72 @@ func (m *Sealing) Receive(ctx context.Context, meta api.RemoteSectorMeta) error
 	return nil
 }
 
-func (m *Sealing) checkSectorMeta(ctx context.Context, meta api.RemoteSectorMeta) (SectorInfo, error) {
-	{
-		mid, err := address.IDFromAddress(m.maddr)
-		if err != nil {
-			panic(err)
-		}
+type randKey struct {
+	dst   crypto.DomainSeparationTag
+	epoch abi.ChainEpoch
+}
 
-		if meta.Sector.Miner != abi.ActorID(mid) {
-			return SectorInfo{}, xerrors.Errorf("sector for wrong actor - expected actor id %d, sector was for actor %d", mid, meta.Sector.Miner)
-		}
+// receiveChecks is the chain state the received sectors are checked against,
+// fetched once for a batch of sectors
+type receiveChecks struct {
+	mid      abi.ActorID
+	spt      abi.RegisteredSealProof
+	ts       *types.TipSet
+	maddrBuf []byte
+
+	rand map[randKey]abi.Randomness
+}
+
+func (m *Sealing) newReceiveChecks(ctx context.Context) (*receiveChecks, error) {
+	mid, err := address.IDFromAddress(m.maddr)
+	if err != nil {
+		return nil, xerrors.Errorf("getting miner actor id: %w", err)
+	}
+
+	spt, err := m.currentSealProof(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ts, err := m.Api.ChainHead(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("getting chain head: %w", err)
+	}
+
+	maddrBuf := new(bytes.Buffer)
+	if err := m.maddr.MarshalCBOR(maddrBuf); err != nil {
+		return nil, xerrors.Errorf("marshal miner address: %w", err)
+	}
+
+	return &receiveChecks{
+		mid:      abi.ActorID(mid),
+		spt:      spt,
+		ts:       ts,
+		maddrBuf: maddrBuf.Bytes(),
+
+		rand: map[randKey]abi.Randomness{},
+	}, nil
+}
+
+// randomness returns the randomness drawn by the miner at the epoch, sectors
+// sealed in bulk usually share their ticket epochs.
+func (rc *receiveChecks) randomness(ctx context.Context, sapi SealingAPI, dst crypto.DomainSeparationTag, epoch abi.ChainEpoch) (abi.Randomness, error) {
+	k := randKey{dst: dst, epoch: epoch}
+	if r, ok := rc.rand[k]; ok {
+		return r, nil
+	}
+
+	r, err := sapi.StateGetRandomnessFromTickets(ctx, dst, epoch, rc.maddrBuf, rc.ts.Key())
+	if err != nil {
+		return nil, err
+	}
+	rc.rand[k] = r
+	return r, nil
+}
+
+func (m *Sealing) checkSectorMeta(ctx context.Context, rc *receiveChecks, meta api.RemoteSectorMeta) (SectorInfo, error) {
+	if meta.Sector.Miner != rc.mid {
+		return SectorInfo{}, xerrors.Errorf("sector for wrong actor - expected actor id %d, sector was for actor %d", rc.mid, meta.Sector.Miner)
 	}
 
 	{
@@ -66,24 +159,12 @@ func (m *Sealing) checkSectorMeta(ctx context.Context, meta api.RemoteSectorMeta
 			return SectorInfo{}, err
 		}
 		if err == nil {
-			return SectorInfo{}, xerrors.Errorf("sector with ID %d already exists in the sealing pipeline", meta.Sector.Number)
+			return SectorInfo{}, xerrors.Errorf("sector with ID %d already exists in the sealing pipeline: %w", meta.Sector.Number, ErrSectorExists)
 		}
 	}
 
-	{
-		spt, err := m.currentSealProof(ctx)
-		if err != nil {
-			return SectorInfo{}, err
-		}
-
-		if meta.Type != spt {
-			return SectorInfo{}, xerrors.Errorf("sector seal proof type doesn't match current seal proof type (%d!=%d)", meta.Type, spt)
-		}
-	}
-
-	ts, err := m.Api.ChainHead(ctx)
-	if err != nil {
-		return SectorInfo{}, xerrors.Errorf("getting chain head: %w", err)
+	if meta.Type != rc.spt {
+		return SectorInfo{}, xerrors.Errorf("sector seal proof type doesn't match current seal proof type (%d!=%d)", meta.Type, rc.spt)
 	}
 
 	var info SectorInfo
@@ -119,11 +200,7 @@ func (m *Sealing) checkSectorMeta(ctx context.Context, meta api.RemoteSectorMeta
 			return SectorInfo{}, xerrors.Errorf("seed randomness had wrong length %d", len(meta.SeedValue))
 		}
 
-		maddrBuf := new(bytes.Buffer)
-		if err := m.maddr.MarshalCBOR(maddrBuf); err != nil {
-			return SectorInfo{}, xerrors.Errorf("marshal miner address for seed check: %w", err)
-		}
-		rand, err := m.Api.StateGetRandomnessFromTickets(ctx, crypto.DomainSeparationTag_InteractiveSealChallengeSeed, meta.SeedEpoch, maddrBuf.Bytes(), ts.Key())
+		rand, err := rc.randomness(ctx, m.Api, crypto.DomainSeparationTag_InteractiveSealChallengeSeed, meta.SeedEpoch)
 		if err != nil {
 			return SectorInfo{}, xerrors.Errorf("generating check seed: %w", err)
 		}
@@ -144,11 +221,7 @@ func (m *Sealing) checkSectorMeta(ctx context.Context, meta api.RemoteSectorMeta
 			return SectorInfo{}, xerrors.Errorf("ticket randomness had wrong length %d", len(meta.TicketValue))
 		}
 
-		maddrBuf := new(bytes.Buffer)
-		if err := m.maddr.MarshalCBOR(maddrBuf); err != nil {
-			return SectorInfo{}, xerrors.Errorf("marshal miner address for ticket check: %w", err)
-		}
-		rand, err := m.Api.StateGetRandomnessFromTickets(ctx, crypto.DomainSeparationTag_SealRandomness, meta.TicketEpoch, maddrBuf.Bytes(), ts.Key())
+		rand, err := rc.randomness(ctx, m.Api, crypto.DomainSeparationTag_SealRandomness, meta.TicketEpoch)
 		if err != nil {
 			return SectorInfo{}, xerrors.Errorf("generating check ticket: %w", err)
 		}
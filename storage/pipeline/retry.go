@@ -0,0 +1,134 @@
+package sealing
+
+import (
+	"errors"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/storage/pipeline/sealiface"
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+// failureClass returns the class of the failure events, which sets the
+// retry policy applied in the failed state the event leads to.
+func failureClass(evt interface{}) (sealiface.FailureClass, bool) {
+	switch evt := evt.(type) {
+	case SectorSealPreCommit1Failed:
+		var expired *ErrExpiredTicket
+		if errors.As(evt.error, &expired) {
+			return sealiface.FailTicketExpired, true
+		}
+		return errorClass(evt.error, sealiface.FailSealing), true
+	case SectorSealPreCommit2Failed:
+		return errorClass(evt.error, sealiface.FailSealing), true
+	case SectorUpdateReplicaFailed:
+		return errorClass(evt.error, sealiface.FailSealing), true
+	case SectorComputeProofFailed:
+		return errorClass(evt.error, sealiface.FailProof), true
+	case SectorProveReplicaUpdateFailed:
+		return errorClass(evt.error, sealiface.FailProof), true
+	case SectorRemoteCommit1Failed, SectorRemoteCommit2Failed:
+		return sealiface.FailProof, true
+	case SectorChainPreCommitFailed, SectorCommitFailed, SectorSubmitReplicaUpdateFailed, SectorTerminateFailed:
+		return sealiface.FailChain, true
+	case SectorFinalizeFailed, SectorReleaseKeyFailed:
+		return sealiface.FailStorage, true
+	case SectorAddPieceFailed, SectorRemoveFailed:
+		return sealiface.FailOther, true
+	}
+	return "", false
+}
+
+// errorClass tells the failures of the workers to get the sector files they
+// need from the failures of the task itself.
+func errorClass(err error, class sealiface.FailureClass) sealiface.FailureClass {
+	var ferr *storiface.FetchError
+	if errors.As(err, &ferr) {
+		return sealiface.FailStorage
+	}
+
+	var cerr *storiface.CallError
+	if errors.As(err, &cerr) && cerr.Code == storiface.ErrTempAllocateSpace {
+		return sealiface.FailStorage
+	}
+
+	return class
+}
+
+func (t *SectorInfo) failed(class sealiface.FailureClass) {
+	if t.FailureClass != class {
+		t.FailureClass = class
+		t.Failures = 0
+	}
+	t.Failures++
+}
+
+// retryBackoff is the wait before retrying the nth consecutive failure.
+func retryBackoff(p sealiface.RetryPolicy, failures uint64) time.Duration {
+	minBackoff := p.MinBackoff
+	if minBackoff <= 0 {
+		minBackoff = MinRetryTime
+	}
+	if p.MaxBackoff <= minBackoff {
+		return minBackoff
+	}
+
+	backoff := minBackoff
+	for i := uint64(1); i < failures && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+
+	return backoff
+}
+
+func retriesExhausted(p sealiface.RetryPolicy, failures uint64) bool {
+	return p.MaxRetries > 0 && failures > uint64(p.MaxRetries)
+}
+
+// abortEvent returns the event aborting a sector in a failed state, nil when
+// it's too late for the sector to be aborted.
+func abortEvent(sector SectorInfo, reason error) interface{} {
+	switch sector.State {
+	case SealPreCommit1Failed, SealPreCommit2Failed, PreCommitFailed, ComputeProofFailed, RemoteCommitFailed, CommitFailed:
+		return SectorRemove{}
+	case ReplicaUpdateFailed:
+		return SectorAbortUpgrade{reason}
+	}
+	return nil
+}
+
+func (m *Sealing) SectorRetryPolicy(sid abi.SectorNumber) (api.SectorRetryPolicy, error) {
+	info, err := m.GetSectorInfo(sid)
+	if err != nil {
+		return api.SectorRetryPolicy{}, err
+	}
+
+	cfg, err := m.getConfig()
+	if err != nil {
+		return api.SectorRetryPolicy{}, xerrors.Errorf("getting sealing config: %w", err)
+	}
+
+	out := api.SectorRetryPolicy{
+		SectorID: sid,
+		State:    api.SectorState(info.State),
+		Class:    info.FailureClass,
+		Failures: info.Failures,
+	}
+	if info.FailureClass == "" {
+		return out, nil
+	}
+
+	out.Policy = cfg.RetryPolicies[info.FailureClass]
+	out.Exhausted = retriesExhausted(out.Policy, info.Failures)
+	if !out.Exhausted {
+		out.Backoff = retryBackoff(out.Policy, info.Failures)
+	}
+	return out, nil
+}
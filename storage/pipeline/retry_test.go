@@ -0,0 +1,70 @@
+// stm: #unit
+package sealing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/storage/pipeline/sealiface"
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+func TestFailureClass(t *testing.T) {
+	for _, tc := range []struct {
+		evt   interface{}
+		class sealiface.FailureClass
+	}{
+		{SectorSealPreCommit1Failed{xerrors.New("pc1")}, sealiface.FailSealing},
+		{SectorSealPreCommit1Failed{xerrors.Errorf("checking: %w", &ErrExpiredTicket{xerrors.New("expired")})}, sealiface.FailTicketExpired},
+		{SectorSealPreCommit2Failed{xerrors.Errorf("pc2: %w", &storiface.FetchError{Err: xerrors.New("no space")})}, sealiface.FailStorage},
+		{SectorSealPreCommit2Failed{storiface.Err(storiface.ErrTempAllocateSpace, xerrors.New("no space"))}, sealiface.FailStorage},
+		{SectorComputeProofFailed{xerrors.New("c2")}, sealiface.FailProof},
+		{SectorCommitFailed{xerrors.New("commit")}, sealiface.FailChain},
+		{SectorFinalizeFailed{xerrors.New("finalize")}, sealiface.FailStorage},
+	} {
+		class, ok := failureClass(tc.evt)
+		require.True(t, ok)
+		require.Equal(t, tc.class, class, "%T", tc.evt)
+	}
+
+	_, ok := failureClass(SectorRetrySealPreCommit1{})
+	require.False(t, ok)
+}
+
+func TestSectorFailed(t *testing.T) {
+	var si SectorInfo
+
+	si.failed(sealiface.FailSealing)
+	si.failed(sealiface.FailSealing)
+	require.Equal(t, sealiface.FailSealing, si.FailureClass)
+	require.Equal(t, uint64(2), si.Failures)
+
+	si.failed(sealiface.FailStorage)
+	require.Equal(t, sealiface.FailStorage, si.FailureClass)
+	require.Equal(t, uint64(1), si.Failures)
+}
+
+func TestRetryBackoff(t *testing.T) {
+	require.Equal(t, MinRetryTime, retryBackoff(sealiface.RetryPolicy{}, 5))
+
+	p := sealiface.RetryPolicy{
+		MinBackoff: time.Minute,
+		MaxBackoff: 10 * time.Minute,
+	}
+	require.Equal(t, time.Minute, retryBackoff(p, 1))
+	require.Equal(t, 2*time.Minute, retryBackoff(p, 2))
+	require.Equal(t, 8*time.Minute, retryBackoff(p, 4))
+	require.Equal(t, 10*time.Minute, retryBackoff(p, 5))
+	require.Equal(t, 10*time.Minute, retryBackoff(p, 500))
+}
+
+func TestRetriesExhausted(t *testing.T) {
+	require.False(t, retriesExhausted(sealiface.RetryPolicy{}, 1000))
+
+	p := sealiface.RetryPolicy{MaxRetries: 3}
+	require.False(t, retriesExhausted(p, 3))
+	require.True(t, retriesExhausted(p, 4))
+}
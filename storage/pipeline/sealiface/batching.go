@@ -21,3 +21,61 @@ type PreCommitBatchRes struct {
 	Msg   *cid.Cid
 	Error string // if set, means that all sectors are failed, implies Msg==nil
 }
+
+// CommitAggregationPolicy decides whether the pending commits are aggregated
+// or sent individually.
+type CommitAggregationPolicy string
+
+const (
+	// CommitAggregateAboveBaseFee aggregates when the base fee is above
+	// AggregateAboveBaseFee.
+	CommitAggregateAboveBaseFee CommitAggregationPolicy = "basefee"
+	// CommitAggregateAlways aggregates whenever the batch is large enough.
+	CommitAggregateAlways CommitAggregationPolicy = "always"
+	// CommitAggregateNever always sends the commits individually.
+	CommitAggregateNever CommitAggregationPolicy = "never"
+	// CommitAggregateCheapest aggregates when the projected fee of the
+	// aggregate is lower than the projected fee of the individual commits.
+	CommitAggregateCheapest CommitAggregationPolicy = "cheapest"
+)
+
+var CommitAggregationPolicies = []CommitAggregationPolicy{
+	CommitAggregateAboveBaseFee,
+	CommitAggregateAlways,
+	CommitAggregateNever,
+	CommitAggregateCheapest,
+}
+
+// CommitFeeProjection is the projected fee of sending the pending commits in
+// one way.
+type CommitFeeProjection struct {
+	Messages int
+	GasLimit int64
+	// GasFee is the gas burnt at the current base fee.
+	GasFee abi.TokenAmount
+	// NetworkFee is the batch fee paid by aggregates.
+	NetworkFee abi.TokenAmount
+	Total      abi.TokenAmount
+}
+
+// CommitPolicyDecision is what a policy would do with the pending commits.
+type CommitPolicyDecision struct {
+	Policy    CommitAggregationPolicy
+	Aggregate bool
+	Reason    string
+	// Fee is the projected fee of the decision.
+	Fee abi.TokenAmount
+}
+
+type CommitAggregationSimulation struct {
+	Sectors []abi.SectorNumber
+	Height  abi.ChainEpoch
+	BaseFee abi.TokenAmount
+	// Policy is the configured policy.
+	Policy CommitAggregationPolicy
+
+	Individual CommitFeeProjection
+	Aggregate  CommitFeeProjection
+
+	Decisions []CommitPolicyDecision
+}
@@ -55,6 +55,7 @@ type Config struct {
 	CommitBatchWait  time.Duration
 	CommitBatchSlack time.Duration
 
+	CommitAggregationPolicy    CommitAggregationPolicy
 	AggregateAboveBaseFee      abi.TokenAmount
 	BatchPreCommitAboveBaseFee abi.TokenAmount
 
@@ -63,4 +64,45 @@ type Config struct {
 	TerminateBatchMax  uint64
 	TerminateBatchMin  uint64
 	TerminateBatchWait time.Duration
+
+	// the classes without a policy use the zero RetryPolicy
+	RetryPolicies map[FailureClass]RetryPolicy
+}
+
+// FailureClass is a class of sector failures sharing a RetryPolicy.
+type FailureClass string
+
+const (
+	// FailTicketExpired is when the ticket expired before the sector was
+	// precommitted, so it has to be sealed again with a new ticket
+	FailTicketExpired FailureClass = "ticket-expired"
+	// FailSealing is when computing PC1, PC2 or the replica update failed
+	FailSealing FailureClass = "sealing"
+	// FailStorage is when fetching, moving or allocating space for the sector
+	// files failed
+	FailStorage FailureClass = "storage"
+	// FailProof is when computing or verifying the proofs of the sector failed
+	FailProof FailureClass = "proof"
+	// FailChain is when the messages of the sector failed
+	FailChain FailureClass = "chain"
+	// FailOther is for the other failures, like adding pieces or removing the
+	// sector
+	FailOther FailureClass = "other"
+)
+
+var FailureClasses = []FailureClass{FailTicketExpired, FailSealing, FailStorage, FailProof, FailChain, FailOther}
+
+type RetryPolicy struct {
+	// consecutive failures of the class retried, 0 = no limit
+	MaxRetries int
+
+	// 0 = the default of 1 minute
+	MinBackoff time.Duration
+	// the backoff doubles with each consecutive failure up to MaxBackoff, it
+	// stays at MinBackoff when MaxBackoff isn't above it
+	MaxBackoff time.Duration
+
+	// remove the sector (or abort its upgrade) once the retries are
+	// exhausted, instead of leaving it in the failed state
+	AutoAbort bool
 }
@@ -40,6 +40,10 @@ const SectorStorePrefix = "/sectors"
 
 var ErrTooManySectorsSealing = xerrors.New("too many sectors sealing")
 
+// ErrSectorExists is returned when receiving a sector with the number of a
+// sector which is already in the sealing pipeline.
+var ErrSectorExists = xerrors.New("sector already exists")
+
 var log = logging.Logger("sectors")
 
 //go:generate go run github.com/golang/mock/mockgen -destination=mocks/api.go -package=mocks . SealingAPI
@@ -275,6 +279,18 @@ func New(mctx context.Context, api SealingAPI, fc config.MinerFeeConfig, events
 	return s
 }
 
+// AddNotifee registers a function called with the sector state before and
+// after every state machine transition. It must be called before Run.
+func (m *Sealing) AddNotifee(n SectorStateNotifee) {
+	prev := m.notifee
+	m.notifee = func(before, after SectorInfo) {
+		if prev != nil {
+			prev(before, after)
+		}
+		n(before, after)
+	}
+}
+
 func (m *Sealing) Run(ctx context.Context) {
 	if err := m.restartSectors(ctx); err != nil {
 		log.Errorf("failed load sector states: %+v", err)
@@ -316,6 +332,11 @@ func (m *Sealing) SectorPreCommitFlush(ctx context.Context) ([]sealiface.PreComm
 	return m.precommiter.Flush(ctx)
 }
 
+// PendingSubmissions returns the pending PreCommit and Commit messages.
+func (m *Sealing) PendingSubmissions() []api.PendingSubmission {
+	return []api.PendingSubmission{m.precommiter.PendingSubmission(), m.commiter.PendingSubmission()}
+}
+
 func (m *Sealing) SectorPreCommitPending(ctx context.Context) ([]abi.SectorID, error) {
 	return m.precommiter.Pending(ctx)
 }
@@ -324,6 +345,12 @@ func (m *Sealing) CommitFlush(ctx context.Context) ([]sealiface.CommitBatchRes,
 	return m.commiter.Flush(ctx)
 }
 
+// CommitAggregationSimulate projects the fees of the pending commits under
+// each aggregation policy.
+func (m *Sealing) CommitAggregationSimulate(ctx context.Context) (*sealiface.CommitAggregationSimulation, error) {
+	return m.commiter.Simulate(ctx)
+}
+
 func (m *Sealing) CommitPending(ctx context.Context) ([]abi.SectorID, error) {
 	return m.commiter.Pending(ctx)
 }
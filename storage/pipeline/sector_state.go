@@ -178,6 +178,12 @@ func toStatState(st SectorState, finEarly bool) statSectorState {
 	return sstFailed
 }
 
+// IsFailedState returns whether the state is a failure state, which the
+// sector leaves after recovering or being removed.
+func IsFailedState(st SectorState) bool {
+	return toStatState(st, false) == sstFailed
+}
+
 func IsUpgradeState(st SectorState) bool {
 	switch st {
 	case SnapDealsWaitDeals,
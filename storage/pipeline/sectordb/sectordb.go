@@ -0,0 +1,372 @@
+package sectordb
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	_ "github.com/mattn/go-sqlite3"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin/v9/miner"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	sealing "github.com/filecoin-project/lotus/storage/pipeline"
+)
+
+var log = logging.Logger("sectordb")
+
+const DBName = "sectors.db"
+
+var dbDefs = []string{
+	`CREATE TABLE IF NOT EXISTS sectors (
+		sector_number INTEGER PRIMARY KEY,
+		state TEXT NOT NULL,
+		sector_type INTEGER NOT NULL,
+		failed INTEGER NOT NULL,
+		last_error TEXT NOT NULL,
+		expiration INTEGER,
+		updated_at INTEGER NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS sectors_state ON sectors (state)`,
+	`CREATE INDEX IF NOT EXISTS sectors_expiration ON sectors (expiration)`,
+	`CREATE TABLE IF NOT EXISTS sector_deals (
+		sector_number INTEGER NOT NULL,
+		deal_id INTEGER NOT NULL,
+		PRIMARY KEY (sector_number, deal_id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS sector_deals_deal_id ON sector_deals (deal_id)`,
+	`CREATE TABLE IF NOT EXISTS _meta (
+		version UINT64 NOT NULL UNIQUE
+	)`,
+	`INSERT OR IGNORE INTO _meta (version) VALUES (1)`,
+}
+
+const (
+	dbqUpsertSector = `INSERT INTO sectors (sector_number, state, sector_type, failed, last_error, updated_at) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (sector_number) DO UPDATE SET state = excluded.state, sector_type = excluded.sector_type,
+		failed = excluded.failed, last_error = excluded.last_error, updated_at = excluded.updated_at`
+	dbqDeleteDeals      = "DELETE FROM sector_deals WHERE sector_number = ?"
+	dbqInsertDeal       = "INSERT OR IGNORE INTO sector_deals (sector_number, deal_id) VALUES (?, ?)"
+	dbqSetExpiration    = "UPDATE sectors SET expiration = ? WHERE sector_number = ?"
+	dbqListSectors      = "SELECT sector_number FROM sectors"
+	dbqDeleteSector     = "DELETE FROM sectors WHERE sector_number = ?"
+	dbqSelectSectorDeal = "SELECT deal_id FROM sector_deals WHERE sector_number = ? ORDER BY deal_id"
+)
+
+// maximum number of sectors returned by a query
+const maxQueryLimit = 100000
+
+// ExpirationRefreshInterval is the interval at which the on-chain
+// expirations of the sectors are refreshed.
+var ExpirationRefreshInterval = 30 * time.Minute
+
+// SectorsAPI is the full node API used to look up on-chain expirations.
+type SectorsAPI interface {
+	StateMinerSectors(context.Context, address.Address, *bitfield.BitField, types.TipSetKey) ([]*miner.SectorOnChainInfo, error)
+}
+
+// DB is an SQLite mirror of the sector metadata of the sealing pipeline,
+// updated on every sector state transition.
+type DB struct {
+	api   SectorsAPI
+	maddr address.Address
+
+	lk sync.Mutex
+	db *sql.DB
+
+	cancel func()
+	wg     sync.WaitGroup
+}
+
+func Open(path string, a SectorsAPI, maddr address.Address) (*DB, error) {
+	db, err := sql.Open("sqlite3", path+"?mode=rwc")
+	if err != nil {
+		return nil, xerrors.Errorf("opening sector database: %w", err)
+	}
+
+	for _, stmt := range dbDefs {
+		if _, err := db.Exec(stmt); err != nil {
+			_ = db.Close()
+			return nil, xerrors.Errorf("executing sql statement '%s': %w", stmt, err)
+		}
+	}
+
+	return &DB{
+		api:   a,
+		maddr: maddr,
+		db:    db,
+	}, nil
+}
+
+// Sync makes the database mirror the given sectors, removing the others. It
+// is called with all the sectors of the pipeline before it is started.
+func (d *DB) Sync(ctx context.Context, sectors []sealing.SectorInfo) error {
+	d.lk.Lock()
+	defer d.lk.Unlock()
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	known := map[abi.SectorNumber]struct{}{}
+	for _, si := range sectors {
+		if err := upsert(ctx, tx, si); err != nil {
+			return err
+		}
+		known[si.SectorNumber] = struct{}{}
+	}
+
+	rows, err := tx.QueryContext(ctx, dbqListSectors)
+	if err != nil {
+		return err
+	}
+	var removed []abi.SectorNumber
+	for rows.Next() {
+		var sn abi.SectorNumber
+		if err := rows.Scan(&sn); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		if _, ok := known[sn]; !ok {
+			removed = append(removed, sn)
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	for _, sn := range removed {
+		if _, err := tx.ExecContext(ctx, dbqDeleteSector, sn); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, dbqDeleteDeals, sn); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Update is a sealing.SectorStateNotifee recording the sector state after a
+// transition.
+func (d *DB) Update(before, after sealing.SectorInfo) {
+	d.lk.Lock()
+	defer d.lk.Unlock()
+
+	ctx := context.TODO()
+	err := func() error {
+		tx, err := d.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback() //nolint:errcheck
+
+		if err := upsert(ctx, tx, after); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}()
+	if err != nil {
+		log.Errorw("updating sector database", "sector", after.SectorNumber, "error", err)
+	}
+}
+
+func upsert(ctx context.Context, tx *sql.Tx, si sealing.SectorInfo) error {
+	_, err := tx.ExecContext(ctx, dbqUpsertSector, si.SectorNumber, string(si.State), si.SectorType,
+		sealing.IsFailedState(si.State), lastError(si), time.Now().Unix())
+	if err != nil {
+		return xerrors.Errorf("upserting sector %d: %w", si.SectorNumber, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, dbqDeleteDeals, si.SectorNumber); err != nil {
+		return err
+	}
+	for _, p := range si.Pieces {
+		if p.DealInfo == nil {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, dbqInsertDeal, si.SectorNumber, p.DealInfo.DealID); err != nil {
+			return xerrors.Errorf("inserting deal of sector %d: %w", si.SectorNumber, err)
+		}
+	}
+	return nil
+}
+
+// lastError returns the message of the most recent error event of the
+// sector log.
+func lastError(si sealing.SectorInfo) string {
+	for i := len(si.Log) - 1; i >= 0; i-- {
+		if si.Log[i].Trace != "" {
+			return si.Log[i].Message
+		}
+	}
+	return ""
+}
+
+// Start starts refreshing the on-chain expirations of the sectors.
+func (d *DB) Start(ctx context.Context) {
+	ctx, d.cancel = context.WithCancel(ctx)
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+
+		for {
+			if err := d.RefreshExpirations(ctx); err != nil {
+				log.Warnw("refreshing sector expirations", "error", err)
+			}
+
+			select {
+			case <-time.After(ExpirationRefreshInterval):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// RefreshExpirations updates the expirations of the sectors from their
+// on-chain info.
+func (d *DB) RefreshExpirations(ctx context.Context) error {
+	sectors, err := d.api.StateMinerSectors(ctx, d.maddr, nil, types.EmptyTSK)
+	if err != nil {
+		return xerrors.Errorf("getting miner sectors: %w", err)
+	}
+
+	d.lk.Lock()
+	defer d.lk.Unlock()
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	stmt, err := tx.PrepareContext(ctx, dbqSetExpiration)
+	if err != nil {
+		return err
+	}
+	for _, s := range sectors {
+		if _, err := stmt.ExecContext(ctx, int64(s.Expiration), s.SectorNumber); err != nil {
+			return xerrors.Errorf("setting expiration of sector %d: %w", s.SectorNumber, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Query returns the sectors matching the query, ordered by sector number.
+func (d *DB) Query(ctx context.Context, q api.SectorQuery) ([]api.SectorQueryResult, error) {
+	var (
+		where []string
+		args  []interface{}
+	)
+
+	if len(q.States) > 0 {
+		where = append(where, "state IN (?"+strings.Repeat(", ?", len(q.States)-1)+")")
+		for _, st := range q.States {
+			args = append(args, string(st))
+		}
+	}
+	if q.DealID != nil {
+		where = append(where, "sector_number IN (SELECT sector_number FROM sector_deals WHERE deal_id = ?)")
+		args = append(args, *q.DealID)
+	}
+	if q.ExpiresAfter != 0 {
+		where = append(where, "expiration > ?")
+		args = append(args, int64(q.ExpiresAfter))
+	}
+	if q.ExpiresBefore != 0 {
+		where = append(where, "expiration < ?")
+		args = append(args, int64(q.ExpiresBefore))
+	}
+	if q.Failed {
+		where = append(where, "failed = 1")
+	}
+	if q.After != 0 {
+		where = append(where, "sector_number > ?")
+		args = append(args, q.After)
+	}
+
+	limit := q.Limit
+	if limit <= 0 || limit > maxQueryLimit {
+		limit = maxQueryLimit
+	}
+
+	query := "SELECT sector_number, state, sector_type, failed, last_error, expiration, updated_at FROM sectors"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY sector_number LIMIT ?"
+	args = append(args, limit)
+
+	d.lk.Lock()
+	defer d.lk.Unlock()
+
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, xerrors.Errorf("querying sectors: %w", err)
+	}
+
+	out := []api.SectorQueryResult{}
+	for rows.Next() {
+		var (
+			r          api.SectorQueryResult
+			state      string
+			expiration sql.NullInt64
+			updated    int64
+		)
+		if err := rows.Scan(&r.SectorNumber, &state, &r.SectorType, &r.Failed, &r.LastError, &expiration, &updated); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		r.State = api.SectorState(state)
+		r.Expiration = abi.ChainEpoch(expiration.Int64)
+		r.UpdatedAt = time.Unix(updated, 0)
+		out = append(out, r)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range out {
+		deals, err := d.db.QueryContext(ctx, dbqSelectSectorDeal, out[i].SectorNumber)
+		if err != nil {
+			return nil, xerrors.Errorf("querying deals of sector %d: %w", out[i].SectorNumber, err)
+		}
+		for deals.Next() {
+			var id abi.DealID
+			if err := deals.Scan(&id); err != nil {
+				_ = deals.Close()
+				return nil, err
+			}
+			out[i].Deals = append(out[i].Deals, id)
+		}
+		if err := deals.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+func (d *DB) Close() error {
+	if d.cancel != nil {
+		d.cancel()
+	}
+	d.wg.Wait()
+	return d.db.Close()
+}
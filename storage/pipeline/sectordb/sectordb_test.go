@@ -0,0 +1,123 @@
+// stm: #unit
+package sectordb
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin/v9/miner"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	sealing "github.com/filecoin-project/lotus/storage/pipeline"
+)
+
+type fakeSectorsAPI struct {
+	sectors []*miner.SectorOnChainInfo
+}
+
+func (f *fakeSectorsAPI) StateMinerSectors(context.Context, address.Address, *bitfield.BitField, types.TipSetKey) ([]*miner.SectorOnChainInfo, error) {
+	return f.sectors, nil
+}
+
+func sector(sn abi.SectorNumber, state sealing.SectorState, deals ...abi.DealID) sealing.SectorInfo {
+	si := sealing.SectorInfo{
+		SectorNumber: sn,
+		State:        state,
+		SectorType:   abi.RegisteredSealProof_StackedDrg32GiBV1_1,
+	}
+	for _, d := range deals {
+		si.Pieces = append(si.Pieces, api.SectorPiece{DealInfo: &api.PieceDealInfo{DealID: d}})
+	}
+	si.Pieces = append(si.Pieces, api.SectorPiece{}) // filler
+	return si
+}
+
+func TestSectorDB(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), DBName)
+
+	fapi := &fakeSectorsAPI{}
+	maddr, err := address.NewIDAddress(1000)
+	require.NoError(t, err)
+
+	db, err := Open(path, fapi, maddr)
+	require.NoError(t, err)
+
+	require.NoError(t, db.Sync(ctx, []sealing.SectorInfo{
+		sector(1, sealing.Proving, 10, 11),
+		sector(2, sealing.Proving, 12),
+		sector(3, sealing.PreCommit1),
+		sector(4, sealing.WaitDeals),
+	}))
+
+	failed := sector(3, sealing.SealPreCommit1Failed)
+	failed.Log = []sealing.Log{
+		{Message: "precommit1 failed", Trace: "stack"},
+		{Message: "restart"},
+	}
+	db.Update(sector(3, sealing.PreCommit1), failed)
+	db.Update(sector(4, sealing.WaitDeals), sector(4, sealing.Packing, 13))
+
+	fapi.sectors = []*miner.SectorOnChainInfo{
+		{SectorNumber: 1, Expiration: 1000},
+		{SectorNumber: 2, Expiration: 2000},
+	}
+	require.NoError(t, db.RefreshExpirations(ctx))
+
+	query := func(q api.SectorQuery) []abi.SectorNumber {
+		res, err := db.Query(ctx, q)
+		require.NoError(t, err)
+
+		out := []abi.SectorNumber{}
+		for _, r := range res {
+			out = append(out, r.SectorNumber)
+		}
+		return out
+	}
+
+	require.Equal(t, []abi.SectorNumber{1, 2, 3, 4}, query(api.SectorQuery{}))
+	require.Equal(t, []abi.SectorNumber{1, 2}, query(api.SectorQuery{States: []api.SectorState{api.SectorState(sealing.Proving)}}))
+	require.Equal(t, []abi.SectorNumber{3, 4}, query(api.SectorQuery{States: []api.SectorState{
+		api.SectorState(sealing.SealPreCommit1Failed), api.SectorState(sealing.Packing),
+	}}))
+	require.Equal(t, []abi.SectorNumber{3}, query(api.SectorQuery{Failed: true}))
+
+	deal := abi.DealID(13)
+	require.Equal(t, []abi.SectorNumber{4}, query(api.SectorQuery{DealID: &deal}))
+
+	require.Equal(t, []abi.SectorNumber{1}, query(api.SectorQuery{ExpiresBefore: 1500}))
+	require.Equal(t, []abi.SectorNumber{2}, query(api.SectorQuery{ExpiresAfter: 1500}))
+	require.Equal(t, []abi.SectorNumber{2}, query(api.SectorQuery{ExpiresAfter: 500, States: []api.SectorState{api.SectorState(sealing.Proving)}, After: 1}))
+	require.Equal(t, []abi.SectorNumber{1, 2}, query(api.SectorQuery{Limit: 2}))
+
+	res, err := db.Query(ctx, api.SectorQuery{After: 2, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Equal(t, api.SectorState(sealing.SealPreCommit1Failed), res[0].State)
+	require.True(t, res[0].Failed)
+	require.Equal(t, "precommit1 failed", res[0].LastError)
+	require.Empty(t, res[0].Deals)
+	require.Zero(t, res[0].Expiration)
+
+	res, err = db.Query(ctx, api.SectorQuery{Limit: 1})
+	require.NoError(t, err)
+	require.Equal(t, []abi.DealID{10, 11}, res[0].Deals)
+	require.EqualValues(t, 1000, res[0].Expiration)
+	require.Equal(t, abi.RegisteredSealProof_StackedDrg32GiBV1_1, res[0].SectorType)
+	require.NoError(t, db.Close())
+
+	// removed sectors are dropped on sync
+	db, err = Open(path, fapi, maddr)
+	require.NoError(t, err)
+	require.NoError(t, db.Sync(ctx, []sealing.SectorInfo{sector(2, sealing.Proving, 12)}))
+	require.Equal(t, []abi.SectorNumber{2}, query(api.SectorQuery{}))
+	require.Empty(t, query(api.SectorQuery{DealID: &deal}))
+	require.NoError(t, db.Close())
+}
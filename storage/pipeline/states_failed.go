@@ -22,20 +22,46 @@ import (
 
 var MinRetryTime = 1 * time.Minute
 
-func failedCooldown(ctx statemachine.Context, sector SectorInfo) error {
-	// TODO: Exponential backoff when we see consecutive failures
+// failedCooldown waits before retrying a failed sector, as set by the retry
+// policy of the class of its failures. It returns false if the sector must not
+// be retried because its retries are exhausted, after aborting it if the
+// policy says so.
+func (m *Sealing) failedCooldown(ctx statemachine.Context, sector SectorInfo) (bool, error) {
+	cfg, err := m.getConfig()
+	if err != nil {
+		return false, xerrors.Errorf("getting sealing config: %w", err)
+	}
+	p := cfg.RetryPolicies[sector.FailureClass]
+
+	if retriesExhausted(p, sector.Failures) {
+		reason := xerrors.Errorf("%d consecutive %s failures, retrying at most %d", sector.Failures, sector.FailureClass, p.MaxRetries)
+
+		if p.AutoAbort {
+			if evt := abortEvent(sector, reason); evt != nil {
+				log.Warnw("aborting sector", "sector", sector.SectorNumber, "state", sector.State, "reason", reason)
+				return false, ctx.Send(evt)
+			}
+		}
+
+		log.Errorw("not retrying sector, it needs manual action", "sector", sector.SectorNumber, "state", sector.State, "reason", reason)
+		return false, nil
+	}
+
+	if len(sector.Log) == 0 {
+		return true, nil
+	}
 
-	retryStart := time.Unix(int64(sector.Log[len(sector.Log)-1].Timestamp), 0).Add(MinRetryTime)
-	if len(sector.Log) > 0 && !time.Now().After(retryStart) {
+	retryStart := time.Unix(int64(sector.Log[len(sector.Log)-1].Timestamp), 0).Add(retryBackoff(p, sector.Failures))
+	if !time.Now().After(retryStart) {
 		log.Infof("%s(%d), waiting %s before retrying", sector.State, sector.SectorNumber, time.Until(retryStart))
 		select {
 		case <-time.After(time.Until(retryStart)):
 		case <-ctx.Context().Done():
-			return ctx.Context().Err()
+			return false, ctx.Context().Err()
 		}
 	}
 
-	return nil
+	return true, nil
 }
 
 func (m *Sealing) checkPreCommitted(ctx statemachine.Context, sector SectorInfo) (*miner.SectorPreCommitOnChainInfo, bool) {
@@ -55,7 +81,7 @@ func (m *Sealing) checkPreCommitted(ctx statemachine.Context, sector SectorInfo)
 }
 
 func (m *Sealing) handleSealPrecommit1Failed(ctx statemachine.Context, sector SectorInfo) error {
-	if err := failedCooldown(ctx, sector); err != nil {
+	if retry, err := m.failedCooldown(ctx, sector); !retry || err != nil {
 		return err
 	}
 
@@ -63,7 +89,7 @@ func (m *Sealing) handleSealPrecommit1Failed(ctx statemachine.Context, sector Se
 }
 
 func (m *Sealing) handleSealPrecommit2Failed(ctx statemachine.Context, sector SectorInfo) error {
-	if err := failedCooldown(ctx, sector); err != nil {
+	if retry, err := m.failedCooldown(ctx, sector); !retry || err != nil {
 		return err
 	}
 
@@ -85,7 +111,7 @@ func (m *Sealing) handlePreCommitFailed(ctx statemachine.Context, sector SectorI
 		mw, err := m.Api.StateSearchMsg(ctx.Context(), ts.Key(), *sector.PreCommitMessage, api.LookbackNoLimit, true)
 		if err != nil {
 			// API error
-			if err := failedCooldown(ctx, sector); err != nil {
+			if retry, err := m.failedCooldown(ctx, sector); !retry || err != nil {
 				return err
 			}
 
@@ -153,7 +179,7 @@ func (m *Sealing) handlePreCommitFailed(ctx statemachine.Context, sector SectorI
 		// TODO: we could compare more things, but I don't think we really need to
 		//  CommR tells us that CommD (and CommPs), and the ticket are all matching
 
-		if err := failedCooldown(ctx, sector); err != nil {
+		if retry, err := m.failedCooldown(ctx, sector); !retry || err != nil {
 			return err
 		}
 
@@ -164,7 +190,7 @@ func (m *Sealing) handlePreCommitFailed(ctx statemachine.Context, sector SectorI
 		log.Warn("retrying precommit even though the message failed to apply")
 	}
 
-	if err := failedCooldown(ctx, sector); err != nil {
+	if retry, err := m.failedCooldown(ctx, sector); !retry || err != nil {
 		return err
 	}
 
@@ -174,7 +200,7 @@ func (m *Sealing) handlePreCommitFailed(ctx statemachine.Context, sector SectorI
 func (m *Sealing) handleComputeProofFailed(ctx statemachine.Context, sector SectorInfo) error {
 	// TODO: Check sector files
 
-	if err := failedCooldown(ctx, sector); err != nil {
+	if retry, err := m.failedCooldown(ctx, sector); !retry || err != nil {
 		return err
 	}
 
@@ -186,7 +212,7 @@ func (m *Sealing) handleComputeProofFailed(ctx statemachine.Context, sector Sect
 }
 
 func (m *Sealing) handleRemoteCommitFailed(ctx statemachine.Context, sector SectorInfo) error {
-	if err := failedCooldown(ctx, sector); err != nil {
+	if retry, err := m.failedCooldown(ctx, sector); !retry || err != nil {
 		return err
 	}
 
@@ -198,7 +224,7 @@ func (m *Sealing) handleRemoteCommitFailed(ctx statemachine.Context, sector Sect
 }
 
 func (m *Sealing) handleSubmitReplicaUpdateFailed(ctx statemachine.Context, sector SectorInfo) error {
-	if err := failedCooldown(ctx, sector); err != nil {
+	if retry, err := m.failedCooldown(ctx, sector); !retry || err != nil {
 		return err
 	}
 
@@ -269,7 +295,7 @@ func (m *Sealing) handleSubmitReplicaUpdateFailed(ctx statemachine.Context, sect
 func (m *Sealing) handleReleaseSectorKeyFailed(ctx statemachine.Context, sector SectorInfo) error {
 	// not much we can do, wait for a bit and try again
 
-	if err := failedCooldown(ctx, sector); err != nil {
+	if retry, err := m.failedCooldown(ctx, sector); !retry || err != nil {
 		return err
 	}
 
@@ -287,7 +313,7 @@ func (m *Sealing) handleCommitFailed(ctx statemachine.Context, sector SectorInfo
 		mw, err := m.Api.StateSearchMsg(ctx.Context(), ts.Key(), *sector.CommitMessage, api.LookbackNoLimit, true)
 		if err != nil {
 			// API error
-			if err := failedCooldown(ctx, sector); err != nil {
+			if retry, err := m.failedCooldown(ctx, sector); !retry || err != nil {
 				return err
 			}
 
@@ -320,7 +346,7 @@ func (m *Sealing) handleCommitFailed(ctx statemachine.Context, sector SectorInfo
 			log.Errorf("seed changed, will retry: %+v", err)
 			return ctx.Send(SectorRetryWaitSeed{})
 		case *ErrInvalidProof:
-			if err := failedCooldown(ctx, sector); err != nil {
+			if retry, err := m.failedCooldown(ctx, sector); !retry || err != nil {
 				return err
 			}
 
@@ -340,7 +366,7 @@ func (m *Sealing) handleCommitFailed(ctx statemachine.Context, sector SectorInfo
 		case *ErrExpiredDeals:
 			return ctx.Send(SectorDealsExpired{xerrors.Errorf("sector deals expired: %w", err)})
 		case *ErrCommitWaitFailed:
-			if err := failedCooldown(ctx, sector); err != nil {
+			if retry, err := m.failedCooldown(ctx, sector); !retry || err != nil {
 				return err
 			}
 
@@ -352,7 +378,7 @@ func (m *Sealing) handleCommitFailed(ctx statemachine.Context, sector SectorInfo
 
 	// TODO: Check sector files
 
-	if err := failedCooldown(ctx, sector); err != nil {
+	if retry, err := m.failedCooldown(ctx, sector); !retry || err != nil {
 		return err
 	}
 
@@ -362,7 +388,7 @@ func (m *Sealing) handleCommitFailed(ctx statemachine.Context, sector SectorInfo
 func (m *Sealing) handleFinalizeFailed(ctx statemachine.Context, sector SectorInfo) error {
 	// TODO: Check sector files
 
-	if err := failedCooldown(ctx, sector); err != nil {
+	if retry, err := m.failedCooldown(ctx, sector); !retry || err != nil {
 		return err
 	}
 
@@ -370,7 +396,7 @@ func (m *Sealing) handleFinalizeFailed(ctx statemachine.Context, sector SectorIn
 }
 
 func (m *Sealing) handleRemoveFailed(ctx statemachine.Context, sector SectorInfo) error {
-	if err := failedCooldown(ctx, sector); err != nil {
+	if retry, err := m.failedCooldown(ctx, sector); !retry || err != nil {
 		return err
 	}
 
@@ -386,7 +412,7 @@ func (m *Sealing) handleTerminateFailed(ctx statemachine.Context, sector SectorI
 		return nil // pause the fsm, needs manual user action
 	}
 
-	if err := failedCooldown(ctx, sector); err != nil {
+	if retry, err := m.failedCooldown(ctx, sector); !retry || err != nil {
 		return err
 	}
 
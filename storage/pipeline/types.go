@@ -10,6 +10,7 @@ import (
 
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/storage/pipeline/sealiface"
 	"github.com/filecoin-project/lotus/storage/sealer"
 	"github.com/filecoin-project/lotus/storage/sealer/storiface"
 )
@@ -102,6 +103,11 @@ type SectorInfo struct {
 	RemoteSealingDoneEndpoint string
 	RemoteDataFinalized       bool
 
+	// Retries, counting the consecutive failures of the same class since the
+	// sector was last proving
+	FailureClass sealiface.FailureClass
+	Failures     uint64
+
 	// Debug
 	LastErr string
 
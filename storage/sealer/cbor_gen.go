@@ -28,7 +28,7 @@ func (t *Call) MarshalCBOR(w io.Writer) error {
 
 	cw := cbg.NewCborWriter(w)
 
-	if _, err := cw.Write([]byte{164}); err != nil {
+	if _, err := cw.Write([]byte{165}); err != nil {
 		return err
 	}
 
@@ -48,6 +48,22 @@ func (t *Call) MarshalCBOR(w io.Writer) error {
 		return err
 	}
 
+	// t.Err (sealer.ManyBytes) (struct)
+	if len("Err") > cbg.MaxLength {
+		return xerrors.Errorf("Value in field \"Err\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("Err"))); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, string("Err")); err != nil {
+		return err
+	}
+
+	if err := t.Err.MarshalCBOR(cw); err != nil {
+		return err
+	}
+
 	// t.State (sealer.CallState) (uint64)
 	if len("State") > cbg.MaxLength {
 		return xerrors.Errorf("Value in field \"State\" was too long")
@@ -152,6 +168,26 @@ func (t *Call) UnmarshalCBOR(r io.Reader) (err error) {
 					return xerrors.Errorf("unmarshaling t.ID: %w", err)
 				}
 
+			}
+			// t.Err (sealer.ManyBytes) (struct)
+		case "Err":
+
+			{
+
+				b, err := cr.ReadByte()
+				if err != nil {
+					return err
+				}
+				if b != cbg.CborNull[0] {
+					if err := cr.UnreadByte(); err != nil {
+						return err
+					}
+					t.Err = new(ManyBytes)
+					if err := t.Err.UnmarshalCBOR(cr); err != nil {
+						return xerrors.Errorf("unmarshaling t.Err pointer: %w", err)
+					}
+				}
+
 			}
 			// t.State (sealer.CallState) (uint64)
 		case "State":
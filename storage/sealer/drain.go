@@ -0,0 +1,249 @@
+package sealer
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/storage/sealer/sealtasks"
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+// DrainPollInterval is the interval at which draining workers are checked for
+// unfinished tasks.
+var DrainPollInterval = 5 * time.Second
+
+// SectorProofLookup returns the seal proof type of a sector.
+type SectorProofLookup func(ctx context.Context, sector abi.SectorID) (abi.RegisteredSealProof, error)
+
+type workerDrain struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// WorkerDrain stops assigning new tasks to the worker. Once the tasks already
+// assigned to it are finished, the sector files in its sealing-only storage
+// paths are moved to other workers, and the worker is detached. The drain runs
+// in the background, and can be cancelled with WorkerUndrain until the worker
+// is detached.
+func (m *Manager) WorkerDrain(ctx context.Context, wid storiface.WorkerID, proofType SectorProofLookup) error {
+	m.drainLk.Lock()
+	defer m.drainLk.Unlock()
+
+	if _, ok := m.drains[wid]; ok {
+		return xerrors.Errorf("worker %s is already draining", wid)
+	}
+
+	if err := m.sched.setDraining(wid, true); err != nil {
+		return err
+	}
+
+	dctx, cancel := context.WithCancel(context.Background())
+	d := &workerDrain{
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	m.drains[wid] = d
+
+	go func() {
+		defer close(d.done)
+
+		err := m.drainWorker(dctx, wid, proofType)
+
+		m.drainLk.Lock()
+		if m.drains[wid] == d {
+			delete(m.drains, wid)
+		}
+		m.drainLk.Unlock()
+
+		switch {
+		case err == nil:
+			log.Infow("drained worker detached", "worker", wid)
+		case dctx.Err() != nil:
+			log.Infow("worker drain cancelled", "worker", wid)
+		default:
+			log.Errorw("draining worker failed, the worker will not get new tasks until undrained", "worker", wid, "error", err)
+		}
+	}()
+
+	return nil
+}
+
+// WorkerUndrain cancels draining the worker, making it get new tasks assigned
+// again.
+func (m *Manager) WorkerUndrain(ctx context.Context, wid storiface.WorkerID) error {
+	m.drainLk.Lock()
+	d, ok := m.drains[wid]
+	if ok {
+		delete(m.drains, wid)
+	}
+	m.drainLk.Unlock()
+
+	if ok {
+		d.cancel()
+		select {
+		case <-d.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if err := m.sched.setDraining(wid, false); err != nil {
+		return xerrors.Errorf("worker was detached or went away: %w", err)
+	}
+	return nil
+}
+
+func (m *Manager) drainWorker(ctx context.Context, wid storiface.WorkerID, proofType SectorProofLookup) error {
+	for {
+		idle, err := m.sched.workerIdle(wid)
+		if err != nil {
+			return err
+		}
+		if idle {
+			break
+		}
+
+		select {
+		case <-time.After(DrainPollInterval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if err := m.moveWorkerSectors(ctx, wid, proofType); err != nil {
+		return xerrors.Errorf("moving sector files off the worker: %w", err)
+	}
+
+	return m.sched.detachWorker(ctx, wid)
+}
+
+// moveWorkerSectors moves the sector files stored in the sealing-only paths
+// of the worker to sealing paths of other workers. Paths reachable through
+// other workers, e.g. shared ones, are left alone.
+func (m *Manager) moveWorkerSectors(ctx context.Context, wid storiface.WorkerID, proofType SectorProofLookup) error {
+	m.sched.workersLk.RLock()
+	w, ok := m.sched.Workers[wid]
+	m.sched.workersLk.RUnlock()
+	if !ok {
+		return xerrors.Errorf("worker %s not found", wid)
+	}
+
+	paths, err := w.rpc().Paths(ctx)
+	if err != nil {
+		return xerrors.Errorf("getting worker paths: %w", err)
+	}
+
+	decls, err := m.index.StorageList(ctx)
+	if err != nil {
+		return xerrors.Errorf("listing storage: %w", err)
+	}
+
+	toMove := map[abi.SectorID]storiface.SectorFileType{}
+	for _, p := range paths {
+		if p.CanStore {
+			continue
+		}
+
+		si, err := m.index.StorageInfo(ctx, p.ID)
+		if err != nil {
+			return xerrors.Errorf("getting info of path %s: %w", p.ID, err)
+		}
+		if len(si.URLs) > 1 {
+			continue
+		}
+
+		for _, decl := range decls[p.ID] {
+			toMove[decl.SectorID] |= decl.SectorFileType
+		}
+	}
+
+	for sid, ft := range toMove {
+		spt, err := proofType(ctx, sid)
+		if err != nil {
+			log.Warnw("not moving files of unknown sector off draining worker", "worker", wid, "sector", sid, "error", err)
+			continue
+		}
+
+		sector := storiface.SectorRef{ID: sid, ProofType: spt}
+		err = m.sched.Schedule(ctx, sector, sealtasks.TTFetch, newAllocSelector(m.index, ft, storiface.PathSealing),
+			m.schedFetch(sector, ft, storiface.PathSealing, storiface.AcquireMove),
+			func(ctx context.Context, w Worker) error {
+				return nil
+			})
+		if err != nil {
+			return xerrors.Errorf("moving sector %d: %w", sid.Number, err)
+		}
+	}
+
+	return nil
+}
+
+func (sh *Scheduler) setDraining(wid storiface.WorkerID, draining bool) error {
+	sh.workersLk.Lock()
+	w, ok := sh.Workers[wid]
+	if ok {
+		w.Draining = draining
+	}
+	sh.workersLk.Unlock()
+
+	if !ok {
+		return xerrors.Errorf("worker %s not found", wid)
+	}
+
+	select {
+	case sh.workerChange <- struct{}{}:
+	default: // workerChange is buffered, and scheduling is global, so it's ok if we don't send here
+	}
+	return nil
+}
+
+// workerIdle returns true if the worker has no tasks waiting to be started,
+// preparing or running.
+func (sh *Scheduler) workerIdle(wid storiface.WorkerID) (bool, error) {
+	sh.workersLk.RLock()
+	defer sh.workersLk.RUnlock()
+
+	w, ok := sh.Workers[wid]
+	if !ok {
+		return false, xerrors.Errorf("worker %s not found", wid)
+	}
+
+	w.wndLk.Lock()
+	pending := len(w.activeWindows) > 0
+	w.wndLk.Unlock()
+
+	w.lk.Lock()
+	running := w.active.taskCounters.Sum()
+	w.lk.Unlock()
+
+	return !pending && running == 0, nil
+}
+
+// detachWorker stops handling the worker, and waits for its goroutine to exit.
+func (sh *Scheduler) detachWorker(ctx context.Context, wid storiface.WorkerID) error {
+	sh.workersLk.Lock()
+	w, ok := sh.Workers[wid]
+	if ok {
+		select {
+		case <-w.closingMgr:
+		default:
+			close(w.closingMgr)
+		}
+	}
+	sh.workersLk.Unlock()
+
+	if !ok {
+		return xerrors.Errorf("worker %s not found", wid)
+	}
+
+	select {
+	case <-w.closedMgr:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
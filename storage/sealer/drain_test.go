@@ -0,0 +1,117 @@
+// stm: #unit
+package sealer
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-statestore"
+
+	"github.com/filecoin-project/lotus/storage/paths"
+	"github.com/filecoin-project/lotus/storage/sealer/sealtasks"
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+func TestDrainWorker(t *testing.T) {
+	paths.HeartbeatInterval = 5 * time.Millisecond
+	DrainPollInterval = 5 * time.Millisecond
+
+	ctx, done := context.WithCancel(context.Background())
+	defer done()
+
+	m, lstor, stor, idx, cleanup := newTestMgr(ctx, t, datastore.NewMapDatastore())
+	defer cleanup()
+
+	arch := make(chan chan apres)
+	w := newLocalWorker(func() (storiface.Storage, error) {
+		return &testExec{apch: arch}, nil
+	}, WorkerConfig{
+		TaskTypes: []sealtasks.TaskType{sealtasks.TTAddPiece, sealtasks.TTFetch},
+	}, os.LookupEnv, stor, lstor, idx, m, statestore.New(datastore.NewMapDatastore()))
+
+	require.NoError(t, m.AddWorker(ctx, w))
+	wid := storiface.WorkerID(w.session)
+
+	proofType := func(ctx context.Context, sector abi.SectorID) (abi.RegisteredSealProof, error) {
+		return abi.RegisteredSealProof_StackedDrg2KiBV1, nil
+	}
+
+	openWindows := func(n int) {
+		require.Eventually(t, func() bool {
+			i, _ := m.sched.Info(ctx)
+			return len(i.(SchedDiagInfo).OpenWindows) == n
+		}, 5*time.Second, 3*time.Millisecond)
+	}
+
+	openWindows(SchedWindows)
+
+	addPiece := func(sn abi.SectorNumber) chan error {
+		sid := storiface.SectorRef{
+			ID:        abi.SectorID{Miner: 1000, Number: sn},
+			ProofType: abi.RegisteredSealProof_StackedDrg2KiBV1,
+		}
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := m.AddPiece(ctx, sid, nil, 1016, strings.NewReader(strings.Repeat("testthis", 127)))
+			done <- err
+		}()
+		return done
+	}
+
+	started := func() chan apres {
+		select {
+		case res := <-arch:
+			return res
+		case <-time.After(5 * time.Second):
+			t.Fatal("task not started")
+		}
+		return nil
+	}
+
+	ap1 := addPiece(1)
+	res1 := started()
+
+	// draining workers with running tasks don't get new tasks
+	require.NoError(t, m.WorkerDrain(ctx, wid, proofType))
+	require.Error(t, m.WorkerDrain(ctx, wid, proofType))
+	require.True(t, m.WorkerStats(ctx)[w.session].Draining)
+	openWindows(0)
+
+	ap2 := addPiece(2)
+	select {
+	case <-arch:
+		t.Fatal("task assigned to a draining worker")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// undrained workers get tasks again
+	require.NoError(t, m.WorkerUndrain(ctx, wid))
+	require.False(t, m.WorkerStats(ctx)[w.session].Draining)
+
+	res1 <- apres{pi: abi.PieceInfo{Size: 1024}}
+	require.NoError(t, <-ap1)
+	res2 := started()
+
+	// the worker is detached once its tasks are done
+	require.NoError(t, m.WorkerDrain(ctx, wid, proofType))
+	time.Sleep(50 * time.Millisecond)
+	require.Len(t, m.WorkerStats(ctx), 1)
+
+	res2 <- apres{pi: abi.PieceInfo{Size: 1024}}
+	require.NoError(t, <-ap2)
+
+	require.Eventually(t, func() bool {
+		return len(m.WorkerStats(ctx)) == 0
+	}, 5*time.Second, 3*time.Millisecond)
+	openWindows(0)
+
+	require.Error(t, m.WorkerUndrain(ctx, wid))
+}
@@ -0,0 +1,179 @@
+package sealer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+// JobsTree returns the state of all sealing jobs: the queued ones, with the
+// reason they can't be assigned to a worker if any, the ones assigned to each
+// worker, and the ones returned by workers.
+func (m *Manager) JobsTree(ctx context.Context) (storiface.JobsTree, error) {
+	var out storiface.JobsTree
+	now := time.Now()
+
+	queued, err := m.sched.queued(ctx)
+	if err != nil {
+		return storiface.JobsTree{}, xerrors.Errorf("getting scheduler queue: %w", err)
+	}
+
+	blocked := m.sched.blockedReasons(ctx, queued)
+	for i, req := range queued {
+		out.Queued = append(out.Queued, storiface.QueuedJob{
+			SchedId:       req.SchedId,
+			Sector:        req.Sector.ID,
+			Task:          req.TaskType,
+			Priority:      req.Priority,
+			Queued:        req.start,
+			Waiting:       now.Sub(req.start),
+			BlockedBy:     blocked[i].reason,
+			BlockedDetail: blocked[i].detail,
+		})
+	}
+
+	jobs := m.WorkerJobs()
+	treeJobs := func(wid uuid.UUID) []storiface.TreeJob {
+		out := make([]storiface.TreeJob, 0, len(jobs[wid]))
+		for _, job := range jobs[wid] {
+			tj := storiface.TreeJob{WorkerJob: job}
+			if !job.Start.IsZero() {
+				tj.Elapsed = now.Sub(job.Start)
+			}
+			out = append(out, tj)
+		}
+
+		sort.Slice(out, func(i, j int) bool {
+			if out[i].RunWait != out[j].RunWait {
+				return out[i].RunWait < out[j].RunWait
+			}
+			return out[i].Start.Before(out[j].Start)
+		})
+		return out
+	}
+
+	m.sched.workersLk.RLock()
+	for id, handle := range m.sched.Workers {
+		out.Workers = append(out.Workers, storiface.WorkerJobsTree{
+			Worker:   uuid.UUID(id),
+			Hostname: handle.Info.Hostname,
+			Enabled:  handle.Enabled,
+			Draining: handle.Draining,
+			Jobs:     treeJobs(uuid.UUID(id)),
+		})
+	}
+	m.sched.workersLk.RUnlock()
+
+	sort.Slice(out.Workers, func(i, j int) bool {
+		if out.Workers[i].Hostname != out.Workers[j].Hostname {
+			return out.Workers[i].Hostname < out.Workers[j].Hostname
+		}
+		return out.Workers[i].Worker.String() < out.Workers[j].Worker.String()
+	})
+
+	// WorkerJobs lists the returned jobs under the zero worker id
+	out.Returned = treeJobs(uuid.UUID{})
+
+	return out, nil
+}
+
+// queued returns a snapshot of the requests waiting to be scheduled, in queue
+// order.
+func (sh *Scheduler) queued(ctx context.Context) ([]*WorkerRequest, error) {
+	ch := make(chan []*WorkerRequest, 1)
+
+	select {
+	case sh.info <- func(interface{}) {
+		ch <- append([]*WorkerRequest{}, (*sh.SchedQueue)...)
+	}:
+	case <-sh.closing:
+		return nil, xerrors.New("closing")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	select {
+	case q := <-ch:
+		return q, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+type blockReason struct {
+	reason storiface.JobBlockReason
+	detail string
+}
+
+// blockedReasons checks why each request can't be assigned to any worker
+// right now, following the same checks as the assigner.
+func (sh *Scheduler) blockedReasons(ctx context.Context, reqs []*WorkerRequest) []blockReason {
+	sh.workersLk.RLock()
+	defer sh.workersLk.RUnlock()
+
+	cachedWorkers := &schedWorkerCache{
+		Workers: sh.Workers,
+		cached:  map[storiface.WorkerID]*cachedSchedWorker{},
+	}
+
+	out := make([]blockReason, len(reqs))
+	for i, req := range reqs {
+		var accepting, withStorage int
+		var fits bool
+
+		for id, handle := range sh.Workers {
+			worker, _ := cachedWorkers.Get(id)
+			if !worker.Enabled || worker.Draining {
+				continue
+			}
+
+			tasks, err := worker.TaskTypes(ctx)
+			if err != nil {
+				log.Warnw("getting worker task types", "worker", id, "error", err)
+				continue
+			}
+			if _, ok := tasks[req.TaskType]; !ok {
+				continue
+			}
+			accepting++
+
+			rpcCtx, cancel := context.WithTimeout(ctx, SelectorTimeout)
+			ok, _, err := req.Sel.Ok(rpcCtx, req.TaskType, req.Sector.ProofType, worker)
+			cancel()
+			if err != nil {
+				log.Warnw("checking if worker is acceptable", "worker", id, "error", err)
+				continue
+			}
+			if !ok {
+				continue
+			}
+			withStorage++
+
+			needRes := worker.Info.Resources.ResourceSpec(req.Sector.ProofType, req.TaskType)
+			handle.lk.Lock()
+			fits = handle.active.CanHandleRequest(req.SealTask(), needRes, id, "jobsTree", worker.Info)
+			handle.lk.Unlock()
+			if fits {
+				break
+			}
+		}
+
+		switch {
+		case fits:
+		case accepting == 0:
+			out[i] = blockReason{storiface.JobBlockedPreconditions, "no enabled worker accepts the task type"}
+		case withStorage == 0:
+			out[i] = blockReason{storiface.JobBlockedStorage, fmt.Sprintf("none of the %d workers accepting the task can access or allocate the sector files", accepting)}
+		default:
+			out[i] = blockReason{storiface.JobBlockedResources, fmt.Sprintf("none of the %d workers able to run the task have enough free resources", withStorage)}
+		}
+	}
+
+	return out
+}
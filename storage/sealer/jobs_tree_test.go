@@ -0,0 +1,98 @@
+// stm: #unit
+package sealer
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-statestore"
+
+	"github.com/filecoin-project/lotus/storage/paths"
+	"github.com/filecoin-project/lotus/storage/sealer/sealtasks"
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+func TestJobsTree(t *testing.T) {
+	paths.HeartbeatInterval = 5 * time.Millisecond
+
+	ctx, done := context.WithCancel(context.Background())
+	defer done()
+
+	m, lstor, stor, idx, cleanup := newTestMgr(ctx, t, datastore.NewMapDatastore())
+	defer cleanup()
+
+	arch := make(chan chan apres)
+	w := newLocalWorker(func() (storiface.Storage, error) {
+		return &testExec{apch: arch}, nil
+	}, WorkerConfig{
+		Name:      "jobstree",
+		TaskTypes: []sealtasks.TaskType{sealtasks.TTAddPiece, sealtasks.TTFetch},
+	}, os.LookupEnv, stor, lstor, idx, m, statestore.New(datastore.NewMapDatastore()))
+
+	require.NoError(t, m.AddWorker(ctx, w))
+
+	sector := func(sn abi.SectorNumber) storiface.SectorRef {
+		return storiface.SectorRef{
+			ID:        abi.SectorID{Miner: 1000, Number: sn},
+			ProofType: abi.RegisteredSealProof_StackedDrg2KiBV1,
+		}
+	}
+
+	apDone := make(chan error, 1)
+	go func() {
+		_, err := m.AddPiece(ctx, sector(1), nil, 1016, strings.NewReader(strings.Repeat("testthis", 127)))
+		apDone <- err
+	}()
+
+	var res chan apres
+	select {
+	case res = <-arch:
+	case <-time.After(5 * time.Second):
+		t.Fatal("task not started")
+	}
+
+	// no worker accepts PC1
+	pc1ctx, pc1cancel := context.WithCancel(ctx)
+	defer pc1cancel()
+	go func() {
+		_ = m.sched.Schedule(WithPriority(pc1ctx, 1234), sector(2), sealtasks.TTPreCommit1, newTaskSelector(), schedNop, func(ctx context.Context, w Worker) error {
+			return nil
+		})
+	}()
+
+	var tree storiface.JobsTree
+	require.Eventually(t, func() bool {
+		var err error
+		tree, err = m.JobsTree(ctx)
+		require.NoError(t, err)
+		return len(tree.Queued) == 1
+	}, 5*time.Second, 5*time.Millisecond)
+
+	q := tree.Queued[0]
+	require.Equal(t, abi.SectorNumber(2), q.Sector.Number)
+	require.Equal(t, sealtasks.TTPreCommit1, q.Task)
+	require.Equal(t, 1234, q.Priority)
+	require.Equal(t, storiface.JobBlockedPreconditions, q.BlockedBy)
+
+	require.Len(t, tree.Workers, 1)
+	require.Equal(t, "jobstree", tree.Workers[0].Hostname)
+	require.True(t, tree.Workers[0].Enabled)
+	require.Len(t, tree.Workers[0].Jobs, 1)
+	job := tree.Workers[0].Jobs[0]
+	require.Equal(t, abi.SectorNumber(1), job.Sector.Number)
+	require.Equal(t, sealtasks.TTAddPiece, job.Task)
+	require.Equal(t, storiface.RWRunning, job.RunWait)
+	require.NotZero(t, job.Elapsed)
+
+	res <- apres{pi: abi.PieceInfo{Size: 1024}}
+	require.NoError(t, <-apDone)
+
+	pc1cancel()
+}
@@ -85,6 +85,8 @@ type Manager struct {
 
 	results map[WorkID]result
 	waitRes map[WorkID]chan struct{}
+	drainLk sync.Mutex
+	drains  map[storiface.WorkerID]*workerDrain
 }
 
 var _ storiface.ProverPoSt = &Manager{}
@@ -133,6 +135,7 @@ func New(ctx context.Context, lstor *paths.Local, stor paths.Store, ls paths.Loc
 		work:       mss,
 		callToWork: map[storiface.CallID]WorkID{},
 		callRes:    map[storiface.CallID]chan result{},
+		drains:     map[storiface.WorkerID]*workerDrain{},
 		results:    map[WorkID]result{},
 		waitRes:    map[WorkID]chan struct{}{},
 	}
@@ -300,7 +303,10 @@ func (m *Manager) schedFetch(sector storiface.SectorRef, ft storiface.SectorFile
 	return PrepareAction{
 		Action: func(ctx context.Context, worker Worker) error {
 			_, err := m.waitSimpleCall(ctx)(worker.Fetch(ctx, sector, ft, ptype, am))
-			return err
+			if err != nil {
+				return &storiface.FetchError{Err: err}
+			}
+			return nil
 		},
 		PrepType: sealtasks.TTFetch,
 	}
@@ -1320,6 +1326,12 @@ func (m *Manager) RemoveSchedRequest(ctx context.Context, schedId uuid.UUID) err
 }
 
 func (m *Manager) Close(ctx context.Context) error {
+	m.drainLk.Lock()
+	for _, d := range m.drains {
+		d.cancel()
+	}
+	m.drainLk.Unlock()
+
 	m.windowPoStSched.schedClose()
 	m.winningPoStSched.schedClose()
 	return m.sched.Close(ctx)
@@ -371,7 +371,10 @@ func (m *Manager) returnResult(ctx context.Context, callID storiface.CallID, r i
 		}
 
 		if len(rch) > 0 {
-			return xerrors.Errorf("callRes channel already has a response")
+			// the worker delivers results again when it doesn't know whether
+			// they were received, e.g. after a reconnect
+			log.Warnw("duplicate call result, ignoring", "call", callID)
+			return nil
 		}
 		if cap(rch) == 0 {
 			return xerrors.Errorf("expected rch to be buffered")
@@ -383,7 +386,8 @@ func (m *Manager) returnResult(ctx context.Context, callID storiface.CallID, r i
 
 	_, ok = m.results[wid]
 	if ok {
-		return xerrors.Errorf("result for call %v already reported", wid)
+		log.Warnw("duplicate work result, ignoring", "call", callID, "work", wid)
+		return nil
 	}
 	m.results[wid] = res
 
@@ -130,6 +130,7 @@ func newTestMgr(ctx context.Context, t *testing.T, ds datastore.Datastore) (*Man
 		callRes:    map[storiface.CallID]chan result{},
 		results:    map[WorkID]result{},
 		waitRes:    map[WorkID]chan struct{}{},
+		drains:     map[storiface.WorkerID]*workerDrain{},
 	}
 
 	m.setupWorkTracker()
@@ -688,6 +689,29 @@ func TestRestartWorker(t *testing.T) {
 	require.Empty(t, uf)
 }
 
+func TestDuplicateReturn(t *testing.T) {
+	ctx, done := context.WithCancel(context.Background())
+	defer done()
+
+	m, _, _, _, cleanup := newTestMgr(ctx, t, datastore.NewMapDatastore())
+	defer cleanup()
+
+	ci := storiface.CallID{
+		Sector: abi.SectorID{Miner: 1000, Number: 1},
+		ID:     uuid.New(),
+	}
+	pi := abi.PieceInfo{Size: 1024}
+
+	// workers deliver the result again when they don't know whether it was
+	// received, e.g. after a reconnect
+	require.NoError(t, m.ReturnAddPiece(ctx, ci, pi, nil))
+	require.NoError(t, m.ReturnAddPiece(ctx, ci, pi, nil))
+
+	res, err := m.waitCall(ctx, ci)
+	require.NoError(t, err)
+	require.Equal(t, pi, res)
+}
+
 func TestReenableWorker(t *testing.T) {
 	logging.SetAllLoggers(logging.LevelDebug)
 	paths.HeartbeatInterval = 5 * time.Millisecond
@@ -816,7 +840,8 @@ l:
 	st := m.WorkerStats(ctx)
 	require.Len(t, st, 1)
 	for _, w := range st {
-		require.Equal(t, storiface.ResourceTable[sealtasks.TTAddPiece][abi.RegisteredSealProof_StackedDrg2KiBV1].MaxMemory, w.MemUsedMax)
+		res := storiface.ResourceTable[sealtasks.TTAddPiece][abi.RegisteredSealProof_StackedDrg2KiBV1]
+		require.Equal(t, res.MaxMemory+res.BaseMinMemory, w.MemUsedMax)
 	}
 }
 
@@ -878,6 +903,6 @@ l:
 	st := m.WorkerStats(ctx)
 	require.Len(t, st, 1)
 	for _, w := range st {
-		require.Equal(t, uint64(99999), w.MemUsedMax)
+		require.Equal(t, uint64(99999)+storiface.ResourceTable[sealtasks.TTAddPiece][abi.RegisteredSealProof_StackedDrg2KiBV1].BaseMinMemory, w.MemUsedMax)
 	}
 }
@@ -90,7 +90,8 @@ type Scheduler struct {
 }
 
 type WorkerHandle struct {
-	workerRpc Worker
+	workerRpc Worker // use WorkerHandle.rpc, replaced when the worker registers again
+	rpcLk     sync.RWMutex
 
 	Info storiface.WorkerInfo
 
@@ -103,6 +104,8 @@ type WorkerHandle struct {
 	activeWindows []*SchedWindow
 
 	Enabled bool
+	// Draining workers don't get new tasks assigned, see Manager.WorkerDrain
+	Draining bool
 
 	// for sync manager goroutine closing
 	cleanupStarted bool
@@ -100,6 +100,11 @@ func (a *AssignerCommon) TrySched(sh *Scheduler) {
 					continue
 				}
 
+				if worker.Draining {
+					log.Debugw("skipping draining worker", "worker", windowRequest.Worker)
+					continue
+				}
+
 				needRes := worker.Info.Resources.ResourceSpec(task.Sector.ProofType, task.TaskType)
 
 				// TODO: allow bigger windows
@@ -114,7 +114,7 @@ func (ps *poStScheduler) Schedule(ctx context.Context, primary bool, spt abi.Reg
 			ps.lk.Unlock()
 			defer ps.lk.Lock()
 
-			return work(ctx, worker.workerRpc)
+			return work(ctx, worker.rpc())
 		})
 		if err == nil {
 			return nil
@@ -200,7 +200,7 @@ func (ps *poStScheduler) watch(wid storiface.WorkerID, worker *WorkerHandle) {
 		}
 
 		sctx, scancel := context.WithTimeout(ctx, paths.HeartbeatInterval/2)
-		curSes, err := worker.workerRpc.Session(sctx)
+		curSes, err := worker.rpc().Session(sctx)
 		scancel()
 		if err != nil {
 			// Likely temporary error
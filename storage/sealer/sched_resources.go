@@ -15,6 +15,10 @@ type ActiveResources struct {
 
 	taskCounters *taskCounter
 
+	// number of tasks of each type counted in these resources, used to only
+	// account needRes.BaseMinMemory once per task type
+	baseTasks map[sealtasks.SealTaskType]int
+
 	cond    *sync.Cond
 	waiting int
 }
@@ -111,6 +115,15 @@ func (a *ActiveResources) Add(tt sealtasks.SealTaskType, wr storiface.WorkerReso
 	a.memUsedMax += r.MaxMemory
 	a.taskCounters.Add(tt)
 
+	if a.baseTasks == nil {
+		a.baseTasks = map[sealtasks.SealTaskType]int{}
+	}
+	if a.baseTasks[tt] == 0 {
+		a.memUsedMin += r.BaseMinMemory
+		a.memUsedMax += r.BaseMinMemory
+	}
+	a.baseTasks[tt]++
+
 	return a.utilization(wr) - startUtil
 }
 
@@ -123,6 +136,13 @@ func (a *ActiveResources) Free(tt sealtasks.SealTaskType, wr storiface.WorkerRes
 	a.memUsedMax -= r.MaxMemory
 	a.taskCounters.Free(tt)
 
+	a.baseTasks[tt]--
+	if a.baseTasks[tt] == 0 {
+		a.memUsedMin -= r.BaseMinMemory
+		a.memUsedMax -= r.BaseMinMemory
+		delete(a.baseTasks, tt)
+	}
+
 	if a.cond != nil {
 		a.cond.Broadcast()
 	}
@@ -145,8 +165,14 @@ func (a *ActiveResources) CanHandleRequest(tt sealtasks.SealTaskType, needRes st
 
 	res := info.Resources
 
-	// TODO: dedupe needRes.BaseMinMemory per task type (don't add if that task is already running)
-	memNeeded := needRes.MinMemory + needRes.BaseMinMemory
+	// BaseMinMemory is shared between all tasks of a type, so it's only needed
+	// if no task of that type is running yet
+	var baseMemNeeded uint64
+	if a.baseTasks[tt] == 0 {
+		baseMemNeeded = needRes.BaseMinMemory
+	}
+
+	memNeeded := needRes.MinMemory + baseMemNeeded
 	memUsed := a.memUsedMin
 	// assume that MemUsed can be swapped, so only check it in the vmem Check
 	memAvail := res.MemPhysical - memUsed
@@ -155,7 +181,7 @@ func (a *ActiveResources) CanHandleRequest(tt sealtasks.SealTaskType, needRes st
 		return false
 	}
 
-	vmemNeeded := needRes.MaxMemory + needRes.BaseMinMemory
+	vmemNeeded := needRes.MaxMemory + baseMemNeeded
 	vmemUsed := a.memUsedMax
 	workerMemoryReserved := res.MemUsed + res.MemSwapUsed // memory used outside lotus-worker (used by the OS, etc.)
 
@@ -8,6 +8,7 @@ import (
 	"runtime"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -15,6 +16,7 @@ import (
 	"github.com/ipfs/go-cid"
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-state-types/abi"
 	prooftypes "github.com/filecoin-project/go-state-types/proof"
@@ -236,6 +238,69 @@ func TestSchedStartStop(t *testing.T) {
 	require.NoError(t, sched.Close(context.TODO()))
 }
 
+type brokenConnWorker struct {
+	*schedTestWorker
+	broken atomic.Bool
+}
+
+func (b *brokenConnWorker) Session(ctx context.Context) (uuid.UUID, error) {
+	if b.broken.Load() {
+		return uuid.UUID{}, xerrors.New("connection broken")
+	}
+	return b.schedTestWorker.Session(ctx)
+}
+
+func TestSchedWorkerRegisteredAgain(t *testing.T) {
+	sched, err := newScheduler(context.Background(), "")
+	require.NoError(t, err)
+	go sched.runSched()
+
+	w := &schedTestWorker{
+		name:      "fred",
+		session:   uuid.New(),
+		resources: decentWorkerResources,
+	}
+	wid := storiface.WorkerID(w.session)
+
+	conn := &brokenConnWorker{schedTestWorker: w}
+	wh, err := newWorkerHandle(context.TODO(), conn)
+	require.NoError(t, err)
+	require.NoError(t, sched.runWorker(context.TODO(), wid, wh))
+
+	handle := func() *WorkerHandle {
+		sched.workersLk.RLock()
+		defer sched.workersLk.RUnlock()
+		return sched.Workers[wid]
+	}
+
+	// a task is running on the worker
+	tt := sealtasks.TTPreCommit1.SealTask(abi.RegisteredSealProof_StackedDrg2KiBV1)
+	res := storiface.ResourceTable[sealtasks.TTPreCommit1][abi.RegisteredSealProof_StackedDrg2KiBV1]
+	wh.lk.Lock()
+	wh.active.Add(tt, wh.Info.Resources, res)
+	wh.lk.Unlock()
+	utilization := wh.Utilization()
+	require.NotZero(t, utilization)
+
+	// the worker is still reachable over the first connection
+	again, err := newWorkerHandle(context.TODO(), w)
+	require.NoError(t, err)
+	require.NoError(t, sched.runWorker(context.TODO(), wid, again))
+	require.Same(t, wh, handle())
+	require.Same(t, conn, wh.rpc())
+
+	// the worker is resumed over the new connection, the running task stays
+	// accounted
+	conn.broken.Store(true)
+	require.NoError(t, sched.runWorker(context.TODO(), wid, again))
+	require.Same(t, wh, handle())
+	require.Same(t, w, wh.rpc())
+	require.Equal(t, 1, handle().TaskCount(&tt))
+	require.Equal(t, utilization, handle().Utilization())
+
+	require.NoError(t, sched.Close(context.TODO()))
+}
+
 func TestSched(t *testing.T) {
 	//stm: @WORKER_JOBS_001
 	storiface.ParallelNum = 1
@@ -754,3 +819,45 @@ func TestWindowCompact(t *testing.T) {
 		[][]sealtasks.TaskType{{sealtasks.TTPreCommit1, sealtasks.TTPreCommit1, sealtasks.TTAddPiece}, {sealtasks.TTPreCommit1, sealtasks.TTPreCommit2}}),
 	)
 }
+
+func TestBaseMinMemoryShared(t *testing.T) {
+	tt := sealtasks.TTPreCommit1.SealTask(abi.RegisteredSealProof_StackedDrg32GiBV1)
+	res := storiface.Resources{
+		MinMemory:      32 << 30,
+		MaxMemory:      32 << 30,
+		MaxParallelism: 1,
+		BaseMinMemory:  24 << 30,
+	}
+
+	info := storiface.WorkerInfo{
+		Resources: storiface.WorkerResources{
+			MemPhysical: 100 << 30,
+			CPUs:        32,
+		},
+	}
+
+	a := NewActiveResources(newTaskCounter())
+
+	// the base memory is only needed by the first task
+	require.True(t, a.CanHandleRequest(tt, res, storiface.WorkerID{}, "test", info))
+	a.Add(tt, info.Resources, res)
+	require.Equal(t, uint64(56<<30), a.memUsedMin)
+
+	require.True(t, a.CanHandleRequest(tt, res, storiface.WorkerID{}, "test", info))
+	a.Add(tt, info.Resources, res)
+	require.Equal(t, uint64(88<<30), a.memUsedMin)
+	require.Equal(t, uint64(88<<30), a.memUsedMax)
+
+	require.False(t, a.CanHandleRequest(tt, res, storiface.WorkerID{}, "test", info))
+
+	// other task types need their own base memory
+	other := sealtasks.TTUnseal.SealTask(abi.RegisteredSealProof_StackedDrg32GiBV1)
+	require.False(t, a.CanHandleRequest(other, storiface.Resources{BaseMinMemory: 24 << 30}, storiface.WorkerID{}, "test", info))
+
+	// and it's freed with the last task
+	a.Free(tt, info.Resources, res)
+	require.Equal(t, uint64(56<<30), a.memUsedMin)
+	a.Free(tt, info.Resources, res)
+	require.Equal(t, uint64(0), a.memUsedMin)
+	require.Equal(t, uint64(0), a.memUsedMax)
+}
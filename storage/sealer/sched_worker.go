@@ -47,16 +47,62 @@ func newWorkerHandle(ctx context.Context, w Worker) (*WorkerHandle, error) {
 	return worker, nil
 }
 
+// rpc returns the connection to the worker.
+func (wh *WorkerHandle) rpc() Worker {
+	wh.rpcLk.RLock()
+	defer wh.rpcLk.RUnlock()
+	return wh.workerRpc
+}
+
+func (wh *WorkerHandle) setRpc(w Worker) {
+	wh.rpcLk.Lock()
+	defer wh.rpcLk.Unlock()
+	wh.workerRpc = w
+}
+
 // context only used for startup
 func (sh *Scheduler) runWorker(ctx context.Context, wid storiface.WorkerID, worker *WorkerHandle) error {
 	sh.workersLk.Lock()
-	_, exist := sh.Workers[wid]
+	old, exist := sh.Workers[wid]
 	if exist {
-		log.Warnw("duplicated worker added", "id", wid)
-
-		// this is ok, we're already handling this worker in a different goroutine
 		sh.workersLk.Unlock()
-		return nil
+
+		// The worker registers again when it lost its connection to the miner.
+		// The connection to the worker isn't re-established by the RPC client,
+		// so when it's broken the worker is resumed over the new one. The
+		// handle is kept, the resources of the tasks still running on the
+		// worker stay accounted on it.
+		sctx, cancel := context.WithTimeout(ctx, paths.HeartbeatInterval/2)
+		_, err := old.rpc().Session(sctx)
+		cancel()
+		if err == nil {
+			log.Warnw("duplicated worker added", "id", wid)
+
+			// this is ok, we're already handling this worker in a different goroutine
+			return nil
+		}
+
+		sh.workersLk.Lock()
+		if cur, exist := sh.Workers[wid]; exist {
+			defer sh.workersLk.Unlock()
+
+			if cur != old {
+				log.Warnw("worker registered again concurrently", "id", wid)
+				return nil
+			}
+			if old.cleanupStarted {
+				return xerrors.Errorf("worker %s with a broken connection is being closed", wid)
+			}
+
+			log.Warnw("worker registered again after its connection was lost, resuming it", "id", wid, "error", err)
+
+			// the session check of the worker goroutine passes over the new
+			// connection, which enables the worker again
+			old.setRpc(worker.rpc())
+			old.Info = worker.Info
+			return nil
+		}
+		// the old handle got closed in the meantime
 	}
 
 	sh.Workers[wid] = worker
@@ -95,6 +141,14 @@ func (sw *schedWorker) handleWorker() {
 			log.Warnw("failed to disable worker", "worker", sw.wid, "error", err)
 		}
 
+		// windows assigned before the worker got disabled may not have been
+		// received yet, return their tasks to the scheduler too
+		if sw.receiveInflightWindows() {
+			if err := sw.disable(ctx); err != nil {
+				log.Warnw("failed to return in-flight windows", "worker", sw.wid, "error", err)
+			}
+		}
+
 		sched.workersLk.Lock()
 		delete(sched.Workers, sw.wid)
 		sched.workersLk.Unlock()
@@ -105,11 +159,24 @@ func (sw *schedWorker) handleWorker() {
 	for {
 		{
 			sched.workersLk.Lock()
-			enabled := worker.Enabled
+			enabled, draining := worker.Enabled, worker.Draining
 			sched.workersLk.Unlock()
 
-			// ask for more windows if we need them (non-blocking)
-			if enabled {
+			switch {
+			case draining:
+				// return tasks which didn't start yet to the scheduler, and
+				// don't ask for more
+				worker.wndLk.Lock()
+				pending := len(worker.activeWindows) > 0
+				worker.wndLk.Unlock()
+
+				if pending || sw.windowsRequested > 0 {
+					if err := sw.disable(ctx); err != nil {
+						log.Warnw("failed to disable draining worker", "worker", sw.wid, "error", err)
+					}
+				}
+			case enabled:
+				// ask for more windows if we need them (non-blocking)
 				if !sw.requestWindows() {
 					return // graceful shutdown
 				}
@@ -126,7 +193,7 @@ func (sw *schedWorker) handleWorker() {
 			// session looks good
 			{
 				sched.workersLk.Lock()
-				enabled := worker.Enabled
+				enabled, draining := worker.Enabled, worker.Draining
 				worker.Enabled = true
 				sched.workersLk.Unlock()
 
@@ -134,6 +201,14 @@ func (sw *schedWorker) handleWorker() {
 					// go send window requests
 					break
 				}
+				if draining && sw.windowsRequested > 0 {
+					// go return windows of the draining worker
+					break
+				}
+				if !draining && sw.windowsRequested == 0 {
+					// the worker was undrained, go send window requests
+					break
+				}
 			}
 
 			// wait for more tasks to be assigned by the main scheduler or for the worker
@@ -156,6 +231,12 @@ func (sw *schedWorker) handleWorker() {
 
 		// process assigned windows (non-blocking)
 		sched.workersLk.RLock()
+		if worker.Draining {
+			// windows assigned before the worker started draining are
+			// returned to the scheduler at the top of the loop
+			sched.workersLk.RUnlock()
+			continue
+		}
 		worker.wndLk.Lock()
 
 		sw.workerCompactWindows()
@@ -200,13 +281,38 @@ func (sw *schedWorker) disable(ctx context.Context) error {
 	return nil
 }
 
+// receiveInflightWindows moves windows sent to the worker but not received yet
+// into its active windows, returning true if there were any.
+func (sw *schedWorker) receiveInflightWindows() bool {
+	var received bool
+	for {
+		select {
+		case w := <-sw.scheduledWindows:
+			sw.worker.wndLk.Lock()
+			sw.worker.activeWindows = append(sw.worker.activeWindows, w)
+			sw.worker.wndLk.Unlock()
+			received = true
+		default:
+			return received
+		}
+	}
+}
+
 func (sw *schedWorker) checkSession(ctx context.Context) bool {
+	var lostAt time.Time
+
 	for {
 		sctx, scancel := context.WithTimeout(ctx, paths.HeartbeatInterval/2)
-		curSes, err := sw.worker.workerRpc.Session(sctx)
+		curSes, err := sw.worker.rpc().Session(sctx)
 		scancel()
 		if err != nil {
-			// Likely temporary error
+			// Likely temporary error. Tasks which are already running aren't
+			// affected, the worker delivers their results once it can reach the
+			// miner again, and it registers again over a new connection if this
+			// one is broken, see Scheduler.runWorker.
+			if lostAt.IsZero() {
+				lostAt = time.Now()
+			}
 
 			log.Warnw("failed to check worker session", "error", err)
 
@@ -243,6 +349,10 @@ func (sw *schedWorker) checkSession(ctx context.Context) bool {
 			return false
 		}
 
+		if !lostAt.IsZero() {
+			log.Infow("worker session resumed", "worker", sw.wid, "after", time.Since(lostAt))
+		}
+
 		return true
 	}
 }
@@ -462,7 +572,7 @@ func (sw *schedWorker) startProcessingTask(req *WorkerRequest) error {
 
 	go func() {
 		// first run the prepare step (e.g. fetching sector data from other worker)
-		tw := sh.workTracker.worker(sw.wid, w.Info, w.workerRpc)
+		tw := sh.workTracker.worker(sw.wid, w.Info, w.rpc())
 		tw.start()
 		err := req.prepare.Action(req.Ctx, tw)
 		w.lk.Lock()
@@ -488,7 +598,7 @@ func (sw *schedWorker) startProcessingTask(req *WorkerRequest) error {
 			return
 		}
 
-		tw = sh.workTracker.worker(sw.wid, w.Info, w.workerRpc)
+		tw = sh.workTracker.worker(sw.wid, w.Info, w.rpc())
 
 		// start tracking work first early in case we need to wait for resources
 		werr := make(chan error, 1)
@@ -543,7 +653,7 @@ func (sw *schedWorker) startProcessingReadyTask(req *WorkerRequest) error {
 
 	go func() {
 		// Do the work!
-		tw := sh.workTracker.worker(sw.wid, w.Info, w.workerRpc)
+		tw := sh.workTracker.worker(sw.wid, w.Info, w.rpc())
 		tw.start()
 		err := req.work(req.Ctx, tw)
 
@@ -0,0 +1,158 @@
+// stm: #unit
+package sealprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/storage/paths"
+	"github.com/filecoin-project/lotus/storage/sealer/fsutil"
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+type testStorage struct {
+	c storiface.StorageConfig
+}
+
+func (t *testStorage) GetStorage() (storiface.StorageConfig, error) {
+	return t.c, nil
+}
+
+func (t *testStorage) SetStorage(f func(*storiface.StorageConfig)) error {
+	f(&t.c)
+	return nil
+}
+
+func (t *testStorage) Stat(path string) (fsutil.FsStat, error) {
+	return fsutil.Statfs(path)
+}
+
+func (t *testStorage) DiskUsage(path string) (int64, error) {
+	return 1, nil
+}
+
+func TestProvider(t *testing.T) {
+	ctx := context.Background()
+
+	root := t.TempDir()
+	meta, err := json.Marshal(&storiface.LocalStorageMeta{
+		ID:      storiface.ID(uuid.New().String()),
+		Weight:  1,
+		CanSeal: true,
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(root, paths.MetaFile), meta, 0644))
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, storiface.FTUnsealed.String()), 0755))
+	sectorFile := filepath.Join(root, storiface.FTUnsealed.String(), "s-t01000-1")
+	require.NoError(t, os.WriteFile(sectorFile, []byte("sector data"), 0644))
+	otherFile := filepath.Join(root, storiface.FTUnsealed.String(), "s-t01001-1")
+	require.NoError(t, os.WriteFile(otherFile, []byte("other sector data"), 0644))
+
+	index := paths.NewIndex(nil)
+	local, err := paths.NewLocal(ctx, &testStorage{c: storiface.StorageConfig{
+		StoragePaths: []storiface.LocalPath{{Path: root}},
+	}}, index, nil)
+	require.NoError(t, err)
+
+	_, err = New(local, index, "")
+	require.Error(t, err)
+
+	p, err := New(local, index, "secret")
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	token := MinerToken("secret", 1000)
+	require.NotEqual(t, token, MinerToken("secret", 1001))
+	require.NotEqual(t, token, MinerToken("other", 1000))
+
+	request := func(method, path, token string, body interface{}) (int, []byte) {
+		var rd io.Reader
+		if body != nil {
+			b, err := json.Marshal(body)
+			require.NoError(t, err)
+			rd = bytes.NewReader(b)
+		}
+
+		req, err := http.NewRequest(method, srv.URL+path, rd)
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close() //nolint:errcheck
+
+		out, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, out
+	}
+
+	code, _ := request("GET", "/sectors/unsealed/s-t01000-1", "", nil)
+	require.Equal(t, http.StatusUnauthorized, code)
+	code, _ = request("GET", "/sectors/unsealed/s-t01000-1", "wrong", nil)
+	require.Equal(t, http.StatusUnauthorized, code)
+	code, _ = request("GET", "/sectors/unsealed/s-t01000-1", "secret", nil)
+	require.Equal(t, http.StatusUnauthorized, code)
+
+	code, data := request("GET", "/sectors/unsealed/s-t01000-1", token, nil)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "sector data", string(data))
+
+	code, _ = request("GET", "/sectors/update/s-t01000-1", token, nil)
+	require.Equal(t, http.StatusBadRequest, code)
+
+	// the token can be embedded in endpoint urls
+	u, err := url.Parse(srv.URL + "/sectors/unsealed/s-t01000-1")
+	require.NoError(t, err)
+	u.User = url.UserPassword("miner", token)
+	resp, err := http.Get(u.String())
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// the token gives no access to the sectors of other miners
+	code, _ = request("GET", "/sectors/unsealed/s-t01001-1", token, nil)
+	require.Equal(t, http.StatusUnauthorized, code)
+	code, _ = request("POST", "/sectors/s-t01001-1/commit1", token, api.RemoteCommit1Params{})
+	require.Equal(t, http.StatusUnauthorized, code)
+	code, _ = request("POST", "/commit2", token, api.RemoteCommit2Params{Sector: abi.SectorID{Miner: 1001, Number: 1}})
+	require.Equal(t, http.StatusUnauthorized, code)
+	code, _ = request("POST", "/sectors/s-t01001-1/done", token, api.RemoteSealingDoneParams{Successful: true, State: "Proving"})
+	require.Equal(t, http.StatusUnauthorized, code)
+	require.FileExists(t, otherFile)
+
+	code, data = request("GET", "/sectors/unsealed/s-t01001-1", MinerToken("secret", 1001), nil)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "other sector data", string(data))
+
+	// sectors are only removed once successfully imported
+	code, _ = request("POST", "/sectors/s-t01000-1/done", token, api.RemoteSealingDoneParams{State: "Removing"})
+	require.Equal(t, http.StatusOK, code)
+	require.FileExists(t, sectorFile)
+
+	code, _ = request("POST", "/sectors/s-t01000-1/done", token, api.RemoteSealingDoneParams{Successful: true, State: "Proving"})
+	require.Equal(t, http.StatusOK, code)
+	require.NoFileExists(t, sectorFile)
+	require.FileExists(t, otherFile)
+
+	code, _ = request("GET", "/sectors/unsealed/s-t01000-1", token, nil)
+	require.Equal(t, http.StatusInternalServerError, code)
+}
@@ -0,0 +1,118 @@
+// Package sharedmap keeps read-only files mapped into memory for as long as
+// any task is using them.
+//
+// The mappings are shared (MAP_SHARED), so all processes mapping the same file,
+// including the proofs code running the tasks, are backed by the same page
+// cache pages. Holding a mapping while tasks run keeps the data resident, and
+// lets the tasks run in parallel with a single copy of it in memory.
+package sharedmap
+
+import (
+	"os"
+	"sync"
+
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/sys/unix"
+	"golang.org/x/xerrors"
+)
+
+var log = logging.Logger("sharedmap")
+
+type mapping struct {
+	data []byte
+	refs int
+}
+
+// Registry tracks the shared mappings of a process.
+type Registry struct {
+	lk   sync.Mutex
+	maps map[string]*mapping
+}
+
+func New() *Registry {
+	return &Registry{
+		maps: map[string]*mapping{},
+	}
+}
+
+// Acquire maps the file at path, or reuses an existing mapping of it. The
+// returned function must be called once the caller is done with the file; the
+// file is unmapped when the last user releases it.
+func (r *Registry) Acquire(path string) (func(), error) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	m, ok := r.maps[path]
+	if !ok {
+		data, err := mapFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		m = &mapping{data: data}
+		r.maps[path] = m
+	}
+	m.refs++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			r.release(path, m)
+		})
+	}, nil
+}
+
+func (r *Registry) release(path string, m *mapping) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	m.refs--
+	if m.refs > 0 {
+		return
+	}
+
+	delete(r.maps, path)
+	if err := unix.Munmap(m.data); err != nil {
+		log.Errorw("unmapping shared file", "path", path, "error", err)
+	}
+}
+
+// Size returns the total size of the files currently mapped.
+func (r *Registry) Size() uint64 {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	var size uint64
+	for _, m := range r.maps {
+		size += uint64(len(m.data))
+	}
+	return size
+}
+
+func mapFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, xerrors.Errorf("opening file: %w", err)
+	}
+	defer f.Close() // nolint:errcheck
+
+	st, err := f.Stat()
+	if err != nil {
+		return nil, xerrors.Errorf("stat file: %w", err)
+	}
+	if st.Size() == 0 {
+		return nil, xerrors.Errorf("can't map empty file %s", path)
+	}
+
+	data, err := unix.Mmap(int(f.Fd()), 0, int(st.Size()), unix.PROT_READ, unix.MAP_SHARED)
+	if err != nil {
+		return nil, xerrors.Errorf("mapping file %s: %w", path, err)
+	}
+
+	// start reading the data in the background, the tasks will need all of it
+	if err := unix.Madvise(data, unix.MADV_WILLNEED); err != nil {
+		log.Warnw("madvise on shared file", "path", path, "error", err)
+	}
+
+	return data, nil
+}
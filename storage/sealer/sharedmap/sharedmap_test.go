@@ -0,0 +1,49 @@
+// stm: #unit
+package sharedmap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	dir := t.TempDir()
+
+	a := filepath.Join(dir, "a")
+	require.NoError(t, os.WriteFile(a, make([]byte, 4096), 0644))
+	b := filepath.Join(dir, "b")
+	require.NoError(t, os.WriteFile(b, make([]byte, 8192), 0644))
+	empty := filepath.Join(dir, "empty")
+	require.NoError(t, os.WriteFile(empty, nil, 0644))
+
+	r := New()
+
+	relA1, err := r.Acquire(a)
+	require.NoError(t, err)
+	relA2, err := r.Acquire(a)
+	require.NoError(t, err)
+	relB, err := r.Acquire(b)
+	require.NoError(t, err)
+
+	// files are only mapped once
+	require.Equal(t, uint64(4096+8192), r.Size())
+
+	_, err = r.Acquire(empty)
+	require.Error(t, err)
+	_, err = r.Acquire(filepath.Join(dir, "missing"))
+	require.Error(t, err)
+
+	relB()
+	require.Equal(t, uint64(4096), r.Size())
+
+	// releasing twice is a no-op
+	relA1()
+	relA1()
+	require.Equal(t, uint64(4096), r.Size())
+
+	relA2()
+	require.Equal(t, uint64(0), r.Size())
+}
@@ -21,7 +21,7 @@ func (m *Manager) WorkerStats(ctx context.Context) map[uuid.UUID]storiface.Worke
 		ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 		defer cancel()
 
-		tt, err := handle.workerRpc.TaskTypes(ctx)
+		tt, err := handle.rpc().TaskTypes(ctx)
 		var taskList []sealtasks.TaskType
 		if err != nil {
 			log.Warnw("getting worker task types in WorkerStats", "error", err)
@@ -35,6 +35,7 @@ func (m *Manager) WorkerStats(ctx context.Context) map[uuid.UUID]storiface.Worke
 			Info:       handle.Info,
 			Tasks:      taskList,
 			Enabled:    handle.Enabled,
+			Draining:   handle.Draining,
 			MemUsedMin: handle.active.memUsedMin,
 			MemUsedMax: handle.active.memUsedMax,
 			GpuUsed:    handle.active.gpuUsed,
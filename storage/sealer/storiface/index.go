@@ -86,6 +86,9 @@ type StorageInfo struct {
 	// - "update-cache"
 	// Any other value will generate a warning and be ignored.
 	DenyTypes []string
+
+	// Labels describe where the path is, see LocalStorageMeta.Labels
+	Labels map[string]string
 }
 
 type HealthReport struct {
@@ -0,0 +1,159 @@
+package storiface
+
+import (
+	"sort"
+	"strings"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+)
+
+// Storage path labels defining the failure domains of the path, from the
+// widest to the narrowest.
+const (
+	LabelRegion = "region"
+	LabelRack   = "rack"
+	LabelHost   = "host"
+)
+
+var FailureDomainLabels = []string{LabelRegion, LabelRack, LabelHost}
+
+// SamePath is the failure domain shared by copies in the same storage path,
+// narrower than all the labels.
+const SamePath = "path"
+
+// FTCopies are the file types holding copies of the data of a sector: the
+// sealed replica can be regenerated from the unsealed copy, and the unsealed
+// copy can be unsealed from the replica.
+const FTCopies = FTUnsealed | FTSealed | FTUpdate
+
+// RedundantWith returns the file types holding the other copies of the data
+// of a sector than the file type.
+func (t SectorFileType) RedundantWith() SectorFileType {
+	switch t {
+	case FTUnsealed:
+		return FTSealed | FTUpdate
+	case FTSealed, FTUpdate:
+		return FTUnsealed
+	default:
+		return FTNone
+	}
+}
+
+// SharedFailureDomain returns the narrowest failure domain label shared by the
+// paths with the labels a and b, or "" if they don't share one. A label not
+// set on one of the paths isn't shared, the paths are in different narrower
+// failure domains when they are in different wider ones.
+func SharedFailureDomain(a, b map[string]string) string {
+	var shared string
+	for _, l := range FailureDomainLabels {
+		av, bv := a[l], b[l]
+		if av == "" || bv == "" {
+			continue
+		}
+		if av != bv {
+			break
+		}
+		shared = l
+	}
+	return shared
+}
+
+// FailureDomainLevel ranks the failure domain labels, the narrower the higher.
+// Not sharing a failure domain is level 0.
+func FailureDomainLevel(label string) int {
+	if label == SamePath {
+		return len(FailureDomainLabels) + 1
+	}
+	for i, l := range FailureDomainLabels {
+		if l == label {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// FailureDomain formats the failure domain labels of a path, widest first.
+func FailureDomain(labels map[string]string) string {
+	var parts []string
+	for _, l := range FailureDomainLabels {
+		if v := labels[l]; v != "" {
+			parts = append(parts, l+"="+v)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseLabels parses key=value path labels.
+func ParseLabels(args []string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, a := range args {
+		k, v, ok := strings.Cut(a, "=")
+		if !ok || k == "" {
+			return nil, xerrors.Errorf("invalid label %q, expected key=value", a)
+		}
+		out[k] = v
+	}
+	return out, nil
+}
+
+// PlacementReport describes how the copies of the data of the sectors are
+// spread over the failure domains of the storage paths.
+type PlacementReport struct {
+	// Domains are the failure domains of the long-term storage paths, with the
+	// number of sector data copies they hold.
+	Domains []DomainPlacement
+	// Unlabeled are the long-term storage paths without failure domain labels.
+	Unlabeled []ID
+
+	// Sectors is the number of sectors with several copies of their data.
+	Sectors int
+	// CoLocated are the sectors with copies sharing a failure domain.
+	CoLocated []SectorPlacement
+}
+
+type DomainPlacement struct {
+	// Domain is the failure domain of the paths, e.g.
+	// "region=eu,rack=r1,host=store-1"
+	Domain string
+	Paths  []ID
+	Copies int
+}
+
+// SectorPlacement is a sector with several copies of its data in a failure
+// domain.
+type SectorPlacement struct {
+	Sector abi.SectorID
+	// Shared is the narrowest failure domain label shared by copies.
+	Shared string
+	Copies []SectorCopy
+}
+
+type SectorCopy struct {
+	Storage  ID
+	FileType SectorFileType
+	Domain   string
+}
+
+// SortPlacement orders the co-located sectors by the narrowest shared failure
+// domain first, then by sector.
+func (r *PlacementReport) SortPlacement() {
+	sort.Slice(r.CoLocated, func(i, j int) bool {
+		li, lj := FailureDomainLevel(r.CoLocated[i].Shared), FailureDomainLevel(r.CoLocated[j].Shared)
+		if li != lj {
+			return li > lj
+		}
+		si, sj := r.CoLocated[i].Sector, r.CoLocated[j].Sector
+		if si.Miner != sj.Miner {
+			return si.Miner < sj.Miner
+		}
+		return si.Number < sj.Number
+	})
+	sort.Slice(r.Domains, func(i, j int) bool {
+		return r.Domains[i].Domain < r.Domains[j].Domain
+	})
+	sort.Slice(r.Unlabeled, func(i, j int) bool {
+		return r.Unlabeled[i] < r.Unlabeled[j]
+	})
+}
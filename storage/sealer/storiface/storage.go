@@ -214,4 +214,9 @@ type LocalStorageMeta struct {
 	// - "update-cache"
 	// Any other value will generate a warning and be ignored.
 	DenyTypes []string
+
+	// Labels describe where the path is. The "region", "rack" and "host"
+	// labels are the failure domains of the path, the copies of the data of a
+	// sector are preferably placed in different failure domains.
+	Labels map[string]string
 }
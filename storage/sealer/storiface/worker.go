@@ -80,9 +80,10 @@ func (wr WorkerResources) PrepResourceSpec(spt abi.RegisteredSealProof, tt, prep
 }
 
 type WorkerStats struct {
-	Info    WorkerInfo
-	Tasks   []sealtasks.TaskType
-	Enabled bool
+	Info     WorkerInfo
+	Tasks    []sealtasks.TaskType
+	Enabled  bool
+	Draining bool
 
 	MemUsedMin uint64
 	MemUsedMax uint64
@@ -117,6 +118,66 @@ type WorkerJob struct {
 	Hostname string `json:",omitempty"` // optional, set for ret-wait jobs
 }
 
+// JobsTree is a snapshot of all sealing jobs known to the scheduler.
+type JobsTree struct {
+	// Queued are the jobs waiting to be assigned to a worker, in the order the
+	// scheduler considers them.
+	Queued []QueuedJob
+	// Workers are the workers with the jobs assigned to, preparing or running
+	// on them.
+	Workers []WorkerJobsTree
+	// Returned are the jobs finished by workers, which the miner didn't process
+	// yet.
+	Returned []TreeJob
+}
+
+type QueuedJob struct {
+	SchedId  uuid.UUID
+	Sector   abi.SectorID
+	Task     sealtasks.TaskType
+	Priority int
+
+	Queued  time.Time
+	Waiting time.Duration
+
+	// BlockedBy is set when no worker can take the job right now, the job is
+	// otherwise waiting for a worker to request more work.
+	BlockedBy JobBlockReason
+	// BlockedDetail describes why the job is blocked.
+	BlockedDetail string `json:",omitempty"`
+}
+
+type JobBlockReason string
+
+const (
+	// JobBlockedPreconditions means that no enabled, non-draining worker
+	// accepts the task type.
+	JobBlockedPreconditions JobBlockReason = "preconditions"
+	// JobBlockedStorage means that none of the workers accepting the task can
+	// access the sector files, or allocate space for them.
+	JobBlockedStorage JobBlockReason = "storage"
+	// JobBlockedResources means that none of the workers able to run the task
+	// have enough free resources for it.
+	JobBlockedResources JobBlockReason = "resources"
+)
+
+type WorkerJobsTree struct {
+	Worker   uuid.UUID
+	Hostname string
+	Enabled  bool
+	Draining bool
+
+	// Jobs are sorted by state, running jobs first, then by start time.
+	Jobs []TreeJob
+}
+
+type TreeJob struct {
+	WorkerJob
+
+	// Elapsed is the time since Start, or zero if unknown.
+	Elapsed time.Duration
+}
+
 type CallID struct {
 	Sector abi.SectorID
 	ID     uuid.UUID
@@ -213,6 +274,20 @@ func Err(code ErrorCode, sub error) *CallError {
 	}
 }
 
+// FetchError is returned by the calls which failed to fetch the sector files
+// they need to the worker running them.
+type FetchError struct {
+	Err error
+}
+
+func (e *FetchError) Error() string {
+	return fmt.Sprintf("fetching sector files: %s", e.Err)
+}
+
+func (e *FetchError) Unwrap() error {
+	return e.Err
+}
+
 type WorkerReturn interface {
 	ReturnDataCid(ctx context.Context, callID CallID, pi abi.PieceInfo, err *CallError) error
 	ReturnAddPiece(ctx context.Context, callID CallID, pi abi.PieceInfo, err *CallError) error
@@ -233,3 +308,37 @@ type WorkerReturn interface {
 	ReturnDownloadSector(ctx context.Context, callID CallID, err *CallError) error
 	ReturnFetch(ctx context.Context, callID CallID, err *CallError) error
 }
+
+// TaskLogReturn is implemented by the WorkerReturn collecting the logs of the
+// tasks run by the workers.
+type TaskLogReturn interface {
+	ReturnTaskLogs(ctx context.Context, log TaskLog) error
+}
+
+// TaskLog is the output captured on a worker while it ran a task.
+type TaskLog struct {
+	CallID CallID
+	// Task is empty for the calls which aren't a sealing task, like moving
+	// the sector files
+	Task   sealtasks.TaskType
+	Method string
+	Worker string
+
+	Start time.Time
+	End   time.Time
+	Error string
+
+	// Lines are the lines captured while the task ran. The tasks running
+	// concurrently on the worker share the lines captured meanwhile.
+	Lines []TaskLogLine
+	// Dropped is the number of lines dropped from the start of the log when
+	// the task outputs more than the worker keeps.
+	Dropped int
+}
+
+type TaskLogLine struct {
+	Time time.Time
+	// Stream is stdout, stderr or log for the structured logs
+	Stream  string
+	Message string
+}
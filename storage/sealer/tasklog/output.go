@@ -0,0 +1,38 @@
+package tasklog
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+	"golang.org/x/xerrors"
+)
+
+// CaptureOutput records the stdout and stderr of the process, including the
+// output of the proofs library, which is still written to the original
+// outputs.
+func (r *Recorder) CaptureOutput() error {
+	for _, o := range []struct {
+		stream string
+		fd     int
+	}{{StreamStdout, 1}, {StreamStderr, 2}} {
+		orig, err := unix.Dup(o.fd)
+		if err != nil {
+			return xerrors.Errorf("duplicating %s: %w", o.stream, err)
+		}
+
+		pr, pw, err := os.Pipe()
+		if err != nil {
+			return xerrors.Errorf("creating %s pipe: %w", o.stream, err)
+		}
+		if err := unix.Dup2(int(pw.Fd()), o.fd); err != nil {
+			return xerrors.Errorf("redirecting %s: %w", o.stream, err)
+		}
+		// the output is now the only writer of the pipe
+		if err := pw.Close(); err != nil {
+			return err
+		}
+
+		go r.Capture(o.stream, pr, os.NewFile(uintptr(orig), o.stream))
+	}
+	return nil
+}
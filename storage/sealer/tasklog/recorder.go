@@ -0,0 +1,139 @@
+package tasklog
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+
+	"github.com/filecoin-project/lotus/storage/sealer/sealtasks"
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+	StreamLog    = "log"
+
+	// longer lines are truncated
+	maxLineSize = 4 << 10
+)
+
+// the lines printed by the loggers on stderr, which are captured as
+// structured logs already
+var loggerLine = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\S+\t(DEBUG|INFO|WARN|ERROR|DPANIC|PANIC|FATAL)\t`)
+
+// Recorder captures the output of a worker while it runs tasks, and keeps
+// the logs of the tasks in a store. The output of the proofs library doesn't
+// tell which task it's about, so the lines captured are added to the logs of
+// all the tasks running at the time.
+type Recorder struct {
+	store    *Store
+	maxLines int
+
+	lk      sync.Mutex
+	running map[storiface.CallID]*storiface.TaskLog
+}
+
+// NewRecorder creates a recorder keeping up to maxLines lines per task.
+func NewRecorder(store *Store, maxLines int) *Recorder {
+	return &Recorder{
+		store:    store,
+		maxLines: maxLines,
+		running:  map[storiface.CallID]*storiface.TaskLog{},
+	}
+}
+
+// Start starts capturing the output for the call.
+func (r *Recorder) Start(ci storiface.CallID, task sealtasks.TaskType, method, worker string) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	r.running[ci] = &storiface.TaskLog{
+		CallID: ci,
+		Task:   task,
+		Method: method,
+		Worker: worker,
+		Start:  time.Now(),
+	}
+}
+
+// Finish stops capturing the output for the call, and stores its log.
+func (r *Recorder) Finish(ctx context.Context, ci storiface.CallID, err error) *storiface.TaskLog {
+	r.lk.Lock()
+	tl, ok := r.running[ci]
+	delete(r.running, ci)
+	r.lk.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	tl.End = time.Now()
+	if err != nil {
+		tl.Error = err.Error()
+	}
+	if err := r.store.Put(ctx, *tl); err != nil {
+		log.Warnw("failed to store task log", "call", ci, "error", err)
+	}
+	return tl
+}
+
+// Record adds a line to the logs of the running tasks.
+func (r *Recorder) Record(stream, msg string) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	if len(r.running) == 0 {
+		return
+	}
+
+	if len(msg) > maxLineSize {
+		msg = msg[:maxLineSize]
+	}
+	line := storiface.TaskLogLine{
+		Time:    time.Now(),
+		Stream:  stream,
+		Message: msg,
+	}
+	for _, tl := range r.running {
+		if len(tl.Lines) >= r.maxLines {
+			tl.Lines = tl.Lines[1:]
+			tl.Dropped++
+		}
+		tl.Lines = append(tl.Lines, line)
+	}
+}
+
+// Capture records the lines read from rd until it's closed, writing them to
+// echo too when it's set.
+func (r *Recorder) Capture(stream string, rd io.Reader, echo io.Writer) {
+	br := bufio.NewReader(rd)
+	for {
+		line, err := br.ReadString('\n')
+		if line != "" {
+			if echo != nil {
+				_, _ = io.WriteString(echo, line)
+			}
+			if stream != StreamStderr || !loggerLine.MatchString(line) {
+				r.Record(stream, strings.TrimRight(line, "\r\n"))
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// CaptureLogs records the structured logs of the process, until the returned
+// closer is closed.
+func (r *Recorder) CaptureLogs() io.Closer {
+	pr := logging.NewPipeReader(logging.PipeFormat(logging.JSONOutput))
+	go r.Capture(StreamLog, pr, nil)
+	return pr
+}
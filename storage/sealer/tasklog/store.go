@@ -0,0 +1,175 @@
+package tasklog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/storage/sealer/sealtasks"
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+var log = logging.Logger("tasklog")
+
+const gcInterval = time.Hour
+
+// Store keeps the logs of the tasks for the retention period.
+type Store struct {
+	ds        datastore.Batching
+	retention time.Duration
+
+	closing chan struct{}
+	closed  chan struct{}
+}
+
+func NewStore(ds datastore.Batching, retention time.Duration) *Store {
+	return &Store{
+		ds:        ds,
+		retention: retention,
+		closing:   make(chan struct{}),
+		closed:    make(chan struct{}),
+	}
+}
+
+// the logs are keyed by sector, then start time
+func sectorKey(sector abi.SectorID) datastore.Key {
+	return datastore.NewKey(fmt.Sprintf("/%d/%d", sector.Miner, sector.Number))
+}
+
+func logKey(tl *storiface.TaskLog) datastore.Key {
+	return sectorKey(tl.CallID.Sector).ChildString(fmt.Sprintf("%d-%s", tl.Start.UnixNano(), tl.CallID.ID))
+}
+
+func (s *Store) Put(ctx context.Context, tl storiface.TaskLog) error {
+	b, err := json.Marshal(&tl)
+	if err != nil {
+		return xerrors.Errorf("marshaling task log: %w", err)
+	}
+	if err := s.ds.Put(ctx, logKey(&tl), b); err != nil {
+		return xerrors.Errorf("storing task log: %w", err)
+	}
+	return nil
+}
+
+// Get returns the logs of the tasks of the given type run for the sector, or
+// of all its tasks when the type is empty, oldest first.
+func (s *Store) Get(ctx context.Context, sector abi.SectorID, task sealtasks.TaskType) ([]storiface.TaskLog, error) {
+	res, err := s.ds.Query(ctx, query.Query{Prefix: sectorKey(sector).String()})
+	if err != nil {
+		return nil, xerrors.Errorf("querying task logs: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	out := []storiface.TaskLog{}
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, xerrors.Errorf("iterating task logs: %w", r.Error)
+		}
+
+		var tl storiface.TaskLog
+		if err := json.Unmarshal(r.Value, &tl); err != nil {
+			return nil, xerrors.Errorf("unmarshaling task log %s: %w", r.Key, err)
+		}
+		if task != "" && tl.Task != task {
+			continue
+		}
+		out = append(out, tl)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Start.Before(out[j].Start)
+	})
+	return out, nil
+}
+
+// GC removes the logs of the tasks started before the retention period.
+func (s *Store) GC(ctx context.Context) error {
+	res, err := s.ds.Query(ctx, query.Query{KeysOnly: true})
+	if err != nil {
+		return xerrors.Errorf("querying task logs: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	cutoff := time.Now().Add(-s.retention).UnixNano()
+	var expired []datastore.Key
+	for r := range res.Next() {
+		if r.Error != nil {
+			return xerrors.Errorf("iterating task logs: %w", r.Error)
+		}
+
+		k := datastore.NewKey(r.Key)
+		start, err := strconv.ParseInt(strings.SplitN(k.BaseNamespace(), "-", 2)[0], 10, 64)
+		if err != nil {
+			log.Warnw("invalid task log key", "key", r.Key)
+			continue
+		}
+		if start < cutoff {
+			expired = append(expired, k)
+		}
+	}
+
+	b, err := s.ds.Batch(ctx)
+	if err != nil {
+		return err
+	}
+	for _, k := range expired {
+		if err := b.Delete(ctx, k); err != nil {
+			return xerrors.Errorf("removing task log %s: %w", k, err)
+		}
+	}
+	return b.Commit(ctx)
+}
+
+// Start runs the GC of the logs periodically.
+func (s *Store) Start(context.Context) error {
+	go s.run()
+	return nil
+}
+
+func (s *Store) Stop(ctx context.Context) error {
+	close(s.closing)
+
+	select {
+	case <-s.closed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Store) run() {
+	defer close(s.closed)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-s.closing
+		cancel()
+	}()
+
+	ticker := time.NewTicker(gcInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.GC(ctx); err != nil && ctx.Err() == nil {
+			log.Warnw("failed to gc task logs", "error", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
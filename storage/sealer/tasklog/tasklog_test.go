@@ -0,0 +1,125 @@
+// stm: #unit
+package tasklog
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/storage/sealer/sealtasks"
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+func callID(n abi.SectorNumber) storiface.CallID {
+	return storiface.CallID{
+		Sector: abi.SectorID{Miner: 1000, Number: n},
+		ID:     uuid.New(),
+	}
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewStore(dssync.MutexWrap(datastore.NewMapDatastore()), time.Hour)
+
+	now := time.Now()
+	put := func(n abi.SectorNumber, task sealtasks.TaskType, start time.Time) {
+		require.NoError(t, s.Put(ctx, storiface.TaskLog{
+			CallID: callID(n),
+			Task:   task,
+			Start:  start,
+			End:    start.Add(time.Minute),
+		}))
+	}
+
+	put(9, sealtasks.TTPreCommit2, now.Add(-time.Minute))
+	put(9, sealtasks.TTPreCommit1, now.Add(-30*time.Minute))
+	put(9, sealtasks.TTPreCommit2, now.Add(-2*time.Hour))
+	put(90, sealtasks.TTPreCommit2, now)
+
+	sector := abi.SectorID{Miner: 1000, Number: 9}
+
+	all, err := s.Get(ctx, sector, "")
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	require.Equal(t, sealtasks.TTPreCommit2, all[0].Task)
+	require.Equal(t, sealtasks.TTPreCommit1, all[1].Task)
+	require.True(t, all[1].Start.Before(all[2].Start))
+
+	pc2, err := s.Get(ctx, sector, sealtasks.TTPreCommit2)
+	require.NoError(t, err)
+	require.Len(t, pc2, 2)
+
+	require.NoError(t, s.GC(ctx))
+
+	pc2, err = s.Get(ctx, sector, sealtasks.TTPreCommit2)
+	require.NoError(t, err)
+	require.Len(t, pc2, 1)
+	require.WithinDuration(t, now.Add(-time.Minute), pc2[0].Start, time.Millisecond)
+
+	other, err := s.Get(ctx, abi.SectorID{Miner: 1000, Number: 90}, "")
+	require.NoError(t, err)
+	require.Len(t, other, 1)
+}
+
+func TestRecorder(t *testing.T) {
+	ctx := context.Background()
+	s := NewStore(dssync.MutexWrap(datastore.NewMapDatastore()), time.Hour)
+	r := NewRecorder(s, 2)
+
+	// nothing is running
+	r.Record(StreamStdout, "lost")
+
+	pc1, pc2 := callID(1), callID(2)
+	r.Start(pc1, sealtasks.TTPreCommit1, "SealPreCommit1", "worker")
+	r.Record(StreamStdout, "a")
+	r.Start(pc2, sealtasks.TTPreCommit2, "SealPreCommit2", "worker")
+	r.Record(StreamStderr, "b")
+	r.Record(StreamLog, "c")
+
+	tl := r.Finish(ctx, pc1, nil)
+	require.NotNil(t, tl)
+	require.Empty(t, tl.Error)
+	require.Equal(t, 1, tl.Dropped)
+	require.Len(t, tl.Lines, 2)
+	require.Equal(t, "b", tl.Lines[0].Message)
+	require.Equal(t, StreamStderr, tl.Lines[0].Stream)
+	require.Equal(t, "c", tl.Lines[1].Message)
+
+	r.Record(StreamStdout, "d")
+	tl = r.Finish(ctx, pc2, xerrors.New("pc2 failed"))
+	require.NotNil(t, tl)
+	require.Equal(t, "pc2 failed", tl.Error)
+	require.Equal(t, 1, tl.Dropped)
+	require.Equal(t, "c", tl.Lines[0].Message)
+	require.Equal(t, "d", tl.Lines[1].Message)
+
+	// already finished
+	require.Nil(t, r.Finish(ctx, pc2, nil))
+
+	stored, err := s.Get(ctx, pc2.Sector, "")
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	require.Equal(t, "pc2 failed", stored[0].Error)
+	require.Equal(t, "SealPreCommit2", stored[0].Method)
+}
+
+func TestCaptureSkipsLoggerLines(t *testing.T) {
+	r := NewRecorder(NewStore(dssync.MutexWrap(datastore.NewMapDatastore()), time.Hour), 10)
+
+	ci := callID(1)
+	r.Start(ci, sealtasks.TTPreCommit2, "SealPreCommit2", "worker")
+	r.Capture(StreamStderr, strings.NewReader("2023-01-02T15:04:05.000Z\tINFO\tsealer\tworker_local.go:1\tdone\nrust panicked\n"), nil)
+
+	tl := r.Finish(context.Background(), ci, nil)
+	require.Len(t, tl.Lines, 1)
+	require.Equal(t, "rust panicked", tl.Lines[0].Message)
+}
@@ -1,6 +1,7 @@
 package sealer
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 
@@ -31,6 +32,7 @@ type Call struct {
 	State CallState
 
 	Result *ManyBytes // json bytes
+	Err    *ManyBytes // json storiface.CallError, empty if the call succeeded
 }
 
 func (wt *workerCallTracker) onStart(ci storiface.CallID, rt ReturnType) error {
@@ -41,11 +43,26 @@ func (wt *workerCallTracker) onStart(ci storiface.CallID, rt ReturnType) error {
 	})
 }
 
-func (wt *workerCallTracker) onDone(ci storiface.CallID, ret []byte) error {
+// onDone buffers the result of the call until it's returned to the manager,
+// so that it can be delivered again after a worker restart.
+func (wt *workerCallTracker) onDone(ci storiface.CallID, res interface{}, cerr *storiface.CallError) error {
+	rb, err := json.Marshal(res)
+	if err != nil {
+		return xerrors.Errorf("marshaling call result: %w", err)
+	}
+
+	var eb []byte
+	if cerr != nil {
+		if eb, err = json.Marshal(cerr); err != nil {
+			return xerrors.Errorf("marshaling call error: %w", err)
+		}
+	}
+
 	st := wt.st.Get(ci)
 	return st.Mutate(func(cs *Call) error {
 		cs.State = CallDone
-		cs.Result = &ManyBytes{ret}
+		cs.Result = &ManyBytes{rb}
+		cs.Err = &ManyBytes{eb}
 		return nil
 	})
 }
@@ -60,6 +77,24 @@ func (wt *workerCallTracker) unfinished() ([]Call, error) {
 	return out, wt.st.List(&out)
 }
 
+// bufferedResult returns the result of a finished call, in the form accepted by
+// doReturn.
+func (c *Call) bufferedResult() (interface{}, *storiface.CallError, error) {
+	var cerr *storiface.CallError
+	if c.Err != nil && len(c.Err.b) > 0 {
+		cerr = new(storiface.CallError)
+		if err := json.Unmarshal(c.Err.b, cerr); err != nil {
+			return nil, nil, xerrors.Errorf("unmarshaling call error: %w", err)
+		}
+	}
+
+	var res storedResult
+	if c.Result != nil {
+		res = c.Result.b
+	}
+	return res, cerr, nil
+}
+
 // Ideally this would be a tag on the struct field telling cbor-gen to enforce higher max-len
 type ManyBytes struct {
 	b []byte
@@ -25,7 +25,9 @@ import (
 	"github.com/filecoin-project/lotus/storage/paths"
 	"github.com/filecoin-project/lotus/storage/sealer/ffiwrapper"
 	"github.com/filecoin-project/lotus/storage/sealer/sealtasks"
+	"github.com/filecoin-project/lotus/storage/sealer/sharedmap"
 	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+	"github.com/filecoin-project/lotus/storage/sealer/tasklog"
 )
 
 var pathTypes = []storiface.SectorFileType{storiface.FTUnsealed, storiface.FTSealed, storiface.FTCache, storiface.FTUpdate, storiface.FTUpdateCache}
@@ -44,6 +46,15 @@ type WorkerConfig struct {
 
 	MaxParallelChallengeReads int           // 0 = no limit
 	ChallengeReadTimeout      time.Duration // 0 = no timeout
+
+	// SharedPC1Params keeps the SDR parent cache mapped while PC1 tasks run,
+	// so that parallel PC1 tasks share a single copy of it in memory, and makes
+	// the scheduler account for that memory once instead of per task.
+	SharedPC1Params bool
+
+	// TaskLogs captures the output of the tasks when set. Their logs are sent
+	// to the WorkerReturn when it implements storiface.TaskLogReturn.
+	TaskLogs *tasklog.Recorder
 }
 
 // used do provide custom proofs impl (mostly used in testing)
@@ -72,6 +83,11 @@ type LocalWorker struct {
 	challengeThrottle    chan struct{}
 	challengeReadTimeout time.Duration
 
+	// nil unless WorkerConfig.SharedPC1Params is set
+	sharedParams *sharedmap.Registry
+	// nil unless WorkerConfig.TaskLogs is set
+	taskLogs *tasklog.Recorder
+
 	session     uuid.UUID
 	testDisable int64
 	closing     chan struct{}
@@ -99,6 +115,7 @@ func newLocalWorker(executor ExecutorFunc, wcfg WorkerConfig, envLookup EnvFunc,
 		envLookup:            envLookup,
 		ignoreResources:      wcfg.IgnoreResourceFiltering,
 		challengeReadTimeout: wcfg.ChallengeReadTimeout,
+		taskLogs:             wcfg.TaskLogs,
 		session:              uuid.New(),
 		closing:              make(chan struct{}),
 	}
@@ -115,6 +132,10 @@ func newLocalWorker(executor ExecutorFunc, wcfg WorkerConfig, envLookup EnvFunc,
 		w.challengeThrottle = make(chan struct{}, wcfg.MaxParallelChallengeReads)
 	}
 
+	if wcfg.SharedPC1Params {
+		w.sharedParams = sharedmap.New()
+	}
+
 	if w.executor == nil {
 		w.executor = w.ffiExec
 	}
@@ -127,11 +148,25 @@ func newLocalWorker(executor ExecutorFunc, wcfg WorkerConfig, envLookup EnvFunc,
 
 	go func() {
 		for _, call := range unfinished {
-			err := storiface.Err(storiface.ErrTempWorkerRestart, xerrors.Errorf("worker [name: %s] restarted", w.name))
+			var res interface{}
+			var err *storiface.CallError
+
+			if call.State == CallDone {
+				// the call finished, but the result didn't reach the manager
+				// before the restart, deliver it again
+				var berr error
+				res, err, berr = call.bufferedResult()
+				if berr != nil {
+					log.Errorf("reading buffered result: %s: %+v", call.RetType, berr)
+					res, err = nil, storiface.Err(storiface.ErrTempWorkerRestart, xerrors.Errorf("worker [name: %s] restarted, buffered result unreadable: %w", w.name, berr))
+				}
+			} else {
+				err = storiface.Err(storiface.ErrTempWorkerRestart, xerrors.Errorf("worker [name: %s] restarted", w.name))
+			}
 
 			// TODO: Handle restarting PC1 once support is merged
 
-			if doReturn(context.TODO(), call.RetType, call.ID, ret, nil, err) {
+			if doReturn(context.TODO(), call.RetType, call.ID, ret, res, err) {
 				if err := w.ct.onReturned(call.ID); err != nil {
 					log.Errorf("marking call as returned failed: %s: %+v", call.RetType, err)
 				}
@@ -206,6 +241,9 @@ const (
 	Fetch                 ReturnType = "Fetch"
 )
 
+// storedResult is a json encoded call result, buffered by the call tracker
+type storedResult []byte
+
 // in: func(WorkerReturn, context.Context, CallID, err string)
 // in: func(WorkerReturn, context.Context, CallID, ret T, err string)
 func rfunc(in interface{}) func(context.Context, storiface.CallID, storiface.WorkerReturn, interface{}, *storiface.CallError) error {
@@ -223,8 +261,18 @@ func rfunc(in interface{}) func(context.Context, storiface.CallID, storiface.Wor
 
 		if withRet {
 			ret := reflect.ValueOf(i)
-			if i == nil {
+			switch r := i.(type) {
+			case nil:
 				ret = reflect.Zero(rf.Type().In(3))
+			case storedResult:
+				rv := reflect.New(rf.Type().In(3))
+				if uerr := json.Unmarshal(r, rv.Interface()); uerr != nil {
+					// don't retry returning a result which can't be decoded
+					ret = reflect.Zero(rf.Type().In(3))
+					rerr = reflect.ValueOf(storiface.Err(storiface.ErrUnknown, xerrors.Errorf("unmarshaling buffered result: %w", uerr)))
+				} else {
+					ret = rv.Elem()
+				}
 			}
 
 			ro = rf.Call([]reflect.Value{rwr, rctx, rci, ret, rerr})
@@ -260,6 +308,26 @@ var returnFunc = map[ReturnType]func(context.Context, storiface.CallID, storifac
 	Fetch:                 rfunc(storiface.WorkerReturn.ReturnFetch),
 }
 
+// the task type of the calls, in their logs
+var returnTaskType = map[ReturnType]sealtasks.TaskType{
+	DataCid:               sealtasks.TTDataCid,
+	AddPiece:              sealtasks.TTAddPiece,
+	SealPreCommit1:        sealtasks.TTPreCommit1,
+	SealPreCommit2:        sealtasks.TTPreCommit2,
+	SealCommit1:           sealtasks.TTCommit1,
+	SealCommit2:           sealtasks.TTCommit2,
+	FinalizeSector:        sealtasks.TTFinalize,
+	ReleaseUnsealed:       sealtasks.TTFinalizeUnsealed,
+	ReplicaUpdate:         sealtasks.TTReplicaUpdate,
+	ProveReplicaUpdate1:   sealtasks.TTProveReplicaUpdate1,
+	ProveReplicaUpdate2:   sealtasks.TTProveReplicaUpdate2,
+	GenerateSectorKey:     sealtasks.TTRegenSectorKey,
+	FinalizeReplicaUpdate: sealtasks.TTFinalizeReplicaUpdate,
+	UnsealPiece:           sealtasks.TTUnseal,
+	DownloadSector:        sealtasks.TTDownloadSector,
+	Fetch:                 sealtasks.TTFetch,
+}
+
 func (l *LocalWorker) asyncCall(ctx context.Context, sector storiface.SectorRef, rt ReturnType, work func(ctx context.Context, ci storiface.CallID) (interface{}, error)) (storiface.CallID, error) {
 	ci := storiface.CallID{
 		Sector: sector.ID,
@@ -280,27 +348,39 @@ func (l *LocalWorker) asyncCall(ctx context.Context, sector storiface.SectorRef,
 			closing: l.closing,
 		}
 
-		res, err := work(ctx, ci)
-		if err != nil {
-			rb, err := json.Marshal(res)
-			if err != nil {
-				log.Errorf("tracking call (marshaling results): %+v", err)
-			} else {
-				if err := l.ct.onDone(ci, rb); err != nil {
-					log.Errorf("tracking call (done): %+v", err)
-				}
-			}
+		if l.taskLogs != nil {
+			l.taskLogs.Start(ci, returnTaskType[rt], string(rt), l.name)
 		}
 
+		res, err := work(ctx, ci)
 		if err != nil {
 			err = xerrors.Errorf("%w [name: %s]", err, l.name)
 		}
+		cerr := toCallError(err)
 
-		if doReturn(ctx, rt, ci, l.ret, res, toCallError(err)) {
+		var tl *storiface.TaskLog
+		if l.taskLogs != nil {
+			tl = l.taskLogs.Finish(ctx, ci, err)
+		}
+
+		// buffer the result, if the worker restarts before the manager gets it,
+		// it's delivered again on startup
+		if err := l.ct.onDone(ci, res, cerr); err != nil {
+			log.Errorf("tracking call (done): %+v", err)
+		}
+
+		if doReturn(ctx, rt, ci, l.ret, res, cerr) {
 			if err := l.ct.onReturned(ci); err != nil {
 				log.Errorf("tracking call (done): %+v", err)
 			}
 		}
+
+		// the logs are kept on the worker too, not retrying
+		if tr, ok := l.ret.(storiface.TaskLogReturn); ok && tl != nil {
+			if err := tr.ReturnTaskLogs(ctx, *tl); err != nil {
+				log.Warnw("failed to return task logs", "call", ci, "error", err)
+			}
+		}
 	}()
 	return ci, nil
 }
@@ -394,6 +474,20 @@ func (l *LocalWorker) SealPreCommit1(ctx context.Context, sector storiface.Secto
 			}
 		}
 
+		if l.sharedParams != nil {
+			ssize, err := sector.ProofType.SectorSize()
+			if err != nil {
+				return nil, err
+			}
+
+			release, err := l.acquireParentCache(ssize)
+			if err != nil {
+				log.Warnw("sharing parent cache", "sector", sector.ID, "error", err)
+			} else {
+				defer release()
+			}
+		}
+
 		sb, err := l.executor()
 		if err != nil {
 			return nil, err
@@ -808,6 +902,12 @@ func (l *LocalWorker) Info(context.Context) (storiface.WorkerInfo, error) {
 		return storiface.WorkerInfo{}, xerrors.Errorf("interpreting resource env vars: %w", err)
 	}
 
+	if l.sharedParams != nil {
+		if err := sharedPC1Resources(resEnv); err != nil {
+			return storiface.WorkerInfo{}, xerrors.Errorf("adjusting PC1 resources for shared params: %w", err)
+		}
+	}
+
 	return storiface.WorkerInfo{
 		Hostname:        l.name,
 		IgnoreResources: l.ignoreResources,
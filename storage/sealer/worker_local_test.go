@@ -2,9 +2,13 @@ package sealer
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-datastore"
 	"github.com/stretchr/testify/require"
 
@@ -12,6 +16,7 @@ import (
 	"github.com/filecoin-project/go-statestore"
 
 	"github.com/filecoin-project/lotus/storage/paths"
+	"github.com/filecoin-project/lotus/storage/sealer/sealtasks"
 	"github.com/filecoin-project/lotus/storage/sealer/storiface"
 )
 
@@ -63,3 +68,98 @@ func TestWorkerChallengeThrottle(t *testing.T) {
 	_, err := lw.GenerateWindowPoSt(ctx, abi.RegisteredPoStProof_StackedDrgWindow32GiBV1, 0, ch, 0, nil)
 	require.NoError(t, err)
 }
+
+func TestWorkerSharedPC1Params(t *testing.T) {
+	dir := t.TempDir()
+
+	// 2KiB parent cache is 3.5KiB, the other file is for a different sector size
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "v28-sdr-parent-2k.cache"), make([]byte, 3584), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "v28-sdr-parent-8m.cache"), make([]byte, 14<<20), 0644))
+
+	lw := NewLocalWorker(WorkerConfig{SharedPC1Params: true}, nil, nil, nil, nil, statestore.New(datastore.NewMapDatastore()))
+	lw.envLookup = func(key string) (string, bool) {
+		if key == "FIL_PROOFS_PARENT_CACHE" {
+			return dir, true
+		}
+		return "", false
+	}
+
+	release, err := lw.acquireParentCache(2 << 10)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3584), lw.sharedParams.Size())
+	release()
+	require.Equal(t, uint64(0), lw.sharedParams.Size())
+
+	res := map[sealtasks.TaskType]map[abi.RegisteredSealProof]storiface.Resources{
+		sealtasks.TTPreCommit1: {
+			abi.RegisteredSealProof_StackedDrg32GiBV1:  storiface.ResourceTable[sealtasks.TTPreCommit1][abi.RegisteredSealProof_StackedDrg32GiBV1],
+			abi.RegisteredSealProof_StackedDrg2KiBV1:   storiface.ResourceTable[sealtasks.TTPreCommit1][abi.RegisteredSealProof_StackedDrg2KiBV1],
+			abi.RegisteredSealProof_StackedDrg512MiBV1: storiface.ResourceTable[sealtasks.TTPreCommit1][abi.RegisteredSealProof_StackedDrg512MiBV1],
+		},
+	}
+	require.NoError(t, sharedPC1Resources(res))
+
+	r := res[sealtasks.TTPreCommit1][abi.RegisteredSealProof_StackedDrg32GiBV1]
+	require.Equal(t, uint64(32<<30), r.MinMemory)
+	require.Equal(t, uint64(40<<30), r.MaxMemory)
+	require.Equal(t, uint64(24<<30+10<<20), r.BaseMinMemory)
+
+	// nothing to share if a task only needs memory for a layer
+	require.Equal(t, storiface.ResourceTable[sealtasks.TTPreCommit1][abi.RegisteredSealProof_StackedDrg2KiBV1], res[sealtasks.TTPreCommit1][abi.RegisteredSealProof_StackedDrg2KiBV1])
+}
+
+type testReturn struct {
+	storiface.WorkerReturn
+
+	pieces chan abi.PieceInfo
+	errs   chan *storiface.CallError
+}
+
+func (r *testReturn) ReturnAddPiece(ctx context.Context, callID storiface.CallID, pi abi.PieceInfo, err *storiface.CallError) error {
+	r.pieces <- pi
+	r.errs <- err
+	return nil
+}
+
+func TestWorkerRedeliverBufferedResults(t *testing.T) {
+	cst := statestore.New(datastore.NewMapDatastore())
+	ct := &workerCallTracker{st: cst}
+
+	sector := abi.SectorID{Miner: 1000, Number: 1}
+	pi := abi.PieceInfo{Size: 1024, PieceCID: cid.NewCidV1(cid.Raw, []byte{0x00, 0x00})}
+
+	// a call which finished before the worker restarted, and one which was
+	// still running
+	done := storiface.CallID{Sector: sector, ID: uuid.New()}
+	require.NoError(t, ct.onStart(done, AddPiece))
+	require.NoError(t, ct.onDone(done, pi, nil))
+
+	running := storiface.CallID{Sector: sector, ID: uuid.New()}
+	require.NoError(t, ct.onStart(running, AddPiece))
+
+	ret := &testReturn{
+		pieces: make(chan abi.PieceInfo, 2),
+		errs:   make(chan *storiface.CallError, 2),
+	}
+	NewLocalWorker(WorkerConfig{}, nil, nil, nil, ret, cst)
+
+	results := map[bool]abi.PieceInfo{}
+	var restartErr *storiface.CallError
+	for i := 0; i < 2; i++ {
+		p, err := <-ret.pieces, <-ret.errs
+		results[err == nil] = p
+		if err != nil {
+			restartErr = err
+		}
+	}
+
+	// the buffered result is delivered again, the running call fails
+	require.Equal(t, pi, results[true])
+	require.NotNil(t, restartErr)
+	require.Equal(t, storiface.ErrTempWorkerRestart, restartErr.Code)
+
+	require.Eventually(t, func() bool {
+		uf, err := ct.unfinished()
+		return err == nil && len(uf) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,104 @@
+package sealer
+
+import (
+	"os"
+	"path/filepath"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/storage/sealer/sealtasks"
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+// DefaultParentCacheDir is where the proofs library stores the SDR parent
+// cache when FIL_PROOFS_PARENT_CACHE isn't set.
+const DefaultParentCacheDir = "/var/tmp/filecoin-parents"
+
+// parentCacheSize returns the size of the SDR parent cache file for a sector
+// size; every node has 14 parents, stored as 4 byte indexes.
+func parentCacheSize(ssize abi.SectorSize) uint64 {
+	return uint64(ssize) / 32 * 56
+}
+
+func (l *LocalWorker) parentCacheDir() string {
+	if dir, ok := l.envLookup("FIL_PROOFS_PARENT_CACHE"); ok && dir != "" {
+		return dir
+	}
+	return DefaultParentCacheDir
+}
+
+// acquireParentCache maps the parent cache files for the sector size into the
+// shared mappings of the worker. The returned function releases them.
+func (l *LocalWorker) acquireParentCache(ssize abi.SectorSize) (func(), error) {
+	files, err := filepath.Glob(filepath.Join(l.parentCacheDir(), "*.cache"))
+	if err != nil {
+		return nil, xerrors.Errorf("listing parent cache files: %w", err)
+	}
+
+	var releases []func()
+	release := func() {
+		for _, r := range releases {
+			r()
+		}
+	}
+
+	for _, file := range files {
+		st, err := os.Stat(file)
+		if err != nil {
+			release()
+			return nil, xerrors.Errorf("stat parent cache file: %w", err)
+		}
+		if uint64(st.Size()) != parentCacheSize(ssize) {
+			continue
+		}
+
+		r, err := l.sharedParams.Acquire(file)
+		if err != nil {
+			release()
+			return nil, xerrors.Errorf("mapping parent cache: %w", err)
+		}
+		releases = append(releases, r)
+	}
+
+	if len(releases) == 0 {
+		// the first PC1 task for the sector size generates the cache
+		log.Debugw("no parent cache to share", "dir", l.parentCacheDir(), "sectorSize", ssize)
+	}
+
+	return release, nil
+}
+
+// sharedPC1Resources moves the parent cache part of PC1 memory requirements to
+// BaseMinMemory, which the scheduler only accounts once for all PC1 tasks
+// running on the worker. The part of MinMemory needed to hold a layer is left
+// per-task.
+func sharedPC1Resources(res map[sealtasks.TaskType]map[abi.RegisteredSealProof]storiface.Resources) error {
+	for spt, r := range res[sealtasks.TTPreCommit1] {
+		ssize, err := spt.SectorSize()
+		if err != nil {
+			return xerrors.Errorf("getting sector size: %w", err)
+		}
+
+		if r.MinMemory <= uint64(ssize) {
+			continue
+		}
+
+		shared := r.MinMemory - uint64(ssize)
+		if pc := parentCacheSize(ssize); shared > pc {
+			shared = pc
+		}
+		if shared > r.MaxMemory {
+			shared = r.MaxMemory
+		}
+
+		r.MinMemory -= shared
+		r.MaxMemory -= shared
+		r.BaseMinMemory += shared
+
+		res[sealtasks.TTPreCommit1][spt] = r
+	}
+
+	return nil
+}
@@ -0,0 +1,309 @@
+package sectorcost
+
+import (
+	"context"
+	"math"
+
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	builtintypes "github.com/filecoin-project/go-state-types/builtin"
+	miner8 "github.com/filecoin-project/go-state-types/builtin/v8/miner"
+	minertypes "github.com/filecoin-project/go-state-types/builtin/v9/miner"
+	"github.com/filecoin-project/go-state-types/dline"
+	"github.com/filecoin-project/go-state-types/network"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/actors/adt"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/power"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/reward"
+	"github.com/filecoin-project/lotus/chain/actors/policy"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// The gas used by the messages of a single sector, used when the chain node
+// doesn't record gas statistics
+var (
+	DefaultPreCommitGas   int64 = 30_000_000
+	DefaultProveCommitGas int64 = 70_000_000
+	DefaultWindowPoStGas  int64 = 60_000_000
+)
+
+// The collateral is over-estimated the same way the chain node does it, so the
+// estimate matches what the sealing pipeline sends
+var (
+	collateralNum = big.NewInt(110)
+	collateralDen = big.NewInt(100)
+)
+
+// The termination penalty of a sector is its expected reward over
+// terminationProjection, plus 1/terminationRewardDivisor of its daily reward
+// for each day of its age, up to terminationLifetimeCap
+var (
+	terminationProjection    = miner8.InitialPledgeProjectionPeriod
+	terminationLifetimeCap   = abi.ChainEpoch(140) * builtintypes.EpochsInDay
+	terminationRewardDivisor = int64(2)
+)
+
+type EstimateApi interface {
+	blockstore.ChainIO
+
+	ChainHead(context.Context) (*types.TipSet, error)
+	StateGetActor(context.Context, address.Address, types.TipSetKey) (*types.Actor, error)
+	StateNetworkVersion(context.Context, types.TipSetKey) (network.Version, error)
+	StateMinerInfo(context.Context, address.Address, types.TipSetKey) (api.MinerInfo, error)
+	StateMinerProvingDeadline(context.Context, address.Address, types.TipSetKey) (*dline.Info, error)
+	StateVMCirculatingSupplyInternal(context.Context, types.TipSetKey) (api.CirculatingSupply, error)
+	StateGasStats(context.Context, api.GasStatsFilter) (*api.GasStatsResult, error)
+}
+
+// Estimate estimates the collateral, fees and penalties of a sector of the
+// miner sealed with the proof type, holding the deal mix and committed for
+// duration epochs, at the state of the chain head.
+func Estimate(ctx context.Context, a EstimateApi, maddr address.Address, spt abi.RegisteredSealProof, mix api.SectorDealMix, duration abi.ChainEpoch) (*api.SectorCostEstimate, error) {
+	if duration < policy.GetMinSectorExpiration() || duration > policy.GetMaxSectorExpirationExtension() {
+		return nil, xerrors.Errorf("sector duration must be between %d and %d epochs", policy.GetMinSectorExpiration(), policy.GetMaxSectorExpirationExtension())
+	}
+
+	ssize, err := spt.SectorSize()
+	if err != nil {
+		return nil, xerrors.Errorf("getting sector size: %w", err)
+	}
+
+	qaPower, err := QAPower(ssize, duration, mix)
+	if err != nil {
+		return nil, err
+	}
+
+	head, err := a.ChainHead(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("getting chain head: %w", err)
+	}
+
+	nv, err := a.StateNetworkVersion(ctx, head.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting network version: %w", err)
+	}
+
+	store := adt.WrapStore(ctx, cbor.NewCborStore(blockstore.NewAPIBlockstore(a)))
+
+	pact, err := a.StateGetActor(ctx, power.Address, head.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("loading power actor: %w", err)
+	}
+	pst, err := power.Load(store, pact)
+	if err != nil {
+		return nil, xerrors.Errorf("loading power actor state: %w", err)
+	}
+	powerSmoothed, err := pst.TotalPowerSmoothed()
+	if err != nil {
+		return nil, xerrors.Errorf("getting total power: %w", err)
+	}
+	totalLocked, err := pst.TotalLocked()
+	if err != nil {
+		return nil, xerrors.Errorf("getting pledge collateral: %w", err)
+	}
+
+	ract, err := a.StateGetActor(ctx, reward.Address, head.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("loading reward actor: %w", err)
+	}
+	rst, err := reward.Load(store, ract)
+	if err != nil {
+		return nil, xerrors.Errorf("loading reward actor state: %w", err)
+	}
+	rewardSmoothed, err := rst.ThisEpochRewardSmoothed()
+	if err != nil {
+		return nil, xerrors.Errorf("getting reward estimate: %w", err)
+	}
+
+	circ, err := a.StateVMCirculatingSupplyInternal(ctx, head.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting circulating supply: %w", err)
+	}
+
+	// since nv17 the deposit is computed for the largest power a sector can have
+	depositPower := qaPower
+	if nv > network.Version16 {
+		depositPower = minertypes.QAPowerMax(ssize)
+	}
+	deposit, err := rst.PreCommitDepositForPower(powerSmoothed, depositPower)
+	if err != nil {
+		return nil, xerrors.Errorf("calculating precommit deposit: %w", err)
+	}
+	pledge, err := rst.InitialPledgeForPower(qaPower, totalLocked, &powerSmoothed, circ.FilCirculating)
+	if err != nil {
+		return nil, xerrors.Errorf("calculating initial pledge: %w", err)
+	}
+
+	mi, err := a.StateMinerInfo(ctx, maddr, head.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting miner info: %w", err)
+	}
+	di, err := a.StateMinerProvingDeadline(ctx, maddr, head.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting proving deadline: %w", err)
+	}
+
+	gas, fromStats := gasUsage(ctx, a, head.Height())
+	baseFee := head.MinTicketBlock().ParentBaseFee
+	fees := gas.fees(baseFee, duration, di.WPoStProvingPeriod, mi.WindowPoStPartitionSectors)
+
+	initial, maxPenalty := TerminationPenalties(rewardSmoothed, powerSmoothed, qaPower, duration)
+
+	return &api.SectorCostEstimate{
+		Height:    head.Height(),
+		SealProof: spt,
+		Duration:  duration,
+		QAPower:   qaPower,
+		BaseFee:   baseFee,
+
+		PreCommitDeposit: big.Div(big.Mul(deposit, collateralNum), collateralDen),
+		InitialPledge:    big.Div(big.Mul(pledge, collateralNum), collateralDen),
+
+		PreCommitFee:  fees.preCommit,
+		CommitFee:     fees.commit,
+		WindowPoStFee: fees.windowPoSt,
+		GasFromStats:  fromStats,
+
+		ExpectedReward:        miner8.ExpectedRewardForPower(rewardSmoothed, powerSmoothed, qaPower, duration),
+		TerminationPenalty:    initial,
+		MaxTerminationPenalty: maxPenalty,
+	}, nil
+}
+
+// QAPower returns the quality adjusted power of a sector of ssize filled with
+// the deal mix for duration epochs
+func QAPower(ssize abi.SectorSize, duration abi.ChainEpoch, mix api.SectorDealMix) (abi.StoragePower, error) {
+	if mix.Deals < 0 || mix.VerifiedDeals < 0 || mix.Deals+mix.VerifiedDeals > 1 {
+		return big.Zero(), xerrors.Errorf("deal fractions must be positive and add up to at most 1")
+	}
+
+	spacetime := big.Mul(big.NewInt(int64(ssize)), big.NewInt(int64(duration)))
+	weight := func(f float64) abi.DealWeight {
+		// fractions are kept to a millionth
+		return big.Div(big.Mul(spacetime, big.NewInt(int64(math.Round(f*1e6)))), big.NewInt(1e6))
+	}
+
+	return builtin.QAPowerForWeight(ssize, duration, weight(mix.Deals), weight(mix.VerifiedDeals)), nil
+}
+
+// TerminationPenalties returns the penalty for terminating a sector of the
+// power as soon as it's activated, and the largest penalty for terminating it
+// before it expires, reached once it's terminationLifetimeCap old.
+func TerminationPenalties(rewardEstimate, powerEstimate builtin.FilterEstimate, qaPower abi.StoragePower, duration abi.ChainEpoch) (abi.TokenAmount, abi.TokenAmount) {
+	initial := miner8.ExpectedRewardForPower(rewardEstimate, powerEstimate, qaPower, terminationProjection)
+
+	age := duration
+	if age > terminationLifetimeCap {
+		age = terminationLifetimeCap
+	}
+	dayReward := miner8.ExpectedRewardForPower(rewardEstimate, powerEstimate, qaPower, builtintypes.EpochsInDay)
+	ageReward := big.Div(big.Mul(dayReward, big.NewInt(int64(age))), big.NewInt(int64(builtintypes.EpochsInDay)*terminationRewardDivisor))
+
+	return initial, big.Add(initial, ageReward)
+}
+
+// sectorGas is the gas used by the messages sent for a single sector
+type sectorGas struct {
+	preCommit   int64
+	proveCommit int64
+	windowPoSt  int64
+}
+
+type sectorFees struct {
+	preCommit  abi.TokenAmount
+	commit     abi.TokenAmount
+	windowPoSt abi.TokenAmount
+}
+
+// fees returns the fees of the messages at the base fee. A Window PoSt is sent
+// for the partitions of the sector every proving period, its fee is shared by
+// the sectors of the partition.
+func (g sectorGas) fees(baseFee abi.TokenAmount, duration, provingPeriod abi.ChainEpoch, partitionSectors uint64) sectorFees {
+	fee := func(gas int64) abi.TokenAmount {
+		return big.Mul(baseFee, big.NewInt(gas))
+	}
+
+	posts := int64(0)
+	if provingPeriod > 0 {
+		posts = int64((duration + provingPeriod - 1) / provingPeriod)
+	}
+	if partitionSectors == 0 {
+		partitionSectors = 1
+	}
+
+	return sectorFees{
+		preCommit:  fee(g.preCommit),
+		commit:     fee(g.proveCommit),
+		windowPoSt: big.Div(big.Mul(fee(g.windowPoSt), big.NewInt(posts)), big.NewInt(int64(partitionSectors))),
+	}
+}
+
+// gasUsage returns the average gas used by the messages of the miners over the
+// last day, when the chain node records gas statistics, and the defaults
+// otherwise
+func gasUsage(ctx context.Context, a EstimateApi, height abi.ChainEpoch) (sectorGas, bool) {
+	defaults := sectorGas{
+		preCommit:   DefaultPreCommitGas,
+		proveCommit: DefaultProveCommitGas,
+		windowPoSt:  DefaultWindowPoStGas,
+	}
+
+	from := height - builtintypes.EpochsInDay
+	if from < 0 {
+		from = 0
+	}
+	res, err := a.StateGasStats(ctx, api.GasStatsFilter{FromHeight: from})
+	if err != nil {
+		return defaults, false
+	}
+
+	g, ok := minerGas(res.Stats)
+	if !ok {
+		return defaults, false
+	}
+	return g, true
+}
+
+// minerGas returns the average gas used by the messages sent to miner actors
+// for a single sector, if there are statistics for all of them
+func minerGas(stats []api.GasStats) (sectorGas, bool) {
+	type sum struct{ gas, msgs int64 }
+	sums := map[abi.MethodNum]*sum{}
+	for _, m := range []abi.MethodNum{
+		builtintypes.MethodsMiner.PreCommitSector,
+		builtintypes.MethodsMiner.ProveCommitSector,
+		builtintypes.MethodsMiner.SubmitWindowedPoSt,
+	} {
+		sums[m] = &sum{}
+	}
+
+	for _, st := range stats {
+		s, ok := sums[st.Method]
+		if !ok || !builtin.IsStorageMinerActor(st.ActorCode) {
+			continue
+		}
+		s.gas += st.GasUsed
+		s.msgs += int64(st.Messages)
+	}
+
+	avg := func(m abi.MethodNum) int64 {
+		s := sums[m]
+		if s.msgs <= 0 {
+			return 0
+		}
+		return s.gas / s.msgs
+	}
+	g := sectorGas{
+		preCommit:   avg(builtintypes.MethodsMiner.PreCommitSector),
+		proveCommit: avg(builtintypes.MethodsMiner.ProveCommitSector),
+		windowPoSt:  avg(builtintypes.MethodsMiner.SubmitWindowedPoSt),
+	}
+	return g, g.preCommit > 0 && g.proveCommit > 0 && g.windowPoSt > 0
+}
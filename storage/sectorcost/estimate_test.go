@@ -0,0 +1,85 @@
+// stm: #unit
+package sectorcost
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	builtintypes "github.com/filecoin-project/go-state-types/builtin"
+	miner8 "github.com/filecoin-project/go-state-types/builtin/v8/miner"
+	builtin0 "github.com/filecoin-project/specs-actors/actors/builtin"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+)
+
+func TestQAPower(t *testing.T) {
+	ssize := abi.SectorSize(32 << 30)
+	duration := abi.ChainEpoch(180) * builtintypes.EpochsInDay
+
+	cc, err := QAPower(ssize, duration, api.SectorDealMix{})
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(int64(ssize)), cc)
+
+	verified, err := QAPower(ssize, duration, api.SectorDealMix{VerifiedDeals: 1})
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(10*int64(ssize)), verified)
+
+	// half verified deals, half committed capacity
+	half, err := QAPower(ssize, duration, api.SectorDealMix{VerifiedDeals: 0.5})
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(11*int64(ssize)/2), half)
+
+	_, err = QAPower(ssize, duration, api.SectorDealMix{Deals: 0.6, VerifiedDeals: 0.6})
+	require.Error(t, err)
+	_, err = QAPower(ssize, duration, api.SectorDealMix{Deals: -0.1})
+	require.Error(t, err)
+}
+
+func TestTerminationPenalties(t *testing.T) {
+	rewardEstimate := builtin.FilterEstimate{PositionEstimate: big.Lsh(big.Mul(big.NewInt(20), big.NewInt(1e18)), 128), VelocityEstimate: big.Zero()}
+	powerEstimate := builtin.FilterEstimate{PositionEstimate: big.Lsh(big.NewInt(1<<50), 128), VelocityEstimate: big.Zero()}
+	qaPower := big.NewInt(32 << 30)
+
+	dayReward := miner8.ExpectedRewardForPower(rewardEstimate, powerEstimate, qaPower, builtintypes.EpochsInDay)
+	require.True(t, dayReward.GreaterThan(big.Zero()))
+
+	// sectors younger than the cap pay half a day of reward for each day of age
+	initial, maxPenalty := TerminationPenalties(rewardEstimate, powerEstimate, qaPower, 100*builtintypes.EpochsInDay)
+	require.Equal(t, big.Mul(dayReward, big.NewInt(20)), initial)
+	require.Equal(t, big.Mul(dayReward, big.NewInt(70)), maxPenalty)
+
+	_, maxPenalty = TerminationPenalties(rewardEstimate, powerEstimate, qaPower, 540*builtintypes.EpochsInDay)
+	require.Equal(t, big.Mul(dayReward, big.NewInt(90)), maxPenalty)
+}
+
+func TestFees(t *testing.T) {
+	g := sectorGas{preCommit: 10, proveCommit: 20, windowPoSt: 2349}
+
+	// 3 proving periods, and a partial one
+	f := g.fees(big.NewInt(100), 3*2880+1, 2880, 2349)
+	require.Equal(t, big.NewInt(1000), f.preCommit)
+	require.Equal(t, big.NewInt(2000), f.commit)
+	require.Equal(t, big.NewInt(400), f.windowPoSt)
+}
+
+func TestMinerGas(t *testing.T) {
+	minerCode := builtin0.StorageMinerActorCodeID
+	stats := []api.GasStats{
+		{ActorCode: minerCode, Method: builtintypes.MethodsMiner.PreCommitSector, Messages: 2, GasUsed: 60},
+		{ActorCode: minerCode, Method: builtintypes.MethodsMiner.ProveCommitSector, Messages: 1, GasUsed: 70},
+		// not a miner
+		{ActorCode: builtin0.MultisigActorCodeID, Method: builtintypes.MethodsMiner.PreCommitSector, Messages: 1, GasUsed: 1000},
+	}
+
+	_, ok := minerGas(stats)
+	require.False(t, ok)
+
+	stats = append(stats, api.GasStats{ActorCode: minerCode, Method: builtintypes.MethodsMiner.SubmitWindowedPoSt, Messages: 4, GasUsed: 400})
+	g, ok := minerGas(stats)
+	require.True(t, ok)
+	require.Equal(t, sectorGas{preCommit: 30, proveCommit: 70, windowPoSt: 100}, g)
+}
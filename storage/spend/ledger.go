@@ -0,0 +1,383 @@
+// Package spend keeps a ledger of the gas spent by the messages the miner
+// sends, attributed to the subsystem which sent them, so that operational costs
+// can be reported without parsing the chain.
+package spend
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/market"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var log = logging.Logger("spend")
+
+// The subsystems the gas is attributed to.
+const (
+	SubsystemWindowPoSt   = "window-post"
+	SubsystemPreCommit    = "precommit"
+	SubsystemCommit       = "commit"
+	SubsystemPublishDeals = "publish-deals"
+	SubsystemOther        = "other"
+)
+
+var ledgerKey = datastore.NewKey("/spend/ledger")
+
+const (
+	// resolveInterval is how often the costs of the pending messages are
+	// looked up.
+	resolveInterval = 5 * time.Minute
+	// pendingTimeout is how long messages which don't land on chain are kept.
+	pendingTimeout = 7 * 24 * time.Hour
+	// searchLimit bounds how far back executed messages are looked up.
+	searchLimit = abi.ChainEpoch(7 * builtin.EpochsInDay)
+)
+
+type ChainAPI interface {
+	ChainHead(context.Context) (*types.TipSet, error)
+	StateSearchMsg(ctx context.Context, from types.TipSetKey, msg cid.Cid, limit abi.ChainEpoch, allowReplaced bool) (*api.MsgLookup, error)
+	StateReplay(context.Context, types.TipSetKey, cid.Cid) (*api.InvocResult, error)
+}
+
+type entry struct {
+	Message   cid.Cid
+	Subsystem string
+	Method    abi.MethodNum
+	Sent      time.Time
+
+	// set once the message is executed
+	Executed bool
+	Epoch    abi.ChainEpoch
+	GasUsed  int64
+	Cost     api.MsgGasCost
+}
+
+// Ledger records the messages sent by the miner, and their gas costs once they
+// are executed.
+type Ledger struct {
+	maddr address.Address
+	chain ChainAPI
+	ds    datastore.Batching
+
+	lk      sync.Mutex
+	entries map[cid.Cid]*entry
+
+	closing chan struct{}
+	closed  chan struct{}
+}
+
+func NewLedger(maddr address.Address, chain ChainAPI, ds datastore.Batching) *Ledger {
+	return &Ledger{
+		maddr:   maddr,
+		chain:   chain,
+		ds:      ds,
+		entries: map[cid.Cid]*entry{},
+		closing: make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+}
+
+// Start loads the ledger, and starts looking up the costs of the messages.
+func (l *Ledger) Start(ctx context.Context) error {
+	if err := l.load(ctx); err != nil {
+		return err
+	}
+	go l.run()
+	return nil
+}
+
+func (l *Ledger) Stop(ctx context.Context) error {
+	close(l.closing)
+
+	select {
+	case <-l.closed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Subsystem returns the subsystem a message sent by the miner is attributed
+// to.
+func (l *Ledger) Subsystem(msg *types.Message) string {
+	switch msg.To {
+	case l.maddr:
+		switch msg.Method {
+		case builtin.MethodsMiner.SubmitWindowedPoSt, builtin.MethodsMiner.DeclareFaults, builtin.MethodsMiner.DeclareFaultsRecovered:
+			return SubsystemWindowPoSt
+		case builtin.MethodsMiner.PreCommitSector, builtin.MethodsMiner.PreCommitSectorBatch, builtin.MethodsMiner.PreCommitSectorBatch2:
+			return SubsystemPreCommit
+		case builtin.MethodsMiner.ProveCommitSector, builtin.MethodsMiner.ProveCommitAggregate,
+			builtin.MethodsMiner.ProveReplicaUpdates, builtin.MethodsMiner.ProveReplicaUpdates2:
+			return SubsystemCommit
+		}
+	case market.Address:
+		if msg.Method == builtin.MethodsMarket.PublishStorageDeals {
+			return SubsystemPublishDeals
+		}
+	}
+	return SubsystemOther
+}
+
+// Track records a message pushed by the miner.
+func (l *Ledger) Track(ctx context.Context, sm *types.SignedMessage) error {
+	e := &entry{
+		Message:   sm.Cid(),
+		Subsystem: l.Subsystem(&sm.Message),
+		Method:    sm.Message.Method,
+		Sent:      time.Now(),
+	}
+
+	l.lk.Lock()
+	defer l.lk.Unlock()
+
+	if err := l.put(ctx, e); err != nil {
+		return err
+	}
+	l.entries[e.Message] = e
+	return nil
+}
+
+func (l *Ledger) run() {
+	defer close(l.closed)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-l.closing
+		cancel()
+	}()
+
+	ticker := time.NewTicker(resolveInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := l.resolve(ctx); err != nil {
+			log.Errorw("looking up message costs", "error", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-l.closing:
+			return
+		}
+	}
+}
+
+// resolve looks up the costs of the messages executed with enough confidence.
+func (l *Ledger) resolve(ctx context.Context) error {
+	head, err := l.chain.ChainHead(ctx)
+	if err != nil {
+		return xerrors.Errorf("getting chain head: %w", err)
+	}
+
+	l.lk.Lock()
+	var pending []entry
+	for _, e := range l.entries {
+		if !e.Executed {
+			pending = append(pending, *e)
+		}
+	}
+	l.lk.Unlock()
+
+	for _, e := range pending {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		lookup, err := l.chain.StateSearchMsg(ctx, head.Key(), e.Message, searchLimit, true)
+		if err != nil {
+			log.Warnw("searching message", "message", e.Message, "error", err)
+			continue
+		}
+		if lookup == nil {
+			if time.Since(e.Sent) > pendingTimeout {
+				log.Warnw("message sent didn't land on chain, dropping it from the ledger", "message", e.Message, "subsystem", e.Subsystem)
+				if err := l.drop(ctx, e.Message); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if head.Height()-lookup.Height < abi.ChainEpoch(build.MessageConfidence) {
+			continue
+		}
+
+		res, err := l.chain.StateReplay(ctx, types.EmptyTSK, lookup.Message)
+		if err != nil {
+			log.Warnw("replaying message", "message", lookup.Message, "error", err)
+			continue
+		}
+
+		done := e
+		done.Executed = true
+		done.Epoch = lookup.Height
+		done.GasUsed = lookup.Receipt.GasUsed
+		done.Cost = res.GasCost
+
+		l.lk.Lock()
+		err = l.put(ctx, &done)
+		if err == nil {
+			l.entries[done.Message] = &done
+		}
+		l.lk.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Report aggregates the gas spent by the messages executed in the epoch range,
+// per subsystem, in buckets of the given number of epochs. A to epoch of 0
+// means up to the chain head, a bucket of 0 means daily buckets.
+func (l *Ledger) Report(ctx context.Context, from, to, bucket abi.ChainEpoch) (*api.SpendReport, error) {
+	if to <= 0 {
+		head, err := l.chain.ChainHead(ctx)
+		if err != nil {
+			return nil, xerrors.Errorf("getting chain head: %w", err)
+		}
+		to = head.Height()
+	}
+	if bucket <= 0 {
+		bucket = builtin.EpochsInDay
+	}
+	if from > to {
+		return nil, xerrors.Errorf("report range starts at %d after it ends at %d", from, to)
+	}
+
+	rep := &api.SpendReport{
+		From:   from,
+		To:     to,
+		Bucket: bucket,
+	}
+
+	totals := map[string]*api.SpendTotal{}
+	buckets := map[abi.ChainEpoch]map[string]*api.SpendTotal{}
+
+	l.lk.Lock()
+	for _, e := range l.entries {
+		if !e.Executed {
+			rep.Pending++
+			continue
+		}
+		if e.Epoch < from || e.Epoch > to {
+			continue
+		}
+
+		add(totals, e)
+
+		start := from + (e.Epoch-from)/bucket*bucket
+		if buckets[start] == nil {
+			buckets[start] = map[string]*api.SpendTotal{}
+		}
+		add(buckets[start], e)
+	}
+	l.lk.Unlock()
+
+	rep.Totals = sortedTotals(totals)
+	for start, ts := range buckets {
+		rep.Buckets = append(rep.Buckets, api.SpendBucket{
+			Start:  start,
+			Totals: sortedTotals(ts),
+		})
+	}
+	sort.Slice(rep.Buckets, func(i, j int) bool {
+		return rep.Buckets[i].Start < rep.Buckets[j].Start
+	})
+	return rep, nil
+}
+
+func add(totals map[string]*api.SpendTotal, e *entry) {
+	t, ok := totals[e.Subsystem]
+	if !ok {
+		t = &api.SpendTotal{
+			Subsystem:          e.Subsystem,
+			BaseFeeBurn:        big.Zero(),
+			OverEstimationBurn: big.Zero(),
+			MinerTip:           big.Zero(),
+			TotalCost:          big.Zero(),
+		}
+		totals[e.Subsystem] = t
+	}
+
+	t.Messages++
+	t.GasUsed += e.GasUsed
+	t.BaseFeeBurn = big.Add(t.BaseFeeBurn, e.Cost.BaseFeeBurn)
+	t.OverEstimationBurn = big.Add(t.OverEstimationBurn, e.Cost.OverEstimationBurn)
+	t.MinerTip = big.Add(t.MinerTip, e.Cost.MinerTip)
+	t.TotalCost = big.Add(t.TotalCost, e.Cost.TotalCost)
+}
+
+func sortedTotals(totals map[string]*api.SpendTotal) []api.SpendTotal {
+	out := make([]api.SpendTotal, 0, len(totals))
+	for _, t := range totals {
+		out = append(out, *t)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Subsystem < out[j].Subsystem
+	})
+	return out
+}
+
+func (l *Ledger) put(ctx context.Context, e *entry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := l.ds.Put(ctx, ledgerKey.ChildString(e.Message.String()), b); err != nil {
+		return xerrors.Errorf("storing ledger entry: %w", err)
+	}
+	return nil
+}
+
+func (l *Ledger) drop(ctx context.Context, msg cid.Cid) error {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+
+	if err := l.ds.Delete(ctx, ledgerKey.ChildString(msg.String())); err != nil {
+		return xerrors.Errorf("deleting ledger entry: %w", err)
+	}
+	delete(l.entries, msg)
+	return nil
+}
+
+func (l *Ledger) load(ctx context.Context) error {
+	res, err := l.ds.Query(ctx, query.Query{Prefix: ledgerKey.String()})
+	if err != nil {
+		return xerrors.Errorf("querying ledger: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	l.lk.Lock()
+	defer l.lk.Unlock()
+
+	for r := range res.Next() {
+		if r.Error != nil {
+			return xerrors.Errorf("reading ledger: %w", r.Error)
+		}
+		var e entry
+		if err := json.Unmarshal(r.Value, &e); err != nil {
+			return xerrors.Errorf("decoding ledger entry %s: %w", r.Key, err)
+		}
+		l.entries[e.Message] = &e
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+// stm: #unit
+package spend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/market"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+type testChain struct {
+	head    *types.TipSet
+	lookups map[cid.Cid]*api.MsgLookup
+	costs   map[cid.Cid]api.MsgGasCost
+}
+
+func (c *testChain) ChainHead(context.Context) (*types.TipSet, error) {
+	return c.head, nil
+}
+
+func (c *testChain) StateSearchMsg(ctx context.Context, from types.TipSetKey, msg cid.Cid, limit abi.ChainEpoch, allowReplaced bool) (*api.MsgLookup, error) {
+	return c.lookups[msg], nil
+}
+
+func (c *testChain) StateReplay(ctx context.Context, tsk types.TipSetKey, msg cid.Cid) (*api.InvocResult, error) {
+	return &api.InvocResult{GasCost: c.costs[msg]}, nil
+}
+
+func TestLedger(t *testing.T) {
+	ctx := context.Background()
+
+	maddr, err := address.NewIDAddress(1000)
+	require.NoError(t, err)
+	worker, err := address.NewIDAddress(1001)
+	require.NoError(t, err)
+
+	dummy, err := abi.CidBuilder.Sum([]byte("dummy"))
+	require.NoError(t, err)
+	head, err := types.NewTipSet([]*types.BlockHeader{{
+		Miner:                 maddr,
+		Height:                1000,
+		Ticket:                &types.Ticket{VRFProof: []byte("ticket")},
+		ParentStateRoot:       dummy,
+		Messages:              dummy,
+		ParentMessageReceipts: dummy,
+		ParentWeight:          big.Zero(),
+		ParentBaseFee:         big.Zero(),
+	}})
+	require.NoError(t, err)
+
+	chain := &testChain{
+		head:    head,
+		lookups: map[cid.Cid]*api.MsgLookup{},
+		costs:   map[cid.Cid]api.MsgGasCost{},
+	}
+
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	l := NewLedger(maddr, chain, ds)
+	require.NoError(t, l.load(ctx))
+
+	msg := func(to address.Address, method abi.MethodNum, nonce uint64) *types.SignedMessage {
+		return &types.SignedMessage{Message: types.Message{From: worker, To: to, Method: method, Nonce: nonce}}
+	}
+	cost := func(total int64) api.MsgGasCost {
+		return api.MsgGasCost{
+			BaseFeeBurn:        big.NewInt(total / 2),
+			OverEstimationBurn: big.Zero(),
+			MinerTip:           big.NewInt(total - total/2),
+			TotalCost:          big.NewInt(total),
+		}
+	}
+
+	wdpost := msg(maddr, builtin.MethodsMiner.SubmitWindowedPoSt, 0)
+	precommit := msg(maddr, builtin.MethodsMiner.PreCommitSectorBatch2, 1)
+	commit := msg(maddr, builtin.MethodsMiner.ProveCommitAggregate, 2)
+	publish := msg(market.Address, builtin.MethodsMarket.PublishStorageDeals, 3)
+	withdraw := msg(maddr, builtin.MethodsMiner.WithdrawBalance, 4)
+	pending := msg(maddr, builtin.MethodsMiner.SubmitWindowedPoSt, 5)
+
+	require.Equal(t, SubsystemWindowPoSt, l.Subsystem(&wdpost.Message))
+	require.Equal(t, SubsystemPreCommit, l.Subsystem(&precommit.Message))
+	require.Equal(t, SubsystemCommit, l.Subsystem(&commit.Message))
+	require.Equal(t, SubsystemPublishDeals, l.Subsystem(&publish.Message))
+	require.Equal(t, SubsystemOther, l.Subsystem(&withdraw.Message))
+
+	executed := []struct {
+		sm    *types.SignedMessage
+		epoch abi.ChainEpoch
+		gas   int64
+		total int64
+	}{
+		{wdpost, 100, 10, 100},
+		{precommit, 200, 20, 200},
+		{commit, 300, 30, 300},
+		{publish, 400, 40, 400},
+		{withdraw, 500, 50, 500},
+	}
+	for _, e := range executed {
+		require.NoError(t, l.Track(ctx, e.sm))
+		chain.lookups[e.sm.Cid()] = &api.MsgLookup{Message: e.sm.Cid(), Height: e.epoch, Receipt: types.MessageReceipt{GasUsed: e.gas}}
+		chain.costs[e.sm.Cid()] = cost(e.total)
+	}
+	require.NoError(t, l.Track(ctx, pending))
+
+	// a second window post, executed too recently to be counted
+	recent := msg(maddr, builtin.MethodsMiner.SubmitWindowedPoSt, 6)
+	require.NoError(t, l.Track(ctx, recent))
+	chain.lookups[recent.Cid()] = &api.MsgLookup{Message: recent.Cid(), Height: 999}
+	chain.costs[recent.Cid()] = cost(1000)
+
+	require.NoError(t, l.resolve(ctx))
+
+	rep, err := l.Report(ctx, 0, 1000, 250)
+	require.NoError(t, err)
+	require.Equal(t, 2, rep.Pending)
+	require.Len(t, rep.Totals, 5)
+
+	totals := map[string]api.SpendTotal{}
+	for _, tot := range rep.Totals {
+		totals[tot.Subsystem] = tot
+	}
+	require.Equal(t, 1, totals[SubsystemWindowPoSt].Messages)
+	require.Equal(t, int64(10), totals[SubsystemWindowPoSt].GasUsed)
+	require.Equal(t, big.NewInt(100), totals[SubsystemWindowPoSt].TotalCost)
+	require.Equal(t, big.NewInt(150), totals[SubsystemCommit].BaseFeeBurn)
+	require.Equal(t, big.NewInt(500), totals[SubsystemOther].TotalCost)
+
+	// epochs 0-249, 250-499 and 500-749
+	require.Len(t, rep.Buckets, 3)
+	require.Equal(t, abi.ChainEpoch(0), rep.Buckets[0].Start)
+	require.Len(t, rep.Buckets[0].Totals, 2)
+	require.Equal(t, abi.ChainEpoch(250), rep.Buckets[1].Start)
+	require.Len(t, rep.Buckets[1].Totals, 2)
+	require.Equal(t, abi.ChainEpoch(500), rep.Buckets[2].Start)
+
+	// the range only counts the messages executed in it
+	rep, err = l.Report(ctx, 250, 450, 0)
+	require.NoError(t, err)
+	require.Len(t, rep.Totals, 2)
+	require.Len(t, rep.Buckets, 1)
+
+	// the ledger is reloaded from the datastore
+	l2 := NewLedger(maddr, chain, ds)
+	require.NoError(t, l2.load(ctx))
+	rep2, err := l2.Report(ctx, 0, 1000, 250)
+	require.NoError(t, err)
+	rep, err = l.Report(ctx, 0, 1000, 250)
+	require.NoError(t, err)
+	require.Equal(t, rep, rep2)
+}
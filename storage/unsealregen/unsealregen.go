@@ -0,0 +1,372 @@
+// Package unsealregen regenerates the unsealed copies of sectors which were
+// lost, for example when the storage path holding them died, while the sector
+// still has active deals which should be kept unsealed.
+package unsealregen
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	sealing "github.com/filecoin-project/lotus/storage/pipeline"
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+var log = logging.Logger("unsealregen")
+
+type ChainAPI interface {
+	ChainHead(context.Context) (*types.TipSet, error)
+}
+
+type SectorLister interface {
+	ListSectors() ([]sealing.SectorInfo, error)
+}
+
+type SectorIndex interface {
+	StorageFindSector(ctx context.Context, sector abi.SectorID, ft storiface.SectorFileType, ssize abi.SectorSize, allowFetch bool) ([]storiface.SectorStorageInfo, error)
+	StorageHealthy(id storiface.ID) bool
+}
+
+type Unsealer interface {
+	SectorsUnsealPiece(ctx context.Context, sector storiface.SectorRef, offset storiface.UnpaddedByteIndex, size abi.UnpaddedPieceSize, randomness abi.SealRandomness, commd *cid.Cid) error
+}
+
+type Config struct {
+	// Approval requires the operator to approve the jobs before they run
+	Approval bool
+	// MaxConcurrent caps the number of jobs running at the same time
+	MaxConcurrent int
+	CheckInterval time.Duration
+	// AlwaysKeepUnsealed keeps the unsealed copies of all deal sectors, not
+	// only of the pieces marked KeepUnsealed
+	AlwaysKeepUnsealed bool
+}
+
+type job struct {
+	api.UnsealRegenJob
+
+	ref    storiface.SectorRef
+	ticket abi.SealRandomness
+	commD  *cid.Cid
+}
+
+func (j *job) setState(st api.UnsealRegenState) {
+	j.State = st
+	j.Since = time.Now()
+}
+
+type Regenerator struct {
+	cfg      Config
+	miner    abi.ActorID
+	chain    ChainAPI
+	sectors  SectorLister
+	index    SectorIndex
+	unsealer Unsealer
+
+	lk      sync.Mutex
+	jobs    map[abi.SectorNumber]*job
+	running int
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func New(cfg Config, miner abi.ActorID, chain ChainAPI, sectors SectorLister, index SectorIndex, unsealer Unsealer) *Regenerator {
+	if cfg.MaxConcurrent < 1 {
+		cfg.MaxConcurrent = 1
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Regenerator{
+		cfg:      cfg,
+		miner:    miner,
+		chain:    chain,
+		sectors:  sectors,
+		index:    index,
+		unsealer: unsealer,
+
+		jobs: map[abi.SectorNumber]*job{},
+
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+func (r *Regenerator) Start() {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		tick := time.NewTicker(r.cfg.CheckInterval)
+		defer tick.Stop()
+
+		for {
+			if err := r.check(r.ctx); err != nil {
+				log.Errorw("checking unsealed copies", "error", err)
+			}
+
+			select {
+			case <-tick.C:
+			case <-r.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (r *Regenerator) Stop(ctx context.Context) error {
+	r.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// List returns the jobs ordered by sector number.
+func (r *Regenerator) List() []api.UnsealRegenJob {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	out := make([]api.UnsealRegenJob, 0, len(r.jobs))
+	for _, j := range r.jobs {
+		out = append(out, j.UnsealRegenJob)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Sector < out[j].Sector
+	})
+	return out
+}
+
+func (r *Regenerator) Approve(sectors []abi.SectorNumber) error {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	for _, s := range sectors {
+		j, ok := r.jobs[s]
+		if !ok {
+			return xerrors.Errorf("no unsealed copy to regenerate for sector %d", s)
+		}
+		if j.Approved {
+			continue
+		}
+
+		j.Approved = true
+		j.setState(api.UnsealRegenQueued)
+	}
+
+	r.schedule()
+	return nil
+}
+
+func (r *Regenerator) Reject(sectors []abi.SectorNumber) error {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	for _, s := range sectors {
+		j, ok := r.jobs[s]
+		if !ok {
+			return xerrors.Errorf("no unsealed copy to regenerate for sector %d", s)
+		}
+		if j.State == api.UnsealRegenRunning {
+			return xerrors.Errorf("unsealed copy of sector %d already being regenerated", s)
+		}
+	}
+	for _, s := range sectors {
+		j := r.jobs[s]
+		j.Approved = false
+		j.setState(api.UnsealRegenRejected)
+	}
+	return nil
+}
+
+// check finds the sectors whose unsealed copy was lost, updates the jobs and
+// schedules the jobs ready to run.
+func (r *Regenerator) check(ctx context.Context) error {
+	head, err := r.chain.ChainHead(ctx)
+	if err != nil {
+		return xerrors.Errorf("getting chain head: %w", err)
+	}
+
+	sectors, err := r.sectors.ListSectors()
+	if err != nil {
+		return xerrors.Errorf("listing sectors: %w", err)
+	}
+
+	lost := map[abi.SectorNumber]*job{}
+	for _, si := range sectors {
+		deals := keepUnsealedDeals(si, head.Height(), r.cfg.AlwaysKeepUnsealed)
+		if len(deals) == 0 {
+			continue
+		}
+
+		sid := abi.SectorID{Miner: r.miner, Number: si.SectorNumber}
+		hasUnsealed, err := r.hasCopy(ctx, sid, storiface.FTUnsealed)
+		if err != nil {
+			return err
+		}
+		if hasUnsealed {
+			continue
+		}
+		hasSealed, err := r.hasCopy(ctx, sid, storiface.FTSealed|storiface.FTUpdate)
+		if err != nil {
+			return err
+		}
+		if !hasSealed {
+			log.Warnw("can't regenerate unsealed copy without a sealed copy", "sector", si.SectorNumber)
+			continue
+		}
+
+		commD := si.CommD
+		if si.CCUpdate {
+			commD = si.UpdateUnsealed
+		}
+
+		lost[si.SectorNumber] = &job{
+			UnsealRegenJob: api.UnsealRegenJob{
+				Sector: si.SectorNumber,
+				Deals:  deals,
+			},
+			ref:    storiface.SectorRef{ID: sid, ProofType: si.SectorType},
+			ticket: si.TicketValue,
+			commD:  commD,
+		}
+	}
+
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	for s, j := range r.jobs {
+		if _, ok := lost[s]; !ok && j.State != api.UnsealRegenRunning {
+			delete(r.jobs, s)
+		}
+	}
+
+	for s, nj := range lost {
+		j, ok := r.jobs[s]
+		if !ok {
+			nj.Approved = !r.cfg.Approval
+			if nj.Approved {
+				nj.setState(api.UnsealRegenQueued)
+			} else {
+				nj.setState(api.UnsealRegenAwaitingApproval)
+			}
+			r.jobs[s] = nj
+
+			log.Infow("unsealed copy lost", "sector", s, "deals", nj.Deals, "state", nj.State)
+			continue
+		}
+
+		j.Deals = nj.Deals
+		if j.State == api.UnsealRegenFailed {
+			// retry the failed jobs once per check
+			j.setState(api.UnsealRegenQueued)
+		}
+	}
+
+	r.schedule()
+	return nil
+}
+
+// schedule starts the queued jobs, up to MaxConcurrent running jobs. Must be
+// called with r.lk held.
+func (r *Regenerator) schedule() {
+	var queued []*job
+	for _, j := range r.jobs {
+		if j.State == api.UnsealRegenQueued {
+			queued = append(queued, j)
+		}
+	}
+	sort.Slice(queued, func(i, j int) bool {
+		return queued[i].Sector < queued[j].Sector
+	})
+
+	for _, j := range queued {
+		if r.running >= r.cfg.MaxConcurrent {
+			return
+		}
+
+		r.running++
+		j.Attempts++
+		j.setState(api.UnsealRegenRunning)
+
+		r.wg.Add(1)
+		go r.run(j)
+	}
+}
+
+func (r *Regenerator) run(j *job) {
+	defer r.wg.Done()
+
+	log.Infow("regenerating unsealed copy", "sector", j.Sector, "attempt", j.Attempts)
+	err := r.unsealer.SectorsUnsealPiece(r.ctx, j.ref, 0, 0, j.ticket, j.commD)
+
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	r.running--
+	if err != nil {
+		log.Errorw("regenerating unsealed copy", "sector", j.Sector, "error", err)
+		j.LastError = err.Error()
+		j.setState(api.UnsealRegenFailed)
+	} else {
+		log.Infow("regenerated unsealed copy", "sector", j.Sector)
+		delete(r.jobs, j.Sector)
+	}
+
+	if r.ctx.Err() == nil {
+		r.schedule()
+	}
+}
+
+// hasCopy returns whether a file of the sector is stored in a healthy path.
+func (r *Regenerator) hasCopy(ctx context.Context, sid abi.SectorID, ft storiface.SectorFileType) (bool, error) {
+	locs, err := r.index.StorageFindSector(ctx, sid, ft, 0, false)
+	if err != nil {
+		return false, xerrors.Errorf("finding sector %d: %w", sid.Number, err)
+	}
+	for _, l := range locs {
+		if r.index.StorageHealthy(l.ID) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// keepUnsealedDeals returns the active deals of a proving sector whose pieces
+// should be kept unsealed.
+func keepUnsealedDeals(si sealing.SectorInfo, height abi.ChainEpoch, alwaysKeep bool) []abi.DealID {
+	if si.State != sealing.Proving && si.State != sealing.Available {
+		return nil
+	}
+
+	var deals []abi.DealID
+	for _, p := range si.Pieces {
+		if p.DealInfo == nil || !(p.DealInfo.KeepUnsealed || alwaysKeep) {
+			continue
+		}
+		if p.DealInfo.DealSchedule.EndEpoch <= height {
+			continue
+		}
+		deals = append(deals, p.DealInfo.DealID)
+	}
+	return deals
+}
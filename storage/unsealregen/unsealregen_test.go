@@ -0,0 +1,184 @@
+// stm: #unit
+package unsealregen
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+	sealing "github.com/filecoin-project/lotus/storage/pipeline"
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+type fakeChain struct{}
+
+func (fakeChain) ChainHead(context.Context) (*types.TipSet, error) {
+	return mock.TipSet(mock.MkBlock(nil, 0, 0)), nil
+}
+
+type fakeSectors []sealing.SectorInfo
+
+func (f fakeSectors) ListSectors() ([]sealing.SectorInfo, error) {
+	return f, nil
+}
+
+type fakeIndex struct {
+	files   map[abi.SectorNumber]map[storiface.SectorFileType]storiface.ID
+	healthy map[storiface.ID]bool
+}
+
+func (f *fakeIndex) StorageFindSector(_ context.Context, sector abi.SectorID, ft storiface.SectorFileType, _ abi.SectorSize, _ bool) ([]storiface.SectorStorageInfo, error) {
+	var out []storiface.SectorStorageInfo
+	for t, id := range f.files[sector.Number] {
+		if t&ft != 0 {
+			out = append(out, storiface.SectorStorageInfo{ID: id})
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeIndex) StorageHealthy(id storiface.ID) bool {
+	return f.healthy[id]
+}
+
+type fakeUnsealer struct {
+	lk       sync.Mutex
+	started  chan abi.SectorNumber
+	finish   chan error
+	unsealed []abi.SectorNumber
+}
+
+func (f *fakeUnsealer) SectorsUnsealPiece(_ context.Context, sector storiface.SectorRef, _ storiface.UnpaddedByteIndex, _ abi.UnpaddedPieceSize, _ abi.SealRandomness, _ *cid.Cid) error {
+	f.started <- sector.ID.Number
+	err := <-f.finish
+
+	f.lk.Lock()
+	defer f.lk.Unlock()
+	if err == nil {
+		f.unsealed = append(f.unsealed, sector.ID.Number)
+	}
+	return err
+}
+
+func dealSector(n abi.SectorNumber, keep bool, end abi.ChainEpoch) sealing.SectorInfo {
+	return sealing.SectorInfo{
+		State:        sealing.Proving,
+		SectorNumber: n,
+		Pieces: []api.SectorPiece{{
+			DealInfo: &api.PieceDealInfo{
+				DealID:       abi.DealID(100 + n),
+				DealSchedule: api.DealSchedule{EndEpoch: end},
+				KeepUnsealed: keep,
+			},
+		}},
+	}
+}
+
+func TestKeepUnsealedDeals(t *testing.T) {
+	require.Equal(t, []abi.DealID{101}, keepUnsealedDeals(dealSector(1, true, 1000), 500, false))
+	// the unsealed copy of the deal isn't needed
+	require.Empty(t, keepUnsealedDeals(dealSector(1, false, 1000), 500, false))
+	require.Equal(t, []abi.DealID{101}, keepUnsealedDeals(dealSector(1, false, 1000), 500, true))
+	// the deal expired
+	require.Empty(t, keepUnsealedDeals(dealSector(1, true, 1000), 1000, false))
+
+	sealingSector := dealSector(1, true, 1000)
+	sealingSector.State = sealing.PreCommit1
+	require.Empty(t, keepUnsealedDeals(sealingSector, 500, false))
+
+	cc := dealSector(1, true, 1000)
+	cc.Pieces[0].DealInfo = nil
+	require.Empty(t, keepUnsealedDeals(cc, 500, false))
+}
+
+func TestRegenerate(t *testing.T) {
+	ctx := context.Background()
+
+	sectors := fakeSectors{
+		// lost unsealed copies
+		dealSector(1, true, 1000),
+		dealSector(2, true, 1000),
+		// unsealed copy available
+		dealSector(3, true, 1000),
+		// sealed copy lost too
+		dealSector(4, true, 1000),
+		// no deal to keep unsealed
+		dealSector(5, false, 1000),
+	}
+	idx := &fakeIndex{
+		files: map[abi.SectorNumber]map[storiface.SectorFileType]storiface.ID{
+			1: {storiface.FTSealed: "good", storiface.FTUnsealed: "dead"},
+			2: {storiface.FTUpdate: "good"},
+			3: {storiface.FTSealed: "good", storiface.FTUnsealed: "good"},
+			4: {storiface.FTSealed: "dead"},
+			5: {storiface.FTSealed: "good"},
+		},
+		healthy: map[storiface.ID]bool{"good": true},
+	}
+	uns := &fakeUnsealer{started: make(chan abi.SectorNumber), finish: make(chan error)}
+
+	r := New(Config{Approval: true, MaxConcurrent: 1}, 1000, fakeChain{}, sectors, idx, uns)
+	defer r.Stop(ctx) //nolint:errcheck
+
+	states := func() map[abi.SectorNumber]api.UnsealRegenState {
+		out := map[abi.SectorNumber]api.UnsealRegenState{}
+		for _, j := range r.List() {
+			out[j.Sector] = j.State
+		}
+		return out
+	}
+
+	require.NoError(t, r.check(ctx))
+	require.Equal(t, map[abi.SectorNumber]api.UnsealRegenState{
+		1: api.UnsealRegenAwaitingApproval,
+		2: api.UnsealRegenAwaitingApproval,
+	}, states())
+
+	require.Error(t, r.Approve([]abi.SectorNumber{3}))
+	require.NoError(t, r.Reject([]abi.SectorNumber{2}))
+	require.NoError(t, r.Approve([]abi.SectorNumber{1}))
+	require.Equal(t, abi.SectorNumber(1), <-uns.started)
+
+	// rejected jobs stay rejected
+	require.NoError(t, r.check(ctx))
+	require.Equal(t, map[abi.SectorNumber]api.UnsealRegenState{
+		1: api.UnsealRegenRunning,
+		2: api.UnsealRegenRejected,
+	}, states())
+	require.Error(t, r.Reject([]abi.SectorNumber{1}))
+
+	// sector 2 waits for sector 1 to finish
+	require.NoError(t, r.Approve([]abi.SectorNumber{2}))
+	require.Equal(t, api.UnsealRegenQueued, states()[2])
+
+	uns.finish <- xerrors.New("unseal failed")
+	require.Equal(t, abi.SectorNumber(2), <-uns.started)
+	uns.finish <- nil
+	r.wg.Wait()
+
+	jobs := r.List()
+	require.Len(t, jobs, 1)
+	require.Equal(t, api.UnsealRegenFailed, jobs[0].State)
+	require.Equal(t, "unseal failed", jobs[0].LastError)
+	require.Equal(t, []abi.SectorNumber{2}, uns.unsealed)
+
+	// failed jobs are retried on the next check, the unsealed copy of sector 2
+	// was regenerated
+	idx.files[2][storiface.FTUnsealed] = "good"
+	require.NoError(t, r.check(ctx))
+	require.Equal(t, abi.SectorNumber(1), <-uns.started)
+	uns.finish <- nil
+	r.wg.Wait()
+
+	require.Empty(t, r.List())
+	require.Equal(t, []abi.SectorNumber{2, 1}, uns.unsealed)
+}
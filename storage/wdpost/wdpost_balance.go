@@ -0,0 +1,398 @@
+package wdpost
+
+import (
+	"container/heap"
+	"context"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/builtin/v9/miner"
+	"github.com/filecoin-project/go-state-types/dline"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+type DeadlineLoadAPI interface {
+	ChainHead(context.Context) (*types.TipSet, error)
+	StateMinerInfo(context.Context, address.Address, types.TipSetKey) (api.MinerInfo, error)
+	StateMinerProvingDeadline(context.Context, address.Address, types.TipSetKey) (*dline.Info, error)
+	StateMinerPartitions(context.Context, address.Address, uint64, types.TipSetKey) ([]api.Partition, error)
+}
+
+type partitionLoad struct {
+	index                       uint64
+	total, live, active, faulty uint64
+}
+
+type deadlineLoad struct {
+	api.DeadlineLoad
+	partitions []partitionLoad
+}
+
+// DeadlineLoads is the load of the deadlines of a miner at a tipset.
+type DeadlineLoads struct {
+	info          *dline.Info
+	partitionSize uint64
+	deadlines     []deadlineLoad
+}
+
+// LoadDeadlines reads the sectors of the partitions of all deadlines of the
+// miner at the chain head.
+func LoadDeadlines(ctx context.Context, a DeadlineLoadAPI, maddr address.Address) (*DeadlineLoads, error) {
+	ts, err := a.ChainHead(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("getting chain head: %w", err)
+	}
+
+	mi, err := a.StateMinerInfo(ctx, maddr, ts.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting miner info: %w", err)
+	}
+
+	di, err := a.StateMinerProvingDeadline(ctx, maddr, ts.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting proving deadline: %w", err)
+	}
+
+	out := &DeadlineLoads{
+		info:          di,
+		partitionSize: mi.WindowPoStPartitionSectors,
+	}
+
+	for idx := uint64(0); idx < di.WPoStPeriodDeadlines; idx++ {
+		parts, err := a.StateMinerPartitions(ctx, maddr, idx, ts.Key())
+		if err != nil {
+			return nil, xerrors.Errorf("getting partitions of deadline %d: %w", idx, err)
+		}
+
+		dl := deadlineLoad{
+			DeadlineLoad: api.DeadlineLoad{
+				Index:       idx,
+				Partitions:  len(parts),
+				Mutable:     deadlineMutable(di, idx),
+				Compactable: deadlineMutable(di, idx) && !deadlineDisputable(di, idx),
+			},
+		}
+
+		for pIdx, p := range parts {
+			pl, err := countPartition(uint64(pIdx), p)
+			if err != nil {
+				return nil, xerrors.Errorf("counting sectors of partition %d of deadline %d: %w", pIdx, idx, err)
+			}
+
+			dl.TotalSectors += pl.total
+			dl.LiveSectors += pl.live
+			dl.ActiveSectors += pl.active
+			dl.FaultySectors += pl.faulty
+			dl.partitions = append(dl.partitions, pl)
+		}
+
+		out.deadlines = append(out.deadlines, dl)
+	}
+
+	return out, nil
+}
+
+func countPartition(idx uint64, p api.Partition) (partitionLoad, error) {
+	pl := partitionLoad{index: idx}
+
+	var err error
+	if pl.total, err = p.AllSectors.Count(); err != nil {
+		return pl, err
+	}
+	if pl.live, err = p.LiveSectors.Count(); err != nil {
+		return pl, err
+	}
+	if pl.active, err = p.ActiveSectors.Count(); err != nil {
+		return pl, err
+	}
+	if pl.faulty, err = p.FaultySectors.Count(); err != nil {
+		return pl, err
+	}
+	return pl, nil
+}
+
+// deadlineOccurrence returns the next non-elapsed occurrence of the deadline.
+func deadlineOccurrence(di *dline.Info, idx uint64) *dline.Info {
+	return dline.NewInfo(di.PeriodStart, idx, di.CurrentEpoch, di.WPoStPeriodDeadlines, di.WPoStProvingPeriod, di.WPoStChallengeWindow, di.WPoStChallengeLookback, di.FaultDeclarationCutoff).NextNotElapsed()
+}
+
+// deadlineMutable mirrors the miner actor: sectors can only be added to, and
+// partitions compacted in, deadlines which don't open within a challenge
+// window.
+func deadlineMutable(di *dline.Info, idx uint64) bool {
+	return di.CurrentEpoch < deadlineOccurrence(di, idx).Open-di.WPoStChallengeWindow
+}
+
+// deadlineDisputable mirrors the miner actor: the proofs of a deadline can be
+// disputed, and so its partitions can't be compacted, for a dispute window
+// after it closes.
+func deadlineDisputable(di *dline.Info, idx uint64) bool {
+	if di.PeriodStart > di.CurrentEpoch {
+		return false
+	}
+	next := deadlineOccurrence(di, idx)
+	return !next.IsOpen() && di.CurrentEpoch < (next.Close-di.WPoStProvingPeriod)+miner.WPoStDisputeWindow
+}
+
+func partitionsFor(sectors, partitionSize uint64) int {
+	return int((sectors + partitionSize - 1) / partitionSize)
+}
+
+// imbalance returns the spread of live sectors across the deadlines relative to
+// the mean.
+func imbalance(live []uint64) (lo, hi uint64, mean, spread float64) {
+	if len(live) == 0 {
+		return 0, 0, 0, 0
+	}
+
+	var sum uint64
+	lo = live[0]
+	for _, l := range live {
+		sum += l
+		if l < lo {
+			lo = l
+		}
+		if l > hi {
+			hi = l
+		}
+	}
+
+	mean = float64(sum) / float64(len(live))
+	if mean == 0 {
+		return lo, hi, mean, 0
+	}
+	return lo, hi, mean, float64(hi-lo) / mean
+}
+
+func (l *DeadlineLoads) Report() *api.DeadlineLoadReport {
+	r := &api.DeadlineLoadReport{
+		Height:           l.info.CurrentEpoch,
+		CurrentDeadline:  l.info.Index,
+		PartitionSectors: l.partitionSize,
+	}
+
+	live := make([]uint64, 0, len(l.deadlines))
+	for _, dl := range l.deadlines {
+		r.Deadlines = append(r.Deadlines, dl.DeadlineLoad)
+		live = append(live, dl.LiveSectors)
+		if dl.Partitions > r.MaxPartitions {
+			r.MaxPartitions = dl.Partitions
+		}
+	}
+	r.MinLiveSectors, r.MaxLiveSectors, r.MeanLiveSectors, r.Imbalance = imbalance(live)
+
+	return r
+}
+
+// planCompaction selects the partitions of the deadline holding terminated
+// sectors. Partitions with faulty or unproven sectors can't be compacted.
+func planCompaction(dl deadlineLoad, partitionSize uint64) (api.DeadlineCompaction, bool) {
+	c := api.DeadlineCompaction{
+		Deadline:    dl.Index,
+		Compactable: dl.Compactable,
+	}
+
+	var live uint64
+	for _, p := range dl.partitions {
+		if p.live == p.total || p.live != p.active {
+			continue
+		}
+		c.Partitions = append(c.Partitions, p.index)
+		live += p.live
+	}
+
+	c.SavedPartitions = len(c.Partitions) - partitionsFor(live, partitionSize)
+	return c, c.SavedPartitions > 0
+}
+
+// Plan projects the deadlines the miner actor would assign newSectors new
+// sectors to at the current epoch, and the compactions which would remove the
+// partitions wasted on terminated sectors.
+func (l *DeadlineLoads) Plan(newSectors uint64) *api.DeadlineRebalancePlan {
+	plan := &api.DeadlineRebalancePlan{
+		NewSectors: newSectors,
+	}
+
+	partitions := make([]int, len(l.deadlines))
+	live := make([]uint64, len(l.deadlines))
+	for i, dl := range l.deadlines {
+		partitions[i] = dl.Partitions
+		live[i] = dl.LiveSectors
+		if dl.Partitions > plan.MaxPartitionsBefore {
+			plan.MaxPartitionsBefore = dl.Partitions
+		}
+	}
+	_, _, _, plan.ImbalanceBefore = imbalance(live)
+
+	for i, dl := range l.deadlines {
+		c, ok := planCompaction(dl, l.partitionSize)
+		if !ok {
+			continue
+		}
+		plan.Compactions = append(plan.Compactions, c)
+		partitions[i] -= c.SavedPartitions
+	}
+
+	assigned := assignDeadlines(l.deadlines, l.partitionSize, newSectors)
+	for i, n := range assigned {
+		if n == 0 {
+			continue
+		}
+		plan.Assignments = append(plan.Assignments, api.DeadlineAssignment{
+			Deadline: l.deadlines[i].Index,
+			Sectors:  n,
+		})
+
+		total := l.deadlines[i].TotalSectors
+		live[i] += n
+		partitions[i] += partitionsFor(total+n, l.partitionSize) - partitionsFor(total, l.partitionSize)
+	}
+
+	for _, p := range partitions {
+		if p > plan.MaxPartitionsAfter {
+			plan.MaxPartitionsAfter = p
+		}
+	}
+	_, _, _, plan.ImbalanceAfter = imbalance(live)
+
+	return plan
+}
+
+// Compactions returns the compactions of the rebalance plan which can be sent
+// now.
+func (l *DeadlineLoads) Compactions() []api.DeadlineCompaction {
+	var out []api.DeadlineCompaction
+	for _, c := range l.Plan(0).Compactions {
+		if c.Compactable {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// assignDeadlines returns the number of the new sectors the miner actor would
+// assign to each deadline, following the ordering of its deadline assignment
+// heap. Only the mutable deadlines take new sectors.
+func assignDeadlines(deadlines []deadlineLoad, partitionSize uint64, newSectors uint64) []uint64 {
+	out := make([]uint64, len(deadlines))
+
+	h := &assignmentHeap{
+		maxPartitions: miner.MaxPartitionsPerDeadline,
+		partitionSize: partitionSize,
+	}
+	for i, dl := range deadlines {
+		if dl.Mutable {
+			h.deadlines = append(h.deadlines, &assignmentInfo{
+				index:        i,
+				liveSectors:  dl.LiveSectors,
+				totalSectors: dl.TotalSectors,
+			})
+		}
+	}
+	if len(h.deadlines) == 0 {
+		return out
+	}
+	heap.Init(h)
+
+	for n := uint64(0); n < newSectors; n++ {
+		info := h.deadlines[0]
+		if info.maxPartitionsReached(h.partitionSize, h.maxPartitions) {
+			break
+		}
+
+		out[info.index]++
+		info.liveSectors++
+		info.totalSectors++
+		heap.Fix(h, 0)
+	}
+
+	return out
+}
+
+type assignmentInfo struct {
+	index        int
+	liveSectors  uint64
+	totalSectors uint64
+}
+
+func (ai *assignmentInfo) partitionsAfterAssignment(partitionSize uint64) uint64 {
+	return uint64(partitionsFor(ai.totalSectors+1, partitionSize))
+}
+
+func (ai *assignmentInfo) compactPartitionsAfterAssignment(partitionSize uint64) uint64 {
+	return uint64(partitionsFor(ai.liveSectors+1, partitionSize))
+}
+
+func (ai *assignmentInfo) isFullNow(partitionSize uint64) bool {
+	return ai.totalSectors%partitionSize == 0
+}
+
+func (ai *assignmentInfo) maxPartitionsReached(partitionSize, maxPartitions uint64) bool {
+	return ai.totalSectors >= partitionSize*maxPartitions
+}
+
+type assignmentHeap struct {
+	maxPartitions uint64
+	partitionSize uint64
+	deadlines     []*assignmentInfo
+}
+
+func (h *assignmentHeap) Len() int {
+	return len(h.deadlines)
+}
+
+func (h *assignmentHeap) Swap(i, j int) {
+	h.deadlines[i], h.deadlines[j] = h.deadlines[j], h.deadlines[i]
+}
+
+// Less orders the deadlines the way the miner actor does when assigning new
+// sectors: fewest partitions after compaction, then before compaction, then
+// deadlines with a partially filled partition (the most filled first), then
+// fewest live sectors, then lowest index.
+func (h *assignmentHeap) Less(i, j int) bool {
+	a, b := h.deadlines[i], h.deadlines[j]
+
+	aMax := a.maxPartitionsReached(h.partitionSize, h.maxPartitions)
+	bMax := b.maxPartitionsReached(h.partitionSize, h.maxPartitions)
+	if aMax != bMax {
+		return !aMax
+	}
+
+	aCompact, bCompact := a.compactPartitionsAfterAssignment(h.partitionSize), b.compactPartitionsAfterAssignment(h.partitionSize)
+	if aCompact != bCompact {
+		return aCompact < bCompact
+	}
+
+	aParts, bParts := a.partitionsAfterAssignment(h.partitionSize), b.partitionsAfterAssignment(h.partitionSize)
+	if aParts != bParts {
+		return aParts < bParts
+	}
+
+	aFull, bFull := a.isFullNow(h.partitionSize), b.isFullNow(h.partitionSize)
+	if aFull != bFull {
+		return !aFull
+	}
+
+	if !aFull && !bFull && a.totalSectors != b.totalSectors {
+		return a.totalSectors > b.totalSectors
+	}
+
+	if a.liveSectors != b.liveSectors {
+		return a.liveSectors < b.liveSectors
+	}
+
+	return a.index < b.index
+}
+
+func (h *assignmentHeap) Push(x interface{}) {
+	h.deadlines = append(h.deadlines, x.(*assignmentInfo))
+}
+
+func (h *assignmentHeap) Pop() interface{} {
+	last := h.deadlines[len(h.deadlines)-1]
+	h.deadlines = h.deadlines[:len(h.deadlines)-1]
+	return last
+}
@@ -0,0 +1,126 @@
+// stm: #unit
+package wdpost
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/builtin/v9/miner"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+func testDeadline(idx uint64, mutable bool, parts ...partitionLoad) deadlineLoad {
+	dl := deadlineLoad{
+		DeadlineLoad: api.DeadlineLoad{
+			Index:       idx,
+			Partitions:  len(parts),
+			Mutable:     mutable,
+			Compactable: mutable,
+		},
+	}
+	for i, p := range parts {
+		p.index = uint64(i)
+		dl.TotalSectors += p.total
+		dl.LiveSectors += p.live
+		dl.ActiveSectors += p.active
+		dl.partitions = append(dl.partitions, p)
+	}
+	return dl
+}
+
+func full(n uint64) partitionLoad {
+	return partitionLoad{total: n, live: n, active: n}
+}
+
+func TestDeadlineMutable(t *testing.T) {
+	di := miner.NewDeadlineInfo(0, 10, miner.WPoStChallengeWindow*10+5)
+	require.Equal(t, uint64(10), di.Index)
+
+	// the current and the next deadline can't be changed
+	require.False(t, deadlineMutable(di, 10))
+	require.False(t, deadlineMutable(di, 11))
+	require.True(t, deadlineMutable(di, 12))
+	require.True(t, deadlineMutable(di, 9))
+
+	// the deadline which closed last is in its dispute window
+	require.True(t, deadlineDisputable(di, 9))
+	require.False(t, deadlineDisputable(di, 12))
+}
+
+func TestAssignDeadlines(t *testing.T) {
+	const partitionSize = 4
+
+	deadlines := []deadlineLoad{
+		testDeadline(0, true, full(4), full(4)),
+		testDeadline(1, true, full(4), full(2)),
+		testDeadline(2, true),
+		// deadlines which can't take sectors now
+		testDeadline(3, false),
+	}
+
+	// the deadline with the fewest partitions after assignment comes first
+	require.Equal(t, []uint64{0, 0, 2, 0}, assignDeadlines(deadlines, partitionSize, 2))
+	// on a tie, partial partitions are filled before new ones are opened
+	require.Equal(t, []uint64{0, 2, 8, 0}, assignDeadlines(deadlines, partitionSize, 10))
+	require.Equal(t, []uint64{4, 6, 12, 0}, assignDeadlines(deadlines, partitionSize, 22))
+}
+
+func TestPlan(t *testing.T) {
+	const partitionSize = 4
+
+	loads := &DeadlineLoads{
+		info:          miner.NewDeadlineInfo(0, 0, 0),
+		partitionSize: partitionSize,
+		deadlines: []deadlineLoad{
+			// two partitions with terminated sectors compact into one
+			testDeadline(0, true, full(4), partitionLoad{total: 4, live: 2, active: 2}, partitionLoad{total: 4, live: 1, active: 1}),
+			// the faulty partition can't be compacted
+			testDeadline(1, true, partitionLoad{total: 4, live: 2, active: 1, faulty: 1}, partitionLoad{total: 4, live: 1, active: 1}),
+			testDeadline(2, false),
+		},
+	}
+
+	report := loads.Report()
+	require.Equal(t, uint64(7), report.MaxLiveSectors)
+	require.Equal(t, uint64(0), report.MinLiveSectors)
+	require.Equal(t, 3, report.MaxPartitions)
+	require.InDelta(t, 7/(10.0/3), report.Imbalance, 0.001)
+
+	plan := loads.Plan(0)
+	require.Equal(t, []api.DeadlineCompaction{{
+		Deadline:        0,
+		Partitions:      []uint64{1, 2},
+		SavedPartitions: 1,
+		Compactable:     true,
+	}}, plan.Compactions)
+	require.Empty(t, plan.Assignments)
+	require.Equal(t, 3, plan.MaxPartitionsBefore)
+	require.Equal(t, 2, plan.MaxPartitionsAfter)
+	require.Equal(t, plan.ImbalanceBefore, plan.ImbalanceAfter)
+
+	plan = loads.Plan(1)
+	require.Equal(t, []api.DeadlineAssignment{{Deadline: 1, Sectors: 1}}, plan.Assignments)
+	require.Less(t, plan.ImbalanceAfter, plan.ImbalanceBefore)
+
+	loads.deadlines[0].Compactable = false
+	require.Empty(t, loads.Compactions())
+}
+
+func TestImbalance(t *testing.T) {
+	lo, hi, mean, spread := imbalance([]uint64{10, 10, 10})
+	require.Equal(t, uint64(10), lo)
+	require.Equal(t, uint64(10), hi)
+	require.Equal(t, 10.0, mean)
+	require.Zero(t, spread)
+
+	_, _, _, spread = imbalance([]uint64{0, 0})
+	require.Zero(t, spread)
+
+	_, _, _, spread = imbalance([]uint64{5, 15})
+	require.Equal(t, 1.0, spread)
+
+	require.Equal(t, 2, partitionsFor(5, 4))
+	require.Equal(t, 1, partitionsFor(4, 4))
+}
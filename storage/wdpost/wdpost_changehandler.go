@@ -27,6 +27,7 @@ type wdPoStCommands interface {
 
 	startGeneratePoST(ctx context.Context, ts *types.TipSet, deadline *dline.Info, onComplete CompleteGeneratePoSTCb) context.CancelFunc
 	startSubmitPoST(ctx context.Context, ts *types.TipSet, deadline *dline.Info, posts []miner.SubmitWindowedPoStParams, onComplete CompleteSubmitPoSTCb) context.CancelFunc
+	deferSubmitPoST(ts *types.TipSet, deadline *dline.Info) bool
 	onAbort(ts *types.TipSet, deadline *dline.Info)
 	recordPoStFailure(err error, ts *types.TipSet, deadline *dline.Info)
 }
@@ -479,6 +480,12 @@ func (s *submitHandler) submitIfReady(ctx context.Context, advance *types.TipSet
 		return
 	}
 
+	// Wait for the base fee to fall below the cap, checked again on the next
+	// head change
+	if s.api.deferSubmitPoST(advance, pw.di) {
+		return
+	}
+
 	// Start submitting post
 	pw.submitState = SubmitStateSubmitting
 	pw.abort = s.api.startSubmitPoST(ctx, advance, pw.di, posts, func(err error) {
@@ -179,6 +179,10 @@ func (m *mockAPI) startSubmitPoST(
 	return cancel
 }
 
+func (m *mockAPI) deferSubmitPoST(ts *types.TipSet, deadline *dline.Info) bool {
+	return false
+}
+
 func (m *mockAPI) onAbort(ts *types.TipSet, deadline *dline.Info) {
 	m.abortCalledLock.Lock()
 	defer m.abortCalledLock.Unlock()
@@ -0,0 +1,81 @@
+package wdpost
+
+import (
+	"sort"
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/dline"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// BaseFeeCapSubmitSlack is how many epochs before the deadline closes WindowPoSt
+// messages deferred because of the base fee are sent regardless of it.
+const BaseFeeCapSubmitSlack = abi.ChainEpoch(20)
+
+// deferSubmitPoST returns whether the submission of the proofs of the deadline
+// should be deferred, because the base fee is above WindowPoStBaseFeeCap.
+func (s *WindowPoStScheduler) deferSubmitPoST(ts *types.TipSet, deadline *dline.Info) bool {
+	s.deferredLk.Lock()
+	defer s.deferredLk.Unlock()
+
+	if s.deferred == nil {
+		s.deferred = map[abi.ChainEpoch]api.PendingSubmission{}
+	}
+	for open, ps := range s.deferred {
+		if ps.ForceEpoch+BaseFeeCapSubmitSlack <= ts.Height() {
+			// the deadline closed
+			delete(s.deferred, open)
+		}
+	}
+
+	feeCap := s.feeCfg.WindowPoStBaseFeeCap
+	baseFee := ts.MinTicketBlock().ParentBaseFee
+	if feeCap.Int == nil || big.Int(feeCap).Equals(big.Zero()) || baseFee.LessThanEqual(big.Int(feeCap)) {
+		delete(s.deferred, deadline.Open)
+		return false
+	}
+
+	forceEpoch := deadline.Close - BaseFeeCapSubmitSlack
+	if ts.Height() >= forceEpoch {
+		log.Warnw("submitting window post with the base fee above the cap to meet the deadline", "deadline", deadline.Index, "baseFee", types.FIL(baseFee), "cap", feeCap)
+		delete(s.deferred, deadline.Open)
+		return false
+	}
+
+	ps, ok := s.deferred[deadline.Open]
+	if !ok {
+		log.Infow("deferring window post until the base fee falls below the cap", "deadline", deadline.Index, "baseFee", types.FIL(baseFee), "cap", feeCap, "forceEpoch", forceEpoch)
+		ps = api.PendingSubmission{
+			Class:         api.SubmissionWindowPoSt,
+			Deadline:      deadline.Index,
+			Deferred:      true,
+			DeferredSince: time.Now(),
+			FeeCap:        big.Int(feeCap),
+			ForceEpoch:    forceEpoch,
+		}
+	}
+	ps.BaseFee = baseFee
+	s.deferred[deadline.Open] = ps
+
+	return true
+}
+
+// PendingSubmissions returns the WindowPoSt messages deferred because of the
+// base fee.
+func (s *WindowPoStScheduler) PendingSubmissions() []api.PendingSubmission {
+	s.deferredLk.Lock()
+	defer s.deferredLk.Unlock()
+
+	out := make([]api.PendingSubmission, 0, len(s.deferred))
+	for _, ps := range s.deferred {
+		out = append(out, ps)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].ForceEpoch < out[j].ForceEpoch
+	})
+	return out
+}
@@ -0,0 +1,55 @@
+// stm: #unit
+package wdpost
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+	"github.com/filecoin-project/lotus/node/config"
+)
+
+func TestDeferSubmitPoST(t *testing.T) {
+	tsAt := func(h abi.ChainEpoch, baseFee int64) *types.TipSet {
+		blk := mock.MkBlock(nil, 0, 0)
+		blk.Height = h
+		blk.ParentBaseFee = big.NewInt(baseFee)
+		return mock.TipSet(blk)
+	}
+
+	s := &WindowPoStScheduler{feeCfg: config.MinerFeeConfig{WindowPoStBaseFeeCap: types.FIL(big.NewInt(100))}}
+	di := NewDeadlineInfo(0, 1, 65)
+	require.Equal(t, abi.ChainEpoch(60), di.Open)
+	require.Equal(t, abi.ChainEpoch(120), di.Close)
+
+	require.False(t, s.deferSubmitPoST(tsAt(65, 100), di))
+	require.Empty(t, s.PendingSubmissions())
+
+	require.True(t, s.deferSubmitPoST(tsAt(66, 1000), di))
+	pending := s.PendingSubmissions()
+	require.Len(t, pending, 1)
+	require.Equal(t, api.SubmissionWindowPoSt, pending[0].Class)
+	require.Equal(t, uint64(1), pending[0].Deadline)
+	require.Equal(t, abi.ChainEpoch(100), pending[0].ForceEpoch)
+	require.Equal(t, big.NewInt(1000), pending[0].BaseFee)
+
+	// the deadline forces the submission
+	require.True(t, s.deferSubmitPoST(tsAt(99, 1000), di))
+	require.False(t, s.deferSubmitPoST(tsAt(100, 1000), di))
+	require.Empty(t, s.PendingSubmissions())
+
+	// the base fee falls
+	require.True(t, s.deferSubmitPoST(tsAt(70, 1000), di))
+	require.False(t, s.deferSubmitPoST(tsAt(71, 50), di))
+	require.Empty(t, s.PendingSubmissions())
+
+	// without a cap
+	s.feeCfg.WindowPoStBaseFeeCap = types.FIL(big.Zero())
+	require.False(t, s.deferSubmitPoST(tsAt(66, 1000), di))
+}
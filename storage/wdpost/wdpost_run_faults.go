@@ -454,3 +454,35 @@ func (s *WindowPoStScheduler) manualRecoveryMsg(ctx context.Context, Recovery []
 
 	return sm.Cid(), nil
 }
+
+// DeclareFaults sends a DeclareFaults message for the given declarations and
+// returns its CID without waiting for it to land on chain. The declarations
+// must target deadlines whose fault declaration cutoff hasn't passed yet.
+func (s *WindowPoStScheduler) DeclareFaults(ctx context.Context, faults []miner.FaultDeclaration) (cid.Cid, error) {
+	if len(faults) == 0 {
+		return cid.Undef, xerrors.Errorf("no faults to declare")
+	}
+
+	enc, aerr := actors.SerializeParams(&miner.DeclareFaultsParams{Faults: faults})
+	if aerr != nil {
+		return cid.Undef, xerrors.Errorf("could not serialize declare faults parameters: %w", aerr)
+	}
+
+	msg := &types.Message{
+		To:     s.actor,
+		Method: builtin.MethodsMiner.DeclareFaults,
+		Params: enc,
+		Value:  types.NewInt(0),
+	}
+	spec := &api.MessageSendSpec{MaxFee: abi.TokenAmount(s.feeCfg.MaxWindowPoStGasFee)}
+	if err := s.prepareMessage(ctx, msg, spec); err != nil {
+		return cid.Undef, err
+	}
+	sm, err := s.api.MpoolPushMessage(ctx, msg, spec)
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("pushing message to mpool: %w", err)
+	}
+
+	log.Warnw("declared faults", "message", sm.Cid(), "deadlines", len(faults))
+	return sm.Cid(), nil
+}
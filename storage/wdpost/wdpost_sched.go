@@ -2,6 +2,7 @@ package wdpost
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/ipfs/go-cid"
@@ -83,6 +84,11 @@ type WindowPoStScheduler struct {
 	evtTypes [4]journal.EventType
 	journal  journal.Journal
 
+	// WindowPoSt submissions deferred because of the base fee, by deadline open
+	// epoch
+	deferredLk sync.Mutex
+	deferred   map[abi.ChainEpoch]api.PendingSubmission
+
 	// failed abi.ChainEpoch // eps
 	// failLk sync.Mutex
 }